@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/abuse"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetAbuseReports 获取滥用举报列表
+// @Summary 获取滥用举报列表
+// @Description 按实例、用户、状态、处置状态和类别筛选滥用举报
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param instanceId query int false "实例ID"
+// @Param userId query int false "用户ID"
+// @Param status query string false "状态：open, resolved"
+// @Param enforcement query string false "处置状态：none, warned, limited, suspended"
+// @Param category query string false "类别"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/abuse-reports [get]
+func GetAbuseReports(c *gin.Context) {
+	var req admin.AbuseReportListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	reports, total, err := abuse.List(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, reports, total, req.Page, req.PageSize)
+}
+
+// CreateAbuseReport 登记滥用举报
+// @Summary 登记滥用举报
+// @Description 把举报登记到实例或用户下，针对实例时被举报用户为实例所有者。可填写来源、证据链接和外部工单编号
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.CreateAbuseReportRequest true "举报信息"
+// @Success 200 {object} common.Response{data=admin.AbuseReport} "登记成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/abuse-reports [post]
+func CreateAbuseReport(c *gin.Context) {
+	var req admin.CreateAbuseReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var operatorID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		operatorID = authCtx.UserID
+	}
+	report, err := abuse.Create(operatorID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, report, "登记成功")
+}
+
+// GetAbuseReport 获取滥用举报详情
+// @Summary 获取滥用举报详情
+// @Description 返回举报信息、被举报的用户和实例名称以及全部处置记录
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "举报ID"
+// @Success 200 {object} common.Response{data=admin.AbuseReportDetail} "获取成功"
+// @Failure 404 {object} common.Response "举报不存在"
+// @Router /admin/abuse-reports/{id} [get]
+func GetAbuseReport(c *gin.Context) {
+	id, ok := parseAbuseReportID(c)
+	if !ok {
+		return
+	}
+	detail, err := abuse.Get(id)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail)
+}
+
+// UpdateAbuseReport 更新滥用举报
+// @Summary 更新滥用举报
+// @Description 更新举报状态、证据链接、说明和关联工单，结案不会解除已有处置
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "举报ID"
+// @Param request body admin.UpdateAbuseReportRequest true "更新内容"
+// @Success 200 {object} common.Response{data=admin.AbuseReport} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/abuse-reports/{id} [put]
+func UpdateAbuseReport(c *gin.Context) {
+	id, ok := parseAbuseReportID(c)
+	if !ok {
+		return
+	}
+	var req admin.UpdateAbuseReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	report, err := abuse.Update(id, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, report, "更新成功")
+}
+
+// SetAbuseEnforcement 设置滥用举报处置状态
+// @Summary 设置滥用举报处置状态
+// @Description 设置为 warned 时邮件警告用户；limited 禁止用户申请新实例；suspended 停止并冻结相关实例。降级或解除时只解冻由该举报冻结的实例
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "举报ID"
+// @Param request body admin.SetAbuseEnforcementRequest true "处置状态"
+// @Success 200 {object} common.Response{data=abuse.EnforcementResult} "处置成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/abuse-reports/{id}/enforcement [put]
+func SetAbuseEnforcement(c *gin.Context) {
+	id, ok := parseAbuseReportID(c)
+	if !ok {
+		return
+	}
+	var req admin.SetAbuseEnforcementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var operatorID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		operatorID = authCtx.UserID
+	}
+	result, err := abuse.SetEnforcement(id, operatorID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	stopSuspendedInstances(id, result.Frozen)
+	common.ResponseSuccess(c, result, "处置成功")
+}
+
+func parseAbuseReportID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的举报ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// stopSuspendedInstances 停止因举报冻结的运行中实例，失败只记录日志，实例保持冻结
+func stopSuspendedInstances(reportID uint, instanceIDs []uint) {
+	if len(instanceIDs) == 0 {
+		return
+	}
+	var running []uint
+	global.APP_DB.Model(&providerModel.Instance{}).
+		Where("id IN ? AND status = ?", instanceIDs, constant.InstanceStatusRunning).
+		Pluck("id", &running)
+
+	instanceService := instance.NewService(task.GetTaskService())
+	for _, instanceID := range running {
+		if err := instanceService.InstanceAction(instanceID, admin.InstanceActionRequest{Action: "stop"}); err != nil {
+			global.APP_LOG.Warn("停止被举报实例失败",
+				zap.Uint("reportId", reportID),
+				zap.Uint("instanceId", instanceID),
+				zap.Error(err))
+		}
+	}
+}
@@ -1,14 +1,20 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 	"oneclickvirt/service/provider"
 	"strconv"
 
 	"oneclickvirt/global"
+	"oneclickvirt/middleware"
 	"oneclickvirt/model/admin"
+	authModel "oneclickvirt/model/auth"
 	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/counters"
+	"oneclickvirt/service/instancename"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/task"
 	"oneclickvirt/utils"
@@ -39,6 +45,26 @@ func GetAdminDashboard(c *gin.Context) {
 	common.ResponseSuccess(c, dashboard)
 }
 
+// GetDashboardCounters 获取仪表板计数器
+// @Summary 获取仪表板计数器
+// @Description 返回按Provider和状态分组的实例数、按Provider分组的端口映射数和按等级分组的用户数，数据来自写入时失效的计数缓存
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=dashboard.Counters} "获取成功"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/dashboard/counters [get]
+func GetDashboardCounters(c *gin.Context) {
+	snapshot, err := counters.Snapshot()
+	if err != nil {
+		global.APP_LOG.Error("获取仪表板计数器失败", zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取仪表板计数器失败"))
+		return
+	}
+	common.ResponseSuccess(c, snapshot)
+}
+
 // GetInstanceList 获取实例列表
 // @Summary 获取实例列表
 // @Description 管理员获取系统中所有实例的列表，支持分页和过滤
@@ -48,6 +74,9 @@ func GetAdminDashboard(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param name query string false "实例名称"
 // @Param status query string false "实例状态"
 // @Param providerName query string false "节点名称"
@@ -69,22 +98,37 @@ func GetInstanceList(c *gin.Context) {
 		})
 		return
 	}
+	req.ProviderIDs = middleware.GetProviderScope(c)
 
 	instanceService := instance.NewService(task.GetTaskService())
 	instances, total, err := instanceService.GetInstanceList(req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, common.Response{
 			Code: 500,
 			Msg:  "获取实例列表失败",
 		})
 		return
 	}
+	// 审计员只读查看，不返回实例登录密码
+	if authCtx, exists := middleware.GetAuthContext(c); exists && authCtx.UserType == authModel.UserTypeAuditor {
+		for i := range instances {
+			instances[i].Password = ""
+		}
+	}
 	c.JSON(http.StatusOK, common.Response{
 		Code: 200,
 		Msg:  "获取成功",
 		Data: map[string]interface{}{
-			"list":  instances,
-			"total": total,
+			"list":          instances,
+			"total":         total,
+			"nextPageToken": common.NextPageToken(req.Page, req.PageSize, total),
 		},
 	})
 }
@@ -112,6 +156,10 @@ func CreateInstance(c *gin.Context) {
 		return
 	}
 
+	if rejectOutOfScope(c, &providerModel.Provider{}, "id", "name = ?", req.Provider) {
+		return
+	}
+
 	global.APP_LOG.Info("管理员开始创建实例",
 		zap.String("instance_name", utils.TruncateString(req.Name, 50)),
 		zap.String("provider", req.Provider),
@@ -143,6 +191,44 @@ func CreateInstance(c *gin.Context) {
 	})
 }
 
+// CheckInstanceName 检查实例名称是否可用
+// @Summary 检查实例名称是否可用
+// @Description 检查节点上是否已有同名实例，或同名实例删除后仍在重用冷却期内（task.name-reuse-cooldown）
+// @Tags 管理员管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param name query string true "实例名称"
+// @Success 200 {object} common.Response{data=instancename.Conflict} "检查完成"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/providers/{id}/instance-names/check [get]
+func CheckInstanceName(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	name := c.Query("name")
+	if err != nil || name == "" {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误",
+		})
+		return
+	}
+
+	conflict, err := instancename.Check(global.APP_DB, uint(providerID), name, instancename.ReserveOptions{})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "检查完成",
+		Data: conflict,
+	})
+}
+
 func UpdateInstance(c *gin.Context) {
 	var req admin.UpdateInstanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,131 @@
+package admin
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/provider"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 控制台日志默认返回的大小（KB）
+const defaultConsoleLogKB = 64
+
+// CaptureConsoleLogRequest 抓取控制台日志请求
+type CaptureConsoleLogRequest struct {
+	Minutes int `json:"minutes"` // 抓取时长（分钟），默认10分钟，最大60分钟
+}
+
+// GetInstanceConsoleLog 管理员获取虚拟机控制台日志
+// @Summary 获取虚拟机控制台日志
+// @Description 读取虚拟机串口控制台日志的最后N KB，用于排查启动失败、网络未就绪等问题（仅Proxmox虚拟机）
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param kb query int false "返回最后多少KB，默认64，最大512"
+// @Success 200 {object} common.Response "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "读取失败"
+// @Router /admin/instances/{id}/console-log [get]
+func GetInstanceConsoleLog(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return
+	}
+
+	kb, _ := strconv.Atoi(c.DefaultQuery("kb", strconv.Itoa(defaultConsoleLogKB)))
+	if kb <= 0 {
+		kb = defaultConsoleLogKB
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	providerApiService := &provider.ProviderApiService{}
+	content, err := providerApiService.GetInstanceConsoleLog(ctx, inst, kb*1024)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, gin.H{
+		"instanceId": inst.ID,
+		"name":       inst.Name,
+		"content":    content,
+		"size":       len(content),
+	})
+}
+
+// CaptureInstanceConsoleLog 管理员重新抓取虚拟机控制台日志
+// @Summary 抓取虚拟机控制台日志
+// @Description 在宿主机上重新开始抓取虚拟机串口输出，抓取在指定时长后自动结束（仅Proxmox虚拟机）
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body CaptureConsoleLogRequest false "抓取参数"
+// @Success 200 {object} common.Response "已开始抓取"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Failure 500 {object} common.Response "抓取失败"
+// @Router /admin/instances/{id}/console-log/capture [post]
+func CaptureInstanceConsoleLog(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	var req CaptureConsoleLogRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+			return
+		}
+	}
+	if req.Minutes <= 0 {
+		req.Minutes = 10
+	}
+	if req.Minutes > 60 {
+		req.Minutes = 60
+	}
+
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	providerApiService := &provider.ProviderApiService{}
+	if err := providerApiService.CaptureInstanceConsoleLog(ctx, inst, time.Duration(req.Minutes)*time.Minute); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	global.APP_LOG.Info("管理员开始抓取实例控制台日志",
+		zap.Uint64("instanceID", instanceID),
+		zap.Int("minutes", req.Minutes))
+	common.ResponseSuccess(c, nil, "已开始抓取控制台日志")
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/consolerecord"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetConsoleRecordings 获取管理员终端会话录像列表
+// @Summary 获取管理员终端会话录像列表
+// @Description 按实例、管理员和状态筛选管理员网页终端会话的录像记录
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param instanceId query int false "实例ID"
+// @Param adminId query int false "管理员ID"
+// @Param status query string false "状态：recording, completed, failed, expired"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/console-recordings [get]
+func GetConsoleRecordings(c *gin.Context) {
+	var req admin.ConsoleRecordingListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	recordings, total, err := consolerecord.List(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, recordings, total, req.Page, req.PageSize)
+}
+
+// DownloadConsoleRecording 下载管理员终端会话录像
+// @Summary 下载管理员终端会话录像
+// @Description 下载 asciicast v2 格式的录像文件，可用 asciinema play 回放，下载操作写入审计日志
+// @Tags 管理员管理
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path int true "录像ID"
+// @Success 200 {file} file "录像文件"
+// @Failure 404 {object} common.Response "录像不存在或已删除"
+// @Router /admin/console-recordings/{id}/download [get]
+func DownloadConsoleRecording(c *gin.Context) {
+	startTime := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的录像ID"))
+		return
+	}
+
+	recording, reader, err := consolerecord.Open(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, consolerecord.ErrRecordingNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		global.APP_LOG.Error("读取终端会话录像失败", zap.Uint64("recordingId", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "读取录像失败"))
+		return
+	}
+	defer reader.Close()
+
+	recordAuditLog(c, startTime, http.StatusOK, gin.H{"recordingId": recording.ID, "instanceId": recording.InstanceID}, gin.H{"size": recording.Size})
+	fileName := fmt.Sprintf("console_%d_%s.cast", recording.InstanceID, recording.StartedAt.Format("20060102_150405"))
+	c.DataFromReader(http.StatusOK, recording.Size, "application/x-asciicast", reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, fileName),
+	})
+}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/cpbackup"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetControlPlaneBackups 获取控制面灾备导出记录
+// @Summary 获取控制面灾备导出记录
+// @Description 列出定时和手动触发的控制面数据加密导出，objectKey 可用于 restore 命令从对象存储恢复
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "状态：running, completed, failed, pruned"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/control-plane-backups [get]
+func GetControlPlaneBackups(c *gin.Context) {
+	var req admin.ControlPlaneBackupListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	backups, total, err := cpbackup.List(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, backups, total, req.Page, req.PageSize)
+}
+
+// CreateControlPlaneBackup 立即执行一次控制面灾备导出
+// @Summary 立即执行控制面灾备导出
+// @Description 在后台导出控制面数据并写入对象存储，不受定时导出开关影响，需要已配置加密口令
+// @Tags 管理员管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=admin.ControlPlaneBackup} "已开始导出"
+// @Failure 400 {object} common.Response "未配置加密口令"
+// @Failure 409 {object} common.Response "已有导出正在进行"
+// @Router /admin/control-plane-backups [post]
+func CreateControlPlaneBackup(c *gin.Context) {
+	startTime := time.Now()
+	backup, err := cpbackup.RunAsync(admin.ControlPlaneBackupTriggerManual)
+	if err != nil {
+		switch {
+		case errors.Is(err, cpbackup.ErrNoPassphrase):
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		case errors.Is(err, cpbackup.ErrBackupRunning):
+			common.ResponseWithError(c, common.NewError(common.CodeConflict, err.Error()))
+		default:
+			global.APP_LOG.Error("触发控制面导出失败", zap.Error(err))
+			common.ResponseWithError(c, common.NewError(common.CodeInternalError, "触发控制面导出失败"))
+		}
+		return
+	}
+	recordAuditLog(c, startTime, http.StatusOK, nil, gin.H{"backupId": backup.ID})
+	common.ResponseSuccess(c, backup, "已开始导出")
+}
+
+// DownloadControlPlaneBackup 下载控制面灾备归档
+// @Summary 下载控制面灾备归档
+// @Description 下载加密的控制面归档，用于在备用面板上通过 restore --file 恢复，下载操作写入审计日志
+// @Tags 管理员管理
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path int true "导出记录ID"
+// @Success 200 {file} file "归档文件"
+// @Failure 404 {object} common.Response "导出记录不存在或归档已删除"
+// @Router /admin/control-plane-backups/{id}/download [get]
+func DownloadControlPlaneBackup(c *gin.Context) {
+	startTime := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的导出记录ID"))
+		return
+	}
+
+	backup, reader, err := cpbackup.Open(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, cpbackup.ErrBackupNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		global.APP_LOG.Error("读取控制面归档失败", zap.Uint64("backupId", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "读取归档失败"))
+		return
+	}
+	defer reader.Close()
+
+	recordAuditLog(c, startTime, http.StatusOK, gin.H{"backupId": backup.ID}, gin.H{"size": backup.Size})
+	fileName := fmt.Sprintf("control-plane_%s_%d.ocvbak", backup.CreatedAt.Format("20060102_150405"), backup.ID)
+	c.DataFromReader(http.StatusOK, backup.Size, "application/octet-stream", reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, fileName),
+		"X-Checksum-Sha256":   backup.Checksum,
+	})
+}
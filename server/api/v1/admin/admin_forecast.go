@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/forecast"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsageForecasts 获取资源用量趋势预测
+// @Summary 获取资源用量趋势预测
+// @Description 返回Provider资源占用率和用户当月流量的最近一次预测结果，包括每日增长量和预计达到阈值的时间
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param kind query string false "预测对象类型：provider 或 user，为空返回全部"
+// @Param warning query bool false "只返回处于预警期的预测"
+// @Success 200 {object} common.Response{data=[]monitoring.UsageForecast} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/forecasts [get]
+func GetUsageForecasts(c *gin.Context) {
+	warningOnly, _ := strconv.ParseBool(c.Query("warning"))
+	forecasts, err := forecast.List(c.Query("kind"), warningOnly)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取趋势预测失败"))
+		return
+	}
+	common.ResponseSuccess(c, forecasts)
+}
+
+// GetUsageSamples 获取资源用量采样
+// @Summary 获取资源用量采样
+// @Description 返回预测对象某项指标最近一段时间的采样，用于绘制趋势图
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param kind query string true "预测对象类型：provider 或 user"
+// @Param subjectId query int true "Provider ID 或用户ID"
+// @Param metric query string true "指标：cpu, memory, disk, traffic"
+// @Param days query int false "天数，默认35" default(35)
+// @Success 200 {object} common.Response{data=[]monitoring.UsageSample} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/forecasts/samples [get]
+func GetUsageSamples(c *gin.Context) {
+	subjectID, err := strconv.ParseUint(c.Query("subjectId"), 10, 32)
+	if err != nil || c.Query("kind") == "" || c.Query("metric") == "" {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "35"))
+	samples, err := forecast.Samples(c.Query("kind"), uint(subjectID), c.Query("metric"), days)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取用量采样失败"))
+		return
+	}
+	common.ResponseSuccess(c, samples)
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/common"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/service/hostevents"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHostEvents 获取宿主机日志事件
+// @Summary 获取宿主机日志事件
+// @Description 查询从宿主机内核日志和虚拟化服务日志中识别出的OOM、磁盘错误和服务错误，按时间倒序
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param providerId query int false "Provider ID"
+// @Param instanceId query int false "实例ID"
+// @Param category query string false "类别：oom, disk, daemon"
+// @Param severity query string false "级别：warning, error, critical"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-events [get]
+func GetHostEvents(c *gin.Context) {
+	listHostEvents(c, func(*monitoringModel.HostEventListRequest) bool { return true })
+}
+
+// GetProviderHostEvents 获取Provider的宿主机日志事件
+// @Summary 获取Provider的宿主机日志事件
+// @Description 查询Provider宿主机日志中识别出的事件，按时间倒序
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param category query string false "类别：oom, disk, daemon"
+// @Param severity query string false "级别：warning, error, critical"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/providers/{id}/host-events [get]
+func GetProviderHostEvents(c *gin.Context) {
+	listHostEvents(c, func(req *monitoringModel.HostEventListRequest) bool {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		req.ProviderID, req.InstanceID = uint(id), 0
+		return err == nil && id != 0
+	})
+}
+
+// GetInstanceHostEvents 获取实例相关的宿主机日志事件
+// @Summary 获取实例相关的宿主机日志事件
+// @Description 查询宿主机日志中与实例相关的事件（如实例内进程被OOM killer杀死），按时间倒序
+// @Tags 实例管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param category query string false "类别：oom, disk, daemon"
+// @Param severity query string false "级别：warning, error, critical"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/instances/{id}/host-events [get]
+func GetInstanceHostEvents(c *gin.Context) {
+	listHostEvents(c, func(req *monitoringModel.HostEventListRequest) bool {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		req.ProviderID, req.InstanceID = 0, uint(id)
+		return err == nil && id != 0
+	})
+}
+
+// listHostEvents 绑定查询参数，由 scope 按路径参数限定范围后查询
+func listHostEvents(c *gin.Context, scope func(*monitoringModel.HostEventListRequest) bool) {
+	var req monitoringModel.HostEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil || !scope(&req) {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	events, total, err := hostevents.List(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, events, total, req.Page, req.PageSize)
+}
@@ -0,0 +1,368 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/hostscript"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHostScripts 获取宿主机脚本列表
+// @Summary 获取宿主机脚本列表
+// @Description 分页返回脚本库中的脚本，指定Provider时只返回允许在该Provider上执行的脚本
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param providerId query int false "Provider ID"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-scripts [get]
+func GetHostScripts(c *gin.Context) {
+	var req admin.HostScriptListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	scripts, total, err := hostscript.List(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, scripts, total, req.Page, req.PageSize)
+}
+
+// CreateHostScript 创建宿主机脚本
+// @Summary 创建宿主机脚本
+// @Description 创建脚本并保存为第1版。参数以同名环境变量传给脚本，可设置必填、默认值和取值正则
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.SaveHostScriptRequest true "脚本信息"
+// @Success 200 {object} common.Response{data=admin.HostScriptDetail} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-scripts [post]
+func CreateHostScript(c *gin.Context) {
+	var req admin.SaveHostScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	detail, err := hostscript.Create(hostScriptOperatorID(c), req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail, "创建成功")
+}
+
+// GetHostScript 获取宿主机脚本详情
+// @Summary 获取宿主机脚本详情
+// @Description 返回脚本设置和当前版本的内容及参数定义
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Success 200 {object} common.Response{data=admin.HostScriptDetail} "获取成功"
+// @Failure 404 {object} common.Response "脚本不存在"
+// @Router /admin/host-scripts/{id} [get]
+func GetHostScript(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	detail, err := hostscript.Get(id)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail)
+}
+
+// UpdateHostScript 修改宿主机脚本
+// @Summary 修改宿主机脚本
+// @Description 内容或参数定义变化时保存为新版本，已发起的执行仍使用发起时的版本
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Param request body admin.SaveHostScriptRequest true "脚本信息"
+// @Success 200 {object} common.Response{data=admin.HostScriptDetail} "保存成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-scripts/{id} [put]
+func UpdateHostScript(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	var req admin.SaveHostScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	detail, err := hostscript.Update(id, hostScriptOperatorID(c), req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail, "保存成功")
+}
+
+// DeleteHostScript 删除宿主机脚本
+// @Summary 删除宿主机脚本
+// @Description 删除后不能再发起执行，版本和执行记录保留，等待审批的申请被拒绝
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 404 {object} common.Response "脚本不存在"
+// @Router /admin/host-scripts/{id} [delete]
+func DeleteHostScript(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	if err := hostscript.Delete(id, hostScriptOperatorID(c)); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// GetHostScriptVersions 获取宿主机脚本版本列表
+// @Summary 获取宿主机脚本版本列表
+// @Description 按版本号倒序返回脚本的全部版本
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Success 200 {object} common.Response{data=[]admin.HostScriptVersion} "获取成功"
+// @Failure 404 {object} common.Response "脚本不存在"
+// @Router /admin/host-scripts/{id}/versions [get]
+func GetHostScriptVersions(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	versions, err := hostscript.ListVersions(id)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, versions)
+}
+
+// GetHostScriptVersion 获取宿主机脚本指定版本
+// @Summary 获取宿主机脚本指定版本
+// @Description 返回指定版本的内容和参数定义，已删除脚本的版本仍可查看
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} common.Response{data=admin.HostScriptVersion} "获取成功"
+// @Failure 404 {object} common.Response "脚本版本不存在"
+// @Router /admin/host-scripts/{id}/versions/{version} [get]
+func GetHostScriptVersion(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的版本号"))
+		return
+	}
+	v, err := hostscript.GetVersion(id, version)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, v)
+}
+
+// RunHostScript 发起宿主机脚本执行
+// @Summary 发起宿主机脚本执行
+// @Description 在一个或多个Provider宿主机上执行脚本，每个Provider生成一条执行记录。需要审批时等待其他管理员批准，否则立即创建任务。Provider启用命令白名单时脚本内容逐条检查，拦截模式下包含白名单外程序的脚本不能执行
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "脚本ID"
+// @Param request body admin.RunHostScriptRequest true "执行参数"
+// @Success 200 {object} common.Response{data=[]admin.HostScriptRun} "已提交"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-scripts/{id}/runs [post]
+func RunHostScript(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的脚本ID")
+	if !ok {
+		return
+	}
+	var req admin.RunHostScriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	canManage := func(providerID uint) bool { return middleware.CanManageProvider(c, providerID) }
+	runs, err := hostscript.RequestRun(id, hostScriptOperatorID(c), canManage, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, runs, "已提交")
+}
+
+// GetHostScriptRuns 获取宿主机脚本执行记录
+// @Summary 获取宿主机脚本执行记录
+// @Description 按脚本、Provider和状态筛选执行记录，列表不包含输出
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param scriptId query int false "脚本ID"
+// @Param providerId query int false "Provider ID"
+// @Param status query string false "状态：pending_approval, rejected, queued, running, succeeded, failed"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-script-runs [get]
+func GetHostScriptRuns(c *gin.Context) {
+	var req admin.HostScriptRunListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+
+	runs, total, err := hostscript.ListRuns(req, middleware.GetProviderScope(c))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, runs, total, req.Page, req.PageSize)
+}
+
+// GetHostScriptRun 获取宿主机脚本执行详情
+// @Summary 获取宿主机脚本执行详情
+// @Description 返回执行参数、审批信息、退出状态和输出
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "执行记录ID"
+// @Success 200 {object} common.Response{data=admin.HostScriptRun} "获取成功"
+// @Failure 404 {object} common.Response "执行记录不存在"
+// @Router /admin/host-script-runs/{id} [get]
+func GetHostScriptRun(c *gin.Context) {
+	id, ok := parseHostScriptID(c, "无效的执行记录ID")
+	if !ok {
+		return
+	}
+	run, err := hostscript.GetRun(id)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, run)
+}
+
+// ApproveHostScriptRun 批准宿主机脚本执行
+// @Summary 批准宿主机脚本执行
+// @Description 批准等待审批的执行申请并创建执行任务，默认不能批准自己发起的申请；批准时按Provider当前的命令白名单重新检查脚本内容
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "执行记录ID"
+// @Param request body admin.ReviewHostScriptRunRequest false "审批意见"
+// @Success 200 {object} common.Response{data=admin.HostScriptRun} "已批准"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-script-runs/{id}/approve [post]
+func ApproveHostScriptRun(c *gin.Context) {
+	id, req, ok := bindHostScriptReview(c)
+	if !ok {
+		return
+	}
+	run, err := hostscript.Approve(id, hostScriptOperatorID(c), req.Note)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, run, "已批准")
+}
+
+// RejectHostScriptRun 拒绝宿主机脚本执行
+// @Summary 拒绝宿主机脚本执行
+// @Description 拒绝等待审批的执行申请，脚本不会执行
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "执行记录ID"
+// @Param request body admin.ReviewHostScriptRunRequest false "拒绝原因"
+// @Success 200 {object} common.Response{data=admin.HostScriptRun} "已拒绝"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/host-script-runs/{id}/reject [post]
+func RejectHostScriptRun(c *gin.Context) {
+	id, req, ok := bindHostScriptReview(c)
+	if !ok {
+		return
+	}
+	run, err := hostscript.Reject(id, hostScriptOperatorID(c), req.Note)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, run, "已拒绝")
+}
+
+func bindHostScriptReview(c *gin.Context) (uint, admin.ReviewHostScriptRunRequest, bool) {
+	var req admin.ReviewHostScriptRunRequest
+	id, ok := parseHostScriptID(c, "无效的执行记录ID")
+	if !ok {
+		return 0, req, false
+	}
+	// 审批意见可以不填
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+			return 0, req, false
+		}
+	}
+	return id, req, true
+}
+
+func parseHostScriptID(c *gin.Context, msg string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, msg))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func hostScriptOperatorID(c *gin.Context) uint {
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		return authCtx.UserID
+	}
+	return 0
+}
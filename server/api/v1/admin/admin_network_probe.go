@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/middleware"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	monitoringModel "oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/netprobe"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StartProviderNetworkProbe 发起Provider网络质量测试
+// @Summary 发起Provider网络质量测试
+// @Description 创建任务，在Provider宿主机上执行限量限时的下载测速，并对探测目标执行ping和mtr（宿主机已安装时）。目标和测速地址为空时使用 network-probe 配置
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body adminModel.NetworkProbeTaskRequest false "测试参数"
+// @Success 200 {object} common.Response{data=adminModel.Task} "任务已创建"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 404 {object} common.Response "Provider不存在"
+// @Router /admin/providers/{id}/network-probe [post]
+func StartProviderNetworkProbe(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req adminModel.NetworkProbeTaskRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+	req.Trigger = monitoringModel.NetworkProbeTriggerManual
+
+	var prov providerModel.Provider
+	if err := global.APP_DB.Select("id", "is_frozen").First(&prov, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, common.Response{
+				Code: 404,
+				Msg:  "Provider不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "查询Provider失败",
+		})
+		return
+	}
+	if prov.IsFrozen {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "Provider已冻结",
+		})
+		return
+	}
+
+	var userID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		userID = authCtx.UserID
+	}
+	created, err := task.GetTaskService().CreateNetworkProbeTask(userID, prov.ID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "网络质量测试任务已创建",
+		Data: created,
+	})
+}
+
+// GetProviderNetworkProbes 获取Provider网络质量测试记录
+// @Summary 获取Provider网络质量测试记录
+// @Description 返回Provider最近N次网络质量测试的测速结果、各目标的延迟丢包和mtr路由，按时间倒序
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param limit query int false "返回条数，默认20，最大200"
+// @Success 200 {object} common.Response{data=[]monitoring.NetworkProbeDetail} "获取成功"
+// @Failure 400 {object} common.Response "无效的Provider ID"
+// @Router /admin/providers/{id}/network-probes [get]
+func GetProviderNetworkProbes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	records, err := netprobe.List(uint(id), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "获取成功",
+		Data: records,
+	})
+}
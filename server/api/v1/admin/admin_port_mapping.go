@@ -32,6 +32,7 @@ import (
 // @Param instanceId query int false "实例ID"
 // @Param protocol query string false "协议类型"
 // @Param status query string false "状态"
+// @Param serviceType query string false "探测到的服务类型，如 ssh、http、https、unknown"
 // @Success 200 {object} common.Response{data=object} "获取成功"
 // @Failure 400 {object} common.Response "参数错误"
 // @Failure 500 {object} common.Response "获取失败"
@@ -58,6 +59,7 @@ func GetPortMappingList(c *gin.Context) {
 
 	req.Protocol = c.Query("protocol")
 	req.Status = c.Query("status")
+	req.ProviderIDs = middleware.GetProviderScope(c)
 
 	// 参数验证
 	if req.Page <= 0 {
@@ -187,6 +189,9 @@ func CreatePortMapping(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
 		return
 	}
+	if rejectOutOfScope(c, &provider.Instance{}, "provider_id", "id = ?", req.InstanceID) {
+		return
+	}
 
 	// 获取当前管理员用户ID（使用认证上下文）
 	authCtx, exists := middleware.GetAuthContext(c)
@@ -199,17 +204,26 @@ func CreatePortMapping(c *gin.Context) {
 	portID, taskData, err := portMappingService.CreatePortMappingWithTask(req)
 	if err != nil {
 		global.APP_LOG.Error("创建端口映射失败", zap.Error(err))
-		// 判断是否为端口范围验证错误
-		if errors.Is(err, resources.ErrPortRangeValidation) {
-			// 去掉错误类型前缀，只保留实际的错误消息
-			errMsg := strings.TrimPrefix(err.Error(), "port range validation error: ")
-			common.ResponseWithError(c, common.NewError(common.CodeValidationError, errMsg))
-		} else {
-			common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
-		}
+		respondPortMappingError(c, err)
+		return
+	}
+
+	startPortMappingTask(c, authCtx.UserID, portID, taskData)
+}
+
+// respondPortMappingError 返回端口映射错误，端口范围验证错误作为参数错误返回
+func respondPortMappingError(c *gin.Context, err error) {
+	if errors.Is(err, resources.ErrPortRangeValidation) {
+		// 去掉错误类型前缀，只保留实际的错误消息
+		errMsg := strings.TrimPrefix(err.Error(), "port range validation error: ")
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, errMsg))
 		return
 	}
+	common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+}
 
+// startPortMappingTask 创建并启动端口映射异步任务，返回任务ID和端口ID
+func startPortMappingTask(c *gin.Context, userID uint, portID uint, taskData *admin.CreatePortMappingTaskRequest) {
 	// 序列化任务数据
 	taskDataJSON, err := json.Marshal(taskData)
 	if err != nil {
@@ -221,7 +235,7 @@ func CreatePortMapping(c *gin.Context) {
 	// 创建任务
 	taskService := task.GetTaskService()
 	newTask, err := taskService.CreateTask(
-		authCtx.UserID,
+		userID,
 		&taskData.ProviderID,
 		&taskData.InstanceID,
 		"create-port-mapping",
@@ -337,6 +351,9 @@ func BatchDeletePortMapping(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
 		return
 	}
+	if rejectOutOfScope(c, &provider.Port{}, "provider_id", "id IN ?", req.IDs) {
+		return
+	}
 
 	// 获取当前管理员用户ID
 	authCtx, exists := middleware.GetAuthContext(c)
@@ -535,6 +552,10 @@ func CheckPortAvailability(c *gin.Context) {
 		return
 	}
 
+	if rejectOutOfScope(c, &provider.Provider{}, "id", "id = ?", req.ProviderID) {
+		return
+	}
+
 	// 默认端口数量为1
 	if req.PortCount == 0 {
 		req.PortCount = 1
@@ -604,3 +625,171 @@ func SyncPortMappings(c *gin.Context) {
 		"taskCount": len(tasks),
 	}, fmt.Sprintf("已创建 %d 个同步任务，正在后台执行", len(tasks)))
 }
+
+// GetProviderPortRanges 获取Provider可分配端口段
+// @Summary 获取Provider可分配端口段
+// @Description 管理员获取Provider自定义的可分配端口段列表
+// @Tags 端口映射管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=[]provider.ProviderPortRange} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/{id}/port-ranges [get]
+func GetProviderPortRanges(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的Provider ID"))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	ranges, err := portMappingService.ListProviderPortRanges(uint(id))
+	if err != nil {
+		global.APP_LOG.Error("获取Provider端口段失败", zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, ranges, "获取成功")
+}
+
+// CreateProviderPortRange 新增Provider可分配端口段
+// @Summary 新增Provider可分配端口段
+// @Description 管理员为NAT Provider定义可分配端口段，端口段必须位于Provider端口范围内且互不重叠
+// @Tags 端口映射管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body admin.ProviderPortRangeRequest true "端口段参数"
+// @Success 200 {object} common.Response{data=provider.ProviderPortRange} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "创建失败"
+// @Router /admin/providers/{id}/port-ranges [post]
+func CreateProviderPortRange(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的Provider ID"))
+		return
+	}
+
+	var req admin.ProviderPortRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	portRange, err := portMappingService.CreateProviderPortRange(uint(id), req)
+	if err != nil {
+		global.APP_LOG.Error("创建Provider端口段失败", zap.Error(err))
+		respondPortMappingError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, portRange, "端口段创建成功")
+}
+
+// DeleteProviderPortRange 删除Provider可分配端口段
+// @Summary 删除Provider可分配端口段
+// @Description 管理员删除Provider的可分配端口段，已分配的端口映射不受影响
+// @Tags 端口映射管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param rangeId path int true "端口段ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "删除失败"
+// @Router /admin/providers/{id}/port-ranges/{rangeId} [delete]
+func DeleteProviderPortRange(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的Provider ID"))
+		return
+	}
+	rangeID, err := strconv.ParseUint(c.Param("rangeId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的端口段ID"))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	if err := portMappingService.DeleteProviderPortRange(uint(id), uint(rangeID)); err != nil {
+		global.APP_LOG.Error("删除Provider端口段失败", zap.Error(err))
+		respondPortMappingError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c, nil, "端口段删除成功")
+}
+
+// GetProviderPortPlan 获取Provider端口规划视图
+// @Summary 获取Provider端口规划视图
+// @Description 管理员查看Provider各可分配端口段的使用率、空闲块和碎片化程度
+// @Tags 端口映射管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.ProviderPortPlanResponse} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/{id}/port-plan [get]
+func GetProviderPortPlan(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的Provider ID"))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	plan, err := portMappingService.GetProviderPortPlan(uint(id))
+	if err != nil {
+		global.APP_LOG.Error("获取Provider端口规划失败", zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, plan, "获取成功")
+}
+
+// AllocatePortBlock 为实例分配连续端口块
+// @Summary 为实例分配连续端口块
+// @Description 管理员在Provider可分配端口段中为实例分配N个连续端口，以端口段映射方式异步创建
+// @Tags 端口映射管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.AllocatePortBlockRequest true "分配参数"
+// @Success 200 {object} common.Response{data=object} "创建成功，返回任务ID"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "创建失败"
+// @Router /admin/port-mappings/allocate-block [post]
+func AllocatePortBlock(c *gin.Context) {
+	var req admin.AllocatePortBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	authCtx, exists := middleware.GetAuthContext(c)
+	if !exists {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	portID, taskData, err := portMappingService.AllocatePortBlock(req)
+	if err != nil {
+		global.APP_LOG.Error("分配连续端口块失败", zap.Error(err))
+		respondPortMappingError(c, err)
+		return
+	}
+
+	startPortMappingTask(c, authCtx.UserID, portID, taskData)
+}
@@ -2,17 +2,22 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"oneclickvirt/service/provider"
 	"oneclickvirt/utils"
 	"strconv"
 	"strings"
+	"time"
 
 	"oneclickvirt/global"
+	"oneclickvirt/middleware"
 	"oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
+	"oneclickvirt/model/resource"
 	adminProvider "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/resources"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -27,6 +32,9 @@ import (
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param keyword query string false "搜索关键字"
 // @Param type query string false "提供商类型筛选"
 // @Success 200 {object} common.Response{data=object} "获取成功"
@@ -44,17 +52,20 @@ func GetProviderList(c *gin.Context) {
 		global.APP_LOG.Warn("Provider列表查询参数绑定失败，使用默认值", zap.Error(err))
 	}
 
-	// 确保页码和页大小的合理性
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 || req.PageSize > 100 {
-		req.PageSize = 10
-	}
+	// 解析游标并确保页码和页大小的合理性
+	req.Normalize()
+	req.ProviderIDs = middleware.GetProviderScope(c)
 
 	providerService := adminProvider.NewService()
 	providers, total, err := providerService.GetProviderList(req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, common.Response{
 			Code: 500,
 			Msg:  "获取提供商列表失败",
@@ -66,8 +77,9 @@ func GetProviderList(c *gin.Context) {
 		Code: 200,
 		Msg:  "获取成功",
 		Data: map[string]interface{}{
-			"list":  providers,
-			"total": total,
+			"list":          providers,
+			"total":         total,
+			"nextPageToken": common.NextPageToken(req.Page, req.PageSize, total),
 		},
 	})
 }
@@ -135,6 +147,9 @@ func UpdateProvider(c *gin.Context) {
 
 	// 设置ID从URL参数
 	req.ID = uint(id)
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		req.OperatorID = authCtx.UserID
+	}
 
 	providerService := adminProvider.NewService()
 	if err := providerService.UpdateProvider(req); err != nil {
@@ -474,6 +489,202 @@ func GetProviderStatus(c *gin.Context) {
 	})
 }
 
+// GetProvidersCapacity 获取Provider容量看板
+// @Summary 获取Provider容量看板
+// @Description 获取各Provider的物理资源、系统预留、超售比例与已售资源对比
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]resource.ProviderCapacity} "获取成功"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/providers/capacity [get]
+func GetProvidersCapacity(c *gin.Context) {
+	resourceService := &resources.ResourceService{}
+	capacity, err := resourceService.GetProvidersCapacity()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "获取容量信息失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "获取成功",
+		Data: capacity,
+	})
+}
+
+// SimulateProvidersCapacity 容量规划模拟
+// @Summary 容量规划模拟
+// @Description 假设批量创建指定数量的同规格实例，按当前系统预留与超售规则报告各Provider能容纳的数量及放置后的物理占用率，不会预留资源
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body resource.CapacitySimulateRequest true "模拟参数"
+// @Success 200 {object} common.Response{data=resource.CapacitySimulateResult} "模拟成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "模拟失败"
+// @Router /admin/providers/capacity/simulate [post]
+func SimulateProvidersCapacity(c *gin.Context) {
+	var req resource.CapacitySimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	resourceService := &resources.ResourceService{}
+	result, err := resourceService.SimulateCapacity(req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "容量模拟失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "模拟成功",
+		Data: result,
+	})
+}
+
+// PlanProviderDecommission 节点下线迁移规划
+// @Summary 节点下线迁移规划
+// @Description 按迁移顺序（已停止的实例优先，其余按内存从大到小）列出节点上的全部实例，在同类型的目标节点中按当前预留与超售规则分散放置，报告每个实例的目标节点以及能否全部容纳，不会预留资源或迁移实例
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body resource.DecommissionPlanRequest false "规划参数"
+// @Success 200 {object} common.Response{data=resource.DecommissionPlan} "规划成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "规划失败"
+// @Router /admin/providers/{id}/decommission/plan [post]
+func PlanProviderDecommission(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req resource.DecommissionPlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	resourceService := &resources.ResourceService{}
+	plan, err := resourceService.PlanDecommission(uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "下线规划失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "规划成功",
+		Data: plan,
+	})
+}
+
+// GetProviderHostCompatibility 获取Provider宿主机兼容性报告
+// @Summary 获取Provider宿主机兼容性报告
+// @Description 返回连接时检测到的内核版本、cgroup模式和内核功能，以及按当前配置评估出的不兼容项和处理方法
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=hostcompat.Report} "获取成功"
+// @Failure 400 {object} common.Response "无效的Provider ID"
+// @Failure 404 {object} common.Response "Provider不存在"
+// @Router /admin/providers/{id}/compatibility [get]
+func GetProviderHostCompatibility(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	report, err := adminProvider.NewService().GetHostCompatibility(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, common.Response{
+			Code: 404,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "获取成功",
+		Data: report,
+	})
+}
+
+// GetProviderHealthHistory 获取Provider健康检查历史
+// @Summary 获取Provider健康检查历史
+// @Description 返回Provider最近N次定时健康检查的状态、耗时、错误和距离下次检查的间隔，按时间倒序
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param limit query int false "返回条数，默认20，最大200"
+// @Success 200 {object} common.Response{data=[]providerModel.ProviderHealthCheck} "获取成功"
+// @Failure 400 {object} common.Response "无效的Provider ID"
+// @Failure 404 {object} common.Response "Provider不存在"
+// @Router /admin/providers/{id}/health-history [get]
+func GetProviderHealthHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	records, err := adminProvider.NewService().GetHealthCheckHistory(uint(id), limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, common.Response{
+			Code: 404,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "获取成功",
+		Data: records,
+	})
+}
+
 // ExportProviderConfigs 导出所有Provider配置
 // @Summary 导出所有Provider配置
 // @Description 导出所有已配置的Provider认证信息到文件
@@ -611,6 +822,200 @@ func TestSSHConnection(c *gin.Context) {
 	})
 }
 
+// RotateProviderCredentials 轮换Provider SSH凭据
+// @Summary 轮换Provider SSH凭据
+// @Description 先使用新凭据测试SSH连接，通过后原子更新存储的凭据并切换到新连接，进行中的任务不受影响，操作记录到审计日志
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body admin.RotateProviderCredentialsRequest true "新的SSH凭据"
+// @Success 200 {object} common.Response{data=admin.RotateProviderCredentialsResponse} "轮换成功"
+// @Failure 400 {object} common.Response "请求参数错误或新凭据测试失败"
+// @Router /admin/providers/{id}/rotate-credentials [post]
+func RotateProviderCredentials(c *gin.Context) {
+	startTime := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req admin.RotateProviderCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	// 审计日志只记录凭据类型，不记录凭据内容
+	auditRequest := gin.H{
+		"providerId":      id,
+		"usernameChanged": req.Username != "",
+		"passwordSet":     req.Password != "",
+		"sshKeySet":       req.SSHKey != "",
+		"reason":          req.Reason,
+	}
+
+	providerService := adminProvider.NewService()
+	resp, err := providerService.RotateProviderCredentials(uint(id), req)
+	if err != nil {
+		recordAuditLog(c, startTime, http.StatusBadRequest, auditRequest, gin.H{"success": false, "error": err.Error()})
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	recordAuditLog(c, startTime, http.StatusOK, auditRequest, gin.H{"success": true, "result": resp})
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "凭据轮换成功",
+		Data: resp,
+	})
+}
+
+// GetProviderSSHKey 获取Provider SSH密钥状态
+// @Summary 获取Provider SSH密钥状态
+// @Description 返回当前认证方式、是否仍保存密码、当前密钥和待启用密钥的公钥及指纹，不返回私钥
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.ProviderSSHKeyResponse} "获取成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Router /admin/providers/{id}/ssh-key [get]
+func GetProviderSSHKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	providerService := adminProvider.NewService()
+	resp, err := providerService.GetProviderSSHKey(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "获取成功",
+		Data: resp,
+	})
+}
+
+// GenerateProviderSSHKey 为Provider生成SSH密钥对
+// @Summary 为Provider生成SSH密钥对
+// @Description 生成专用的ed25519密钥对，私钥保存在面板中等待启用，返回需要安装到宿主机的公钥和安装命令；重新生成会替换尚未启用的密钥，当前凭据不受影响
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=admin.ProviderSSHKeyResponse} "生成成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Router /admin/providers/{id}/ssh-key/generate [post]
+func GenerateProviderSSHKey(c *gin.Context) {
+	startTime := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	auditRequest := gin.H{"providerId": id}
+	providerService := adminProvider.NewService()
+	resp, err := providerService.GenerateProviderSSHKey(uint(id))
+	if err != nil {
+		recordAuditLog(c, startTime, http.StatusBadRequest, auditRequest, gin.H{"success": false, "error": err.Error()})
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	recordAuditLog(c, startTime, http.StatusOK, auditRequest, gin.H{"success": true, "fingerprint": resp.PendingFingerprint})
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "密钥生成成功，请将公钥安装到宿主机后启用",
+		Data: resp,
+	})
+}
+
+// ActivateProviderSSHKey 启用面板生成的Provider SSH密钥
+// @Summary 启用面板生成的Provider SSH密钥
+// @Description 使用待启用的密钥测试SSH连接，通过后切换为仅密钥认证并删除保存的SSH密码，操作记录到审计日志
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body admin.ActivateProviderSSHKeyRequest false "启用参数"
+// @Success 200 {object} common.Response{data=admin.RotateProviderCredentialsResponse} "启用成功"
+// @Failure 400 {object} common.Response "没有待启用的密钥或密钥测试失败"
+// @Router /admin/providers/{id}/ssh-key/activate [post]
+func ActivateProviderSSHKey(c *gin.Context) {
+	startTime := time.Now()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req admin.ActivateProviderSSHKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	auditRequest := gin.H{"providerId": id, "reason": req.Reason}
+	providerService := adminProvider.NewService()
+	resp, err := providerService.ActivateProviderSSHKey(uint(id), req)
+	if err != nil {
+		recordAuditLog(c, startTime, http.StatusBadRequest, auditRequest, gin.H{"success": false, "error": err.Error()})
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	recordAuditLog(c, startTime, http.StatusOK, auditRequest, gin.H{"success": true, "result": resp})
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "已切换为密钥认证",
+		Data: resp,
+	})
+}
+
 // CheckProviderName 检查Provider名称是否已存在
 // @Summary 检查Provider名称是否已存在
 // @Description 检查指定的Provider名称是否已被使用（用于前端实时验证）
@@ -733,3 +1138,51 @@ func CheckProviderEndpoint(c *gin.Context) {
 		},
 	})
 }
+
+// TestProviderImageMirrors 测试Provider镜像源
+// @Summary 测试Provider镜像源
+// @Description 在Provider宿主机上试下载镜像，依次测试镜像源规则改写出的地址和原始地址，返回各地址的可用性和下载速度，不修改已保存的配置
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body adminProvider.ImageMirrorTestRequest true "测试参数"
+// @Success 200 {object} common.Response{data=adminProvider.ImageMirrorTestResult} "测试完成"
+// @Failure 400 {object} common.Response "请求参数错误或测试失败"
+// @Router /admin/providers/{id}/image-mirrors/test [post]
+func TestProviderImageMirrors(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req adminProvider.ImageMirrorTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+	req.ProviderID = uint(id)
+
+	result, err := adminProvider.NewService().TestImageMirrors(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "镜像源测试完成",
+		Data: result,
+	})
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAdminProviderScope 获取管理员的Provider管理范围
+// @Summary 获取管理员的Provider管理范围
+// @Description 返回管理员被限定可管理的Provider，列表为空表示不限制
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} common.Response{data=admin.AdminProviderScopeResponse} "获取成功"
+// @Failure 404 {object} common.Response "用户不存在"
+// @Router /admin/users/{id}/provider-scope [get]
+func GetAdminProviderScope(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的用户ID"))
+		return
+	}
+	resp, err := user.NewService().GetProviderScope(uint(userID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, resp)
+}
+
+// SetAdminProviderScope 设置管理员的Provider管理范围
+// @Summary 设置管理员的Provider管理范围
+// @Description 把管理员限定为只能管理指定Provider上的实例、端口映射和任务，其他管理接口不可访问；传空列表恢复为完整管理员。不能修改自己的范围
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body admin.SetAdminProviderScopeRequest true "Provider ID列表"
+// @Success 200 {object} common.Response{data=admin.AdminProviderScopeResponse} "设置成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/users/{id}/provider-scope [put]
+func SetAdminProviderScope(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的用户ID"))
+		return
+	}
+	var req admin.SetAdminProviderScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	var operatorID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		operatorID = authCtx.UserID
+	}
+	resp, err := user.NewService().SetProviderScope(uint(userID), operatorID, req.ProviderIDs)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, resp, "设置成功")
+}
+
+// rejectOutOfScope 子管理员的请求体引用了管理范围外的资源时返回403
+// model、column 和查询条件用于找出请求涉及的Provider，未限定范围的管理员不做查询
+func rejectOutOfScope(c *gin.Context, model interface{}, column string, query string, args ...interface{}) bool {
+	if len(middleware.GetProviderScope(c)) == 0 {
+		return false
+	}
+	var providerIDs []uint
+	global.APP_DB.Model(model).Where(query, args...).Distinct().Pluck(column, &providerIDs)
+	if len(providerIDs) == 0 {
+		providerIDs = []uint{0}
+	}
+	for _, providerID := range providerIDs {
+		if !middleware.CanManageProvider(c, providerID) {
+			common.ResponseWithError(c, common.NewError(common.CodeForbidden, "该资源不在您的管理范围内"))
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRegistrationApplications 获取注册申请列表
+// @Summary 获取注册申请列表
+// @Description 管理员分页查看注册申请，默认按提交时间倒序
+// @Tags 注册审核
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "按用户名、邮箱或IP搜索"
+// @Param status query string false "状态筛选：pending, approved, rejected"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/registration-applications [get]
+func GetRegistrationApplications(c *gin.Context) {
+	var req admin.RegistrationApplicationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	list, total, err := auth.NewRegistrationService().ListApplications(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取注册申请列表失败"))
+		return
+	}
+	common.ResponseSuccess(c, gin.H{
+		"list":  list,
+		"total": total,
+	})
+}
+
+// ApproveRegistrationApplications 批量通过注册申请
+// @Summary 批量通过注册申请
+// @Description 管理员批量通过注册申请并创建用户，结果会通过邮件通知申请人
+// @Tags 注册审核
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.ReviewRegistrationRequest true "审核请求参数"
+// @Success 200 {object} common.Response{data=[]auth.RegistrationReviewResult} "审核完成"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 401 {object} common.Response "未授权"
+// @Router /admin/registration-applications/approve [post]
+func ApproveRegistrationApplications(c *gin.Context) {
+	reviewRegistrationApplications(c, true)
+}
+
+// RejectRegistrationApplications 批量拒绝注册申请
+// @Summary 批量拒绝注册申请
+// @Description 管理员批量拒绝注册申请，结果会通过邮件通知申请人
+// @Tags 注册审核
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body admin.ReviewRegistrationRequest true "审核请求参数"
+// @Success 200 {object} common.Response{data=[]auth.RegistrationReviewResult} "审核完成"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 401 {object} common.Response "未授权"
+// @Router /admin/registration-applications/reject [post]
+func RejectRegistrationApplications(c *gin.Context) {
+	reviewRegistrationApplications(c, false)
+}
+
+func reviewRegistrationApplications(c *gin.Context, approve bool) {
+	var req admin.ReviewRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	adminID, err := getUserIDFromContext(c)
+	if err != nil {
+		respondUnauthorized(c, "用户未登录")
+		return
+	}
+
+	service := auth.NewRegistrationService()
+	var results []auth.RegistrationReviewResult
+	if approve {
+		results = service.Approve(req.IDs, req.Note, adminID)
+	} else {
+		results = service.Reject(req.IDs, req.Note, adminID)
+	}
+	common.ResponseSuccess(c, results, "审核完成")
+}
+
+// GetRegistrationRejectionStats 获取注册拒绝统计
+// @Summary 获取注册拒绝统计
+// @Description 统计最近一段时间因邮箱策略（域名允许/禁止列表、一次性邮箱、MX记录）被拒绝的注册，按原因、域名和IP汇总
+// @Tags 注册审核
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "统计天数，默认30，最大365" default(30)
+// @Success 200 {object} common.Response{data=auth.RegistrationRejectionStats} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/registration-rejections/stats [get]
+func GetRegistrationRejectionStats(c *gin.Context) {
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	stats, err := auth.NewRegistrationService().RejectionStats(days)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取注册拒绝统计失败"))
+		return
+	}
+	common.ResponseSuccess(c, stats)
+}
@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	"oneclickvirt/middleware"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/consolerecord"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -52,7 +54,7 @@ func AdminSSHWebSocket(c *gin.Context) {
 
 	// 获取实例信息（管理员可以访问任意实例）
 	var instance providerModel.Instance
-	err := global.APP_DB.Select("id", "name", "provider_id", "status", "private_ip", "public_ip", "ipv6_address", "public_ipv6", "ssh_port", "username", "password").
+	err := global.APP_DB.Select("id", "name", "user_id", "provider_id", "status", "private_ip", "public_ip", "ipv6_address", "public_ipv6", "ssh_port", "username", "password").
 		Where("id = ?", instanceID).
 		First(&instance).Error
 	if err != nil {
@@ -193,6 +195,21 @@ func AdminSSHWebSocket(c *gin.Context) {
 		return
 	}
 
+	// 按配置录制会话，录制失败不影响连接
+	sessionInfo := consolerecord.SessionInfo{Instance: &instance, ClientIP: c.ClientIP(), Cols: 80, Rows: 24}
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		sessionInfo.AdminID = authCtx.UserID
+		sessionInfo.AdminName = authCtx.Username
+	}
+	recorder, err := consolerecord.Start(sessionInfo)
+	if err != nil {
+		global.APP_LOG.Error("启动终端会话录制失败", zap.String("instanceID", instanceID), zap.Error(err))
+	}
+	defer recorder.Close()
+	if recorder != nil {
+		ws.WriteMessage(websocket.BinaryMessage, []byte("\x1b[33m[本次会话正在录制，用于运维审计]\x1b[0m\r\n"))
+	}
+
 	// 创建context用于超时控制
 	ctx, cancel := context.WithTimeout(context.Background(), 24*time.Hour)
 	defer cancel()
@@ -244,6 +261,7 @@ func AdminSSHWebSocket(c *gin.Context) {
 										if err := sshSession.WindowChange(int(rows), int(cols)); err != nil {
 											global.APP_LOG.Error("窗口大小调整失败", zap.Error(err))
 										}
+										recorder.Resize(int(cols), int(rows))
 										continue
 									}
 								}
@@ -257,6 +275,7 @@ func AdminSSHWebSocket(c *gin.Context) {
 				}
 
 				// 发送数据到SSH - 直接写入原始字节
+				recorder.Input(p)
 				if _, err := sshStdin.Write(p); err != nil {
 					global.APP_LOG.Error("写入SSH stdin失败", zap.Error(err))
 					return
@@ -292,6 +311,7 @@ func AdminSSHWebSocket(c *gin.Context) {
 				return
 			}
 			if n > 0 {
+				recorder.Stdout(buf[:n])
 				// 使用 BinaryMessage 而不是 TextMessage，避免UTF-8验证问题
 				if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
 					global.APP_LOG.Error("写入WebSocket失败", zap.Error(err))
@@ -328,6 +348,7 @@ func AdminSSHWebSocket(c *gin.Context) {
 				return
 			}
 			if n > 0 {
+				recorder.Stderr(buf[:n])
 				// 使用 BinaryMessage 而不是 TextMessage
 				if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
 					global.APP_LOG.Error("写入WebSocket失败", zap.Error(err))
@@ -1,11 +1,15 @@
 package admin
 
 import (
+	"errors"
 	"oneclickvirt/service/task"
 	"strconv"
 
+	"oneclickvirt/global"
+	"oneclickvirt/middleware"
 	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
+	"oneclickvirt/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,6 +23,9 @@ import (
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "页大小" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param providerId query int false "Provider ID"
 // @Param username query string false "用户名"
 // @Param taskType query string false "任务类型"
@@ -35,26 +42,27 @@ func GetAdminTasks(c *gin.Context) {
 		return
 	}
 
-	// 设置默认值
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
-	}
+	// 解析游标并设置默认值
+	req.Normalize()
+	req.ProviderIDs = middleware.GetProviderScope(c)
 
 	taskService := task.GetTaskService()
 	tasks, total, err := taskService.GetAdminTasks(req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取任务列表失败"))
 		return
 	}
 
 	response := adminModel.AdminTaskListResponse{
-		List:     tasks,
-		Total:    total,
-		Page:     req.Page,
-		PageSize: req.PageSize,
+		List:          tasks,
+		Total:         total,
+		Page:          req.Page,
+		PageSize:      req.PageSize,
+		NextPageToken: common.NextPageToken(req.Page, req.PageSize, total),
 	}
 
 	common.ResponseSuccess(c, response)
@@ -163,6 +171,62 @@ func GetTaskOverallStats(c *gin.Context) {
 	common.ResponseSuccess(c, stats)
 }
 
+// GetTaskAnalytics 获取任务分析数据
+// @Summary 获取任务分析数据
+// @Description 按时间窗口统计各任务类型、Provider的任务数量和失败率，归类常见失败原因，统计排队和执行耗时，并列出超时未结束的任务
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param hours query int false "统计时间窗口（小时），最大2160" default(24)
+// @Param bucket query string false "时间序列粒度：hour、day"
+// @Param providerId query int false "Provider ID"
+// @Param taskType query string false "任务类型"
+// @Param topErrors query int false "返回的常见错误数量" default(10)
+// @Success 200 {object} common.Response{data=adminModel.TaskAnalyticsResponse} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/tasks/analytics [get]
+func GetTaskAnalytics(c *gin.Context) {
+	var req adminModel.TaskAnalyticsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	taskService := task.GetTaskService()
+	analytics, err := taskService.GetTaskAnalytics(req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取任务分析数据失败"))
+		return
+	}
+
+	common.ResponseSuccess(c, analytics)
+}
+
+// GetStuckTasks 获取卡住的任务
+// @Summary 获取卡住的任务
+// @Description 列出执行时间已超过超时时间但仍处于运行状态的任务
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]adminModel.StuckTask} "获取成功"
+// @Failure 401 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "获取失败"
+// @Router /admin/tasks/stuck [get]
+func GetStuckTasks(c *gin.Context) {
+	taskService := task.GetTaskService()
+	tasks, err := taskService.GetStuckTasks()
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取卡住的任务失败"))
+		return
+	}
+
+	common.ResponseSuccess(c, tasks)
+}
+
 // GetTaskDetail 获取任务详情
 // @Summary 获取任务详情
 // @Description 管理员获取指定任务的详细信息
@@ -198,3 +262,30 @@ func GetTaskDetail(c *gin.Context) {
 
 	common.ResponseSuccess(c, detail)
 }
+
+// GetTaskEvents 订阅任务进度
+// @Summary 订阅任务进度
+// @Description 管理员以SSE订阅任意任务的进度：连接后推送snapshot事件，进度变化推送progress事件，任务结束推送done事件后断开
+// @Tags 管理员管理
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param taskId path int true "任务ID"
+// @Success 200 {string} string "SSE事件流"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "任务不存在"
+// @Router /admin/tasks/{taskId}/events [get]
+func GetTaskEvents(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的任务ID"))
+		return
+	}
+
+	var taskInfo adminModel.Task
+	if err := global.APP_DB.First(&taskInfo, taskID).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "任务不存在"))
+		return
+	}
+
+	task.StreamTaskEvents(c, &taskInfo)
+}
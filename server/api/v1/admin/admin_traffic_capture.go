@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"strconv"
+
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/common"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/service/pmacct"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInstanceTrafficCaptures 获取实例流量明细抓取记录
+// @Summary 获取实例流量明细抓取记录
+// @Description 返回实例最近20次按端口和对端聚合的流量抓取记录
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=[]monitoring.TrafficCapture} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/instances/{id}/traffic-captures [get]
+func GetInstanceTrafficCaptures(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+	captures, err := pmacct.NewService().ListTrafficCaptures(uint(instanceID), 20)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, captures)
+}
+
+// StartInstanceTrafficCapture 开始实例流量明细抓取
+// @Summary 开始实例流量明细抓取
+// @Description 在宿主机上临时按端口和对端聚合实例流量，到期后自动结束并保存结果，每个实例同时只能有一个抓取
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body monitoring.StartTrafficCaptureRequest false "抓取参数"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCapture} "已开始抓取"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/instances/{id}/traffic-captures [post]
+func StartInstanceTrafficCapture(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+	var req monitoringModel.StartTrafficCaptureRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+			return
+		}
+	}
+
+	var operatorID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		operatorID = authCtx.UserID
+	}
+	capture, err := pmacct.NewService().StartTrafficCapture(uint(instanceID), operatorID, req.Minutes)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, capture, "已开始抓取流量明细")
+}
+
+// GetInstanceTrafficCapture 获取实例流量明细
+// @Summary 获取实例流量明细
+// @Description 抓取进行中时实时读取宿主机数据，结束后返回保存的结果
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param captureId path int true "抓取ID"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCaptureDetail} "获取成功"
+// @Failure 404 {object} common.Response "抓取记录不存在"
+// @Router /admin/instances/{id}/traffic-captures/{captureId} [get]
+func GetInstanceTrafficCapture(c *gin.Context) {
+	instanceID, captureID, ok := parseTrafficCaptureParams(c)
+	if !ok {
+		return
+	}
+	detail, err := pmacct.NewService().GetTrafficCapture(instanceID, captureID)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail)
+}
+
+// StopInstanceTrafficCapture 提前结束实例流量明细抓取
+// @Summary 提前结束实例流量明细抓取
+// @Description 结束宿主机上的抓取进程并保存当前结果，已结束的抓取直接返回结果
+// @Tags 管理员管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param captureId path int true "抓取ID"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCaptureDetail} "已结束"
+// @Failure 404 {object} common.Response "抓取记录不存在"
+// @Router /admin/instances/{id}/traffic-captures/{captureId}/stop [post]
+func StopInstanceTrafficCapture(c *gin.Context) {
+	instanceID, captureID, ok := parseTrafficCaptureParams(c)
+	if !ok {
+		return
+	}
+	detail, err := pmacct.NewService().StopTrafficCapture(instanceID, captureID)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail, "抓取已结束")
+}
+
+func parseTrafficCaptureParams(c *gin.Context) (uint, uint, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return 0, 0, false
+	}
+	captureID, err := strconv.ParseUint(c.Param("captureId"), 10, 32)
+	if err != nil || captureID == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的抓取ID"))
+		return 0, 0, false
+	}
+	return uint(instanceID), uint(captureID), true
+}
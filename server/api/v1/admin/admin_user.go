@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"fmt"
 	"oneclickvirt/service/provider"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
 	"oneclickvirt/service/admin/user"
+	"oneclickvirt/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,6 +41,9 @@ func requireAdminOnly(c *gin.Context) bool {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param username query string false "用户名搜索"
 // @Param email query string false "邮箱搜索"
 // @Param status query string false "用户状态"
@@ -59,6 +64,10 @@ func GetUserList(c *gin.Context) {
 	userService := user.NewService()
 	users, total, err := userService.GetUserList(req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取用户列表失败"))
 		return
 	}
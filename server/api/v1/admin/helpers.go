@@ -1,12 +1,17 @@
 package admin
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
+	"oneclickvirt/global"
 	"oneclickvirt/middleware"
+	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // getUserIDFromContext 从认证上下文中获取用户ID（使用全局函数）
@@ -21,3 +26,37 @@ func respondUnauthorized(c *gin.Context, msg string) {
 		Msg:  msg,
 	})
 }
+
+// recordAuditLog 将管理员的敏感操作写入审计日志，request/response 中不得包含凭据等敏感信息
+func recordAuditLog(c *gin.Context, startTime time.Time, statusCode int, request, response interface{}) {
+	if global.APP_DB == nil {
+		return
+	}
+	requestJSON, _ := json.Marshal(request)
+	responseJSON, _ := json.Marshal(response)
+
+	auditLog := adminModel.AuditLog{
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		StatusCode: statusCode,
+		Latency:    time.Since(startTime).Milliseconds(),
+		ClientIP:   c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Request:    string(requestJSON),
+		Response:   string(responseJSON),
+	}
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		userID := authCtx.UserID
+		auditLog.UserID = &userID
+		auditLog.Username = authCtx.Username
+	}
+	if len(auditLog.UserAgent) > 255 {
+		auditLog.UserAgent = auditLog.UserAgent[:255]
+	}
+
+	if err := global.APP_DB.Create(&auditLog).Error; err != nil {
+		global.APP_LOG.Error("写入审计日志失败",
+			zap.String("path", auditLog.Path),
+			zap.Error(err))
+	}
+}
@@ -2,12 +2,14 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"oneclickvirt/global"
 	"oneclickvirt/model/common"
 	adminProvider "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/startup"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -205,3 +207,114 @@ func CheckInstanceSync(c *gin.Context) {
 		Data: report,
 	})
 }
+
+// ImportHostPortMappings 导入宿主机已有的端口映射
+// @Summary 导入宿主机端口映射
+// @Description 扫描Provider宿主机上已有的proxy设备和iptables DNAT规则，匹配到已纳管实例后创建端口映射记录，不修改宿主机规则
+// @Tags Provider管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body adminProvider.PortImportOptions false "导入选项"
+// @Success 200 {object} common.Response{data=adminProvider.PortImportResult} "导入成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/providers/{id}/import-port-mappings [post]
+func ImportHostPortMappings(c *gin.Context) {
+	providerIDStr := c.Param("id")
+	providerID, err := strconv.ParseUint(providerIDStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "Provider ID无效",
+		})
+		return
+	}
+
+	var options adminProvider.PortImportOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&options); err != nil {
+			c.JSON(http.StatusBadRequest, common.Response{
+				Code: 400,
+				Msg:  "参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+	options.ProviderID = uint(providerID)
+
+	providerService := adminProvider.NewService()
+	result, err := providerService.ImportHostPortMappings(c.Request.Context(), options)
+	if err != nil {
+		global.APP_LOG.Error("导入宿主机端口映射失败",
+			zap.Uint64("providerId", providerID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "导入端口映射失败: " + err.Error(),
+		})
+		return
+	}
+
+	msg := "导入端口映射成功"
+	if result.DryRun {
+		msg = "端口映射导入预览完成"
+	}
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  msg,
+		Data: result,
+	})
+}
+
+// RecoverStoppedInstances 按启动顺序恢复意外停止的实例
+// @Summary 按启动顺序恢复实例
+// @Description 比对宿主机实例状态，在后台按实例启动顺序分批启动面板记录为运行中、宿主机上已停止的实例，用于宿主机重启后恢复
+// @Tags Provider管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Success 200 {object} common.Response{data=map[string]interface{}} "已开始恢复"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/providers/{id}/recover-instances [post]
+func RecoverStoppedInstances(c *gin.Context) {
+	providerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "Provider ID无效",
+		})
+		return
+	}
+
+	providerService := adminProvider.NewService()
+	count, err := providerService.RecoverStoppedInstances(context.Background(), uint(providerID))
+	if errors.Is(err, startup.ErrInProgress) {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		global.APP_LOG.Error("恢复实例失败",
+			zap.Uint64("providerId", providerID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "恢复实例失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "已开始按启动顺序恢复实例",
+		Data: gin.H{
+			"queued": count,
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"oneclickvirt/middleware"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MigrateProviderPortMethod 在线切换Provider的端口映射方式
+// @Summary 在线切换Provider的端口映射方式
+// @Description 创建任务，按批为已有实例创建并校验新方式的端口映射，校验通过后删除旧映射并更新记录，失败的实例回滚并保留原映射。全部成功后修改Provider的IPv4端口映射方式。仅支持LXD/Incus在device_proxy和iptables之间切换
+// @Tags 提供商管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Provider ID"
+// @Param request body adminModel.MigratePortMethodTaskRequest true "迁移参数"
+// @Success 200 {object} common.Response{data=adminModel.Task} "任务已创建"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Router /admin/providers/{id}/port-mapping-method/migrate [post]
+func MigrateProviderPortMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的Provider ID",
+		})
+		return
+	}
+
+	var req adminModel.MigratePortMethodTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	var userID uint
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		userID = authCtx.UserID
+	}
+	created, err := task.GetTaskService().CreatePortMethodMigrationTask(userID, uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 200,
+		Msg:  "端口映射方式迁移任务已创建",
+		Data: created,
+	})
+}
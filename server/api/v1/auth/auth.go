@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	auth2 "oneclickvirt/service/auth"
 	"strings"
 
@@ -8,6 +9,7 @@ import (
 	"oneclickvirt/middleware"
 	"oneclickvirt/model/auth"
 	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -115,7 +117,7 @@ func ResetPassword(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body auth.RegisterRequest true "注册请求参数"
-// @Success 200 {object} common.Response{data=object} "注册成功，返回用户信息和token"
+// @Success 200 {object} common.Response{data=object} "注册成功，返回用户信息和token；开启注册审核时返回 pending=true"
 // @Failure 400 {object} common.Response "请求参数错误或注册失败"
 // @Router /auth/register [post]
 func Register(c *gin.Context) {
@@ -135,6 +137,13 @@ func Register(c *gin.Context) {
 
 	authService := auth2.AuthService{}
 	user, token, err := authService.RegisterAndLogin(req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if errors.Is(err, auth2.ErrRegistrationPending) {
+		global.APP_LOG.Info("用户提交注册申请",
+			zap.String("username", req.Username),
+			zap.String("ip", c.ClientIP()))
+		common.ResponseSuccess(c, gin.H{"pending": true}, err.Error())
+		return
+	}
 	if err != nil {
 		global.APP_LOG.Warn("用户注册失败",
 			zap.String("username", req.Username),
@@ -214,6 +223,10 @@ func Logout(c *gin.Context) {
 	if after, ok := strings.CutPrefix(token, "Bearer "); ok {
 		token = after
 	}
+	if strings.HasPrefix(token, userModel.APITokenPrefix) {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "API令牌无需登出，请在API令牌管理中撤销"))
+		return
+	}
 
 	// 将Token添加到黑名单
 	blacklistService := auth2.GetJWTBlacklistService()
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"oneclickvirt/config"
+	"oneclickvirt/model/common"
+	configModel "oneclickvirt/model/config"
+	"oneclickvirt/service/cdn"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateCDNConfig 校验静态资源CDN配置
+// @Summary 校验静态资源CDN配置
+// @Description 按提交的设置（未填写的使用当前配置）生成资源、头像和私有资源签名地址，校验签名往返，可选通过CDN实际请求样例地址并返回缓存相关响应头，不保存配置
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body configModel.CDNValidateRequest true "校验参数"
+// @Success 200 {object} common.Response{data=cdn.ValidationResult} "校验完成"
+// @Failure 400 {object} common.Response "参数错误"
+// @Router /admin/config/cdn/validate [post]
+func ValidateCDNConfig(c *gin.Context) {
+	var req configModel.CDNValidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	opts := cdn.Current()
+	if req.AssetDomain != nil {
+		domain, err := config.NormalizeAssetDomain(*req.AssetDomain)
+		if err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		opts.AssetDomain = domain
+	}
+	if req.AssetVersion != nil {
+		opts.AssetVersion = *req.AssetVersion
+	}
+	if req.SignKey != nil && !config.IsSecretMask(*req.SignKey) {
+		opts.SignKey = *req.SignKey
+	}
+	if req.SignExpire != nil {
+		opts.SignExpire = *req.SignExpire
+	}
+
+	common.ResponseSuccess(c, opts.Validate(c.Request.Context(), req.SamplePath, req.PrivatePath, req.Probe))
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	configModel "oneclickvirt/model/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfigConsistencyReport 获取最近一次配置一致性检查结果
+// @Summary 获取最近一次配置一致性检查结果
+// @Description 返回本节点最近一次（定时或手动）config.yaml、数据库与生效配置的一致性检查结果，尚未执行过时data为空
+// @Tags 配置管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=config.ConfigConsistencyReport} "获取成功"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Router /admin/config/consistency [get]
+func GetConfigConsistencyReport(c *gin.Context) {
+	configManager, ok := checkConfigSyncAccess(c)
+	if !ok {
+		return
+	}
+	common.ResponseSuccess(c, configManager.LastConsistencyReport())
+}
+
+// RunConfigConsistencyCheck 立即执行配置一致性检查
+// @Summary 立即执行配置一致性检查
+// @Description 比较本节点config.yaml、数据库和生效配置中的非系统级配置，返回三处不一致的配置项及各来源的值，敏感配置已脱敏
+// @Tags 配置管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=config.ConfigConsistencyReport} "检查完成"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "检查失败"
+// @Router /admin/config/consistency/check [post]
+func RunConfigConsistencyCheck(c *gin.Context) {
+	configManager, ok := checkConfigSyncAccess(c)
+	if !ok {
+		return
+	}
+
+	report, err := configManager.CheckConsistency(global.APP_CONFIG, "manual")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	common.ResponseSuccess(c, report, "检查完成")
+}
+
+// ReconcileConfigKey 修复不一致的配置项
+// @Summary 修复不一致的配置项
+// @Description 以所选来源（yaml、db、global）的值为准，将配置项写入数据库和config.yaml并同步到生效配置，完成后重新检查并返回最新结果。系统级配置不支持修复
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body configModel.ConfigReconcileRequest true "修复请求"
+// @Success 200 {object} common.Response{data=config.ConfigConsistencyReport} "修复成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "修复失败"
+// @Router /admin/config/consistency/reconcile [post]
+func ReconcileConfigKey(c *gin.Context) {
+	var req configModel.ConfigReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	configManager, ok := checkConfigSyncAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := configManager.ReconcileConfigKey(req.Key, req.Source, global.APP_CONFIG); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrConfigReconcileInvalid) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, common.Response{
+			Code: status,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	report, err := configManager.CheckConsistency(global.APP_CONFIG, "manual")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "配置项已修复，重新检查失败: " + err.Error(),
+		})
+		return
+	}
+	common.ResponseSuccess(c, report, "配置项已修复")
+}
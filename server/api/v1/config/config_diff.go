@@ -0,0 +1,75 @@
+package config
+
+import (
+	"net/http"
+
+	"oneclickvirt/config"
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewConfigDiff 预览配置变更
+// @Summary 预览配置变更
+// @Description 计算提交的配置与当前生效配置之间的差异（新增、变更的配置项及新旧值），敏感配置已脱敏，不会修改任何配置
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body configModel.UnifiedConfigRequest true "待提交的配置"
+// @Success 200 {object} common.Response{data=object} "计算成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "配置管理器未初始化"
+// @Router /admin/config/diff [post]
+func PreviewConfigDiff(c *gin.Context) {
+	authCtx, exists := middleware.GetAuthContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, common.Response{
+			Code: 401,
+			Msg:  "用户未认证",
+		})
+		return
+	}
+
+	req, ok := bindUnifiedConfigRequest(c)
+	if !ok {
+		return
+	}
+	if !hasConfigUpdatePermission(authCtx, req.Scope) {
+		c.JSON(http.StatusForbidden, common.Response{
+			Code: 403,
+			Msg:  "权限不足",
+		})
+		return
+	}
+
+	configManager := config.GetConfigManager()
+	if configManager == nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "配置管理器未初始化",
+		})
+		return
+	}
+
+	// 与更新接口使用相同的范围过滤，预览结果即为实际会写入的内容
+	filteredConfig := filterConfigByScope(req.Config, req.Scope, authCtx)
+	changes := configManager.PreviewUpdate(filteredConfig)
+
+	summary := map[string]int{
+		config.ConfigDiffAdded:   0,
+		config.ConfigDiffRemoved: 0,
+		config.ConfigDiffChanged: 0,
+	}
+	for _, change := range changes {
+		summary[change.Type]++
+	}
+
+	common.ResponseSuccess(c, map[string]interface{}{
+		"changes": changes,
+		"summary": summary,
+	})
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"net/http"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetConfigMetadata 获取按分组组织的配置元数据
+// @Summary 获取配置元数据
+// @Description 按分组返回全部配置项的类型、说明、取值范围、默认值和当前值（敏感配置已脱敏），以及公开、敏感和系统级标记，供前端生成设置页面
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]config.ConfigSectionMeta} "获取成功"
+// @Failure 500 {object} common.Response "配置管理器未初始化"
+// @Router /admin/config/metadata [get]
+func GetConfigMetadata(c *gin.Context) {
+	configManager := config.GetConfigManager()
+	if configManager == nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "配置管理器未初始化",
+		})
+		return
+	}
+	common.ResponseSuccess(c, configManager.ConfigMetadata(global.APP_CONFIG))
+}
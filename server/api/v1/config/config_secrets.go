@@ -0,0 +1,38 @@
+package config
+
+import (
+	"net/http"
+
+	"oneclickvirt/config"
+	"oneclickvirt/model/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSecretConfigStatus 获取敏感配置的设置状态
+// @Summary 获取敏感配置状态
+// @Description 返回所有注册的敏感配置项是否已设置（set/unset），不返回配置值
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=map[string]string} "获取成功"
+// @Failure 500 {object} common.Response "配置管理器未初始化"
+// @Router /admin/config/secrets [get]
+func GetSecretConfigStatus(c *gin.Context) {
+	configManager := config.GetConfigManager()
+	if configManager == nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "配置管理器未初始化",
+		})
+		return
+	}
+
+	allConfig := configManager.GetAllConfig()
+	status := make(map[string]string)
+	for _, key := range config.SecretConfigKeys() {
+		status[key] = config.SecretStatus(allConfig[key])
+	}
+	common.ResponseSuccess(c, status)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+
+	"oneclickvirt/config"
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/common"
+	configModel "oneclickvirt/model/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configSyncSummary 按差异类型统计变更数量
+func configSyncSummary(changes []config.ConfigDiffEntry) map[string]int {
+	summary := map[string]int{
+		config.ConfigDiffAdded:   0,
+		config.ConfigDiffRemoved: 0,
+		config.ConfigDiffChanged: 0,
+	}
+	for _, change := range changes {
+		summary[change.Type]++
+	}
+	return summary
+}
+
+// checkConfigSyncAccess 校验认证和权限，返回配置管理器
+func checkConfigSyncAccess(c *gin.Context) (*config.ConfigManager, bool) {
+	authCtx, exists := middleware.GetAuthContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, common.Response{
+			Code: 401,
+			Msg:  "用户未认证",
+		})
+		return nil, false
+	}
+	if !hasConfigUpdatePermission(authCtx, "global") {
+		c.JSON(http.StatusForbidden, common.Response{
+			Code: 403,
+			Msg:  "权限不足",
+		})
+		return nil, false
+	}
+
+	configManager := config.GetConfigManager()
+	if configManager == nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  "配置管理器未初始化",
+		})
+		return nil, false
+	}
+	return configManager, true
+}
+
+// PreviewConfigSync 预览配置同步
+// @Summary 预览配置同步
+// @Description 计算数据库重建config.yaml（db-to-yaml）或config.yaml同步到数据库（yaml-to-db）会产生的变更，敏感配置已脱敏，系统级配置不参与同步，不会修改任何配置
+// @Tags 配置管理
+// @Produce json
+// @Security BearerAuth
+// @Param direction query string true "同步方向：db-to-yaml, yaml-to-db"
+// @Success 200 {object} common.Response{data=object} "计算成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Failure 500 {object} common.Response "计算失败"
+// @Router /admin/config/sync/preview [get]
+func PreviewConfigSync(c *gin.Context) {
+	direction := c.Query("direction")
+	if !config.ValidConfigSyncDirection(direction) {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "无效的同步方向，可选值：db-to-yaml, yaml-to-db",
+		})
+		return
+	}
+
+	configManager, ok := checkConfigSyncAccess(c)
+	if !ok {
+		return
+	}
+
+	changes, err := configManager.PreviewSync(direction)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 500,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	common.ResponseSuccess(c, map[string]interface{}{
+		"direction": direction,
+		"changes":   changes,
+		"summary":   configSyncSummary(changes),
+	})
+}
+
+// ExecuteConfigSync 执行配置同步
+// @Summary 执行配置同步
+// @Description db-to-yaml 用数据库中的配置重建config.yaml，写入前备份原文件；yaml-to-db 用config.yaml覆盖数据库配置并重新加载。同一时间只允许一个同步任务
+// @Tags 配置管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body configModel.ConfigSyncRequest true "同步请求"
+// @Success 200 {object} common.Response{data=config.ConfigSyncResult} "同步成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "认证失败"
+// @Failure 403 {object} common.Response "权限不足"
+// @Failure 409 {object} common.Response "已有同步任务在执行"
+// @Failure 500 {object} common.Response "同步失败"
+// @Router /admin/config/sync [post]
+func ExecuteConfigSync(c *gin.Context) {
+	var req configModel.ConfigSyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 400,
+			Msg:  "参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	configManager, ok := checkConfigSyncAccess(c)
+	if !ok {
+		return
+	}
+
+	result, err := configManager.ExecuteSync(req.Direction)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, config.ErrConfigSyncRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, common.Response{
+			Code: status,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	common.ResponseSuccess(c, map[string]interface{}{
+		"direction":  result.Direction,
+		"changes":    result.Changes,
+		"summary":    configSyncSummary(result.Changes),
+		"backupFile": result.BackupFile,
+	}, "配置同步完成")
+}
@@ -14,6 +14,7 @@ import (
 	configModel "oneclickvirt/model/config"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // GetUnifiedConfig 获取统一配置接口
@@ -72,10 +73,9 @@ func GetUnifiedConfig(c *gin.Context) {
 		// 用户配置，普通用户可以访问的配置
 		result = getUserConfig(configManager, authCtx)
 	case "admin", "global":
-		// 管理员配置和全局配置，只有管理员可以访问
+		// 管理员配置和全局配置，管理员和只读审计员可以查看，审计员只能看到脱敏后的内容
 		permissionService := auth.PermissionService{}
-		hasAdminPermission := permissionService.HasPermission(authCtx.UserID, "admin")
-		if !hasAdminPermission {
+		if !permissionService.CanViewAdminData(authCtx.UserID) {
 			c.JSON(http.StatusForbidden, common.Response{
 				Code: 403,
 				Msg:  "权限不足",
@@ -83,6 +83,22 @@ func GetUnifiedConfig(c *gin.Context) {
 			return
 		}
 		result = getAdminConfig(configManager)
+
+		// 敏感配置默认脱敏，需显式请求且具备查看权限才返回明文
+		if c.Query("reveal") == "true" {
+			if !permissionService.CanRevealSecrets(authCtx.UserID) {
+				c.JSON(http.StatusForbidden, common.Response{
+					Code: 403,
+					Msg:  "无权查看敏感配置",
+				})
+				return
+			}
+			global.APP_LOG.Warn("管理员查看敏感配置明文",
+				zap.Uint("userID", authCtx.UserID),
+				zap.String("clientIP", c.ClientIP()))
+		} else {
+			maskAdminConfigSecrets(result)
+		}
 	default:
 		c.JSON(http.StatusBadRequest, common.Response{
 			Code: 400,
@@ -118,34 +134,11 @@ func UpdateUnifiedConfig(c *gin.Context) {
 		return
 	}
 
-	// 解析请求体
-	var rawData map[string]interface{}
-	if err := c.ShouldBindJSON(&rawData); err != nil {
-		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+	req, ok := bindUnifiedConfigRequest(c)
+	if !ok {
 		return
 	}
 
-	var req configModel.UnifiedConfigRequest
-
-	// 检查是否是新的统一格式
-	if scope, exists := rawData["scope"]; exists {
-		if config, configExists := rawData["config"]; configExists {
-			req.Scope = scope.(string)
-			req.Config = config.(map[string]interface{})
-		} else {
-			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "统一格式缺少config字段"))
-			return
-		}
-	} else {
-		// 向后兼容：直接配置数据，根据路径判断 scope
-		if strings.Contains(c.Request.URL.Path, "/admin/") {
-			req.Scope = "admin"
-		} else {
-			req.Scope = "user"
-		}
-		req.Config = rawData
-	}
-
 	// 验证权限
 	if !hasConfigUpdatePermission(authCtx, req.Scope) {
 		c.JSON(http.StatusForbidden, common.Response{
@@ -184,6 +177,51 @@ func UpdateUnifiedConfig(c *gin.Context) {
 	common.ResponseSuccess(c, nil, "配置更新成功")
 }
 
+// maskAdminConfigSecrets 脱敏管理员配置中的敏感项，已设置的显示为占位值，未设置的为空
+func maskAdminConfigSecrets(result map[string]interface{}) {
+	for section, value := range result {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, fieldValue := range fields {
+			if config.IsSecretConfigKey(section + "." + key) {
+				fields[key] = config.MaskSecretValue(fieldValue)
+			}
+		}
+	}
+}
+
+// bindUnifiedConfigRequest 解析配置更新请求体，兼容统一格式和直接提交配置数据两种形式
+func bindUnifiedConfigRequest(c *gin.Context) (configModel.UnifiedConfigRequest, bool) {
+	var req configModel.UnifiedConfigRequest
+	var rawData map[string]interface{}
+	if err := c.ShouldBindJSON(&rawData); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return req, false
+	}
+
+	// 检查是否是新的统一格式
+	if scope, exists := rawData["scope"]; exists {
+		if config, configExists := rawData["config"]; configExists {
+			req.Scope = scope.(string)
+			req.Config = config.(map[string]interface{})
+		} else {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "统一格式缺少config字段"))
+			return req, false
+		}
+	} else {
+		// 向后兼容：直接配置数据，根据路径判断 scope
+		if strings.Contains(c.Request.URL.Path, "/admin/") {
+			req.Scope = "admin"
+		} else {
+			req.Scope = "user"
+		}
+		req.Config = rawData
+	}
+	return req, true
+}
+
 // getPublicConfig 获取公开配置
 func getPublicConfig(cm *config.ConfigManager) map[string]interface{} {
 	allConfig := cm.GetAllConfig()
@@ -223,6 +261,8 @@ func getUserConfig(cm *config.ConfigManager, authCtx *authModel.AuthContext) map
 		levelKey := fmt.Sprintf("%d", level)
 		levelLimits[levelKey] = map[string]interface{}{
 			"max-instances": limitInfo.MaxInstances,
+			"max-running":   limitInfo.MaxRunning,
+			"max-snapshots": limitInfo.MaxSnapshots,
 			"max-resources": limitInfo.MaxResources,
 			"max-traffic":   limitInfo.MaxTraffic,
 		}
@@ -279,6 +319,8 @@ func getAdminConfig(cm *config.ConfigManager) map[string]interface{} {
 		levelKey := fmt.Sprintf("%d", level)
 		levelLimits[levelKey] = map[string]interface{}{
 			"max-instances": limitInfo.MaxInstances,
+			"max-running":   limitInfo.MaxRunning,
+			"max-snapshots": limitInfo.MaxSnapshots,
 			"max-resources": limitInfo.MaxResources,
 			"max-traffic":   limitInfo.MaxTraffic,
 		}
@@ -297,6 +339,31 @@ func getAdminConfig(cm *config.ConfigManager) map[string]interface{} {
 		},
 	}
 
+	// 对象存储配置（存储类型 system.oss-type 只能通过YAML修改）
+	result["oss"] = map[string]interface{}{
+		"endpoint":  global.APP_CONFIG.Oss.Endpoint,
+		"region":    global.APP_CONFIG.Oss.Region,
+		"bucket":    global.APP_CONFIG.Oss.Bucket,
+		"accessKey": global.APP_CONFIG.Oss.AccessKey,
+		"secretKey": global.APP_CONFIG.Oss.SecretKey,
+		"useSSL":    global.APP_CONFIG.Oss.UseSSL,
+		"pathStyle": global.APP_CONFIG.Oss.PathStyle,
+		"basePath":  global.APP_CONFIG.Oss.BasePath,
+	}
+
+	// 任务配置（只开放可在线修改的项）
+	result["task"] = map[string]interface{}{
+		"nameReuseCooldown": global.APP_CONFIG.Task.NameReuseCooldown,
+	}
+
+	// 静态资源CDN配置
+	result["cdn"] = map[string]interface{}{
+		"assetDomain":  global.APP_CONFIG.CDN.AssetDomain,
+		"assetVersion": global.APP_CONFIG.CDN.AssetVersion,
+		"signKey":      global.APP_CONFIG.CDN.SignKey,
+		"signExpire":   global.APP_CONFIG.CDN.SignExpire,
+	}
+
 	// 其他配置
 	result["other"] = map[string]interface{}{
 		"defaultLanguage": global.APP_CONFIG.Other.DefaultLanguage,
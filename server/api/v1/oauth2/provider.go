@@ -1,6 +1,7 @@
 package oauth2
 
 import (
+	"oneclickvirt/config"
 	"oneclickvirt/global"
 	"oneclickvirt/model/common"
 	oauth2Service "oneclickvirt/service/oauth2"
@@ -30,9 +31,7 @@ func GetProviders(c *gin.Context) {
 
 	// 隐藏敏感信息
 	for i := range providers {
-		if providers[i].ClientSecret != "" {
-			providers[i].ClientSecret = "********"
-		}
+		providers[i].ClientSecret = config.MaskSecretValue(providers[i].ClientSecret)
 	}
 
 	common.ResponseSuccess(c, providers)
@@ -93,7 +92,7 @@ func GetProvider(c *gin.Context) {
 	}
 
 	// 隐藏敏感信息
-	provider.ClientSecret = "********"
+	provider.ClientSecret = config.MaskSecretValue(provider.ClientSecret)
 
 	common.ResponseSuccess(c, provider)
 }
@@ -0,0 +1,83 @@
+package public
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/cdn"
+	"oneclickvirt/service/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAvatar 获取头像文件
+// @Summary 获取头像
+// @Description 无需登录，作为CDN回源地址返回头像目录下的文件
+// @Tags 公开接口
+// @Produce octet-stream
+// @Param filename path string true "头像文件名"
+// @Success 200 {file} binary "文件内容"
+// @Failure 404 {object} common.Response "文件不存在"
+// @Router /public/avatars/{filename} [get]
+func GetAvatar(c *gin.Context) {
+	name := c.Param("filename")
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+		return
+	}
+	serveStorageFile(c, filepath.Join(storage.GetStorageService().GetAvatarsPath(), name), "public, max-age=86400")
+}
+
+// GetSignedAsset 通过签名地址获取私有资源
+// @Summary 获取私有资源
+// @Description 无需登录，校验签名和有效期后返回上传目录下的文件，签名地址由服务端按 cdn.sign-key 生成
+// @Tags 公开接口
+// @Produce octet-stream
+// @Param filepath path string true "上传目录内的相对路径"
+// @Param expires query int true "过期时间（Unix秒）"
+// @Param sign query string true "签名"
+// @Success 200 {file} binary "文件内容"
+// @Failure 403 {object} common.Response "签名无效或已过期"
+// @Failure 404 {object} common.Response "文件不存在"
+// @Router /public/assets/{filepath} [get]
+func GetSignedAsset(c *gin.Context) {
+	filePath, ok := cdn.CleanAssetPath(c.Param("filepath"))
+	if !ok {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+		return
+	}
+	opts := cdn.Current()
+	if err := opts.Verify(filePath, c.Query("expires"), c.Query("sign"), time.Now()); err != nil {
+		if errors.Is(err, cdn.ErrSignDisabled) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+		return
+	}
+
+	// 缓存时间不超过签名剩余有效期
+	cacheControl := "private, no-store"
+	if exp, err := strconv.ParseInt(c.Query("expires"), 10, 64); err == nil && exp > time.Now().Unix() {
+		remaining := exp - time.Now().Unix()
+		cacheControl = fmt.Sprintf("public, max-age=%d", remaining)
+	}
+	serveStorageFile(c, filepath.Join(storage.GetStorageService().GetUploadsPath(), filepath.FromSlash(filePath)), cacheControl)
+}
+
+// serveStorageFile 返回存储目录下的普通文件
+func serveStorageFile(c *gin.Context, fullPath, cacheControl string) {
+	info, err := os.Stat(fullPath)
+	if err != nil || info.IsDir() {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+		return
+	}
+	c.Header("Cache-Control", cacheControl)
+	c.File(fullPath)
+}
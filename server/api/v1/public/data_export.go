@@ -0,0 +1,40 @@
+package public
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/dataexport"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DownloadDataExport 通过下载令牌下载导出文件
+// @Summary 下载数据导出文件
+// @Description 无需登录，通过导出记录中的下载地址在有效期内下载压缩包，按IP限流
+// @Tags 公开接口
+// @Produce application/zip
+// @Param token path string true "下载令牌"
+// @Success 200 {file} binary "文件内容"
+// @Failure 404 {object} common.Response "导出文件不存在或已过期"
+// @Failure 429 {object} common.Response "请求过于频繁"
+// @Router /public/data-exports/{token} [get]
+func DownloadDataExport(c *gin.Context) {
+	export, reader, err := dataexport.Open(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		if !errors.Is(err, dataexport.ErrExportNotFound) {
+			global.APP_LOG.Error("读取导出文件失败", zap.Error(err))
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, dataexport.ErrExportNotFound.Error()))
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, export.Size, "application/zip", reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, export.FileName),
+	})
+}
@@ -3,6 +3,8 @@ package public
 import (
 	"net/http"
 	"oneclickvirt/service/auth"
+	"oneclickvirt/service/cdn"
+	"oneclickvirt/service/database"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/system"
 	"os"
@@ -14,6 +16,7 @@ import (
 	"oneclickvirt/model/common"
 	configModel "oneclickvirt/model/config"
 	"oneclickvirt/source"
+	"oneclickvirt/utils"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -69,7 +72,8 @@ func CheckInit(c *gin.Context) {
 			global.APP_LOG.Warn("数据库连接ping失败", zap.Error(err))
 		} else {
 			// 检查数据库配置是否完整
-			if global.APP_CONFIG.Mysql.Dbname == "" || global.APP_CONFIG.Mysql.Path == "" {
+			if !database.IsSQLiteType(global.APP_CONFIG.System.DbType) &&
+				(global.APP_CONFIG.Mysql.Dbname == "" || global.APP_CONFIG.Mysql.Path == "") {
 				message = "数据库配置不完整，需要初始化"
 				needInit = true
 				global.APP_LOG.Info("数据库配置不完整，需要初始化")
@@ -137,10 +141,10 @@ func CheckInit(c *gin.Context) {
 func TestDatabaseConnection(c *gin.Context) {
 	var req struct {
 		Type     string `json:"type" binding:"required"`
-		Host     string `json:"host" binding:"required"`
-		Port     string `json:"port" binding:"required"`
-		Database string `json:"database" binding:"required"`
-		Username string `json:"username" binding:"required"`
+		Host     string `json:"host"`
+		Port     string `json:"port"`
+		Database string `json:"database" binding:"required"` // SQLite 时为数据库文件路径
+		Username string `json:"username"`
 		Password string `json:"password"`
 	}
 
@@ -150,9 +154,14 @@ func TestDatabaseConnection(c *gin.Context) {
 		return
 	}
 
-	// 支持MySQL和MariaDB
-	if req.Type != "mysql" && req.Type != "mariadb" {
-		c.JSON(http.StatusBadRequest, common.Error("仅支持MySQL和MariaDB数据库"))
+	// 支持MySQL、MariaDB、PostgreSQL和SQLite
+	if !database.IsSupportedType(req.Type) {
+		c.JSON(http.StatusBadRequest, common.Error("仅支持MySQL、MariaDB、PostgreSQL和SQLite数据库"))
+		return
+	}
+	// SQLite 只需要文件路径，其余类型需要服务器地址和账号
+	if !database.IsSQLiteType(req.Type) && (req.Host == "" || req.Port == "" || req.Username == "") {
+		c.JSON(http.StatusBadRequest, common.Error("参数错误"))
 		return
 	}
 
@@ -160,7 +169,7 @@ func TestDatabaseConnection(c *gin.Context) {
 	initService := &system.InitService{}
 
 	// 转换端口字符串为整数
-	port, err := strconv.Atoi(req.Port)
+	port, err := parseDatabasePort(req.Type, req.Port)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, common.Error("端口格式错误"))
 		return
@@ -234,7 +243,7 @@ func InitSystem(c *gin.Context) {
 
 	// 先检查数据库配置并确保数据库连接
 	// 转换端口字符串为整数
-	port, err := strconv.Atoi(req.Database.Port)
+	port, err := parseDatabasePort(req.Database.Type, req.Database.Port)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, common.Error("数据库端口格式错误"))
 		return
@@ -340,6 +349,14 @@ func InitSystem(c *gin.Context) {
 	c.JSON(http.StatusOK, common.Success("系统初始化成功"))
 }
 
+// parseDatabasePort 解析数据库端口，SQLite 没有端口时返回0
+func parseDatabasePort(dbType, port string) (int, error) {
+	if database.IsSQLiteType(dbType) && port == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(port)
+}
+
 // GetRegisterConfig 获取注册配置信息
 // @Summary 获取注册配置信息
 // @Description 获取注册页面所需的配置信息（不需要认证）
@@ -353,6 +370,10 @@ func GetRegisterConfig(c *gin.Context) {
 		"auth": map[string]interface{}{
 			"enablePublicRegistration": global.APP_CONFIG.Auth.EnablePublicRegistration,
 		},
+		"registration": map[string]interface{}{
+			"approvalRequired": global.APP_CONFIG.Registration.ApprovalRequired,
+			"requireReason":    global.APP_CONFIG.Registration.RequireReason,
+		},
 		"inviteCode": map[string]interface{}{
 			"enabled": global.APP_CONFIG.InviteCode.Enabled,
 		},
@@ -391,7 +412,7 @@ func GetPublicSystemConfig(c *gin.Context) {
 		}
 
 		if err := global.APP_DB.Table("system_configs").
-			Select("`key`, `value`").
+			Select(utils.QuoteIdent(global.APP_DB, "key")+", "+utils.QuoteIdent(global.APP_DB, "value")).
 			Where("is_public = ? AND deleted_at IS NULL", true).
 			Find(&configs).Error; err == nil {
 			global.APP_LOG.Info("从数据库查询到的公开配置数量", zap.Int("count", len(configs)))
@@ -425,6 +446,11 @@ func GetPublicSystemConfig(c *gin.Context) {
 			zap.String("default_language", result["default_language"].(string)))
 	}
 
+	// 静态资源CDN地址，前端据此拼接头像等资源地址
+	opts := cdn.Current()
+	result["asset_domain"] = opts.AssetDomain
+	result["asset_version"] = opts.AssetVersion
+
 	c.JSON(http.StatusOK, common.Success(result))
 }
 
@@ -453,7 +479,7 @@ func GetRecommendedDatabaseType(c *gin.Context) {
 		"recommendedType": recommendedType,
 		"reason":          reason,
 		"architecture":    arch,
-		"supportedTypes":  []string{"mysql", "mariadb"},
+		"supportedTypes":  database.SupportedTypes(),
 	}
 
 	c.JSON(http.StatusOK, common.Success(response))
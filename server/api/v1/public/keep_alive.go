@@ -0,0 +1,34 @@
+package public
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/idlestop"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// KeepInstanceAlive 通过闲置停机预告中的链接保持实例运行
+// @Summary 保持闲置实例运行
+// @Description 无需登录，通过闲置停机预告邮件中的链接撤销停机并重新开始闲置计时，按IP限流
+// @Tags 公开接口
+// @Produce json
+// @Param token path string true "保持运行令牌"
+// @Success 200 {object} common.Response "已保持运行"
+// @Failure 404 {object} common.Response "链接已失效"
+// @Failure 429 {object} common.Response "请求过于频繁"
+// @Router /public/instances/keep-alive/{token} [get]
+func KeepInstanceAlive(c *gin.Context) {
+	instance, err := idlestop.KeepAlive(c.Param("token"))
+	if err != nil {
+		if !errors.Is(err, idlestop.ErrKeepAliveNotFound) {
+			global.APP_LOG.Error("保持实例运行失败", zap.Error(err))
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, idlestop.ErrKeepAliveNotFound.Error()))
+		return
+	}
+	common.ResponseSuccess(c, gin.H{"instanceName": instance.Name}, "已保持实例运行，闲置计时已重新开始")
+}
@@ -0,0 +1,97 @@
+package public
+
+import (
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/traffic"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// shareTrafficDays 分享页展示的流量曲线天数
+const shareTrafficDays = 30
+
+// SharedInstanceInfo 分享链接公开的实例信息，只包含不涉及访问凭据和网络地址的只读字段
+type SharedInstanceInfo struct {
+	Status         string                  `json:"status"`                   // 实例状态
+	InstanceType   string                  `json:"instanceType"`             // 实例类型：container, vm
+	OSType         string                  `json:"osType"`                   // 操作系统类型
+	Region         string                  `json:"region"`                   // 所在地区
+	Country        string                  `json:"country"`                  // 所在国家
+	CPU            int                     `json:"cpu"`                      // CPU核心数
+	Memory         int64                   `json:"memory"`                   // 内存大小（MB）
+	Disk           int64                   `json:"disk"`                     // 磁盘大小（MB）
+	CreatedAt      time.Time               `json:"createdAt"`                // 创建时间
+	UptimeSeconds  int64                   `json:"uptimeSeconds"`            // 运行时长（秒），非运行状态为0
+	TrafficHistory []*traffic.HistoryPoint `json:"trafficHistory,omitempty"` // 最近30天按天聚合的流量，未公开流量时为空
+}
+
+// GetSharedInstance 通过分享令牌获取实例公开信息
+// @Summary 获取分享的实例信息
+// @Description 无需登录，通过实例所有者生成的分享令牌获取实例的状态、运行时长、地区和流量曲线，按IP限流
+// @Tags 公开接口
+// @Accept json
+// @Produce json
+// @Param token path string true "分享令牌"
+// @Success 200 {object} common.Response{data=SharedInstanceInfo} "获取成功"
+// @Failure 404 {object} common.Response "分享链接不存在或已失效"
+// @Failure 429 {object} common.Response "请求过于频繁"
+// @Router /public/share/{token} [get]
+func GetSharedInstance(c *gin.Context) {
+	var share providerModel.InstanceShare
+	if err := global.APP_DB.Where("token = ?", c.Param("token")).First(&share).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "分享链接不存在或已失效"))
+		return
+	}
+	now := time.Now()
+	if share.IsExpired(now) {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "分享链接不存在或已失效"))
+		return
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, share.InstanceID).Error; err != nil || instance.UserID != share.UserID {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "分享链接不存在或已失效"))
+		return
+	}
+
+	info := SharedInstanceInfo{
+		Status:       instance.Status,
+		InstanceType: instance.InstanceType,
+		OSType:       instance.OSType,
+		Region:       instance.Region,
+		CPU:          instance.CPU,
+		Memory:       instance.Memory,
+		Disk:         instance.Disk,
+		CreatedAt:    instance.CreatedAt,
+	}
+	if instance.Status == "running" {
+		info.UptimeSeconds = int64(now.Sub(instance.CreatedAt).Seconds())
+	}
+	var provider providerModel.Provider
+	if err := global.APP_DB.Select("region", "country").First(&provider, instance.ProviderID).Error; err == nil {
+		if info.Region == "" {
+			info.Region = provider.Region
+		}
+		info.Country = provider.Country
+	}
+	if share.ShowTraffic {
+		history, err := traffic.NewQueryService().GetInstanceTrafficHistory(instance.ID, shareTrafficDays)
+		if err != nil {
+			global.APP_LOG.Warn("获取分享实例流量历史失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		} else {
+			info.TrafficHistory = history
+		}
+	}
+
+	global.APP_DB.Model(&share).UpdateColumns(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": now,
+	})
+	common.ResponseSuccess(c, info)
+}
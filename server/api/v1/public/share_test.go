@@ -0,0 +1,88 @@
+package public
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/testutil"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupShareDB 实例1属于用户1并有有效分享，实例2的分享已过期，实例3已转给其他用户
+func setupShareDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &providerModel.Provider{}, &providerModel.Instance{}, &providerModel.InstanceShare{})
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	testutil.Seed(t, db,
+		&providerModel.Provider{ID: 1, Name: "node", Region: "Tokyo", Country: "JP", Endpoint: "203.0.113.1"},
+		&providerModel.Instance{ID: 1, Name: "web", ProviderID: 1, UserID: 1, Status: "running", CPU: 2, Memory: 1024,
+			PublicIP: "198.51.100.7", Username: "root", Password: "secret-password", CreatedAt: time.Now().Add(-time.Hour)},
+		&providerModel.Instance{ID: 2, Name: "old", ProviderID: 1, UserID: 1, Status: "stopped"},
+		&providerModel.Instance{ID: 3, Name: "moved", ProviderID: 1, UserID: 2, Status: "running"},
+		&providerModel.InstanceShare{InstanceID: 1, UserID: 1, Token: "valid", ExpiresAt: &future},
+		&providerModel.InstanceShare{InstanceID: 2, UserID: 1, Token: "expired", ExpiresAt: &past},
+		&providerModel.InstanceShare{InstanceID: 3, UserID: 1, Token: "transferred"},
+	)
+	return db
+}
+
+func TestGetSharedInstance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupShareDB(t)
+	engine := gin.New()
+	engine.GET("/public/share/:token", GetSharedInstance)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "有效链接", token: "valid", want: http.StatusOK},
+		{name: "不存在的令牌", token: "unknown", want: http.StatusNotFound},
+		{name: "已过期", token: "expired", want: http.StatusNotFound},
+		{name: "实例已转给其他用户", token: "transferred", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/share/"+tt.token, nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body.String(), tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			// 不公开访问凭据和网络地址
+			for _, secret := range []string{"secret-password", "198.51.100.7", "203.0.113.1", "web"} {
+				if strings.Contains(w.Body.String(), secret) {
+					t.Errorf("响应泄露了 %q: %s", secret, w.Body.String())
+				}
+			}
+			var resp struct {
+				Data SharedInstanceInfo `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Data.Region != "Tokyo" || resp.Data.Country != "JP" || resp.Data.UptimeSeconds <= 0 || resp.Data.TrafficHistory != nil {
+				t.Errorf("data = %+v", resp.Data)
+			}
+		})
+	}
+
+	var share providerModel.InstanceShare
+	if err := db.Where("token = ?", "valid").First(&share).Error; err != nil {
+		t.Fatal(err)
+	}
+	if share.ViewCount != 1 || share.LastViewedAt == nil {
+		t.Errorf("访问统计 = %d, %v", share.ViewCount, share.LastViewedAt)
+	}
+}
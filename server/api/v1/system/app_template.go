@@ -0,0 +1,183 @@
+package system
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	systemModel "oneclickvirt/model/system"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AppTemplateRequest 创建/更新一键应用请求
+type AppTemplateRequest struct {
+	Slug            string `json:"slug" binding:"required,max=64"`
+	Name            string `json:"name" binding:"required,max=64"`
+	Category        string `json:"category" binding:"max=32"`
+	Description     string `json:"description" binding:"max=512"`
+	Icon            string `json:"icon" binding:"max=255"`
+	Status          *int   `json:"status" binding:"omitempty,oneof=0 1"`
+	Sort            int    `json:"sort"`
+	InstanceType    string `json:"instanceType" binding:"omitempty,oneof=vm container"`
+	OSTypes         string `json:"osTypes" binding:"max=128"`
+	MinCPU          int    `json:"minCpu" binding:"min=0"`
+	MinMemoryMB     int    `json:"minMemoryMB" binding:"min=0"`
+	MinDiskMB       int    `json:"minDiskMB" binding:"min=0"`
+	Ports           string `json:"ports" binding:"max=255"`
+	InstallScript   string `json:"installScript" binding:"required"`
+	PostInstallInfo string `json:"postInstallInfo"`
+}
+
+// apply 将请求写入应用模型
+func (r *AppTemplateRequest) apply(app *systemModel.AppTemplate) {
+	app.Slug = r.Slug
+	app.Name = r.Name
+	app.Category = r.Category
+	app.Description = r.Description
+	app.Icon = r.Icon
+	app.Sort = r.Sort
+	app.InstanceType = r.InstanceType
+	app.OSTypes = r.OSTypes
+	app.MinCPU = r.MinCPU
+	app.MinMemoryMB = r.MinMemoryMB
+	app.MinDiskMB = r.MinDiskMB
+	app.Ports = r.Ports
+	app.InstallScript = r.InstallScript
+	app.PostInstallInfo = r.PostInstallInfo
+	if r.Status != nil {
+		app.Status = *r.Status
+	}
+}
+
+// GetAppTemplateList 获取一键应用列表
+// @Summary 获取一键应用列表
+// @Description 获取应用市场中的全部应用模板（包括已下架）
+// @Tags 应用市场管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]systemModel.AppTemplate} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/apps [get]
+func GetAppTemplateList(c *gin.Context) {
+	var apps []systemModel.AppTemplate
+	if err := global.APP_DB.Order("sort ASC, id ASC").Find(&apps).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取应用列表失败"))
+		return
+	}
+	common.ResponseSuccess(c, apps)
+}
+
+// CreateAppTemplate 创建一键应用
+// @Summary 创建一键应用
+// @Description 创建应用模板，包含安装脚本、端口和资源要求
+// @Tags 应用市场管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AppTemplateRequest true "应用参数"
+// @Success 200 {object} common.Response{data=systemModel.AppTemplate} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/apps [post]
+func CreateAppTemplate(c *gin.Context) {
+	var req AppTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var count int64
+	global.APP_DB.Model(&systemModel.AppTemplate{}).Where("slug = ?", req.Slug).Count(&count)
+	if count > 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "应用标识已存在"))
+		return
+	}
+
+	app := systemModel.AppTemplate{Status: 1}
+	req.apply(&app)
+	if err := global.APP_DB.Create(&app).Error; err != nil {
+		global.APP_LOG.Error("创建应用失败", zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建应用失败"))
+		return
+	}
+	common.ResponseSuccess(c, app, "创建成功")
+}
+
+// UpdateAppTemplate 更新一键应用
+// @Summary 更新一键应用
+// @Description 更新应用模板的安装脚本、端口、资源要求或上架状态
+// @Tags 应用市场管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "应用ID"
+// @Param request body AppTemplateRequest true "应用参数"
+// @Success 200 {object} common.Response{data=systemModel.AppTemplate} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "应用不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/apps/{id} [put]
+func UpdateAppTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的应用ID"))
+		return
+	}
+
+	var req AppTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var app systemModel.AppTemplate
+	if err := global.APP_DB.First(&app, id).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "应用不存在"))
+		return
+	}
+
+	var count int64
+	global.APP_DB.Model(&systemModel.AppTemplate{}).Where("slug = ? AND id <> ?", req.Slug, id).Count(&count)
+	if count > 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "应用标识已存在"))
+		return
+	}
+
+	req.apply(&app)
+	if err := global.APP_DB.Save(&app).Error; err != nil {
+		global.APP_LOG.Error("更新应用失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新应用失败"))
+		return
+	}
+	common.ResponseSuccess(c, app, "更新成功")
+}
+
+// DeleteAppTemplate 删除一键应用
+// @Summary 删除一键应用
+// @Description 删除应用模板，已安装该应用的实例不受影响
+// @Tags 应用市场管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "应用ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/apps/{id} [delete]
+func DeleteAppTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的应用ID"))
+		return
+	}
+
+	if err := global.APP_DB.Delete(&systemModel.AppTemplate{}, id).Error; err != nil {
+		global.APP_LOG.Error("删除应用失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除应用失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
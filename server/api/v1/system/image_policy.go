@@ -0,0 +1,179 @@
+package system
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/common"
+	systemModel "oneclickvirt/model/system"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImagePolicyRequest 创建/更新镜像禁用策略请求
+type ImagePolicyRequest struct {
+	Name         string `json:"name" binding:"required,max=128"`
+	Description  string `json:"description" binding:"max=255"`
+	Status       *int   `json:"status" binding:"omitempty,oneof=0 1"`
+	OSType       string `json:"osType" binding:"max=32"`
+	ImageID      uint   `json:"imageId"`
+	InstanceType string `json:"instanceType" binding:"omitempty,oneof=vm container"`
+	ProviderID   uint   `json:"providerId"`
+	MinUserLevel int    `json:"minUserLevel" binding:"min=0"`
+}
+
+// validate 至少需要一个镜像匹配条件，避免误禁用全部镜像
+func (r *ImagePolicyRequest) validate() bool {
+	return r.OSType != "" || r.ImageID != 0 || r.InstanceType != ""
+}
+
+// apply 将请求写入策略模型
+func (r *ImagePolicyRequest) apply(policy *systemModel.ImagePolicy) {
+	policy.Name = r.Name
+	policy.Description = r.Description
+	policy.OSType = r.OSType
+	policy.ImageID = r.ImageID
+	policy.InstanceType = r.InstanceType
+	policy.ProviderID = r.ProviderID
+	policy.MinUserLevel = r.MinUserLevel
+	if r.Status != nil {
+		policy.Status = *r.Status
+	}
+}
+
+// GetImagePolicyList 获取镜像禁用策略列表
+// @Summary 获取镜像禁用策略列表
+// @Description 获取按用户等级或节点限制系统镜像的策略列表
+// @Tags 系统镜像管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param providerId query int false "节点ID"
+// @Success 200 {object} common.Response{data=[]systemModel.ImagePolicy} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/image-policies [get]
+func GetImagePolicyList(c *gin.Context) {
+	db := global.APP_DB.Model(&systemModel.ImagePolicy{})
+	if providerID, err := strconv.ParseUint(c.Query("providerId"), 10, 32); err == nil {
+		db = db.Where("provider_id = ?", providerID)
+	}
+
+	var policies []systemModel.ImagePolicy
+	if err := db.Order("id DESC").Find(&policies).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取镜像禁用策略失败"))
+		return
+	}
+	common.ResponseSuccess(c, policies)
+}
+
+// CreateImagePolicy 创建镜像禁用策略
+// @Summary 创建镜像禁用策略
+// @Description 按操作系统、镜像或实例类型禁用镜像，可限定节点和用户等级（例如小节点不提供Windows虚拟机）
+// @Tags 系统镜像管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ImagePolicyRequest true "策略参数"
+// @Success 200 {object} common.Response{data=systemModel.ImagePolicy} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/image-policies [post]
+func CreateImagePolicy(c *gin.Context) {
+	var req ImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if !req.validate() {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "至少需要指定操作系统、镜像或实例类型中的一项"))
+		return
+	}
+
+	policy := systemModel.ImagePolicy{Status: 1}
+	req.apply(&policy)
+	if userID, err := middleware.GetUserIDFromContext(c); err == nil {
+		policy.CreatedBy = &userID
+	}
+
+	if err := global.APP_DB.Create(&policy).Error; err != nil {
+		global.APP_LOG.Error("创建镜像禁用策略失败", zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建镜像禁用策略失败"))
+		return
+	}
+	common.ResponseSuccess(c, policy, "创建成功")
+}
+
+// UpdateImagePolicy 更新镜像禁用策略
+// @Summary 更新镜像禁用策略
+// @Description 更新镜像禁用策略的匹配条件、作用范围或状态
+// @Tags 系统镜像管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "策略ID"
+// @Param request body ImagePolicyRequest true "策略参数"
+// @Success 200 {object} common.Response{data=systemModel.ImagePolicy} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "策略不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/image-policies/{id} [put]
+func UpdateImagePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的策略ID"))
+		return
+	}
+
+	var req ImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if !req.validate() {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "至少需要指定操作系统、镜像或实例类型中的一项"))
+		return
+	}
+
+	var policy systemModel.ImagePolicy
+	if err := global.APP_DB.First(&policy, id).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "策略不存在"))
+		return
+	}
+	req.apply(&policy)
+
+	if err := global.APP_DB.Save(&policy).Error; err != nil {
+		global.APP_LOG.Error("更新镜像禁用策略失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新镜像禁用策略失败"))
+		return
+	}
+	common.ResponseSuccess(c, policy, "更新成功")
+}
+
+// DeleteImagePolicy 删除镜像禁用策略
+// @Summary 删除镜像禁用策略
+// @Description 删除指定的镜像禁用策略
+// @Tags 系统镜像管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "策略ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/image-policies/{id} [delete]
+func DeleteImagePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的策略ID"))
+		return
+	}
+
+	if err := global.APP_DB.Delete(&systemModel.ImagePolicy{}, id).Error; err != nil {
+		global.APP_LOG.Error("删除镜像禁用策略失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除镜像禁用策略失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
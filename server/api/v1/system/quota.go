@@ -1,9 +1,11 @@
 package system
 
 import (
+	"errors"
 	"oneclickvirt/service/resources"
 	"strconv"
 
+	"oneclickvirt/middleware"
 	"oneclickvirt/model/common"
 
 	"github.com/gin-gonic/gin"
@@ -27,3 +29,63 @@ func GetUserQuotaInfo(c *gin.Context) {
 
 	common.ResponseSuccess(c, quotaInfo, "获取配额信息成功")
 }
+
+// ConsistencyAuditRequest 手动执行一致性审计请求
+type ConsistencyAuditRequest struct {
+	AutoCorrect bool `json:"autoCorrect"` // 是否修正发现的偏差
+}
+
+// GetConsistencyAuditReport 获取最近一次一致性审计结果
+// @Summary 获取最近一次一致性审计结果
+// @Description 返回最近一次（定时或手动）配额与资源计数一致性审计的偏差报告，尚未执行过时data为空
+// @Tags 配额管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=resources.ConsistencyAuditReport} "获取成功"
+// @Router /admin/quota/consistency-audit [get]
+func GetConsistencyAuditReport(c *gin.Context) {
+	common.ResponseSuccess(c, resources.GetConsistencyAuditService().LastReport())
+}
+
+// RunConsistencyAudit 立即执行一致性审计
+// @Summary 立即执行一致性审计
+// @Description 根据实例记录重新计算所有用户的已使用/待确认配额和Provider资源占用，与已记录的计数比较并报告偏差；autoCorrect为true时修正偏差并写入审计日志
+// @Tags 配额管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ConsistencyAuditRequest false "审计参数"
+// @Success 200 {object} common.Response{data=resources.ConsistencyAuditReport} "审计完成"
+// @Failure 409 {object} common.Response "已有审计正在执行"
+// @Failure 500 {object} common.Response "审计失败"
+// @Router /admin/quota/consistency-audit [post]
+func RunConsistencyAudit(c *gin.Context) {
+	var req ConsistencyAuditRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+			return
+		}
+	}
+
+	opts := resources.ConsistencyAuditOptions{
+		Trigger:     "manual",
+		AutoCorrect: req.AutoCorrect,
+	}
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		userID := authCtx.UserID
+		opts.OperatorID = &userID
+		opts.Operator = authCtx.Username
+	}
+
+	report, err := resources.GetConsistencyAuditService().Run(opts)
+	if err != nil {
+		if errors.Is(err, resources.ErrConsistencyAuditRunning) {
+			common.ResponseWithError(c, common.NewError(common.CodeConflict, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, report, "审计完成")
+}
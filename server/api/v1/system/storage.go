@@ -1,9 +1,12 @@
 package system
 
 import (
+	"context"
+	"oneclickvirt/global"
 	"oneclickvirt/service/log"
 	"oneclickvirt/service/storage"
 	"strconv"
+	"time"
 
 	"oneclickvirt/model/common"
 
@@ -138,3 +141,38 @@ func (s *StorageApi) CleanupOldLogs(c *gin.Context) {
 	}
 	common.ResponseSuccess(c, "旧日志清理成功")
 }
+
+// CheckObjectStorageHealth 检查对象存储健康状态
+// @Tags System
+// @Summary 检查对象存储健康状态
+// @Description 检查当前配置的对象存储（本地或S3/MinIO）是否可用
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {object} common.Response{data=map[string]interface{}} "success"
+// @Router /api/v1/admin/storage/health [get]
+func (s *StorageApi) CheckObjectStorageHealth(c *gin.Context) {
+	result := gin.H{
+		"type":    global.APP_CONFIG.System.OssType,
+		"healthy": false,
+	}
+
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		result["error"] = err.Error()
+		common.ResponseSuccess(c, result)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := store.HealthCheck(ctx); err != nil {
+		result["error"] = err.Error()
+	} else {
+		result["healthy"] = true
+	}
+	result["type"] = store.Type()
+	result["latencyMs"] = time.Since(start).Milliseconds()
+	common.ResponseSuccess(c, result)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"oneclickvirt/global"
+	"oneclickvirt/middleware"
 	"oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
 	adminInstance "oneclickvirt/service/admin/instance"
@@ -104,6 +105,9 @@ func UpdateProviderStatus(c *gin.Context) {
 	}
 	// 设置ID从URL参数
 	req.ID = uint(id)
+	if authCtx, exists := middleware.GetAuthContext(c); exists {
+		req.OperatorID = authCtx.UserID
+	}
 	providerService := adminProvider.NewService()
 	if err := providerService.UpdateProvider(req); err != nil {
 		c.JSON(http.StatusInternalServerError, common.Response{
@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"oneclickvirt/service/database"
 	"oneclickvirt/service/images"
+	"oneclickvirt/service/storage"
 	"strconv"
 	"strings"
 	"time"
 
 	"oneclickvirt/global"
 	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,7 +27,7 @@ type SystemImageResponse struct {
 // CreateSystemImageRequest 创建系统镜像请求
 type CreateSystemImageRequest struct {
 	Name         string `json:"name" binding:"required"`
-	ProviderType string `json:"providerType" binding:"required,oneof=proxmox lxd incus docker"`
+	ProviderType string `json:"providerType" binding:"required,oneof=proxmox lxd incus docker lxc kubernetes"`
 	InstanceType string `json:"instanceType" binding:"required,oneof=vm container"`
 	Architecture string `json:"architecture" binding:"required,oneof=amd64 arm64 s390x"`
 	URL          string `json:"url" binding:"required,url"`
@@ -38,25 +40,27 @@ type CreateSystemImageRequest struct {
 	MinMemoryMB  int    `json:"minMemoryMB" binding:"required,min=1"`
 	MinDiskMB    int    `json:"minDiskMB" binding:"required,min=1"`
 	UseCDN       bool   `json:"useCdn"`
+	EOLDate      string `json:"eolDate"` // 操作系统EOL日期（YYYY-MM-DD），为空时使用内置的发行版日期
 }
 
 // UpdateSystemImageRequest 更新系统镜像请求
 type UpdateSystemImageRequest struct {
-	Name         string `json:"name"`
-	ProviderType string `json:"providerType" binding:"omitempty,oneof=proxmox lxd incus docker"`
-	InstanceType string `json:"instanceType" binding:"omitempty,oneof=vm container"`
-	Architecture string `json:"architecture" binding:"omitempty,oneof=amd64 arm64 s390x"`
-	URL          string `json:"url" binding:"omitempty,url"`
-	Checksum     string `json:"checksum"`
-	Size         int64  `json:"size"`
-	Status       string `json:"status" binding:"omitempty,oneof=active inactive"`
-	Description  string `json:"description"`
-	OSType       string `json:"osType"`
-	OSVersion    string `json:"osVersion"`
-	Tags         string `json:"tags"`
-	MinMemoryMB  *int   `json:"minMemoryMB" binding:"omitempty,min=1"`
-	MinDiskMB    *int   `json:"minDiskMB" binding:"omitempty,min=1"`
-	UseCDN       *bool  `json:"useCdn"`
+	Name         string  `json:"name"`
+	ProviderType string  `json:"providerType" binding:"omitempty,oneof=proxmox lxd incus docker lxc kubernetes"`
+	InstanceType string  `json:"instanceType" binding:"omitempty,oneof=vm container"`
+	Architecture string  `json:"architecture" binding:"omitempty,oneof=amd64 arm64 s390x"`
+	URL          string  `json:"url" binding:"omitempty,url"`
+	Checksum     string  `json:"checksum"`
+	Size         int64   `json:"size"`
+	Status       string  `json:"status" binding:"omitempty,oneof=active inactive"`
+	Description  string  `json:"description"`
+	OSType       string  `json:"osType"`
+	OSVersion    string  `json:"osVersion"`
+	Tags         string  `json:"tags"`
+	MinMemoryMB  *int    `json:"minMemoryMB" binding:"omitempty,min=1"`
+	MinDiskMB    *int    `json:"minDiskMB" binding:"omitempty,min=1"`
+	UseCDN       *bool   `json:"useCdn"`
+	EOLDate      *string `json:"eolDate"` // 操作系统EOL日期（YYYY-MM-DD），传空字符串清除
 }
 
 // GetSystemImageList 获取系统镜像列表
@@ -175,6 +179,27 @@ func CreateSystemImage(c *gin.Context) {
 		return
 	}
 
+	// 引用分片上传的镜像文件时，替换为可供节点下载的地址
+	if storage.IsUploadReference(req.URL) {
+		downloadURL, checksum, size, err := resolveUploadedImageURL(req.URL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+				"data": nil,
+			})
+			return
+		}
+		req.URL = downloadURL
+		req.UseCDN = false
+		if req.Checksum == "" {
+			req.Checksum = checksum
+		}
+		if req.Size == 0 {
+			req.Size = size
+		}
+	}
+
 	// 验证文件扩展名
 	if err := validateImageURL(req.ProviderType, req.InstanceType, req.URL); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -185,6 +210,16 @@ func CreateSystemImage(c *gin.Context) {
 		return
 	}
 
+	eolDate, err := parseEOLDate(req.EOLDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 400,
+			"msg":  err.Error(),
+			"data": nil,
+		})
+		return
+	}
+
 	// 获取当前用户ID
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -225,6 +260,7 @@ func CreateSystemImage(c *gin.Context) {
 		MinMemoryMB:  req.MinMemoryMB,
 		MinDiskMB:    req.MinDiskMB,
 		UseCDN:       req.UseCDN,
+		EOLDate:      eolDate,
 		CreatedBy:    func() *uint { id := userID.(uint); return &id }(),
 	}
 
@@ -289,6 +325,28 @@ func UpdateSystemImage(c *gin.Context) {
 		return
 	}
 
+	// 引用分片上传的镜像文件时，替换为可供节点下载的地址
+	if storage.IsUploadReference(req.URL) {
+		downloadURL, checksum, size, err := resolveUploadedImageURL(req.URL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+				"data": nil,
+			})
+			return
+		}
+		useCDN := false
+		req.URL = downloadURL
+		req.UseCDN = &useCDN
+		if req.Checksum == "" {
+			req.Checksum = checksum
+		}
+		if req.Size == 0 {
+			req.Size = size
+		}
+	}
+
 	// 验证文件扩展名（如果更新了URL）
 	if req.URL != "" && req.URL != image.URL {
 		providerType := req.ProviderType
@@ -356,6 +414,18 @@ func UpdateSystemImage(c *gin.Context) {
 	if req.UseCDN != nil {
 		updates["use_cdn"] = *req.UseCDN
 	}
+	if req.EOLDate != nil {
+		eolDate, err := parseEOLDate(*req.EOLDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code": 400,
+				"msg":  err.Error(),
+				"data": nil,
+			})
+			return
+		}
+		updates["eol_date"] = eolDate
+	}
 	updates["updated_at"] = time.Now()
 
 	if err := global.APP_DB.Model(&image).Updates(updates).Error; err != nil {
@@ -509,6 +579,8 @@ func GetAvailableSystemImages(c *gin.Context) {
 		})
 		return
 	}
+	// 公开目录无法得知用户和节点，仅排除对所有用户和节点禁用的镜像
+	images = imageService.FilterBannedImages(images, 0, 0)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
@@ -517,6 +589,32 @@ func GetAvailableSystemImages(c *gin.Context) {
 	})
 }
 
+// resolveUploadedImageURL 解析 upload://<uuid> 引用，返回下载地址、校验和与文件大小
+func resolveUploadedImageURL(ref string) (string, string, int64, error) {
+	session, err := storage.GetUploadService().ResolveReference(ref, systemModel.UploadPurposeImage)
+	if err != nil {
+		return "", "", 0, err
+	}
+	downloadURL, err := storage.ArtifactDownloadURL(session)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return downloadURL, session.Checksum, session.TotalSize, nil
+}
+
+// parseEOLDate 解析EOL日期，空字符串返回nil
+func parseEOLDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, utils.DefaultLocation())
+	if err != nil {
+		return nil, fmt.Errorf("EOL日期格式错误，应为YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
 // validateImageURL 验证镜像URL的文件扩展名
 func validateImageURL(providerType, instanceType, url string) error {
 	switch providerType {
@@ -535,6 +633,20 @@ func validateImageURL(providerType, instanceType, url string) error {
 		if instanceType == "container" && !strings.HasSuffix(url, ".tar.gz") {
 			return fmt.Errorf("Docker容器镜像地址必须是.tar.gz文件")
 		}
+	case "lxc":
+		if instanceType != "container" {
+			return fmt.Errorf("LXC只支持容器镜像")
+		}
+		if !strings.HasSuffix(url, ".tar.xz") && !strings.HasSuffix(url, ".tar.gz") {
+			return fmt.Errorf("LXC容器镜像地址必须是.tar.xz或.tar.gz格式的根文件系统")
+		}
+	case "kubernetes":
+		if instanceType != "container" {
+			return fmt.Errorf("Kubernetes只支持容器镜像")
+		}
+		if !strings.HasPrefix(url, "oci://") {
+			return fmt.Errorf("Kubernetes镜像地址必须是 oci://<仓库>/<镜像>:<标签> 格式")
+		}
 	}
 	return nil
 }
@@ -1,3 +1,240 @@
 package system
 
 // 头像上传功能已移除，统一使用默认头像
+// 此处仅提供自定义镜像、恢复归档等大文件的分片上传（断点续传）接口
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"oneclickvirt/middleware"
+	"oneclickvirt/model/common"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/service/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setUploadHeaders 设置与tus协议类似的上传进度响应头
+func setUploadHeaders(c *gin.Context, offset, length int64) {
+	c.Header("Upload-Offset", strconv.FormatInt(offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(length, 10))
+	c.Header("Cache-Control", "no-store")
+}
+
+// CreateUploadSession 创建分片上传会话
+// @Summary 创建分片上传会话
+// @Description 为自定义镜像或恢复归档等大文件创建可断点续传的上传会话
+// @Tags 文件上传
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body storage.CreateUploadRequest true "上传会话参数"
+// @Success 200 {object} common.Response{data=system.UploadSession} "创建成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/uploads [post]
+func CreateUploadSession(c *gin.Context) {
+	var req storage.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "参数错误: "+err.Error()))
+		return
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	session, err := storage.GetUploadService().CreateSession(userID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	setUploadHeaders(c, session.UploadedSize, session.TotalSize)
+	common.ResponseSuccess(c, session, "上传会话创建成功")
+}
+
+// GetUploadSessionList 获取上传会话列表
+// @Summary 获取上传会话列表
+// @Description 分页获取分片上传会话，可按用途和状态过滤
+// @Tags 文件上传
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param purpose query string false "用途" Enums(image,backup)
+// @Param status query string false "状态" Enums(uploading,completed,failed,cancelled,expired)
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /admin/uploads [get]
+func GetUploadSessionList(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	sessions, total, err := storage.GetUploadService().ListSessions(c.Query("purpose"), c.Query("status"), page, pageSize)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeDatabaseError, err.Error()))
+		return
+	}
+	common.ResponseSuccessWithPagination(c, sessions, total, page, pageSize)
+}
+
+// GetUploadSession 获取上传会话进度
+// @Summary 获取上传会话进度
+// @Description 获取上传会话状态，响应头 Upload-Offset 为下一个分片的起始偏移量，可用于断点续传
+// @Tags 文件上传
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "上传会话UUID"
+// @Success 200 {object} common.Response{data=system.UploadSession} "获取成功"
+// @Failure 404 {object} common.Response "上传会话不存在"
+// @Router /admin/uploads/{uuid} [get]
+func GetUploadSession(c *gin.Context) {
+	session, err := storage.GetUploadService().GetSession(c.Param("uuid"))
+	if err != nil {
+		if errors.Is(err, storage.ErrUploadSessionNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeDatabaseError, err.Error()))
+		return
+	}
+	setUploadHeaders(c, session.UploadedSize, session.TotalSize)
+	// HEAD 请求只返回进度响应头
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	common.ResponseSuccess(c, session)
+}
+
+// UploadChunk 上传分片
+// @Summary 上传分片
+// @Description 以原始二进制请求体上传一个分片，Upload-Offset 请求头必须等于服务端已接收的大小；可通过 Upload-Checksum 请求头传入分片SHA256（十六进制）进行校验
+// @Tags 文件上传
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "上传会话UUID"
+// @Param Upload-Offset header int true "分片起始偏移量"
+// @Param Upload-Checksum header string false "分片SHA256"
+// @Success 200 {object} common.Response{data=system.UploadSession} "上传成功"
+// @Failure 400 {object} common.Response "请求参数错误"
+// @Failure 409 {object} common.Response "偏移量不匹配"
+// @Router /admin/uploads/{uuid} [patch]
+func UploadChunk(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "缺少或无效的 Upload-Offset 请求头"))
+		return
+	}
+
+	session, err := storage.GetUploadService().AppendChunk(c.Param("uuid"), offset, c.Request.Body, c.GetHeader("Upload-Checksum"))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUploadSessionNotFound):
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		case errors.Is(err, storage.ErrUploadOffsetMismatch):
+			// 返回服务端当前偏移量，客户端据此续传
+			setUploadHeaders(c, session.UploadedSize, session.TotalSize)
+			common.ResponseWithError(c, common.NewError(common.CodeConflict, err.Error()))
+		default:
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		}
+		return
+	}
+	setUploadHeaders(c, session.UploadedSize, session.TotalSize)
+	common.ResponseSuccess(c, session)
+}
+
+// CompleteUploadSession 完成上传
+// @Summary 完成上传
+// @Description 校验整个文件的SHA256并归档，完成后可通过 upload://{uuid} 在镜像等功能中引用
+// @Tags 文件上传
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "上传会话UUID"
+// @Success 200 {object} common.Response{data=system.UploadSession} "上传完成"
+// @Failure 400 {object} common.Response "文件未上传完成或校验失败"
+// @Failure 404 {object} common.Response "上传会话不存在"
+// @Router /admin/uploads/{uuid}/complete [post]
+func CompleteUploadSession(c *gin.Context) {
+	session, err := storage.GetUploadService().CompleteSession(c.Param("uuid"))
+	if err != nil {
+		if errors.Is(err, storage.ErrUploadSessionNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, gin.H{
+		"session":   session,
+		"reference": session.Reference(),
+	}, "上传完成")
+}
+
+// CancelUploadSession 取消上传并删除文件
+// @Summary 取消上传
+// @Description 取消上传会话并删除已上传的数据，已完成的文件也会被删除
+// @Tags 文件上传
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param uuid path string true "上传会话UUID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 404 {object} common.Response "上传会话不存在"
+// @Router /admin/uploads/{uuid} [delete]
+func CancelUploadSession(c *gin.Context) {
+	if err := storage.GetUploadService().CancelSession(c.Param("uuid")); err != nil {
+		if errors.Is(err, storage.ErrUploadSessionNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// DownloadArtifact 下载已完成上传的镜像文件
+// @Summary 下载上传的镜像文件
+// @Description 供Provider节点拉取通过分片上传的自定义镜像，仅开放用途为image且已完成校验的文件
+// @Tags 文件上传
+// @Produce application/octet-stream
+// @Param uuid path string true "上传会话UUID"
+// @Param filename path string true "文件名"
+// @Success 200 {file} binary "文件内容"
+// @Failure 404 {object} common.Response "文件不存在"
+// @Router /public/artifacts/{uuid}/{filename} [get]
+func DownloadArtifact(c *gin.Context) {
+	session, err := storage.GetUploadService().ResolveReference(systemModel.UploadReferencePrefix+c.Param("uuid"), systemModel.UploadPurposeImage)
+	if err != nil || session.FileName != c.Param("filename") {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+		return
+	}
+	reader, err := storage.GetUploadService().OpenArtifact(c.Request.Context(), session)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "文件不存在"))
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, session.TotalSize, "application/octet-stream", reader, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, session.FileName),
+		"X-Checksum-Sha256":   session.Checksum,
+	})
+}
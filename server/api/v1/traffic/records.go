@@ -0,0 +1,195 @@
+package traffic
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/monitoring"
+	"oneclickvirt/service/traffic"
+	"oneclickvirt/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// parseRecordTime 解析时间参数，支持 RFC3339 和 2006-01-02 两种格式
+func parseRecordTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, utils.DefaultLocation())
+	if err != nil {
+		return nil, fmt.Errorf("时间格式错误: %s", value)
+	}
+	return &t, nil
+}
+
+// parseRecordQuery 从请求参数解析原始流量记录查询条件
+func parseRecordQuery(c *gin.Context) (traffic.RecordQuery, error) {
+	var q traffic.RecordQuery
+	for name, target := range map[string]*uint{
+		"instance_id": &q.InstanceID,
+		"user_id":     &q.UserID,
+		"provider_id": &q.ProviderID,
+	} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return q, fmt.Errorf("%s格式错误", name)
+			}
+			*target = uint(id)
+		}
+	}
+
+	var err error
+	if q.StartTime, err = parseRecordTime(c.Query("start")); err != nil {
+		return q, err
+	}
+	if q.EndTime, err = parseRecordTime(c.Query("end")); err != nil {
+		return q, err
+	}
+	if q.StartTime != nil && q.EndTime != nil && !q.StartTime.Before(*q.EndTime) {
+		return q, fmt.Errorf("开始时间必须早于结束时间")
+	}
+
+	q.Cursor = c.Query("cursor")
+	if _, err := common.DecodeIDCursor(q.Cursor); err != nil {
+		return q, err
+	}
+	if value := c.Query("pageSize"); value != "" {
+		if q.PageSize, err = strconv.Atoi(value); err != nil {
+			return q, fmt.Errorf("pageSize格式错误")
+		}
+	}
+	return q, nil
+}
+
+// GetTrafficRecords 游标分页查询原始流量记录
+// @Summary 查询原始流量记录
+// @Description 按实例、用户、Provider和时间范围查询pmacct原始流量记录，使用游标分页，不返回总数
+// @Tags 管理员流量
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param instance_id query int false "实例ID"
+// @Param user_id query int false "用户ID"
+// @Param provider_id query int false "Provider ID"
+// @Param start query string false "开始时间（含），RFC3339或YYYY-MM-DD"
+// @Param end query string false "结束时间（不含），RFC3339或YYYY-MM-DD"
+// @Param cursor query string false "翻页游标，取自上一页响应的 nextCursor"
+// @Param pageSize query int false "每页数量，默认50，最大500"
+// @Success 200 {object} common.Response{data=traffic.RecordPage}
+// @Router /api/v1/admin/traffic/records [get]
+func (api *AdminTrafficAPI) GetTrafficRecords(c *gin.Context) {
+	q, err := parseRecordQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 40000,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	page, err := traffic.NewRecordService().ListRecords(q)
+	if err != nil {
+		global.APP_LOG.Error("查询原始流量记录失败", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, common.Response{
+			Code: 50000,
+			Msg:  "查询原始流量记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, common.Response{
+		Code: 0,
+		Msg:  "查询原始流量记录成功",
+		Data: page,
+	})
+}
+
+// ExportTrafficRecords 流式导出原始流量记录为CSV
+// @Summary 导出原始流量记录
+// @Description 按条件流式导出pmacct原始流量记录为CSV，分批查询并逐批写出，不在内存中缓存全部结果
+// @Tags 管理员流量
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param instance_id query int false "实例ID"
+// @Param user_id query int false "用户ID"
+// @Param provider_id query int false "Provider ID"
+// @Param start query string false "开始时间（含），RFC3339或YYYY-MM-DD"
+// @Param end query string false "结束时间（不含），RFC3339或YYYY-MM-DD"
+// @Success 200 {file} file "CSV文件"
+// @Router /api/v1/admin/traffic/records/export [get]
+func (api *AdminTrafficAPI) ExportTrafficRecords(c *gin.Context) {
+	q, err := parseRecordQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, common.Response{
+			Code: 40000,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("traffic_records_%s.csv", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	_ = writer.Write([]string{
+		"id", "instance_id", "user_id", "provider_id", "provider_type", "mapped_ip",
+		"rx_bytes", "tx_bytes", "total_bytes", "timestamp", "record_time",
+		"rx_bytes_v6", "tx_bytes_v6",
+	})
+
+	var rows int
+	err = traffic.NewRecordService().StreamRecords(q, func(records []monitoring.PmacctTrafficRecord) error {
+		for _, r := range records {
+			if err := writer.Write([]string{
+				strconv.FormatUint(uint64(r.ID), 10),
+				strconv.FormatUint(uint64(r.InstanceID), 10),
+				strconv.FormatUint(uint64(r.UserID), 10),
+				strconv.FormatUint(uint64(r.ProviderID), 10),
+				r.ProviderType,
+				r.MappedIP,
+				strconv.FormatInt(r.RxBytes, 10),
+				strconv.FormatInt(r.TxBytes, 10),
+				strconv.FormatInt(r.TotalBytes, 10),
+				r.Timestamp.Format(time.RFC3339),
+				r.RecordTime.Format(time.RFC3339),
+				strconv.FormatInt(r.RxBytesV6, 10),
+				strconv.FormatInt(r.TxBytesV6, 10),
+			}); err != nil {
+				return err
+			}
+		}
+		rows += len(records)
+		// 每批写出后立即刷新，客户端可边下载边接收
+		writer.Flush()
+		c.Writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		// 客户端断开后停止查询
+		return c.Request.Context().Err()
+	})
+	writer.Flush()
+
+	if err != nil {
+		// 响应头已发送，只能记录日志
+		global.APP_LOG.Error("导出原始流量记录中断",
+			zap.Int("rows", rows),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("导出原始流量记录完成", zap.Int("rows", rows))
+}
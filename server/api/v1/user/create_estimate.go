@@ -0,0 +1,57 @@
+package user
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/createestimate"
+	"oneclickvirt/service/instancedefaults"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetCreateInstanceEstimate 估算创建实例的等待时间
+// @Summary 估算创建实例的等待时间
+// @Description 按节点当前的任务队列、节点近期的创建耗时和镜像是否已在节点上，估算新建实例的排队、镜像下载和创建耗时。未填写的节点和镜像使用实例默认设置
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param providerId query int false "节点ID"
+// @Param imageId query int false "镜像ID"
+// @Success 200 {object} common.Response{data=userModel.CreateEstimateResponse} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/instances/create-estimate [get]
+func GetCreateInstanceEstimate(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req userModel.CreateEstimateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+
+	createReq := userModel.CreateInstanceRequest{ProviderId: req.ProviderID, ImageId: req.ImageID}
+	if err := instancedefaults.NewService().ApplyToRequest(userID, &createReq); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+
+	estimate, err := createestimate.Estimate(createReq.ProviderId, createReq.ImageId)
+	if err != nil {
+		if errors.Is(err, createestimate.ErrProviderUnavailable) || errors.Is(err, createestimate.ErrImageUnavailable) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		global.APP_LOG.Error("估算创建实例等待时间失败", zap.Uint("userID", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "估算失败"))
+		return
+	}
+	common.ResponseSuccess(c, estimate)
+}
@@ -2,6 +2,10 @@ package user
 
 import (
 	"errors"
+	"fmt"
+	"oneclickvirt/service/apiquota"
+	"oneclickvirt/service/instancedefaults"
+	"oneclickvirt/service/instancegroup"
 	"oneclickvirt/service/pmacct"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/task"
@@ -9,6 +13,7 @@ import (
 
 	"oneclickvirt/global"
 	"oneclickvirt/middleware"
+	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/common"
 	"oneclickvirt/model/resource"
 	"oneclickvirt/model/user"
@@ -148,9 +153,13 @@ func ClaimResource(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param status query string false "实例状态"
 // @Param type query string false "实例类型"
 // @Param providerName query string false "节点名称"
+// @Param live query bool false "是否在时限内从节点获取实时状态和IP，取自节点的字段见返回的 live"
 // @Success 200 {object} common.Response{data=object} "获取成功"
 // @Failure 400 {object} common.Response "参数错误"
 // @Failure 401 {object} common.Response "用户未登录"
@@ -168,10 +177,15 @@ func GetUserInstances(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
 		return
 	}
+	req.Normalize()
 
 	userServiceInstance := userService.NewService()
 	instances, total, err := userServiceInstance.GetUserInstances(userID, req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取实例列表失败"))
 		return
 	}
@@ -190,6 +204,7 @@ func GetUserInstances(c *gin.Context) {
 // @Success 200 {object} common.Response "操作成功"
 // @Failure 400 {object} common.Response "参数错误"
 // @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "同时运行的实例数已达上限"
 // @Failure 500 {object} common.Response "操作失败"
 // @Router /user/instances/action [post]
 func InstanceAction(c *gin.Context) {
@@ -215,6 +230,10 @@ func InstanceAction(c *gin.Context) {
 
 	userServiceInstance := userService.NewService()
 	err = userServiceInstance.InstanceAction(userID, req)
+	if errors.Is(err, resources.ErrRunningLimitExceeded) {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+		return
+	}
 	if err != nil {
 		global.APP_LOG.Error("用户实例操作失败",
 			zap.Uint("userID", userID),
@@ -257,6 +276,14 @@ func UpdateProfile(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
 		return
 	}
+	if req.Timezone != nil {
+		timezone, err := utils.NormalizeTimezone(*req.Timezone)
+		if err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		req.Timezone = &timezone
+	}
 
 	userServiceInstance := userService.NewService()
 	err = userServiceInstance.UpdateProfile(userID, req)
@@ -385,6 +412,7 @@ func GetUserLimits(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param architecture query string false "CPU架构" Enums(amd64,arm64)
 // @Success 200 {object} common.Response{data=[]user.AvailableProviderResponse} "获取成功"
 // @Failure 401 {object} common.Response "用户未登录"
 // @Failure 500 {object} common.Response "服务器内部错误"
@@ -397,7 +425,7 @@ func GetAvailableProviders(c *gin.Context) {
 	}
 
 	userServiceInstance := userService.NewService()
-	providers, err := userServiceInstance.GetAvailableProviders(userID)
+	providers, err := userServiceInstance.GetAvailableProviders(userID, c.Query("architecture"))
 	if err != nil {
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取可用节点失败"))
 		return
@@ -543,6 +571,9 @@ func GetProviderCapabilities(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param pageSize query int false "每页数量" default(10)
+// @Param sort query string false "排序字段，逗号分隔，前缀-表示降序，如 -createdAt"
+// @Param filter query []string false "过滤条件，格式 字段:操作符:值，操作符支持 eq ne gt gte lt lte like in null" collectionFormat(multi)
+// @Param pageToken query string false "翻页令牌，取自上一页响应的 nextPageToken"
 // @Param taskType query string false "任务类型"
 // @Param status query string false "任务状态"
 // @Param providerId query string false "节点ID"
@@ -562,10 +593,15 @@ func GetUserTasks(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
 		return
 	}
+	req.Normalize()
 
 	userServiceInstance := userService.NewService()
 	tasks, total, err := userServiceInstance.GetUserTasks(userID, req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidListQuery) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取任务列表失败"))
 		return
 	}
@@ -573,6 +609,49 @@ func GetUserTasks(c *gin.Context) {
 	common.ResponseSuccessWithPagination(c, tasks, total, req.Page, req.PageSize)
 }
 
+// GetUserTaskHistory 获取用户任务历史
+// @Summary 获取用户任务历史
+// @Description 获取当前用户的实例操作历史，包含本地化的状态摘要、耗时、失败原因说明和实例链接，不包含管理员操作和系统内部任务
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param instanceId query int false "实例ID"
+// @Param status query string false "任务状态"
+// @Param lang query string false "语言，zh-CN 或 en-US，默认取 Accept-Language"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/tasks/history [get]
+func GetUserTaskHistory(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.UserTaskHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+		return
+	}
+	req.Normalize()
+	if req.Lang == "" {
+		req.Lang = c.GetHeader("Accept-Language")
+	}
+
+	userServiceInstance := userService.NewService()
+	entries, total, err := userServiceInstance.GetUserTaskHistory(userID, req)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取任务历史失败"))
+		return
+	}
+
+	common.ResponseSuccessWithPagination(c, entries, total, req.Page, req.PageSize)
+}
+
 // CancelUserTask 取消用户任务
 // @Summary 取消用户任务
 // @Description 用户取消自己的等待中任务
@@ -609,6 +688,40 @@ func CancelUserTask(c *gin.Context) {
 	common.ResponseSuccess(c, nil, "任务已取消")
 }
 
+// GetUserTaskEvents 订阅任务进度
+// @Summary 订阅任务进度
+// @Description 以SSE推送任务的进度和阶段信息：连接后推送snapshot事件，进度变化推送progress事件，任务结束推送done事件后断开；EventSource无法设置请求头时可通过token查询参数认证
+// @Tags 用户管理
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param taskId path int true "任务ID"
+// @Success 200 {string} string "SSE事件流"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "任务不存在"
+// @Router /user/tasks/{taskId}/events [get]
+func GetUserTaskEvents(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的任务ID"))
+		return
+	}
+
+	var taskInfo adminModel.Task
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", taskID, userID).First(&taskInfo).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "任务不存在"))
+		return
+	}
+
+	task.StreamTaskEvents(c, &taskInfo)
+}
+
 // CreateUserInstance 创建实例
 // @Summary 创建实例
 // @Description 用户创建新的虚拟机或容器实例（异步处理）
@@ -635,10 +748,20 @@ func CreateUserInstance(c *gin.Context) {
 		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
 		return
 	}
+	tags, err := instancegroup.NormalizeTags(req.Tags)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	req.Tags = tags
 
 	userServiceInstance := userService.NewService()
 	task, err := userServiceInstance.CreateUserInstance(userID, req)
 	if err != nil {
+		if errors.Is(err, instancedefaults.ErrProviderRequired) || errors.Is(err, instancedefaults.ErrImageRequired) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
 		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
 		return
 	}
@@ -650,10 +773,73 @@ func CreateUserInstance(c *gin.Context) {
 		"message":    "实例创建任务已提交，正在后台处理",
 		"created_at": task.CreatedAt,
 	}
+	if task.ProviderID != nil {
+		responseData["providerId"] = *task.ProviderID
+	}
 
 	common.ResponseSuccess(c, responseData, "实例创建任务已提交")
 }
 
+// BatchCreateUserInstances 批量创建实例
+// @Summary 批量创建实例
+// @Description 用相同的镜像和规格创建多个实例（异步处理）。未指定节点且 spread 为 provider 或 region 时，每个实例优先放到尚无自己实例的节点或地区，无法满足时选择实例最少的节点。遇到失败时停止提交，已提交的任务不受影响
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.BatchCreateInstanceRequest true "批量创建实例请求参数"
+// @Success 200 {object} common.Response{data=[]user.BatchCreateInstanceResult} "任务提交结果"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "创建失败"
+// @Router /user/instances/batch [post]
+func BatchCreateUserInstances(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req user.BatchCreateInstanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	tags, err := instancegroup.NormalizeTags(req.Tags)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	req.Tags = tags
+	// 批量创建按实例数量扣减创建配额
+	if !middleware.CheckAPIQuota(c, apiquota.ActionInstanceCreate, req.Count) {
+		return
+	}
+
+	results, err := userService.NewService().BatchCreateUserInstances(userID, req)
+	if err != nil {
+		if errors.Is(err, instancedefaults.ErrProviderRequired) || errors.Is(err, instancedefaults.ErrImageRequired) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, results, fmt.Sprintf("已提交 %d 个实例创建任务", countSubmitted(results)))
+}
+
+// countSubmitted 统计批量创建中已提交的任务数
+func countSubmitted(results []user.BatchCreateInstanceResult) int {
+	n := 0
+	for _, r := range results {
+		if r.TaskID != 0 {
+			n++
+		}
+	}
+	return n
+}
+
 // GetInstanceTypePermissions 获取实例类型权限配置
 // @Summary 获取实例类型权限配置
 // @Description 获取当前用户可以创建的实例类型权限配置，基于用户配额和Provider能力
@@ -690,6 +876,7 @@ func GetInstanceTypePermissions(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "实例ID"
+// @Param live query bool false "是否在时限内从节点获取实时状态和IP，取自节点的字段见返回的 live"
 // @Success 200 {object} common.Response{data=user.UserInstanceDetailResponse} "获取成功"
 // @Failure 401 {object} common.Response "用户未登录"
 // @Failure 403 {object} common.Response "实例不存在或无权限"
@@ -710,7 +897,12 @@ func GetUserInstanceDetail(c *gin.Context) {
 	}
 
 	userServiceInstance := userService.NewService()
-	detail, err := userServiceInstance.GetInstanceDetail(userID, uint(instanceID))
+	var detail *user.UserInstanceDetailResponse
+	if live, _ := strconv.ParseBool(c.Query("live")); live {
+		detail, err = userServiceInstance.GetInstanceDetailLive(userID, uint(instanceID))
+	} else {
+		detail, err = userServiceInstance.GetInstanceDetail(userID, uint(instanceID))
+	}
 	if err != nil {
 		if err.Error() == "实例不存在" {
 			common.ResponseWithError(c, common.NewError(common.CodeForbidden, "实例不存在或无权限"))
@@ -723,6 +915,53 @@ func GetUserInstanceDetail(c *gin.Context) {
 	common.ResponseSuccess(c, detail)
 }
 
+// GetInstanceConnectionBundle 获取实例连接信息包
+// @Summary 获取实例连接信息包
+// @Description 返回可直接使用的连接信息：ssh命令、ssh config片段、PuTTY命令、端口映射表、IPv6地址和二维码内容，按语言本地化
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param lang query string false "语言：zh-CN 或 en-US，默认取 Accept-Language，再回退到系统默认语言"
+// @Success 200 {object} common.Response{data=user.InstanceConnectionBundle} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "实例不存在或无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/connection-bundle [get]
+func GetInstanceConnectionBundle(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "无效的实例ID"))
+		return
+	}
+
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+
+	userServiceInstance := userService.NewService()
+	bundle, err := userServiceInstance.GetInstanceConnectionBundle(userID, uint(instanceID), lang)
+	if err != nil {
+		if err.Error() == "实例不存在" {
+			common.ResponseWithError(c, common.NewError(common.CodeForbidden, "实例不存在或无权限"))
+			return
+		}
+		global.APP_LOG.Error("获取实例连接信息失败", zap.Uint("instanceID", uint(instanceID)), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取实例连接信息失败"))
+		return
+	}
+
+	common.ResponseSuccess(c, bundle)
+}
+
 // GetInstanceConfig 获取实例配置选项
 // @Summary 获取实例配置选项
 // @Description 获取可用的镜像、规格等实例创建配置选项
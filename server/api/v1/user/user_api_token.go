@@ -0,0 +1,120 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	authService "oneclickvirt/service/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateAPITokenRequest 创建API令牌请求
+type CreateAPITokenRequest struct {
+	Name          string `json:"name" binding:"required,max=64"`         // 令牌名称
+	ExpiresInDays int    `json:"expiresInDays" binding:"min=0,max=3650"` // 有效天数，0表示长期有效
+}
+
+// CreateAPITokenResponse 创建API令牌响应
+type CreateAPITokenResponse struct {
+	userModel.UserAPIToken
+	Token string `json:"token"` // 令牌明文，仅在创建时返回一次
+}
+
+// GetUserAPITokens 获取API令牌列表
+// @Summary 获取API令牌列表
+// @Description 获取当前用户的个人API令牌，不包含令牌明文
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]userModel.UserAPIToken} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/api-tokens [get]
+func GetUserAPITokens(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	service := authService.APITokenService{}
+	tokens, err := service.ListTokens(userID)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取API令牌失败"))
+		return
+	}
+	common.ResponseSuccess(c, tokens)
+}
+
+// CreateUserAPIToken 创建API令牌
+// @Summary 创建API令牌
+// @Description 创建用于命令行客户端和脚本的个人API令牌，以 Authorization: Bearer ocv_xxx 方式认证，拥有与当前用户相同的权限；令牌明文只在本次响应中返回
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAPITokenRequest true "令牌参数"
+// @Success 200 {object} common.Response{data=CreateAPITokenResponse} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Router /user/api-tokens [post]
+func CreateUserAPIToken(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := authService.APITokenService{}
+	plain, token, err := service.CreateToken(userID, req.Name, req.ExpiresInDays)
+	if err != nil {
+		global.APP_LOG.Warn("创建API令牌失败", zap.Uint("userID", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, CreateAPITokenResponse{UserAPIToken: *token, Token: plain}, "创建成功")
+}
+
+// DeleteUserAPIToken 撤销API令牌
+// @Summary 撤销API令牌
+// @Description 删除个人API令牌，使用该令牌的请求立即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "令牌ID"
+// @Success 200 {object} common.Response "撤销成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 404 {object} common.Response "令牌不存在"
+// @Router /user/api-tokens/{id} [delete]
+func DeleteUserAPIToken(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "令牌ID格式错误"))
+		return
+	}
+
+	service := authService.APITokenService{}
+	if err := service.DeleteToken(userID, uint(tokenID)); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "撤销成功")
+}
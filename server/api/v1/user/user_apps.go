@@ -0,0 +1,119 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/service/admin/instance"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AppCatalogItem 应用市场条目（不包含安装脚本）
+type AppCatalogItem struct {
+	ID           uint                  `json:"id"`
+	Slug         string                `json:"slug"`
+	Name         string                `json:"name"`
+	Category     string                `json:"category"`
+	Description  string                `json:"description"`
+	Icon         string                `json:"icon"`
+	InstanceType string                `json:"instanceType"`
+	OSTypes      string                `json:"osTypes"`
+	MinCPU       int                   `json:"minCpu"`
+	MinMemoryMB  int                   `json:"minMemoryMB"`
+	MinDiskMB    int                   `json:"minDiskMB"`
+	Ports        []systemModel.AppPort `json:"ports"`
+}
+
+// GetAppCatalog 获取应用市场列表
+// @Summary 获取应用市场列表
+// @Description 获取可一键安装的应用列表及其资源要求，创建实例时通过 appId 选择
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param category query string false "应用分类"
+// @Success 200 {object} common.Response{data=[]AppCatalogItem} "获取成功"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/apps [get]
+func GetAppCatalog(c *gin.Context) {
+	db := global.APP_DB.Where("status = ?", 1)
+	if category := c.Query("category"); category != "" {
+		db = db.Where("category = ?", category)
+	}
+
+	var apps []systemModel.AppTemplate
+	if err := db.Order("sort ASC, id ASC").Find(&apps).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取应用列表失败"))
+		return
+	}
+
+	items := make([]AppCatalogItem, 0, len(apps))
+	for i := range apps {
+		app := &apps[i]
+		items = append(items, AppCatalogItem{
+			ID:           app.ID,
+			Slug:         app.Slug,
+			Name:         app.Name,
+			Category:     app.Category,
+			Description:  app.Description,
+			Icon:         app.Icon,
+			InstanceType: app.InstanceType,
+			OSTypes:      app.OSTypes,
+			MinCPU:       app.MinCPU,
+			MinMemoryMB:  app.MinMemoryMB,
+			MinDiskMB:    app.MinDiskMB,
+			Ports:        app.ParsePorts(),
+		})
+	}
+	common.ResponseSuccess(c, items)
+}
+
+// GetInstanceApp 获取实例应用安装信息
+// @Summary 获取实例应用安装信息
+// @Description 获取实例上一键应用的安装状态及安装后信息（访问地址、凭据等）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Success 200 {object} common.Response{data=systemModel.InstanceApp} "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Failure 404 {object} common.Response "实例未安装应用"
+// @Router /user/instances/{id}/app [get]
+func GetInstanceApp(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "实例ID格式错误"))
+		return
+	}
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	// 验证实例是否属于当前用户
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return
+	}
+	if inst.UserID != userID {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "无权限访问此实例"))
+		return
+	}
+
+	var instanceApp systemModel.InstanceApp
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).First(&instanceApp).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例未安装应用"))
+		return
+	}
+	common.ResponseSuccess(c, instanceApp)
+}
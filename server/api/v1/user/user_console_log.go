@@ -0,0 +1,76 @@
+package user
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInstanceConsoleLog 获取虚拟机控制台日志
+// @Summary 获取虚拟机控制台日志
+// @Description 读取自己名下虚拟机串口控制台日志的最后N KB，用于排查启动失败、网络未就绪等问题
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Param kb query int false "返回最后多少KB，默认64，最大512"
+// @Success 200 {object} common.Response "获取成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Router /user/instances/{id}/console-log [get]
+func GetInstanceConsoleLog(c *gin.Context) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "实例ID格式错误"))
+		return
+	}
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	// 验证实例是否属于当前用户
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return
+	}
+	if inst.UserID != userID {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "无权限访问此实例"))
+		return
+	}
+
+	kb, _ := strconv.Atoi(c.DefaultQuery("kb", "64"))
+	if kb <= 0 {
+		kb = 64
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	providerApiService := &provider.ProviderApiService{}
+	content, err := providerApiService.GetInstanceConsoleLog(ctx, inst, kb*1024)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	common.ResponseSuccess(c, gin.H{
+		"instanceId": inst.ID,
+		"name":       inst.Name,
+		"content":    content,
+		"size":       len(content),
+	})
+}
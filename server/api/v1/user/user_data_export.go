@@ -0,0 +1,101 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/dataexport"
+	"oneclickvirt/service/task"
+	"oneclickvirt/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateDataExport 发起用户数据导出
+// @Summary 发起数据导出
+// @Description 异步打包当前用户的实例配置、端口映射、流量历史和任务记录，生成完成后可通过有效期内的下载链接获取压缩包
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=userModel.DataExport} "已提交导出任务"
+// @Failure 400 {object} common.Response "已有进行中的导出或导出过于频繁"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/data-exports [post]
+func CreateDataExport(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	export, err := dataexport.Create(userID)
+	if err != nil {
+		if errors.Is(err, dataexport.ErrExportInProgress) || errors.Is(err, dataexport.ErrExportTooFrequent) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		global.APP_LOG.Error("创建数据导出失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建数据导出失败"))
+		return
+	}
+
+	taskData, _ := json.Marshal(adminModel.ExportDataTaskRequest{ExportID: export.ID})
+	taskService := task.GetTaskService()
+	exportTask, err := taskService.CreateTask(userID, nil, nil, adminModel.TaskTypeExportData, string(taskData), utils.GetDefaultTaskTimeout(adminModel.TaskTypeExportData))
+	if err != nil {
+		dataexport.MarkFailed(export.ID, err.Error())
+		global.APP_LOG.Error("创建数据导出任务失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建数据导出任务失败"))
+		return
+	}
+	if err := dataexport.AttachTask(export.ID, exportTask.ID); err != nil {
+		global.APP_LOG.Warn("记录导出任务ID失败", zap.Uint("exportId", export.ID), zap.Error(err))
+	}
+	export.TaskID = exportTask.ID
+
+	// 启动失败时任务保持pending，由任务调度器稍后重试
+	if err := taskService.StartTask(exportTask.ID); err != nil {
+		global.APP_LOG.Warn("启动数据导出任务失败，等待调度器重试", zap.Uint("taskId", exportTask.ID), zap.Error(err))
+	}
+	common.ResponseSuccess(c, export, "导出任务已提交")
+}
+
+// GetDataExports 获取数据导出记录
+// @Summary 获取数据导出记录
+// @Description 获取当前用户的数据导出记录，已生成且未过期的记录返回下载地址
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]userModel.DataExport} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/data-exports [get]
+func GetDataExports(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var exports []userModel.DataExport
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(20).Find(&exports).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取导出记录失败"))
+		return
+	}
+	now := time.Now()
+	for i := range exports {
+		if exports[i].IsDownloadable(now) {
+			exports[i].DownloadURL = dataexport.DownloadPath(&exports[i])
+		}
+	}
+	common.ResponseSuccess(c, exports)
+}
@@ -0,0 +1,270 @@
+package user
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/admin/instance"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 健康检查参数限制
+const (
+	minHealthCheckInterval = 30
+	maxHealthCheckTimeout  = 30
+	maxHealthEventsLimit   = 100
+)
+
+// InstanceHealthCheckRequest 保存实例健康检查请求
+type InstanceHealthCheckRequest struct {
+	Enabled              *bool  `json:"enabled"`
+	CheckType            string `json:"checkType" binding:"required,oneof=tcp http"`
+	GuestPort            int    `json:"guestPort" binding:"required,min=1,max=65535"`
+	HTTPPath             string `json:"httpPath" binding:"max=255"`
+	ExpectStatus         int    `json:"expectStatus" binding:"min=0,max=599"`
+	IntervalSeconds      int    `json:"intervalSeconds"`
+	TimeoutSeconds       int    `json:"timeoutSeconds"`
+	FailureThreshold     int    `json:"failureThreshold"`
+	RestartPolicy        string `json:"restartPolicy" binding:"omitempty,oneof=none on-failure"`
+	MaxRestarts          int    `json:"maxRestarts"`
+	RestartWindowMinutes int    `json:"restartWindowMinutes"`
+}
+
+// validate 校验参数并填充默认值
+func (r *InstanceHealthCheckRequest) validate() error {
+	if r.IntervalSeconds == 0 {
+		r.IntervalSeconds = 60
+	}
+	if r.IntervalSeconds < minHealthCheckInterval {
+		return fmt.Errorf("检查间隔不能小于 %d 秒", minHealthCheckInterval)
+	}
+	if r.TimeoutSeconds == 0 {
+		r.TimeoutSeconds = 5
+	}
+	if r.TimeoutSeconds < 1 || r.TimeoutSeconds > maxHealthCheckTimeout {
+		return fmt.Errorf("探测超时需在 1-%d 秒之间", maxHealthCheckTimeout)
+	}
+	if r.FailureThreshold == 0 {
+		r.FailureThreshold = 3
+	}
+	if r.FailureThreshold < 1 || r.FailureThreshold > 10 {
+		return fmt.Errorf("失败阈值需在 1-10 之间")
+	}
+	if r.RestartPolicy == "" {
+		r.RestartPolicy = providerModel.RestartPolicyNone
+	}
+	if r.MaxRestarts == 0 {
+		r.MaxRestarts = 3
+	}
+	if r.MaxRestarts < 1 || r.MaxRestarts > 10 {
+		return fmt.Errorf("窗口内最大重启次数需在 1-10 之间")
+	}
+	if r.RestartWindowMinutes == 0 {
+		r.RestartWindowMinutes = 60
+	}
+	if r.RestartWindowMinutes < 5 || r.RestartWindowMinutes > 1440 {
+		return fmt.Errorf("防抖时间窗口需在 5-1440 分钟之间")
+	}
+	if r.CheckType == providerModel.HealthCheckTypeHTTP {
+		if r.HTTPPath == "" {
+			r.HTTPPath = "/"
+		}
+		if !strings.HasPrefix(r.HTTPPath, "/") {
+			return fmt.Errorf("HTTP检查路径必须以 / 开头")
+		}
+	} else {
+		r.HTTPPath = ""
+		r.ExpectStatus = 0
+	}
+	return nil
+}
+
+// getOwnedInstance 解析实例ID并验证实例属于当前用户
+func getOwnedInstance(c *gin.Context) (*providerModel.Instance, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "实例ID格式错误"))
+		return nil, false
+	}
+
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return nil, false
+	}
+
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return nil, false
+	}
+	if inst.UserID != userID {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "无权限访问此实例"))
+		return nil, false
+	}
+	return inst, true
+}
+
+// GetInstanceHealthCheck 获取实例健康检查配置
+// @Summary 获取实例健康检查配置
+// @Description 获取实例的健康检查配置、重启策略及当前健康状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=providerModel.InstanceHealthCheck} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 404 {object} common.Response "未配置健康检查"
+// @Router /user/instances/{id}/health-check [get]
+func GetInstanceHealthCheck(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var check providerModel.InstanceHealthCheck
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).First(&check).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例未配置健康检查"))
+		return
+	}
+	common.ResponseSuccess(c, check)
+}
+
+// SaveInstanceHealthCheck 保存实例健康检查配置
+// @Summary 保存实例健康检查配置
+// @Description 创建或更新实例的健康检查（TCP端口或HTTP地址，通过端口映射访问）及重启策略；修改配置会重置健康状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body InstanceHealthCheckRequest true "健康检查参数"
+// @Success 200 {object} common.Response{data=providerModel.InstanceHealthCheck} "保存成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/health-check [put]
+func SaveInstanceHealthCheck(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var req InstanceHealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if err := req.validate(); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+
+	// 非独立IP实例必须存在对应的端口映射
+	if inst.PublicIP == "" {
+		var count int64
+		global.APP_DB.Model(&providerModel.Port{}).
+			Where("instance_id = ? AND guest_port = ? AND status = ?", inst.ID, req.GuestPort, "active").
+			Count(&count)
+		if count == 0 {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, fmt.Sprintf("端口 %d 没有可用的端口映射", req.GuestPort)))
+			return
+		}
+	}
+
+	var check providerModel.InstanceHealthCheck
+	global.APP_DB.Where("instance_id = ?", inst.ID).First(&check)
+	check.InstanceID = inst.ID
+	check.UserID = inst.UserID
+	check.Enabled = req.Enabled == nil || *req.Enabled
+	check.CheckType = req.CheckType
+	check.GuestPort = req.GuestPort
+	check.HTTPPath = req.HTTPPath
+	check.ExpectStatus = req.ExpectStatus
+	check.IntervalSeconds = req.IntervalSeconds
+	check.TimeoutSeconds = req.TimeoutSeconds
+	check.FailureThreshold = req.FailureThreshold
+	check.RestartPolicy = req.RestartPolicy
+	check.MaxRestarts = req.MaxRestarts
+	check.RestartWindowMinutes = req.RestartWindowMinutes
+	// 配置变更后重新开始统计
+	check.Status = providerModel.HealthStatusUnknown
+	check.ConsecutiveFailures = 0
+	check.LastError = ""
+	check.LastCheckedAt = nil
+	check.WindowRestarts = 0
+	check.WindowStartedAt = nil
+
+	if err := global.APP_DB.Save(&check).Error; err != nil {
+		global.APP_LOG.Error("保存实例健康检查失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "保存健康检查失败"))
+		return
+	}
+	common.ResponseSuccess(c, check, "保存成功")
+}
+
+// DeleteInstanceHealthCheck 删除实例健康检查配置
+// @Summary 删除实例健康检查配置
+// @Description 删除实例的健康检查配置，停止探测和自动重启
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/health-check [delete]
+func DeleteInstanceHealthCheck(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).Delete(&providerModel.InstanceHealthCheck{}).Error; err != nil {
+		global.APP_LOG.Error("删除实例健康检查失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除健康检查失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// GetInstanceHealthEvents 获取实例健康检查事件
+// @Summary 获取实例健康检查事件
+// @Description 获取实例最近的健康检查事件（不健康、恢复、自动重启、频繁重启暂停）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param limit query int false "返回条数，默认20，最大100"
+// @Success 200 {object} common.Response{data=[]providerModel.InstanceHealthEvent} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/health-events [get]
+func GetInstanceHealthEvents(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > maxHealthEventsLimit {
+		limit = 20
+	}
+
+	var events []providerModel.InstanceHealthEvent
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取健康检查事件失败"))
+		return
+	}
+	common.ResponseSuccess(c, events)
+}
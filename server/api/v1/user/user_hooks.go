@@ -0,0 +1,208 @@
+package user
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/hooks"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UserHookRequest 创建/更新钩子脚本请求
+type UserHookRequest struct {
+	Name    string `json:"name" binding:"required,max=64"`
+	Events  string `json:"events" binding:"required,max=32"`
+	Script  string `json:"script" binding:"required"`
+	Enabled *bool  `json:"enabled"`
+	Sort    int    `json:"sort"`
+}
+
+// validate 校验事件和脚本大小
+func (r *UserHookRequest) validate() error {
+	for _, event := range strings.Split(r.Events, ",") {
+		event = strings.TrimSpace(event)
+		if event != userModel.HookEventCreate && event != userModel.HookEventReset {
+			return fmt.Errorf("不支持的触发事件: %s", event)
+		}
+	}
+	if maxSize := hooks.MaxScriptSize(); len(r.Script) > maxSize {
+		return fmt.Errorf("脚本大小不能超过 %dKB", maxSize/1024)
+	}
+	return nil
+}
+
+// apply 将请求写入钩子模型
+func (r *UserHookRequest) apply(hook *userModel.UserHook) {
+	hook.Name = r.Name
+	hook.Events = r.Events
+	hook.Script = r.Script
+	hook.Sort = r.Sort
+	if r.Enabled != nil {
+		hook.Enabled = *r.Enabled
+	}
+}
+
+// bindUserHookRequest 校验用户权限并解析请求
+func bindUserHookRequest(c *gin.Context) (uint, *UserHookRequest, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return 0, nil, false
+	}
+	if err := hooks.CheckUserAllowed(userID); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+		return 0, nil, false
+	}
+
+	var req UserHookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return 0, nil, false
+	}
+	if err := req.validate(); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return 0, nil, false
+	}
+	return userID, &req, true
+}
+
+// GetUserHooks 获取钩子脚本列表
+// @Summary 获取钩子脚本列表
+// @Description 获取当前用户注册的实例创建/重置后执行的钩子脚本
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]userModel.UserHook} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/hooks [get]
+func GetUserHooks(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var userHooks []userModel.UserHook
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("sort ASC, id ASC").Find(&userHooks).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取钩子脚本失败"))
+		return
+	}
+	common.ResponseSuccess(c, userHooks)
+}
+
+// CreateUserHook 创建钩子脚本
+// @Summary 创建钩子脚本
+// @Description 注册在实例创建或重置完成后于实例内执行的脚本，输出写入任务日志
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UserHookRequest true "钩子参数"
+// @Success 200 {object} common.Response{data=userModel.UserHook} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "功能未开启或等级不足"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/hooks [post]
+func CreateUserHook(c *gin.Context) {
+	userID, req, ok := bindUserHookRequest(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	global.APP_DB.Model(&userModel.UserHook{}).Where("user_id = ?", userID).Count(&count)
+	if count >= hooks.MaxHooksPerUser {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, fmt.Sprintf("最多只能注册 %d 个钩子脚本", hooks.MaxHooksPerUser)))
+		return
+	}
+
+	hook := userModel.UserHook{UserID: userID, Enabled: true}
+	req.apply(&hook)
+	if err := global.APP_DB.Create(&hook).Error; err != nil {
+		global.APP_LOG.Error("创建钩子脚本失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建钩子脚本失败"))
+		return
+	}
+	common.ResponseSuccess(c, hook, "创建成功")
+}
+
+// UpdateUserHook 更新钩子脚本
+// @Summary 更新钩子脚本
+// @Description 更新当前用户的钩子脚本内容、触发事件或启用状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "钩子ID"
+// @Param request body UserHookRequest true "钩子参数"
+// @Success 200 {object} common.Response{data=userModel.UserHook} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "功能未开启或等级不足"
+// @Failure 404 {object} common.Response "钩子不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/hooks/{id} [put]
+func UpdateUserHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的钩子ID"))
+		return
+	}
+	userID, req, ok := bindUserHookRequest(c)
+	if !ok {
+		return
+	}
+
+	var hook userModel.UserHook
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", id, userID).First(&hook).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "钩子不存在"))
+		return
+	}
+	req.apply(&hook)
+	if err := global.APP_DB.Save(&hook).Error; err != nil {
+		global.APP_LOG.Error("更新钩子脚本失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新钩子脚本失败"))
+		return
+	}
+	common.ResponseSuccess(c, hook, "更新成功")
+}
+
+// DeleteUserHook 删除钩子脚本
+// @Summary 删除钩子脚本
+// @Description 删除当前用户的钩子脚本
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "钩子ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/hooks/{id} [delete]
+func DeleteUserHook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的钩子ID"))
+		return
+	}
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", id, userID).Delete(&userModel.UserHook{}).Error; err != nil {
+		global.APP_LOG.Error("删除钩子脚本失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除钩子脚本失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
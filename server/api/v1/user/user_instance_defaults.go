@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/instancedefaults"
+	"oneclickvirt/service/instancegroup"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InstanceDefaultsRequest 保存实例默认设置请求
+type InstanceDefaultsRequest struct {
+	ImageID      uint   `json:"imageId"`                         // 首选镜像ID，0表示不设置
+	ProviderID   uint   `json:"providerId"`                      // 首选节点ID，0表示不设置
+	Region       string `json:"region" binding:"max=64"`         // 首选地区，未设置首选节点时在该地区的可用节点中选择
+	SSHPublicKey string `json:"sshPublicKey" binding:"max=8192"` // 新建实例默认写入的SSH公钥
+	Tags         string `json:"tags"`                            // 默认标签（逗号分隔）
+	Timezone     string `json:"timezone"`                        // 默认时区，如 Asia/Shanghai
+}
+
+// apply 校验请求并写入默认设置模型
+func (r *InstanceDefaultsRequest) apply(defaults *userModel.InstanceDefaults) error {
+	sshKey, err := instancegroup.NormalizeSSHPublicKey(r.SSHPublicKey)
+	if err != nil {
+		return err
+	}
+	tags, err := instancegroup.NormalizeTags(r.Tags)
+	if err != nil {
+		return err
+	}
+	timezone, err := instancedefaults.NormalizeTimezone(r.Timezone)
+	if err != nil {
+		return err
+	}
+	defaults.ImageID = r.ImageID
+	defaults.ProviderID = r.ProviderID
+	defaults.Region = r.Region
+	defaults.SSHPublicKey = sshKey
+	defaults.Tags = tags
+	defaults.Timezone = timezone
+	return nil
+}
+
+// GetInstanceDefaults 获取实例默认设置
+// @Summary 获取实例默认设置
+// @Description 获取当前用户创建实例时使用的默认设置，尚未保存过时返回空设置
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=userModel.InstanceDefaults} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-defaults [get]
+func GetInstanceDefaults(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	defaults, err := instancedefaults.NewService().Get(userID)
+	if err != nil {
+		global.APP_LOG.Error("获取实例默认设置失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取实例默认设置失败"))
+		return
+	}
+	common.ResponseSuccess(c, defaults)
+}
+
+// UpdateInstanceDefaults 保存实例默认设置
+// @Summary 保存实例默认设置
+// @Description 保存首选镜像、首选节点或地区、SSH公钥、标签和时区。创建实例时未填写的镜像、节点和标签使用默认设置，SSH公钥和时区在实例创建或重置完成后写入实例
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body InstanceDefaultsRequest true "默认设置"
+// @Success 200 {object} common.Response{data=userModel.InstanceDefaults} "保存成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-defaults [put]
+func UpdateInstanceDefaults(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req InstanceDefaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	defaults := userModel.InstanceDefaults{UserID: userID}
+	if err := req.apply(&defaults); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	service := instancedefaults.NewService()
+	if err := service.Save(&defaults); err != nil {
+		if errors.Is(err, instancedefaults.ErrImageUnavailable) || errors.Is(err, instancedefaults.ErrProviderUnavailable) {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+			return
+		}
+		global.APP_LOG.Error("保存实例默认设置失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "保存实例默认设置失败"))
+		return
+	}
+
+	saved, err := service.Get(userID)
+	if err != nil {
+		global.APP_LOG.Error("获取实例默认设置失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取实例默认设置失败"))
+		return
+	}
+	common.ResponseSuccess(c, saved, "保存成功")
+}
@@ -0,0 +1,315 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/instancegroup"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InstanceGroupRequest 创建/更新实例分组请求
+type InstanceGroupRequest struct {
+	Name         string `json:"name" binding:"required,max=64"`
+	Description  string `json:"description" binding:"max=255"`
+	SSHPublicKey string `json:"sshPublicKey" binding:"max=8192"` // 组内新建实例默认写入的SSH公钥
+	Tags         string `json:"tags"`                            // 默认标签（逗号分隔）
+	Sort         int    `json:"sort"`
+}
+
+// apply 校验请求并写入分组模型
+func (r *InstanceGroupRequest) apply(group *userModel.InstanceGroup) error {
+	sshKey, err := instancegroup.NormalizeSSHPublicKey(r.SSHPublicKey)
+	if err != nil {
+		return err
+	}
+	tags, err := instancegroup.NormalizeTags(r.Tags)
+	if err != nil {
+		return err
+	}
+	group.Name = r.Name
+	group.Description = r.Description
+	group.SSHPublicKey = sshKey
+	group.Tags = tags
+	group.Sort = r.Sort
+	return nil
+}
+
+// AssignInstanceGroupRequest 调整实例所在分组请求
+type AssignInstanceGroupRequest struct {
+	GroupID     uint   `json:"groupId"` // 目标分组ID，0表示移出分组
+	InstanceIDs []uint `json:"instanceIds" binding:"required,min=1,max=100"`
+}
+
+// InstanceGroupActionRequest 分组批量操作请求
+type InstanceGroupActionRequest struct {
+	Action string `json:"action" binding:"required,oneof=start stop restart"`
+}
+
+// respondInstanceGroupError 分组不存在返回404，其余返回500
+func respondInstanceGroupError(c *gin.Context, err error, message string) {
+	if errors.Is(err, instancegroup.ErrGroupNotFound) {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseWithError(c, common.NewError(common.CodeInternalError, message))
+}
+
+// isGroupNameTaken 检查分组名称是否已被同一用户的其他分组使用
+func isGroupNameTaken(userID, excludeID uint, name string) bool {
+	var count int64
+	global.APP_DB.Model(&userModel.InstanceGroup{}).
+		Where("user_id = ? AND name = ? AND id <> ?", userID, name, excludeID).Count(&count)
+	return count > 0
+}
+
+// GetInstanceGroups 获取实例分组列表
+// @Summary 获取实例分组列表
+// @Description 获取当前用户的实例分组，附带组内实例数量和当月流量汇总
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]userModel.InstanceGroupResponse} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-groups [get]
+func GetInstanceGroups(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	groups, err := instancegroup.NewService().ListGroups(userID)
+	if err != nil {
+		global.APP_LOG.Error("获取实例分组失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取实例分组失败"))
+		return
+	}
+	common.ResponseSuccess(c, groups)
+}
+
+// CreateInstanceGroup 创建实例分组
+// @Summary 创建实例分组
+// @Description 创建实例分组，可设置组内新建实例默认写入的SSH公钥和标签
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body InstanceGroupRequest true "分组参数"
+// @Success 200 {object} common.Response{data=userModel.InstanceGroup} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 409 {object} common.Response "分组名称已存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-groups [post]
+func CreateInstanceGroup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req InstanceGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var count int64
+	global.APP_DB.Model(&userModel.InstanceGroup{}).Where("user_id = ?", userID).Count(&count)
+	if count >= instancegroup.MaxGroupsPerUser {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, fmt.Sprintf("最多只能创建 %d 个分组", instancegroup.MaxGroupsPerUser)))
+		return
+	}
+	if isGroupNameTaken(userID, 0, req.Name) {
+		common.ResponseWithError(c, common.NewError(common.CodeConflict, "分组名称已存在"))
+		return
+	}
+
+	group := userModel.InstanceGroup{UserID: userID}
+	if err := req.apply(&group); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	if err := global.APP_DB.Create(&group).Error; err != nil {
+		global.APP_LOG.Error("创建实例分组失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建实例分组失败"))
+		return
+	}
+	cache.GetUserCacheService().InvalidateUserCache(userID)
+	common.ResponseSuccess(c, group, "创建成功")
+}
+
+// UpdateInstanceGroup 更新实例分组
+// @Summary 更新实例分组
+// @Description 更新分组名称和默认设置，默认SSH公钥只对之后创建或重置的实例生效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分组ID"
+// @Param request body InstanceGroupRequest true "分组参数"
+// @Success 200 {object} common.Response{data=userModel.InstanceGroup} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "分组不存在"
+// @Failure 409 {object} common.Response "分组名称已存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-groups/{id} [put]
+func UpdateInstanceGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的分组ID"))
+		return
+	}
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req InstanceGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	group, err := instancegroup.NewService().GetOwnedGroup(userID, uint(id))
+	if err != nil {
+		respondInstanceGroupError(c, err, "获取实例分组失败")
+		return
+	}
+	if isGroupNameTaken(userID, group.ID, req.Name) {
+		common.ResponseWithError(c, common.NewError(common.CodeConflict, "分组名称已存在"))
+		return
+	}
+	if err := req.apply(group); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	if err := global.APP_DB.Save(group).Error; err != nil {
+		global.APP_LOG.Error("更新实例分组失败", zap.Uint64("id", id), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新实例分组失败"))
+		return
+	}
+	cache.GetUserCacheService().InvalidateUserCache(userID)
+	common.ResponseSuccess(c, group, "更新成功")
+}
+
+// DeleteInstanceGroup 删除实例分组
+// @Summary 删除实例分组
+// @Description 删除实例分组，组内实例变为未分组，实例本身不受影响
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分组ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "分组不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-groups/{id} [delete]
+func DeleteInstanceGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的分组ID"))
+		return
+	}
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	if err := instancegroup.NewService().DeleteGroup(userID, uint(id)); err != nil {
+		global.APP_LOG.Error("删除实例分组失败", zap.Uint64("id", id), zap.Error(err))
+		respondInstanceGroupError(c, err, "删除实例分组失败")
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// InstanceGroupAction 分组批量操作
+// @Summary 分组批量操作
+// @Description 对分组内所有实例执行启动、停止或重启，状态不满足的实例会被跳过
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分组ID"
+// @Param request body InstanceGroupActionRequest true "操作参数"
+// @Success 200 {object} common.Response{data=[]instancegroup.ActionResult} "操作已提交"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "分组不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instance-groups/{id}/action [post]
+func InstanceGroupAction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的分组ID"))
+		return
+	}
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req InstanceGroupActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	results, err := instancegroup.NewService().GroupAction(userID, uint(id), req.Action)
+	if err != nil {
+		global.APP_LOG.Error("分组批量操作失败",
+			zap.Uint64("id", id),
+			zap.String("action", req.Action),
+			zap.Error(err))
+		respondInstanceGroupError(c, err, "分组批量操作失败")
+		return
+	}
+	common.ResponseSuccess(c, results, "操作已提交")
+}
+
+// AssignInstanceGroup 调整实例所在分组
+// @Summary 调整实例所在分组
+// @Description 将实例移入指定分组，groupId为0时移出分组；不属于当前用户的实例会被忽略
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body AssignInstanceGroupRequest true "分组参数"
+// @Success 200 {object} common.Response{data=object} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "分组不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/group [put]
+func AssignInstanceGroup(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	var req AssignInstanceGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	updated, err := instancegroup.NewService().AssignInstances(userID, req.GroupID, req.InstanceIDs)
+	if err != nil {
+		global.APP_LOG.Error("调整实例分组失败",
+			zap.Uint("userId", userID),
+			zap.Uint("groupId", req.GroupID),
+			zap.Error(err))
+		respondInstanceGroupError(c, err, "调整实例分组失败")
+		return
+	}
+	common.ResponseSuccess(c, gin.H{"updated": updated}, "更新成功")
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"oneclickvirt/middleware"
 	"oneclickvirt/service/resources"
 	"strconv"
@@ -8,9 +9,11 @@ import (
 	"oneclickvirt/global"
 	"oneclickvirt/model/common"
 	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/task"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func getUserIDFromContext(c *gin.Context) (uint, error) {
@@ -83,6 +86,11 @@ func GetInstancePorts(c *gin.Context) {
 			"description": port.Description,
 			"isSSH":       port.IsSSH,
 			"createdAt":   port.CreatedAt,
+
+			"serviceType":       port.ServiceType,
+			"serviceBanner":     port.ServiceBanner,
+			"serviceTlsName":    port.ServiceTLSName,
+			"serviceDetectedAt": port.ServiceDetectedAt,
 		}
 	}
 
@@ -157,3 +165,95 @@ func GetUserPortMappings(c *gin.Context) {
 		"limit": req.Limit,
 	})
 }
+
+// UpdatePortDescriptionRequest 修改端口映射描述请求
+type UpdatePortDescriptionRequest struct {
+	Description string `json:"description" binding:"max=256"`
+}
+
+// DetectPortServicesRequest 探测端口映射服务类型请求
+type DetectPortServicesRequest struct {
+	PortIDs []uint `json:"portIds"` // 要探测的端口映射ID，为空时探测全部TCP端口映射
+	Consent bool   `json:"consent"` // 同意面板连接实例的映射端口进行探测
+}
+
+// UpdateInstancePortDescription 修改端口映射描述
+// @Summary 修改端口映射描述
+// @Description 为自己实例的端口映射填写用途说明，只修改面板记录，不影响节点上的映射
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param portId path int true "端口映射ID"
+// @Param request body UpdatePortDescriptionRequest true "描述"
+// @Success 200 {object} common.Response "修改成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Failure 404 {object} common.Response "端口映射不存在"
+// @Router /user/instances/{id}/ports/{portId} [put]
+func UpdateInstancePortDescription(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+	portID, err := strconv.ParseUint(c.Param("portId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "端口映射ID格式错误"))
+		return
+	}
+	var req UpdatePortDescriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	portMappingService := resources.PortMappingService{}
+	if err := portMappingService.UpdatePortDescription(inst.ID, uint(portID), req.Description); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, "端口映射不存在"))
+			return
+		}
+		global.APP_LOG.Error("修改端口映射描述失败", zap.Uint("portId", uint(portID)), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "修改端口映射描述失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "修改成功")
+}
+
+// DetectInstancePortServices 探测端口映射服务类型
+// @Summary 探测端口映射服务类型
+// @Description 经用户同意后，由面板连接实例的映射端口，根据SSH欢迎信息、网页标题和TLS证书识别服务类型，结果显示在端口列表中。探测以任务形式执行，consent 必须为 true
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body DetectPortServicesRequest true "探测参数"
+// @Success 200 {object} common.Response{data=object} "任务已提交"
+// @Failure 400 {object} common.Response "参数错误或未同意探测"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Failure 409 {object} common.Response "已有进行中的探测任务"
+// @Router /user/instances/{id}/ports/detect [post]
+func DetectInstancePortServices(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+	var req DetectPortServicesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	detectTask, err := task.GetTaskService().CreateDetectPortServiceTask(inst.UserID, inst, req.PortIDs, req.Consent)
+	if err != nil {
+		if errors.Is(err, task.ErrPortDetectInProgress) {
+			common.ResponseWithError(c, common.NewError(common.CodeConflict, err.Error()))
+			return
+		}
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, gin.H{"taskId": detectTask.ID}, "端口服务探测任务已提交")
+}
@@ -0,0 +1,120 @@
+package user
+
+import (
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/rdns"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InstanceRDNSRequest 设置实例反向解析请求
+type InstanceRDNSRequest struct {
+	IP       string `json:"ip" binding:"required"`
+	Hostname string `json:"hostname" binding:"required"`
+}
+
+// GetInstanceRDNS 获取实例反向解析
+// @Summary 获取实例反向解析
+// @Description 获取实例可设置反向解析的独立公网IP及当前PTR主机名；NAT共享IPv4不能设置反向解析
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=object} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 404 {object} common.Response "实例不存在"
+// @Router /user/instances/{id}/rdns [get]
+func GetInstanceRDNS(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	entries, err := rdns.List(inst)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+
+	allowed := rdns.CheckUserAllowed(inst.UserID)
+	data := gin.H{
+		"allowed": allowed == nil,
+		"ips":     entries,
+	}
+	if allowed != nil {
+		data["reason"] = allowed.Error()
+	}
+	common.ResponseSuccess(c, data)
+}
+
+// SetInstanceRDNS 设置实例反向解析
+// @Summary 设置实例反向解析
+// @Description 为实例的独立公网IP设置PTR记录。主机名必须已有指向该IP的A/AAAA记录，需要用户等级达到管理员配置的下限
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body InstanceRDNSRequest true "反向解析参数"
+// @Success 200 {object} common.Response{data=providerModel.InstanceRDNS} "设置成功"
+// @Failure 400 {object} common.Response "参数错误或正向解析校验失败"
+// @Failure 403 {object} common.Response "无权限或等级不支持"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/rdns [put]
+func SetInstanceRDNS(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+	if err := rdns.CheckUserAllowed(inst.UserID); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, err.Error()))
+		return
+	}
+
+	var req InstanceRDNSRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	record, err := rdns.Set(c.Request.Context(), inst, req.IP, req.Hostname)
+	if err != nil {
+		global.APP_LOG.Warn("设置实例反向解析失败",
+			zap.Uint("instanceId", inst.ID),
+			zap.String("ip", req.IP),
+			zap.String("hostname", req.Hostname),
+			zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, record, "设置成功")
+}
+
+// DeleteInstanceRDNS 删除实例反向解析
+// @Summary 删除实例反向解析
+// @Description 删除实例独立公网IP的PTR记录
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param ip query string true "IP地址"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限"
+// @Router /user/instances/{id}/rdns [delete]
+func DeleteInstanceRDNS(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+	// 不检查等级，功能关闭或等级下调后仍允许删除已有记录
+	if err := rdns.Delete(c.Request.Context(), inst, c.Query("ip")); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
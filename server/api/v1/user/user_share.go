@@ -0,0 +1,137 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InstanceShareRequest 生成或更新实例分享链接请求
+type InstanceShareRequest struct {
+	ShowTraffic   *bool `json:"showTraffic"`                           // 是否公开流量曲线，默认公开
+	ExpiresInDays int   `json:"expiresInDays" binding:"min=0,max=365"` // 有效天数，0表示长期有效
+	Regenerate    bool  `json:"regenerate"`                            // 是否重新生成令牌，旧链接立即失效
+}
+
+// newShareToken 生成分享令牌
+func newShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GetInstanceShare 获取实例分享链接
+// @Summary 获取实例分享链接
+// @Description 获取实例当前有效的公开分享链接配置
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=providerModel.InstanceShare} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 404 {object} common.Response "未创建分享链接"
+// @Router /user/instances/{id}/share [get]
+func GetInstanceShare(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var share providerModel.InstanceShare
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).First(&share).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例未创建分享链接"))
+		return
+	}
+	common.ResponseSuccess(c, share)
+}
+
+// SaveInstanceShare 生成或更新实例分享链接
+// @Summary 生成或更新实例分享链接
+// @Description 为实例生成无需登录即可访问的只读分享链接（状态、运行时长、地区、流量曲线），已存在时更新配置，regenerate为true时更换令牌使旧链接失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body InstanceShareRequest true "分享链接参数"
+// @Success 200 {object} common.Response{data=providerModel.InstanceShare} "保存成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/share [put]
+func SaveInstanceShare(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var req InstanceShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var share providerModel.InstanceShare
+	global.APP_DB.Where("instance_id = ?", inst.ID).First(&share)
+	if share.ID == 0 || req.Regenerate {
+		token, err := newShareToken()
+		if err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeTokenGenerateError, "生成分享令牌失败"))
+			return
+		}
+		share.Token = token
+		share.ViewCount = 0
+		share.LastViewedAt = nil
+	}
+	share.InstanceID = inst.ID
+	share.UserID = inst.UserID
+	share.ShowTraffic = req.ShowTraffic == nil || *req.ShowTraffic
+	share.ExpiresAt = nil
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		share.ExpiresAt = &expiresAt
+	}
+
+	if err := global.APP_DB.Save(&share).Error; err != nil {
+		global.APP_LOG.Error("保存实例分享链接失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "保存分享链接失败"))
+		return
+	}
+	common.ResponseSuccess(c, share, "保存成功")
+}
+
+// DeleteInstanceShare 撤销实例分享链接
+// @Summary 撤销实例分享链接
+// @Description 删除实例的分享链接，已发出的链接立即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response "撤销成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/share [delete]
+func DeleteInstanceShare(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).Delete(&providerModel.InstanceShare{}).Error; err != nil {
+		global.APP_LOG.Error("撤销实例分享链接失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "撤销分享链接失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "撤销成功")
+}
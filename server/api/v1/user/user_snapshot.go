@@ -0,0 +1,168 @@
+package user
+
+import (
+	"errors"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/snapshot"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InstanceSnapshotScheduleRequest 保存实例定时快照计划请求
+type InstanceSnapshotScheduleRequest struct {
+	Enabled   *bool  `json:"enabled"`
+	Frequency string `json:"frequency" binding:"required,oneof=daily weekly"`
+	Keep      int    `json:"keep" binding:"required,min=1"`
+}
+
+// GetInstanceSnapshotSchedule 获取实例定时快照计划
+// @Summary 获取实例定时快照计划
+// @Description 获取实例的定时快照计划及最近一次执行状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=providerModel.InstanceSnapshotSchedule} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 404 {object} common.Response "未配置定时快照"
+// @Router /user/instances/{id}/snapshot-schedule [get]
+func GetInstanceSnapshotSchedule(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var schedule providerModel.InstanceSnapshotSchedule
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).First(&schedule).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例未配置定时快照"))
+		return
+	}
+	common.ResponseSuccess(c, schedule)
+}
+
+// SaveInstanceSnapshotSchedule 保存实例定时快照计划
+// @Summary 保存实例定时快照计划
+// @Description 创建或更新实例的每日/每周定时快照计划，保留数量不能超过用户等级的上限；快照在管理员配置的窗口内执行，超出保留数量的旧快照自动删除
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Param request body InstanceSnapshotScheduleRequest true "快照计划参数"
+// @Success 200 {object} common.Response{data=providerModel.InstanceSnapshotSchedule} "保存成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限或等级不支持"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/snapshot-schedule [put]
+func SaveInstanceSnapshotSchedule(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+	if !global.APP_CONFIG.Snapshot.Enabled {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "定时快照功能未启用"))
+		return
+	}
+
+	var req InstanceSnapshotScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.Select("id, type").First(&provider, inst.ProviderID).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "节点不存在"))
+		return
+	}
+	if !snapshot.Supported(provider.Type) {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "实例所在节点不支持快照"))
+		return
+	}
+
+	limit, err := snapshot.LevelLimit(inst.UserID)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取用户等级失败"))
+		return
+	}
+	if err := snapshot.Validate(req.Frequency, req.Keep, limit); err != nil {
+		code := common.CodeValidationError
+		if errors.Is(err, snapshot.ErrNotAllowed) {
+			code = common.CodeForbidden
+		}
+		common.ResponseWithError(c, common.NewError(code, err.Error()))
+		return
+	}
+
+	var schedule providerModel.InstanceSnapshotSchedule
+	global.APP_DB.Where("instance_id = ?", inst.ID).First(&schedule)
+	schedule.InstanceID = inst.ID
+	schedule.UserID = inst.UserID
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	schedule.Frequency = req.Frequency
+	schedule.Keep = req.Keep
+
+	if err := global.APP_DB.Save(&schedule).Error; err != nil {
+		global.APP_LOG.Error("保存实例定时快照计划失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "保存定时快照计划失败"))
+		return
+	}
+	common.ResponseSuccess(c, schedule, "保存成功")
+}
+
+// DeleteInstanceSnapshotSchedule 删除实例定时快照计划
+// @Summary 删除实例定时快照计划
+// @Description 删除实例的定时快照计划，停止创建新快照；已创建的快照保留在节点上
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/snapshot-schedule [delete]
+func DeleteInstanceSnapshotSchedule(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).Delete(&providerModel.InstanceSnapshotSchedule{}).Error; err != nil {
+		global.APP_LOG.Error("删除实例定时快照计划失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除定时快照计划失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// GetInstanceSnapshots 获取实例的定时快照列表
+// @Summary 获取实例的定时快照列表
+// @Description 获取定时快照计划创建且仍保留的快照，按创建时间倒序
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "实例ID"
+// @Success 200 {object} common.Response{data=[]providerModel.InstanceSnapshot} "获取成功"
+// @Failure 403 {object} common.Response "无权限"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/instances/{id}/snapshots [get]
+func GetInstanceSnapshots(c *gin.Context) {
+	inst, ok := getOwnedInstance(c)
+	if !ok {
+		return
+	}
+
+	var snapshots []providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ?", inst.ID).Order("id DESC").Find(&snapshots).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取快照列表失败"))
+		return
+	}
+	common.ResponseSuccess(c, snapshots)
+}
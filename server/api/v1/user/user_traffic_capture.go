@@ -0,0 +1,156 @@
+package user
+
+import (
+	"strconv"
+
+	"oneclickvirt/model/common"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/service/admin/instance"
+	"oneclickvirt/service/pmacct"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInstanceTrafficCaptures 获取实例流量明细抓取记录
+// @Summary 获取实例流量明细抓取记录
+// @Description 返回自己名下实例最近20次按端口和对端聚合的流量抓取记录
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Success 200 {object} common.Response{data=[]monitoring.TrafficCapture} "获取成功"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Router /user/instances/{id}/traffic-captures [get]
+func GetInstanceTrafficCaptures(c *gin.Context) {
+	instanceID, ok := ownedTrafficCaptureInstance(c)
+	if !ok {
+		return
+	}
+	captures, err := pmacct.NewService().ListTrafficCaptures(instanceID, 20)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, captures)
+}
+
+// StartInstanceTrafficCapture 开始实例流量明细抓取
+// @Summary 开始实例流量明细抓取
+// @Description 临时按端口和对端统计实例流量，用于排查哪个服务在消耗流量，到期后自动结束
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Param request body monitoring.StartTrafficCaptureRequest false "抓取参数"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCapture} "已开始抓取"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "无权限访问此实例"
+// @Router /user/instances/{id}/traffic-captures [post]
+func StartInstanceTrafficCapture(c *gin.Context) {
+	instanceID, ok := ownedTrafficCaptureInstance(c)
+	if !ok {
+		return
+	}
+	var req monitoringModel.StartTrafficCaptureRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误"))
+			return
+		}
+	}
+
+	userID, _ := getUserIDFromContext(c)
+	capture, err := pmacct.NewService().StartTrafficCapture(instanceID, userID, req.Minutes)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, capture, "已开始抓取流量明细")
+}
+
+// GetInstanceTrafficCapture 获取实例流量明细
+// @Summary 获取实例流量明细
+// @Description 抓取进行中时实时读取数据，结束后返回保存的结果
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Param captureId path int true "抓取ID"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCaptureDetail} "获取成功"
+// @Failure 404 {object} common.Response "抓取记录不存在"
+// @Router /user/instances/{id}/traffic-captures/{captureId} [get]
+func GetInstanceTrafficCapture(c *gin.Context) {
+	instanceID, ok := ownedTrafficCaptureInstance(c)
+	if !ok {
+		return
+	}
+	captureID, err := strconv.ParseUint(c.Param("captureId"), 10, 32)
+	if err != nil || captureID == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "抓取ID格式错误"))
+		return
+	}
+	detail, err := pmacct.NewService().GetTrafficCapture(instanceID, uint(captureID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail)
+}
+
+// StopInstanceTrafficCapture 提前结束实例流量明细抓取
+// @Summary 提前结束实例流量明细抓取
+// @Description 结束抓取并保存当前结果，已结束的抓取直接返回结果
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "实例ID"
+// @Param captureId path int true "抓取ID"
+// @Success 200 {object} common.Response{data=monitoring.TrafficCaptureDetail} "已结束"
+// @Failure 404 {object} common.Response "抓取记录不存在"
+// @Router /user/instances/{id}/traffic-captures/{captureId}/stop [post]
+func StopInstanceTrafficCapture(c *gin.Context) {
+	instanceID, ok := ownedTrafficCaptureInstance(c)
+	if !ok {
+		return
+	}
+	captureID, err := strconv.ParseUint(c.Param("captureId"), 10, 32)
+	if err != nil || captureID == 0 {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "抓取ID格式错误"))
+		return
+	}
+	detail, err := pmacct.NewService().StopTrafficCapture(instanceID, uint(captureID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, err.Error()))
+		return
+	}
+	common.ResponseSuccess(c, detail, "抓取已结束")
+}
+
+// ownedTrafficCaptureInstance 解析实例ID并校验实例属于当前用户
+func ownedTrafficCaptureInstance(c *gin.Context) (uint, bool) {
+	instanceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "实例ID格式错误"))
+		return 0, false
+	}
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return 0, false
+	}
+	adminInstanceService := instance.Service{}
+	inst, err := adminInstanceService.GetInstanceByID(uint(instanceID))
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeNotFound, "实例不存在"))
+		return 0, false
+	}
+	if inst.UserID != userID {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "无权限访问此实例"))
+		return 0, false
+	}
+	return inst.ID, true
+}
@@ -0,0 +1,339 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/userwebhook"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UserWebhookRequest 创建/更新Webhook请求
+type UserWebhookRequest struct {
+	Name             string `json:"name" binding:"required,max=64"`
+	URL              string `json:"url" binding:"required,max=512"`
+	Events           string `json:"events" binding:"required,max=128"` // 逗号分隔：instance.created, instance.stopped, traffic.threshold, instance.expiring
+	TrafficThreshold int    `json:"trafficThreshold" binding:"omitempty,min=1,max=100"`
+	ExpiringDays     int    `json:"expiringDays" binding:"omitempty,min=1,max=30"`
+	Enabled          *bool  `json:"enabled"`
+}
+
+// UserWebhookWithSecret 创建或重新生成密钥时返回，密钥只在此时返回
+type UserWebhookWithSecret struct {
+	userModel.UserWebhook
+	Secret string `json:"secret"`
+}
+
+// apply 校验请求并写入Webhook模型
+func (r *UserWebhookRequest) apply(webhook *userModel.UserWebhook) error {
+	if err := userwebhook.ValidateURL(r.URL); err != nil {
+		return err
+	}
+	events, err := userwebhook.NormalizeEvents(strings.Split(r.Events, ","))
+	if err != nil {
+		return err
+	}
+	webhook.Name = r.Name
+	webhook.URL = strings.TrimSpace(r.URL)
+	webhook.Events = events
+	if r.TrafficThreshold > 0 {
+		webhook.TrafficThreshold = r.TrafficThreshold
+	}
+	if r.ExpiringDays > 0 {
+		webhook.ExpiringDays = r.ExpiringDays
+	}
+	if r.Enabled != nil {
+		webhook.Enabled = *r.Enabled
+	}
+	return nil
+}
+
+// userWebhookContext 校验功能是否开启并返回当前用户ID
+func userWebhookContext(c *gin.Context) (uint, bool) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return 0, false
+	}
+	if !userwebhook.Enabled() {
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "Webhook功能未开启"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// findUserWebhook 查询当前用户的Webhook
+func findUserWebhook(c *gin.Context, userID uint) (*userModel.UserWebhook, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInvalidParam, "无效的Webhook ID"))
+		return nil, false
+	}
+	var webhook userModel.UserWebhook
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", id, userID).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ResponseWithError(c, common.NewError(common.CodeNotFound, "Webhook不存在"))
+		} else {
+			common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取Webhook失败"))
+		}
+		return nil, false
+	}
+	return &webhook, true
+}
+
+// GetUserWebhooks 获取Webhook列表
+// @Summary 获取Webhook列表
+// @Description 获取当前用户注册的Webhook，密钥不返回
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} common.Response{data=[]userModel.UserWebhook} "获取成功"
+// @Failure 401 {object} common.Response "用户未登录"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks [get]
+func GetUserWebhooks(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+
+	var webhooks []userModel.UserWebhook
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取Webhook失败"))
+		return
+	}
+	common.ResponseSuccess(c, webhooks)
+}
+
+// CreateUserWebhook 创建Webhook
+// @Summary 创建Webhook
+// @Description 注册接收自己实例事件的地址，返回的签名密钥只显示一次
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UserWebhookRequest true "Webhook参数"
+// @Success 200 {object} common.Response{data=UserWebhookWithSecret} "创建成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "功能未开启"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks [post]
+func CreateUserWebhook(c *gin.Context) {
+	userID, ok := userWebhookContext(c)
+	if !ok {
+		return
+	}
+	var req UserWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+
+	var count int64
+	global.APP_DB.Model(&userModel.UserWebhook{}).Where("user_id = ?", userID).Count(&count)
+	if maxCount := userwebhook.MaxPerUser(); count >= int64(maxCount) {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, fmt.Sprintf("最多只能注册 %d 个Webhook", maxCount)))
+		return
+	}
+
+	webhook := userModel.UserWebhook{UserID: userID, Enabled: true, TrafficThreshold: 80, ExpiringDays: 3}
+	if err := req.apply(&webhook); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	secret, err := userwebhook.GenerateSecret()
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	webhook.Secret = secret
+	if err := global.APP_DB.Create(&webhook).Error; err != nil {
+		global.APP_LOG.Error("创建Webhook失败", zap.Uint("userId", userID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "创建Webhook失败"))
+		return
+	}
+	common.ResponseSuccess(c, UserWebhookWithSecret{UserWebhook: webhook, Secret: secret}, "创建成功")
+}
+
+// UpdateUserWebhook 更新Webhook
+// @Summary 更新Webhook
+// @Description 更新当前用户的Webhook地址、订阅事件、阈值或启用状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param request body UserWebhookRequest true "Webhook参数"
+// @Success 200 {object} common.Response{data=userModel.UserWebhook} "更新成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 403 {object} common.Response "功能未开启"
+// @Failure 404 {object} common.Response "Webhook不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks/{id} [put]
+func UpdateUserWebhook(c *gin.Context) {
+	userID, ok := userWebhookContext(c)
+	if !ok {
+		return
+	}
+	webhook, ok := findUserWebhook(c, userID)
+	if !ok {
+		return
+	}
+	var req UserWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, "参数错误: "+err.Error()))
+		return
+	}
+	if err := req.apply(webhook); err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeValidationError, err.Error()))
+		return
+	}
+	if err := global.APP_DB.Save(webhook).Error; err != nil {
+		global.APP_LOG.Error("更新Webhook失败", zap.Uint("id", webhook.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新Webhook失败"))
+		return
+	}
+	common.ResponseSuccess(c, webhook, "更新成功")
+}
+
+// DeleteUserWebhook 删除Webhook
+// @Summary 删除Webhook
+// @Description 删除当前用户的Webhook及其投递记录
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response "删除成功"
+// @Failure 400 {object} common.Response "参数错误"
+// @Failure 404 {object} common.Response "Webhook不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks/{id} [delete]
+func DeleteUserWebhook(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	webhook, ok := findUserWebhook(c, userID)
+	if !ok {
+		return
+	}
+	err = global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&userModel.UserWebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+	if err != nil {
+		global.APP_LOG.Error("删除Webhook失败", zap.Uint("id", webhook.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "删除Webhook失败"))
+		return
+	}
+	common.ResponseSuccess(c, nil, "删除成功")
+}
+
+// RotateUserWebhookSecret 重新生成Webhook签名密钥
+// @Summary 重新生成Webhook签名密钥
+// @Description 生成新的签名密钥，旧密钥立即失效，新密钥只显示一次
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response{data=UserWebhookWithSecret} "生成成功"
+// @Failure 403 {object} common.Response "功能未开启"
+// @Failure 404 {object} common.Response "Webhook不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks/{id}/secret [post]
+func RotateUserWebhookSecret(c *gin.Context) {
+	userID, ok := userWebhookContext(c)
+	if !ok {
+		return
+	}
+	webhook, ok := findUserWebhook(c, userID)
+	if !ok {
+		return
+	}
+	secret, err := userwebhook.GenerateSecret()
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, err.Error()))
+		return
+	}
+	if err := global.APP_DB.Model(webhook).Update("secret", secret).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "更新签名密钥失败"))
+		return
+	}
+	common.ResponseSuccess(c, UserWebhookWithSecret{UserWebhook: *webhook, Secret: secret}, "生成成功")
+}
+
+// TestUserWebhook 发送测试事件
+// @Summary 发送测试事件
+// @Description 向Webhook发送一次 ping 事件并返回投递结果，计入投递频率限制
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response{data=userModel.UserWebhookDelivery} "已发送"
+// @Failure 403 {object} common.Response "功能未开启"
+// @Failure 404 {object} common.Response "Webhook不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks/{id}/test [post]
+func TestUserWebhook(c *gin.Context) {
+	userID, ok := userWebhookContext(c)
+	if !ok {
+		return
+	}
+	webhook, ok := findUserWebhook(c, userID)
+	if !ok {
+		return
+	}
+	delivery, err := userwebhook.Ping(webhook)
+	if err != nil {
+		global.APP_LOG.Error("发送Webhook测试事件失败", zap.Uint("id", webhook.ID), zap.Error(err))
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "发送测试事件失败"))
+		return
+	}
+	common.ResponseSuccess(c, delivery, "已发送")
+}
+
+// GetUserWebhookDeliveries 获取Webhook最近的投递记录
+// @Summary 获取Webhook投递记录
+// @Description 获取Webhook最近50条投递记录，包括请求体、状态码和失败原因
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response{data=[]userModel.UserWebhookDelivery} "获取成功"
+// @Failure 404 {object} common.Response "Webhook不存在"
+// @Failure 500 {object} common.Response "服务器内部错误"
+// @Router /user/webhooks/{id}/deliveries [get]
+func GetUserWebhookDeliveries(c *gin.Context) {
+	userID, err := getUserIDFromContext(c)
+	if err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeUnauthorized, err.Error()))
+		return
+	}
+	webhook, ok := findUserWebhook(c, userID)
+	if !ok {
+		return
+	}
+	var deliveries []userModel.UserWebhookDelivery
+	if err := global.APP_DB.Where("webhook_id = ?", webhook.ID).Order("id DESC").Limit(50).Find(&deliveries).Error; err != nil {
+		common.ResponseWithError(c, common.NewError(common.CodeInternalError, "获取投递记录失败"))
+		return
+	}
+	common.ResponseSuccess(c, deliveries)
+}
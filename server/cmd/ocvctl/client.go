@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiResponse 服务端统一响应结构，成功时code为0（部分旧接口为200）
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Msg     string          `json:"msg"`
+	Details string          `json:"details"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiError 接口返回的错误
+type apiError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("请求失败 (HTTP %d, code %d): %s", e.Status, e.Code, e.Message)
+}
+
+// client OneClickVirt API客户端
+type client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+func newClient(server, token string) *client {
+	return &client{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// newRequest 构造带认证头的请求，path为 /api 之后的路径
+func (c *client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	u := c.server + "/api" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", "ocvctl")
+	return req, nil
+}
+
+// do 发送请求并解析统一响应，out不为空时解码data字段
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return &apiError{Status: resp.StatusCode, Message: "无法解析响应: " + err.Error()}
+	}
+	if resp.StatusCode >= 400 || (result.Code != 0 && result.Code != 200) {
+		message := result.Message
+		if message == "" {
+			message = result.Msg
+		}
+		if result.Details != "" {
+			message += ": " + result.Details
+		}
+		return &apiError{Status: resp.StatusCode, Code: result.Code, Message: message}
+	}
+	if out != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
+
+// sseEvent 服务端推送的事件
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// stream 订阅SSE接口，每收到一个事件调用一次handle，handle返回false时停止
+func (c *client) stream(ctx context.Context, path string, handle func(sseEvent) bool) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	// 事件流是长连接，不能使用普通请求的超时
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var result apiResponse
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		return &apiError{Status: resp.StatusCode, Code: result.Code, Message: result.Message + result.Msg}
+	}
+	return readSSE(resp.Body, handle)
+}
+
+// readSSE 按行解析SSE事件流
+func readSSE(r io.Reader, handle func(sseEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var event sseEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event.Name != "" || len(data) > 0 {
+				event.Data = strings.Join(data, "\n")
+				if !handle(event) {
+					return nil
+				}
+			}
+			event, data = sseEvent{}, nil
+		case strings.HasPrefix(line, ":"):
+			// 注释行
+		case strings.HasPrefix(line, "event:"):
+			event.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadSSE(t *testing.T) {
+	input := ": keepalive\n\nevent: snapshot\ndata: {\"progress\":10}\n\nevent: progress\ndata: a\ndata: b\n\nevent: done\ndata: {}\n\nevent: ignored\ndata: x\n\n"
+	var got []sseEvent
+	err := readSSE(strings.NewReader(input), func(ev sseEvent) bool {
+		got = append(got, ev)
+		return ev.Name != "done"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sseEvent{
+		{Name: "snapshot", Data: `{"progress":10}`},
+		{Name: "progress", Data: "a\nb"},
+		{Name: "done", Data: "{}"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClientDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ocv_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":1001,"message":"未授权"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/ok":
+			w.Write([]byte(`{"code":0,"message":"success","data":{"taskId":7}}`))
+		case "/api/v1/legacy":
+			w.Write([]byte(`{"code":200,"msg":"ok"}`))
+		default:
+			w.Write([]byte(`{"code":2001,"message":"实例不存在","details":"id=9"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := newClient(srv.URL+"/", "ocv_test")
+	var out struct {
+		TaskID uint `json:"taskId"`
+	}
+	if err := c.do(context.Background(), http.MethodGet, "/v1/ok", nil, nil, &out); err != nil || out.TaskID != 7 {
+		t.Fatalf("ok: err=%v taskId=%d", err, out.TaskID)
+	}
+	if err := c.do(context.Background(), http.MethodGet, "/v1/legacy", nil, nil, nil); err != nil {
+		t.Fatalf("legacy: %v", err)
+	}
+	err := c.do(context.Background(), http.MethodGet, "/v1/missing", nil, nil, nil)
+	if e, ok := err.(*apiError); !ok || e.Code != 2001 || e.Message != "实例不存在: id=9" {
+		t.Fatalf("missing: %v", err)
+	}
+	c.token = "bad"
+	err = c.do(context.Background(), http.MethodGet, "/v1/ok", nil, nil, nil)
+	if e, ok := err.(*apiError); !ok || e.Status != http.StatusUnauthorized {
+		t.Fatalf("unauthorized: %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// instance 实例列表中用到的字段
+type instance struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	InstanceType string `json:"instance_type"`
+	Provider     string `json:"provider"`
+	OSType       string `json:"osType"`
+	CPU          int    `json:"cpu"`
+	Memory       int64  `json:"memory"`
+	Disk         int64  `json:"disk"`
+	PublicIP     string `json:"publicIP"`
+	SSHPort      int    `json:"sshPort"`
+}
+
+// pageData 分页响应
+type pageData[T any] struct {
+	List          []T    `json:"list"`
+	Total         int64  `json:"total"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func runInstances(a *app, args []string) error {
+	sub, args, err := subcommand(args, "instances")
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "list", "ls":
+		return listInstances(a, args)
+	case "create":
+		return createInstance(a, args)
+	case "estimate":
+		return estimateInstance(a, args)
+	case "delete", "rm":
+		return instanceAction(a, "delete", args)
+	case "start", "stop", "restart":
+		return instanceAction(a, sub, args)
+	}
+	return fmt.Errorf("未知子命令: instances %s", sub)
+}
+
+func listInstances(a *app, args []string) error {
+	fs := flag.NewFlagSet("instances list", flag.ContinueOnError)
+	status := fs.String("status", "", "按状态过滤")
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 50, "每页数量")
+	live := fs.Bool("live", false, "从节点获取实时状态")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	query := url.Values{"page": {strconv.Itoa(*page)}, "pageSize": {strconv.Itoa(*pageSize)}}
+	if *status != "" {
+		query.Set("status", *status)
+	}
+	if *live {
+		query.Set("live", "true")
+	}
+	var data pageData[instance]
+	if err := a.api.do(a.ctx, http.MethodGet, "/v1/user/instances", query, nil, &data); err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(data.List))
+	for _, inst := range data.List {
+		ssh := "-"
+		if inst.PublicIP != "" {
+			ssh = fmt.Sprintf("%s:%d", inst.PublicIP, inst.SSHPort)
+		}
+		rows = append(rows, []interface{}{inst.ID, inst.Name, inst.Status, inst.InstanceType, inst.Provider,
+			fmt.Sprintf("%dC/%dM/%dM", inst.CPU, inst.Memory, inst.Disk), ssh})
+	}
+	return a.print(data, []string{"ID", "NAME", "STATUS", "TYPE", "PROVIDER", "SPEC", "SSH"}, rows)
+}
+
+func createInstance(a *app, args []string) error {
+	fs := flag.NewFlagSet("instances create", flag.ContinueOnError)
+	providerID := fs.Uint("provider", 0, "节点ID，不填时使用实例默认设置")
+	imageID := fs.Uint("image", 0, "镜像ID，不填时使用实例默认设置")
+	cpu := fs.String("cpu", "", "CPU规格ID")
+	memory := fs.String("memory", "", "内存规格ID")
+	disk := fs.String("disk", "", "磁盘规格ID")
+	bandwidth := fs.String("bandwidth", "", "带宽规格ID")
+	description := fs.String("description", "", "描述")
+	appID := fs.Uint("app", 0, "一键应用ID")
+	tags := fs.String("tags", "", "实例标签（逗号分隔），不填时使用实例默认设置")
+	count := fs.Int("count", 1, "创建数量，最多10个")
+	spread := fs.String("spread", "", "未指定节点时分散放置：provider 分散到不同节点，region 分散到不同地区")
+	wait := fs.Bool("wait", false, "等待创建任务结束并输出进度")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *cpu == "" || *memory == "" || *disk == "" || *bandwidth == "" {
+		return errors.New("--cpu、--memory、--disk、--bandwidth 均为必填")
+	}
+	if *count < 1 || *count > 10 {
+		return errors.New("--count 必须在1到10之间")
+	}
+	if *spread != "" && *spread != "provider" && *spread != "region" {
+		return errors.New("--spread 只能是 provider 或 region")
+	}
+
+	body := map[string]interface{}{
+		"providerId":  *providerID,
+		"imageId":     *imageID,
+		"cpuId":       *cpu,
+		"memoryId":    *memory,
+		"diskId":      *disk,
+		"bandwidthId": *bandwidth,
+		"description": *description,
+		"appId":       *appID,
+		"tags":        *tags,
+		"spread":      *spread,
+	}
+	if *count > 1 {
+		body["count"] = *count
+		return createInstances(a, body, *wait)
+	}
+	var result struct {
+		TaskID uint   `json:"taskId"`
+		Status string `json:"status"`
+	}
+	if err := a.api.do(a.ctx, http.MethodPost, "/v1/user/instances", nil, body, &result); err != nil {
+		return err
+	}
+	if !*wait {
+		return a.print(result, []string{"TASK", "STATUS"}, [][]interface{}{{result.TaskID, result.Status}})
+	}
+	fmt.Fprintf(a.stdout, "创建任务 %d 已提交\n", result.TaskID)
+	return tailTask(a, result.TaskID)
+}
+
+// createInstances 批量创建实例，--wait 时依次等待各创建任务结束，有任务失败时返回第一个错误
+func createInstances(a *app, body map[string]interface{}, wait bool) error {
+	var results []struct {
+		Index      int    `json:"index"`
+		TaskID     uint   `json:"taskId"`
+		ProviderID uint   `json:"providerId"`
+		Status     string `json:"status"`
+		Error      string `json:"error"`
+	}
+	if err := a.api.do(a.ctx, http.MethodPost, "/v1/user/instances/batch", nil, body, &results); err != nil {
+		return err
+	}
+	rows := make([][]interface{}, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, []interface{}{r.Index, r.TaskID, r.ProviderID, r.Status, r.Error})
+	}
+	if err := a.print(results, []string{"#", "TASK", "PROVIDER", "STATUS", "ERROR"}, rows); err != nil {
+		return err
+	}
+	if !wait {
+		return nil
+	}
+	var failed error
+	for _, r := range results {
+		if r.TaskID == 0 {
+			continue
+		}
+		fmt.Fprintf(a.stdout, "等待创建任务 %d\n", r.TaskID)
+		if err := tailTask(a, r.TaskID); err != nil {
+			fmt.Fprintln(a.stdout, err)
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+	return failed
+}
+
+// estimateInstance 估算创建实例的等待时间
+func estimateInstance(a *app, args []string) error {
+	fs := flag.NewFlagSet("instances estimate", flag.ContinueOnError)
+	providerID := fs.Uint("provider", 0, "节点ID，不填时使用实例默认设置")
+	imageID := fs.Uint("image", 0, "镜像ID，不填时使用实例默认设置")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *providerID != 0 {
+		query.Set("providerId", strconv.FormatUint(uint64(*providerID), 10))
+	}
+	if *imageID != 0 {
+		query.Set("imageId", strconv.FormatUint(uint64(*imageID), 10))
+	}
+	var data struct {
+		ProviderName         string `json:"providerName"`
+		ImageName            string `json:"imageName"`
+		PendingTasks         int    `json:"pendingTasks"`
+		RunningTasks         int    `json:"runningTasks"`
+		QueueWaitSeconds     int    `json:"queueWaitSeconds"`
+		ImageCached          bool   `json:"imageCached"`
+		ImageDownloadSeconds int    `json:"imageDownloadSeconds"`
+		CreateSeconds        int    `json:"createSeconds"`
+		TotalSeconds         int    `json:"totalSeconds"`
+		Basis                string `json:"basis"`
+	}
+	if err := a.api.do(a.ctx, http.MethodGet, "/v1/user/instances/create-estimate", query, nil, &data); err != nil {
+		return err
+	}
+	seconds := func(s int) string { return (time.Duration(s) * time.Second).String() }
+	return a.print(data, []string{"PROVIDER", "IMAGE", "QUEUE", "WAIT", "CACHED", "DOWNLOAD", "CREATE", "TOTAL", "BASIS"},
+		[][]interface{}{{data.ProviderName, data.ImageName, fmt.Sprintf("%d/%d", data.RunningTasks, data.PendingTasks),
+			seconds(data.QueueWaitSeconds), data.ImageCached, seconds(data.ImageDownloadSeconds),
+			seconds(data.CreateSeconds), seconds(data.TotalSeconds), data.Basis}})
+}
+
+// instanceAction 执行实例操作，删除等操作通过任务异步完成
+func instanceAction(a *app, action string, args []string) error {
+	fs := flag.NewFlagSet("instances "+action, flag.ContinueOnError)
+	wait := fs.Bool("wait", false, "等待操作任务结束并输出进度")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("用法: ocvctl instances %s ID", action)
+	}
+	id, err := strconv.ParseUint(positional[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的实例ID: %s", positional[0])
+	}
+
+	startedAt := time.Now()
+	body := map[string]interface{}{"instanceId": id, "action": action}
+	if err := a.api.do(a.ctx, http.MethodPost, "/v1/user/instances/action", nil, body, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "实例 %d 的 %s 操作已提交\n", id, action)
+	if !*wait {
+		return nil
+	}
+	taskID, err := findInstanceTask(a, uint(id), startedAt)
+	if err != nil {
+		return err
+	}
+	return tailTask(a, taskID)
+}
+
+// findInstanceTask 查找刚提交的实例任务，操作接口不返回任务ID
+func findInstanceTask(a *app, instanceID uint, since time.Time) (uint, error) {
+	query := url.Values{"page": {"1"}, "pageSize": {"20"}}
+	var data pageData[task]
+	if err := a.api.do(a.ctx, http.MethodGet, "/v1/user/tasks", query, nil, &data); err != nil {
+		return 0, err
+	}
+	for _, t := range data.List {
+		if t.InstanceID != nil && *t.InstanceID == instanceID && !t.CreatedAt.Before(since.Add(-time.Minute)) {
+			return t.ID, nil
+		}
+	}
+	return 0, errors.New("未找到对应的任务，请使用 ocvctl tasks list 查看")
+}
@@ -0,0 +1,151 @@
+// ocvctl 是 OneClickVirt 的命令行客户端，使用个人API令牌调用服务端API
+//
+// 在网页的API令牌管理中创建令牌后，通过 --token 参数或 OCV_TOKEN 环境变量传入，
+// 服务端地址通过 --server 参数或 OCV_SERVER 环境变量指定。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+)
+
+const usage = `ocvctl - OneClickVirt 命令行客户端
+
+用法:
+  ocvctl [全局参数] <命令> [子命令] [参数]
+
+全局参数:
+  --server URL    服务端地址，默认读取 OCV_SERVER，未设置时为 http://127.0.0.1:8888
+  --token TOKEN   个人API令牌（ocv_开头），默认读取 OCV_TOKEN
+  --json          以JSON格式输出
+
+命令:
+  instances list [--status S] [--page N] [--page-size N] [--live]
+  instances create --provider ID --image ID --cpu ID --memory ID --disk ID --bandwidth ID [--description D] [--count N] [--spread provider|region] [--wait]
+  instances estimate [--provider ID] [--image ID]
+  instances delete ID [--wait]
+  instances start|stop|restart ID
+  tasks list [--status S]
+  tasks tail TASK_ID
+  ports list [--instance ID]
+  ports add --instance ID --guest-port P [--protocol tcp|udp|both] [--host-port P] [--count N] [--description D]   (需要管理员令牌)
+  ports delete ID   (需要管理员令牌)
+`
+
+// app 命令执行上下文
+type app struct {
+	ctx    context.Context
+	api    *client
+	json   bool
+	stdout io.Writer
+}
+
+// print 以表格或JSON输出，rows的每一行与header对应
+func (a *app) print(raw interface{}, header []string, rows [][]interface{}) error {
+	if a.json {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(raw)
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	for i, h := range header {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, h)
+	}
+	fmt.Fprintln(w)
+	for _, row := range rows {
+		for i, v := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, v)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("ocvctl", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	server := global.String("server", envOr("OCV_SERVER", "http://127.0.0.1:8888"), "服务端地址")
+	token := global.String("token", os.Getenv("OCV_TOKEN"), "个人API令牌")
+	jsonOutput := global.Bool("json", false, "以JSON格式输出")
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	rest := global.Args()
+	if len(rest) == 0 {
+		global.Usage()
+		return errors.New("缺少命令")
+	}
+	if *token == "" {
+		return errors.New("未提供API令牌，请使用 --token 参数或设置 OCV_TOKEN 环境变量")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	a := &app{ctx: ctx, api: newClient(*server, *token), json: *jsonOutput, stdout: os.Stdout}
+
+	commands := map[string]func(*app, []string) error{
+		"instances": runInstances,
+		"tasks":     runTasks,
+		"ports":     runPorts,
+	}
+	cmd, ok := commands[rest[0]]
+	if !ok {
+		global.Usage()
+		return fmt.Errorf("未知命令: %s", rest[0])
+	}
+	return cmd(a, rest[1:])
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// subcommand 取出子命令及其参数
+func subcommand(args []string, group string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%s 缺少子命令，运行 ocvctl --help 查看用法", group)
+	}
+	return args[0], args[1:], nil
+}
+
+// parseFlags 解析子命令参数，允许位置参数出现在参数之前
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// portMapping 端口映射列表中用到的字段
+type portMapping struct {
+	ID           uint   `json:"id"`
+	InstanceID   uint   `json:"instanceId"`
+	InstanceName string `json:"instanceName"`
+	HostPort     int    `json:"hostPort"`
+	GuestPort    int    `json:"guestPort"`
+	Protocol     string `json:"protocol"`
+	Status       string `json:"status"`
+	Description  string `json:"description"`
+}
+
+func runPorts(a *app, args []string) error {
+	sub, args, err := subcommand(args, "ports")
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "list", "ls":
+		return listPorts(a, args)
+	case "add":
+		return addPort(a, args)
+	case "delete", "rm":
+		return deletePort(a, args)
+	}
+	return fmt.Errorf("未知子命令: ports %s", sub)
+}
+
+func listPorts(a *app, args []string) error {
+	fs := flag.NewFlagSet("ports list", flag.ContinueOnError)
+	instanceID := fs.Uint("instance", 0, "只列出指定实例的端口映射")
+	page := fs.Int("page", 1, "页码")
+	limit := fs.Int("limit", 100, "每页数量")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var data struct {
+		List  []portMapping `json:"list"`
+		Total int64         `json:"total"`
+	}
+	if *instanceID > 0 {
+		if err := a.api.do(a.ctx, http.MethodGet, fmt.Sprintf("/v1/user/instances/%d/ports", *instanceID), nil, nil, &data); err != nil {
+			return err
+		}
+		for i := range data.List {
+			data.List[i].InstanceID = *instanceID
+		}
+	} else {
+		query := url.Values{"page": {strconv.Itoa(*page)}, "limit": {strconv.Itoa(*limit)}}
+		if err := a.api.do(a.ctx, http.MethodGet, "/v1/user/port-mappings", query, nil, &data); err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]interface{}, 0, len(data.List))
+	for _, p := range data.List {
+		rows = append(rows, []interface{}{p.ID, p.InstanceID, p.HostPort, p.GuestPort, p.Protocol, p.Status, p.Description})
+	}
+	return a.print(data, []string{"ID", "INSTANCE", "HOST", "GUEST", "PROTO", "STATUS", "DESCRIPTION"}, rows)
+}
+
+func addPort(a *app, args []string) error {
+	fs := flag.NewFlagSet("ports add", flag.ContinueOnError)
+	instanceID := fs.Uint("instance", 0, "实例ID")
+	guestPort := fs.Int("guest-port", 0, "实例内端口（端口段的起始端口）")
+	hostPort := fs.Int("host-port", 0, "宿主机端口，不指定则自动分配")
+	count := fs.Int("count", 1, "端口数量，大于1时映射连续端口段")
+	protocol := fs.String("protocol", "tcp", "协议：tcp, udp, both")
+	description := fs.String("description", "", "端口用途描述")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *instanceID == 0 || *guestPort == 0 {
+		return errors.New("--instance 和 --guest-port 为必填")
+	}
+
+	body := map[string]interface{}{
+		"instanceId":  *instanceID,
+		"guestPort":   *guestPort,
+		"hostPort":    *hostPort,
+		"portCount":   *count,
+		"protocol":    *protocol,
+		"description": *description,
+	}
+	var result struct {
+		TaskID uint `json:"taskId"`
+		PortID uint `json:"portId"`
+	}
+	if err := a.api.do(a.ctx, http.MethodPost, "/v1/admin/port-mappings", nil, body, &result); err != nil {
+		return err
+	}
+	return a.print(result, []string{"PORT", "TASK"}, [][]interface{}{{result.PortID, result.TaskID}})
+}
+
+func deletePort(a *app, args []string) error {
+	if len(args) != 1 {
+		return errors.New("用法: ocvctl ports delete ID")
+	}
+	id, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("无效的端口映射ID: %s", args[0])
+	}
+	if err := a.api.do(a.ctx, http.MethodDelete, fmt.Sprintf("/v1/admin/port-mappings/%d", id), nil, nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "端口映射 %d 已删除\n", id)
+	return nil
+}
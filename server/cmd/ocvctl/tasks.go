@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// task 任务列表中用到的字段
+type task struct {
+	ID            uint      `json:"id"`
+	TaskType      string    `json:"taskType"`
+	Status        string    `json:"status"`
+	Progress      int       `json:"progress"`
+	StatusMessage string    `json:"statusMessage"`
+	ErrorMessage  string    `json:"errorMessage"`
+	InstanceID    *uint     `json:"instanceId"`
+	InstanceName  string    `json:"instanceName"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// taskEvent 任务进度事件
+type taskEvent struct {
+	TaskID   uint   `json:"taskId"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Message  string `json:"message"`
+}
+
+func runTasks(a *app, args []string) error {
+	sub, args, err := subcommand(args, "tasks")
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "list", "ls":
+		return listTasks(a, args)
+	case "tail":
+		if len(args) != 1 {
+			return errors.New("用法: ocvctl tasks tail TASK_ID")
+		}
+		id, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("无效的任务ID: %s", args[0])
+		}
+		return tailTask(a, uint(id))
+	}
+	return fmt.Errorf("未知子命令: tasks %s", sub)
+}
+
+func listTasks(a *app, args []string) error {
+	fs := flag.NewFlagSet("tasks list", flag.ContinueOnError)
+	status := fs.String("status", "", "按状态过滤")
+	page := fs.Int("page", 1, "页码")
+	pageSize := fs.Int("page-size", 20, "每页数量")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	query := url.Values{"page": {strconv.Itoa(*page)}, "pageSize": {strconv.Itoa(*pageSize)}}
+	if *status != "" {
+		query.Set("status", *status)
+	}
+	var data pageData[task]
+	if err := a.api.do(a.ctx, http.MethodGet, "/v1/user/tasks", query, nil, &data); err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(data.List))
+	for _, t := range data.List {
+		message := t.StatusMessage
+		if t.ErrorMessage != "" {
+			message = t.ErrorMessage
+		}
+		rows = append(rows, []interface{}{t.ID, t.TaskType, t.Status, fmt.Sprintf("%d%%", t.Progress), t.InstanceName,
+			t.CreatedAt.Local().Format("2006-01-02 15:04:05"), message})
+	}
+	return a.print(data, []string{"ID", "TYPE", "STATUS", "PROGRESS", "INSTANCE", "CREATED", "MESSAGE"}, rows)
+}
+
+// tailTask 订阅任务进度直到任务结束，任务失败或取消时返回错误
+func tailTask(a *app, taskID uint) error {
+	var last taskEvent
+	err := a.api.stream(a.ctx, fmt.Sprintf("/v1/user/tasks/%d/events", taskID), func(ev sseEvent) bool {
+		if ev.Name == "ping" {
+			return true
+		}
+		var event taskEvent
+		if err := json.Unmarshal([]byte(ev.Data), &event); err != nil {
+			return true
+		}
+		last = event
+		if a.json {
+			fmt.Fprintln(a.stdout, ev.Data)
+		} else {
+			fmt.Fprintf(a.stdout, "[%s] %-10s %3d%%  %s\n", time.Now().Format("15:04:05"), event.Status, event.Progress, event.Message)
+		}
+		return ev.Name != "done"
+	})
+	if err != nil {
+		return err
+	}
+	switch last.Status {
+	case "completed":
+		return nil
+	case "":
+		return errors.New("连接已断开，任务仍在后台执行")
+	case "failed", "cancelled", "timeout":
+		return fmt.Errorf("任务 %d 结束状态: %s", taskID, last.Status)
+	}
+	return errors.New("连接已断开，任务仍在后台执行")
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRList 解析IP或CIDR列表，单个IP按/32或/128处理，空白项忽略
+func ParseCIDRList(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("无效的CIDR: %s", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("无效的IP: %s", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// validateCIDRList 校验通过配置接口提交的IP或CIDR列表
+func validateCIDRList(value interface{}) error {
+	var entries []string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []string:
+		entries = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("IP列表只能包含字符串")
+			}
+			entries = append(entries, s)
+		}
+	default:
+		return fmt.Errorf("IP列表类型错误，期望字符串数组")
+	}
+	_, err := ParseCIDRList(entries)
+	return err
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeAssetDomain 校验并规范化静态资源CDN域名，只接受不带路径参数的 http(s) 地址，去掉末尾斜杠
+func NormalizeAssetDomain(domain string) (string, error) {
+	domain = strings.TrimRight(strings.TrimSpace(domain), "/")
+	if domain == "" {
+		return "", nil
+	}
+	u, err := url.Parse(domain)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("无效的CDN域名: %s", domain)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("CDN域名必须以 http:// 或 https:// 开头: %s", domain)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("CDN域名不能包含查询参数、锚点或用户信息: %s", domain)
+	}
+	return domain, nil
+}
+
+// validateAssetDomain 校验通过配置接口提交的静态资源CDN域名
+func validateAssetDomain(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		_, err := NormalizeAssetDomain(v)
+		return err
+	default:
+		return fmt.Errorf("CDN域名类型错误，期望字符串")
+	}
+}
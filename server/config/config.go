@@ -5,6 +5,7 @@ type Server struct {
 	Zap        Zap        `mapstructure:"zap" json:"zap" yaml:"zap"`
 	System     System     `mapstructure:"system" json:"system" yaml:"system"`
 	Mysql      Mysql      `mapstructure:"mysql" json:"mysql" yaml:"mysql"`
+	Sqlite     Sqlite     `mapstructure:"sqlite" json:"sqlite" yaml:"sqlite"`
 	Auth       Auth       `mapstructure:"auth" json:"auth" yaml:"auth"`
 	Quota      Quota      `mapstructure:"quota" json:"quota" yaml:"quota"`
 	InviteCode InviteCode `mapstructure:"invite-code" json:"invite-code" yaml:"invite-code"`
@@ -14,7 +15,37 @@ type Server struct {
 	CDN        CDN        `mapstructure:"cdn" json:"cdn" yaml:"cdn"`
 	Task       Task       `mapstructure:"task" json:"task" yaml:"task"`
 	Upload     Upload     `mapstructure:"upload" json:"upload" yaml:"upload"`
+	Oss        Oss        `mapstructure:"oss" json:"oss" yaml:"oss"`
 	Other      Other      `mapstructure:"other" json:"other" yaml:"other"`
+	Retention  Retention  `mapstructure:"retention" json:"retention" yaml:"retention"`
+	Cluster    Cluster    `mapstructure:"cluster" json:"cluster" yaml:"cluster"`
+
+	ConsistencyAudit ConsistencyAudit `mapstructure:"consistency-audit" json:"consistency-audit" yaml:"consistency-audit"`
+	InstanceDefaults InstanceDefaults `mapstructure:"instance-defaults" json:"instance-defaults" yaml:"instance-defaults"`
+	Blackout         Blackout         `mapstructure:"blackout" json:"blackout" yaml:"blackout"`
+	DiskUsage        DiskUsage        `mapstructure:"disk-usage" json:"disk-usage" yaml:"disk-usage"`
+	Registration     Registration     `mapstructure:"registration" json:"registration" yaml:"registration"`
+	OSEOL            OSEOL            `mapstructure:"os-eol" json:"os-eol" yaml:"os-eol"`
+	Hardening        Hardening        `mapstructure:"hardening" json:"hardening" yaml:"hardening"`
+	DataExport       DataExport       `mapstructure:"data-export" json:"data-export" yaml:"data-export"`
+	HardwareCheck    HardwareCheck    `mapstructure:"hardware-check" json:"hardware-check" yaml:"hardware-check"`
+	MOTD             MOTD             `mapstructure:"motd" json:"motd" yaml:"motd"`
+	IdleStop         IdleStop         `mapstructure:"idle-stop" json:"idle-stop" yaml:"idle-stop"`
+	Snapshot         Snapshot         `mapstructure:"snapshot" json:"snapshot" yaml:"snapshot"`
+	Forecast         Forecast         `mapstructure:"forecast" json:"forecast" yaml:"forecast"`
+	RDNS             RDNS             `mapstructure:"rdns" json:"rdns" yaml:"rdns"`
+	HealthCheck      HealthCheck      `mapstructure:"health-check" json:"health-check" yaml:"health-check"`
+	AdminAccess      AdminAccess      `mapstructure:"admin-access" json:"admin-access" yaml:"admin-access"`
+	PasswordPolicy   PasswordPolicy   `mapstructure:"password-policy" json:"password-policy" yaml:"password-policy"`
+
+	ConfigConsistency ConfigConsistency `mapstructure:"config-consistency" json:"config-consistency" yaml:"config-consistency"`
+	ConsoleRecording  ConsoleRecording  `mapstructure:"console-recording" json:"console-recording" yaml:"console-recording"`
+	NetworkProbe      NetworkProbe      `mapstructure:"network-probe" json:"network-probe" yaml:"network-probe"`
+	HostEvents        HostEvents        `mapstructure:"host-events" json:"host-events" yaml:"host-events"`
+
+	ControlPlaneBackup ControlPlaneBackup `mapstructure:"control-plane-backup" json:"control-plane-backup" yaml:"control-plane-backup"`
+	UserWebhook        UserWebhook        `mapstructure:"user-webhook" json:"user-webhook" yaml:"user-webhook"`
+	HostScript         HostScript         `mapstructure:"host-script" json:"host-script" yaml:"host-script"`
 }
 
 type Other struct {
@@ -45,6 +76,13 @@ type Quota struct {
 	DefaultLevel            int                     `mapstructure:"default-level" json:"default-level" yaml:"default-level"`
 	LevelLimits             map[int]LevelLimitInfo  `mapstructure:"level-limits" json:"level-limits" yaml:"level-limits"`
 	InstanceTypePermissions InstanceTypePermissions `mapstructure:"instance-type-permissions" json:"instance-type-permissions" yaml:"instance-type-permissions"`
+	APILimits               map[string]APILimit     `mapstructure:"api-limits" json:"api-limits" yaml:"api-limits"` // 按操作类别的接口调用配额，键为操作类别
+}
+
+// APILimit 一类控制面接口的令牌桶配额，令牌在一个周期内匀速补满
+type APILimit struct {
+	Period int         `mapstructure:"period" json:"period" yaml:"period"` // 令牌补满周期（秒）
+	Levels map[int]int `mapstructure:"levels" json:"levels" yaml:"levels"` // 各等级每个周期可调用的次数（桶容量），未配置或为0的等级不限制
 }
 
 type InstanceTypePermissions struct {
@@ -58,16 +96,26 @@ type InstanceTypePermissions struct {
 
 type LevelLimitInfo struct {
 	MaxInstances int                    `mapstructure:"max-instances" json:"max-instances" yaml:"max-instances"`
+	MaxRunning   int                    `mapstructure:"max-running" json:"max-running" yaml:"max-running"`       // 可同时运行的实例数，0表示不单独限制（以max-instances为准）
+	MaxSnapshots int                    `mapstructure:"max-snapshots" json:"max-snapshots" yaml:"max-snapshots"` // 每个实例定时快照最多保留的数量，0表示不允许定时快照
 	MaxResources map[string]interface{} `mapstructure:"max-resources" json:"max-resources" yaml:"max-resources"`
 	MaxTraffic   int64                  `mapstructure:"max-traffic" json:"max-traffic" yaml:"max-traffic"` // 最大流量限制（MB）
 	ExpiryDays   int                    `mapstructure:"expiry-days" json:"expiry-days" yaml:"expiry-days"` // 新注册用户的默认过期天数，0表示不过期
 }
 
+// RunningLimit 返回可同时运行的实例数上限，未单独配置或大于拥有上限时等于最大实例数
+func (l LevelLimitInfo) RunningLimit() int {
+	if l.MaxRunning > 0 && l.MaxRunning < l.MaxInstances {
+		return l.MaxRunning
+	}
+	return l.MaxInstances
+}
+
 type System struct {
 	Env                     string `mapstructure:"env" json:"env" yaml:"env"`                                                                      // 环境值
 	Addr                    int    `mapstructure:"addr" json:"addr" yaml:"addr"`                                                                   // 端口值
-	DbType                  string `mapstructure:"db-type" json:"db-type" yaml:"db-type"`                                                          // 数据库类型:mysql(默认)|mariadb
-	OssType                 string `mapstructure:"oss-type" json:"oss-type" yaml:"oss-type"`                                                       // Oss类型
+	DbType                  string `mapstructure:"db-type" json:"db-type" yaml:"db-type"`                                                          // 数据库类型:mysql(默认)|mariadb|postgres|sqlite
+	OssType                 string `mapstructure:"oss-type" json:"oss-type" yaml:"oss-type"`                                                       // Oss类型:local(默认)|s3|minio
 	UseMultipoint           bool   `mapstructure:"use-multipoint" json:"use-multipoint" yaml:"use-multipoint"`                                     // 多点登录拦截
 	UseRedis                bool   `mapstructure:"use-redis" json:"use-redis" yaml:"use-redis"`                                                    // 使用redis
 	LimitCountIP            int    `mapstructure:"iplimit-count" json:"iplimit-count" yaml:"iplimit-count"`                                        // IP限流计数
@@ -75,11 +123,13 @@ type System struct {
 	FrontendURL             string `mapstructure:"frontend-url" json:"frontend-url" yaml:"frontend-url"`                                           // 前端URL，用于OAuth2回调跳转
 	ProviderInactiveHours   int    `mapstructure:"provider-inactive-hours" json:"provider-inactive-hours" yaml:"provider-inactive-hours"`          // Provider不活动阈值（小时），默认72小时
 	OAuth2StateTokenMinutes int    `mapstructure:"oauth2-state-token-minutes" json:"oauth2-state-token-minutes" yaml:"oauth2-state-token-minutes"` // OAuth2 State令牌有效期（分钟），默认15分钟
+	Timezone                string `mapstructure:"timezone" json:"timezone" yaml:"timezone"`                                                       // 默认时区，如 Asia/Shanghai，用于未设置时区的用户和Provider的流量重置与统计，为空时使用服务器本地时区
 
 	// 实例同步配置
 	EnableInstanceSync    bool `mapstructure:"enable-instance-sync" json:"enable-instance-sync" yaml:"enable-instance-sync"`          // 是否启用实例同步检查，默认false
 	InstanceSyncInterval  int  `mapstructure:"instance-sync-interval" json:"instance-sync-interval" yaml:"instance-sync-interval"`    // 实例同步检查间隔（分钟），默认30分钟
 	ImportedInstanceOwner uint `mapstructure:"imported-instance-owner" json:"imported-instance-owner" yaml:"imported-instance-owner"` // 导入实例的默认所有者用户ID，默认1（管理员）
+	StartupConcurrency    int  `mapstructure:"startup-concurrency" json:"startup-concurrency" yaml:"startup-concurrency"`             // 批量恢复实例时每个Provider同时启动的实例数，默认2
 }
 
 type JWT struct {
@@ -89,7 +139,7 @@ type JWT struct {
 	Issuer      string `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                   // 签发者
 }
 
-// Database 数据库配置，支持MySQL和MariaDB
+// Database 数据库配置，支持MySQL、MariaDB和PostgreSQL（db-type: postgres 时同样使用本配置段）
 type Mysql struct {
 	Path         string `mapstructure:"path" json:"path" yaml:"path"`                               // 服务器地址:端口
 	Port         string `mapstructure:"port" json:"port" yaml:"port"`                               //:端口
@@ -108,6 +158,15 @@ type Mysql struct {
 	AutoCreate   bool   `mapstructure:"auto-create" json:"auto-create" yaml:"auto-create"`          // 是否自动创建数据库
 }
 
+// Sqlite SQLite嵌入式数据库配置（db-type: sqlite 时使用），适合单节点的小规模部署
+type Sqlite struct {
+	Path         string `mapstructure:"path" json:"path" yaml:"path"`                               // 数据库文件路径，不存在时自动创建
+	BusyTimeout  int    `mapstructure:"busy-timeout" json:"busy-timeout" yaml:"busy-timeout"`       // 等待其他连接释放写锁的超时时间（毫秒）
+	JournalMode  string `mapstructure:"journal-mode" json:"journal-mode" yaml:"journal-mode"`       // 日志模式，默认WAL（读写互不阻塞）
+	MaxOpenConns int    `mapstructure:"max-open-conns" json:"max-open-conns" yaml:"max-open-conns"` // 打开到数据库的最大连接数
+	LogMode      string `mapstructure:"log-mode" json:"log-mode" yaml:"log-mode"`                   // 是否开启Gorm全局日志
+}
+
 type InviteCode struct {
 	Enabled  bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`    // 是否启用邀请码
 	Required bool `mapstructure:"required" json:"required" yaml:"required"` // 是否必须邀请码
@@ -132,15 +191,330 @@ type Redis struct {
 type CDN struct {
 	Endpoints    []string `mapstructure:"endpoints" json:"endpoints" yaml:"endpoints"`             // CDN端点列表
 	BaseEndpoint string   `mapstructure:"base-endpoint" json:"base-endpoint" yaml:"base-endpoint"` // 基础CDN端点
+
+	// 静态资源CDN，与上面的镜像下载加速端点无关
+	AssetDomain  string `mapstructure:"asset-domain" json:"asset-domain" yaml:"asset-domain"`    // 前端静态资源和头像使用的CDN域名（含协议），为空表示不改写
+	AssetVersion string `mapstructure:"asset-version" json:"asset-version" yaml:"asset-version"` // 资源版本号，以 v 参数追加到改写后的URL，修改后CDN缓存随之失效
+	SignKey      string `mapstructure:"sign-key" json:"sign-key" yaml:"sign-key"`                // 私有资源签名密钥，为空表示不提供私有资源访问
+	SignExpire   int    `mapstructure:"sign-expire" json:"sign-expire" yaml:"sign-expire"`       // 私有资源签名有效期（秒），默认3600
 }
 
 // Task 任务配置
 type Task struct {
-	DeleteRetryCount int `mapstructure:"delete-retry-count" json:"delete-retry-count" yaml:"delete-retry-count"` // 删除实例重试次数，默认3
-	DeleteRetryDelay int `mapstructure:"delete-retry-delay" json:"delete-retry-delay" yaml:"delete-retry-delay"` // 删除实例重试延迟（秒），默认2
+	DeleteRetryCount  int  `mapstructure:"delete-retry-count" json:"delete-retry-count" yaml:"delete-retry-count"`    // 删除实例重试次数，默认3
+	DeleteRetryDelay  int  `mapstructure:"delete-retry-delay" json:"delete-retry-delay" yaml:"delete-retry-delay"`    // 删除实例重试延迟（秒），默认2
+	PostCreateVerify  bool `mapstructure:"post-create-verify" json:"post-create-verify" yaml:"post-create-verify"`    // 创建实例后是否执行冒烟验证（SSH、DNS、外网、磁盘），默认关闭
+	UserHooksEnabled  bool `mapstructure:"user-hooks-enabled" json:"user-hooks-enabled" yaml:"user-hooks-enabled"`    // 是否允许用户注册创建/重置后执行的钩子脚本，默认关闭
+	UserHookMinLevel  int  `mapstructure:"user-hook-min-level" json:"user-hook-min-level" yaml:"user-hook-min-level"` // 允许使用钩子脚本的最低用户等级，0表示不限
+	UserHookMaxSize   int  `mapstructure:"user-hook-max-size" json:"user-hook-max-size" yaml:"user-hook-max-size"`    // 单个钩子脚本最大大小（KB），默认16
+	UserHookTimeout   int  `mapstructure:"user-hook-timeout" json:"user-hook-timeout" yaml:"user-hook-timeout"`       // 单个钩子脚本执行超时（秒），默认300
+	NameReuseCooldown int  `mapstructure:"name-reuse-cooldown" json:"name-reuse-cooldown" yaml:"name-reuse-cooldown"` // 实例删除后同一节点上同名实例的重用冷却时间（分钟），0表示不限制；重置实例不受限制
+
+	FailurePoints []string `mapstructure:"failure-points" json:"failure-points" yaml:"failure-points"` // 开发调试用：在指定任务阶段注入故障以验证回滚，仅 system.env 为 development/debug 时生效
+}
+
+// Retention 监控数据保留与降采样配置
+type Retention struct {
+	Enabled      bool                       `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                      // 是否启用保留策略管理器，关闭时沿用内置的pmacct分层清理
+	RunHour      int                        `mapstructure:"run-hour" json:"run-hour" yaml:"run-hour"`                   // 每日执行时刻（0-23），默认3
+	BatchSize    int                        `mapstructure:"batch-size" json:"batch-size" yaml:"batch-size"`             // 每批删除行数，默认5000
+	BatchPauseMs int                        `mapstructure:"batch-pause-ms" json:"batch-pause-ms" yaml:"batch-pause-ms"` // 批次之间的暂停时间（毫秒），默认200，降低对数据库的压力
+	Tables       map[string]RetentionPolicy `mapstructure:"tables" json:"tables" yaml:"tables"`                         // 按表配置的保留策略，键为表名
+}
+
+// ConsistencyAudit 配额与资源计数一致性审计配置
+// 定时根据实例记录重新计算用户配额和Provider资源占用，与已记录的计数比较并报告偏差
+type ConsistencyAudit struct {
+	Enabled     bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                // 是否启用定时审计，启用后维护任务不再静默修复用户配额
+	Interval    int  `mapstructure:"interval" json:"interval" yaml:"interval"`             // 审计间隔（分钟），默认60
+	AutoCorrect bool `mapstructure:"auto-correct" json:"auto-correct" yaml:"auto-correct"` // 定时审计发现偏差时是否自动修正，修正记录写入审计日志
+}
+
+// InstanceDefaults 实例默认环境变量和元数据配置
+// 创建和重置实例时按顺序匹配规则，渲染后写入实例配置，后匹配的规则覆盖先匹配规则的同名项
+type InstanceDefaults struct {
+	Rules []InstanceDefaultsRule `mapstructure:"rules" json:"rules" yaml:"rules"`
+}
+
+// InstanceDefaultsRule 注入规则，匹配条件为空表示不限
+// 环境变量和元数据使用 KEY=VALUE 列表而不是映射，避免键名被配置加载转为小写
+type InstanceDefaultsRule struct {
+	Providers     []string `mapstructure:"providers" json:"providers" yaml:"providers"`                // 匹配的Provider名称或类型
+	Levels        []int    `mapstructure:"levels" json:"levels" yaml:"levels"`                         // 匹配的用户等级
+	InstanceTypes []string `mapstructure:"instance-types" json:"instance-types" yaml:"instance-types"` // 匹配的实例类型：container、vm
+	Env           []string `mapstructure:"env" json:"env" yaml:"env"`                                  // 环境变量，格式 KEY=VALUE，值中可使用 ${变量}
+	Metadata      []string `mapstructure:"metadata" json:"metadata" yaml:"metadata"`                   // 元数据，格式 key=value，值中可使用 ${变量}
+	Profiles      []string `mapstructure:"profiles" json:"profiles" yaml:"profiles"`                   // LXD/Incus 配置文件，追加在Provider配置文件之后
+}
+
+// Blackout 维护窗口配置
+// 窗口内推迟自动执行的破坏性操作（流量超限停机、到期删除、健康检查自动重启），窗口结束后的下一轮检查再执行
+// Provider 可另外配置自己的窗口，与全局窗口同时生效
+type Blackout struct {
+	Windows  []string `mapstructure:"windows" json:"windows" yaml:"windows"`    // 全局窗口，格式 "mon-fri 19:00-23:00"，省略星期表示每天，结束早于开始表示跨天
+	Timezone string   `mapstructure:"timezone" json:"timezone" yaml:"timezone"` // 窗口使用的时区，如 Asia/Shanghai，为空时使用服务器本地时区
+}
+
+// DiskUsage 实例内磁盘使用量采集配置
+// 定时通过SSH在实例内执行 df 获取根分区实际使用量，精简置备的磁盘在宿主机上无法反映真实占用
+type DiskUsage struct {
+	Enabled        bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用定时采集
+	Interval       int  `mapstructure:"interval" json:"interval" yaml:"interval"`                      // 采集间隔（分钟），默认60
+	WarnPercent    int  `mapstructure:"warn-percent" json:"warn-percent" yaml:"warn-percent"`          // 已用空间达到分配大小的百分比时提示用户，默认90
+	MaxConcurrency int  `mapstructure:"max-concurrency" json:"max-concurrency" yaml:"max-concurrency"` // 同时采集的实例数，默认5
+}
+
+// Registration 公开注册审核配置
+// 开启审核后，未使用邀请码的公开注册先进入待审核队列，管理员批准后才创建账号
+// 邮箱策略用于限制注册邮箱的域名，减少公开部署被批量注册滥用
+type Registration struct {
+	ApprovalRequired bool `mapstructure:"approval-required" json:"approval-required" yaml:"approval-required"`    // 公开注册是否需要管理员审核
+	RequireReason    bool `mapstructure:"require-reason" json:"require-reason" yaml:"require-reason"`             // 申请时是否必须填写理由
+	MaxPerIPPerDay   int  `mapstructure:"max-per-ip-per-day" json:"max-per-ip-per-day" yaml:"max-per-ip-per-day"` // 同一IP每24小时最多提交的申请数，0表示不限
+	MaxPending       int  `mapstructure:"max-pending" json:"max-pending" yaml:"max-pending"`                      // 待审核申请的数量上限，达到后暂停接受新申请，0表示不限
+
+	// 邮箱策略，对公开注册和审核申请同样生效，使用邀请码注册时也会检查
+	RequireEmail           bool     `mapstructure:"require-email" json:"require-email" yaml:"require-email"`                                  // 注册时是否必须填写邮箱，配置了允许域名时总是必须
+	EmailAllowDomains      []string `mapstructure:"email-allow-domains" json:"email-allow-domains" yaml:"email-allow-domains"`                // 只允许这些域名（含子域名）的邮箱注册，为空表示不限
+	EmailDenyDomains       []string `mapstructure:"email-deny-domains" json:"email-deny-domains" yaml:"email-deny-domains"`                   // 禁止这些域名（含子域名）的邮箱注册
+	CheckEmailMX           bool     `mapstructure:"check-email-mx" json:"check-email-mx" yaml:"check-email-mx"`                               // 是否检查邮箱域名存在MX记录（无MX时接受A/AAAA记录）
+	BlockDisposableEmail   bool     `mapstructure:"block-disposable-email" json:"block-disposable-email" yaml:"block-disposable-email"`       // 是否拒绝一次性邮箱
+	DisposableListURL      string   `mapstructure:"disposable-list-url" json:"disposable-list-url" yaml:"disposable-list-url"`                // 一次性邮箱域名列表地址，每行一个域名，为空时只使用内置列表
+	DisposableRefreshHours int      `mapstructure:"disposable-refresh-hours" json:"disposable-refresh-hours" yaml:"disposable-refresh-hours"` // 一次性邮箱列表刷新间隔（小时），默认24
+}
+
+// OSEOL 操作系统生命周期终止（EOL）提醒配置
+// 镜像的EOL日期由管理员在系统镜像上设置，未设置时使用内置的常见发行版EOL日期
+type OSEOL struct {
+	BlockCreation bool `mapstructure:"block-creation" json:"block-creation" yaml:"block-creation"` // 是否禁止使用已EOL的镜像创建新实例
+	WarnDays      int  `mapstructure:"warn-days" json:"warn-days" yaml:"warn-days"`                // EOL前多少天开始提示，默认30
+	NotifyOwners  bool `mapstructure:"notify-owners" json:"notify-owners" yaml:"notify-owners"`    // 是否邮件通知运行EOL系统的实例所有者
+	Interval      int  `mapstructure:"interval" json:"interval" yaml:"interval"`                   // 同步实例EOL状态的间隔（小时），默认24
+}
+
+// Hardening 新实例自动安全加固配置
+// 创建或重置实例后通过SSH在实例内执行，结果写入任务日志；Levels按用户等级（套餐）覆盖全局策略
+type Hardening struct {
+	HardeningPolicy `mapstructure:",squash" yaml:",inline"`
+	Timeout         int                     `mapstructure:"timeout" json:"timeout" yaml:"timeout"` // 加固脚本执行超时（秒），默认600
+	Levels          map[int]HardeningPolicy `mapstructure:"levels" json:"levels" yaml:"levels"`    // 按用户等级覆盖的加固策略，未配置的等级使用全局策略
+}
+
+// HardeningPolicy 实例加固策略
+type HardeningPolicy struct {
+	Enabled             bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                                           // 是否启用自动加固
+	Fail2ban            bool `mapstructure:"fail2ban" json:"fail2ban" yaml:"fail2ban"`                                        // 安装并启用fail2ban
+	UnattendedUpgrades  bool `mapstructure:"unattended-upgrades" json:"unattended-upgrades" yaml:"unattended-upgrades"`       // 启用自动安全更新
+	SSHD                bool `mapstructure:"sshd" json:"sshd" yaml:"sshd"`                                                    // 收紧sshd配置
+	DisablePasswordAuth bool `mapstructure:"disable-password-auth" json:"disable-password-auth" yaml:"disable-password-auth"` // 登录用户已配置公钥时禁用密码登录
+}
+
+// DataExport 用户数据导出配置
+type DataExport struct {
+	ExpireHours int `mapstructure:"expire-hours" json:"expire-hours" yaml:"expire-hours"` // 导出文件下载链接有效期（小时），到期后删除文件，默认24
+	MinInterval int `mapstructure:"min-interval" json:"min-interval" yaml:"min-interval"` // 同一用户两次导出的最小间隔（分钟），默认60
+}
+
+// HardwareCheck 宿主机硬件健康检查配置
+// 检查项在各Provider上单独开启，随Provider健康检查按间隔执行，发现问题时记录告警
+type HardwareCheck struct {
+	Interval      int  `mapstructure:"interval" json:"interval" yaml:"interval"`                   // 检查间隔（分钟），默认30
+	TempThreshold int  `mapstructure:"temp-threshold" json:"temp-threshold" yaml:"temp-threshold"` // 温度告警阈值（℃），Provider未单独设置时使用，默认85
+	NotifyAdmins  bool `mapstructure:"notify-admins" json:"notify-admins" yaml:"notify-admins"`    // 出现新告警时是否邮件通知管理员
+}
+
+// HostEvents 宿主机日志事件采集配置
+// 采集的日志在各Provider上单独开启，随Provider健康检查按间隔通过SSH增量读取，识别OOM、磁盘错误和虚拟化服务错误
+type HostEvents struct {
+	Interval      int `mapstructure:"interval" json:"interval" yaml:"interval"`                   // 采集间隔（分钟），默认5
+	MaxLines      int `mapstructure:"max-lines" json:"max-lines" yaml:"max-lines"`                // 每次每类日志最多读取的行数，默认2000
+	RetentionDays int `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"` // 事件保留天数，默认30
+}
+
+// ControlPlaneBackup 控制面数据灾备导出配置
+// 定时将用户、Provider、实例、端口等控制面数据加密导出到对象存储，备用面板通过 restore 命令恢复
+type ControlPlaneBackup struct {
+	Enabled        bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用定时导出
+	Interval       int    `mapstructure:"interval" json:"interval" yaml:"interval"`                      // 导出间隔（小时），默认24
+	Scope          string `mapstructure:"scope" json:"scope" yaml:"scope"`                               // 导出范围：core 仅核心表，full 额外包含公告、邀请码、分组等业务表，默认core
+	Passphrase     string `mapstructure:"passphrase" json:"passphrase" yaml:"passphrase"`                // 加密口令，为空时不导出；恢复时需要提供相同口令
+	RetentionCount int    `mapstructure:"retention-count" json:"retention-count" yaml:"retention-count"` // 保留最近成功导出的份数，默认7
+}
+
+// UserWebhook 用户Webhook配置
+// 用户注册的接收地址只收到自己实例的事件，请求以HMAC-SHA256签名，投递频率受 quota.api-limits 的 webhook-delivery 配额限制
+type UserWebhook struct {
+	Enabled             bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                                           // 是否允许用户注册Webhook
+	MaxPerUser          int  `mapstructure:"max-per-user" json:"max-per-user" yaml:"max-per-user"`                            // 每个用户最多注册的数量，默认5
+	Timeout             int  `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                                           // 单次请求超时（秒），默认10
+	AllowPrivateNetwork bool `mapstructure:"allow-private-network" json:"allow-private-network" yaml:"allow-private-network"` // 是否允许投递到内网和本机地址，默认不允许
+	RetentionDays       int  `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"`                      // 投递记录保留天数，默认30
+}
+
+// HostScript 宿主机脚本库配置
+// 管理员维护的脚本通过任务在Provider宿主机上执行，执行申请、审批和输出都有记录
+type HostScript struct {
+	RequireApproval   bool `mapstructure:"require-approval" json:"require-approval" yaml:"require-approval"`          // 所有脚本执行都需要审批，为false时按脚本的设置
+	AllowSelfApproval bool `mapstructure:"allow-self-approval" json:"allow-self-approval" yaml:"allow-self-approval"` // 是否允许审批自己发起的执行，只有一个管理员时需要开启
+	MaxTimeout        int  `mapstructure:"max-timeout" json:"max-timeout" yaml:"max-timeout"`                         // 脚本执行超时上限（秒），默认3600
+	MaxOutputKB       int  `mapstructure:"max-output-kb" json:"max-output-kb" yaml:"max-output-kb"`                   // 保存的输出上限（KB），超出时只保留末尾，默认64
+}
+
+// MOTD 实例登录提示与hosts条目注入配置
+// 实例创建或重置后写入 /etc/motd 和 /etc/hosts 中的托管区块，到期时间或流量配额变化时自动刷新
+type MOTD struct {
+	Enabled        bool     `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用注入
+	Template       string   `mapstructure:"template" json:"template" yaml:"template"`                      // MOTD模板（Go text/template），为空时使用内置模板
+	Hosts          []string `mapstructure:"hosts" json:"hosts" yaml:"hosts"`                               // 写入 /etc/hosts 的条目模板，每项一行，渲染后不是合法条目的行会被跳过
+	PanelURL       string   `mapstructure:"panel-url" json:"panel-url" yaml:"panel-url"`                   // 面板地址，模板中以 {{.PanelURL}} 引用
+	SupportContact string   `mapstructure:"support-contact" json:"support-contact" yaml:"support-contact"` // 支持联系方式，模板中以 {{.SupportContact}} 引用
+}
+
+// IdleStop 闲置实例自动停机配置
+// 实例连续多天没有SSH登录且流量接近零时通知所有者，宽限期后仍闲置则停机；Levels按用户等级覆盖全局策略
+type IdleStop struct {
+	IdleStopPolicy `mapstructure:",squash" yaml:",inline"`
+	Interval       int                    `mapstructure:"interval" json:"interval" yaml:"interval"`          // 检测间隔（小时），默认6
+	GraceHours     int                    `mapstructure:"grace-hours" json:"grace-hours" yaml:"grace-hours"` // 通知后仍闲置多少小时停机，默认48
+	Levels         map[int]IdleStopPolicy `mapstructure:"levels" json:"levels" yaml:"levels"`                // 按用户等级覆盖的闲置策略，未配置的等级使用全局策略
+}
+
+// IdleStopPolicy 闲置判定策略
+type IdleStopPolicy struct {
+	Enabled          bool  `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                               // 是否启用闲置停机
+	Days             int   `mapstructure:"days" json:"days" yaml:"days"`                                        // 连续闲置天数，默认7
+	TrafficThreshold int64 `mapstructure:"traffic-threshold" json:"traffic-threshold" yaml:"traffic-threshold"` // 每日流量低于该值（MB）视为闲置，默认10
+}
+
+// Snapshot 实例定时快照配置
+// 用户为实例设置每日或每周快照并指定保留数量（受等级 max-snapshots 限制），调度器只在快照窗口内执行
+type Snapshot struct {
+	Enabled        bool     `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用定时快照
+	Windows        []string `mapstructure:"windows" json:"windows" yaml:"windows"`                         // 执行窗口，格式同维护窗口 "mon-fri 02:00-06:00"，为空表示不限时间
+	Timezone       string   `mapstructure:"timezone" json:"timezone" yaml:"timezone"`                      // 窗口使用的时区，为空时使用服务器本地时区
+	MaxConcurrency int      `mapstructure:"max-concurrency" json:"max-concurrency" yaml:"max-concurrency"` // 同时执行的快照数，默认3
+}
+
+// Forecast 资源用量趋势预测配置
+// 定期采样Provider资源占用率和用户当月流量，按线性回归预测Provider何时达到阈值，按EWMA预测用户何时用完当月流量
+type Forecast struct {
+	Enabled        bool    `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用趋势预测
+	SampleInterval int     `mapstructure:"sample-interval" json:"sample-interval" yaml:"sample-interval"` // 采样间隔（分钟），默认60
+	HistoryDays    int     `mapstructure:"history-days" json:"history-days" yaml:"history-days"`          // Provider预测使用的历史天数，默认14
+	Threshold      float64 `mapstructure:"threshold" json:"threshold" yaml:"threshold"`                   // Provider容量预警阈值（%），默认90
+	WarnDays       int     `mapstructure:"warn-days" json:"warn-days" yaml:"warn-days"`                   // 预计在该天数内达到阈值时预警，默认7
+	Alpha          float64 `mapstructure:"alpha" json:"alpha" yaml:"alpha"`                               // 用户流量EWMA平滑系数（0-1），越大越偏重近期，默认0.3
+	NotifyAdmins   bool    `mapstructure:"notify-admins" json:"notify-admins" yaml:"notify-admins"`       // Provider预警时邮件通知管理员
+	NotifyUsers    bool    `mapstructure:"notify-users" json:"notify-users" yaml:"notify-users"`          // 预计当月流量耗尽时邮件通知用户
+}
+
+// RDNS 实例反向解析（PTR）配置
+// 独立公网IP实例的所有者可以为IP设置PTR记录，主机名必须有指向该IP的正向解析；Backend 选择PTR记录的维护方式
+type RDNS struct {
+	Enabled       bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                      // 是否启用反向解析管理
+	MinLevel      int    `mapstructure:"min-level" json:"min-level" yaml:"min-level"`                // 可设置反向解析的最低用户等级，0表示不限制
+	Backend       string `mapstructure:"backend" json:"backend" yaml:"backend"`                      // 维护方式：host（在实例所在节点执行命令维护PTR区域）、webhook（调用上游服务商API）
+	SetCommand    string `mapstructure:"set-command" json:"set-command" yaml:"set-command"`          // host方式设置PTR的命令，可引用 {ip} {ptr} {hostname}，替换时已做shell转义
+	DeleteCommand string `mapstructure:"delete-command" json:"delete-command" yaml:"delete-command"` // host方式删除PTR的命令，可引用 {ip} {ptr}
+	WebhookURL    string `mapstructure:"webhook-url" json:"webhook-url" yaml:"webhook-url"`          // webhook方式的接口地址，以JSON POST调用
+	WebhookToken  string `mapstructure:"webhook-token" json:"webhook-token" yaml:"webhook-token"`    // webhook方式的Bearer令牌
+	Timeout       int    `mapstructure:"timeout" json:"timeout" yaml:"timeout"`                      // 单次设置的超时时间（秒），默认30
+}
+
+// HealthCheck Provider健康检查调度配置
+// 每个Provider按各自的间隔检查，下次检查时间加入随机抖动避免集中发起连接，离线时按指数退避降低检查频率
+type HealthCheck struct {
+	Interval       int `mapstructure:"interval" json:"interval" yaml:"interval"`                      // 默认检查间隔（秒），Provider未单独设置时使用，默认180
+	Jitter         int `mapstructure:"jitter" json:"jitter" yaml:"jitter"`                            // 抖动比例（%），实际间隔在设定值上下该比例内随机，默认20
+	MaxBackoff     int `mapstructure:"max-backoff" json:"max-backoff" yaml:"max-backoff"`             // 离线时退避的最大间隔（秒），默认1800
+	MaxConcurrency int `mapstructure:"max-concurrency" json:"max-concurrency" yaml:"max-concurrency"` // 同时检查的Provider数量上限，默认3
+	HistorySize    int `mapstructure:"history-size" json:"history-size" yaml:"history-size"`          // 每个Provider保留的检查记录条数，默认50
+}
+
+// AdminAccess 管理接口来源IP白名单
+// 启用且白名单非空时，只有来源IP在白名单内的请求才能访问管理接口；携带应急令牌的请求不受限制，用于白名单配置错误后恢复访问
+type AdminAccess struct {
+	Enabled        bool     `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                         // 是否启用白名单
+	AllowedCIDRs   []string `mapstructure:"allowed-cidrs" json:"allowed-cidrs" yaml:"allowed-cidrs"`       // 允许访问的IP或CIDR，单个IP按/32或/128处理
+	ProtectLogin   bool     `mapstructure:"protect-login" json:"protect-login" yaml:"protect-login"`       // 登录接口也应用白名单，开启后白名单外的所有用户都无法登录
+	TrustedProxies []string `mapstructure:"trusted-proxies" json:"trusted-proxies" yaml:"trusted-proxies"` // 可信反向代理的IP或CIDR，只有来自这些地址的请求才使用X-Forwarded-For中的来源IP
+	BypassToken    string   `mapstructure:"bypass-token" json:"bypass-token" yaml:"bypass-token"`          // 应急令牌，通过 X-Admin-Bypass-Token 请求头提交，为空表示不启用
+}
+
+// ConsoleRecording 管理员终端会话录制配置
+// 管理员通过网页终端连接用户实例时录制会话（asciicast v2格式），结束后写入对象存储，超过保留天数后删除
+type ConsoleRecording struct {
+	Enabled       bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                      // 是否录制管理员终端会话
+	RecordInput   bool   `mapstructure:"record-input" json:"record-input" yaml:"record-input"`       // 是否同时录制管理员的键盘输入（可能包含密码），默认只录制终端输出
+	NotifyPolicy  string `mapstructure:"notify-policy" json:"notify-policy" yaml:"notify-policy"`    // 通知实例所有者的方式：none 不通知，email 会话开始时发送邮件，默认email
+	RetentionDays int    `mapstructure:"retention-days" json:"retention-days" yaml:"retention-days"` // 录像保留天数，默认90
+	MaxSizeMB     int    `mapstructure:"max-size-mb" json:"max-size-mb" yaml:"max-size-mb"`          // 单个录像最大大小（MB），超出后停止录制，默认20
+}
+
+// NetworkProbe 节点网络质量测试配置
+// 在宿主机上执行限量限时的下载测速和ping/mtr探测，结果展示给用户用于按网络质量选择节点
+type NetworkProbe struct {
+	Targets          []string `mapstructure:"targets" json:"targets" yaml:"targets"`                                  // 探测目标，格式 "名称=地址" 或 "地址"，地址为域名或IP
+	SpeedTestURL     string   `mapstructure:"speed-test-url" json:"speed-test-url" yaml:"speed-test-url"`             // 下载测速地址，为空时不测速
+	SpeedTestMaxMB   int      `mapstructure:"speed-test-max-mb" json:"speed-test-max-mb" yaml:"speed-test-max-mb"`    // 测速最多下载量（MB），默认100
+	SpeedTestSeconds int      `mapstructure:"speed-test-seconds" json:"speed-test-seconds" yaml:"speed-test-seconds"` // 测速最长时间（秒），默认15，最大60
+	PingCount        int      `mapstructure:"ping-count" json:"ping-count" yaml:"ping-count"`                         // 每个目标的ping次数，默认10，最大50
+	Interval         int      `mapstructure:"interval" json:"interval" yaml:"interval"`                               // 定时重新测试的间隔（小时），0表示只由管理员手动触发
+}
+
+// ConfigConsistency 配置一致性检查
+// 定时比较config.yaml、数据库和当前生效的配置，发现不一致时记录日志，由管理员通过接口按配置项选择来源修复
+// 每个节点检查自己的config.yaml和生效配置
+type ConfigConsistency struct {
+	Enabled  bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`    // 是否启用定时检查
+	Interval int  `mapstructure:"interval" json:"interval" yaml:"interval"` // 检查间隔（分钟），默认30
+}
+
+// PasswordPolicy 密码策略
+// 启用后注册、找回和修改密码时按这里的规则校验用户设置的密码，未启用时使用内置默认策略；生成密码的长度不受开关影响
+type PasswordPolicy struct {
+	Enabled                bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                                                    // 是否启用自定义密码策略
+	MinLength              int    `mapstructure:"min-length" json:"min-length" yaml:"min-length"`                                           // 最小长度，默认8，不低于6
+	RequireUpper           bool   `mapstructure:"require-upper" json:"require-upper" yaml:"require-upper"`                                  // 要求大写字母
+	RequireLower           bool   `mapstructure:"require-lower" json:"require-lower" yaml:"require-lower"`                                  // 要求小写字母
+	RequireDigit           bool   `mapstructure:"require-digit" json:"require-digit" yaml:"require-digit"`                                  // 要求数字
+	RequireSpecial         bool   `mapstructure:"require-special" json:"require-special" yaml:"require-special"`                            // 要求特殊字符
+	BreachCheck            bool   `mapstructure:"breach-check" json:"breach-check" yaml:"breach-check"`                                     // 检查密码是否出现在公开泄露库中，只发送SHA-1的前5位（k-anonymity）
+	BreachAPI              string `mapstructure:"breach-api" json:"breach-api" yaml:"breach-api"`                                           // 泄露库查询接口，请求时追加哈希前缀，默认 https://api.pwnedpasswords.com/range/
+	GeneratedLength        int    `mapstructure:"generated-length" json:"generated-length" yaml:"generated-length"`                         // 系统为用户生成的密码长度，默认12，不低于8
+	InstancePasswordLength int    `mapstructure:"instance-password-length" json:"instance-password-length" yaml:"instance-password-length"` // 实例密码长度，默认12，不低于8
+}
+
+// Cluster 集群部署配置
+// 多副本部署时所有节点都提供API服务，定时任务只在通过数据库租约选出的主节点上运行
+type Cluster struct {
+	Enabled  bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`       // 是否启用集群模式，关闭时本节点始终运行定时任务
+	NodeID   string `mapstructure:"node-id" json:"node-id" yaml:"node-id"`       // 节点ID，为空时使用主机名和进程号生成
+	LeaseTTL int    `mapstructure:"lease-ttl" json:"lease-ttl" yaml:"lease-ttl"` // 主节点租约有效期（秒），默认30，主节点故障后最迟在该时间后切换
+}
+
+// RetentionPolicy 单表保留策略，各项为0表示跳过该阶段
+type RetentionPolicy struct {
+	HourlyAfterDays int `mapstructure:"hourly-after-days" json:"hourly-after-days" yaml:"hourly-after-days"` // 超过该天数的原始数据降采样为小时级（仅pmacct_traffic_records支持）
+	DailyAfterDays  int `mapstructure:"daily-after-days" json:"daily-after-days" yaml:"daily-after-days"`    // 超过该天数的数据降采样为日级（仅pmacct_traffic_records支持）
+	KeepDays        int `mapstructure:"keep-days" json:"keep-days" yaml:"keep-days"`                         // 超过该天数的数据删除
+}
+
+// Oss 对象存储配置（system.oss-type 为 s3 或 minio 时生效）
+type Oss struct {
+	Endpoint  string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`       // S3兼容服务地址，如 s3.amazonaws.com、minio.example.com:9000
+	Region    string `mapstructure:"region" json:"region" yaml:"region"`             // 区域，默认us-east-1
+	Bucket    string `mapstructure:"bucket" json:"bucket" yaml:"bucket"`             // 存储桶名称
+	AccessKey string `mapstructure:"access-key" json:"access-key" yaml:"access-key"` // 访问密钥ID
+	SecretKey string `mapstructure:"secret-key" json:"secret-key" yaml:"secret-key"` // 访问密钥
+	UseSSL    bool   `mapstructure:"use-ssl" json:"use-ssl" yaml:"use-ssl"`          // 是否使用HTTPS
+	PathStyle bool   `mapstructure:"path-style" json:"path-style" yaml:"path-style"` // 是否使用路径风格访问（MinIO需开启）
+	BasePath  string `mapstructure:"base-path" json:"base-path" yaml:"base-path"`    // 对象键前缀
 }
 
-// Upload 上传配置
+// Upload 上传配置（头像上传功能已移除，仅用于大文件分片上传）
 type Upload struct {
-	// 头像上传功能已移除
+	ChunkSize          int `mapstructure:"chunk-size" json:"chunk-size" yaml:"chunk-size"`                               // 分片大小（MB），默认8
+	MaxFileSize        int `mapstructure:"max-file-size" json:"max-file-size" yaml:"max-file-size"`                      // 单个文件最大大小（MB），默认20480
+	SessionExpireHours int `mapstructure:"session-expire-hours" json:"session-expire-hours" yaml:"session-expire-hours"` // 未完成上传会话的过期时间（小时），默认24
 }
@@ -0,0 +1,284 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置来源
+const (
+	ConfigSourceYAML   = "yaml"   // config.yaml
+	ConfigSourceDB     = "db"     // 数据库 system_configs
+	ConfigSourceGlobal = "global" // 当前进程内生效的配置
+)
+
+// ErrConfigReconcileInvalid 修复请求无效：来源无效、系统级配置、未知配置项或所选来源中没有该配置项
+var ErrConfigReconcileInvalid = errors.New("无法修复该配置项")
+
+// ConfigConsistencyEntry 三处不一致的配置项
+type ConfigConsistencyEntry struct {
+	Key     string                 `json:"key"`
+	Values  map[string]interface{} `json:"values"`            // 各来源的值，缺少该配置项的来源不出现
+	Missing []string               `json:"missing,omitempty"` // 缺少该配置项的来源
+	Masked  bool                   `json:"masked,omitempty"`  // 敏感配置，值已脱敏
+}
+
+// ConfigConsistencyReport 一次配置一致性检查的结果
+type ConfigConsistencyReport struct {
+	CheckedAt  time.Time                `json:"checkedAt"`
+	DurationMs int64                    `json:"durationMs"`
+	Trigger    string                   `json:"trigger"` // scheduled 或 manual
+	Checked    int                      `json:"checked"` // 比较的配置项数量
+	Divergent  []ConfigConsistencyEntry `json:"divergent"`
+}
+
+// ValidConfigSource 检查配置来源是否有效
+func ValidConfigSource(source string) bool {
+	return source == ConfigSourceYAML || source == ConfigSourceDB || source == ConfigSourceGlobal
+}
+
+// configSnapshot 三处配置的扁平化快照，只包含非系统级配置
+type configSnapshot struct {
+	yaml   map[string]interface{}
+	db     map[string]interface{}
+	global map[string]interface{}
+}
+
+// takeConfigSnapshot 读取config.yaml、数据库和当前生效配置
+func (cm *ConfigManager) takeConfigSnapshot(current Server) (*configSnapshot, error) {
+	file, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	yamlConfig, err := cm.readYAMLSyncConfig(file)
+	if err != nil {
+		return nil, err
+	}
+
+	dbConfigs, err := cm.loadNonSystemConfigsFromDB()
+	if err != nil {
+		return nil, err
+	}
+	dbConfig := make(map[string]interface{}, len(dbConfigs))
+	for _, config := range dbConfigs {
+		dbConfig[config.Key] = parseConfigValue(config.Value)
+	}
+
+	// 生效配置按yaml标签序列化后展开，键名与config.yaml一致
+	out, err := yaml.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("序列化当前配置失败: %v", err)
+	}
+	globalConfig, err := cm.readYAMLSyncConfig(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return &configSnapshot{yaml: yamlConfig, db: dbConfig, global: globalConfig}, nil
+}
+
+// value 返回指定来源中的配置值
+func (s *configSnapshot) value(source, key string) (interface{}, bool) {
+	var values map[string]interface{}
+	switch source {
+	case ConfigSourceYAML:
+		values = s.yaml
+	case ConfigSourceDB:
+		values = s.db
+	default:
+		values = s.global
+	}
+	value, ok := values[key]
+	return value, ok
+}
+
+// compare 比较一个配置项，一致时返回nil
+// 只比较生效配置中存在的配置项；config.yaml和数据库都没有的配置项使用默认值，不算不一致
+func (s *configSnapshot) compare(key string) *ConfigConsistencyEntry {
+	globalValue, inGlobal := s.global[key]
+	yamlValue, inYAML := s.yaml[key]
+	dbValue, inDB := s.db[key]
+	if !inGlobal || (!inYAML && !inDB) {
+		return nil
+	}
+
+	entry := &ConfigConsistencyEntry{Key: key, Values: map[string]interface{}{ConfigSourceGlobal: globalValue}}
+	divergent := false
+	for _, source := range []struct {
+		name   string
+		value  interface{}
+		exists bool
+	}{{ConfigSourceYAML, yamlValue, inYAML}, {ConfigSourceDB, dbValue, inDB}} {
+		if !source.exists {
+			entry.Missing = append(entry.Missing, source.name)
+			divergent = true
+			continue
+		}
+		entry.Values[source.name] = source.value
+		if !consistencyValueEqual(source.value, globalValue) {
+			divergent = true
+		}
+	}
+	if !divergent {
+		return nil
+	}
+
+	if IsSecretConfigKey(key) {
+		entry.Masked = true
+		for source, value := range entry.Values {
+			entry.Values[source] = MaskSecretValue(value)
+		}
+	}
+	return entry
+}
+
+// keys 返回三处配置中出现的所有键
+func (s *configSnapshot) keys() []string {
+	seen := make(map[string]bool)
+	for _, values := range []map[string]interface{}{s.yaml, s.db, s.global} {
+		for key := range values {
+			seen[key] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CheckConsistency 比较config.yaml、数据库和当前生效配置，报告不一致的配置项
+// current 为当前进程的全局配置（global.APP_CONFIG），由调用方传入以避免循环导入
+func (cm *ConfigManager) CheckConsistency(current Server, trigger string) (*ConfigConsistencyReport, error) {
+	start := time.Now()
+	snapshot, err := cm.takeConfigSnapshot(current)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ConfigConsistencyReport{
+		CheckedAt: start,
+		Trigger:   trigger,
+		Divergent: []ConfigConsistencyEntry{},
+	}
+	for _, key := range snapshot.keys() {
+		if _, inGlobal := snapshot.global[key]; inGlobal {
+			report.Checked++
+		}
+		if entry := snapshot.compare(key); entry != nil {
+			report.Divergent = append(report.Divergent, *entry)
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	cm.mu.Lock()
+	cm.lastConsistencyReport = report
+	cm.mu.Unlock()
+
+	if len(report.Divergent) > 0 {
+		keys := make([]string, 0, len(report.Divergent))
+		for _, entry := range report.Divergent {
+			keys = append(keys, entry.Key)
+		}
+		cm.logger.Warn("config.yaml、数据库与生效配置不一致",
+			zap.String("trigger", trigger),
+			zap.Strings("keys", keys))
+	}
+	return report, nil
+}
+
+// LastConsistencyReport 返回最近一次配置一致性检查结果，尚未执行过时返回nil
+func (cm *ConfigManager) LastConsistencyReport() *ConfigConsistencyReport {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.lastConsistencyReport
+}
+
+// ReconcileConfigKey 以指定来源的值为准修复单个配置项：写入数据库和config.yaml并同步到生效配置
+func (cm *ConfigManager) ReconcileConfigKey(key, source string, current Server) error {
+	if !ValidConfigSource(source) {
+		return fmt.Errorf("%w: 无效的配置来源 %s", ErrConfigReconcileInvalid, source)
+	}
+	if isSystemLevelConfig(key) {
+		return fmt.Errorf("%w: 系统级配置 %s 只能通过config.yaml修改并重启服务", ErrConfigReconcileInvalid, key)
+	}
+	snapshot, err := cm.takeConfigSnapshot(current)
+	if err != nil {
+		return err
+	}
+	if _, inGlobal := snapshot.global[key]; !inGlobal {
+		return fmt.Errorf("%w: 未知的配置项 %s", ErrConfigReconcileInvalid, key)
+	}
+	value, ok := snapshot.value(source, key)
+	if !ok {
+		return fmt.Errorf("%w: %s 中没有配置项 %s", ErrConfigReconcileInvalid, source, key)
+	}
+
+	nested := make(map[string]interface{})
+	setNestedValue(nested, key, normalizeConfigValue(value))
+	if err := cm.UpdateConfig(nested); err != nil {
+		return err
+	}
+	cm.logger.Info("已修复不一致的配置项",
+		zap.String("key", key),
+		zap.String("source", source))
+	return nil
+}
+
+// consistencyValueEqual 比较两个来源的配置值
+// 写回YAML时空字符串会写成空值，零值与空值视为相同
+func consistencyValueEqual(a, b interface{}) bool {
+	a, b = normalizeConfigValue(a), normalizeConfigValue(b)
+	if isZeroConfigValue(a) && isZeroConfigValue(b) {
+		return true
+	}
+	return configValueEqual(a, b)
+}
+
+// normalizeConfigValue 将YAML解析出的非字符串键映射转换为字符串键映射，便于按JSON比较和保存
+func normalizeConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalizeConfigValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalizeConfigValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalizeConfigValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// isZeroConfigValue 判断配置值是否为空值或零值
+func isZeroConfigValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
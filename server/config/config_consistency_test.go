@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestConsistencyValueEqual(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  interface{}
+		equal bool
+	}{
+		{"YAML整数与数据库浮点数", 30, float64(30), true},
+		{"空字符串与空值", "", nil, true},
+		{"零值与空值", 0, nil, true},
+		{"空列表与空值", []interface{}{}, nil, true},
+		{"不同值", 30, float64(60), false},
+		{"YAML映射与数据库映射", map[interface{}]interface{}{"a": 1}, map[string]interface{}{"a": float64(1)}, true},
+		{"映射值不同", map[interface{}]interface{}{"a": 1}, map[string]interface{}{"a": float64(2)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := consistencyValueEqual(tt.a, tt.b); got != tt.equal {
+				t.Errorf("consistencyValueEqual(%v, %v) = %v, expected %v", tt.a, tt.b, got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestConfigSnapshotCompare(t *testing.T) {
+	s := &configSnapshot{
+		yaml: map[string]interface{}{
+			"quota.default-level": 1,
+			"auth.enable-email":   true,
+			"auth.email-password": "old",
+			"task.only-yaml":      1,
+		},
+		db: map[string]interface{}{
+			"quota.default-level": float64(1),
+			"auth.enable-email":   false,
+			"auth.email-password": "new",
+		},
+		global: map[string]interface{}{
+			"quota.default-level": 1,
+			"auth.enable-email":   true,
+			"auth.email-password": "old",
+			"auth.enable-qq":      false,
+		},
+	}
+
+	if entry := s.compare("quota.default-level"); entry != nil {
+		t.Errorf("一致的配置项不应报告: %+v", entry)
+	}
+	if entry := s.compare("auth.enable-qq"); entry != nil {
+		t.Errorf("config.yaml和数据库都没有的配置项不应报告: %+v", entry)
+	}
+	if entry := s.compare("task.only-yaml"); entry != nil {
+		t.Errorf("生效配置中没有的配置项不应报告: %+v", entry)
+	}
+
+	entry := s.compare("auth.enable-email")
+	if entry == nil || entry.Values[ConfigSourceDB] != false || entry.Values[ConfigSourceYAML] != true {
+		t.Fatalf("数据库不一致时应报告各来源的值: %+v", entry)
+	}
+
+	secret := s.compare("auth.email-password")
+	if secret == nil || !secret.Masked {
+		t.Fatalf("敏感配置不一致时应报告并脱敏: %+v", secret)
+	}
+	for source, value := range secret.Values {
+		if value == "old" || value == "new" {
+			t.Errorf("%s 的敏感值未脱敏: %v", source, value)
+		}
+	}
+
+	delete(s.db, "quota.default-level")
+	missing := s.compare("quota.default-level")
+	if missing == nil || len(missing.Missing) != 1 || missing.Missing[0] != ConfigSourceDB {
+		t.Errorf("数据库缺少配置项时应报告: %+v", missing)
+	}
+}
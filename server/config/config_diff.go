@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// 配置差异类型
+const (
+	ConfigDiffAdded   = "added"
+	ConfigDiffRemoved = "removed"
+	ConfigDiffChanged = "changed"
+)
+
+// ConfigDiffEntry 单个配置项的差异
+type ConfigDiffEntry struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"` // added, removed, changed
+	OldValue    interface{} `json:"oldValue,omitempty"`
+	NewValue    interface{} `json:"newValue,omitempty"`
+	Masked      bool        `json:"masked,omitempty"`      // 敏感配置，值已脱敏
+	SystemLevel bool        `json:"systemLevel,omitempty"` // 系统级配置，不能通过API修改
+}
+
+// configValueEqual 比较两个配置值，统一按JSON序列化结果比较，避免数据库解析出的数值类型与请求中的不一致
+func configValueEqual(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
+
+// DiffConfig 计算两份扁平化配置之间的差异，结果按键名排序，敏感值已脱敏
+func DiffConfig(oldConfig, newConfig map[string]interface{}) []ConfigDiffEntry {
+	diffs := make([]ConfigDiffEntry, 0)
+	for key, newValue := range newConfig {
+		oldValue, exists := oldConfig[key]
+		switch {
+		case !exists:
+			diffs = append(diffs, ConfigDiffEntry{Key: key, Type: ConfigDiffAdded, NewValue: newValue})
+		case !configValueEqual(oldValue, newValue):
+			diffs = append(diffs, ConfigDiffEntry{Key: key, Type: ConfigDiffChanged, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, oldValue := range oldConfig {
+		if _, exists := newConfig[key]; !exists {
+			diffs = append(diffs, ConfigDiffEntry{Key: key, Type: ConfigDiffRemoved, OldValue: oldValue})
+		}
+	}
+
+	for i := range diffs {
+		diffs[i].SystemLevel = isSystemLevelConfig(diffs[i].Key)
+		if IsSecretConfigKey(diffs[i].Key) {
+			diffs[i].Masked = true
+			if diffs[i].OldValue != nil {
+				diffs[i].OldValue = SecretMask
+			}
+			if diffs[i].NewValue != nil {
+				diffs[i].NewValue = SecretMask
+			}
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+// PreviewUpdate 预览一次批量更新会产生的变化，不修改任何配置
+// 与 UpdateConfig 使用相同的键名转换和扁平化规则，只比较本次提交的配置项
+func (cm *ConfigManager) PreviewUpdate(config map[string]interface{}) []ConfigDiffEntry {
+	flatConfig := cm.flattenConfig(convertMapKeysToKebab(config), "")
+	dropMaskedSecrets(flatConfig)
+	current := cm.GetAllConfig()
+
+	// 部分更新：未提交的配置项保持不变，不视为删除
+	oldConfig := make(map[string]interface{}, len(flatConfig))
+	for key := range flatConfig {
+		if value, exists := current[key]; exists {
+			oldConfig[key] = value
+		}
+	}
+	return DiffConfig(oldConfig, flatConfig)
+}
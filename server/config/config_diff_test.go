@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestDiffConfig(t *testing.T) {
+	oldConfig := map[string]interface{}{
+		"auth.enable-email":    false,
+		"auth.email-password":  "old-secret",
+		"quota.default-level":  float64(1), // 数据库解析出的数值
+		"other.removed-option": "x",
+	}
+	newConfig := map[string]interface{}{
+		"auth.enable-email":   true,
+		"auth.email-password": "new-secret",
+		"quota.default-level": 1, // 请求中的数值，与旧值相等
+		"task.new-option":     3,
+	}
+
+	diffs := DiffConfig(oldConfig, newConfig)
+	expected := map[string]string{
+		"auth.email-password":  ConfigDiffChanged,
+		"auth.enable-email":    ConfigDiffChanged,
+		"other.removed-option": ConfigDiffRemoved,
+		"task.new-option":      ConfigDiffAdded,
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("DiffConfig returned %d entries, expected %d: %+v", len(diffs), len(expected), diffs)
+	}
+	for i, diff := range diffs {
+		if i > 0 && diffs[i-1].Key > diff.Key {
+			t.Errorf("diffs not sorted by key: %q before %q", diffs[i-1].Key, diff.Key)
+		}
+		if expected[diff.Key] != diff.Type {
+			t.Errorf("%s: type = %q, expected %q", diff.Key, diff.Type, expected[diff.Key])
+		}
+		if diff.Key == "auth.email-password" {
+			if !diff.Masked || diff.OldValue != SecretMask || diff.NewValue != SecretMask {
+				t.Errorf("secret value not masked: %+v", diff)
+			}
+		}
+	}
+}
+
+func TestIsSecretConfigKey(t *testing.T) {
+	tests := map[string]bool{
+		"auth.email-password":               true,
+		"auth.emailPassword":                true,
+		"auth.telegramBotToken":             true,
+		"auth.qqAppKey":                     true,
+		"oss.secretKey":                     true,
+		"jwt.signing-key":                   true,
+		"auth.email-username":               false,
+		"system.oauth2-state-token-minutes": false,
+	}
+	for key, expected := range tests {
+		if got := IsSecretConfigKey(key); got != expected {
+			t.Errorf("IsSecretConfigKey(%q) = %v, expected %v", key, got, expected)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFilePath   = "config.yaml"
+	configBackupDir  = "./storage/config-backups"
+	configBackupKeep = 10
+)
+
+// configFileMu 串行化所有对config.yaml的写入
+var configFileMu sync.Mutex
+
+// writeConfigFile 原子写入config.yaml，调用方需持有 configFileMu
+// 内容未变化时不写入；写入前备份原文件，返回备份文件路径
+func writeConfigFile(out []byte) (string, error) {
+	current, err := os.ReadFile(configFilePath)
+	if err == nil && bytes.Equal(current, out) {
+		return "", nil
+	}
+
+	backupFile := ""
+	if err == nil && validConfigData(current) {
+		backupFile, err = backupConfigFile(configFilePath, configBackupDir, configBackupKeep, time.Now())
+		if err != nil {
+			return "", fmt.Errorf("备份配置文件失败: %v", err)
+		}
+	}
+
+	if err := atomicWriteFile(configFilePath, out, 0644); err != nil {
+		return "", err
+	}
+	return backupFile, nil
+}
+
+// atomicWriteFile 先写入同目录的临时文件并刷盘，再重命名替换目标文件
+// 写入中途崩溃时目标文件保持原样，不会留下不完整的内容
+func atomicWriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// 刷新目录项，确保重命名在断电后依然生效；部分平台不支持对目录Sync，忽略错误
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// backupConfigFile 把配置文件复制到备份目录，只保留最近 keep 份备份
+func backupConfigFile(path, dir string, keep int, now time.Time) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	backupFile := filepath.Join(dir, filepath.Base(path)+"."+now.Format("20060102-150405.000"))
+	if err := atomicWriteFile(backupFile, data, 0600); err != nil {
+		return "", err
+	}
+
+	// 备份文件名按时间排序，删除最旧的备份
+	backups, err := listConfigBackups(path, dir)
+	if err != nil {
+		return backupFile, nil
+	}
+	for i := 0; i < len(backups)-keep; i++ {
+		os.Remove(backups[i])
+	}
+	return backupFile, nil
+}
+
+// listConfigBackups 列出配置文件的所有备份，按时间从旧到新排序
+func listConfigBackups(path, dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(path) + "."
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// 跳过写入中的临时文件
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// validConfigData 检查配置内容能否解析为非空的YAML映射
+func validConfigData(data []byte) bool {
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return false
+	}
+	return len(content) > 0
+}
+
+// RecoverConfigFile 启动时检查配置文件，无法解析或内容为空时用最近一份有效备份恢复
+// 损坏的文件会重命名保留以便排查，返回用于恢复的备份路径，未恢复时为空
+func RecoverConfigFile(path string) (string, error) {
+	return recoverConfigFile(path, configBackupDir, time.Now())
+}
+
+func recoverConfigFile(path, dir string, now time.Time) (string, error) {
+	// 清理上次写入中断留下的临时文件
+	os.Remove(path + ".tmp")
+
+	data, err := os.ReadFile(path)
+	if err != nil || validConfigData(data) {
+		// 文件不存在时由调用方创建默认配置
+		return "", nil
+	}
+
+	backups, err := listConfigBackups(path, dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("读取配置备份失败: %v", err)
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		backup, err := os.ReadFile(backups[i])
+		if err != nil || !validConfigData(backup) {
+			continue
+		}
+		corrupted := fmt.Sprintf("%s.corrupted-%s", path, now.Format("20060102-150405"))
+		if err := os.Rename(path, corrupted); err != nil {
+			return "", fmt.Errorf("保留损坏的配置文件失败: %v", err)
+		}
+		if err := atomicWriteFile(path, backup, 0644); err != nil {
+			return "", fmt.Errorf("从备份恢复配置文件失败: %v", err)
+		}
+		return backups[i], nil
+	}
+	return "", fmt.Errorf("配置文件 %s 已损坏且没有可用的备份", path)
+}
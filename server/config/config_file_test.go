@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupConfigFileKeepsLatest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	backupDir := filepath.Join(dir, "backups")
+	if err := os.WriteFile(path, []byte("system:\n    env: test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var last string
+	for i := 0; i < 4; i++ {
+		backupFile, err := backupConfigFile(path, backupDir, 2, start.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("backupConfigFile: %v", err)
+		}
+		last = backupFile
+	}
+
+	backups, err := listConfigBackups(path, backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	if filepath.Base(backups[0]) != "config.yaml.20260102-030407.000" || backups[1] != last {
+		t.Errorf("unexpected backups kept: %v", backups)
+	}
+	data, err := os.ReadFile(last)
+	if err != nil || string(data) != "system:\n    env: test\n" {
+		t.Errorf("backup content = %q, %v", data, err)
+	}
+}
+
+func TestRecoverConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	backupDir := filepath.Join(dir, "backups")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// 有效的配置文件不做任何处理
+	if err := os.WriteFile(path, []byte("system:\n    env: test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if backup, err := recoverConfigFile(path, backupDir, now); backup != "" || err != nil {
+		t.Fatalf("recoverConfigFile on valid file = %q, %v", backup, err)
+	}
+
+	// 最新的备份同样损坏时，使用更早的有效备份
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(backupDir, "config.yaml.20260101-000000.000")
+	bad := filepath.Join(backupDir, "config.yaml.20260102-000000.000")
+	os.WriteFile(good, []byte("system:\n    env: backup\n"), 0600)
+	os.WriteFile(bad, []byte("system: [\n"), 0600)
+	os.WriteFile(path, []byte("system:\n    env: te"+"\x00\x00:\n  - ["), 0644)
+
+	backup, err := recoverConfigFile(path, backupDir, now)
+	if err != nil || backup != good {
+		t.Fatalf("recoverConfigFile = %q, %v, want %q", backup, err, good)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "system:\n    env: backup\n" {
+		t.Errorf("restored content = %q", data)
+	}
+	if _, err := os.Stat(path + ".corrupted-20260102-030405"); err != nil {
+		t.Errorf("corrupted file not kept: %v", err)
+	}
+
+	// 空文件且没有可用备份时返回错误
+	os.WriteFile(path, nil, 0644)
+	if _, err := recoverConfigFile(path, filepath.Join(dir, "none"), now); err == nil {
+		t.Error("expected error without backups")
+	}
+}
@@ -74,6 +74,11 @@ var systemLevelConfigKeys = map[string]bool{
 	"zap.log-in-console":     true,
 	"zap.max-string-length":  true,
 	"zap.max-array-elements": true,
+
+	// 集群配置（每个节点独立，启动时决定调度器的运行方式）
+	"cluster.enabled":   true,
+	"cluster.node-id":   true,
+	"cluster.lease-ttl": true,
 }
 
 // isSystemLevelConfig 检查是否为系统级配置（启动必需，必须来自YAML）
@@ -114,6 +119,8 @@ type ConfigManager struct {
 	lastUpdate      time.Time
 	validationRules map[string]ConfigValidationRule
 	changeCallbacks []ConfigChangeCallback
+
+	lastConsistencyReport *ConfigConsistencyReport
 }
 
 // ConfigValidationRule 配置验证规则
@@ -235,6 +242,39 @@ func (cm *ConfigManager) initValidationRules() {
 		},
 	}
 
+	// 管理接口IP白名单验证规则
+	cm.validationRules["admin-access.allowed-cidrs"] = ConfigValidationRule{
+		Required:  false,
+		Type:      "array",
+		Validator: validateCIDRList,
+	}
+	cm.validationRules["admin-access.trusted-proxies"] = ConfigValidationRule{
+		Required:  false,
+		Type:      "array",
+		Validator: validateCIDRList,
+	}
+
+	// 静态资源CDN验证规则
+	cm.validationRules["cdn.asset-domain"] = ConfigValidationRule{
+		Required:  false,
+		Type:      "string",
+		Validator: validateAssetDomain,
+	}
+	cm.validationRules["cdn.sign-expire"] = ConfigValidationRule{
+		Required: false,
+		Type:     "int",
+		MinValue: 60,
+		MaxValue: 604800,
+	}
+
+	// 实例名称重用冷却时间（分钟），最长30天
+	cm.validationRules["task.name-reuse-cooldown"] = ConfigValidationRule{
+		Required: false,
+		Type:     "int",
+		MinValue: 0,
+		MaxValue: 43200,
+	}
+
 	// 更多验证规则...
 }
 
@@ -316,6 +356,9 @@ func (cm *ConfigManager) UpdateConfig(config map[string]interface{}) error {
 			return keys
 		}()))
 
+	// 敏感配置为只写语义，提交脱敏占位值表示保持原值
+	dropMaskedSecrets(flatConfig)
+
 	// 检查是否包含系统级配置，禁止通过API修改
 	for key := range flatConfig {
 		if isSystemLevelConfig(key) {
@@ -700,6 +743,11 @@ func (cm *ConfigManager) loadConfigFromDB() {
 		return
 	}
 
+	// 先执行版本升级带来的配置结构迁移，再按下面的策略加载
+	if err := cm.migrateDatabaseConfigs(); err != nil {
+		cm.logger.Error("执行数据库配置迁移失败", zap.Error(err))
+	}
+
 	// 检查是否存在数据库配置数据
 	var configCount int64
 	if err := cm.db.Model(&SystemConfig{}).Count(&configCount).Error; err != nil {
@@ -912,7 +960,7 @@ func (cm *ConfigManager) prepareConfigForDB(key string, value interface{}) (Syst
 
 	cm.logger.Debug("准备配置数据",
 		zap.String("key", key),
-		zap.String("value", valueStr),
+		zap.String("value", maskConfigLogValue(key, valueStr)),
 		zap.Bool("isPublic", isPublic))
 
 	return SystemConfig{
@@ -972,7 +1020,7 @@ func (cm *ConfigManager) saveConfigToDBWithTx(tx *gorm.DB, key string, value int
 
 	cm.logger.Info("保存配置到数据库",
 		zap.String("key", key),
-		zap.String("value", valueStr),
+		zap.String("value", maskConfigLogValue(key, valueStr)),
 		zap.Bool("isPublic", isPublic))
 
 	config := SystemConfig{
@@ -983,7 +1031,7 @@ func (cm *ConfigManager) saveConfigToDBWithTx(tx *gorm.DB, key string, value int
 
 	// 先尝试查找已存在的配置
 	var existingConfig SystemConfig
-	err := tx.Where("`key` = ?", key).First(&existingConfig).Error
+	err := tx.Where(map[string]interface{}{"key": key}).First(&existingConfig).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// 记录不存在，创建新记录
@@ -1452,6 +1500,16 @@ func getDefaultConfigMap() map[string]interface{} {
 			"buffer-time":  "1d",
 			"issuer":       "oneclickvirt",
 		},
+		"oss": map[string]interface{}{
+			"endpoint":   "",
+			"region":     "us-east-1",
+			"bucket":     "",
+			"access-key": "",
+			"secret-key": "",
+			"use-ssl":    true,
+			"path-style": false,
+			"base-path":  "oneclickvirt",
+		},
 		"other": map[string]interface{}{
 			"default-language": "zh",
 		},
@@ -0,0 +1,475 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// configVersionKey config.yaml 中记录已执行的配置迁移版本的键
+// 每个节点的配置文件单独记录版本，数据库侧的版本记录在 config_migrations 表中
+const configVersionKey = "config-version"
+
+// ConfigMigration 数据库配置已执行的迁移记录
+type ConfigMigration struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	Version     int       `json:"version" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description" gorm:"size:255"`
+	AppliedAt   time.Time `json:"appliedAt"`
+}
+
+func (ConfigMigration) TableName() string {
+	return "config_migrations"
+}
+
+// configEditor 配置迁移对一处配置的读写操作，键为点分隔的 kebab-case 路径
+type configEditor interface {
+	keys() []string      // 所有配置项的键
+	has(key string) bool // 配置项或分组是否存在
+	// rename 移动配置项或整个分组，目标已存在时保留目标并删除原配置
+	rename(from, to string) error
+	// setDefault 配置项不存在时设置默认值
+	setDefault(key string, value interface{}) error
+	// remove 删除配置项或整个分组
+	remove(key string) error
+}
+
+// configMigration 一个版本的配置结构变更，分别对 config.yaml 和数据库配置执行一次
+// 操作需要可以重复执行：多节点部署时每个节点的配置文件都会执行一次
+type configMigration struct {
+	version     int
+	description string
+	apply       func(e configEditor) error
+}
+
+// configMigrations 按版本递增排列的配置迁移，调整配置结构（改名、拆分分组、增加默认值）时在末尾追加
+var configMigrations = []configMigration{
+	{
+		version:     1,
+		description: "驼峰格式的配置键改为 kebab-case",
+		apply: func(e configEditor) error {
+			// 只处理能对应到已知配置项的键，自定义内容（如注入的环境变量名）保持原样
+			known := flattenDefaultConfigKeys()
+			for _, key := range e.keys() {
+				normalized := normalizeConfigKey(key)
+				if normalized == key || !isKnownConfigKey(known, normalized) {
+					continue
+				}
+				if err := e.rename(key, normalized); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version:     2,
+		description: "补充宿主机日志事件配置的默认值",
+		apply: func(e configEditor) error {
+			return setDefaults(e, map[string]interface{}{
+				"host-events.interval":       5,
+				"host-events.max-lines":      2000,
+				"host-events.retention-days": 30,
+			})
+		},
+	},
+}
+
+// setDefaults 逐项设置默认值，已存在的配置项保持不变
+func setDefaults(e configEditor, values map[string]interface{}) error {
+	for key, value := range values {
+		if err := e.setDefault(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenDefaultConfigKeys 返回默认配置中所有配置项的键
+func flattenDefaultConfigKeys() map[string]bool {
+	flat := (&ConfigManager{}).flattenConfig(getDefaultConfigMap(), "")
+	known := make(map[string]bool, len(flat))
+	for key := range flat {
+		known[key] = true
+	}
+	return known
+}
+
+// isKnownConfigKey 判断键是已知配置项或已知配置项（如 level-limits）下的子项
+func isKnownConfigKey(known map[string]bool, key string) bool {
+	for k := key; k != ""; {
+		if known[k] {
+			return true
+		}
+		i := strings.LastIndex(k, ".")
+		if i < 0 {
+			break
+		}
+		k = k[:i]
+	}
+	return false
+}
+
+// MigrateConfigFile 对配置文件执行尚未执行的配置迁移，在读取配置文件之前调用
+// 迁移后保留文件原来的修改时间，避免影响启动时对YAML和数据库配置新旧的判断；返回执行的迁移数量
+func MigrateConfigFile(path string) (int, error) {
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	out, applied, err := migrateConfigData(data)
+	if err != nil || applied == 0 {
+		return 0, err
+	}
+
+	if validConfigData(data) {
+		if _, err := backupConfigFile(path, configBackupDir, configBackupKeep, time.Now()); err != nil {
+			return 0, fmt.Errorf("备份配置文件失败: %v", err)
+		}
+	}
+	if err := atomicWriteFile(path, out, info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	_ = os.Chtimes(path, info.ModTime(), info.ModTime())
+	return applied, nil
+}
+
+// migrateConfigData 对YAML内容执行版本号之后的迁移并更新版本号，没有需要执行的迁移时 applied 为0
+func migrateConfigData(data []byte) ([]byte, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, 0, nil
+	}
+	editor := &yamlConfigEditor{root: doc.Content[0]}
+	version := editor.version()
+
+	applied := 0
+	for _, m := range configMigrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.apply(editor); err != nil {
+			return nil, 0, fmt.Errorf("执行配置迁移 %d（%s）失败: %v", m.version, m.description, err)
+		}
+		version = m.version
+		applied++
+	}
+	if applied == 0 {
+		return nil, 0, nil
+	}
+	editor.setVersion(version)
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("序列化配置文件失败: %v", err)
+	}
+	return out, applied, nil
+}
+
+// migrateDatabaseConfigs 对数据库中的配置执行尚未执行的迁移，每个版本一个事务并记录到 config_migrations
+func (cm *ConfigManager) migrateDatabaseConfigs() error {
+	var applied []int
+	if err := cm.db.Model(&ConfigMigration{}).Pluck("version", &applied).Error; err != nil {
+		return fmt.Errorf("查询配置迁移记录失败: %v", err)
+	}
+	done := make(map[int]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	for _, m := range configMigrations {
+		if done[m.version] {
+			continue
+		}
+		err := cm.db.Transaction(func(tx *gorm.DB) error {
+			editor := &dbConfigEditor{cm: cm, tx: tx}
+			if err := m.apply(editor); err != nil {
+				return err
+			}
+			if editor.err != nil {
+				return editor.err
+			}
+			record := ConfigMigration{Version: m.version, Description: m.description, AppliedAt: time.Now()}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error
+		})
+		if err != nil {
+			return fmt.Errorf("执行配置迁移 %d（%s）失败: %v", m.version, m.description, err)
+		}
+		cm.logger.Info("数据库配置迁移完成", zap.Int("version", m.version), zap.String("description", m.description))
+	}
+	return nil
+}
+
+// yamlConfigEditor 在config.yaml的节点树上执行迁移，保留注释和原有键的顺序
+type yamlConfigEditor struct {
+	root *yaml.Node
+}
+
+// lookup 查找键所在的映射节点和键节点的下标，不存在时下标为-1
+func (e *yamlConfigEditor) lookup(key string) (*yaml.Node, int) {
+	node := e.root
+	parts := splitKey(key)
+	for i, part := range parts {
+		if node.Kind != yaml.MappingNode {
+			return nil, -1
+		}
+		idx := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, -1
+		}
+		if i == len(parts)-1 {
+			return node, idx
+		}
+		node = node.Content[idx+1]
+	}
+	return nil, -1
+}
+
+// ensureMapping 返回路径对应的映射节点，不存在时逐级创建
+func (e *yamlConfigEditor) ensureMapping(parts []string) (*yaml.Node, error) {
+	node := e.root
+	for i, part := range parts {
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == part {
+				next = node.Content[j+1]
+				break
+			}
+		}
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, next)
+		}
+		if next.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s 不是配置分组", strings.Join(parts[:i+1], "."))
+		}
+		node = next
+	}
+	return node, nil
+}
+
+func (e *yamlConfigEditor) keys() []string {
+	var keys []string
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			key := node.Content[j].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if value := node.Content[j+1]; value.Kind == yaml.MappingNode && len(value.Content) > 0 {
+				walk(value, key)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+	}
+	walk(e.root, "")
+	return keys
+}
+
+func (e *yamlConfigEditor) has(key string) bool {
+	_, idx := e.lookup(key)
+	return idx >= 0
+}
+
+func (e *yamlConfigEditor) rename(from, to string) error {
+	parent, idx := e.lookup(from)
+	if idx < 0 || from == to {
+		return nil
+	}
+	if e.has(to) {
+		return e.remove(from)
+	}
+	toParts := splitKey(to)
+	target, err := e.ensureMapping(toParts[:len(toParts)-1])
+	if err != nil {
+		return err
+	}
+	keyNode, valueNode := parent.Content[idx], parent.Content[idx+1]
+	keyNode.Value = toParts[len(toParts)-1]
+	if target == parent {
+		// 同一分组内改名，保持原来的位置
+		return nil
+	}
+	parent.Content = append(parent.Content[:idx], parent.Content[idx+2:]...)
+	target.Content = append(target.Content, keyNode, valueNode)
+	e.prune(splitKey(from))
+	return nil
+}
+
+func (e *yamlConfigEditor) setDefault(key string, value interface{}) error {
+	if e.has(key) {
+		return nil
+	}
+	parts := splitKey(key)
+	parent, err := e.ensureMapping(parts[:len(parts)-1])
+	if err != nil {
+		return err
+	}
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[len(parts)-1]}, &valueNode)
+	return nil
+}
+
+func (e *yamlConfigEditor) remove(key string) error {
+	parent, idx := e.lookup(key)
+	if idx < 0 {
+		return nil
+	}
+	parent.Content = append(parent.Content[:idx], parent.Content[idx+2:]...)
+	e.prune(splitKey(key))
+	return nil
+}
+
+// prune 删除移走配置项后留下的空分组
+func (e *yamlConfigEditor) prune(parts []string) {
+	for n := len(parts) - 1; n > 0; n-- {
+		parent, idx := e.lookup(strings.Join(parts[:n], "."))
+		if idx < 0 {
+			return
+		}
+		if value := parent.Content[idx+1]; value.Kind != yaml.MappingNode || len(value.Content) > 0 {
+			return
+		}
+		parent.Content = append(parent.Content[:idx], parent.Content[idx+2:]...)
+	}
+}
+
+// version 读取配置文件记录的迁移版本，未记录时为0
+func (e *yamlConfigEditor) version() int {
+	parent, idx := e.lookup(configVersionKey)
+	if idx < 0 {
+		return 0
+	}
+	version, _ := strconv.Atoi(parent.Content[idx+1].Value)
+	return version
+}
+
+// setVersion 更新配置文件记录的迁移版本，首次记录时写在文件开头
+func (e *yamlConfigEditor) setVersion(version int) {
+	value := strconv.Itoa(version)
+	if parent, idx := e.lookup(configVersionKey); idx >= 0 {
+		parent.Content[idx+1].Value = value
+		return
+	}
+	e.root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: configVersionKey},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, e.root.Content...)
+}
+
+// dbConfigEditor 在数据库配置行上执行迁移，分组展开为多行，以键前缀匹配
+// 查询出错时记录在 err 中，由调用方在迁移结束后检查
+type dbConfigEditor struct {
+	cm  *ConfigManager
+	tx  *gorm.DB
+	err error
+}
+
+func (e *dbConfigEditor) keys() []string {
+	var keys []string
+	if err := e.tx.Model(&SystemConfig{}).Pluck("key", &keys).Error; err != nil && e.err == nil {
+		e.err = err
+	}
+	return keys
+}
+
+// match 返回键本身及其下所有子项的配置行
+func (e *dbConfigEditor) match(key string) []SystemConfig {
+	var rows, matched []SystemConfig
+	if err := e.tx.Select("id", "key").Find(&rows).Error; err != nil && e.err == nil {
+		e.err = err
+	}
+	for _, row := range rows {
+		if row.Key == key || strings.HasPrefix(row.Key, key+".") {
+			matched = append(matched, row)
+		}
+	}
+	return matched
+}
+
+func (e *dbConfigEditor) has(key string) bool {
+	return len(e.match(key)) > 0
+}
+
+func (e *dbConfigEditor) rename(from, to string) error {
+	rows := e.match(from)
+	if len(rows) == 0 || from == to {
+		return nil
+	}
+	if e.has(to) {
+		return e.remove(from)
+	}
+	for _, row := range rows {
+		newKey := to + strings.TrimPrefix(row.Key, from)
+		if err := e.purgeDeleted(newKey); err != nil {
+			return err
+		}
+		if err := e.tx.Model(&SystemConfig{}).Where("id = ?", row.ID).Update("key", newKey).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *dbConfigEditor) setDefault(key string, value interface{}) error {
+	if e.has(key) {
+		return nil
+	}
+	if err := e.purgeDeleted(key); err != nil {
+		return err
+	}
+	config, err := e.cm.prepareConfigForDB(key, value)
+	if err != nil {
+		return err
+	}
+	return e.tx.Create(&config).Error
+}
+
+// purgeDeleted 清除同名的软删除配置行，键上有唯一索引，残留的软删除行会导致写入失败
+func (e *dbConfigEditor) purgeDeleted(key string) error {
+	return e.tx.Unscoped().Where(map[string]interface{}{"key": key}).Where("deleted_at IS NOT NULL").Delete(&SystemConfig{}).Error
+}
+
+func (e *dbConfigEditor) remove(key string) error {
+	rows := e.match(key)
+	if len(rows) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	// 直接删除而不是软删除，以免之后无法写入同名配置
+	return e.tx.Unscoped().Where("id IN ?", ids).Delete(&SystemConfig{}).Error
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateConfigData(t *testing.T) {
+	input := `# 配置文件
+auth:
+    enableEmail: true
+    enable-oauth2: false
+quota:
+    default-level: 1
+    levelLimits:
+        "1":
+            maxInstances: 2
+            max-traffic: 1024
+instance-env:
+    env:
+        MY_VAR: keep
+host-events:
+    interval: 10
+`
+	out, applied, err := migrateConfigData([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(configMigrations) {
+		t.Fatalf("applied = %d, want %d", applied, len(configMigrations))
+	}
+
+	var got map[string]interface{}
+	if err := yaml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	flat := (&ConfigManager{}).flattenConfig(got, "")
+	if flat["auth.enable-email"] != true || flat["auth.enableEmail"] != nil {
+		t.Errorf("驼峰配置键未改名: %v", flat)
+	}
+	limits, _ := flat["quota.level-limits"].(map[string]interface{})
+	level1, _ := limits["1"].(map[string]interface{})
+	if level1["max-instances"] != 2 || level1["max-traffic"] != 1024 {
+		t.Errorf("level-limits 迁移错误: %v", limits)
+	}
+	if _, ok := flat["quota.levelLimits"]; ok {
+		t.Errorf("迁移后应删除空的旧分组: %v", flat)
+	}
+	if flat["instance-env.env.MY_VAR"] != "keep" {
+		t.Errorf("自定义内容不应改名: %v", flat)
+	}
+	if flat["host-events.interval"] != 10 || flat["host-events.max-lines"] != 2000 || flat["host-events.retention-days"] != 30 {
+		t.Errorf("默认值补充错误: %v", flat)
+	}
+	if got[configVersionKey] != configMigrations[len(configMigrations)-1].version {
+		t.Errorf("配置版本未记录: %v", got[configVersionKey])
+	}
+	if !strings.HasPrefix(string(out), configVersionKey+":") || !strings.Contains(string(out), "# 配置文件") {
+		t.Errorf("版本号应写在开头并保留注释:\n%s", out)
+	}
+
+	// 已是最新版本时不再执行
+	if _, applied, err := migrateConfigData(out); err != nil || applied != 0 {
+		t.Fatalf("重复执行 applied = %d, err = %v", applied, err)
+	}
+}
+
+func TestYAMLConfigEditorRename(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("a:\n    b: 1\n    c: 2\nd:\n    e: 3\n"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	e := &yamlConfigEditor{root: doc.Content[0]}
+
+	// 拆分分组：移动到新的分组，原分组保留其余配置项
+	if err := e.rename("a.b", "x.y.b"); err != nil {
+		t.Fatal(err)
+	}
+	if e.has("a.b") || !e.has("x.y.b") || !e.has("a.c") {
+		t.Fatalf("移动配置项错误: %v", e.keys())
+	}
+	// 目标已存在时保留目标并删除原配置，空分组一并删除
+	if err := e.setDefault("x.y.c", 9); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.rename("a.c", "x.y.c"); err != nil {
+		t.Fatal(err)
+	}
+	if e.has("a") {
+		t.Fatalf("空分组未删除: %v", e.keys())
+	}
+	// 整个分组改名
+	if err := e.rename("d", "f"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"f.e", "x.y.b", "x.y.c"}
+	if got := e.keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+	if err := e.rename("f.e", "f.e.g"); err == nil {
+		t.Fatal("移动到配置项之下应返回错误")
+	}
+}
+
+func TestMigrateConfigFileKeepsModTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("quota:\n    default-level: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	applied, err := MigrateConfigFile(path)
+	if err != nil || applied == 0 {
+		t.Fatalf("applied = %d, err = %v", applied, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("修改时间 = %v, want %v", info.ModTime(), modTime)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("文件权限 = %v", info.Mode().Perm())
+	}
+}
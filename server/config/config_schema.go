@@ -0,0 +1,217 @@
+package config
+
+import (
+	"embed"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// configSchemaSource 配置结构体的源码，配置项的说明取自字段注释，避免另外维护一份说明
+//
+//go:embed config.go zap.go
+var configSchemaSource embed.FS
+
+// ConfigFieldMeta 配置项元数据
+type ConfigFieldMeta struct {
+	Key          string      `json:"key"`  // 完整配置键，如 quota.default-level
+	Name         string      `json:"name"` // 配置键的最后一段
+	Type         string      `json:"type"` // bool, int, float, string, array, object
+	Description  string      `json:"description,omitempty"`
+	Value        interface{} `json:"value"`                  // 当前生效的值，敏感配置为脱敏值
+	Default      interface{} `json:"default,omitempty"`      // 默认值
+	Min          interface{} `json:"min,omitempty"`          // 允许的最小值
+	Max          interface{} `json:"max,omitempty"`          // 允许的最大值
+	Secret       bool        `json:"secret"`                 // 敏感配置，只写
+	SecretStatus string      `json:"secretStatus,omitempty"` // 敏感配置的设置状态：set/unset
+	Public       bool        `json:"public"`                 // 公开配置，无需认证即可读取
+	SystemLevel  bool        `json:"systemLevel"`            // 系统级配置，只能通过config.yaml修改
+}
+
+// ConfigSectionMeta 配置分组元数据，嵌套的配置结构作为子分组
+type ConfigSectionMeta struct {
+	Key         string              `json:"key"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Fields      []ConfigFieldMeta   `json:"fields"`
+	Sections    []ConfigSectionMeta `json:"sections,omitempty"`
+}
+
+// configComments 配置结构体的类型注释和字段注释
+type configComments struct {
+	types  map[string]string            // 类型名 -> 类型注释
+	fields map[string]map[string]string // 类型名 -> 字段名 -> 字段注释
+}
+
+var (
+	configCommentsOnce sync.Once
+	configCommentsData *configComments
+)
+
+// loadConfigComments 解析配置结构体源码中的注释
+func loadConfigComments() *configComments {
+	configCommentsOnce.Do(func() {
+		comments := &configComments{types: make(map[string]string), fields: make(map[string]map[string]string)}
+		entries, _ := configSchemaSource.ReadDir(".")
+		fset := token.NewFileSet()
+		for _, entry := range entries {
+			src, err := configSchemaSource.ReadFile(entry.Name())
+			if err != nil {
+				continue
+			}
+			file, err := parser.ParseFile(fset, entry.Name(), src, parser.ParseComments)
+			if err != nil {
+				continue
+			}
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					name := typeSpec.Name.Name
+					doc := gen.Doc
+					if typeSpec.Doc != nil {
+						doc = typeSpec.Doc
+					}
+					comments.types[name] = strings.TrimSpace(strings.TrimPrefix(commentText(doc), name))
+					fields := make(map[string]string)
+					for _, field := range structType.Fields.List {
+						text := commentText(field.Comment)
+						if text == "" {
+							text = commentText(field.Doc)
+						}
+						for _, ident := range field.Names {
+							fields[ident.Name] = text
+						}
+					}
+					comments.fields[name] = fields
+				}
+			}
+		}
+		configCommentsData = comments
+	})
+	return configCommentsData
+}
+
+// commentText 返回注释文本，多行注释合并为一行
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
+
+// ConfigMetadata 按分组返回全部配置项的元数据，供前端生成设置页面
+// 类型和层级取自配置结构体，说明取自字段注释，取值范围取自验证规则，公开、敏感和系统级标记取自各自的注册表
+// current 为当前生效的配置（global.APP_CONFIG），由调用方传入以避免循环导入
+func (cm *ConfigManager) ConfigMetadata(current Server) []ConfigSectionMeta {
+	comments := loadConfigComments()
+	defaults := cm.flattenConfig(getDefaultConfigMap(), "")
+
+	v := reflect.ValueOf(current)
+	t := v.Type()
+	sections := make([]ConfigSectionMeta, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := configTagName(field)
+		if key == "" || field.Type.Kind() != reflect.Struct {
+			continue
+		}
+		section := cm.buildConfigSection(comments, defaults, key, key, v.Field(i))
+		if desc := comments.fields[t.Name()][field.Name]; desc != "" {
+			section.Description = desc
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// buildConfigSection 构建一个配置分组，嵌套的结构体作为子分组
+func (cm *ConfigManager) buildConfigSection(comments *configComments, defaults map[string]interface{}, key, name string, v reflect.Value) ConfigSectionMeta {
+	t := v.Type()
+	section := ConfigSectionMeta{
+		Key:         key,
+		Name:        name,
+		Description: comments.types[t.Name()],
+		Fields:      []ConfigFieldMeta{},
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := configTagName(field)
+		if tag == "" {
+			continue
+		}
+		fieldKey := key + "." + tag
+		desc := comments.fields[t.Name()][field.Name]
+
+		if field.Type.Kind() == reflect.Struct {
+			sub := cm.buildConfigSection(comments, defaults, fieldKey, tag, v.Field(i))
+			if desc != "" {
+				sub.Description = desc
+			}
+			section.Sections = append(section.Sections, sub)
+			continue
+		}
+
+		meta := ConfigFieldMeta{
+			Key:         fieldKey,
+			Name:        tag,
+			Type:        configFieldType(field.Type),
+			Description: desc,
+			Value:       v.Field(i).Interface(),
+			Default:     defaults[fieldKey],
+			Public:      publicConfigKeys[fieldKey],
+			SystemLevel: isSystemLevelConfig(fieldKey),
+		}
+		if rule, ok := cm.validationRules[fieldKey]; ok {
+			meta.Min = rule.MinValue
+			meta.Max = rule.MaxValue
+		}
+		if IsSecretConfigKey(fieldKey) {
+			meta.Secret = true
+			meta.SecretStatus = SecretStatus(meta.Value)
+			meta.Value = MaskSecretValue(meta.Value)
+			if meta.Default != nil {
+				meta.Default = MaskSecretValue(meta.Default)
+			}
+		}
+		section.Fields = append(section.Fields, meta)
+	}
+	return section
+}
+
+// configTagName 返回字段的配置键名，没有 mapstructure 标签或显式忽略的字段返回空
+func configTagName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
+
+// configFieldType 返回配置项的类型名称
+func configFieldType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,68 @@
+package config
+
+import "testing"
+
+func findConfigSection(sections []ConfigSectionMeta, key string) *ConfigSectionMeta {
+	for i := range sections {
+		if sections[i].Key == key {
+			return &sections[i]
+		}
+		if sub := findConfigSection(sections[i].Sections, key); sub != nil {
+			return sub
+		}
+	}
+	return nil
+}
+
+func findConfigField(section *ConfigSectionMeta, key string) *ConfigFieldMeta {
+	for i := range section.Fields {
+		if section.Fields[i].Key == key {
+			return &section.Fields[i]
+		}
+	}
+	return nil
+}
+
+func TestConfigMetadata(t *testing.T) {
+	cm := &ConfigManager{validationRules: make(map[string]ConfigValidationRule)}
+	cm.initValidationRules()
+
+	var current Server
+	current.Auth.EnablePublicRegistration = true
+	current.Auth.EmailPassword = "secret"
+	current.Auth.EmailSMTPPort = 465
+	current.System.Addr = 8888
+	sections := cm.ConfigMetadata(current)
+
+	auth := findConfigSection(sections, "auth")
+	if auth == nil {
+		t.Fatal("缺少 auth 分组")
+	}
+	reg := findConfigField(auth, "auth.enable-public-registration")
+	if reg == nil || reg.Type != "bool" || reg.Value != true || !reg.Public || reg.Description != "是否启用公开注册（无需邀请码）" {
+		t.Errorf("公开配置元数据错误: %+v", reg)
+	}
+	pwd := findConfigField(auth, "auth.email-password")
+	if pwd == nil || !pwd.Secret || pwd.Value != SecretMask || pwd.SecretStatus != SecretStatusSet {
+		t.Errorf("敏感配置未脱敏: %+v", pwd)
+	}
+	port := findConfigField(auth, "auth.email-smtp-port")
+	if port == nil || port.Type != "int" || port.Min != 1 || port.Max != 65535 || port.Default != 587 || port.Value != 465 {
+		t.Errorf("取值范围错误: %+v", port)
+	}
+
+	addr := findConfigField(findConfigSection(sections, "system"), "system.addr")
+	if addr == nil || !addr.SystemLevel || addr.Description != "端口值" {
+		t.Errorf("系统级配置元数据错误: %+v", addr)
+	}
+
+	// 嵌套的配置结构作为子分组
+	perms := findConfigSection(sections, "quota.instance-type-permissions")
+	if perms == nil || findConfigField(perms, "quota.instance-type-permissions.min-level-for-vm") == nil {
+		t.Errorf("缺少子分组: %+v", perms)
+	}
+	quota := findConfigSection(sections, "quota")
+	if limits := findConfigField(quota, "quota.level-limits"); limits == nil || limits.Type != "object" {
+		t.Errorf("level-limits 应作为整体: %+v", limits)
+	}
+}
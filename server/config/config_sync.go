@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置同步方向
+const (
+	ConfigSyncDBToYAML = "db-to-yaml" // 用数据库中的配置重建config.yaml
+	ConfigSyncYAMLToDB = "yaml-to-db" // 用config.yaml覆盖数据库中的配置
+)
+
+// configSyncMu 保证同一时间只有一个手动同步任务
+var configSyncMu sync.Mutex
+
+// ErrConfigSyncRunning 已有同步任务在执行
+var ErrConfigSyncRunning = errors.New("已有配置同步任务正在执行，请稍后再试")
+
+// ConfigSyncResult 配置同步结果
+type ConfigSyncResult struct {
+	Direction  string            `json:"direction"`
+	Changes    []ConfigDiffEntry `json:"changes"`
+	BackupFile string            `json:"backupFile,omitempty"` // 写入前备份的原config.yaml，未写入时为空
+}
+
+// ValidConfigSyncDirection 检查同步方向是否有效
+func ValidConfigSyncDirection(direction string) bool {
+	return direction == ConfigSyncDBToYAML || direction == ConfigSyncYAMLToDB
+}
+
+// readYAMLSyncConfig 读取config.yaml中会同步到数据库的非系统级配置
+func (cm *ConfigManager) readYAMLSyncConfig(file []byte) (map[string]interface{}, error) {
+	var yamlConfig map[string]interface{}
+	if err := yaml.Unmarshal(file, &yamlConfig); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	result := make(map[string]interface{})
+	for key, value := range cm.flattenConfig(yamlConfig, "") {
+		if !isSystemLevelConfig(key) {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// PreviewSync 预览同步会产生的变更，不修改任何配置
+// db-to-yaml 对比当前与重建后的config.yaml；yaml-to-db 对比数据库与config.yaml，只包含YAML中存在的配置项
+func (cm *ConfigManager) PreviewSync(direction string) ([]ConfigDiffEntry, error) {
+	if !ValidConfigSyncDirection(direction) {
+		return nil, fmt.Errorf("无效的同步方向: %s", direction)
+	}
+
+	file, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	yamlConfig, err := cm.readYAMLSyncConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	dbConfigs, err := cm.loadNonSystemConfigsFromDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if direction == ConfigSyncDBToYAML {
+		if len(dbConfigs) == 0 {
+			return []ConfigDiffEntry{}, nil
+		}
+		out, err := cm.renderYAMLFromConfigs(file, dbConfigs)
+		if err != nil {
+			return nil, err
+		}
+		rebuilt, err := cm.readYAMLSyncConfig(out)
+		if err != nil {
+			return nil, err
+		}
+		return DiffConfig(yamlConfig, rebuilt), nil
+	}
+
+	// 同步到数据库只会新增或覆盖YAML中存在的配置项，不会删除数据库中多出的配置
+	dbConfig := make(map[string]interface{})
+	for _, config := range dbConfigs {
+		if _, ok := yamlConfig[config.Key]; ok {
+			dbConfig[config.Key] = parseConfigValue(config.Value)
+		}
+	}
+	return DiffConfig(dbConfig, yamlConfig), nil
+}
+
+// ExecuteSync 执行YAML与数据库之间的手动同步，同一时间只允许一个同步任务
+// 写入config.yaml前会备份原文件；yaml-to-db 同步后重新加载配置到全局配置
+func (cm *ConfigManager) ExecuteSync(direction string) (*ConfigSyncResult, error) {
+	if !ValidConfigSyncDirection(direction) {
+		return nil, fmt.Errorf("无效的同步方向: %s", direction)
+	}
+	if !configSyncMu.TryLock() {
+		return nil, ErrConfigSyncRunning
+	}
+	defer configSyncMu.Unlock()
+
+	changes, err := cm.PreviewSync(direction)
+	if err != nil {
+		return nil, err
+	}
+	result := &ConfigSyncResult{Direction: direction, Changes: changes}
+	if len(changes) == 0 {
+		cm.logger.Info("配置已一致，无需同步", zap.String("direction", direction))
+		return result, nil
+	}
+
+	switch direction {
+	case ConfigSyncDBToYAML:
+		backupFile, err := cm.restoreConfigFromDatabase()
+		if err != nil {
+			return nil, err
+		}
+		result.BackupFile = backupFile
+	case ConfigSyncYAMLToDB:
+		if err := cm.ReloadFromYAML(); err != nil {
+			return nil, err
+		}
+	}
+
+	cm.logger.Info("配置手动同步完成",
+		zap.String("direction", direction),
+		zap.Int("changeCount", len(changes)),
+		zap.String("backupFile", result.BackupFile))
+	return result, nil
+}
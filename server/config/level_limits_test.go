@@ -236,3 +236,21 @@ func toFloat64(v interface{}) float64 {
 		return 0
 	}
 }
+
+// TestLevelLimitRunningLimit 测试同时运行实例数上限的计算
+func TestLevelLimitRunningLimit(t *testing.T) {
+	tests := []struct {
+		limit LevelLimitInfo
+		want  int
+	}{
+		{LevelLimitInfo{MaxInstances: 5}, 5},
+		{LevelLimitInfo{MaxInstances: 5, MaxRunning: 2}, 2},
+		{LevelLimitInfo{MaxInstances: 5, MaxRunning: 5}, 5},
+		{LevelLimitInfo{MaxInstances: 5, MaxRunning: 8}, 5},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.RunningLimit(); got != tt.want {
+			t.Errorf("RunningLimit(%+v) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SecretMask 敏感配置在读取接口中的占位值，提交该值表示保持原值不变
+const SecretMask = "********"
+
+// 敏感配置的设置状态
+const (
+	SecretStatusSet   = "set"
+	SecretStatusUnset = "unset"
+)
+
+// secretConfigKeys 敏感配置项注册表（扁平化的 kebab-case 键）
+// 注册的配置项在读取接口和日志中只显示是否已设置，写入时为只写语义
+var secretConfigKeys = map[string]bool{
+	"jwt.signing-key":                 true,
+	"mysql.password":                  true,
+	"redis.password":                  true,
+	"auth.email-password":             true,
+	"auth.telegram-bot-token":         true,
+	"auth.qq-app-key":                 true,
+	"oss.access-key":                  true,
+	"oss.secret-key":                  true,
+	"rdns.webhook-token":              true,
+	"admin-access.bypass-token":       true,
+	"cdn.sign-key":                    true,
+	"control-plane-backup.passphrase": true,
+}
+
+// normalizeConfigKey 将点分隔的配置键逐段转换为 kebab-case，兼容前端的驼峰键名
+func normalizeConfigKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = camelToKebab(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// IsSecretConfigKey 判断配置项是否为注册的敏感配置
+func IsSecretConfigKey(key string) bool {
+	return secretConfigKeys[normalizeConfigKey(key)]
+}
+
+// SecretConfigKeys 返回所有注册的敏感配置键
+func SecretConfigKeys() []string {
+	keys := make([]string, 0, len(secretConfigKeys))
+	for key := range secretConfigKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsSecretMask 判断提交的值是否为脱敏占位值
+func IsSecretMask(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && s == SecretMask
+}
+
+// SecretStatus 返回敏感配置的设置状态
+func SecretStatus(value interface{}) string {
+	if value == nil || fmt.Sprint(value) == "" {
+		return SecretStatusUnset
+	}
+	return SecretStatusSet
+}
+
+// MaskSecretValue 脱敏单个敏感配置值，未设置时返回空字符串
+func MaskSecretValue(value interface{}) string {
+	if SecretStatus(value) == SecretStatusUnset {
+		return ""
+	}
+	return SecretMask
+}
+
+// MaskSecretConfig 返回扁平化配置的脱敏副本
+func MaskSecretConfig(flat map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(flat))
+	for key, value := range flat {
+		if IsSecretConfigKey(key) {
+			result[key] = MaskSecretValue(value)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// dropMaskedSecrets 移除值为脱敏占位值的敏感配置项，避免占位值覆盖真实值
+func dropMaskedSecrets(flat map[string]interface{}) {
+	for key, value := range flat {
+		if IsSecretConfigKey(key) && IsSecretMask(value) {
+			delete(flat, key)
+		}
+	}
+}
+
+// maskConfigLogValue 日志中输出配置值时使用，敏感配置只输出设置状态
+func maskConfigLogValue(key, value string) string {
+	if IsSecretConfigKey(key) {
+		return SecretStatus(value)
+	}
+	return value
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// secretFieldWords 字段名包含这些词的字符串配置项视为敏感配置
+var secretFieldWords = []string{"passphrase", "password", "key", "token"}
+
+// nonSecretConfigKeys 名称包含上述词但不是敏感配置的配置项
+var nonSecretConfigKeys = map[string]bool{
+	"zap.stacktrace-key": true, // 日志中堆栈字段的名称
+}
+
+// collectStringConfigKeys 按 mapstructure 标签遍历配置结构体，返回全部字符串配置项的扁平键
+func collectStringConfigKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		key := prefix
+		if opts != "squash" {
+			if name == "" {
+				continue
+			}
+			key = prefix + name
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			if opts == "squash" {
+				collectStringConfigKeys(field.Type, key, keys)
+			} else {
+				collectStringConfigKeys(field.Type, key+".", keys)
+			}
+		case reflect.String:
+			*keys = append(*keys, key)
+		}
+	}
+}
+
+func TestSecretConfigKeysRegistered(t *testing.T) {
+	var keys []string
+	collectStringConfigKeys(reflect.TypeOf(Server{}), "", &keys)
+	if len(keys) == 0 {
+		t.Fatal("未找到配置项")
+	}
+	for _, key := range keys {
+		name := key[strings.LastIndex(key, ".")+1:]
+		for _, word := range secretFieldWords {
+			if strings.Contains(name, word) && !IsSecretConfigKey(key) && !nonSecretConfigKeys[key] {
+				t.Errorf("配置项 %s 疑似敏感配置，但未在 secretConfigKeys 中注册", key)
+			}
+		}
+	}
+	for key := range secretConfigKeys {
+		found := false
+		for _, k := range keys {
+			found = found || k == key
+		}
+		if !found {
+			t.Errorf("secretConfigKeys 中的 %s 不是有效的配置项", key)
+		}
+	}
+}
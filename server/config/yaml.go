@@ -66,8 +66,11 @@ func (cm *ConfigManager) handleYAMLFirst() error {
 
 // writeConfigToYAML 将配置写回到YAML文件（保留原始key格式）
 func (cm *ConfigManager) writeConfigToYAML(updates map[string]interface{}) error {
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+
 	// 读取现有配置文件
-	file, err := os.ReadFile("config.yaml")
+	file, err := os.ReadFile(configFilePath)
 	if err != nil {
 		cm.logger.Error("读取配置文件失败", zap.Error(err))
 		return err
@@ -86,8 +89,9 @@ func (cm *ConfigManager) writeConfigToYAML(updates map[string]interface{}) error
 		zap.Int("originalCount", len(updates)),
 		zap.Int("convertedCount", len(kebabUpdates)))
 
-	// 使用Node API更新值,保持原有key格式不变
-	for key, value := range kebabUpdates {
+	// 使用Node API逐个更新叶子配置项，保持原有key格式不变
+	// 只提交了部分配置项的分组不会覆盖整个分组，未提交的配置项保留原值
+	for key, value := range cm.flattenConfig(kebabUpdates, "") {
 		if err := updateYAMLNode(&node, key, value); err != nil {
 			// 只在debug级别记录配置键不存在的警告，避免日志噪音
 			cm.logger.Debug("更新YAML节点失败", zap.String("key", key), zap.Error(err))
@@ -100,7 +104,7 @@ func (cm *ConfigManager) writeConfigToYAML(updates map[string]interface{}) error
 	}
 
 	// 写回文件
-	if err := os.WriteFile("config.yaml", out, 0644); err != nil {
+	if _, err := writeConfigFile(out); err != nil {
 		cm.logger.Error("写入配置文件失败", zap.Error(err))
 		return err
 	}
@@ -439,21 +443,70 @@ func parseConfigValue(valueStr string) interface{} {
 
 // RestoreConfigFromDatabase 从数据库恢复配置到YAML文件
 func (cm *ConfigManager) RestoreConfigFromDatabase() error {
+	_, err := cm.restoreConfigFromDatabase()
+	return err
+}
+
+// restoreConfigFromDatabase 从数据库恢复配置到YAML文件，返回写入前原文件的备份路径
+func (cm *ConfigManager) restoreConfigFromDatabase() (string, error) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
 	cm.logger.Info("开始从数据库恢复配置到YAML文件")
 
+	nonSystemConfigs, err := cm.loadNonSystemConfigsFromDB()
+	if err != nil {
+		return "", err
+	}
+	if len(nonSystemConfigs) == 0 {
+		cm.logger.Info("没有需要恢复的配置")
+		return "", nil
+	}
+
+	configFileMu.Lock()
+	defer configFileMu.Unlock()
+
+	// 读取现有YAML文件
+	file, err := os.ReadFile(configFilePath)
+	if err != nil {
+		cm.logger.Error("读取配置文件失败", zap.Error(err))
+		return "", fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	out, err := cm.renderYAMLFromConfigs(file, nonSystemConfigs)
+	if err != nil {
+		return "", err
+	}
+
+	// 写回文件
+	backupFile, err := writeConfigFile(out)
+	if err != nil {
+		cm.logger.Error("写入配置文件失败", zap.Error(err))
+		return "", fmt.Errorf("写入配置文件失败: %v", err)
+	}
+
+	// 更新内存缓存 - 使用解析后的值，确保类型正确（只更新非系统级配置）
+	for _, config := range nonSystemConfigs {
+		parsedValue := parseConfigValue(config.Value)
+		cm.configCache[config.Key] = parsedValue
+		cm.logger.Debug("更新配置缓存",
+			zap.String("key", config.Key),
+			zap.String("rawValue", config.Value),
+			zap.Any("parsedValue", parsedValue),
+			zap.String("parsedType", fmt.Sprintf("%T", parsedValue)))
+	}
+
+	cm.logger.Info("配置已成功从数据库恢复到YAML文件", zap.String("backupFile", backupFile))
+	return backupFile, nil
+}
+
+// loadNonSystemConfigsFromDB 从数据库读取所有非系统级配置
+func (cm *ConfigManager) loadNonSystemConfigsFromDB() ([]SystemConfig, error) {
 	// 从数据库读取所有配置
 	var configs []SystemConfig
 	if err := cm.db.Find(&configs).Error; err != nil {
 		cm.logger.Error("从数据库读取配置失败", zap.Error(err))
-		return fmt.Errorf("从数据库读取配置失败: %v", err)
-	}
-
-	if len(configs) == 0 {
-		cm.logger.Warn("数据库中没有配置数据，跳过恢复")
-		return nil
+		return nil, fmt.Errorf("从数据库读取配置失败: %v", err)
 	}
 
 	cm.logger.Info("从数据库读取到配置", zap.Int("count", len(configs)))
@@ -475,29 +528,21 @@ func (cm *ConfigManager) RestoreConfigFromDatabase() error {
 		zap.Int("totalCount", len(configs)),
 		zap.Int("restoreCount", len(nonSystemConfigs)),
 		zap.Int("skippedSystemCount", skippedSystemCount))
+	return nonSystemConfigs, nil
+}
 
-	if len(nonSystemConfigs) == 0 {
-		cm.logger.Info("没有需要恢复的配置（所有配置都是系统级配置）")
-		return nil
-	}
-
-	// 读取现有YAML文件
-	file, err := os.ReadFile("config.yaml")
-	if err != nil {
-		cm.logger.Error("读取配置文件失败", zap.Error(err))
-		return fmt.Errorf("读取配置文件失败: %v", err)
-	}
-
+// renderYAMLFromConfigs 用数据库中的配置值更新YAML内容，保持原有key格式和顺序
+func (cm *ConfigManager) renderYAMLFromConfigs(file []byte, configs []SystemConfig) ([]byte, error) {
 	// 使用Node API解析，保持原有格式
 	var node yaml.Node
 	if err := yaml.Unmarshal(file, &node); err != nil {
 		cm.logger.Error("解析YAML失败", zap.Error(err))
-		return fmt.Errorf("解析YAML失败: %v", err)
+		return nil, fmt.Errorf("解析YAML失败: %v", err)
 	}
 
-	// 使用Node API更新每个配置值（只更新非系统级配置）
+	// 使用Node API更新每个配置值
 	restoredCount := 0
-	for _, config := range nonSystemConfigs {
+	for _, config := range configs {
 		// 尝试反序列化JSON值
 		value := parseConfigValue(config.Value)
 
@@ -512,35 +557,16 @@ func (cm *ConfigManager) RestoreConfigFromDatabase() error {
 	}
 
 	cm.logger.Info("配置恢复统计",
-		zap.Int("attemptedCount", len(nonSystemConfigs)),
+		zap.Int("attemptedCount", len(configs)),
 		zap.Int("restoredCount", restoredCount))
 
 	// 序列化Node，保持原有key格式
 	out, err := yaml.Marshal(&node)
 	if err != nil {
 		cm.logger.Error("序列化配置失败", zap.Error(err))
-		return fmt.Errorf("序列化配置失败: %v", err)
+		return nil, fmt.Errorf("序列化配置失败: %v", err)
 	}
-
-	// 写回文件
-	if err := os.WriteFile("config.yaml", out, 0644); err != nil {
-		cm.logger.Error("写入配置文件失败", zap.Error(err))
-		return fmt.Errorf("写入配置文件失败: %v", err)
-	}
-
-	// 更新内存缓存 - 使用解析后的值，确保类型正确（只更新非系统级配置）
-	for _, config := range nonSystemConfigs {
-		parsedValue := parseConfigValue(config.Value)
-		cm.configCache[config.Key] = parsedValue
-		cm.logger.Debug("更新配置缓存",
-			zap.String("key", config.Key),
-			zap.String("rawValue", config.Value),
-			zap.Any("parsedValue", parsedValue),
-			zap.String("parsedType", fmt.Sprintf("%T", parsedValue)))
-	}
-
-	cm.logger.Info("配置已成功从数据库恢复到YAML文件")
-	return nil
+	return out, nil
 }
 
 // syncYAMLConfigToDatabase 将YAML配置同步到数据库
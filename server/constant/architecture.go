@@ -0,0 +1,27 @@
+package constant
+
+import "strings"
+
+// CPU架构常量
+const (
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+	ArchS390X = "s390x"
+
+	// DefaultArchitecture 节点或镜像未设置架构时的默认值
+	DefaultArchitecture = ArchAMD64
+)
+
+// NormalizeArchitecture 将 uname -m 等不同写法统一为 amd64/arm64 等标准名称，空值视为默认架构
+func NormalizeArchitecture(arch string) string {
+	switch strings.ToLower(strings.TrimSpace(arch)) {
+	case "", "amd64", "x86_64", "x64":
+		return ArchAMD64
+	case "arm64", "aarch64", "armv8", "armv8l":
+		return ArchARM64
+	case "s390x":
+		return ArchS390X
+	default:
+		return strings.ToLower(strings.TrimSpace(arch))
+	}
+}
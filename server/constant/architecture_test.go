@@ -0,0 +1,20 @@
+package constant
+
+import "testing"
+
+func TestNormalizeArchitecture(t *testing.T) {
+	cases := map[string]string{
+		"":        ArchAMD64,
+		"x86_64":  ArchAMD64,
+		" AMD64 ": ArchAMD64,
+		"aarch64": ArchARM64,
+		"armv8l":  ArchARM64,
+		"s390x":   ArchS390X,
+		"RISCV64": "riscv64",
+	}
+	for raw, want := range cases {
+		if got := NormalizeArchitecture(raw); got != want {
+			t.Errorf("NormalizeArchitecture(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
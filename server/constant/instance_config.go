@@ -21,10 +21,12 @@ const (
 type ProviderType string
 
 const (
-	ProviderTypeDocker  ProviderType = "docker"
-	ProviderTypeLXD     ProviderType = "lxd"
-	ProviderTypeIncus   ProviderType = "incus"
-	ProviderTypeProxmox ProviderType = "proxmox"
+	ProviderTypeDocker     ProviderType = "docker"
+	ProviderTypeLXD        ProviderType = "lxd"
+	ProviderTypeIncus      ProviderType = "incus"
+	ProviderTypeProxmox    ProviderType = "proxmox"
+	ProviderTypeLXC        ProviderType = "lxc"
+	ProviderTypeKubernetes ProviderType = "kubernetes"
 )
 
 // Architecture 架构类型
@@ -15,6 +15,16 @@ const (
 	InstanceStatusDeleting = "deleting"
 	InstanceStatusDeleted  = "deleted"
 	InstanceStatusFailed   = "failed"
+
+	// Operation states - 操作进行中状态（由任务设置，操作完成后回到稳定状态）
+	InstanceStatusStarting   = "starting"
+	InstanceStatusStopping   = "stopping"
+	InstanceStatusRestarting = "restarting"
+
+	// Provider-reported states - Provider上报的其他状态
+	InstanceStatusPaused      = "paused"
+	InstanceStatusUnavailable = "unavailable"
+	InstanceStatusUnknown     = "unknown"
 )
 
 // GetStableStatuses 返回所有稳定状态
@@ -53,6 +63,18 @@ func GetQuotaCountableStatuses() []string {
 	return GetStableStatuses()
 }
 
+// GetRunningCountableStatuses 返回计入同时运行实例数的状态
+// 正在启动、重启、创建或重置的实例完成后都会处于运行状态，同样占用运行名额
+func GetRunningCountableStatuses() []string {
+	return []string{
+		InstanceStatusRunning,
+		InstanceStatusStarting,
+		InstanceStatusRestarting,
+		InstanceStatusCreating,
+		InstanceStatusResetting,
+	}
+}
+
 // IsStableStatus 判断是否为稳定状态
 func IsStableStatus(status string) bool {
 	for _, s := range GetStableStatuses() {
@@ -0,0 +1,171 @@
+package constant
+
+import (
+	"fmt"
+	"strings"
+)
+
+// providerStatusMappings 各Provider原始状态到标准实例状态的映射表（键为小写原始状态）
+var providerStatusMappings = map[string]map[string]string{
+	"docker": {
+		"running":    InstanceStatusRunning,
+		"restarting": InstanceStatusRestarting,
+		"paused":     InstanceStatusPaused,
+		"created":    InstanceStatusStopped,
+		"exited":     InstanceStatusStopped,
+		"dead":       InstanceStatusError,
+		"removing":   InstanceStatusDeleting,
+	},
+	"lxd": {
+		"running":  InstanceStatusRunning,
+		"stopped":  InstanceStatusStopped,
+		"frozen":   InstanceStatusPaused,
+		"starting": InstanceStatusStarting,
+		"stopping": InstanceStatusStopping,
+		"error":    InstanceStatusError,
+		"aborting": InstanceStatusError,
+	},
+	"incus": {
+		"running":  InstanceStatusRunning,
+		"stopped":  InstanceStatusStopped,
+		"frozen":   InstanceStatusPaused,
+		"starting": InstanceStatusStarting,
+		"stopping": InstanceStatusStopping,
+		"error":    InstanceStatusError,
+		"aborting": InstanceStatusError,
+	},
+	"proxmox": {
+		"running":   InstanceStatusRunning,
+		"stopped":   InstanceStatusStopped,
+		"paused":    InstanceStatusPaused,
+		"suspended": InstanceStatusPaused,
+		"prelaunch": InstanceStatusStarting,
+	},
+	"lxc": {
+		"running":  InstanceStatusRunning,
+		"thawed":   InstanceStatusRunning,
+		"stopped":  InstanceStatusStopped,
+		"starting": InstanceStatusStarting,
+		"stopping": InstanceStatusStopping,
+		"frozen":   InstanceStatusPaused,
+		"freezing": InstanceStatusPaused,
+		"paused":   InstanceStatusPaused,
+		"aborting": InstanceStatusError,
+		"error":    InstanceStatusError,
+	},
+	"kubernetes": {
+		"running":          InstanceStatusRunning,
+		"stopped":          InstanceStatusStopped,
+		"pending":          InstanceStatusStarting,
+		"starting":         InstanceStatusStarting,
+		"stopping":         InstanceStatusStopping,
+		"failed":           InstanceStatusError,
+		"crashloopbackoff": InstanceStatusError,
+		"error":            InstanceStatusError,
+	},
+}
+
+// commonStatusMapping 未知Provider类型或映射表未覆盖时使用的通用映射
+var commonStatusMapping = map[string]string{
+	"running": InstanceStatusRunning,
+	"active":  InstanceStatusRunning,
+	"up":      InstanceStatusRunning,
+	"stopped": InstanceStatusStopped,
+	"shutoff": InstanceStatusStopped,
+	"exited":  InstanceStatusStopped,
+	"paused":  InstanceStatusPaused,
+	"frozen":  InstanceStatusPaused,
+	"error":   InstanceStatusError,
+}
+
+// NormalizeProviderStatus 将Provider返回的原始状态（如 Running、RUNNING、frozen）转换为标准实例状态
+// 无法识别的状态返回 unknown
+func NormalizeProviderStatus(providerType, rawStatus string) string {
+	status := strings.ToLower(strings.TrimSpace(rawStatus))
+	if status == "" {
+		return InstanceStatusUnknown
+	}
+	if mapped, ok := providerStatusMappings[strings.ToLower(providerType)][status]; ok {
+		return mapped
+	}
+	if mapped, ok := commonStatusMapping[status]; ok {
+		return mapped
+	}
+	return InstanceStatusUnknown
+}
+
+// instanceStatusTransitions 允许的实例状态迁移，未列出的迁移视为非法
+var instanceStatusTransitions = map[string][]string{
+	InstanceStatusCreating:    {InstanceStatusRunning, InstanceStatusStopped, InstanceStatusError, InstanceStatusFailed, InstanceStatusDeleting},
+	InstanceStatusRunning:     {InstanceStatusStopping, InstanceStatusStopped, InstanceStatusRestarting, InstanceStatusResetting, InstanceStatusPaused, InstanceStatusError, InstanceStatusUnavailable, InstanceStatusDeleting},
+	InstanceStatusStopped:     {InstanceStatusStarting, InstanceStatusRunning, InstanceStatusResetting, InstanceStatusError, InstanceStatusUnavailable, InstanceStatusDeleting},
+	InstanceStatusError:       {InstanceStatusStarting, InstanceStatusStopping, InstanceStatusRestarting, InstanceStatusResetting, InstanceStatusRunning, InstanceStatusStopped, InstanceStatusUnavailable, InstanceStatusDeleting},
+	InstanceStatusStarting:    {InstanceStatusRunning, InstanceStatusStopped, InstanceStatusError, InstanceStatusDeleting},
+	InstanceStatusStopping:    {InstanceStatusStopped, InstanceStatusRunning, InstanceStatusError, InstanceStatusDeleting},
+	InstanceStatusRestarting:  {InstanceStatusRunning, InstanceStatusStopped, InstanceStatusError, InstanceStatusDeleting},
+	InstanceStatusResetting:   {InstanceStatusRunning, InstanceStatusStopped, InstanceStatusError, InstanceStatusFailed, InstanceStatusDeleting},
+	InstanceStatusPaused:      {InstanceStatusRunning, InstanceStatusStopping, InstanceStatusStopped, InstanceStatusError, InstanceStatusDeleting},
+	InstanceStatusUnavailable: {InstanceStatusStarting, InstanceStatusStopping, InstanceStatusRunning, InstanceStatusStopped, InstanceStatusError, InstanceStatusDeleting},
+	InstanceStatusDeleting:    {InstanceStatusDeleted, InstanceStatusStopped, InstanceStatusError, InstanceStatusFailed}, // 删除任务取消时回退为stopped
+	InstanceStatusFailed:      {InstanceStatusDeleting},
+	InstanceStatusDeleted:     {},
+}
+
+// instanceActionTargetStatus 实例操作对应的目标状态
+var instanceActionTargetStatus = map[string]string{
+	"start":   InstanceStatusStarting,
+	"stop":    InstanceStatusStopping,
+	"restart": InstanceStatusRestarting,
+	"reset":   InstanceStatusResetting,
+	"delete":  InstanceStatusDeleting,
+}
+
+// CanTransitionInstanceStatus 判断实例状态能否从 from 迁移到 to
+// unknown 或未登记的历史状态不做限制，避免阻塞对异常实例的处理
+func CanTransitionInstanceStatus(from, to string) bool {
+	if from == to {
+		return true
+	}
+	allowed, ok := instanceStatusTransitions[from]
+	if !ok {
+		return true
+	}
+	for _, status := range allowed {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInstanceActionStatus 返回实例操作（start/stop/restart/reset/delete）对应的目标状态
+func GetInstanceActionStatus(action string) (string, bool) {
+	status, ok := instanceActionTargetStatus[action]
+	return status, ok
+}
+
+// ValidateInstanceAction 校验当前状态下能否执行实例操作，如删除中的实例不能启动
+func ValidateInstanceAction(currentStatus, action string) error {
+	target, ok := GetInstanceActionStatus(action)
+	if !ok {
+		return fmt.Errorf("不支持的操作类型: %s", action)
+	}
+	if currentStatus == target {
+		return fmt.Errorf("实例正在执行该操作（当前状态: %s）", currentStatus)
+	}
+	if !CanTransitionInstanceStatus(currentStatus, target) {
+		return fmt.Errorf("实例当前状态为 %s，不允许执行 %s 操作", currentStatus, action)
+	}
+	return nil
+}
+
+// IsInstanceOperationInProgress 判断实例是否处于操作进行中的状态
+// 此类状态与Provider实际状态不一致属于正常现象，对账时应跳过
+func IsInstanceOperationInProgress(status string) bool {
+	switch status {
+	case InstanceStatusCreating, InstanceStatusResetting, InstanceStatusStarting,
+		InstanceStatusStopping, InstanceStatusRestarting, InstanceStatusDeleting:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package constant
+
+import "testing"
+
+func TestNormalizeProviderStatus(t *testing.T) {
+	cases := []struct {
+		providerType, raw, want string
+	}{
+		{"incus", "Running", InstanceStatusRunning},
+		{"lxd", "STOPPED", InstanceStatusStopped},
+		{"lxd", "Frozen", InstanceStatusPaused},
+		{"proxmox", "suspended", InstanceStatusPaused},
+		{"docker", "exited", InstanceStatusStopped},
+		{"docker", "dead", InstanceStatusError},
+		{"unknown-type", "active", InstanceStatusRunning},
+		{"incus", "", InstanceStatusUnknown},
+		{"incus", "something-new", InstanceStatusUnknown},
+	}
+	for _, c := range cases {
+		if got := NormalizeProviderStatus(c.providerType, c.raw); got != c.want {
+			t.Errorf("NormalizeProviderStatus(%q, %q) = %q, want %q", c.providerType, c.raw, got, c.want)
+		}
+	}
+}
+
+func TestValidateInstanceAction(t *testing.T) {
+	if err := ValidateInstanceAction(InstanceStatusDeleting, "start"); err == nil {
+		t.Error("deleting instance should not be startable")
+	}
+	if err := ValidateInstanceAction(InstanceStatusDeleting, "delete"); err == nil {
+		t.Error("deleting instance should not accept a second delete")
+	}
+	if err := ValidateInstanceAction(InstanceStatusStopped, "start"); err != nil {
+		t.Errorf("stopped instance should be startable: %v", err)
+	}
+	if err := ValidateInstanceAction(InstanceStatusRunning, "reset"); err != nil {
+		t.Errorf("running instance should be resettable: %v", err)
+	}
+	if err := ValidateInstanceAction(InstanceStatusRunning, "migrate"); err == nil {
+		t.Error("unsupported action should be rejected")
+	}
+	// 未登记的历史状态不做限制
+	if err := ValidateInstanceAction("legacy", "stop"); err != nil {
+		t.Errorf("unregistered status should not block actions: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package constant
+
+import "strings"
+
+// Windows 实例相关常量
+const (
+	OSTypeWindows    = "windows"       // Windows 镜像的 os_type
+	WindowsAdminUser = "Administrator" // Windows 默认管理员账户
+	LinuxRootUser    = "root"          // Linux 默认管理员账户
+	SSHGuestPort     = 22              // SSH 默认端口
+	RDPGuestPort     = 3389            // RDP 默认端口
+)
+
+// IsWindowsOSType 判断操作系统类型是否为 Windows（兼容 windows、win2022、windows-11 等写法）
+func IsWindowsOSType(osType string) bool {
+	osType = strings.ToLower(strings.TrimSpace(osType))
+	return strings.HasPrefix(osType, "win")
+}
+
+// DefaultLoginUser 返回操作系统默认的登录用户名
+func DefaultLoginUser(osType string) string {
+	if IsWindowsOSType(osType) {
+		return WindowsAdminUser
+	}
+	return LinuxRootUser
+}
+
+// DefaultLoginPort 返回操作系统默认的远程登录端口（Linux为SSH，Windows为RDP）
+func DefaultLoginPort(osType string) int {
+	if IsWindowsOSType(osType) {
+		return RDPGuestPort
+	}
+	return SSHGuestPort
+}
+
+// GuestSSHPortOrDefault 返回实例内SSH端口，未设置时为默认的22
+func GuestSSHPortOrDefault(port int) int {
+	if port <= 0 || port > 65535 {
+		return SSHGuestPort
+	}
+	return port
+}
+
+// InstanceLoginPort 返回实例内的远程登录端口：Windows为RDP，其余为实例内SSH端口
+func InstanceLoginPort(osType string, guestSSHPort int) int {
+	if IsWindowsOSType(osType) {
+		return RDPGuestPort
+	}
+	return GuestSSHPortOrDefault(guestSSHPort)
+}
@@ -0,0 +1,30 @@
+package constant
+
+import "testing"
+
+func TestInstanceLoginDefaults(t *testing.T) {
+	cases := []struct {
+		osType  string
+		sshPort int
+		windows bool
+		user    string
+		login   int
+	}{
+		{"windows", 0, true, WindowsAdminUser, RDPGuestPort},
+		{" Win2022 ", 2222, true, WindowsAdminUser, RDPGuestPort},
+		{"ubuntu", 0, false, LinuxRootUser, SSHGuestPort},
+		{"debian", 2222, false, LinuxRootUser, 2222},
+		{"alpine", 70000, false, LinuxRootUser, SSHGuestPort},
+	}
+	for _, c := range cases {
+		if got := IsWindowsOSType(c.osType); got != c.windows {
+			t.Errorf("IsWindowsOSType(%q) = %v", c.osType, got)
+		}
+		if got := DefaultLoginUser(c.osType); got != c.user {
+			t.Errorf("DefaultLoginUser(%q) = %s", c.osType, got)
+		}
+		if got := InstanceLoginPort(c.osType, c.sshPort); got != c.login {
+			t.Errorf("InstanceLoginPort(%q, %d) = %d, want %d", c.osType, c.sshPort, got, c.login)
+		}
+	}
+}
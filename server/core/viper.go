@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	configPkg "oneclickvirt/config"
 	"oneclickvirt/global"
 
 	"github.com/fsnotify/fsnotify"
@@ -23,6 +24,13 @@ func Viper(path ...string) *viper.Viper {
 		config = path[0]
 	}
 
+	// 版本升级后先迁移配置文件的结构，再读取配置
+	if applied, err := configPkg.MigrateConfigFile(config); err != nil {
+		fmt.Printf("[VIPER] 配置文件迁移失败: %s\n", err)
+	} else if applied > 0 {
+		fmt.Printf("[VIPER] 已对配置文件执行 %d 个配置迁移\n", applied)
+	}
+
 	v := viper.New()
 	v.SetConfigFile(config)
 	v.SetConfigType("yaml")
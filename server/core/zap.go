@@ -151,7 +151,7 @@ func GetWriteSyncer(level string) zapcore.WriteSyncer {
 	return zapcore.AddSync(cutter)
 }
 
-// CustomTimeEncoder 自定义日志输出时间格式
+// CustomTimeEncoder 自定义日志输出时间格式，按默认时区显示
 func CustomTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-	enc.AppendString(t.Format(global.APP_CONFIG.Zap.Prefix + "2006/01/02 - 15:04:05.000"))
+	enc.AppendString(t.In(utils.DefaultLocation()).Format(global.APP_CONFIG.Zap.Prefix + "2006/01/02 - 15:04:05.000"))
 }
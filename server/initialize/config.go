@@ -47,7 +47,7 @@ func getDefaultConfig() config.Server {
 		Mysql: config.Mysql{
 			Path:         "127.0.0.1",
 			Port:         "3306",
-			Config:       "charset=utf8mb4&parseTime=True&loc=Local&time_zone=%27%2B08%3A00%27",
+			Config:       "charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%27%2B00%3A00%27",
 			Dbname:       "oneclickvirt",
 			Username:     "root",
 			Password:     "root",
@@ -61,6 +61,13 @@ func getDefaultConfig() config.Server {
 			MaxLifetime:  3600,
 			AutoCreate:   true,
 		},
+		Sqlite: config.Sqlite{
+			Path:         "./storage/oneclickvirt.db",
+			BusyTimeout:  5000,
+			JournalMode:  "WAL",
+			MaxOpenConns: 8,
+			LogMode:      "error",
+		},
 		Auth: config.Auth{
 			EnableEmail:              false,
 			EnableTelegram:           false,
@@ -148,6 +155,40 @@ func getDefaultConfig() config.Server {
 			Password: "",
 			DB:       0,
 		},
+		Oss: config.Oss{
+			Region:   "us-east-1",
+			UseSSL:   true,
+			BasePath: "oneclickvirt",
+		},
+		Upload: config.Upload{
+			ChunkSize:          8,
+			MaxFileSize:        20480,
+			SessionExpireHours: 24,
+		},
+	}
+}
+
+// 检查并恢复损坏的配置文件，日志系统尚未初始化，直接输出到控制台
+func recoverConfigFile(configPath string) {
+	backup, err := config.RecoverConfigFile(configPath)
+	if err != nil {
+		fmt.Printf("[CONFIG] %v\n", err)
+		return
+	}
+	if backup != "" {
+		fmt.Printf("[CONFIG] 配置文件 %s 已损坏，已从备份 %s 恢复\n", configPath, backup)
+	}
+}
+
+// 执行配置文件的结构迁移，日志系统尚未初始化，直接输出到控制台
+func migrateConfigFile(configPath string) {
+	applied, err := config.MigrateConfigFile(configPath)
+	if err != nil {
+		fmt.Printf("[CONFIG] 配置文件迁移失败: %v\n", err)
+		return
+	}
+	if applied > 0 {
+		fmt.Printf("[CONFIG] 已对配置文件执行 %d 个配置迁移\n", applied)
 	}
 }
 
@@ -178,7 +219,9 @@ func createDefaultConfigFile(configPath string) error {
 // 智能选择数据库类型
 func selectDatabaseType(cfg *config.Server) {
 	switch cfg.System.DbType {
-	case "mysql", "mariadb":
+	case "sqlite":
+		fmt.Printf("[CONFIG] 使用数据库类型: sqlite，数据库文件: %s\n", cfg.Sqlite.Path)
+	case "mysql", "mariadb", "postgres":
 		if cfg.Mysql.Dbname == "" && !cfg.Mysql.AutoCreate {
 			fmt.Printf("[CONFIG] %s配置不完整且未启用自动创建数据库\n", cfg.System.DbType)
 		}
@@ -195,7 +238,7 @@ func selectDatabaseType(cfg *config.Server) {
 func detectDatabaseType() string {
 	// 检查环境变量
 	if dbType := os.Getenv("DB_TYPE"); dbType != "" {
-		if dbType == "mysql" || dbType == "mariadb" {
+		if dbType == "mysql" || dbType == "mariadb" || dbType == "postgres" || dbType == "sqlite" {
 			return dbType
 		}
 	}
@@ -233,6 +276,11 @@ func InitConfig(configPath ...string) *viper.Viper {
 		}
 	}
 
+	// 上次写入中断导致配置文件损坏时，用最近一份有效备份恢复
+	recoverConfigFile(config)
+	// 版本升级后先迁移配置文件的结构
+	migrateConfigFile(config)
+
 	// 读取配置文件
 	if err := v.ReadInConfig(); err != nil {
 		fmt.Printf("[CONFIG] 读取配置文件失败: %v，使用内存默认配置\n", err)
@@ -75,6 +75,18 @@ func syncConfigToGlobal(key string, oldValue, newValue interface{}) error {
 		if otherConfig, ok := newValue.(map[string]interface{}); ok {
 			syncOtherConfig(otherConfig)
 		}
+	case "oss":
+		if ossConfig, ok := newValue.(map[string]interface{}); ok {
+			syncOssConfig(ossConfig)
+		}
+	case "task":
+		if taskConfig, ok := newValue.(map[string]interface{}); ok {
+			syncTaskConfig(taskConfig)
+		}
+	case "cdn":
+		if cdnConfig, ok := newValue.(map[string]interface{}); ok {
+			syncCDNConfig(cdnConfig)
+		}
 	}
 	return nil
 }
@@ -149,6 +161,18 @@ func syncQuotaConfig(quotaConfig map[string]interface{}) {
 					levelLimit.MaxInstances = v
 				}
 
+				if v, ok := limitMap["max-running"].(float64); ok {
+					levelLimit.MaxRunning = int(v)
+				} else if v, ok := limitMap["max-running"].(int); ok {
+					levelLimit.MaxRunning = v
+				}
+
+				if v, ok := limitMap["max-snapshots"].(float64); ok {
+					levelLimit.MaxSnapshots = int(v)
+				} else if v, ok := limitMap["max-snapshots"].(int); ok {
+					levelLimit.MaxSnapshots = v
+				}
+
 				if v, ok := limitMap["max-resources"].(map[string]interface{}); ok {
 					levelLimit.MaxResources = v
 				}
@@ -204,6 +228,36 @@ func syncQuotaConfig(quotaConfig map[string]interface{}) {
 			global.APP_CONFIG.Quota.InstanceTypePermissions.MinLevelForResetVM = v
 		}
 	}
+
+	// 同步接口调用配额
+	if apiLimits, ok := quotaConfig["api-limits"].(map[string]interface{}); ok {
+		limits := make(map[string]config.APILimit, len(apiLimits))
+		for action, ruleData := range apiLimits {
+			ruleMap, ok := ruleData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			rule := config.APILimit{Levels: make(map[int]int)}
+			if v, ok := ruleMap["period"].(float64); ok {
+				rule.Period = int(v)
+			} else if v, ok := ruleMap["period"].(int); ok {
+				rule.Period = v
+			}
+			if levels, ok := ruleMap["levels"].(map[string]interface{}); ok {
+				for levelStr, limitValue := range levels {
+					var level int
+					fmt.Sscanf(levelStr, "%d", &level)
+					if v, ok := limitValue.(float64); ok {
+						rule.Levels[level] = int(v)
+					} else if v, ok := limitValue.(int); ok {
+						rule.Levels[level] = v
+					}
+				}
+			}
+			limits[action] = rule
+		}
+		global.APP_CONFIG.Quota.APILimits = limits
+	}
 }
 
 // syncSystemConfig 同步系统配置
@@ -308,3 +362,58 @@ func syncOtherConfig(otherConfig map[string]interface{}) {
 		global.APP_CONFIG.Other.DefaultLanguage = v
 	}
 }
+
+// syncOssConfig 同步对象存储配置
+func syncOssConfig(ossConfig map[string]interface{}) {
+	if v, ok := ossConfig["endpoint"].(string); ok {
+		global.APP_CONFIG.Oss.Endpoint = v
+	}
+	if v, ok := ossConfig["region"].(string); ok {
+		global.APP_CONFIG.Oss.Region = v
+	}
+	if v, ok := ossConfig["bucket"].(string); ok {
+		global.APP_CONFIG.Oss.Bucket = v
+	}
+	if v, ok := ossConfig["access-key"].(string); ok {
+		global.APP_CONFIG.Oss.AccessKey = v
+	}
+	if v, ok := ossConfig["secret-key"].(string); ok {
+		global.APP_CONFIG.Oss.SecretKey = v
+	}
+	if v, ok := ossConfig["use-ssl"].(bool); ok {
+		global.APP_CONFIG.Oss.UseSSL = v
+	}
+	if v, ok := ossConfig["path-style"].(bool); ok {
+		global.APP_CONFIG.Oss.PathStyle = v
+	}
+	if v, ok := ossConfig["base-path"].(string); ok {
+		global.APP_CONFIG.Oss.BasePath = v
+	}
+}
+
+// syncTaskConfig 同步任务配置中可在线修改的项
+func syncTaskConfig(taskConfig map[string]interface{}) {
+	if v, ok := taskConfig["name-reuse-cooldown"].(float64); ok {
+		global.APP_CONFIG.Task.NameReuseCooldown = int(v)
+	} else if v, ok := taskConfig["name-reuse-cooldown"].(int); ok {
+		global.APP_CONFIG.Task.NameReuseCooldown = v
+	}
+}
+
+// syncCDNConfig 同步静态资源CDN配置
+func syncCDNConfig(cdnConfig map[string]interface{}) {
+	if v, ok := cdnConfig["asset-domain"].(string); ok {
+		global.APP_CONFIG.CDN.AssetDomain = v
+	}
+	if v, ok := cdnConfig["asset-version"].(string); ok {
+		global.APP_CONFIG.CDN.AssetVersion = v
+	}
+	if v, ok := cdnConfig["sign-key"].(string); ok {
+		global.APP_CONFIG.CDN.SignKey = v
+	}
+	if v, ok := cdnConfig["sign-expire"].(float64); ok {
+		global.APP_CONFIG.CDN.SignExpire = int(v)
+	} else if v, ok := cdnConfig["sign-expire"].(int); ok {
+		global.APP_CONFIG.CDN.SignExpire = v
+	}
+}
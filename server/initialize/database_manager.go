@@ -8,6 +8,8 @@ import (
 	"oneclickvirt/global"
 	"oneclickvirt/initialize/internal"
 	"oneclickvirt/model/config"
+	"oneclickvirt/service/counters"
+	"oneclickvirt/service/database"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -105,7 +107,7 @@ func (dm *DatabaseManager) updateGlobalStats() {
 func (dm *DatabaseManager) connect() (*gorm.DB, error) {
 	global.APP_LOG.Info("正在连接数据库...")
 
-	db, err := GormMysqlConnect(dm.config)
+	db, err := GormConnect(dm.config)
 	if err != nil {
 		return nil, err
 	}
@@ -295,14 +297,33 @@ func (dm *DatabaseManager) Shutdown() {
 	}
 }
 
-// GormMysqlConnect 直接连接数据库（不经过管理器）
-func GormMysqlConnect(cfg config.MysqlConfig) (*gorm.DB, error) {
+// GormConnect 直接连接数据库（不经过管理器），按 System.DbType 选择驱动
+func GormConnect(cfg config.MysqlConfig) (*gorm.DB, error) {
 	m := global.APP_CONFIG.Mysql
 	dbType := global.APP_CONFIG.System.DbType
 	if dbType == "" {
 		dbType = "mysql"
 	}
 
+	// SQLite 使用独立的 sqlite 配置段
+	if database.IsSQLiteType(dbType) {
+		s := global.APP_CONFIG.Sqlite
+		if s.Path == "" {
+			s.Path = database.DefaultSQLitePath
+		}
+		db, err := internal.GormOpen(dbType, config.MysqlConfig{
+			Dbname:       s.Path,
+			Config:       database.SQLiteConfig(s.BusyTimeout, s.JournalMode),
+			MaxOpenConns: s.MaxOpenConns,
+			LogMode:      s.LogMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		registerCounterCallbacks(db)
+		return db, nil
+	}
+
 	// 使用传入的配置或全局配置
 	if cfg.Path == "" {
 		cfg = config.MysqlConfig{
@@ -321,11 +342,21 @@ func GormMysqlConnect(cfg config.MysqlConfig) (*gorm.DB, error) {
 		}
 	}
 
-	db, err := internal.GormMysql(cfg)
+	db, err := internal.GormOpen(dbType, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	db.InstanceSet("gorm:table_options", "ENGINE="+m.Engine)
+	if !database.IsPostgresType(dbType) && m.Engine != "" {
+		db.InstanceSet("gorm:table_options", "ENGINE="+m.Engine)
+	}
+	registerCounterCallbacks(db)
 	return db, nil
 }
+
+// registerCounterCallbacks 注册仪表板计数器的失效回调，注册失败时计数器仍按兜底有效期刷新
+func registerCounterCallbacks(db *gorm.DB) {
+	if err := counters.RegisterCallbacks(db); err != nil {
+		global.APP_LOG.Warn("注册计数器回调失败", zap.Error(err))
+	}
+}
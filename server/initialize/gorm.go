@@ -1,6 +1,7 @@
 package initialize
 
 import (
+	configPkg "oneclickvirt/config"
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
 	authModel "oneclickvirt/model/auth"
@@ -13,6 +14,7 @@ import (
 	systemModel "oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
 	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -101,7 +103,27 @@ func RegisterTables(db *gorm.DB) {
 		global.APP_LOG.Warn("修复重复数据时出现警告（可忽略，如果是新数据库）", zap.Error(fixErr))
 	}
 
-	err := db.AutoMigrate(
+	if err := database.PrepareSchema(db); err != nil {
+		global.APP_LOG.Error("数据库迁移前准备失败", zap.Error(err))
+		return
+	}
+
+	models := registeredModels()
+	err := db.AutoMigrate(models...)
+	if err != nil {
+		global.APP_LOG.Error("register table failed", zap.Error(err))
+		return
+	}
+	dropLegacyIndexes(db)
+	if err := database.MigrateTimestampsToUTC(db, models); err != nil {
+		global.APP_LOG.Error("已有时间数据转换为UTC失败，将在下次启动时重试", zap.Error(err))
+	}
+	global.APP_LOG.Info("数据库表注册成功")
+}
+
+// registeredModels 需要自动迁移的全部模型
+func registeredModels() []interface{} {
+	return []interface{}{
 		// 用户相关表
 		&userModel.User{},     // 用户基础信息表
 		&authModel.Role{},     // 角色管理表
@@ -111,36 +133,74 @@ func RegisterTables(db *gorm.DB) {
 		&oauth2Model.OAuth2Provider{}, // OAuth2提供商配置表
 
 		// 实例相关表
-		&providerModel.Instance{}, // 虚拟机/容器实例表
-		&providerModel.Provider{}, // 服务提供商配置表
-		&providerModel.Port{},     // 端口映射表
-		&adminModel.Task{},        // 用户任务表
+		&providerModel.Instance{},                 // 虚拟机/容器实例表
+		&providerModel.Provider{},                 // 服务提供商配置表
+		&providerModel.Port{},                     // 端口映射表
+		&providerModel.ProviderPortRange{},        // Provider可分配端口段表
+		&providerModel.InstanceHealthCheck{},      // 实例健康检查配置表
+		&providerModel.InstanceHealthEvent{},      // 实例健康检查事件表
+		&providerModel.InstanceShare{},            // 实例公开分享链接表
+		&providerModel.InstanceSnapshotSchedule{}, // 实例定时快照计划表
+		&providerModel.InstanceSnapshot{},         // 实例定时快照记录表
+		&providerModel.InstanceRDNS{},             // 实例反向解析记录表
+		&providerModel.ProviderHealthCheck{},      // Provider健康检查记录表
+		&adminModel.Task{},                        // 用户任务表
 
 		// 资源管理表
 		&resourceModel.ResourceReservation{}, // 资源预留表
 
 		// 认证相关表
-		&userModel.VerifyCode{},    // 验证码表（邮箱/短信）
-		&userModel.PasswordReset{}, // 密码重置令牌表
+		&userModel.VerifyCode{},              // 验证码表（邮箱/短信）
+		&userModel.PasswordReset{},           // 密码重置令牌表
+		&userModel.UserHook{},                // 用户钩子脚本表
+		&userModel.UserWebhook{},             // 用户Webhook表
+		&userModel.UserWebhookDelivery{},     // 用户Webhook投递记录表
+		&userModel.UserAPIToken{},            // 个人API令牌表
+		&userModel.InstanceGroup{},           // 实例分组表
+		&userModel.InstanceDefaults{},        // 实例默认设置表
+		&userModel.APIQuotaBucket{},          // 接口调用配额令牌桶表
+		&userModel.RegistrationApplication{}, // 注册申请表
+		&userModel.RegistrationRejection{},   // 注册拒绝记录表
+		&userModel.DataExport{},              // 用户数据导出表
 
 		// 系统配置表
-		&adminModel.SystemConfig{},  // 系统配置表
-		&systemModel.Announcement{}, // 系统公告表
-		&systemModel.SystemImage{},  // 系统镜像模板表
-		&systemModel.Captcha{},      // 图形验证码表
-		&systemModel.JWTSecret{},    // JWT密钥表
+		&adminModel.SystemConfig{},   // 系统配置表
+		&configPkg.ConfigMigration{}, // 配置迁移记录表
+		&systemModel.Announcement{},  // 系统公告表
+		&systemModel.SystemImage{},   // 系统镜像模板表
+		&systemModel.Captcha{},       // 图形验证码表
+		&systemModel.JWTSecret{},     // JWT密钥表
+		&systemModel.UploadSession{}, // 分片上传会话表
+		&systemModel.ImagePolicy{},   // 镜像禁用策略表
+		&systemModel.AppTemplate{},   // 一键应用模板表
+		&systemModel.InstanceApp{},   // 实例应用安装记录表
+
+		// 集群相关表
+		&systemModel.SchedulerLease{}, // 调度器主节点租约表
 
 		// 邀请码相关表
 		&systemModel.InviteCode{},      // 邀请码表
 		&systemModel.InviteCodeUsage{}, // 邀请码使用记录表
 
 		// 权限管理表
-		&permissionModel.UserPermission{}, // 用户权限组合表
+		&permissionModel.UserPermission{},     // 用户权限组合表
+		&permissionModel.AdminProviderScope{}, // 子管理员Provider范围表
 
 		// 审计日志表
 		&adminModel.AuditLog{},           // 操作审计日志表
+		&adminModel.ConsoleRecording{},   // 管理员终端会话录像表
+		&adminModel.ControlPlaneBackup{}, // 控制面灾备导出记录表
 		&providerModel.PendingDeletion{}, // 待删除资源表
 
+		// 滥用举报表
+		&adminModel.AbuseReport{},       // 滥用举报表
+		&adminModel.AbuseReportAction{}, // 举报处置记录表
+
+		// 宿主机脚本库
+		&adminModel.HostScript{},        // 宿主机脚本表
+		&adminModel.HostScriptVersion{}, // 宿主机脚本版本表
+		&adminModel.HostScriptRun{},     // 宿主机脚本执行记录表
+
 		// 管理员配置任务表
 		&adminModel.ConfigurationTask{},  // 管理员配置任务表
 		&adminModel.TrafficMonitorTask{}, // 流量监控操作任务表
@@ -148,14 +208,52 @@ func RegisterTables(db *gorm.DB) {
 		// 监控数据表
 		&monitoringModel.PmacctTrafficRecord{},    // pmacct流量记录表（原始数据，5分钟粒度）
 		&monitoringModel.PmacctMonitor{},          // pmacct监控配置表
+		&monitoringModel.TrafficCapture{},         // 实例流量明细抓取记录表
 		&monitoringModel.InstanceTrafficHistory{}, // 实例流量历史表
 		&monitoringModel.ProviderTrafficHistory{}, // Provider流量历史表
 		&monitoringModel.UserTrafficHistory{},     // 用户流量历史表
 		&monitoringModel.PerformanceMetric{},      // 性能指标历史表
-	)
-	if err != nil {
-		global.APP_LOG.Error("register table failed", zap.Error(err))
+		&monitoringModel.UsageSample{},            // 资源用量采样表
+		&monitoringModel.UsageForecast{},          // 资源用量趋势预测表
+		&monitoringModel.NetworkProbe{},           // 节点网络质量测试记录表
+		&monitoringModel.HostEvent{},              // 宿主机日志事件表
+
+		// 数据迁移记录表
+		&systemModel.DataMigration{},         // 一次性数据迁移记录表
+		&systemModel.DataMigrationProgress{}, // 分批数据迁移进度表
+	}
+}
+
+// legacyIndexes 已重命名的索引旧名称
+// PostgreSQL 的索引名在整个schema内唯一，原先在多张表中重复使用的索引名已改为带表名前缀
+var legacyIndexes = []struct {
+	model interface{}
+	names []string
+}{
+	{&adminModel.Task{}, []string{"idx_provider_status", "idx_user_status"}},
+	{&userModel.User{}, []string{"idx_status", "idx_expires_at"}},
+	{&providerModel.Provider{}, []string{"idx_status", "idx_frozen"}},
+	{&providerModel.Port{}, []string{"idx_provider_id"}},
+	{&monitoringModel.InstanceTrafficHistory{}, []string{"idx_provider_id", "idx_user_id", "idx_record_time", "idx_deleted_at"}},
+	{&monitoringModel.PerformanceMetric{}, []string{"idx_timestamp"}},
+}
+
+// dropLegacyIndexes 删除已有MySQL数据库中的旧名称索引，AutoMigrate 已按新名称重新创建
+func dropLegacyIndexes(db *gorm.DB) {
+	if utils.IsPostgres(db) || utils.IsSQLite(db) {
 		return
 	}
-	global.APP_LOG.Info("数据库表注册成功")
+	migrator := db.Migrator()
+	for _, legacy := range legacyIndexes {
+		for _, name := range legacy.names {
+			if !migrator.HasIndex(legacy.model, name) {
+				continue
+			}
+			if err := migrator.DropIndex(legacy.model, name); err != nil {
+				global.APP_LOG.Warn("删除旧索引失败", zap.String("index", name), zap.Error(err))
+				continue
+			}
+			global.APP_LOG.Info("已删除旧索引", zap.String("index", name))
+		}
+	}
 }
@@ -8,16 +8,21 @@ import (
 
 	"oneclickvirt/global"
 	"oneclickvirt/model/config"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// GormMysql 初始化数据库（支持MySQL和MariaDB），支持自动创建数据库
-func GormMysql(m config.MysqlConfig) (*gorm.DB, error) {
+// GormOpen 初始化数据库（支持MySQL、MariaDB、PostgreSQL和SQLite），支持自动创建数据库
+func GormOpen(dbType string, m config.MysqlConfig) (*gorm.DB, error) {
+	if database.IsSQLiteType(dbType) {
+		return gormSqlite(m)
+	}
+
 	// 检查基本参数
 	if m.Username == "" {
 		return nil, fmt.Errorf("数据库用户名不能为空")
@@ -26,10 +31,10 @@ func GormMysql(m config.MysqlConfig) (*gorm.DB, error) {
 		m.Path = "127.0.0.1"
 	}
 	if m.Port == "" {
-		m.Port = "3306"
+		m.Port = database.DefaultPort(dbType)
 	}
 	if m.Config == "" {
-		m.Config = "charset=utf8mb4&parseTime=True&loc=Local&time_zone=%27%2B08%3A00%27"
+		m.Config = database.DefaultConfig(dbType)
 	}
 
 	// 如果没有指定数据库名且需要自动创建，则设置默认数据库名
@@ -38,33 +43,25 @@ func GormMysql(m config.MysqlConfig) (*gorm.DB, error) {
 	}
 
 	// 如果启用自动创建且有权限，尝试创建数据库
-	if m.AutoCreate && m.Username == "root" && m.Dbname != "" {
-		if err := createDatabaseIfNotExists(m); err != nil {
+	if m.AutoCreate && (m.Username == "root" || m.Username == "postgres") && m.Dbname != "" {
+		if err := createDatabaseIfNotExists(dbType, m); err != nil {
 			global.APP_LOG.Warn("自动创建数据库失败", zap.Error(err))
 		} else {
 			global.APP_LOG.Info("数据库检查/创建完成", zap.String("database", m.Dbname))
 		}
 	}
 
-	// 构建DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
-		m.Username, m.Password, m.Path, m.Port, m.Dbname, m.Config)
-
-	mysqlConfig := mysql.Config{
-		DSN:                       dsn,
-		DefaultStringSize:         191,   // string 类型字段的默认长度
-		SkipInitializeWithVersion: false, // 根据版本自动配置
-	}
-
 	gormConfig := gormConfig(m.LogMode, m.LogZap)
 
-	db, err := gorm.Open(mysql.New(mysqlConfig), gormConfig)
+	db, err := gorm.Open(database.NewDialector(dbType, connOptions(m, m.Dbname)), gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
 
-	// 设置表选项为InnoDB引擎
-	db.InstanceSet("gorm:table_options", "ENGINE=InnoDB")
+	// 设置表选项为InnoDB引擎（仅MySQL/MariaDB）
+	if !database.IsPostgresType(dbType) {
+		db.InstanceSet("gorm:table_options", "ENGINE=InnoDB")
+	}
 
 	// 配置连接池
 	sqlDB, err := db.DB()
@@ -95,33 +92,74 @@ func GormMysql(m config.MysqlConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
+// gormSqlite 打开SQLite数据库，m.Dbname 为数据库文件路径，文件不存在时自动创建
+func gormSqlite(m config.MysqlConfig) (*gorm.DB, error) {
+	if m.Dbname == "" {
+		return nil, fmt.Errorf("SQLite数据库文件路径不能为空")
+	}
+	if m.Config == "" {
+		m.Config = database.DefaultConfig(utils.DBTypeSQLite)
+	}
+
+	created, err := database.EnsureSQLiteFile(m.Dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, connOptions(m, m.Dbname)), gormConfig(m.LogMode, m.LogZap))
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	if created {
+		global.APP_LOG.Info("自动创建SQLite数据库", zap.String("path", m.Dbname))
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接池失败: %w", err)
+	}
+
+	// SQLite 为本地文件，连接开销小，保持所有连接常驻
+	maxOpen := m.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = 8
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxOpen)
+
+	return db, nil
+}
+
+// connOptions 将配置转换为连接参数
+func connOptions(m config.MysqlConfig, dbname string) database.ConnOptions {
+	return database.ConnOptions{
+		Host:     m.Path,
+		Port:     m.Port,
+		Username: m.Username,
+		Password: m.Password,
+		Dbname:   dbname,
+		Config:   m.Config,
+	}
+}
+
 // createDatabaseIfNotExists 创建数据库（如果不存在）
-func createDatabaseIfNotExists(m config.MysqlConfig) error {
+func createDatabaseIfNotExists(dbType string, m config.MysqlConfig) error {
 	// 连接数据库服务器（不指定数据库）
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/?%s",
-		m.Username, m.Password, m.Path, m.Port, m.Config)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(database.NewDialector(dbType, connOptions(m, "")), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
 		return fmt.Errorf("连接数据库服务器失败: %w", err)
 	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
 
-	// 检查数据库是否存在
-	var count int64
-	err = db.Raw("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", m.Dbname).Scan(&count).Error
+	created, err := database.EnsureDatabase(db, m.Dbname)
 	if err != nil {
-		return fmt.Errorf("检查数据库是否存在失败: %w", err)
+		return err
 	}
-
-	// 如果数据库不存在则创建
-	if count == 0 {
-		createSQL := fmt.Sprintf("CREATE DATABASE `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", m.Dbname)
-		err = db.Exec(createSQL).Error
-		if err != nil {
-			return fmt.Errorf("创建数据库失败: %w", err)
-		}
+	if created {
 		global.APP_LOG.Info("自动创建数据库成功", zap.String("database", m.Dbname))
 	}
 
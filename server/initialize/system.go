@@ -7,6 +7,7 @@ import (
 	"oneclickvirt/core"
 	"oneclickvirt/global"
 	"oneclickvirt/service/auth"
+	"oneclickvirt/service/cluster"
 	"oneclickvirt/service/lifecycle"
 	"oneclickvirt/service/log"
 	"oneclickvirt/service/pmacct"
@@ -22,6 +23,7 @@ import (
 	_ "oneclickvirt/provider/portmapping/docker"
 	_ "oneclickvirt/provider/portmapping/incus"
 	_ "oneclickvirt/provider/portmapping/iptables"
+	_ "oneclickvirt/provider/portmapping/kubernetes"
 	_ "oneclickvirt/provider/portmapping/lxd"
 
 	"go.uber.org/zap"
@@ -323,6 +325,12 @@ func initializeSchedulers() {
 	// 启动前先同步Provider层面的数据（资源和流量统计）
 	syncProvidersDataOnStartup()
 
+	// 启动主节点选举（集群模式下只有主节点运行定时任务）
+	// 先于调度器注册，关闭时最后停止并释放租约，避免与新主节点同时运行任务
+	leaderElector := cluster.GetLeaderElector()
+	leaderElector.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("LeaderElector", leaderElector)
+
 	// 启动调度器服务
 	schedulerService := scheduler.NewSchedulerService(taskService)
 	global.APP_SCHEDULER = schedulerService
@@ -347,6 +355,71 @@ func initializeSchedulers() {
 	instanceSyncSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
 	lifecycleMgr.Register("InstanceSyncScheduler", instanceSyncSchedulerService)
 
+	// 启动实例健康检查调度器
+	instanceHealthSchedulerService := scheduler.NewInstanceHealthSchedulerService()
+	instanceHealthSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("InstanceHealthScheduler", instanceHealthSchedulerService)
+
+	// 启动监控数据保留策略调度器
+	retentionSchedulerService := scheduler.NewRetentionSchedulerService()
+	retentionSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("RetentionScheduler", retentionSchedulerService)
+
+	// 启动配额与资源计数一致性审计调度器
+	consistencyAuditSchedulerService := scheduler.NewConsistencyAuditSchedulerService()
+	consistencyAuditSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("ConsistencyAuditScheduler", consistencyAuditSchedulerService)
+
+	// 启动config.yaml、数据库与生效配置一致性检查调度器
+	configConsistencySchedulerService := scheduler.NewConfigConsistencySchedulerService()
+	configConsistencySchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("ConfigConsistencyScheduler", configConsistencySchedulerService)
+
+	// 启动实例内磁盘使用量采集调度器
+	diskUsageSchedulerService := scheduler.NewDiskUsageSchedulerService()
+	diskUsageSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("DiskUsageScheduler", diskUsageSchedulerService)
+
+	// 启动实例操作系统EOL同步与通知调度器
+	osEOLSchedulerService := scheduler.NewOSEOLSchedulerService()
+	osEOLSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("OSEOLScheduler", osEOLSchedulerService)
+
+	// 启动闲置实例检测与自动停机调度器
+	idleStopSchedulerService := scheduler.NewIdleStopSchedulerService()
+	idleStopSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("IdleStopScheduler", idleStopSchedulerService)
+
+	// 启动实例定时快照调度器
+	snapshotSchedulerService := scheduler.NewSnapshotSchedulerService()
+	snapshotSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("SnapshotScheduler", snapshotSchedulerService)
+
+	// 启动资源用量采样与趋势预测调度器
+	forecastSchedulerService := scheduler.NewForecastSchedulerService()
+	forecastSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("ForecastScheduler", forecastSchedulerService)
+
+	// 启动一次性邮箱列表刷新调度器
+	disposableEmailSchedulerService := scheduler.NewDisposableEmailSchedulerService()
+	disposableEmailSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("DisposableEmailScheduler", disposableEmailSchedulerService)
+
+	// 启动网络质量定时测试调度器
+	networkProbeSchedulerService := scheduler.NewNetworkProbeSchedulerService()
+	networkProbeSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("NetworkProbeScheduler", networkProbeSchedulerService)
+
+	// 启动控制面数据定时灾备导出调度器
+	controlPlaneBackupSchedulerService := scheduler.NewControlPlaneBackupSchedulerService()
+	controlPlaneBackupSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("ControlPlaneBackupScheduler", controlPlaneBackupSchedulerService)
+
+	// 启动用户Webhook调度器（即将到期事件和投递记录清理）
+	userWebhookSchedulerService := scheduler.NewUserWebhookSchedulerService()
+	userWebhookSchedulerService.Start(global.APP_SHUTDOWN_CONTEXT)
+	lifecycleMgr.Register("UserWebhookScheduler", userWebhookSchedulerService)
+
 	// 注册pmacct批处理器
 	pmacctBatchProcessor := pmacct.GetBatchProcessor()
 	lifecycleMgr.Register("PmacctBatchProcessor", pmacctBatchProcessor)
@@ -8,10 +8,13 @@ import (
 	systemAPI "oneclickvirt/api/v1/system"
 	"oneclickvirt/global"
 	"oneclickvirt/initialize"
+	"oneclickvirt/utils"
 
 	_ "oneclickvirt/docs"
 	_ "oneclickvirt/provider/docker"
 	_ "oneclickvirt/provider/incus"
+	_ "oneclickvirt/provider/kubernetes"
+	_ "oneclickvirt/provider/lxc"
 	_ "oneclickvirt/provider/lxd"
 	_ "oneclickvirt/provider/proxmox"
 
@@ -39,9 +42,17 @@ import (
 // @name Authorization
 
 func main() {
+	// 时间统一按UTC处理，展示和计算重置边界时再换算到用户或Provider的时区
+	utils.UseUTC()
+
 	// 确保从正确的工作目录运行
 	ensureCorrectWorkingDirectory()
 
+	// 从控制面灾备归档恢复数据后退出
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		os.Exit(runRestore(os.Args[2:]))
+	}
+
 	// 设置系统初始化完成后的回调函数
 	initialize.SetSystemInitCallback()
 
@@ -0,0 +1,10 @@
+//go:build fake
+// +build fake
+
+package main
+
+// 使用 -tags fake 构建时注册模拟Provider，用于CI集成测试和本地开发，生产构建不包含
+import (
+	_ "oneclickvirt/provider/fake"
+	_ "oneclickvirt/provider/portmapping/fake"
+)
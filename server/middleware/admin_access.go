@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/netip"
+	"strings"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminBypassTokenHeader 提交管理接口白名单应急令牌的请求头
+const AdminBypassTokenHeader = "X-Admin-Bypass-Token"
+
+// AdminIPAllowlist 管理接口来源IP白名单中间件，login 为 true 时只在开启 protect-login 后生效
+// 白名单在每次请求时读取当前配置，通过配置接口修改后立即生效
+func AdminIPAllowlist(login bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := global.APP_CONFIG.AdminAccess
+		if !cfg.Enabled || len(cfg.AllowedCIDRs) == 0 || (login && !cfg.ProtectLogin) {
+			c.Next()
+			return
+		}
+
+		if cfg.BypassToken != "" {
+			token := c.GetHeader(AdminBypassTokenHeader)
+			if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.BypassToken)) == 1 {
+				global.APP_LOG.Warn("使用应急令牌绕过管理接口IP白名单",
+					zap.String("ip", c.RemoteIP()),
+					zap.String("path", c.Request.URL.Path))
+				c.Next()
+				return
+			}
+		}
+
+		allowed, err := config.ParseCIDRList(cfg.AllowedCIDRs)
+		if err != nil {
+			// 配置文件中的无效条目不应让白名单失效，拒绝访问并提示修正
+			global.APP_LOG.Error("管理接口IP白名单配置无效", zap.Error(err))
+		}
+		trusted, _ := config.ParseCIDRList(cfg.TrustedProxies)
+
+		ip := requestSourceIP(c, trusted)
+		if err == nil && ipInPrefixes(ip, allowed) {
+			c.Next()
+			return
+		}
+
+		global.APP_LOG.Warn("来源IP不在管理接口白名单内",
+			zap.String("ip", ip.String()),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("method", c.Request.Method))
+		common.ResponseWithError(c, common.NewError(common.CodeForbidden, "当前IP不允许访问管理接口"))
+		c.Abort()
+	}
+}
+
+// requestSourceIP 返回请求的来源IP
+// 只有直连地址属于可信代理时才读取X-Forwarded-For，并从右往左跳过可信代理，取第一个不可信的地址，避免客户端伪造最左侧的条目
+func requestSourceIP(c *gin.Context, trusted []netip.Prefix) netip.Addr {
+	remote, _ := netip.ParseAddr(c.RemoteIP())
+	remote = remote.Unmap()
+	if !ipInPrefixes(remote, trusted) {
+		return remote
+	}
+	hops := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = addr.Unmap()
+		if !ipInPrefixes(addr, trusted) {
+			return addr
+		}
+		remote = addr
+	}
+	return remote
+}
+
+// ipInPrefixes 判断IP是否属于任一网段
+func ipInPrefixes(ip netip.Addr, prefixes []netip.Prefix) bool {
+	if !ip.IsValid() {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"oneclickvirt/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestSourceIP(t *testing.T) {
+	trusted, err := config.ParseCIDRList([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"203.0.113.5:1234", "198.51.100.1", "203.0.113.5"},         // 非可信代理，忽略XFF
+		{"10.0.0.2:1234", "198.51.100.1", "198.51.100.1"},           // 可信代理转发
+		{"10.0.0.2:1234", "1.1.1.1, 198.51.100.1", "198.51.100.1"},  // 伪造的最左侧条目被忽略
+		{"10.0.0.2:1234", "198.51.100.1, 10.0.0.3", "198.51.100.1"}, // 多级可信代理
+		{"10.0.0.2:1234", "", "10.0.0.2"},                           // 无XFF时使用直连地址
+		{"[::ffff:203.0.113.5]:1234", "", "203.0.113.5"},            // IPv4映射地址
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/v1/admin/dashboard", nil)
+		c.Request.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			c.Request.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if got := requestSourceIP(c, trusted).String(); got != tc.want {
+			t.Errorf("requestSourceIP(%s, %q) = %s, want %s", tc.remote, tc.forwarded, got, tc.want)
+		}
+	}
+}
+
+func TestParseCIDRListAndMatch(t *testing.T) {
+	prefixes, err := config.ParseCIDRList([]string{"192.168.1.0/24", " 203.0.113.7 ", "", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := []string{"192.168.1.20", "203.0.113.7", "2001:db8::1"}
+	denied := []string{"192.168.2.1", "203.0.113.8", "2001:db9::1"}
+	for _, ip := range allowed {
+		if !ipInPrefixes(netip.MustParseAddr(ip), prefixes) {
+			t.Errorf("%s should be allowed", ip)
+		}
+	}
+	for _, ip := range denied {
+		if ipInPrefixes(netip.MustParseAddr(ip), prefixes) {
+			t.Errorf("%s should be denied", ip)
+		}
+	}
+	if _, err := config.ParseCIDRList([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("invalid CIDR should fail")
+	}
+}
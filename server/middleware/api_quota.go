@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/service/apiquota"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIQuota 按用户等级限制控制面接口调用次数的中间件，每次请求扣减一次配额
+// 配额见 quota.api-limits，需放在 RequireAuth 之后
+func APIQuota(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CheckAPIQuota(c, action, 1) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// CheckAPIQuota 扣减当前用户 n 次配额并写入 X-RateLimit-* 响应头，配额不足时写入429响应并返回false
+// 供需要按请求内容计数的接口（如批量创建）在绑定参数后调用；管理员和未配置配额的等级不受限制
+func CheckAPIQuota(c *gin.Context, action string, n int) bool {
+	authCtx, ok := GetAuthContext(c)
+	if !ok || authCtx.UserType == "admin" {
+		return true
+	}
+	res, err := apiquota.Take(authCtx.UserID, authCtx.Level, action, n)
+	if err != nil {
+		// 配额存储异常时放行，避免影响正常使用
+		global.APP_LOG.Warn("检查接口调用配额失败", zap.Uint("userID", authCtx.UserID), zap.String("action", action), zap.Error(err))
+		return true
+	}
+	if !res.Limited {
+		return true
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(res.Reset))
+	if res.Allowed {
+		return true
+	}
+	msg := "操作过于频繁，已超出当前等级的接口调用配额，请稍后再试"
+	if n > res.Limit {
+		msg = fmt.Sprintf("单次请求数量超出当前等级的接口调用配额（%d）", res.Limit)
+	} else {
+		c.Header("Retry-After", strconv.Itoa(res.RetryAfter))
+	}
+	common.ResponseWithError(c, common.NewError(common.CodeTooManyRequests, msg))
+	return false
+}
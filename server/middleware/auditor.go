@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// auditorWritableRoutes 审计员可以调用的非只读接口，只涉及自己的登录状态和账户
+var auditorWritableRoutes = map[string]bool{
+	"POST /api/v1/auth/logout":        true,
+	"PUT /api/v1/user/reset-password": true,
+}
+
+// auditorGuardedPrefixes 管理接口的路径前缀，其中的只读接口需要在 auditorReadableRoutes 中列出才对审计员开放
+var auditorGuardedPrefixes = []string{"/api/v1/admin/", "/api/v1/config", "/api/v1/oauth2/"}
+
+// auditorReadableRoutes 审计员可以查看的管理接口，HEAD 请求按同路径的 GET 判断；新增管理接口默认不对审计员开放
+var auditorReadableRoutes = map[string]bool{
+	// 仪表盘与监控
+	"GET /api/v1/admin/dashboard":             true,
+	"GET /api/v1/admin/dashboard/counters":    true,
+	"GET /api/v1/admin/forecasts":             true,
+	"GET /api/v1/admin/forecasts/samples":     true,
+	"GET /api/v1/admin/host-events":           true,
+	"GET /api/v1/admin/monitoring/audit-logs": true,
+	"GET /api/v1/admin/monitoring/system":     true,
+	"GET /api/v1/admin/performance/history":   true,
+	"GET /api/v1/admin/performance/metrics":   true,
+	"GET /api/v1/admin/storage/health":        true,
+
+	// 配置（敏感项以脱敏形式返回）
+	"GET /api/v1/admin/config":                  true,
+	"GET /api/v1/admin/config/consistency":      true,
+	"GET /api/v1/admin/config/metadata":         true,
+	"GET /api/v1/admin/config/secrets":          true,
+	"GET /api/v1/admin/config/sync/preview":     true,
+	"GET /api/v1/admin/configuration-tasks":     true,
+	"GET /api/v1/admin/configuration-tasks/:id": true,
+	"GET /api/v1/config":                        true,
+	"GET /api/v1/oauth2/presets":                true,
+	"GET /api/v1/oauth2/presets/:name":          true,
+	"GET /api/v1/oauth2/providers":              true,
+	"GET /api/v1/oauth2/providers/:id":          true,
+
+	// 用户与注册
+	"GET /api/v1/admin/invite-codes":                  true,
+	"GET /api/v1/admin/invite-codes/export":           true,
+	"GET /api/v1/admin/quota/consistency-audit":       true,
+	"GET /api/v1/admin/quota/users/:userId":           true,
+	"GET /api/v1/admin/registration-applications":     true,
+	"GET /api/v1/admin/registration-rejections/stats": true,
+	"GET /api/v1/admin/users":                         true,
+	"GET /api/v1/admin/users/:id/provider-scope":      true,
+
+	// 实例与端口
+	"GET /api/v1/admin/console-recordings":                        true,
+	"GET /api/v1/admin/instance-type-permissions":                 true,
+	"GET /api/v1/admin/instances":                                 true,
+	"GET /api/v1/admin/instances/:id/console-log":                 true,
+	"GET /api/v1/admin/instances/:id/host-events":                 true,
+	"GET /api/v1/admin/instances/:id/port-mappings":               true,
+	"GET /api/v1/admin/instances/:id/traffic-captures":            true,
+	"GET /api/v1/admin/instances/:id/traffic-captures/:captureId": true,
+	"GET /api/v1/admin/port-mappings":                             true,
+
+	// Provider与镜像
+	"GET /api/v1/admin/apps":                                true,
+	"GET /api/v1/admin/image-policies":                      true,
+	"GET /api/v1/admin/providers":                           true,
+	"GET /api/v1/admin/providers/:id/compatibility":         true,
+	"GET /api/v1/admin/providers/:id/health-history":        true,
+	"GET /api/v1/admin/providers/:id/host-events":           true,
+	"GET /api/v1/admin/providers/:id/instance-names/check":  true,
+	"GET /api/v1/admin/providers/:id/network-probes":        true,
+	"GET /api/v1/admin/providers/:id/orphaned":              true,
+	"GET /api/v1/admin/providers/:id/port-plan":             true,
+	"GET /api/v1/admin/providers/:id/port-ranges":           true,
+	"GET /api/v1/admin/providers/:id/port-usage":            true,
+	"GET /api/v1/admin/providers/:id/ssh-key":               true,
+	"GET /api/v1/admin/providers/:id/status":                true,
+	"GET /api/v1/admin/providers/:id/traffic/history":       true,
+	"GET /api/v1/admin/providers/capacity":                  true,
+	"GET /api/v1/admin/providers/check-endpoint":            true,
+	"GET /api/v1/admin/providers/check-name":                true,
+	"GET /api/v1/admin/providers/traffic-monitor/latest":    true,
+	"GET /api/v1/admin/providers/traffic-monitor/tasks":     true,
+	"GET /api/v1/admin/providers/traffic-monitor/tasks/:id": true,
+	"GET /api/v1/admin/system-images":                       true,
+	"GET /api/v1/admin/uploads":                             true,
+	"GET /api/v1/admin/uploads/:uuid":                       true,
+
+	// 任务
+	"GET /api/v1/admin/tasks":                true,
+	"GET /api/v1/admin/tasks/:taskId":        true,
+	"GET /api/v1/admin/tasks/:taskId/events": true,
+	"GET /api/v1/admin/tasks/analytics":      true,
+	"GET /api/v1/admin/tasks/overall-stats":  true,
+	"GET /api/v1/admin/tasks/stats":          true,
+	"GET /api/v1/admin/tasks/stuck":          true,
+
+	// 流量
+	"GET /api/v1/admin/traffic/overview":             true,
+	"GET /api/v1/admin/traffic/provider/:providerId": true,
+	"GET /api/v1/admin/traffic/records":              true,
+	"GET /api/v1/admin/traffic/records/export":       true,
+	"GET /api/v1/admin/traffic/user/:userId":         true,
+	"GET /api/v1/admin/traffic/users/rank":           true,
+
+	// 运维记录
+	"GET /api/v1/admin/abuse-reports":                      true,
+	"GET /api/v1/admin/abuse-reports/:id":                  true,
+	"GET /api/v1/admin/announcements":                      true,
+	"GET /api/v1/admin/control-plane-backups":              true,
+	"GET /api/v1/admin/host-script-runs":                   true,
+	"GET /api/v1/admin/host-script-runs/:id":               true,
+	"GET /api/v1/admin/host-scripts":                       true,
+	"GET /api/v1/admin/host-scripts/:id":                   true,
+	"GET /api/v1/admin/host-scripts/:id/versions":          true,
+	"GET /api/v1/admin/host-scripts/:id/versions/:version": true,
+}
+
+// auditorDeniedRoutes 明确不对审计员开放的只读接口：会打开终端、返回明文密码、可能含有输入凭据的终端录像或整个数据库
+var auditorDeniedRoutes = map[string]bool{
+	"GET /api/v1/admin/instances/:id/ssh":                  true,
+	"GET /api/v1/admin/instances/:id/password/:taskId":     true,
+	"GET /api/v1/admin/console-recordings/:id/download":    true,
+	"GET /api/v1/admin/control-plane-backups/:id/download": true,
+}
+
+// auditorAllowed 判断审计员能否访问指定路由，route 为 gin 注册的路由模板
+func auditorAllowed(method, route string) bool {
+	switch method {
+	case http.MethodOptions:
+		return true
+	case http.MethodGet, http.MethodHead:
+		key := http.MethodGet + " " + route
+		if auditorDeniedRoutes[key] {
+			return false
+		}
+		if auditorGuardedRoute(route) {
+			return auditorReadableRoutes[key]
+		}
+		return true
+	}
+	return auditorWritableRoutes[method+" "+route]
+}
+
+func auditorGuardedRoute(route string) bool {
+	for _, prefix := range auditorGuardedPrefixes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditorRouteClassified 判断管理接口是否已明确对审计员开放或关闭，供路由测试检查新增的只读接口
+func AuditorRouteClassified(method, route string) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return true
+	}
+	if !auditorGuardedRoute(route) {
+		return true
+	}
+	key := http.MethodGet + " " + route
+	return auditorReadableRoutes[key] || auditorDeniedRoutes[key]
+}
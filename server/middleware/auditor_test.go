@@ -0,0 +1,37 @@
+package middleware
+
+import "testing"
+
+func TestAuditorAllowed(t *testing.T) {
+	cases := []struct {
+		method string
+		route  string
+		want   bool
+	}{
+		{"GET", "/api/v1/admin/instances", true},
+		{"GET", "/api/v1/admin/traffic/records/export", true},
+		{"GET", "/api/v1/admin/config", true},
+		{"POST", "/api/v1/admin/instances/:id/action", false},
+		{"PUT", "/api/v1/admin/config", false},
+		{"DELETE", "/api/v1/admin/users/:id", false},
+		{"POST", "/api/v1/admin/providers/export-configs", false},
+		{"GET", "/api/v1/admin/instances/:id/ssh", false},
+		{"GET", "/api/v1/admin/instances/:id/password/:taskId", false},
+		{"GET", "/api/v1/admin/control-plane-backups", true},
+		{"GET", "/api/v1/admin/control-plane-backups/:id/download", false},
+		{"GET", "/api/v1/admin/console-recordings", true},
+		{"GET", "/api/v1/admin/console-recordings/:id/download", false},
+		{"HEAD", "/api/v1/admin/uploads/:uuid", true},
+		{"GET", "/api/v1/admin/unlisted-report", false},
+		{"GET", "/api/v1/oauth2/providers/:id", true},
+		{"GET", "/api/v1/user/info", true},
+		{"OPTIONS", "/api/v1/admin/unlisted-report", true},
+		{"POST", "/api/v1/auth/logout", true},
+		{"PUT", "/api/v1/user/reset-password", true},
+	}
+	for _, tc := range cases {
+		if got := auditorAllowed(tc.method, tc.route); got != tc.want {
+			t.Errorf("auditorAllowed(%s %s) = %v, want %v", tc.method, tc.route, got, tc.want)
+		}
+	}
+}
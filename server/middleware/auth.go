@@ -72,6 +72,40 @@ func RequireAuth(minLevel auth.AuthLevel) gin.HandlerFunc {
 			return
 		}
 
+		// 只读审计员只能发起只读请求，且不能访问会暴露凭据的接口
+		if authCtx.UserType == auth.UserTypeAuditor && !auditorAllowed(c.Request.Method, c.FullPath()) {
+			global.APP_LOG.Warn("审计员尝试执行受限操作",
+				zap.Uint("userID", authCtx.UserID),
+				zap.String("username", authCtx.Username),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method))
+
+			c.JSON(http.StatusForbidden, common.Response{
+				Code: 403,
+				Msg:  "审计员账户为只读，无法执行此操作",
+			})
+			c.Abort()
+			return
+		}
+
+		// 子管理员只能访问与自己Provider相关的管理接口
+		if minLevel == auth.AuthLevelAdmin && authCtx.IsProviderScoped() {
+			if msg := checkProviderScope(c, authCtx); msg != "" {
+				global.APP_LOG.Warn("子管理员访问超出管理范围",
+					zap.Uint("userID", authCtx.UserID),
+					zap.String("username", authCtx.Username),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("method", c.Request.Method))
+
+				c.JSON(http.StatusForbidden, common.Response{
+					Code: 403,
+					Msg:  msg,
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// 检查token是否需要刷新（滑动过期机制）
 		if utils.ShouldRefreshToken(claims) {
 			// 生成新token
@@ -162,6 +196,20 @@ func validateJWTTokenWithClaims(c *gin.Context) (*auth.AuthContext, *jwt.MapClai
 		token = after
 	}
 
+	// 个人API令牌不携带claims，也不参与滑动刷新
+	if strings.HasPrefix(token, user.APITokenPrefix) {
+		apiTokenService := auth2.APITokenService{}
+		userID, err := apiTokenService.ValidateToken(token)
+		if err != nil {
+			return nil, nil, common.NewError(common.CodeUnauthorized, "无效的API令牌")
+		}
+		userAuth, err := getUserAuthInfo(userID)
+		if err != nil {
+			return nil, nil, common.NewError(common.CodeUnauthorized, "获取用户权限失败")
+		}
+		return userAuth, nil, nil
+	}
+
 	// 使用JWT验证逻辑
 	claims, err := utils.ValidateToken(token)
 	if err != nil {
@@ -243,7 +291,7 @@ func getUserAuthInfo(userID uint) (*auth.AuthContext, error) {
 	}
 
 	// 确保有效权限类型是合法的
-	validTypes := map[string]bool{"user": true, "admin": true}
+	validTypes := map[string]bool{"user": true, "admin": true, auth.UserTypeAuditor: true}
 	if !validTypes[effectivePermission.EffectiveType] {
 		global.APP_LOG.Error("权限服务返回无效的权限类型，拒绝访问",
 			zap.Uint("userID", userID),
@@ -263,15 +311,28 @@ func getUserAuthInfo(userID uint) (*auth.AuthContext, error) {
 		}
 	}
 
+	// 管理员可能被限定为只管理部分Provider
+	var providerScope []uint
+	if effectivePermission.EffectiveType == "admin" {
+		providerScope, err = permissionService.GetAdminProviderScope(userID)
+		if err != nil {
+			global.APP_LOG.Error("获取管理员Provider范围失败，拒绝访问",
+				zap.Uint("userID", userID),
+				zap.Error(err))
+			return nil, fmt.Errorf("权限验证失败，请稍后重试")
+		}
+	}
+
 	// 构建认证上下文
 	authCtx := &auth.AuthContext{
-		UserID:       user.ID,
-		Username:     user.Username,
-		UserType:     effectivePermission.EffectiveType,
-		Level:        effectivePermission.EffectiveLevel,
-		BaseUserType: user.UserType,
-		AllUserTypes: effectivePermission.AllTypes,
-		IsEffective:  true,
+		UserID:        user.ID,
+		Username:      user.Username,
+		UserType:      effectivePermission.EffectiveType,
+		Level:         effectivePermission.EffectiveLevel,
+		BaseUserType:  user.UserType,
+		AllUserTypes:  effectivePermission.AllTypes,
+		IsEffective:   true,
+		ProviderScope: providerScope,
 	}
 
 	// 记录权限获取成功的调试信息（仅在开发环境）
@@ -315,6 +376,9 @@ func getUserLevel(userType string) auth.AuthLevel {
 	switch userType {
 	case "admin":
 		return auth.AuthLevelAdmin
+	case auth.UserTypeAuditor:
+		// 审计员可以进入管理路由，写操作由 auditorAllowed 拦截
+		return auth.AuthLevelAdmin
 	case "user":
 		return auth.AuthLevelUser
 	default:
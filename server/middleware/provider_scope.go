@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"strconv"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/auth"
+	providerModel "oneclickvirt/model/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 子管理员路由中需要校验归属的资源类型
+const (
+	scopeProvider  = "provider"
+	scopeInstance  = "instance"
+	scopePort      = "port"
+	scopeTask      = "task"
+	scopeScriptRun = "host-script-run"
+)
+
+// scopedRoute 子管理员可访问的路由，param 为需要校验归属的路径参数，为空时由处理函数按范围过滤或校验请求体
+type scopedRoute struct {
+	param string
+	kind  string
+}
+
+// scopedAdminRoutes 子管理员可以访问的管理接口，未列出的接口一律拒绝
+var scopedAdminRoutes = map[string]scopedRoute{
+	// 实例
+	"GET /api/v1/admin/instances":                                       {},
+	"POST /api/v1/admin/instances":                                      {},
+	"PUT /api/v1/admin/instances/:id":                                   {"id", scopeInstance},
+	"DELETE /api/v1/admin/instances/:id":                                {"id", scopeInstance},
+	"POST /api/v1/admin/instances/:id/action":                           {"id", scopeInstance},
+	"PUT /api/v1/admin/instances/:id/reset-password":                    {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/password/:taskId":                  {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/console-log":                       {"id", scopeInstance},
+	"POST /api/v1/admin/instances/:id/console-log/capture":              {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/traffic-captures":                  {"id", scopeInstance},
+	"POST /api/v1/admin/instances/:id/traffic-captures":                 {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/traffic-captures/:captureId":       {"id", scopeInstance},
+	"POST /api/v1/admin/instances/:id/traffic-captures/:captureId/stop": {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/ssh":                               {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/port-mappings":                     {"id", scopeInstance},
+	"GET /api/v1/admin/instances/:id/host-events":                       {"id", scopeInstance},
+	"GET /api/v1/admin/providers":                                       {},
+	"GET /api/v1/admin/providers/:id/status":                            {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/health-history":                    {"id", scopeProvider},
+	"POST /api/v1/admin/providers/:id/health-check":                     {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/host-events":                       {"id", scopeProvider},
+	"POST /api/v1/admin/providers/:id/network-probe":                    {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/network-probes":                    {"id", scopeProvider},
+	"POST /api/v1/admin/providers/:id/recover-instances":                {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/instance-names/check":              {"id", scopeProvider},
+	"POST /api/v1/admin/providers/:id/image-mirrors/test":               {"id", scopeProvider},
+	"GET /api/v1/admin/system-images":                                   {},
+	"GET /api/v1/admin/port-mappings":                                   {},
+	"POST /api/v1/admin/port-mappings":                                  {},
+	"DELETE /api/v1/admin/port-mappings/:id":                            {"id", scopePort},
+	"POST /api/v1/admin/port-mappings/batch-delete":                     {},
+	"POST /api/v1/admin/ports/check":                                    {},
+	"PUT /api/v1/admin/providers/:id/port-config":                       {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/port-usage":                        {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/port-ranges":                       {"id", scopeProvider},
+	"POST /api/v1/admin/providers/:id/port-ranges":                      {"id", scopeProvider},
+	"DELETE /api/v1/admin/providers/:id/port-ranges/:rangeId":           {"id", scopeProvider},
+	"GET /api/v1/admin/providers/:id/port-plan":                         {"id", scopeProvider},
+	"GET /api/v1/admin/tasks":                                           {},
+	"GET /api/v1/admin/tasks/:taskId":                                   {"taskId", scopeTask},
+	"GET /api/v1/admin/tasks/:taskId/events":                            {"taskId", scopeTask},
+	"POST /api/v1/admin/tasks/:taskId/cancel":                           {"taskId", scopeTask},
+	// 宿主机脚本：执行时由处理函数校验每个目标Provider，审批和维护脚本仅限完整管理员
+	"GET /api/v1/admin/host-scripts":                       {},
+	"GET /api/v1/admin/host-scripts/:id":                   {},
+	"GET /api/v1/admin/host-scripts/:id/versions":          {},
+	"GET /api/v1/admin/host-scripts/:id/versions/:version": {},
+	"POST /api/v1/admin/host-scripts/:id/runs":             {},
+	"GET /api/v1/admin/host-script-runs":                   {},
+	"GET /api/v1/admin/host-script-runs/:id":               {"id", scopeScriptRun},
+}
+
+// ScopedAdminRoutes 返回子管理员可访问的全部路由（格式为 "METHOD path"），用于检查是否与注册的路由一致
+func ScopedAdminRoutes() []string {
+	routes := make([]string, 0, len(scopedAdminRoutes))
+	for route := range scopedAdminRoutes {
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// checkProviderScope 校验子管理员的请求是否在管理范围内，返回拒绝原因，为空表示放行
+func checkProviderScope(c *gin.Context, authCtx *auth.AuthContext) string {
+	route, ok := scopedAdminRoutes[c.Request.Method+" "+c.FullPath()]
+	if !ok {
+		return "子管理员无权访问该接口"
+	}
+	if route.param == "" {
+		return ""
+	}
+	id, err := strconv.ParseUint(c.Param(route.param), 10, 32)
+	if err != nil {
+		return "无效的资源ID"
+	}
+	providerID, found := resourceProviderID(route.kind, uint(id))
+	if !found || !authCtx.CanManageProvider(providerID) {
+		return "该资源不在您的管理范围内"
+	}
+	return ""
+}
+
+// resourceProviderID 查询资源所属的Provider
+func resourceProviderID(kind string, id uint) (uint, bool) {
+	var providerIDs []uint
+	switch kind {
+	case scopeProvider:
+		return id, true
+	case scopeInstance:
+		global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", id).Pluck("provider_id", &providerIDs)
+	case scopePort:
+		global.APP_DB.Model(&providerModel.Port{}).Where("id = ?", id).Pluck("provider_id", &providerIDs)
+	case scopeTask:
+		global.APP_DB.Model(&adminModel.Task{}).Where("id = ? AND provider_id IS NOT NULL", id).Pluck("provider_id", &providerIDs)
+	case scopeScriptRun:
+		global.APP_DB.Model(&adminModel.HostScriptRun{}).Where("id = ?", id).Pluck("provider_id", &providerIDs)
+	}
+	if len(providerIDs) == 0 {
+		return 0, false
+	}
+	return providerIDs[0], true
+}
+
+// GetProviderScope 返回当前管理员可管理的Provider ID，为空表示不限制，供列表接口过滤
+func GetProviderScope(c *gin.Context) []uint {
+	if authCtx, exists := GetAuthContext(c); exists {
+		return authCtx.ProviderScope
+	}
+	return nil
+}
+
+// CanManageProvider 判断当前管理员能否管理指定Provider，用于校验请求体中引用的资源
+func CanManageProvider(c *gin.Context, providerID uint) bool {
+	authCtx, exists := GetAuthContext(c)
+	return exists && authCtx.CanManageProvider(providerID)
+}
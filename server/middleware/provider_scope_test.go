@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/auth"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupScopeDB 准备两个Provider上的实例、端口、任务和脚本执行记录，ID 1 属于 Provider 1，ID 2 属于 Provider 2
+func setupScopeDB(t *testing.T) {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &providerModel.Instance{}, &providerModel.Port{}, &adminModel.Task{}, &adminModel.HostScriptRun{})
+	for _, providerID := range []uint{1, 2} {
+		pid := providerID
+		testutil.Seed(t, db,
+			&providerModel.Instance{ID: pid, Name: "vm", ProviderID: pid},
+			&providerModel.Port{ID: pid, ProviderID: pid},
+			&adminModel.Task{ID: pid, ProviderID: &pid},
+			&adminModel.HostScriptRun{ID: pid, ProviderID: pid},
+		)
+	}
+	// 未关联Provider的任务
+	testutil.Seed(t, db, &adminModel.Task{ID: 3})
+}
+
+func TestCheckProviderScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupScopeDB(t)
+	authCtx := &auth.AuthContext{UserID: 7, ProviderScope: []uint{1}}
+
+	engine := gin.New()
+	handler := func(c *gin.Context) {
+		if msg := checkProviderScope(c, authCtx); msg != "" {
+			c.String(http.StatusForbidden, msg)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	for _, route := range []string{
+		"GET /api/v1/admin/instances",
+		"PUT /api/v1/admin/instances/:id",
+		"GET /api/v1/admin/instances/:id/ssh",
+		"GET /api/v1/admin/providers/:id/status",
+		"PUT /api/v1/admin/providers/:id",
+		"DELETE /api/v1/admin/port-mappings/:id",
+		"GET /api/v1/admin/tasks/:taskId",
+		"GET /api/v1/admin/host-script-runs/:id",
+		"POST /api/v1/admin/host-scripts/:id/approve",
+		"GET /api/v1/admin/users",
+		"GET /api/v1/admin/control-plane-backups",
+	} {
+		method, path, _ := strings.Cut(route, " ")
+		engine.Handle(method, path, handler)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"范围过滤的列表", "GET", "/api/v1/admin/instances", http.StatusOK},
+		{"范围内的实例", "PUT", "/api/v1/admin/instances/1", http.StatusOK},
+		{"范围外的实例", "PUT", "/api/v1/admin/instances/2", http.StatusForbidden},
+		{"不存在的实例", "PUT", "/api/v1/admin/instances/99", http.StatusForbidden},
+		{"无效的实例ID", "GET", "/api/v1/admin/instances/abc/ssh", http.StatusForbidden},
+		{"范围内的Provider", "GET", "/api/v1/admin/providers/1/status", http.StatusOK},
+		{"范围外的Provider", "GET", "/api/v1/admin/providers/2/status", http.StatusForbidden},
+		{"未列出的Provider修改", "PUT", "/api/v1/admin/providers/1", http.StatusForbidden},
+		{"范围内的端口", "DELETE", "/api/v1/admin/port-mappings/1", http.StatusOK},
+		{"范围外的端口", "DELETE", "/api/v1/admin/port-mappings/2", http.StatusForbidden},
+		{"范围内的任务", "GET", "/api/v1/admin/tasks/1", http.StatusOK},
+		{"范围外的任务", "GET", "/api/v1/admin/tasks/2", http.StatusForbidden},
+		{"未关联Provider的任务", "GET", "/api/v1/admin/tasks/3", http.StatusForbidden},
+		{"范围内的脚本执行", "GET", "/api/v1/admin/host-script-runs/1", http.StatusOK},
+		{"范围外的脚本执行", "GET", "/api/v1/admin/host-script-runs/2", http.StatusForbidden},
+		{"脚本审批", "POST", "/api/v1/admin/host-scripts/1/approve", http.StatusForbidden},
+		{"用户管理", "GET", "/api/v1/admin/users", http.StatusForbidden},
+		{"控制面备份", "GET", "/api/v1/admin/control-plane-backups", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d (%s), want %d", tt.method, tt.path, w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageProvider(t *testing.T) {
+	tests := []struct {
+		scope      []uint
+		providerID uint
+		want       bool
+	}{
+		{nil, 5, true},
+		{[]uint{1, 3}, 3, true},
+		{[]uint{1, 3}, 2, false},
+	}
+	for _, tt := range tests {
+		authCtx := &auth.AuthContext{ProviderScope: tt.scope}
+		if got := authCtx.CanManageProvider(tt.providerID); got != tt.want {
+			t.Errorf("scope %v CanManageProvider(%d) = %v, want %v", tt.scope, tt.providerID, got, tt.want)
+		}
+	}
+}
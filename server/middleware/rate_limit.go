@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"oneclickvirt/model/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRateLimitClients 单个限流器最多记录的客户端数量，防止大量伪造来源撑爆内存
+const maxRateLimitClients = 10000
+
+type rateLimitWindow struct {
+	start time.Time
+	count int
+}
+
+// ipRateLimiter 按客户端IP的固定窗口限流器
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*rateLimitWindow
+}
+
+// allow 记录一次请求，返回是否放行以及被拒绝时需要等待的时间
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.clients[ip]
+	if !ok || now.Sub(entry.start) >= l.window {
+		if !ok && len(l.clients) >= maxRateLimitClients {
+			l.pruneLocked(now)
+		}
+		l.clients[ip] = &rateLimitWindow{start: now, count: 1}
+		return true, 0
+	}
+	if entry.count >= l.limit {
+		return false, entry.start.Add(l.window).Sub(now)
+	}
+	entry.count++
+	return true, 0
+}
+
+// pruneLocked 清理已过期的窗口，仍然满时清空重新计数
+func (l *ipRateLimiter) pruneLocked(now time.Time) {
+	for ip, entry := range l.clients {
+		if now.Sub(entry.start) >= l.window {
+			delete(l.clients, ip)
+		}
+	}
+	if len(l.clients) >= maxRateLimitClients {
+		l.clients = make(map[string]*rateLimitWindow)
+	}
+}
+
+// RateLimitByIP 按客户端IP限流中间件，每个窗口内最多放行limit次请求
+// 用于无需登录的公开接口，超出限制返回429并通过Retry-After提示重试时间
+func RateLimitByIP(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := &ipRateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateLimitWindow),
+	}
+	return func(c *gin.Context) {
+		ok, retryAfter := limiter.allow(c.ClientIP(), time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			common.ResponseWithError(c, common.NewError(common.CodeTooManyRequests, "请求过于频繁，请稍后再试"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPRateLimiterAllow(t *testing.T) {
+	limiter := &ipRateLimiter{limit: 2, window: time.Minute, clients: make(map[string]*rateLimitWindow)}
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		ip        string
+		at        time.Duration // 相对 start 的时间
+		allowed   bool
+		wantRetry time.Duration
+	}{
+		{name: "首次请求", ip: "198.51.100.1", allowed: true},
+		{name: "窗口内第二次", ip: "198.51.100.1", at: 10 * time.Second, allowed: true},
+		{name: "超出限制", ip: "198.51.100.1", at: 20 * time.Second, wantRetry: 40 * time.Second},
+		{name: "其他IP不受影响", ip: "198.51.100.2", at: 20 * time.Second, allowed: true},
+		{name: "窗口结束前仍拒绝", ip: "198.51.100.1", at: 59 * time.Second, wantRetry: time.Second},
+		{name: "新窗口重新计数", ip: "198.51.100.1", at: time.Minute, allowed: true},
+	}
+	for _, tt := range tests {
+		allowed, retry := limiter.allow(tt.ip, start.Add(tt.at))
+		if allowed != tt.allowed || retry != tt.wantRetry {
+			t.Errorf("%s: allow = %v, %v, want %v, %v", tt.name, allowed, retry, tt.allowed, tt.wantRetry)
+		}
+	}
+}
+
+func TestIPRateLimiterPrune(t *testing.T) {
+	limiter := &ipRateLimiter{limit: 1, window: time.Minute, clients: make(map[string]*rateLimitWindow)}
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < maxRateLimitClients; i++ {
+		limiter.clients[string(rune(i))] = &rateLimitWindow{start: start, count: 1}
+	}
+	limiter.allow("198.51.100.1", start.Add(2*time.Minute))
+	if len(limiter.clients) != 1 {
+		t.Errorf("过期窗口应被清理，剩余 %d 个", len(limiter.clients))
+	}
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/share", RateLimitByIP(1, time.Minute), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/share", nil)
+		req.RemoteAddr = ip + ":12345"
+		engine.ServeHTTP(w, req)
+		return w
+	}
+	if w := request("198.51.100.1"); w.Code != http.StatusOK {
+		t.Fatalf("首次请求 = %d", w.Code)
+	}
+	w := request("198.51.100.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("超出限制 = %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := request("198.51.100.2"); w.Code != http.StatusOK {
+		t.Errorf("其他IP = %d", w.Code)
+	}
+}
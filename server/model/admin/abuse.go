@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"time"
+
+	"oneclickvirt/model/common"
+)
+
+// 滥用举报处置状态，按严重程度递增
+const (
+	AbuseEnforcementNone      = "none"      // 未处置
+	AbuseEnforcementWarned    = "warned"    // 已警告：邮件通知用户，不限制使用
+	AbuseEnforcementLimited   = "limited"   // 已限制：禁止用户申请新实例
+	AbuseEnforcementSuspended = "suspended" // 已暂停：冻结相关实例，冻结原因引用举报ID
+)
+
+// 滥用举报状态
+const (
+	AbuseStatusOpen     = "open"     // 处理中
+	AbuseStatusResolved = "resolved" // 已结案，结案不解除处置
+)
+
+// AbuseReport 滥用举报，关联实例或用户，所有处置操作都记录在举报下
+type AbuseReport struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	InstanceID  *uint      `json:"instanceId" gorm:"index"`                       // 被举报的实例，为空表示针对用户
+	UserID      uint       `json:"userId" gorm:"index;not null"`                  // 被举报的用户，针对实例时为实例所有者
+	Source      string     `json:"source" gorm:"size:64"`                         // 举报来源，如上游服务商、邮件、监控告警
+	Category    string     `json:"category" gorm:"size:32;index"`                 // 类别：spam, ddos, scan, phishing, malware, copyright, other
+	EvidenceURL string     `json:"evidenceUrl" gorm:"size:512"`                   // 证据链接
+	Description string     `json:"description" gorm:"type:text"`                  // 详细说明
+	TicketRef   string     `json:"ticketRef" gorm:"size:255"`                     // 关联的外部工单编号或链接
+	Status      string     `json:"status" gorm:"size:16;default:open;index"`      // 状态：open, resolved
+	Enforcement string     `json:"enforcement" gorm:"size:16;default:none;index"` // 当前处置：none, warned, limited, suspended
+	CreatedBy   uint       `json:"createdBy"`                                     // 登记举报的管理员
+	ResolvedAt  *time.Time `json:"resolvedAt"`                                    // 结案时间
+}
+
+// AbuseReportAction 举报处置记录
+type AbuseReportAction struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ReportID   uint      `json:"reportId" gorm:"index;not null"`
+	OperatorID uint      `json:"operatorId"`
+	FromState  string    `json:"from" gorm:"size:16"` // 变更前的处置状态
+	ToState    string    `json:"to" gorm:"size:16"`   // 变更后的处置状态
+	Note       string    `json:"note" gorm:"size:1024"`
+	Detail     string    `json:"detail" gorm:"size:1024"` // 执行结果，如冻结和解冻的实例数
+}
+
+// AbuseReportListRequest 举报列表请求
+type AbuseReportListRequest struct {
+	common.PageInfo
+	InstanceID  uint   `json:"instanceId" form:"instanceId"`
+	UserID      uint   `json:"userId" form:"userId"`
+	Status      string `json:"status" form:"status"`
+	Enforcement string `json:"enforcement" form:"enforcement"`
+	Category    string `json:"category" form:"category"`
+}
+
+// CreateAbuseReportRequest 登记举报请求，实例和用户至少提供一个
+type CreateAbuseReportRequest struct {
+	InstanceID  *uint  `json:"instanceId"`
+	UserID      uint   `json:"userId"`
+	Source      string `json:"source" binding:"required,max=64"`
+	Category    string `json:"category" binding:"required,oneof=spam ddos scan phishing malware copyright other"`
+	EvidenceURL string `json:"evidenceUrl" binding:"omitempty,url,max=512"`
+	Description string `json:"description"`
+	TicketRef   string `json:"ticketRef" binding:"max=255"`
+}
+
+// UpdateAbuseReportRequest 更新举报信息，字段为空时保持不变
+type UpdateAbuseReportRequest struct {
+	Status      string `json:"status" binding:"omitempty,oneof=open resolved"`
+	EvidenceURL string `json:"evidenceUrl" binding:"omitempty,url,max=512"`
+	Description string `json:"description"`
+	TicketRef   string `json:"ticketRef" binding:"max=255"`
+}
+
+// SetAbuseEnforcementRequest 设置举报处置状态
+type SetAbuseEnforcementRequest struct {
+	Enforcement string `json:"enforcement" binding:"required,oneof=none warned limited suspended"`
+	Note        string `json:"note" binding:"max=1024"`
+}
+
+// AbuseReportDetail 举报详情，包含处置记录
+type AbuseReportDetail struct {
+	AbuseReport
+	Username     string              `json:"username"`
+	InstanceName string              `json:"instanceName"`
+	Actions      []AbuseReportAction `json:"actions"`
+}
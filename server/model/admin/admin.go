@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"sync"
 	"time"
 
 	providerModel "oneclickvirt/model/provider"
@@ -19,15 +20,16 @@ type Task struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`                                                                         // 软删除时间
 
 	// 任务基本信息
-	TaskType string `json:"taskType" gorm:"not null;size:32"`                                                                               // 任务类型：create, start, stop, restart, reset, delete, reset-password
-	Status   string `json:"status" gorm:"default:pending;size:32;index:idx_status_created,priority:1;index:idx_provider_status,priority:2"` // 任务状态：pending, processing, running, completed, failed, cancelling, cancelled, timeout
-	Progress int    `json:"progress" gorm:"default:0"`                                                                                      // 任务执行进度百分比（0-100）
+	TaskType string `json:"taskType" gorm:"not null;size:32;index:idx_task_type"`                                                                 // 任务类型：create, start, stop, restart, reset, delete, reset-password
+	Status   string `json:"status" gorm:"default:pending;size:32;index:idx_status_created,priority:1;index:idx_tasks_provider_status,priority:2"` // 任务状态：pending, processing, running, completed, failed, cancelling, cancelled, timeout
+	Progress int    `json:"progress" gorm:"default:0"`                                                                                            // 任务执行进度百分比（0-100）
 
 	// 错误和状态信息
 	ErrorMessage  string `json:"errorMessage" gorm:"type:text"` // 任务失败时的错误信息
 	CancelReason  string `json:"cancelReason" gorm:"type:text"` // 任务取消的原因
 	StatusMessage string `json:"statusMessage" gorm:"size:512"` // 当前状态的描述信息
 	TaskData      string `json:"taskData" gorm:"type:text"`     // 任务执行所需的数据（JSON格式）
+	LogOutput     string `json:"logOutput" gorm:"type:text"`    // 任务执行日志（如用户钩子脚本输出）
 
 	// 时间信息
 	StartedAt         *time.Time `json:"startedAt"`                           // 任务开始执行时间
@@ -42,9 +44,9 @@ type Task struct {
 	PreallocatedBandwidth int `json:"preallocatedBandwidth" gorm:"default:0"` // 预分配的带宽(Mbps)
 
 	// 关联信息
-	UserID     uint  `json:"userId" gorm:"index:idx_user_created,priority:1;index:idx_user_status,priority:1"` // 任务所属用户ID
-	ProviderID *uint `json:"providerId" gorm:"index:idx_provider_status,priority:1"`                           // 执行任务的Provider ID（可为空）
-	InstanceID *uint `json:"instanceId"`                                                                       // 关联的实例ID（可选，用于实例相关任务）
+	UserID     uint  `json:"userId" gorm:"index:idx_user_created,priority:1;index:idx_tasks_user_status,priority:1"` // 任务所属用户ID
+	ProviderID *uint `json:"providerId" gorm:"index:idx_tasks_provider_status,priority:1"`                           // 执行任务的Provider ID（可为空）
+	InstanceID *uint `json:"instanceId" gorm:"index:idx_instance_id"`                                                // 关联的实例ID（可选，用于实例相关任务）
 
 	// 关联对象
 	Provider *providerModel.Provider `json:"provider,omitempty" gorm:"foreignKey:ProviderID"` // 关联的Provider对象
@@ -59,6 +61,54 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// TaskTypeExportData 用户数据导出任务
+const TaskTypeExportData = "export-data"
+
+// TaskTypeMigratePortIP Provider端口映射地址变更后的迁移任务
+const TaskTypeMigratePortIP = "migrate-port-ip"
+
+// TaskTypeMigratePortMethod Provider端口映射方式在线切换任务
+const TaskTypeMigratePortMethod = "migrate-port-method"
+
+// TaskTypeNetworkProbe Provider网络质量测试任务
+const TaskTypeNetworkProbe = "network-probe"
+
+// TaskTypeDetectPortService 端口映射服务类型探测任务
+const TaskTypeDetectPortService = "detect-port-service"
+
+// systemTaskTypes 不依赖Provider的系统任务类型，自定义任务类型注册时可以加入
+var (
+	systemTaskTypes   = map[string]bool{TaskTypeExportData: true}
+	systemTaskTypesMu sync.RWMutex
+)
+
+// RegisterSystemTaskType 将任务类型标记为系统任务
+func RegisterSystemTaskType(taskType string) {
+	systemTaskTypesMu.Lock()
+	defer systemTaskTypesMu.Unlock()
+	systemTaskTypes[taskType] = true
+}
+
+// UnregisterSystemTaskType 取消任务类型的系统任务标记，内置的系统任务类型不受影响
+func UnregisterSystemTaskType(taskType string) {
+	if taskType == TaskTypeExportData {
+		return
+	}
+	systemTaskTypesMu.Lock()
+	defer systemTaskTypesMu.Unlock()
+	delete(systemTaskTypes, taskType)
+}
+
+// IsSystemTask 是否为不依赖Provider的系统任务，这类任务在独立的系统任务池中执行
+func (t *Task) IsSystemTask() bool {
+	if t.ProviderID != nil {
+		return false
+	}
+	systemTaskTypesMu.RLock()
+	defer systemTaskTypesMu.RUnlock()
+	return systemTaskTypes[t.TaskType]
+}
+
 // AuditLog 审计日志模型
 type AuditLog struct {
 	ID         uint           `json:"id" gorm:"primarykey"`
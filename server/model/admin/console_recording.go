@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"time"
+
+	"oneclickvirt/model/common"
+)
+
+// 终端会话录像状态
+const (
+	ConsoleRecordingStatusRecording = "recording" // 录制中
+	ConsoleRecordingStatusCompleted = "completed" // 已结束，录像已写入对象存储
+	ConsoleRecordingStatusFailed    = "failed"    // 写入对象存储失败
+	ConsoleRecordingStatusExpired   = "expired"   // 超过保留天数，录像已删除
+)
+
+// ConsoleRecording 管理员终端会话录像记录
+// 录像为 asciicast v2 格式，可使用 asciinema play 回放
+type ConsoleRecording struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	InstanceID    uint       `json:"instanceId" gorm:"index;not null"`              // 实例ID
+	InstanceName  string     `json:"instanceName" gorm:"size:128"`                  // 实例名称，实例删除后仍可识别
+	OwnerID       uint       `json:"ownerId" gorm:"index"`                          // 实例所有者ID
+	AdminID       uint       `json:"adminId" gorm:"index"`                          // 发起会话的管理员ID
+	AdminName     string     `json:"adminName" gorm:"size:64"`                      // 发起会话的管理员用户名
+	ClientIP      string     `json:"clientIp" gorm:"size:64"`                       // 管理员客户端IP
+	Status        string     `json:"status" gorm:"index;size:16;default:recording"` // 状态：recording, completed, failed, expired
+	StartedAt     time.Time  `json:"startedAt" gorm:"index"`                        // 会话开始时间
+	EndedAt       *time.Time `json:"endedAt"`                                       // 会话结束时间
+	Size          int64      `json:"size" gorm:"default:0"`                         // 录像大小（字节）
+	Truncated     bool       `json:"truncated" gorm:"default:false"`                // 是否因超过大小上限停止录制
+	InputRecorded bool       `json:"inputRecorded" gorm:"default:false"`            // 是否录制了管理员的键盘输入
+	OwnerNotified bool       `json:"ownerNotified" gorm:"default:false"`            // 是否已通知实例所有者
+	ObjectKey     string     `json:"-" gorm:"size:512"`                             // 对象存储中的键
+	ErrorMessage  string     `json:"errorMessage" gorm:"size:512"`                  // 失败原因
+}
+
+func (ConsoleRecording) TableName() string {
+	return "console_recordings"
+}
+
+// ConsoleRecordingListRequest 终端会话录像列表请求
+type ConsoleRecordingListRequest struct {
+	common.PageInfo
+	InstanceID uint   `json:"instanceId" form:"instanceId"` // 按实例筛选
+	AdminID    uint   `json:"adminId" form:"adminId"`       // 按管理员筛选
+	Status     string `json:"status" form:"status"`         // 按状态筛选
+}
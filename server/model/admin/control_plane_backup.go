@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"time"
+
+	"oneclickvirt/model/common"
+)
+
+// 控制面灾备导出状态
+const (
+	ControlPlaneBackupStatusRunning   = "running"   // 导出中
+	ControlPlaneBackupStatusCompleted = "completed" // 已写入对象存储
+	ControlPlaneBackupStatusFailed    = "failed"    // 导出或写入失败
+	ControlPlaneBackupStatusPruned    = "pruned"    // 超出保留份数，归档已删除
+)
+
+// 控制面灾备导出触发方式
+const (
+	ControlPlaneBackupTriggerScheduled = "scheduled" // 定时导出
+	ControlPlaneBackupTriggerManual    = "manual"    // 管理员手动触发
+)
+
+// ControlPlaneBackup 控制面数据灾备导出记录
+// 归档为加密后的gob数据流，只能通过 restore 命令在备用面板上恢复
+type ControlPlaneBackup struct {
+	ID           uint       `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	Trigger      string     `json:"trigger" gorm:"size:16"`                      // 触发方式：scheduled, manual
+	Scope        string     `json:"scope" gorm:"size:16"`                        // 导出范围：core, full
+	Status       string     `json:"status" gorm:"index;size:16;default:running"` // 状态：running, completed, failed, pruned
+	Tables       int        `json:"tables" gorm:"default:0"`                     // 导出的表数量
+	Rows         int64      `json:"rows" gorm:"default:0"`                       // 导出的总行数
+	Size         int64      `json:"size" gorm:"default:0"`                       // 归档大小（字节）
+	Checksum     string     `json:"checksum" gorm:"size:64"`                     // 归档的SHA256，恢复前可用于校验下载是否完整
+	ObjectKey    string     `json:"objectKey" gorm:"size:512"`                   // 对象存储中的键，恢复时通过 --object 指定
+	FinishedAt   *time.Time `json:"finishedAt"`                                  // 完成时间
+	ErrorMessage string     `json:"errorMessage" gorm:"size:512"`                // 失败原因
+}
+
+func (ControlPlaneBackup) TableName() string {
+	return "control_plane_backups"
+}
+
+// ControlPlaneBackupListRequest 控制面灾备导出记录列表请求
+type ControlPlaneBackupListRequest struct {
+	common.PageInfo
+	Status string `json:"status" form:"status"` // 按状态筛选
+}
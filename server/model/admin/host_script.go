@@ -0,0 +1,180 @@
+package admin
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/model/common"
+
+	"gorm.io/gorm"
+)
+
+// TaskTypeHostScript 在Provider宿主机上执行脚本库中脚本的任务
+const TaskTypeHostScript = "host-script"
+
+// 宿主机脚本执行状态
+const (
+	HostScriptRunPending   = "pending_approval" // 等待审批
+	HostScriptRunRejected  = "rejected"         // 审批拒绝
+	HostScriptRunQueued    = "queued"           // 已创建任务，等待执行
+	HostScriptRunRunning   = "running"          // 执行中
+	HostScriptRunSucceeded = "succeeded"        // 执行成功
+	HostScriptRunFailed    = "failed"           // 执行失败、超时或任务取消
+)
+
+// HostScript 管理员维护的宿主机脚本，内容和参数的每次修改都保存为新版本
+type HostScript struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // 删除后执行记录仍保留
+
+	Name            string `json:"name" gorm:"not null;size:64"`             // 名称
+	Description     string `json:"description" gorm:"size:512"`              // 用途说明
+	ProviderIDs     string `json:"providerIds" gorm:"size:512"`              // 允许执行的Provider ID（逗号分隔），为空表示全部
+	RequireApproval bool   `json:"requireApproval" gorm:"default:true"`      // 执行前是否需要其他管理员审批
+	Timeout         int    `json:"timeout" gorm:"default:300"`               // 执行超时（秒）
+	Enabled         bool   `json:"enabled" gorm:"default:true"`              // 停用后不能发起新的执行
+	CurrentVersion  int    `json:"currentVersion" gorm:"not null;default:1"` // 当前版本号
+	CreatedBy       uint   `json:"createdBy"`                                // 创建的管理员
+	UpdatedBy       uint   `json:"updatedBy"`                                // 最后修改的管理员
+}
+
+func (HostScript) TableName() string {
+	return "host_scripts"
+}
+
+// AllowsProvider 判断脚本是否允许在指定Provider上执行
+func (s *HostScript) AllowsProvider(providerID uint) bool {
+	if strings.TrimSpace(s.ProviderIDs) == "" {
+		return true
+	}
+	for _, part := range strings.Split(s.ProviderIDs, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil && uint(id) == providerID {
+			return true
+		}
+	}
+	return false
+}
+
+// HostScriptParam 脚本参数定义，执行时以同名环境变量传给脚本
+type HostScriptParam struct {
+	Name        string `json:"name"`        // 参数名，大写字母开头，只含大写字母、数字和下划线
+	Description string `json:"description"` // 说明
+	Required    bool   `json:"required"`    // 是否必填
+	Default     string `json:"default"`     // 未传入时的默认值
+	Pattern     string `json:"pattern"`     // 取值需完整匹配的正则表达式，为空时不限制
+}
+
+// HostScriptVersion 脚本的一个版本，创建后不再修改，执行记录引用具体版本
+type HostScriptVersion struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	ScriptID   uint   `json:"scriptId" gorm:"not null;uniqueIndex:idx_host_script_version"`
+	Version    int    `json:"version" gorm:"not null;uniqueIndex:idx_host_script_version"`
+	Content    string `json:"content" gorm:"type:text"`    // 脚本内容，以 sh 在宿主机上执行
+	Parameters string `json:"parameters" gorm:"type:text"` // 参数定义，JSON格式: []HostScriptParam
+	Comment    string `json:"comment" gorm:"size:512"`     // 修改说明
+	CreatedBy  uint   `json:"createdBy"`
+}
+
+func (HostScriptVersion) TableName() string {
+	return "host_script_versions"
+}
+
+// GetParameters 返回参数定义，未定义或格式错误时返回空
+func (v *HostScriptVersion) GetParameters() []HostScriptParam {
+	if v.Parameters == "" {
+		return nil
+	}
+	var params []HostScriptParam
+	if err := json.Unmarshal([]byte(v.Parameters), &params); err != nil {
+		return nil
+	}
+	return params
+}
+
+// HostScriptRun 一次脚本执行申请，记录发起人、审批人、参数和输出，作为宿主机操作的审计记录
+type HostScriptRun struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ScriptID     uint       `json:"scriptId" gorm:"index;not null"`
+	ScriptName   string     `json:"scriptName" gorm:"size:64"` // 发起时的脚本名称，脚本删除后仍可查看
+	Version      int        `json:"version" gorm:"not null"`   // 执行的脚本版本
+	ProviderID   uint       `json:"providerId" gorm:"index;not null"`
+	Params       string     `json:"params" gorm:"type:text"`           // 执行参数，JSON格式: map[string]string
+	Reason       string     `json:"reason" gorm:"size:512"`            // 执行原因
+	Status       string     `json:"status" gorm:"size:20;index"`       // pending_approval, rejected, queued, running, succeeded, failed
+	RequestedBy  uint       `json:"requestedBy" gorm:"index"`          // 发起的管理员
+	ReviewedBy   uint       `json:"reviewedBy"`                        // 审批的管理员，无需审批时为空
+	ReviewedAt   *time.Time `json:"reviewedAt"`                        // 审批时间
+	ReviewNote   string     `json:"reviewNote" gorm:"size:512"`        // 审批意见或拒绝原因
+	TaskID       *uint      `json:"taskId" gorm:"index"`               // 执行任务ID
+	Output       string     `json:"output,omitempty" gorm:"type:text"` // 标准输出和标准错误，超出长度时只保留末尾
+	Error        string     `json:"error" gorm:"size:1024"`            // 失败原因
+	StartedAt    *time.Time `json:"startedAt"`                         // 开始执行时间
+	FinishedAt   *time.Time `json:"finishedAt"`                        // 执行结束时间
+	ProviderName string     `json:"providerName,omitempty" gorm:"-"`   // 列表展示用
+	Requester    string     `json:"requester,omitempty" gorm:"-"`      // 发起人用户名
+	Reviewer     string     `json:"reviewer,omitempty" gorm:"-"`       // 审批人用户名
+}
+
+func (HostScriptRun) TableName() string {
+	return "host_script_runs"
+}
+
+// HostScriptTaskData 宿主机脚本任务数据
+type HostScriptTaskData struct {
+	RunID uint `json:"runId"`
+}
+
+// HostScriptListRequest 脚本列表请求
+type HostScriptListRequest struct {
+	common.PageInfo
+	ProviderID uint `json:"providerId" form:"providerId"` // 只返回允许在该Provider上执行的脚本
+}
+
+// SaveHostScriptRequest 创建或修改脚本，内容或参数变化时保存为新版本
+type SaveHostScriptRequest struct {
+	Name            string            `json:"name" binding:"required,max=64"`
+	Description     string            `json:"description" binding:"max=512"`
+	ProviderIDs     []uint            `json:"providerIds"` // 为空表示全部Provider
+	RequireApproval *bool             `json:"requireApproval"`
+	Timeout         int               `json:"timeout"` // 秒，默认300
+	Enabled         *bool             `json:"enabled"`
+	Content         string            `json:"content" binding:"required"`
+	Parameters      []HostScriptParam `json:"parameters"`
+	Comment         string            `json:"comment" binding:"max=512"` // 修改说明
+}
+
+// HostScriptDetail 脚本详情，包含当前版本
+type HostScriptDetail struct {
+	HostScript
+	Current *HostScriptVersion `json:"current"`
+}
+
+// RunHostScriptRequest 发起脚本执行，每个Provider生成一条执行记录
+type RunHostScriptRequest struct {
+	ProviderIDs []uint            `json:"providerIds" binding:"required,min=1,max=50"`
+	Version     int               `json:"version"` // 为0时使用当前版本
+	Params      map[string]string `json:"params"`
+	Reason      string            `json:"reason" binding:"required,max=512"`
+}
+
+// HostScriptRunListRequest 执行记录列表请求
+type HostScriptRunListRequest struct {
+	common.PageInfo
+	ScriptID   uint   `json:"scriptId" form:"scriptId"`
+	ProviderID uint   `json:"providerId" form:"providerId"`
+	Status     string `json:"status" form:"status"`
+}
+
+// ReviewHostScriptRunRequest 审批执行申请
+type ReviewHostScriptRunRequest struct {
+	Note string `json:"note" binding:"max=512"`
+}
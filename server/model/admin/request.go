@@ -2,6 +2,7 @@ package admin
 
 import (
 	"oneclickvirt/model/common"
+	providerModel "oneclickvirt/model/provider"
 	"time"
 )
 
@@ -73,6 +74,22 @@ type CreateProviderRequest struct {
 	EnableTaskPolling     bool   `json:"enableTaskPolling"`     // 是否启用任务轮询，默认true
 	// 存储配置（所有Provider类型通用）
 	StoragePool string `json:"storagePool"` // 存储池名称，用于存储虚拟机磁盘和容器（实际路径将自动检测）
+	// 系统预留资源（宿主机自身开销，不计入可分配资源池）
+	SystemReservedCPUPercent int   `json:"systemReservedCpuPercent" binding:"min=0,max=90"` // 预留CPU百分比
+	SystemReservedMemory     int64 `json:"systemReservedMemory" binding:"min=0"`            // 预留内存（MB）
+	SystemReservedDiskGB     int   `json:"systemReservedDiskGB" binding:"min=0"`            // 预留磁盘（GB）
+	// 硬件健康检查
+	HardwareChecks        string `json:"hardwareChecks"`                                // 检查项，逗号分隔：smart, raid, sensors, zpool，为空表示不检查
+	HardwareTempThreshold int    `json:"hardwareTempThreshold" binding:"min=0,max=150"` // 温度告警阈值（℃），0表示使用全局配置
+	// 宿主机日志事件采集
+	HostEventSources string `json:"hostEventSources"` // 采集的日志，逗号分隔：kernel, daemon，为空表示不采集
+	// 连接健康检查间隔（秒），0表示使用全局配置
+	HealthCheckInterval int `json:"healthCheckInterval" binding:"omitempty,min=30,max=86400"`
+	// 超售比例（0表示不超售，否则需在1-10之间）
+	ContainerCPUOvercommit    float64 `json:"containerCpuOvercommit" binding:"omitempty,gte=1,lte=10"`    // 容器CPU超售比例
+	ContainerMemoryOvercommit float64 `json:"containerMemoryOvercommit" binding:"omitempty,gte=1,lte=10"` // 容器内存超售比例
+	VMCPUOvercommit           float64 `json:"vmCpuOvercommit" binding:"omitempty,gte=1,lte=10"`           // 虚拟机CPU超售比例
+	VMMemoryOvercommit        float64 `json:"vmMemoryOvercommit" binding:"omitempty,gte=1,lte=10"`        // 虚拟机内存超售比例
 	// 操作执行配置
 	ExecutionRule string `json:"executionRule" binding:"oneof=auto api_only ssh_only"` // 操作轮转规则：auto(自动切换), api_only(仅API), ssh_only(仅SSH)
 	// 端口映射配置
@@ -80,6 +97,9 @@ type CreateProviderRequest struct {
 	PortRangeStart   int    `json:"portRangeStart"`                                                                                  // 端口映射范围起始，默认10000
 	PortRangeEnd     int    `json:"portRangeEnd"`                                                                                    // 端口映射范围结束，默认65535
 	NetworkType      string `json:"networkType" binding:"oneof=nat_ipv4 nat_ipv4_ipv6 dedicated_ipv4 dedicated_ipv4_ipv6 ipv6_only"` // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
+	// 实例内SSH端口配置
+	GuestSSHPort       int  `json:"guestSshPort" binding:"omitempty,min=1,max=65535"` // 新实例内sshd监听的端口，默认22
+	RandomGuestSSHPort bool `json:"randomGuestSshPort"`                               // 为每个新实例随机生成实例内SSH端口
 	// 带宽配置
 	DefaultInboundBandwidth  int `json:"defaultInboundBandwidth"`  // 默认入站带宽限制（Mbps）
 	DefaultOutboundBandwidth int `json:"defaultOutboundBandwidth"` // 默认出站带宽限制（Mbps）
@@ -90,6 +110,8 @@ type CreateProviderRequest struct {
 	MaxTraffic           int64   `json:"maxTraffic"`           // 最大流量限制（MB），默认1TB=1048576MB
 	TrafficCountMode     string  `json:"trafficCountMode"`     // 流量统计模式：both(双向), out(仅出向), in(仅入向)
 	TrafficMultiplier    float64 `json:"trafficMultiplier"`    // 流量计费倍率，默认1.0
+	// 分时段流量计费倍率，如闲时 [{"window":"01:00-07:00","multiplier":0.5}]
+	TrafficMultiplierWindows []providerModel.TrafficMultiplierWindow `json:"trafficMultiplierWindows"`
 	// 流量统计性能配置
 	TrafficStatsMode           string `json:"trafficStatsMode"`           // 流量统计性能模式：high, standard, light, minimal, custom
 	TrafficCollectInterval     int    `json:"trafficStatsInterval"`       // 流量统计间隔（秒）
@@ -126,6 +148,31 @@ type CreateProviderRequest struct {
 	// 用于限制该节点上不同等级用户能创建的最大资源
 	LevelLimits map[int]map[string]interface{} `json:"levelLimits"` // 等级限制配置
 
+	// 实例同步发现意外停止的实例时按启动顺序自动启动
+	RecoverStoppedInstances bool `json:"recoverStoppedInstances"`
+
+	// 宿主机网卡：公网网卡用于端口映射DNAT规则，附加上行网卡承载独立IP段
+	PublicInterface string                         `json:"publicInterface"` // 为空时不限定网卡（Proxmox为vmbr0）
+	Uplinks         []providerModel.ProviderUplink `json:"uplinks"`
+
+	// 镜像源改写规则，下载镜像时优先于CDN
+	ImageMirrors []providerModel.ImageMirrorRule `json:"imageMirrors"`
+
+	// 维护窗口，如 ["mon-fri 19:00-23:00"]，窗口内推迟自动停机、到期删除和自动重启
+	BlackoutWindows []string `json:"blackoutWindows"`
+
+	// 时区，如 Asia/Shanghai，应与宿主机时钟一致，为空时使用系统默认时区
+	Timezone string `json:"timezone"`
+
+	// SSH命令白名单：模式为 off(默认)、log(仅记录)、block(拦截)，允许列表在内置白名单基础上追加
+	CommandGuardMode string   `json:"commandGuardMode"`
+	CommandAllowlist []string `json:"commandAllowlist"`
+	CommandDenylist  []string `json:"commandDenylist"`
+
+	// LXD/Incus 项目与配置文件
+	Project  string   `json:"project"`  // 实例所在的项目，为空时使用default项目，不存在时自动创建
+	Profiles []string `json:"profiles"` // 创建实例时应用的配置文件，为空时使用default
+
 	// 实例发现与导入配置
 	DiscoverMode          bool    `json:"discoverMode"`          // 是否启用实例发现模式（发现并导入已有实例）
 	AutoImport            bool    `json:"autoImport"`            // 是否自动导入发现的实例
@@ -164,6 +211,22 @@ type UpdateProviderRequest struct {
 	EnableTaskPolling     bool    `json:"enableTaskPolling"`     // 是否启用任务轮询，默认true
 	// 存储配置（所有Provider类型通用）
 	StoragePool string `json:"storagePool"` // 存储池名称，用于存储虚拟机磁盘和容器（实际路径将自动检测）
+	// 系统预留资源（宿主机自身开销，不计入可分配资源池）
+	SystemReservedCPUPercent int   `json:"systemReservedCpuPercent" binding:"min=0,max=90"` // 预留CPU百分比
+	SystemReservedMemory     int64 `json:"systemReservedMemory" binding:"min=0"`            // 预留内存（MB）
+	SystemReservedDiskGB     int   `json:"systemReservedDiskGB" binding:"min=0"`            // 预留磁盘（GB）
+	// 硬件健康检查
+	HardwareChecks        string `json:"hardwareChecks"`                                // 检查项，逗号分隔：smart, raid, sensors, zpool，为空表示不检查
+	HardwareTempThreshold int    `json:"hardwareTempThreshold" binding:"min=0,max=150"` // 温度告警阈值（℃），0表示使用全局配置
+	// 宿主机日志事件采集
+	HostEventSources string `json:"hostEventSources"` // 采集的日志，逗号分隔：kernel, daemon，为空表示不采集
+	// 连接健康检查间隔（秒），0表示使用全局配置
+	HealthCheckInterval int `json:"healthCheckInterval" binding:"omitempty,min=30,max=86400"`
+	// 超售比例（0表示不超售，否则需在1-10之间）
+	ContainerCPUOvercommit    float64 `json:"containerCpuOvercommit" binding:"omitempty,gte=1,lte=10"`    // 容器CPU超售比例
+	ContainerMemoryOvercommit float64 `json:"containerMemoryOvercommit" binding:"omitempty,gte=1,lte=10"` // 容器内存超售比例
+	VMCPUOvercommit           float64 `json:"vmCpuOvercommit" binding:"omitempty,gte=1,lte=10"`           // 虚拟机CPU超售比例
+	VMMemoryOvercommit        float64 `json:"vmMemoryOvercommit" binding:"omitempty,gte=1,lte=10"`        // 虚拟机内存超售比例
 	// 操作执行配置
 	ExecutionRule string `json:"executionRule" binding:"oneof=auto api_only ssh_only"` // 操作轮转规则：auto(自动切换), api_only(仅API), ssh_only(仅SSH)
 	// 端口映射配置
@@ -171,6 +234,9 @@ type UpdateProviderRequest struct {
 	PortRangeStart   int    `json:"portRangeStart"`                                                                                  // 端口映射范围起始，默认10000
 	PortRangeEnd     int    `json:"portRangeEnd"`                                                                                    // 端口映射范围结束，默认65535
 	NetworkType      string `json:"networkType" binding:"oneof=nat_ipv4 nat_ipv4_ipv6 dedicated_ipv4 dedicated_ipv4_ipv6 ipv6_only"` // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
+	// 实例内SSH端口配置
+	GuestSSHPort       int  `json:"guestSshPort" binding:"omitempty,min=1,max=65535"` // 新实例内sshd监听的端口，默认22
+	RandomGuestSSHPort bool `json:"randomGuestSshPort"`                               // 为每个新实例随机生成实例内SSH端口
 	// 带宽配置
 	DefaultInboundBandwidth  int `json:"defaultInboundBandwidth"`  // 默认入站带宽限制（Mbps）
 	DefaultOutboundBandwidth int `json:"defaultOutboundBandwidth"` // 默认出站带宽限制（Mbps）
@@ -181,6 +247,8 @@ type UpdateProviderRequest struct {
 	MaxTraffic           int64   `json:"maxTraffic"`           // 最大流量限制（MB），默认1TB=1048576MB
 	TrafficCountMode     string  `json:"trafficCountMode"`     // 流量统计模式：both(双向), out(仅出向), in(仅入向)
 	TrafficMultiplier    float64 `json:"trafficMultiplier"`    // 流量计费倍率，默认1.0
+	// 分时段流量计费倍率，未提供时保持不变，提供空列表时清除
+	TrafficMultiplierWindows []providerModel.TrafficMultiplierWindow `json:"trafficMultiplierWindows"`
 	// 流量统计性能配置
 	TrafficStatsMode           string `json:"trafficStatsMode"`           // 流量统计性能模式：high, standard, light, minimal, custom
 	TrafficCollectInterval     int    `json:"trafficStatsInterval"`       // 流量统计间隔（秒）
@@ -216,13 +284,47 @@ type UpdateProviderRequest struct {
 	// 节点级别的等级限制配置
 	// 用于限制该节点上不同等级用户能创建的最大资源
 	LevelLimits map[int]map[string]interface{} `json:"levelLimits"` // 等级限制配置
+
+	// 实例同步发现意外停止的实例时按启动顺序自动启动，未提供时保持不变
+	RecoverStoppedInstances *bool `json:"recoverStoppedInstances"`
+
+	// 宿主机网卡，未提供时保持不变，附加上行网卡提供空列表时清除
+	PublicInterface *string                        `json:"publicInterface"`
+	Uplinks         []providerModel.ProviderUplink `json:"uplinks"`
+
+	// 镜像源改写规则，下载镜像时优先于CDN
+	ImageMirrors []providerModel.ImageMirrorRule `json:"imageMirrors"`
+
+	// 维护窗口，未提供时保持不变，提供空列表时清除
+	BlackoutWindows []string `json:"blackoutWindows"`
+
+	// 时区，未提供时保持不变，空字符串表示使用系统默认时区
+	Timezone *string `json:"timezone"`
+
+	// SSH命令白名单，未提供时保持不变，提供空列表时清除
+	CommandGuardMode *string  `json:"commandGuardMode"`
+	CommandAllowlist []string `json:"commandAllowlist"`
+	CommandDenylist  []string `json:"commandDenylist"`
+
+	// LXD/Incus 项目与配置文件，未提供时保持不变
+	Project  *string  `json:"project"`  // 已有实例时不能修改
+	Profiles []string `json:"profiles"` // 提供空列表时恢复为default
+
+	// 操作人，由接口从认证上下文填充，端口映射地址变更时作为迁移任务的创建者
+	OperatorID uint `json:"-"`
 }
 
 type ProviderListRequest struct {
 	common.PageInfo
-	Name   string `json:"name" form:"name"`
-	Type   string `json:"type" form:"type"`
-	Status string `json:"status" form:"status"`
+	Name        string `json:"name" form:"name"`
+	Type        string `json:"type" form:"type"`
+	Status      string `json:"status" form:"status"`
+	ProviderIDs []uint `json:"-" form:"-"` // 子管理员的管理范围，由处理函数根据登录身份填充
+}
+
+// SetAdminProviderScopeRequest 设置子管理员的Provider管理范围，空列表表示不限制
+type SetAdminProviderScopeRequest struct {
+	ProviderIDs []uint `json:"providerIds"`
 }
 
 // 冻结管理相关请求
@@ -292,6 +394,18 @@ type InviteCodeListRequest struct {
 	Status int    `json:"status" form:"status"`
 }
 
+// RegistrationApplicationListRequest 注册申请列表请求
+type RegistrationApplicationListRequest struct {
+	common.PageInfo
+	Status string `json:"status" form:"status"` // 状态筛选：pending, approved, rejected
+}
+
+// ReviewRegistrationRequest 批量审核注册申请请求
+type ReviewRegistrationRequest struct {
+	IDs  []uint `json:"ids" binding:"required,min=1,max=100"`
+	Note string `json:"note" binding:"max=255"` // 审核备注，会随结果通知给申请人
+}
+
 // BatchDeleteInviteCodesRequest 批量删除邀请码请求
 type BatchDeleteInviteCodesRequest struct {
 	IDs []uint `json:"ids" binding:"required,min=1"`
@@ -315,6 +429,9 @@ type UpdateInstanceRequest struct {
 	Memory int64  `json:"memory"`
 	Disk   int64  `json:"disk"`
 	Status string `json:"status"`
+	// 批量启动顺序，未提供时保持不变
+	StartupOrder *int `json:"startupOrder" binding:"omitempty,min=0,max=100"` // 数值小的先启动
+	StartupDelay *int `json:"startupDelay" binding:"omitempty,min=0,max=600"` // 启动后等待的秒数
 }
 
 type InstanceListRequest struct {
@@ -325,6 +442,7 @@ type InstanceListRequest struct {
 	Status       string `json:"status" form:"status"`
 	InstanceType string `json:"instance_type" form:"instance_type"`
 	UserID       uint   `json:"userId" form:"userId"`
+	ProviderIDs  []uint `json:"-" form:"-"` // 子管理员的管理范围，由处理函数根据登录身份填充
 }
 
 type InstanceActionRequest struct {
@@ -441,11 +559,13 @@ type UpdateInstanceTypePermissionsRequest struct {
 // PortMappingListRequest 端口映射列表请求
 type PortMappingListRequest struct {
 	common.PageInfo
-	Keyword    string `json:"keyword" form:"keyword"` // 搜索关键字（实例名称）
-	ProviderID uint   `json:"providerId" form:"providerId"`
-	InstanceID uint   `json:"instanceId" form:"instanceId"`
-	Protocol   string `json:"protocol" form:"protocol"`
-	Status     string `json:"status" form:"status"`
+	Keyword     string `json:"keyword" form:"keyword"` // 搜索关键字（实例名称）
+	ProviderID  uint   `json:"providerId" form:"providerId"`
+	InstanceID  uint   `json:"instanceId" form:"instanceId"`
+	Protocol    string `json:"protocol" form:"protocol"`
+	Status      string `json:"status" form:"status"`
+	ServiceType string `json:"serviceType" form:"serviceType"` // 按探测到的服务类型过滤
+	ProviderIDs []uint `json:"-" form:"-"`                     // 子管理员的管理范围，由处理函数根据登录身份填充
 }
 
 // CreatePortMappingRequest 创建端口映射请求（支持单个端口和端口段批量添加，仅支持 LXD/Incus/PVE）
@@ -471,6 +591,23 @@ type ProviderPortConfigRequest struct {
 	NetworkType      string `json:"networkType" binding:"oneof=nat_ipv4 nat_ipv4_ipv6 dedicated_ipv4 dedicated_ipv4_ipv6 ipv6_only"` // 网络配置类型
 }
 
+// ProviderPortRangeRequest 定义Provider可分配端口段请求
+type ProviderPortRangeRequest struct {
+	StartPort   int    `json:"startPort" binding:"required,min=1024,max=65535"` // 起始端口
+	EndPort     int    `json:"endPort" binding:"required,min=1024,max=65535"`   // 结束端口（包含）
+	Description string `json:"description" binding:"max=255"`                   // 端口段用途说明
+}
+
+// AllocatePortBlockRequest 为实例批量分配连续端口块请求
+type AllocatePortBlockRequest struct {
+	InstanceID  uint   `json:"instanceId" binding:"required"`
+	PortCount   int    `json:"portCount" binding:"required,min=1,max=1500"`    // 连续端口数量
+	GuestPort   int    `json:"guestPort" binding:"required,min=1,max=65535"`   // 实例内部起始端口
+	Protocol    string `json:"protocol" binding:"required,oneof=tcp udp both"` // 协议类型
+	RangeID     uint   `json:"rangeId"`                                        // 可选，指定端口段；为空时依次尝试所有可分配端口段
+	Description string `json:"description"`                                    // 端口用途描述
+}
+
 // CreateInstanceTaskRequest 创建实例任务数据结构
 type CreateInstanceTaskRequest struct {
 	ProviderId  uint   `json:"providerId"`
@@ -481,6 +618,9 @@ type CreateInstanceTaskRequest struct {
 	BandwidthId string `json:"bandwidthId"`
 	Description string `json:"description"`
 	SessionId   string `json:"sessionId"` // 会话ID，用于新的资源预留机制
+	AppId       uint   `json:"appId"`     // 一键应用ID，0表示不安装应用
+	GroupId     uint   `json:"groupId"`   // 实例分组ID，0表示未分组
+	Tags        string `json:"tags"`      // 实例标签（逗号分隔）
 }
 
 // InstanceOperationTaskRequest 实例操作任务数据结构（启动、停止、重启、重置）
@@ -491,9 +631,10 @@ type InstanceOperationTaskRequest struct {
 
 // DeleteInstanceTaskRequest 删除实例任务数据结构
 type DeleteInstanceTaskRequest struct {
-	InstanceId     uint `json:"instanceId"`
-	ProviderId     uint `json:"providerId"`
-	AdminOperation bool `json:"adminOperation,omitempty"` // 是否为管理员操作
+	InstanceId        uint `json:"instanceId"`
+	ProviderId        uint `json:"providerId"`
+	AdminOperation    bool `json:"adminOperation,omitempty"`    // 是否为管理员操作
+	ResourcesReleased bool `json:"resourcesReleased,omitempty"` // Provider资源和配额已在创建失败回滚时释放，删除时不再重复释放
 }
 
 // ResetPasswordTaskRequest 重置密码任务数据结构
@@ -528,6 +669,38 @@ type SyncPortMappingsTaskRequest struct {
 	ProviderIDs []uint `json:"providerIds,omitempty"` // 指定要同步的Provider IDs（为空则同步所有）
 }
 
+// ExportDataTaskRequest 用户数据导出任务数据结构
+type ExportDataTaskRequest struct {
+	ExportID uint `json:"exportId"` // 数据导出记录ID
+}
+
+// MigratePortIPTaskRequest 端口映射地址迁移任务数据结构
+type MigratePortIPTaskRequest struct {
+	OldHost string `json:"oldHost"` // 变更前用户访问的地址
+	NewHost string `json:"newHost"` // 变更后用户访问的地址
+}
+
+// MigratePortMethodTaskRequest 端口映射方式迁移任务数据结构
+type MigratePortMethodTaskRequest struct {
+	TargetMethod string `json:"targetMethod" binding:"required"` // 目标映射方式：device_proxy, iptables
+	BatchSize    int    `json:"batchSize"`                       // 每批同时迁移的实例数，默认5，最大20
+	SourceMethod string `json:"sourceMethod,omitempty"`          // 迁移前Provider的映射方式，创建任务时记录
+}
+
+// NetworkProbeTaskRequest Provider网络质量测试任务数据，目标和测速地址为空时使用配置
+type NetworkProbeTaskRequest struct {
+	Trigger      string   `json:"trigger"`                // 触发方式：manual, scheduled
+	Targets      []string `json:"targets,omitempty"`      // 探测目标，格式 "名称=地址" 或 "地址"
+	SpeedTestURL string   `json:"speedTestUrl,omitempty"` // 下载测速地址
+}
+
+// DetectPortServiceTaskRequest 端口映射服务类型探测任务数据
+type DetectPortServiceTaskRequest struct {
+	InstanceID uint   `json:"instanceId"`
+	PortIDs    []uint `json:"portIds,omitempty"` // 要探测的端口映射，为空时探测实例的全部TCP端口映射
+	Consent    bool   `json:"consent"`           // 实例所有者已同意探测
+}
+
 // CheckPortAvailabilityRequest 检查端口可用性请求
 type CheckPortAvailabilityRequest struct {
 	ProviderID uint   `json:"providerId" binding:"required"`                  // Provider ID
@@ -545,3 +718,17 @@ type CheckPortAvailabilityResponse struct {
 	PortRange        string `json:"portRange"`        // 端口范围描述（如 "10000-10009"）
 	Suggestion       string `json:"suggestion"`       // 建议（如果有冲突，提供替代方案）
 }
+
+// RotateProviderCredentialsRequest 轮换Provider SSH凭据请求
+// 提交的密码和私钥整体替换原有凭据，至少提供其中一种
+type RotateProviderCredentialsRequest struct {
+	Username string `json:"username"`                 // 新的SSH用户名，为空时保持不变
+	Password string `json:"password"`                 // 新的SSH密码
+	SSHKey   string `json:"sshKey"`                   // 新的SSH私钥，优先于密码使用
+	Reason   string `json:"reason" binding:"max=255"` // 轮换原因，记录到审计日志
+}
+
+// ActivateProviderSSHKeyRequest 启用面板生成的Provider SSH密钥请求
+type ActivateProviderSSHKeyRequest struct {
+	Reason string `json:"reason" binding:"max=255"` // 启用原因，记录到审计日志
+}
@@ -3,6 +3,7 @@ package admin
 import (
 	"time"
 
+	"oneclickvirt/model/monitoring"
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/system"
 	"oneclickvirt/model/user"
@@ -18,6 +19,7 @@ type AdminDashboardResponse struct {
 		TotalInstances   int `json:"totalInstances"`
 		RunningInstances int `json:"runningInstances"`
 		ActiveProviders  int `json:"activeProviders"`
+		EOLInstances     int `json:"eolInstances"` // 运行已EOL操作系统的实例数
 	} `json:"statistics"`
 	RecentUsers     []user.User         `json:"recentUsers"`
 	RecentInstances []provider.Instance `json:"recentInstances"`
@@ -27,6 +29,7 @@ type AdminDashboardResponse struct {
 		DiskUsage   float64 `json:"diskUsage"`
 		Uptime      string  `json:"uptime"`
 	} `json:"systemStatus"`
+	Forecasts []monitoring.UsageForecast `json:"forecasts"` // 处于预警期的趋势预测，按预计到达时间排序
 }
 
 type UserManageResponse struct {
@@ -73,6 +76,7 @@ type InstanceManageResponse struct {
 	HealthStatus   string `json:"healthStatus"`
 	UsedTrafficIn  int64  `json:"usedTrafficIn"`  // 当月入站流量（MB）- 从历史记录查询
 	UsedTrafficOut int64  `json:"usedTrafficOut"` // 当月出站流量（MB）- 从历史记录查询
+	OSEOLStatus    string `json:"osEolStatus"`    // 操作系统EOL状态：approaching即将EOL，eol已EOL，空为正常
 }
 
 type SystemConfigResponse struct {
@@ -103,6 +107,10 @@ type ProviderStatusResponse struct {
 	NodeDiskTotal    int64      `json:"nodeDiskTotal"`
 	ResourceSynced   bool       `json:"resourceSynced"`
 	ResourceSyncedAt *time.Time `json:"resourceSyncedAt"`
+	// 宿主机日志事件
+	HostEventSources    string     `json:"hostEventSources"`
+	HostEventsCheckedAt *time.Time `json:"hostEventsCheckedAt"`
+	RecentHostEvents    int64      `json:"recentHostEvents"` // 最近24小时的宿主机事件数
 }
 
 // ConfigurationTaskResponse 配置任务响应
@@ -184,3 +192,73 @@ type TestSSHConnectionResponse struct {
 	TestCount          int    `json:"testCount"`              // 测试次数
 	ErrorMessage       string `json:"errorMessage,omitempty"` // 错误信息（如果失败）
 }
+
+// RotateProviderCredentialsResponse 轮换Provider SSH凭据响应
+type RotateProviderCredentialsResponse struct {
+	ProviderID     uint   `json:"providerId"`
+	AuthMethod     string `json:"authMethod"`               // 轮换后的认证方式：password 或 sshKey
+	TestLatency    int64  `json:"testLatency"`              // 新凭据连接测试延迟（毫秒）
+	Reconnected    bool   `json:"reconnected"`              // 已加载的Provider实例是否已切换到新连接
+	ReconnectError string `json:"reconnectError,omitempty"` // 切换失败原因，凭据已保存，可稍后手动重连
+}
+
+// ProviderSSHKeyResponse Provider SSH密钥状态
+type ProviderSSHKeyResponse struct {
+	ProviderID         uint   `json:"providerId"`
+	Username           string `json:"username"`
+	AuthMethod         string `json:"authMethod"`                   // 当前认证方式：password 或 sshKey
+	HasPassword        bool   `json:"hasPassword"`                  // 是否仍保存着SSH密码
+	ActivePublicKey    string `json:"activePublicKey,omitempty"`    // 当前使用的私钥对应的公钥
+	ActiveFingerprint  string `json:"activeFingerprint,omitempty"`  // 当前公钥指纹（SHA256）
+	PendingPublicKey   string `json:"pendingPublicKey,omitempty"`   // 面板生成、等待安装的公钥
+	PendingFingerprint string `json:"pendingFingerprint,omitempty"` // 待安装公钥指纹（SHA256）
+	InstallCommand     string `json:"installCommand,omitempty"`     // 在宿主机上安装待安装公钥的命令
+}
+
+// PortPlanSegment 端口规划中的连续端口段
+type PortPlanSegment struct {
+	StartPort  int    `json:"startPort"`
+	EndPort    int    `json:"endPort"`
+	Status     string `json:"status"`               // free, used
+	PortID     uint   `json:"portId,omitempty"`     // 占用该端口段的端口映射ID
+	InstanceID uint   `json:"instanceId,omitempty"` // 占用该端口段的实例ID
+}
+
+// PortRangePlan 单个端口段的使用与碎片情况
+type PortRangePlan struct {
+	RangeID          uint              `json:"rangeId"` // 0 表示未定义可分配端口段时的Provider整体端口映射范围
+	StartPort        int               `json:"startPort"`
+	EndPort          int               `json:"endPort"`
+	Description      string            `json:"description"`
+	TotalPorts       int               `json:"totalPorts"`
+	UsedPorts        int               `json:"usedPorts"`
+	FreePorts        int               `json:"freePorts"`
+	UsageRate        float64           `json:"usageRate"`        // 使用率（百分比）
+	FreeBlocks       int               `json:"freeBlocks"`       // 空闲块数量
+	LargestFreeBlock int               `json:"largestFreeBlock"` // 最大连续空闲端口数
+	Fragmentation    float64           `json:"fragmentation"`    // 碎片率（百分比）：1 - 最大空闲块/空闲端口数，0 表示空闲端口完全连续
+	Segments         []PortPlanSegment `json:"segments"`         // 按端口排序的占用/空闲端口段，用于可视化
+}
+
+// ProviderPortPlanResponse Provider端口规划响应
+type ProviderPortPlanResponse struct {
+	ProviderID     uint            `json:"providerId"`
+	PortRangeStart int             `json:"portRangeStart"`
+	PortRangeEnd   int             `json:"portRangeEnd"`
+	Ranges         []PortRangePlan `json:"ranges"`
+}
+
+// ScopedProvider 管理范围内的Provider
+type ScopedProvider struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// AdminProviderScopeResponse 管理员的Provider管理范围
+type AdminProviderScopeResponse struct {
+	UserID      uint             `json:"userId"`
+	Username    string           `json:"username"`
+	Scoped      bool             `json:"scoped"` // 是否为子管理员
+	ProviderIDs []uint           `json:"providerIds"`
+	Providers   []ScopedProvider `json:"providers"`
+}
@@ -2,17 +2,19 @@ package admin
 
 import (
 	"time"
+
+	"oneclickvirt/model/common"
 )
 
 // AdminTaskListRequest 管理员任务列表请求
 type AdminTaskListRequest struct {
-	Page         int    `json:"page" form:"page"`
-	PageSize     int    `json:"pageSize" form:"pageSize"`
+	common.PageInfo
 	ProviderID   uint   `json:"providerId" form:"providerId"`
 	Username     string `json:"username" form:"username"` // 用户名搜索
 	TaskType     string `json:"taskType" form:"taskType"`
 	Status       string `json:"status" form:"status"`
 	InstanceType string `json:"instanceType" form:"instanceType"` // container or vm
+	ProviderIDs  []uint `json:"-" form:"-"`                       // 子管理员的管理范围，由处理函数根据登录身份填充
 }
 
 // AdminTaskResponse 管理员任务响应
@@ -48,10 +50,11 @@ type AdminTaskResponse struct {
 
 // AdminTaskListResponse 管理员任务列表响应
 type AdminTaskListResponse struct {
-	List     []AdminTaskResponse `json:"list"`
-	Total    int64               `json:"total"`
-	Page     int                 `json:"page"`
-	PageSize int                 `json:"pageSize"`
+	List          []AdminTaskResponse `json:"list"`
+	Total         int64               `json:"total"`
+	Page          int                 `json:"page"`
+	PageSize      int                 `json:"pageSize"`
+	NextPageToken string              `json:"nextPageToken,omitempty"`
 }
 
 // AdminTaskDetailResponse 管理员任务详情响应
@@ -75,3 +78,82 @@ type TaskStatsResponse struct {
 	FailedTasks    int64 `json:"failedTasks"`
 	TimeoutTasks   int64 `json:"timeoutTasks"`
 }
+
+// TaskAnalyticsRequest 任务分析请求
+type TaskAnalyticsRequest struct {
+	Hours      int    `json:"hours" form:"hours"`           // 统计时间窗口（小时），默认24，最大2160（90天）
+	Bucket     string `json:"bucket" form:"bucket"`         // 时间序列粒度：hour、day，默认按窗口长度选择
+	ProviderID uint   `json:"providerId" form:"providerId"` // 只统计指定Provider
+	TaskType   string `json:"taskType" form:"taskType"`     // 只统计指定任务类型
+	TopErrors  int    `json:"topErrors" form:"topErrors"`   // 返回的常见错误数量，默认10
+}
+
+// TaskAnalyticsResponse 任务分析响应
+type TaskAnalyticsResponse struct {
+	Start      time.Time           `json:"start"`
+	End        time.Time           `json:"end"`
+	Bucket     string              `json:"bucket"`
+	Total      int64               `json:"total"`
+	ByType     []TaskGroupStats    `json:"byType"`     // 按任务类型聚合
+	ByProvider []TaskGroupStats    `json:"byProvider"` // 按Provider聚合
+	Timeline   []TaskTimelinePoint `json:"timeline"`   // 按时间桶聚合
+	Durations  []TaskPhaseDuration `json:"durations"`  // 各任务类型的阶段耗时
+	TopErrors  []TaskErrorGroup    `json:"topErrors"`  // 归一化后最常见的失败原因
+	StuckTasks []StuckTask         `json:"stuckTasks"` // 运行超过超时时间的任务（不受时间窗口限制）
+	Statuses   map[string]int64    `json:"statuses"`   // 各状态任务数
+}
+
+// TaskGroupStats 分组的任务数量统计
+type TaskGroupStats struct {
+	Key         string           `json:"key"`            // 任务类型，或Provider ID
+	Name        string           `json:"name,omitempty"` // Provider名称
+	Total       int64            `json:"total"`          // 任务总数
+	Statuses    map[string]int64 `json:"statuses"`       // 各状态任务数
+	FailureRate float64          `json:"failureRate"`    // 失败率（failed+timeout 占已结束任务的比例）
+}
+
+// TaskTimelinePoint 时间桶内的任务数量
+type TaskTimelinePoint struct {
+	Time      time.Time `json:"time"`
+	Total     int64     `json:"total"`
+	Completed int64     `json:"completed"`
+	Failed    int64     `json:"failed"` // failed + timeout
+	Cancelled int64     `json:"cancelled"`
+}
+
+// TaskPhaseDuration 任务类型的阶段平均耗时（秒）
+// 排队阶段为创建到开始执行，执行阶段为开始执行到结束
+type TaskPhaseDuration struct {
+	TaskType             string  `json:"taskType"`
+	Count                int64   `json:"count"` // 参与统计的已结束任务数
+	AvgQueueSeconds      float64 `json:"avgQueueSeconds"`
+	AvgRunSeconds        float64 `json:"avgRunSeconds"`
+	MaxRunSeconds        float64 `json:"maxRunSeconds"`
+	AvgRunSecondsSuccess float64 `json:"avgRunSecondsSuccess"` // 成功任务的平均执行耗时
+	AvgRunSecondsFailure float64 `json:"avgRunSecondsFailure"` // 失败任务的平均执行耗时
+}
+
+// TaskErrorGroup 归一化后相同的失败原因
+type TaskErrorGroup struct {
+	Pattern      string    `json:"pattern"`      // 去除ID、IP、数字等变化部分后的错误信息
+	Count        int64     `json:"count"`        // 出现次数
+	TaskTypes    []string  `json:"taskTypes"`    // 涉及的任务类型
+	LastSeen     time.Time `json:"lastSeen"`     // 最近一次出现时间
+	SampleTaskID uint      `json:"sampleTaskId"` // 最近一次出现的任务ID
+	Sample       string    `json:"sample"`       // 最近一次的原始错误信息
+}
+
+// StuckTask 运行超过超时时间仍未结束的任务
+type StuckTask struct {
+	ID              uint      `json:"id"`
+	TaskType        string    `json:"taskType"`
+	Status          string    `json:"status"`
+	UserID          uint      `json:"userId"`
+	ProviderID      *uint     `json:"providerId"`
+	InstanceID      *uint     `json:"instanceId"`
+	StartedAt       time.Time `json:"startedAt"`
+	TimeoutDuration int       `json:"timeoutDuration"` // 超时时间（秒）
+	OverdueSeconds  int64     `json:"overdueSeconds"`  // 已超出超时时间的秒数
+	Progress        int       `json:"progress"`
+	StatusMessage   string    `json:"statusMessage"`
+}
@@ -33,6 +33,7 @@ type RegisterRequest struct {
 	Captcha      string `json:"captcha"`
 	CaptchaId    string `json:"captchaId"`
 	RegisterType string `json:"registerType,omitempty"` // 注册类型，前端兼容字段
+	Reason       string `json:"reason,omitempty"`       // 申请理由，开启注册审核时使用
 }
 
 // ForgotPasswordRequest 忘记密码请求
@@ -9,6 +9,9 @@ const (
 	AuthLevelAdmin  AuthLevel = 3 // 管理员
 )
 
+// UserTypeAuditor 只读审计员：可以查看管理后台的全部数据并导出报表，但不能做任何修改
+const UserTypeAuditor = "auditor"
+
 // AuthContext 认证上下文
 type AuthContext struct {
 	UserID       uint     `json:"user_id"`
@@ -18,4 +21,24 @@ type AuthContext struct {
 	BaseUserType string   `json:"base_user_type"` // 用户基础类型
 	AllUserTypes []string `json:"all_user_types"` // 用户拥有的所有权限类型
 	IsEffective  bool     `json:"is_effective"`   // 权限是否有效
+	// ProviderScope 子管理员可管理的Provider ID，为空表示不限制
+	ProviderScope []uint `json:"provider_scope,omitempty"`
+}
+
+// IsProviderScoped 是否为限定Provider范围的子管理员
+func (a *AuthContext) IsProviderScoped() bool {
+	return len(a.ProviderScope) > 0
+}
+
+// CanManageProvider 判断能否管理指定Provider，未限定范围时始终可以
+func (a *AuthContext) CanManageProvider(providerID uint) bool {
+	if !a.IsProviderScoped() {
+		return true
+	}
+	for _, id := range a.ProviderScope {
+		if id == providerID {
+			return true
+		}
+	}
+	return false
 }
@@ -21,6 +21,7 @@ const (
 	CodeNotFound        = 1005
 	CodeConflict        = 1006
 	CodeValidationError = 1007
+	CodeTooManyRequests = 1008
 
 	// 用户相关错误 2000-2999
 	CodeUserNotFound       = 2001
@@ -67,6 +68,7 @@ var ErrorMessages = map[int]string{
 	CodeNotFound:                "资源不存在",
 	CodeConflict:                "资源冲突",
 	CodeValidationError:         "数据验证失败",
+	CodeTooManyRequests:         "请求过于频繁",
 	CodeUserNotFound:            "用户不存在",
 	CodeUserExists:              "用户已存在",
 	CodeUsernameExists:          "用户名已存在",
@@ -165,10 +167,11 @@ func ResponseSuccessWithPagination(c *gin.Context, data interface{}, total int64
 		"code":    CodeSuccess,
 		"message": ErrorMessages[CodeSuccess],
 		"data": gin.H{
-			"list":     data,
-			"total":    total,
-			"page":     page,
-			"pageSize": pageSize,
+			"list":          data,
+			"total":         total,
+			"page":          page,
+			"pageSize":      pageSize,
+			"nextPageToken": NextPageToken(page, pageSize, total),
 		},
 	})
 }
@@ -186,6 +189,8 @@ func getHTTPCode(code int) int {
 		return http.StatusNotFound
 	case CodeConflict, CodeUserExists, CodeUsernameExists, CodeRoleExists:
 		return http.StatusConflict
+	case CodeTooManyRequests:
+		return http.StatusTooManyRequests
 	case CodeRequestTooLarge:
 		return http.StatusRequestEntityTooLarge
 	default:
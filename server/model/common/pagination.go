@@ -0,0 +1,88 @@
+package common
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+
+	pageTokenPrefix = "p:"
+	idCursorPrefix  = "id:"
+)
+
+// Normalize 解析翻页令牌并修正页码和每页数量
+func (p *PageInfo) Normalize() {
+	if p.PageToken != "" {
+		if page := DecodePageToken(p.PageToken); page > 0 {
+			p.Page = page
+		}
+	}
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.PageSize <= 0 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+}
+
+// Offset 返回当前页的偏移量
+func (p *PageInfo) Offset() int {
+	if p.Page <= 0 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// NextPageToken 生成下一页的翻页令牌，没有下一页时返回空字符串
+// 令牌只记录页码，仍按 OFFSET 翻页，翻页期间数据有增删时可能出现重复或遗漏；需要稳定翻页的大表使用 EncodeIDCursor
+func NextPageToken(page, pageSize int, total int64) string {
+	if page <= 0 || pageSize <= 0 || int64(page)*int64(pageSize) >= total {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + strconv.Itoa(page+1)))
+}
+
+// DecodePageToken 解析翻页令牌，返回页码，无效令牌返回0
+func DecodePageToken(token string) int {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), pageTokenPrefix) {
+		return 0
+	}
+	page, err := strconv.Atoi(strings.TrimPrefix(string(raw), pageTokenPrefix))
+	if err != nil || page <= 0 {
+		return 0
+	}
+	return page
+}
+
+// EncodeIDCursor 生成基于主键的游标，用于大表的键集分页（WHERE id < ?），避免深度OFFSET扫描
+func EncodeIDCursor(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(idCursorPrefix + strconv.FormatUint(uint64(id), 10)))
+}
+
+// DecodeIDCursor 解析基于主键的游标，空游标返回0，格式错误返回error
+func DecodeIDCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), idCursorPrefix) {
+		return 0, errors.New("无效的游标")
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(raw), idCursorPrefix), 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("无效的游标")
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,69 @@
+package common
+
+import "testing"
+
+func TestPageToken(t *testing.T) {
+	tests := []struct {
+		page, pageSize int
+		total          int64
+		next           int // 0 表示没有下一页
+	}{
+		{page: 1, pageSize: 10, total: 25, next: 2},
+		{page: 2, pageSize: 10, total: 25, next: 3},
+		{page: 3, pageSize: 10, total: 25},
+		{page: 1, pageSize: 10, total: 10},
+		{page: 0, pageSize: 10, total: 25},
+	}
+	for _, tt := range tests {
+		token := NextPageToken(tt.page, tt.pageSize, tt.total)
+		if tt.next == 0 {
+			if token != "" {
+				t.Errorf("page %d/%d: 不应有下一页，得到 %q", tt.page, tt.total, token)
+			}
+			continue
+		}
+		info := PageInfo{Page: 1, PageToken: token}
+		info.Normalize()
+		if info.Page != tt.next {
+			t.Errorf("page %d: 下一页 = %d, want %d", tt.page, info.Page, tt.next)
+		}
+	}
+
+	// 无效令牌和主键游标不能当作页码
+	for _, token := range []string{"garbage", EncodeIDCursor(42), "cDowCg"} {
+		if page := DecodePageToken(token); page != 0 {
+			t.Errorf("DecodePageToken(%q) = %d", token, page)
+		}
+	}
+}
+
+func TestIDCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    uint
+		wantErr bool
+	}{
+		{name: "空游标", cursor: ""},
+		{name: "往返", cursor: EncodeIDCursor(12345), want: 12345},
+		{name: "非base64", cursor: "%%%", wantErr: true},
+		{name: "页码令牌", cursor: NextPageToken(1, 10, 100), wantErr: true},
+		{name: "主键为0", cursor: "aWQ6MA", wantErr: true},
+		{name: "主键非数字", cursor: "aWQ6eA", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeIDCursor(tt.cursor)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应拒绝 %q，得到 %d", tt.name, tt.cursor, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DecodeIDCursor = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+	if EncodeIDCursor(0) != "" {
+		t.Error("主键为0时不应生成游标")
+	}
+}
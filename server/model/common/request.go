@@ -1,7 +1,10 @@
 package common
 
 type PageInfo struct {
-	Page     int    `json:"page" form:"page"`
-	PageSize int    `json:"pageSize" form:"pageSize"`
-	Keyword  string `json:"keyword" form:"keyword"`
+	Page      int      `json:"page" form:"page"`
+	PageSize  int      `json:"pageSize" form:"pageSize"`
+	Keyword   string   `json:"keyword" form:"keyword"`
+	Sort      string   `json:"sort" form:"sort"`           // 排序字段，逗号分隔，前缀 - 表示降序，如 -createdAt,name
+	Filter    []string `json:"filter" form:"filter"`       // 过滤条件，格式 字段:操作符:值，如 status:in:running,stopped
+	PageToken string   `json:"pageToken" form:"pageToken"` // 翻页令牌，取自上一页响应的 nextPageToken，优先于 page
 }
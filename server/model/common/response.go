@@ -7,10 +7,11 @@ type Response struct {
 }
 
 type PageResult struct {
-	List     interface{} `json:"list"`
-	Total    int64       `json:"total"`
-	Page     int         `json:"page"`
-	PageSize int         `json:"pageSize"`
+	List          interface{} `json:"list"`
+	Total         int64       `json:"total"`
+	Page          int         `json:"page"`
+	PageSize      int         `json:"pageSize"`
+	NextPageToken string      `json:"nextPageToken,omitempty"`
 }
 
 func Success(data interface{}) map[string]interface{} {
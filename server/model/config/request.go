@@ -41,6 +41,8 @@ type QuotaConfig struct {
 
 type LevelLimitInfo struct {
 	MaxInstances int                    `json:"maxInstances"`
+	MaxRunning   int                    `json:"maxRunning"`   // 可同时运行的实例数，0表示不单独限制
+	MaxSnapshots int                    `json:"maxSnapshots"` // 每个实例定时快照最多保留的数量，0表示不允许定时快照
 	MaxResources map[string]interface{} `json:"maxResources"`
 	MaxTraffic   int64                  `json:"maxTraffic"`                                // 最大流量限制(MB)
 	ExpiryDays   int                    `json:"expiryDays"`                                // 新注册用户的默认过期天数，0表示不过期
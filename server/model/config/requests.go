@@ -5,3 +5,25 @@ type UnifiedConfigRequest struct {
 	Scope  string                 `json:"scope" binding:"required"` // public, user, admin
 	Config map[string]interface{} `json:"config" binding:"required"`
 }
+
+// ConfigSyncRequest 配置同步请求
+type ConfigSyncRequest struct {
+	Direction string `json:"direction" binding:"required,oneof=db-to-yaml yaml-to-db"` // db-to-yaml: 数据库重建YAML, yaml-to-db: YAML同步到数据库
+}
+
+// ConfigReconcileRequest 配置不一致修复请求
+type ConfigReconcileRequest struct {
+	Key    string `json:"key" binding:"required"`                         // 配置项，如 quota.default-level
+	Source string `json:"source" binding:"required,oneof=yaml db global"` // 以哪个来源的值为准
+}
+
+// CDNValidateRequest 静态资源CDN配置校验请求，未填写的字段使用当前配置
+type CDNValidateRequest struct {
+	AssetDomain  *string `json:"assetDomain"`                                      // CDN域名
+	AssetVersion *string `json:"assetVersion"`                                     // 资源版本号
+	SignKey      *string `json:"signKey"`                                          // 签名密钥，脱敏占位值表示使用当前密钥
+	SignExpire   *int    `json:"signExpire" binding:"omitempty,min=60,max=604800"` // 签名有效期（秒）
+	SamplePath   string  `json:"samplePath"`                                       // 用于探测CDN回源的站内路径，默认 /favicon.ico
+	PrivatePath  string  `json:"privatePath"`                                      // 用于生成签名地址的上传目录内相对路径
+	Probe        bool    `json:"probe"`                                            // 是否通过CDN实际请求样例地址
+}
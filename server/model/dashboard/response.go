@@ -75,3 +75,33 @@ type ProviderCountStats struct {
 	TotalProviders   int64 `json:"total_providers"`
 	LimitedProviders int64 `json:"limited_providers"`
 }
+
+// InstanceCounter 按Provider、实例类型和状态分组的实例数
+type InstanceCounter struct {
+	ProviderID   uint   `json:"providerId"`
+	InstanceType string `json:"instanceType"`
+	Status       string `json:"status"`
+	Count        int64  `json:"count"`
+}
+
+// PortCounter 按Provider和状态分组的端口映射数
+type PortCounter struct {
+	ProviderID uint   `json:"providerId"`
+	Status     string `json:"status"`
+	Count      int64  `json:"count"`
+}
+
+// UserCounter 按等级、状态和用户类型分组的用户数
+type UserCounter struct {
+	Level    int    `json:"level"`
+	Status   int    `json:"status"`
+	UserType string `json:"userType"`
+	Count    int64  `json:"count"`
+}
+
+// Counters 仪表板计数器快照
+type Counters struct {
+	Instances []InstanceCounter `json:"instances"`
+	Ports     []PortCounter     `json:"ports"`
+	Users     []UserCounter     `json:"users"`
+}
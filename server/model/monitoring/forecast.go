@@ -0,0 +1,54 @@
+package monitoring
+
+import "time"
+
+// 趋势预测的对象类型
+const (
+	ForecastKindProvider = "provider" // Provider资源占用率
+	ForecastKindUser     = "user"     // 用户当月流量
+)
+
+// 趋势预测的指标
+const (
+	ForecastMetricCPU     = "cpu"
+	ForecastMetricMemory  = "memory"
+	ForecastMetricDisk    = "disk"
+	ForecastMetricTraffic = "traffic"
+)
+
+// UsageSample 资源用量采样，用于趋势预测
+type UsageSample struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"size:16;index:idx_usage_sample_subject,priority:1;not null"`
+	SubjectID uint      `json:"subjectId" gorm:"index:idx_usage_sample_subject,priority:2;not null"`
+	Metric    string    `json:"metric" gorm:"size:16;index:idx_usage_sample_subject,priority:3;not null"`
+	Value     float64   `json:"value"` // Provider为物理占用率（%），用户流量为当月已用流量（MB）
+	SampledAt time.Time `json:"sampledAt" gorm:"index:idx_usage_sample_subject,priority:4;index:idx_usage_sample_time;not null"`
+}
+
+// TableName 指定表名
+func (UsageSample) TableName() string {
+	return "usage_samples"
+}
+
+// UsageForecast 最近一次趋势预测结果
+type UsageForecast struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Kind       string     `json:"kind" gorm:"size:16;uniqueIndex:uk_usage_forecast,priority:1;not null"`
+	SubjectID  uint       `json:"subjectId" gorm:"uniqueIndex:uk_usage_forecast,priority:2;not null"`
+	Metric     string     `json:"metric" gorm:"size:16;uniqueIndex:uk_usage_forecast,priority:3;not null"`
+	Name       string     `json:"name" gorm:"size:128"`  // Provider名称或用户名
+	Method     string     `json:"method" gorm:"size:16"` // 预测方法：linear, ewma
+	Current    float64    `json:"current"`               // 当前值，单位同采样值
+	Threshold  float64    `json:"threshold"`             // 预警阈值：Provider为占用率（%），用户为当月流量配额（MB）
+	DailyRate  float64    `json:"dailyRate"`             // 预测的每日增长量
+	ExhaustAt  *time.Time `json:"exhaustAt"`             // 预计达到阈值的时间，为空表示按当前趋势不会达到
+	Warning    bool       `json:"warning" gorm:"index"`  // 是否在预警期内
+	NotifiedAt *time.Time `json:"notifiedAt"`            // 最近一次发送预警通知的时间
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// TableName 指定表名
+func (UsageForecast) TableName() string {
+	return "usage_forecasts"
+}
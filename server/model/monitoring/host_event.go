@@ -0,0 +1,56 @@
+package monitoring
+
+import (
+	"time"
+
+	"oneclickvirt/model/common"
+)
+
+// 宿主机事件日志来源
+const (
+	HostEventSourceKernel = "kernel" // 内核日志（journalctl -k 或 dmesg）
+	HostEventSourceDaemon = "daemon" // 虚拟化服务日志（lxd、incus、docker、pve服务）
+)
+
+// 宿主机事件类别
+const (
+	HostEventCategoryOOM    = "oom"    // OOM killer杀死进程
+	HostEventCategoryDisk   = "disk"   // 磁盘或文件系统错误
+	HostEventCategoryDaemon = "daemon" // 虚拟化服务错误
+)
+
+// 宿主机事件级别
+const (
+	HostEventSeverityWarning  = "warning"
+	HostEventSeverityError    = "error"
+	HostEventSeverityCritical = "critical"
+)
+
+// HostEvent 从宿主机日志中识别出的事件
+type HostEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProviderID  uint      `json:"providerId" gorm:"index;not null"`                        // Provider ID
+	InstanceID  *uint     `json:"instanceId" gorm:"index"`                                 // 关联的实例ID，无法关联时为空
+	Source      string    `json:"source" gorm:"size:16"`                                   // 日志来源：kernel, daemon
+	Category    string    `json:"category" gorm:"size:16;index"`                           // 类别：oom, disk, daemon
+	Severity    string    `json:"severity" gorm:"size:16"`                                 // 级别：warning, error, critical
+	Subject     string    `json:"subject" gorm:"size:128"`                                 // 事件涉及的实例名、进程名或设备
+	Message     string    `json:"message" gorm:"size:1024"`                                // 日志原文
+	Fingerprint string    `json:"-" gorm:"size:40;uniqueIndex:idx_host_event_fingerprint"` // 去重指纹，重复读取的日志不重复记录
+	OccurredAt  time.Time `json:"occurredAt" gorm:"index"`                                 // 日志时间
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// TableName 指定表名
+func (HostEvent) TableName() string {
+	return "host_events"
+}
+
+// HostEventListRequest 宿主机事件列表请求
+type HostEventListRequest struct {
+	common.PageInfo
+	ProviderID uint   `json:"providerId" form:"providerId"` // 按Provider筛选
+	InstanceID uint   `json:"instanceId" form:"instanceId"` // 按实例筛选
+	Category   string `json:"category" form:"category"`     // 按类别筛选：oom, disk, daemon
+	Severity   string `json:"severity" form:"severity"`     // 按级别筛选：warning, error, critical
+}
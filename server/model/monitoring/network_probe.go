@@ -0,0 +1,85 @@
+package monitoring
+
+import "time"
+
+// 网络质量测试状态
+const (
+	NetworkProbeStatusCompleted = "completed"
+	NetworkProbeStatusFailed    = "failed"
+)
+
+// 网络质量测试触发方式
+const (
+	NetworkProbeTriggerManual    = "manual"
+	NetworkProbeTriggerScheduled = "scheduled"
+)
+
+// NetworkProbe 节点网络质量测试记录，由宿主机上的下载测速和ping/mtr探测得出
+type NetworkProbe struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ProviderID     uint      `json:"providerId" gorm:"index;not null"`         // Provider ID
+	TaskID         uint      `json:"taskId" gorm:"index"`                      // 执行测试的任务ID
+	Trigger        string    `json:"trigger" gorm:"size:16"`                   // 触发方式：manual, scheduled
+	Status         string    `json:"status" gorm:"size:16;index"`              // 状态：completed, failed
+	SpeedTestURL   string    `json:"speedTestUrl" gorm:"size:512"`             // 测速地址，为空表示未测速
+	DownloadMbps   float64   `json:"downloadMbps"`                             // 下载速度（Mbps）
+	DownloadBytes  int64     `json:"downloadBytes"`                            // 测速实际下载字节数
+	SpeedTestError string    `json:"speedTestError,omitempty" gorm:"size:512"` // 测速失败原因
+	TargetCount    int       `json:"targetCount"`                              // 探测目标数
+	Reachable      int       `json:"reachable"`                                // 可达的目标数
+	AvgLatencyMs   float64   `json:"avgLatencyMs"`                             // 可达目标的平均延迟（毫秒）
+	AvgLossPercent float64   `json:"avgLossPercent"`                           // 各目标的平均丢包率
+	Result         string    `json:"-" gorm:"type:text"`                       // 各目标的探测结果（JSON）
+	Error          string    `json:"error,omitempty" gorm:"size:512"`          // 失败原因
+	CreatedAt      time.Time `json:"createdAt" gorm:"index"`
+}
+
+// TableName 指定表名
+func (NetworkProbe) TableName() string {
+	return "network_probes"
+}
+
+// NetworkProbeTargetResult 单个目标的探测结果
+type NetworkProbeTargetResult struct {
+	Name        string            `json:"name"`
+	Host        string            `json:"host"`
+	Sent        int               `json:"sent"`        // 发送的ping包数
+	Received    int               `json:"received"`    // 收到的回复数
+	LossPercent float64           `json:"lossPercent"` // 丢包率
+	MinRttMs    float64           `json:"minRttMs"`
+	AvgRttMs    float64           `json:"avgRttMs"`
+	MaxRttMs    float64           `json:"maxRttMs"`
+	Hops        []NetworkProbeHop `json:"hops,omitempty"` // mtr路由，宿主机未安装mtr时为空
+	Error       string            `json:"error,omitempty"`
+}
+
+// NetworkProbeHop mtr报告中的一跳
+type NetworkProbeHop struct {
+	Index       int     `json:"index"`
+	Host        string  `json:"host"` // 无响应的跳为 ???
+	LossPercent float64 `json:"lossPercent"`
+	AvgRttMs    float64 `json:"avgRttMs"`
+}
+
+// NetworkProbeDetail 测试记录及各目标的探测结果
+type NetworkProbeDetail struct {
+	NetworkProbe
+	Targets []NetworkProbeTargetResult `json:"targets"`
+}
+
+// NetworkQualitySummary 展示给用户的节点网络质量摘要，不包含路由信息
+type NetworkQualitySummary struct {
+	ProbedAt       time.Time              `json:"probedAt"`
+	DownloadMbps   float64                `json:"downloadMbps"`
+	AvgLatencyMs   float64                `json:"avgLatencyMs"`
+	AvgLossPercent float64                `json:"avgLossPercent"`
+	Targets        []NetworkQualityTarget `json:"targets"`
+}
+
+// NetworkQualityTarget 用户可见的单个目标的延迟和丢包
+type NetworkQualityTarget struct {
+	Name        string  `json:"name"`
+	Reachable   bool    `json:"reachable"`
+	AvgRttMs    float64 `json:"avgRttMs"`
+	LossPercent float64 `json:"lossPercent"`
+}
@@ -14,7 +14,7 @@ type PerformanceMetric struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 时间戳
-	Timestamp time.Time `gorm:"index:idx_timestamp;not null" json:"timestamp"`
+	Timestamp time.Time `gorm:"index:idx_performance_metrics_timestamp;not null" json:"timestamp"`
 
 	// 系统基础指标
 	GoroutineCount int `json:"goroutine_count"`
@@ -21,6 +21,8 @@ type PmacctTrafficRecord struct {
 	RxBytes    int64 `json:"rx_bytes"`    // 接收字节数（入站流量）
 	TxBytes    int64 `json:"tx_bytes"`    // 发送字节数（出站流量）
 	TotalBytes int64 `json:"total_bytes"` // 总流量字节数
+	RxBytesV6  int64 `json:"rx_bytes_v6"` // 其中IPv6接收字节数（IPv4部分 = RxBytes - RxBytesV6）
+	TxBytesV6  int64 `json:"tx_bytes_v6"` // 其中IPv6发送字节数（IPv4部分 = TxBytes - TxBytesV6）
 
 	// 时间维度（支持5分钟精度）
 	// year和month添加到复合索引中，提升月度查询性能
@@ -44,6 +46,26 @@ func (PmacctTrafficRecord) TableName() string {
 	return "pmacct_traffic_records"
 }
 
+// TrafficFamilyBreakdown 按地址族拆分的流量（单位: 字节）
+type TrafficFamilyBreakdown struct {
+	IPv4RxBytes int64 `json:"ipv4_rx_bytes"` // IPv4接收字节数
+	IPv4TxBytes int64 `json:"ipv4_tx_bytes"` // IPv4发送字节数
+	IPv6RxBytes int64 `json:"ipv6_rx_bytes"` // IPv6接收字节数
+	IPv6TxBytes int64 `json:"ipv6_tx_bytes"` // IPv6发送字节数
+}
+
+// NewTrafficFamilyBreakdown 根据总流量和其中的IPv6流量计算地址族拆分
+// 历史记录没有IPv6字段（为0）时全部计入IPv4
+func NewTrafficFamilyBreakdown(rxBytes, txBytes, rxBytesV6, txBytesV6 int64) *TrafficFamilyBreakdown {
+	b := &TrafficFamilyBreakdown{
+		IPv6RxBytes: min(rxBytesV6, rxBytes),
+		IPv6TxBytes: min(txBytesV6, txBytes),
+	}
+	b.IPv4RxBytes = rxBytes - b.IPv6RxBytes
+	b.IPv4TxBytes = txBytes - b.IPv6TxBytes
+	return b
+}
+
 // PmacctMonitor pmacct监控配置
 type PmacctMonitor struct {
 	ID             uint      `json:"id" gorm:"primaryKey"`
@@ -69,13 +91,14 @@ func (PmacctMonitor) TableName() string {
 
 // PmacctSummary pmacct流量汇总响应
 type PmacctSummary struct {
-	InstanceID uint                   `json:"instance_id"`
-	MappedIP   string                 `json:"mapped_ip"`
-	MappedIPv6 string                 `json:"mapped_ipv6,omitempty"`
-	Today      *PmacctTrafficRecord   `json:"today"`      // 今日流量
-	ThisMonth  *PmacctTrafficRecord   `json:"this_month"` // 本月流量
-	AllTime    *PmacctTrafficRecord   `json:"all_time"`   // 总流量
-	History    []*PmacctTrafficRecord `json:"history"`    // 历史记录
+	InstanceID uint                    `json:"instance_id"`
+	MappedIP   string                  `json:"mapped_ip"`
+	MappedIPv6 string                  `json:"mapped_ipv6,omitempty"`
+	Today      *PmacctTrafficRecord    `json:"today"`      // 今日流量
+	ThisMonth  *PmacctTrafficRecord    `json:"this_month"` // 本月流量
+	AllTime    *PmacctTrafficRecord    `json:"all_time"`   // 总流量
+	Families   *TrafficFamilyBreakdown `json:"families"`   // 本月流量的IPv4/IPv6拆分
+	History    []*PmacctTrafficRecord  `json:"history"`    // 历史记录
 }
 
 // PmacctQuery pmacct查询条件
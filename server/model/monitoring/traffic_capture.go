@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"time"
+)
+
+// 流量明细抓取状态
+const (
+	TrafficCaptureRunning  = "running"  // 抓取中，查看时实时读取宿主机数据
+	TrafficCaptureFinished = "finished" // 已结束（到期或手动停止），结果已保存
+	TrafficCaptureFailed   = "failed"   // 启动或收尾失败
+)
+
+// 流量明细抓取时长限制（分钟）
+const (
+	TrafficCaptureDefaultMinutes = 10
+	TrafficCaptureMaxMinutes     = 60
+)
+
+// TrafficCapture 实例流量明细抓取记录
+// 抓取期间在宿主机上临时运行一个按端口和对端聚合的pmacctd，到期后自动退出，结果汇总后保存在Result中
+type TrafficCapture struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	InstanceID uint       `json:"instance_id" gorm:"index;not null"`    // 实例ID
+	ProviderID uint       `json:"provider_id" gorm:"index;not null"`    // Provider ID
+	StartedBy  uint       `json:"started_by"`                           // 发起抓取的用户ID
+	Status     string     `json:"status" gorm:"size:16;index;not null"` // 状态：running, finished, failed
+	StartedAt  time.Time  `json:"started_at"`                           // 开始时间
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"`              // 到期时间，宿主机进程在此时自动退出
+	StoppedAt  *time.Time `json:"stopped_at"`                           // 实际结束时间
+	Error      string     `json:"error,omitempty" gorm:"size:512"`      // 失败原因
+	Result     string     `json:"-" gorm:"type:text"`                   // 结束时保存的明细（JSON）
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (TrafficCapture) TableName() string {
+	return "traffic_captures"
+}
+
+// TrafficTalkerPort 按端口汇总的流量
+// Local为true表示端口在实例一侧（实例对外提供的服务），否则为实例访问的远端服务端口
+type TrafficTalkerPort struct {
+	Proto   string `json:"proto"`
+	Port    int    `json:"port"`
+	Local   bool   `json:"local"`
+	RxBytes int64  `json:"rx_bytes"`
+	TxBytes int64  `json:"tx_bytes"`
+	Packets int64  `json:"packets"`
+	Peers   int    `json:"peers"` // 涉及的对端地址数
+}
+
+// TrafficTalkerPeer 按对端地址汇总的流量
+type TrafficTalkerPeer struct {
+	Host    string `json:"host"`
+	RxBytes int64  `json:"rx_bytes"`
+	TxBytes int64  `json:"tx_bytes"`
+	Packets int64  `json:"packets"`
+}
+
+// TrafficCaptureBreakdown 流量明细汇总，端口和对端均按总字节数降序排列
+type TrafficCaptureBreakdown struct {
+	RxBytes   int64               `json:"rx_bytes"`
+	TxBytes   int64               `json:"tx_bytes"`
+	Ports     []TrafficTalkerPort `json:"ports"`
+	Peers     []TrafficTalkerPeer `json:"peers"`
+	SampledAt time.Time           `json:"sampled_at"` // 数据读取时间
+}
+
+// TrafficCaptureDetail 抓取记录及其明细
+type TrafficCaptureDetail struct {
+	TrafficCapture
+	Breakdown *TrafficCaptureBreakdown `json:"breakdown"`
+}
+
+// StartTrafficCaptureRequest 开始抓取请求
+type StartTrafficCaptureRequest struct {
+	Minutes int `json:"minutes"` // 抓取时长（分钟），默认10分钟，最大60分钟
+}
@@ -0,0 +1,17 @@
+package permission
+
+import "time"
+
+// AdminProviderScope 子管理员可管理的Provider
+// 管理员存在任意一条记录即为子管理员，只能管理这些Provider上的实例、端口映射和任务，其他管理接口不可访问
+type AdminProviderScope struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UserID     uint      `json:"userId" gorm:"not null;uniqueIndex:idx_admin_provider_scope,priority:1"`
+	ProviderID uint      `json:"providerId" gorm:"not null;uniqueIndex:idx_admin_provider_scope,priority:2;index"`
+}
+
+// TableName 指定表名
+func (AdminProviderScope) TableName() string {
+	return "admin_provider_scopes"
+}
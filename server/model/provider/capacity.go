@@ -0,0 +1,79 @@
+package provider
+
+// ReservedCPUCores 按预留百分比计算预留的CPU核心数（向上取整）
+func (p *Provider) ReservedCPUCores() int {
+	if p.SystemReservedCPUPercent <= 0 || p.NodeCPUCores <= 0 {
+		return 0
+	}
+	return (p.NodeCPUCores*p.SystemReservedCPUPercent + 99) / 100
+}
+
+// ReservedDisk 返回预留的磁盘空间（MB）
+func (p *Provider) ReservedDisk() int64 {
+	if p.SystemReservedDiskGB <= 0 {
+		return 0
+	}
+	return int64(p.SystemReservedDiskGB) * 1024
+}
+
+// AllocatableCPUCores 可分配给实例的CPU核心数（节点总量 - 系统预留）
+func (p *Provider) AllocatableCPUCores() int {
+	return nonNegativeInt(p.NodeCPUCores - p.ReservedCPUCores())
+}
+
+// AllocatableMemory 可分配给实例的内存大小（MB）
+func (p *Provider) AllocatableMemory() int64 {
+	reserved := p.SystemReservedMemory
+	if reserved < 0 {
+		reserved = 0
+	}
+	return nonNegativeInt64(p.NodeMemoryTotal - reserved)
+}
+
+// AllocatableDisk 可分配给实例的磁盘空间（MB）
+func (p *Provider) AllocatableDisk() int64 {
+	return nonNegativeInt64(p.NodeDiskTotal - p.ReservedDisk())
+}
+
+func nonNegativeInt(v int) int {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+func nonNegativeInt64(v int64) int64 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// CPUOvercommitRatio 返回指定实例类型的CPU超售比例，未配置或小于1时按1处理
+func (p *Provider) CPUOvercommitRatio(instanceType string) float64 {
+	if instanceType == "vm" {
+		return normalizeOvercommit(p.VMCPUOvercommit)
+	}
+	return normalizeOvercommit(p.ContainerCPUOvercommit)
+}
+
+// MemoryOvercommitRatio 返回指定实例类型的内存超售比例，未配置或小于1时按1处理
+func (p *Provider) MemoryOvercommitRatio(instanceType string) float64 {
+	if instanceType == "vm" {
+		return normalizeOvercommit(p.VMMemoryOvercommit)
+	}
+	return normalizeOvercommit(p.ContainerMemoryOvercommit)
+}
+
+// HasOvercommit 是否为任一实例类型配置了超售
+func (p *Provider) HasOvercommit() bool {
+	return p.CPUOvercommitRatio("vm") > 1 || p.CPUOvercommitRatio("container") > 1 ||
+		p.MemoryOvercommitRatio("vm") > 1 || p.MemoryOvercommitRatio("container") > 1
+}
+
+func normalizeOvercommit(ratio float64) float64 {
+	if ratio < 1 {
+		return 1
+	}
+	return ratio
+}
@@ -0,0 +1,90 @@
+package provider
+
+import "testing"
+
+func TestSystemReservation(t *testing.T) {
+	tests := []struct {
+		name              string
+		provider          Provider
+		reservedCPU       int
+		allocatableCPU    int
+		allocatableMemory int64
+		reservedDisk      int64
+		allocatableDisk   int64
+	}{
+		{
+			name:        "未配置预留",
+			provider:    Provider{NodeCPUCores: 4, NodeMemoryTotal: 8192, NodeDiskTotal: 102400},
+			reservedCPU: 0, allocatableCPU: 4, allocatableMemory: 8192, reservedDisk: 0, allocatableDisk: 102400,
+		},
+		{
+			name: "CPU预留向上取整",
+			provider: Provider{NodeCPUCores: 6, SystemReservedCPUPercent: 10, NodeMemoryTotal: 8192, SystemReservedMemory: 1024,
+				NodeDiskTotal: 102400, SystemReservedDiskGB: 20},
+			reservedCPU: 1, allocatableCPU: 5, allocatableMemory: 7168, reservedDisk: 20480, allocatableDisk: 81920,
+		},
+		{
+			name:        "整除不进位",
+			provider:    Provider{NodeCPUCores: 8, SystemReservedCPUPercent: 25},
+			reservedCPU: 2, allocatableCPU: 6,
+		},
+		{
+			name: "预留超过总量时可分配为0",
+			provider: Provider{NodeCPUCores: 2, SystemReservedCPUPercent: 150, NodeMemoryTotal: 512, SystemReservedMemory: 1024,
+				NodeDiskTotal: 1024, SystemReservedDiskGB: 2},
+			reservedCPU: 3, allocatableCPU: 0, allocatableMemory: 0, reservedDisk: 2048, allocatableDisk: 0,
+		},
+		{
+			name:        "负数预留按0处理",
+			provider:    Provider{NodeCPUCores: 4, SystemReservedCPUPercent: -10, NodeMemoryTotal: 4096, SystemReservedMemory: -1, NodeDiskTotal: 100, SystemReservedDiskGB: -1},
+			reservedCPU: 0, allocatableCPU: 4, allocatableMemory: 4096, reservedDisk: 0, allocatableDisk: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.provider
+			if got := p.ReservedCPUCores(); got != tt.reservedCPU {
+				t.Errorf("ReservedCPUCores = %d, want %d", got, tt.reservedCPU)
+			}
+			if got := p.AllocatableCPUCores(); got != tt.allocatableCPU {
+				t.Errorf("AllocatableCPUCores = %d, want %d", got, tt.allocatableCPU)
+			}
+			if got := p.AllocatableMemory(); got != tt.allocatableMemory {
+				t.Errorf("AllocatableMemory = %d, want %d", got, tt.allocatableMemory)
+			}
+			if got := p.ReservedDisk(); got != tt.reservedDisk {
+				t.Errorf("ReservedDisk = %d, want %d", got, tt.reservedDisk)
+			}
+			if got := p.AllocatableDisk(); got != tt.allocatableDisk {
+				t.Errorf("AllocatableDisk = %d, want %d", got, tt.allocatableDisk)
+			}
+		})
+	}
+}
+
+func TestOvercommitRatio(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      Provider
+		containerCPU  float64
+		vmMemory      float64
+		hasOvercommit bool
+	}{
+		{name: "未配置按1处理", provider: Provider{}, containerCPU: 1, vmMemory: 1},
+		{name: "小于1按1处理", provider: Provider{ContainerCPUOvercommit: 0.5, VMMemoryOvercommit: -2}, containerCPU: 1, vmMemory: 1},
+		{name: "容器CPU超售", provider: Provider{ContainerCPUOvercommit: 4}, containerCPU: 4, vmMemory: 1, hasOvercommit: true},
+		{name: "虚拟机内存超售", provider: Provider{VMMemoryOvercommit: 1.5}, containerCPU: 1, vmMemory: 1.5, hasOvercommit: true},
+	}
+	for _, tt := range tests {
+		p := tt.provider
+		if got := p.CPUOvercommitRatio("container"); got != tt.containerCPU {
+			t.Errorf("%s: CPUOvercommitRatio(container) = %v, want %v", tt.name, got, tt.containerCPU)
+		}
+		if got := p.MemoryOvercommitRatio("vm"); got != tt.vmMemory {
+			t.Errorf("%s: MemoryOvercommitRatio(vm) = %v, want %v", tt.name, got, tt.vmMemory)
+		}
+		if got := p.HasOvercommit(); got != tt.hasOvercommit {
+			t.Errorf("%s: HasOvercommit = %v, want %v", tt.name, got, tt.hasOvercommit)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import "time"
+
+// 实例健康检查类型
+const (
+	HealthCheckTypeTCP  = "tcp"
+	HealthCheckTypeHTTP = "http"
+)
+
+// 实例健康检查重启策略
+const (
+	RestartPolicyNone      = "none"       // 仅记录不重启
+	RestartPolicyOnFailure = "on-failure" // 连续失败达到阈值后自动重启
+)
+
+// 实例健康状态
+const (
+	HealthStatusUnknown   = "unknown"
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+	HealthStatusFlapping  = "flapping" // 重启过于频繁，暂停自动重启
+)
+
+// 健康检查事件类型
+const (
+	HealthEventUnhealthy = "unhealthy"
+	HealthEventRecovered = "recovered"
+	HealthEventRestarted = "restarted"
+	HealthEventFlapping  = "flapping"
+)
+
+// InstanceHealthCheck 实例健康检查配置及运行状态
+// 通过实例的端口映射探测TCP端口或HTTP地址，连续失败达到阈值后按重启策略创建重启任务
+type InstanceHealthCheck struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	InstanceID uint `json:"instanceId" gorm:"uniqueIndex;not null"` // 实例ID
+	UserID     uint `json:"userId" gorm:"index;not null"`           // 所属用户ID
+	Enabled    bool `json:"enabled" gorm:"default:true;index"`      // 是否启用
+
+	// 探测配置
+	CheckType        string `json:"checkType" gorm:"size:8;default:tcp"` // 检查类型：tcp, http
+	GuestPort        int    `json:"guestPort" gorm:"not null"`           // 实例内端口，通过端口映射转换为外部访问端口
+	HTTPPath         string `json:"httpPath" gorm:"size:255"`            // HTTP检查路径，如 /health
+	ExpectStatus     int    `json:"expectStatus" gorm:"default:0"`       // 期望的HTTP状态码，0表示2xx/3xx均视为健康
+	IntervalSeconds  int    `json:"intervalSeconds" gorm:"default:60"`   // 检查间隔（秒）
+	TimeoutSeconds   int    `json:"timeoutSeconds" gorm:"default:5"`     // 单次探测超时（秒）
+	FailureThreshold int    `json:"failureThreshold" gorm:"default:3"`   // 连续失败多少次判定为不健康
+
+	// 重启策略与防抖
+	RestartPolicy        string `json:"restartPolicy" gorm:"size:16;default:none"` // 重启策略：none, on-failure
+	MaxRestarts          int    `json:"maxRestarts" gorm:"default:3"`              // 时间窗口内最多自动重启次数，超过后暂停自动重启
+	RestartWindowMinutes int    `json:"restartWindowMinutes" gorm:"default:60"`    // 防抖时间窗口（分钟）
+
+	// 运行状态
+	Status              string     `json:"status" gorm:"size:16;default:unknown"` // 健康状态：unknown, healthy, unhealthy, flapping
+	ConsecutiveFailures int        `json:"consecutiveFailures" gorm:"default:0"`  // 连续失败次数
+	LastError           string     `json:"lastError" gorm:"size:255"`             // 最近一次失败原因
+	LastCheckedAt       *time.Time `json:"lastCheckedAt"`                         // 最近检查时间
+	LastRestartAt       *time.Time `json:"lastRestartAt"`                         // 最近自动重启时间
+	WindowRestarts      int        `json:"windowRestarts" gorm:"default:0"`       // 当前时间窗口内的自动重启次数
+	WindowStartedAt     *time.Time `json:"windowStartedAt"`                       // 当前时间窗口开始时间
+}
+
+func (InstanceHealthCheck) TableName() string {
+	return "instance_health_checks"
+}
+
+// InstanceHealthEvent 实例健康检查事件，作为站内通知展示给用户
+type InstanceHealthEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+
+	InstanceID uint   `json:"instanceId" gorm:"index;not null"` // 实例ID
+	UserID     uint   `json:"userId" gorm:"index;not null"`     // 所属用户ID
+	Event      string `json:"event" gorm:"size:16"`             // 事件类型：unhealthy, recovered, restarted, flapping
+	Message    string `json:"message" gorm:"size:512"`          // 事件描述
+}
+
+func (InstanceHealthEvent) TableName() string {
+	return "instance_health_events"
+}
@@ -0,0 +1,19 @@
+package provider
+
+import "time"
+
+// ProviderPortRange Provider可分配端口段
+// NAT节点上用于按实例批量分配连续端口块，端口段必须位于Provider的端口映射范围之内
+type ProviderPortRange struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	ProviderID  uint      `json:"providerId" gorm:"not null;index:idx_provider_port_range"` // 关联的Provider ID
+	StartPort   int       `json:"startPort" gorm:"not null"`                                // 起始端口
+	EndPort     int       `json:"endPort" gorm:"not null"`                                  // 结束端口（包含）
+	Description string    `json:"description" gorm:"size:255"`                              // 端口段用途说明
+}
+
+func (ProviderPortRange) TableName() string {
+	return "provider_port_ranges"
+}
@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"encoding/json"
+	"net/netip"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -84,24 +87,25 @@ type Provider struct {
 
 	// 基本信息
 	// name已有uniqueIndex，type添加索引
-	Name     string `json:"name" gorm:"uniqueIndex;not null;size:64"`    // Provider名称（唯一）
-	Type     string `json:"type" gorm:"not null;size:32;index:idx_type"` // Provider类型：docker, lxd, incus, proxmox
-	Endpoint string `json:"endpoint" gorm:"size:255"`                    // SSH连接端点地址
-	PortIP   string `json:"portIP" gorm:"size:255"`                      // 端口映射使用的公网IP（非必填，若为空则使用Endpoint）
-	SSHPort  int    `json:"sshPort" gorm:"default:22"`                   // SSH连接端口
-	Username string `json:"username" gorm:"size:128"`                    // SSH连接用户名
-	Password string `json:"-" gorm:"size:255"`                           // SSH连接密码（不返回给前端）
-	SSHKey   string `json:"-" gorm:"type:text"`                          // SSH私钥（不返回给前端，优先于密码使用）
-	Token    string `json:"-" gorm:"size:255"`                           // API访问令牌（不返回给前端）
-	Config   string `json:"config" gorm:"type:text"`                     // 额外配置信息（JSON格式）
+	Name          string `json:"name" gorm:"uniqueIndex;not null;size:64"`    // Provider名称（唯一）
+	Type          string `json:"type" gorm:"not null;size:32;index:idx_type"` // Provider类型：docker, lxd, incus, proxmox
+	Endpoint      string `json:"endpoint" gorm:"size:255"`                    // SSH连接端点地址
+	PortIP        string `json:"portIP" gorm:"size:255"`                      // 端口映射使用的公网IP（非必填，若为空则使用Endpoint）
+	SSHPort       int    `json:"sshPort" gorm:"default:22"`                   // SSH连接端口
+	Username      string `json:"username" gorm:"size:128"`                    // SSH连接用户名
+	Password      string `json:"-" gorm:"size:255"`                           // SSH连接密码（不返回给前端）
+	SSHKey        string `json:"-" gorm:"type:text"`                          // SSH私钥（不返回给前端，优先于密码使用）
+	PendingSSHKey string `json:"-" gorm:"type:text"`                          // 面板生成、等待安装到宿主机的SSH私钥，启用后替换SSHKey
+	Token         string `json:"-" gorm:"size:255"`                           // API访问令牌（不返回给前端）
+	Config        string `json:"config" gorm:"type:text"`                     // 额外配置信息（JSON格式）
 
 	// 状态和地理信息
-	Status      string `json:"status" gorm:"default:active;size:16;index:idx_status"` // Provider状态：active, inactive
-	Region      string `json:"region" gorm:"size:64;index:idx_region"`                // 地区
-	Country     string `json:"country" gorm:"size:64"`                                // 国家
-	CountryCode string `json:"countryCode" gorm:"size:8"`                             // 国家代码
-	City        string `json:"city" gorm:"size:64"`                                   // 城市（可选）
-	Version     string `json:"version" gorm:"size:32;default:''"`                     // 虚拟化平台版本（如Proxmox版本）
+	Status      string `json:"status" gorm:"default:active;size:16;index:idx_providers_status"` // Provider状态：active, inactive
+	Region      string `json:"region" gorm:"size:64;index:idx_region"`                          // 地区
+	Country     string `json:"country" gorm:"size:64"`                                          // 国家
+	CountryCode string `json:"countryCode" gorm:"size:8"`                                       // 国家代码
+	City        string `json:"city" gorm:"size:64"`                                             // 城市（可选）
+	Version     string `json:"version" gorm:"size:32;default:''"`                               // 虚拟化平台版本（如Proxmox版本）
 
 	// 功能支持
 	ContainerEnabled      bool   `json:"container_enabled" gorm:"default:true"` // 是否支持容器实例
@@ -114,14 +118,14 @@ type Provider struct {
 	IPv6PortMappingMethod string `json:"ipv6PortMappingMethod" gorm:"size:16;default:device_proxy"` // IPv6端口映射方式：device_proxy, iptables, native
 
 	// 配额管理
-	UsedQuota      int        `json:"usedQuota" gorm:"default:0"`                     // 已使用配额（传统字段，兼容性保留）
-	TotalQuota     int        `json:"totalQuota" gorm:"default:0"`                    // 总配额（传统字段，兼容性保留）
-	Architecture   string     `json:"architecture" gorm:"size:16;default:amd64"`      // CPU架构：amd64, arm64, s390x等
-	ExpiresAt      *time.Time `json:"expiresAt" gorm:"index;column:expires_at"`       // Provider过期时间
-	IsFrozen       bool       `json:"isFrozen" gorm:"default:false;index:idx_frozen"` // 是否被冻结（冻结后无法使用，除了删除操作）
-	IsManualExpiry bool       `json:"isManualExpiry" gorm:"default:false"`            // 是否手动设置了过期时间
-	FrozenReason   string     `json:"frozenReason" gorm:"size:255"`                   // 冻结原因
-	FrozenAt       *time.Time `json:"frozenAt"`                                       // 冻结时间
+	UsedQuota      int        `json:"usedQuota" gorm:"default:0"`                               // 已使用配额（传统字段，兼容性保留）
+	TotalQuota     int        `json:"totalQuota" gorm:"default:0"`                              // 总配额（传统字段，兼容性保留）
+	Architecture   string     `json:"architecture" gorm:"size:16;default:amd64"`                // CPU架构：amd64, arm64, s390x等
+	ExpiresAt      *time.Time `json:"expiresAt" gorm:"index;column:expires_at"`                 // Provider过期时间
+	IsFrozen       bool       `json:"isFrozen" gorm:"default:false;index:idx_providers_frozen"` // 是否被冻结（冻结后无法使用，除了删除操作）
+	IsManualExpiry bool       `json:"isManualExpiry" gorm:"default:false"`                      // 是否手动设置了过期时间
+	FrozenReason   string     `json:"frozenReason" gorm:"size:255"`                             // 冻结原因
+	FrozenAt       *time.Time `json:"frozenAt"`                                                 // 冻结时间
 
 	// 存储配置（所有Provider类型通用）
 	StoragePool     string `json:"storagePool" gorm:"size:64;default:local"`   // 存储池名称，用于存储虚拟机磁盘和容器
@@ -152,6 +156,40 @@ type Provider struct {
 	NodeMemoryTotal int64 `json:"nodeMemoryTotal" gorm:"default:0"` // 节点总内存大小（MB）
 	NodeDiskTotal   int64 `json:"nodeDiskTotal" gorm:"default:0"`   // 节点总磁盘空间（MB）
 
+	// 系统预留资源（宿主机自身开销），从可分配资源池中扣除
+	SystemReservedCPUPercent int        `json:"systemReservedCpuPercent" gorm:"default:0"` // 预留CPU百分比（0-90）
+	SystemReservedMemory     int64      `json:"systemReservedMemory" gorm:"default:0"`     // 预留内存大小（MB）
+	SystemReservedDiskGB     int        `json:"systemReservedDiskGB" gorm:"default:0"`     // 预留磁盘空间（GB）
+	SystemReservedAlert      string     `json:"systemReservedAlert" gorm:"size:255"`       // 宿主机实际占用侵占预留资源的告警信息，为空表示正常
+	SystemReservedAlertAt    *time.Time `json:"systemReservedAlertAt"`                     // 告警产生时间
+
+	// 连接健康检查
+	HealthCheckInterval int `json:"healthCheckInterval" gorm:"default:0"` // 健康检查间隔（秒），0表示使用全局配置
+
+	// 宿主机硬件健康检查（通过SSH执行）
+	HardwareChecks        string     `json:"hardwareChecks" gorm:"size:64"`          // 启用的检查项，逗号分隔：smart, raid, sensors, zpool，为空表示不检查
+	HardwareTempThreshold int        `json:"hardwareTempThreshold" gorm:"default:0"` // 温度告警阈值（℃），0表示使用全局配置
+	HardwareAlert         string     `json:"hardwareAlert" gorm:"size:1024"`         // 硬件告警信息，为空表示正常
+	HardwareAlertAt       *time.Time `json:"hardwareAlertAt"`                        // 告警产生时间
+	HardwareCheckedAt     *time.Time `json:"hardwareCheckedAt"`                      // 最后一次硬件检查时间
+
+	// 宿主机日志事件采集（通过SSH增量读取）
+	HostEventSources    string     `json:"hostEventSources" gorm:"size:32"` // 采集的日志，逗号分隔：kernel, daemon，为空表示不采集
+	HostEventsCheckedAt *time.Time `json:"hostEventsCheckedAt"`             // 最后一次采集时间，下次从该时间之后读取
+
+	// 宿主机内核与cgroup兼容性（连接时检测）
+	HostKernelVersion   string     `json:"hostKernelVersion" gorm:"size:64"`  // 内核版本
+	HostCgroupVersion   string     `json:"hostCgroupVersion" gorm:"size:8"`   // cgroup模式：v1, v2, hybrid
+	HostCompatProbe     string     `json:"-" gorm:"type:text"`                // 探测结果（JSON），用于校验功能开关
+	HostCompatIssues    string     `json:"hostCompatIssues" gorm:"size:1024"` // 已启用功能的不兼容项，为空表示兼容
+	HostCompatCheckedAt *time.Time `json:"hostCompatCheckedAt"`               // 最后一次检测时间
+
+	// 超售比例（按实例类型区分，1表示不超售），仅对计入总量预算的CPU和内存生效
+	ContainerCPUOvercommit    float64 `json:"containerCpuOvercommit" gorm:"default:1"`    // 容器CPU超售比例
+	ContainerMemoryOvercommit float64 `json:"containerMemoryOvercommit" gorm:"default:1"` // 容器内存超售比例
+	VMCPUOvercommit           float64 `json:"vmCpuOvercommit" gorm:"default:1"`           // 虚拟机CPU超售比例
+	VMMemoryOvercommit        float64 `json:"vmMemoryOvercommit" gorm:"default:1"`        // 虚拟机内存超售比例
+
 	// 并发控制配置
 	AllowConcurrentTasks bool `json:"allowConcurrentTasks" gorm:"default:false"` // 是否允许并发执行任务
 	MaxConcurrentTasks   int  `json:"maxConcurrentTasks" gorm:"default:1"`       // 最大并发任务数量
@@ -160,6 +198,9 @@ type Provider struct {
 	SSHConnectTimeout int `json:"sshConnectTimeout" gorm:"default:30"`  // SSH连接超时时间（秒），默认30秒
 	SSHExecuteTimeout int `json:"sshExecuteTimeout" gorm:"default:300"` // SSH命令执行超时时间（秒），默认300秒
 
+	// 实例同步发现面板记录为运行中、宿主机上已停止的实例时，按实例启动顺序自动启动（宿主机重启后恢复实例）
+	RecoverStoppedInstances bool `json:"recoverStoppedInstances" gorm:"default:false"`
+
 	// 任务调度配置
 	TaskPollInterval  int  `json:"taskPollInterval" gorm:"default:60"`    // 任务轮询间隔（秒）
 	EnableTaskPolling bool `json:"enableTaskPolling" gorm:"default:true"` // 是否启用任务轮询机制
@@ -192,6 +233,14 @@ type Provider struct {
 	NextAvailablePort int    `json:"nextAvailablePort" gorm:"default:10000"`               // 下一个可用端口
 	NetworkType       string `json:"networkType" gorm:"default:nat_ipv4;size:32;not null"` // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
 
+	// 实例内SSH服务端口（仅Linux实例，Windows实例使用RDP）
+	GuestSSHPort       int  `json:"guestSshPort" gorm:"default:22"`          // 新实例内sshd监听的端口，默认22
+	RandomGuestSSHPort bool `json:"randomGuestSshPort" gorm:"default:false"` // 为每个新实例随机生成非22的实例内SSH端口，优先于GuestSSHPort
+
+	// 宿主机网卡配置
+	PublicInterface string `json:"publicInterface" gorm:"size:32"` // 公网网卡，端口映射的DNAT规则只匹配从该网卡进入的流量，为空时不限定网卡（Proxmox为vmbr0）
+	Uplinks         string `json:"uplinks" gorm:"type:text"`       // 附加上行网卡及其承载的独立IP段，JSON格式: []ProviderUplink
+
 	// 带宽配置（Mbps为单位）
 	DefaultInboundBandwidth  int `json:"defaultInboundBandwidth" gorm:"default:300"`  // 默认入站带宽限制（Mbps）
 	DefaultOutboundBandwidth int `json:"defaultOutboundBandwidth" gorm:"default:300"` // 默认出站带宽限制（Mbps）
@@ -205,6 +254,8 @@ type Provider struct {
 	TrafficResetAt       *time.Time `json:"trafficResetAt"`                               // 流量重置时间
 	TrafficCountMode     string     `json:"trafficCountMode" gorm:"default:both;size:16"` // 流量统计模式：both(双向), out(仅出向), in(仅入向)
 	TrafficMultiplier    float64    `json:"trafficMultiplier" gorm:"default:1.0"`         // 流量计费倍率（例如：入向0.5倍，出向1倍）
+	// 分时段流量计费倍率，时段内产生的流量按时段倍率计费，其余时间使用 TrafficMultiplier
+	TrafficMultiplierWindows string `json:"trafficMultiplierWindows" gorm:"type:text"` // JSON格式: []TrafficMultiplierWindow
 
 	// 流量统计性能配置
 	TrafficStatsMode           string `json:"trafficStatsMode" gorm:"default:light;size:16"`                               // 流量统计性能模式：high(高性能), standard(标准), light(轻量), minimal(最小), custom(自定义)
@@ -235,6 +286,22 @@ type Provider struct {
 	// 该字段会与用户全局等级限制进行比较，取两者的最小值作为实际限制
 	LevelLimits string `json:"levelLimits" gorm:"type:text"` // JSON格式: map[int]config.LevelLimitInfo
 
+	// 镜像源改写规则，下载镜像时优先于CDN生效，用于不同地区的节点使用就近镜像站
+	ImageMirrors string `json:"imageMirrors" gorm:"type:text"` // JSON格式: []ImageMirrorRule
+	// 上次下载镜像成功的来源（镜像源前缀、CDN端点或origin），下次下载时优先尝试
+	PreferredImageSource string `json:"preferredImageSource" gorm:"size:512"`
+
+	// 维护窗口，窗口内推迟流量超限停机、到期删除和健康检查自动重启，与全局窗口同时生效
+	BlackoutWindows string `json:"blackoutWindows" gorm:"type:text"` // JSON格式: []string，如 ["mon-fri 19:00-23:00"]
+
+	// 时区，应与宿主机时钟一致，用于流量记录按小时/天/月分桶、流量重置时间、维护窗口和分时段计费倍率，为空时使用系统默认时区
+	Timezone string `json:"timezone" gorm:"size:64"`
+
+	// SSH命令白名单，校验发往宿主机的命令只调用预期的程序，防止拼接的用户输入注入命令
+	CommandGuardMode string `json:"commandGuardMode" gorm:"size:8;default:''"` // 检查模式：空(关闭), log(仅记录), block(拦截)
+	CommandAllowlist string `json:"commandAllowlist" gorm:"type:text"`         // JSON格式: []string，在内置白名单基础上追加允许的程序
+	CommandDenylist  string `json:"commandDenylist" gorm:"type:text"`          // JSON格式: []string，始终拒绝的程序，优先于白名单
+
 	// 节点标识信息（用于区分多个hostname相同的节点）
 	HostName string `json:"hostName" gorm:"size:128"` // 节点主机名（hostname），由健康检查自动更新
 
@@ -246,6 +313,10 @@ type Provider struct {
 	ContainerMemorySwap   bool   `json:"containerMemorySwap" gorm:"default:true"`           // 内存交换：允许使用swap空间
 	ContainerMaxProcesses int    `json:"containerMaxProcesses" gorm:"default:0"`            // 最大进程数：0表示不限制
 	ContainerDiskIOLimit  string `json:"containerDiskIoLimit" gorm:"size:32"`               // 磁盘IO限制：例如 "10MB" 或 "100iops"
+
+	// 项目与配置文件（仅适用于 LXD 和 Incus），用于在共享宿主机上隔离本系统管理的实例
+	Project  string `json:"project" gorm:"size:64"`   // 实例所在的项目，为空时使用default项目
+	Profiles string `json:"profiles" gorm:"size:255"` // 创建实例时应用的配置文件，逗号分隔，为空时使用default
 }
 
 func (p *Provider) BeforeCreate(tx *gorm.DB) error {
@@ -304,6 +375,118 @@ func (p *Provider) GetAuthMethod() string {
 	return "password"
 }
 
+// ImageMirrorRule 镜像下载地址改写规则
+// 原始地址以 Prefix 开头时，依次尝试将前缀替换为 Mirrors 中的地址，使用第一个可访问的镜像
+type ImageMirrorRule struct {
+	Prefix  string   `json:"prefix"`  // 原始地址前缀，如 https://github.com/oneclickvirt/
+	Mirrors []string `json:"mirrors"` // 替换后的前缀列表，按顺序尝试
+}
+
+// GetImageMirrors 解析镜像源改写规则，未配置时返回空
+func (p *Provider) GetImageMirrors() ([]ImageMirrorRule, error) {
+	if p.ImageMirrors == "" {
+		return nil, nil
+	}
+	var rules []ImageMirrorRule
+	if err := json.Unmarshal([]byte(p.ImageMirrors), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ProviderUplink 附加上行网卡，独立IP段经由主公网网卡以外的网卡接入时配置
+type ProviderUplink struct {
+	Interface   string `json:"interface"`   // 网卡名称
+	IPv4Pool    string `json:"ipv4Pool"`    // 经由该网卡的独立IPv4段（CIDR），如 "203.0.113.0/27"
+	Description string `json:"description"` // 备注
+}
+
+// GetUplinks 返回附加上行网卡，未配置或格式错误时返回空
+func (p *Provider) GetUplinks() []ProviderUplink {
+	if p.Uplinks == "" {
+		return nil
+	}
+	var uplinks []ProviderUplink
+	if err := json.Unmarshal([]byte(p.Uplinks), &uplinks); err != nil {
+		return nil
+	}
+	return uplinks
+}
+
+// InterfaceForIP 返回承载指定公网IP的网卡：命中附加上行网卡的IP段时返回该网卡，否则返回公网网卡（可能为空）
+func (p *Provider) InterfaceForIP(ip string) string {
+	if addr, err := netip.ParseAddr(strings.TrimSpace(ip)); err == nil {
+		for _, uplink := range p.GetUplinks() {
+			if prefix, err := netip.ParsePrefix(uplink.IPv4Pool); err == nil && prefix.Contains(addr.Unmap()) {
+				return uplink.Interface
+			}
+		}
+	}
+	return p.PublicInterface
+}
+
+// TrafficMultiplierWindow 分时段流量计费倍率，多个时段重叠时使用第一个匹配的时段
+type TrafficMultiplierWindow struct {
+	Window     string  `json:"window"`     // 时段，格式与维护窗口相同，如 "01:00-07:00"、"sat,sun 00:00-24:00"
+	Multiplier float64 `json:"multiplier"` // 时段内的计费倍率，如 0.5 表示闲时流量按一半计费
+}
+
+// GetTrafficMultiplierWindows 返回分时段流量计费倍率，未配置或格式错误时返回空
+func (p *Provider) GetTrafficMultiplierWindows() []TrafficMultiplierWindow {
+	if p.TrafficMultiplierWindows == "" {
+		return nil
+	}
+	var windows []TrafficMultiplierWindow
+	if err := json.Unmarshal([]byte(p.TrafficMultiplierWindows), &windows); err != nil {
+		return nil
+	}
+	return windows
+}
+
+// GetBlackoutWindows 返回配置的维护窗口，未配置或格式错误时返回空
+func (p *Provider) GetBlackoutWindows() []string {
+	if p.BlackoutWindows == "" {
+		return nil
+	}
+	var windows []string
+	if err := json.Unmarshal([]byte(p.BlackoutWindows), &windows); err != nil {
+		return nil
+	}
+	return windows
+}
+
+// GetCommandAllowlist 返回追加允许的程序，未配置或格式错误时返回空
+func (p *Provider) GetCommandAllowlist() []string {
+	return decodeStringList(p.CommandAllowlist)
+}
+
+// GetCommandDenylist 返回始终拒绝的程序，未配置或格式错误时返回空
+func (p *Provider) GetCommandDenylist() []string {
+	return decodeStringList(p.CommandDenylist)
+}
+
+func decodeStringList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return nil
+	}
+	return list
+}
+
+// GetProfiles 返回配置的配置文件列表，未配置时返回空
+func (p *Provider) GetProfiles() []string {
+	var profiles []string
+	for _, name := range strings.Split(p.Profiles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
 // Instance 实例模型
 type Instance struct {
 	// 基础字段
@@ -320,6 +503,7 @@ type Instance struct {
 	ProviderID   uint   `json:"providerId" gorm:"uniqueIndex:idx_instance_name_provider,priority:2;index:idx_provider_id;index:idx_provider_status,priority:1;not null"` // 关联的Provider ID（与name组合唯一）
 	Status       string `json:"status" gorm:"size:32;index:idx_status;index:idx_provider_status,priority:2"`                                                             // 实例状态：creating, running, stopped, failed等
 	Image        string `json:"image" gorm:"size:128"`                                                                                                                   // 使用的镜像名称
+	ImageID      uint   `json:"imageId" gorm:"default:0;index"`                                                                                                          // 使用的系统镜像ID，0表示未知（旧实例按镜像名称匹配）
 	InstanceType string `json:"instance_type" gorm:"size:16;default:container;index:idx_instance_type"`                                                                  // 实例类型：container, vm
 
 	// 资源配置
@@ -329,22 +513,25 @@ type Instance struct {
 	Bandwidth int   `json:"bandwidth" gorm:"default:10"` // 网络带宽（Mbps）
 
 	// 网络配置
-	Network        string `json:"network" gorm:"size:64"`      // 网络名称或配置
-	PrivateIP      string `json:"privateIP" gorm:"size:64"`    // 内网/私有IPv4地址
-	PublicIP       string `json:"publicIP" gorm:"size:64"`     // 公网IPv4地址
-	IPv6Address    string `json:"ipv6Address" gorm:"size:128"` // 内网IPv6地址
-	PublicIPv6     string `json:"publicIPv6" gorm:"size:128"`  // 公网IPv6地址
-	SSHPort        int    `json:"sshPort" gorm:"default:22"`   // SSH访问端口
-	PortRangeStart int    `json:"portRangeStart"`              // 端口映射范围起始
-	PortRangeEnd   int    `json:"portRangeEnd"`                // 端口映射范围结束
+	Network        string `json:"network" gorm:"size:64"`         // 网络名称或配置
+	PrivateIP      string `json:"privateIP" gorm:"size:64"`       // 内网/私有IPv4地址
+	PublicIP       string `json:"publicIP" gorm:"size:64"`        // 公网IPv4地址
+	IPv6Address    string `json:"ipv6Address" gorm:"size:128"`    // 内网IPv6地址
+	PublicIPv6     string `json:"publicIPv6" gorm:"size:128"`     // 公网IPv6地址
+	SSHPort        int    `json:"sshPort" gorm:"default:22"`      // SSH访问端口
+	GuestSSHPort   int    `json:"guestSshPort" gorm:"default:22"` // 实例内sshd监听的端口，创建时由Provider配置决定
+	PortRangeStart int    `json:"portRangeStart"`                 // 端口映射范围起始
+	PortRangeEnd   int    `json:"portRangeEnd"`                   // 端口映射范围结束
 
 	// 访问凭据
 	Username string `json:"username" gorm:"size:64"`  // 登录用户名
 	Password string `json:"password" gorm:"size:128"` // 登录密码
 
 	// 系统信息
-	OSType string `json:"osType" gorm:"size:64"` // 操作系统类型：ubuntu, centos, debian等
-	Region string `json:"region" gorm:"size:64"` // 所在地区
+	OSType          string     `json:"osType" gorm:"size:64"`  // 操作系统类型：ubuntu, centos, debian等
+	Region          string     `json:"region" gorm:"size:64"`  // 所在地区
+	OSEOLDate       *time.Time `json:"osEolDate" gorm:"index"` // 所用镜像的操作系统EOL日期（定时从系统镜像同步）
+	OSEOLNotifiedAt *time.Time `json:"-"`                      // 已通知所有者EOL的时间，EOL日期变化时清空
 
 	// 流量统计（实例层面）
 	MaxTraffic         int64  `json:"maxTraffic" gorm:"default:0"`                  // 实例流量限制（MB），0表示不限制，从用户等级继承
@@ -357,12 +544,14 @@ type Instance struct {
 	ExpiresAt      *time.Time `json:"expiresAt" gorm:"index:idx_expires_at;column:expires_at"` // 实例到期时间（默认与节点同步，手动设置优先级更高）
 	IsFrozen       bool       `json:"isFrozen" gorm:"default:false;index:idx_frozen"`          // 是否被冻结（冻结后无法操作，除了删除）
 	IsManualExpiry bool       `json:"isManualExpiry" gorm:"default:false"`                     // 是否手动设置了过期时间（手动设置的优先级高于节点）
-	FrozenReason   string     `json:"frozenReason" gorm:"size:255"`                            // 冻结原因：expired(到期), node_frozen(节点冻结), manual(手动冻结)
+	FrozenReason   string     `json:"frozenReason" gorm:"size:255"`                            // 冻结原因：expired(到期), node_frozen(节点冻结), manual(手动冻结), abuse:<举报ID>(滥用举报)
 	FrozenAt       *time.Time `json:"frozenAt"`                                                // 冻结时间
 
 	// 关联关系
 	// 添加UserID索引以支持按用户查询
-	UserID uint `json:"userId" gorm:"index:idx_user_id;index:idx_user_status,priority:1"` // 所属用户ID
+	UserID  uint   `json:"userId" gorm:"index:idx_user_id;index:idx_user_status,priority:1"` // 所属用户ID
+	GroupID uint   `json:"groupId" gorm:"default:0;index:idx_group_id"`                      // 所属实例分组ID，0表示未分组
+	Tags    string `json:"instanceTags" gorm:"column:tags;size:255"`                         // 实例自身的标签（逗号分隔），创建时填写或继承用户默认设置
 
 	// 实例导入相关字段
 	IsImported         bool       `json:"isImported" gorm:"default:false;index:idx_imported"` // 是否为导入的实例（从已有provider发现）
@@ -370,6 +559,25 @@ type Instance struct {
 	HasPortConflict    bool       `json:"hasPortConflict" gorm:"default:false"`               // 是否存在端口冲突
 	PortConflictDetail string     `json:"portConflictDetail" gorm:"type:text"`                // 端口冲突详情（JSON格式记录冲突端口信息）
 	DiscoveredData     string     `json:"discoveredData" gorm:"type:text"`                    // 发现时的原始数据（JSON格式，用于调试和审计）
+
+	// 创建后验证
+	VerifyStatus   string     `json:"verifyStatus" gorm:"size:16;default:''"` // 创建后验证状态：verified(通过), warning(存在警告)，空表示未验证
+	VerifyWarnings string     `json:"verifyWarnings" gorm:"type:text"`        // 验证警告详情（每行一条）
+	VerifiedAt     *time.Time `json:"verifiedAt"`                             // 验证时间
+
+	// 实例内磁盘使用量（定时采集）
+	DiskUsedMB  int64      `json:"diskUsedMB" gorm:"default:0"`  // 实例内根分区已用空间（MB）
+	DiskTotalMB int64      `json:"diskTotalMB" gorm:"default:0"` // 实例内根分区可见大小（MB）
+	DiskUsageAt *time.Time `json:"diskUsageAt"`                  // 最近一次采集时间，为空表示未采集
+
+	// 批量启动顺序（宿主机重启后按顺序恢复实例）
+	StartupOrder int `json:"startupOrder" gorm:"default:0"` // 启动顺序，数值小的先启动，相同顺序的实例可同时启动
+	StartupDelay int `json:"startupDelay" gorm:"default:0"` // 启动后等待的秒数，之后再启动下一顺序的实例，用于数据库等被依赖的实例先就绪
+
+	// 闲置自动停机
+	LastActiveAt       *time.Time `json:"lastActiveAt"`           // 最近一次检测到活动（SSH登录、流量或用户启动）的时间
+	IdleNoticeAt       *time.Time `json:"idleNoticeAt"`           // 闲置停机预告时间，为空表示未预告
+	IdleKeepAliveToken string     `json:"-" gorm:"size:64;index"` // 预告通知中保持运行链接的令牌
 }
 
 func (i *Instance) BeforeCreate(tx *gorm.DB) error {
@@ -388,7 +596,7 @@ type Port struct {
 	// 端口映射信息
 	// 为常用查询添加复合索引
 	InstanceID   uint   `json:"instanceId" gorm:"index:idx_instance_ssh,priority:1;index:idx_instance_status,priority:1"` // 关联的实例ID
-	ProviderID   uint   `json:"providerId" gorm:"index:idx_provider_id"`                                                  // 关联的Provider ID
+	ProviderID   uint   `json:"providerId" gorm:"index:idx_ports_provider_id"`                                            // 关联的Provider ID
 	HostPort     int    `json:"hostPort" gorm:"not null"`                                                                 // 宿主机端口（起始端口）
 	HostPortEnd  int    `json:"hostPortEnd" gorm:"default:0"`                                                             // 宿主机端口结束（0表示单端口）
 	GuestPort    int    `json:"guestPort" gorm:"not null"`                                                                // 容器/虚拟机内部端口（起始端口）
@@ -405,6 +613,12 @@ type Port struct {
 	IPv6Enabled   bool   `json:"ipv6Enabled" gorm:"default:false"`            // 是否启用IPv6映射
 	IPv6Address   string `json:"ipv6Address" gorm:"size:64"`                  // IPv6映射地址
 	MappingMethod string `json:"mappingMethod" gorm:"size:32;default:native"` // 映射方法：native, iptables, firewall
+
+	// 服务类型探测（用户同意后按需探测）
+	ServiceType       string     `json:"serviceType" gorm:"size:16;index"` // 探测到的服务类型：ssh, http, https, tls, unknown, unreachable 等，为空表示未探测
+	ServiceBanner     string     `json:"serviceBanner" gorm:"size:256"`    // SSH等服务的欢迎信息或网页标题
+	ServiceTLSName    string     `json:"serviceTlsName" gorm:"size:256"`   // TLS证书的通用名称
+	ServiceDetectedAt *time.Time `json:"serviceDetectedAt"`                // 最近一次探测时间
 }
 
 // PendingDeletion 待删除资源模型
@@ -473,6 +687,9 @@ type ProviderInstanceConfig struct {
 	MemorySwap   *bool   `json:"memorySwap,omitempty"`   // 内存交换
 	MaxProcesses *int    `json:"maxProcesses,omitempty"` // 最大进程数
 	DiskIOLimit  *string `json:"diskIoLimit,omitempty"`  // 磁盘IO限制
+
+	// 在Provider配置文件之后追加应用的配置文件（仅适用于 LXD 和 Incus），由 instance-defaults 规则按等级和实例类型注入
+	Profiles []string `json:"profiles,omitempty"`
 }
 
 // ProviderNodeConfig 节点配置
@@ -501,6 +718,7 @@ type ProviderNodeConfig struct {
 	SSHExecuteTimeout     int      `json:"ssh_execute_timeout"` // SSH命令执行超时时间（秒）
 	ExecutionRule         string   `json:"execution_rule"`      // 操作轮转规则：auto, api_only, ssh_only
 	NetworkType           string   `json:"networkType"`         // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
+	PublicInterface       string   `json:"public_interface"`    // 公网网卡，端口映射DNAT规则的入口网卡，为空时不限定
 
 	// 容器资源限制配置（Provider层面）
 	ContainerLimitCPU    bool `json:"containerLimitCpu"`    // 容器是否限制CPU数量，默认不限制
@@ -523,6 +741,18 @@ type ProviderNodeConfig struct {
 	ContainerMemorySwap   bool   `json:"containerMemorySwap"`   // 内存交换
 	ContainerMaxProcesses int    `json:"containerMaxProcesses"` // 最大进程数
 	ContainerDiskIOLimit  string `json:"containerDiskIoLimit"`  // 磁盘IO限制
+
+	// 镜像源改写规则（下载镜像时优先于CDN）
+	ImageMirrors []ImageMirrorRule `json:"image_mirrors"`
+
+	// LXD/Incus 项目与配置文件
+	Project  string   `json:"project"`  // 为空时使用default项目
+	Profiles []string `json:"profiles"` // 为空时使用default配置文件
+
+	// SSH命令白名单
+	CommandGuardMode string   `json:"command_guard_mode"` // 空(关闭), log, block
+	CommandAllowlist []string `json:"command_allowlist"`  // 追加允许的程序
+	CommandDenylist  []string `json:"command_denylist"`   // 始终拒绝的程序
 }
 
 // ProviderResponse 用于返回给前端的Provider响应结构
@@ -0,0 +1,17 @@
+package provider
+
+import "time"
+
+// ProviderHealthCheck Provider健康检查记录，每个Provider只保留最近若干条
+type ProviderHealthCheck struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_provider_health_check,priority:2"` // 检查时间
+
+	ProviderID uint   `json:"providerId" gorm:"index:idx_provider_health_check,priority:1;not null"` // Provider ID
+	Status     string `json:"status" gorm:"size:16"`                                                 // 检查后的整体状态：active, partial, inactive
+	SSHStatus  string `json:"sshStatus" gorm:"size:16"`                                              // SSH状态：online, offline
+	APIStatus  string `json:"apiStatus" gorm:"size:16"`                                              // API状态：online, offline, unknown
+	DurationMs int64  `json:"durationMs"`                                                            // 检查耗时（毫秒）
+	Error      string `json:"error" gorm:"size:512"`                                                 // 检查出错或超时的原因
+	NextDelay  int    `json:"nextDelay"`                                                             // 距离下次检查的间隔（秒），包含抖动和离线退避
+}
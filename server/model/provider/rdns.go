@@ -0,0 +1,20 @@
+package provider
+
+import "time"
+
+// InstanceRDNS 实例公网IP的反向解析（PTR）记录
+type InstanceRDNS struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	InstanceID uint   `json:"instanceId" gorm:"uniqueIndex:uk_instance_rdns_ip,priority:1;not null"` // 实例ID
+	UserID     uint   `json:"userId" gorm:"index;not null"`                                          // 所属用户ID
+	IP         string `json:"ip" gorm:"uniqueIndex:uk_instance_rdns_ip,priority:2;size:64;not null"` // 公网IPv4或IPv6地址
+	Hostname   string `json:"hostname" gorm:"size:255;not null"`                                     // PTR指向的主机名
+	Backend    string `json:"backend" gorm:"size:32"`                                                // 写入时使用的维护方式，删除时使用同一方式
+}
+
+func (InstanceRDNS) TableName() string {
+	return "instance_rdns"
+}
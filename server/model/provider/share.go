@@ -0,0 +1,29 @@
+package provider
+
+import "time"
+
+// InstanceShare 实例公开分享链接
+// 持有令牌的访客无需登录即可查看实例的状态、运行时长、地区和流量曲线，每个实例只保留一个有效链接，重新生成会使旧链接失效
+type InstanceShare struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	InstanceID  uint   `json:"instanceId" gorm:"uniqueIndex;not null"`    // 实例ID
+	UserID      uint   `json:"userId" gorm:"index;not null"`              // 所属用户ID
+	Token       string `json:"token" gorm:"uniqueIndex;size:64;not null"` // 分享令牌
+	ShowTraffic bool   `json:"showTraffic"`                               // 是否公开流量曲线
+
+	ExpiresAt    *time.Time `json:"expiresAt"`                  // 过期时间，为空表示长期有效
+	ViewCount    int64      `json:"viewCount" gorm:"default:0"` // 访问次数
+	LastViewedAt *time.Time `json:"lastViewedAt"`               // 最近访问时间
+}
+
+func (InstanceShare) TableName() string {
+	return "instance_shares"
+}
+
+// IsExpired 分享链接是否已过期
+func (s *InstanceShare) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && now.After(*s.ExpiresAt)
+}
@@ -0,0 +1,54 @@
+package provider
+
+import "time"
+
+// 定时快照频率
+const (
+	SnapshotFrequencyDaily  = "daily"
+	SnapshotFrequencyWeekly = "weekly"
+)
+
+// 定时快照最近一次执行结果
+const (
+	SnapshotStatusSuccess = "success"
+	SnapshotStatusFailed  = "failed"
+)
+
+// InstanceSnapshotSchedule 实例定时快照计划及运行状态
+// 调度器在快照窗口内为到期的计划创建快照，并删除超出保留数量的旧快照
+type InstanceSnapshotSchedule struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	InstanceID uint   `json:"instanceId" gorm:"uniqueIndex;not null"` // 实例ID
+	UserID     uint   `json:"userId" gorm:"index;not null"`           // 所属用户ID
+	Enabled    bool   `json:"enabled" gorm:"default:true;index"`      // 是否启用
+	Frequency  string `json:"frequency" gorm:"size:8;default:daily"`  // 频率：daily, weekly
+	Keep       int    `json:"keep" gorm:"default:1"`                  // 保留的快照数量，不超过用户等级的 max-snapshots
+
+	// 运行状态
+	LastSuccessAt       *time.Time `json:"lastSuccessAt"`                        // 最近一次成功创建快照的时间，按此计算下次执行时间
+	LastAttemptAt       *time.Time `json:"lastAttemptAt"`                        // 最近一次执行时间
+	LastStatus          string     `json:"lastStatus" gorm:"size:16"`            // 最近一次执行结果：success, failed
+	LastError           string     `json:"lastError" gorm:"size:255"`            // 最近一次失败原因
+	ConsecutiveFailures int        `json:"consecutiveFailures" gorm:"default:0"` // 连续失败次数，首次失败时通知所有者
+}
+
+func (InstanceSnapshotSchedule) TableName() string {
+	return "instance_snapshot_schedules"
+}
+
+// InstanceSnapshot 定时快照创建的快照记录，用于按保留数量清理
+type InstanceSnapshot struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+
+	InstanceID uint   `json:"instanceId" gorm:"index;not null"` // 实例ID
+	UserID     uint   `json:"userId" gorm:"index;not null"`     // 所属用户ID
+	Name       string `json:"name" gorm:"size:64;not null"`     // Provider上的快照名
+}
+
+func (InstanceSnapshot) TableName() string {
+	return "instance_snapshots"
+}
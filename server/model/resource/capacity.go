@@ -0,0 +1,124 @@
+package resource
+
+// CapacityItem 单项资源容量（CPU单位为核，内存单位为MB）
+type CapacityItem struct {
+	Physical      int64   `json:"physical"`      // 节点物理总量
+	Reserved      int64   `json:"reserved"`      // 系统预留
+	Allocatable   int64   `json:"allocatable"`   // 可分配物理量（物理总量 - 系统预留）
+	PhysicalUsed  float64 `json:"physicalUsed"`  // 折算后的物理占用（已售 / 超售比例）
+	PhysicalUsage float64 `json:"physicalUsage"` // 物理占用率（%）
+
+	ContainerRatio     float64 `json:"containerRatio"`     // 容器超售比例
+	VMRatio            float64 `json:"vmRatio"`            // 虚拟机超售比例
+	ContainerSold      int64   `json:"containerSold"`      // 容器已售出量
+	VMSold             int64   `json:"vmSold"`             // 虚拟机已售出量
+	ContainerAvailable int64   `json:"containerAvailable"` // 按容器超售比例计算的剩余可售量
+	VMAvailable        int64   `json:"vmAvailable"`        // 按虚拟机超售比例计算的剩余可售量
+}
+
+// DiskCapacity 磁盘容量（MB），磁盘不支持超售
+type DiskCapacity struct {
+	Physical    int64 `json:"physical"`
+	Reserved    int64 `json:"reserved"`
+	Allocatable int64 `json:"allocatable"`
+	Used        int64 `json:"used"`
+	Available   int64 `json:"available"`
+}
+
+// ProviderCapacity Provider物理资源与已售资源对比
+type ProviderCapacity struct {
+	ProviderID     uint         `json:"providerId"`
+	Name           string       `json:"name"`
+	Type           string       `json:"type"`
+	ContainerCount int64        `json:"containerCount"`
+	VMCount        int64        `json:"vmCount"`
+	CPU            CapacityItem `json:"cpu"`
+	Memory         CapacityItem `json:"memory"`
+	Disk           DiskCapacity `json:"disk"`
+}
+
+// CapacitySimulateRequest 容量模拟请求：假设批量创建 Count 个同规格实例
+type CapacitySimulateRequest struct {
+	InstanceType string `json:"instanceType" binding:"required,oneof=container vm"`
+	Count        int    `json:"count" binding:"required,min=1,max=10000"`
+	CPU          int    `json:"cpu" binding:"required,min=1"`
+	Memory       int64  `json:"memory" binding:"required,min=1"` // MB
+	Disk         int64  `json:"disk" binding:"min=0"`            // MB
+	ProviderIDs  []uint `json:"providerIds"`                     // 限定参与模拟的Provider，为空时模拟全部
+}
+
+// UsageProjection 模拟前后的物理占用率（%）
+type UsageProjection struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// ProviderSimulation 单个Provider的模拟结果
+type ProviderSimulation struct {
+	ProviderID uint   `json:"providerId"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Eligible   bool   `json:"eligible"`         // 能否容纳至少一个实例
+	Reason     string `json:"reason,omitempty"` // 不能容纳时的原因
+	Capacity   int    `json:"capacity"`         // 单独放置时最多还能容纳的实例数（不超过请求数量）
+	Limit      string `json:"limit,omitempty"`  // 限制容纳数量的因素：cpu, memory, disk, count
+	Assigned   int    `json:"assigned"`         // 批量分散放置时分配到该Provider的实例数
+
+	CPU    UsageProjection `json:"cpu"`
+	Memory UsageProjection `json:"memory"`
+	Disk   UsageProjection `json:"disk"`
+}
+
+// CapacitySimulateResult 容量模拟结果
+type CapacitySimulateResult struct {
+	Requested int                  `json:"requested"`
+	Placed    int                  `json:"placed"`
+	Fits      bool                 `json:"fits"` // 整批实例能否全部放置
+	Providers []ProviderSimulation `json:"providers"`
+}
+
+// DecommissionPlanRequest 节点下线迁移规划请求
+type DecommissionPlanRequest struct {
+	TargetProviderIDs []uint `json:"targetProviderIds"` // 限定迁移目标，为空时使用全部同类型节点
+}
+
+// DecommissionInstancePlan 单个实例的迁移规划
+type DecommissionInstancePlan struct {
+	Order        int    `json:"order"` // 迁移顺序，从1开始
+	InstanceID   uint   `json:"instanceId"`
+	Name         string `json:"name"`
+	UserID       uint   `json:"userId"`
+	InstanceType string `json:"instanceType"`
+	Status       string `json:"status"`
+	CPU          int    `json:"cpu"`
+	Memory       int64  `json:"memory"` // MB
+	Disk         int64  `json:"disk"`   // MB
+	TargetID     uint   `json:"targetId,omitempty"`
+	TargetName   string `json:"targetName,omitempty"`
+	Reason       string `json:"reason,omitempty"` // 无法放置时的原因
+}
+
+// DecommissionTarget 迁移目标节点的规划结果
+type DecommissionTarget struct {
+	ProviderID uint   `json:"providerId"`
+	Name       string `json:"name"`
+	Eligible   bool   `json:"eligible"`
+	Reason     string `json:"reason,omitempty"` // 不能作为目标的原因
+	Assigned   int    `json:"assigned"`         // 分配到该节点的实例数
+
+	CPU    UsageProjection `json:"cpu"`
+	Memory UsageProjection `json:"memory"`
+	Disk   UsageProjection `json:"disk"`
+}
+
+// DecommissionPlan 节点下线迁移规划结果
+type DecommissionPlan struct {
+	ProviderID uint                       `json:"providerId"`
+	Name       string                     `json:"name"`
+	Type       string                     `json:"type"`
+	Total      int                        `json:"total"`
+	Placed     int                        `json:"placed"`
+	Fits       bool                       `json:"fits"` // 所有实例能否全部放置到目标节点
+	Instances  []DecommissionInstancePlan `json:"instances"`
+	Targets    []DecommissionTarget       `json:"targets"`
+}
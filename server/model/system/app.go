@@ -0,0 +1,104 @@
+package system
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 实例应用安装状态
+const (
+	InstanceAppStatusPending    = "pending"
+	InstanceAppStatusInstalling = "installing"
+	InstanceAppStatusInstalled  = "installed"
+	InstanceAppStatusFailed     = "failed"
+)
+
+// AppTemplate 一键应用模板 - 在创建实例的基础上执行安装脚本并暴露应用端口
+type AppTemplate struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Slug        string `json:"slug" gorm:"uniqueIndex;not null;size:64"` // 应用标识，如 wordpress
+	Name        string `json:"name" gorm:"not null;size:64"`             // 应用名称
+	Category    string `json:"category" gorm:"size:32"`                  // 分类，如 cms、devtools、storage
+	Description string `json:"description" gorm:"size:512"`              // 应用描述
+	Icon        string `json:"icon" gorm:"size:255"`                     // 图标地址
+	Status      int    `json:"status" gorm:"default:1;index"`            // 1=上架 0=下架
+	Sort        int    `json:"sort" gorm:"default:0"`                    // 排序，数值越小越靠前
+
+	// 兼容性与资源要求
+	InstanceType string `json:"instanceType" gorm:"size:16"`  // 实例类型：vm, container，空表示不限
+	OSTypes      string `json:"osTypes" gorm:"size:128"`      // 兼容的操作系统（逗号分隔），空表示不限
+	MinCPU       int    `json:"minCpu" gorm:"default:0"`      // 最低CPU核心数
+	MinMemoryMB  int    `json:"minMemoryMB" gorm:"default:0"` // 最低内存（MB）
+	MinDiskMB    int    `json:"minDiskMB" gorm:"default:0"`   // 最低硬盘（MB）
+
+	// 安装配置
+	Ports           string `json:"ports" gorm:"size:255"`            // 应用端口（name:port，逗号分隔），如 http:80
+	InstallScript   string `json:"installScript" gorm:"type:text"`   // 安装脚本，以root在实例内执行
+	PostInstallInfo string `json:"postInstallInfo" gorm:"type:text"` // 安装完成后展示的信息模板，支持 ${HOST}、${PORT_<NAME>}、${APP_PASSWORD}
+}
+
+func (AppTemplate) TableName() string {
+	return "app_templates"
+}
+
+// AppPort 应用需要暴露的端口
+type AppPort struct {
+	Name string `json:"name"` // 端口名称，用于脚本变量 APP_PORT_<NAME>
+	Port int    `json:"port"` // 默认监听端口（独立IP时直接使用）
+}
+
+// ParsePorts 解析应用端口配置
+func (a *AppTemplate) ParsePorts() []AppPort {
+	var ports []AppPort
+	for _, item := range strings.Split(a.Ports, ",") {
+		name, portStr, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			continue
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(portStr))
+		if err != nil || port <= 0 || port > 65535 {
+			continue
+		}
+		ports = append(ports, AppPort{Name: strings.ToUpper(strings.TrimSpace(name)), Port: port})
+	}
+	return ports
+}
+
+// SupportsOSType 判断应用是否兼容指定的操作系统
+func (a *AppTemplate) SupportsOSType(osType string) bool {
+	if strings.TrimSpace(a.OSTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(a.OSTypes, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), osType) {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceApp 实例上安装的应用及其安装结果
+type InstanceApp struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	InstanceID   uint       `json:"instanceId" gorm:"uniqueIndex;not null"` // 实例ID
+	AppID        uint       `json:"appId" gorm:"index;not null"`            // 应用模板ID
+	AppName      string     `json:"appName" gorm:"size:64"`                 // 应用名称
+	Status       string     `json:"status" gorm:"size:16;default:pending"`  // pending, installing, installed, failed
+	Info         string     `json:"info" gorm:"type:text"`                  // 安装后信息（访问地址、凭据等）
+	ErrorMessage string     `json:"errorMessage" gorm:"size:512"`           // 安装失败原因
+	InstalledAt  *time.Time `json:"installedAt"`                            // 安装完成时间
+}
+
+func (InstanceApp) TableName() string {
+	return "instance_apps"
+}
@@ -0,0 +1,30 @@
+package system
+
+import (
+	"time"
+)
+
+// DataMigration 一次性数据迁移记录表
+// 表结构迁移之后对已有数据做的转换只能执行一次，完成后写入记录，记录存在时不再执行
+type DataMigration struct {
+	Name      string    `gorm:"primarykey;type:varchar(64);comment:迁移名称" json:"name"`
+	AppliedAt time.Time `gorm:"not null;comment:执行时间" json:"appliedAt"`
+}
+
+func (DataMigration) TableName() string {
+	return "data_migrations"
+}
+
+// DataMigrationProgress 分批执行的数据迁移进度
+// 每批在独立事务中处理并更新进度，中断后从记录的位置继续；MaxID 在首次执行时记录，之后新写入的行不处理
+type DataMigrationProgress struct {
+	Name      string    `gorm:"primarykey;type:varchar(191);comment:迁移名称与处理对象" json:"name"`
+	MaxID     uint64    `gorm:"not null;default:0;comment:首次执行时的最大主键" json:"maxId"`
+	Cursor    uint64    `gorm:"not null;default:0;comment:已处理到的主键" json:"cursor"`
+	Done      bool      `gorm:"not null;default:false;comment:是否已完成" json:"done"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (DataMigrationProgress) TableName() string {
+	return "data_migration_progress"
+}
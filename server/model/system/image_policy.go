@@ -0,0 +1,62 @@
+package system
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImagePolicy 镜像禁用策略 - 按用户等级或节点限制可用的系统镜像
+// 匹配条件（OSType/ImageID/InstanceType）为空表示不限制该条件，全部满足时镜像被禁用
+type ImagePolicy struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Name        string `json:"name" gorm:"not null;size:128"` // 策略名称
+	Description string `json:"description" gorm:"size:255"`   // 策略说明
+	Status      int    `json:"status" gorm:"default:1;index"` // 1=启用 0=禁用
+	CreatedBy   *uint  `json:"createdBy"`                     // 创建者ID
+
+	// 镜像匹配条件
+	OSType       string `json:"osType" gorm:"size:32"`       // 操作系统类型，如 windows，不区分大小写
+	ImageID      uint   `json:"imageId" gorm:"default:0"`    // 指定系统镜像ID，0表示不限
+	InstanceType string `json:"instanceType" gorm:"size:16"` // 实例类型：vm, container
+
+	// 作用范围
+	ProviderID   uint `json:"providerId" gorm:"default:0;index"` // 生效的节点ID，0表示所有节点
+	MinUserLevel int  `json:"minUserLevel" gorm:"default:0"`     // 等级低于该值的用户禁用，0表示对所有用户禁用
+}
+
+func (ImagePolicy) TableName() string {
+	return "image_policies"
+}
+
+// MatchesImage 判断镜像是否命中策略的匹配条件
+func (p *ImagePolicy) MatchesImage(image *SystemImage) bool {
+	if p.ImageID != 0 && p.ImageID != image.ID {
+		return false
+	}
+	if p.OSType != "" && !strings.EqualFold(p.OSType, image.OSType) {
+		return false
+	}
+	if p.InstanceType != "" && p.InstanceType != image.InstanceType {
+		return false
+	}
+	return true
+}
+
+// AppliesTo 判断策略是否对指定用户等级和节点生效
+// userLevel 为 0 表示未知用户（如节点侧镜像解析），此时仅对所有用户生效的策略起作用
+// providerID 为 0 表示未知节点（如公开镜像目录），此时仅对所有节点生效的策略起作用
+func (p *ImagePolicy) AppliesTo(userLevel int, providerID uint) bool {
+	if p.ProviderID != 0 && p.ProviderID != providerID {
+		return false
+	}
+	if p.MinUserLevel == 0 {
+		return true
+	}
+	return userLevel > 0 && userLevel < p.MinUserLevel
+}
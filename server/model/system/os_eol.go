@@ -0,0 +1,71 @@
+package system
+
+import (
+	"strings"
+	"time"
+)
+
+// knownOSEOL 常见发行版的生命周期终止日期（取官方常规/LTS支持结束日期）
+// 键为 osType:版本，版本支持发行代号
+var knownOSEOL = map[string]string{
+	"ubuntu:14.04": "2019-04-30", "ubuntu:trusty": "2019-04-30",
+	"ubuntu:16.04": "2021-04-30", "ubuntu:xenial": "2021-04-30",
+	"ubuntu:18.04": "2023-05-31", "ubuntu:bionic": "2023-05-31",
+	"ubuntu:20.04": "2025-05-31", "ubuntu:focal": "2025-05-31",
+	"ubuntu:22.04": "2027-06-01", "ubuntu:jammy": "2027-06-01",
+	"ubuntu:24.04": "2029-05-31", "ubuntu:noble": "2029-05-31",
+
+	"debian:8": "2020-06-30", "debian:jessie": "2020-06-30",
+	"debian:9": "2022-06-30", "debian:stretch": "2022-06-30",
+	"debian:10": "2024-06-30", "debian:buster": "2024-06-30",
+	"debian:11": "2026-08-31", "debian:bullseye": "2026-08-31",
+	"debian:12": "2028-06-30", "debian:bookworm": "2028-06-30",
+
+	"centos:6": "2020-11-30",
+	"centos:7": "2024-06-30",
+	"centos:8": "2021-12-31",
+
+	"almalinux:8": "2029-03-01", "rockylinux:8": "2029-05-31",
+	"almalinux:9": "2032-05-31", "rockylinux:9": "2032-05-31",
+
+	"alpine:3.15": "2023-11-01",
+	"alpine:3.16": "2024-05-23",
+	"alpine:3.17": "2024-11-22",
+	"alpine:3.18": "2025-05-09",
+	"alpine:3.19": "2025-11-01",
+	"alpine:3.20": "2026-04-01",
+	"alpine:3.21": "2026-11-01",
+}
+
+// KnownOSEOLDate 查询内置的发行版EOL日期，版本依次按完整版本、主次版本、主版本匹配
+// 未收录的系统或版本返回nil
+func KnownOSEOLDate(osType, osVersion string) *time.Time {
+	osType = strings.ToLower(strings.TrimSpace(osType))
+	osVersion = strings.ToLower(strings.TrimSpace(osVersion))
+	if osType == "" || osVersion == "" {
+		return nil
+	}
+
+	candidates := []string{osVersion}
+	if parts := strings.Split(osVersion, "."); len(parts) > 1 {
+		candidates = append(candidates, parts[0]+"."+parts[1], parts[0])
+	}
+	for _, version := range candidates {
+		if value, ok := knownOSEOL[osType+":"+version]; ok {
+			t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return nil
+			}
+			return &t
+		}
+	}
+	return nil
+}
+
+// EffectiveEOLDate 返回镜像的EOL日期：优先使用管理员设置的日期，否则使用内置日期
+func (s *SystemImage) EffectiveEOLDate() *time.Time {
+	if s.EOLDate != nil {
+		return s.EOLDate
+	}
+	return KnownOSEOLDate(s.OSType, s.OSVersion)
+}
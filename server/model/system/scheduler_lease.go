@@ -0,0 +1,19 @@
+package system
+
+import (
+	"time"
+)
+
+// SchedulerLease 调度器主节点租约表
+// 集群部署时各节点竞争同一租约，持有未过期租约的节点负责运行定时任务
+type SchedulerLease struct {
+	Name      string    `gorm:"primarykey;type:varchar(64);comment:租约名称" json:"name"`
+	Holder    string    `gorm:"type:varchar(128);not null;comment:持有者节点ID" json:"holder"`
+	ExpiresAt time.Time `gorm:"not null;index;comment:租约到期时间" json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (SchedulerLease) TableName() string {
+	return "scheduler_leases"
+}
@@ -64,9 +64,10 @@ type SystemImage struct {
 	Size     int64  `json:"size" gorm:"default:0"`    // 文件大小（字节）
 
 	// 操作系统信息
-	OSType    string `json:"osType" gorm:"size:32"`    // 操作系统类型：ubuntu, centos, debian, alpine等
-	OSVersion string `json:"osVersion" gorm:"size:32"` // 操作系统版本号
-	Tags      string `json:"tags" gorm:"size:255"`     // 标签列表（用逗号分隔）
+	OSType    string     `json:"osType" gorm:"size:32"`    // 操作系统类型：ubuntu, centos, debian, alpine等
+	OSVersion string     `json:"osVersion" gorm:"size:32"` // 操作系统版本号
+	Tags      string     `json:"tags" gorm:"size:255"`     // 标签列表（用逗号分隔）
+	EOLDate   *time.Time `json:"eolDate"`                  // 操作系统生命周期终止日期，为空时使用内置的发行版EOL日期
 
 	// 硬件要求
 	MinMemoryMB int `json:"minMemoryMB" gorm:"default:0"` // 最低内存要求（MB）
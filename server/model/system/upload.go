@@ -0,0 +1,69 @@
+package system
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 上传会话状态
+const (
+	UploadStatusUploading = "uploading" // 上传中
+	UploadStatusCompleted = "completed" // 已完成并通过校验
+	UploadStatusFailed    = "failed"    // 完整性校验失败
+	UploadStatusCancelled = "cancelled" // 已取消
+	UploadStatusExpired   = "expired"   // 已过期
+)
+
+// 上传用途
+const (
+	UploadPurposeImage  = "image"  // 自定义镜像
+	UploadPurposeBackup = "backup" // 恢复归档
+)
+
+// UploadReferencePrefix 其他子系统引用已完成上传文件时使用的前缀，如 upload://<uuid>
+const UploadReferencePrefix = "upload://"
+
+// UploadSession 分片上传会话，支持断点续传
+type UploadSession struct {
+	// 基础字段
+	ID        uint           `json:"id" gorm:"primarykey"`                     // 会话主键ID
+	UUID      string         `json:"uuid" gorm:"uniqueIndex;not null;size:36"` // 会话唯一标识符
+	CreatedAt time.Time      `json:"createdAt"`                                // 创建时间
+	UpdatedAt time.Time      `json:"updatedAt"`                                // 更新时间
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`                           // 软删除时间
+
+	// 文件信息
+	UserID    uint   `json:"userId" gorm:"index;not null"`      // 发起上传的用户ID
+	FileName  string `json:"fileName" gorm:"not null;size:255"` // 原始文件名
+	Purpose   string `json:"purpose" gorm:"index;size:32"`      // 用途：image, backup
+	TotalSize int64  `json:"totalSize" gorm:"not null"`         // 文件总大小（字节）
+	ChunkSize int64  `json:"chunkSize" gorm:"not null"`         // 单个分片最大大小（字节）
+	Checksum  string `json:"checksum" gorm:"size:128"`          // 文件SHA256校验和（十六进制），为空时完成后自动计算
+
+	// 上传进度
+	UploadedSize int64      `json:"uploadedSize" gorm:"default:0"`                 // 已上传大小（字节），即下一个分片的偏移量
+	Status       string     `json:"status" gorm:"index;default:uploading;size:16"` // 状态：uploading, completed, failed, cancelled, expired
+	ErrorMessage string     `json:"errorMessage" gorm:"size:512"`                  // 失败原因
+	Backend      string     `json:"backend" gorm:"size:16;default:local"`          // 完成后文件所在存储：local, s3
+	StoragePath  string     `json:"-" gorm:"size:512"`                             // 文件存储路径（本地路径或对象键）
+	ExpiresAt    time.Time  `json:"expiresAt" gorm:"index"`                        // 未完成会话的过期时间
+	CompletedAt  *time.Time `json:"completedAt"`                                   // 完成时间
+}
+
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+func (u *UploadSession) BeforeCreate(tx *gorm.DB) error {
+	if u.UUID == "" {
+		u.UUID = uuid.New().String()
+	}
+	return nil
+}
+
+// Reference 返回供镜像、备份等子系统引用的地址
+func (u *UploadSession) Reference() string {
+	return UploadReferencePrefix + u.UUID
+}
@@ -0,0 +1,16 @@
+package user
+
+import "time"
+
+// APIQuotaBucket 用户某类控制面接口的令牌桶状态
+type APIQuotaBucket struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	UserID     uint      `json:"userId" gorm:"uniqueIndex:idx_api_quota_user_action;not null"`         // 所属用户ID
+	Action     string    `json:"action" gorm:"uniqueIndex:idx_api_quota_user_action;size:32;not null"` // 操作类别，对应 quota.api-limits 的键
+	Tokens     float64   `json:"tokens"`                                                               // 上次结算时剩余的令牌数
+	RefilledAt time.Time `json:"refilledAt"`                                                           // 上次结算令牌的时间
+}
+
+func (APIQuotaBucket) TableName() string {
+	return "user_api_quota_buckets"
+}
@@ -0,0 +1,30 @@
+package user
+
+import "time"
+
+// APITokenPrefix 个人API令牌前缀，认证中间件据此区分API令牌与JWT
+const APITokenPrefix = "ocv_"
+
+// UserAPIToken 个人API令牌，供命令行客户端和脚本长期调用API
+// 只保存令牌的SHA-256摘要，明文仅在创建时返回一次
+type UserAPIToken struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID     uint       `json:"userId" gorm:"index;not null"`          // 所属用户ID
+	Name       string     `json:"name" gorm:"not null;size:64"`          // 令牌名称
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;size:64;not null"` // 令牌SHA-256摘要
+	Prefix     string     `json:"prefix" gorm:"size:16"`                 // 令牌开头几位，用于在列表中辨认
+	ExpiresAt  *time.Time `json:"expiresAt"`                             // 过期时间，为空表示长期有效
+	LastUsedAt *time.Time `json:"lastUsedAt"`                            // 最近使用时间
+}
+
+func (UserAPIToken) TableName() string {
+	return "user_api_tokens"
+}
+
+// IsExpired 令牌是否已过期
+func (t *UserAPIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
@@ -0,0 +1,29 @@
+package user
+
+// CreateEstimateRequest 创建实例预计耗时请求，未填写的节点和镜像使用实例默认设置
+type CreateEstimateRequest struct {
+	ProviderID uint `json:"providerId" form:"providerId"`
+	ImageID    uint `json:"imageId" form:"imageId"`
+}
+
+// CreateEstimateResponse 创建实例预计耗时
+type CreateEstimateResponse struct {
+	ProviderID   uint   `json:"providerId"`
+	ProviderName string `json:"providerName"`
+	ImageID      uint   `json:"imageId"`
+	ImageName    string `json:"imageName"`
+	InstanceType string `json:"instanceType"`
+
+	PendingTasks     int `json:"pendingTasks"`     // 节点上排队中的任务数
+	RunningTasks     int `json:"runningTasks"`     // 节点上执行中的任务数
+	Concurrency      int `json:"concurrency"`      // 节点同时执行的任务数
+	QueueWaitSeconds int `json:"queueWaitSeconds"` // 预计排队时长（秒）
+
+	ImageCached          bool `json:"imageCached"`          // 节点上是否已有该镜像
+	ImageDownloadSeconds int  `json:"imageDownloadSeconds"` // 需要下载镜像时额外的耗时（秒）
+	CreateSeconds        int  `json:"createSeconds"`        // 预计创建耗时，不含排队和镜像下载（秒）
+	TotalSeconds         int  `json:"totalSeconds"`         // 预计总耗时（秒）
+
+	SampleSize int    `json:"sampleSize"` // 参与估算的历史创建任务数
+	Basis      string `json:"basis"`      // 估算依据：history 按节点历史耗时，default 按默认耗时
+}
@@ -0,0 +1,40 @@
+package user
+
+import "time"
+
+// 数据导出状态
+const (
+	DataExportStatusPending   = "pending"   // 等待任务生成
+	DataExportStatusCompleted = "completed" // 已生成，可下载
+	DataExportStatusFailed    = "failed"    // 生成失败
+	DataExportStatusExpired   = "expired"   // 下载链接已过期，文件已删除
+)
+
+// DataExport 用户数据导出记录
+// 导出文件由异步任务生成并写入对象存储，通过带令牌的链接在有效期内下载
+type DataExport struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID       uint       `json:"userId" gorm:"index;not null"`                // 所属用户ID
+	TaskID       uint       `json:"taskId" gorm:"index"`                         // 生成导出文件的任务ID
+	Status       string     `json:"status" gorm:"index;size:16;default:pending"` // 状态：pending, completed, failed, expired
+	Token        string     `json:"-" gorm:"uniqueIndex;size:64;not null"`       // 下载令牌
+	FileName     string     `json:"fileName" gorm:"size:255"`                    // 导出文件名
+	Size         int64      `json:"size" gorm:"default:0"`                       // 文件大小（字节）
+	ObjectKey    string     `json:"-" gorm:"size:512"`                           // 对象存储中的键
+	ErrorMessage string     `json:"errorMessage" gorm:"size:512"`                // 失败原因
+	ExpiresAt    *time.Time `json:"expiresAt"`                                   // 下载链接过期时间
+	Downloads    int64      `json:"downloads" gorm:"default:0"`                  // 下载次数
+	DownloadURL  string     `json:"downloadUrl,omitempty" gorm:"-"`              // 下载地址，仅可下载时返回
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// IsDownloadable 导出文件是否已生成且未过期
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportStatusCompleted && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
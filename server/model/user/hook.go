@@ -0,0 +1,40 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// 钩子触发事件
+const (
+	HookEventCreate = "create" // 实例创建完成后
+	HookEventReset  = "reset"  // 实例重置完成后
+)
+
+// UserHook 用户自定义的实例初始化钩子脚本，在实例创建/重置后于实例内执行
+type UserHook struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID  uint   `json:"userId" gorm:"index;not null"`         // 所属用户ID
+	Name    string `json:"name" gorm:"not null;size:64"`         // 钩子名称
+	Events  string `json:"events" gorm:"size:32;default:create"` // 触发事件（逗号分隔）：create, reset
+	Script  string `json:"script" gorm:"type:text"`              // 脚本内容，以实例登录用户执行
+	Enabled bool   `json:"enabled" gorm:"default:true"`          // 是否启用
+	Sort    int    `json:"sort" gorm:"default:0"`                // 执行顺序，数值越小越先执行
+}
+
+func (UserHook) TableName() string {
+	return "user_hooks"
+}
+
+// HasEvent 判断钩子是否订阅了指定事件
+func (h *UserHook) HasEvent(event string) bool {
+	for _, e := range strings.Split(h.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package user
+
+import "time"
+
+// InstanceDefaults 用户的实例创建默认设置，创建实例时未填写的项使用这里的设置
+type InstanceDefaults struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID       uint   `json:"userId" gorm:"uniqueIndex:idx_instance_defaults_user;not null"` // 所属用户ID
+	ImageID      uint   `json:"imageId" gorm:"default:0"`                                      // 首选镜像ID，0表示不设置
+	ProviderID   uint   `json:"providerId" gorm:"default:0"`                                   // 首选节点ID，0表示不设置
+	Region       string `json:"region" gorm:"size:64"`                                         // 首选地区，未设置首选节点时在该地区的可用节点中选择
+	SSHPublicKey string `json:"sshPublicKey" gorm:"type:text"`                                 // 默认SSH公钥，新建实例时写入 authorized_keys
+	Tags         string `json:"tags" gorm:"size:255"`                                          // 默认标签（逗号分隔）
+	Timezone     string `json:"timezone" gorm:"size:64"`                                       // 默认时区，如 Asia/Shanghai，新建实例时设置
+}
+
+func (InstanceDefaults) TableName() string {
+	return "user_instance_defaults"
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// InstanceGroup 用户的实例分组（项目），用于批量操作、流量汇总和组内新实例的默认设置
+type InstanceGroup struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID       uint   `json:"userId" gorm:"uniqueIndex:idx_user_group_name,priority:1;not null"`       // 所属用户ID
+	Name         string `json:"name" gorm:"uniqueIndex:idx_user_group_name,priority:2;not null;size:64"` // 分组名称（同一用户下唯一）
+	Description  string `json:"description" gorm:"size:255"`                                             // 分组描述
+	SSHPublicKey string `json:"sshPublicKey" gorm:"type:text"`                                           // 默认SSH公钥，组内新建实例时写入 authorized_keys
+	Tags         string `json:"tags" gorm:"size:255"`                                                    // 默认标签（逗号分隔），组内实例继承
+	Sort         int    `json:"sort" gorm:"default:0"`                                                   // 排序，数值越小越靠前
+}
+
+func (InstanceGroup) TableName() string {
+	return "instance_groups"
+}
+
+// TagList 返回去除空白后的标签列表
+func (g *InstanceGroup) TagList() []string {
+	return SplitTags(g.Tags)
+}
+
+// SplitTags 将逗号分隔的标签拆分为去除空白后的列表
+func SplitTags(tags string) []string {
+	result := []string{}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// InstanceGroupResponse 实例分组列表项，附带组内实例数量和当月流量汇总
+type InstanceGroupResponse struct {
+	InstanceGroup
+	InstanceCount int     `json:"instanceCount"` // 组内实例数量
+	RunningCount  int     `json:"runningCount"`  // 组内运行中的实例数量
+	RxBytes       int64   `json:"rxBytes"`       // 当月接收字节数
+	TxBytes       int64   `json:"txBytes"`       // 当月发送字节数
+	TrafficMB     float64 `json:"trafficMB"`     // 当月实际计费流量（MB，已应用流量计算模式）
+}
+
+// InstanceGroupSummary 仪表盘中的分组概览
+type InstanceGroupSummary struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Total   int    `json:"total"`
+	Running int    `json:"running"`
+}
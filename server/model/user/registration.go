@@ -0,0 +1,55 @@
+package user
+
+import "time"
+
+// 注册申请状态
+const (
+	RegistrationStatusPending  = "pending"
+	RegistrationStatusApproved = "approved"
+	RegistrationStatusRejected = "rejected"
+)
+
+// RegistrationApplication 待审核的注册申请
+// 开启注册审核后公开注册不直接创建用户，审核通过后才根据申请创建账号
+type RegistrationApplication struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Username     string `json:"username" gorm:"size:64;not null;index"`
+	PasswordHash string `json:"-" gorm:"size:255;not null"` // 申请时提交的密码（bcrypt），审核通过后直接作为用户密码
+	Nickname     string `json:"nickname" gorm:"size:64"`
+	Email        string `json:"email" gorm:"size:100"`
+	Phone        string `json:"phone" gorm:"size:20"`
+	Telegram     string `json:"telegram" gorm:"size:64"`
+	QQ           string `json:"qq" gorm:"size:20"`
+	Reason       string `json:"reason" gorm:"size:512"`      // 申请理由
+	IP           string `json:"ip" gorm:"size:64;index"`     // 申请来源IP，用于限制同一IP的申请频率
+	UserAgent    string `json:"userAgent" gorm:"size:255"`   // 申请时的User-Agent
+	Status       string `json:"status" gorm:"size:16;index"` // 状态：pending, approved, rejected
+
+	ReviewNote string     `json:"reviewNote" gorm:"size:255"` // 审核备注，会随结果通知给申请人
+	ReviewedBy uint       `json:"reviewedBy"`                 // 审核管理员ID
+	ReviewedAt *time.Time `json:"reviewedAt"`                 // 审核时间
+	UserID     uint       `json:"userId"`                     // 审核通过后创建的用户ID
+}
+
+func (RegistrationApplication) TableName() string {
+	return "registration_applications"
+}
+
+// RegistrationRejection 因邮箱策略被拒绝的注册记录，用于统计滥用情况
+type RegistrationRejection struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+
+	Username string `json:"username" gorm:"size:64"`
+	Email    string `json:"email" gorm:"size:100"`
+	Domain   string `json:"domain" gorm:"size:100;index"` // 邮箱域名
+	Reason   string `json:"reason" gorm:"size:32;index"`  // 拒绝原因：required, invalid, not_allowed, denied, disposable, no_mx
+	IP       string `json:"ip" gorm:"size:64"`            // 注册来源IP
+}
+
+func (RegistrationRejection) TableName() string {
+	return "registration_rejections"
+}
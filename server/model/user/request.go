@@ -24,6 +24,8 @@ type UserInstanceListRequest struct {
 	InstanceType string `json:"instanceType" form:"instanceType"`
 	Type         string `json:"type" form:"type"`                 // 实例类型筛选（和instanceType一样，兼容前端）
 	ProviderName string `json:"providerName" form:"providerName"` // 节点名称搜索
+	GroupID      *uint  `json:"groupId" form:"groupId"`           // 分组筛选，0表示未分组
+	Live         bool   `json:"live" form:"live"`                 // 是否从节点获取实时状态
 }
 
 type AvailableResourcesRequest struct {
@@ -33,10 +35,11 @@ type AvailableResourcesRequest struct {
 }
 
 type UpdateProfileRequest struct {
-	Nickname string `json:"nickname"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
-	Telegram string `json:"telegram"`
+	Nickname string  `json:"nickname"`
+	Email    string  `json:"email"`
+	Phone    string  `json:"phone"`
+	Telegram string  `json:"telegram"`
+	Timezone *string `json:"timezone"` // 时区，如 Asia/Shanghai，未提供时保持不变，空字符串表示使用系统默认时区
 }
 
 type ChangePasswordRequest struct {
@@ -74,13 +77,23 @@ type SystemImagesRequest struct {
 // 安全设计：所有参数都是从后端预定义配置中选择的ID，不允许自定义输入
 // 实例名称由后端根据provider名称自动生成
 type CreateInstanceRequest struct {
-	ProviderId  uint   `json:"providerId" binding:"required"`  // 节点ID
-	ImageId     uint   `json:"imageId" binding:"required"`     // 镜像ID（从数据库获取）
-	CPUId       string `json:"cpuId" binding:"required"`       // CPU规格ID
-	MemoryId    string `json:"memoryId" binding:"required"`    // 内存规格ID
-	DiskId      string `json:"diskId" binding:"required"`      // 磁盘规格ID
-	BandwidthId string `json:"bandwidthId" binding:"required"` // 带宽规格ID
-	Description string `json:"description"`                    // 描述信息
+	ProviderId  uint   `json:"providerId"`                                       // 节点ID，未填写时使用默认设置中的首选节点或首选地区
+	ImageId     uint   `json:"imageId"`                                          // 镜像ID（从数据库获取），未填写时使用默认设置中的首选镜像
+	CPUId       string `json:"cpuId" binding:"required"`                         // CPU规格ID
+	MemoryId    string `json:"memoryId" binding:"required"`                      // 内存规格ID
+	DiskId      string `json:"diskId" binding:"required"`                        // 磁盘规格ID
+	BandwidthId string `json:"bandwidthId" binding:"required"`                   // 带宽规格ID
+	Description string `json:"description"`                                      // 描述信息
+	AppId       uint   `json:"appId"`                                            // 一键应用ID（可选）
+	GroupId     uint   `json:"groupId"`                                          // 实例分组ID（可选），新实例应用分组的默认设置
+	Tags        string `json:"tags"`                                             // 实例标签（逗号分隔，可选），未填写时使用默认设置中的标签
+	Spread      string `json:"spread" binding:"omitempty,oneof=provider region"` // 分散放置（可选）：provider 优先选择尚无自己实例的节点，region 优先选择尚无自己实例的地区，仅在未指定节点时生效
+}
+
+// BatchCreateInstanceRequest 批量创建实例请求，各实例使用相同的镜像和规格
+type BatchCreateInstanceRequest struct {
+	CreateInstanceRequest
+	Count int `json:"count" binding:"required,min=1,max=10"` // 创建数量
 }
 
 // QuotaCheckRequest 配额检查请求
@@ -92,3 +105,11 @@ type QuotaCheckRequest struct {
 	Disk         int    `json:"disk"`
 	Bandwidth    int    `json:"bandwidth"`
 }
+
+// UserTaskHistoryRequest 用户任务历史请求
+type UserTaskHistoryRequest struct {
+	common.PageInfo
+	InstanceID uint   `json:"instanceId" form:"instanceId"` // 只看指定实例的任务
+	Status     string `json:"status" form:"status"`
+	Lang       string `json:"lang" form:"lang"` // 语言：zh-CN 或 en-US，默认取 Accept-Language
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"time"
 
+	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
 )
 
@@ -16,9 +17,12 @@ type UserDashboardResponse struct {
 		Stopped    int `json:"stopped"`
 		Containers int `json:"containers"`
 		VMs        int `json:"vms"`
+		EOL        int `json:"eol"` // 运行已EOL操作系统的实例数
 	} `json:"instances"`
-	RecentInstances []providerModel.Instance `json:"recentInstances"`
-	ResourceUsage   *ResourceUsageInfo       `json:"resourceUsage,omitempty"`
+	RecentInstances []providerModel.Instance       `json:"recentInstances"`
+	ResourceUsage   *ResourceUsageInfo             `json:"resourceUsage,omitempty"`
+	Groups          []InstanceGroupSummary         `json:"groups"`                    // 实例分组概览
+	TrafficForecast *monitoringModel.UsageForecast `json:"trafficForecast,omitempty"` // 当月流量用量预测
 }
 
 type ResourceUsageInfo struct {
@@ -56,25 +60,64 @@ type UserInstanceResponse struct {
 	PublicIP       string                   `json:"publicIP"`       // 纯净的公网IP（不含端口）
 	ProviderType   string                   `json:"providerType"`   // Provider虚拟化类型：docker, lxd, incus, proxmox
 	ProviderStatus string                   `json:"providerStatus"` // Provider状态：active, inactive, partial
+	GroupName      string                   `json:"groupName"`      // 所在分组名称，未分组为空
+	Tags           []string                 `json:"tags"`           // 实例自身的标签和继承自分组的标签
+	OSEOLStatus    string                   `json:"osEolStatus"`    // 操作系统EOL状态：approaching即将EOL，eol已EOL，空为正常
+	Live           *InstanceLiveState       `json:"live,omitempty"` // 节点实时状态，仅在请求 live=true 时返回
+}
+
+// InstanceLiveState 说明实例信息中哪些字段来自节点的实时数据
+// Fields 中列出的字段取自节点，其余字段为数据库中记录的值
+type InstanceLiveState struct {
+	Source     string     `json:"source"`               // live：本次从节点获取；cached：使用几秒内从节点获取的数据；unavailable：节点未能在时限内返回，全部为数据库中的值
+	Fields     []string   `json:"fields"`               // 取自节点的字段：status、privateIP、ipv6Address
+	FetchedAt  *time.Time `json:"fetchedAt,omitempty"`  // 节点数据的获取时间
+	Reconciled []string   `json:"reconciled,omitempty"` // 与数据库记录明显不一致、已按节点数据修正的字段
+	Error      string     `json:"error,omitempty"`      // 未能获取节点数据的原因
 }
 
 // UserLimitsResponse 用户配额限制响应
 type UserLimitsResponse struct {
-	Level          int   `json:"level"`
-	MaxInstances   int   `json:"maxInstances"`
-	UsedInstances  int   `json:"usedInstances"`
-	ContainerCount int   `json:"containerCount"` // 容器数量
-	VMCount        int   `json:"vmCount"`        // 虚拟机数量
-	MaxCpu         int   `json:"maxCpu"`         // 最大CPU核心数
-	UsedCpu        int   `json:"usedCpu"`        // 已使用CPU核心数
-	MaxMemory      int   `json:"maxMemory"`      // 最大内存(MB)
-	UsedMemory     int   `json:"usedMemory"`     // 已使用内存(MB)
-	MaxDisk        int   `json:"maxDisk"`        // 最大磁盘(MB)
-	UsedDisk       int   `json:"usedDisk"`       // 已使用磁盘(MB)
-	MaxBandwidth   int   `json:"maxBandwidth"`   // 最大带宽(Mbps)
-	UsedBandwidth  int   `json:"usedBandwidth"`  // 已使用带宽(Mbps)
-	MaxTraffic     int64 `json:"maxTraffic"`     // 最大流量(MB)
-	UsedTraffic    int64 `json:"usedTraffic"`    // 已使用流量(MB)
+	Level            int   `json:"level"`
+	MaxInstances     int   `json:"maxInstances"`
+	UsedInstances    int   `json:"usedInstances"`
+	MaxRunning       int   `json:"maxRunning"`       // 可同时运行的实例数上限
+	RunningInstances int   `json:"runningInstances"` // 同时运行（含启动中、创建中）的实例数
+	MaxSnapshots     int   `json:"maxSnapshots"`     // 每个实例定时快照最多保留的数量，0表示不允许定时快照
+	ContainerCount   int   `json:"containerCount"`   // 容器数量
+	VMCount          int   `json:"vmCount"`          // 虚拟机数量
+	MaxCpu           int   `json:"maxCpu"`           // 最大CPU核心数
+	UsedCpu          int   `json:"usedCpu"`          // 已使用CPU核心数
+	MaxMemory        int   `json:"maxMemory"`        // 最大内存(MB)
+	UsedMemory       int   `json:"usedMemory"`       // 已使用内存(MB)
+	MaxDisk          int   `json:"maxDisk"`          // 最大磁盘(MB)
+	UsedDisk         int   `json:"usedDisk"`         // 已使用磁盘(MB)
+	MaxBandwidth     int   `json:"maxBandwidth"`     // 最大带宽(Mbps)
+	UsedBandwidth    int   `json:"usedBandwidth"`    // 已使用带宽(Mbps)
+	MaxTraffic       int64 `json:"maxTraffic"`       // 最大流量(MB)
+	UsedTraffic      int64 `json:"usedTraffic"`      // 已使用流量(MB)
+}
+
+// TaskHistoryEntry 用户任务历史条目，状态、摘要和错误说明已按语言本地化
+type TaskHistoryEntry struct {
+	ID               uint       `json:"id"`
+	TaskType         string     `json:"taskType"`
+	Title            string     `json:"title"`      // 操作名称，如"重启实例 web-1"
+	Status           string     `json:"status"`     // 原始状态
+	StatusText       string     `json:"statusText"` // 本地化状态
+	Summary          string     `json:"summary"`    // 当前阶段或结果的摘要
+	Progress         int        `json:"progress"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	StartedAt        *time.Time `json:"startedAt"`
+	CompletedAt      *time.Time `json:"completedAt"`
+	WaitSeconds      int        `json:"waitSeconds"`      // 排队时长（秒）
+	DurationSeconds  int        `json:"durationSeconds"`  // 执行时长（秒），执行中的任务为已执行时长
+	DurationText     string     `json:"durationText"`     // 本地化的执行时长
+	ErrorMessage     string     `json:"errorMessage"`     // 原始错误信息
+	ErrorExplanation string     `json:"errorExplanation"` // 错误原因说明和处理建议
+	InstanceID       *uint      `json:"instanceId"`
+	InstanceName     string     `json:"instanceName"`
+	InstanceLink     string     `json:"instanceLink"` // 实例详情页路径，实例已删除时为空
 }
 
 // UserTaskResponse 用户任务响应
@@ -129,6 +172,7 @@ type UserInstanceDetailResponse struct {
 	IPv6Address     string     `json:"ipv6Address"` // 内网IPv6地址
 	PublicIPv6      string     `json:"publicIPv6"`  // 公网IPv6地址
 	SSHPort         int        `json:"sshPort"`
+	GuestSSHPort    int        `json:"guestSshPort"` // 实例内sshd监听的端口
 	Username        string     `json:"username"`
 	Password        string     `json:"password"`
 	ProviderName    string     `json:"providerName"`
@@ -140,8 +184,46 @@ type UserInstanceDetailResponse struct {
 	NetworkType     string     `json:"networkType"`     // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
 	CreatedAt       time.Time  `json:"createdAt"`
 	ExpiresAt       *time.Time `json:"expiresAt"` // 实例过期时间
+	// 实例内磁盘使用量（定时采集，DiskUsageAt为空表示尚未采集）
+	DiskUsedMB       int64      `json:"diskUsedMB"`       // 根分区已用空间（MB）
+	DiskUsagePercent float64    `json:"diskUsagePercent"` // 已用空间占分配大小的百分比
+	DiskUsageAt      *time.Time `json:"diskUsageAt"`      // 采集时间
+	DiskUsageWarning bool       `json:"diskUsageWarning"` // 是否接近分配的磁盘大小
 	// 关联任务信息
 	RelatedTask *UserTaskResponse `json:"relatedTask,omitempty"` // 关联的最新任务（如果有）
+	// 节点实时状态，仅在请求 live=true 时返回
+	Live *InstanceLiveState `json:"live,omitempty"`
+}
+
+// InstanceConnectionBundle 实例连接信息包，可直接复制使用
+type InstanceConnectionBundle struct {
+	InstanceID         uint                   `json:"instanceId"`
+	InstanceName       string                 `json:"instanceName"`
+	Language           string                 `json:"language"`     // 本地化语言：zh-CN, en-US
+	Host               string                 `json:"host"`         // SSH连接地址
+	SSHPort            int                    `json:"sshPort"`      // SSH公网端口
+	GuestSSHPort       int                    `json:"guestSshPort"` // 实例内sshd监听的端口
+	PrivateIP          string                 `json:"privateIP,omitempty"`
+	Username           string                 `json:"username"`
+	Password           string                 `json:"password"`
+	IPv6Address        string                 `json:"ipv6Address,omitempty"`        // 优先公网IPv6地址
+	SSHCommand         string                 `json:"sshCommand"`                   // ssh命令行
+	InternalSSHCommand string                 `json:"internalSshCommand,omitempty"` // 同节点其他实例经内网连接的ssh命令行
+	SSHConfig          string                 `json:"sshConfig"`                    // ~/.ssh/config 片段
+	PuTTYCommand       string                 `json:"puttyCommand"`                 // PuTTY命令行
+	Ports              []ConnectionBundlePort `json:"ports"`                        // 端口映射表
+	QRCodePayload      string                 `json:"qrCodePayload"`                // 二维码内容（ssh:// URI，不含密码）
+	Text               string                 `json:"text"`                         // 本地化的纯文本连接信息
+}
+
+// ConnectionBundlePort 连接信息包中的端口映射
+type ConnectionBundlePort struct {
+	PublicPort  string `json:"publicPort"` // 公网端口，端口段形如 10000-10010
+	GuestPort   string `json:"guestPort"`  // 实例内部端口
+	Protocol    string `json:"protocol"`
+	Address     string `json:"address"` // 公网访问地址 host:port
+	Description string `json:"description"`
+	IsSSH       bool   `json:"isSsh"`
 }
 
 // InstanceMonitoringResponse 实例监控数据响应
@@ -183,6 +265,7 @@ type AvailableProviderResponse struct {
 	ID                      uint    `json:"id"`
 	Name                    string  `json:"name"`
 	Type                    string  `json:"type"`
+	Architecture            string  `json:"architecture"` // CPU架构：amd64, arm64等
 	Region                  string  `json:"region"`
 	Country                 string  `json:"country"`
 	CountryCode             string  `json:"countryCode"`
@@ -199,6 +282,17 @@ type AvailableProviderResponse struct {
 	MemoryUsage             float64 `json:"memoryUsage"`
 	ContainerEnabled        bool    `json:"containerEnabled"`
 	VmEnabled               bool    `json:"vmEnabled"`
+
+	NetworkQuality *monitoringModel.NetworkQualitySummary `json:"networkQuality,omitempty"` // 最近一次网络质量测试摘要，未测试时为空
+}
+
+// BatchCreateInstanceResult 批量创建中单个实例的提交结果
+type BatchCreateInstanceResult struct {
+	Index      int    `json:"index"`                // 序号，从0开始
+	TaskID     uint   `json:"taskId,omitempty"`     // 创建任务ID
+	ProviderID uint   `json:"providerId,omitempty"` // 放置的节点ID
+	Status     string `json:"status,omitempty"`     // 任务状态
+	Error      string `json:"error,omitempty"`      // 提交失败原因
 }
 
 // SystemImageResponse 系统镜像响应
@@ -24,11 +24,12 @@ type User struct {
 	Phone    string `json:"phone" gorm:"size:32"`                         // 手机号码
 	Telegram string `json:"telegram" gorm:"size:64"`                      // Telegram用户名
 	QQ       string `json:"qq" gorm:"size:32"`                            // QQ号码
+	Timezone string `json:"timezone" gorm:"size:64"`                      // 时区，如 Asia/Shanghai，用于计算流量重置时间和按月统计，为空时使用系统默认时区
 
 	// 状态和权限
-	Status   int    `json:"status" gorm:"default:1;index:idx_status"` // 用户状态：0=禁用（不可登录），1=正常
-	Level    int    `json:"level" gorm:"default:1;index:idx_level"`   // 用户等级，用于权限控制
-	UserType string `json:"userType" gorm:"default:user;size:16"`     // 用户类型：user, admin, super_admin等
+	Status   int    `json:"status" gorm:"default:1;index:idx_users_status"` // 用户状态：0=禁用（不可登录），1=正常
+	Level    int    `json:"level" gorm:"default:1;index:idx_level"`         // 用户等级，用于权限控制
+	UserType string `json:"userType" gorm:"default:user;size:16"`           // 用户类型：user, admin, super_admin等
 
 	// 配额管理（两阶段配额系统）
 	UsedQuota    int `json:"usedQuota" gorm:"default:0"`    // 已确认使用的配额（稳定状态实例）
@@ -52,8 +53,8 @@ type User struct {
 	LastLoginAt *time.Time `json:"lastLoginAt"`               // 最后登录时间
 
 	// 过期管理
-	ExpiresAt      *time.Time `json:"expiresAt" gorm:"index:idx_expires_at"` // 用户过期时间，过期后自动禁用（Status设为0）
-	IsManualExpiry bool       `json:"isManualExpiry" gorm:"default:false"`   // 是否手动设置了过期时间（手动设置优先级高于全局配置）
+	ExpiresAt      *time.Time `json:"expiresAt" gorm:"index:idx_users_expires_at"` // 用户过期时间，过期后自动禁用（Status设为0）
+	IsManualExpiry bool       `json:"isManualExpiry" gorm:"default:false"`         // 是否手动设置了过期时间（手动设置优先级高于全局配置）
 
 	// OAuth2关联信息
 	OAuth2ProviderID uint   `json:"oauth2ProviderId" gorm:"index"`   // OAuth2提供商ID（关联oauth2_providers表）
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook 事件
+const (
+	WebhookEventInstanceCreated  = "instance.created"  // 实例创建完成
+	WebhookEventInstanceStopped  = "instance.stopped"  // 实例已停止（用户操作、闲置停机或流量超限）
+	WebhookEventTrafficThreshold = "traffic.threshold" // 当月流量使用达到阈值
+	WebhookEventInstanceExpiring = "instance.expiring" // 实例即将到期
+	WebhookEventPing             = "ping"              // 测试投递
+)
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending     = "pending"
+	WebhookDeliverySuccess     = "success"
+	WebhookDeliveryFailed      = "failed"
+	WebhookDeliveryRateLimited = "rate_limited"
+)
+
+// UserWebhook 用户注册的Webhook，只接收自己实例的事件
+type UserWebhook struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	UserID           uint       `json:"userId" gorm:"index;not null"`       // 所属用户ID
+	Name             string     `json:"name" gorm:"not null;size:64"`       // 名称
+	URL              string     `json:"url" gorm:"not null;size:512"`       // 接收地址，以JSON POST投递
+	Secret           string     `json:"-" gorm:"size:80"`                   // 签名密钥，只在创建和重新生成时返回
+	Events           string     `json:"events" gorm:"size:128"`             // 订阅的事件（逗号分隔）
+	TrafficThreshold int        `json:"trafficThreshold" gorm:"default:80"` // 流量阈值事件的使用百分比
+	ExpiringDays     int        `json:"expiringDays" gorm:"default:3"`      // 到期前多少天发送即将到期事件
+	Enabled          bool       `json:"enabled" gorm:"default:true"`        // 是否启用
+	LastDeliveryAt   *time.Time `json:"lastDeliveryAt"`                     // 最近一次投递时间
+	LastStatus       string     `json:"lastStatus" gorm:"size:16"`          // 最近一次投递状态
+	LastError        string     `json:"lastError" gorm:"size:512"`          // 最近一次投递失败原因
+}
+
+func (UserWebhook) TableName() string {
+	return "user_webhooks"
+}
+
+// HasEvent 判断是否订阅了指定事件
+func (w *UserWebhook) HasEvent(event string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// UserWebhookDelivery Webhook投递记录
+// 同一Webhook的同一去重键只投递一次，多节点同时检测到同一事件时只有一个节点投递
+type UserWebhookDelivery struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	WebhookID  uint   `json:"webhookId" gorm:"not null;uniqueIndex:idx_webhook_dedup"`
+	UserID     uint   `json:"userId" gorm:"index;not null"`
+	Event      string `json:"event" gorm:"size:32"`
+	InstanceID uint   `json:"instanceId"`
+	DedupKey   string `json:"-" gorm:"size:128;not null;uniqueIndex:idx_webhook_dedup"` // 去重键
+	Payload    string `json:"payload" gorm:"type:text"`                                 // 请求体
+	Status     string `json:"status" gorm:"size:16;index"`                              // pending, success, failed, rate_limited
+	StatusCode int    `json:"statusCode"`                                               // 接收方返回的HTTP状态码
+	Error      string `json:"error" gorm:"size:512"`                                    // 失败原因
+	Attempts   int    `json:"attempts"`                                                 // 已尝试次数
+	DurationMs int64  `json:"durationMs"`                                               // 最后一次请求耗时
+}
+
+func (UserWebhookDelivery) TableName() string {
+	return "user_webhook_deliveries"
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 
@@ -169,21 +170,12 @@ func (d *DockerProvider) DiscoverInstances(ctx context.Context) ([]provider.Disc
 // 辅助函数
 func (d *DockerProvider) mapDockerStatus(status string, running, paused bool) string {
 	if paused {
-		return "paused"
+		return constant.InstanceStatusPaused
 	}
 	if running {
-		return "running"
-	}
-	switch strings.ToLower(status) {
-	case "exited":
-		return "stopped"
-	case "created":
-		return "stopped"
-	case "dead":
-		return "failed"
-	default:
-		return status
+		return constant.InstanceStatusRunning
 	}
+	return constant.NormalizeProviderStatus("docker", status)
 }
 
 func (d *DockerProvider) parsePortNumber(portStr string) int {
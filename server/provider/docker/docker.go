@@ -64,6 +64,7 @@ func (d *DockerProvider) Connect(ctx context.Context, config provider.NodeConfig
 		PrivateKey:     config.PrivateKey,
 		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
 		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
 	}
 	client, err := utils.NewSSHClient(sshConfig)
 	if err != nil {
@@ -396,7 +397,7 @@ func (d *DockerProvider) GetInstance(ctx context.Context, id string) (*provider.
 	}
 
 	// 使用简单的分隔符格式获取信息，避免table格式的解析问题
-	output, err := d.sshClient.ExecuteWithLogging(fmt.Sprintf("docker inspect %s --format '{{.Name}}|{{.State.Status}}|{{.Config.Image}}|{{.Id}}|{{.Created}}'", id), "DOCKER_INSPECT")
+	output, err := d.sshClient.ExecuteWithLogging(fmt.Sprintf("docker inspect %s --format '{{.Name}}|{{.State.Status}}|{{.Config.Image}}|{{.Id}}|{{.Created}}'", utils.ShellQuote(id)), "DOCKER_INSPECT")
 	if err != nil {
 		global.APP_LOG.Debug("Docker inspect命令执行失败",
 			zap.String("id", utils.TruncateString(id, 32)),
@@ -455,7 +456,7 @@ func (d *DockerProvider) GetInstance(ctx context.Context, id string) (*provider.
 // enrichInstanceWithNetworkInfo 补充单个实例的网络信息
 func (d *DockerProvider) enrichInstanceWithNetworkInfo(instance *provider.Instance) {
 	// 1. 获取容器的内网IP地址
-	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", instance.Name)
+	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", utils.ShellQuote(instance.Name))
 	output, err := d.sshClient.Execute(cmd)
 	if err == nil {
 		ipAddress := strings.TrimSpace(output)
@@ -470,7 +471,7 @@ func (d *DockerProvider) enrichInstanceWithNetworkInfo(instance *provider.Instan
 
 	// 2. 获取容器对应的宿主机veth接口
 	vethCmd := fmt.Sprintf(`
-CONTAINER_NAME='%s'
+CONTAINER_NAME=%s
 CONTAINER_PID=$(docker inspect -f '{{.State.Pid}}' "$CONTAINER_NAME" 2>/dev/null)
 if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
     exit 1
@@ -483,7 +484,7 @@ VETH_NAME=$(ip -o link show 2>/dev/null | awk -v idx="$HOST_VETH_IFINDEX" -F': '
 if [ -n "$VETH_NAME" ]; then
     echo "$VETH_NAME"
 fi
-`, instance.Name)
+`, utils.ShellQuote(instance.Name))
 
 	vethOutput, err := d.sshClient.Execute(vethCmd)
 	if err == nil {
@@ -501,7 +502,7 @@ fi
 
 	// 如果没有获取到PrivateIP，尝试使用旧方法获取
 	if instance.PrivateIP == "" {
-		cmd := fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", instance.Name)
+		cmd := fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", utils.ShellQuote(instance.Name))
 		output, err := d.sshClient.Execute(cmd)
 		if err == nil {
 			ipAddress := strings.TrimSpace(output)
@@ -516,11 +517,11 @@ fi
 	}
 
 	// 3. 检查容器是否连接到ipv6_net网络，如果是则获取IPv6地址
-	checkIPv6Cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$net}}{{println}}{{end}}'", instance.Name)
+	checkIPv6Cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$net}}{{println}}{{end}}'", utils.ShellQuote(instance.Name))
 	networksOutput, err := d.sshClient.Execute(checkIPv6Cmd)
 	if err == nil && strings.Contains(networksOutput, "ipv6_net") {
 		// 容器连接到了ipv6_net，获取IPv6地址
-		cmd = fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{if $config.GlobalIPv6Address}}{{$config.GlobalIPv6Address}}{{end}}{{end}}'", instance.Name)
+		cmd = fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{if $config.GlobalIPv6Address}}{{$config.GlobalIPv6Address}}{{end}}{{end}}'", utils.ShellQuote(instance.Name))
 		output, err = d.sshClient.Execute(cmd)
 		if err == nil {
 			ipv6Address := strings.TrimSpace(output)
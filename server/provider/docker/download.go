@@ -18,7 +18,7 @@ func (d *DockerProvider) downloadImageToRemote(imageURL, imageName, providerCoun
 	downloadDir := "/usr/local/bin/docker_ct_images"
 
 	// 在远程服务器上创建下载目录
-	cmd := fmt.Sprintf("mkdir -p %s", downloadDir)
+	cmd := fmt.Sprintf("mkdir -p %s", utils.ShellQuote(downloadDir))
 	_, err := d.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("创建远程下载目录失败: %w", err)
@@ -36,21 +36,23 @@ func (d *DockerProvider) downloadImageToRemote(imageURL, imageName, providerCoun
 		return remotePath, nil
 	}
 
-	// 确定下载URL，传递 useCDN 参数
-	downloadURL := d.getDownloadURL(imageURL, providerCountry, useCDN)
+	// 确定候选下载来源，传递 useCDN 参数
+	sources := d.getDownloadSources(imageURL, useCDN)
 
 	global.APP_LOG.Info("开始在远程服务器下载镜像",
 		zap.String("imageName", imageName),
-		zap.String("downloadURL", downloadURL),
+		zap.Int("sources", len(sources)),
 		zap.String("remotePath", remotePath),
 		zap.Bool("useCDN", useCDN))
 
-	// 在远程服务器上下载文件
-	if err := d.downloadFileToRemote(downloadURL, remotePath); err != nil {
+	// 在远程服务器上下载文件，失败时轮换下载来源
+	source, err := utils.DownloadToRemote(d.sshClient, sources, remotePath)
+	if err != nil {
 		// 下载失败，删除不完整的文件
 		d.removeRemoteFile(remotePath)
 		return "", fmt.Errorf("远程下载镜像失败: %w", err)
 	}
+	utils.RecordImageSource(d.config.ID, source.Base)
 
 	global.APP_LOG.Info("远程镜像下载完成",
 		zap.String("imageName", imageName),
@@ -88,14 +90,14 @@ func (d *DockerProvider) generateRemoteFileName(imageName, imageURL, architectur
 // isRemoteFileValid 检查远程文件是否存在且完整
 func (d *DockerProvider) isRemoteFileValid(remotePath string) bool {
 	// 检查文件是否存在且大小大于0
-	cmd := fmt.Sprintf("test -f %s -a -s %s", remotePath, remotePath)
+	cmd := fmt.Sprintf("test -f %s -a -s %s", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath))
 	_, err := d.sshClient.Execute(cmd)
 	return err == nil
 }
 
 // removeRemoteFile 删除远程文件
 func (d *DockerProvider) removeRemoteFile(remotePath string) error {
-	cmd := fmt.Sprintf("rm -f %s", remotePath)
+	cmd := fmt.Sprintf("rm -f %s", utils.ShellQuote(remotePath))
 	_, err := d.sshClient.Execute(cmd)
 	return err
 }
@@ -107,8 +109,8 @@ func (d *DockerProvider) downloadFileToRemote(url, remotePath string) error {
 
 	// 下载文件，支持断点续传
 	curlCmd := fmt.Sprintf(
-		"curl -4 -L -C - --connect-timeout 30 --retry 5 --retry-delay 10 --retry-max-time 0 -o %s '%s'",
-		tmpPath, url,
+		"curl -4 -L -C - --connect-timeout 30 --retry 5 --retry-delay 10 --retry-max-time 0 -o %s %s",
+		utils.ShellQuote(tmpPath), utils.ShellQuote(url),
 	)
 
 	global.APP_LOG.Info("执行远程下载命令",
@@ -117,7 +119,7 @@ func (d *DockerProvider) downloadFileToRemote(url, remotePath string) error {
 	output, err := d.sshClient.Execute(curlCmd)
 	if err != nil {
 		// 清理临时文件
-		d.sshClient.Execute(fmt.Sprintf("rm -f %s", tmpPath))
+		d.sshClient.Execute(fmt.Sprintf("rm -f %s", utils.ShellQuote(tmpPath)))
 
 		global.APP_LOG.Error("远程下载失败",
 			zap.String("url", utils.TruncateString(url, 100)),
@@ -128,7 +130,7 @@ func (d *DockerProvider) downloadFileToRemote(url, remotePath string) error {
 	}
 
 	// 移动文件到最终位置
-	mvCmd := fmt.Sprintf("mv %s %s", tmpPath, remotePath)
+	mvCmd := fmt.Sprintf("mv %s %s", utils.ShellQuote(tmpPath), utils.ShellQuote(remotePath))
 	_, err = d.sshClient.Execute(mvCmd)
 	if err != nil {
 		global.APP_LOG.Error("移动文件失败",
@@ -198,7 +200,7 @@ func (d *DockerProvider) ensureSSHScriptsAvailable(providerCountry string) error
 		}
 
 		// 设置执行权限
-		chmodCmd := fmt.Sprintf("chmod +x %s", scriptPath)
+		chmodCmd := fmt.Sprintf("chmod +x %s", utils.ShellQuote(scriptPath))
 		if _, err := d.sshClient.Execute(chmodCmd); err != nil {
 			global.APP_LOG.Error("设置SSH脚本执行权限失败",
 				zap.String("script", script),
@@ -207,7 +209,7 @@ func (d *DockerProvider) ensureSSHScriptsAvailable(providerCountry string) error
 		}
 
 		// 使用dos2unix处理脚本格式（如果可用）
-		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", scriptPath)
+		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", utils.ShellQuote(scriptPath))
 		d.sshClient.Execute(dos2unixCmd)
 
 		global.APP_LOG.Info("SSH脚本下载并设置完成",
@@ -225,7 +227,7 @@ func (d *DockerProvider) getSSHScriptDownloadURL(originalURL, providerCountry st
 	if providerCountry == "CN" || providerCountry == "cn" {
 		if cdnURL := d.getSSHScriptCDNURL(originalURL); cdnURL != "" {
 			// 测试CDN可用性
-			testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+			testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 			if _, err := d.sshClient.Execute(testCmd); err == nil {
 				global.APP_LOG.Info("使用CDN下载SSH脚本",
 					zap.String("cdnURL", cdnURL))
@@ -246,7 +248,7 @@ func (d *DockerProvider) getSSHScriptCDNURL(originalURL string) string {
 	for _, endpoint := range cdnEndpoints {
 		cdnURL := endpoint + originalURL
 		// 测试CDN可用性
-		testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+		testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 		if _, err := d.sshClient.Execute(testCmd); err == nil {
 			return cdnURL
 		}
@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"oneclickvirt/global"
@@ -46,7 +47,7 @@ func (d *DockerProvider) sshListImages(ctx context.Context) ([]provider.Image, e
 
 // sshPullImage 拉取镜像
 func (d *DockerProvider) sshPullImage(ctx context.Context, image string) error {
-	pullCmd := fmt.Sprintf("docker pull %s", image)
+	pullCmd := fmt.Sprintf("docker pull %s", utils.ShellQuote(image))
 	global.APP_LOG.Info("开始拉取Docker镜像",
 		zap.String("image", utils.TruncateString(image, 64)),
 		zap.String("command", pullCmd))
@@ -67,7 +68,7 @@ func (d *DockerProvider) sshPullImage(ctx context.Context, image string) error {
 
 // sshDeleteImage 删除镜像
 func (d *DockerProvider) sshDeleteImage(ctx context.Context, id string) error {
-	_, err := d.sshClient.Execute(fmt.Sprintf("docker rmi -f %s", id))
+	_, err := d.sshClient.Execute(fmt.Sprintf("docker rmi -f %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to delete image: %w", err)
 	}
@@ -78,7 +79,7 @@ func (d *DockerProvider) sshDeleteImage(ctx context.Context, id string) error {
 
 // loadImageToDocker 加载镜像到Docker
 func (d *DockerProvider) loadImageToDocker(imagePath, targetImageName string) error {
-	loadCmd := fmt.Sprintf("docker load -i %s", imagePath)
+	loadCmd := fmt.Sprintf("docker load -i %s", utils.ShellQuote(imagePath))
 
 	global.APP_LOG.Info("开始加载Docker镜像",
 		zap.String("imagePath", utils.TruncateString(imagePath, 64)),
@@ -109,7 +110,7 @@ func (d *DockerProvider) loadImageToDocker(imagePath, targetImageName string) er
 	}
 	// 如果找到了加载的镜像名称且与目标名称不同，则重新标记
 	if loadedImageName != "" && loadedImageName != targetImageName {
-		tagCmd := fmt.Sprintf("docker tag %s %s", loadedImageName, targetImageName)
+		tagCmd := fmt.Sprintf("docker tag %s %s", utils.ShellQuote(loadedImageName), utils.ShellQuote(targetImageName))
 		global.APP_LOG.Info("重新标记Docker镜像",
 			zap.String("sourceImage", utils.TruncateString(loadedImageName, 64)),
 			zap.String("targetImage", utils.TruncateString(targetImageName, 64)),
@@ -137,7 +138,7 @@ func (d *DockerProvider) loadImageToDocker(imagePath, targetImageName string) er
 // cleanupDockerImage 清理Docker镜像
 func (d *DockerProvider) cleanupDockerImage(imageName string) {
 	// 删除损坏的Docker镜像（忽略错误）
-	d.sshClient.Execute(fmt.Sprintf("docker rmi -f %s", imageName))
+	d.sshClient.Execute(fmt.Sprintf("docker rmi -f %s", utils.ShellQuote(imageName)))
 	// 清理未使用的镜像
 	d.sshClient.Execute("docker image prune -f")
 	global.APP_LOG.Info("清理Docker镜像", zap.String("imageName", utils.TruncateString(imageName, 64)))
@@ -145,7 +146,7 @@ func (d *DockerProvider) cleanupDockerImage(imageName string) {
 
 // imageExists 检查Docker镜像是否已存在
 func (d *DockerProvider) imageExists(imageName string) bool {
-	output, err := d.sshClient.Execute(fmt.Sprintf("docker images --format '{{.Repository}}:{{.Tag}}' | grep -E '^%s($|:)'", imageName))
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker images --format '{{.Repository}}:{{.Tag}}' | grep -E %s", utils.ShellQuote("^"+regexp.QuoteMeta(imageName)+"($|:)")))
 	if err != nil {
 		global.APP_LOG.Debug("检查Docker镜像存在性失败",
 			zap.String("imageName", utils.TruncateString(imageName, 64)),
@@ -71,7 +71,7 @@ func (d *DockerProvider) enrichInstancesWithNetworkInfo(instances *[]provider.In
 		}
 
 		// 1. 获取容器的内网IP地址
-		cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", instance.Name)
+		cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", utils.ShellQuote(instance.Name))
 		output, err := d.sshClient.Execute(cmd)
 		if err == nil {
 			ipAddress := utils.CleanCommandOutput(output)
@@ -86,7 +86,7 @@ func (d *DockerProvider) enrichInstancesWithNetworkInfo(instances *[]provider.In
 
 		// 2. 获取容器对应的宿主机veth接口
 		vethCmd := fmt.Sprintf(`
-CONTAINER_NAME='%s'
+CONTAINER_NAME=%s
 CONTAINER_PID=$(docker inspect -f '{{.State.Pid}}' "$CONTAINER_NAME" 2>/dev/null)
 if [ -z "$CONTAINER_PID" ] || [ "$CONTAINER_PID" = "0" ]; then
     exit 1
@@ -99,7 +99,7 @@ VETH_NAME=$(ip -o link show 2>/dev/null | awk -v idx="$HOST_VETH_IFINDEX" -F': '
 if [ -n "$VETH_NAME" ]; then
     echo "$VETH_NAME"
 fi
-`, instance.Name)
+`, utils.ShellQuote(instance.Name))
 
 		vethOutput, err := d.sshClient.Execute(vethCmd)
 		if err == nil {
@@ -117,7 +117,7 @@ fi
 
 		// 如果没有获取到PrivateIP，尝试使用旧方法获取
 		if instance.PrivateIP == "" {
-			cmd := fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", instance.Name)
+			cmd := fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", utils.ShellQuote(instance.Name))
 			output, err := d.sshClient.Execute(cmd)
 			if err == nil {
 				ipAddress := strings.TrimSpace(output)
@@ -132,11 +132,11 @@ fi
 		}
 
 		// 3. 检查容器是否连接到ipv6_net网络，如果是则获取IPv6地址
-		checkIPv6Cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$net}}{{println}}{{end}}'", instance.Name)
+		checkIPv6Cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$net}}{{println}}{{end}}'", utils.ShellQuote(instance.Name))
 		networksOutput, err := d.sshClient.Execute(checkIPv6Cmd)
 		if err == nil && strings.Contains(networksOutput, "ipv6_net") {
 			// 容器连接到了ipv6_net，获取IPv6地址
-			cmd = fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{if $config.GlobalIPv6Address}}{{$config.GlobalIPv6Address}}{{end}}{{end}}'", instance.Name)
+			cmd = fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{if $config.GlobalIPv6Address}}{{$config.GlobalIPv6Address}}{{end}}{{end}}'", utils.ShellQuote(instance.Name))
 			output, err = d.sshClient.Execute(cmd)
 			if err == nil {
 				ipv6Address := strings.TrimSpace(output)
@@ -261,7 +261,7 @@ func (d *DockerProvider) sshCreateInstanceWithProgress(ctx context.Context, conf
 	updateProgress(70, "清理同名残留容器...")
 	// 预先清理任何同名的残留容器（包括停止、失败或创建失败的容器）
 	// 这可以避免端口冲突和容器名称冲突
-	cleanupCmd := fmt.Sprintf("docker ps -a --filter name=^%s$ -q | xargs -r docker rm -f", config.Name)
+	cleanupCmd := fmt.Sprintf("docker ps -a --filter %s -q | xargs -r docker rm -f", utils.ShellQuote("name=^"+config.Name+"$"))
 	global.APP_LOG.Debug("创建前清理同名容器",
 		zap.String("instance", utils.TruncateString(config.Name, 32)),
 		zap.String("command", cleanupCmd))
@@ -280,7 +280,7 @@ func (d *DockerProvider) sshCreateInstanceWithProgress(ctx context.Context, conf
 
 	updateProgress(72, "构建Docker run命令...")
 	// 构建docker run命令
-	cmd := fmt.Sprintf("docker run -d --name %s", config.Name)
+	cmd := fmt.Sprintf("docker run -d --name %s", utils.ShellQuote(config.Name))
 
 	// 检查是否启用IPv6网络（支持标准的网络类型值）
 	networkType := d.config.NetworkType
@@ -461,11 +461,12 @@ func (d *DockerProvider) sshCreateInstanceWithProgress(ctx context.Context, conf
 	// 必要的能力
 	cmd += " --cap-add=MKNOD"
 
+	// 环境变量值可能来自管理员配置的模板，使用单引号避免被shell解析
 	for key, value := range config.Env {
-		cmd += fmt.Sprintf(" -e %s=%s", key, value)
+		cmd += " -e " + utils.ShellQuote(key+"="+value)
 	}
 
-	cmd += fmt.Sprintf(" %s", imageNameWithPrefix)
+	cmd += " " + utils.ShellQuote(imageNameWithPrefix)
 
 	updateProgress(95, "执行Docker创建命令...")
 	global.APP_LOG.Info("开始执行Docker创建命令",
@@ -501,7 +502,7 @@ func (d *DockerProvider) sshCreateInstanceWithProgress(ctx context.Context, conf
 		time.Sleep(checkInterval)
 
 		// 检查容器状态
-		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", config.Name))
+		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(config.Name)))
 		if err == nil {
 			status := strings.ToLower(strings.TrimSpace(statusOutput))
 			if status == "running" {
@@ -566,7 +567,7 @@ func (d *DockerProvider) sshCreateInstanceWithProgress(ctx context.Context, conf
 // sshStartInstance 启动实例
 func (d *DockerProvider) sshStartInstance(ctx context.Context, id string) error {
 	// 先检查容器状态，如果是Exited状态则使用restart命令
-	statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", id))
+	statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(id)))
 	if err != nil {
 		global.APP_LOG.Error("检查Docker容器状态失败",
 			zap.String("id", utils.TruncateString(id, 32)),
@@ -576,7 +577,7 @@ func (d *DockerProvider) sshStartInstance(ctx context.Context, id string) error
 
 	status := strings.ToLower(strings.TrimSpace(statusOutput))
 	var startCmd string
-	startCmd = fmt.Sprintf("docker restart %s", id)
+	startCmd = fmt.Sprintf("docker restart %s", utils.ShellQuote(id))
 	if strings.Contains(status, "exited") {
 		global.APP_LOG.Info("检测到容器为Exited状态，使用restart命令",
 			zap.String("id", utils.TruncateString(id, 32)),
@@ -615,7 +616,7 @@ func (d *DockerProvider) sshStartInstance(ctx context.Context, id string) error
 		time.Sleep(checkInterval)
 
 		// 检查容器状态
-		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", id))
+		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(id)))
 		if err == nil {
 			currentStatus := strings.ToLower(strings.TrimSpace(statusOutput))
 			if currentStatus == "running" {
@@ -636,7 +637,7 @@ func (d *DockerProvider) sshStartInstance(ctx context.Context, id string) error
 
 // sshStopInstance 停止实例
 func (d *DockerProvider) sshStopInstance(ctx context.Context, id string) error {
-	stopCmd := fmt.Sprintf("docker stop %s", id)
+	stopCmd := fmt.Sprintf("docker stop %s", utils.ShellQuote(id))
 	global.APP_LOG.Info("开始停止Docker实例",
 		zap.String("id", utils.TruncateString(id, 32)),
 		zap.String("command", stopCmd))
@@ -654,7 +655,7 @@ func (d *DockerProvider) sshStopInstance(ctx context.Context, id string) error {
 	maxRetries := 10
 	retryInterval := 1 * time.Second
 	for i := 0; i < maxRetries; i++ {
-		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", id))
+		statusOutput, err := d.sshClient.Execute(fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(id)))
 		if err != nil {
 			global.APP_LOG.Warn("检查Docker容器停止状态失败",
 				zap.String("id", utils.TruncateString(id, 32)),
@@ -686,7 +687,7 @@ func (d *DockerProvider) sshStopInstance(ctx context.Context, id string) error {
 
 // sshRestartInstance 重启实例
 func (d *DockerProvider) sshRestartInstance(ctx context.Context, id string) error {
-	restartCmd := fmt.Sprintf("docker restart %s", id)
+	restartCmd := fmt.Sprintf("docker restart %s", utils.ShellQuote(id))
 	global.APP_LOG.Info("开始重启Docker实例",
 		zap.String("id", utils.TruncateString(id, 32)),
 		zap.String("command", restartCmd))
@@ -711,7 +712,7 @@ func (d *DockerProvider) sshDeleteInstance(ctx context.Context, id string) error
 		zap.String("id", utils.TruncateString(id, 32)))
 
 	// 预清理：先尝试删除所有同名的已停止容器（Exited状态）
-	cleanupCmd := fmt.Sprintf("docker ps -a --filter name=^%s$ --filter status=exited -q | xargs -r docker rm -f", id)
+	cleanupCmd := fmt.Sprintf("docker ps -a --filter %s --filter status=exited -q | xargs -r docker rm -f", utils.ShellQuote("name=^"+id+"$"))
 	global.APP_LOG.Debug("清理已停止的同名容器",
 		zap.String("id", utils.TruncateString(id, 32)),
 		zap.String("command", cleanupCmd))
@@ -737,30 +738,30 @@ func (d *DockerProvider) sshDeleteInstance(ctx context.Context, id string) error
 		{
 			name: "graceful_stop_and_remove",
 			commands: []string{
-				fmt.Sprintf("docker stop %s", id),
-				fmt.Sprintf("docker rm %s", id),
+				fmt.Sprintf("docker stop %s", utils.ShellQuote(id)),
+				fmt.Sprintf("docker rm %s", utils.ShellQuote(id)),
 			},
 			description: "优雅停止并删除容器",
 		},
 		{
 			name: "force_remove_running",
 			commands: []string{
-				fmt.Sprintf("docker rm -f %s", id),
+				fmt.Sprintf("docker rm -f %s", utils.ShellQuote(id)),
 			},
 			description: "强制删除正在运行的容器",
 		},
 		{
 			name: "kill_and_remove",
 			commands: []string{
-				fmt.Sprintf("docker kill %s", id),
-				fmt.Sprintf("docker rm %s", id),
+				fmt.Sprintf("docker kill %s", utils.ShellQuote(id)),
+				fmt.Sprintf("docker rm %s", utils.ShellQuote(id)),
 			},
 			description: "强制杀死进程并删除容器",
 		},
 		{
 			name: "system_prune_targeted",
 			commands: []string{
-				fmt.Sprintf("docker rm -f %s", id),
+				fmt.Sprintf("docker rm -f %s", utils.ShellQuote(id)),
 				"docker system prune -f --volumes",
 			},
 			description: "删除容器并清理系统资源",
@@ -882,7 +883,7 @@ func (d *DockerProvider) sshDeleteInstance(ctx context.Context, id string) error
 	global.APP_LOG.Info("执行最终清理，删除所有同名已停止容器",
 		zap.String("id", utils.TruncateString(id, 32)))
 
-	finalCleanupCmd := fmt.Sprintf("docker ps -a --filter name=^%s$ -q | xargs -r docker rm -f", id)
+	finalCleanupCmd := fmt.Sprintf("docker ps -a --filter %s -q | xargs -r docker rm -f", utils.ShellQuote("name=^"+id+"$"))
 	finalOutput, finalErr := d.sshClient.Execute(finalCleanupCmd)
 	if finalErr != nil {
 		global.APP_LOG.Debug("最终清理失败（可忽略）",
@@ -932,7 +933,7 @@ func (d *DockerProvider) isAcceptableError(err error, output string) bool {
 // verifyContainerDeleted 验证容器是否真的被删除（包括已停止的容器）
 func (d *DockerProvider) verifyContainerDeleted(ctx context.Context, id string) bool {
 	// 方法1：检查运行中的容器
-	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", id)
+	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(id))
 	output, err := d.sshClient.Execute(checkCmd)
 
 	if err != nil {
@@ -961,7 +962,7 @@ func (d *DockerProvider) verifyContainerDeleted(ctx context.Context, id string)
 
 	// 方法2：通过docker ps -a检查所有状态的容器（包括已停止的）
 	// 使用精确匹配的name filter
-	listByNameCmd := fmt.Sprintf("docker ps -a --filter name=^%s$ --format '{{.Names}}:{{.Status}}'", id)
+	listByNameCmd := fmt.Sprintf("docker ps -a --filter %s --format '{{.Names}}:{{.Status}}'", utils.ShellQuote("name=^"+id+"$"))
 	listByNameOutput, listByNameErr := d.sshClient.Execute(listByNameCmd)
 
 	if listByNameErr == nil {
@@ -976,7 +977,7 @@ func (d *DockerProvider) verifyContainerDeleted(ctx context.Context, id string)
 	}
 
 	// 方法3：用ID进行filter检查
-	listCmd := fmt.Sprintf("docker ps -a --filter id=%s --format '{{.ID}}'", id)
+	listCmd := fmt.Sprintf("docker ps -a --filter %s --format '{{.ID}}'", utils.ShellQuote("id="+id))
 	listOutput, listErr := d.sshClient.Execute(listCmd)
 
 	if listErr == nil && strings.TrimSpace(listOutput) != "" {
@@ -1064,7 +1065,7 @@ func (d *DockerProvider) checkLXCFS() (bool, []string, string, error) {
 	var availableFiles []string
 
 	for hostPath, containerPath := range potentialMounts {
-		checkCmd := fmt.Sprintf("[ -f '%s' ] && echo 'exists' || echo 'not_exists'", hostPath)
+		checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'not_exists'", utils.ShellQuote(hostPath))
 		output, err := d.sshClient.Execute(checkCmd)
 		if err == nil && strings.TrimSpace(output) == "exists" {
 			volumeMount := fmt.Sprintf("--volume %s:%s:rw", hostPath, containerPath)
@@ -1102,7 +1103,7 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 	// 根据系统类型选择脚本
 	var scriptName string
 	// 检测系统类型
-	output, err := d.sshClient.Execute(fmt.Sprintf("docker exec %s cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", config.Name))
+	output, err := d.sshClient.Execute(fmt.Sprintf("docker exec %s cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", utils.ShellQuote(config.Name)))
 	if err == nil {
 		osType := utils.CleanCommandOutput(strings.ToLower(output))
 		if osType == "alpine" || osType == "openwrt" {
@@ -1124,7 +1125,7 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 	} else {
 		time.Sleep(3 * time.Second)
 		// 复制脚本到容器
-		copyCmd := fmt.Sprintf("docker cp %s %s:/root/", scriptPath, config.Name)
+		copyCmd := fmt.Sprintf("docker cp %s %s:/root/", utils.ShellQuote(scriptPath), utils.ShellQuote(config.Name))
 		_, err = d.sshClient.Execute(copyCmd)
 		if err != nil {
 			global.APP_LOG.Warn("复制SSH脚本到容器失败",
@@ -1133,12 +1134,12 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 				zap.Error(err))
 		} else {
 			// 设置脚本权限
-			_, err = d.sshClient.Execute(fmt.Sprintf("docker exec %s chmod +x /root/%s", config.Name, scriptName))
+			_, err = d.sshClient.Execute(fmt.Sprintf("docker exec %s chmod +x /root/%s", utils.ShellQuote(config.Name), scriptName))
 			if err != nil {
 				global.APP_LOG.Warn("设置脚本权限失败", zap.Error(err))
 			} else {
 				// 执行脚本配置SSH和密码
-				execCmd := fmt.Sprintf("docker exec %s /root/%s %s", config.Name, scriptName, password)
+				execCmd := fmt.Sprintf("docker exec %s /root/%s %s", utils.ShellQuote(config.Name), scriptName, utils.ShellQuote(password))
 				_, execErr := d.sshClient.Execute(execCmd)
 				if execErr != nil {
 					global.APP_LOG.Warn("执行SSH配置脚本失败，将使用直接设置密码",
@@ -1151,8 +1152,8 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 		}
 	}
 
-	// 直接使用docker exec设置密码
-	directPasswordCmd := fmt.Sprintf("docker exec %s bash -c 'echo \"root:%s\" | chpasswd'", config.Name, password)
+	// 直接使用docker exec设置密码，密码通过标准输入传给chpasswd，不经过容器内的shell
+	directPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | docker exec -i %s chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(config.Name))
 	_, err = d.sshClient.Execute(directPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("设置容器密码失败",
@@ -1161,6 +1162,9 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 		return fmt.Errorf("设置容器密码失败: %w", err)
 	}
 
+	// SSH脚本会重写sshd_config，需在其之后应用实例内SSH端口
+	d.applyGuestSSHPort(config.Name, provider.GuestSSHPortFromConfig(config))
+
 	global.APP_LOG.Info("Docker容器SSH密码配置成功",
 		zap.String("instanceName", config.Name))
 
@@ -1182,7 +1186,7 @@ func (d *DockerProvider) configureInstanceSSHPassword(ctx context.Context, confi
 
 // getContainerPrivateIP 获取容器的内网IP地址
 func (d *DockerProvider) getContainerPrivateIP(containerName string) (string, error) {
-	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", containerName)
+	cmd := fmt.Sprintf("docker inspect %s --format '{{range $net, $config := .NetworkSettings.Networks}}{{$config.IPAddress}}{{end}}'", utils.ShellQuote(containerName))
 	output, err := d.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("failed to get container IP: %w", err)
@@ -1191,7 +1195,7 @@ func (d *DockerProvider) getContainerPrivateIP(containerName string) (string, er
 	ipAddress := utils.CleanCommandOutput(output)
 	if ipAddress == "" || ipAddress == "<no value>" {
 		// 尝试使用默认网络
-		cmd = fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", containerName)
+		cmd = fmt.Sprintf("docker inspect %s --format '{{.NetworkSettings.IPAddress}}'", utils.ShellQuote(containerName))
 		output, err = d.sshClient.Execute(cmd)
 		if err != nil {
 			return "", fmt.Errorf("failed to get container IP from default network: %w", err)
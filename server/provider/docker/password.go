@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	"oneclickvirt/provider"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -54,7 +55,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	var containerStatus string
 	maxRetries := 3
 	for i := 0; i < maxRetries; i++ {
-		checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", instanceID)
+		checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(instanceID))
 		output, err := d.sshClient.Execute(checkCmd)
 		if err != nil {
 			global.APP_LOG.Error("检查容器状态失败",
@@ -100,7 +101,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	}
 
 	// 额外检查容器是否真正可用（测试基础命令）
-	healthCheckCmd := fmt.Sprintf("docker exec %s echo 'container_ready' 2>/dev/null", instanceID)
+	healthCheckCmd := fmt.Sprintf("docker exec %s echo 'container_ready' 2>/dev/null", utils.ShellQuote(instanceID))
 	healthOutput, err := d.sshClient.Execute(healthCheckCmd)
 	if err != nil || !strings.Contains(healthOutput, "container_ready") {
 		global.APP_LOG.Warn("容器健康检查失败，再等待一段时间",
@@ -120,7 +121,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	}
 
 	// 检查SSH相关进程和服务是否可用（更具体的就绪检查）
-	sshReadinessCmd := fmt.Sprintf("docker exec %s sh -c 'command -v passwd >/dev/null 2>&1 && echo ssh_ready' 2>/dev/null", instanceID)
+	sshReadinessCmd := fmt.Sprintf("docker exec %s sh -c 'command -v passwd >/dev/null 2>&1 && echo ssh_ready' 2>/dev/null", utils.ShellQuote(instanceID))
 	sshOutput, err := d.sshClient.Execute(sshReadinessCmd)
 	if err != nil || !strings.Contains(sshOutput, "ssh_ready") {
 		global.APP_LOG.Warn("SSH服务未就绪，等待初始化",
@@ -158,7 +159,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 		zap.String("instanceID", utils.TruncateString(instanceID, 12)))
 
 	// 检测容器操作系统类型
-	osCmd := fmt.Sprintf("docker exec %s cat /etc/os-release 2>/dev/null | grep -E '^ID=' | cut -d '=' -f 2 | tr -d '\"'", instanceID)
+	osCmd := fmt.Sprintf("docker exec %s cat /etc/os-release 2>/dev/null | grep -E '^ID=' | cut -d '=' -f 2 | tr -d '\"'", utils.ShellQuote(instanceID))
 	osOutput, err := d.sshClient.Execute(osCmd)
 	osType := utils.CleanCommandOutput(osOutput)
 	if err != nil || osType == "" {
@@ -197,7 +198,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	}
 
 	// 检查容器内是否已存在SSH脚本
-	checkScriptCmd := fmt.Sprintf("docker exec %s %s -c '[ -f /%s ]'", instanceID, shellType, scriptName)
+	checkScriptCmd := fmt.Sprintf("docker exec %s %s -c '[ -f /%s ]'", utils.ShellQuote(instanceID), shellType, scriptName)
 	_, err = d.sshClient.Execute(checkScriptCmd)
 
 	if err != nil {
@@ -207,7 +208,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 			zap.String("scriptName", scriptName))
 
 		// 复制脚本到容器内
-		copyCmd := fmt.Sprintf("docker cp \"%s\" \"%s:/%s\"", hostScriptPath, instanceID, scriptName)
+		copyCmd := fmt.Sprintf("docker cp %s %s", utils.ShellQuote(hostScriptPath), utils.ShellQuote(instanceID+":/"+scriptName))
 		_, err = d.sshClient.Execute(copyCmd)
 		if err != nil {
 			global.APP_LOG.Error("复制SSH脚本到容器失败",
@@ -218,7 +219,7 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 		}
 
 		// 给脚本添加执行权限
-		chmodCmd := fmt.Sprintf("docker exec %s %s -c 'chmod +x /%s'", instanceID, shellType, scriptName)
+		chmodCmd := fmt.Sprintf("docker exec %s %s -c 'chmod +x /%s'", utils.ShellQuote(instanceID), shellType, scriptName)
 		_, err = d.sshClient.Execute(chmodCmd)
 		if err != nil {
 			global.APP_LOG.Error("设置SSH脚本执行权限失败",
@@ -237,11 +238,11 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	}
 
 	// 设置interactionless环境变量，避免交互式操作
-	envCmd := fmt.Sprintf("docker exec %s %s -c 'export interactionless=true'", instanceID, shellType)
+	envCmd := fmt.Sprintf("docker exec %s %s -c 'export interactionless=true'", utils.ShellQuote(instanceID), shellType)
 	d.sshClient.Execute(envCmd)
 
 	// 执行SSH配置脚本
-	executeScriptCmd := fmt.Sprintf("docker exec %s %s -c 'interactionless=true %s /%s %s'", instanceID, shellType, shellType, scriptName, password)
+	executeScriptCmd := fmt.Sprintf("docker exec -e interactionless=true %s %s /%s %s", utils.ShellQuote(instanceID), shellType, scriptName, utils.ShellQuote(password))
 	scriptOutput, err := d.sshClient.Execute(executeScriptCmd)
 	if err != nil {
 		global.APP_LOG.Error("执行SSH配置脚本失败",
@@ -252,8 +253,8 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 		return fmt.Errorf("执行SSH配置脚本失败: %w", err)
 	}
 
-	// 额外使用chpasswd命令确保密码设置
-	setPasswordCmd := fmt.Sprintf("docker exec %s %s -c 'echo \"root:%s\" | chpasswd'", instanceID, shellType, password)
+	// 额外使用chpasswd命令确保密码设置，密码通过标准输入传入
+	setPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | docker exec -i %s chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(instanceID))
 	_, err = d.sshClient.Execute(setPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("使用chpasswd设置密码失败",
@@ -262,6 +263,9 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 		return fmt.Errorf("使用chpasswd设置密码失败: %w", err)
 	}
 
+	// 重新执行的SSH脚本会还原sshd_config，需要再次应用实例内SSH端口
+	d.applyGuestSSHPort(instanceID, provider.LookupGuestSSHPort(d.config.ID, instanceID))
+
 	global.APP_LOG.Info("容器SSH密码设置成功",
 		zap.String("instanceID", utils.TruncateString(instanceID, 12)),
 		zap.String("osType", osType),
@@ -270,6 +274,26 @@ func (d *DockerProvider) sshSetInstancePassword(ctx context.Context, instanceID,
 	return nil
 }
 
+// applyGuestSSHPort 将容器内sshd改为监听配置的SSH端口，默认22时无需处理
+func (d *DockerProvider) applyGuestSSHPort(instanceName string, port int) {
+	script := provider.GuestSSHPortScript(port)
+	if script == "" {
+		return
+	}
+	cmd := fmt.Sprintf("docker exec %s sh -c %s", utils.ShellQuote(instanceName), utils.ShellQuote(script))
+	if output, err := d.sshClient.Execute(cmd); err != nil {
+		global.APP_LOG.Warn("配置容器内SSH端口失败",
+			zap.String("instanceName", instanceName),
+			zap.Int("guestSSHPort", port),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("容器内SSH端口配置完成",
+		zap.String("instanceName", instanceName),
+		zap.Int("guestSSHPort", port))
+}
+
 // generateRandomPassword 生成随机密码（仅包含数字和大小写英文字母，长度不低于8位）
 func (d *DockerProvider) generateRandomPassword() string {
 	return utils.GenerateInstancePassword()
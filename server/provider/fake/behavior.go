@@ -0,0 +1,101 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 可注入延迟与故障的操作类型
+const (
+	OpConnect     = "connect"
+	OpHealthCheck = "health"
+	OpCreate      = "create"
+	OpStart       = "start"
+	OpStop        = "stop"
+	OpRestart     = "restart"
+	OpDelete      = "delete"
+	OpPassword    = "password"
+	OpImage       = "image"
+	OpExecute     = "exec"
+)
+
+// Behavior 模拟行为配置
+type Behavior struct {
+	Latency  time.Duration   // 每个操作的模拟耗时
+	FailRate float64         // 故障注入概率（0-1）
+	FailOps  map[string]bool // 只对这些操作注入故障，为空表示所有操作
+}
+
+var (
+	behaviorMu      sync.RWMutex
+	currentBehavior = loadBehaviorFromEnv()
+)
+
+// SetBehavior 设置模拟行为，测试中可随时调整
+func SetBehavior(b Behavior) {
+	behaviorMu.Lock()
+	defer behaviorMu.Unlock()
+	currentBehavior = b
+}
+
+// GetBehavior 获取当前模拟行为
+func GetBehavior() Behavior {
+	behaviorMu.RLock()
+	defer behaviorMu.RUnlock()
+	return currentBehavior
+}
+
+// loadBehaviorFromEnv 从环境变量读取模拟行为
+// FAKE_PROVIDER_LATENCY: 操作耗时，如 500ms、2s
+// FAKE_PROVIDER_FAIL_RATE: 故障概率，如 0.1
+// FAKE_PROVIDER_FAIL_OPS: 注入故障的操作，逗号分隔，如 create,start
+func loadBehaviorFromEnv() Behavior {
+	var b Behavior
+	if v := os.Getenv("FAKE_PROVIDER_LATENCY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			b.Latency = d
+		}
+	}
+	if v := os.Getenv("FAKE_PROVIDER_FAIL_RATE"); v != "" {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil {
+			b.FailRate = rate
+		}
+	}
+	if v := os.Getenv("FAKE_PROVIDER_FAIL_OPS"); v != "" {
+		b.FailOps = make(map[string]bool)
+		for _, op := range strings.Split(v, ",") {
+			if op = strings.TrimSpace(op); op != "" {
+				b.FailOps[op] = true
+			}
+		}
+	}
+	return b
+}
+
+// simulate 按配置模拟操作耗时，并按概率注入故障
+func simulate(ctx context.Context, op string) error {
+	b := GetBehavior()
+	if b.Latency > 0 {
+		select {
+		case <-time.After(b.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if b.FailRate <= 0 {
+		return nil
+	}
+	if len(b.FailOps) > 0 && !b.FailOps[op] {
+		return nil
+	}
+	if b.FailRate >= 1 || rand.Float64() < b.FailRate {
+		return fmt.Errorf("fake provider: injected failure for %s", op)
+	}
+	return nil
+}
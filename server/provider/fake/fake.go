@@ -0,0 +1,170 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+
+	"go.uber.org/zap"
+)
+
+// FakeProvider 模拟Provider，在内存中模拟实例生命周期、IP分配和SSH命令执行
+// 用于CI和本地开发时在没有真实虚拟化宿主机的情况下测试任务引擎与API
+type FakeProvider struct {
+	config        provider.NodeConfig
+	store         *instanceStore
+	connected     bool
+	healthChecker *fakeHealthChecker
+	mu            sync.RWMutex
+}
+
+func NewFakeProvider() provider.Provider {
+	return &FakeProvider{}
+}
+
+func (f *FakeProvider) GetType() string {
+	return "fake"
+}
+
+func (f *FakeProvider) GetName() string {
+	return f.config.Name
+}
+
+func (f *FakeProvider) GetSupportedInstanceTypes() []string {
+	return []string{"container", "vm"}
+}
+
+func (f *FakeProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	if err := simulate(ctx, OpConnect); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.config = config
+	// 同一Provider ID共享实例存储，重连后实例依然存在
+	f.store = getInstanceStore(config.ID)
+	f.healthChecker = &fakeHealthChecker{config: health.HealthConfig{
+		ProviderID:   config.ID,
+		ProviderName: config.Name,
+		Host:         config.Host,
+		Port:         config.Port,
+	}}
+	f.connected = true
+
+	global.APP_LOG.Info("Fake provider连接成功",
+		zap.Uint("providerId", config.ID),
+		zap.String("name", config.Name))
+	return nil
+}
+
+func (f *FakeProvider) Disconnect(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.connected = false
+	return nil
+}
+
+func (f *FakeProvider) IsConnected() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.connected
+}
+
+func (f *FakeProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	if !f.IsConnected() {
+		return nil, fmt.Errorf("fake provider not connected")
+	}
+	if err := simulate(ctx, OpHealthCheck); err != nil {
+		return &health.HealthResult{
+			Status:    health.HealthStatusUnhealthy,
+			Timestamp: time.Now(),
+			SSHStatus: "offline",
+			APIStatus: "offline",
+			Errors:    []string{err.Error()},
+		}, nil
+	}
+	return f.healthChecker.CheckHealth(ctx)
+}
+
+func (f *FakeProvider) GetHealthChecker() health.HealthChecker {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.healthChecker == nil {
+		return nil
+	}
+	return f.healthChecker
+}
+
+func (f *FakeProvider) GetVersion() string {
+	return "fake-1.0"
+}
+
+// ExecuteSSHCommand 记录命令并返回空输出，不在任何主机上执行
+func (f *FakeProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	if !f.IsConnected() {
+		return "", fmt.Errorf("fake provider not connected")
+	}
+	if err := simulate(ctx, OpExecute); err != nil {
+		return "", err
+	}
+	f.store.recordCommand(command)
+	global.APP_LOG.Debug("Fake provider执行命令", zap.String("command", command))
+	return "", nil
+}
+
+// ExecutedCommands 返回该Provider记录的SSH命令，便于测试断言
+func (f *FakeProvider) ExecutedCommands() []string {
+	if f.store == nil {
+		return nil
+	}
+	return f.store.commandHistory()
+}
+
+func (f *FakeProvider) ensureConnected() error {
+	if !f.IsConnected() {
+		return fmt.Errorf("fake provider not connected")
+	}
+	return nil
+}
+
+// fakeHealthChecker 始终健康的健康检查器
+type fakeHealthChecker struct {
+	config health.HealthConfig
+}
+
+func (h *fakeHealthChecker) CheckHealth(ctx context.Context) (*health.HealthResult, error) {
+	return &health.HealthResult{
+		Status:        health.HealthStatusHealthy,
+		Timestamp:     time.Now(),
+		SSHStatus:     "online",
+		APIStatus:     "online",
+		ServiceStatus: "online",
+		HostName:      "fake-" + h.config.ProviderName,
+		ResourceInfo: &health.ResourceInfo{
+			CPUCores:    16,
+			MemoryTotal: 32768,
+			DiskTotal:   512000,
+			DiskFree:    409600,
+			Synced:      true,
+			HostName:    "fake-" + h.config.ProviderName,
+		},
+	}, nil
+}
+
+func (h *fakeHealthChecker) GetHealthStatus() health.HealthStatus {
+	return health.HealthStatusHealthy
+}
+
+func (h *fakeHealthChecker) SetConfig(config health.HealthConfig) {
+	h.config = config
+}
+
+func init() {
+	provider.RegisterProvider("fake", NewFakeProvider)
+}
@@ -0,0 +1,86 @@
+package fake
+
+import (
+	"context"
+	"testing"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+
+	"go.uber.org/zap"
+)
+
+func TestFakeProviderLifecycle(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	defer ResetStore(9001)
+	SetBehavior(Behavior{})
+
+	ctx := context.Background()
+	p := NewFakeProvider()
+	if err := p.Connect(ctx, provider.NodeConfig{ID: 9001, Name: "ci", Host: "192.0.2.10", NetworkType: "nat_ipv4_ipv6"}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	var lastProgress int
+	err := p.CreateInstanceWithProgress(ctx, provider.InstanceConfig{Name: "vm1", Image: "debian:12"}, func(percentage int, _ string) {
+		lastProgress = percentage
+	})
+	if err != nil || lastProgress != 100 {
+		t.Fatalf("create: err=%v progress=%d", err, lastProgress)
+	}
+
+	inst, err := p.GetInstance(ctx, "vm1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if inst.Status != constant.InstanceStatusRunning || inst.PrivateIP == "" || inst.PublicIP != "192.0.2.10" || inst.IPv6Address == "" {
+		t.Fatalf("unexpected instance: %+v", inst)
+	}
+
+	if err := p.StopInstance(ctx, "vm1"); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if inst, _ = p.GetInstance(ctx, "vm1"); inst.Status != constant.InstanceStatusStopped {
+		t.Fatalf("status after stop = %s", inst.Status)
+	}
+
+	// 重连后实例依然存在
+	reconnected := NewFakeProvider()
+	_ = reconnected.Connect(ctx, provider.NodeConfig{ID: 9001, Name: "ci"})
+	if list, _ := reconnected.ListInstances(ctx); len(list) != 1 {
+		t.Fatalf("instances after reconnect = %d", len(list))
+	}
+
+	if err := p.DeleteInstance(ctx, "vm1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := p.GetInstance(ctx, "vm1"); err == nil {
+		t.Fatal("instance should be deleted")
+	}
+}
+
+func TestFakeProviderFailureInjection(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	defer ResetStore(9002)
+	defer SetBehavior(Behavior{})
+
+	ctx := context.Background()
+	p := NewFakeProvider()
+	_ = p.Connect(ctx, provider.NodeConfig{ID: 9002, Name: "ci"})
+	if err := p.CreateInstance(ctx, provider.InstanceConfig{Name: "vm1"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	SetBehavior(Behavior{FailRate: 1, FailOps: map[string]bool{OpStart: true}})
+	if err := p.StopInstance(ctx, "vm1"); err != nil {
+		t.Fatalf("stop should not be affected: %v", err)
+	}
+	if err := p.StartInstance(ctx, "vm1"); err == nil {
+		t.Fatal("expected injected start failure")
+	}
+	// 失败后恢复原状态
+	if inst, _ := p.GetInstance(ctx, "vm1"); inst.Status != constant.InstanceStatusStopped {
+		t.Fatalf("status after failed start = %s", inst.Status)
+	}
+}
@@ -0,0 +1,321 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+)
+
+// maxCommandHistory 每个Provider保留的SSH命令记录条数
+const maxCommandHistory = 200
+
+// instanceStore 单个Provider的内存状态
+type instanceStore struct {
+	mu        sync.Mutex
+	instances map[string]*provider.Instance
+	images    map[string]provider.Image
+	commands  []string
+	nextIP    int
+}
+
+// stores 按Provider ID保存内存状态，providerID -> *instanceStore
+var stores sync.Map
+
+func getInstanceStore(providerID uint) *instanceStore {
+	value, _ := stores.LoadOrStore(providerID, &instanceStore{
+		instances: make(map[string]*provider.Instance),
+		images:    make(map[string]provider.Image),
+	})
+	return value.(*instanceStore)
+}
+
+// ResetStore 清空指定Provider的内存状态
+func ResetStore(providerID uint) {
+	stores.Delete(providerID)
+}
+
+func (s *instanceStore) recordCommand(command string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+	if len(s.commands) > maxCommandHistory {
+		s.commands = s.commands[len(s.commands)-maxCommandHistory:]
+	}
+}
+
+func (s *instanceStore) commandHistory() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// allocateAddresses 按顺序分配内网IPv4，需要时分配IPv6
+func (f *FakeProvider) allocateAddresses(inst *provider.Instance) {
+	f.store.nextIP++
+	seq := f.store.nextIP
+	inst.PrivateIP = fmt.Sprintf("10.%d.%d.%d", f.config.ID%256, seq/250, seq%250+2)
+	inst.IP = inst.PrivateIP
+
+	if strings.HasPrefix(f.config.NetworkType, "dedicated") {
+		inst.PublicIP = fmt.Sprintf("198.18.%d.%d", seq/250, seq%250+2)
+	} else if f.config.PortIP != "" {
+		inst.PublicIP = f.config.PortIP
+	} else {
+		inst.PublicIP = f.config.Host
+	}
+	if strings.Contains(f.config.NetworkType, "ipv6") {
+		inst.IPv6Address = fmt.Sprintf("fd00:%x::%x", f.config.ID, seq)
+	}
+	if f.config.NetworkType == "ipv6_only" {
+		inst.PublicIP = ""
+	}
+}
+
+func (f *FakeProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	if err := f.ensureConnected(); err != nil {
+		return nil, err
+	}
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+
+	instances := make([]provider.Instance, 0, len(f.store.instances))
+	for _, inst := range f.store.instances {
+		instances = append(instances, *inst)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+	return instances, nil
+}
+
+func (f *FakeProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return f.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+func (f *FakeProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	if err := f.ensureConnected(); err != nil {
+		return err
+	}
+	if config.Name == "" {
+		return fmt.Errorf("instance name is required")
+	}
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+	}
+
+	f.store.mu.Lock()
+	if _, exists := f.store.instances[config.Name]; exists {
+		f.store.mu.Unlock()
+		return fmt.Errorf("instance %s already exists", config.Name)
+	}
+	instanceType := config.InstanceType
+	if instanceType == "" {
+		instanceType = "container"
+	}
+	inst := &provider.Instance{
+		ID:       config.Name,
+		Name:     config.Name,
+		Status:   constant.InstanceStatusCreating,
+		Type:     instanceType,
+		Image:    config.Image,
+		CPU:      config.CPU,
+		Memory:   config.Memory,
+		Disk:     config.Disk,
+		Created:  time.Now(),
+		Metadata: make(map[string]string),
+	}
+	for k, v := range config.Metadata {
+		inst.Metadata[k] = v
+	}
+	f.store.instances[config.Name] = inst
+	f.store.mu.Unlock()
+
+	updateProgress(10, "准备模拟实例...")
+	if err := simulate(ctx, OpCreate); err != nil {
+		f.setStatus(config.Name, constant.InstanceStatusFailed)
+		return fmt.Errorf("failed to create instance: %w", err)
+	}
+
+	updateProgress(60, "分配网络地址...")
+	f.store.mu.Lock()
+	f.allocateAddresses(inst)
+	inst.Status = constant.InstanceStatusRunning
+	f.store.mu.Unlock()
+
+	updateProgress(100, "实例创建完成")
+	return nil
+}
+
+func (f *FakeProvider) StartInstance(ctx context.Context, id string) error {
+	return f.transition(ctx, id, OpStart, constant.InstanceStatusStarting, constant.InstanceStatusRunning)
+}
+
+func (f *FakeProvider) StopInstance(ctx context.Context, id string) error {
+	return f.transition(ctx, id, OpStop, constant.InstanceStatusStopping, constant.InstanceStatusStopped)
+}
+
+func (f *FakeProvider) RestartInstance(ctx context.Context, id string) error {
+	return f.transition(ctx, id, OpRestart, constant.InstanceStatusRestarting, constant.InstanceStatusRunning)
+}
+
+func (f *FakeProvider) DeleteInstance(ctx context.Context, id string) error {
+	if err := f.ensureConnected(); err != nil {
+		return err
+	}
+	if _, err := f.GetInstance(ctx, id); err != nil {
+		return err
+	}
+	if err := simulate(ctx, OpDelete); err != nil {
+		return fmt.Errorf("failed to delete instance: %w", err)
+	}
+	f.store.mu.Lock()
+	delete(f.store.instances, id)
+	f.store.mu.Unlock()
+	return nil
+}
+
+func (f *FakeProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if err := f.ensureConnected(); err != nil {
+		return nil, err
+	}
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	inst, ok := f.store.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("instance %s not found", id)
+	}
+	result := *inst
+	return &result, nil
+}
+
+// transition 模拟实例状态迁移：先进入中间状态，耗时结束后进入目标状态，失败时恢复原状态
+func (f *FakeProvider) transition(ctx context.Context, id, op, pending, target string) error {
+	if err := f.ensureConnected(); err != nil {
+		return err
+	}
+	f.store.mu.Lock()
+	inst, ok := f.store.instances[id]
+	if !ok {
+		f.store.mu.Unlock()
+		return fmt.Errorf("instance %s not found", id)
+	}
+	previous := inst.Status
+	inst.Status = pending
+	f.store.mu.Unlock()
+
+	if err := simulate(ctx, op); err != nil {
+		f.setStatus(id, previous)
+		return fmt.Errorf("failed to %s instance: %w", op, err)
+	}
+	f.setStatus(id, target)
+	return nil
+}
+
+func (f *FakeProvider) setStatus(id, status string) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	if inst, ok := f.store.instances[id]; ok {
+		inst.Status = status
+	}
+}
+
+func (f *FakeProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if _, err := f.GetInstance(ctx, instanceID); err != nil {
+		return err
+	}
+	if err := simulate(ctx, OpPassword); err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	f.store.mu.Lock()
+	f.store.instances[instanceID].Metadata["password"] = password
+	f.store.mu.Unlock()
+	return nil
+}
+
+func (f *FakeProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	password := utils.GenerateStrongPassword(utils.InstancePasswordLength())
+	if err := f.SetInstancePassword(ctx, instanceID, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+func (f *FakeProvider) DiscoverInstances(ctx context.Context) ([]provider.DiscoveredInstance, error) {
+	instances, err := f.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discovered := make([]provider.DiscoveredInstance, 0, len(instances))
+	for _, inst := range instances {
+		discovered = append(discovered, provider.DiscoveredInstance{
+			UUID:         inst.ID,
+			Name:         inst.Name,
+			Status:       inst.Status,
+			InstanceType: inst.Type,
+			PrivateIP:    inst.PrivateIP,
+			PublicIP:     inst.PublicIP,
+			IPv6Address:  inst.IPv6Address,
+			Image:        inst.Image,
+			OSType:       "linux",
+			RawData:      inst,
+		})
+	}
+	return discovered, nil
+}
+
+func (f *FakeProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	if err := f.ensureConnected(); err != nil {
+		return nil, err
+	}
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	images := make([]provider.Image, 0, len(f.store.images))
+	for _, image := range f.store.images {
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ID < images[j].ID })
+	return images, nil
+}
+
+func (f *FakeProvider) PullImage(ctx context.Context, image string) error {
+	if err := f.ensureConnected(); err != nil {
+		return err
+	}
+	if err := simulate(ctx, OpImage); err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	name, tag := image, "latest"
+	if idx := strings.LastIndex(image, ":"); idx > 0 {
+		name, tag = image[:idx], image[idx+1:]
+	}
+	f.store.mu.Lock()
+	f.store.images[image] = provider.Image{
+		ID:      image,
+		Name:    name,
+		Tag:     tag,
+		Size:    "100MB",
+		Created: time.Now(),
+	}
+	f.store.mu.Unlock()
+	return nil
+}
+
+func (f *FakeProvider) DeleteImage(ctx context.Context, id string) error {
+	if err := f.ensureConnected(); err != nil {
+		return err
+	}
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	if _, ok := f.store.images[id]; !ok {
+		return fmt.Errorf("image %s not found", id)
+	}
+	delete(f.store.images, id)
+	return nil
+}
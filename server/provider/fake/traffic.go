@@ -0,0 +1,55 @@
+package fake
+
+import (
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SimulateTraffic 为实例写入模拟流量，累加到当前5分钟时间槽的pmacct流量记录
+// 用于在没有pmacct采集的环境下测试流量统计与限额逻辑
+func SimulateTraffic(instanceID uint, rxBytes, txBytes int64) error {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return fmt.Errorf("实例不存在: %v", err)
+	}
+
+	now := time.Now()
+	slot := now.Truncate(5 * time.Minute)
+	record := monitoring.PmacctTrafficRecord{
+		InstanceID:   instance.ID,
+		UserID:       instance.UserID,
+		ProviderID:   instance.ProviderID,
+		ProviderType: "fake",
+		MappedIP:     instance.PublicIP,
+		RxBytes:      rxBytes,
+		TxBytes:      txBytes,
+		TotalBytes:   rxBytes + txBytes,
+		Timestamp:    slot,
+		Year:         slot.Year(),
+		Month:        int(slot.Month()),
+		Day:          slot.Day(),
+		Hour:         slot.Hour(),
+		Minute:       slot.Minute(),
+		RecordTime:   now,
+	}
+	if record.MappedIP == "" {
+		record.MappedIP = instance.PrivateIP
+	}
+
+	return global.APP_DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "instance_id"}, {Name: "timestamp"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"rx_bytes":    gorm.Expr("rx_bytes + ?", rxBytes),
+			"tx_bytes":    gorm.Expr("tx_bytes + ?", txBytes),
+			"total_bytes": gorm.Expr("total_bytes + ?", rxBytes+txBytes),
+			"record_time": now,
+		}),
+	}).Create(&record).Error
+}
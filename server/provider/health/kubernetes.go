@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// KubernetesHealthChecker Kubernetes健康检查器，通过控制节点上的kubectl检查集群，SSH检查复用SSH通用检查器
+type KubernetesHealthChecker struct {
+	*DockerHealthChecker
+}
+
+// NewKubernetesHealthChecker 创建Kubernetes健康检查器
+func NewKubernetesHealthChecker(config HealthConfig, logger *zap.Logger) *KubernetesHealthChecker {
+	return &KubernetesHealthChecker{DockerHealthChecker: NewDockerHealthChecker(config, logger)}
+}
+
+// NewKubernetesHealthCheckerWithSSH 创建使用外部SSH连接的Kubernetes健康检查器
+func NewKubernetesHealthCheckerWithSSH(config HealthConfig, logger *zap.Logger, sshClient *ssh.Client) *KubernetesHealthChecker {
+	return &KubernetesHealthChecker{DockerHealthChecker: NewDockerHealthCheckerWithSSH(config, logger, sshClient)}
+}
+
+// CheckHealth 执行Kubernetes健康检查，集群API由kubectl访问，不单独检查API端口
+func (k *KubernetesHealthChecker) CheckHealth(ctx context.Context) (*HealthResult, error) {
+	checks := []func(context.Context) CheckResult{}
+
+	if k.config.SSHEnabled {
+		checks = append(checks, k.createCheckFunc(CheckTypeSSH, k.checkSSH))
+	}
+	if len(k.config.ServiceChecks) > 0 {
+		checks = append(checks, k.createCheckFunc(CheckTypeService, k.checkKubernetesService))
+	}
+
+	result := k.executeChecks(ctx, checks)
+
+	if result.SSHStatus == "online" && k.sshClient != nil {
+		if hostname, err := k.getHostname(ctx); err == nil {
+			result.HostName = hostname
+		} else if k.logger != nil {
+			k.logger.Warn("获取节点hostname失败",
+				zap.String("host", k.config.Host),
+				zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// checkKubernetesService 检查kubectl能否访问集群API Server
+func (k *KubernetesHealthChecker) checkKubernetesService(ctx context.Context) error {
+	if k.useExternalSSH {
+		if k.sshClient == nil {
+			return fmt.Errorf("external SSH client is required for service check but is nil")
+		}
+	} else if k.sshClient == nil {
+		if err := k.checkSSH(ctx); err != nil {
+			return fmt.Errorf("无法建立SSH连接进行服务检查: %w", err)
+		}
+	}
+
+	session, err := k.sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("创建SSH会话失败: %w", err)
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput("export PATH=$PATH:/usr/local/bin:/usr/sbin:/sbin; kubectl get --raw=/readyz 2>&1")
+	if err != nil {
+		return fmt.Errorf("kubectl无法访问集群: %s", strings.TrimSpace(string(output)))
+	}
+	if strings.TrimSpace(string(output)) != "ok" {
+		return fmt.Errorf("Kubernetes API Server未就绪: %s", strings.TrimSpace(string(output)))
+	}
+
+	if k.logger != nil {
+		k.logger.Debug("Kubernetes服务检查成功", zap.String("host", k.config.Host))
+	}
+	return nil
+}
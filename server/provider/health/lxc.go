@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// LXCHealthChecker 原生LXC健康检查器，SSH检查和hostname获取复用SSH通用检查器
+type LXCHealthChecker struct {
+	*DockerHealthChecker
+}
+
+// NewLXCHealthChecker 创建LXC健康检查器
+func NewLXCHealthChecker(config HealthConfig, logger *zap.Logger) *LXCHealthChecker {
+	return &LXCHealthChecker{DockerHealthChecker: NewDockerHealthChecker(config, logger)}
+}
+
+// NewLXCHealthCheckerWithSSH 创建使用外部SSH连接的LXC健康检查器
+func NewLXCHealthCheckerWithSSH(config HealthConfig, logger *zap.Logger, sshClient *ssh.Client) *LXCHealthChecker {
+	return &LXCHealthChecker{DockerHealthChecker: NewDockerHealthCheckerWithSSH(config, logger, sshClient)}
+}
+
+// CheckHealth 执行LXC健康检查，LXC没有API，只检查SSH和lxc工具
+func (l *LXCHealthChecker) CheckHealth(ctx context.Context) (*HealthResult, error) {
+	checks := []func(context.Context) CheckResult{}
+
+	if l.config.SSHEnabled {
+		checks = append(checks, l.createCheckFunc(CheckTypeSSH, l.checkSSH))
+	}
+	if len(l.config.ServiceChecks) > 0 {
+		checks = append(checks, l.createCheckFunc(CheckTypeService, l.checkLXCService))
+	}
+
+	result := l.executeChecks(ctx, checks)
+
+	if result.SSHStatus == "online" && l.sshClient != nil {
+		if hostname, err := l.getHostname(ctx); err == nil {
+			result.HostName = hostname
+		} else if l.logger != nil {
+			l.logger.Warn("获取节点hostname失败",
+				zap.String("host", l.config.Host),
+				zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// checkLXCService 检查lxc工具是否可用
+func (l *LXCHealthChecker) checkLXCService(ctx context.Context) error {
+	if l.useExternalSSH {
+		if l.sshClient == nil {
+			return fmt.Errorf("external SSH client is required for service check but is nil")
+		}
+	} else if l.sshClient == nil {
+		if err := l.checkSSH(ctx); err != nil {
+			return fmt.Errorf("无法建立SSH连接进行服务检查: %w", err)
+		}
+	}
+
+	session, err := l.sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("创建SSH会话失败: %w", err)
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput("export PATH=$PATH:/usr/local/bin:/usr/sbin:/sbin; lxc-ls --version && lxc-checkconfig >/dev/null 2>&1 && echo LXC_OK")
+	if err != nil {
+		return fmt.Errorf("LXC工具不可用: %w", err)
+	}
+	if !strings.Contains(string(output), "LXC_OK") {
+		return fmt.Errorf("LXC内核配置检查未通过")
+	}
+
+	if l.logger != nil {
+		l.logger.Debug("LXC服务检查成功", zap.String("host", l.config.Host))
+	}
+	return nil
+}
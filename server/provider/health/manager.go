@@ -12,10 +12,12 @@ import (
 type ProviderType string
 
 const (
-	ProviderTypeDocker  ProviderType = "docker"
-	ProviderTypeLXD     ProviderType = "lxd"
-	ProviderTypeIncus   ProviderType = "incus"
-	ProviderTypeProxmox ProviderType = "proxmox"
+	ProviderTypeDocker     ProviderType = "docker"
+	ProviderTypeLXD        ProviderType = "lxd"
+	ProviderTypeIncus      ProviderType = "incus"
+	ProviderTypeProxmox    ProviderType = "proxmox"
+	ProviderTypeLXC        ProviderType = "lxc"
+	ProviderTypeKubernetes ProviderType = "kubernetes"
 )
 
 // HealthManager 健康检查管理器
@@ -95,6 +97,16 @@ func (hm *HealthManager) CreateChecker(providerType ProviderType, config HealthC
 		checker = NewProxmoxHealthChecker(configCopy, hm.logger)
 		checkerTypeName = "ProxmoxHealthChecker"
 
+	case ProviderTypeLXC:
+		configCopy.APIEnabled = false // LXC没有API
+		checker = NewLXCHealthChecker(configCopy, hm.logger)
+		checkerTypeName = "LXCHealthChecker"
+
+	case ProviderTypeKubernetes:
+		configCopy.APIEnabled = false // 集群API通过kubectl访问
+		checker = NewKubernetesHealthChecker(configCopy, hm.logger)
+		checkerTypeName = "KubernetesHealthChecker"
+
 	default:
 		if hm.logger != nil {
 			hm.logger.Error("不支持的Provider类型",
@@ -20,6 +20,8 @@ func (phc *ProviderHealthChecker) DetectStoragePoolPath(client *ssh.Client, prov
 		return phc.detectIncusStoragePath(client, storagePoolName)
 	case "docker":
 		return phc.detectDockerStoragePath(client)
+	case "lxc":
+		return phc.detectLXCStoragePath(client)
 	default:
 		// 默认返回根目录
 		if phc.logger != nil {
@@ -199,6 +201,27 @@ func (phc *ProviderHealthChecker) detectDockerStoragePath(client *ssh.Client) (s
 	return defaultPath, nil
 }
 
+// detectLXCStoragePath 检测LXC容器存储路径
+func (phc *ProviderHealthChecker) detectLXCStoragePath(client *ssh.Client) (string, error) {
+	// lxc.lxcpath 为容器根文件系统所在目录
+	output, err := phc.executeSSHCommand(client, "lxc-config lxc.lxcpath 2>/dev/null")
+	if err == nil && utils.CleanCommandOutput(output) != "" {
+		path := utils.CleanCommandOutput(output)
+		if phc.logger != nil {
+			phc.logger.Info("检测到LXC存储路径",
+				zap.String("path", path))
+		}
+		return path, nil
+	}
+
+	defaultPath := "/var/lib/lxc"
+	if phc.logger != nil {
+		phc.logger.Info("使用LXC默认存储路径",
+			zap.String("path", defaultPath))
+	}
+	return defaultPath, nil
+}
+
 // getDiskInfoByPath 根据指定路径获取磁盘信息
 func (phc *ProviderHealthChecker) getDiskInfoByPath(client *ssh.Client, path string) (total int64, free int64, err error) {
 	// 如果没有指定路径，使用根目录
@@ -115,6 +115,12 @@ func (phc *ProviderHealthChecker) CheckProviderHealthWithAuthConfig(ctx context.
 		config.APIPort = 2375
 		config.APIScheme = "http"
 		config.ServiceChecks = []string{"docker"}
+	case "lxc":
+		config.APIEnabled = false // LXC没有API
+		config.ServiceChecks = []string{"lxc"}
+	case "kubernetes":
+		config.APIEnabled = false // 集群API通过kubectl访问
+		config.ServiceChecks = []string{"kubernetes"}
 	}
 
 	// 创建checker前再次记录配置，确保config.Host正确
@@ -147,6 +153,10 @@ func (phc *ProviderHealthChecker) CheckProviderHealthWithAuthConfig(ctx context.
 		c.Close()
 	case *ProxmoxHealthChecker:
 		c.Close()
+	case *LXCHealthChecker:
+		c.Close()
+	case *KubernetesHealthChecker:
+		c.Close()
 	}
 
 	sshStatus := "unknown"
@@ -197,6 +207,12 @@ func (phc *ProviderHealthChecker) CheckProviderHealthFromConfig(ctx context.Cont
 		config.APIPort = 8006
 		config.APIScheme = "https"
 		config.ServiceChecks = []string{"pvestatd", "pvedaemon", "pveproxy"}
+	case "lxc":
+		config.APIEnabled = false // LXC没有API
+		config.ServiceChecks = []string{"lxc"}
+	case "kubernetes":
+		config.APIEnabled = false // 集群API通过kubectl访问
+		config.ServiceChecks = []string{"kubernetes"}
 	}
 	checker, err := phc.manager.CreateChecker(ProviderType(providerType), config)
 	if err != nil {
@@ -215,6 +231,10 @@ func (phc *ProviderHealthChecker) CheckProviderHealthFromConfig(ctx context.Cont
 		c.Close()
 	case *ProxmoxHealthChecker:
 		c.Close()
+	case *LXCHealthChecker:
+		c.Close()
+	case *KubernetesHealthChecker:
+		c.Close()
 	}
 	sshStatus := "unknown"
 	apiStatus := "unknown"
@@ -299,6 +319,10 @@ func (phc *ProviderHealthChecker) CheckAPIConnection(ctx context.Context, provid
 			c.Close()
 		case *ProxmoxHealthChecker:
 			c.Close()
+		case *LXCHealthChecker:
+			c.Close()
+		case *KubernetesHealthChecker:
+			c.Close()
 		}
 	}()
 	result, err := checker.CheckHealth(ctx)
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 	"oneclickvirt/utils"
@@ -43,7 +44,7 @@ func (i *IncusProvider) apiListInstances(ctx context.Context) ([]provider.Instan
 				instance := provider.Instance{
 					ID:     name,
 					Name:   name,
-					Status: status,
+					Status: constant.NormalizeProviderStatus("incus", status),
 					Type:   instanceType,
 				}
 
@@ -217,6 +218,11 @@ func (i *IncusProvider) apiCreateInstanceWithProgress(ctx context.Context, confi
 
 	updateProgress(50, "调用Incus API创建实例...")
 
+	profiles, err := provider.InstanceProfiles(i.config.Profiles, config.Profiles)
+	if err != nil {
+		return err
+	}
+
 	// 构造实例配置
 	instanceConfig := map[string]interface{}{
 		"name": config.Name,
@@ -226,7 +232,7 @@ func (i *IncusProvider) apiCreateInstanceWithProgress(ctx context.Context, confi
 		},
 		"config":   map[string]interface{}{},
 		"devices":  map[string]interface{}{},
-		"profiles": []string{"default"},
+		"profiles": profiles,
 	}
 
 	// 设置实例类型
@@ -507,7 +513,7 @@ func (i *IncusProvider) apiSetInstancePassword(ctx context.Context, instanceID,
 	// Incus API方式设置密码
 	// 构造执行命令的请求
 	execData := map[string]interface{}{
-		"command":     []string{"bash", "-c", fmt.Sprintf("echo 'root:%s' | chpasswd", password)},
+		"command":     []string{"bash", "-c", fmt.Sprintf("echo %s | chpasswd", utils.ShellQuote("root:"+password))},
 		"wait-for-ws": true,
 		"interactive": false,
 	}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 
@@ -282,16 +283,7 @@ func (i *IncusProvider) sshDiscoverInstances(ctx context.Context) ([]provider.Di
 
 // 辅助函数
 func (i *IncusProvider) mapIncusStatus(status string) string {
-	switch strings.ToLower(status) {
-	case "running":
-		return "running"
-	case "stopped":
-		return "stopped"
-	case "frozen":
-		return "frozen"
-	default:
-		return status
-	}
+	return constant.NormalizeProviderStatus("incus", status)
 }
 
 func (i *IncusProvider) mapIncusType(incusType string) string {
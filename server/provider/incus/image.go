@@ -11,6 +11,7 @@ import (
 	"oneclickvirt/global"
 	systemModel "oneclickvirt/model/system"
 	"oneclickvirt/provider"
+	"oneclickvirt/service/images"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -75,18 +76,18 @@ func (i *IncusProvider) handleImageDownloadAndImport(ctx context.Context, config
 				// 虚拟机镜像导入
 				if strings.HasSuffix(config.ImagePath, ".zip") {
 					extractDir := strings.TrimSuffix(config.ImagePath, ".zip")
-					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", config.ImagePath, extractDir)
+					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(extractDir))
 					_, err := i.sshClient.Execute(unzipCmd)
 					if err != nil {
 						return fmt.Errorf("解压Incus虚拟机镜像失败: %w", err)
 					}
 
 					// 查找解压后的VM镜像文件（可能是img、qcow2等格式）
-					findCmd := fmt.Sprintf("find %s -name '*.img' -o -name '*.qcow2' -o -name '*.vmdk' | head -1", extractDir)
+					findCmd := fmt.Sprintf("find %s -name '*.img' -o -name '*.qcow2' -o -name '*.vmdk' | head -1", utils.ShellQuote(extractDir))
 					vmImagePath, err := i.sshClient.Execute(findCmd)
 					if err != nil || strings.TrimSpace(vmImagePath) == "" {
 						// 如果没找到VM镜像，尝试查找tar.xz
-						findCmd = fmt.Sprintf("find %s -name '*.tar.xz' | head -1", extractDir)
+						findCmd = fmt.Sprintf("find %s -name '*.tar.xz' | head -1", utils.ShellQuote(extractDir))
 						vmImagePath, err = i.sshClient.Execute(findCmd)
 						if err != nil || utils.CleanCommandOutput(vmImagePath) == "" {
 							return fmt.Errorf("未找到解压后的Incus虚拟机镜像文件")
@@ -98,21 +99,21 @@ func (i *IncusProvider) handleImageDownloadAndImport(ctx context.Context, config
 					incusTarPath := fmt.Sprintf("%s/incus.tar.xz", extractDir)
 					diskPath := fmt.Sprintf("%s/disk.qcow2", extractDir)
 					if i.isRemoteFileValid(incusTarPath) && i.isRemoteFileValid(diskPath) {
-						importCmd = fmt.Sprintf("incus image import %s %s --alias %s", incusTarPath, diskPath, config.Image)
+						importCmd = fmt.Sprintf("incus image import %s %s --alias %s", utils.ShellQuote(incusTarPath), utils.ShellQuote(diskPath), utils.ShellQuote(config.Image))
 					} else {
-						importCmd = fmt.Sprintf("incus image import %s --alias %s --vm", vmImagePath, config.Image)
+						importCmd = fmt.Sprintf("incus image import %s --alias %s --vm", utils.ShellQuote(vmImagePath), utils.ShellQuote(config.Image))
 					}
 
 					// 清理解压后的临时目录
-					defer i.sshClient.Execute(fmt.Sprintf("rm -rf %s", extractDir))
+					defer i.sshClient.Execute(fmt.Sprintf("rm -rf %s", utils.ShellQuote(extractDir)))
 				} else {
-					importCmd = fmt.Sprintf("incus image import %s --alias %s --vm", config.ImagePath, config.Image)
+					importCmd = fmt.Sprintf("incus image import %s --alias %s --vm", utils.ShellQuote(config.ImagePath), utils.ShellQuote(config.Image))
 				}
 			} else {
 				// 容器镜像导入
 				if strings.HasSuffix(config.ImagePath, ".zip") {
 					extractDir := strings.TrimSuffix(config.ImagePath, ".zip")
-					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", config.ImagePath, extractDir)
+					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(extractDir))
 					_, err := i.sshClient.Execute(unzipCmd)
 					if err != nil {
 						return fmt.Errorf("解压Incus容器镜像失败: %w", err)
@@ -123,22 +124,22 @@ func (i *IncusProvider) handleImageDownloadAndImport(ctx context.Context, config
 					rootfsPath := fmt.Sprintf("%s/rootfs.squashfs", extractDir)
 
 					if i.isRemoteFileValid(incusTarPath) && i.isRemoteFileValid(rootfsPath) {
-						importCmd = fmt.Sprintf("incus image import %s %s --alias %s", incusTarPath, rootfsPath, config.Image)
+						importCmd = fmt.Sprintf("incus image import %s %s --alias %s", utils.ShellQuote(incusTarPath), utils.ShellQuote(rootfsPath), utils.ShellQuote(config.Image))
 					} else {
 						// 查找任何tar.xz文件
-						findCmd := fmt.Sprintf("find %s -name '*.tar.xz' | head -1", extractDir)
+						findCmd := fmt.Sprintf("find %s -name '*.tar.xz' | head -1", utils.ShellQuote(extractDir))
 						tarPath, err := i.sshClient.Execute(findCmd)
 						if err != nil || utils.CleanCommandOutput(tarPath) == "" {
 							return fmt.Errorf("未找到解压后的Incus容器镜像文件")
 						}
 						tarPath = utils.CleanCommandOutput(tarPath)
-						importCmd = fmt.Sprintf("incus image import %s --alias %s", tarPath, config.Image)
+						importCmd = fmt.Sprintf("incus image import %s --alias %s", utils.ShellQuote(tarPath), utils.ShellQuote(config.Image))
 					}
 
 					// 清理解压后的临时目录
-					defer i.sshClient.Execute(fmt.Sprintf("rm -rf %s", extractDir))
+					defer i.sshClient.Execute(fmt.Sprintf("rm -rf %s", utils.ShellQuote(extractDir)))
 				} else {
-					importCmd = fmt.Sprintf("incus image import %s --alias %s", config.ImagePath, config.Image)
+					importCmd = fmt.Sprintf("incus image import %s --alias %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(config.Image))
 				}
 			}
 
@@ -206,11 +207,19 @@ func (i *IncusProvider) queryAndSetSystemImage(ctx context.Context, config *prov
 	// 优先获取启用状态的镜像
 	query = query.Where("status = ?", "active").Order("created_at DESC")
 
-	err := query.First(&systemImage).Error
-	if err != nil {
+	var candidates []systemModel.SystemImage
+	if err := query.Find(&candidates).Error; err != nil {
 		return fmt.Errorf("未找到匹配的系统镜像: %w", err)
 	}
 
+	// 排除被镜像禁用策略命中的镜像（节点侧无法得知用户等级，仅应用对所有用户生效的策略）
+	imageService := &images.ImageService{}
+	candidates = imageService.FilterBannedImages(candidates, 0, i.config.ID)
+	if len(candidates) == 0 {
+		return fmt.Errorf("未找到匹配的系统镜像: 无可用镜像或镜像已被禁用")
+	}
+	systemImage = candidates[0]
+
 	// 设置镜像配置，不在这里添加CDN前缀
 	// CDN前缀应该在实际下载时根据可用性和UseCDN设置动态添加
 	if systemImage.URL != "" {
@@ -240,7 +249,7 @@ func (i *IncusProvider) generateImageAlias(imageURL, imageName, architecture str
 
 // imageExists 检查镜像是否已存在
 func (i *IncusProvider) imageExists(alias string) bool {
-	output, err := i.sshClient.Execute(fmt.Sprintf("incus image list %s --format csv", alias))
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus image list %s --format csv", utils.ShellQuote(alias)))
 	if err != nil {
 		return false
 	}
@@ -250,7 +259,7 @@ func (i *IncusProvider) imageExists(alias string) bool {
 // isRemoteFileValid 检查远程文件是否存在
 func (i *IncusProvider) isRemoteFileValid(remotePath string) bool {
 	// 检查文件是否存在且大小大于 0
-	output, err := i.sshClient.Execute(fmt.Sprintf("test -f %s -a -s %s && echo 'exists'", remotePath, remotePath))
+	output, err := i.sshClient.Execute(fmt.Sprintf("test -f %s -a -s %s && echo 'exists'", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath)))
 	if err != nil || strings.TrimSpace(output) != "exists" {
 		return false
 	}
@@ -268,7 +277,7 @@ func (i *IncusProvider) downloadImageToRemote(imageURL, imageName, architecture,
 	}
 
 	// 在远程服务器上创建下载目录
-	cmd := fmt.Sprintf("mkdir -p %s", downloadDir)
+	cmd := fmt.Sprintf("mkdir -p %s", utils.ShellQuote(downloadDir))
 	_, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("创建远程下载目录失败: %w", err)
@@ -287,23 +296,25 @@ func (i *IncusProvider) downloadImageToRemote(imageURL, imageName, architecture,
 	}
 
 	// 如果文件存在但无效，先删除它
-	i.sshClient.Execute(fmt.Sprintf("test -f %s && rm -f %s || true", remotePath, remotePath))
+	i.sshClient.Execute(fmt.Sprintf("test -f %s && rm -f %s || true", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath)))
 
-	// 确定下载URL，传递 useCDN 参数
-	downloadURL := i.getDownloadURL(imageURL, useCDN)
+	// 确定候选下载来源，传递 useCDN 参数
+	sources := i.getDownloadSources(imageURL, useCDN)
 
 	global.APP_LOG.Info("开始在远程服务器下载镜像",
 		zap.String("imageName", imageName),
-		zap.String("downloadURL", downloadURL),
+		zap.Int("sources", len(sources)),
 		zap.String("remotePath", remotePath),
 		zap.Bool("useCDN", useCDN))
 
-	// 在远程服务器上下载文件
-	if err := i.downloadFileToRemote(downloadURL, remotePath); err != nil {
+	// 在远程服务器上下载文件，失败时轮换下载来源
+	source, err := utils.DownloadToRemote(i.sshClient, sources, remotePath)
+	if err != nil {
 		// 下载失败，删除不完整的文件
 		i.removeRemoteFile(remotePath)
 		return "", fmt.Errorf("远程下载镜像失败: %w", err)
 	}
+	utils.RecordImageSource(i.config.ID, source.Base)
 
 	global.APP_LOG.Info("远程镜像下载完成",
 		zap.String("imageName", imageName),
@@ -333,7 +344,7 @@ func (i *IncusProvider) generateRemoteFileName(imageName, imageURL, architecture
 
 // removeRemoteFile 删除远程文件
 func (i *IncusProvider) removeRemoteFile(remotePath string) error {
-	cmd := fmt.Sprintf("rm -f %s", remotePath)
+	cmd := fmt.Sprintf("rm -f %s", utils.ShellQuote(remotePath))
 	_, err := i.sshClient.Execute(cmd)
 	return err
 }
@@ -355,7 +366,7 @@ func (i *IncusProvider) downloadFileToRemote(url, remotePath string) error {
 	output, err := i.sshClient.Execute(curlCmd)
 	if err != nil {
 		// 清理临时文件
-		i.sshClient.Execute(fmt.Sprintf("rm -f %s", tmpPath))
+		i.sshClient.Execute(fmt.Sprintf("rm -f %s", utils.ShellQuote(tmpPath)))
 
 		global.APP_LOG.Error("远程下载失败",
 			zap.String("url", utils.TruncateString(url, 100)),
@@ -366,7 +377,7 @@ func (i *IncusProvider) downloadFileToRemote(url, remotePath string) error {
 	}
 
 	// 移动文件到最终位置
-	mvCmd := fmt.Sprintf("mv %s %s", tmpPath, remotePath)
+	mvCmd := fmt.Sprintf("mv %s %s", utils.ShellQuote(tmpPath), utils.ShellQuote(remotePath))
 	_, err = i.sshClient.Execute(mvCmd)
 	if err != nil {
 		global.APP_LOG.Error("移动文件失败",
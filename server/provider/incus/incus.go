@@ -111,11 +111,25 @@ func (i *IncusProvider) Connect(ctx context.Context, config provider.NodeConfig)
 		PrivateKey:     config.PrivateKey,
 		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
 		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		ShellInit:      provider.ProjectShellInit("incus", config.Project), // 所有CLI命令在配置的项目中执行
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
 	}
 	client, err := utils.NewSSHClient(sshConfig)
 	if err != nil {
 		return fmt.Errorf("failed to connect via SSH: %w", err)
 	}
+
+	if provider.UsesProject(config.Project) {
+		if err := provider.ValidateLXDObjectName("项目", config.Project); err != nil {
+			client.Close()
+			return err
+		}
+		if output, err := client.Execute(provider.EnsureProjectCommand("incus", config.Project)); err != nil {
+			client.Close()
+			return fmt.Errorf("初始化项目 %s 失败: %w, output: %s", config.Project, err, strings.TrimSpace(output))
+		}
+		i.apiClient.Transport = provider.WithProject(i.transport, config.Project)
+	}
 	i.sshClient = client
 	i.connected = true
 
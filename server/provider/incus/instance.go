@@ -37,7 +37,7 @@ func (i *IncusProvider) validateInstanceConfig(config provider.InstanceConfig) e
 
 // instanceExists 检查实例是否已存在
 func (i *IncusProvider) instanceExists(name string) (bool, error) {
-	cmd := fmt.Sprintf("incus list %s --format csv", name)
+	cmd := fmt.Sprintf("incus list %s --format csv", utils.ShellQuote(name))
 	output, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return false, fmt.Errorf("检查实例是否存在失败: %w", err)
@@ -60,9 +60,9 @@ func (i *IncusProvider) buildCreateCommand(config provider.InstanceConfig) (stri
 
 	// 根据实例类型构建基础命令
 	if config.InstanceType == "vm" {
-		cmd = fmt.Sprintf("incus init %s %s --vm", config.Image, config.Name)
+		cmd = fmt.Sprintf("incus init %s %s --vm", utils.ShellQuote(config.Image), utils.ShellQuote(config.Name))
 	} else {
-		cmd = fmt.Sprintf("incus init %s %s", config.Image, config.Name)
+		cmd = fmt.Sprintf("incus init %s %s", utils.ShellQuote(config.Image), utils.ShellQuote(config.Name))
 	}
 
 	// 基础配置参数
@@ -150,6 +150,15 @@ func (i *IncusProvider) buildCreateCommand(config provider.InstanceConfig) (stri
 		cmd += fmt.Sprintf(" -c %s", param)
 	}
 
+	// 配置文件：指定 -p 后不再自动应用default，由 InstanceProfiles 补充
+	profiles, err := provider.InstanceProfiles(i.config.Profiles, config.Profiles)
+	if err != nil {
+		return "", err
+	}
+	for _, profile := range profiles {
+		cmd += fmt.Sprintf(" -p %s", profile)
+	}
+
 	// 如果有磁盘大小配置
 	if config.Disk != "" {
 		diskFormatted := convertDiskFormat(config.Disk)
@@ -199,7 +208,7 @@ func (i *IncusProvider) executeCreateCommand(cmd string) error {
 // waitForInstanceState 等待实例达到指定状态
 func (i *IncusProvider) waitForInstanceState(name, expectedState string, timeoutSeconds int) error {
 	for elapsed := 0; elapsed < timeoutSeconds; elapsed += 3 {
-		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", name)
+		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(name))
 		output, err := i.sshClient.Execute(cmd)
 		if err != nil {
 			global.APP_LOG.Debug("获取实例状态失败",
@@ -278,10 +287,23 @@ func (i *IncusProvider) configureInstanceSSHPassword(ctx context.Context, config
 	// 生成随机密码
 	password := i.generateRandomPassword()
 
+	// 虚拟机Agent可用时直接通过执行通道设置密码，Agent不可用或失败时回退到推送脚本的方式
+	if config.InstanceType == "vm" && i.VMAgentAvailable(ctx, config.Name) {
+		if err := i.agentSetPassword(config.Name, "root", password); err == nil {
+			i.applyGuestSSHPort(config.Name, provider.GuestSSHPortFromConfig(config))
+			i.saveInstancePassword(ctx, config.Name, password)
+			return nil
+		} else {
+			global.APP_LOG.Warn("通过incus-agent设置密码失败，回退到脚本方式",
+				zap.String("instanceName", config.Name),
+				zap.Error(err))
+		}
+	}
+
 	// 根据系统类型选择脚本
 	var scriptName string
 	// 检测系统类型
-	output, err := i.sshClient.Execute(fmt.Sprintf("incus exec %s -- cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", config.Name))
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus exec %s -- cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", utils.ShellQuote(config.Name)))
 	if err == nil {
 		osType := strings.TrimSpace(strings.ToLower(output))
 		if osType == "alpine" || osType == "openwrt" {
@@ -303,18 +325,18 @@ func (i *IncusProvider) configureInstanceSSHPassword(ctx context.Context, config
 	} else {
 		time.Sleep(3 * time.Second)
 		// 复制脚本到实例
-		copyCmd := fmt.Sprintf("incus file push %s %s/root/", scriptPath, config.Name)
+		copyCmd := fmt.Sprintf("incus file push %s %s/root/", utils.ShellQuote(scriptPath), utils.ShellQuote(config.Name))
 		_, err = i.sshClient.Execute(copyCmd)
 		if err != nil {
 			global.APP_LOG.Warn("复制SSH脚本到实例失败，仅设置密码", zap.Error(err))
 		} else {
 			// 设置脚本权限
-			_, err = i.sshClient.Execute(fmt.Sprintf("incus exec %s -- chmod +x /root/%s", config.Name, scriptName))
+			_, err = i.sshClient.Execute(fmt.Sprintf("incus exec %s -- chmod +x /root/%s", utils.ShellQuote(config.Name), scriptName))
 			if err != nil {
 				global.APP_LOG.Warn("设置脚本权限失败", zap.Error(err))
 			} else {
 				// 执行脚本配置SSH和密码
-				execCmd := fmt.Sprintf("incus exec %s -- /root/%s %s", config.Name, scriptName, password)
+				execCmd := fmt.Sprintf("incus exec %s -- /root/%s %s", utils.ShellQuote(config.Name), scriptName, utils.ShellQuote(password))
 				_, err = i.sshClient.Execute(execCmd)
 				if err != nil {
 					global.APP_LOG.Warn("执行SSH配置脚本失败，将使用直接设置密码",
@@ -328,7 +350,7 @@ func (i *IncusProvider) configureInstanceSSHPassword(ctx context.Context, config
 	}
 
 	// 直接使用incus exec设置密码
-	directPasswordCmd := fmt.Sprintf("incus exec %s -- bash -c 'echo \"root:%s\" | chpasswd'", config.Name, password)
+	directPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | incus exec %s -- chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(config.Name))
 	_, err = i.sshClient.Execute(directPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("设置实例密码失败",
@@ -337,8 +359,11 @@ func (i *IncusProvider) configureInstanceSSHPassword(ctx context.Context, config
 		return fmt.Errorf("设置实例密码失败: %w", err)
 	}
 
+	// SSH脚本会重写sshd_config，需在其之后应用实例内SSH端口
+	i.applyGuestSSHPort(config.Name, provider.GuestSSHPortFromConfig(config))
+
 	// 清理历史记录 - 非阻塞式，如果失败不影响整体流程
-	_, err = i.sshClient.Execute(fmt.Sprintf("incus exec %s -- bash -c 'history -c 2>/dev/null || true'", config.Name))
+	_, err = i.sshClient.Execute(fmt.Sprintf("incus exec %s -- bash -c 'history -c 2>/dev/null || true'", utils.ShellQuote(config.Name)))
 	if err != nil {
 		global.APP_LOG.Warn("清理历史记录失败",
 			zap.String("instanceName", config.Name),
@@ -349,25 +374,49 @@ func (i *IncusProvider) configureInstanceSSHPassword(ctx context.Context, config
 		zap.String("instanceName", config.Name),
 		zap.String("rootPassword", password))
 
+	i.saveInstancePassword(ctx, config.Name, password)
+	return nil
+}
+
+// applyGuestSSHPort 将实例内sshd改为监听配置的SSH端口，默认22时无需处理
+func (i *IncusProvider) applyGuestSSHPort(instanceName string, port int) {
+	script := provider.GuestSSHPortScript(port)
+	if script == "" {
+		return
+	}
+	cmd := fmt.Sprintf("incus exec %s -- sh -c %s", utils.ShellQuote(instanceName), utils.ShellQuote(script))
+	if output, err := i.sshClient.Execute(cmd); err != nil {
+		global.APP_LOG.Warn("配置实例内SSH端口失败",
+			zap.String("instanceName", instanceName),
+			zap.Int("guestSSHPort", port),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("实例内SSH端口配置完成",
+		zap.String("instanceName", instanceName),
+		zap.Int("guestSSHPort", port))
+}
+
+// saveInstancePassword 将实例密码保存到实例配置和数据库中，确保数据库与实际密码一致
+func (i *IncusProvider) saveInstancePassword(ctx context.Context, instanceName, password string) {
 	// 保存密码到实例配置中（用于后续获取）
-	if err = i.setInstanceConfig(ctx, config.Name, "user.password", password); err != nil {
+	if err := i.setInstanceConfig(ctx, instanceName, "user.password", password); err != nil {
 		global.APP_LOG.Warn("保存密码到实例配置失败", zap.Error(err))
 	}
 
 	// 更新数据库中的密码记录，确保数据库与实际密码一致
-	err = global.APP_DB.Model(&providerModel.Instance{}).
-		Where("name = ?", config.Name).
+	err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ?", instanceName).
 		Update("password", password).Error
 	if err != nil {
 		global.APP_LOG.Warn("更新实例密码到数据库失败",
-			zap.String("instanceName", config.Name),
+			zap.String("instanceName", instanceName),
 			zap.Error(err))
 	} else {
 		global.APP_LOG.Info("实例密码已同步到数据库",
-			zap.String("instanceName", config.Name))
+			zap.String("instanceName", instanceName))
 	}
-
-	return nil
 }
 
 // waitForInstanceExecReady 等待实例可以执行命令（容器直接可用，虚拟机需要等待Agent）
@@ -380,7 +429,7 @@ func (i *IncusProvider) waitForInstanceExecReady(instanceName string, timeoutSec
 	for elapsed := 0; elapsed < timeoutSeconds; elapsed += 5 {
 		// 每两轮循环（10秒）尝试启动实例，避免实例因故障停止导致一直干等待
 		if loopCount > 0 && loopCount%2 == 0 {
-			startCmd := fmt.Sprintf("incus start %s", instanceName)
+			startCmd := fmt.Sprintf("incus start %s", utils.ShellQuote(instanceName))
 			startOutput, startErr := i.sshClient.Execute(startCmd)
 			// "already running" 不是错误，而是实例已在运行的正常状态
 			if startErr == nil || strings.Contains(startOutput, "already running") {
@@ -396,7 +445,7 @@ func (i *IncusProvider) waitForInstanceExecReady(instanceName string, timeoutSec
 		}
 
 		// 尝试执行一个简单的命令来检测VM agent是否就绪
-		cmd := fmt.Sprintf("incus exec %s -- echo 'agent-ready' 2>/dev/null", instanceName)
+		cmd := fmt.Sprintf("incus exec %s -- echo 'agent-ready' 2>/dev/null", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(cmd)
 		if err == nil && strings.Contains(output, "agent-ready") {
 			global.APP_LOG.Info("实例可执行命令",
@@ -72,7 +72,7 @@ func (i *IncusProvider) checkIPv6(ctx context.Context) (string, error) {
 		"https://ipv6.icanhazip.com",
 	}
 	for _, endpoint := range apiEndpoints {
-		cmd := fmt.Sprintf("curl -sLk6m8 '%s' | tr -d '[:space:]'", endpoint)
+		cmd := fmt.Sprintf("curl -sLk6m8 %s | tr -d '[:space:]'", utils.ShellQuote(endpoint))
 		output, err := i.sshClient.Execute(cmd)
 		if err == nil {
 			ipv6 := strings.TrimSpace(output)
@@ -90,7 +90,7 @@ func (i *IncusProvider) checkIPv6(ctx context.Context) (string, error) {
 
 // getContainerIPv6 获取容器内网IPv6地址
 func (i *IncusProvider) getContainerIPv6(ctx context.Context, containerName string) (string, error) {
-	cmd := fmt.Sprintf("incus list %s --format=json | jq -r '.[0].state.network.eth0.addresses[] | select(.family==\"inet6\") | select(.scope==\"global\") | .address'", containerName)
+	cmd := fmt.Sprintf("incus list %s --format=json | jq -r '.[0].state.network.eth0.addresses[] | select(.family==\"inet6\") | select(.scope==\"global\") | .address'", utils.ShellQuote(containerName))
 	output, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取容器IPv6地址失败: %w", err)
@@ -121,7 +121,7 @@ func (i *IncusProvider) GetInstanceIPv4(ctx context.Context, instanceName string
 // GetInstancePublicIPv6 获取实例的公网IPv6地址
 func (i *IncusProvider) GetInstancePublicIPv6(ctx context.Context, instanceName string) (string, error) {
 	// 尝试从保存的IPv6文件中读取公网IPv6地址
-	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", instanceName)
+	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", utils.ShellQuote(instanceName))
 	publicIPv6Output, err := i.sshClient.Execute(publicIPv6Cmd)
 	if err == nil {
 		publicIPv6 := utils.CleanCommandOutput(publicIPv6Output)
@@ -134,7 +134,7 @@ func (i *IncusProvider) GetInstancePublicIPv6(ctx context.Context, instanceName
 	}
 
 	// 如果文件中没有，尝试从eth1网络设备获取
-	eth1Cmd := fmt.Sprintf("incus list %s --format json | jq -r '.[0].state.network.eth1.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", instanceName)
+	eth1Cmd := fmt.Sprintf("incus list %s --format json | jq -r '.[0].state.network.eth1.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", utils.ShellQuote(instanceName))
 	eth1Output, err := i.sshClient.Execute(eth1Cmd)
 	if err == nil {
 		eth1IPv6 := utils.CleanCommandOutput(eth1Output)
@@ -153,7 +153,7 @@ func (i *IncusProvider) GetInstancePublicIPv6(ctx context.Context, instanceName
 // GetVethInterfaceName 获取容器对应的宿主机veth接口名称（IPv4）
 // 通过 incus config show 获取 volatile.eth0.host_name
 func (i *IncusProvider) GetVethInterfaceName(ctx context.Context, instanceName string) (string, error) {
-	cmd := fmt.Sprintf("incus config show %s | grep 'volatile.eth0.host_name:' | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("incus config show %s | grep 'volatile.eth0.host_name:' | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取veth接口名称失败: %w", err)
@@ -174,7 +174,7 @@ func (i *IncusProvider) GetVethInterfaceName(ctx context.Context, instanceName s
 // GetVethInterfaceNameV6 获取容器对应的宿主机veth接口名称（IPv6）
 // 通过 incus config show 获取 volatile.eth1.host_name（如果存在）
 func (i *IncusProvider) GetVethInterfaceNameV6(ctx context.Context, instanceName string) (string, error) {
-	cmd := fmt.Sprintf("incus config show %s | grep 'volatile.eth1.host_name:' | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("incus config show %s | grep 'volatile.eth1.host_name:' | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取veth接口名称(IPv6)失败: %w", err)
@@ -313,7 +313,7 @@ func (i *IncusProvider) installSipcalcRHEL(ctx context.Context) error {
 
 	for _, mirror := range mirrors {
 		global.APP_LOG.Info("尝试从镜像下载sipcalc", zap.String("mirror", mirror))
-		downloadCmd := fmt.Sprintf("curl -fLO '%s'", mirror)
+		downloadCmd := fmt.Sprintf("curl -fLO %s", utils.ShellQuote(mirror))
 		_, err := i.sshClient.Execute(downloadCmd)
 		if err == nil {
 			break
@@ -442,13 +442,13 @@ func (i *IncusProvider) setupNetworkDeviceIPv6(ctx context.Context, config IPv6C
 		zap.String("ipv6", containerIPv6))
 
 	// 停止容器
-	stopCmd := fmt.Sprintf("incus stop %s", config.ContainerName)
+	stopCmd := fmt.Sprintf("incus stop %s", utils.ShellQuote(config.ContainerName))
 	i.sshClient.Execute(stopCmd)
 	time.Sleep(3 * time.Second)
 
 	// IPv6网络设备
 	deviceCmd := fmt.Sprintf("incus config device add %s eth1 nic nictype=routed parent=%s ipv6.address=%s",
-		config.ContainerName, ipv6NetworkName, containerIPv6)
+		utils.ShellQuote(config.ContainerName), ipv6NetworkName, containerIPv6)
 	_, err = i.sshClient.Execute(deviceCmd)
 	if err != nil {
 		return "", fmt.Errorf("添加IPv6网络设备失败: %w", err)
@@ -460,7 +460,7 @@ func (i *IncusProvider) setupNetworkDeviceIPv6(ctx context.Context, config IPv6C
 	i.configureFirewallForIPv6(ctx, ipv6NetworkName)
 
 	// 启动容器
-	startCmd := fmt.Sprintf("incus start %s", config.ContainerName)
+	startCmd := fmt.Sprintf("incus start %s", utils.ShellQuote(config.ContainerName))
 	_, err = i.sshClient.Execute(startCmd)
 	if err != nil {
 		return "", fmt.Errorf("启动容器失败: %w", err)
@@ -640,7 +640,7 @@ func (i *IncusProvider) configureIPv6Network(ctx context.Context, containerName
 	}
 
 	// 保存IPv6地址到文件
-	saveCmd := fmt.Sprintf("echo \"%s\" >> %s_v6", containerIPv6, containerName)
+	saveCmd := fmt.Sprintf("echo %s >> %s", utils.ShellQuote(containerIPv6), utils.ShellQuote(containerName+"_v6"))
 	i.sshClient.Execute(saveCmd)
 
 	global.APP_LOG.Info("IPv6网络配置完成",
@@ -906,7 +906,7 @@ func (i *IncusProvider) setupPersistenceServiceIncus(ctx context.Context) error
 	var cdnSuccessUrl string
 	for _, cdnUrl := range cdnUrls {
 		testUrl := cdnUrl + "https://raw.githubusercontent.com/spiritLHLS/ecs/main/back/test"
-		testCmd := fmt.Sprintf("curl -4 -sL -k '%s' --max-time 6 | grep -q 'success'", testUrl)
+		testCmd := fmt.Sprintf("curl -4 -sL -k %s --max-time 6 | grep -q 'success'", utils.ShellQuote(testUrl))
 		_, err := i.sshClient.Execute(testCmd)
 		if err == nil {
 			cdnSuccessUrl = cdnUrl
@@ -917,31 +917,31 @@ func (i *IncusProvider) setupPersistenceServiceIncus(ctx context.Context) error
 
 	// 下载add-ipv6.sh脚本 (Incus版本)
 	scriptPath := "/usr/local/bin/add-ipv6.sh"
-	checkScriptCmd := fmt.Sprintf("[ -f %s ]", scriptPath)
+	checkScriptCmd := fmt.Sprintf("[ -f %s ]", utils.ShellQuote(scriptPath))
 	_, err := i.sshClient.Execute(checkScriptCmd)
 	if err != nil {
 		scriptUrl := cdnSuccessUrl + "https://raw.githubusercontent.com/oneclickvirt/incus/main/scripts/add-ipv6.sh"
-		downloadCmd := fmt.Sprintf("wget '%s' -O %s", scriptUrl, scriptPath)
+		downloadCmd := fmt.Sprintf("wget %s -O %s", utils.ShellQuote(scriptUrl), utils.ShellQuote(scriptPath))
 		_, err := i.sshClient.Execute(downloadCmd)
 		if err != nil {
 			global.APP_LOG.Warn("下载add-ipv6.sh脚本失败", zap.Error(err))
 		} else {
-			i.sshClient.Execute(fmt.Sprintf("chmod +x %s", scriptPath))
+			i.sshClient.Execute(fmt.Sprintf("chmod +x %s", utils.ShellQuote(scriptPath)))
 		}
 	}
 
 	// 下载add-ipv6.service服务文件 (Incus版本)
 	servicePath := "/etc/systemd/system/add-ipv6.service"
-	checkServiceCmd := fmt.Sprintf("[ -f %s ]", servicePath)
+	checkServiceCmd := fmt.Sprintf("[ -f %s ]", utils.ShellQuote(servicePath))
 	_, err = i.sshClient.Execute(checkServiceCmd)
 	if err != nil {
 		serviceUrl := cdnSuccessUrl + "https://raw.githubusercontent.com/oneclickvirt/incus/main/scripts/add-ipv6.service"
-		downloadCmd := fmt.Sprintf("wget '%s' -O %s", serviceUrl, servicePath)
+		downloadCmd := fmt.Sprintf("wget %s -O %s", utils.ShellQuote(serviceUrl), utils.ShellQuote(servicePath))
 		_, err := i.sshClient.Execute(downloadCmd)
 		if err != nil {
 			global.APP_LOG.Warn("下载add-ipv6.service服务文件失败", zap.Error(err))
 		} else {
-			i.sshClient.Execute(fmt.Sprintf("chmod +x %s", servicePath))
+			i.sshClient.Execute(fmt.Sprintf("chmod +x %s", utils.ShellQuote(servicePath)))
 			i.sshClient.Execute("systemctl daemon-reload")
 			i.sshClient.Execute("systemctl enable --now add-ipv6.service")
 		}
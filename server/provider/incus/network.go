@@ -305,7 +305,7 @@ func (i *IncusProvider) restartVMForNetwork(instanceName string) error {
 	global.APP_LOG.Info("重启虚拟机获取网络配置", zap.String("instanceName", instanceName))
 
 	// 尝试优雅重启，给虚拟机足够的超时时间
-	restartCmd := fmt.Sprintf("incus restart %s --timeout=120", instanceName)
+	restartCmd := fmt.Sprintf("incus restart %s --timeout=120", utils.ShellQuote(instanceName))
 	_, err := i.sshClient.Execute(restartCmd)
 
 	if err != nil {
@@ -326,7 +326,7 @@ func (i *IncusProvider) restartContainerForNetwork(instanceName string) error {
 	global.APP_LOG.Info("重启容器获取网络配置", zap.String("instanceName", instanceName))
 
 	// 容器重启
-	restartCmd := fmt.Sprintf("incus restart %s --timeout=60", instanceName)
+	restartCmd := fmt.Sprintf("incus restart %s --timeout=60", utils.ShellQuote(instanceName))
 	_, err := i.sshClient.Execute(restartCmd)
 
 	if err != nil {
@@ -347,7 +347,7 @@ func (i *IncusProvider) forceRestartVM(instanceName string) error {
 	global.APP_LOG.Info("强制重启虚拟机", zap.String("instanceName", instanceName))
 
 	// 强制停止虚拟机
-	stopCmd := fmt.Sprintf("incus stop %s --force --timeout=60", instanceName)
+	stopCmd := fmt.Sprintf("incus stop %s --force --timeout=60", utils.ShellQuote(instanceName))
 	_, err := i.sshClient.Execute(stopCmd)
 	if err != nil {
 		global.APP_LOG.Error("强制停止虚拟机失败",
@@ -360,7 +360,7 @@ func (i *IncusProvider) forceRestartVM(instanceName string) error {
 	time.Sleep(10 * time.Second)
 
 	// 启动虚拟机
-	startCmd := fmt.Sprintf("incus start %s", instanceName)
+	startCmd := fmt.Sprintf("incus start %s", utils.ShellQuote(instanceName))
 	_, err = i.sshClient.Execute(startCmd)
 	if err != nil {
 		return fmt.Errorf("启动虚拟机失败: %w", err)
@@ -375,7 +375,7 @@ func (i *IncusProvider) forceRestartContainer(instanceName string) error {
 	global.APP_LOG.Info("强制重启容器", zap.String("instanceName", instanceName))
 
 	// 强制停止容器
-	stopCmd := fmt.Sprintf("incus stop %s --force --timeout=30", instanceName)
+	stopCmd := fmt.Sprintf("incus stop %s --force --timeout=30", utils.ShellQuote(instanceName))
 	_, err := i.sshClient.Execute(stopCmd)
 	if err != nil {
 		global.APP_LOG.Error("强制停止容器失败",
@@ -388,7 +388,7 @@ func (i *IncusProvider) forceRestartContainer(instanceName string) error {
 	time.Sleep(3 * time.Second)
 
 	// 启动容器
-	startCmd := fmt.Sprintf("incus start %s", instanceName)
+	startCmd := fmt.Sprintf("incus start %s", utils.ShellQuote(instanceName))
 	_, err = i.sshClient.Execute(startCmd)
 	if err != nil {
 		return fmt.Errorf("启动容器失败: %w", err)
@@ -415,7 +415,7 @@ func (i *IncusProvider) waitForVMNetworkReady(instanceName string) error {
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 检查虚拟机状态
-		statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(statusCmd)
 		if err != nil {
 			global.APP_LOG.Warn("检查虚拟机状态失败",
@@ -468,7 +468,7 @@ func (i *IncusProvider) waitForContainerNetworkReady(instanceName string) error
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 检查容器状态
-		statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(statusCmd)
 		if err != nil {
 			global.APP_LOG.Warn("检查容器状态失败",
@@ -506,7 +506,7 @@ func (i *IncusProvider) waitForContainerNetworkReady(instanceName string) error
 
 // getInstanceType 获取实例类型
 func (i *IncusProvider) getInstanceType(instanceName string) (string, error) {
-	cmd := fmt.Sprintf("incus info %s | grep \"Type:\" | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("incus info %s | grep \"Type:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取实例类型失败: %w", err)
@@ -526,7 +526,7 @@ func (i *IncusProvider) stopInstanceForConfig(instanceName string) error {
 
 	// 等待一段时间确保实例已经获取到IP
 	time.Sleep(6 * time.Second)
-	_, err := i.sshClient.Execute(fmt.Sprintf("incus stop %s --timeout=30", instanceName))
+	_, err := i.sshClient.Execute(fmt.Sprintf("incus stop %s --timeout=30", utils.ShellQuote(instanceName)))
 	if err != nil {
 		return fmt.Errorf("停止实例失败: %w", err)
 	}
@@ -535,7 +535,7 @@ func (i *IncusProvider) stopInstanceForConfig(instanceName string) error {
 	maxWait := 30
 	waited := 0
 	for waited < maxWait {
-		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(cmd)
 		if err == nil && strings.TrimSpace(output) == "STOPPED" {
 			global.APP_LOG.Info("实例已安全停止", zap.String("instanceName", instanceName))
@@ -573,7 +573,7 @@ func (i *IncusProvider) configureNetworkLimits(instanceName string, networkConfi
 	}
 
 	// 找到主网络接口
-	cmd := fmt.Sprintf("incus config show %s | grep -A5 \"devices:\" | grep \"type: nic\" -B3 | grep \"^  \" | head -n1 | sed 's/://g'", instanceName)
+	cmd := fmt.Sprintf("incus config show %s | grep -A5 \"devices:\" | grep \"type: nic\" -B3 | grep \"^  \" | head -n1 | sed 's/://g'", utils.ShellQuote(instanceName))
 	output, err := i.sshClient.Execute(cmd)
 	var targetInterface string
 	if err == nil && utils.CleanCommandOutput(output) != "" {
@@ -588,7 +588,7 @@ func (i *IncusProvider) configureNetworkLimits(instanceName string, networkConfi
 	maxSpeedMbit := fmt.Sprintf("%dMbit", speedLimit)
 
 	cmd = fmt.Sprintf("incus config device override %s %s limits.egress=%s limits.ingress=%s limits.max=%s",
-		instanceName, targetInterface, outSpeedMbit, inSpeedMbit, maxSpeedMbit)
+		utils.ShellQuote(instanceName), targetInterface, outSpeedMbit, inSpeedMbit, maxSpeedMbit)
 	_, err = i.sshClient.Execute(cmd)
 	if err != nil {
 		global.APP_LOG.Warn("网络限速配置失败",
@@ -715,18 +715,11 @@ func (i *IncusProvider) getVMInstanceIP(instanceName string) (string, error) {
 
 		time.Sleep(time.Duration(delay) * time.Second)
 
-		// 虚拟机通常使用 enp5s0 接口，如果没有则尝试 eth0
-		interfaces := []string{"enp5s0", "eth0"}
-
-		for _, iface := range interfaces {
-			cmd := fmt.Sprintf("incus list %s --format json | jq -r '.[0].state.network.%s.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", instanceName, iface)
-			output, err := i.sshClient.Execute(cmd)
-
-			if err == nil && strings.TrimSpace(output) != "" {
-				vmIP := strings.TrimSpace(output)
+		// 虚拟机的网络地址由Agent上报，Agent未运行时继续等待
+		if state, err := i.queryInstanceState(instanceName); err == nil && state.AgentRunning() {
+			if vmIP := state.IPv4(); vmIP != "" {
 				global.APP_LOG.Info("虚拟机IPv4地址获取成功",
 					zap.String("instanceName", instanceName),
-					zap.String("interface", iface),
 					zap.String("ip", vmIP),
 					zap.Int("attempt", attempt))
 				return vmIP, nil
@@ -760,7 +753,7 @@ func (i *IncusProvider) getContainerInstanceIP(instanceName string) (string, err
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 容器通常使用 eth0 接口
-		cmd := fmt.Sprintf("incus list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", instanceName)
+		cmd := fmt.Sprintf("incus list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(cmd)
 
 		if err == nil && strings.TrimSpace(output) != "" {
@@ -795,7 +788,7 @@ func (i *IncusProvider) getInstanceIPGeneric(instanceName string) (string, error
 			zap.Int("maxRetries", maxRetries))
 
 		// 使用 incus list 简单格式获取IP
-		cmd := fmt.Sprintf("incus list %s -c 4 --format csv", instanceName)
+		cmd := fmt.Sprintf("incus list %s -c 4 --format csv", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(cmd)
 		if err == nil && strings.TrimSpace(output) != "" {
 			global.APP_LOG.Debug("incus list原始输出",
@@ -915,7 +908,7 @@ func (i *IncusProvider) waitForInstanceReady(instanceName string) error {
 	waited := 0
 
 	for waited < maxWait {
-		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		cmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := i.sshClient.Execute(cmd)
 		if err == nil && strings.TrimSpace(output) == "RUNNING" {
 			// 额外等待网络配置就绪
@@ -946,7 +939,7 @@ func (i *IncusProvider) setIPAddressBinding(instanceName, instanceIP string) err
 	}
 
 	// 获取网络接口名称
-	cmd := fmt.Sprintf("incus config show %s | grep -A5 \"devices:\" | grep \"type: nic\" -B3 | grep \"^  \" | head -n1 | sed 's/://g'", instanceName)
+	cmd := fmt.Sprintf("incus config show %s | grep -A5 \"devices:\" | grep \"type: nic\" -B3 | grep \"^  \" | head -n1 | sed 's/://g'", utils.ShellQuote(instanceName))
 	output, err := i.sshClient.Execute(cmd)
 	var targetInterface string
 	if err == nil && utils.CleanCommandOutput(output) != "" {
@@ -960,7 +953,7 @@ func (i *IncusProvider) setIPAddressBinding(instanceName, instanceIP string) err
 	}
 
 	// 尝试设置IP地址绑定
-	cmd = fmt.Sprintf("incus config device set %s %s ipv4.address %s", instanceName, targetInterface, cleanIP)
+	cmd = fmt.Sprintf("incus config device set %s %s ipv4.address %s", utils.ShellQuote(instanceName), targetInterface, cleanIP)
 	_, err = i.sshClient.Execute(cmd)
 	if err != nil {
 		global.APP_LOG.Debug("device set失败，尝试override方式",
@@ -968,7 +961,7 @@ func (i *IncusProvider) setIPAddressBinding(instanceName, instanceIP string) err
 			zap.Error(err))
 
 		// 尝试override方式
-		cmd = fmt.Sprintf("incus config device override %s %s ipv4.address=%s", instanceName, targetInterface, cleanIP)
+		cmd = fmt.Sprintf("incus config device override %s %s ipv4.address=%s", utils.ShellQuote(instanceName), targetInterface, cleanIP)
 		_, err = i.sshClient.Execute(cmd)
 		if err != nil {
 			// 如果不是eth0，最后尝试eth0
@@ -977,7 +970,7 @@ func (i *IncusProvider) setIPAddressBinding(instanceName, instanceIP string) err
 					zap.String("interface", targetInterface),
 					zap.Error(err))
 
-				cmd = fmt.Sprintf("incus config device override %s eth0 ipv4.address=%s", instanceName, cleanIP)
+				cmd = fmt.Sprintf("incus config device override %s eth0 ipv4.address=%s", utils.ShellQuote(instanceName), cleanIP)
 				_, err = i.sshClient.Execute(cmd)
 				if err != nil {
 					global.APP_LOG.Warn("IP地址绑定失败，继续执行",
@@ -1009,7 +1002,7 @@ func (i *IncusProvider) tryUseExistingNetworkConfig(ctx context.Context, config
 		zap.String("instanceName", config.Name))
 
 	// 检查实例是否仍在运行
-	statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", config.Name)
+	statusCmd := fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(config.Name))
 	output, err := i.sshClient.Execute(statusCmd)
 	if err != nil {
 		return fmt.Errorf("检查实例状态失败: %w", err)
@@ -1022,7 +1015,7 @@ func (i *IncusProvider) tryUseExistingNetworkConfig(ctx context.Context, config
 			zap.String("status", status))
 
 		// 尝试启动实例
-		startCmd := fmt.Sprintf("incus start %s", config.Name)
+		startCmd := fmt.Sprintf("incus start %s", utils.ShellQuote(config.Name))
 		_, err := i.sshClient.Execute(startCmd)
 		if err != nil {
 			return fmt.Errorf("启动实例失败: %w", err)
@@ -1033,7 +1026,7 @@ func (i *IncusProvider) tryUseExistingNetworkConfig(ctx context.Context, config
 			zap.String("instanceName", config.Name))
 
 		// 判断实例类型
-		typeCmd := fmt.Sprintf("incus info %s | grep \"Type:\" | awk '{print $2}'", config.Name)
+		typeCmd := fmt.Sprintf("incus info %s | grep \"Type:\" | awk '{print $2}'", utils.ShellQuote(config.Name))
 		typeOutput, err := i.sshClient.Execute(typeCmd)
 		instanceType := strings.TrimSpace(typeOutput)
 
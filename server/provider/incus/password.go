@@ -69,24 +69,27 @@ func (i *IncusProvider) generateRandomPassword() string {
 
 // sshSetInstancePassword 通过SSH设置实例密码
 func (i *IncusProvider) sshSetInstancePassword(instanceID, password string) error {
-	// 首先尝试简单的状态检查命令
-	simpleCheckCmd := fmt.Sprintf("incus list | grep %s", instanceID)
-	output, err := i.sshClient.Execute(simpleCheckCmd)
+	// 检查实例是否存在且运行，同时检测虚拟机Agent是否可用
+	state, err := i.queryInstanceState(instanceID)
 	if err != nil {
 		global.APP_LOG.Error("检查Incus实例状态失败",
 			zap.String("instanceID", instanceID),
 			zap.Error(err))
 		return fmt.Errorf("检查实例状态失败: %w", err)
 	}
-	// 检查实例是否存在且运行
-	if !strings.Contains(output, instanceID) {
-		return fmt.Errorf("实例 %s 不存在", instanceID)
-	}
-	if !strings.Contains(output, "RUNNING") {
+	if !strings.EqualFold(state.Status, "running") {
 		return fmt.Errorf("实例 %s 未运行，无法设置密码", instanceID)
 	}
+	if state.IsVM() {
+		if state.AgentRunning() {
+			return i.agentSetPassword(instanceID, "root", password)
+		}
+		global.APP_LOG.Warn("虚拟机incus-agent未运行，回退到脚本方式设置密码",
+			zap.String("instanceID", instanceID))
+	}
+
 	// 设置密码 - 使用incus exec命令
-	setPasswordCmd := fmt.Sprintf("incus exec %s -- bash -c 'echo \"root:%s\" | chpasswd'", instanceID, password)
+	setPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | incus exec %s -- chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(instanceID))
 	_, err = i.sshClient.Execute(setPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("设置Incus实例密码失败",
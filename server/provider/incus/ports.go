@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
 	"sort"
 	"strings"
 
@@ -273,7 +274,7 @@ func (i *IncusProvider) setupDeviceProxyMappingWithIP(instanceName string, hostP
 		// 创建TCP设备
 		tcpDeviceName := fmt.Sprintf("proxy-tcp-%d", hostPort)
 		tcpCmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d connect=%s:0.0.0.0:%d nat=true",
-			instanceName, tcpDeviceName, "tcp", hostIP, hostPort, "tcp", guestPort)
+			utils.ShellQuote(instanceName), tcpDeviceName, "tcp", hostIP, hostPort, "tcp", guestPort)
 
 		_, err = i.sshClient.Execute(tcpCmd)
 		if err != nil {
@@ -283,7 +284,7 @@ func (i *IncusProvider) setupDeviceProxyMappingWithIP(instanceName string, hostP
 		// 创建UDP设备
 		udpDeviceName := fmt.Sprintf("proxy-udp-%d", hostPort)
 		udpCmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d connect=%s:0.0.0.0:%d nat=true",
-			instanceName, udpDeviceName, "udp", hostIP, hostPort, "udp", guestPort)
+			utils.ShellQuote(instanceName), udpDeviceName, "udp", hostIP, hostPort, "udp", guestPort)
 
 		_, err = i.sshClient.Execute(udpCmd)
 		if err != nil {
@@ -300,7 +301,7 @@ func (i *IncusProvider) setupDeviceProxyMappingWithIP(instanceName string, hostP
 		// 单一协议
 		deviceName := fmt.Sprintf("proxy-%s-%d", protocol, hostPort)
 		cmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d connect=%s:0.0.0.0:%d nat=true",
-			instanceName, deviceName, strings.ToLower(protocol), hostIP, hostPort, strings.ToLower(protocol), guestPort)
+			utils.ShellQuote(instanceName), deviceName, strings.ToLower(protocol), hostIP, hostPort, strings.ToLower(protocol), guestPort)
 
 		_, err = i.sshClient.Execute(cmd)
 		if err != nil {
@@ -334,8 +335,8 @@ func (i *IncusProvider) setupIptablesMappingWithIP(instanceName string, hostPort
 
 	for _, proto := range protocols {
 		// DNAT规则
-		dnatCmd := fmt.Sprintf("iptables -t nat -A PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
+		dnatCmd := utils.DNATAddCommand(i.config.PublicInterface, fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+			proto, hostPort, instanceIP, guestPort))
 		_, err := i.sshClient.Execute(dnatCmd)
 		if err != nil {
 			return fmt.Errorf("添加%s DNAT规则失败: %w", proto, err)
@@ -500,7 +501,7 @@ func (i *IncusProvider) setupPortRangeMapping(instanceName string, startPort, en
 		// 创建TCP范围映射
 		tcpDeviceName := fmt.Sprintf("proxy-tcp-%d-%d", startPort, endPort)
 		tcpCmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:0.0.0.0:%d-%d nat=true",
-			instanceName, tcpDeviceName, "tcp", hostIP, startPort, endPort, "tcp", startPort, endPort)
+			utils.ShellQuote(instanceName), tcpDeviceName, "tcp", hostIP, startPort, endPort, "tcp", startPort, endPort)
 
 		_, err = i.sshClient.Execute(tcpCmd)
 		if err != nil {
@@ -510,7 +511,7 @@ func (i *IncusProvider) setupPortRangeMapping(instanceName string, startPort, en
 		// 创建UDP范围映射
 		udpDeviceName := fmt.Sprintf("proxy-udp-%d-%d", startPort, endPort)
 		udpCmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:0.0.0.0:%d-%d nat=true",
-			instanceName, udpDeviceName, "udp", hostIP, startPort, endPort, "udp", startPort, endPort)
+			utils.ShellQuote(instanceName), udpDeviceName, "udp", hostIP, startPort, endPort, "udp", startPort, endPort)
 
 		_, err = i.sshClient.Execute(udpCmd)
 		if err != nil {
@@ -527,7 +528,7 @@ func (i *IncusProvider) setupPortRangeMapping(instanceName string, startPort, en
 		// 单一协议
 		deviceName := fmt.Sprintf("proxy-%s-%d-%d", protocol, startPort, endPort)
 		cmd := fmt.Sprintf("incus config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:0.0.0.0:%d-%d nat=true",
-			instanceName, deviceName, strings.ToLower(protocol), hostIP, startPort, endPort, strings.ToLower(protocol), startPort, endPort)
+			utils.ShellQuote(instanceName), deviceName, strings.ToLower(protocol), hostIP, startPort, endPort, strings.ToLower(protocol), startPort, endPort)
 
 		_, err = i.sshClient.Execute(cmd)
 		if err != nil {
@@ -569,7 +570,7 @@ func (i *IncusProvider) removeDeviceProxyMapping(instanceName string, hostPort i
 	if protocol == "both" {
 		// 删除TCP设备
 		tcpDeviceName := fmt.Sprintf("proxy-tcp-%d", hostPort)
-		tcpRemoveCmd := fmt.Sprintf("incus config device remove %s %s", instanceName, tcpDeviceName)
+		tcpRemoveCmd := fmt.Sprintf("incus config device remove %s %s", utils.ShellQuote(instanceName), tcpDeviceName)
 		_, err := i.sshClient.Execute(tcpRemoveCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移除TCP proxy设备失败",
@@ -580,7 +581,7 @@ func (i *IncusProvider) removeDeviceProxyMapping(instanceName string, hostPort i
 
 		// 删除UDP设备
 		udpDeviceName := fmt.Sprintf("proxy-udp-%d", hostPort)
-		udpRemoveCmd := fmt.Sprintf("incus config device remove %s %s", instanceName, udpDeviceName)
+		udpRemoveCmd := fmt.Sprintf("incus config device remove %s %s", utils.ShellQuote(instanceName), udpDeviceName)
 		_, err = i.sshClient.Execute(udpRemoveCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移除UDP proxy设备失败",
@@ -595,7 +596,7 @@ func (i *IncusProvider) removeDeviceProxyMapping(instanceName string, hostPort i
 	} else {
 		// 单一协议
 		deviceName := fmt.Sprintf("proxy-%s-%d", protocol, hostPort)
-		removeCmd := fmt.Sprintf("incus config device remove %s %s", instanceName, deviceName)
+		removeCmd := fmt.Sprintf("incus config device remove %s %s", utils.ShellQuote(instanceName), deviceName)
 		_, err := i.sshClient.Execute(removeCmd)
 		if err != nil {
 			return fmt.Errorf("移除proxy设备失败: %w", err)
@@ -625,8 +626,8 @@ func (i *IncusProvider) removeIptablesMappingByPort(instanceName string, hostPor
 
 	for _, proto := range protocols {
 		// 移除DNAT规则
-		dnatCmd := fmt.Sprintf("iptables -t nat -D PREROUTING -p %s --dport %d -j DNAT --to-destination %s",
-			proto, hostPort, instanceIP)
+		dnatCmd := utils.DNATDeleteCommand(fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s",
+			proto, hostPort, instanceIP), i.config.PublicInterface, "")
 
 		_, err = i.sshClient.Execute(dnatCmd)
 		if err != nil {
@@ -0,0 +1,49 @@
+package incus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// CreateSnapshot 为实例创建快照
+func (i *IncusProvider) CreateSnapshot(ctx context.Context, instanceID, name string) error {
+	if !i.connected || i.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if !provider.ValidSnapshotName(name) {
+		return fmt.Errorf("无效的快照名: %s", name)
+	}
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus snapshot create %s %s", utils.ShellQuote(instanceID), name))
+	if err != nil {
+		return fmt.Errorf("创建快照失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	global.APP_LOG.Info("Incus实例快照创建成功",
+		zap.String("instanceName", utils.TruncateString(instanceID, 50)),
+		zap.String("snapshot", name))
+	return nil
+}
+
+// DeleteSnapshot 删除实例快照，快照不存在时视为成功
+func (i *IncusProvider) DeleteSnapshot(ctx context.Context, instanceID, name string) error {
+	if !i.connected || i.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if !provider.ValidSnapshotName(name) {
+		return fmt.Errorf("无效的快照名: %s", name)
+	}
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus snapshot delete %s %s", utils.ShellQuote(instanceID), name))
+	if err != nil {
+		if strings.Contains(output, "not found") {
+			return nil
+		}
+		return fmt.Errorf("删除快照失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
@@ -40,7 +41,7 @@ func (i *IncusProvider) sshListInstances() ([]provider.Instance, error) {
 		instance := provider.Instance{
 			ID:     name,
 			Name:   name,
-			Status: strings.ToLower(status),
+			Status: constant.NormalizeProviderStatus("incus", status),
 			Type:   instanceType,
 		}
 
@@ -275,7 +276,7 @@ func (i *IncusProvider) sshCreateInstanceWithProgress(ctx context.Context, confi
 	updateProgress(50, "启动实例...")
 	// 启动实例
 	time.Sleep(6 * time.Second)
-	_, err = i.sshClient.Execute(fmt.Sprintf("incus start %s", config.Name))
+	_, err = i.sshClient.Execute(fmt.Sprintf("incus start %s", utils.ShellQuote(config.Name)))
 	if err != nil {
 		return fmt.Errorf("启动实例失败: %w", err)
 	}
@@ -572,14 +573,14 @@ func (i *IncusProvider) configureInstanceStorage(ctx context.Context, config pro
 
 func (i *IncusProvider) sshStartInstance(id string) error {
 	// 先检查实例状态，如果已经在运行则跳过启动
-	output, err := i.sshClient.Execute(fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", id))
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(id)))
 	if err == nil && strings.TrimSpace(output) == "RUNNING" {
 		global.APP_LOG.Info("Incus 实例已在运行，跳过启动", zap.String("id", id))
 		return nil
 	}
 
 	// 执行启动命令
-	_, err = i.sshClient.Execute(fmt.Sprintf("incus start %s", id))
+	_, err = i.sshClient.Execute(fmt.Sprintf("incus start %s", utils.ShellQuote(id)))
 	if err != nil {
 		// 如果错误信息提示实例已在运行，则不视为错误
 		if strings.Contains(err.Error(), "already running") ||
@@ -607,7 +608,7 @@ func (i *IncusProvider) sshStartInstance(id string) error {
 		time.Sleep(checkInterval)
 
 		// 检查实例状态
-		statusOutput, err := i.sshClient.Execute(fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", id))
+		statusOutput, err := i.sshClient.Execute(fmt.Sprintf("incus info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(id)))
 		if err == nil {
 			status := strings.TrimSpace(statusOutput)
 			if status == "RUNNING" || status == "Running" {
@@ -627,7 +628,7 @@ func (i *IncusProvider) sshStartInstance(id string) error {
 }
 
 func (i *IncusProvider) sshStopInstance(id string) error {
-	_, err := i.sshClient.Execute(fmt.Sprintf("incus stop %s", id))
+	_, err := i.sshClient.Execute(fmt.Sprintf("incus stop %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to stop instance: %w", err)
 	}
@@ -637,7 +638,7 @@ func (i *IncusProvider) sshStopInstance(id string) error {
 }
 
 func (i *IncusProvider) sshRestartInstance(id string) error {
-	_, err := i.sshClient.Execute(fmt.Sprintf("incus restart %s", id))
+	_, err := i.sshClient.Execute(fmt.Sprintf("incus restart %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to restart instance: %w", err)
 	}
@@ -657,7 +658,7 @@ func (i *IncusProvider) sshDeleteInstance(id string) error {
 		zap.String("host", utils.TruncateString(i.config.Host, 32)),
 		zap.String("instance_id", id))
 
-	output, err := i.sshClient.Execute(fmt.Sprintf("incus delete %s --force", id))
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus delete %s --force", utils.ShellQuote(id)))
 	if err != nil {
 		// 检查是否是实例不存在的错误
 		if strings.Contains(output, "Instance not found") || strings.Contains(output, "not found") {
@@ -703,7 +704,7 @@ func (i *IncusProvider) sshListImages() ([]provider.Image, error) {
 }
 
 func (i *IncusProvider) sshPullImage(image string) error {
-	_, err := i.sshClient.Execute(fmt.Sprintf("incus image copy images:%s local:", image))
+	_, err := i.sshClient.Execute(fmt.Sprintf("incus image copy images:%s local:", utils.ShellQuote(image)))
 	if err != nil {
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
@@ -713,7 +714,7 @@ func (i *IncusProvider) sshPullImage(image string) error {
 }
 
 func (i *IncusProvider) sshDeleteImage(id string) error {
-	_, err := i.sshClient.Execute(fmt.Sprintf("incus image delete %s", id))
+	_, err := i.sshClient.Execute(fmt.Sprintf("incus image delete %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to delete image: %w", err)
 	}
@@ -797,7 +798,7 @@ func (i *IncusProvider) setInstanceConfig(ctx context.Context, instanceName stri
 	}
 
 	// SSH方式设置配置
-	cmd := fmt.Sprintf("incus config set %s %s %s", instanceName, key, value)
+	cmd := fmt.Sprintf("incus config set %s", utils.ShellJoin(instanceName, key, value))
 	_, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return fmt.Errorf("SSH设置实例配置失败: %w", err)
@@ -841,7 +842,7 @@ func (i *IncusProvider) setInstanceDeviceConfig(ctx context.Context, instanceNam
 	}
 
 	// SSH方式设置设备配置
-	cmd := fmt.Sprintf("incus config device set %s %s %s %s", instanceName, deviceName, key, value)
+	cmd := fmt.Sprintf("incus config device set %s", utils.ShellJoin(instanceName, deviceName, key, value))
 	_, err := i.sshClient.Execute(cmd)
 	if err != nil {
 		return fmt.Errorf("SSH设置实例设备配置失败: %w", err)
@@ -908,7 +909,7 @@ func (i *IncusProvider) ensureSSHScriptsAvailable(providerCountry string) error
 		}
 
 		// 设置执行权限
-		chmodCmd := fmt.Sprintf("chmod +x %s", scriptPath)
+		chmodCmd := fmt.Sprintf("chmod +x %s", utils.ShellQuote(scriptPath))
 		if _, err := i.sshClient.Execute(chmodCmd); err != nil {
 			global.APP_LOG.Error("设置SSH脚本执行权限失败",
 				zap.String("script", script),
@@ -917,7 +918,7 @@ func (i *IncusProvider) ensureSSHScriptsAvailable(providerCountry string) error
 		}
 
 		// 使用dos2unix处理脚本格式（如果可用）
-		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", scriptPath)
+		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", utils.ShellQuote(scriptPath))
 		i.sshClient.Execute(dos2unixCmd)
 
 		global.APP_LOG.Info("SSH脚本下载并设置完成",
@@ -935,7 +936,7 @@ func (i *IncusProvider) getSSHScriptDownloadURL(originalURL, providerCountry str
 	if providerCountry == "CN" || providerCountry == "cn" {
 		if cdnURL := i.getSSHScriptCDNURL(originalURL); cdnURL != "" {
 			// 测试CDN可用性
-			testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+			testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 			if _, err := i.sshClient.Execute(testCmd); err == nil {
 				global.APP_LOG.Info("使用CDN下载SSH脚本",
 					zap.String("cdnURL", cdnURL))
@@ -956,7 +957,7 @@ func (i *IncusProvider) getSSHScriptCDNURL(originalURL string) string {
 	for _, endpoint := range cdnEndpoints {
 		cdnURL := endpoint + originalURL
 		// 测试CDN可用性
-		testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+		testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 		if _, err := i.sshClient.Execute(testCmd); err == nil {
 			return cdnURL
 		}
@@ -5,10 +5,7 @@ import (
 	"strconv"
 	"strings"
 
-	"oneclickvirt/global"
 	"oneclickvirt/utils"
-
-	"go.uber.org/zap"
 )
 
 // convertMemoryFormat 转换内存格式为Incus支持的格式
@@ -91,18 +88,7 @@ func m(a, b int) int {
 	return b
 }
 
-// getDownloadURL 确定下载URL
-func (i *IncusProvider) getDownloadURL(originalURL string, useCDN bool) string {
-	// 如果不使用CDN，直接返回原始URL
-	if !useCDN {
-		global.APP_LOG.Info("镜像配置不使用CDN，使用原始URL",
-			zap.String("originalURL", utils.TruncateString(originalURL, 100)))
-		return originalURL
-	}
-
-	// 默认随机尝试CDN，不再限制地区
-	if cdnURL := utils.GetCDNURL(i.sshClient, originalURL, "Incus"); cdnURL != "" {
-		return cdnURL
-	}
-	return originalURL
+// getDownloadSources 确定镜像的候选下载来源：Provider镜像源、CDN（useCDN时）、原始地址，上次成功的来源优先
+func (i *IncusProvider) getDownloadSources(originalURL string, useCDN bool) []utils.DownloadSource {
+	return utils.ImageDownloadSources(originalURL, i.config.ImageMirrors, useCDN, utils.PreferredImageSource(i.config.ID))
 }
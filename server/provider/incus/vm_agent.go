@@ -0,0 +1,61 @@
+package incus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// queryInstanceState 读取实例状态，虚拟机的进程数和网络地址由incus-agent上报
+func (i *IncusProvider) queryInstanceState(instanceName string) (*provider.VMAgentState, error) {
+	if i.sshClient == nil {
+		return nil, fmt.Errorf("SSH连接不可用")
+	}
+	output, err := i.sshClient.Execute(fmt.Sprintf("incus list %s --format json", utils.ShellQuote(instanceName)))
+	if err != nil {
+		return nil, fmt.Errorf("获取实例状态失败: %w", err)
+	}
+	return provider.ParseVMAgentState(output, instanceName)
+}
+
+// VMAgentAvailable 检测实例的执行通道是否可用
+func (i *IncusProvider) VMAgentAvailable(ctx context.Context, instanceID string) bool {
+	state, err := i.queryInstanceState(instanceID)
+	if err != nil {
+		global.APP_LOG.Debug("检测incus-agent失败", zap.String("instanceName", instanceID), zap.Error(err))
+		return false
+	}
+	return state.AgentRunning()
+}
+
+// agentExec 通过incus-agent执行通道在实例内执行脚本
+func (i *IncusProvider) agentExec(instanceName, script string) (string, error) {
+	return i.sshClient.Execute(fmt.Sprintf("incus exec %s -- sh -c %s", utils.ShellQuote(instanceName), utils.ShellQuote(script)))
+}
+
+// agentSetPassword 通过incus-agent设置用户密码并开启SSH密码登录，无需推送配置脚本
+func (i *IncusProvider) agentSetPassword(instanceName, username, password string) error {
+	if output, err := i.agentExec(instanceName, provider.AgentPasswordScript(username, password)); err != nil {
+		return fmt.Errorf("通过incus-agent设置密码失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	global.APP_LOG.Info("Incus实例密码设置成功(Agent)",
+		zap.String("instanceName", utils.TruncateString(instanceName, 12)))
+	return nil
+}
+
+// InjectInstanceSSHKeys 通过incus-agent执行通道写入SSH公钥
+func (i *IncusProvider) InjectInstanceSSHKeys(ctx context.Context, instanceID, username string, keys []string) error {
+	if !i.connected || i.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if output, err := i.agentExec(instanceID, provider.AgentSSHKeysScript(username, keys)); err != nil {
+		return fmt.Errorf("通过incus-agent写入SSH公钥失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+
+	"go.uber.org/zap"
+)
+
+// DiscoverInstances 发现命名空间中由本系统创建的实例
+func (k *KubernetesProvider) DiscoverInstances(ctx context.Context) ([]provider.DiscoveredInstance, error) {
+	if !k.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	global.APP_LOG.Info("开始发现Kubernetes实例",
+		zap.String("provider", k.config.Name),
+		zap.String("namespace", k.namespace()))
+
+	instances, err := k.sshListInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("执行SSH命令失败: %w", err)
+	}
+
+	discovered := make([]provider.DiscoveredInstance, 0, len(instances))
+	for _, inst := range instances {
+		discovered = append(discovered, provider.DiscoveredInstance{
+			UUID:         inst.Name,
+			Name:         inst.Name,
+			Status:       inst.Status,
+			InstanceType: "container",
+			PrivateIP:    inst.PrivateIP,
+			IPv6Address:  inst.IPv6Address,
+			SSHPort:      22,
+			Image:        inst.Image,
+			RawData:      inst,
+		})
+	}
+
+	global.APP_LOG.Info("Kubernetes实例发现完成",
+		zap.String("provider", k.config.Name),
+		zap.Int("count", len(discovered)))
+	return discovered, nil
+}
@@ -0,0 +1,314 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/images"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// instanceSelector 选择本系统创建的实例资源
+var instanceSelector = fmt.Sprintf("-l %s=%s", labelManagedBy, managedByValue)
+
+func (k *KubernetesProvider) sshListInstances(ctx context.Context) ([]provider.Instance, error) {
+	return k.listInstances(instanceSelector)
+}
+
+func (k *KubernetesProvider) sshGetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if err := validateName(id); err != nil {
+		return nil, err
+	}
+	instances, err := k.listInstances(fmt.Sprintf("-l %s=%s", labelInstance, id))
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("instance not found: %s", id)
+	}
+
+	instance := &instances[0]
+	// 独立IP模式下的公网地址由LoadBalancer Service分配
+	lbCmd := k.kubectl(fmt.Sprintf("get service %s -o jsonpath='{.status.loadBalancer.ingress[0].ip}' 2>/dev/null", utils.ShellQuote(loadBalancerServiceName(id))))
+	if output, err := k.sshClient.Execute(lbCmd); err == nil {
+		instance.PublicIP = utils.CleanCommandOutput(output)
+	}
+	return instance, nil
+}
+
+// listInstances 按标签选择器列出实例
+func (k *KubernetesProvider) listInstances(selector string) ([]provider.Instance, error) {
+	stsOutput, err := k.sshClient.ExecuteWithLogging(k.kubectl(fmt.Sprintf("get statefulsets %s -o jsonpath=%s", selector, utils.ShellQuote(statefulSetListJSONPath))), "K8S_LIST")
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet列表失败: %w", err)
+	}
+	podOutput, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("get pods %s -o jsonpath=%s", selector, utils.ShellQuote(podListJSONPath))))
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %w", err)
+	}
+
+	instances := parseInstanceList(stsOutput, parsePodStates(podOutput))
+	global.APP_LOG.Debug("获取Kubernetes实例列表成功", zap.Int("count", len(instances)))
+	return instances, nil
+}
+
+// statefulSetExists 检查实例的StatefulSet是否存在
+func (k *KubernetesProvider) statefulSetExists(name string) bool {
+	_, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("get statefulset %s -o name", utils.ShellQuote(name))))
+	return err == nil
+}
+
+// GetInstanceIPv4 获取实例Pod的IPv4地址 (公开方法)
+func (k *KubernetesProvider) GetInstanceIPv4(ctx context.Context, instanceName string) (string, error) {
+	output, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("get pod %s -o jsonpath='{.status.podIP}'", utils.ShellQuote(podName(instanceName)))))
+	if err != nil {
+		return "", fmt.Errorf("获取Pod信息失败: %w", err)
+	}
+	ip := utils.CleanCommandOutput(output)
+	if ip == "" {
+		return "", fmt.Errorf("实例 %s 的Pod尚未分配IP", instanceName)
+	}
+	return ip, nil
+}
+
+func (k *KubernetesProvider) sshCreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	// 进度更新辅助函数
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+		global.APP_LOG.Info("Kubernetes实例创建进度",
+			zap.String("instance", config.Name),
+			zap.Int("percentage", percentage),
+			zap.String("message", message))
+	}
+
+	updateProgress(10, "开始创建Kubernetes实例...")
+
+	if err := validateName(config.Name); err != nil {
+		return err
+	}
+	if k.statefulSetExists(config.Name) {
+		return fmt.Errorf("实例 %s 已存在", config.Name)
+	}
+
+	if config.ImageURL == "" {
+		if err := k.queryAndSetSystemImage(ctx, &config); err != nil {
+			return err
+		}
+	}
+	image, err := imageReference(config.ImageURL)
+	if err != nil {
+		return err
+	}
+
+	diskMB := sizeMB(config.Disk)
+	if diskMB == 0 {
+		return fmt.Errorf("无效的磁盘大小: %s", config.Disk)
+	}
+
+	updateProgress(20, "创建实例密码...")
+	password := utils.GenerateInstancePassword()
+	secret, err := buildSecretManifest(k.namespace(), config.Name, password)
+	if err != nil {
+		return fmt.Errorf("生成Secret失败: %w", err)
+	}
+	if err := k.apply(secret); err != nil {
+		return fmt.Errorf("创建Secret失败: %w", err)
+	}
+
+	updateProgress(30, "创建StatefulSet和网络策略...")
+	manifest, err := buildInstanceManifest(instanceSpec{
+		Name:      config.Name,
+		Namespace: k.namespace(),
+		Image:     image,
+		CPU:       config.CPU,
+		MemoryMB:  sizeMB(config.Memory),
+		DiskMB:    diskMB,
+		SSHPort:   provider.GuestSSHPortFromConfig(config),
+	})
+	if err != nil {
+		k.cleanupInstanceResources(config.Name)
+		return fmt.Errorf("生成实例清单失败: %w", err)
+	}
+	if err := k.apply(manifest); err != nil {
+		k.cleanupInstanceResources(config.Name)
+		return fmt.Errorf("创建实例失败: %w", err)
+	}
+
+	updateProgress(50, "等待Pod就绪...")
+	if err := k.waitForReady(ctx, config.Name, 10*time.Minute); err != nil {
+		// Pod未就绪时保留资源，便于排查镜像拉取或调度失败的原因
+		return err
+	}
+	k.saveInstancePassword(config.Name, password)
+
+	updateProgress(85, "配置端口映射...")
+	networkType := k.config.NetworkType
+	if config.Metadata != nil {
+		if metaNetworkType, ok := config.Metadata["network_type"]; ok {
+			networkType = metaNetworkType
+		}
+	}
+	if err := k.configurePortMappings(ctx, config.Name, networkType, provider.GuestSSHPortFromConfig(config)); err != nil {
+		global.APP_LOG.Warn("配置端口映射失败",
+			zap.String("instance", config.Name),
+			zap.Error(err))
+	}
+
+	updateProgress(100, "Kubernetes实例创建完成")
+	global.APP_LOG.Info("Kubernetes实例创建成功",
+		zap.String("instance", config.Name),
+		zap.String("namespace", k.namespace()),
+		zap.String("image", image))
+	return nil
+}
+
+// queryAndSetSystemImage 从数据库查询匹配的系统镜像记录并设置到配置中
+func (k *KubernetesProvider) queryAndSetSystemImage(ctx context.Context, config *provider.InstanceConfig) error {
+	query := global.APP_DB.WithContext(ctx).Where("provider_type = ? AND instance_type = ?", "kubernetes", "container")
+
+	// 按操作系统匹配（如果配置中有指定）
+	if config.Image != "" {
+		imageLower := strings.ToLower(config.Image)
+		query = query.Where("LOWER(os_type) LIKE ? OR LOWER(name) LIKE ?", "%"+imageLower+"%", "%"+imageLower+"%")
+	}
+
+	// 按架构筛选，默认使用amd64
+	architecture := k.config.Architecture
+	if architecture == "" {
+		architecture = "amd64"
+	}
+	query = query.Where("architecture = ?", architecture)
+
+	var candidates []systemModel.SystemImage
+	if err := query.Where("status = ?", "active").Order("created_at DESC").Find(&candidates).Error; err != nil {
+		return fmt.Errorf("未找到匹配的系统镜像: %w", err)
+	}
+
+	// 排除被镜像禁用策略命中的镜像（节点侧无法得知用户等级，仅应用对所有用户生效的策略）
+	imageService := &images.ImageService{}
+	candidates = imageService.FilterBannedImages(candidates, 0, k.config.ID)
+	if len(candidates) == 0 {
+		return fmt.Errorf("未找到匹配的系统镜像: 无可用镜像或镜像已被禁用")
+	}
+
+	config.ImageURL = candidates[0].URL
+	global.APP_LOG.Info("从数据库获取到系统镜像配置",
+		zap.String("imageName", candidates[0].Name),
+		zap.String("originalURL", utils.TruncateString(candidates[0].URL, 100)))
+	return nil
+}
+
+// waitForReady 等待实例Pod就绪（sshd开始监听）
+func (k *KubernetesProvider) waitForReady(ctx context.Context, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		instance, err := k.sshGetInstance(ctx, name)
+		if err == nil {
+			switch instance.Status {
+			case "running":
+				return nil
+			case "failed", "crashloopbackoff":
+				return fmt.Errorf("实例 %s 启动失败，Pod状态: %s", name, instance.Status)
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待实例 %s 就绪超时", name)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// scale 调整实例StatefulSet的副本数，0为停止，1为运行
+func (k *KubernetesProvider) scale(name string, replicas int) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	output, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("scale statefulset %s --replicas=%d", utils.ShellQuote(name), replicas)))
+	if err != nil {
+		return fmt.Errorf("调整实例副本数失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+	return nil
+}
+
+func (k *KubernetesProvider) sshStartInstance(ctx context.Context, id string) error {
+	if err := k.scale(id, 1); err != nil {
+		return err
+	}
+	if err := k.waitForReady(ctx, id, 5*time.Minute); err != nil {
+		return err
+	}
+	global.APP_LOG.Info("Kubernetes实例启动成功", zap.String("instance", id))
+	return nil
+}
+
+func (k *KubernetesProvider) sshStopInstance(ctx context.Context, id string) error {
+	if err := k.scale(id, 0); err != nil {
+		return err
+	}
+	// 等待Pod终止，PVC保留
+	waitCmd := k.kubectl(fmt.Sprintf("wait --for=delete pod/%s --timeout=60s 2>/dev/null || true", podName(id)))
+	k.sshClient.Execute(waitCmd)
+	global.APP_LOG.Info("Kubernetes实例停止成功", zap.String("instance", id))
+	return nil
+}
+
+// sshRestartInstance 删除Pod由StatefulSet重建，容器根文件系统会被还原，PVC中的数据保留
+func (k *KubernetesProvider) sshRestartInstance(ctx context.Context, id string) error {
+	if err := validateName(id); err != nil {
+		return err
+	}
+	output, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("delete pod %s --wait=true --ignore-not-found", utils.ShellQuote(podName(id)))))
+	if err != nil {
+		return fmt.Errorf("重启实例失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+	return k.sshStartInstance(ctx, id)
+}
+
+func (k *KubernetesProvider) sshDeleteInstance(ctx context.Context, id string) error {
+	if err := validateName(id); err != nil {
+		return err
+	}
+	if err := k.cleanupInstanceResources(id); err != nil {
+		return err
+	}
+	global.APP_LOG.Info("Kubernetes实例删除成功", zap.String("instance", id))
+	return nil
+}
+
+// cleanupInstanceResources 删除实例的全部资源，包括端口映射Service和PVC
+// volumeClaimTemplates中的标签会带到PVC上，PVC在Pod终止后由保护机制释放
+func (k *KubernetesProvider) cleanupInstanceResources(name string) error {
+	selector := fmt.Sprintf("-l %s=%s", labelInstance, name)
+	cmd := k.kubectl(fmt.Sprintf("delete statefulset,service,networkpolicy,secret,pvc %s --ignore-not-found --wait=false", selector))
+	output, err := k.sshClient.Execute(cmd)
+	if err != nil {
+		return fmt.Errorf("删除实例资源失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+	return nil
+}
+
+// saveInstancePassword 保存实例密码到数据库
+func (k *KubernetesProvider) saveInstancePassword(name, password string) {
+	err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ?", name).
+		Update("password", password).Error
+	if err != nil {
+		global.APP_LOG.Warn("更新实例密码到数据库失败",
+			zap.String("instanceName", name),
+			zap.Error(err))
+	}
+}
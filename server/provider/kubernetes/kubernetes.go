@@ -0,0 +1,309 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// defaultNamespace 未配置项目时实例所在的命名空间
+const defaultNamespace = "oneclickvirt"
+
+// KubernetesProvider 实验性的Kubernetes Provider，实例以单副本StatefulSet运行
+// 通过SSH在已配置kubectl的控制节点上执行命令，不直接访问API Server
+type KubernetesProvider struct {
+	config        provider.NodeConfig
+	sshClient     *utils.SSHClient
+	connected     bool
+	healthChecker health.HealthChecker
+	version       string       // Kubernetes 服务端版本
+	mu            sync.RWMutex // 保护并发访问
+}
+
+func NewKubernetesProvider() provider.Provider {
+	return &KubernetesProvider{}
+}
+
+func (k *KubernetesProvider) GetType() string {
+	return "kubernetes"
+}
+
+func (k *KubernetesProvider) GetName() string {
+	return k.config.Name
+}
+
+func (k *KubernetesProvider) GetSupportedInstanceTypes() []string {
+	return []string{"container"}
+}
+
+func (k *KubernetesProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	k.config = config
+	global.APP_LOG.Info("Kubernetes provider开始连接",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port))
+
+	// 设置SSH超时配置
+	sshConnectTimeout := config.SSHConnectTimeout
+	sshExecuteTimeout := config.SSHExecuteTimeout
+	if sshConnectTimeout <= 0 {
+		sshConnectTimeout = 30 // 默认30秒
+	}
+	if sshExecuteTimeout <= 0 {
+		sshExecuteTimeout = 300 // 默认300秒
+	}
+
+	sshConfig := utils.SSHConfig{
+		Host:           config.Host,
+		Port:           config.Port,
+		Username:       config.Username,
+		Password:       config.Password,
+		PrivateKey:     config.PrivateKey,
+		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
+		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
+	}
+	client, err := utils.NewSSHClient(sshConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+
+	k.sshClient = client
+	k.connected = true
+
+	// 初始化健康检查器，使用Provider的SSH连接，避免创建独立连接导致节点混淆
+	healthConfig := health.HealthConfig{
+		Host:          config.Host,
+		Port:          config.Port,
+		Username:      config.Username,
+		Password:      config.Password,
+		PrivateKey:    config.PrivateKey,
+		APIEnabled:    false, // 集群API通过kubectl访问
+		SSHEnabled:    true,
+		Timeout:       30 * time.Second,
+		ServiceChecks: []string{"kubernetes"},
+	}
+	zapLogger, _ := zap.NewProduction()
+	k.healthChecker = health.NewKubernetesHealthCheckerWithSSH(healthConfig, zapLogger, client.GetUnderlyingClient())
+
+	if err := k.getKubernetesVersion(); err != nil {
+		global.APP_LOG.Warn("Kubernetes 版本获取失败", zap.Error(err))
+	}
+
+	// 实例所在的命名空间不存在时自动创建
+	ensureCmd := fmt.Sprintf("kubectl get namespace %s >/dev/null 2>&1 || kubectl create namespace %s", utils.ShellQuote(k.namespace()), utils.ShellQuote(k.namespace()))
+	if output, err := k.sshClient.Execute(ensureCmd); err != nil {
+		global.APP_LOG.Warn("创建Kubernetes命名空间失败",
+			zap.String("namespace", k.namespace()),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+	}
+
+	global.APP_LOG.Info("Kubernetes provider连接成功",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port),
+		zap.String("namespace", k.namespace()),
+		zap.String("version", k.version))
+
+	return nil
+}
+
+func (k *KubernetesProvider) Disconnect(ctx context.Context) error {
+	if k.sshClient != nil {
+		k.sshClient.Close()
+		k.connected = false
+	}
+	return nil
+}
+
+func (k *KubernetesProvider) IsConnected() bool {
+	return k.connected && k.sshClient != nil && k.sshClient.IsHealthy()
+}
+
+func (k *KubernetesProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	if k.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	return k.healthChecker.CheckHealth(ctx)
+}
+
+func (k *KubernetesProvider) GetHealthChecker() health.HealthChecker {
+	return k.healthChecker
+}
+
+func (k *KubernetesProvider) GetVersion() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.version
+}
+
+// getKubernetesVersion 获取集群服务端版本
+func (k *KubernetesProvider) getKubernetesVersion() error {
+	if k.sshClient == nil {
+		return fmt.Errorf("SSH client not connected")
+	}
+
+	output, err := k.sshClient.Execute("kubectl version -o json 2>/dev/null | grep -A8 serverVersion | grep gitVersion | head -1 | cut -d'\"' -f4")
+	version := utils.CleanCommandOutput(output)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err != nil || version == "" {
+		k.version = "unknown"
+		if err == nil {
+			err = fmt.Errorf("无法解析版本信息")
+		}
+		return err
+	}
+	k.version = version
+	return nil
+}
+
+// checkExecutionRule Kubernetes只能通过SSH上的kubectl管理，不支持api_only执行规则
+func (k *KubernetesProvider) checkExecutionRule() error {
+	if k.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("Kubernetes provider通过kubectl管理集群，无法使用api_only执行规则")
+	}
+	return nil
+}
+
+func (k *KubernetesProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	if !k.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return k.sshListInstances(ctx)
+}
+
+func (k *KubernetesProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return k.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+func (k *KubernetesProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	if !k.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := k.checkExecutionRule(); err != nil {
+		return err
+	}
+	return k.sshCreateInstanceWithProgress(ctx, config, progressCallback)
+}
+
+func (k *KubernetesProvider) StartInstance(ctx context.Context, id string) error {
+	if !k.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := k.checkExecutionRule(); err != nil {
+		return err
+	}
+	return k.sshStartInstance(ctx, id)
+}
+
+func (k *KubernetesProvider) StopInstance(ctx context.Context, id string) error {
+	if !k.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := k.checkExecutionRule(); err != nil {
+		return err
+	}
+	return k.sshStopInstance(ctx, id)
+}
+
+func (k *KubernetesProvider) RestartInstance(ctx context.Context, id string) error {
+	if !k.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := k.checkExecutionRule(); err != nil {
+		return err
+	}
+	return k.sshRestartInstance(ctx, id)
+}
+
+func (k *KubernetesProvider) DeleteInstance(ctx context.Context, id string) error {
+	if err := k.checkExecutionRule(); err != nil {
+		return err
+	}
+	if !k.connected {
+		if err := k.Connect(ctx, k.config); err != nil {
+			return fmt.Errorf("重连失败: %w", err)
+		}
+	}
+	return k.sshDeleteInstance(ctx, id)
+}
+
+func (k *KubernetesProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if !k.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return k.sshGetInstance(ctx, id)
+}
+
+// ListImages 镜像由各节点的kubelet在创建Pod时拉取，控制节点上没有可管理的镜像
+func (k *KubernetesProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	if !k.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return []provider.Image{}, nil
+}
+
+func (k *KubernetesProvider) PullImage(ctx context.Context, image string) error {
+	return fmt.Errorf("Kubernetes节点在创建实例时自动拉取镜像，不支持预先拉取")
+}
+
+func (k *KubernetesProvider) DeleteImage(ctx context.Context, id string) error {
+	return fmt.Errorf("Kubernetes节点上的镜像由kubelet垃圾回收管理，不支持手动删除")
+}
+
+// ExecuteSSHCommand 执行SSH命令
+func (k *KubernetesProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	if !k.connected || k.sshClient == nil {
+		return "", fmt.Errorf("Kubernetes provider not connected")
+	}
+
+	global.APP_LOG.Debug("执行SSH命令",
+		zap.String("command", utils.TruncateString(command, 200)))
+
+	output, err := k.sshClient.Execute(command)
+	if err != nil {
+		global.APP_LOG.Error("SSH命令执行失败",
+			zap.String("command", utils.TruncateString(command, 200)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return "", fmt.Errorf("SSH command execution failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// namespace 返回实例所在的命名空间，Provider配置了项目时使用项目名
+func (k *KubernetesProvider) namespace() string {
+	if k.config.Project != "" {
+		return k.config.Project
+	}
+	return defaultNamespace
+}
+
+// kubectl 生成在实例命名空间内执行的kubectl命令
+func (k *KubernetesProvider) kubectl(args string) string {
+	return fmt.Sprintf("kubectl -n %s %s", utils.ShellQuote(k.namespace()), args)
+}
+
+// apply 通过标准输入把清单交给 kubectl apply
+func (k *KubernetesProvider) apply(manifest []byte) error {
+	cmd := fmt.Sprintf("printf '%%s' %s | %s", utils.ShellQuote(string(manifest)), k.kubectl("apply -f -"))
+	output, err := k.sshClient.Execute(cmd)
+	if err != nil {
+		return fmt.Errorf("%s: %w", utils.TruncateString(strings.TrimSpace(output), 300), err)
+	}
+	return nil
+}
+
+func init() {
+	provider.RegisterProvider("kubernetes", NewKubernetesProvider)
+}
@@ -0,0 +1,321 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/constant"
+)
+
+const (
+	// labelManagedBy 标记由本系统创建的资源
+	labelManagedBy = "app.kubernetes.io/managed-by"
+	managedByValue = "oneclickvirt"
+	// labelInstance 关联资源所属实例
+	labelInstance = "oneclickvirt.io/instance"
+	// instanceContainer 运行实例系统的容器名称
+	instanceContainer = "instance"
+	// dataVolume 实例磁盘的PVC模板名称，实际PVC名称为 data-<实例名>-0
+	dataVolume = "data"
+	// dataMountPath 实例磁盘的挂载路径，重建Pod后该目录下的数据保留
+	dataMountPath = "/root"
+	// maxNameLength StatefulSet会在Pod标签中追加修订哈希，名称超过52个字符时无法创建
+	maxNameLength = 52
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateName 检查实例名称能否作为Kubernetes资源名称
+func validateName(name string) error {
+	if len(name) > maxNameLength || !nameRegexp.MatchString(name) {
+		return fmt.Errorf("实例名称 %s 不符合Kubernetes资源命名规则（小写字母、数字和'-'，最长%d个字符）", name, maxNameLength)
+	}
+	return nil
+}
+
+// instanceSpec 生成实例清单所需的参数
+type instanceSpec struct {
+	Name      string
+	Namespace string
+	Image     string
+	CPU       string // CPU核心数，如 1、0.5，为空表示不限制
+	MemoryMB  int64  // 内存上限，0表示不限制
+	DiskMB    int64  // PVC容量
+	SSHPort   int    // 实例内sshd监听的端口，0表示22
+}
+
+// bootstrapScript 实例容器的启动脚本：确保sshd可用并设置root密码，然后常驻
+// 容器根文件系统在Pod重建后会被还原，因此每次启动都要重新执行；SSH主机密钥保存在PVC中，避免重启后指纹变化
+const bootstrapScript = `if ! command -v sshd >/dev/null 2>&1 && [ ! -x /usr/sbin/sshd ]; then
+  if command -v apt-get >/dev/null 2>&1; then apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y openssh-server
+  elif command -v apk >/dev/null 2>&1; then apk add --no-cache openssh
+  elif command -v dnf >/dev/null 2>&1; then dnf install -y openssh-server
+  elif command -v yum >/dev/null 2>&1; then yum install -y openssh-server
+  fi
+fi
+mkdir -p /run/sshd /var/run/sshd /root/.ssh_host_keys
+cp -p /root/.ssh_host_keys/ssh_host_* /etc/ssh/ 2>/dev/null
+ssh-keygen -A >/dev/null 2>&1
+cp -p /etc/ssh/ssh_host_* /root/.ssh_host_keys/ 2>/dev/null
+sed -i -e '/^#\?PermitRootLogin/d' -e '/^#\?PasswordAuthentication/d' -e '/^#\?Port[[:space:]]/d' /etc/ssh/sshd_config
+sed -i '1i Port '"${SSH_PORT:-22}" /etc/ssh/sshd_config
+printf 'PermitRootLogin yes\nPasswordAuthentication yes\n' >> /etc/ssh/sshd_config
+echo "root:${ROOT_PASSWORD}" | chpasswd
+$(command -v sshd || echo /usr/sbin/sshd)
+trap 'exit 0' TERM INT
+while true; do sleep 3600 & wait $!; done
+`
+
+// object Kubernetes资源对象，只需要序列化为JSON交给kubectl
+type object = map[string]interface{}
+
+// instanceLabels 实例资源的公共标签
+func instanceLabels(name string) map[string]string {
+	return map[string]string{
+		labelManagedBy: managedByValue,
+		labelInstance:  name,
+	}
+}
+
+// secretName 保存实例root密码的Secret名称
+func secretName(name string) string {
+	return name + "-ssh"
+}
+
+// podName StatefulSet单副本Pod的名称
+func podName(name string) string {
+	return name + "-0"
+}
+
+// portServiceName 端口映射对应的Service名称
+func portServiceName(name, protocol string, hostPort int) string {
+	return fmt.Sprintf("%s-%s-%d", name, protocol, hostPort)
+}
+
+// loadBalancerServiceName 独立IP模式下实例的LoadBalancer Service名称
+func loadBalancerServiceName(name string) string {
+	return name + "-lb"
+}
+
+// buildInstanceManifest 生成实例的StatefulSet和NetworkPolicy，返回kubectl apply可直接使用的List
+func buildInstanceManifest(spec instanceSpec) ([]byte, error) {
+	sshPort := constant.GuestSSHPortOrDefault(spec.SSHPort)
+	container := object{
+		"name":            instanceContainer,
+		"image":           spec.Image,
+		"imagePullPolicy": "IfNotPresent",
+		"command":         []string{"/bin/sh", "-c", bootstrapScript},
+		"env": []object{{
+			"name": "ROOT_PASSWORD",
+			"valueFrom": object{"secretKeyRef": object{
+				"name": secretName(spec.Name),
+				"key":  "password",
+			}},
+		}, {
+			"name":  "SSH_PORT",
+			"value": strconv.Itoa(sshPort),
+		}},
+		"ports":        []object{{"name": "ssh", "containerPort": sshPort}},
+		"volumeMounts": []object{{"name": dataVolume, "mountPath": dataMountPath}},
+		// sshd开始监听后才视为就绪，首次启动需要安装openssh时会等待较长时间
+		"readinessProbe": object{
+			"tcpSocket":           object{"port": sshPort},
+			"initialDelaySeconds": 3,
+			"periodSeconds":       5,
+		},
+	}
+	if limits := resourceLimits(spec.CPU, spec.MemoryMB); len(limits) > 0 {
+		// requests与limits相同，实例获得Guaranteed QoS，不会因节点资源紧张被优先驱逐
+		container["resources"] = object{"limits": limits, "requests": limits}
+	}
+
+	statefulSet := object{
+		"apiVersion": "apps/v1",
+		"kind":       "StatefulSet",
+		"metadata": object{
+			"name":      spec.Name,
+			"namespace": spec.Namespace,
+			"labels":    instanceLabels(spec.Name),
+		},
+		"spec": object{
+			"serviceName": spec.Name,
+			"replicas":    1,
+			"selector":    object{"matchLabels": map[string]string{labelInstance: spec.Name}},
+			"template": object{
+				"metadata": object{"labels": instanceLabels(spec.Name)},
+				"spec": object{
+					"hostname":                      spec.Name,
+					"enableServiceLinks":            false,
+					"automountServiceAccountToken":  false,
+					"terminationGracePeriodSeconds": 10,
+					"containers":                    []object{container},
+				},
+			},
+			"volumeClaimTemplates": []object{{
+				"metadata": object{"name": dataVolume, "labels": instanceLabels(spec.Name)},
+				"spec": object{
+					"accessModes": []string{"ReadWriteOnce"},
+					"resources":   object{"requests": object{"storage": fmt.Sprintf("%dMi", spec.DiskMB)}},
+				},
+			}},
+		},
+	}
+
+	// 实例之间互相隔离：只允许非实例Pod和集群外部访问，NodePort流量经过节点转发后来源为节点地址
+	networkPolicy := object{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": object{
+			"name":      spec.Name,
+			"namespace": spec.Namespace,
+			"labels":    instanceLabels(spec.Name),
+		},
+		"spec": object{
+			"podSelector": object{"matchLabels": map[string]string{labelInstance: spec.Name}},
+			"policyTypes": []string{"Ingress"},
+			"ingress": []object{{
+				"from": []object{
+					{
+						"namespaceSelector": object{},
+						"podSelector": object{"matchExpressions": []object{{
+							"key":      labelInstance,
+							"operator": "DoesNotExist",
+						}}},
+					},
+					{"ipBlock": object{"cidr": "0.0.0.0/0"}},
+					{"ipBlock": object{"cidr": "::/0"}},
+				},
+			}},
+		},
+	}
+
+	return json.Marshal(object{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      []object{statefulSet, networkPolicy},
+	})
+}
+
+// buildSecretManifest 生成保存实例root密码的Secret
+func buildSecretManifest(namespace, name, password string) ([]byte, error) {
+	return json.Marshal(object{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata": object{
+			"name":      secretName(name),
+			"namespace": namespace,
+			"labels":    instanceLabels(name),
+		},
+		"stringData": map[string]string{"password": password},
+	})
+}
+
+// buildPortServiceManifest 生成端口映射的NodePort Service，协议为both时同一个节点端口同时转发TCP和UDP
+func buildPortServiceManifest(namespace, name string, hostPort, guestPort int, protocol string) ([]byte, error) {
+	var ports []object
+	for _, proto := range servicePortProtocols(protocol) {
+		ports = append(ports, object{
+			"name":       strings.ToLower(proto),
+			"protocol":   proto,
+			"port":       guestPort,
+			"targetPort": guestPort,
+			"nodePort":   hostPort,
+		})
+	}
+	return json.Marshal(object{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": object{
+			"name":      portServiceName(name, protocol, hostPort),
+			"namespace": namespace,
+			"labels":    instanceLabels(name),
+		},
+		"spec": object{
+			"type":     "NodePort",
+			"selector": map[string]string{labelInstance: name},
+			"ports":    ports,
+		},
+	})
+}
+
+// buildLoadBalancerManifest 生成独立IP模式下的LoadBalancer Service，由集群负载均衡为实例分配公网地址
+// 独立IP直接暴露实例内SSH端口，与其他虚拟化类型的独立IP实例保持一致
+func buildLoadBalancerManifest(namespace, name string, guestSSHPort int) ([]byte, error) {
+	return json.Marshal(object{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": object{
+			"name":      loadBalancerServiceName(name),
+			"namespace": namespace,
+			"labels":    instanceLabels(name),
+		},
+		"spec": object{
+			"type":                  "LoadBalancer",
+			"externalTrafficPolicy": "Local",
+			"selector":              map[string]string{labelInstance: name},
+			"ports":                 []object{{"name": "ssh", "protocol": "TCP", "port": guestSSHPort, "targetPort": guestSSHPort}},
+		},
+	})
+}
+
+// servicePortProtocols 将端口映射协议转换为Service端口协议
+func servicePortProtocols(protocol string) []string {
+	switch strings.ToLower(protocol) {
+	case "udp":
+		return []string{"UDP"}
+	case "both":
+		return []string{"TCP", "UDP"}
+	default:
+		return []string{"TCP"}
+	}
+}
+
+// resourceLimits 生成容器的CPU和内存限制
+func resourceLimits(cpu string, memoryMB int64) object {
+	limits := object{}
+	if cores, err := strconv.ParseFloat(strings.TrimSpace(cpu), 64); err == nil && cores > 0 {
+		limits["cpu"] = fmt.Sprintf("%dm", int64(cores*1000))
+	}
+	if memoryMB > 0 {
+		limits["memory"] = fmt.Sprintf("%dMi", memoryMB)
+	}
+	return limits
+}
+
+// sizeMB 解析 512m、10g、2048 格式的容量为MB，无法解析时返回0
+func sizeMB(size string) int64 {
+	s := strings.ToLower(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, suffix := range []string{"gib", "gb", "g"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix)
+			multiplier = 1024
+			break
+		}
+	}
+	for _, suffix := range []string{"mib", "mb", "m"} {
+		if strings.HasSuffix(s, suffix) {
+			s = strings.TrimSuffix(s, suffix)
+			break
+		}
+	}
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+	return value * multiplier
+}
+
+// imageReference 将系统镜像地址 oci://<registry>/<repository>:<tag> 转换为容器镜像引用
+func imageReference(imageURL string) (string, error) {
+	trimmed := strings.TrimSpace(imageURL)
+	ref := strings.TrimPrefix(trimmed, "oci://")
+	if !strings.HasPrefix(trimmed, "oci://") || ref == "" || strings.Contains(ref, "://") {
+		return "", fmt.Errorf("Kubernetes镜像地址必须使用 oci://<registry>/<repository>:<tag> 格式: %s", imageURL)
+	}
+	return ref, nil
+}
@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"web", "user-1-abc"} {
+		if err := validateName(name); err != nil {
+			t.Errorf("validateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Web", "a_b", "-a", "a-", strings.Repeat("a", 53)} {
+		if err := validateName(name); err == nil {
+			t.Errorf("validateName(%q) 应返回错误", name)
+		}
+	}
+}
+
+func TestSizeMBAndImageReference(t *testing.T) {
+	cases := map[string]int64{"512m": 512, "10240m": 10240, "2g": 2048, "1GiB": 1024, "256": 256, "": 0, "abc": 0}
+	for in, want := range cases {
+		if got := sizeMB(in); got != want {
+			t.Errorf("sizeMB(%q) = %d, want %d", in, got, want)
+		}
+	}
+
+	if ref, err := imageReference("oci://docker.io/library/debian:12"); err != nil || ref != "docker.io/library/debian:12" {
+		t.Errorf("imageReference = %q, %v", ref, err)
+	}
+	for _, url := range []string{"https://example.com/debian.tar.gz", "oci://", "debian:12"} {
+		if _, err := imageReference(url); err == nil {
+			t.Errorf("imageReference(%q) 应返回错误", url)
+		}
+	}
+}
+
+func TestBuildInstanceManifest(t *testing.T) {
+	data, err := buildInstanceManifest(instanceSpec{
+		Name:      "ct1",
+		Namespace: "ocv",
+		Image:     "debian:12",
+		CPU:       "2",
+		MemoryMB:  512,
+		DiskMB:    10240,
+		SSHPort:   2222,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list struct {
+		Items []struct {
+			Kind string `json:"kind"`
+			Spec struct {
+				Replicas int `json:"replicas"`
+				Template struct {
+					Spec struct {
+						Containers []struct {
+							Image string `json:"image"`
+							Ports []struct {
+								ContainerPort int `json:"containerPort"`
+							} `json:"ports"`
+							Resources struct {
+								Limits map[string]string `json:"limits"`
+							} `json:"resources"`
+						} `json:"containers"`
+					} `json:"spec"`
+				} `json:"template"`
+				VolumeClaimTemplates []struct {
+					Spec struct {
+						Resources struct {
+							Requests map[string]string `json:"requests"`
+						} `json:"resources"`
+					} `json:"spec"`
+				} `json:"volumeClaimTemplates"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Kind != "StatefulSet" || list.Items[1].Kind != "NetworkPolicy" {
+		t.Fatalf("清单资源不正确: %s", data)
+	}
+
+	sts := list.Items[0].Spec
+	container := sts.Template.Spec.Containers[0]
+	if sts.Replicas != 1 || container.Image != "debian:12" {
+		t.Errorf("StatefulSet配置错误: %s", data)
+	}
+	if container.Ports[0].ContainerPort != 2222 {
+		t.Errorf("SSH端口错误: %v", container.Ports)
+	}
+	if container.Resources.Limits["cpu"] != "2000m" || container.Resources.Limits["memory"] != "512Mi" {
+		t.Errorf("资源限制错误: %v", container.Resources.Limits)
+	}
+	if sts.VolumeClaimTemplates[0].Spec.Resources.Requests["storage"] != "10240Mi" {
+		t.Errorf("PVC容量错误: %v", sts.VolumeClaimTemplates[0].Spec.Resources.Requests)
+	}
+}
+
+func TestBuildPortServiceManifest(t *testing.T) {
+	data, err := buildPortServiceManifest("ocv", "ct1", 30022, 22, "both")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var svc struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Type  string `json:"type"`
+			Ports []struct {
+				Protocol string `json:"protocol"`
+				NodePort int    `json:"nodePort"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Metadata.Name != "ct1-both-30022" || svc.Spec.Type != "NodePort" || len(svc.Spec.Ports) != 2 {
+		t.Fatalf("Service配置错误: %s", data)
+	}
+	if svc.Spec.Ports[0].Protocol != "TCP" || svc.Spec.Ports[1].Protocol != "UDP" || svc.Spec.Ports[1].NodePort != 30022 {
+		t.Errorf("Service端口错误: %s", data)
+	}
+}
+
+func TestParseInstanceList(t *testing.T) {
+	sts := "web|1|1|2026-01-02T03:04:05Z|debian:12\n" +
+		"db|0||2026-01-02T03:04:05Z|debian:12\n" +
+		"new|1||2026-01-02T03:04:05Z|debian:12\n" +
+		"bad|1||2026-01-02T03:04:05Z|debian:12\n"
+	pods := parsePodStates("web|Running|||node1|10.42.0.5 fd00::5\n" +
+		"new|Pending||ContainerCreating|node1|\n" +
+		"bad|Running||CrashLoopBackOff|node1|10.42.0.7\n")
+
+	instances := parseInstanceList(sts, pods)
+	if len(instances) != 4 {
+		t.Fatalf("got %d instances", len(instances))
+	}
+	want := map[string]string{"web": "running", "db": "stopped", "new": "pending", "bad": "crashloopbackoff"}
+	for _, inst := range instances {
+		if inst.Status != want[inst.Name] {
+			t.Errorf("%s: status %s, want %s", inst.Name, inst.Status, want[inst.Name])
+		}
+	}
+	if instances[0].PrivateIP != "10.42.0.5" || instances[0].IPv6Address != "fd00::5" || instances[0].Created.IsZero() {
+		t.Errorf("web: %+v", instances[0])
+	}
+}
@@ -0,0 +1,132 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// configurePortMappings 根据数据库中的端口记录为实例创建NodePort Service
+// 独立IP模式下改为创建LoadBalancer Service，由集群负载均衡分配公网地址
+func (k *KubernetesProvider) configurePortMappings(ctx context.Context, instanceName, networkType string, guestSSHPort int) error {
+	if networkType == "dedicated_ipv4" || networkType == "dedicated_ipv4_ipv6" {
+		manifest, err := buildLoadBalancerManifest(k.namespace(), instanceName, guestSSHPort)
+		if err != nil {
+			return err
+		}
+		if err := k.apply(manifest); err != nil {
+			return fmt.Errorf("创建LoadBalancer Service失败: %w", err)
+		}
+		return nil
+	}
+	if networkType == "ipv6_only" {
+		global.APP_LOG.Info("纯IPv6模式，跳过端口映射配置",
+			zap.String("instance", instanceName))
+		return nil
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("name = ?", instanceName).First(&instance).Error; err != nil {
+		return fmt.Errorf("获取实例信息失败: %w", err)
+	}
+
+	var portMappings []providerModel.Port
+	if err := global.APP_DB.Where("instance_id = ? AND status = 'active'", instance.ID).Find(&portMappings).Error; err != nil {
+		return fmt.Errorf("获取端口映射失败: %w", err)
+	}
+	if len(portMappings) == 0 {
+		global.APP_LOG.Warn("未找到端口映射配置", zap.String("instance", instanceName))
+		return nil
+	}
+
+	for _, port := range portMappings {
+		if err := k.setupPortMapping(instanceName, port.HostPort, port.GuestPort, port.Protocol); err != nil {
+			global.APP_LOG.Warn("配置端口映射失败",
+				zap.String("instance", instanceName),
+				zap.Int("hostPort", port.HostPort),
+				zap.Int("guestPort", port.GuestPort),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// setupPortMapping 创建端口映射对应的NodePort Service，节点端口需要在集群的NodePort范围内
+func (k *KubernetesProvider) setupPortMapping(instanceName string, hostPort, guestPort int, protocol string) error {
+	if err := validateName(instanceName); err != nil {
+		return err
+	}
+	manifest, err := buildPortServiceManifest(k.namespace(), instanceName, hostPort, guestPort, protocol)
+	if err != nil {
+		return err
+	}
+	if err := k.apply(manifest); err != nil {
+		return fmt.Errorf("创建NodePort Service失败: %w", err)
+	}
+
+	global.APP_LOG.Info("Kubernetes端口映射设置成功",
+		zap.String("instance", instanceName),
+		zap.Int("nodePort", hostPort),
+		zap.Int("targetPort", guestPort),
+		zap.String("protocol", protocol))
+	return nil
+}
+
+// SetupPortMappingWithIP 公开的方法：创建端口映射（用于手动添加端口和重置系统）
+// Service按标签选择Pod，不需要实例IP，method 和 instanceIP 参数仅为保持与其他Provider的API一致
+func (k *KubernetesProvider) SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error {
+	return k.setupPortMapping(instanceName, hostPort, guestPort, protocol)
+}
+
+// RemovePortMapping 删除端口映射对应的Service
+func (k *KubernetesProvider) RemovePortMapping(ctx context.Context, instanceName string, hostPort int, protocol string) error {
+	if err := validateName(instanceName); err != nil {
+		return err
+	}
+	cmd := k.kubectl(fmt.Sprintf("delete service %s --ignore-not-found", utils.ShellQuote(portServiceName(instanceName, protocol, hostPort))))
+	if output, err := k.sshClient.Execute(cmd); err != nil {
+		return fmt.Errorf("删除NodePort Service失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+	return nil
+}
+
+// GetVethInterfaceName 获取实例Pod在宿主机上的veth接口名称，用于流量统计
+// 只能统计调度到SSH节点上的Pod，其他节点上的Pod返回错误
+func (k *KubernetesProvider) GetVethInterfaceName(instanceName string) (string, error) {
+	pod := utils.ShellQuote(podName(instanceName))
+	nodeOutput, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("get pod %s -o jsonpath='{.spec.nodeName}'", pod)))
+	if err != nil {
+		return "", fmt.Errorf("获取Pod所在节点失败: %w", err)
+	}
+	hostOutput, _ := k.sshClient.Execute("hostname")
+	node := utils.CleanCommandOutput(nodeOutput)
+	if node == "" || !strings.EqualFold(node, utils.CleanCommandOutput(hostOutput)) {
+		return "", fmt.Errorf("实例 %s 的Pod运行在节点 %s 上，不在SSH连接的节点上，无法统计流量", instanceName, node)
+	}
+
+	// Pod内eth0的iflink即宿主机上对应veth接口的ifindex
+	ifindexOutput, err := k.sshClient.Execute(k.kubectl(fmt.Sprintf("exec %s -c %s -- cat /sys/class/net/eth0/iflink", pod, instanceContainer)))
+	if err != nil {
+		return "", fmt.Errorf("读取Pod网卡索引失败: %w", err)
+	}
+	ifindex := utils.CleanCommandOutput(ifindexOutput)
+	if ifindex == "" {
+		return "", fmt.Errorf("实例 %s 的Pod网卡索引为空", instanceName)
+	}
+
+	vethOutput, err := k.sshClient.Execute(fmt.Sprintf("ip -o link show 2>/dev/null | awk -v idx=%s -F': ' '$1 == idx {print $2}' | cut -d'@' -f1", utils.ShellQuote(ifindex)))
+	if err != nil {
+		return "", fmt.Errorf("查找宿主机veth接口失败: %w", err)
+	}
+	veth := utils.CleanCommandOutput(vethOutput)
+	if veth == "" {
+		return "", fmt.Errorf("未找到索引为 %s 的宿主机接口", ifindex)
+	}
+	return veth, nil
+}
@@ -0,0 +1,61 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// SetInstancePassword 设置实例密码
+func (k *KubernetesProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if !k.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	return k.sshSetInstancePassword(instanceID, password)
+}
+
+// ResetInstancePassword 重置实例密码
+func (k *KubernetesProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	if !k.connected {
+		return "", fmt.Errorf("provider not connected")
+	}
+
+	newPassword := utils.GenerateInstancePassword()
+	if err := k.sshSetInstancePassword(instanceID, newPassword); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// sshSetInstancePassword 更新Secret中的密码并在运行中的容器内生效
+// 容器根文件系统在Pod重建后会被还原，启动脚本从Secret读取密码，因此必须先更新Secret
+func (k *KubernetesProvider) sshSetInstancePassword(instanceName, password string) error {
+	if err := validateName(instanceName); err != nil {
+		return err
+	}
+
+	secret, err := buildSecretManifest(k.namespace(), instanceName, password)
+	if err != nil {
+		return fmt.Errorf("生成Secret失败: %w", err)
+	}
+	if err := k.apply(secret); err != nil {
+		return fmt.Errorf("更新密码Secret失败: %w", err)
+	}
+
+	// 实例停止时只更新Secret，下次启动时生效
+	setPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | %s", utils.ShellQuote("root:"+password),
+		k.kubectl(fmt.Sprintf("exec -i %s -c %s -- chpasswd", utils.ShellQuote(podName(instanceName)), instanceContainer)))
+	if output, err := k.sshClient.Execute(setPasswordCmd); err != nil {
+		global.APP_LOG.Warn("在运行中的容器内设置密码失败，将在下次启动时生效",
+			zap.String("instanceName", instanceName),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+	}
+
+	global.APP_LOG.Info("Kubernetes实例密码设置成功", zap.String("instanceName", instanceName))
+	return nil
+}
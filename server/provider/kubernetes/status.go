@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/provider"
+)
+
+// statefulSetListJSONPath 每行输出 名称|期望副本数|就绪副本数|创建时间|镜像
+const statefulSetListJSONPath = `{range .items[*]}{.metadata.name}{"|"}{.spec.replicas}{"|"}{.status.readyReplicas}{"|"}{.metadata.creationTimestamp}{"|"}{.spec.template.spec.containers[0].image}{"\n"}{end}`
+
+// podListJSONPath 每行输出 实例名|阶段|删除时间|等待原因|节点|IP列表
+const podListJSONPath = `{range .items[*]}{.metadata.labels.oneclickvirt\.io/instance}{"|"}{.status.phase}{"|"}{.metadata.deletionTimestamp}{"|"}{.status.containerStatuses[0].state.waiting.reason}{"|"}{.spec.nodeName}{"|"}{.status.podIPs[*].ip}{"\n"}{end}`
+
+// podState Pod的运行状态
+type podState struct {
+	Phase       string
+	Terminating bool
+	Waiting     string
+	Node        string
+	IPv4        string
+	IPv6        string
+}
+
+// parsePodStates 解析 podListJSONPath 的输出，按实例名索引
+func parsePodStates(output string) map[string]podState {
+	states := make(map[string]podState)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 6 || fields[0] == "" {
+			continue
+		}
+		state := podState{
+			Phase:       fields[1],
+			Terminating: fields[2] != "",
+			Waiting:     fields[3],
+			Node:        fields[4],
+		}
+		for _, ip := range strings.Fields(fields[5]) {
+			if strings.Contains(ip, ":") {
+				if state.IPv6 == "" {
+					state.IPv6 = ip
+				}
+			} else if state.IPv4 == "" {
+				state.IPv4 = ip
+			}
+		}
+		states[fields[0]] = state
+	}
+	return states
+}
+
+// parseInstanceList 解析 statefulSetListJSONPath 的输出，结合Pod状态得到实例列表
+func parseInstanceList(statefulSets string, pods map[string]podState) []provider.Instance {
+	var instances []provider.Instance
+	for _, line := range strings.Split(strings.TrimSpace(statefulSets), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 5 || fields[0] == "" {
+			continue
+		}
+		replicas, _ := strconv.Atoi(fields[1])
+		ready, _ := strconv.Atoi(fields[2])
+		pod, hasPod := pods[fields[0]]
+
+		instance := provider.Instance{
+			ID:     fields[0],
+			Name:   fields[0],
+			Status: instanceStatus(replicas, ready, pod, hasPod),
+			Type:   "container",
+			Image:  fields[4],
+			Metadata: map[string]string{
+				"node": pod.Node,
+			},
+		}
+		if created, err := time.Parse(time.RFC3339, fields[3]); err == nil {
+			instance.Created = created
+		}
+		if hasPod && !pod.Terminating {
+			instance.IP = pod.IPv4
+			instance.PrivateIP = pod.IPv4
+			instance.IPv6Address = pod.IPv6
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+// instanceStatus 根据StatefulSet副本数和Pod状态推断实例状态，状态值与 constant 中的 kubernetes 映射表一致
+func instanceStatus(replicas, ready int, pod podState, hasPod bool) string {
+	if replicas == 0 {
+		if hasPod {
+			return "stopping"
+		}
+		return "stopped"
+	}
+	if !hasPod {
+		return "pending"
+	}
+	switch {
+	case pod.Terminating:
+		return "stopping"
+	case pod.Waiting == "CrashLoopBackOff":
+		return "crashloopbackoff"
+	case pod.Waiting == "ImagePullBackOff" || pod.Waiting == "ErrImagePull" || pod.Phase == "Failed":
+		return "failed"
+	case pod.Phase == "Running" && ready > 0:
+		return "running"
+	case pod.Phase == "Running":
+		return "starting"
+	default:
+		return "pending"
+	}
+}
@@ -0,0 +1,59 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// DiscoverInstances 发现节点上已有的LXC容器
+func (l *LXCProvider) DiscoverInstances(ctx context.Context) ([]provider.DiscoveredInstance, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	global.APP_LOG.Info("开始发现LXC容器", zap.String("provider", l.config.Name))
+
+	instances, err := l.sshListInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("执行SSH命令失败: %w", err)
+	}
+
+	discovered := make([]provider.DiscoveredInstance, 0, len(instances))
+	for _, inst := range instances {
+		d := provider.DiscoveredInstance{
+			UUID:         inst.Name,
+			Name:         inst.Name,
+			Status:       inst.Status,
+			InstanceType: "container",
+			PrivateIP:    inst.PrivateIP,
+			IPv6Address:  inst.IPv6Address,
+			RawData:      inst,
+		}
+		if d.PrivateIP == "" {
+			if ip, err := l.configuredIPv4(inst.Name); err == nil {
+				d.PrivateIP = ip
+			}
+		}
+		// MAC地址和系统类型从容器配置和根文件系统读取，读取失败不影响发现
+		if output, err := l.sshClient.Execute(fmt.Sprintf("grep -E '^lxc.net.0.hwaddr' %s/config | head -1 | awk -F'=' '{print $2}'", utils.ShellQuote(containerDir(inst.Name)))); err == nil {
+			d.MACAddress = strings.TrimSpace(output)
+		}
+		if output, err := l.sshClient.Execute(fmt.Sprintf("grep -E '^ID=' %s/rootfs/etc/os-release 2>/dev/null | cut -d= -f2 | tr -d '\"'", utils.ShellQuote(containerDir(inst.Name)))); err == nil {
+			d.OSType = strings.TrimSpace(output)
+			d.Image = d.OSType
+		}
+		discovered = append(discovered, d)
+	}
+
+	global.APP_LOG.Info("LXC容器发现完成",
+		zap.String("provider", l.config.Name),
+		zap.Int("count", len(discovered)))
+	return discovered, nil
+}
@@ -0,0 +1,160 @@
+package lxc
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// sshListImages 列出已下载到节点的容器模板
+func (l *LXCProvider) sshListImages(ctx context.Context) ([]provider.Image, error) {
+	cmd := fmt.Sprintf("find %s -maxdepth 1 -type f -printf '%%f|%%s|%%T@\\n' 2>/dev/null", utils.ShellQuote(imageDownloadDir))
+	output, err := l.sshClient.Execute(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var imageList []provider.Image
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		image := provider.Image{
+			ID:   fields[0],
+			Name: fields[0],
+			Size: fields[1],
+		}
+		if ts, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			image.Created = time.Unix(int64(ts), 0)
+		}
+		imageList = append(imageList, image)
+	}
+
+	global.APP_LOG.Info("获取LXC模板列表成功", zap.Int("count", len(imageList)))
+	return imageList, nil
+}
+
+// sshPullImage 下载容器模板，image 为模板的下载地址
+func (l *LXCProvider) sshPullImage(ctx context.Context, image string) error {
+	if !strings.HasPrefix(image, "http://") && !strings.HasPrefix(image, "https://") {
+		return fmt.Errorf("LXC模板需要提供下载地址: %s", image)
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(path.Base(image), ".tar.xz"), ".tar.gz")
+	_, err := l.downloadImageToRemote(image, name, l.config.Architecture, false)
+	return err
+}
+
+// sshDeleteImage 删除已下载的容器模板
+func (l *LXCProvider) sshDeleteImage(ctx context.Context, id string) error {
+	if id == "" || strings.Contains(id, "/") {
+		return fmt.Errorf("无效的模板名称: %s", id)
+	}
+	if err := l.removeRemoteFile(filepath.Join(imageDownloadDir, id)); err != nil {
+		return fmt.Errorf("删除模板失败: %w", err)
+	}
+
+	global.APP_LOG.Info("LXC模板删除成功", zap.String("image", utils.TruncateString(id, 64)))
+	return nil
+}
+
+// downloadImageToRemote 在远程服务器上下载容器模板，已下载的模板会被复用
+func (l *LXCProvider) downloadImageToRemote(imageURL, imageName, architecture string, useCDN bool) (string, error) {
+	if _, err := l.sshClient.Execute(fmt.Sprintf("mkdir -p %s", utils.ShellQuote(imageDownloadDir))); err != nil {
+		return "", fmt.Errorf("创建远程下载目录失败: %w", err)
+	}
+
+	remotePath := filepath.Join(imageDownloadDir, l.generateRemoteFileName(imageName, imageURL, architecture))
+	if l.isRemoteFileValid(remotePath) {
+		global.APP_LOG.Info("远程模板文件已存在且完整，跳过下载",
+			zap.String("imageName", imageName),
+			zap.String("remotePath", remotePath))
+		return remotePath, nil
+	}
+
+	sources := utils.ImageDownloadSources(imageURL, l.config.ImageMirrors, useCDN, utils.PreferredImageSource(l.config.ID))
+	global.APP_LOG.Info("开始在远程服务器下载模板",
+		zap.String("imageName", imageName),
+		zap.Int("sources", len(sources)),
+		zap.String("remotePath", remotePath),
+		zap.Bool("useCDN", useCDN))
+
+	// 在远程服务器上下载文件，失败时轮换下载来源
+	source, err := utils.DownloadToRemote(l.sshClient, sources, remotePath)
+	if err != nil {
+		l.removeRemoteFile(remotePath)
+		return "", fmt.Errorf("远程下载模板失败: %w", err)
+	}
+	utils.RecordImageSource(l.config.ID, source.Base)
+
+	global.APP_LOG.Info("远程模板下载完成",
+		zap.String("imageName", imageName),
+		zap.String("remotePath", remotePath))
+	return remotePath, nil
+}
+
+// generateRemoteFileName 生成远程文件名，保留原始压缩格式的扩展名以便tar识别
+func (l *LXCProvider) generateRemoteFileName(imageName, imageURL, architecture string) string {
+	md5Hash := fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%s_%s", imageName, imageURL, architecture))))
+
+	safeName := strings.ReplaceAll(imageName, "/", "_")
+	safeName = strings.ReplaceAll(safeName, ":", "_")
+
+	ext := ".tar.xz"
+	if strings.HasSuffix(imageURL, ".tar.gz") {
+		ext = ".tar.gz"
+	}
+	return fmt.Sprintf("%s_%s%s", safeName, md5Hash[:8], ext)
+}
+
+// isRemoteFileValid 检查远程文件是否存在且完整
+func (l *LXCProvider) isRemoteFileValid(remotePath string) bool {
+	cmd := fmt.Sprintf("test -f %s -a -s %s", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath))
+	_, err := l.sshClient.Execute(cmd)
+	return err == nil
+}
+
+// removeRemoteFile 删除远程文件
+func (l *LXCProvider) removeRemoteFile(remotePath string) error {
+	_, err := l.sshClient.Execute(fmt.Sprintf("rm -f %s", utils.ShellQuote(remotePath)))
+	return err
+}
+
+// ensureSSHScriptsAvailable 确保SSH脚本文件在远程服务器上可用，脚本与LXD共用
+func (l *LXCProvider) ensureSSHScriptsAvailable(providerCountry string) error {
+	scriptsDir := "/usr/local/bin"
+	for _, script := range []string{"ssh_bash.sh", "ssh_sh.sh"} {
+		scriptPath := filepath.Join(scriptsDir, script)
+		if l.isRemoteFileValid(scriptPath) {
+			continue
+		}
+
+		baseURL := "https://raw.githubusercontent.com/oneclickvirt/lxd/main/scripts/" + script
+		useCDN := providerCountry == "CN" || providerCountry == "cn"
+		if _, err := utils.DownloadToRemote(l.sshClient, utils.ImageDownloadSources(baseURL, nil, useCDN, ""), scriptPath); err != nil {
+			l.removeRemoteFile(scriptPath)
+			return fmt.Errorf("下载SSH脚本 %s 失败: %w", script, err)
+		}
+
+		chmodCmd := fmt.Sprintf("chmod +x %s && (command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true)", utils.ShellQuote(scriptPath), utils.ShellQuote(scriptPath))
+		if _, err := l.sshClient.Execute(chmodCmd); err != nil {
+			return fmt.Errorf("设置SSH脚本 %s 执行权限失败: %w", script, err)
+		}
+
+		global.APP_LOG.Info("SSH脚本下载并设置完成",
+			zap.String("script", script),
+			zap.String("scriptPath", scriptPath))
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/images"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+func (l *LXCProvider) sshListInstances(ctx context.Context) ([]provider.Instance, error) {
+	output, err := l.sshClient.ExecuteWithLogging(listCommand(), "LXC_LIST")
+	if err != nil {
+		return nil, err
+	}
+
+	instances := parseInstanceList(output)
+	global.APP_LOG.Info("获取LXC实例列表成功", zap.Int("count", len(instances)))
+	return instances, nil
+}
+
+func (l *LXCProvider) sshGetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if !l.containerExists(id) {
+		return nil, fmt.Errorf("instance not found: %s", id)
+	}
+
+	output, err := l.sshClient.Execute(listCommand(id))
+	if err != nil {
+		return nil, fmt.Errorf("获取容器信息失败: %w", err)
+	}
+	instances := parseInstanceList(output)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("instance not found: %s", id)
+	}
+
+	// 容器停止时 lxc-info 不返回IP，从配置文件读取静态地址
+	if instances[0].PrivateIP == "" {
+		if ip, err := l.configuredIPv4(id); err == nil {
+			instances[0].PrivateIP = ip
+			instances[0].IP = ip
+		}
+	}
+	return &instances[0], nil
+}
+
+// containerExists 检查容器是否存在
+func (l *LXCProvider) containerExists(name string) bool {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc-info -n %s -sH 2>&1", utils.ShellQuote(name)))
+	return err == nil && !isNotFound(output) && strings.TrimSpace(output) != ""
+}
+
+// configuredIPv4 从容器配置文件读取分配的IPv4地址
+func (l *LXCProvider) configuredIPv4(name string) (string, error) {
+	cmd := fmt.Sprintf("grep -E '^lxc.net.0.ipv4.address' %s/config | head -1 | awk -F'=' '{print $2}' | cut -d/ -f1", utils.ShellQuote(containerDir(name)))
+	output, err := l.sshClient.Execute(cmd)
+	if err != nil {
+		return "", err
+	}
+	ip := utils.CleanCommandOutput(output)
+	if ip == "" {
+		return "", fmt.Errorf("容器 %s 未配置IPv4地址", name)
+	}
+	return ip, nil
+}
+
+// GetInstanceIPv4 获取实例的内网IPv4地址 (公开方法)
+func (l *LXCProvider) GetInstanceIPv4(ctx context.Context, instanceName string) (string, error) {
+	return l.configuredIPv4(instanceName)
+}
+
+func (l *LXCProvider) sshCreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	// 进度更新辅助函数
+	updateProgress := func(percentage int, message string) {
+		if progressCallback != nil {
+			progressCallback(percentage, message)
+		}
+		global.APP_LOG.Info("LXC实例创建进度",
+			zap.String("instance", config.Name),
+			zap.Int("percentage", percentage),
+			zap.String("message", message))
+	}
+
+	updateProgress(10, "开始创建LXC容器...")
+
+	if l.containerExists(config.Name) {
+		return fmt.Errorf("容器 %s 已存在", config.Name)
+	}
+
+	if config.ImageURL == "" {
+		if err := l.queryAndSetSystemImage(ctx, &config); err != nil {
+			return err
+		}
+	}
+	if config.ImageURL == "" {
+		return fmt.Errorf("镜像 %s 没有可用的下载地址", config.Image)
+	}
+
+	updateProgress(15, "确保SSH脚本可用...")
+	if err := l.ensureSSHScriptsAvailable(l.config.Country); err != nil {
+		return fmt.Errorf("确保SSH脚本可用失败: %w", err)
+	}
+
+	updateProgress(20, "下载容器模板到远程服务器...")
+	remotePath, err := l.downloadImageToRemote(config.ImageURL, config.Image, l.config.Architecture, config.UseCDN)
+	if err != nil {
+		return fmt.Errorf("下载镜像失败: %w", err)
+	}
+
+	updateProgress(45, "解压根文件系统...")
+	rootfs := containerDir(config.Name) + "/rootfs"
+	extractCmd := fmt.Sprintf("mkdir -p %s && tar -xpf %s -C %s --numeric-owner", utils.ShellQuote(rootfs), utils.ShellQuote(remotePath), utils.ShellQuote(rootfs))
+	if output, err := l.sshClient.Execute(extractCmd); err != nil {
+		l.removeContainerDir(config.Name)
+		// 模板文件可能已损坏，删除后下次重新下载
+		l.removeRemoteFile(remotePath)
+		return fmt.Errorf("解压根文件系统失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+
+	updateProgress(55, "分配容器网络...")
+	ip, prefix, gateway, err := l.allocateIPv4()
+	if err != nil {
+		l.removeContainerDir(config.Name)
+		return err
+	}
+
+	updateProgress(60, "写入容器配置...")
+	spec := containerSpec{
+		Name:     config.Name,
+		Arch:     lxcArch(l.config.Architecture),
+		Bridge:   l.bridge(),
+		HWAddr:   hwAddr(config.Name),
+		VethPair: vethPairName(config.Name),
+		IPv4CIDR: fmt.Sprintf("%s/%d", ip, prefix),
+		Gateway:  gateway,
+		Memory:   memoryLimit(config.Memory),
+		CPUQuota: cpuQuota(config.CPU),
+		CgroupV2: l.isCgroupV2(),
+	}
+	configPath := containerDir(config.Name) + "/config"
+	writeCmd := fmt.Sprintf("printf '%%s' %s > %s", utils.ShellQuote(buildContainerConfig(spec)), utils.ShellQuote(configPath))
+	if _, err := l.sshClient.Execute(writeCmd); err != nil {
+		l.removeContainerDir(config.Name)
+		return fmt.Errorf("写入容器配置失败: %w", err)
+	}
+	// 容器内的DNS配置，模板中的resolv.conf通常指向不存在的本地解析服务
+	l.sshClient.Execute(fmt.Sprintf("rm -f %s/etc/resolv.conf && printf 'nameserver 1.1.1.1\\nnameserver 8.8.8.8\\n' > %s/etc/resolv.conf", utils.ShellQuote(rootfs), utils.ShellQuote(rootfs)))
+
+	updateProgress(70, "启动容器...")
+	if err := l.sshStartInstance(ctx, config.Name); err != nil {
+		l.destroyContainer(config.Name)
+		return err
+	}
+
+	updateProgress(80, "配置SSH密码...")
+	if err := l.configureInstanceSSHPassword(ctx, config); err != nil {
+		// 密码设置失败不影响容器创建，可以通过重置密码补救
+		global.APP_LOG.Warn("配置容器SSH密码失败",
+			zap.String("instance", config.Name),
+			zap.Error(err))
+	}
+
+	updateProgress(90, "配置端口映射...")
+	networkType := l.config.NetworkType
+	if config.Metadata != nil {
+		if metaNetworkType, ok := config.Metadata["network_type"]; ok {
+			networkType = metaNetworkType
+		}
+	}
+	if err := l.configurePortMappingsWithIP(ctx, config.Name, networkType, ip); err != nil {
+		global.APP_LOG.Warn("配置端口映射失败",
+			zap.String("instance", config.Name),
+			zap.Error(err))
+	}
+
+	updateProgress(100, "LXC容器创建完成")
+	global.APP_LOG.Info("LXC容器创建成功",
+		zap.String("instance", config.Name),
+		zap.String("ip", ip))
+	return nil
+}
+
+// queryAndSetSystemImage 从数据库查询匹配的系统镜像记录并设置到配置中
+func (l *LXCProvider) queryAndSetSystemImage(ctx context.Context, config *provider.InstanceConfig) error {
+	query := global.APP_DB.WithContext(ctx).Where("provider_type = ? AND instance_type = ?", "lxc", "container")
+
+	// 按操作系统匹配（如果配置中有指定）
+	if config.Image != "" {
+		imageLower := strings.ToLower(config.Image)
+		query = query.Where("LOWER(os_type) LIKE ? OR LOWER(name) LIKE ?", "%"+imageLower+"%", "%"+imageLower+"%")
+	}
+
+	// 按架构筛选，默认使用amd64
+	architecture := l.config.Architecture
+	if architecture == "" {
+		architecture = "amd64"
+	}
+	query = query.Where("architecture = ?", architecture)
+
+	var candidates []systemModel.SystemImage
+	if err := query.Where("status = ?", "active").Order("created_at DESC").Find(&candidates).Error; err != nil {
+		return fmt.Errorf("未找到匹配的系统镜像: %w", err)
+	}
+
+	// 排除被镜像禁用策略命中的镜像（节点侧无法得知用户等级，仅应用对所有用户生效的策略）
+	imageService := &images.ImageService{}
+	candidates = imageService.FilterBannedImages(candidates, 0, l.config.ID)
+	if len(candidates) == 0 {
+		return fmt.Errorf("未找到匹配的系统镜像: 无可用镜像或镜像已被禁用")
+	}
+
+	config.ImageURL = candidates[0].URL
+	config.UseCDN = candidates[0].UseCDN
+	global.APP_LOG.Info("从数据库获取到系统镜像配置",
+		zap.String("imageName", candidates[0].Name),
+		zap.String("originalURL", utils.TruncateString(candidates[0].URL, 100)),
+		zap.Bool("useCDN", candidates[0].UseCDN))
+	return nil
+}
+
+// isCgroupV2 检查宿主机是否使用cgroup v2
+func (l *LXCProvider) isCgroupV2() bool {
+	output, err := l.sshClient.Execute("stat -fc %T /sys/fs/cgroup 2>/dev/null")
+	return err == nil && strings.Contains(output, "cgroup2fs")
+}
+
+func (l *LXCProvider) sshStartInstance(ctx context.Context, id string) error {
+	name := utils.ShellQuote(id)
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc-start -n %s -d 2>&1 || lxc-info -n %s -sH | grep -q RUNNING", name, name))
+	if err != nil {
+		return fmt.Errorf("启动容器失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+	if output, err := l.sshClient.Execute(fmt.Sprintf("lxc-wait -n %s -s RUNNING -t 60", name)); err != nil {
+		return fmt.Errorf("等待容器启动超时: %s: %w", utils.TruncateString(output, 200), err)
+	}
+
+	global.APP_LOG.Info("LXC容器启动成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+func (l *LXCProvider) sshStopInstance(ctx context.Context, id string) error {
+	name := utils.ShellQuote(id)
+	// lxc-stop 对已停止的容器返回错误，先检查状态
+	state, _ := l.sshClient.Execute(fmt.Sprintf("lxc-info -n %s -sH", name))
+	if mapLXCStatus(state) == "stopped" {
+		return nil
+	}
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc-stop -n %s -t 30 2>&1", name))
+	if err != nil {
+		return fmt.Errorf("停止容器失败: %s: %w", utils.TruncateString(output, 200), err)
+	}
+
+	global.APP_LOG.Info("LXC容器停止成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+func (l *LXCProvider) sshDeleteInstance(ctx context.Context, id string) error {
+	if !l.containerExists(id) {
+		global.APP_LOG.Info("LXC容器不存在，视为已删除", zap.String("id", utils.TruncateString(id, 32)))
+		return nil
+	}
+
+	// 删除前读取IP，用于清理端口映射规则
+	ip, ipErr := l.configuredIPv4(id)
+
+	if err := l.destroyContainer(id); err != nil {
+		return err
+	}
+
+	if ipErr == nil {
+		l.cleanupIptablesRulesForIP(ctx, ip)
+	} else {
+		global.APP_LOG.Warn("读取容器IP失败，跳过端口映射清理",
+			zap.String("id", utils.TruncateString(id, 32)),
+			zap.Error(ipErr))
+	}
+
+	global.APP_LOG.Info("LXC容器删除成功", zap.String("id", utils.TruncateString(id, 32)))
+	return nil
+}
+
+// destroyContainer 强制停止并删除容器
+func (l *LXCProvider) destroyContainer(name string) error {
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc-destroy -n %s -f 2>&1", utils.ShellQuote(name)))
+	if err != nil && !isNotFound(output) {
+		global.APP_LOG.Warn("lxc-destroy失败，直接删除容器目录",
+			zap.String("name", utils.TruncateString(name, 32)),
+			zap.String("output", utils.TruncateString(output, 200)))
+		if err := l.removeContainerDir(name); err != nil {
+			return fmt.Errorf("删除容器失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeContainerDir 删除容器目录，用于清理创建失败的容器
+func (l *LXCProvider) removeContainerDir(name string) error {
+	if strings.TrimSpace(name) == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("无效的容器名称: %s", name)
+	}
+	_, err := l.sshClient.Execute(fmt.Sprintf("rm -rf %s", utils.ShellQuote(containerDir(name))))
+	return err
+}
+
+// waitForContainerReady 等待容器内的系统完成初始化
+func (l *LXCProvider) waitForContainerReady(name string) error {
+	cmd := fmt.Sprintf("lxc-attach -n %s -- sh -c 'echo container_ready'", utils.ShellQuote(name))
+	for i := 0; i < 10; i++ {
+		if output, err := l.sshClient.Execute(cmd); err == nil && strings.Contains(output, "container_ready") {
+			return nil
+		}
+		time.Sleep(3 * time.Second)
+	}
+	return fmt.Errorf("容器 %s 未准备就绪", name)
+}
+
+// saveInstancePassword 更新数据库中的密码记录，确保数据库与实际密码一致
+func (l *LXCProvider) saveInstancePassword(name, password string) {
+	err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ?", name).
+		Update("password", password).Error
+	if err != nil {
+		global.APP_LOG.Warn("更新实例密码到数据库失败",
+			zap.String("instanceName", name),
+			zap.Error(err))
+	}
+}
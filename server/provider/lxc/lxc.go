@@ -0,0 +1,304 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/provider/health"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// lxcPath 容器目录，与 lxc-create 的默认路径一致
+	lxcPath = "/var/lib/lxc"
+	// defaultBridge 容器veth接口接入的网桥，lxc-net 默认创建 lxcbr0
+	defaultBridge = "lxcbr0"
+	// imageDownloadDir 容器根文件系统模板的下载目录
+	imageDownloadDir = "/usr/local/bin/lxc_ct_images"
+)
+
+// LXCProvider 原生LXC容器Provider，通过SSH调用 lxc-* 工具管理容器，不依赖LXD/Incus
+type LXCProvider struct {
+	config        provider.NodeConfig
+	sshClient     *utils.SSHClient
+	connected     bool
+	healthChecker health.HealthChecker
+	version       string       // LXC 版本
+	mu            sync.RWMutex // 保护并发访问
+}
+
+func NewLXCProvider() provider.Provider {
+	return &LXCProvider{}
+}
+
+func (l *LXCProvider) GetType() string {
+	return "lxc"
+}
+
+func (l *LXCProvider) GetName() string {
+	return l.config.Name
+}
+
+func (l *LXCProvider) GetSupportedInstanceTypes() []string {
+	return []string{"container"}
+}
+
+func (l *LXCProvider) Connect(ctx context.Context, config provider.NodeConfig) error {
+	l.config = config
+	global.APP_LOG.Info("LXC provider开始连接",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port))
+
+	// 设置SSH超时配置
+	sshConnectTimeout := config.SSHConnectTimeout
+	sshExecuteTimeout := config.SSHExecuteTimeout
+	if sshConnectTimeout <= 0 {
+		sshConnectTimeout = 30 // 默认30秒
+	}
+	if sshExecuteTimeout <= 0 {
+		sshExecuteTimeout = 300 // 默认300秒
+	}
+
+	sshConfig := utils.SSHConfig{
+		Host:           config.Host,
+		Port:           config.Port,
+		Username:       config.Username,
+		Password:       config.Password,
+		PrivateKey:     config.PrivateKey,
+		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
+		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
+	}
+	client, err := utils.NewSSHClient(sshConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect via SSH: %w", err)
+	}
+
+	l.sshClient = client
+	l.connected = true
+
+	// 初始化健康检查器，使用Provider的SSH连接，避免创建独立连接导致节点混淆
+	healthConfig := health.HealthConfig{
+		Host:          config.Host,
+		Port:          config.Port,
+		Username:      config.Username,
+		Password:      config.Password,
+		PrivateKey:    config.PrivateKey,
+		APIEnabled:    false, // LXC 没有API
+		SSHEnabled:    true,
+		Timeout:       30 * time.Second,
+		ServiceChecks: []string{"lxc"},
+	}
+	zapLogger, _ := zap.NewProduction()
+	l.healthChecker = health.NewLXCHealthCheckerWithSSH(healthConfig, zapLogger, client.GetUnderlyingClient())
+
+	if err := l.getLXCVersion(); err != nil {
+		global.APP_LOG.Warn("LXC 版本获取失败", zap.Error(err))
+	}
+
+	global.APP_LOG.Info("LXC provider连接成功",
+		zap.String("host", utils.TruncateString(config.Host, 32)),
+		zap.Int("port", config.Port),
+		zap.String("version", l.version))
+
+	return nil
+}
+
+func (l *LXCProvider) Disconnect(ctx context.Context) error {
+	if l.sshClient != nil {
+		l.sshClient.Close()
+		l.connected = false
+	}
+	return nil
+}
+
+func (l *LXCProvider) IsConnected() bool {
+	return l.connected && l.sshClient != nil && l.sshClient.IsHealthy()
+}
+
+func (l *LXCProvider) HealthCheck(ctx context.Context) (*health.HealthResult, error) {
+	if l.healthChecker == nil {
+		return nil, fmt.Errorf("health checker not initialized")
+	}
+	return l.healthChecker.CheckHealth(ctx)
+}
+
+func (l *LXCProvider) GetHealthChecker() health.HealthChecker {
+	return l.healthChecker
+}
+
+func (l *LXCProvider) GetVersion() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.version
+}
+
+// getLXCVersion 获取 LXC 版本
+func (l *LXCProvider) getLXCVersion() error {
+	if l.sshClient == nil {
+		return fmt.Errorf("SSH client not connected")
+	}
+
+	output, err := l.sshClient.Execute("lxc-ls --version")
+	version := utils.CleanCommandOutput(output)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil || version == "" {
+		l.version = "unknown"
+		if err == nil {
+			err = fmt.Errorf("无法解析版本信息")
+		}
+		return err
+	}
+	l.version = version
+	return nil
+}
+
+// checkExecutionRule LXC只能通过SSH管理，不支持api_only执行规则
+func (l *LXCProvider) checkExecutionRule() error {
+	if l.config.ExecutionRule == "api_only" {
+		return fmt.Errorf("LXC provider不支持API调用，无法使用api_only执行规则")
+	}
+	return nil
+}
+
+func (l *LXCProvider) ListInstances(ctx context.Context) ([]provider.Instance, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return l.sshListInstances(ctx)
+}
+
+func (l *LXCProvider) CreateInstance(ctx context.Context, config provider.InstanceConfig) error {
+	return l.CreateInstanceWithProgress(ctx, config, nil)
+}
+
+func (l *LXCProvider) CreateInstanceWithProgress(ctx context.Context, config provider.InstanceConfig, progressCallback provider.ProgressCallback) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := l.checkExecutionRule(); err != nil {
+		return err
+	}
+	return l.sshCreateInstanceWithProgress(ctx, config, progressCallback)
+}
+
+func (l *LXCProvider) StartInstance(ctx context.Context, id string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := l.checkExecutionRule(); err != nil {
+		return err
+	}
+	return l.sshStartInstance(ctx, id)
+}
+
+func (l *LXCProvider) StopInstance(ctx context.Context, id string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := l.checkExecutionRule(); err != nil {
+		return err
+	}
+	return l.sshStopInstance(ctx, id)
+}
+
+func (l *LXCProvider) RestartInstance(ctx context.Context, id string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	if err := l.checkExecutionRule(); err != nil {
+		return err
+	}
+	if err := l.sshStopInstance(ctx, id); err != nil {
+		return err
+	}
+	return l.sshStartInstance(ctx, id)
+}
+
+func (l *LXCProvider) DeleteInstance(ctx context.Context, id string) error {
+	if err := l.checkExecutionRule(); err != nil {
+		return err
+	}
+	if !l.connected {
+		if err := l.Connect(ctx, l.config); err != nil {
+			return fmt.Errorf("重连失败: %w", err)
+		}
+	}
+	return l.sshDeleteInstance(ctx, id)
+}
+
+func (l *LXCProvider) GetInstance(ctx context.Context, id string) (*provider.Instance, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return l.sshGetInstance(ctx, id)
+}
+
+func (l *LXCProvider) ListImages(ctx context.Context) ([]provider.Image, error) {
+	if !l.connected {
+		return nil, fmt.Errorf("not connected")
+	}
+	return l.sshListImages(ctx)
+}
+
+func (l *LXCProvider) PullImage(ctx context.Context, image string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	return l.sshPullImage(ctx, image)
+}
+
+func (l *LXCProvider) DeleteImage(ctx context.Context, id string) error {
+	if !l.connected {
+		return fmt.Errorf("not connected")
+	}
+	return l.sshDeleteImage(ctx, id)
+}
+
+// ExecuteSSHCommand 执行SSH命令
+func (l *LXCProvider) ExecuteSSHCommand(ctx context.Context, command string) (string, error) {
+	if !l.connected || l.sshClient == nil {
+		return "", fmt.Errorf("LXC provider not connected")
+	}
+
+	global.APP_LOG.Debug("执行SSH命令",
+		zap.String("command", utils.TruncateString(command, 200)))
+
+	output, err := l.sshClient.Execute(command)
+	if err != nil {
+		global.APP_LOG.Error("SSH命令执行失败",
+			zap.String("command", utils.TruncateString(command, 200)),
+			zap.String("output", utils.TruncateString(output, 500)),
+			zap.Error(err))
+		return "", fmt.Errorf("SSH command execution failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// bridge 返回容器接入的网桥
+func (l *LXCProvider) bridge() string {
+	return defaultBridge
+}
+
+// containerDir 返回容器目录
+func containerDir(name string) string {
+	return lxcPath + "/" + name
+}
+
+// isNotFound 判断 lxc-info 的错误输出是否表示容器不存在
+func isNotFound(output string) bool {
+	return strings.Contains(strings.ToLower(output), "doesn't exist") || strings.Contains(strings.ToLower(output), "does not exist")
+}
+
+func init() {
+	provider.RegisterProvider("lxc", NewLXCProvider)
+}
@@ -0,0 +1,158 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// allocateIPv4 在网桥子网内为新容器分配静态IPv4地址
+// 已被其他容器配置文件占用和 lxc-net DHCP 租约中的地址都会跳过
+func (l *LXCProvider) allocateIPv4() (ip string, prefix int, gateway string, err error) {
+	bridge := utils.ShellQuote(l.bridge())
+	output, err := l.sshClient.Execute(fmt.Sprintf("ip -4 -o addr show dev %s | awk '{print $4}' | head -1", bridge))
+	bridgeCIDR := utils.CleanCommandOutput(output)
+	if err != nil || bridgeCIDR == "" {
+		return "", 0, "", fmt.Errorf("网桥 %s 不存在或未配置IPv4地址，请确认已启用 lxc-net", l.bridge())
+	}
+
+	usedCmd := fmt.Sprintf("grep -h '^lxc.net.0.ipv4.address' %s/*/config 2>/dev/null | awk -F'=' '{print $2}'; "+
+		"awk '{print $3}' /var/lib/misc/dnsmasq.%s.leases 2>/dev/null; true", lxcPath, l.bridge())
+	usedOutput, _ := l.sshClient.Execute(usedCmd)
+
+	return nextFreeIPv4(bridgeCIDR, strings.Fields(usedOutput))
+}
+
+// GetVethInterfaceName 获取容器对应的宿主机veth接口名称
+func (l *LXCProvider) GetVethInterfaceName(instanceName string) (string, error) {
+	cmd := fmt.Sprintf("grep -E '^lxc.net.0.veth.pair' %s/config | head -1 | awk -F'=' '{print $2}'", utils.ShellQuote(containerDir(instanceName)))
+	output, err := l.sshClient.Execute(cmd)
+	if err != nil {
+		return "", fmt.Errorf("读取容器配置失败: %w", err)
+	}
+	veth := utils.CleanCommandOutput(output)
+	if veth == "" {
+		return "", fmt.Errorf("容器 %s 未配置veth接口名称", instanceName)
+	}
+	return veth, nil
+}
+
+// configurePortMappingsWithIP 根据数据库中的端口记录为容器配置iptables端口映射
+func (l *LXCProvider) configurePortMappingsWithIP(ctx context.Context, instanceName, networkType, instanceIP string) error {
+	// 独立IP模式和纯IPv6模式不需要IPv4端口映射
+	if networkType == "dedicated_ipv4" || networkType == "dedicated_ipv4_ipv6" || networkType == "ipv6_only" {
+		global.APP_LOG.Info("独立IP模式或纯IPv6模式，跳过IPv4端口映射配置",
+			zap.String("instance", instanceName),
+			zap.String("networkType", networkType))
+		return nil
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("name = ?", instanceName).First(&instance).Error; err != nil {
+		return fmt.Errorf("获取实例信息失败: %w", err)
+	}
+
+	var portMappings []providerModel.Port
+	if err := global.APP_DB.Where("instance_id = ? AND status = 'active'", instance.ID).Find(&portMappings).Error; err != nil {
+		return fmt.Errorf("获取端口映射失败: %w", err)
+	}
+	if len(portMappings) == 0 {
+		global.APP_LOG.Warn("未找到端口映射配置", zap.String("instance", instanceName))
+		return nil
+	}
+
+	for _, port := range portMappings {
+		if err := l.setupPortMappingWithIP(ctx, instanceName, port.HostPort, port.GuestPort, port.Protocol, instanceIP); err != nil {
+			global.APP_LOG.Warn("配置端口映射失败",
+				zap.String("instance", instanceName),
+				zap.Int("hostPort", port.HostPort),
+				zap.Int("guestPort", port.GuestPort),
+				zap.Error(err))
+		}
+	}
+
+	if err := l.saveIptablesRules(); err != nil {
+		global.APP_LOG.Warn("保存iptables规则失败", zap.Error(err))
+	}
+	return nil
+}
+
+// setupPortMappingWithIP 使用指定的实例IP设置iptables端口映射，协议为both时同时创建TCP和UDP规则
+func (l *LXCProvider) setupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, instanceIP string) error {
+	cleanInstanceIP := strings.TrimSpace(strings.Split(instanceIP, "/")[0])
+	if cleanInstanceIP == "" {
+		return fmt.Errorf("实例 %s 的IP地址为空", instanceName)
+	}
+
+	protocols := []string{protocol}
+	if protocol == "both" {
+		protocols = []string{"tcp", "udp"}
+	}
+
+	for _, proto := range protocols {
+		// DNAT规则 - 将外部请求转发到内部实例
+		dnatCmd := utils.DNATAddCommand(l.config.PublicInterface, fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+			proto, hostPort, cleanInstanceIP, guestPort))
+		if _, err := l.sshClient.Execute(dnatCmd); err != nil {
+			return fmt.Errorf("添加%s DNAT规则失败: %w", proto, err)
+		}
+
+		// FORWARD规则 - 允许转发流量
+		forwardCmd := fmt.Sprintf("iptables -A FORWARD -d %s -p %s --dport %d -j ACCEPT", cleanInstanceIP, proto, guestPort)
+		if _, err := l.sshClient.Execute(forwardCmd); err != nil {
+			return fmt.Errorf("添加%s FORWARD规则失败: %w", proto, err)
+		}
+	}
+
+	global.APP_LOG.Info("Iptables端口映射设置成功",
+		zap.String("instance", instanceName),
+		zap.Int("hostPort", hostPort),
+		zap.String("target", fmt.Sprintf("%s:%d", cleanInstanceIP, guestPort)),
+		zap.String("protocol", protocol))
+	return nil
+}
+
+// SetupPortMappingWithIP 公开的方法：在远程服务器上创建端口映射（用于手动添加端口和重置系统）
+// LXC只支持iptables映射，method 参数仅为保持与其他Provider的API一致
+func (l *LXCProvider) SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error {
+	return l.setupPortMappingWithIP(ctx, instanceName, hostPort, guestPort, protocol, instanceIP)
+}
+
+// cleanupIptablesRulesForIP 清理指向指定IP地址的端口映射规则
+func (l *LXCProvider) cleanupIptablesRulesForIP(ctx context.Context, ipAddress string) {
+	global.APP_LOG.Info("清理IP地址的iptables规则", zap.String("ipAddress", ipAddress))
+
+	// 匹配时带上端口分隔符和掩码，避免 10.0.3.1 误匹配 10.0.3.10
+	dnatCmd := fmt.Sprintf("iptables -t nat -S PREROUTING | grep -F -- '--to-destination %s:' | sed 's/^-A /-D /' | while read line; do iptables -t nat $line 2>/dev/null || true; done", ipAddress)
+	if _, err := l.sshClient.Execute(dnatCmd); err != nil {
+		global.APP_LOG.Warn("清理DNAT规则失败", zap.String("ipAddress", ipAddress), zap.Error(err))
+	}
+
+	forwardCmd := fmt.Sprintf("iptables -S FORWARD | grep -F -- '-d %s/32' | sed 's/^-A /-D /' | while read line; do iptables $line 2>/dev/null || true; done", ipAddress)
+	if _, err := l.sshClient.Execute(forwardCmd); err != nil {
+		global.APP_LOG.Warn("清理FORWARD规则失败", zap.String("ipAddress", ipAddress), zap.Error(err))
+	}
+
+	if err := l.saveIptablesRules(); err != nil {
+		global.APP_LOG.Warn("保存iptables规则失败", zap.Error(err))
+	}
+}
+
+// saveIptablesRules 保存iptables规则（内部方法）
+func (l *LXCProvider) saveIptablesRules() error {
+	if _, err := l.sshClient.Execute("mkdir -p /etc/iptables && iptables-save > /etc/iptables/rules.v4"); err != nil {
+		return fmt.Errorf("保存iptables规则失败: %w", err)
+	}
+	return nil
+}
+
+// SaveIptablesRules 保存iptables规则（公开方法）
+func (l *LXCProvider) SaveIptablesRules() error {
+	return l.saveIptablesRules()
+}
@@ -0,0 +1,104 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// SetInstancePassword 设置实例密码
+func (l *LXCProvider) SetInstancePassword(ctx context.Context, instanceID, password string) error {
+	if !l.connected {
+		return fmt.Errorf("provider not connected")
+	}
+	return l.sshSetInstancePassword(instanceID, password, provider.LookupGuestSSHPort(l.config.ID, instanceID))
+}
+
+// ResetInstancePassword 重置实例密码
+func (l *LXCProvider) ResetInstancePassword(ctx context.Context, instanceID string) (string, error) {
+	if !l.connected {
+		return "", fmt.Errorf("provider not connected")
+	}
+
+	newPassword := utils.GenerateInstancePassword()
+	if err := l.sshSetInstancePassword(instanceID, newPassword, provider.LookupGuestSSHPort(l.config.ID, instanceID)); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// configureInstanceSSHPassword 创建容器后启用SSH并设置随机root密码
+func (l *LXCProvider) configureInstanceSSHPassword(ctx context.Context, config provider.InstanceConfig) error {
+	password := utils.GenerateInstancePassword()
+	if err := l.sshSetInstancePassword(config.Name, password, provider.GuestSSHPortFromConfig(config)); err != nil {
+		return err
+	}
+	l.saveInstancePassword(config.Name, password)
+	return nil
+}
+
+// sshSetInstancePassword 在容器内执行SSH配置脚本并设置root密码
+// guestSSHPort 为实例内sshd监听的端口，SSH脚本会重写sshd_config，因此每次执行后都需重新应用
+func (l *LXCProvider) sshSetInstancePassword(instanceName, password string, guestSSHPort int) error {
+	if err := l.waitForContainerReady(instanceName); err != nil {
+		return err
+	}
+	name := utils.ShellQuote(instanceName)
+
+	// 根据操作系统类型选择合适的SSH脚本
+	scriptName := "ssh_bash.sh"
+	osOutput, _ := l.sshClient.Execute(fmt.Sprintf("lxc-attach -n %s -- sh -c \"grep -E '^ID=' /etc/os-release | cut -d= -f2 | tr -d '\\\"'\"", name))
+	if osType := utils.CleanCommandOutput(strings.ToLower(osOutput)); osType == "alpine" || osType == "openwrt" {
+		scriptName = "ssh_sh.sh"
+	}
+
+	// 直接复制到容器根文件系统，无需容器内的文件传输工具
+	hostScript := "/usr/local/bin/" + scriptName
+	if l.isRemoteFileValid(hostScript) {
+		copyCmd := fmt.Sprintf("cp %s %s/rootfs/root/%s && chmod +x %s/rootfs/root/%s",
+			utils.ShellQuote(hostScript), utils.ShellQuote(containerDir(instanceName)), scriptName, utils.ShellQuote(containerDir(instanceName)), scriptName)
+		if _, err := l.sshClient.Execute(copyCmd); err != nil {
+			global.APP_LOG.Warn("复制SSH脚本到容器失败",
+				zap.String("instanceName", instanceName),
+				zap.Error(err))
+		} else {
+			execCmd := fmt.Sprintf("lxc-attach -n %s --set-var interactionless=true -- /root/%s %s", name, scriptName, utils.ShellQuote(password))
+			if output, err := l.sshClient.Execute(execCmd); err != nil {
+				global.APP_LOG.Warn("执行SSH配置脚本失败，将直接设置密码",
+					zap.String("instanceName", instanceName),
+					zap.String("output", utils.TruncateString(output, 200)),
+					zap.Error(err))
+			}
+		}
+	} else {
+		global.APP_LOG.Warn("SSH脚本不存在，仅设置密码不配置SSH",
+			zap.String("scriptPath", hostScript))
+	}
+
+	// 密码通过标准输入传给chpasswd，不经过容器内的shell
+	setPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | lxc-attach -n %s -- chpasswd", utils.ShellQuote("root:"+password), name)
+	if _, err := l.sshClient.Execute(setPasswordCmd); err != nil {
+		return fmt.Errorf("设置容器密码失败: %w", err)
+	}
+
+	if script := provider.GuestSSHPortScript(guestSSHPort); script != "" {
+		if output, err := l.sshClient.Execute(fmt.Sprintf("lxc-attach -n %s -- sh -c %s", name, utils.ShellQuote(script))); err != nil {
+			global.APP_LOG.Warn("配置容器内SSH端口失败",
+				zap.String("instanceName", instanceName),
+				zap.Int("guestSSHPort", guestSSHPort),
+				zap.String("output", utils.TruncateString(output, 200)),
+				zap.Error(err))
+		}
+	}
+
+	global.APP_LOG.Info("LXC容器SSH密码设置成功",
+		zap.String("instanceName", instanceName),
+		zap.String("scriptName", scriptName))
+	return nil
+}
@@ -0,0 +1,223 @@
+package lxc
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+)
+
+// containerSpec 生成容器配置文件所需的参数
+type containerSpec struct {
+	Name     string
+	Arch     string // lxc.arch 取值，如 x86_64、aarch64
+	Bridge   string
+	HWAddr   string
+	VethPair string
+	IPv4CIDR string // 容器IPv4地址，带前缀长度
+	Gateway  string
+	Memory   string // 内存上限，如 512M
+	CPUQuota int    // 每100ms周期内可用的CPU时间（微秒），0表示不限制
+	CgroupV2 bool
+}
+
+// cpuPeriod CFS调度周期（微秒）
+const cpuPeriod = 100000
+
+// buildContainerConfig 生成 /var/lib/lxc/<name>/config 的内容
+func buildContainerConfig(spec containerSpec) string {
+	var b strings.Builder
+	b.WriteString("# Generated by oneclickvirt\n")
+	b.WriteString("lxc.include = /usr/share/lxc/config/common.conf\n")
+	fmt.Fprintf(&b, "lxc.arch = %s\n", spec.Arch)
+	fmt.Fprintf(&b, "lxc.uts.name = %s\n", spec.Name)
+	fmt.Fprintf(&b, "lxc.rootfs.path = dir:%s/rootfs\n", containerDir(spec.Name))
+	b.WriteString("lxc.net.0.type = veth\n")
+	fmt.Fprintf(&b, "lxc.net.0.link = %s\n", spec.Bridge)
+	b.WriteString("lxc.net.0.flags = up\n")
+	fmt.Fprintf(&b, "lxc.net.0.hwaddr = %s\n", spec.HWAddr)
+	fmt.Fprintf(&b, "lxc.net.0.veth.pair = %s\n", spec.VethPair)
+	fmt.Fprintf(&b, "lxc.net.0.ipv4.address = %s\n", spec.IPv4CIDR)
+	fmt.Fprintf(&b, "lxc.net.0.ipv4.gateway = %s\n", spec.Gateway)
+
+	cgroupPrefix := "lxc.cgroup"
+	if spec.CgroupV2 {
+		cgroupPrefix = "lxc.cgroup2"
+	}
+	if spec.Memory != "" {
+		if spec.CgroupV2 {
+			fmt.Fprintf(&b, "%s.memory.max = %s\n", cgroupPrefix, spec.Memory)
+		} else {
+			fmt.Fprintf(&b, "%s.memory.limit_in_bytes = %s\n", cgroupPrefix, spec.Memory)
+		}
+	}
+	if spec.CPUQuota > 0 {
+		if spec.CgroupV2 {
+			fmt.Fprintf(&b, "%s.cpu.max = %d %d\n", cgroupPrefix, spec.CPUQuota, cpuPeriod)
+		} else {
+			fmt.Fprintf(&b, "%s.cpu.cfs_quota_us = %d\n", cgroupPrefix, spec.CPUQuota)
+			fmt.Fprintf(&b, "%s.cpu.cfs_period_us = %d\n", cgroupPrefix, cpuPeriod)
+		}
+	}
+	b.WriteString("lxc.start.auto = 1\n")
+	return b.String()
+}
+
+// lxcArch 将节点架构转换为 lxc.arch 取值
+func lxcArch(architecture string) string {
+	switch strings.ToLower(architecture) {
+	case "arm64", "aarch64":
+		return "aarch64"
+	case "s390x":
+		return "s390x"
+	default:
+		return "x86_64"
+	}
+}
+
+// memoryLimit 将 512m、1g、1024MB 等格式转换为cgroup接受的内存上限，如 512M
+func memoryLimit(memory string) string {
+	s := strings.TrimSpace(strings.ToLower(memory))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "ib"), "b")
+	if s == "" {
+		return ""
+	}
+	unit := "M"
+	switch s[len(s)-1] {
+	case 'm':
+		s = s[:len(s)-1]
+	case 'g':
+		s, unit = s[:len(s)-1], "G"
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d%s", n, unit)
+}
+
+// cpuQuota 将CPU核数转换为每个调度周期可用的CPU时间
+func cpuQuota(cpu string) int {
+	n, err := strconv.ParseFloat(strings.TrimSpace(cpu), 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return int(n * cpuPeriod)
+}
+
+// hwAddr 根据容器名生成固定的MAC地址，使用 00:16:3e 前缀
+func hwAddr(name string) string {
+	sum := md5.Sum([]byte(name))
+	return fmt.Sprintf("00:16:3e:%02x:%02x:%02x", sum[0], sum[1], sum[2])
+}
+
+// vethPairName 根据容器名生成宿主机侧veth接口名，接口名不能超过15个字符
+func vethPairName(name string) string {
+	sum := md5.Sum([]byte(name))
+	return fmt.Sprintf("veth%x", sum[:5])
+}
+
+// nextFreeIPv4 在网桥子网内分配一个未被使用的IPv4地址
+// bridgeCIDR 为网桥地址（如 10.0.3.1/24），网桥地址即容器网关
+func nextFreeIPv4(bridgeCIDR string, used []string) (ip string, prefix int, gateway string, err error) {
+	gw, ipNet, err := net.ParseCIDR(strings.TrimSpace(bridgeCIDR))
+	if err != nil || gw.To4() == nil {
+		return "", 0, "", fmt.Errorf("无效的网桥地址: %s", bridgeCIDR)
+	}
+	prefix, bits := ipNet.Mask.Size()
+	if bits-prefix < 2 {
+		return "", 0, "", fmt.Errorf("网桥子网过小: %s", bridgeCIDR)
+	}
+
+	usedSet := map[string]bool{gw.String(): true}
+	for _, u := range used {
+		if parsed := net.ParseIP(strings.TrimSpace(strings.Split(u, "/")[0])); parsed != nil {
+			usedSet[parsed.String()] = true
+		}
+	}
+
+	base := binary.BigEndian.Uint32(ipNet.IP.To4())
+	size := uint32(1) << uint(bits-prefix)
+	// 跳过网络地址和广播地址
+	for offset := uint32(1); offset < size-1; offset++ {
+		candidate := make(net.IP, 4)
+		binary.BigEndian.PutUint32(candidate, base+offset)
+		if !usedSet[candidate.String()] {
+			return candidate.String(), prefix, gw.String(), nil
+		}
+	}
+	return "", 0, "", fmt.Errorf("网桥 %s 子网内没有可用的IPv4地址", bridgeCIDR)
+}
+
+// mapLXCStatus 将 lxc-info 的状态转换为统一的实例状态
+func mapLXCStatus(state string) string {
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "RUNNING", "THAWED":
+		return "running"
+	case "STOPPED":
+		return "stopped"
+	case "STARTING":
+		return "starting"
+	case "STOPPING":
+		return "stopping"
+	case "FROZEN", "FREEZING":
+		return "paused"
+	case "ABORTING":
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// parseInstanceList 解析 listCommand 的输出，每行格式为 名称|状态|IP1,IP2,
+func parseInstanceList(output string) []provider.Instance {
+	instances := []provider.Instance{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		inst := provider.Instance{
+			ID:     parts[0],
+			Name:   parts[0],
+			Status: mapLXCStatus(parts[1]),
+			Type:   "container",
+		}
+		if len(parts) == 3 {
+			for _, addr := range strings.Split(parts[2], ",") {
+				ip := net.ParseIP(strings.TrimSpace(addr))
+				if ip == nil {
+					continue
+				}
+				if ip.To4() != nil {
+					if inst.PrivateIP == "" {
+						inst.PrivateIP = ip.String()
+						inst.IP = ip.String()
+					}
+				} else if inst.IPv6Address == "" && ip.IsGlobalUnicast() {
+					inst.IPv6Address = ip.String()
+				}
+			}
+		}
+		instances = append(instances, inst)
+	}
+	return instances
+}
+
+// listCommand 生成列出容器状态和IP的命令，names 为空时列出全部容器
+func listCommand(names ...string) string {
+	list := "$(lxc-ls -1)"
+	if len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, n := range names {
+			quoted[i] = utils.ShellQuote(n)
+		}
+		list = strings.Join(quoted, " ")
+	}
+	return fmt.Sprintf(`for n in %s; do echo "$n|$(lxc-info -n "$n" -sH 2>/dev/null)|$(lxc-info -n "$n" -iH 2>/dev/null | tr '\n' ',')"; done`, list)
+}
@@ -0,0 +1,90 @@
+package lxc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextFreeIPv4(t *testing.T) {
+	ip, prefix, gw, err := nextFreeIPv4("10.0.3.1/24", []string{"10.0.3.2", " 10.0.3.3/24", "bad"})
+	if err != nil || ip != "10.0.3.4" || prefix != 24 || gw != "10.0.3.1" {
+		t.Errorf("got %s/%d gw %s err %v", ip, prefix, gw, err)
+	}
+
+	// 子网已满
+	if _, _, _, err := nextFreeIPv4("192.168.0.1/30", []string{"192.168.0.2"}); err == nil {
+		t.Error("子网已满时应返回错误")
+	}
+	if _, _, _, err := nextFreeIPv4("lxcbr0", nil); err == nil {
+		t.Error("无效网桥地址应返回错误")
+	}
+}
+
+func TestMemoryLimitAndCPUQuota(t *testing.T) {
+	cases := map[string]string{"512m": "512M", "1024MB": "1024M", "1g": "1G", "2GiB": "2G", "256": "256M", "": "", "abc": ""}
+	for in, want := range cases {
+		if got := memoryLimit(in); got != want {
+			t.Errorf("memoryLimit(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := cpuQuota("2"); got != 200000 {
+		t.Errorf("cpuQuota(2) = %d", got)
+	}
+	if got := cpuQuota("0.5"); got != 50000 {
+		t.Errorf("cpuQuota(0.5) = %d", got)
+	}
+	if got := cpuQuota(""); got != 0 {
+		t.Errorf("cpuQuota(\"\") = %d", got)
+	}
+}
+
+func TestBuildContainerConfig(t *testing.T) {
+	spec := containerSpec{
+		Name:     "ct1",
+		Arch:     "x86_64",
+		Bridge:   "lxcbr0",
+		HWAddr:   hwAddr("ct1"),
+		VethPair: vethPairName("ct1"),
+		IPv4CIDR: "10.0.3.5/24",
+		Gateway:  "10.0.3.1",
+		Memory:   "512M",
+		CPUQuota: 200000,
+		CgroupV2: true,
+	}
+	cfg := buildContainerConfig(spec)
+	for _, want := range []string{
+		"lxc.rootfs.path = dir:/var/lib/lxc/ct1/rootfs\n",
+		"lxc.net.0.ipv4.address = 10.0.3.5/24\n",
+		"lxc.net.0.ipv4.gateway = 10.0.3.1\n",
+		"lxc.cgroup2.memory.max = 512M\n",
+		"lxc.cgroup2.cpu.max = 200000 100000\n",
+	} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("配置缺少 %q:\n%s", want, cfg)
+		}
+	}
+
+	spec.CgroupV2 = false
+	cfg = buildContainerConfig(spec)
+	if !strings.Contains(cfg, "lxc.cgroup.memory.limit_in_bytes = 512M\n") || !strings.Contains(cfg, "lxc.cgroup.cpu.cfs_quota_us = 200000\n") {
+		t.Errorf("cgroup v1 配置错误:\n%s", cfg)
+	}
+
+	if veth := vethPairName("a-very-long-container-name"); len(veth) > 15 {
+		t.Errorf("veth接口名过长: %s", veth)
+	}
+}
+
+func TestParseInstanceList(t *testing.T) {
+	output := "web|RUNNING|10.0.3.5,fd42::5,fe80::1,\ndb|STOPPED|\n\n|RUNNING|\n"
+	instances := parseInstanceList(output)
+	if len(instances) != 2 {
+		t.Fatalf("got %d instances", len(instances))
+	}
+	if instances[0].Name != "web" || instances[0].Status != "running" || instances[0].PrivateIP != "10.0.3.5" || instances[0].IPv6Address != "fd42::5" {
+		t.Errorf("web: %+v", instances[0])
+	}
+	if instances[1].Status != "stopped" || instances[1].PrivateIP != "" {
+		t.Errorf("db: %+v", instances[1])
+	}
+}
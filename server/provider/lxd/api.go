@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 	"oneclickvirt/utils"
@@ -39,7 +40,7 @@ func (l *LXDProvider) apiListInstances(ctx context.Context) ([]provider.Instance
 				instance := provider.Instance{
 					ID:     instanceData["name"].(string),
 					Name:   instanceData["name"].(string),
-					Status: instanceData["status"].(string),
+					Status: constant.NormalizeProviderStatus("lxd", fmt.Sprint(instanceData["status"])),
 					Type:   instanceData["type"].(string),
 				}
 				instances = append(instances, instance)
@@ -76,6 +77,11 @@ func (l *LXDProvider) apiCreateInstanceWithProgress(ctx context.Context, config
 
 	updateProgress(50, "调用LXD API创建实例...")
 
+	profiles, err := provider.InstanceProfiles(l.config.Profiles, config.Profiles)
+	if err != nil {
+		return err
+	}
+
 	// 构造实例配置
 	instanceConfig := map[string]interface{}{
 		"name": config.Name,
@@ -85,7 +91,7 @@ func (l *LXDProvider) apiCreateInstanceWithProgress(ctx context.Context, config
 		},
 		"config":   map[string]interface{}{},
 		"devices":  map[string]interface{}{},
-		"profiles": []string{"default"},
+		"profiles": profiles,
 	}
 
 	// 设置实例类型
@@ -366,7 +372,7 @@ func (l *LXDProvider) apiSetInstancePassword(ctx context.Context, instanceID, pa
 	// LXD API方式设置密码
 	// 构造执行命令的请求
 	execData := map[string]interface{}{
-		"command":     []string{"bash", "-c", fmt.Sprintf("echo 'root:%s' | chpasswd", password)},
+		"command":     []string{"bash", "-c", fmt.Sprintf("echo %s | chpasswd", utils.ShellQuote("root:"+password))},
 		"wait-for-ws": true,
 		"interactive": false,
 	}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 
@@ -309,16 +310,7 @@ func (l *LXDProvider) sshDiscoverInstances(ctx context.Context) ([]provider.Disc
 // 辅助函数
 
 func (l *LXDProvider) mapLXDStatus(lxdStatus string) string {
-	switch strings.ToLower(lxdStatus) {
-	case "running":
-		return "running"
-	case "stopped":
-		return "stopped"
-	case "frozen":
-		return "frozen"
-	default:
-		return lxdStatus
-	}
+	return constant.NormalizeProviderStatus("lxd", lxdStatus)
 }
 
 func (l *LXDProvider) mapLXDType(lxdType string) string {
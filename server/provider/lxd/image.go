@@ -11,6 +11,7 @@ import (
 	"oneclickvirt/global"
 	systemModel "oneclickvirt/model/system"
 	"oneclickvirt/provider"
+	"oneclickvirt/service/images"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -75,18 +76,18 @@ func (l *LXDProvider) handleImageDownloadAndImport(ctx context.Context, config *
 				// 虚拟机镜像导入
 				if strings.HasSuffix(config.ImagePath, ".zip") {
 					extractDir := strings.TrimSuffix(config.ImagePath, ".zip")
-					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", config.ImagePath, extractDir)
+					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(extractDir))
 					_, err := l.sshClient.Execute(unzipCmd)
 					if err != nil {
 						return fmt.Errorf("解压LXD虚拟机镜像失败: %w", err)
 					}
 
 					// 查找解压后的VM镜像文件（可能是img、qcow2等格式）
-					findCmd := fmt.Sprintf("find %s -name '*.img' -o -name '*.qcow2' -o -name '*.vmdk' | head -1", extractDir)
+					findCmd := fmt.Sprintf("find %s -name '*.img' -o -name '*.qcow2' -o -name '*.vmdk' | head -1", utils.ShellQuote(extractDir))
 					vmImagePath, err := l.sshClient.Execute(findCmd)
 					if err != nil || strings.TrimSpace(vmImagePath) == "" {
 						// 如果没找到VM镜像，尝试查找tar.xz
-						findCmd = fmt.Sprintf("find %s -name '*.tar.xz' | head -1", extractDir)
+						findCmd = fmt.Sprintf("find %s -name '*.tar.xz' | head -1", utils.ShellQuote(extractDir))
 						vmImagePath, err = l.sshClient.Execute(findCmd)
 						if err != nil || utils.CleanCommandOutput(vmImagePath) == "" {
 							return fmt.Errorf("未找到解压后的LXD虚拟机镜像文件")
@@ -98,21 +99,21 @@ func (l *LXDProvider) handleImageDownloadAndImport(ctx context.Context, config *
 					lxdTarPath := fmt.Sprintf("%s/lxd.tar.xz", extractDir)
 					diskPath := fmt.Sprintf("%s/disk.qcow2", extractDir)
 					if l.isRemoteFileValid(lxdTarPath) && l.isRemoteFileValid(diskPath) {
-						importCmd = fmt.Sprintf("lxc image import %s %s --alias %s", lxdTarPath, diskPath, config.Image)
+						importCmd = fmt.Sprintf("lxc image import %s %s --alias %s", utils.ShellQuote(lxdTarPath), utils.ShellQuote(diskPath), utils.ShellQuote(config.Image))
 					} else {
-						importCmd = fmt.Sprintf("lxc image import %s --alias %s --vm", vmImagePath, config.Image)
+						importCmd = fmt.Sprintf("lxc image import %s --alias %s --vm", utils.ShellQuote(vmImagePath), utils.ShellQuote(config.Image))
 					}
 
 					// 清理解压后的临时目录
-					defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", extractDir))
+					defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", utils.ShellQuote(extractDir)))
 				} else {
-					importCmd = fmt.Sprintf("lxc image import %s --alias %s --vm", config.ImagePath, config.Image)
+					importCmd = fmt.Sprintf("lxc image import %s --alias %s --vm", utils.ShellQuote(config.ImagePath), utils.ShellQuote(config.Image))
 				}
 			} else {
 				// 容器镜像导入
 				if strings.HasSuffix(config.ImagePath, ".zip") {
 					extractDir := strings.TrimSuffix(config.ImagePath, ".zip")
-					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", config.ImagePath, extractDir)
+					unzipCmd := fmt.Sprintf("unzip -o %s -d %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(extractDir))
 					_, err := l.sshClient.Execute(unzipCmd)
 					if err != nil {
 						return fmt.Errorf("解压LXD容器镜像失败: %w", err)
@@ -122,22 +123,22 @@ func (l *LXDProvider) handleImageDownloadAndImport(ctx context.Context, config *
 					rootfsPath := fmt.Sprintf("%s/rootfs.squashfs", extractDir)
 
 					if l.isRemoteFileValid(lxdTarPath) && l.isRemoteFileValid(rootfsPath) {
-						importCmd = fmt.Sprintf("lxc image import %s %s --alias %s", lxdTarPath, rootfsPath, config.Image)
+						importCmd = fmt.Sprintf("lxc image import %s %s --alias %s", utils.ShellQuote(lxdTarPath), utils.ShellQuote(rootfsPath), utils.ShellQuote(config.Image))
 					} else {
 						// 查找任何tar.xz文件
-						findCmd := fmt.Sprintf("find %s -name '*.tar.xz' | head -1", extractDir)
+						findCmd := fmt.Sprintf("find %s -name '*.tar.xz' | head -1", utils.ShellQuote(extractDir))
 						tarPath, err := l.sshClient.Execute(findCmd)
 						if err != nil || utils.CleanCommandOutput(tarPath) == "" {
 							return fmt.Errorf("未找到解压后的LXD容器镜像文件")
 						}
 						tarPath = utils.CleanCommandOutput(tarPath)
-						importCmd = fmt.Sprintf("lxc image import %s --alias %s", tarPath, config.Image)
+						importCmd = fmt.Sprintf("lxc image import %s --alias %s", utils.ShellQuote(tarPath), utils.ShellQuote(config.Image))
 					}
 
 					// 清理解压后的临时目录
-					defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", extractDir))
+					defer l.sshClient.Execute(fmt.Sprintf("rm -rf %s", utils.ShellQuote(extractDir)))
 				} else {
-					importCmd = fmt.Sprintf("lxc image import %s --alias %s", config.ImagePath, config.Image)
+					importCmd = fmt.Sprintf("lxc image import %s --alias %s", utils.ShellQuote(config.ImagePath), utils.ShellQuote(config.Image))
 				}
 			}
 
@@ -205,11 +206,19 @@ func (l *LXDProvider) queryAndSetSystemImage(ctx context.Context, config *provid
 	// 优先获取启用状态的镜像
 	query = query.Where("status = ?", "active").Order("created_at DESC")
 
-	err := query.First(&systemImage).Error
-	if err != nil {
+	var candidates []systemModel.SystemImage
+	if err := query.Find(&candidates).Error; err != nil {
 		return fmt.Errorf("未找到匹配的系统镜像: %w", err)
 	}
 
+	// 排除被镜像禁用策略命中的镜像（节点侧无法得知用户等级，仅应用对所有用户生效的策略）
+	imageService := &images.ImageService{}
+	candidates = imageService.FilterBannedImages(candidates, 0, l.config.ID)
+	if len(candidates) == 0 {
+		return fmt.Errorf("未找到匹配的系统镜像: 无可用镜像或镜像已被禁用")
+	}
+	systemImage = candidates[0]
+
 	// 设置镜像配置，不在这里添加CDN前缀
 	// CDN前缀应该在实际下载时根据可用性和UseCDN设置动态添加
 	if systemImage.URL != "" {
@@ -239,7 +248,7 @@ func (l *LXDProvider) generateImageAlias(imageURL, imageName, architecture strin
 
 // imageExists 检查镜像是否已存在
 func (l *LXDProvider) imageExists(alias string) bool {
-	output, err := l.sshClient.Execute(fmt.Sprintf("lxc image list %s --format csv", alias))
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc image list %s --format csv", utils.ShellQuote(alias)))
 	if err != nil {
 		return false
 	}
@@ -257,7 +266,7 @@ func (l *LXDProvider) downloadImageToRemote(imageURL, imageName, providerCountry
 	}
 
 	// 在远程服务器上创建下载目录
-	cmd := fmt.Sprintf("mkdir -p %s", downloadDir)
+	cmd := fmt.Sprintf("mkdir -p %s", utils.ShellQuote(downloadDir))
 	_, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("创建远程下载目录失败: %w", err)
@@ -276,22 +285,24 @@ func (l *LXDProvider) downloadImageToRemote(imageURL, imageName, providerCountry
 		return remotePath, nil
 	}
 
-	// 确定下载URL，传递 useCDN 参数
-	downloadURL := l.getDownloadURL(imageURL, providerCountry, useCDN)
+	// 确定候选下载来源，传递 useCDN 参数
+	sources := l.getDownloadSources(imageURL, useCDN)
 
 	global.APP_LOG.Info("开始在远程服务器下载LXD镜像",
 		zap.String("imageName", imageName),
-		zap.String("downloadURL", downloadURL),
+		zap.Int("sources", len(sources)),
 		zap.String("remotePath", remotePath),
 		zap.String("instanceType", instanceType),
 		zap.Bool("useCDN", useCDN))
 
-	// 在远程服务器上下载文件
-	if err := l.downloadFileToRemote(downloadURL, remotePath); err != nil {
+	// 在远程服务器上下载文件，失败时轮换下载来源
+	source, err := utils.DownloadToRemote(l.sshClient, sources, remotePath)
+	if err != nil {
 		// 下载失败，删除不完整的文件
 		l.removeRemoteFile(remotePath)
 		return "", fmt.Errorf("远程下载LXD镜像失败: %w", err)
 	}
+	utils.RecordImageSource(l.config.ID, source.Base)
 
 	global.APP_LOG.Info("远程LXD镜像下载完成",
 		zap.String("imageName", imageName),
@@ -347,14 +358,14 @@ func (l *LXDProvider) generateRemoteFileName(imageName, imageURL, architecture,
 // isRemoteFileValid 检查远程文件是否有效
 func (l *LXDProvider) isRemoteFileValid(remotePath string) bool {
 	// 检查文件是否存在且大小大于0
-	cmd := fmt.Sprintf("test -f %s -a -s %s", remotePath, remotePath)
+	cmd := fmt.Sprintf("test -f %s -a -s %s", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath))
 	_, err := l.sshClient.Execute(cmd)
 	return err == nil
 }
 
 // removeRemoteFile 删除远程文件
 func (l *LXDProvider) removeRemoteFile(remotePath string) error {
-	cmd := fmt.Sprintf("rm -f %s", remotePath)
+	cmd := fmt.Sprintf("rm -f %s", utils.ShellQuote(remotePath))
 	_, err := l.sshClient.Execute(cmd)
 	return err
 }
@@ -377,7 +388,7 @@ func (l *LXDProvider) downloadFileToRemote(url, remotePath string) error {
 	output, err := l.sshClient.Execute(curlCmd)
 	if err != nil {
 		// 清理临时文件
-		l.sshClient.Execute(fmt.Sprintf("rm -f %s", tmpPath))
+		l.sshClient.Execute(fmt.Sprintf("rm -f %s", utils.ShellQuote(tmpPath)))
 
 		global.APP_LOG.Error("远程下载失败",
 			zap.String("url", utils.TruncateString(url, 100)),
@@ -388,7 +399,7 @@ func (l *LXDProvider) downloadFileToRemote(url, remotePath string) error {
 	}
 
 	// 移动文件到最终位置
-	mvCmd := fmt.Sprintf("mv %s %s", tmpPath, remotePath)
+	mvCmd := fmt.Sprintf("mv %s %s", utils.ShellQuote(tmpPath), utils.ShellQuote(remotePath))
 	_, err = l.sshClient.Execute(mvCmd)
 	if err != nil {
 		global.APP_LOG.Error("移动文件失败",
@@ -458,7 +469,7 @@ func (l *LXDProvider) ensureSSHScriptsAvailable(providerCountry string) error {
 		}
 
 		// 设置执行权限
-		chmodCmd := fmt.Sprintf("chmod +x %s", scriptPath)
+		chmodCmd := fmt.Sprintf("chmod +x %s", utils.ShellQuote(scriptPath))
 		if _, err := l.sshClient.Execute(chmodCmd); err != nil {
 			global.APP_LOG.Error("设置SSH脚本执行权限失败",
 				zap.String("script", script),
@@ -467,7 +478,7 @@ func (l *LXDProvider) ensureSSHScriptsAvailable(providerCountry string) error {
 		}
 
 		// 使用dos2unix处理脚本格式（如果可用）
-		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", scriptPath)
+		dos2unixCmd := fmt.Sprintf("command -v dos2unix >/dev/null 2>&1 && dos2unix %s || true", utils.ShellQuote(scriptPath))
 		l.sshClient.Execute(dos2unixCmd)
 
 		global.APP_LOG.Info("SSH脚本下载并设置完成",
@@ -485,7 +496,7 @@ func (l *LXDProvider) getSSHScriptDownloadURL(originalURL, providerCountry strin
 	if providerCountry == "CN" || providerCountry == "cn" {
 		if cdnURL := l.getSSHScriptCDNURL(originalURL); cdnURL != "" {
 			// 测试CDN可用性
-			testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+			testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 			if _, err := l.sshClient.Execute(testCmd); err == nil {
 				global.APP_LOG.Info("使用CDN下载SSH脚本",
 					zap.String("cdnURL", cdnURL))
@@ -506,7 +517,7 @@ func (l *LXDProvider) getSSHScriptCDNURL(originalURL string) string {
 	for _, endpoint := range cdnEndpoints {
 		cdnURL := endpoint + originalURL
 		// 测试CDN可用性
-		testCmd := fmt.Sprintf("curl -s -I --max-time 5 '%s' | head -n 1 | grep -q '200'", cdnURL)
+		testCmd := fmt.Sprintf("curl -s -I --max-time 5 %s | head -n 1 | grep -q '200'", utils.ShellQuote(cdnURL))
 		if _, err := l.sshClient.Execute(testCmd); err == nil {
 			return cdnURL
 		}
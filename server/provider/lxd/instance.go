@@ -10,6 +10,7 @@ import (
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 )
@@ -24,7 +25,7 @@ func (l *LXDProvider) configureInstanceStorage(ctx context.Context, config provi
 	if config.Disk != "" {
 		diskFormatted := convertDiskFormat(config.Disk)
 		// 注意：这里设置的是 limits.max 而不是 size（size已在创建时设置）
-		setMaxCmd := fmt.Sprintf("lxc config device set %s root limits.max %s", config.Name, diskFormatted)
+		setMaxCmd := fmt.Sprintf("lxc config device set %s root limits.max %s", utils.ShellQuote(config.Name), diskFormatted)
 		if _, err := l.sshClient.Execute(setMaxCmd); err != nil {
 			global.APP_LOG.Warn("设置磁盘limits.max失败",
 				zap.String("command", setMaxCmd),
@@ -120,7 +121,7 @@ func (l *LXDProvider) setInstanceConfig(ctx context.Context, instanceName string
 	}
 
 	// SSH方式设置配置
-	cmd := fmt.Sprintf("lxc config set %s %s %s", instanceName, key, value)
+	cmd := fmt.Sprintf("lxc config set %s", utils.ShellJoin(instanceName, key, value))
 	_, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return fmt.Errorf("SSH设置实例配置失败: %w", err)
@@ -164,7 +165,7 @@ func (l *LXDProvider) setInstanceDeviceConfig(ctx context.Context, instanceName
 	}
 
 	// SSH方式设置设备配置
-	cmd := fmt.Sprintf("lxc config device set %s %s %s %s", instanceName, deviceName, key, value)
+	cmd := fmt.Sprintf("lxc config device set %s", utils.ShellJoin(instanceName, deviceName, key, value))
 	_, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return fmt.Errorf("SSH设置实例设备配置失败: %w", err)
@@ -196,7 +197,7 @@ func (l *LXDProvider) waitForInstanceReady(ctx context.Context, instanceName str
 		}
 
 		// 检查实例状态
-		cmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		cmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(cmd)
 		if err != nil {
 			global.APP_LOG.Debug("获取实例状态失败",
@@ -295,10 +296,23 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 	// 生成随机密码
 	password := l.generateRandomPassword()
 
+	// 虚拟机Agent可用时直接通过执行通道设置密码，Agent不可用或失败时回退到推送脚本的方式
+	if config.InstanceType == "vm" && l.VMAgentAvailable(ctx, config.Name) {
+		if err := l.agentSetPassword(config.Name, "root", password); err == nil {
+			l.applyGuestSSHPort(config.Name, provider.GuestSSHPortFromConfig(config))
+			l.saveInstancePassword(ctx, config.Name, password)
+			return nil
+		} else {
+			global.APP_LOG.Warn("通过lxd-agent设置密码失败，回退到脚本方式",
+				zap.String("instanceName", config.Name),
+				zap.Error(err))
+		}
+	}
+
 	// 根据系统类型选择脚本
 	var scriptName string
 	// 检测系统类型
-	output, err := l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", config.Name))
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- cat /etc/os-release 2>/dev/null | grep ^ID= | cut -d= -f2 | tr -d '\"'", utils.ShellQuote(config.Name)))
 	if err == nil {
 		osType := strings.TrimSpace(strings.ToLower(output))
 		if osType == "alpine" || osType == "openwrt" {
@@ -320,18 +334,18 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 	} else {
 		time.Sleep(3 * time.Second)
 		// 复制脚本到实例
-		copyCmd := fmt.Sprintf("lxc file push %s %s/root/", scriptPath, config.Name)
+		copyCmd := fmt.Sprintf("lxc file push %s %s/root/", utils.ShellQuote(scriptPath), utils.ShellQuote(config.Name))
 		_, err = l.sshClient.Execute(copyCmd)
 		if err != nil {
 			global.APP_LOG.Warn("复制SSH脚本到实例失败，仅设置密码", zap.Error(err))
 		} else {
 			// 设置脚本权限
-			_, err = l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- chmod +x /root/%s", config.Name, scriptName))
+			_, err = l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- chmod +x /root/%s", utils.ShellQuote(config.Name), scriptName))
 			if err != nil {
 				global.APP_LOG.Warn("设置脚本权限失败", zap.Error(err))
 			} else {
 				// 执行脚本配置SSH和密码
-				execCmd := fmt.Sprintf("lxc exec %s -- /root/%s %s", config.Name, scriptName, password)
+				execCmd := fmt.Sprintf("lxc exec %s -- /root/%s %s", utils.ShellQuote(config.Name), scriptName, utils.ShellQuote(password))
 				_, err = l.sshClient.Execute(execCmd)
 				if err != nil {
 					global.APP_LOG.Warn("执行SSH配置脚本失败，将使用直接设置密码",
@@ -345,7 +359,7 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 	}
 
 	// 直接使用lxc exec设置密码
-	directPasswordCmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo \"root:%s\" | chpasswd'", config.Name, password)
+	directPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | lxc exec %s -- chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(config.Name))
 	_, err = l.sshClient.Execute(directPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("设置实例密码失败",
@@ -354,8 +368,11 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 		return fmt.Errorf("设置实例密码失败: %w", err)
 	}
 
+	// SSH脚本会重写sshd_config，需在其之后应用实例内SSH端口
+	l.applyGuestSSHPort(config.Name, provider.GuestSSHPortFromConfig(config))
+
 	// 清理历史记录 - 非阻塞式，如果失败不影响整体流程
-	_, err = l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- bash -c 'history -c 2>/dev/null || true'", config.Name))
+	_, err = l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- bash -c 'history -c 2>/dev/null || true'", utils.ShellQuote(config.Name)))
 	if err != nil {
 		global.APP_LOG.Warn("清理历史记录失败",
 			zap.String("instanceName", config.Name),
@@ -366,24 +383,49 @@ func (l *LXDProvider) configureInstanceSSHPassword(ctx context.Context, config p
 		zap.String("instanceName", config.Name),
 		zap.String("rootPassword", password))
 
+	l.saveInstancePassword(ctx, config.Name, password)
+	return nil
+}
+
+// applyGuestSSHPort 将实例内sshd改为监听配置的SSH端口，默认22时无需处理
+func (l *LXDProvider) applyGuestSSHPort(instanceName string, port int) {
+	script := provider.GuestSSHPortScript(port)
+	if script == "" {
+		return
+	}
+	cmd := fmt.Sprintf("lxc exec %s -- sh -c %s", utils.ShellQuote(instanceName), utils.ShellQuote(script))
+	if output, err := l.sshClient.Execute(cmd); err != nil {
+		global.APP_LOG.Warn("配置实例内SSH端口失败",
+			zap.String("instanceName", instanceName),
+			zap.Int("guestSSHPort", port),
+			zap.String("output", utils.TruncateString(output, 200)),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("实例内SSH端口配置完成",
+		zap.String("instanceName", instanceName),
+		zap.Int("guestSSHPort", port))
+}
+
+// saveInstancePassword 将实例密码保存到实例配置和数据库中，确保数据库与实际密码一致
+func (l *LXDProvider) saveInstancePassword(ctx context.Context, instanceName, password string) {
 	// 保存密码到实例配置中（用于后续获取）
-	if err = l.setInstanceConfig(ctx, config.Name, "user.password", password); err != nil {
+	if err := l.setInstanceConfig(ctx, instanceName, "user.password", password); err != nil {
 		global.APP_LOG.Warn("保存密码到实例配置失败", zap.Error(err))
 	}
 
 	// 更新数据库中的密码记录，确保数据库与实际密码一致
-	err = global.APP_DB.Model(&providerModel.Instance{}).
-		Where("name = ?", config.Name).
+	err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("name = ?", instanceName).
 		Update("password", password).Error
 	if err != nil {
 		global.APP_LOG.Warn("更新实例密码到数据库失败",
-			zap.String("instanceName", config.Name),
+			zap.String("instanceName", instanceName),
 			zap.Error(err))
 	} else {
 		global.APP_LOG.Info("实例密码已同步到数据库",
-			zap.String("instanceName", config.Name))
+			zap.String("instanceName", instanceName))
 	}
-	return nil
 }
 
 // waitForInstanceExecReady 等待实例可以执行命令（容器直接可用，虚拟机需要等待Agent）
@@ -396,7 +438,7 @@ func (l *LXDProvider) waitForInstanceExecReady(instanceName string, timeoutSecon
 	for elapsed := 0; elapsed < timeoutSeconds; elapsed += 5 {
 		// 每两轮循环（10秒）尝试启动实例，避免实例因故障停止导致一直干等待
 		if loopCount > 0 && loopCount%2 == 0 {
-			startCmd := fmt.Sprintf("lxc start %s", instanceName)
+			startCmd := fmt.Sprintf("lxc start %s", utils.ShellQuote(instanceName))
 			startOutput, startErr := l.sshClient.Execute(startCmd)
 			// "already running" 不是错误，而是实例已在运行的正常状态
 			if startErr == nil || strings.Contains(startOutput, "already running") {
@@ -412,7 +454,7 @@ func (l *LXDProvider) waitForInstanceExecReady(instanceName string, timeoutSecon
 		}
 
 		// 尝试执行一个简单的命令来检测VM agent是否就绪
-		cmd := fmt.Sprintf("lxc exec %s -- echo 'agent-ready' 2>/dev/null", instanceName)
+		cmd := fmt.Sprintf("lxc exec %s -- echo 'agent-ready' 2>/dev/null", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(cmd)
 		if err == nil && strings.Contains(output, "agent-ready") {
 			global.APP_LOG.Info("实例可执行命令",
@@ -40,21 +40,21 @@ func (l *LXDProvider) ConfigureIPv6(instanceName string, enable bool) error {
 // enableIPv6 启用IPv6网络
 func (l *LXDProvider) enableIPv6(instanceName string) error {
 	// 1. 设置IPv6网络设备配置
-	ipv6NetworkCmd := fmt.Sprintf("lxc config device override %s eth0 ipv6.address=auto", instanceName)
+	ipv6NetworkCmd := fmt.Sprintf("lxc config device override %s eth0 ipv6.address=auto", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(ipv6NetworkCmd)
 	if err != nil {
 		return fmt.Errorf("配置IPv6网络设备失败: %w", err)
 	}
 
 	// 2. 启用IPv6路由
-	routeCmd := fmt.Sprintf("lxc config device override %s eth0 ipv6.routes=true", instanceName)
+	routeCmd := fmt.Sprintf("lxc config device override %s eth0 ipv6.routes=true", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(routeCmd)
 	if err != nil {
 		return fmt.Errorf("配置IPv6路由失败: %w", err)
 	}
 
 	// 3. 在容器内启用IPv6
-	enableIPv6Cmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo 0 > /proc/sys/net/ipv6/conf/all/disable_ipv6'", instanceName)
+	enableIPv6Cmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo 0 > /proc/sys/net/ipv6/conf/all/disable_ipv6'", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(enableIPv6Cmd)
 	if err != nil {
 		global.APP_LOG.Warn("在容器内启用IPv6失败",
@@ -64,7 +64,7 @@ func (l *LXDProvider) enableIPv6(instanceName string) error {
 	}
 
 	// 4. 重启网络接口
-	restartNetworkCmd := fmt.Sprintf("lxc exec %s -- bash -c 'ip addr flush dev eth0 && dhclient -6 eth0'", instanceName)
+	restartNetworkCmd := fmt.Sprintf("lxc exec %s -- bash -c 'ip addr flush dev eth0 && dhclient -6 eth0'", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(restartNetworkCmd)
 	if err != nil {
 		global.APP_LOG.Warn("重启网络接口失败",
@@ -82,7 +82,7 @@ func (l *LXDProvider) enableIPv6(instanceName string) error {
 // disableIPv6 禁用IPv6网络
 func (l *LXDProvider) disableIPv6(instanceName string) error {
 	// 1. 移除IPv6网络设备配置
-	removeIPv6NetworkCmd := fmt.Sprintf("lxc config device unset %s eth0 ipv6.address", instanceName)
+	removeIPv6NetworkCmd := fmt.Sprintf("lxc config device unset %s eth0 ipv6.address", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(removeIPv6NetworkCmd)
 	if err != nil {
 		global.APP_LOG.Warn("移除IPv6网络配置失败",
@@ -92,7 +92,7 @@ func (l *LXDProvider) disableIPv6(instanceName string) error {
 	}
 
 	// 2. 禁用IPv6路由
-	disableRouteCmd := fmt.Sprintf("lxc config device unset %s eth0 ipv6.routes", instanceName)
+	disableRouteCmd := fmt.Sprintf("lxc config device unset %s eth0 ipv6.routes", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(disableRouteCmd)
 	if err != nil {
 		global.APP_LOG.Warn("禁用IPv6路由失败",
@@ -102,7 +102,7 @@ func (l *LXDProvider) disableIPv6(instanceName string) error {
 	}
 
 	// 3. 在容器内禁用IPv6
-	disableIPv6Cmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo 1 > /proc/sys/net/ipv6/conf/all/disable_ipv6'", instanceName)
+	disableIPv6Cmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo 1 > /proc/sys/net/ipv6/conf/all/disable_ipv6'", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(disableIPv6Cmd)
 	if err != nil {
 		global.APP_LOG.Warn("在容器内禁用IPv6失败",
@@ -120,7 +120,7 @@ func (l *LXDProvider) disableIPv6(instanceName string) error {
 // GetInstanceIPv6 获取实例的内网IPv6地址
 func (l *LXDProvider) GetInstanceIPv6(instanceName string) (string, error) {
 	// 获取实例的内网IPv6地址
-	ipv6Cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", instanceName)
+	ipv6Cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", utils.ShellQuote(instanceName))
 	ipv6Output, err := l.sshClient.Execute(ipv6Cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取IPv6地址失败: %w", err)
@@ -137,7 +137,7 @@ func (l *LXDProvider) GetInstanceIPv6(instanceName string) (string, error) {
 // GetInstancePublicIPv6 获取实例的公网IPv6地址
 func (l *LXDProvider) GetInstancePublicIPv6(instanceName string) (string, error) {
 	// 尝试从保存的IPv6文件中读取公网IPv6地址
-	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", instanceName)
+	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", utils.ShellQuote(instanceName))
 	publicIPv6Output, err := l.sshClient.Execute(publicIPv6Cmd)
 	if err == nil {
 		publicIPv6 := utils.CleanCommandOutput(publicIPv6Output)
@@ -150,7 +150,7 @@ func (l *LXDProvider) GetInstancePublicIPv6(instanceName string) (string, error)
 	}
 
 	// 如果文件中没有，尝试从eth1网络设备获取
-	eth1Cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth1.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", instanceName)
+	eth1Cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth1.addresses[]? | select(.family==\"inet6\" and .scope==\"global\") | .address' 2>/dev/null", utils.ShellQuote(instanceName))
 	eth1Output, err := l.sshClient.Execute(eth1Cmd)
 	if err == nil {
 		eth1IPv6 := utils.CleanCommandOutput(eth1Output)
@@ -174,20 +174,20 @@ func (l *LXDProvider) ConfigureIPv6Profile(profileName string, enable bool) erro
 
 	if enable {
 		// 为profile启用IPv6
-		profileCmd := fmt.Sprintf("lxc profile device set %s eth0 ipv6.address auto", profileName)
+		profileCmd := fmt.Sprintf("lxc profile device set %s eth0 ipv6.address auto", utils.ShellQuote(profileName))
 		_, err := l.sshClient.Execute(profileCmd)
 		if err != nil {
 			return fmt.Errorf("配置Profile IPv6失败: %w", err)
 		}
 
-		routeCmd := fmt.Sprintf("lxc profile device set %s eth0 ipv6.routes true", profileName)
+		routeCmd := fmt.Sprintf("lxc profile device set %s eth0 ipv6.routes true", utils.ShellQuote(profileName))
 		_, err = l.sshClient.Execute(routeCmd)
 		if err != nil {
 			return fmt.Errorf("配置Profile IPv6路由失败: %w", err)
 		}
 	} else {
 		// 为profile禁用IPv6
-		unsetCmd := fmt.Sprintf("lxc profile device unset %s eth0 ipv6.address", profileName)
+		unsetCmd := fmt.Sprintf("lxc profile device unset %s eth0 ipv6.address", utils.ShellQuote(profileName))
 		_, err := l.sshClient.Execute(unsetCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移除Profile IPv6配置失败",
@@ -195,7 +195,7 @@ func (l *LXDProvider) ConfigureIPv6Profile(profileName string, enable bool) erro
 				zap.Error(err))
 		}
 
-		unsetRouteCmd := fmt.Sprintf("lxc profile device unset %s eth0 ipv6.routes", profileName)
+		unsetRouteCmd := fmt.Sprintf("lxc profile device unset %s eth0 ipv6.routes", utils.ShellQuote(profileName))
 		_, err = l.sshClient.Execute(unsetRouteCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移除Profile IPv6路由失败",
@@ -260,7 +260,7 @@ func (l *LXDProvider) checkIPv6(ctx context.Context) (string, error) {
 	}
 
 	for _, endpoint := range apiEndpoints {
-		cmd := fmt.Sprintf("curl -sLk6m8 '%s' | tr -d '[:space:]'", endpoint)
+		cmd := fmt.Sprintf("curl -sLk6m8 %s | tr -d '[:space:]'", utils.ShellQuote(endpoint))
 		output, err := l.sshClient.Execute(cmd)
 		if err == nil {
 			ipv6 := strings.TrimSpace(output)
@@ -279,7 +279,7 @@ func (l *LXDProvider) checkIPv6(ctx context.Context) (string, error) {
 
 // getContainerIPv6 获取容器内网IPv6地址
 func (l *LXDProvider) getContainerIPv6(ctx context.Context, containerName string) (string, error) {
-	cmd := fmt.Sprintf("lxc list %s --format=json | jq -r '.[0].state.network.eth0.addresses[] | select(.family==\"inet6\") | select(.scope==\"global\") | .address'", containerName)
+	cmd := fmt.Sprintf("lxc list %s --format=json | jq -r '.[0].state.network.eth0.addresses[] | select(.family==\"inet6\") | select(.scope==\"global\") | .address'", utils.ShellQuote(containerName))
 	output, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取容器IPv6地址失败: %w", err)
@@ -416,7 +416,7 @@ func (l *LXDProvider) installSipcalcRHEL(ctx context.Context) error {
 
 	for _, mirror := range mirrors {
 		global.APP_LOG.Info("尝试从镜像下载sipcalc", zap.String("mirror", mirror))
-		downloadCmd := fmt.Sprintf("curl -fLO '%s'", mirror)
+		downloadCmd := fmt.Sprintf("curl -fLO %s", utils.ShellQuote(mirror))
 		_, err := l.sshClient.Execute(downloadCmd)
 		if err == nil {
 			break
@@ -545,13 +545,13 @@ func (l *LXDProvider) setupNetworkDeviceIPv6(ctx context.Context, config IPv6Con
 		zap.String("ipv6", containerIPv6))
 
 	// 停止容器
-	stopCmd := fmt.Sprintf("lxc stop %s", config.ContainerName)
+	stopCmd := fmt.Sprintf("lxc stop %s", utils.ShellQuote(config.ContainerName))
 	l.sshClient.Execute(stopCmd)
 	time.Sleep(3 * time.Second)
 
 	// IPv6网络设备
 	deviceCmd := fmt.Sprintf("lxc config device add %s eth1 nic nictype=routed parent=%s ipv6.address=%s",
-		config.ContainerName, ipv6NetworkName, containerIPv6)
+		utils.ShellQuote(config.ContainerName), ipv6NetworkName, containerIPv6)
 	_, err = l.sshClient.Execute(deviceCmd)
 	if err != nil {
 		return "", fmt.Errorf("添加IPv6网络设备失败: %w", err)
@@ -563,7 +563,7 @@ func (l *LXDProvider) setupNetworkDeviceIPv6(ctx context.Context, config IPv6Con
 	l.configureFirewallForIPv6(ctx, ipv6NetworkName)
 
 	// 启动容器
-	startCmd := fmt.Sprintf("lxc start %s", config.ContainerName)
+	startCmd := fmt.Sprintf("lxc start %s", utils.ShellQuote(config.ContainerName))
 	_, err = l.sshClient.Execute(startCmd)
 	if err != nil {
 		return "", fmt.Errorf("启动容器失败: %w", err)
@@ -749,7 +749,7 @@ func (l *LXDProvider) configureIPv6Network(ctx context.Context, containerName st
 	}
 
 	// 保存IPv6地址到文件
-	saveCmd := fmt.Sprintf("echo \"%s\" >> %s_v6", containerIPv6, containerName)
+	saveCmd := fmt.Sprintf("echo %s >> %s", utils.ShellQuote(containerIPv6), utils.ShellQuote(containerName+"_v6"))
 	l.sshClient.Execute(saveCmd)
 
 	global.APP_LOG.Info("IPv6网络配置完成",
@@ -908,7 +908,7 @@ func (l *LXDProvider) setupPersistenceService(ctx context.Context) error {
 	var cdnSuccessUrl string
 	for _, cdnUrl := range cdnUrls {
 		testUrl := cdnUrl + "https://raw.githubusercontent.com/spiritLHLS/ecs/main/back/test"
-		testCmd := fmt.Sprintf("curl -4 -sL -k '%s' --max-time 6 | grep -q 'success'", testUrl)
+		testCmd := fmt.Sprintf("curl -4 -sL -k %s --max-time 6 | grep -q 'success'", utils.ShellQuote(testUrl))
 		_, err := l.sshClient.Execute(testCmd)
 		if err == nil {
 			cdnSuccessUrl = cdnUrl
@@ -919,31 +919,31 @@ func (l *LXDProvider) setupPersistenceService(ctx context.Context) error {
 
 	// 下载add-ipv6.sh脚本
 	scriptPath := "/usr/local/bin/add-ipv6.sh"
-	checkScriptCmd := fmt.Sprintf("[ -f %s ]", scriptPath)
+	checkScriptCmd := fmt.Sprintf("[ -f %s ]", utils.ShellQuote(scriptPath))
 	_, err := l.sshClient.Execute(checkScriptCmd)
 	if err != nil {
 		scriptUrl := cdnSuccessUrl + "https://raw.githubusercontent.com/oneclickvirt/lxd/main/scripts/add-ipv6.sh"
-		downloadCmd := fmt.Sprintf("wget '%s' -O %s", scriptUrl, scriptPath)
+		downloadCmd := fmt.Sprintf("wget %s -O %s", utils.ShellQuote(scriptUrl), utils.ShellQuote(scriptPath))
 		_, err := l.sshClient.Execute(downloadCmd)
 		if err != nil {
 			global.APP_LOG.Warn("下载add-ipv6.sh脚本失败", zap.Error(err))
 		} else {
-			l.sshClient.Execute(fmt.Sprintf("chmod +x %s", scriptPath))
+			l.sshClient.Execute(fmt.Sprintf("chmod +x %s", utils.ShellQuote(scriptPath)))
 		}
 	}
 
 	// 下载add-ipv6.service服务文件
 	servicePath := "/etc/systemd/system/add-ipv6.service"
-	checkServiceCmd := fmt.Sprintf("[ -f %s ]", servicePath)
+	checkServiceCmd := fmt.Sprintf("[ -f %s ]", utils.ShellQuote(servicePath))
 	_, err = l.sshClient.Execute(checkServiceCmd)
 	if err != nil {
 		serviceUrl := cdnSuccessUrl + "https://raw.githubusercontent.com/oneclickvirt/lxd/main/scripts/add-ipv6.service"
-		downloadCmd := fmt.Sprintf("wget '%s' -O %s", serviceUrl, servicePath)
+		downloadCmd := fmt.Sprintf("wget %s -O %s", utils.ShellQuote(serviceUrl), utils.ShellQuote(servicePath))
 		_, err := l.sshClient.Execute(downloadCmd)
 		if err != nil {
 			global.APP_LOG.Warn("下载add-ipv6.service服务文件失败", zap.Error(err))
 		} else {
-			l.sshClient.Execute(fmt.Sprintf("chmod +x %s", servicePath))
+			l.sshClient.Execute(fmt.Sprintf("chmod +x %s", utils.ShellQuote(servicePath)))
 			l.sshClient.Execute("systemctl daemon-reload")
 			l.sshClient.Execute("systemctl enable add-ipv6.service")
 			l.sshClient.Execute("systemctl start add-ipv6.service")
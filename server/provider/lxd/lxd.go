@@ -112,6 +112,8 @@ func (l *LXDProvider) Connect(ctx context.Context, config provider.NodeConfig) e
 		PrivateKey:     config.PrivateKey,
 		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
 		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		ShellInit:      provider.ProjectShellInit("lxc", config.Project), // 所有CLI命令在配置的项目中执行
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
 	}
 
 	client, err := utils.NewSSHClient(sshConfig)
@@ -119,6 +121,18 @@ func (l *LXDProvider) Connect(ctx context.Context, config provider.NodeConfig) e
 		return fmt.Errorf("failed to connect via SSH: %w", err)
 	}
 
+	if provider.UsesProject(config.Project) {
+		if err := provider.ValidateLXDObjectName("项目", config.Project); err != nil {
+			client.Close()
+			return err
+		}
+		if output, err := client.Execute(provider.EnsureProjectCommand("lxc", config.Project)); err != nil {
+			client.Close()
+			return fmt.Errorf("初始化项目 %s 失败: %w, output: %s", config.Project, err, strings.TrimSpace(output))
+		}
+		l.apiClient.Transport = provider.WithProject(l.transport, config.Project)
+	}
+
 	l.sshClient = client
 	l.connected = true
 
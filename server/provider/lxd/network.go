@@ -158,7 +158,7 @@ func (l *LXDProvider) restartVMForNetwork(instanceName string) error {
 	global.APP_LOG.Info("重启虚拟机获取网络配置", zap.String("instanceName", instanceName))
 
 	// 尝试优雅重启，给虚拟机足够的超时时间
-	restartCmd := fmt.Sprintf("lxc restart %s --timeout=120", instanceName)
+	restartCmd := fmt.Sprintf("lxc restart %s --timeout=120", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(restartCmd)
 
 	if err != nil {
@@ -177,7 +177,7 @@ func (l *LXDProvider) restartVMForNetwork(instanceName string) error {
 // restartContainerForNetwork 重启容器以获取网络配置
 func (l *LXDProvider) restartContainerForNetwork(instanceName string) error {
 	global.APP_LOG.Info("重启容器获取网络配置", zap.String("instanceName", instanceName))
-	restartCmd := fmt.Sprintf("lxc restart %s --timeout=60", instanceName)
+	restartCmd := fmt.Sprintf("lxc restart %s --timeout=60", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(restartCmd)
 
 	if err != nil {
@@ -198,7 +198,7 @@ func (l *LXDProvider) forceRestartVM(instanceName string) error {
 	global.APP_LOG.Info("强制重启虚拟机", zap.String("instanceName", instanceName))
 
 	// 强制停止虚拟机
-	stopCmd := fmt.Sprintf("lxc stop %s --force --timeout=60", instanceName)
+	stopCmd := fmt.Sprintf("lxc stop %s --force --timeout=60", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(stopCmd)
 	if err != nil {
 		global.APP_LOG.Error("强制停止虚拟机失败",
@@ -211,7 +211,7 @@ func (l *LXDProvider) forceRestartVM(instanceName string) error {
 	time.Sleep(10 * time.Second)
 
 	// 启动虚拟机
-	startCmd := fmt.Sprintf("lxc start %s", instanceName)
+	startCmd := fmt.Sprintf("lxc start %s", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(startCmd)
 	if err != nil {
 		return fmt.Errorf("启动虚拟机失败: %w", err)
@@ -226,7 +226,7 @@ func (l *LXDProvider) forceRestartContainer(instanceName string) error {
 	global.APP_LOG.Info("强制重启容器", zap.String("instanceName", instanceName))
 
 	// 强制停止容器
-	stopCmd := fmt.Sprintf("lxc stop %s --force --timeout=30", instanceName)
+	stopCmd := fmt.Sprintf("lxc stop %s --force --timeout=30", utils.ShellQuote(instanceName))
 	_, err := l.sshClient.Execute(stopCmd)
 	if err != nil {
 		global.APP_LOG.Error("强制停止容器失败",
@@ -239,7 +239,7 @@ func (l *LXDProvider) forceRestartContainer(instanceName string) error {
 	time.Sleep(3 * time.Second)
 
 	// 启动容器
-	startCmd := fmt.Sprintf("lxc start %s", instanceName)
+	startCmd := fmt.Sprintf("lxc start %s", utils.ShellQuote(instanceName))
 	_, err = l.sshClient.Execute(startCmd)
 	if err != nil {
 		return fmt.Errorf("启动容器失败: %w", err)
@@ -266,7 +266,7 @@ func (l *LXDProvider) waitForVMNetworkReady(instanceName string) error {
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 检查虚拟机状态
-		statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(statusCmd)
 		if err != nil {
 			global.APP_LOG.Warn("检查虚拟机状态失败",
@@ -319,7 +319,7 @@ func (l *LXDProvider) waitForContainerNetworkReady(instanceName string) error {
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 检查容器状态
-		statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(statusCmd)
 		if err != nil {
 			global.APP_LOG.Warn("检查容器状态失败",
@@ -357,7 +357,7 @@ func (l *LXDProvider) waitForContainerNetworkReady(instanceName string) error {
 
 // getInstanceType 获取实例类型
 func (l *LXDProvider) getInstanceType(instanceName string) (string, error) {
-	cmd := fmt.Sprintf("lxc info %s | grep \"Type:\" | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("lxc info %s | grep \"Type:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取实例类型失败: %w", err)
@@ -406,18 +406,11 @@ func (l *LXDProvider) getVMInstanceIP(instanceName string) (string, error) {
 
 		time.Sleep(time.Duration(delay) * time.Second)
 
-		// 虚拟机通常使用 enp5s0 接口，如果没有则尝试 eth0
-		interfaces := []string{"enp5s0", "eth0"}
-
-		for _, iface := range interfaces {
-			cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.%s.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", instanceName, iface)
-			output, err := l.sshClient.Execute(cmd)
-
-			if err == nil && strings.TrimSpace(output) != "" {
-				vmIP := strings.TrimSpace(output)
+		// 虚拟机的网络地址由Agent上报，Agent未运行时继续等待
+		if state, err := l.queryInstanceState(instanceName); err == nil && state.AgentRunning() {
+			if vmIP := state.IPv4(); vmIP != "" {
 				global.APP_LOG.Info("虚拟机IPv4地址获取成功",
 					zap.String("instanceName", instanceName),
-					zap.String("interface", iface),
 					zap.String("ip", vmIP),
 					zap.Int("attempt", attempt))
 				return vmIP, nil
@@ -451,7 +444,7 @@ func (l *LXDProvider) getContainerInstanceIP(instanceName string) (string, error
 		time.Sleep(time.Duration(delay) * time.Second)
 
 		// 容器通常使用 eth0 接口
-		cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", instanceName)
+		cmd := fmt.Sprintf("lxc list %s --format json | jq -r '.[0].state.network.eth0.addresses[]? | select(.family==\"inet\") | .address' 2>/dev/null", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(cmd)
 
 		if err == nil && strings.TrimSpace(output) != "" {
@@ -478,7 +471,7 @@ func (l *LXDProvider) getInstanceIPGeneric(instanceName string) (string, error)
 	global.APP_LOG.Debug("使用通用方法获取IP地址", zap.String("instanceName", instanceName))
 
 	// 首先尝试使用 lxc list 简单格式获取IP
-	cmd := fmt.Sprintf("lxc list %s -c 4 --format csv", instanceName)
+	cmd := fmt.Sprintf("lxc list %s -c 4 --format csv", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取实例信息失败: %w", err)
@@ -588,7 +581,7 @@ func (l *LXDProvider) stopInstanceForConfig(instanceName string) error {
 
 	// 停止实例
 	time.Sleep(6 * time.Second)
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc stop %s --timeout=30", instanceName))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc stop %s --timeout=30", utils.ShellQuote(instanceName)))
 	if err != nil {
 		return fmt.Errorf("停止实例失败: %w", err)
 	}
@@ -597,7 +590,7 @@ func (l *LXDProvider) stopInstanceForConfig(instanceName string) error {
 	maxWait := 30
 	waited := 0
 	for waited < maxWait {
-		cmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", instanceName)
+		cmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(instanceName))
 		output, err := l.sshClient.Execute(cmd)
 		if err == nil && strings.TrimSpace(output) == "STOPPED" {
 			global.APP_LOG.Info("实例已安全停止", zap.String("instanceName", instanceName))
@@ -635,7 +628,7 @@ func (l *LXDProvider) configureNetworkLimits(instanceName string, networkConfig
 	}
 
 	// 获取实例的网络接口列表
-	interfaceListCmd := fmt.Sprintf("lxc config device list %s", instanceName)
+	interfaceListCmd := fmt.Sprintf("lxc config device list %s", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(interfaceListCmd)
 
 	var targetInterface string
@@ -662,7 +655,7 @@ func (l *LXDProvider) configureNetworkLimits(instanceName string, networkConfig
 
 	// 配置网络限速
 	egressCmd := fmt.Sprintf("lxc config device override %s %s limits.egress=%dMbit limits.ingress=%dMbit limits.max=%dMbit",
-		instanceName, targetInterface, networkConfig.OutSpeed, networkConfig.InSpeed, speedLimit)
+		utils.ShellQuote(instanceName), targetInterface, networkConfig.OutSpeed, networkConfig.InSpeed, speedLimit)
 
 	_, err = l.sshClient.Execute(egressCmd)
 	if err != nil {
@@ -673,7 +666,7 @@ func (l *LXDProvider) configureNetworkLimits(instanceName string, networkConfig
 				zap.Error(err))
 
 			ethCmd := fmt.Sprintf("lxc config device override %s eth0 limits.egress=%dMbit limits.ingress=%dMbit limits.max=%dMbit",
-				instanceName, networkConfig.OutSpeed, networkConfig.InSpeed, speedLimit)
+				utils.ShellQuote(instanceName), networkConfig.OutSpeed, networkConfig.InSpeed, speedLimit)
 
 			_, err = l.sshClient.Execute(ethCmd)
 			if err != nil {
@@ -712,7 +705,7 @@ func (l *LXDProvider) setIPAddressBinding(instanceName, instanceIP string) error
 		zap.String("cleanIP", cleanIP))
 
 	// 获取实例的网络接口列表，智能选择接口
-	interfaceListCmd := fmt.Sprintf("lxc config device list %s", instanceName)
+	interfaceListCmd := fmt.Sprintf("lxc config device list %s", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(interfaceListCmd)
 
 	var targetInterface string
@@ -738,7 +731,7 @@ func (l *LXDProvider) setIPAddressBinding(instanceName, instanceIP string) error
 	}
 
 	// 尝试设置IP地址绑定
-	cmd := fmt.Sprintf("lxc config device set %s %s ipv4.address %s", instanceName, targetInterface, cleanIP)
+	cmd := fmt.Sprintf("lxc config device set %s %s ipv4.address %s", utils.ShellQuote(instanceName), targetInterface, cleanIP)
 	_, err = l.sshClient.Execute(cmd)
 	if err != nil {
 		global.APP_LOG.Debug("device set失败，尝试override方式",
@@ -746,7 +739,7 @@ func (l *LXDProvider) setIPAddressBinding(instanceName, instanceIP string) error
 			zap.Error(err))
 
 		// 尝试override方式
-		cmd = fmt.Sprintf("lxc config device override %s %s ipv4.address=%s", instanceName, targetInterface, cleanIP)
+		cmd = fmt.Sprintf("lxc config device override %s %s ipv4.address=%s", utils.ShellQuote(instanceName), targetInterface, cleanIP)
 		_, err = l.sshClient.Execute(cmd)
 		if err != nil {
 			// 如果不是eth0，最后尝试eth0
@@ -755,7 +748,7 @@ func (l *LXDProvider) setIPAddressBinding(instanceName, instanceIP string) error
 					zap.String("interface", targetInterface),
 					zap.Error(err))
 
-				cmd = fmt.Sprintf("lxc config device override %s eth0 ipv4.address=%s", instanceName, cleanIP)
+				cmd = fmt.Sprintf("lxc config device override %s eth0 ipv4.address=%s", utils.ShellQuote(instanceName), cleanIP)
 				_, err = l.sshClient.Execute(cmd)
 				if err != nil {
 					global.APP_LOG.Warn("IP地址绑定失败，继续执行",
@@ -1050,7 +1043,7 @@ func (l *LXDProvider) GetInstanceIPv4(ctx context.Context, instanceName string)
 // GetVethInterfaceName 获取容器对应的宿主机veth接口名称（IPv4）
 // 通过 lxc config show 获取 volatile.eth0.host_name
 func (l *LXDProvider) GetVethInterfaceName(instanceName string) (string, error) {
-	cmd := fmt.Sprintf("lxc config show %s | grep 'volatile.eth0.host_name:' | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("lxc config show %s | grep 'volatile.eth0.host_name:' | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取veth接口名称失败: %w", err)
@@ -1071,7 +1064,7 @@ func (l *LXDProvider) GetVethInterfaceName(instanceName string) (string, error)
 // GetVethInterfaceNameV6 获取容器对应的宿主机veth接口名称（IPv6）
 // 通过 lxc config show 获取 volatile.eth1.host_name（如果存在）
 func (l *LXDProvider) GetVethInterfaceNameV6(instanceName string) (string, error) {
-	cmd := fmt.Sprintf("lxc config show %s | grep 'volatile.eth1.host_name:' | awk '{print $2}'", instanceName)
+	cmd := fmt.Sprintf("lxc config show %s | grep 'volatile.eth1.host_name:' | awk '{print $2}'", utils.ShellQuote(instanceName))
 	output, err := l.sshClient.Execute(cmd)
 	if err != nil {
 		return "", fmt.Errorf("获取veth接口名称(IPv6)失败: %w", err)
@@ -1096,7 +1089,7 @@ func (l *LXDProvider) tryUseExistingNetworkConfig(config provider.InstanceConfig
 		zap.String("instanceName", config.Name))
 
 	// 检查实例是否仍在运行
-	statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", config.Name)
+	statusCmd := fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(config.Name))
 	output, err := l.sshClient.Execute(statusCmd)
 	if err != nil {
 		return fmt.Errorf("检查实例状态失败: %w", err)
@@ -1109,7 +1102,7 @@ func (l *LXDProvider) tryUseExistingNetworkConfig(config provider.InstanceConfig
 			zap.String("status", status))
 
 		// 尝试启动实例
-		startCmd := fmt.Sprintf("lxc start %s", config.Name)
+		startCmd := fmt.Sprintf("lxc start %s", utils.ShellQuote(config.Name))
 		_, err := l.sshClient.Execute(startCmd)
 		if err != nil {
 			return fmt.Errorf("启动实例失败: %w", err)
@@ -1120,7 +1113,7 @@ func (l *LXDProvider) tryUseExistingNetworkConfig(config provider.InstanceConfig
 			zap.String("instanceName", config.Name))
 
 		// 判断实例类型
-		typeCmd := fmt.Sprintf("lxc info %s | grep \"Type:\" | awk '{print $2}'", config.Name)
+		typeCmd := fmt.Sprintf("lxc info %s | grep \"Type:\" | awk '{print $2}'", utils.ShellQuote(config.Name))
 		typeOutput, err := l.sshClient.Execute(typeCmd)
 		instanceType := strings.TrimSpace(typeOutput)
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
 	"sort"
 	"strings"
 	"time"
@@ -212,7 +213,7 @@ func (l *LXDProvider) setupDeviceProxyRangeMapping(instanceName string, startPor
 		// 创建TCP区间映射
 		tcpDeviceName := fmt.Sprintf("tcp-range-%d-%d", startPort, endPort)
 		tcpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:%s:%d-%d",
-			instanceName, tcpDeviceName, "tcp", hostIP, startPort, endPort, "tcp", instanceIP, startPort, endPort)
+			utils.ShellQuote(instanceName), tcpDeviceName, "tcp", hostIP, startPort, endPort, "tcp", instanceIP, startPort, endPort)
 
 		global.APP_LOG.Info("执行TCP端口区间映射命令",
 			zap.String("command", tcpProxyCmd))
@@ -225,7 +226,7 @@ func (l *LXDProvider) setupDeviceProxyRangeMapping(instanceName string, startPor
 		// 创建UDP区间映射
 		udpDeviceName := fmt.Sprintf("udp-range-%d-%d", startPort, endPort)
 		udpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:%s:%d-%d",
-			instanceName, udpDeviceName, "udp", hostIP, startPort, endPort, "udp", instanceIP, startPort, endPort)
+			utils.ShellQuote(instanceName), udpDeviceName, "udp", hostIP, startPort, endPort, "udp", instanceIP, startPort, endPort)
 
 		global.APP_LOG.Info("执行UDP端口区间映射命令",
 			zap.String("command", udpProxyCmd))
@@ -248,7 +249,7 @@ func (l *LXDProvider) setupDeviceProxyRangeMapping(instanceName string, startPor
 		// 创建LXD device proxy区间映射
 		// 格式：lxc config device add <instance> <device-name> proxy listen=tcp:<host-ip>:<start-port>-<end-port> connect=tcp:<guest-ip>:<start-port>-<end-port>
 		proxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d-%d connect=%s:%s:%d-%d",
-			instanceName, deviceName, protocol, hostIP, startPort, endPort, protocol, instanceIP, startPort, endPort)
+			utils.ShellQuote(instanceName), deviceName, protocol, hostIP, startPort, endPort, protocol, instanceIP, startPort, endPort)
 
 		global.APP_LOG.Info("执行LXD端口区间映射命令",
 			zap.String("command", proxyCmd))
@@ -305,7 +306,7 @@ func (l *LXDProvider) setupNATPortRangeDeviceProxyWithIP(instanceName string, st
 	// 设置TCP端口范围映射 - 使用与buildct.sh脚本相同的格式
 	tcpDeviceName := "nattcp-ports"
 	tcpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=tcp:%s:%d-%d connect=tcp:0.0.0.0:%d-%d nat=true",
-		instanceName, tcpDeviceName, hostIP, startPort, endPort, startPort, endPort)
+		utils.ShellQuote(instanceName), tcpDeviceName, hostIP, startPort, endPort, startPort, endPort)
 
 	global.APP_LOG.Info("执行TCP NAT端口范围映射命令",
 		zap.String("command", tcpProxyCmd))
@@ -318,7 +319,7 @@ func (l *LXDProvider) setupNATPortRangeDeviceProxyWithIP(instanceName string, st
 	// 设置UDP端口范围映射 - 使用与buildct.sh脚本相同的格式
 	udpDeviceName := "natudp-ports"
 	udpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=udp:%s:%d-%d connect=udp:0.0.0.0:%d-%d nat=true",
-		instanceName, udpDeviceName, hostIP, startPort, endPort, startPort, endPort)
+		utils.ShellQuote(instanceName), udpDeviceName, hostIP, startPort, endPort, startPort, endPort)
 
 	global.APP_LOG.Info("执行UDP NAT端口范围映射命令",
 		zap.String("command", udpProxyCmd))
@@ -416,7 +417,7 @@ func (l *LXDProvider) setupDeviceProxyMapping(instanceName string, hostPort, gue
 
 	// 创建proxy设备
 	proxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%d connect=%s:%d",
-		instanceName, deviceName, protocol, hostPort, instanceIP, guestPort)
+		utils.ShellQuote(instanceName), deviceName, protocol, hostPort, instanceIP, guestPort)
 
 	_, err = l.sshClient.Execute(proxyCmd)
 	if err != nil {
@@ -463,7 +464,7 @@ func (l *LXDProvider) setupDeviceProxyMappingWithIP(instanceName string, hostPor
 		// 创建TCP设备
 		tcpDeviceName := fmt.Sprintf("proxy-tcp-%d", hostPort)
 		tcpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d connect=%s:%s:%d nat=true",
-			instanceName, tcpDeviceName, "tcp", hostIP, hostPort, "tcp", cleanInstanceIP, guestPort)
+			utils.ShellQuote(instanceName), tcpDeviceName, "tcp", hostIP, hostPort, "tcp", cleanInstanceIP, guestPort)
 
 		global.APP_LOG.Info("执行TCP端口映射命令",
 			zap.String("command", tcpProxyCmd))
@@ -476,7 +477,7 @@ func (l *LXDProvider) setupDeviceProxyMappingWithIP(instanceName string, hostPor
 		// 创建UDP设备
 		udpDeviceName := fmt.Sprintf("proxy-udp-%d", hostPort)
 		udpProxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d connect=%s:%s:%d nat=true",
-			instanceName, udpDeviceName, "udp", hostIP, hostPort, "udp", cleanInstanceIP, guestPort)
+			utils.ShellQuote(instanceName), udpDeviceName, "udp", hostIP, hostPort, "udp", cleanInstanceIP, guestPort)
 
 		global.APP_LOG.Info("执行UDP端口映射命令",
 			zap.String("command", udpProxyCmd))
@@ -496,7 +497,7 @@ func (l *LXDProvider) setupDeviceProxyMappingWithIP(instanceName string, hostPor
 
 		// 创建proxy设备 - 使用与buildct.sh脚本相同的格式
 		proxyCmd := fmt.Sprintf("lxc config device add %s %s proxy listen=%s:%s:%d connect=%s:%s:%d nat=true",
-			instanceName, deviceName, protocol, hostIP, hostPort, protocol, cleanInstanceIP, guestPort)
+			utils.ShellQuote(instanceName), deviceName, protocol, hostIP, hostPort, protocol, cleanInstanceIP, guestPort)
 
 		global.APP_LOG.Info("执行端口映射命令",
 			zap.String("command", proxyCmd))
@@ -523,8 +524,8 @@ func (l *LXDProvider) setupIptablesMapping(instanceName string, hostPort, guestP
 	}
 
 	// DNAT规则
-	dnatCmd := fmt.Sprintf("iptables -t nat -A PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-		protocol, hostPort, instanceIP, guestPort)
+	dnatCmd := utils.DNATAddCommand(l.config.PublicInterface, fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+		protocol, hostPort, instanceIP, guestPort))
 
 	_, err = l.sshClient.Execute(dnatCmd)
 	if err != nil {
@@ -572,8 +573,8 @@ func (l *LXDProvider) setupIptablesMappingWithIP(instanceName string, hostPort,
 
 	for _, proto := range protocols {
 		// DNAT规则
-		dnatCmd := fmt.Sprintf("iptables -t nat -A PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
+		dnatCmd := utils.DNATAddCommand(l.config.PublicInterface, fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+			proto, hostPort, instanceIP, guestPort))
 
 		_, err := l.sshClient.Execute(dnatCmd)
 		if err != nil {
@@ -649,7 +650,7 @@ func (l *LXDProvider) removePortMapping(instanceName string, hostPort int, proto
 func (l *LXDProvider) removeDeviceProxyMapping(instanceName string, hostPort int, protocol string) error {
 	deviceName := fmt.Sprintf("proxy-%s-%d", protocol, hostPort)
 
-	removeCmd := fmt.Sprintf("lxc config device remove %s %s", instanceName, deviceName)
+	removeCmd := fmt.Sprintf("lxc config device remove %s %s", utils.ShellQuote(instanceName), deviceName)
 	_, err := l.sshClient.Execute(removeCmd)
 	if err != nil {
 		return fmt.Errorf("移除proxy设备失败: %w", err)
@@ -671,8 +672,8 @@ func (l *LXDProvider) removeIptablesMapping(instanceName string, hostPort int, p
 	}
 
 	// 移除DNAT规则
-	dnatCmd := fmt.Sprintf("iptables -t nat -D PREROUTING -p %s --dport %d -j DNAT --to-destination %s",
-		protocol, hostPort, instanceIP)
+	dnatCmd := utils.DNATDeleteCommand(fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s",
+		protocol, hostPort, instanceIP), l.config.PublicInterface, "")
 
 	_, err = l.sshClient.Execute(dnatCmd)
 	if err != nil {
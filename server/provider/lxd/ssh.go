@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
@@ -39,7 +40,7 @@ func (l *LXDProvider) sshListInstances(ctx context.Context) ([]provider.Instance
 		instance := provider.Instance{
 			ID:     fields[0],
 			Name:   fields[0],
-			Status: strings.ToLower(fields[1]),
+			Status: constant.NormalizeProviderStatus("lxd", fields[1]),
 			Type:   fields[2],
 		}
 		instances = append(instances, instance)
@@ -267,7 +268,7 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 
 	if config.InstanceType == "vm" {
 		// 虚拟机创建命令格式：lxc init image_name vm_name --vm -c limits.cpu=X -c limits.memory=XMiB -d root,size=XGiB
-		cmd = fmt.Sprintf("lxc init %s %s --vm", config.Image, config.Name)
+		cmd = fmt.Sprintf("lxc init %s %s --vm", utils.ShellQuote(config.Image), utils.ShellQuote(config.Name))
 
 		// 资源配置参数
 		if config.CPU != "" {
@@ -286,7 +287,7 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 		configParams = append(configParams, "limits.cpu.priority=0")
 	} else {
 		// 容器创建命令格式
-		cmd = fmt.Sprintf("lxc init %s %s", config.Image, config.Name)
+		cmd = fmt.Sprintf("lxc init %s %s", utils.ShellQuote(config.Image), utils.ShellQuote(config.Name))
 
 		// 基础资源配置
 		if config.CPU != "" {
@@ -360,6 +361,15 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 		cmd += fmt.Sprintf(" -c %s", param)
 	}
 
+	// 配置文件：指定 -p 后不再自动应用default，由 InstanceProfiles 补充
+	profiles, err := provider.InstanceProfiles(l.config.Profiles, config.Profiles)
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		cmd += fmt.Sprintf(" -p %s", profile)
+	}
+
 	// 磁盘配置
 	if config.Disk != "" {
 		diskFormatted := convertDiskFormat(config.Disk)
@@ -368,8 +378,7 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 
 	// 创建实例
 	global.APP_LOG.Debug("执行LXD实例创建命令", zap.String("command", cmd))
-	_, err := l.sshClient.Execute(cmd)
-	if err != nil {
+	if _, err := l.sshClient.Execute(cmd); err != nil {
 		return fmt.Errorf("failed to create instance: %w", err)
 	}
 
@@ -391,8 +400,8 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 	if config.InstanceType != "vm" && config.DiskIOLimit != nil && *config.DiskIOLimit != "" {
 		// 解析格式："10MB"（带宽）或 "100iops"（IOPS）
 		limit := *config.DiskIOLimit
-		_, _ = l.sshClient.Execute(fmt.Sprintf("lxc config device set %s root limits.read %s", config.Name, limit))
-		_, _ = l.sshClient.Execute(fmt.Sprintf("lxc config device set %s root limits.write %s", config.Name, limit))
+		_, _ = l.sshClient.Execute(fmt.Sprintf("lxc config device set %s root limits.read %s", utils.ShellQuote(config.Name), limit))
+		_, _ = l.sshClient.Execute(fmt.Sprintf("lxc config device set %s root limits.write %s", utils.ShellQuote(config.Name), limit))
 		global.APP_LOG.Info("已应用自定义磁盘IO限制", zap.String("limit", limit))
 	}
 
@@ -405,7 +414,7 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 
 	updateProgress(55, "启动实例...")
 	// 启动实例
-	_, err = l.sshClient.Execute(fmt.Sprintf("lxc start %s", config.Name))
+	_, err = l.sshClient.Execute(fmt.Sprintf("lxc start %s", utils.ShellQuote(config.Name)))
 	if err != nil {
 		return fmt.Errorf("failed to start instance: %w", err)
 	}
@@ -573,7 +582,7 @@ func (l *LXDProvider) sshCreateInstanceWithProgress(ctx context.Context, config
 
 func (l *LXDProvider) sshStartInstance(ctx context.Context, id string) error {
 	// 执行启动命令
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc start %s", id))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc start %s", utils.ShellQuote(id)))
 	if err != nil {
 		// 如果错误提示实例已在运行，不视为错误
 		if strings.Contains(err.Error(), "already running") ||
@@ -601,7 +610,7 @@ func (l *LXDProvider) sshStartInstance(ctx context.Context, id string) error {
 		time.Sleep(checkInterval)
 
 		// 检查实例状态
-		statusOutput, err := l.sshClient.Execute(fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", id))
+		statusOutput, err := l.sshClient.Execute(fmt.Sprintf("lxc info %s | grep \"Status:\" | awk '{print $2}'", utils.ShellQuote(id)))
 		if err == nil {
 			status := strings.TrimSpace(statusOutput)
 			if status == "RUNNING" || status == "Running" {
@@ -621,7 +630,7 @@ func (l *LXDProvider) sshStartInstance(ctx context.Context, id string) error {
 }
 
 func (l *LXDProvider) sshStopInstance(ctx context.Context, id string) error {
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc stop %s", id))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc stop %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to stop instance: %w", err)
 	}
@@ -631,7 +640,7 @@ func (l *LXDProvider) sshStopInstance(ctx context.Context, id string) error {
 }
 
 func (l *LXDProvider) sshRestartInstance(ctx context.Context, id string) error {
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc restart %s", id))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc restart %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to restart instance: %w", err)
 	}
@@ -641,7 +650,7 @@ func (l *LXDProvider) sshRestartInstance(ctx context.Context, id string) error {
 }
 
 func (l *LXDProvider) sshDeleteInstance(ctx context.Context, id string) error {
-	output, err := l.sshClient.Execute(fmt.Sprintf("lxc delete %s --force", id))
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc delete %s --force", utils.ShellQuote(id)))
 	if err != nil {
 		// 检查是否是实例不存在的错误
 		if strings.Contains(output, "Instance not found") || strings.Contains(output, "not found") {
@@ -687,7 +696,7 @@ func (l *LXDProvider) sshListImages(ctx context.Context) ([]provider.Image, erro
 }
 
 func (l *LXDProvider) sshPullImage(ctx context.Context, image string) error {
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc image copy images:%s local:", image))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc image copy images:%s local:", utils.ShellQuote(image)))
 	if err != nil {
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
@@ -697,7 +706,7 @@ func (l *LXDProvider) sshPullImage(ctx context.Context, image string) error {
 }
 
 func (l *LXDProvider) sshDeleteImage(ctx context.Context, id string) error {
-	_, err := l.sshClient.Execute(fmt.Sprintf("lxc image delete %s", id))
+	_, err := l.sshClient.Execute(fmt.Sprintf("lxc image delete %s", utils.ShellQuote(id)))
 	if err != nil {
 		return fmt.Errorf("failed to delete image: %w", err)
 	}
@@ -708,27 +717,27 @@ func (l *LXDProvider) sshDeleteImage(ctx context.Context, id string) error {
 
 // sshSetInstancePassword 通过SSH设置实例密码
 func (l *LXDProvider) sshSetInstancePassword(ctx context.Context, instanceID, password string) error {
-	// 首先尝试简单的状态检查命令
-	simpleCheckCmd := fmt.Sprintf("lxc list | grep %s", instanceID)
-	output, err := l.sshClient.Execute(simpleCheckCmd)
+	// 检查实例是否存在且运行，同时检测虚拟机Agent是否可用
+	state, err := l.queryInstanceState(instanceID)
 	if err != nil {
 		global.APP_LOG.Error("检查LXD实例状态失败",
 			zap.String("instanceID", instanceID),
 			zap.Error(err))
 		return fmt.Errorf("检查实例状态失败: %w", err)
 	}
-
-	// 检查实例是否存在且运行
-	if !strings.Contains(output, instanceID) {
-		return fmt.Errorf("实例 %s 不存在", instanceID)
-	}
-
-	if !strings.Contains(output, "RUNNING") {
+	if !strings.EqualFold(state.Status, "running") {
 		return fmt.Errorf("实例 %s 未运行，无法设置密码", instanceID)
 	}
+	if state.IsVM() {
+		if state.AgentRunning() {
+			return l.agentSetPassword(instanceID, "root", password)
+		}
+		global.APP_LOG.Warn("虚拟机lxd-agent未运行，回退到脚本方式设置密码",
+			zap.String("instanceID", instanceID))
+	}
 
 	// 设置密码 - 使用lxc exec命令
-	setPasswordCmd := fmt.Sprintf("lxc exec %s -- bash -c 'echo \"root:%s\" | chpasswd'", instanceID, password)
+	setPasswordCmd := fmt.Sprintf("printf '%%s\\n' %s | lxc exec %s -- chpasswd", utils.ShellQuote("root:"+password), utils.ShellQuote(instanceID))
 	_, err = l.sshClient.Execute(setPasswordCmd)
 	if err != nil {
 		global.APP_LOG.Error("设置LXD实例密码失败",
@@ -5,10 +5,7 @@ import (
 	"strconv"
 	"strings"
 
-	"oneclickvirt/global"
 	"oneclickvirt/utils"
-
-	"go.uber.org/zap"
 )
 
 // convertMemoryFormat converts memory from MB to MiB for LXD compatibility
@@ -94,18 +91,7 @@ func (l *LXDProvider) min(a, b int) int {
 	return b
 }
 
-// getDownloadURL 确定下载URL
-func (l *LXDProvider) getDownloadURL(originalURL, providerCountry string, useCDN bool) string {
-	// 如果不使用CDN，直接返回原始URL
-	if !useCDN {
-		global.APP_LOG.Info("镜像配置不使用CDN，使用原始URL",
-			zap.String("originalURL", utils.TruncateString(originalURL, 100)))
-		return originalURL
-	}
-
-	// 默认随机尝试CDN，不再限制地区
-	if cdnURL := utils.GetCDNURL(l.sshClient, originalURL, "LXD"); cdnURL != "" {
-		return cdnURL
-	}
-	return originalURL
+// getDownloadSources 确定镜像的候选下载来源：Provider镜像源、CDN（useCDN时）、原始地址，上次成功的来源优先
+func (l *LXDProvider) getDownloadSources(originalURL string, useCDN bool) []utils.DownloadSource {
+	return utils.ImageDownloadSources(originalURL, l.config.ImageMirrors, useCDN, utils.PreferredImageSource(l.config.ID))
 }
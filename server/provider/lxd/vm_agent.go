@@ -0,0 +1,61 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// queryInstanceState 读取实例状态，虚拟机的进程数和网络地址由lxd-agent上报
+func (l *LXDProvider) queryInstanceState(instanceName string) (*provider.VMAgentState, error) {
+	if l.sshClient == nil {
+		return nil, fmt.Errorf("SSH连接不可用")
+	}
+	output, err := l.sshClient.Execute(fmt.Sprintf("lxc list %s --format json", utils.ShellQuote(instanceName)))
+	if err != nil {
+		return nil, fmt.Errorf("获取实例状态失败: %w", err)
+	}
+	return provider.ParseVMAgentState(output, instanceName)
+}
+
+// VMAgentAvailable 检测实例的执行通道是否可用
+func (l *LXDProvider) VMAgentAvailable(ctx context.Context, instanceID string) bool {
+	state, err := l.queryInstanceState(instanceID)
+	if err != nil {
+		global.APP_LOG.Debug("检测lxd-agent失败", zap.String("instanceName", instanceID), zap.Error(err))
+		return false
+	}
+	return state.AgentRunning()
+}
+
+// agentExec 通过lxd-agent执行通道在实例内执行脚本
+func (l *LXDProvider) agentExec(instanceName, script string) (string, error) {
+	return l.sshClient.Execute(fmt.Sprintf("lxc exec %s -- sh -c %s", utils.ShellQuote(instanceName), utils.ShellQuote(script)))
+}
+
+// agentSetPassword 通过lxd-agent设置用户密码并开启SSH密码登录，无需推送配置脚本
+func (l *LXDProvider) agentSetPassword(instanceName, username, password string) error {
+	if output, err := l.agentExec(instanceName, provider.AgentPasswordScript(username, password)); err != nil {
+		return fmt.Errorf("通过lxd-agent设置密码失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	global.APP_LOG.Info("LXD实例密码设置成功(Agent)",
+		zap.String("instanceName", utils.TruncateString(instanceName, 12)))
+	return nil
+}
+
+// InjectInstanceSSHKeys 通过lxd-agent执行通道写入SSH公钥
+func (l *LXDProvider) InjectInstanceSSHKeys(ctx context.Context, instanceID, username string, keys []string) error {
+	if !l.connected || l.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if output, err := l.agentExec(instanceID, provider.AgentSSHKeysScript(username, keys)); err != nil {
+		return fmt.Errorf("通过lxd-agent写入SSH公钥失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	return nil
+}
@@ -309,7 +309,7 @@ func (d *DockerPortMapping) createDockerPortMapping(ctx context.Context, instanc
 		zap.String("providerName", providerInfo.Name))
 
 	// 检查容器是否存在
-	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", instance.Name)
+	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(instance.Name))
 	status, err := providerInstance.ExecuteSSHCommand(ctx, checkCmd)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %v", err)
@@ -320,24 +320,24 @@ func (d *DockerPortMapping) createDockerPortMapping(ctx context.Context, instanc
 	// Docker不支持动态端口映射，需要重新创建容器
 	if strings.Contains(status, "running") || strings.Contains(status, "exited") {
 		// 获取现有容器的配置
-		inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", instance.Name)
+		inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", utils.ShellQuote(instance.Name))
 		configInfo, err := providerInstance.ExecuteSSHCommand(ctx, inspectCmd)
 		if err != nil {
 			return fmt.Errorf("failed to get container config: %v", err)
 		}
 
 		// 获取现有的端口映射
-		portsCmd := fmt.Sprintf("docker port %s", instance.Name)
+		portsCmd := "docker port " + utils.ShellQuote(instance.Name)
 		existingPorts, _ := providerInstance.ExecuteSSHCommand(ctx, portsCmd)
 
 		// 停止并删除现有容器
-		stopCmd := fmt.Sprintf("docker stop %s", instance.Name)
+		stopCmd := "docker stop " + utils.ShellQuote(instance.Name)
 		_, err = providerInstance.ExecuteSSHCommand(ctx, stopCmd)
 		if err != nil {
 			global.APP_LOG.Warn("Failed to stop container", zap.Error(err))
 		}
 
-		removeCmd := fmt.Sprintf("docker rm %s", instance.Name)
+		removeCmd := "docker rm " + utils.ShellQuote(instance.Name)
 		_, err = providerInstance.ExecuteSSHCommand(ctx, removeCmd)
 		if err != nil {
 			return fmt.Errorf("failed to remove container: %v", err)
@@ -375,7 +375,7 @@ func (d *DockerPortMapping) createDockerPortMappingWithTempSSH(ctx context.Conte
 	defer sshClient.Close()
 
 	// 检查容器是否存在
-	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", instance.Name)
+	checkCmd := fmt.Sprintf("docker inspect %s --format '{{.State.Status}}'", utils.ShellQuote(instance.Name))
 	status, err := sshClient.Execute(checkCmd)
 	if err != nil {
 		return fmt.Errorf("failed to check container status: %v", err)
@@ -386,24 +386,24 @@ func (d *DockerPortMapping) createDockerPortMappingWithTempSSH(ctx context.Conte
 	// Docker不支持动态端口映射，需要重新创建容器
 	if strings.Contains(status, "running") || strings.Contains(status, "exited") {
 		// 获取现有容器的配置
-		inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", instance.Name)
+		inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", utils.ShellQuote(instance.Name))
 		configInfo, err := sshClient.Execute(inspectCmd)
 		if err != nil {
 			return fmt.Errorf("failed to get container config: %v", err)
 		}
 
 		// 获取现有的端口映射
-		portsCmd := fmt.Sprintf("docker port %s", instance.Name)
+		portsCmd := "docker port " + utils.ShellQuote(instance.Name)
 		existingPorts, _ := sshClient.Execute(portsCmd)
 
 		// 停止并删除现有容器
-		stopCmd := fmt.Sprintf("docker stop %s", instance.Name)
+		stopCmd := "docker stop " + utils.ShellQuote(instance.Name)
 		_, err = sshClient.Execute(stopCmd)
 		if err != nil {
 			global.APP_LOG.Warn("Failed to stop container", zap.Error(err))
 		}
 
-		removeCmd := fmt.Sprintf("docker rm %s", instance.Name)
+		removeCmd := "docker rm " + utils.ShellQuote(instance.Name)
 		_, err = sshClient.Execute(removeCmd)
 		if err != nil {
 			return fmt.Errorf("failed to remove container: %v", err)
@@ -481,17 +481,17 @@ func (d *DockerPortMapping) buildDockerRunCommand(instance *provider.Instance, c
 	image := configParts[0]
 
 	// 构建基础命令
-	cmd := fmt.Sprintf("docker run -d --name %s", instance.Name)
+	cmd := "docker run -d --name " + utils.ShellQuote(instance.Name)
 
 	// 资源限制（如果有的话）
 	if len(configParts) >= 3 && configParts[2] != "0" {
-		cmd += fmt.Sprintf(" --memory=%s", configParts[2])
+		cmd += " --memory=" + utils.ShellQuote(configParts[2])
 	}
 	if len(configParts) >= 4 && configParts[3] != "0" {
 		nanoCpus := configParts[3]
 		if nanoCpus != "0" {
 			// 转换纳秒CPU到CPU核心数
-			cmd += fmt.Sprintf(" --cpus=%s", nanoCpus)
+			cmd += " --cpus=" + utils.ShellQuote(nanoCpus)
 		}
 	}
 
@@ -509,7 +509,7 @@ func (d *DockerPortMapping) buildDockerRunCommand(instance *provider.Instance, c
 					if strings.Contains(hostPart, ":") {
 						hostPortStr := strings.Split(hostPart, ":")[1]
 						// 只映射IPv4端口，明确指定0.0.0.0
-						cmd += fmt.Sprintf(" -p 0.0.0.0:%s:%s", hostPortStr, guestPart)
+						cmd += " -p " + utils.ShellQuote(fmt.Sprintf("0.0.0.0:%s:%s", hostPortStr, guestPart))
 					}
 				}
 			}
@@ -522,14 +522,14 @@ func (d *DockerPortMapping) buildDockerRunCommand(instance *provider.Instance, c
 		cmd += fmt.Sprintf(" -p 0.0.0.0:%d:%d/tcp", newHostPort, newGuestPort)
 		cmd += fmt.Sprintf(" -p 0.0.0.0:%d:%d/udp", newHostPort, newGuestPort)
 	} else {
-		cmd += fmt.Sprintf(" -p 0.0.0.0:%d:%d/%s", newHostPort, newGuestPort, protocol)
+		cmd += " -p " + utils.ShellQuote(fmt.Sprintf("0.0.0.0:%d:%d/%s", newHostPort, newGuestPort, protocol))
 	}
 
 	// 必要的能力
 	cmd += " --cap-add=MKNOD"
 
 	// 镜像
-	cmd += fmt.Sprintf(" %s", image)
+	cmd += " " + utils.ShellQuote(image)
 
 	return cmd
 }
@@ -557,27 +557,27 @@ func (d *DockerPortMapping) removeDockerPortMapping(ctx context.Context, instanc
 
 	// Docker不支持动态移除端口映射，需要重新创建容器（不包含该端口映射）
 	// 获取现有容器的配置
-	inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", instance.Name)
+	inspectCmd := fmt.Sprintf("docker inspect %s --format '{{.Config.Image}} {{.Config.Cmd}} {{.HostConfig.Memory}} {{.HostConfig.NanoCpus}}'", utils.ShellQuote(instance.Name))
 	configInfo, err := sshClient.Execute(inspectCmd)
 	if err != nil {
 		return fmt.Errorf("failed to get container config: %v", err)
 	}
 
 	// 获取现有的端口映射（排除要删除的）
-	portsCmd := fmt.Sprintf("docker port %s", instance.Name)
+	portsCmd := "docker port " + utils.ShellQuote(instance.Name)
 	existingPorts, _ := sshClient.Execute(portsCmd)
 
 	// 过滤掉要删除的端口映射
 	filteredPorts := d.filterPortMappings(existingPorts, hostPort, guestPort, protocol)
 
 	// 停止并删除现有容器
-	stopCmd := fmt.Sprintf("docker stop %s", instance.Name)
+	stopCmd := "docker stop " + utils.ShellQuote(instance.Name)
 	_, err = sshClient.Execute(stopCmd)
 	if err != nil {
 		global.APP_LOG.Warn("Failed to stop container", zap.Error(err))
 	}
 
-	removeCmd := fmt.Sprintf("docker rm %s", instance.Name)
+	removeCmd := "docker rm " + utils.ShellQuote(instance.Name)
 	_, err = sshClient.Execute(removeCmd)
 	if err != nil {
 		return fmt.Errorf("failed to remove container: %v", err)
@@ -657,14 +657,14 @@ func (d *DockerPortMapping) buildDockerRunCommandWithFilteredPorts(instance *pro
 	image := configParts[0]
 
 	// 构建基础命令
-	cmd := fmt.Sprintf("docker run -d --name %s", instance.Name)
+	cmd := "docker run -d --name " + utils.ShellQuote(instance.Name)
 
 	// 资源限制
 	if len(configParts) >= 3 && configParts[2] != "0" {
-		cmd += fmt.Sprintf(" --memory=%s", configParts[2])
+		cmd += " --memory=" + utils.ShellQuote(configParts[2])
 	}
 	if len(configParts) >= 4 && configParts[3] != "0" {
-		cmd += fmt.Sprintf(" --cpus=%s", configParts[3])
+		cmd += " --cpus=" + utils.ShellQuote(configParts[3])
 	}
 
 	// 过滤后的端口映射
@@ -678,7 +678,7 @@ func (d *DockerPortMapping) buildDockerRunCommandWithFilteredPorts(instance *pro
 				if strings.Contains(hostPart, ":") {
 					hostPortStr := strings.Split(hostPart, ":")[1]
 					// 只映射IPv4端口，明确指定0.0.0.0
-					cmd += fmt.Sprintf(" -p 0.0.0.0:%s:%s", hostPortStr, guestPart)
+					cmd += " -p " + utils.ShellQuote(fmt.Sprintf("0.0.0.0:%s:%s", hostPortStr, guestPart))
 				}
 			}
 		}
@@ -688,7 +688,7 @@ func (d *DockerPortMapping) buildDockerRunCommandWithFilteredPorts(instance *pro
 	cmd += " --cap-add=MKNOD"
 
 	// 镜像
-	cmd += fmt.Sprintf(" %s", image)
+	cmd += " " + utils.ShellQuote(image)
 
 	return cmd
 }
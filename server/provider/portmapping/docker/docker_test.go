@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"oneclickvirt/model/provider"
+)
+
+// shellArgs 让shell按原样解析命令，返回docker之后实际得到的参数列表
+func shellArgs(t *testing.T, cmd string) []string {
+	t.Helper()
+	if !strings.HasPrefix(cmd, "docker ") {
+		t.Fatalf("命令应以docker开头: %s", cmd)
+	}
+	out, err := exec.Command("sh", "-c", "printf '%s\\n' "+strings.TrimPrefix(cmd, "docker ")).Output()
+	if err != nil {
+		t.Fatalf("shell解析失败: %v, cmd=%s", err, cmd)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func TestBuildDockerRunCommandQuotesArgs(t *testing.T) {
+	d := &DockerPortMapping{}
+	name := "web; echo pwned $(id) `id`"
+	instance := &provider.Instance{Name: name}
+	configInfo := "nginx:latest;id [] 536870912 1000000000"
+	existing := "80/tcp -> 0.0.0.0:8080"
+
+	cmds := []string{
+		d.buildDockerRunCommand(instance, configInfo, existing, 2222, 22, "tcp"),
+		d.buildDockerRunCommandWithFilteredPorts(instance, configInfo, []string{existing}),
+	}
+	for _, cmd := range cmds {
+		args := shellArgs(t, cmd)
+		if len(args) < 4 || args[0] != "run" || args[2] != "--name" || args[3] != name {
+			t.Fatalf("容器名未作为单个参数传递: %q", args)
+		}
+		if last := args[len(args)-1]; last != "nginx:latest;id" {
+			t.Errorf("镜像应作为单个参数传递, got %q", last)
+		}
+		for _, arg := range args {
+			if arg == "pwned" {
+				t.Errorf("容器名中的shell元字符被执行: %s", cmd)
+			}
+		}
+	}
+}
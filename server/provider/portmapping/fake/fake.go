@@ -0,0 +1,163 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping"
+
+	"go.uber.org/zap"
+)
+
+// FakePortMapping 模拟Provider的端口映射实现，只管理数据库记录
+type FakePortMapping struct {
+	*portmapping.BaseProvider
+}
+
+// NewFakePortMapping 创建模拟端口映射Provider
+func NewFakePortMapping(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+	return &FakePortMapping{
+		BaseProvider: portmapping.NewBaseProvider("fake", config),
+	}
+}
+
+// SupportsDynamicMapping 模拟Provider支持动态端口映射
+func (f *FakePortMapping) SupportsDynamicMapping() bool {
+	return true
+}
+
+// CreatePortMapping 创建模拟端口映射
+func (f *FakePortMapping) CreatePortMapping(ctx context.Context, req *portmapping.PortMappingRequest) (*portmapping.PortMappingResult, error) {
+	if req.InstanceID == "" {
+		return nil, fmt.Errorf("invalid request: instance ID is required")
+	}
+	if req.GuestPort <= 0 || req.GuestPort > 65535 {
+		return nil, fmt.Errorf("invalid request: invalid guest port: %d", req.GuestPort)
+	}
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	if err := portmapping.ValidateProtocol(req.Protocol); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	var providerInfo provider.Provider
+	if err := global.APP_DB.First(&providerInfo, req.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get provider: provider not found: %v", err)
+	}
+
+	hostPort := req.HostPort
+	if hostPort == 0 {
+		var err error
+		hostPort, err = f.BaseProvider.AllocatePort(ctx, req.ProviderID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %v", err)
+		}
+	}
+
+	isSSH := req.GuestPort == 22
+	if req.IsSSH != nil {
+		isSSH = *req.IsSSH
+	}
+
+	result := &portmapping.PortMappingResult{
+		InstanceID:    req.InstanceID,
+		ProviderID:    req.ProviderID,
+		Protocol:      req.Protocol,
+		HostPort:      hostPort,
+		GuestPort:     req.GuestPort,
+		HostIP:        providerInfo.Endpoint,
+		PublicIP:      f.getPublicIP(&providerInfo),
+		IPv6Address:   req.IPv6Address,
+		Status:        "active",
+		Description:   req.Description,
+		MappingMethod: "fake",
+		IsSSH:         isSSH,
+		IsAutomatic:   req.HostPort == 0,
+	}
+
+	portModel := f.BaseProvider.ToDBModel(result)
+	if err := global.APP_DB.Create(portModel).Error; err != nil {
+		return nil, fmt.Errorf("failed to save port mapping: %v", err)
+	}
+
+	result.ID = portModel.ID
+	result.CreatedAt = portModel.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	result.UpdatedAt = portModel.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+
+	global.APP_LOG.Debug("Fake port mapping created",
+		zap.Uint("id", result.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("guestPort", req.GuestPort))
+	return result, nil
+}
+
+// DeletePortMapping 删除模拟端口映射
+func (f *FakePortMapping) DeletePortMapping(ctx context.Context, req *portmapping.DeletePortMappingRequest) error {
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return fmt.Errorf("port mapping not found: %v", err)
+	}
+	if err := global.APP_DB.Delete(&portModel).Error; err != nil {
+		return fmt.Errorf("failed to delete port mapping from database: %v", err)
+	}
+	return nil
+}
+
+// UpdatePortMapping 更新模拟端口映射
+func (f *FakePortMapping) UpdatePortMapping(ctx context.Context, req *portmapping.UpdatePortMappingRequest) (*portmapping.PortMappingResult, error) {
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	updates := map[string]interface{}{
+		"host_port":   req.HostPort,
+		"guest_port":  req.GuestPort,
+		"protocol":    req.Protocol,
+		"description": req.Description,
+		"status":      req.Status,
+	}
+	if err := global.APP_DB.Model(&portModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update port mapping: %v", err)
+	}
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated port mapping: %v", err)
+	}
+
+	result := f.BaseProvider.FromDBModel(&portModel)
+	result.MappingMethod = "fake"
+	return result, nil
+}
+
+// ListPortMappings 列出模拟端口映射
+func (f *FakePortMapping) ListPortMappings(ctx context.Context, instanceID string) ([]*portmapping.PortMappingResult, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+
+	var results []*portmapping.PortMappingResult
+	for _, port := range ports {
+		result := f.BaseProvider.FromDBModel(&port)
+		result.MappingMethod = "fake"
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// getPublicIP 获取公网IP
+func (f *FakePortMapping) getPublicIP(providerInfo *provider.Provider) string {
+	if providerInfo.PortIP != "" {
+		return providerInfo.PortIP
+	}
+	return providerInfo.Endpoint
+}
+
+// init 注册模拟端口映射Provider
+func init() {
+	portmapping.RegisterProvider("fake", func(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+		return NewFakePortMapping(config)
+	})
+}
@@ -70,6 +70,13 @@ func GetProviderDescription(providerType string) map[string]interface{} {
 			"protocols":   []string{"tcp", "udp"},
 			"features":    []string{"vm-specific", "flexible", "host-level"},
 		},
+		"kubernetes": {
+			"name":        "Kubernetes",
+			"description": "Kubernetes端口映射，为每条映射创建NodePort Service",
+			"methods":     []string{"nodeport"},
+			"protocols":   []string{"tcp", "udp"},
+			"features":    []string{"native", "cluster-level", "container-specific"},
+		},
 		"iptables": {
 			"name":        "iptables",
 			"description": "通用iptables NAT端口映射，适用于各种场景",
@@ -131,6 +138,15 @@ func GetProviderCapabilities(providerType string) map[string]bool {
 			"hot_reload":           true,
 			"persistent":           false,
 		},
+		"kubernetes": {
+			"auto_port_allocation": true,
+			"custom_port_range":    true,
+			"ipv6_support":         false,
+			"protocol_tcp":         true,
+			"protocol_udp":         true,
+			"hot_reload":           true,
+			"persistent":           true,
+		},
 		"iptables": {
 			"auto_port_allocation": true,
 			"custom_port_range":    true,
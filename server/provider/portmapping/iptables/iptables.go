@@ -309,8 +309,8 @@ func (i *IptablesPortMapping) createIptablesRule(ctx context.Context, instance *
 
 	for _, proto := range protocols {
 		// 创建PREROUTING DNAT规则 - 将外部端口转发到内部实例
-		dnatRule := fmt.Sprintf("iptables -t nat -A PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
+		dnatRule := utils.DNATAddCommand(providerInfo.PublicInterface, fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+			proto, hostPort, instanceIP, guestPort))
 
 		// 创建FORWARD规则 - 允许转发到实例
 		forwardRule := fmt.Sprintf("iptables -A FORWARD -p %s -d %s --dport %d -j ACCEPT",
@@ -423,6 +423,12 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 		return fmt.Errorf("instance private IP address not found for %s", instance.Name)
 	}
 
+	// 获取provider信息以创建SSH连接
+	providerInfo, err := i.getProvider(instance.ProviderID)
+	if err != nil {
+		return fmt.Errorf("failed to get provider info: %v", err)
+	}
+
 	// 如果协议是both，需要同时删除TCP和UDP规则
 	protocols := []string{protocol}
 	if protocol == "both" {
@@ -433,8 +439,8 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 
 	for _, proto := range protocols {
 		// 删除PREROUTING DNAT规则
-		dnatRule := fmt.Sprintf("iptables -t nat -D PREROUTING -p %s --dport %d -j DNAT --to-destination %s:%d",
-			proto, hostPort, instanceIP, guestPort)
+		dnatRule := utils.DNATDeleteCommand(fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+			proto, hostPort, instanceIP, guestPort), providerInfo.PublicInterface, "")
 
 		// 删除FORWARD规则
 		forwardRule := fmt.Sprintf("iptables -D FORWARD -p %s -d %s --dport %d -j ACCEPT",
@@ -451,15 +457,6 @@ func (i *IptablesPortMapping) removeIptablesRule(ctx context.Context, instance *
 		zap.String("protocol", protocol),
 		zap.Int("commandCount", len(allCommands)))
 
-	// 获取provider信息以创建SSH连接
-	var providerInfo *provider.Provider
-	var err error
-
-	// 从实例信息中获取provider ID，然后获取provider信息
-	if providerInfo, err = i.getProvider(instance.ProviderID); err != nil {
-		return fmt.Errorf("failed to get provider info: %v", err)
-	}
-
 	// 创建SSH客户端连接到provider主机执行iptables命令
 	sshClient, err := i.createSSHClient(providerInfo)
 	if err != nil {
@@ -513,6 +510,7 @@ func (i *IptablesPortMapping) createSSHClient(providerInfo *provider.Provider) (
 		PrivateKey:     providerInfo.SSHKey,
 		ConnectTimeout: 10 * time.Second,
 		ExecuteTimeout: 60 * time.Second,
+		Guard:          utils.ProviderCommandGuard(providerInfo),
 	}
 
 	return utils.NewSSHClient(sshConfig)
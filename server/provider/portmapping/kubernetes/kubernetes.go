@@ -0,0 +1,322 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+	"oneclickvirt/provider/portmapping"
+	providerService "oneclickvirt/service/provider"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// nodePortManager Kubernetes Provider实现的NodePort Service管理方法
+type nodePortManager interface {
+	SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error
+	RemovePortMapping(ctx context.Context, instanceName string, hostPort int, protocol string) error
+}
+
+// KubernetesPortMapping Kubernetes端口映射实现，每条映射对应一个NodePort Service
+type KubernetesPortMapping struct {
+	*portmapping.BaseProvider
+}
+
+// NewKubernetesPortMapping 创建Kubernetes端口映射Provider
+func NewKubernetesPortMapping(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+	return &KubernetesPortMapping{
+		BaseProvider: portmapping.NewBaseProvider("kubernetes", config),
+	}
+}
+
+// SupportsDynamicMapping NodePort Service可以随时创建和删除
+func (k *KubernetesPortMapping) SupportsDynamicMapping() bool {
+	return true
+}
+
+// CreatePortMapping 创建NodePort Service并保存端口映射记录
+func (k *KubernetesPortMapping) CreatePortMapping(ctx context.Context, req *portmapping.PortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Creating Kubernetes port mapping",
+		zap.String("instanceId", req.InstanceID),
+		zap.Int("hostPort", req.HostPort),
+		zap.Int("guestPort", req.GuestPort),
+		zap.String("protocol", req.Protocol))
+
+	// 验证请求参数
+	if err := k.validateRequest(req); err != nil {
+		return nil, fmt.Errorf("invalid request: %v", err)
+	}
+
+	// 获取实例信息
+	instance, err := k.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	// 获取Provider信息
+	providerInfo, err := k.getProvider(req.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	// 分配端口
+	hostPort := req.HostPort
+	if hostPort == 0 {
+		hostPort, err = k.BaseProvider.AllocatePort(ctx, req.ProviderID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %v", err)
+		}
+	}
+
+	manager, err := k.getNodePortManager(providerInfo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.SetupPortMappingWithIP(ctx, instance.Name, hostPort, req.GuestPort, req.Protocol, "", ""); err != nil {
+		return nil, fmt.Errorf("failed to create NodePort service: %v", err)
+	}
+
+	// 判断是否为SSH端口：优先使用请求中的IsSSH字段，否则根据GuestPort判断
+	isSSH := req.GuestPort == 22
+	if req.IsSSH != nil {
+		isSSH = *req.IsSSH
+	}
+
+	// 保存到数据库
+	result := &portmapping.PortMappingResult{
+		InstanceID:    req.InstanceID,
+		ProviderID:    req.ProviderID,
+		Protocol:      req.Protocol,
+		HostPort:      hostPort,
+		GuestPort:     req.GuestPort,
+		HostIP:        providerInfo.Endpoint,
+		PublicIP:      k.getPublicIP(providerInfo),
+		IPv6Address:   req.IPv6Address,
+		Status:        "active",
+		Description:   req.Description,
+		MappingMethod: "nodeport",
+		IsSSH:         isSSH,
+		IsAutomatic:   req.HostPort == 0,
+	}
+
+	// 转换为数据库模型并保存
+	portModel := k.BaseProvider.ToDBModel(result)
+	if err := global.APP_DB.Create(portModel).Error; err != nil {
+		global.APP_LOG.Error("Failed to save port mapping to database", zap.Error(err))
+		// 尝试清理已创建的Service
+		if cleanupErr := manager.RemovePortMapping(ctx, instance.Name, hostPort, req.Protocol); cleanupErr != nil {
+			global.APP_LOG.Error("Failed to cleanup NodePort service", zap.Error(cleanupErr))
+		}
+		return nil, fmt.Errorf("failed to save port mapping: %v", err)
+	}
+
+	result.ID = portModel.ID
+	result.CreatedAt = portModel.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	result.UpdatedAt = portModel.UpdatedAt.Format("2006-01-02T15:04:05Z07:00")
+
+	global.APP_LOG.Info("Kubernetes port mapping created successfully",
+		zap.Uint("id", result.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("guestPort", req.GuestPort))
+
+	return result, nil
+}
+
+// DeletePortMapping 删除NodePort Service和端口映射记录
+func (k *KubernetesPortMapping) DeletePortMapping(ctx context.Context, req *portmapping.DeletePortMappingRequest) error {
+	global.APP_LOG.Info("Deleting Kubernetes port mapping",
+		zap.Uint("id", req.ID),
+		zap.String("instanceId", req.InstanceID))
+
+	// 获取端口映射信息
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	// 获取实例信息
+	instance, err := k.getInstance(req.InstanceID)
+	if err != nil {
+		return fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	// 删除Service
+	manager, err := k.getNodePortManager(portModel.ProviderID)
+	if err == nil {
+		err = manager.RemovePortMapping(ctx, instance.Name, portModel.HostPort, portModel.Protocol)
+	}
+	if err != nil {
+		if !req.ForceDelete {
+			return fmt.Errorf("failed to remove NodePort service: %v", err)
+		}
+		global.APP_LOG.Warn("Failed to remove NodePort service, but force delete is enabled", zap.Error(err))
+	}
+
+	// 从数据库删除
+	if err := global.APP_DB.Delete(&portModel).Error; err != nil {
+		return fmt.Errorf("failed to delete port mapping from database: %v", err)
+	}
+
+	global.APP_LOG.Info("Kubernetes port mapping deleted successfully", zap.Uint("id", req.ID))
+	return nil
+}
+
+// UpdatePortMapping 更新端口映射，端口或协议变化时重建Service
+func (k *KubernetesPortMapping) UpdatePortMapping(ctx context.Context, req *portmapping.UpdatePortMappingRequest) (*portmapping.PortMappingResult, error) {
+	global.APP_LOG.Info("Updating Kubernetes port mapping", zap.Uint("id", req.ID))
+
+	// 获取现有端口映射
+	var portModel provider.Port
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("port mapping not found: %v", err)
+	}
+
+	// 获取实例信息
+	instance, err := k.getInstance(req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance: %v", err)
+	}
+
+	// 获取Provider信息
+	providerInfo, err := k.getProvider(portModel.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+
+	if req.HostPort != portModel.HostPort || req.GuestPort != portModel.GuestPort || req.Protocol != portModel.Protocol {
+		manager, err := k.getNodePortManager(providerInfo.ID)
+		if err != nil {
+			return nil, err
+		}
+		// 删除旧的Service
+		if err := manager.RemovePortMapping(ctx, instance.Name, portModel.HostPort, portModel.Protocol); err != nil {
+			global.APP_LOG.Warn("Failed to remove old NodePort service", zap.Error(err))
+		}
+
+		// 创建新的Service
+		if err := manager.SetupPortMappingWithIP(ctx, instance.Name, req.HostPort, req.GuestPort, req.Protocol, "", ""); err != nil {
+			return nil, fmt.Errorf("failed to create new NodePort service: %v", err)
+		}
+	}
+
+	// 更新数据库记录
+	updates := map[string]interface{}{
+		"host_port":   req.HostPort,
+		"guest_port":  req.GuestPort,
+		"protocol":    req.Protocol,
+		"description": req.Description,
+		"status":      req.Status,
+	}
+
+	if err := global.APP_DB.Model(&portModel).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update port mapping: %v", err)
+	}
+
+	// 重新获取更新后的记录
+	if err := global.APP_DB.First(&portModel, req.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get updated port mapping: %v", err)
+	}
+
+	result := k.BaseProvider.FromDBModel(&portModel)
+	result.HostIP = providerInfo.Endpoint
+	result.PublicIP = k.getPublicIP(providerInfo)
+	result.MappingMethod = "nodeport"
+
+	global.APP_LOG.Info("Kubernetes port mapping updated successfully", zap.Uint("id", req.ID))
+	return result, nil
+}
+
+// ListPortMappings 列出Kubernetes端口映射
+func (k *KubernetesPortMapping) ListPortMappings(ctx context.Context, instanceID string) ([]*portmapping.PortMappingResult, error) {
+	var ports []provider.Port
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list port mappings: %v", err)
+	}
+
+	var results []*portmapping.PortMappingResult
+	for _, port := range ports {
+		result := k.BaseProvider.FromDBModel(&port)
+		result.MappingMethod = "nodeport"
+
+		// 获取Provider信息以填充IP地址
+		if providerInfo, err := k.getProvider(port.ProviderID); err == nil {
+			result.HostIP = providerInfo.Endpoint
+			result.PublicIP = k.getPublicIP(providerInfo)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// validateRequest 验证请求参数
+func (k *KubernetesPortMapping) validateRequest(req *portmapping.PortMappingRequest) error {
+	if req.InstanceID == "" {
+		return fmt.Errorf("instance ID is required")
+	}
+	if req.GuestPort <= 0 || req.GuestPort > 65535 {
+		return fmt.Errorf("invalid guest port: %d", req.GuestPort)
+	}
+	if req.HostPort < 0 || req.HostPort > 65535 {
+		return fmt.Errorf("invalid host port: %d", req.HostPort)
+	}
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	return portmapping.ValidateProtocol(req.Protocol)
+}
+
+// getNodePortManager 获取已连接的Kubernetes Provider实例
+func (k *KubernetesPortMapping) getNodePortManager(providerID uint) (nodePortManager, error) {
+	providerInstance, exists := providerService.GetProviderService().GetProviderByID(providerID)
+	if !exists || !providerInstance.IsConnected() {
+		return nil, fmt.Errorf("Kubernetes provider %d 未连接", providerID)
+	}
+	manager, ok := providerInstance.(nodePortManager)
+	if !ok {
+		return nil, fmt.Errorf("provider %d 不是Kubernetes provider", providerID)
+	}
+	return manager, nil
+}
+
+// getInstance 获取实例信息
+func (k *KubernetesPortMapping) getInstance(instanceID string) (*provider.Instance, error) {
+	var instance provider.Instance
+	id, err := strconv.ParseUint(instanceID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance ID: %s", instanceID)
+	}
+
+	if err := global.APP_DB.First(&instance, uint(id)).Error; err != nil {
+		return nil, fmt.Errorf("instance not found: %v", err)
+	}
+
+	return &instance, nil
+}
+
+// getProvider 获取Provider信息
+func (k *KubernetesPortMapping) getProvider(providerID uint) (*provider.Provider, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.First(&providerInfo, providerID).Error; err != nil {
+		return nil, fmt.Errorf("provider not found: %v", err)
+	}
+	return &providerInfo, nil
+}
+
+// getPublicIP 获取公网IP
+func (k *KubernetesPortMapping) getPublicIP(providerInfo *provider.Provider) string {
+	// 优先使用PortIP（端口映射专用IP），如果为空则使用Endpoint（SSH地址）
+	if providerInfo.PortIP != "" {
+		return providerInfo.PortIP
+	}
+	return providerInfo.Endpoint
+}
+
+// init 注册Kubernetes端口映射Provider
+func init() {
+	portmapping.RegisterProvider("kubernetes", func(config *portmapping.ManagerConfig) portmapping.PortMappingProvider {
+		return NewKubernetesPortMapping(config)
+	})
+}
@@ -120,6 +120,8 @@ func (m *Manager) AutoSelectProvider(instanceType string) string {
 		return "incus"
 	case "pve", "proxmox":
 		return "pve"
+	case "kubernetes":
+		return "kubernetes"
 	default:
 		// 默认使用iptables
 		return "iptables"
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// LXD 和 Incus 共用的项目与配置文件辅助函数
+
+// DefaultProject LXD/Incus 的默认项目
+const DefaultProject = "default"
+
+// lxdObjectNamePattern 项目和配置文件名称格式，名称会拼接到shell命令中，只允许安全字符
+var lxdObjectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// ValidateLXDObjectName 校验项目或配置文件名称
+func ValidateLXDObjectName(kind, name string) error {
+	if !lxdObjectNamePattern.MatchString(name) {
+		return fmt.Errorf("%s名称 %q 不合法，只能包含字母、数字、下划线、点和中划线，且不超过63个字符", kind, name)
+	}
+	return nil
+}
+
+// UsesProject 是否需要切换到非默认项目
+func UsesProject(project string) bool {
+	return project != "" && project != DefaultProject
+}
+
+// ProjectShellInit 返回在指定项目中执行CLI命令的shell初始化语句
+// 定义与CLI同名的函数，为同一条SSH命令中的每次 lxc/incus 调用追加 --project 参数，使用默认项目时返回空字符串
+func ProjectShellInit(cli, project string) string {
+	if !UsesProject(project) {
+		return ""
+	}
+	return fmt.Sprintf(`%s() { command %s --project %s "$@"; }`, cli, cli, project)
+}
+
+// EnsureProjectCommand 返回项目不存在时创建项目的命令
+// 新项目与默认项目共享镜像和配置文件，已有的镜像导入和default配置文件无需在项目中重复准备；已存在的项目保持管理员的配置不变
+func EnsureProjectCommand(cli, project string) string {
+	return fmt.Sprintf("command %s project show %s >/dev/null 2>&1 || command %s project create %s -c features.images=false -c features.profiles=false",
+		cli, project, cli, project)
+}
+
+// InstanceProfiles 合并Provider配置文件和实例追加的配置文件
+// Provider未配置时以default为基础，重复的名称只保留第一次出现的位置
+func InstanceProfiles(nodeProfiles, extra []string) ([]string, error) {
+	base := nodeProfiles
+	if len(base) == 0 {
+		base = []string{"default"}
+	}
+	profiles := make([]string, 0, len(base)+len(extra))
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, base...), extra...) {
+		if seen[name] {
+			continue
+		}
+		if err := ValidateLXDObjectName("配置文件", name); err != nil {
+			return nil, err
+		}
+		seen[name] = true
+		profiles = append(profiles, name)
+	}
+	return profiles, nil
+}
+
+// WithProject 为LXD/Incus API请求追加 project 参数，使用默认项目时直接返回原transport
+func WithProject(base http.RoundTripper, project string) http.RoundTripper {
+	if !UsesProject(project) {
+		return base
+	}
+	return &projectRoundTripper{base: base, project: project}
+}
+
+type projectRoundTripper struct {
+	base    http.RoundTripper
+	project string
+}
+
+func (t *projectRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Query().Get("project") != "" {
+		return t.base.RoundTrip(req)
+	}
+	// RoundTripper不能修改传入的请求，复制后再追加参数
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("project", t.project)
+	req.URL.RawQuery = query.Encode()
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestInstanceProfiles(t *testing.T) {
+	got, err := InstanceProfiles(nil, []string{"gpu", "default"})
+	if err != nil || !reflect.DeepEqual(got, []string{"default", "gpu"}) {
+		t.Fatalf("InstanceProfiles = %v, %v", got, err)
+	}
+	got, err = InstanceProfiles([]string{"ocv-base", "ocv-net"}, []string{"ocv-net", "vip"})
+	if err != nil || !reflect.DeepEqual(got, []string{"ocv-base", "ocv-net", "vip"}) {
+		t.Fatalf("InstanceProfiles = %v, %v", got, err)
+	}
+	if _, err := InstanceProfiles(nil, []string{"bad; rm -rf /"}); err == nil {
+		t.Fatal("InstanceProfiles should reject unsafe names")
+	}
+}
+
+type recordingTransport struct{ url string }
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.url = req.URL.String()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestWithProject(t *testing.T) {
+	base := &recordingTransport{}
+	if WithProject(base, DefaultProject) != http.RoundTripper(base) {
+		t.Fatal("default project should not wrap the transport")
+	}
+
+	client := &http.Client{Transport: WithProject(base, "ocv")}
+	if _, err := client.Get("https://host:8443/1.0/instances?recursion=1"); err != nil {
+		t.Fatal(err)
+	}
+	if base.url != "https://host:8443/1.0/instances?project=ocv&recursion=1" {
+		t.Fatalf("url = %s", base.url)
+	}
+	if _, err := client.Get("https://host:8443/1.0/instances?project=other"); err != nil {
+		t.Fatal(err)
+	}
+	if base.url != "https://host:8443/1.0/instances?project=other" {
+		t.Fatalf("url = %s", base.url)
+	}
+
+	if got := ProjectShellInit("incus", "ocv"); got != `incus() { command incus --project ocv "$@"; }` {
+		t.Fatalf("ProjectShellInit = %s", got)
+	}
+}
@@ -10,9 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 )
@@ -42,10 +44,7 @@ func (p *ProxmoxProvider) apiListInstances(ctx context.Context) ([]provider.Inst
 			if data, ok := vmResponse["data"].([]interface{}); ok {
 				for _, item := range data {
 					if vmData, ok := item.(map[string]interface{}); ok {
-						status := "stopped"
-						if vmData["status"].(string) == "running" {
-							status = "running"
-						}
+						status := constant.NormalizeProviderStatus("proxmox", fmt.Sprint(vmData["status"]))
 
 						instance := provider.Instance{
 							ID:     fmt.Sprintf("%v", vmData["vmid"]),
@@ -88,10 +87,7 @@ func (p *ProxmoxProvider) apiListInstances(ctx context.Context) ([]provider.Inst
 				if data, ok := ctResponse["data"].([]interface{}); ok {
 					for _, item := range data {
 						if ctData, ok := item.(map[string]interface{}); ok {
-							status := "stopped"
-							if ctData["status"].(string) == "running" {
-								status = "running"
-							}
+							status := constant.NormalizeProviderStatus("proxmox", fmt.Sprint(ctData["status"]))
 
 							instance := provider.Instance{
 								ID:     fmt.Sprintf("%v", ctData["vmid"]),
@@ -725,7 +721,7 @@ func (p *ProxmoxProvider) apiSetContainerPassword(ctx context.Context, vmid, pas
 
 	// 构造执行命令的请求体
 	payload := map[string]interface{}{
-		"command": fmt.Sprintf("echo 'root:%s' | chpasswd", password),
+		"command": fmt.Sprintf("echo %s | chpasswd", utils.ShellQuote("root:"+password)),
 	}
 
 	jsonData, err := json.Marshal(payload)
@@ -758,6 +754,17 @@ func (p *ProxmoxProvider) apiSetContainerPassword(ctx context.Context, vmid, pas
 
 // apiSetVMPassword 通过API为QEMU虚拟机设置密码
 func (p *ProxmoxProvider) apiSetVMPassword(ctx context.Context, vmid, password string) error {
+	// Windows 虚拟机优先通过 Guest Agent 设置密码，失败时回退到 cloudbase-init
+	if p.apiIsWindowsVM(ctx, vmid) {
+		err := p.apiSetWindowsPassword(ctx, vmid, password)
+		if err == nil {
+			return nil
+		}
+		global.APP_LOG.Warn("通过Guest Agent设置Windows密码失败，回退到cloudbase-init",
+			zap.String("vmid", vmid),
+			zap.Error(err))
+	}
+
 	// 使用cloud-init设置密码
 	url := fmt.Sprintf("https://%s:8006/api2/json/nodes/%s/qemu/%s/config", p.config.Host, p.node, vmid)
 
@@ -830,7 +837,7 @@ func (p *ProxmoxProvider) apiCreateContainer(ctx context.Context, vmid int, conf
 	localImagePath := filepath.Join("/var/lib/vz/template/cache", fileName)
 
 	// 确保镜像文件存在（通过SSH下载）
-	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", localImagePath)
+	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", utils.ShellQuote(localImagePath))
 	output, err := p.sshClient.Execute(checkCmd)
 	if err != nil {
 		return fmt.Errorf("检查镜像文件失败: %v", err)
@@ -843,7 +850,7 @@ func (p *ProxmoxProvider) apiCreateContainer(ctx context.Context, vmid int, conf
 			return fmt.Errorf("创建缓存目录失败: %v", err)
 		}
 
-		downloadCmd := fmt.Sprintf("curl -L -o %s %s", localImagePath, systemConfig.ImageURL)
+		downloadCmd := fmt.Sprintf("curl -L -o %s %s", utils.ShellQuote(localImagePath), utils.ShellQuote(systemConfig.ImageURL))
 		_, err = p.sshClient.Execute(downloadCmd)
 		if err != nil {
 			return fmt.Errorf("下载镜像失败: %v", err)
@@ -971,7 +978,7 @@ func (p *ProxmoxProvider) apiCreateVM(ctx context.Context, vmid int, config prov
 	localImagePath := fmt.Sprintf("/root/qcow/%s", fileName)
 
 	// 确保镜像文件存在（通过SSH下载）
-	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", localImagePath)
+	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", utils.ShellQuote(localImagePath))
 	output, err := p.sshClient.Execute(checkCmd)
 	if err != nil {
 		return fmt.Errorf("检查镜像文件失败: %v", err)
@@ -984,7 +991,7 @@ func (p *ProxmoxProvider) apiCreateVM(ctx context.Context, vmid int, config prov
 			return fmt.Errorf("创建目录失败: %v", err)
 		}
 
-		downloadCmd := fmt.Sprintf("curl -L -o %s %s", localImagePath, systemConfig.ImageURL)
+		downloadCmd := fmt.Sprintf("curl -L -o %s %s", utils.ShellQuote(localImagePath), utils.ShellQuote(systemConfig.ImageURL))
 		_, err = p.sshClient.Execute(downloadCmd)
 		if err != nil {
 			return fmt.Errorf("下载镜像失败: %v", err)
@@ -1116,7 +1123,7 @@ func (p *ProxmoxProvider) apiCreateVM(ctx context.Context, vmid int, config prov
 		}
 	}
 
-	importCmd := fmt.Sprintf("qm importdisk %d %s %s", vmid, localImagePath, storage)
+	importCmd := fmt.Sprintf("qm importdisk %d %s %s", vmid, utils.ShellQuote(localImagePath), storage)
 	_, err = p.sshClient.Execute(importCmd)
 	if err != nil {
 		return fmt.Errorf("导入磁盘失败: %w", err)
@@ -1213,6 +1220,14 @@ func (p *ProxmoxProvider) apiCreateVM(ctx context.Context, vmid int, config prov
 	userIP := VMIDToInternalIP(vmid)
 	_, _ = p.sshClient.Execute(fmt.Sprintf("qm set %d --ipconfig0 ip=%s/24,gw=%s", vmid, userIP, InternalGateway))
 
+	// Windows 虚拟机需要在首次启动前配置固件、TPM 和驱动ISO
+	if isWindowsImage(&config) {
+		_, _ = p.sshClient.Execute(fmt.Sprintf("qm set %d --ciuser %s", vmid, constant.WindowsAdminUser))
+		if err := p.configureWindowsVM(vmid, storage, parseWindowsVMOptions(&config)); err != nil {
+			return err
+		}
+	}
+
 	updateProgress(80, "虚拟机配置完成...")
 
 	// 启动虚拟机（通过API创建后不会自动启动）
@@ -1223,6 +1238,7 @@ func (p *ProxmoxProvider) apiCreateVM(ctx context.Context, vmid int, config prov
 		global.APP_LOG.Warn("启动虚拟机失败", zap.Int("vmid", vmid), zap.Error(err))
 		// 不返回错误，继续流程
 	} else {
+		p.captureBootConsole(strconv.Itoa(vmid))
 		updateProgress(90, "等待虚拟机启动...")
 
 		// 等待虚拟机状态变为running
@@ -0,0 +1,146 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 宿主机上保存串口控制台日志的目录，每个VM一个文件
+	consoleLogDir = "/var/log/oneclickvirt/console"
+	// 单个控制台日志文件的最大大小，超过后轮转
+	consoleLogMaxBytes = 1024 * 1024
+	// 保留的历史轮转文件数量
+	consoleLogBackups = 3
+	// 默认抓取时长，覆盖系统启动阶段
+	defaultConsoleCaptureDuration = 10 * time.Minute
+	// 单次读取日志的最大字节数
+	maxConsoleLogReadBytes = 512 * 1024
+)
+
+// consoleLogPath 返回VM控制台日志在宿主机上的路径
+func consoleLogPath(vmid string) string {
+	return fmt.Sprintf("%s/%s.log", consoleLogDir, vmid)
+}
+
+// buildConsoleCaptureScript 构建启动串口抓取的脚本
+// VM 创建时已配置 --serial0 socket，对应 /var/run/qemu-server/<vmid>.serial0
+// 抓取前按大小轮转日志，并结束同一VM上仍在运行的旧抓取进程
+func buildConsoleCaptureScript(vmid string, duration time.Duration) string {
+	logFile := consoleLogPath(vmid)
+	socket := fmt.Sprintf("/var/run/qemu-server/%s.serial0", vmid)
+	seconds := int(duration.Seconds())
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("mkdir -p %s; ", consoleLogDir))
+	sb.WriteString("command -v socat >/dev/null 2>&1 || DEBIAN_FRONTEND=noninteractive apt-get install -y socat >/dev/null 2>&1; ")
+	sb.WriteString("command -v socat >/dev/null 2>&1 || { echo 'socat_missing'; exit 0; }; ")
+	sb.WriteString(fmt.Sprintf("[ -S %s ] || { echo 'socket_missing'; exit 0; }; ", socket))
+	sb.WriteString(fmt.Sprintf("if [ -f %s ] && [ $(stat -c %%s %s) -gt %d ]; then ", logFile, logFile, consoleLogMaxBytes))
+	for i := consoleLogBackups - 1; i >= 1; i-- {
+		sb.WriteString(fmt.Sprintf("[ -f %s.%d ] && mv -f %s.%d %s.%d; ", logFile, i, logFile, i, logFile, i+1))
+	}
+	sb.WriteString(fmt.Sprintf("mv -f %s %s.1; fi; ", logFile, logFile))
+	sb.WriteString(fmt.Sprintf("pkill -f 'UNIX-CONNECT:%s' >/dev/null 2>&1; ", socket))
+	sb.WriteString(fmt.Sprintf("echo \"=== console capture started at $(date '+%%Y-%%m-%%d %%H:%%M:%%S') ===\" >> %s; ", logFile))
+	sb.WriteString(fmt.Sprintf("setsid nohup timeout %d socat -u UNIX-CONNECT:%s - >> %s 2>/dev/null </dev/null & ", seconds, socket, logFile))
+	sb.WriteString("echo 'capture_started'")
+	return sb.String()
+}
+
+// CaptureConsoleLog 开始抓取VM串口控制台输出到宿主机日志文件
+// 仅支持虚拟机，抓取在 duration 后自动结束
+func (p *ProxmoxProvider) CaptureConsoleLog(ctx context.Context, instanceName string, duration time.Duration) error {
+	if !p.connected || p.sshClient == nil {
+		return fmt.Errorf("Provider未连接或SSH不可用")
+	}
+	if duration <= 0 {
+		duration = defaultConsoleCaptureDuration
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
+	if err != nil {
+		return fmt.Errorf("查找实例失败: %v", err)
+	}
+	if instanceType != "vm" {
+		return fmt.Errorf("仅虚拟机支持串口控制台日志")
+	}
+	return p.startConsoleCapture(vmid, duration)
+}
+
+// startConsoleCapture 按VMID启动串口抓取
+func (p *ProxmoxProvider) startConsoleCapture(vmid string, duration time.Duration) error {
+	output, err := p.sshClient.Execute(buildConsoleCaptureScript(vmid, duration))
+	if err != nil {
+		return fmt.Errorf("启动控制台日志抓取失败: %v", err)
+	}
+	switch {
+	case strings.Contains(output, "socat_missing"):
+		return fmt.Errorf("宿主机缺少socat，无法抓取控制台日志")
+	case strings.Contains(output, "socket_missing"):
+		return fmt.Errorf("虚拟机串口socket不存在，请确认虚拟机已启动且配置了serial0")
+	}
+
+	global.APP_LOG.Info("已开始抓取虚拟机控制台日志",
+		zap.String("vmid", vmid),
+		zap.Duration("duration", duration))
+	return nil
+}
+
+// captureBootConsole 在虚拟机启动后尽力开始抓取控制台日志，失败只记录日志
+func (p *ProxmoxProvider) captureBootConsole(vmid string) {
+	if p.sshClient == nil {
+		return
+	}
+	if err := p.startConsoleCapture(vmid, defaultConsoleCaptureDuration); err != nil {
+		global.APP_LOG.Debug("抓取虚拟机启动日志失败",
+			zap.String("vmid", vmid),
+			zap.Error(err))
+	}
+}
+
+// captureBootConsoleByName 按实例名称查找VMID后抓取启动日志，容器会被忽略
+func (p *ProxmoxProvider) captureBootConsoleByName(ctx context.Context, instanceName string) {
+	if p.sshClient == nil {
+		return
+	}
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
+	if err != nil || instanceType != "vm" {
+		return
+	}
+	p.captureBootConsole(vmid)
+}
+
+// GetConsoleLog 读取VM控制台日志的最后 maxBytes 字节
+func (p *ProxmoxProvider) GetConsoleLog(ctx context.Context, instanceName string, maxBytes int) (string, error) {
+	if !p.connected || p.sshClient == nil {
+		return "", fmt.Errorf("Provider未连接或SSH不可用")
+	}
+	if maxBytes <= 0 || maxBytes > maxConsoleLogReadBytes {
+		maxBytes = maxConsoleLogReadBytes
+	}
+
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceName)
+	if err != nil {
+		return "", fmt.Errorf("查找实例失败: %v", err)
+	}
+	if instanceType != "vm" {
+		return "", fmt.Errorf("仅虚拟机支持串口控制台日志")
+	}
+
+	// 当前文件不足时拼接上一个轮转文件，保证能返回足够的上下文
+	logFile := consoleLogPath(vmid)
+	cmd := fmt.Sprintf("cat %s.1 %s 2>/dev/null | tail -c %s", logFile, logFile, strconv.Itoa(maxBytes))
+	output, err := p.sshClient.Execute(cmd)
+	if err != nil {
+		return "", fmt.Errorf("读取控制台日志失败: %v", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,30 @@
+package proxmox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/utils"
+)
+
+func TestBuildConsoleCaptureScript(t *testing.T) {
+	script := buildConsoleCaptureScript("105", 10*time.Minute)
+	for _, want := range []string{
+		"[ -S /var/run/qemu-server/105.serial0 ]",
+		"timeout 600 socat -u UNIX-CONNECT:/var/run/qemu-server/105.serial0 - >> /var/log/oneclickvirt/console/105.log",
+		// 轮转从最旧的文件开始，避免覆盖
+		"mv -f /var/log/oneclickvirt/console/105.log.2 /var/log/oneclickvirt/console/105.log.3; [ -f /var/log/oneclickvirt/console/105.log.1 ]",
+		"pkill -f 'UNIX-CONNECT:/var/run/qemu-server/105.serial0'",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("脚本缺少 %q:\n%s", want, script)
+		}
+	}
+
+	// 脚本在启用命令白名单拦截模式的宿主机上也能执行
+	violations, err := utils.NewCommandGuard("test", "block", nil, nil).Violations(script)
+	if err != nil || len(violations) > 0 {
+		t.Errorf("抓取脚本被命令白名单拦截: %v %v", violations, err)
+	}
+}
@@ -3,6 +3,7 @@ package proxmox
 import (
 	"context"
 	"fmt"
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
@@ -139,7 +140,7 @@ func (p *ProxmoxProvider) createContainer(ctx context.Context, vmid int, config
 	localImagePath := filepath.Join("/var/lib/vz/template/cache", fileName)
 
 	// 检查镜像是否已存在，不存在则下载
-	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", localImagePath)
+	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", utils.ShellQuote(localImagePath))
 	output, err := p.sshClient.Execute(checkCmd)
 	if err != nil {
 		return fmt.Errorf("检查镜像文件失败: %v", err)
@@ -153,21 +154,22 @@ func (p *ProxmoxProvider) createContainer(ctx context.Context, vmid int, config
 			return fmt.Errorf("创建缓存目录失败: %v", err)
 		}
 
-		// 确定下载URL（支持CDN）
-		downloadURL := p.getDownloadURL(systemConfig.ImageURL, config.UseCDN)
+		// 确定候选下载来源（镜像源、CDN、原始地址），下载失败时轮换来源
+		sources := p.getDownloadSources(systemConfig.ImageURL, config.UseCDN)
 		global.APP_LOG.Info("下载容器镜像",
-			zap.String("downloadURL", utils.TruncateString(downloadURL, 100)),
+			zap.String("imageURL", utils.TruncateString(systemConfig.ImageURL, 100)),
+			zap.Int("sources", len(sources)),
 			zap.Bool("useCDN", config.UseCDN))
 
-		// 下载镜像文件
-		downloadCmd := fmt.Sprintf("curl -L -o %s %s", localImagePath, downloadURL)
-		_, err = p.sshClient.Execute(downloadCmd)
+		// 下载镜像文件，支持断点续传
+		source, err := utils.DownloadToRemote(p.sshClient, sources, localImagePath)
 		if err != nil {
 			return fmt.Errorf("下载镜像失败: %v", err)
 		}
+		utils.RecordImageSource(p.config.ID, source.Base)
 		global.APP_LOG.Info("容器镜像下载完成",
 			zap.String("image_path", localImagePath),
-			zap.String("url", downloadURL))
+			zap.String("url", utils.TruncateString(source.URL, 100)))
 	}
 
 	updateProgress(50, "创建LXC容器...")
@@ -266,7 +268,7 @@ func (p *ProxmoxProvider) createContainer(ctx context.Context, vmid int, config
 	updateProgress(85, "配置容器SSH...")
 
 	// 配置SSH
-	p.configureContainerSSH(ctx, vmid)
+	p.configureContainerSSH(ctx, vmid, provider.GuestSSHPortFromConfig(config))
 
 	return nil
 }
@@ -291,7 +293,7 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 	localImagePath := fmt.Sprintf("/root/qcow/%s", fileName)
 
 	// 检查镜像是否已存在，不存在则下载
-	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", localImagePath)
+	checkCmd := fmt.Sprintf("[ -f %s ] && echo 'exists' || echo 'missing'", utils.ShellQuote(localImagePath))
 	output, err := p.sshClient.Execute(checkCmd)
 	if err != nil {
 		return fmt.Errorf("检查镜像文件失败: %v", err)
@@ -305,18 +307,19 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 			return fmt.Errorf("创建qcow目录失败: %v", err)
 		}
 
-		// 确定下载URL（支持CDN）
-		downloadURL := p.getDownloadURL(systemConfig.ImageURL, config.UseCDN)
+		// 确定候选下载来源（镜像源、CDN、原始地址），下载失败时轮换来源
+		sources := p.getDownloadSources(systemConfig.ImageURL, config.UseCDN)
 		global.APP_LOG.Info("下载虚拟机镜像",
-			zap.String("downloadURL", utils.TruncateString(downloadURL, 100)),
+			zap.String("imageURL", utils.TruncateString(systemConfig.ImageURL, 100)),
+			zap.Int("sources", len(sources)),
 			zap.Bool("useCDN", config.UseCDN))
 
-		// 下载镜像文件
-		downloadCmd := fmt.Sprintf("curl -L -o %s %s", localImagePath, downloadURL)
-		_, err = p.sshClient.Execute(downloadCmd)
+		// 下载镜像文件，支持断点续传
+		source, err := utils.DownloadToRemote(p.sshClient, sources, localImagePath)
 		if err != nil {
 			return fmt.Errorf("下载镜像失败: %v", err)
 		}
+		utils.RecordImageSource(p.config.ID, source.Base)
 		global.APP_LOG.Info("虚拟机镜像下载完成",
 			zap.String("image_path", localImagePath),
 			zap.String("url", systemConfig.ImageURL))
@@ -499,10 +502,10 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 		if err != nil {
 			return fmt.Errorf("设置ARM BIOS失败: %v", err)
 		}
-		importCmd = fmt.Sprintf("qm importdisk %d %s %s", vmid, localImagePath, storage)
+		importCmd = fmt.Sprintf("qm importdisk %d %s %s", vmid, utils.ShellQuote(localImagePath), storage)
 	} else {
 		// x86/x64架构
-		importCmd = fmt.Sprintf("qm importdisk %d %s %s", vmid, localImagePath, storage)
+		importCmd = fmt.Sprintf("qm importdisk %d %s %s", vmid, utils.ShellQuote(localImagePath), storage)
 	}
 
 	_, err = p.sshClient.Execute(importCmd)
@@ -675,19 +678,31 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 		password = utils.GenerateInstancePassword()
 	}
 
-	_, err = p.sshClient.Execute(fmt.Sprintf("qm set %d --cipassword %s --ciuser root", vmid, password))
+	// Windows 镜像通过 cloudbase-init 设置 Administrator 密码
+	ciUser := constant.LinuxRootUser
+	if isWindowsImage(&config) {
+		ciUser = constant.WindowsAdminUser
+	}
+	_, err = p.sshClient.Execute(fmt.Sprintf("qm set %d --cipassword %s --ciuser %s", vmid, utils.ShellQuote(password), ciUser))
 	if err != nil {
 		global.APP_LOG.Warn("设置用户密码失败", zap.Int("vmid", vmid), zap.Error(err))
 	}
 
 	// 设置虚拟机名称，以便后续能够通过名称查找
-	_, err = p.sshClient.Execute(fmt.Sprintf("qm set %d --name %s", vmid, config.Name))
+	_, err = p.sshClient.Execute(fmt.Sprintf("qm set %d --name %s", vmid, utils.ShellQuote(config.Name)))
 	if err != nil {
 		global.APP_LOG.Warn("设置虚拟机名称失败", zap.Int("vmid", vmid), zap.String("name", config.Name), zap.Error(err))
 	} else {
 		global.APP_LOG.Info("虚拟机名称设置成功", zap.Int("vmid", vmid), zap.String("name", config.Name))
 	}
 
+	// Windows 虚拟机需要在首次启动前配置固件、TPM 和驱动ISO
+	if isWindowsImage(&config) {
+		if err := p.configureWindowsVM(vmid, storage, parseWindowsVMOptions(&config)); err != nil {
+			return err
+		}
+	}
+
 	updateProgress(95, "启动虚拟机...")
 
 	// 启动虚拟机（参考脚本）
@@ -695,6 +710,7 @@ func (p *ProxmoxProvider) createVM(ctx context.Context, vmid int, config provide
 	if err != nil {
 		return fmt.Errorf("启动虚拟机失败: %v", err)
 	}
+	p.captureBootConsole(strconv.Itoa(vmid))
 
 	updateProgress(96, "等待虚拟机启动...")
 
@@ -4,8 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/provider"
 
@@ -283,16 +283,7 @@ func (p *ProxmoxProvider) parsePctConfigs(output string) ([]provider.DiscoveredI
 }
 
 func (p *ProxmoxProvider) mapProxmoxStatus(status string) string {
-	switch strings.ToLower(status) {
-	case "running":
-		return "running"
-	case "stopped":
-		return "stopped"
-	case "paused":
-		return "paused"
-	default:
-		return status
-	}
+	return constant.NormalizeProviderStatus("proxmox", status)
 }
 
 func (p *ProxmoxProvider) mapProxmoxType(proxmoxType string) string {
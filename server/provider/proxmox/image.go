@@ -10,6 +10,7 @@ import (
 	"oneclickvirt/global"
 	systemModel "oneclickvirt/model/system"
 	"oneclickvirt/provider"
+	"oneclickvirt/service/images"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -124,14 +125,14 @@ func (p *ProxmoxProvider) extractImageName(imageURL string) string {
 // imageExists 检查镜像是否已存在
 func (p *ProxmoxProvider) imageExists(imageName string) bool {
 	// 检查ISO目录
-	checkCmd := fmt.Sprintf("ls /var/lib/vz/template/iso/ | grep -i %s", imageName)
+	checkCmd := fmt.Sprintf("ls /var/lib/vz/template/iso/ | grep -i %s", utils.ShellQuote(imageName))
 	output, err := p.sshClient.Execute(checkCmd)
 	if err == nil && strings.TrimSpace(output) != "" {
 		return true
 	}
 
 	// 检查cache目录
-	checkCmd = fmt.Sprintf("ls /var/lib/vz/template/cache/ | grep -i %s", imageName)
+	checkCmd = fmt.Sprintf("ls /var/lib/vz/template/cache/ | grep -i %s", utils.ShellQuote(imageName))
 	output, err = p.sshClient.Execute(checkCmd)
 	if err == nil && strings.TrimSpace(output) != "" {
 		return true
@@ -151,7 +152,7 @@ func (p *ProxmoxProvider) downloadImageToRemote(ctx context.Context, imageURL, i
 	}
 
 	// 确保目录存在
-	_, err := p.sshClient.Execute(fmt.Sprintf("mkdir -p %s", targetDir))
+	_, err := p.sshClient.Execute(fmt.Sprintf("mkdir -p %s", utils.ShellQuote(targetDir)))
 	if err != nil {
 		return "", fmt.Errorf("创建目录失败: %w", err)
 	}
@@ -159,7 +160,7 @@ func (p *ProxmoxProvider) downloadImageToRemote(ctx context.Context, imageURL, i
 	remotePath := fmt.Sprintf("%s/%s", targetDir, imageName)
 
 	// 检查文件是否已存在
-	checkCmd := fmt.Sprintf("test -f %s && echo 'exists'", remotePath)
+	checkCmd := fmt.Sprintf("test -f %s && echo 'exists'", utils.ShellQuote(remotePath))
 	output, _ := p.sshClient.Execute(checkCmd)
 	if strings.TrimSpace(output) == "exists" {
 		global.APP_LOG.Info("镜像文件已存在", zap.String("path", remotePath))
@@ -178,50 +179,14 @@ func (p *ProxmoxProvider) downloadImageToRemote(ctx context.Context, imageURL, i
 	return remotePath, nil
 }
 
-// downloadFileToRemote 在远程服务器上下载文件
+// downloadFileToRemote 在远程服务器上下载镜像文件，依次尝试Provider镜像源和原始地址，支持断点续传
 func (p *ProxmoxProvider) downloadFileToRemote(url, remotePath string) error {
-	tmpPath := remotePath + ".tmp"
-
-	// 下载文件，支持断点续传，优先使用wget，失败则使用curl
-	downloadCmds := []string{
-		fmt.Sprintf("wget --no-check-certificate -c -O %s '%s'", tmpPath, url),
-		fmt.Sprintf("curl -4 -L -C - --connect-timeout 30 --retry 5 --retry-delay 10 --retry-max-time 0 -o %s '%s'", tmpPath, url),
-	}
-
-	var lastErr error
-	for _, cmd := range downloadCmds {
-		global.APP_LOG.Info("执行下载命令",
-			zap.String("url", utils.TruncateString(url, 100)))
-
-		output, err := p.sshClient.Execute(cmd)
-		if err == nil {
-			// 下载成功，移动文件到最终位置
-			mvCmd := fmt.Sprintf("mv %s %s", tmpPath, remotePath)
-			_, err = p.sshClient.Execute(mvCmd)
-			if err != nil {
-				global.APP_LOG.Error("移动文件失败",
-					zap.String("tmpPath", tmpPath),
-					zap.String("remotePath", remotePath),
-					zap.Error(err))
-				return fmt.Errorf("移动文件失败: %w", err)
-			}
-
-			global.APP_LOG.Info("下载成功",
-				zap.String("url", utils.TruncateString(url, 100)),
-				zap.String("remotePath", remotePath))
-			return nil
-		}
-
-		lastErr = err
-		global.APP_LOG.Warn("下载命令失败，尝试下一个",
-			zap.String("output", utils.TruncateString(output, 500)),
-			zap.Error(err))
-
-		// 清理临时文件
-		p.sshClient.Execute(fmt.Sprintf("rm -f %s", tmpPath))
+	source, err := utils.DownloadToRemote(p.sshClient, p.getDownloadSources(url, false), remotePath)
+	if err != nil {
+		return err
 	}
-
-	return fmt.Errorf("所有下载方式都失败: %w", lastErr)
+	utils.RecordImageSource(p.config.ID, source.Base)
+	return nil
 }
 
 func (p *ProxmoxProvider) DeleteImage(ctx context.Context, id string) error {
@@ -292,7 +257,7 @@ func (p *ProxmoxProvider) prepareImage(ctx context.Context, imageName, instanceT
 			zap.String("image", imageName))
 
 		// 检查VM ISO文件是否存在
-		checkCmd := fmt.Sprintf("ls /var/lib/vz/template/iso/ | grep -i %s", imageName)
+		checkCmd := fmt.Sprintf("ls /var/lib/vz/template/iso/ | grep -i %s", utils.ShellQuote(imageName))
 
 		output, err := p.sshClient.Execute(checkCmd)
 		if err != nil || strings.TrimSpace(output) == "" {
@@ -438,14 +403,14 @@ func (p *ProxmoxProvider) generateRemoteFileName(imageName, imageURL, architectu
 // isRemoteFileValid 检查远程文件是否存在且完整
 func (p *ProxmoxProvider) isRemoteFileValid(remotePath string) bool {
 	// 检查文件是否存在且大小大于0
-	cmd := fmt.Sprintf("test -f %s -a -s %s", remotePath, remotePath)
+	cmd := fmt.Sprintf("test -f %s -a -s %s", utils.ShellQuote(remotePath), utils.ShellQuote(remotePath))
 	_, err := p.sshClient.Execute(cmd)
 	return err == nil
 }
 
 // removeRemoteFile 删除远程文件
 func (p *ProxmoxProvider) removeRemoteFile(remotePath string) error {
-	_, err := p.sshClient.Execute(fmt.Sprintf("rm -f %s", remotePath))
+	_, err := p.sshClient.Execute(fmt.Sprintf("rm -f %s", utils.ShellQuote(remotePath)))
 	return err
 }
 
@@ -480,11 +445,26 @@ func (p *ProxmoxProvider) queryAndSetSystemImage(ctx context.Context, config *pr
 	// 优先获取启用状态的镜像
 	query = query.Where("status = ?", "active").Order("created_at DESC")
 
-	err := query.First(&systemImage).Error
-	if err != nil {
+	var candidates []systemModel.SystemImage
+	if err := query.Find(&candidates).Error; err != nil {
 		return fmt.Errorf("未找到匹配的系统镜像: %w", err)
 	}
 
+	// 排除被镜像禁用策略命中的镜像（节点侧无法得知用户等级，仅应用对所有用户生效的策略）
+	imageService := &images.ImageService{}
+	candidates = imageService.FilterBannedImages(candidates, 0, p.config.ID)
+	if len(candidates) == 0 {
+		return fmt.Errorf("未找到匹配的系统镜像: 无可用镜像或镜像已被禁用")
+	}
+	systemImage = candidates[0]
+
+	// 记录镜像的操作系统信息，供 Windows 等特殊系统的创建流程使用
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]string)
+	}
+	config.Metadata["os_type"] = systemImage.OSType
+	config.Metadata["image_tags"] = systemImage.Tags
+
 	// 设置镜像配置，不在这里添加CDN前缀
 	// CDN前缀应该在实际下载时根据可用性和UseCDN设置动态添加
 	if systemImage.URL != "" {
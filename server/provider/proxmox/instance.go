@@ -108,6 +108,7 @@ func (p *ProxmoxProvider) StartInstance(ctx context.Context, id string) error {
 		err := p.apiStartInstance(ctx, id)
 		if err == nil {
 			global.APP_LOG.Info("Proxmox API调用成功 - 启动实例", zap.String("id", utils.TruncateString(id, 50)))
+			p.captureBootConsoleByName(ctx, id)
 			return nil
 		}
 		global.APP_LOG.Warn("Proxmox API失败 - 启动实例", zap.String("id", utils.TruncateString(id, 50)), zap.Error(err))
@@ -124,7 +125,11 @@ func (p *ProxmoxProvider) StartInstance(ctx context.Context, id string) error {
 		return fmt.Errorf("执行规则不允许使用SSH")
 	}
 
-	return p.sshStartInstance(ctx, id)
+	if err := p.sshStartInstance(ctx, id); err != nil {
+		return err
+	}
+	p.captureBootConsoleByName(ctx, id)
+	return nil
 }
 
 func (p *ProxmoxProvider) StopInstance(ctx context.Context, id string) error {
@@ -166,6 +171,7 @@ func (p *ProxmoxProvider) RestartInstance(ctx context.Context, id string) error
 		err := p.apiRestartInstance(ctx, id)
 		if err == nil {
 			global.APP_LOG.Info("Proxmox API调用成功 - 重启实例", zap.String("id", utils.TruncateString(id, 50)))
+			p.captureBootConsoleByName(ctx, id)
 			return nil
 		}
 		global.APP_LOG.Warn("Proxmox API失败 - 重启实例", zap.String("id", utils.TruncateString(id, 50)), zap.Error(err))
@@ -182,7 +188,11 @@ func (p *ProxmoxProvider) RestartInstance(ctx context.Context, id string) error
 		return fmt.Errorf("执行规则不允许使用SSH")
 	}
 
-	return p.sshRestartInstance(ctx, id)
+	if err := p.sshRestartInstance(ctx, id); err != nil {
+		return err
+	}
+	p.captureBootConsoleByName(ctx, id)
+	return nil
 }
 
 func (p *ProxmoxProvider) DeleteInstance(ctx context.Context, id string) error {
@@ -579,7 +579,7 @@ func (p *ProxmoxProvider) GetInstancePublicIPv6(ctx context.Context, instanceNam
 	}
 
 	// 尝试从保存的IPv6文件中读取公网IPv6地址
-	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", instanceName)
+	publicIPv6Cmd := fmt.Sprintf("cat %s_v6 2>/dev/null | tail -1", utils.ShellQuote(instanceName))
 	publicIPv6Output, err := p.sshClient.Execute(publicIPv6Cmd)
 	if err == nil {
 		publicIPv6 := utils.CleanCommandOutput(publicIPv6Output)
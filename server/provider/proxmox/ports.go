@@ -10,6 +10,7 @@ import (
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 )
@@ -331,8 +332,8 @@ func (p *ProxmoxProvider) setupIptablesMappingWithIP(ctx context.Context, instan
 	}
 
 	// DNAT规则 - 将外部请求转发到内部实例
-	dnatCmd := fmt.Sprintf("iptables -t nat -A PREROUTING -i vmbr0 -p %s --dport %d -j DNAT --to-destination %s:%d",
-		protocol, hostPort, cleanInstanceIP, guestPort)
+	dnatCmd := utils.DNATAddCommand(p.publicInterface(), fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s:%d",
+		protocol, hostPort, cleanInstanceIP, guestPort))
 
 	_, err := p.sshClient.Execute(dnatCmd)
 	if err != nil {
@@ -399,8 +400,9 @@ func (p *ProxmoxProvider) removeIptablesMapping(ctx context.Context, instanceNam
 	}
 
 	// 移除DNAT规则
-	dnatCmd := fmt.Sprintf("iptables -t nat -D PREROUTING -i vmbr0 -p %s --dport %d -j DNAT --to-destination %s",
-		protocol, hostPort, cleanInstanceIP)
+	// 同时尝试vmbr0，兼容配置公网网卡之前创建的规则
+	dnatCmd := utils.DNATDeleteCommand(fmt.Sprintf("-p %s --dport %d -j DNAT --to-destination %s",
+		protocol, hostPort, cleanInstanceIP), p.publicInterface(), "vmbr0")
 
 	_, err = p.sshClient.Execute(dnatCmd)
 	if err != nil {
@@ -506,3 +508,11 @@ func (p *ProxmoxProvider) getInstancePrivateIP(ctx context.Context, instanceName
 func (p *ProxmoxProvider) SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error {
 	return p.setupPortMappingWithIP(ctx, instanceName, hostPort, guestPort, protocol, method, instanceIP)
 }
+
+// publicInterface 返回端口映射DNAT规则的入口网卡，未配置时使用默认网桥vmbr0
+func (p *ProxmoxProvider) publicInterface() string {
+	if p.config.PublicInterface != "" {
+		return p.config.PublicInterface
+	}
+	return "vmbr0"
+}
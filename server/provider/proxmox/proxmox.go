@@ -158,6 +158,7 @@ func (p *ProxmoxProvider) Connect(ctx context.Context, config provider.NodeConfi
 		PrivateKey:     config.PrivateKey,
 		ConnectTimeout: time.Duration(sshConnectTimeout) * time.Second,
 		ExecuteTimeout: time.Duration(sshExecuteTimeout) * time.Second,
+		Guard:          utils.NewCommandGuard(config.Name, config.CommandGuardMode, config.CommandAllowlist, config.CommandDenylist),
 	}
 
 	client, err := utils.NewSSHClient(sshConfig)
@@ -432,14 +433,14 @@ func (p *ProxmoxProvider) parseInstanceInfo(ctx context.Context, instanceName st
 
 	// 如果数据库查询失败，尝试通过SSH命令查询
 	// 先检查是否是容器
-	checkContainerCmd := fmt.Sprintf("pct list | grep -w '%s' | awk '{print $1}'", instanceName)
+	checkContainerCmd := fmt.Sprintf("pct list | grep -w %s | awk '{print $1}'", utils.ShellQuote(instanceName))
 	output, err := p.sshClient.Execute(checkContainerCmd)
 	if err == nil && strings.TrimSpace(output) != "" {
 		return strings.TrimSpace(output), "container", nil
 	}
 
 	// 再检查是否是虚拟机
-	checkVMCmd := fmt.Sprintf("qm list | grep -w '%s' | awk '{print $1}'", instanceName)
+	checkVMCmd := fmt.Sprintf("qm list | grep -w %s | awk '{print $1}'", utils.ShellQuote(instanceName))
 	output, err = p.sshClient.Execute(checkVMCmd)
 	if err == nil && strings.TrimSpace(output) != "" {
 		return strings.TrimSpace(output), "vm", nil
@@ -0,0 +1,78 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// snapshotCommand 按实例类型选择 qm 或 pct
+func snapshotCommand(instanceType string) (string, error) {
+	switch instanceType {
+	case "vm":
+		return "qm", nil
+	case "container":
+		return "pct", nil
+	default:
+		return "", fmt.Errorf("unknown instance type: %s", instanceType)
+	}
+}
+
+// CreateSnapshot 为实例创建快照
+func (p *ProxmoxProvider) CreateSnapshot(ctx context.Context, instanceID, name string) error {
+	if !p.connected || p.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if !provider.ValidSnapshotName(name) {
+		return fmt.Errorf("无效的快照名: %s", name)
+	}
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+	command, err := snapshotCommand(instanceType)
+	if err != nil {
+		return err
+	}
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s snapshot %s %s", command, vmid, name))
+	if err != nil {
+		return fmt.Errorf("创建快照失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	global.APP_LOG.Info("Proxmox实例快照创建成功",
+		zap.String("id", utils.TruncateString(instanceID, 50)),
+		zap.String("vmid", vmid),
+		zap.String("snapshot", name))
+	return nil
+}
+
+// DeleteSnapshot 删除实例快照，快照不存在时视为成功
+func (p *ProxmoxProvider) DeleteSnapshot(ctx context.Context, instanceID, name string) error {
+	if !p.connected || p.sshClient == nil {
+		return fmt.Errorf("provider not connected")
+	}
+	if !provider.ValidSnapshotName(name) {
+		return fmt.Errorf("无效的快照名: %s", name)
+	}
+	vmid, instanceType, err := p.findVMIDByNameOrID(ctx, instanceID)
+	if err != nil {
+		return fmt.Errorf("failed to find instance %s: %w", instanceID, err)
+	}
+	command, err := snapshotCommand(instanceType)
+	if err != nil {
+		return err
+	}
+	output, err := p.sshClient.Execute(fmt.Sprintf("%s delsnapshot %s %s", command, vmid, name))
+	if err != nil {
+		if strings.Contains(output, "does not exist") {
+			return nil
+		}
+		return fmt.Errorf("删除快照失败: %w, %s", err, utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
@@ -33,10 +34,7 @@ func (p *ProxmoxProvider) sshListInstances(ctx context.Context) ([]provider.Inst
 					continue
 				}
 
-				status := "stopped"
-				if len(fields) > 2 && fields[2] == "running" {
-					status = "running"
-				}
+				status := constant.NormalizeProviderStatus("proxmox", fields[2])
 
 				instance := provider.Instance{
 					ID:     fields[0],
@@ -73,15 +71,10 @@ func (p *ProxmoxProvider) sshListInstances(ctx context.Context) ([]provider.Inst
 					continue
 				}
 
-				status := "stopped"
 				name := ""
 
 				// pct list 格式: VMID Status [Lock] [Name]
-				if len(fields) >= 2 {
-					if fields[1] == "running" {
-						status = "running"
-					}
-				}
+				status := constant.NormalizeProviderStatus("proxmox", fields[1])
 
 				// Name字段可能在不同位置，取最后一个非空字段作为名称
 				if len(fields) >= 4 {
@@ -542,7 +535,7 @@ func (p *ProxmoxProvider) sshPullImageToPath(ctx context.Context, imageURL, imag
 		zap.String("remotePath", remotePath))
 
 	// 检查文件是否已存在
-	checkCmd := fmt.Sprintf("test -f %s && echo 'exists'", remotePath)
+	checkCmd := fmt.Sprintf("test -f %s && echo 'exists'", utils.ShellQuote(remotePath))
 	output, _ := p.sshClient.Execute(checkCmd)
 	if strings.TrimSpace(output) == "exists" {
 		global.APP_LOG.Info("镜像已存在，跳过下载", zap.String("path", remotePath))
@@ -550,11 +543,11 @@ func (p *ProxmoxProvider) sshPullImageToPath(ctx context.Context, imageURL, imag
 	}
 
 	// 下载镜像
-	downloadCmd := fmt.Sprintf("wget --no-check-certificate -O %s %s", remotePath, imageURL)
+	downloadCmd := fmt.Sprintf("wget --no-check-certificate -O %s %s", utils.ShellQuote(remotePath), utils.ShellQuote(imageURL))
 	_, err = p.sshClient.Execute(downloadCmd)
 	if err != nil {
 		// 尝试使用curl下载
-		downloadCmd = fmt.Sprintf("curl -L -k -o %s %s", remotePath, imageURL)
+		downloadCmd = fmt.Sprintf("curl -L -k -o %s %s", utils.ShellQuote(remotePath), utils.ShellQuote(imageURL))
 		_, err = p.sshClient.Execute(downloadCmd)
 		if err != nil {
 			return "", fmt.Errorf("下载镜像失败: %w", err)
@@ -567,7 +560,7 @@ func (p *ProxmoxProvider) sshPullImageToPath(ctx context.Context, imageURL, imag
 	if strings.HasSuffix(fileName, ".iso") {
 		// ISO文件移动到ISO目录
 		isoPath := fmt.Sprintf("/var/lib/vz/template/iso/%s", fileName)
-		moveCmd := fmt.Sprintf("mv %s %s", remotePath, isoPath)
+		moveCmd := fmt.Sprintf("mv %s %s", utils.ShellQuote(remotePath), utils.ShellQuote(isoPath))
 		_, err = p.sshClient.Execute(moveCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移动ISO文件失败", zap.Error(err))
@@ -577,7 +570,7 @@ func (p *ProxmoxProvider) sshPullImageToPath(ctx context.Context, imageURL, imag
 	} else {
 		// 其他文件可能是LXC模板，移动到cache目录
 		cachePath := fmt.Sprintf("/var/lib/vz/template/cache/%s", fileName)
-		moveCmd := fmt.Sprintf("mv %s %s", remotePath, cachePath)
+		moveCmd := fmt.Sprintf("mv %s %s", utils.ShellQuote(remotePath), utils.ShellQuote(cachePath))
 		_, err = p.sshClient.Execute(moveCmd)
 		if err != nil {
 			global.APP_LOG.Warn("移动模板文件失败", zap.Error(err))
@@ -780,11 +773,15 @@ func (p *ProxmoxProvider) sshSetInstancePassword(ctx context.Context, instanceID
 	switch instanceType {
 	case "container":
 		// LXC容器
-		setPasswordCmd = fmt.Sprintf("pct exec %s -- bash -c 'echo \"root:%s\" | chpasswd'", vmid, password)
+		setPasswordCmd = fmt.Sprintf("printf '%%s\\n' %s | pct exec %s -- chpasswd", utils.ShellQuote("root:"+password), vmid)
 	case "vm":
+		// Windows 虚拟机通过 Guest Agent 或 cloudbase-init 设置 Administrator 密码
+		if p.isWindowsVM(vmid) {
+			return p.sshSetWindowsPassword(vmid, password)
+		}
 		// QEMU虚拟机 - 使用cloud-init设置密码
 		// 首先尝试通过cloud-init设置密码
-		setPasswordCmd = fmt.Sprintf("qm set %s --cipassword '%s'", vmid, password)
+		setPasswordCmd = fmt.Sprintf("qm set %s --cipassword %s", vmid, utils.ShellQuote(password))
 
 		// 执行设置命令
 		_, err := p.sshClient.Execute(setPasswordCmd)
@@ -954,8 +951,8 @@ func (p *ProxmoxProvider) configurePortForwarding(ctx context.Context, vmid int,
 		}
 
 		// iptables规则进行端口转发
-		rule := fmt.Sprintf("iptables -t nat -A PREROUTING -i vmbr0 -p tcp --dport %s -j DNAT --to-destination %s:%s",
-			parts[0], userIP, parts[1])
+		rule := utils.DNATAddCommand(p.publicInterface(), fmt.Sprintf("-p tcp --dport %s -j DNAT --to-destination %s:%s",
+			parts[0], userIP, parts[1]))
 
 		_, err := p.sshClient.Execute(rule)
 		if err != nil {
@@ -973,8 +970,8 @@ func (p *ProxmoxProvider) configurePortForwarding(ctx context.Context, vmid int,
 	}
 }
 
-// configureContainerSSH 配置容器SSH
-func (p *ProxmoxProvider) configureContainerSSH(ctx context.Context, vmid int) {
+// configureContainerSSH 配置容器SSH，guestSSHPort 为容器内sshd监听的端口
+func (p *ProxmoxProvider) configureContainerSSH(ctx context.Context, vmid int, guestSSHPort int) {
 	// 等待容器完全启动
 	time.Sleep(3 * time.Second)
 
@@ -1007,6 +1004,16 @@ func (p *ProxmoxProvider) configureContainerSSH(ctx context.Context, vmid int) {
 		p.configureDebianBasedSSH(vmid)
 	}
 
+	// 各发行版配置固定写入Port 22，非默认端口在其之后改写
+	if script := provider.GuestSSHPortScript(guestSSHPort); script != "" {
+		if _, err := p.sshClient.Execute(fmt.Sprintf("pct exec %d -- sh -c %s", vmid, utils.ShellQuote(script))); err != nil {
+			global.APP_LOG.Warn("配置容器内SSH端口失败",
+				zap.Int("vmid", vmid),
+				zap.Int("guestSSHPort", guestSSHPort),
+				zap.Error(err))
+		}
+	}
+
 	global.APP_LOG.Info("容器SSH配置完成", zap.Int("vmid", vmid), zap.String("packageManager", pkgManager))
 }
 
@@ -1379,7 +1386,7 @@ func (p *ProxmoxProvider) updateInstanceNotes(ctx context.Context, vmid int, con
 	valuesLine := strings.Join(valueFields, " ")
 
 	// 使用echo写入，完全模拟shell行为
-	writeValuesCmd := fmt.Sprintf("echo '%s' > %s", valuesLine, tmpDataFile)
+	writeValuesCmd := fmt.Sprintf("echo %s > %s", utils.ShellQuote(valuesLine), tmpDataFile)
 	_, err := p.sshClient.Execute(writeValuesCmd)
 	if err != nil {
 		return fmt.Errorf("写入数据文件失败: %w", err)
@@ -1395,7 +1402,7 @@ func (p *ProxmoxProvider) updateInstanceNotes(ctx context.Context, vmid int, con
 	for i := 0; i < len(dataFields); i++ {
 		// 每个字段占两行：字段名+值，然后空行
 		formatCommands = append(formatCommands,
-			fmt.Sprintf("echo '%s %s' >> %s", dataFields[i], valueFields[i], tmpFormatFile))
+			fmt.Sprintf("echo %s >> %s", utils.ShellQuote(dataFields[i]+" "+valueFields[i]), tmpFormatFile))
 		formatCommands = append(formatCommands,
 			fmt.Sprintf("echo '' >> %s", tmpFormatFile))
 	}
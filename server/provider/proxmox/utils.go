@@ -15,20 +15,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// getDownloadURL 确定下载URL (支持CDN)
-func (p *ProxmoxProvider) getDownloadURL(originalURL string, useCDN bool) string {
-	// 如果不使用CDN，直接返回原始URL
-	if !useCDN {
-		global.APP_LOG.Info("镜像配置不使用CDN，使用原始URL",
-			zap.String("originalURL", utils.TruncateString(originalURL, 100)))
-		return originalURL
-	}
-
-	// 尝试使用CDN
-	if cdnURL := utils.GetCDNURL(p.sshClient, originalURL, "Proxmox"); cdnURL != "" {
-		return cdnURL
-	}
-	return originalURL
+// getDownloadSources 确定镜像的候选下载来源：Provider镜像源、CDN（useCDN时）、原始地址，上次成功的来源优先
+func (p *ProxmoxProvider) getDownloadSources(originalURL string, useCDN bool) []utils.DownloadSource {
+	return utils.ImageDownloadSources(originalURL, p.config.ImageMirrors, useCDN, utils.PreferredImageSource(p.config.ID))
 }
 
 // convertMemoryFormat 转换内存格式为Proxmox VE支持的格式
@@ -193,7 +182,7 @@ func (p *ProxmoxProvider) safeRemove(ctx context.Context, path string) error {
 	}
 
 	// 检查路径是否存在
-	checkCmd := fmt.Sprintf("[ -e '%s' ]", path)
+	checkCmd := fmt.Sprintf("[ -e %s ]", utils.ShellQuote(path))
 	_, err := p.sshClient.Execute(checkCmd)
 	if err != nil {
 		// 路径不存在，无需删除
@@ -201,7 +190,7 @@ func (p *ProxmoxProvider) safeRemove(ctx context.Context, path string) error {
 	}
 
 	global.APP_LOG.Info("删除路径", zap.String("path", path))
-	removeCmd := fmt.Sprintf("rm -rf '%s'", path)
+	removeCmd := fmt.Sprintf("rm -rf %s", utils.ShellQuote(path))
 	_, err = p.sshClient.Execute(removeCmd)
 	return err
 }
@@ -0,0 +1,218 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// VirtIO 驱动ISO，Windows 需要该驱动才能识别 virtio 磁盘和网卡
+	virtioISOName = "virtio-win.iso"
+	virtioISOURL  = "https://fedorapeople.org/groups/virt/virtio-win/direct-downloads/stable-virtio/virtio-win.iso"
+	// Proxmox local 存储的ISO目录
+	virtioISODir = "/var/lib/vz/template/iso"
+)
+
+// windowsVMOptions Windows 虚拟机的固件选项，由镜像标签控制（uefi、tpm）
+type windowsVMOptions struct {
+	UEFI bool
+	TPM  bool
+}
+
+// isWindowsImage 根据镜像信息判断是否为 Windows 镜像
+// os_type 由 queryAndSetSystemImage 写入 Metadata
+func isWindowsImage(config *provider.InstanceConfig) bool {
+	if config.Metadata != nil && constant.IsWindowsOSType(config.Metadata["os_type"]) {
+		return true
+	}
+	return constant.IsWindowsOSType(config.Image)
+}
+
+// parseWindowsVMOptions 解析镜像标签中的固件选项
+// Windows 11 强制要求 UEFI + TPM 2.0，未打标签时按镜像名称推断
+func parseWindowsVMOptions(config *provider.InstanceConfig) windowsVMOptions {
+	var opts windowsVMOptions
+	if config.Metadata != nil {
+		for _, tag := range strings.Split(strings.ToLower(config.Metadata["image_tags"]), ",") {
+			switch strings.TrimSpace(tag) {
+			case "uefi":
+				opts.UEFI = true
+			case "tpm":
+				opts.UEFI = true
+				opts.TPM = true
+			}
+		}
+	}
+	name := strings.ToLower(config.Image)
+	if strings.Contains(name, "win11") || strings.Contains(name, "windows-11") || strings.Contains(name, "windows11") {
+		opts.UEFI = true
+		opts.TPM = true
+	}
+	return opts
+}
+
+// windowsOSTypeParam 返回 Proxmox 的 ostype 参数
+func windowsOSTypeParam(opts windowsVMOptions) string {
+	if opts.TPM {
+		return "win11"
+	}
+	return "win10"
+}
+
+// ensureVirtIOISO 确保宿主机上存在 VirtIO 驱动ISO，返回可挂载的卷ID
+func (p *ProxmoxProvider) ensureVirtIOISO() (string, error) {
+	isoPath := fmt.Sprintf("%s/%s", virtioISODir, virtioISOName)
+	script := fmt.Sprintf("mkdir -p %s; [ -s %s ] || curl -sSL -o %s.tmp %s && mv -f %s.tmp %s; [ -s %s ] && echo 'iso_ready' || echo 'iso_missing'",
+		virtioISODir, isoPath, isoPath, virtioISOURL, isoPath, isoPath, isoPath)
+	output, err := p.sshClient.Execute(script)
+	if err != nil {
+		return "", fmt.Errorf("下载VirtIO驱动ISO失败: %v", err)
+	}
+	if !strings.Contains(output, "iso_ready") {
+		return "", fmt.Errorf("VirtIO驱动ISO不可用")
+	}
+	return "local:iso/" + virtioISOName, nil
+}
+
+// configureWindowsVM 为 Windows 虚拟机设置 ostype、固件、TPM、驱动ISO 及 cloudbase-init 所需的配置
+// 需在虚拟机首次启动前调用
+func (p *ProxmoxProvider) configureWindowsVM(vmid int, storage string, opts windowsVMOptions) error {
+	cmds := []string{
+		fmt.Sprintf("qm set %d --ostype %s", vmid, windowsOSTypeParam(opts)),
+		// cloudbase-init 读取 configdrive2 格式的配置盘
+		fmt.Sprintf("qm set %d --citype configdrive2", vmid),
+		// Windows 的 RTC 使用本地时间
+		fmt.Sprintf("qm set %d --localtime 1", vmid),
+	}
+	if opts.UEFI {
+		cmds = append(cmds,
+			fmt.Sprintf("qm set %d --bios ovmf --machine q35", vmid),
+			fmt.Sprintf("qm set %d --efidisk0 %s:1,efitype=4m,pre-enrolled-keys=1", vmid, storage),
+		)
+	}
+	if opts.TPM {
+		cmds = append(cmds, fmt.Sprintf("qm set %d --tpmstate0 %s:1,version=v2.0", vmid, storage))
+	}
+
+	for _, cmd := range cmds {
+		if _, err := p.sshClient.Execute(cmd); err != nil {
+			return fmt.Errorf("配置Windows虚拟机失败 (%s): %v", cmd, err)
+		}
+	}
+
+	// 驱动ISO下载失败不影响创建，已集成驱动的镜像可以正常启动
+	if isoVolume, err := p.ensureVirtIOISO(); err != nil {
+		global.APP_LOG.Warn("VirtIO驱动ISO不可用，跳过挂载", zap.Int("vmid", vmid), zap.Error(err))
+	} else if _, err := p.sshClient.Execute(fmt.Sprintf("qm set %d --ide2 %s,media=cdrom", vmid, isoVolume)); err != nil {
+		global.APP_LOG.Warn("挂载VirtIO驱动ISO失败", zap.Int("vmid", vmid), zap.Error(err))
+	}
+
+	global.APP_LOG.Info("Windows虚拟机配置完成",
+		zap.Int("vmid", vmid),
+		zap.Bool("uefi", opts.UEFI),
+		zap.Bool("tpm", opts.TPM))
+	return nil
+}
+
+// isWindowsVM 通过虚拟机配置的 ostype 判断是否为 Windows
+func (p *ProxmoxProvider) isWindowsVM(vmid string) bool {
+	output, err := p.sshClient.Execute(fmt.Sprintf("qm config %s | grep '^ostype:'", vmid))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(output, "ostype: win") || strings.Contains(output, "ostype: w2k")
+}
+
+// sshSetWindowsPassword 通过 QEMU Guest Agent 设置 Windows 管理员密码
+// Guest Agent 不可用时回退到 cloudbase-init（写入 cipassword 后重启生效）
+func (p *ProxmoxProvider) sshSetWindowsPassword(vmid, password string) error {
+	agentCmd := fmt.Sprintf("printf '%%s\\n%%s\\n' %s %s | qm guest passwd %s %s", utils.ShellQuote(password), utils.ShellQuote(password), vmid, constant.WindowsAdminUser)
+	_, err := p.sshClient.Execute(agentCmd)
+	if err == nil {
+		global.APP_LOG.Info("通过Guest Agent设置Windows密码成功", zap.String("vmid", vmid))
+		return nil
+	}
+	global.APP_LOG.Warn("通过Guest Agent设置Windows密码失败，回退到cloudbase-init",
+		zap.String("vmid", vmid),
+		zap.Error(err))
+
+	if _, err := p.sshClient.Execute(fmt.Sprintf("qm set %s --ciuser %s --cipassword %s", vmid, constant.WindowsAdminUser, utils.ShellQuote(password))); err != nil {
+		return fmt.Errorf("通过cloudbase-init设置Windows密码失败: %w", err)
+	}
+	if _, err := p.sshClient.Execute(fmt.Sprintf("qm reboot %s", vmid)); err != nil {
+		global.APP_LOG.Warn("重启Windows虚拟机应用密码失败，可能需要手动重启",
+			zap.String("vmid", vmid),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// apiIsWindowsVM 通过API读取虚拟机配置判断是否为 Windows
+func (p *ProxmoxProvider) apiIsWindowsVM(ctx context.Context, vmid string) bool {
+	url := fmt.Sprintf("https://%s:8006/api2/json/nodes/%s/qemu/%s/config", p.config.Host, p.node, vmid)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false
+	}
+	p.setAPIAuth(req)
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	var respData struct {
+		Data struct {
+			OSType string `json:"ostype"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		return false
+	}
+	return strings.HasPrefix(respData.Data.OSType, "win") || strings.HasPrefix(respData.Data.OSType, "w2k")
+}
+
+// apiSetWindowsPassword 通过 Guest Agent API 设置 Windows 管理员密码
+func (p *ProxmoxProvider) apiSetWindowsPassword(ctx context.Context, vmid, password string) error {
+	url := fmt.Sprintf("https://%s:8006/api2/json/nodes/%s/qemu/%s/agent/set-user-password", p.config.Host, p.node, vmid)
+	payload, err := json.Marshal(map[string]interface{}{
+		"username": constant.WindowsAdminUser,
+		"password": password,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(payload)))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	p.setAPIAuth(req)
+
+	resp, err := p.apiClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("执行API请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var respData map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&respData)
+		return fmt.Errorf("设置Windows密码失败: status %d, response: %v", resp.StatusCode, respData)
+	}
+
+	global.APP_LOG.Info("通过API设置Windows密码成功", zap.String("vmid", vmid))
+	return nil
+}
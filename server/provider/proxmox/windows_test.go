@@ -0,0 +1,38 @@
+package proxmox
+
+import (
+	"testing"
+
+	"oneclickvirt/provider"
+)
+
+func TestWindowsImageOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		image    string
+		metadata map[string]string
+		windows  bool
+		opts     windowsVMOptions
+		ostype   string
+	}{
+		{name: "Linux镜像", image: "debian12", metadata: map[string]string{"os_type": "debian"}, ostype: "win10"},
+		{name: "os_type标记为Windows", image: "server-2022", metadata: map[string]string{"os_type": "windows"}, windows: true, ostype: "win10"},
+		{name: "无元数据按名称判断", image: "win2019", windows: true, ostype: "win10"},
+		{name: "uefi标签", image: "win2022", metadata: map[string]string{"image_tags": "uefi"}, windows: true, opts: windowsVMOptions{UEFI: true}, ostype: "win10"},
+		{name: "tpm标签同时启用uefi", image: "windows-server", metadata: map[string]string{"image_tags": "Fast, TPM"}, windows: true, opts: windowsVMOptions{UEFI: true, TPM: true}, ostype: "win11"},
+		{name: "Windows 11按名称推断", image: "Windows-11-pro", windows: true, opts: windowsVMOptions{UEFI: true, TPM: true}, ostype: "win11"},
+	}
+	for _, c := range cases {
+		config := &provider.InstanceConfig{Image: c.image, Metadata: c.metadata}
+		if got := isWindowsImage(config); got != c.windows {
+			t.Errorf("%s: isWindowsImage = %v, want %v", c.name, got, c.windows)
+		}
+		opts := parseWindowsVMOptions(config)
+		if opts != c.opts {
+			t.Errorf("%s: options = %+v, want %+v", c.name, opts, c.opts)
+		}
+		if got := windowsOSTypeParam(opts); got != c.ostype {
+			t.Errorf("%s: ostype = %s, want %s", c.name, got, c.ostype)
+		}
+	}
+}
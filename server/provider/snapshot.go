@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+)
+
+// SnapshotCapable 支持实例快照的Provider（LXD/Incus/Proxmox），为可选接口
+type SnapshotCapable interface {
+	// CreateSnapshot 为实例创建指定名称的快照
+	CreateSnapshot(ctx context.Context, instanceID, name string) error
+	// DeleteSnapshot 删除实例的指定快照，快照不存在时视为成功
+	DeleteSnapshot(ctx context.Context, instanceID, name string) error
+}
+
+// snapshotNamePattern 同时满足LXD、Incus和Proxmox命名要求的快照名
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,39}$`)
+
+// ValidSnapshotName 快照名是否可安全用于所有Provider
+func ValidSnapshotName(name string) bool {
+	return snapshotNamePattern.MatchString(name)
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+)
+
+// 实例内SSH端口辅助函数，供各Provider在配置SSH和重置密码时使用
+
+// GuestSSHPortFromConfig 返回创建配置元数据中的实例内SSH端口，未设置时为22
+func GuestSSHPortFromConfig(config InstanceConfig) int {
+	if config.Metadata == nil {
+		return constant.SSHGuestPort
+	}
+	port, _ := strconv.Atoi(config.Metadata["guest_ssh_port"])
+	return constant.GuestSSHPortOrDefault(port)
+}
+
+// LookupGuestSSHPort 从数据库读取实例内SSH端口，用于重置密码等不携带创建配置的操作，查不到时为22
+func LookupGuestSSHPort(providerID uint, instanceName string) int {
+	if global.APP_DB == nil {
+		return constant.SSHGuestPort
+	}
+	var instance provider.Instance
+	if err := global.APP_DB.Select("guest_ssh_port").
+		Where("provider_id = ? AND name = ?", providerID, instanceName).
+		First(&instance).Error; err != nil {
+		return constant.SSHGuestPort
+	}
+	return constant.GuestSSHPortOrDefault(instance.GuestSSHPort)
+}
+
+// GuestSSHPortScript 返回在实例内将sshd改为监听指定端口并重启sshd的shell脚本，端口为22时返回空字符串
+// SSH配置脚本会重写sshd_config，因此需要在其之后执行；同时处理systemd的ssh.socket和SELinux端口标签
+func GuestSSHPortScript(port int) string {
+	if port == constant.SSHGuestPort || port <= 0 || port > 65535 {
+		return ""
+	}
+	return fmt.Sprintf(`PORT=%d
+f=/etc/ssh/sshd_config
+if [ -f "$f" ]; then
+  chattr -i "$f" 2>/dev/null
+  grep -viE '^[[:space:]]*#?[[:space:]]*Port[[:space:]]' "$f" > "$f.ocv.tmp"
+  { echo "Port $PORT"; cat "$f.ocv.tmp"; } > "$f"
+  rm -f "$f.ocv.tmp"
+fi
+for conf in /etc/ssh/sshd_config.d/*.conf; do
+  [ -f "$conf" ] && sed -i '/^[[:space:]]*Port[[:space:]]/d' "$conf"
+done
+if command -v semanage >/dev/null 2>&1; then
+  semanage port -a -t ssh_port_t -p tcp "$PORT" 2>/dev/null || semanage port -m -t ssh_port_t -p tcp "$PORT" 2>/dev/null
+fi
+if [ -d /run/systemd/system ] && systemctl is-enabled ssh.socket >/dev/null 2>&1; then
+  mkdir -p /etc/systemd/system/ssh.socket.d
+  printf '[Socket]\nListenStream=\nListenStream=%%s\n' "$PORT" > /etc/systemd/system/ssh.socket.d/oneclickvirt-port.conf
+  systemctl daemon-reload
+  systemctl restart ssh.socket
+fi
+(systemctl restart sshd || systemctl restart ssh || rc-service sshd restart || service ssh restart || service sshd restart) >/dev/null 2>&1
+true
+`, port)
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"oneclickvirt/utils"
+)
+
+// LXD 和 Incus 共用的虚拟机Agent（lxd-agent/incus-agent）辅助函数
+// 虚拟机内的Agent运行后，宿主机可通过 exec 通道直接在实例内执行命令，实例状态中的进程数和网络地址也由Agent上报
+
+// VMAgentCapable 支持通过宿主机执行通道配置实例的Provider（LXD/Incus），为可选接口
+type VMAgentCapable interface {
+	// VMAgentAvailable 检测实例的执行通道是否可用（容器运行即可用，虚拟机需要Agent在运行）
+	VMAgentAvailable(ctx context.Context, instanceID string) bool
+	// InjectInstanceSSHKeys 通过执行通道将SSH公钥写入实例内指定用户的 authorized_keys
+	InjectInstanceSSHKeys(ctx context.Context, instanceID, username string, keys []string) error
+}
+
+// VMAgentState 实例状态中与Agent相关的字段，来自 `lxc/incus list <name> --format json`
+type VMAgentState struct {
+	Name      string
+	Type      string // container 或 virtual-machine
+	Status    string
+	Processes int64 // 虚拟机Agent未运行时为-1
+	Network   map[string][]string
+}
+
+// IsVM 是否为虚拟机实例
+func (s *VMAgentState) IsVM() bool {
+	return s.Type == "virtual-machine"
+}
+
+// AgentRunning 执行通道是否可用
+func (s *VMAgentState) AgentRunning() bool {
+	if !strings.EqualFold(s.Status, "running") {
+		return false
+	}
+	return !s.IsVM() || s.Processes > 0
+}
+
+// IPv4 返回Agent上报的第一个全局IPv4地址，按接口名排序并跳过回环和容器网桥接口
+func (s *VMAgentState) IPv4() string {
+	names := make([]string, 0, len(s.Network))
+	for name := range s.Network {
+		if name == "lo" || strings.HasPrefix(name, "docker") || strings.HasPrefix(name, "br-") ||
+			strings.HasPrefix(name, "veth") || strings.HasPrefix(name, "virbr") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, addr := range s.Network[name] {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+				return addr
+			}
+		}
+	}
+	return ""
+}
+
+// ParseVMAgentState 解析 list --format json 的输出，list 按前缀匹配，需要按名称精确查找
+func ParseVMAgentState(output, instanceName string) (*VMAgentState, error) {
+	var list []struct {
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		State *struct {
+			Status    string `json:"status"`
+			Processes int64  `json:"processes"`
+			Network   map[string]struct {
+				Addresses []struct {
+					Family  string `json:"family"`
+					Address string `json:"address"`
+					Scope   string `json:"scope"`
+				} `json:"addresses"`
+			} `json:"network"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &list); err != nil {
+		return nil, fmt.Errorf("解析实例状态失败: %w", err)
+	}
+	for _, item := range list {
+		if item.Name != instanceName {
+			continue
+		}
+		state := &VMAgentState{Name: item.Name, Type: item.Type, Processes: -1, Network: make(map[string][]string)}
+		if item.State == nil {
+			return state, nil
+		}
+		state.Status = item.State.Status
+		state.Processes = item.State.Processes
+		for iface, network := range item.State.Network {
+			for _, addr := range network.Addresses {
+				if addr.Family == "inet" && addr.Scope == "global" {
+					state.Network[iface] = append(state.Network[iface], addr.Address)
+				}
+			}
+		}
+		return state, nil
+	}
+	return nil, fmt.Errorf("实例 %s 不存在", instanceName)
+}
+
+// AgentPasswordScript 通过执行通道设置用户密码并开启SSH密码登录的脚本
+func AgentPasswordScript(username, password string) string {
+	return fmt.Sprintf(`printf '%%s\n' %s | chpasswd || exit 1
+for f in /etc/ssh/sshd_config /etc/ssh/sshd_config.d/*.conf; do
+  [ -f "$f" ] || continue
+  sed -i -E 's/^#?[[:space:]]*PasswordAuthentication[[:space:]].*/PasswordAuthentication yes/; s/^#?[[:space:]]*PermitRootLogin[[:space:]].*/PermitRootLogin yes/' "$f"
+done
+(systemctl restart sshd || systemctl restart ssh || service sshd restart || service ssh restart || rc-service sshd restart) >/dev/null 2>&1
+exit 0
+`, utils.ShellQuote(username+":"+password))
+}
+
+// AgentSSHKeysScript 通过执行通道将公钥追加到用户 authorized_keys 的脚本，已存在的公钥不会重复写入
+func AgentSSHKeysScript(username string, keys []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `home=$(awk -F: -v u=%s '$1==u{print $6}' /etc/passwd)
+[ -n "$home" ] || { echo "用户不存在"; exit 1; }
+umask 077
+mkdir -p "$home/.ssh" && touch "$home/.ssh/authorized_keys" || exit 1
+`, utils.ShellQuote(username))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, "\r\n") {
+			continue
+		}
+		quoted := utils.ShellQuote(key)
+		fmt.Fprintf(&b, "grep -qxF %s \"$home/.ssh/authorized_keys\" || echo %s >> \"$home/.ssh/authorized_keys\"\n", quoted, quoted)
+	}
+	fmt.Fprintf(&b, "chown -R %s \"$home/.ssh\" 2>/dev/null\nexit 0\n", utils.ShellQuote(username))
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const vmListOutput = `[
+  {"name": "vm1-old", "type": "virtual-machine", "state": {"status": "Running", "processes": 12, "network": {}}},
+  {"name": "vm1", "type": "virtual-machine", "state": {"status": "Running", "processes": 23, "network": {
+    "lo": {"addresses": [{"family": "inet", "address": "127.0.0.1", "scope": "local"}]},
+    "docker0": {"addresses": [{"family": "inet", "address": "172.17.0.1", "scope": "global"}]},
+    "enp5s0": {"addresses": [
+      {"family": "inet6", "address": "fd42::2", "scope": "global"},
+      {"family": "inet", "address": "10.10.0.5", "scope": "global"}
+    ]}
+  }}},
+  {"name": "vm2", "type": "virtual-machine", "state": {"status": "Running", "processes": -1, "network": null}},
+  {"name": "ct1", "type": "container", "state": {"status": "Stopped", "processes": 0}}
+]`
+
+func TestParseVMAgentState(t *testing.T) {
+	state, err := ParseVMAgentState(vmListOutput, "vm1")
+	if err != nil {
+		t.Fatalf("ParseVMAgentState: %v", err)
+	}
+	if !state.IsVM() || !state.AgentRunning() || state.IPv4() != "10.10.0.5" {
+		t.Errorf("unexpected state %+v, ip %q", state, state.IPv4())
+	}
+
+	state, err = ParseVMAgentState(vmListOutput, "vm2")
+	if err != nil || state.AgentRunning() || state.IPv4() != "" {
+		t.Errorf("vm without agent should not be available, got %+v, %v", state, err)
+	}
+	state, err = ParseVMAgentState(vmListOutput, "ct1")
+	if err != nil || state.IsVM() || state.AgentRunning() {
+		t.Errorf("stopped container should not be available, got %+v, %v", state, err)
+	}
+	if _, err := ParseVMAgentState(vmListOutput, "vm"); err == nil {
+		t.Error("prefix match should not be treated as the instance")
+	}
+	if _, err := ParseVMAgentState("Error: not found", "vm1"); err == nil {
+		t.Error("invalid output should fail")
+	}
+}
+
+func TestAgentScripts(t *testing.T) {
+	password := AgentPasswordScript("root", "it's")
+	if !strings.Contains(password, `'root:it'\''s'`) {
+		t.Errorf("password should be quoted, got %q", password)
+	}
+	keys := AgentSSHKeysScript("debian", []string{"ssh-ed25519 AAAA user@host", "", "bad\nkey"})
+	if !strings.Contains(keys, "'ssh-ed25519 AAAA user@host'") || strings.Contains(keys, "bad") {
+		t.Errorf("unexpected keys script %q", keys)
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return
+	}
+	for _, script := range []string{password, keys} {
+		cmd := exec.Command("sh", "-n")
+		cmd.Stdin = strings.NewReader(script)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("script syntax error: %v\n%s", err, out)
+		}
+	}
+}
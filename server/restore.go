@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"oneclickvirt/core"
+	"oneclickvirt/global"
+	"oneclickvirt/initialize"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	adminProvider "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/cpbackup"
+	"oneclickvirt/service/storage"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const restoreUsage = `用法:
+  oneclickvirt restore (--file PATH | --object KEY | --upload upload://UUID) [--force] [--skip-verify]
+
+从控制面灾备归档恢复数据到 config.yaml 中配置的数据库，恢复后重新检查各Provider的连通性。
+加密口令从 OCV_BACKUP_PASSPHRASE 环境变量读取，未设置时使用 control-plane-backup.passphrase。
+
+参数:
+`
+
+// runRestore 执行 restore 子命令，返回进程退出码
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	file := fs.String("file", "", "本地归档文件路径")
+	object := fs.String("object", "", "对象存储中的归档键（导出记录的objectKey），使用config.yaml中的对象存储配置读取")
+	upload := fs.String("upload", "", "通过分片上传接口上传（用途为backup）的归档，格式 upload://<uuid>")
+	force := fs.Bool("force", false, "目标数据库已有用户时仍然恢复，归档中包含的表会被清空后重新写入")
+	skipVerify := fs.Bool("skip-verify", false, "恢复后不检查Provider连通性")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), restoreUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	sources := 0
+	for _, source := range []string{*file, *object, *upload} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		fmt.Fprintln(os.Stderr, "[ERROR] 需要且只能指定 --file、--object 或 --upload 其中之一")
+		fs.Usage()
+		return 2
+	}
+
+	global.APP_VP = core.Viper()
+	global.APP_LOG = core.Zap()
+	zap.ReplaceGlobals(global.APP_LOG)
+	global.APP_SHUTDOWN_CONTEXT, global.APP_SHUTDOWN_CANCEL = context.WithCancel(context.Background())
+	defer global.APP_SHUTDOWN_CANCEL()
+	global.APP_SSH_POOL = utils.InitGlobalSSHPool(global.APP_LOG)
+
+	passphrase := os.Getenv("OCV_BACKUP_PASSPHRASE")
+	if passphrase == "" {
+		passphrase = global.APP_CONFIG.ControlPlaneBackup.Passphrase
+	}
+	if passphrase == "" {
+		fmt.Fprintln(os.Stderr, "[ERROR] 未提供加密口令，请设置 OCV_BACKUP_PASSPHRASE 环境变量")
+		return 1
+	}
+
+	// 连接数据库并完成表结构迁移，恢复写入的是当前版本的表结构
+	global.APP_DB = initialize.Gorm()
+	if global.APP_DB == nil {
+		fmt.Fprintln(os.Stderr, "[ERROR] 数据库连接失败，请检查 config.yaml 中的数据库配置")
+		return 1
+	}
+
+	reader, err := openRestoreSource(*file, *object, *upload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] 打开归档失败: %v\n", err)
+		return 1
+	}
+	defer reader.Close()
+
+	fmt.Println("[INFO] 开始恢复控制面数据")
+	manifest, err := cpbackup.Restore(context.Background(), global.APP_DB, reader, passphrase, cpbackup.RestoreOptions{Force: *force})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] 恢复失败，数据库未修改: %v\n", err)
+		if errors.Is(err, cpbackup.ErrTargetNotEmpty) {
+			return 3
+		}
+		return 1
+	}
+
+	fmt.Printf("[SUCCESS] 已恢复 %s 导出的归档（范围 %s），共 %d 张表 %d 行\n",
+		manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.Scope, len(manifest.Tables), manifest.TotalRows())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, table := range manifest.Tables {
+		fmt.Fprintf(w, "  %s\t%d\n", table, manifest.Rows[table])
+	}
+	w.Flush()
+
+	if *skipVerify {
+		fmt.Println("[INFO] 已跳过Provider连通性检查，请在面板中手动检查")
+		return 0
+	}
+	if offline := verifyProviders(); offline > 0 {
+		fmt.Printf("[WARN] %d 个Provider无法连接，请检查网络和凭据后在面板中重新检查\n", offline)
+		return 4
+	}
+	return 0
+}
+
+// openRestoreSource 打开本地归档文件、对象存储中的归档或已完成的上传文件
+func openRestoreSource(file, object, upload string) (io.ReadCloser, error) {
+	if file != "" {
+		return os.Open(file)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	var reader io.ReadCloser
+	var err error
+	if upload != "" {
+		reader, err = openRestoreUpload(ctx, upload)
+	} else {
+		var store storage.ObjectStorage
+		if store, err = storage.GetObjectStorage(); err == nil {
+			reader, err = store.Get(ctx, strings.TrimPrefix(object, "/"))
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelReadCloser{ReadCloser: reader, cancel: cancel}, nil
+}
+
+// openRestoreUpload 打开用途为 backup 的已完成上传文件，也接受不带 upload:// 前缀的UUID
+func openRestoreUpload(ctx context.Context, ref string) (io.ReadCloser, error) {
+	if !storage.IsUploadReference(ref) {
+		ref = systemModel.UploadReferencePrefix + ref
+	}
+	uploads := storage.GetUploadService()
+	session, err := uploads.ResolveReference(ref, systemModel.UploadPurposeBackup)
+	if err != nil {
+		return nil, err
+	}
+	return uploads.OpenArtifact(ctx, session)
+}
+
+// cancelReadCloser 关闭时同时释放读取对象使用的上下文
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// verifyProviders 逐个检查恢复后的Provider连通性并输出结果，返回SSH无法连接的数量
+func verifyProviders() int {
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Select("id", "name", "type", "endpoint").Order("id").Find(&providers).Error; err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] 读取Provider列表失败: %v\n", err)
+		return 0
+	}
+	if len(providers) == 0 {
+		return 0
+	}
+
+	fmt.Printf("[INFO] 检查 %d 个Provider的连通性\n", len(providers))
+	service := adminProvider.NewService()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tNAME\tTYPE\tENDPOINT\tSSH\tAPI\tERROR")
+	offline := 0
+	for _, p := range providers {
+		checkErr := service.CheckProviderHealth(p.ID)
+		var checked providerModel.Provider
+		global.APP_DB.Select("ssh_status", "api_status").First(&checked, p.ID)
+		if checked.SSHStatus != "online" {
+			offline++
+		}
+		message := ""
+		if checkErr != nil {
+			message = utils.TruncateString(checkErr.Error(), 80)
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Name, p.Type, p.Endpoint, checked.SSHStatus, checked.APIStatus, message)
+	}
+	w.Flush()
+	return offline
+}
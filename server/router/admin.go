@@ -14,14 +14,25 @@ import (
 // InitAdminRouter 管理员路由
 func InitAdminRouter(Router *gin.RouterGroup) {
 	AdminGroup := Router.Group("/v1/admin")
+	AdminGroup.Use(middleware.AdminIPAllowlist(false))
 	AdminGroup.Use(middleware.RequireAuth(authModel.AuthLevelAdmin))
 	{
 		// 仪表盘
 		AdminGroup.GET("/dashboard", admin.GetAdminDashboard)
+		AdminGroup.GET("/dashboard/counters", admin.GetDashboardCounters)
 
 		// 系统配置（管理员专用）
 		AdminGroup.GET("/config", config.GetUnifiedConfig)
 		AdminGroup.PUT("/config", config.UpdateUnifiedConfig)
+		AdminGroup.POST("/config/diff", config.PreviewConfigDiff)
+		AdminGroup.GET("/config/secrets", config.GetSecretConfigStatus)
+		AdminGroup.GET("/config/metadata", config.GetConfigMetadata)
+		AdminGroup.GET("/config/sync/preview", config.PreviewConfigSync)
+		AdminGroup.POST("/config/sync", config.ExecuteConfigSync)
+		AdminGroup.POST("/config/cdn/validate", config.ValidateCDNConfig)
+		AdminGroup.GET("/config/consistency", config.GetConfigConsistencyReport)
+		AdminGroup.POST("/config/consistency/check", config.RunConfigConsistencyCheck)
+		AdminGroup.POST("/config/consistency/reconcile", config.ReconcileConfigKey)
 
 		// 用户管理
 		AdminGroup.GET("/users", admin.GetUserList)
@@ -34,18 +45,39 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.PUT("/users/batch-level", admin.AdminBatchUpdateUserLevel)
 		AdminGroup.PUT("/users/batch-status", admin.AdminBatchUpdateUserStatus)
 		AdminGroup.POST("/users/batch-delete", admin.AdminBatchDeleteUsers)
+		AdminGroup.GET("/users/:id/provider-scope", admin.GetAdminProviderScope)
+		AdminGroup.PUT("/users/:id/provider-scope", admin.SetAdminProviderScope)
+
+		// 注册申请审核
+		AdminGroup.GET("/registration-applications", admin.GetRegistrationApplications)
+		AdminGroup.POST("/registration-applications/approve", admin.ApproveRegistrationApplications)
+		AdminGroup.POST("/registration-applications/reject", admin.RejectRegistrationApplications)
+		AdminGroup.GET("/registration-rejections/stats", admin.GetRegistrationRejectionStats)
 
 		// 实例管理
 		AdminGroup.GET("/instances", admin.GetInstanceList)
 		AdminGroup.POST("/instances", admin.CreateInstance)
+		AdminGroup.GET("/providers/:id/instance-names/check", admin.CheckInstanceName)
 		AdminGroup.PUT("/instances/:id", admin.UpdateInstance)
 		AdminGroup.DELETE("/instances/:id", admin.DeleteInstance)
 		AdminGroup.POST("/instances/:id/action", admin.AdminInstanceAction)
 		AdminGroup.PUT("/instances/:id/reset-password", admin.ResetInstancePassword)
 		AdminGroup.GET("/instances/:id/password/:taskId", admin.GetInstanceNewPassword)
+		AdminGroup.GET("/instances/:id/console-log", admin.GetInstanceConsoleLog)
+		AdminGroup.POST("/instances/:id/console-log/capture", admin.CaptureInstanceConsoleLog)
+		AdminGroup.GET("/instances/:id/traffic-captures", admin.GetInstanceTrafficCaptures)
+		AdminGroup.POST("/instances/:id/traffic-captures", admin.StartInstanceTrafficCapture)
+		AdminGroup.GET("/instances/:id/traffic-captures/:captureId", admin.GetInstanceTrafficCapture)
+		AdminGroup.POST("/instances/:id/traffic-captures/:captureId/stop", admin.StopInstanceTrafficCapture)
 		AdminGroup.GET("/instance-type-permissions", admin.GetAdminInstanceTypePermissions)
 		AdminGroup.PUT("/instance-type-permissions", admin.UpdateAdminInstanceTypePermissions)
 		AdminGroup.GET("/instances/:id/ssh", admin.AdminSSHWebSocket) // 管理员WebSocket SSH连接
+		AdminGroup.GET("/console-recordings", admin.GetConsoleRecordings)
+		AdminGroup.GET("/console-recordings/:id/download", admin.DownloadConsoleRecording)
+		AdminGroup.GET("/control-plane-backups", admin.GetControlPlaneBackups)
+		AdminGroup.POST("/control-plane-backups", admin.CreateControlPlaneBackup)
+		AdminGroup.GET("/control-plane-backups/:id/download", admin.DownloadControlPlaneBackup)
+		AdminGroup.GET("/instances/:id/host-events", admin.GetInstanceHostEvents)
 
 		// 公告管理
 		AdminGroup.GET("/announcements", admin.GetAnnouncements)
@@ -79,6 +111,8 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 
 		// 配额管理
 		AdminGroup.GET("/quota/users/:userId", system.GetUserQuotaInfo)
+		AdminGroup.GET("/quota/consistency-audit", system.GetConsistencyAuditReport)
+		AdminGroup.POST("/quota/consistency-audit", system.RunConsistencyAudit)
 
 		// Provider管理
 		AdminGroup.GET("/providers", admin.GetProviderList)
@@ -88,21 +122,40 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.POST("/providers/freeze", admin.FreezeProvider)
 		AdminGroup.POST("/providers/unfreeze", admin.UnfreezeProvider)
 		AdminGroup.POST("/providers/test-ssh-connection", admin.TestSSHConnection)
+		AdminGroup.POST("/providers/:id/rotate-credentials", admin.RotateProviderCredentials)
+		AdminGroup.GET("/providers/:id/ssh-key", admin.GetProviderSSHKey)
+		AdminGroup.POST("/providers/:id/ssh-key/generate", admin.GenerateProviderSSHKey)
+		AdminGroup.POST("/providers/:id/ssh-key/activate", admin.ActivateProviderSSHKey)
+		AdminGroup.POST("/providers/:id/image-mirrors/test", admin.TestProviderImageMirrors)
+		AdminGroup.GET("/providers/:id/compatibility", admin.GetProviderHostCompatibility)
+		AdminGroup.POST("/providers/:id/decommission/plan", admin.PlanProviderDecommission)
 		// Provider验证接口（用于前端实时验证）
 		AdminGroup.GET("/providers/check-name", admin.CheckProviderName)
 		AdminGroup.GET("/providers/check-endpoint", admin.CheckProviderEndpoint)
+		AdminGroup.GET("/providers/capacity", admin.GetProvidersCapacity)
+		AdminGroup.POST("/providers/capacity/simulate", admin.SimulateProvidersCapacity)
+		AdminGroup.GET("/forecasts", admin.GetUsageForecasts)
+		AdminGroup.GET("/forecasts/samples", admin.GetUsageSamples)
 
 		// Provider实例发现与导入
 		AdminGroup.POST("/providers/:id/discover", admin.DiscoverProviderInstances)
 		AdminGroup.POST("/providers/:id/import", admin.ImportProviderInstances)
 		AdminGroup.GET("/providers/:id/orphaned", admin.GetOrphanedInstances)
 		AdminGroup.POST("/providers/:id/sync-check", admin.CheckInstanceSync)
+		AdminGroup.POST("/providers/:id/recover-instances", admin.RecoverStoppedInstances)
+		AdminGroup.POST("/providers/:id/import-port-mappings", admin.ImportHostPortMappings)
+		AdminGroup.POST("/providers/:id/port-mapping-method/migrate", admin.MigrateProviderPortMethod)
 
 		// 证书管理
 		AdminGroup.POST("/providers/:id/generate-cert", admin.GenerateProviderCert)
 		AdminGroup.POST("/providers/:id/auto-configure-stream", admin.AutoConfigureProviderStream)
 		AdminGroup.POST("/providers/:id/health-check", admin.CheckProviderHealth)
 		AdminGroup.GET("/providers/:id/status", admin.GetProviderStatus)
+		AdminGroup.GET("/providers/:id/health-history", admin.GetProviderHealthHistory)
+		AdminGroup.GET("/providers/:id/host-events", admin.GetProviderHostEvents)
+		AdminGroup.GET("/host-events", admin.GetHostEvents)
+		AdminGroup.POST("/providers/:id/network-probe", admin.StartProviderNetworkProbe)
+		AdminGroup.GET("/providers/:id/network-probes", admin.GetProviderNetworkProbes)
 
 		// 配置导出
 		AdminGroup.POST("/providers/export-configs", admin.ExportProviderConfigs)
@@ -116,9 +169,12 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		// 用户任务管理
 		AdminGroup.GET("/tasks", admin.GetAdminTasks)
 		AdminGroup.GET("/tasks/:taskId", admin.GetTaskDetail)
+		AdminGroup.GET("/tasks/:taskId/events", admin.GetTaskEvents)
 		AdminGroup.POST("/tasks/force-stop", admin.ForceStopTask)
 		AdminGroup.GET("/tasks/stats", admin.GetTaskStats)
 		AdminGroup.GET("/tasks/overall-stats", admin.GetTaskOverallStats)
+		AdminGroup.GET("/tasks/analytics", admin.GetTaskAnalytics)
+		AdminGroup.GET("/tasks/stuck", admin.GetStuckTasks)
 		AdminGroup.POST("/tasks/:taskId/cancel", admin.CancelUserTaskByAdmin)
 
 		// 系统镜像管理
@@ -128,6 +184,29 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.DELETE("/system-images/:id", system.DeleteSystemImage)
 		AdminGroup.POST("/system-images/batch-delete", system.BatchDeleteSystemImages)
 		AdminGroup.PUT("/system-images/batch-status", system.BatchUpdateSystemImageStatus)
+		AdminGroup.GET("/image-policies", system.GetImagePolicyList)
+		AdminGroup.POST("/image-policies", system.CreateImagePolicy)
+		AdminGroup.PUT("/image-policies/:id", system.UpdateImagePolicy)
+		AdminGroup.DELETE("/image-policies/:id", system.DeleteImagePolicy)
+
+		// 应用市场
+		AdminGroup.GET("/apps", system.GetAppTemplateList)
+		AdminGroup.POST("/apps", system.CreateAppTemplate)
+		AdminGroup.PUT("/apps/:id", system.UpdateAppTemplate)
+		AdminGroup.DELETE("/apps/:id", system.DeleteAppTemplate)
+
+		// 对象存储
+		storageApi := &system.StorageApi{}
+		AdminGroup.GET("/storage/health", storageApi.CheckObjectStorageHealth)
+
+		// 大文件分片上传（自定义镜像、恢复归档）
+		AdminGroup.GET("/uploads", system.GetUploadSessionList)
+		AdminGroup.POST("/uploads", system.CreateUploadSession)
+		AdminGroup.GET("/uploads/:uuid", system.GetUploadSession)
+		AdminGroup.HEAD("/uploads/:uuid", system.GetUploadSession)
+		AdminGroup.PATCH("/uploads/:uuid", system.UploadChunk)
+		AdminGroup.POST("/uploads/:uuid/complete", system.CompleteUploadSession)
+		AdminGroup.DELETE("/uploads/:uuid", system.CancelUploadSession)
 
 		// 端口映射管理
 		AdminGroup.GET("/port-mappings", admin.GetPortMappingList)
@@ -140,6 +219,13 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.GET("/providers/:id/port-usage", admin.GetProviderPortUsage)
 		AdminGroup.GET("/instances/:id/port-mappings", admin.GetInstancePortMappings)
 
+		// NAT端口段规划
+		AdminGroup.GET("/providers/:id/port-ranges", admin.GetProviderPortRanges)
+		AdminGroup.POST("/providers/:id/port-ranges", admin.CreateProviderPortRange)
+		AdminGroup.DELETE("/providers/:id/port-ranges/:rangeId", admin.DeleteProviderPortRange)
+		AdminGroup.GET("/providers/:id/port-plan", admin.GetProviderPortPlan)
+		AdminGroup.POST("/port-mappings/allocate-block", admin.AllocatePortBlock) // 在可分配端口段中分配连续端口块
+
 		// 流量管理API
 		adminTrafficAPI := &traffic.AdminTrafficAPI{}
 		AdminGroup.GET("/traffic/overview", adminTrafficAPI.GetSystemTrafficOverview)
@@ -150,6 +236,8 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.POST("/traffic/batch-manage", adminTrafficAPI.BatchManageTrafficLimits)
 		AdminGroup.POST("/traffic/batch-sync", adminTrafficAPI.BatchSyncUserTraffic)
 		AdminGroup.DELETE("/traffic/user/:userId/clear", adminTrafficAPI.ClearUserTrafficRecords)
+		AdminGroup.GET("/traffic/records", adminTrafficAPI.GetTrafficRecords)
+		AdminGroup.GET("/traffic/records/export", adminTrafficAPI.ExportTrafficRecords)
 
 		// 流量历史API
 		AdminGroup.GET("/providers/:id/traffic/history", traffic.GetProviderTrafficHistory)
@@ -160,6 +248,27 @@ func InitAdminRouter(Router *gin.RouterGroup) {
 		AdminGroup.GET("/providers/traffic-monitor/tasks/:id", admin.GetTrafficMonitorTaskDetail)
 		AdminGroup.GET("/providers/traffic-monitor/latest", admin.GetLatestTrafficMonitorTask)
 
+		// 滥用举报
+		AdminGroup.GET("/abuse-reports", admin.GetAbuseReports)
+		AdminGroup.POST("/abuse-reports", admin.CreateAbuseReport)
+		AdminGroup.GET("/abuse-reports/:id", admin.GetAbuseReport)
+		AdminGroup.PUT("/abuse-reports/:id", admin.UpdateAbuseReport)
+		AdminGroup.PUT("/abuse-reports/:id/enforcement", admin.SetAbuseEnforcement)
+
+		// 宿主机脚本库
+		AdminGroup.GET("/host-scripts", admin.GetHostScripts)
+		AdminGroup.POST("/host-scripts", admin.CreateHostScript)
+		AdminGroup.GET("/host-scripts/:id", admin.GetHostScript)
+		AdminGroup.PUT("/host-scripts/:id", admin.UpdateHostScript)
+		AdminGroup.DELETE("/host-scripts/:id", admin.DeleteHostScript)
+		AdminGroup.GET("/host-scripts/:id/versions", admin.GetHostScriptVersions)
+		AdminGroup.GET("/host-scripts/:id/versions/:version", admin.GetHostScriptVersion)
+		AdminGroup.POST("/host-scripts/:id/runs", admin.RunHostScript)
+		AdminGroup.GET("/host-script-runs", admin.GetHostScriptRuns)
+		AdminGroup.GET("/host-script-runs/:id", admin.GetHostScriptRun)
+		AdminGroup.POST("/host-script-runs/:id/approve", admin.ApproveHostScriptRun)
+		AdminGroup.POST("/host-script-runs/:id/reject", admin.RejectHostScriptRun)
+
 		// 冻结管理
 		AdminGroup.POST("/users/set-expiry", admin.SetUserExpiry)
 		AdminGroup.POST("/providers/set-expiry", admin.SetProviderExpiry)
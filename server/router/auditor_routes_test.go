@@ -0,0 +1,25 @@
+package router
+
+import (
+	"testing"
+
+	"oneclickvirt/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAuditorRoutesClassified 每个管理只读接口都必须明确对审计员开放或关闭，避免新增接口时遗漏
+func TestAuditorRoutesClassified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := engine.Group("/api")
+	InitConfigRouter(api)
+	InitAdminRouter(api)
+	InitOAuth2AdminRouter(api)
+
+	for _, route := range engine.Routes() {
+		if !middleware.AuditorRouteClassified(route.Method, route.Path) {
+			t.Errorf("%s %s 未在 middleware/auditor.go 中列为审计员可查看或禁止访问", route.Method, route.Path)
+		}
+	}
+}
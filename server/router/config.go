@@ -12,6 +12,7 @@ import (
 func InitConfigRouter(Router *gin.RouterGroup) {
 	// 统一配置API
 	ConfigGroup := Router.Group("/v1/config")
+	ConfigGroup.Use(middleware.AdminIPAllowlist(false))
 	ConfigGroup.Use(middleware.RequireAuth(authModel.AuthLevelAdmin))
 	{
 		ConfigGroup.GET("", config.GetUnifiedConfig)
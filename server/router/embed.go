@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 
+	"oneclickvirt/service/cdn"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -46,6 +48,12 @@ func setupStaticRoutes(router *gin.Engine) error {
 		// 去掉开头的斜杠
 		path = strings.TrimPrefix(path, "/")
 
+		// 入口页面需要按CDN配置改写资源地址
+		if path == "" || path == "index.html" {
+			serveIndex(c, staticFS, fileServer)
+			return
+		}
+
 		// 尝试打开文件
 		if f, err := staticFS.Open(path); err == nil {
 			// 检查是否是文件
@@ -59,9 +67,23 @@ func setupStaticRoutes(router *gin.Engine) error {
 		}
 
 		// 文件不存在或是目录，返回 index.html（用于 SPA 路由）
-		c.Request.URL.Path = "/"
-		fileServer.ServeHTTP(c.Writer, c.Request)
+		serveIndex(c, staticFS, fileServer)
 	})
 
 	return nil
 }
+
+// serveIndex 返回前端入口页面，配置了静态资源CDN时将其中的资源地址改写到CDN
+func serveIndex(c *gin.Context, staticFS fs.FS, fileServer http.Handler) {
+	opts := cdn.Current()
+	if opts.Enabled() || opts.AssetVersion != "" {
+		if content, err := fs.ReadFile(staticFS, "index.html"); err == nil {
+			// 入口页面不缓存，资源版本变化后立即生效
+			c.Header("Cache-Control", "no-cache")
+			c.Data(http.StatusOK, "text/html; charset=utf-8", opts.RewriteHTML(content))
+			return
+		}
+	}
+	c.Request.URL.Path = "/"
+	fileServer.ServeHTTP(c.Writer, c.Request)
+}
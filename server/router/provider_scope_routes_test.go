@@ -0,0 +1,26 @@
+package router
+
+import (
+	"testing"
+
+	"oneclickvirt/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestScopedAdminRoutesRegistered 子管理员路由表中的每一项都必须对应已注册的路由，路径写错会导致接口被静默拒绝
+func TestScopedAdminRoutesRegistered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	InitAdminRouter(engine.Group("/api"))
+
+	registered := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range middleware.ScopedAdminRoutes() {
+		if !registered[route] {
+			t.Errorf("middleware/provider_scope.go 中的 %s 不是已注册的管理路由", route)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package router
 
 import (
+	"time"
+
 	"oneclickvirt/api/v1/public"
 	"oneclickvirt/api/v1/system"
+	"oneclickvirt/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,5 +17,11 @@ func InitPublicRouter(Router *gin.RouterGroup) {
 		PublicRouter.GET("announcements", system.GetAnnouncement)
 		PublicRouter.GET("stats", public.GetDashboardStats)
 		PublicRouter.GET("system-images/available", system.GetAvailableSystemImages)
+		PublicRouter.GET("artifacts/:uuid/:filename", system.DownloadArtifact)                                               // 分片上传的自定义镜像下载
+		PublicRouter.GET("share/:token", middleware.RateLimitByIP(30, time.Minute), public.GetSharedInstance)                // 实例分享链接（只读）
+		PublicRouter.GET("data-exports/:token", middleware.RateLimitByIP(30, time.Minute), public.DownloadDataExport)        // 用户数据导出下载
+		PublicRouter.GET("instances/keep-alive/:token", middleware.RateLimitByIP(30, time.Minute), public.KeepInstanceAlive) // 闲置停机预告中的保持运行链接
+		PublicRouter.GET("avatars/:filename", public.GetAvatar)                                                              // 头像（CDN回源）
+		PublicRouter.GET("assets/*filepath", public.GetSignedAsset)                                                          // 签名访问的私有资源（CDN回源）
 	}
 }
@@ -12,7 +12,7 @@ import (
 func InitAuthRouter(Router *gin.RouterGroup) {
 	AuthRouter := Router.Group("v1/auth")
 	{
-		AuthRouter.POST("login", auth.Login)
+		AuthRouter.POST("login", middleware.AdminIPAllowlist(true), auth.Login)
 		AuthRouter.POST("register", auth.Register)
 		AuthRouter.GET("captcha", auth.GetCaptcha)
 		AuthRouter.POST("send-verify-code", auth.SendVerifyCode) // 发送登录验证码
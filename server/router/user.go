@@ -7,6 +7,7 @@ import (
 	"oneclickvirt/api/v1/user"
 	"oneclickvirt/middleware"
 	authModel "oneclickvirt/model/auth"
+	"oneclickvirt/service/apiquota"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,19 +24,59 @@ func InitUserRouter(Router *gin.RouterGroup) {
 		UserGroup.GET("/user/info", user.GetUserInfo)
 		UserGroup.GET("/user/dashboard", user.GetUserDashboard)
 		UserGroup.GET("/user/limits", user.GetUserLimits)
+		UserGroup.GET("/user/api-tokens", user.GetUserAPITokens)
+		UserGroup.POST("/user/api-tokens", user.CreateUserAPIToken)
+		UserGroup.DELETE("/user/api-tokens/:id", user.DeleteUserAPIToken)
 
 		// 实例管理
 		UserGroup.GET("/user/instances", user.GetUserInstances)
-		UserGroup.POST("/user/instances", user.CreateUserInstance)
+		UserGroup.POST("/user/instances", middleware.APIQuota(apiquota.ActionInstanceCreate), user.CreateUserInstance)
+		UserGroup.POST("/user/instances/batch", user.BatchCreateUserInstances)
+		UserGroup.GET("/user/instances/create-estimate", user.GetCreateInstanceEstimate)
 		UserGroup.GET("/user/instances/:id", user.GetUserInstanceDetail)
 		UserGroup.GET("/user/instances/:id/monitoring", user.GetInstanceMonitoring)
 		UserGroup.GET("/user/instances/:id/pmacct/summary", user.GetInstancePmacctSummary)
 		UserGroup.GET("/user/instances/:id/pmacct/query", user.QueryInstancePmacctData)
-		UserGroup.PUT("/user/instances/:id/reset-password", user.ResetInstancePassword)
+		UserGroup.GET("/user/instances/:id/traffic-captures", user.GetInstanceTrafficCaptures)
+		UserGroup.POST("/user/instances/:id/traffic-captures", middleware.APIQuota(apiquota.ActionTrafficCapture), user.StartInstanceTrafficCapture)
+		UserGroup.GET("/user/instances/:id/traffic-captures/:captureId", user.GetInstanceTrafficCapture)
+		UserGroup.POST("/user/instances/:id/traffic-captures/:captureId/stop", user.StopInstanceTrafficCapture)
+		UserGroup.PUT("/user/instances/:id/reset-password", middleware.APIQuota(apiquota.ActionInstanceAction), user.ResetInstancePassword)
 		UserGroup.GET("/user/instances/:id/password/:taskId", user.GetInstanceNewPassword)
 		UserGroup.GET("/user/instances/:id/ports", user.GetInstancePorts)
+		UserGroup.PUT("/user/instances/:id/ports/:portId", user.UpdateInstancePortDescription)
+		UserGroup.POST("/user/instances/:id/ports/detect", middleware.APIQuota(apiquota.ActionInstanceAction), user.DetectInstancePortServices)
+		UserGroup.GET("/user/instances/:id/connection-bundle", user.GetInstanceConnectionBundle)
+		UserGroup.GET("/user/instances/:id/console-log", user.GetInstanceConsoleLog)
+		UserGroup.GET("/user/instances/:id/app", user.GetInstanceApp)
+		UserGroup.GET("/user/instances/:id/health-check", user.GetInstanceHealthCheck)
+		UserGroup.PUT("/user/instances/:id/health-check", user.SaveInstanceHealthCheck)
+		UserGroup.DELETE("/user/instances/:id/health-check", user.DeleteInstanceHealthCheck)
+		UserGroup.GET("/user/instances/:id/health-events", user.GetInstanceHealthEvents)
+		UserGroup.GET("/user/instances/:id/snapshot-schedule", user.GetInstanceSnapshotSchedule)
+		UserGroup.PUT("/user/instances/:id/snapshot-schedule", user.SaveInstanceSnapshotSchedule)
+		UserGroup.DELETE("/user/instances/:id/snapshot-schedule", user.DeleteInstanceSnapshotSchedule)
+		UserGroup.GET("/user/instances/:id/snapshots", user.GetInstanceSnapshots)
+		UserGroup.GET("/user/instances/:id/rdns", user.GetInstanceRDNS)
+		UserGroup.PUT("/user/instances/:id/rdns", user.SetInstanceRDNS)
+		UserGroup.DELETE("/user/instances/:id/rdns", user.DeleteInstanceRDNS)
+		UserGroup.GET("/user/instances/:id/share", user.GetInstanceShare)
+		UserGroup.PUT("/user/instances/:id/share", user.SaveInstanceShare)
+		UserGroup.DELETE("/user/instances/:id/share", user.DeleteInstanceShare)
 		UserGroup.GET("/user/instances/:id/ssh", user.SSHWebSocket) // WebSocket SSH连接
-		UserGroup.POST("/user/instances/action", user.InstanceAction)
+		UserGroup.POST("/user/instances/action", middleware.APIQuota(apiquota.ActionInstanceAction), user.InstanceAction)
+		UserGroup.PUT("/user/instances/group", user.AssignInstanceGroup)
+
+		// 实例分组
+		UserGroup.GET("/user/instance-groups", user.GetInstanceGroups)
+		UserGroup.POST("/user/instance-groups", user.CreateInstanceGroup)
+		UserGroup.PUT("/user/instance-groups/:id", user.UpdateInstanceGroup)
+		UserGroup.DELETE("/user/instance-groups/:id", user.DeleteInstanceGroup)
+		UserGroup.POST("/user/instance-groups/:id/action", middleware.APIQuota(apiquota.ActionInstanceAction), user.InstanceGroupAction)
+
+		// 实例默认设置
+		UserGroup.GET("/user/instance-defaults", user.GetInstanceDefaults)
+		UserGroup.PUT("/user/instance-defaults", user.UpdateInstanceDefaults)
 
 		// 端口映射
 		UserGroup.GET("/user/port-mappings", user.GetUserPortMappings)
@@ -49,10 +90,32 @@ func InitUserRouter(Router *gin.RouterGroup) {
 		UserGroup.GET("/user/providers/:id/capabilities", user.GetProviderCapabilities)
 		UserGroup.GET("/user/instance-type-permissions", user.GetInstanceTypePermissions)
 		UserGroup.GET("/user/instance-config", user.GetInstanceConfig)
+		UserGroup.GET("/user/apps", user.GetAppCatalog)
+
+		// 钩子脚本
+		UserGroup.GET("/user/hooks", user.GetUserHooks)
+		UserGroup.POST("/user/hooks", user.CreateUserHook)
+		UserGroup.PUT("/user/hooks/:id", user.UpdateUserHook)
+		UserGroup.DELETE("/user/hooks/:id", user.DeleteUserHook)
+
+		// Webhook
+		UserGroup.GET("/user/webhooks", user.GetUserWebhooks)
+		UserGroup.POST("/user/webhooks", user.CreateUserWebhook)
+		UserGroup.PUT("/user/webhooks/:id", user.UpdateUserWebhook)
+		UserGroup.DELETE("/user/webhooks/:id", user.DeleteUserWebhook)
+		UserGroup.POST("/user/webhooks/:id/secret", user.RotateUserWebhookSecret)
+		UserGroup.POST("/user/webhooks/:id/test", user.TestUserWebhook)
+		UserGroup.GET("/user/webhooks/:id/deliveries", user.GetUserWebhookDeliveries)
+
+		// 数据导出
+		UserGroup.GET("/user/data-exports", user.GetDataExports)
+		UserGroup.POST("/user/data-exports", middleware.APIQuota(apiquota.ActionDataExport), user.CreateDataExport)
 
 		// 任务管理
 		UserGroup.GET("/user/tasks", user.GetUserTasks)
+		UserGroup.GET("/user/tasks/history", user.GetUserTaskHistory)
 		UserGroup.POST("/user/tasks/:taskId/cancel", user.CancelUserTask)
+		UserGroup.GET("/user/tasks/:taskId/events", user.GetUserTaskEvents)
 
 		// 流量统计API
 		trafficAPI := &traffic.UserTrafficAPI{}
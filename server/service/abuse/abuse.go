@@ -0,0 +1,347 @@
+// Package abuse 滥用举报与处置
+// 管理员把举报登记到实例或用户下，并设置处置状态：警告、限制申请新实例、暂停（冻结实例），
+// 每次处置都记录在举报下，冻结原因引用举报ID，解除处置时只解冻由该举报冻结的实例
+package abuse
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// freezeReasonPrefix 因举报冻结的实例的冻结原因前缀，完整格式为 abuse:<举报ID>
+const freezeReasonPrefix = "abuse:"
+
+// enforcementText 通知邮件中的处置说明，同时用于校验处置状态
+var enforcementText = map[string]string{
+	adminModel.AbuseEnforcementNone:      "相关处置已解除",
+	adminModel.AbuseEnforcementWarned:    "本次为警告，请尽快处理，否则可能被限制使用",
+	adminModel.AbuseEnforcementLimited:   "账户已被限制申请新实例",
+	adminModel.AbuseEnforcementSuspended: "相关实例已被停止并冻结",
+}
+
+// EnforcementResult 处置执行结果
+type EnforcementResult struct {
+	Report   *adminModel.AbuseReport `json:"report"`
+	Frozen   []uint                  `json:"frozen"`   // 本次冻结的实例
+	Unfrozen []uint                  `json:"unfrozen"` // 本次解冻的实例
+	Notified bool                    `json:"notified"` // 是否已邮件通知用户
+}
+
+// FreezeReason 返回举报对应的实例冻结原因
+func FreezeReason(reportID uint) string {
+	return freezeReasonPrefix + strconv.FormatUint(uint64(reportID), 10)
+}
+
+// CheckCreationAllowed 检查用户是否因举报被限制申请新实例
+func CheckCreationAllowed(userID uint) error {
+	var report adminModel.AbuseReport
+	err := global.APP_DB.Select("id").
+		Where("user_id = ? AND enforcement IN ?", userID,
+			[]string{adminModel.AbuseEnforcementLimited, adminModel.AbuseEnforcementSuspended}).
+		First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("检查账户限制状态失败: %v", err)
+	}
+	return fmt.Errorf("账户因滥用举报被限制申请新实例，请联系管理员（举报ID %d）", report.ID)
+}
+
+// List 分页查询举报
+func List(req adminModel.AbuseReportListRequest) ([]adminModel.AbuseReport, int64, error) {
+	query := global.APP_DB.Model(&adminModel.AbuseReport{})
+	if req.InstanceID != 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.UserID != 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Enforcement != "" {
+		query = query.Where("enforcement = ?", req.Enforcement)
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计举报数量失败: %v", err)
+	}
+	var reports []adminModel.AbuseReport
+	if err := utils.ApplyListPage(query.Order("id DESC"), req.PageInfo).Find(&reports).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询举报失败: %v", err)
+	}
+	return reports, total, nil
+}
+
+// Create 登记举报，针对实例时被举报用户为实例所有者
+func Create(operatorID uint, req adminModel.CreateAbuseReportRequest) (*adminModel.AbuseReport, error) {
+	report := adminModel.AbuseReport{
+		InstanceID:  req.InstanceID,
+		UserID:      req.UserID,
+		Source:      strings.TrimSpace(req.Source),
+		Category:    req.Category,
+		EvidenceURL: strings.TrimSpace(req.EvidenceURL),
+		Description: req.Description,
+		TicketRef:   strings.TrimSpace(req.TicketRef),
+		Status:      adminModel.AbuseStatusOpen,
+		Enforcement: adminModel.AbuseEnforcementNone,
+		CreatedBy:   operatorID,
+	}
+
+	if req.InstanceID != nil {
+		var instance providerModel.Instance
+		if err := global.APP_DB.Select("id, user_id").First(&instance, *req.InstanceID).Error; err != nil {
+			return nil, errors.New("实例不存在")
+		}
+		if req.UserID != 0 && req.UserID != instance.UserID {
+			return nil, errors.New("用户与实例所有者不一致")
+		}
+		report.UserID = instance.UserID
+	} else {
+		if req.UserID == 0 {
+			return nil, errors.New("请指定被举报的实例或用户")
+		}
+		var user userModel.User
+		if err := global.APP_DB.Select("id").First(&user, req.UserID).Error; err != nil {
+			return nil, errors.New("用户不存在")
+		}
+	}
+
+	if err := global.APP_DB.Create(&report).Error; err != nil {
+		return nil, fmt.Errorf("保存举报失败: %v", err)
+	}
+	global.APP_LOG.Info("登记滥用举报",
+		zap.Uint("reportId", report.ID),
+		zap.Uint("userId", report.UserID),
+		zap.String("category", report.Category),
+		zap.Uint("operatorId", operatorID))
+	return &report, nil
+}
+
+// Get 返回举报详情及处置记录
+func Get(id uint) (*adminModel.AbuseReportDetail, error) {
+	var detail adminModel.AbuseReportDetail
+	if err := global.APP_DB.First(&detail.AbuseReport, id).Error; err != nil {
+		return nil, errors.New("举报不存在")
+	}
+
+	var user userModel.User
+	if err := global.APP_DB.Unscoped().Select("id, username").First(&user, detail.UserID).Error; err == nil {
+		detail.Username = user.Username
+	}
+	if detail.InstanceID != nil {
+		var instance providerModel.Instance
+		if err := global.APP_DB.Unscoped().Select("id, name").First(&instance, *detail.InstanceID).Error; err == nil {
+			detail.InstanceName = instance.Name
+		}
+	}
+	if err := global.APP_DB.Where("report_id = ?", id).Order("id ASC").Find(&detail.Actions).Error; err != nil {
+		return nil, fmt.Errorf("查询处置记录失败: %v", err)
+	}
+	return &detail, nil
+}
+
+// Update 更新举报的状态、证据和工单关联，结案不会解除已有处置
+func Update(id uint, req adminModel.UpdateAbuseReportRequest) (*adminModel.AbuseReport, error) {
+	var report adminModel.AbuseReport
+	if err := global.APP_DB.First(&report, id).Error; err != nil {
+		return nil, errors.New("举报不存在")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Status != "" && req.Status != report.Status {
+		updates["status"] = req.Status
+		if req.Status == adminModel.AbuseStatusResolved {
+			updates["resolved_at"] = time.Now()
+		} else {
+			updates["resolved_at"] = nil
+		}
+	}
+	if req.EvidenceURL != "" {
+		updates["evidence_url"] = strings.TrimSpace(req.EvidenceURL)
+	}
+	if req.Description != "" {
+		updates["description"] = req.Description
+	}
+	if req.TicketRef != "" {
+		updates["ticket_ref"] = strings.TrimSpace(req.TicketRef)
+	}
+	if len(updates) == 0 {
+		return &report, nil
+	}
+	if err := global.APP_DB.Model(&report).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新举报失败: %v", err)
+	}
+	if err := global.APP_DB.First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SetEnforcement 设置举报的处置状态
+// 升级为暂停时冻结相关实例，从暂停降级时解冻由该举报冻结的实例；除撤销警告外都会邮件通知用户
+func SetEnforcement(id, operatorID uint, req adminModel.SetAbuseEnforcementRequest) (*EnforcementResult, error) {
+	var report adminModel.AbuseReport
+	if err := global.APP_DB.First(&report, id).Error; err != nil {
+		return nil, errors.New("举报不存在")
+	}
+	from, to := report.Enforcement, req.Enforcement
+	if from == "" {
+		from = adminModel.AbuseEnforcementNone
+	}
+	if _, ok := enforcementText[to]; !ok {
+		return nil, fmt.Errorf("无效的处置状态: %s", to)
+	}
+	if from == to {
+		return nil, errors.New("处置状态未变化")
+	}
+
+	result := &EnforcementResult{Report: &report}
+	err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if from == adminModel.AbuseEnforcementSuspended {
+			if result.Unfrozen, err = unfreezeInstances(tx, report.ID); err != nil {
+				return err
+			}
+		}
+		if to == adminModel.AbuseEnforcementSuspended {
+			if result.Frozen, err = freezeInstances(tx, &report); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&report).Update("enforcement", to).Error; err != nil {
+			return fmt.Errorf("更新处置状态失败: %v", err)
+		}
+		report.Enforcement = to
+
+		action := adminModel.AbuseReportAction{
+			ReportID:   report.ID,
+			OperatorID: operatorID,
+			FromState:  from,
+			ToState:    to,
+			Note:       req.Note,
+			Detail:     fmt.Sprintf("冻结 %d 个实例，解冻 %d 个实例", len(result.Frozen), len(result.Unfrozen)),
+		}
+		if err := tx.Create(&action).Error; err != nil {
+			return fmt.Errorf("保存处置记录失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 撤销警告不打扰用户，其余变更都通知
+	if to != adminModel.AbuseEnforcementNone || from != adminModel.AbuseEnforcementWarned {
+		result.Notified = notifyUser(&report, req.Note)
+	}
+
+	global.APP_LOG.Info("更新滥用举报处置状态",
+		zap.Uint("reportId", report.ID),
+		zap.Uint("userId", report.UserID),
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int("frozen", len(result.Frozen)),
+		zap.Int("unfrozen", len(result.Unfrozen)),
+		zap.Uint("operatorId", operatorID))
+	return result, nil
+}
+
+// freezeInstances 冻结举报相关的实例：针对实例时只冻结该实例，针对用户时冻结用户的所有实例
+// 已被冻结的实例保持原冻结原因不变
+func freezeInstances(tx *gorm.DB, report *adminModel.AbuseReport) ([]uint, error) {
+	query := tx.Model(&providerModel.Instance{}).Where("is_frozen = ?", false)
+	if report.InstanceID != nil {
+		query = query.Where("id = ?", *report.InstanceID)
+	} else {
+		query = query.Where("user_id = ?", report.UserID)
+	}
+	var ids []uint
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询待冻结实例失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := tx.Model(&providerModel.Instance{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"is_frozen":     true,
+		"frozen_at":     time.Now(),
+		"frozen_reason": FreezeReason(report.ID),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("冻结实例失败: %v", err)
+	}
+	return ids, nil
+}
+
+// unfreezeInstances 解冻由该举报冻结的实例
+func unfreezeInstances(tx *gorm.DB, reportID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Model(&providerModel.Instance{}).
+		Where("is_frozen = ? AND frozen_reason = ?", true, FreezeReason(reportID)).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询待解冻实例失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := tx.Model(&providerModel.Instance{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"is_frozen":     false,
+		"frozen_at":     nil,
+		"frozen_reason": "",
+	}).Error; err != nil {
+		return nil, fmt.Errorf("解冻实例失败: %v", err)
+	}
+	return ids, nil
+}
+
+// notifyUser 邮件通知用户举报处置结果，未配置邮件服务或用户未绑定邮箱时返回false
+func notifyUser(report *adminModel.AbuseReport, note string) bool {
+	var user userModel.User
+	if err := global.APP_DB.Select("id, username, email").First(&user, report.UserID).Error; err != nil {
+		return false
+	}
+	if user.Email == "" || !utils.MailConfigured() {
+		return false
+	}
+
+	target := "您的账户"
+	if report.InstanceID != nil {
+		var instance providerModel.Instance
+		if err := global.APP_DB.Unscoped().Select("id, name").First(&instance, *report.InstanceID).Error; err == nil {
+			target = "您的实例 " + html.EscapeString(instance.Name)
+		}
+	}
+	body := fmt.Sprintf("您好 %s，我们收到了关于%s的滥用举报（举报ID %d，类别 %s）。%s。",
+		html.EscapeString(user.Username), target, report.ID, html.EscapeString(report.Category), enforcementText[report.Enforcement])
+	if note != "" {
+		body += "<br>说明：" + html.EscapeString(note)
+	}
+	body += "<br>如有疑问请联系管理员并提供举报ID。"
+
+	if err := utils.SendMail(user.Email, "滥用举报处置通知", body); err != nil {
+		global.APP_LOG.Warn("发送滥用举报处置通知失败",
+			zap.Uint("reportId", report.ID),
+			zap.Uint("userId", user.ID),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
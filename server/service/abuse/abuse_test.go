@@ -0,0 +1,124 @@
+package abuse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+// setupAbuseDB 用户1拥有实例1、2（实例2已因到期冻结），用户2拥有实例3
+func setupAbuseDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &userModel.User{}, &providerModel.Instance{}, &adminModel.AbuseReport{}, &adminModel.AbuseReportAction{})
+	testutil.Seed(t, db,
+		&userModel.User{ID: 1, Username: "alice", Password: "x"},
+		&userModel.User{ID: 2, Username: "bob", Password: "x"},
+		&providerModel.Instance{ID: 1, Name: "a1", ProviderID: 1, UserID: 1},
+		&providerModel.Instance{ID: 2, Name: "a2", ProviderID: 1, UserID: 1, IsFrozen: true, FrozenReason: "expired"},
+		&providerModel.Instance{ID: 3, Name: "b1", ProviderID: 1, UserID: 2},
+	)
+	testutil.RestoreConfig(t)
+	global.APP_CONFIG.Auth.EmailSMTPHost = ""
+	return db
+}
+
+func TestCreateReport(t *testing.T) {
+	setupAbuseDB(t)
+	instanceID, missingInstance := uint(3), uint(99)
+	tests := []struct {
+		name     string
+		req      adminModel.CreateAbuseReportRequest
+		wantUser uint
+		wantErr  string
+	}{
+		{name: "针对实例时记录实例所有者", req: adminModel.CreateAbuseReportRequest{InstanceID: &instanceID, Category: "spam"}, wantUser: 2},
+		{name: "针对用户", req: adminModel.CreateAbuseReportRequest{UserID: 1, Category: "scan"}, wantUser: 1},
+		{name: "用户与实例所有者不一致", req: adminModel.CreateAbuseReportRequest{InstanceID: &instanceID, UserID: 1}, wantErr: "不一致"},
+		{name: "实例不存在", req: adminModel.CreateAbuseReportRequest{InstanceID: &missingInstance}, wantErr: "实例不存在"},
+		{name: "用户不存在", req: adminModel.CreateAbuseReportRequest{UserID: 99}, wantErr: "用户不存在"},
+		{name: "未指定对象", req: adminModel.CreateAbuseReportRequest{Category: "other"}, wantErr: "请指定"},
+	}
+	for _, tt := range tests {
+		report, err := Create(9, tt.req)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if report.UserID != tt.wantUser || report.Status != adminModel.AbuseStatusOpen || report.Enforcement != adminModel.AbuseEnforcementNone {
+			t.Errorf("%s: report = %+v", tt.name, report)
+		}
+	}
+}
+
+func TestSetEnforcement(t *testing.T) {
+	db := setupAbuseDB(t)
+	report, err := Create(9, adminModel.CreateAbuseReportRequest{UserID: 1, Category: "ddos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name          string
+		enforcement   string
+		wantErr       bool
+		frozen        []uint
+		unfrozen      []uint
+		creationAllow bool // 用户1此后能否申请新实例
+	}{
+		{name: "警告", enforcement: adminModel.AbuseEnforcementWarned, creationAllow: true},
+		{name: "状态未变化", enforcement: adminModel.AbuseEnforcementWarned, wantErr: true, creationAllow: true},
+		{name: "无效状态", enforcement: "banned", wantErr: true, creationAllow: true},
+		{name: "暂停只冻结未冻结的实例", enforcement: adminModel.AbuseEnforcementSuspended, frozen: []uint{1}},
+		{name: "降级为限制时解冻", enforcement: adminModel.AbuseEnforcementLimited, unfrozen: []uint{1}},
+		{name: "解除处置", enforcement: adminModel.AbuseEnforcementNone, creationAllow: true},
+	}
+	for _, step := range steps {
+		result, err := SetEnforcement(report.ID, 9, adminModel.SetAbuseEnforcementRequest{Enforcement: step.enforcement})
+		if step.wantErr {
+			if err == nil {
+				t.Fatalf("%s: 应拒绝", step.name)
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+			if !reflect.DeepEqual(result.Frozen, step.frozen) || !reflect.DeepEqual(result.Unfrozen, step.unfrozen) || result.Notified {
+				t.Errorf("%s: result = %+v", step.name, result)
+			}
+		}
+		if err := CheckCreationAllowed(1); (err == nil) != step.creationAllow {
+			t.Errorf("%s: CheckCreationAllowed(1) = %v, want allowed %v", step.name, err, step.creationAllow)
+		}
+		// 其他用户不受影响
+		if err := CheckCreationAllowed(2); err != nil {
+			t.Errorf("%s: 用户2被限制: %v", step.name, err)
+		}
+	}
+
+	var instances []providerModel.Instance
+	if err := db.Order("id").Find(&instances).Error; err != nil {
+		t.Fatal(err)
+	}
+	if instances[0].IsFrozen || !instances[1].IsFrozen || instances[1].FrozenReason != "expired" || instances[2].IsFrozen {
+		t.Errorf("冻结状态 = %v/%q %v/%q %v", instances[0].IsFrozen, instances[0].FrozenReason,
+			instances[1].IsFrozen, instances[1].FrozenReason, instances[2].IsFrozen)
+	}
+	var actions int64
+	db.Model(&adminModel.AbuseReportAction{}).Where("report_id = ?", report.ID).Count(&actions)
+	if actions != 4 {
+		t.Errorf("处置记录 = %d, want 4", actions)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"oneclickvirt/global"
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/user"
+	"oneclickvirt/service/hooks"
 	"oneclickvirt/service/scheduler"
 
 	"go.uber.org/zap"
@@ -98,6 +99,9 @@ func (s *FreezeManagementService) SetProviderExpiry(providerID uint, expiresAt t
 		zap.Uint("provider_id", providerID),
 		zap.Time("expires_at", expiresAt))
 
+	// 刷新跟随节点到期时间的实例MOTD
+	go hooks.RefreshProviderMOTD(providerID)
+
 	return nil
 }
 
@@ -130,6 +134,8 @@ func (s *FreezeManagementService) SetInstanceExpiry(instanceID uint, expiresAt t
 		zap.Uint("instance_id", instanceID),
 		zap.Time("expires_at", expiresAt))
 
+	go hooks.RefreshInstanceMOTD([]uint{instanceID})
+
 	return nil
 }
 
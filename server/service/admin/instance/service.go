@@ -6,16 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/imageeol"
+	"oneclickvirt/service/instancename"
 	"oneclickvirt/service/interfaces"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/model/admin"
 	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -45,6 +49,23 @@ func (s *Service) GetInstanceByID(instanceID uint) (*providerModel.Instance, err
 	return &instance, nil
 }
 
+// instanceListSpec 管理员实例列表可排序、过滤的字段
+var instanceListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":           "instances.id",
+		"name":         "instances.name",
+		"provider":     "instances.provider",
+		"providerId":   "instances.provider_id",
+		"status":       "instances.status",
+		"instanceType": "instances.instance_type",
+		"userId":       "instances.user_id",
+		"expiresAt":    "instances.expires_at",
+		"isFrozen":     "instances.is_frozen",
+		"createdAt":    "instances.created_at",
+	},
+	DefaultSort: "id",
+}
+
 // GetInstanceList 获取实例列表
 func (s *Service) GetInstanceList(req admin.InstanceListRequest) ([]admin.InstanceManageResponse, int64, error) {
 	var instances []providerModel.Instance
@@ -75,6 +96,13 @@ func (s *Service) GetInstanceList(req admin.InstanceListRequest) ([]admin.Instan
 	if req.UserID != 0 {
 		query = query.Where("user_id = ?", req.UserID)
 	}
+	if len(req.ProviderIDs) > 0 {
+		query = query.Where("instances.provider_id IN ?", req.ProviderIDs)
+	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, instanceListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// 先计数，避免不必要的数据查询
 	if err := query.Count(&total).Error; err != nil {
@@ -86,9 +114,11 @@ func (s *Service) GetInstanceList(req admin.InstanceListRequest) ([]admin.Instan
 		return []admin.InstanceManageResponse{}, 0, nil
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	// 只查询需要的字段，减少数据传输
-	if err := query.Offset(offset).Limit(req.PageSize).Find(&instances).Error; err != nil {
+	query, err = utils.ApplyListSort(query, req.PageInfo, instanceListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Select("instances.*").Find(&instances).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -160,8 +190,8 @@ func (s *Service) GetInstanceList(req admin.InstanceListRequest) ([]admin.Instan
 		sshPortMap[port.InstanceID] = port
 	}
 
-	// 批量查询实例当月流量历史数据
-	now := time.Now()
+	// 批量查询实例当月流量历史数据（按默认时区）
+	now := time.Now().In(utils.DefaultLocation())
 	year := now.Year()
 	month := int(now.Month())
 
@@ -217,6 +247,7 @@ func (s *Service) GetInstanceList(req admin.InstanceListRequest) ([]admin.Instan
 			HealthStatus:   "healthy",
 			UsedTrafficIn:  0,
 			UsedTrafficOut: 0,
+			OSEOLStatus:    imageeol.Status(instance.OSEOLDate, time.Now()),
 		}
 
 		// 从流量查询服务获取的数据中获取（已应用Provider的流量计算模式）
@@ -300,6 +331,7 @@ func (s *Service) CreateInstance(req admin.CreateInstanceRequest) error {
 		InstanceType:   req.InstanceType,
 		UserID:         req.UserID,
 		Status:         "creating",
+		GuestSSHPort:   resources.ResolveGuestSSHPort(&provider, req.InstanceType),
 		ExpiresAt:      &expiredAt,
 		IsManualExpiry: false,             // 默认跟随节点过期时间
 		PublicIP:       provider.Endpoint, // 设置公网IP为Provider的地址
@@ -310,6 +342,11 @@ func (s *Service) CreateInstance(req admin.CreateInstanceRequest) error {
 
 	// 在单个事务中创建实例并更新配额
 	return dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		// 预留实例名称
+		if err := instancename.Reserve(tx, provider.ID, req.Name, instancename.ReserveOptions{}); err != nil {
+			return err
+		}
+
 		// 创建实例
 		if err := tx.Create(&instance).Error; err != nil {
 			return fmt.Errorf("创建实例失败: %v", err)
@@ -351,6 +388,12 @@ func (s *Service) UpdateInstance(req admin.UpdateInstanceRequest) error {
 	instance.Memory = req.Memory
 	instance.Disk = req.Disk
 	instance.Status = req.Status
+	if req.StartupOrder != nil {
+		instance.StartupOrder = *req.StartupOrder
+	}
+	if req.StartupDelay != nil {
+		instance.StartupDelay = *req.StartupDelay
+	}
 
 	dbService := database.GetDatabaseService()
 	return dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
@@ -427,6 +470,11 @@ func (s *Service) InstanceAction(instanceID uint, req admin.InstanceActionReques
 		return fmt.Errorf("获取实例信息失败: %v", err)
 	}
 
+	// 校验状态迁移，如删除中的实例不能启动
+	if err := constant.ValidateInstanceAction(instance.Status, req.Action); err != nil {
+		return err
+	}
+
 	// 根据操作类型执行相应的操作
 	switch req.Action {
 	case "start", "stop", "restart", "reset":
@@ -453,13 +501,7 @@ func (s *Service) InstanceAction(instanceID uint, req admin.InstanceActionReques
 		}
 
 		// 更新实例状态
-		statusMap := map[string]string{
-			"start":   "starting",
-			"stop":    "stopping",
-			"restart": "restarting",
-			"reset":   "resetting",
-		}
-		if newStatus, exists := statusMap[req.Action]; exists {
+		if newStatus, exists := constant.GetInstanceActionStatus(req.Action); exists {
 			instance.Status = newStatus
 			if err := global.APP_DB.Save(&instance).Error; err != nil {
 				return fmt.Errorf("更新实例状态失败: %v", err)
@@ -492,7 +534,7 @@ func (s *Service) InstanceAction(instanceID uint, req admin.InstanceActionReques
 		}
 
 		// 更新实例状态为删除中
-		instance.Status = "deleting"
+		instance.Status = constant.InstanceStatusDeleting
 		if err := global.APP_DB.Save(&instance).Error; err != nil {
 			return fmt.Errorf("更新实例状态失败: %v", err)
 		}
@@ -12,6 +12,7 @@ import (
 	"oneclickvirt/model/admin"
 	"oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -137,7 +138,7 @@ func (s *Service) CreateInviteCode(req admin.CreateInviteCodeRequest, createdBy
 		var expiresAt *time.Time
 		if req.ExpiresAt != "" {
 			// 使用本地时区解析时间，避免时区问题
-			if parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpiresAt, time.Local); err == nil {
+			if parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpiresAt, utils.DefaultLocation()); err == nil {
 				expiresAt = &parsedTime
 				global.APP_LOG.Debug("解析自定义邀请码过期时间",
 					zap.String("input", req.ExpiresAt),
@@ -189,7 +190,7 @@ func (s *Service) CreateInviteCode(req admin.CreateInviteCodeRequest, createdBy
 		var expiresAt *time.Time
 		if req.ExpiresAt != "" {
 			// 使用本地时区解析时间，避免时区问题
-			if parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpiresAt, time.Local); err == nil {
+			if parsedTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpiresAt, utils.DefaultLocation()); err == nil {
 				expiresAt = &parsedTime
 				global.APP_LOG.Debug("解析批量邀请码过期时间",
 					zap.String("input", req.ExpiresAt),
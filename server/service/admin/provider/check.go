@@ -7,6 +7,7 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider/health"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/hostcompat"
 	"oneclickvirt/service/images"
 	provider2 "oneclickvirt/service/provider"
 	"strings"
@@ -226,6 +227,15 @@ func (s *Service) CheckProviderHealthWithOptions(providerID uint, forceRefresh b
 	return err
 }
 
+// GetHostCompatibility 获取Provider宿主机兼容性报告，按当前配置评估连接时保存的探测结果
+func (s *Service) GetHostCompatibility(providerID uint) (*hostcompat.Report, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+	return hostcompat.ReportFor(&provider), nil
+}
+
 // CheckProviderNameExists 检查Provider名称是否已存在
 func (s *Service) CheckProviderNameExists(name string, excludeId *uint) (bool, error) {
 	query := global.APP_DB.Model(&providerModel.Provider{}).Where("name = ?", name)
@@ -265,3 +275,19 @@ func (s *Service) CheckProviderEndpointExists(endpoint string, sshPort int, excl
 
 	return count > 0, nil
 }
+
+// GetHealthCheckHistory 获取Provider最近的健康检查记录，按时间倒序
+func (s *Service) GetHealthCheckHistory(providerID uint, limit int) ([]providerModel.ProviderHealthCheck, error) {
+	if err := global.APP_DB.Select("id").First(&providerModel.Provider{}, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+	var records []providerModel.ProviderHealthCheck
+	if err := global.APP_DB.Where("provider_id = ?", providerID).
+		Order("id DESC").Limit(limit).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询健康检查记录失败: %v", err)
+	}
+	return records, nil
+}
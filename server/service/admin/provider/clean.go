@@ -139,7 +139,13 @@ func (s *Service) DeleteProvider(providerID uint, forceDelete bool) error {
 				zap.Int64("count", instanceResult.RowsAffected))
 		}
 
-		// 5. 硬删除Provider本身
+		// 5. 删除健康检查记录
+		if err := tx.Where("provider_id = ?", providerID).Delete(&providerModel.ProviderHealthCheck{}).Error; err != nil {
+			global.APP_LOG.Error("删除Provider健康检查记录失败", zap.Error(err))
+			return err
+		}
+
+		// 6. 硬删除Provider本身
 		if err := tx.Unscoped().Delete(&providerModel.Provider{}, providerID).Error; err != nil {
 			global.APP_LOG.Error("删除Provider记录失败", zap.Error(err))
 			return err
@@ -153,10 +159,10 @@ func (s *Service) DeleteProvider(providerID uint, forceDelete bool) error {
 		return err
 	}
 
-	// 6. 事务外批量删除流量相关数据（避免长时间锁表）
+	// 7. 事务外批量删除流量相关数据（避免长时间锁表）
 	s.batchCleanupProviderTrafficData(providerID, instanceIDs)
 
-	// 7. 立即清理所有相关资源（防止内存泄漏）
+	// 8. 立即清理所有相关资源（防止内存泄漏）
 	s.cleanupAllProviderResources(providerID)
 
 	global.APP_LOG.Info("Provider及所有关联数据删除成功",
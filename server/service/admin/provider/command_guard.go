@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"oneclickvirt/utils"
+)
+
+const maxCommandGuardEntries = 100
+
+// 白名单中的程序名只允许常见的可执行文件名字符
+var commandNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.+\[-]{1,64}$`)
+
+// normalizeCommandGuardMode 校验命令白名单模式，off 视为关闭
+func normalizeCommandGuardMode(mode string) (string, error) {
+	switch mode = strings.TrimSpace(strings.ToLower(mode)); mode {
+	case "", "off":
+		return utils.CommandGuardOff, nil
+	case utils.CommandGuardLog, utils.CommandGuardBlock:
+		return mode, nil
+	}
+	return "", fmt.Errorf("命令白名单模式只能为 off、log 或 block")
+}
+
+// encodeCommandList 校验程序名列表并序列化为JSON，为空时返回空字符串
+func encodeCommandList(kind string, commands []string) (string, error) {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(commands))
+	for _, name := range commands {
+		if name = strings.TrimSpace(name); name == "" || seen[name] {
+			continue
+		}
+		if !commandNamePattern.MatchString(name) {
+			return "", fmt.Errorf("%s中的程序名 %q 不合法，只填写程序名，不含路径和参数", kind, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) > maxCommandGuardEntries {
+		return "", fmt.Errorf("%s最多 %d 项", kind, maxCommandGuardEntries)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
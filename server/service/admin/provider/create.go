@@ -4,10 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/blackout"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/hostevents"
+	"oneclickvirt/service/hwhealth"
+	"oneclickvirt/service/traffic"
 	"oneclickvirt/utils"
 	"time"
 
@@ -121,9 +126,24 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		MaxConcurrentTasks:    req.MaxConcurrentTasks,
 		TaskPollInterval:      req.TaskPollInterval,
 		EnableTaskPolling:     req.EnableTaskPolling,
+		// 宿主机重启后按启动顺序恢复实例
+		RecoverStoppedInstances: req.RecoverStoppedInstances,
 		// 存储配置（所有Provider类型通用）
 		StoragePool: req.StoragePool,
 		// StoragePoolPath 将在健康检查时自动检测并填充
+		// 系统预留资源
+		SystemReservedCPUPercent: req.SystemReservedCPUPercent,
+		SystemReservedMemory:     req.SystemReservedMemory,
+		SystemReservedDiskGB:     req.SystemReservedDiskGB,
+		// 硬件健康检查
+		HardwareTempThreshold: req.HardwareTempThreshold,
+		// 连接健康检查
+		HealthCheckInterval: req.HealthCheckInterval,
+		// 超售比例
+		ContainerCPUOvercommit:    normalizeOvercommit(req.ContainerCPUOvercommit),
+		ContainerMemoryOvercommit: normalizeOvercommit(req.ContainerMemoryOvercommit),
+		VMCPUOvercommit:           normalizeOvercommit(req.VMCPUOvercommit),
+		VMMemoryOvercommit:        normalizeOvercommit(req.VMMemoryOvercommit),
 		// 操作执行配置
 		ExecutionRule: req.ExecutionRule,
 		// 端口映射配置
@@ -131,6 +151,9 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 		PortRangeStart:   req.PortRangeStart,
 		PortRangeEnd:     req.PortRangeEnd,
 		NetworkType:      req.NetworkType,
+		// 实例内SSH端口配置
+		GuestSSHPort:       req.GuestSSHPort,
+		RandomGuestSSHPort: req.RandomGuestSSHPort,
 		// 带宽配置
 		DefaultInboundBandwidth:  req.DefaultInboundBandwidth,
 		DefaultOutboundBandwidth: req.DefaultOutboundBandwidth,
@@ -221,6 +244,53 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 			zap.String("providerName", req.Name))
 	}
 
+	// 镜像源改写规则
+	imageMirrors, err := encodeImageMirrors(req.ImageMirrors)
+	if err != nil {
+		return err
+	}
+	provider.ImageMirrors = imageMirrors
+
+	// 宿主机网卡
+	if provider.PublicInterface, err = normalizePublicInterface(req.PublicInterface); err != nil {
+		return err
+	}
+	if provider.Uplinks, err = encodeUplinks(req.Uplinks); err != nil {
+		return err
+	}
+
+	// 维护窗口
+	if provider.BlackoutWindows, err = blackout.EncodeProviderWindows(req.BlackoutWindows); err != nil {
+		return err
+	}
+	if provider.Timezone, err = utils.NormalizeTimezone(req.Timezone); err != nil {
+		return err
+	}
+
+	// 分时段流量计费倍率
+	if provider.TrafficMultiplierWindows, err = traffic.EncodeMultiplierWindows(req.TrafficMultiplierWindows); err != nil {
+		return err
+	}
+
+	// SSH命令白名单
+	if provider.CommandGuardMode, err = normalizeCommandGuardMode(req.CommandGuardMode); err != nil {
+		return err
+	}
+	if provider.CommandAllowlist, err = encodeCommandList("命令白名单", req.CommandAllowlist); err != nil {
+		return err
+	}
+	if provider.CommandDenylist, err = encodeCommandList("命令黑名单", req.CommandDenylist); err != nil {
+		return err
+	}
+
+	// LXD/Incus 项目与配置文件
+	if provider.Project, err = normalizeProject(req.Type, req.Project); err != nil {
+		return err
+	}
+	if provider.Profiles, err = encodeProfiles(req.Type, req.Profiles); err != nil {
+		return err
+	}
+
 	// 设置默认值
 	// 并发控制默认值：默认不允许并发，最大并发数为1
 	if !provider.AllowConcurrentTasks && provider.MaxConcurrentTasks <= 0 {
@@ -249,6 +319,9 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 	if provider.NetworkType == "" {
 		provider.NetworkType = "nat_ipv4"
 	}
+	if provider.GuestSSHPort <= 0 {
+		provider.GuestSSHPort = constant.SSHGuestPort
+	}
 	// 带宽配置默认值
 	if provider.DefaultInboundBandwidth <= 0 {
 		provider.DefaultInboundBandwidth = 300
@@ -280,11 +353,25 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 	if req.TrafficCollectInterval > 300 {
 		return fmt.Errorf("流量采集间隔不能超过300秒（5分钟），当前值: %d秒", req.TrafficCollectInterval)
 	}
+	// 硬件检查项校验
+	hardwareChecks, err := hwhealth.NormalizeChecks(req.HardwareChecks)
+	if err != nil {
+		return err
+	}
+	provider.HardwareChecks = hardwareChecks
+	hostEventSources, err := hostevents.NormalizeSources(req.HostEventSources)
+	if err != nil {
+		return err
+	}
+	provider.HostEventSources = hostEventSources
 	// 端口映射方式默认值
-	// Docker 类型固定使用 native
-	if provider.Type == "docker" {
+	// Docker/Kubernetes 类型固定使用 native，LXC 类型固定使用 iptables
+	if provider.Type == "docker" || provider.Type == "kubernetes" {
 		provider.IPv4PortMappingMethod = "native"
 		provider.IPv6PortMappingMethod = "native"
+	} else if provider.Type == "lxc" {
+		provider.IPv4PortMappingMethod = "iptables"
+		provider.IPv6PortMappingMethod = "iptables"
 	} else {
 		if provider.IPv4PortMappingMethod == "" {
 			provider.IPv4PortMappingMethod = "device_proxy" // 默认device_proxy
@@ -306,9 +393,8 @@ func (s *Service) CreateProvider(req admin.CreateProviderRequest) error {
 	}
 	provider.NextAvailablePort = provider.PortRangeStart
 
-	// 初始化流量重置时间为下个月的1号
-	now := time.Now()
-	nextReset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	// 初始化流量重置时间为Provider时区的下个月1号
+	nextReset := utils.NextMonthStart(time.Now(), utils.LoadLocation(provider.Timezone))
 	provider.TrafficResetAt = &nextReset
 
 	dbService := database.GetDatabaseService()
@@ -406,3 +492,11 @@ func (s *Service) discoverAndImportInstances(providerID uint, autoImport, autoAd
 			zap.Int("portConflicts", importResult.PortConflicts))
 	}
 }
+
+// normalizeOvercommit 超售比例未填写时按1（不超售）保存
+func normalizeOvercommit(ratio float64) float64 {
+	if ratio < 1 {
+		return 1
+	}
+	return ratio
+}
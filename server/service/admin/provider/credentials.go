@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// sshPoolRetireGrace 凭据轮换后连接池中旧SSH连接的保留时间，供正在执行的命令完成
+const sshPoolRetireGrace = 5 * time.Minute
+
+// credentialRotationLocks 按Provider串行化凭据轮换，providerID -> *sync.Mutex
+var credentialRotationLocks sync.Map
+
+// RotateProviderCredentials 轮换Provider的SSH凭据
+// 流程：测试新凭据 -> 原子更新数据库 -> 切换已加载的Provider实例 -> 摘除连接池中的旧连接
+// 进行中的任务继续使用旧连接，直到任务结束或超过保留时间
+func (s *Service) RotateProviderCredentials(providerID uint, req admin.RotateProviderCredentialsRequest) (*admin.RotateProviderCredentialsResponse, error) {
+	if req.Password == "" && req.SSHKey == "" {
+		return nil, fmt.Errorf("必须提供SSH密码或SSH密钥其中一种认证方式")
+	}
+
+	lockValue, _ := credentialRotationLocks.LoadOrStore(providerID, &sync.Mutex{})
+	lock := lockValue.(*sync.Mutex)
+	if !lock.TryLock() {
+		return nil, fmt.Errorf("该Provider正在轮换凭据，请稍后再试")
+	}
+	defer lock.Unlock()
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Provider不存在")
+		}
+		return nil, fmt.Errorf("查询Provider失败: %v", err)
+	}
+
+	username := req.Username
+	if username == "" {
+		username = provider.Username
+	}
+	sshPort := provider.SSHPort
+	if sshPort == 0 {
+		sshPort = 22
+	}
+	connectTimeout := provider.SSHConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 30
+	}
+
+	// 1. 使用新凭据测试连接，失败时不修改任何数据
+	sshConfig := utils.SSHConfig{
+		Host:           utils.ExtractHost(provider.Endpoint),
+		Port:           sshPort,
+		Username:       username,
+		Password:       req.Password,
+		PrivateKey:     req.SSHKey,
+		ConnectTimeout: time.Duration(connectTimeout) * time.Second,
+	}
+	_, _, avgLatency, err := utils.TestSSHConnectionLatency(sshConfig, 1)
+	if err != nil {
+		global.APP_LOG.Warn("新凭据SSH连接测试失败，凭据未修改",
+			zap.Uint("providerID", providerID),
+			zap.Error(err))
+		return nil, fmt.Errorf("新凭据SSH连接测试失败: %v", err)
+	}
+
+	// 2. 单条UPDATE原子替换凭据，Select确保空值也被写入
+	if err := global.APP_DB.Model(&providerModel.Provider{}).
+		Where("id = ?", providerID).
+		Select("username", "password", "ssh_key").
+		Updates(map[string]interface{}{
+			"username": username,
+			"password": req.Password,
+			"ssh_key":  req.SSHKey,
+		}).Error; err != nil {
+		return nil, fmt.Errorf("保存新凭据失败: %v", err)
+	}
+
+	provider.Password = req.Password
+	provider.SSHKey = req.SSHKey
+	resp := &admin.RotateProviderCredentialsResponse{
+		ProviderID:  providerID,
+		AuthMethod:  provider.GetAuthMethod(),
+		TestLatency: avgLatency.Milliseconds(),
+	}
+
+	// 3. 切换Provider实例（包含其健康检查器），旧实例待任务结束后断开
+	if err := providerService.GetProviderService().SwapProvider(providerID); err != nil {
+		global.APP_LOG.Warn("凭据已保存，但切换Provider连接失败",
+			zap.Uint("providerID", providerID),
+			zap.Error(err))
+		resp.ReconnectError = err.Error()
+	} else {
+		resp.Reconnected = true
+	}
+
+	// 4. 摘除连接池中的旧连接，后续请求按新配置重建
+	if global.APP_SSH_POOL != nil {
+		if pool, ok := global.APP_SSH_POOL.(interface {
+			Retire(uint, time.Duration)
+		}); ok {
+			pool.Retire(providerID, sshPoolRetireGrace)
+		}
+	}
+
+	global.APP_LOG.Info("Provider SSH凭据轮换完成",
+		zap.Uint("providerID", providerID),
+		zap.String("authMethod", resp.AuthMethod),
+		zap.Bool("usernameChanged", username != provider.Username),
+		zap.Bool("reconnected", resp.Reconnected))
+
+	return resp, nil
+}
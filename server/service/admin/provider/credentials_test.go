@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/testutil"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// startTestSSHServer 启动只接受指定密码的SSH服务，对任意命令输出 test 并以0退出，返回监听端口
+func startTestSSHServer(t *testing.T, password string) int {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, errors.New("密码错误")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "不支持的通道")
+			continue
+		}
+		channel, reqs, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range reqs {
+				req.Reply(true, nil)
+				if req.Type == "exec" || req.Type == "shell" {
+					channel.Write([]byte("test\n"))
+					status := make([]byte, 4)
+					binary.BigEndian.PutUint32(status, 0)
+					channel.SendRequest("exit-status", false, status)
+					channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+func useCredentialsDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &providerModel.Provider{})
+	oldPool := global.APP_SSH_POOL
+	global.APP_SSH_POOL = nil
+	t.Cleanup(func() { global.APP_SSH_POOL = oldPool })
+	return db
+}
+
+func TestRotateProviderCredentials(t *testing.T) {
+	db := useCredentialsDB(t)
+	port := startTestSSHServer(t, "new-secret")
+	provider := providerModel.Provider{
+		Name: "node", Type: "test", Endpoint: "127.0.0.1", SSHPort: port,
+		Username: "root", Password: "old-secret", SSHConnectTimeout: 5,
+	}
+	if err := db.Create(&provider).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		providerID uint
+		req        admin.RotateProviderCredentialsRequest
+		wantErr    string
+		password   string // 操作后数据库中的密码
+	}{
+		{name: "未提供凭据", providerID: provider.ID, wantErr: "必须提供", password: "old-secret"},
+		{name: "Provider不存在", providerID: 999, req: admin.RotateProviderCredentialsRequest{Password: "new-secret"}, wantErr: "Provider不存在", password: "old-secret"},
+		{name: "新凭据无法登录时不修改", providerID: provider.ID, req: admin.RotateProviderCredentialsRequest{Password: "wrong"}, wantErr: "连接测试失败", password: "old-secret"},
+		{name: "新凭据可登录", providerID: provider.ID, req: admin.RotateProviderCredentialsRequest{Password: "new-secret"}, password: "new-secret"},
+	}
+	s := NewService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.RotateProviderCredentials(tt.providerID, tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.ProviderID != provider.ID || resp.AuthMethod != "password" {
+					t.Errorf("resp = %+v", resp)
+				}
+			}
+			var saved providerModel.Provider
+			if err := db.First(&saved, provider.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if saved.Password != tt.password || saved.Username != "root" {
+				t.Errorf("保存的凭据 = %s/%s, want root/%s", saved.Username, saved.Password, tt.password)
+			}
+		})
+	}
+}
+
+func TestRotateProviderCredentialsConcurrent(t *testing.T) {
+	useCredentialsDB(t)
+	// 同一Provider正在轮换时拒绝新的轮换请求
+	lockValue, _ := credentialRotationLocks.LoadOrStore(uint(42), &sync.Mutex{})
+	lock := lockValue.(*sync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+	if _, err := NewService().RotateProviderCredentials(42, admin.RotateProviderCredentialsRequest{Password: "x"}); err == nil || !strings.Contains(err.Error(), "正在轮换") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+)
+
+// maxImageMirrorRules 每个Provider最多配置的改写规则数
+const maxImageMirrorRules = 20
+
+// ImageMirrorTestRequest 镜像源测试请求
+type ImageMirrorTestRequest struct {
+	ProviderID uint                            `json:"-"`
+	ImageURL   string                          `json:"imageUrl" binding:"required"` // 镜像的原始下载地址
+	Mirrors    []providerModel.ImageMirrorRule `json:"mirrors"`                     // 待测试的规则，为空时使用Provider已保存的规则
+	Timeout    int                             `json:"timeout"`                     // 单个地址的测试超时（秒），默认15，最大60
+}
+
+// ImageMirrorTestResult 镜像源测试结果
+type ImageMirrorTestResult struct {
+	ProviderID   uint                  `json:"providerId"`
+	ProviderName string                `json:"providerName"`
+	ImageURL     string                `json:"imageUrl"`
+	Selected     string                `json:"selected"` // 按当前规则下载时使用的地址，镜像均不可用时为空，此时回退到CDN或原始地址
+	Probes       []utils.DownloadProbe `json:"probes"`   // 各镜像地址及原始地址的测试结果
+}
+
+// encodeImageMirrors 校验并序列化镜像源规则，规则为空时返回空字符串
+func encodeImageMirrors(rules []providerModel.ImageMirrorRule) (string, error) {
+	if err := validateImageMirrors(rules); err != nil {
+		return "", err
+	}
+	if len(rules) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return "", fmt.Errorf("镜像源规则格式错误: %v", err)
+	}
+	return string(data), nil
+}
+
+// validateImageMirrors 校验规则：前缀和镜像地址都必须是http(s)地址
+func validateImageMirrors(rules []providerModel.ImageMirrorRule) error {
+	if len(rules) > maxImageMirrorRules {
+		return fmt.Errorf("镜像源规则最多 %d 条", maxImageMirrorRules)
+	}
+	for i := range rules {
+		rules[i].Prefix = strings.TrimSpace(rules[i].Prefix)
+		if !isHTTPURL(rules[i].Prefix) {
+			return fmt.Errorf("镜像源规则%d: 前缀 %q 必须是 http(s) 地址", i+1, rules[i].Prefix)
+		}
+		mirrors := make([]string, 0, len(rules[i].Mirrors))
+		for _, mirror := range rules[i].Mirrors {
+			mirror = strings.TrimSpace(mirror)
+			if mirror == "" {
+				continue
+			}
+			if !isHTTPURL(mirror) {
+				return fmt.Errorf("镜像源规则%d: 镜像地址 %q 必须是 http(s) 地址", i+1, mirror)
+			}
+			mirrors = append(mirrors, mirror)
+		}
+		if len(mirrors) == 0 {
+			return fmt.Errorf("镜像源规则%d: 至少需要一个镜像地址", i+1)
+		}
+		rules[i].Mirrors = mirrors
+	}
+	return nil
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// TestImageMirrors 在Provider宿主机上试下载镜像，验证镜像源规则是否可用
+// 依次测试规则改写出的各镜像地址和原始地址，不会修改已保存的配置
+func (s *Service) TestImageMirrors(ctx context.Context, req ImageMirrorTestRequest) (*ImageMirrorTestResult, error) {
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.First(&providerInfo, req.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("获取Provider信息失败: %w", err)
+	}
+	if !isHTTPURL(req.ImageURL) {
+		return nil, fmt.Errorf("镜像地址必须是 http(s) 地址")
+	}
+
+	rules := req.Mirrors
+	if len(rules) > 0 {
+		if err := validateImageMirrors(rules); err != nil {
+			return nil, err
+		}
+	} else {
+		saved, err := providerInfo.GetImageMirrors()
+		if err != nil {
+			return nil, fmt.Errorf("已保存的镜像源规则格式错误: %w", err)
+		}
+		rules = saved
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = 15
+	}
+	if timeout > 60 {
+		timeout = 60
+	}
+
+	providerInstance, err := provider2.GetProviderInstanceByID(providerInfo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Provider实例失败: %w", err)
+	}
+	executor := sshCommandExecutor{ctx: ctx, prov: providerInstance}
+
+	result := &ImageMirrorTestResult{
+		ProviderID:   providerInfo.ID,
+		ProviderName: providerInfo.Name,
+		ImageURL:     req.ImageURL,
+		Probes:       []utils.DownloadProbe{},
+	}
+	for _, candidate := range append(utils.MirrorCandidates(req.ImageURL, rules), req.ImageURL) {
+		probe := utils.ProbeDownloadURL(executor, candidate, timeout)
+		result.Probes = append(result.Probes, probe)
+		if probe.OK && result.Selected == "" && candidate != req.ImageURL {
+			result.Selected = candidate
+		}
+	}
+	return result, nil
+}
+
+// sshCommandExecutor 通过Provider的SSH连接执行命令
+type sshCommandExecutor struct {
+	ctx  context.Context
+	prov provider.Provider
+}
+
+func (e sshCommandExecutor) Execute(cmd string) (string, error) {
+	return e.prov.ExecuteSSHCommand(e.ctx, cmd)
+}
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+)
+
+func TestImageMirrorRules(t *testing.T) {
+	rules := []providerModel.ImageMirrorRule{
+		{Prefix: " https://github.com/ ", Mirrors: []string{"https://mirror-a.example/gh/", " ", "http://mirror-b.example/"}},
+		{Prefix: "https://cdn.example/", Mirrors: []string{"https://mirror-c.example/"}},
+	}
+	if err := validateImageMirrors(rules); err != nil {
+		t.Fatalf("validateImageMirrors: %v", err)
+	}
+
+	got := utils.MirrorCandidates("https://github.com/org/repo/image.tar.xz", rules)
+	want := []string{"https://mirror-a.example/gh/org/repo/image.tar.xz", "http://mirror-b.example/org/repo/image.tar.xz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MirrorCandidates = %v, want %v", got, want)
+	}
+	if got := utils.MirrorCandidates("https://other.example/image.tar.xz", rules); len(got) != 0 {
+		t.Fatalf("MirrorCandidates without match = %v", got)
+	}
+
+	for _, bad := range [][]providerModel.ImageMirrorRule{
+		{{Prefix: "github.com/", Mirrors: []string{"https://mirror.example/"}}},
+		{{Prefix: "https://github.com/", Mirrors: []string{"ftp://mirror.example/"}}},
+		{{Prefix: "https://github.com/", Mirrors: []string{" "}}},
+	} {
+		if err := validateImageMirrors(bad); err == nil {
+			t.Fatalf("validateImageMirrors(%v) should fail", bad)
+		}
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
 	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/startup"
 
 	"go.uber.org/zap"
 )
@@ -199,9 +201,12 @@ func (s *Service) CompareInstancesWithRemote(ctx context.Context, providerID uin
 		}
 	}
 
-	// 检测状态变化的实例
+	// 检测状态变化的实例（远程状态已由Provider标准化；操作进行中的实例状态不一致属正常，跳过）
 	for uuid, remoteInst := range remoteInstanceMap {
 		if dbInst, exists := dbInstanceMap[uuid]; exists {
+			if constant.IsInstanceOperationInProgress(dbInst.Status) || remoteInst.Status == constant.InstanceStatusUnknown {
+				continue
+			}
 			if dbInst.Status != remoteInst.Status {
 				changedInstances = append(changedInstances, InstanceChange{
 					InstanceID: dbInst.ID,
@@ -254,3 +259,24 @@ type InstanceChange struct {
 	OldStatus  string `json:"oldStatus"`
 	NewStatus  string `json:"newStatus"`
 }
+
+// StoppedUnexpectedly 返回面板记录为运行中、宿主机上已停止的实例ID
+func StoppedUnexpectedly(report *InstanceSyncReport) []uint {
+	var ids []uint
+	for _, change := range report.ChangedInstances {
+		if change.OldStatus == constant.InstanceStatusRunning && change.NewStatus == constant.InstanceStatusStopped {
+			ids = append(ids, change.InstanceID)
+		}
+	}
+	return ids
+}
+
+// RecoverStoppedInstances 比对宿主机实例状态，按启动顺序启动面板记录为运行中、宿主机上已停止的实例
+// 返回排队启动的实例数
+func (s *Service) RecoverStoppedInstances(ctx context.Context, providerID uint) (int, error) {
+	report, err := s.CompareInstancesWithRemote(ctx, providerID)
+	if err != nil {
+		return 0, err
+	}
+	return startup.Recover(providerID, StoppedUnexpectedly(report), "管理员手动恢复")
+}
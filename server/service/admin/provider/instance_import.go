@@ -9,6 +9,7 @@ import (
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
+	"oneclickvirt/service/instancename"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -228,7 +229,12 @@ func (s *Service) ImportDiscoveredInstances(ctx context.Context, options ImportO
 				DiscoveredData:     string(rawDataBytes),
 			}
 
-			if err := tx.Create(&instance).Error; err != nil {
+			// 宿主机上已存在的实例不受重用冷却期限制
+			err := instancename.Reserve(tx, options.ProviderID, discovered.Name, instancename.ReserveOptions{IgnoreCooldown: true})
+			if err == nil {
+				err = tx.Create(&instance).Error
+			}
+			if err != nil {
 				result.FailedCount++
 				importDetail.Status = "failed"
 				importDetail.Error = err.Error()
@@ -0,0 +1,475 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// PortImportOptions 端口映射导入选项
+type PortImportOptions struct {
+	ProviderID uint     `json:"providerId"` // Provider ID
+	Sources    []string `json:"sources"`    // 扫描来源：proxy(incus/lxd proxy设备), iptables(DNAT规则)，为空时按Provider类型自动选择
+	DryRun     bool     `json:"dryRun"`     // 仅预览，不写入数据库
+}
+
+// PortImportResult 端口映射导入结果
+type PortImportResult struct {
+	ProviderID     uint               `json:"providerId"`
+	ProviderName   string             `json:"providerName"`
+	DryRun         bool               `json:"dryRun"`
+	ScannedRules   int                `json:"scannedRules"`     // 解析到的规则数（TCP/UDP合并后）
+	ImportedCount  int                `json:"importedCount"`    // 导入数（预览模式下为可导入数）
+	SkippedCount   int                `json:"skippedCount"`     // 跳过数（已纳管）
+	ConflictCount  int                `json:"conflictCount"`    // 冲突数（端口已被其他实例占用）
+	UnmatchedCount int                `json:"unmatchedCount"`   // 未匹配到实例的规则数
+	Details        []ImportedPortInfo `json:"details"`          // 导入详情
+	Errors         []string           `json:"errors,omitempty"` // 扫描错误
+	ImportedAt     time.Time          `json:"importedAt"`
+}
+
+// ImportedPortInfo 单条端口规则的导入信息
+type ImportedPortInfo struct {
+	Source       string `json:"source"` // proxy, iptables
+	Rule         string `json:"rule"`   // 原始规则（设备名或iptables规则）
+	InstanceID   uint   `json:"instanceId,omitempty"`
+	InstanceName string `json:"instanceName,omitempty"`
+	HostPort     int    `json:"hostPort"`
+	HostPortEnd  int    `json:"hostPortEnd"`
+	GuestPort    int    `json:"guestPort"`
+	GuestPortEnd int    `json:"guestPortEnd"`
+	Protocol     string `json:"protocol"`
+	PortID       uint   `json:"portId,omitempty"`
+	Status       string `json:"status"` // imported, planned, skipped, conflict, unmatched, failed
+	Reason       string `json:"reason,omitempty"`
+}
+
+// hostPortRule 从宿主机解析出的端口转发规则
+type hostPortRule struct {
+	source       string
+	rule         string
+	instanceName string // proxy设备所属实例
+	targetIP     string // DNAT目标地址
+	protocol     string
+	hostPort     int
+	hostPortEnd  int // 0表示单端口
+	guestPort    int
+	guestPortEnd int
+}
+
+// ImportHostPortMappings 扫描宿主机上已有的proxy设备和iptables DNAT规则，匹配到实例后创建端口映射记录
+// 只写数据库，不修改宿主机上的规则，便于接管存量主机
+func (s *Service) ImportHostPortMappings(ctx context.Context, options PortImportOptions) (*PortImportResult, error) {
+	var providerInfo providerModel.Provider
+	if err := global.APP_DB.First(&providerInfo, options.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("获取Provider信息失败: %w", err)
+	}
+
+	result := &PortImportResult{
+		ProviderID:   providerInfo.ID,
+		ProviderName: providerInfo.Name,
+		DryRun:       options.DryRun,
+		Details:      []ImportedPortInfo{},
+		ImportedAt:   time.Now(),
+	}
+
+	sources := options.Sources
+	if len(sources) == 0 {
+		switch providerInfo.Type {
+		case "lxd", "incus":
+			sources = []string{"proxy", "iptables"}
+		case "proxmox":
+			sources = []string{"iptables"}
+		default:
+			return nil, fmt.Errorf("%s 类型的Provider不支持导入端口映射", providerInfo.Type)
+		}
+	}
+
+	providerInstance, err := provider2.GetProviderInstanceByID(providerInfo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Provider实例失败: %w", err)
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("provider_id = ? AND status NOT IN ?", providerInfo.ID,
+		[]string{"deleted", "deleting"}).Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("查询实例失败: %w", err)
+	}
+
+	// 1. 扫描宿主机规则
+	var rules []hostPortRule
+	for _, source := range sources {
+		switch source {
+		case "proxy":
+			if providerInfo.Type != "lxd" && providerInfo.Type != "incus" {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s 类型的Provider没有proxy设备", providerInfo.Type))
+				continue
+			}
+			cli := "incus"
+			if providerInfo.Type == "lxd" {
+				cli = "lxc"
+			}
+			for _, inst := range instances {
+				output, err := providerInstance.ExecuteSSHCommand(ctx, fmt.Sprintf("%s config device show %s", cli, inst.Name))
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("读取实例 %s 的设备失败: %v", inst.Name, err))
+					continue
+				}
+				parsed, err := parseProxyDevices(inst.Name, output)
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("解析实例 %s 的设备失败: %v", inst.Name, err))
+					continue
+				}
+				rules = append(rules, parsed...)
+			}
+		case "iptables":
+			output, err := providerInstance.ExecuteSSHCommand(ctx, "iptables-save -t nat")
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("读取iptables规则失败: %v", err))
+				continue
+			}
+			rules = append(rules, parseIptablesDNAT(output)...)
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("不支持的扫描来源: %s", source))
+		}
+	}
+	rules = mergeProtocolRules(rules)
+	result.ScannedRules = len(rules)
+
+	// 2. 匹配实例：proxy设备按实例名，DNAT规则按内网IP
+	instanceByName := make(map[string]*providerModel.Instance, len(instances))
+	instanceByIP := make(map[string]*providerModel.Instance, len(instances))
+	hasSSHPort := make(map[uint]bool)
+	for i := range instances {
+		instanceByName[instances[i].Name] = &instances[i]
+		if instances[i].PrivateIP != "" {
+			instanceByIP[instances[i].PrivateIP] = &instances[i]
+		}
+	}
+
+	var existingPorts []providerModel.Port
+	if err := global.APP_DB.Where("provider_id = ? AND status IN ?", providerInfo.ID,
+		[]string{"active", "pending"}).Find(&existingPorts).Error; err != nil {
+		return nil, fmt.Errorf("查询已有端口映射失败: %w", err)
+	}
+	for _, port := range existingPorts {
+		if port.IsSSH {
+			hasSSHPort[port.InstanceID] = true
+		}
+	}
+
+	// 3. 逐条导入，单条规则使用独立事务，失败不影响其他规则
+	for _, rule := range rules {
+		detail := ImportedPortInfo{
+			Source:       rule.source,
+			Rule:         rule.rule,
+			HostPort:     rule.hostPort,
+			HostPortEnd:  rule.hostPortEnd,
+			GuestPort:    rule.guestPort,
+			GuestPortEnd: rule.guestPortEnd,
+			Protocol:     rule.protocol,
+		}
+
+		var instance *providerModel.Instance
+		if rule.instanceName != "" {
+			instance = instanceByName[rule.instanceName]
+		} else {
+			instance = instanceByIP[rule.targetIP]
+		}
+		if instance == nil {
+			detail.Status = "unmatched"
+			detail.Reason = "未找到对应的实例"
+			if rule.targetIP != "" {
+				detail.Reason = fmt.Sprintf("未找到内网IP为 %s 的实例", rule.targetIP)
+			}
+			result.UnmatchedCount++
+			result.Details = append(result.Details, detail)
+			continue
+		}
+		detail.InstanceID = instance.ID
+		detail.InstanceName = instance.Name
+
+		if owner, overlap := findOverlappingPort(existingPorts, rule); overlap {
+			if owner.InstanceID == instance.ID {
+				detail.Status = "skipped"
+				detail.Reason = "端口映射已纳管"
+				detail.PortID = owner.ID
+				result.SkippedCount++
+			} else {
+				detail.Status = "conflict"
+				detail.Reason = fmt.Sprintf("宿主机端口已分配给实例ID %d", owner.InstanceID)
+				result.ConflictCount++
+			}
+			result.Details = append(result.Details, detail)
+			continue
+		}
+
+		portCount := 1
+		if rule.hostPortEnd > rule.hostPort {
+			portCount = rule.hostPortEnd - rule.hostPort + 1
+		}
+		mappingMethod := "iptables"
+		if rule.source == "proxy" {
+			mappingMethod = "device_proxy"
+		}
+		isSSH := rule.guestPort == 22 && portCount == 1 && !hasSSHPort[instance.ID]
+		port := providerModel.Port{
+			InstanceID:    instance.ID,
+			ProviderID:    providerInfo.ID,
+			HostPort:      rule.hostPort,
+			HostPortEnd:   rule.hostPortEnd,
+			GuestPort:     rule.guestPort,
+			GuestPortEnd:  rule.guestPortEnd,
+			PortCount:     portCount,
+			Protocol:      rule.protocol,
+			Status:        "active",
+			Description:   "导入: " + rule.rule,
+			IsSSH:         isSSH,
+			IsAutomatic:   false,
+			PortType:      "manual",
+			MappingMethod: mappingMethod,
+		}
+		if len(port.Description) > 256 {
+			port.Description = port.Description[:256]
+		}
+
+		if options.DryRun {
+			detail.Status = "planned"
+		} else {
+			err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&port).Error; err != nil {
+					return err
+				}
+				if isSSH && instance.SSHPort == 0 {
+					return tx.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).
+						Update("ssh_port", port.HostPort).Error
+				}
+				return nil
+			})
+			if err != nil {
+				detail.Status = "failed"
+				detail.Reason = err.Error()
+				result.Details = append(result.Details, detail)
+				global.APP_LOG.Error("导入端口映射失败",
+					zap.Uint("instanceId", instance.ID),
+					zap.Int("hostPort", rule.hostPort),
+					zap.Error(err))
+				continue
+			}
+			detail.Status = "imported"
+			detail.PortID = port.ID
+		}
+
+		if isSSH {
+			hasSSHPort[instance.ID] = true
+		}
+		existingPorts = append(existingPorts, port)
+		result.ImportedCount++
+		result.Details = append(result.Details, detail)
+	}
+
+	global.APP_LOG.Info("宿主机端口映射导入完成",
+		zap.Uint("providerId", providerInfo.ID),
+		zap.Bool("dryRun", options.DryRun),
+		zap.Int("scanned", result.ScannedRules),
+		zap.Int("imported", result.ImportedCount),
+		zap.Int("skipped", result.SkippedCount),
+		zap.Int("conflicts", result.ConflictCount),
+		zap.Int("unmatched", result.UnmatchedCount))
+
+	return result, nil
+}
+
+// findOverlappingPort 查找与规则宿主机端口重叠且协议相交的已有端口映射
+func findOverlappingPort(ports []providerModel.Port, rule hostPortRule) (providerModel.Port, bool) {
+	ruleEnd := rule.hostPort
+	if rule.hostPortEnd > ruleEnd {
+		ruleEnd = rule.hostPortEnd
+	}
+	for _, port := range ports {
+		portEnd := port.HostPort
+		if port.HostPortEnd > portEnd {
+			portEnd = port.HostPortEnd
+		}
+		if port.HostPort > ruleEnd || portEnd < rule.hostPort {
+			continue
+		}
+		if port.Protocol == rule.protocol || port.Protocol == "both" || rule.protocol == "both" {
+			return port, true
+		}
+	}
+	return providerModel.Port{}, false
+}
+
+// mergeProtocolRules 将同一目标、同一端口的TCP和UDP规则合并为both，并按宿主机端口排序
+func mergeProtocolRules(rules []hostPortRule) []hostPortRule {
+	type ruleKey struct {
+		target                  string
+		hostPort, hostPortEnd   int
+		guestPort, guestPortEnd int
+		source                  string
+	}
+	merged := make([]hostPortRule, 0, len(rules))
+	index := make(map[ruleKey]int)
+	for _, rule := range rules {
+		key := ruleKey{rule.instanceName + "|" + rule.targetIP, rule.hostPort, rule.hostPortEnd, rule.guestPort, rule.guestPortEnd, rule.source}
+		if i, ok := index[key]; ok {
+			if merged[i].protocol != rule.protocol {
+				merged[i].protocol = "both"
+				merged[i].rule += ", " + rule.rule
+			}
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, rule)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].hostPort < merged[j].hostPort })
+	return merged
+}
+
+// parseProxyDevices 解析 `incus/lxc config device show` 输出中的proxy设备
+// 例如 listen: tcp:0.0.0.0:20000-20010，connect: tcp:0.0.0.0:20000-20010
+func parseProxyDevices(instanceName, output string) ([]hostPortRule, error) {
+	devices := make(map[string]map[string]string)
+	if err := yaml.Unmarshal([]byte(output), &devices); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var rules []hostPortRule
+	for _, name := range names {
+		device := devices[name]
+		if device["type"] != "proxy" {
+			continue
+		}
+		listenProto, listenStart, listenEnd, ok := parseProxyAddress(device["listen"])
+		if !ok {
+			continue
+		}
+		connectProto, connectStart, connectEnd, ok := parseProxyAddress(device["connect"])
+		if !ok || connectProto != listenProto || (listenEnd > 0) != (connectEnd > 0) {
+			continue
+		}
+		rules = append(rules, hostPortRule{
+			source:       "proxy",
+			rule:         name,
+			instanceName: instanceName,
+			protocol:     listenProto,
+			hostPort:     listenStart,
+			hostPortEnd:  listenEnd,
+			guestPort:    connectStart,
+			guestPortEnd: connectEnd,
+		})
+	}
+	return rules, nil
+}
+
+// parseProxyAddress 解析 proto:host:port 或 proto:host:start-end 形式的proxy地址
+func parseProxyAddress(addr string) (string, int, int, bool) {
+	protoIdx := strings.Index(addr, ":")
+	portIdx := strings.LastIndex(addr, ":")
+	if protoIdx <= 0 || portIdx <= protoIdx {
+		return "", 0, 0, false
+	}
+	proto := strings.ToLower(addr[:protoIdx])
+	if proto != "tcp" && proto != "udp" {
+		return "", 0, 0, false
+	}
+	start, end, ok := parsePortSpan(addr[portIdx+1:], "-")
+	return proto, start, end, ok
+}
+
+// parseIptablesDNAT 解析 `iptables-save -t nat` 输出中的DNAT规则
+// 例如 -A PREROUTING -i vmbr0 -p tcp -m tcp --dport 20000 -j DNAT --to-destination 172.16.1.2:22
+func parseIptablesDNAT(output string) []hostPortRule {
+	var rules []hostPortRule
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-A ") || !strings.Contains(line, "-j DNAT") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var proto, dport, dest string
+		for i := 0; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "-p":
+				proto = strings.ToLower(fields[i+1])
+			case "--dport":
+				dport = fields[i+1]
+			case "--to-destination":
+				dest = fields[i+1]
+			}
+		}
+		if (proto != "tcp" && proto != "udp") || dport == "" || dest == "" {
+			continue
+		}
+		hostStart, hostEnd, ok := parsePortSpan(dport, ":")
+		if !ok {
+			continue
+		}
+
+		// 目标地址可能省略端口，此时与宿主机端口相同
+		targetIP, guestSpan := dest, ""
+		if idx := strings.LastIndex(dest, ":"); idx > 0 && !strings.HasSuffix(dest, "]") {
+			targetIP, guestSpan = dest[:idx], dest[idx+1:]
+		}
+		targetIP = strings.Trim(targetIP, "[]")
+		guestStart, guestEnd := hostStart, hostEnd
+		if guestSpan != "" {
+			if guestStart, guestEnd, ok = parsePortSpan(guestSpan, "-"); !ok {
+				continue
+			}
+		}
+		if (hostEnd > 0) != (guestEnd > 0) {
+			continue // 端口段与单端口之间的多对一转发无法用端口映射记录表示
+		}
+
+		rules = append(rules, hostPortRule{
+			source:       "iptables",
+			rule:         line,
+			targetIP:     targetIP,
+			protocol:     proto,
+			hostPort:     hostStart,
+			hostPortEnd:  hostEnd,
+			guestPort:    guestStart,
+			guestPortEnd: guestEnd,
+		})
+	}
+	return rules
+}
+
+// parsePortSpan 解析单端口或端口段，端口段结束端口为0表示单端口
+func parsePortSpan(span, sep string) (int, int, bool) {
+	startStr, endStr, isRange := strings.Cut(span, sep)
+	start, err := strconv.Atoi(startStr)
+	if err != nil || start < 1 || start > 65535 {
+		return 0, 0, false
+	}
+	if !isRange {
+		return start, 0, true
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil || end < start || end > 65535 {
+		return 0, 0, false
+	}
+	if end == start {
+		return start, 0, true
+	}
+	return start, end, true
+}
@@ -0,0 +1,63 @@
+package provider
+
+import "testing"
+
+func TestParseProxyDevices(t *testing.T) {
+	output := `eth0:
+  name: eth0
+  network: incusbr0
+  type: nic
+proxy-tcp-20000:
+  connect: tcp:0.0.0.0:22
+  listen: tcp:203.0.113.10:20000
+  nat: "true"
+  type: proxy
+proxy-udp-20000:
+  connect: udp:0.0.0.0:22
+  listen: udp:203.0.113.10:20000
+  nat: "true"
+  type: proxy
+proxy-tcp-20001-20010:
+  connect: tcp:0.0.0.0:20001-20010
+  listen: tcp:203.0.113.10:20001-20010
+  type: proxy
+`
+	rules, err := parseProxyDevices("ct1", output)
+	if err != nil {
+		t.Fatalf("parseProxyDevices error: %v", err)
+	}
+	rules = mergeProtocolRules(rules)
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2: %+v", len(rules), rules)
+	}
+	if r := rules[0]; r.protocol != "both" || r.hostPort != 20000 || r.hostPortEnd != 0 || r.guestPort != 22 {
+		t.Errorf("unexpected ssh rule: %+v", r)
+	}
+	if r := rules[1]; r.protocol != "tcp" || r.hostPort != 20001 || r.hostPortEnd != 20010 || r.guestPortEnd != 20010 {
+		t.Errorf("unexpected range rule: %+v", r)
+	}
+}
+
+func TestParseIptablesDNAT(t *testing.T) {
+	output := `*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -i vmbr0 -p tcp -m tcp --dport 30000 -j DNAT --to-destination 172.16.1.2:22
+-A PREROUTING -p udp -m udp --dport 30001:30005 -j DNAT --to-destination 172.16.1.2:30001-30005
+-A PREROUTING -p tcp -m tcp --dport 30010 -j DNAT --to-destination 172.16.1.3
+-A POSTROUTING -s 172.16.1.0/24 -j MASQUERADE
+COMMIT
+`
+	rules := parseIptablesDNAT(output)
+	if len(rules) != 3 {
+		t.Fatalf("got %d rules, want 3: %+v", len(rules), rules)
+	}
+	if r := rules[0]; r.targetIP != "172.16.1.2" || r.hostPort != 30000 || r.guestPort != 22 || r.protocol != "tcp" {
+		t.Errorf("unexpected rule: %+v", r)
+	}
+	if r := rules[1]; r.hostPortEnd != 30005 || r.guestPort != 30001 || r.guestPortEnd != 30005 || r.protocol != "udp" {
+		t.Errorf("unexpected range rule: %+v", r)
+	}
+	if r := rules[2]; r.targetIP != "172.16.1.3" || r.guestPort != 30010 {
+		t.Errorf("destination without port should keep host port: %+v", r)
+	}
+}
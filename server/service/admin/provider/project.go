@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+)
+
+// normalizeProject 校验LXD/Incus项目名称，default与空值等价
+func normalizeProject(providerType, project string) (string, error) {
+	project = strings.TrimSpace(project)
+	if project == "" || project == provider.DefaultProject {
+		return "", nil
+	}
+	if providerType != "lxd" && providerType != "incus" {
+		return "", fmt.Errorf("仅LXD和Incus支持项目配置")
+	}
+	if err := provider.ValidateLXDObjectName("项目", project); err != nil {
+		return "", err
+	}
+	return project, nil
+}
+
+// encodeProfiles 校验配置文件名称并序列化为逗号分隔的字符串
+func encodeProfiles(providerType string, profiles []string) (string, error) {
+	names := make([]string, 0, len(profiles))
+	for _, name := range profiles {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := provider.ValidateLXDObjectName("配置文件", name); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+	if len(names) > 0 && providerType != "lxd" && providerType != "incus" {
+		return "", fmt.Errorf("仅LXD和Incus支持配置文件")
+	}
+	encoded := strings.Join(names, ",")
+	if len(encoded) > 255 {
+		return "", fmt.Errorf("配置文件列表过长")
+	}
+	return encoded, nil
+}
+
+// checkProjectChangeable 已有实例时不能修改项目，否则这些实例会留在原项目中无法管理
+func checkProjectChangeable(providerID uint) error {
+	var count int64
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND status NOT IN (?)", providerID, []string{"deleted"}).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("检查Provider实例失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("Provider上还有 %d 个实例，不能修改项目", count)
+	}
+	return nil
+}
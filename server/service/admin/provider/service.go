@@ -10,8 +10,17 @@ import (
 	providerModel "oneclickvirt/model/provider"
 
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/service/counters"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/hostcompat"
+	"oneclickvirt/service/hostevents"
+	"oneclickvirt/service/hwhealth"
 	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/task"
+	"oneclickvirt/service/timezone"
+	"oneclickvirt/service/traffic"
 	"oneclickvirt/utils"
 	"strings"
 	"time"
@@ -28,6 +37,21 @@ func NewService() *Service {
 	return &Service{}
 }
 
+// providerListSpec Provider列表可排序、过滤的字段
+var providerListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":        "id",
+		"name":      "name",
+		"type":      "type",
+		"status":    "status",
+		"region":    "region",
+		"isFrozen":  "is_frozen",
+		"expiresAt": "expires_at",
+		"createdAt": "created_at",
+	},
+	DefaultSort: "id",
+}
+
 // GetProviderList 获取Provider列表
 func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.ProviderManageResponse, int64, error) {
 	global.APP_LOG.Debug("获取Provider列表",
@@ -51,14 +75,24 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 	if req.Status != "" {
 		query = query.Where("status = ?", req.Status)
 	}
+	if len(req.ProviderIDs) > 0 {
+		query = query.Where("id IN ?", req.ProviderIDs)
+	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, providerListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		global.APP_LOG.Error("查询Provider总数失败", zap.Error(err))
 		return nil, 0, err
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Offset(offset).Limit(req.PageSize).Find(&providers).Error; err != nil {
+	query, err = utils.ApplyListSort(query, req.PageInfo, providerListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Find(&providers).Error; err != nil {
 		global.APP_LOG.Error("查询Provider列表失败", zap.Error(err))
 		return nil, 0, err
 	}
@@ -69,23 +103,15 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 		providerIDs = append(providerIDs, provider.ID)
 	}
 
-	// 批量统计实例数量（总数、容器、虚拟机）
+	// 实例数量（总数、容器、虚拟机）取自计数器
 	type InstanceCountResult struct {
-		ProviderID     uint
 		TotalCount     int64
 		ContainerCount int64
 		VMCount        int64
 	}
-	var instanceCounts []InstanceCountResult
-	if len(providerIDs) > 0 {
-		global.APP_DB.Model(&providerModel.Instance{}).
-			Select(`provider_id,
-				COUNT(*) as total_count,
-				SUM(CASE WHEN instance_type = 'container' THEN 1 ELSE 0 END) as container_count,
-				SUM(CASE WHEN instance_type = 'vm' THEN 1 ELSE 0 END) as vm_count`).
-			Where("provider_id IN ?", providerIDs).
-			Group("provider_id").
-			Scan(&instanceCounts)
+	instanceCounters, err := counters.Instances()
+	if err != nil {
+		global.APP_LOG.Warn("获取实例计数失败", zap.Error(err))
 	}
 
 	// 批量统计运行中的任务数量
@@ -110,7 +136,7 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 	}
 	var trafficUsages []TrafficUsageResult
 	if len(providerIDs) > 0 {
-		now := time.Now()
+		now := time.Now().In(utils.DefaultLocation())
 		year, month := now.Year(), int(now.Month())
 
 		// 使用聚合表查询，性能大幅提升
@@ -137,8 +163,16 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 
 	// 构建映射表
 	instanceCountMap := make(map[uint]InstanceCountResult)
-	for _, count := range instanceCounts {
-		instanceCountMap[count.ProviderID] = count
+	for _, count := range instanceCounters {
+		result := instanceCountMap[count.ProviderID]
+		result.TotalCount += count.Count
+		switch count.InstanceType {
+		case "container":
+			result.ContainerCount += count.Count
+		case "vm":
+			result.VMCount += count.Count
+		}
+		instanceCountMap[count.ProviderID] = result
 	}
 
 	taskCountMap := make(map[uint]int64)
@@ -158,10 +192,13 @@ func (s *Service) GetProviderList(req admin.ProviderListRequest) ([]admin.Provid
 		runningTasksCount := taskCountMap[provider.ID]
 		usedTraffic := trafficUsageMap[provider.ID]
 
-		// Docker 类型固定使用 native 端口映射方式
-		if provider.Type == "docker" {
+		// Docker/Kubernetes 类型固定使用 native 端口映射方式，LXC 类型固定使用 iptables
+		if provider.Type == "docker" || provider.Type == "kubernetes" {
 			provider.IPv4PortMappingMethod = "native"
 			provider.IPv6PortMappingMethod = "native"
+		} else if provider.Type == "lxc" {
+			provider.IPv4PortMappingMethod = "iptables"
+			provider.IPv6PortMappingMethod = "iptables"
 		}
 
 		// 计算已分配资源（基于实例配置和limit配置）
@@ -216,6 +253,7 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 		}
 		return err
 	}
+	original := provider
 
 	// 1. 检查Provider名称是否与其他Provider重复（排除当前Provider）
 	if req.Name != provider.Name {
@@ -350,6 +388,32 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	provider.Status = req.Status
 	provider.MaxContainerInstances = req.MaxContainerInstances
 	provider.MaxVMInstances = req.MaxVMInstances
+	provider.SystemReservedCPUPercent = req.SystemReservedCPUPercent
+	provider.SystemReservedMemory = req.SystemReservedMemory
+	provider.SystemReservedDiskGB = req.SystemReservedDiskGB
+	hardwareChecks, checksErr := hwhealth.NormalizeChecks(req.HardwareChecks)
+	if checksErr != nil {
+		return checksErr
+	}
+	if hardwareChecks == "" {
+		provider.HardwareAlert = ""
+		provider.HardwareAlertAt = nil
+	}
+	provider.HardwareChecks = hardwareChecks
+	provider.HardwareTempThreshold = req.HardwareTempThreshold
+	hostEventSources, sourcesErr := hostevents.NormalizeSources(req.HostEventSources)
+	if sourcesErr != nil {
+		return sourcesErr
+	}
+	if hostEventSources == "" {
+		provider.HostEventsCheckedAt = nil
+	}
+	provider.HostEventSources = hostEventSources
+	provider.HealthCheckInterval = req.HealthCheckInterval
+	provider.ContainerCPUOvercommit = normalizeOvercommit(req.ContainerCPUOvercommit)
+	provider.ContainerMemoryOvercommit = normalizeOvercommit(req.ContainerMemoryOvercommit)
+	provider.VMCPUOvercommit = normalizeOvercommit(req.VMCPUOvercommit)
+	provider.VMMemoryOvercommit = normalizeOvercommit(req.VMMemoryOvercommit)
 	provider.AllowConcurrentTasks = req.AllowConcurrentTasks
 	provider.MaxConcurrentTasks = req.MaxConcurrentTasks
 	provider.TaskPollInterval = req.TaskPollInterval
@@ -373,6 +437,11 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	if req.NetworkType != "" {
 		provider.NetworkType = req.NetworkType
 	}
+	// 实例内SSH端口配置更新，只影响之后创建的实例
+	if req.GuestSSHPort > 0 {
+		provider.GuestSSHPort = req.GuestSSHPort
+	}
+	provider.RandomGuestSSHPort = req.RandomGuestSSHPort
 	// 带宽配置更新
 	if req.DefaultInboundBandwidth > 0 {
 		provider.DefaultInboundBandwidth = req.DefaultInboundBandwidth
@@ -447,6 +516,14 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 			zap.Float64("oldValue", oldValue),
 			zap.Float64("newValue", req.TrafficMultiplier))
 	}
+	// 分时段流量计费倍率更新，未提供时保持不变，提供空列表时清除
+	if req.TrafficMultiplierWindows != nil {
+		windows, err := traffic.EncodeMultiplierWindows(req.TrafficMultiplierWindows)
+		if err != nil {
+			return err
+		}
+		provider.TrafficMultiplierWindows = windows
+	}
 
 	// 检查Provider过期时间是否发生变化，需要同步到非手动设置过期时间的实例
 	var oldProvider providerModel.Provider
@@ -469,10 +546,13 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 	}
 
 	// 端口映射方式更新
-	// Docker 类型固定使用 native，忽略前端传入的值
-	if provider.Type == "docker" {
+	// Docker/Kubernetes 类型固定使用 native，LXC 类型固定使用 iptables，忽略前端传入的值
+	if provider.Type == "docker" || provider.Type == "kubernetes" {
 		provider.IPv4PortMappingMethod = "native"
 		provider.IPv6PortMappingMethod = "native"
+	} else if provider.Type == "lxc" {
+		provider.IPv4PortMappingMethod = "iptables"
+		provider.IPv6PortMappingMethod = "iptables"
 	} else {
 		if req.IPv4PortMappingMethod != "" {
 			provider.IPv4PortMappingMethod = req.IPv4PortMappingMethod
@@ -540,6 +620,116 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 		provider.LevelLimits = string(levelLimitsJSON)
 	}
 
+	// 镜像源改写规则更新，未提供时保持不变，提供空列表时清除
+	// 镜像源、项目和配置文件在连接时读取，变更后需要重新加载Provider
+	reloadNeeded := false
+	if req.ImageMirrors != nil {
+		imageMirrors, err := encodeImageMirrors(req.ImageMirrors)
+		if err != nil {
+			return err
+		}
+		reloadNeeded = imageMirrors != provider.ImageMirrors
+		provider.ImageMirrors = imageMirrors
+	}
+
+	if req.RecoverStoppedInstances != nil {
+		provider.RecoverStoppedInstances = *req.RecoverStoppedInstances
+	}
+
+	// 宿主机网卡更新，公网网卡在连接时读取，变更后需要重新加载Provider
+	if req.PublicInterface != nil {
+		iface, err := normalizePublicInterface(*req.PublicInterface)
+		if err != nil {
+			return err
+		}
+		if iface != provider.PublicInterface {
+			reloadNeeded = true
+		}
+		provider.PublicInterface = iface
+	}
+	if req.Uplinks != nil {
+		uplinks, err := encodeUplinks(req.Uplinks)
+		if err != nil {
+			return err
+		}
+		provider.Uplinks = uplinks
+	}
+
+	// 维护窗口更新，未提供时保持不变，提供空列表时清除
+	if req.BlackoutWindows != nil {
+		windows, err := blackout.EncodeProviderWindows(req.BlackoutWindows)
+		if err != nil {
+			return err
+		}
+		provider.BlackoutWindows = windows
+	}
+
+	// 时区更新，按新时区重新计算下次流量重置时间
+	timezoneChanged := false
+	if req.Timezone != nil {
+		tz, err := utils.NormalizeTimezone(*req.Timezone)
+		if err != nil {
+			return err
+		}
+		if tz != provider.Timezone {
+			timezoneChanged = true
+			provider.Timezone = tz
+			if provider.TrafficResetAt != nil {
+				nextReset := utils.NextMonthStart(time.Now(), utils.LoadLocation(tz))
+				provider.TrafficResetAt = &nextReset
+			}
+		}
+	}
+
+	// SSH命令白名单更新，未提供时保持不变，变更后重新加载连接使其生效
+	if req.CommandGuardMode != nil {
+		mode, err := normalizeCommandGuardMode(*req.CommandGuardMode)
+		if err != nil {
+			return err
+		}
+		reloadNeeded = reloadNeeded || mode != provider.CommandGuardMode
+		provider.CommandGuardMode = mode
+	}
+	if req.CommandAllowlist != nil {
+		allowlist, err := encodeCommandList("命令白名单", req.CommandAllowlist)
+		if err != nil {
+			return err
+		}
+		reloadNeeded = reloadNeeded || allowlist != provider.CommandAllowlist
+		provider.CommandAllowlist = allowlist
+	}
+	if req.CommandDenylist != nil {
+		denylist, err := encodeCommandList("命令黑名单", req.CommandDenylist)
+		if err != nil {
+			return err
+		}
+		reloadNeeded = reloadNeeded || denylist != provider.CommandDenylist
+		provider.CommandDenylist = denylist
+	}
+
+	// LXD/Incus 项目与配置文件更新，未提供时保持不变
+	if req.Project != nil {
+		project, err := normalizeProject(provider.Type, *req.Project)
+		if err != nil {
+			return err
+		}
+		if project != provider.Project {
+			if err := checkProjectChangeable(provider.ID); err != nil {
+				return err
+			}
+			reloadNeeded = true
+			provider.Project = project
+		}
+	}
+	if req.Profiles != nil {
+		profiles, err := encodeProfiles(provider.Type, req.Profiles)
+		if err != nil {
+			return err
+		}
+		reloadNeeded = reloadNeeded || profiles != provider.Profiles
+		provider.Profiles = profiles
+	}
+
 	// 设置默认值
 	// 并发控制默认值：确保一致性
 	if !provider.AllowConcurrentTasks && provider.MaxConcurrentTasks <= 0 {
@@ -552,8 +742,18 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 		provider.TaskPollInterval = 60
 	}
 
+	// 宿主机内核或cgroup无法支持新启用的功能时拒绝保存
+	if err := hostcompat.ValidateChange(&original, &provider); err != nil {
+		return err
+	}
+
+	// 用户访问端口映射的地址变更后，已有实例的连接信息和绑定旧地址的端口映射需要迁移
+	oldPortHost, newPortHost := resources.ProviderPublicHost(&original), resources.ProviderPublicHost(&provider)
+	portHostChanged := oldPortHost != "" && newPortHost != "" && oldPortHost != newPortHost
+	reloadNeeded = reloadNeeded || portHostChanged
+
 	dbService := database.GetDatabaseService()
-	return dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+	err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
 		// 保存Provider更新
 		if err := tx.Save(&provider).Error; err != nil {
 			return err
@@ -582,6 +782,35 @@ func (s *Service) UpdateProvider(req admin.UpdateProviderRequest) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if timezoneChanged {
+		timezone.InvalidateProvider(provider.ID)
+	}
+
+	// 已加载的Provider实例持有连接时的配置，相关配置变更后替换为新实例
+	// 迁移任务在重新加载之后创建，保证使用新的地址配置连接
+	if reloadNeeded {
+		go func(providerID uint) {
+			if err := provider2.GetProviderService().SwapProvider(providerID); err != nil {
+				global.APP_LOG.Warn("Provider配置已保存，但重新加载Provider失败，将在下次连接时生效",
+					zap.Uint("providerID", providerID),
+					zap.Error(err))
+			}
+			if !portHostChanged {
+				return
+			}
+			if _, err := task.GetTaskService().CreatePortIPMigrationTask(req.OperatorID, providerID, oldPortHost, newPortHost); err != nil {
+				global.APP_LOG.Error("创建端口映射地址迁移任务失败，实例连接信息仍为旧地址",
+					zap.Uint("providerID", providerID),
+					zap.String("oldHost", oldPortHost),
+					zap.String("newHost", newPortHost),
+					zap.Error(err))
+			}
+		}(provider.ID)
+	}
+	return nil
 }
 
 // handleTrafficControlToggle 处理流量统计开关切换（后台任务）
@@ -869,6 +1098,12 @@ func (s *Service) GetProviderStatus(providerID uint) (*admin.ProviderStatusRespo
 		NodeDiskTotal:    provider.NodeDiskTotal,
 		ResourceSynced:   provider.ResourceSynced,
 		ResourceSyncedAt: provider.ResourceSyncedAt,
+		// 宿主机日志事件
+		HostEventSources:    provider.HostEventSources,
+		HostEventsCheckedAt: provider.HostEventsCheckedAt,
+	}
+	if provider.HostEventSources != "" {
+		response.RecentHostEvents = hostevents.CountSince(provider.ID, time.Now().Add(-24*time.Hour))
 	}
 
 	return response, nil
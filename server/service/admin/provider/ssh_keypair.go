@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// generateSSHKeyPair 生成ed25519密钥对，返回OpenSSH格式的私钥
+func generateSSHKeyPair(comment string) (string, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("生成密钥失败: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return "", fmt.Errorf("编码私钥失败: %v", err)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// sshPublicKeyOf 从私钥推导 authorized_keys 格式的公钥和SHA256指纹
+func sshPublicKeyOf(privateKey, comment string) (string, string, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return "", "", err
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line, ssh.FingerprintSHA256(signer.PublicKey()), nil
+}
+
+// sshKeyInstallCommand 返回把公钥追加到当前用户 authorized_keys 的命令，重复执行不会重复追加
+func sshKeyInstallCommand(publicKey string) string {
+	return fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && touch ~/.ssh/authorized_keys && "+
+		"chmod 600 ~/.ssh/authorized_keys && (grep -qxF '%[1]s' ~/.ssh/authorized_keys || echo '%[1]s' >> ~/.ssh/authorized_keys)",
+		publicKey)
+}
+
+// sshKeyComment 面板生成的密钥注释，便于在宿主机上识别
+func sshKeyComment(provider *providerModel.Provider) string {
+	return fmt.Sprintf("oneclickvirt-provider-%d", provider.ID)
+}
+
+func loadProvider(providerID uint) (*providerModel.Provider, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("Provider不存在")
+		}
+		return nil, fmt.Errorf("查询Provider失败: %v", err)
+	}
+	return &provider, nil
+}
+
+// providerSSHKeyStatus 组装Provider的SSH密钥状态，不返回任何私钥内容
+func providerSSHKeyStatus(provider *providerModel.Provider) *admin.ProviderSSHKeyResponse {
+	resp := &admin.ProviderSSHKeyResponse{
+		ProviderID:  provider.ID,
+		Username:    provider.Username,
+		AuthMethod:  provider.GetAuthMethod(),
+		HasPassword: provider.Password != "",
+	}
+	if provider.SSHKey != "" {
+		// 手动上传的私钥可能带密码保护或格式不受支持，此时不显示公钥
+		if publicKey, fingerprint, err := sshPublicKeyOf(provider.SSHKey, ""); err == nil {
+			resp.ActivePublicKey, resp.ActiveFingerprint = publicKey, fingerprint
+		}
+	}
+	if provider.PendingSSHKey != "" {
+		if publicKey, fingerprint, err := sshPublicKeyOf(provider.PendingSSHKey, sshKeyComment(provider)); err == nil {
+			resp.PendingPublicKey, resp.PendingFingerprint = publicKey, fingerprint
+			resp.InstallCommand = sshKeyInstallCommand(publicKey)
+		}
+	}
+	return resp
+}
+
+// GetProviderSSHKey 获取Provider的SSH密钥状态
+func (s *Service) GetProviderSSHKey(providerID uint) (*admin.ProviderSSHKeyResponse, error) {
+	provider, err := loadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return providerSSHKeyStatus(provider), nil
+}
+
+// GenerateProviderSSHKey 为Provider生成专用密钥对，私钥保存为待启用状态，返回需要安装到宿主机的公钥
+// 重新生成会替换尚未启用的密钥，不影响当前使用的凭据
+func (s *Service) GenerateProviderSSHKey(providerID uint) (*admin.ProviderSSHKeyResponse, error) {
+	provider, err := loadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := generateSSHKeyPair(sshKeyComment(provider))
+	if err != nil {
+		return nil, err
+	}
+	if err := global.APP_DB.Model(&providerModel.Provider{}).
+		Where("id = ?", providerID).
+		Update("pending_ssh_key", privateKey).Error; err != nil {
+		return nil, fmt.Errorf("保存密钥失败: %v", err)
+	}
+	provider.PendingSSHKey = privateKey
+
+	global.APP_LOG.Info("已为Provider生成SSH密钥对", zap.Uint("providerID", providerID))
+	return providerSSHKeyStatus(provider), nil
+}
+
+// ActivateProviderSSHKey 测试待启用的密钥，通过后切换为仅密钥认证并删除保存的SSH密码
+func (s *Service) ActivateProviderSSHKey(providerID uint, req admin.ActivateProviderSSHKeyRequest) (*admin.RotateProviderCredentialsResponse, error) {
+	provider, err := loadProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if provider.PendingSSHKey == "" {
+		return nil, fmt.Errorf("没有待启用的SSH密钥，请先生成密钥并安装公钥")
+	}
+
+	// 复用凭据轮换流程：测试连接、原子替换凭据（密码置空）、切换连接
+	resp, err := s.RotateProviderCredentials(providerID, admin.RotateProviderCredentialsRequest{
+		SSHKey: provider.PendingSSHKey,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("密钥认证失败，请确认公钥已安装到 %s 用户的 authorized_keys: %v", provider.Username, err)
+	}
+
+	// 只清除本次启用的密钥，避免覆盖启用期间重新生成的密钥
+	if err := global.APP_DB.Model(&providerModel.Provider{}).
+		Where("id = ? AND pending_ssh_key = ?", providerID, provider.PendingSSHKey).
+		Update("pending_ssh_key", "").Error; err != nil {
+		global.APP_LOG.Warn("清除待启用SSH密钥失败", zap.Uint("providerID", providerID), zap.Error(err))
+	}
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestGenerateSSHKeyPair(t *testing.T) {
+	privateKey, err := generateSSHKeyPair("oneclickvirt-provider-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, fingerprint, err := sshPublicKeyOf(privateKey, "oneclickvirt-provider-1")
+	if err != nil {
+		t.Fatalf("generated key should parse: %v", err)
+	}
+	if !strings.HasPrefix(publicKey, "ssh-ed25519 ") || !strings.HasSuffix(publicKey, " oneclickvirt-provider-1") {
+		t.Errorf("unexpected public key %q", publicKey)
+	}
+	if !strings.HasPrefix(fingerprint, "SHA256:") {
+		t.Errorf("unexpected fingerprint %q", fingerprint)
+	}
+
+	other, _ := generateSSHKeyPair("")
+	if otherPublic, _, _ := sshPublicKeyOf(other, ""); strings.HasPrefix(publicKey, otherPublic) {
+		t.Error("each call should generate a new key")
+	}
+}
+
+func TestProviderSSHKeyStatus(t *testing.T) {
+	privateKey, err := generateSSHKeyPair("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &providerModel.Provider{Username: "root", Password: "secret", PendingSSHKey: privateKey}
+	provider.ID = 7
+	status := providerSSHKeyStatus(provider)
+	if status.AuthMethod != "password" || !status.HasPassword || status.ActivePublicKey != "" {
+		t.Errorf("unexpected active state: %+v", status)
+	}
+	if !strings.HasSuffix(status.PendingPublicKey, " oneclickvirt-provider-7") ||
+		!strings.Contains(status.InstallCommand, status.PendingPublicKey) {
+		t.Errorf("pending key not reported: %+v", status)
+	}
+
+	provider.SSHKey, provider.Password, provider.PendingSSHKey = privateKey, "", ""
+	status = providerSSHKeyStatus(provider)
+	if status.AuthMethod != "sshKey" || status.HasPassword || status.ActiveFingerprint == "" || status.InstallCommand != "" {
+		t.Errorf("unexpected key-only state: %+v", status)
+	}
+}
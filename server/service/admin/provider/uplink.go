@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+)
+
+// maxProviderUplinks 每个Provider最多配置的附加上行网卡数
+const maxProviderUplinks = 8
+
+// normalizePublicInterface 校验公网网卡名称，为空表示不限定网卡
+func normalizePublicInterface(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name != "" && !utils.IsValidInterfaceName(name) {
+		return "", fmt.Errorf("公网网卡名称 %q 格式错误", name)
+	}
+	return name, nil
+}
+
+// encodeUplinks 校验并序列化附加上行网卡，为空时返回空字符串
+// 每个上行网卡必须配置独立IP段，且各IP段之间不能重叠
+func encodeUplinks(uplinks []providerModel.ProviderUplink) (string, error) {
+	if len(uplinks) == 0 {
+		return "", nil
+	}
+	if len(uplinks) > maxProviderUplinks {
+		return "", fmt.Errorf("附加上行网卡最多 %d 个", maxProviderUplinks)
+	}
+	prefixes := make([]netip.Prefix, 0, len(uplinks))
+	for i := range uplinks {
+		u := &uplinks[i]
+		u.Interface = strings.TrimSpace(u.Interface)
+		u.IPv4Pool = strings.TrimSpace(u.IPv4Pool)
+		u.Description = strings.TrimSpace(u.Description)
+		if !utils.IsValidInterfaceName(u.Interface) {
+			return "", fmt.Errorf("上行网卡%d: 网卡名称 %q 格式错误", i+1, u.Interface)
+		}
+		prefix, err := netip.ParsePrefix(u.IPv4Pool)
+		if err != nil || !prefix.Addr().Is4() {
+			return "", fmt.Errorf("上行网卡%d: IP段 %q 必须是IPv4 CIDR，如 203.0.113.0/27", i+1, u.IPv4Pool)
+		}
+		prefix = prefix.Masked()
+		for j, other := range prefixes {
+			if other.Overlaps(prefix) {
+				return "", fmt.Errorf("上行网卡%d的IP段与上行网卡%d重叠", i+1, j+1)
+			}
+		}
+		prefixes = append(prefixes, prefix)
+		u.IPv4Pool = prefix.String()
+	}
+	data, err := json.Marshal(uplinks)
+	if err != nil {
+		return "", fmt.Errorf("附加上行网卡格式错误: %v", err)
+	}
+	return string(data), nil
+}
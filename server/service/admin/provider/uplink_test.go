@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestEncodeUplinks(t *testing.T) {
+	encoded, err := encodeUplinks([]providerModel.ProviderUplink{
+		{Interface: " eth1 ", IPv4Pool: "203.0.113.5/27", Description: "机房B段"},
+		{Interface: "eth2", IPv4Pool: "198.51.100.0/28"},
+	})
+	if err != nil {
+		t.Fatalf("encodeUplinks: %v", err)
+	}
+	p := &providerModel.Provider{PublicInterface: "eth0", Uplinks: encoded}
+	uplinks := p.GetUplinks()
+	if len(uplinks) != 2 || uplinks[0].Interface != "eth1" || uplinks[0].IPv4Pool != "203.0.113.0/27" {
+		t.Fatalf("unexpected uplinks %+v", uplinks)
+	}
+	for ip, want := range map[string]string{
+		"203.0.113.20":  "eth1",
+		"198.51.100.15": "eth2",
+		"192.0.2.1":     "eth0",
+		"":              "eth0",
+	} {
+		if got := p.InterfaceForIP(ip); got != want {
+			t.Errorf("InterfaceForIP(%q) = %q, want %q", ip, got, want)
+		}
+	}
+
+	if encoded, err := encodeUplinks(nil); err != nil || encoded != "" {
+		t.Errorf("empty uplinks = %q, %v", encoded, err)
+	}
+	for _, bad := range [][]providerModel.ProviderUplink{
+		{{Interface: "eth1; reboot", IPv4Pool: "203.0.113.0/27"}},
+		{{Interface: "eth1", IPv4Pool: "203.0.113.0"}},
+		{{Interface: "eth1", IPv4Pool: "2001:db8::/64"}},
+		{{Interface: "eth1", IPv4Pool: "203.0.113.0/24"}, {Interface: "eth2", IPv4Pool: "203.0.113.64/27"}},
+	} {
+		if _, err := encodeUplinks(bad); err == nil {
+			t.Errorf("encodeUplinks(%+v) should fail", bad)
+		}
+	}
+	if _, err := normalizePublicInterface("$(id)"); err == nil {
+		t.Error("invalid public interface should fail")
+	}
+}
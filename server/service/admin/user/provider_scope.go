@@ -0,0 +1,90 @@
+package user
+
+import (
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/permission"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+
+	"gorm.io/gorm"
+)
+
+// GetProviderScope 获取管理员的Provider管理范围
+func (s *Service) GetProviderScope(userID uint) (*admin.AdminProviderScopeResponse, error) {
+	var user userModel.User
+	if err := global.APP_DB.Select("id, username, user_type").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+
+	resp := &admin.AdminProviderScopeResponse{
+		UserID:      user.ID,
+		Username:    user.Username,
+		ProviderIDs: []uint{},
+		Providers:   []admin.ScopedProvider{},
+	}
+	if err := global.APP_DB.Model(&permission.AdminProviderScope{}).
+		Where("user_id = ?", userID).
+		Order("provider_id").
+		Pluck("provider_id", &resp.ProviderIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询管理范围失败: %v", err)
+	}
+	if len(resp.ProviderIDs) > 0 {
+		global.APP_DB.Model(&providerModel.Provider{}).
+			Select("id, name").
+			Where("id IN ?", resp.ProviderIDs).
+			Order("id").
+			Find(&resp.Providers)
+	}
+	resp.Scoped = len(resp.ProviderIDs) > 0
+	return resp, nil
+}
+
+// SetProviderScope 设置管理员的Provider管理范围，传空列表恢复为完整管理员
+func (s *Service) SetProviderScope(userID, operatorID uint, providerIDs []uint) (*admin.AdminProviderScopeResponse, error) {
+	if userID == operatorID {
+		return nil, fmt.Errorf("不能修改自己的管理范围")
+	}
+
+	var user userModel.User
+	if err := global.APP_DB.Select("id, user_type").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if len(providerIDs) > 0 && user.UserType != "admin" {
+		return nil, fmt.Errorf("只能为管理员设置管理范围")
+	}
+
+	unique := make([]uint, 0, len(providerIDs))
+	seen := make(map[uint]bool, len(providerIDs))
+	for _, id := range providerIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > 0 {
+		var count int64
+		global.APP_DB.Model(&providerModel.Provider{}).Where("id IN ?", unique).Count(&count)
+		if int(count) != len(unique) {
+			return nil, fmt.Errorf("部分Provider不存在")
+		}
+	}
+
+	err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&permission.AdminProviderScope{}).Error; err != nil {
+			return err
+		}
+		for _, providerID := range unique {
+			if err := tx.Create(&permission.AdminProviderScope{UserID: userID, ProviderID: providerID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存管理范围失败: %v", err)
+	}
+	return s.GetProviderScope(userID)
+}
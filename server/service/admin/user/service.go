@@ -8,6 +8,7 @@ import (
 	"math/big"
 	auth2 "oneclickvirt/service/auth"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/hooks"
 
 	"oneclickvirt/config"
 	"oneclickvirt/global"
@@ -31,6 +32,21 @@ func NewService() *Service {
 	return &Service{}
 }
 
+// userListSpec 用户列表可排序、过滤的字段
+var userListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":        "id",
+		"username":  "username",
+		"email":     "email",
+		"userType":  "user_type",
+		"status":    "status",
+		"level":     "level",
+		"expiresAt": "expires_at",
+		"createdAt": "created_at",
+	},
+	DefaultSort: "id",
+}
+
 // GetUserList 获取用户列表
 func (s *Service) GetUserList(req admin.UserListRequest) ([]admin.UserManageResponse, int64, error) {
 	var users []userModel.User
@@ -48,13 +64,20 @@ func (s *Service) GetUserList(req admin.UserListRequest) ([]admin.UserManageResp
 	if req.Status != nil {
 		query = query.Where("status = ?", *req.Status)
 	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, userListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Offset(offset).Limit(req.PageSize).Find(&users).Error; err != nil {
+	query, err = utils.ApplyListSort(query, req.PageInfo, userListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -578,6 +601,9 @@ func (s *Service) BatchUpdateUserLevel(userIDs []uint, level int) error {
 		// 不返回错误，因为等级更新已经成功，资源限制同步失败只记录日志
 	}
 
+	// 流量配额随等级变化，刷新实例MOTD
+	go hooks.RefreshUserMOTD(allUserIDs)
+
 	return nil
 }
 
@@ -619,6 +645,9 @@ func (s *Service) UpdateUserLevel(userID uint, level int) error {
 		// 不返回错误，因为等级更新已经成功，资源限制同步失败只记录日志
 	}
 
+	// 流量配额随等级变化，刷新实例MOTD
+	go hooks.RefreshUserMOTD([]uint{userID})
+
 	return nil
 }
 
@@ -633,22 +662,11 @@ func (s *Service) ResetUserPassword(userID uint) (string, error) {
 		return "", err
 	}
 
-	// 生成强密码（12位）
-	newPassword := utils.GenerateStrongPassword(12)
+	// 生成强密码，长度由密码策略配置
+	newPassword := utils.GenerateUserPassword()
 
-	// 管理员重置密码使用放宽的策略（不要求特殊字符，因为生成的密码仅包含字母和数字）
-	adminResetPolicy := utils.PasswordStrengthConfig{
-		MinLength:        8,     // 最小8位
-		RequireUpperCase: true,  // 要求大写字母
-		RequireLowerCase: true,  // 要求小写字母
-		RequireDigit:     true,  // 要求数字
-		RequireSpecial:   false, // 不要求特殊字符（管理员生成的密码）
-		ForbidCommon:     true,  // 禁止常见弱密码
-		ForbidPersonal:   true,  // 禁止包含个人信息
-	}
-
-	// 密码强度验证（确保生成的密码符合策略）
-	if err := utils.ValidatePasswordStrength(newPassword, adminResetPolicy, user.Username); err != nil {
+	// 密码强度验证（生成的密码仅包含字母和数字，不要求特殊字符）
+	if err := utils.ValidatePasswordStrength(newPassword, utils.GeneratedPasswordPolicy(), user.Username); err != nil {
 		return "", common.NewError(common.CodeValidationError, err.Error())
 	}
 
@@ -683,11 +701,11 @@ func (s *Service) ResetUserPasswordAndNotify(userID uint) error {
 		return err
 	}
 
-	// 生成强密码（12位）
-	newPassword := utils.GenerateStrongPassword(12)
+	// 生成强密码，长度由密码策略配置
+	newPassword := utils.GenerateUserPassword()
 
 	// 密码强度验证（确保生成的密码符合策略）
-	if err := utils.ValidatePasswordStrength(newPassword, utils.DefaultPasswordPolicy, user.Username); err != nil {
+	if err := utils.ValidatePasswordStrength(newPassword, utils.GeneratedPasswordPolicy(), user.Username); err != nil {
 		return common.NewError(common.CodeValidationError, err.Error())
 	}
 
@@ -0,0 +1,97 @@
+// Package apiquota 按用户等级限制控制面接口的调用次数
+// 每个用户的每类操作对应一个令牌桶，桶容量为该等级一个周期内可调用的次数，令牌在周期内匀速补满；
+// 令牌桶状态保存在数据库中，服务重启或多节点部署时配额不会被重置
+package apiquota
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"oneclickvirt/global"
+	userModel "oneclickvirt/model/user"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 操作类别，对应 quota.api-limits 的键
+const (
+	ActionInstanceCreate  = "instance-create"  // 创建实例，批量创建按实例数量计
+	ActionInstanceAction  = "instance-action"  // 开关机、重启、重置、删除实例，分组批量操作和重置密码
+	ActionTrafficCapture  = "traffic-capture"  // 发起实例流量明细抓取
+	ActionDataExport      = "data-export"      // 发起用户数据导出
+	ActionWebhookDelivery = "webhook-delivery" // 用户Webhook投递，按事件数量计
+)
+
+// Result 一次配额检查的结果，用于填写限流响应头
+type Result struct {
+	Limited    bool // 是否配置了配额，为false时其余字段无意义
+	Allowed    bool // 是否放行
+	Limit      int  // 桶容量
+	Remaining  int  // 本次扣减后剩余的可调用次数
+	Reset      int  // 令牌补满所需秒数
+	RetryAfter int  // 被拒绝时令牌足够所需的秒数
+}
+
+// Rule 返回等级在某类操作上的配额，未配置或配置为0时 ok 为 false
+func Rule(action string, level int) (limit int, period time.Duration, ok bool) {
+	rule, exists := global.APP_CONFIG.Quota.APILimits[action]
+	if !exists || rule.Period <= 0 {
+		return 0, 0, false
+	}
+	limit = rule.Levels[level]
+	if limit <= 0 {
+		return 0, 0, false
+	}
+	return limit, time.Duration(rule.Period) * time.Second, true
+}
+
+// Take 从用户的令牌桶中扣减 n 个令牌，令牌不足时不扣减并返回需要等待的时间
+func Take(userID uint, level int, action string, n int) (Result, error) {
+	limit, period, ok := Rule(action, level)
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+	var res Result
+	err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		bucket := userModel.APIQuotaBucket{UserID: userID, Action: action, Tokens: float64(limit), RefilledAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND action = ?", userID, action).First(&bucket).Error; err != nil {
+			return err
+		}
+		var tokens float64
+		tokens, res = take(bucket.Tokens, bucket.RefilledAt, limit, period, n, now)
+		return tx.Model(&userModel.APIQuotaBucket{}).Where("id = ?", bucket.ID).
+			Updates(map[string]interface{}{"tokens": tokens, "refilled_at": now}).Error
+	})
+	if err != nil {
+		return Result{Allowed: true}, fmt.Errorf("更新接口调用配额失败: %w", err)
+	}
+	return res, nil
+}
+
+// take 结算令牌桶并尝试扣减 n 个令牌，返回结算后的令牌数
+func take(tokens float64, refilledAt time.Time, limit int, period time.Duration, n int, now time.Time) (float64, Result) {
+	capacity := float64(limit)
+	rate := capacity / period.Seconds()
+	if elapsed := now.Sub(refilledAt).Seconds(); elapsed > 0 {
+		tokens += elapsed * rate
+	}
+	tokens = math.Min(tokens, capacity)
+
+	res := Result{Limited: true, Limit: limit}
+	if tokens >= float64(n) {
+		tokens -= float64(n)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = int(math.Ceil((float64(n) - tokens) / rate))
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = int(math.Ceil((capacity - tokens) / rate))
+	return tokens, res
+}
@@ -0,0 +1,41 @@
+package apiquota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	period := time.Hour
+
+	// 满桶扣减
+	tokens, res := take(10, now, 10, period, 1, now)
+	if !res.Allowed || tokens != 9 || res.Remaining != 9 || res.Reset != 360 {
+		t.Fatalf("满桶扣减错误: tokens=%v %+v", tokens, res)
+	}
+
+	// 令牌不足时不扣减，返回等待时间
+	tokens, res = take(0.5, now, 10, period, 1, now)
+	if res.Allowed || tokens != 0.5 || res.Remaining != 0 || res.RetryAfter != 180 {
+		t.Fatalf("令牌不足处理错误: tokens=%v %+v", tokens, res)
+	}
+
+	// 按经过的时间补充令牌
+	tokens, res = take(0, now.Add(-30*time.Minute), 10, period, 2, now)
+	if !res.Allowed || tokens != 3 || res.Remaining != 3 {
+		t.Fatalf("补充令牌错误: tokens=%v %+v", tokens, res)
+	}
+
+	// 补充的令牌不超过桶容量，调小配额后按新容量截断
+	tokens, res = take(20, now.Add(-24*time.Hour), 5, period, 1, now)
+	if !res.Allowed || tokens != 4 || res.Limit != 5 {
+		t.Fatalf("桶容量截断错误: tokens=%v %+v", tokens, res)
+	}
+
+	// 批量扣减超过剩余令牌时整体拒绝
+	tokens, res = take(3, now, 10, period, 5, now)
+	if res.Allowed || tokens != 3 || res.RetryAfter != 720 {
+		t.Fatalf("批量扣减错误: tokens=%v %+v", tokens, res)
+	}
+}
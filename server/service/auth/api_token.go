@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	userModel "oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxAPITokensPerUser 每个用户最多持有的API令牌数量
+	maxAPITokensPerUser = 20
+	// apiTokenTouchInterval 最近使用时间的最小更新间隔，避免每个请求都写数据库
+	apiTokenTouchInterval = time.Minute
+)
+
+// ErrInvalidAPIToken API令牌不存在、格式错误或已过期
+var ErrInvalidAPIToken = errors.New("无效的API令牌")
+
+// hashAPIToken 计算令牌摘要
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APITokenService 个人API令牌服务
+type APITokenService struct{}
+
+// CreateToken 为用户创建API令牌，返回仅此一次可见的令牌明文
+func (s *APITokenService) CreateToken(userID uint, name string, expiresInDays int) (string, *userModel.UserAPIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, fmt.Errorf("令牌名称不能为空")
+	}
+
+	var count int64
+	if err := global.APP_DB.Model(&userModel.UserAPIToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return "", nil, fmt.Errorf("查询API令牌失败: %w", err)
+	}
+	if count >= maxAPITokensPerUser {
+		return "", nil, fmt.Errorf("每个用户最多创建 %d 个API令牌", maxAPITokensPerUser)
+	}
+
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("生成API令牌失败: %w", err)
+	}
+	plain := userModel.APITokenPrefix + hex.EncodeToString(buf)
+
+	token := &userModel.UserAPIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAPIToken(plain),
+		Prefix:    plain[:len(userModel.APITokenPrefix)+6],
+	}
+	if expiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := global.APP_DB.Create(token).Error; err != nil {
+		return "", nil, fmt.Errorf("保存API令牌失败: %w", err)
+	}
+	global.APP_LOG.Info("创建API令牌", zap.Uint("userID", userID), zap.String("name", name), zap.String("prefix", token.Prefix))
+	return plain, token, nil
+}
+
+// ListTokens 获取用户的API令牌列表
+func (s *APITokenService) ListTokens(userID uint) ([]userModel.UserAPIToken, error) {
+	var tokens []userModel.UserAPIToken
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteToken 撤销用户的API令牌
+func (s *APITokenService) DeleteToken(userID, tokenID uint) error {
+	result := global.APP_DB.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&userModel.UserAPIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("API令牌不存在")
+	}
+	return nil
+}
+
+// ValidateToken 校验API令牌并返回所属用户ID
+func (s *APITokenService) ValidateToken(plain string) (uint, error) {
+	if !strings.HasPrefix(plain, userModel.APITokenPrefix) {
+		return 0, ErrInvalidAPIToken
+	}
+	var token userModel.UserAPIToken
+	if err := global.APP_DB.Where("token_hash = ?", hashAPIToken(plain)).First(&token).Error; err != nil {
+		return 0, ErrInvalidAPIToken
+	}
+	now := time.Now()
+	if token.IsExpired(now) {
+		return 0, ErrInvalidAPIToken
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		global.APP_DB.Model(&token).UpdateColumn("last_used_at", now)
+	}
+	return token.UserID, nil
+}
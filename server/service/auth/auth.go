@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"math/big"
 	mathRand "math/rand"
-	"net/smtp"
 	"oneclickvirt/service/database"
 	"time"
 
@@ -69,6 +68,9 @@ func (s *AuthService) loginWithPassword(req auth.LoginRequest) (*userModel.User,
 	var user userModel.User
 	if err := global.APP_DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
 		global.APP_LOG.Debug("用户登录失败", zap.String("username", utils.SanitizeUserInput(req.Username)), zap.String("error", "record not found"))
+		if hasPendingApplication(req.Username) {
+			return nil, "", common.NewError(common.CodeUserDisabled, "注册申请正在审核中，请耐心等待")
+		}
 		return nil, "", common.NewError(common.CodeInvalidCredentials)
 	}
 
@@ -278,7 +280,7 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 	}
 
 	// 密码强度验证（仅在非初始化场景下执行）
-	if err := utils.ValidatePasswordStrength(req.Password, utils.DefaultPasswordPolicy, req.Username); err != nil {
+	if err := utils.ValidateUserPassword(req.Password, req.Username); err != nil {
 		return err
 	}
 
@@ -297,6 +299,11 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 		}
 	}
 
+	// 注册邮箱策略：域名允许/禁止列表、一次性邮箱、MX记录
+	if err := s.checkRegistrationEmail(req, ip); err != nil {
+		return err
+	}
+
 	// 如果提供了邀请码，提前验证邀请码的有效性（不消费）
 	// 这样可以在验证码被消费前就发现邀请码无效的问题
 	if req.InviteCode != "" {
@@ -305,6 +312,14 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 		}
 	}
 
+	// 开启注册审核时，未使用邀请码的注册进入待审核队列
+	needApproval := req.InviteCode == "" && global.APP_CONFIG.Registration.ApprovalRequired
+	if needApproval {
+		if err := s.checkRegistrationApplication(req, ip); err != nil {
+			return err
+		}
+	}
+
 	// 用户名检查通过后，验证并消费验证码
 	// 这样可以避免用户名已存在时验证码被消费的问题
 	if authValidationService.ShouldCheckCaptcha() {
@@ -317,9 +332,18 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 	if err != nil {
 		return err
 	}
+	if needApproval {
+		return s.submitRegistrationApplication(req, string(hashedPassword), ip, userAgent)
+	}
+	_, err = s.createRegisteredUser(req, string(hashedPassword), ip, userAgent)
+	return err
+}
+
+// createRegisteredUser 根据注册信息创建普通用户、分配默认角色并记录邀请码使用
+func (s *AuthService) createRegisteredUser(req auth.RegisterRequest, hashedPassword string, ip string, userAgent string) (*userModel.User, error) {
 	user := userModel.User{
 		Username: req.Username,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Nickname: req.Nickname,
 		Email:    req.Email,
 		Phone:    req.Phone,
@@ -334,9 +358,9 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 		TrafficLimited: false,
 	}
 
-	// 设置流量重置时间为下个月1号
+	// 设置流量重置时间为默认时区的下个月1号
 	now := time.Now()
-	resetTime := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	resetTime := utils.NextMonthStart(now, utils.DefaultLocation())
 	user.TrafficResetAt = &resetTime
 
 	// 根据全局配置设置用户过期时间
@@ -413,7 +437,10 @@ func (s *AuthService) RegisterWithContext(req auth.RegisterRequest, ip string, u
 		}()
 	}
 
-	return transactionErr
+	if transactionErr != nil {
+		return nil, transactionErr
+	}
+	return &user, nil
 }
 
 // RegisterAndLogin 注册并自动登录
@@ -594,7 +621,7 @@ func (s *AuthService) ForgotPassword(req auth.ForgotPasswordRequest) error {
 	}
 	resetURL := fmt.Sprintf("http://localhost:3000/reset-password?token=%s", resetToken)
 	emailBody := fmt.Sprintf("请点击以下链接重置密码：<br><a href='%s'>重置密码</a><br>链接有效期为24小时。", resetURL)
-	return s.sendEmail(req.Email, "密码重置", emailBody)
+	return utils.SendMail(req.Email, "密码重置", emailBody)
 }
 
 func (s *AuthService) ResetPassword(token, newPassword string) error {
@@ -614,7 +641,7 @@ func (s *AuthService) ResetPassword(token, newPassword string) error {
 	}
 
 	// 密码强度验证
-	if err := utils.ValidatePasswordStrength(newPassword, utils.DefaultPasswordPolicy, user.Username); err != nil {
+	if err := utils.ValidateUserPassword(newPassword, user.Username); err != nil {
 		return err
 	}
 
@@ -659,11 +686,11 @@ func (s *AuthService) ResetPasswordWithToken(token string) error {
 		return err
 	}
 
-	// 生成强密码（12位）
-	newPassword := utils.GenerateStrongPassword(12)
+	// 生成强密码，长度由密码策略配置
+	newPassword := utils.GenerateUserPassword()
 
 	// 密码强度验证（确保生成的密码符合策略）
-	if err := utils.ValidatePasswordStrength(newPassword, utils.DefaultPasswordPolicy, user.Username); err != nil {
+	if err := utils.ValidatePasswordStrength(newPassword, utils.GeneratedPasswordPolicy(), user.Username); err != nil {
 		return err
 	}
 
@@ -747,7 +774,7 @@ func (s *AuthService) sendPasswordByEmail(email, username, newPassword string) e
 	// 实际实现中应该调用邮件服务
 	subject := "密码重置成功"
 	body := fmt.Sprintf("您好 %s，<br><br>您的新密码是：<strong>%s</strong><br><br>请妥善保管并尽快登录修改密码。", username, newPassword)
-	return s.sendEmail(email, subject, body)
+	return utils.SendMail(email, subject, body)
 }
 
 // sendPasswordByTelegram 通过Telegram发送新密码
@@ -885,7 +912,7 @@ func (s *AuthService) sendEmailCode(email, code string) error {
 
 	subject := "登录验证码"
 	body := fmt.Sprintf("您的登录验证码是：<strong>%s</strong><br><br>验证码5分钟内有效，请勿泄露给他人。", code)
-	return s.sendEmail(email, subject, body)
+	return utils.SendMail(email, subject, body)
 }
 
 func (s *AuthService) sendTelegramCode(telegram, code string) error {
@@ -1009,22 +1036,6 @@ func (s *AuthService) sendSMSCode(phone, code string) error {
 	return errors.New("短信验证码服务API集成待实现，请配置短信服务商")
 }
 
-func (s *AuthService) sendEmail(to, subject, body string) error {
-	config := global.APP_CONFIG.Auth
-	if config.EmailSMTPHost == "" {
-		return errors.New("邮件服务未配置")
-	}
-	auth := smtp.PlainAuth("", config.EmailUsername, config.EmailPassword, config.EmailSMTPHost)
-	msg := fmt.Sprintf("To: %s\r\nSubject: %s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s", to, subject, body)
-	return smtp.SendMail(
-		fmt.Sprintf("%s:%d", config.EmailSMTPHost, config.EmailSMTPPort),
-		auth,
-		config.EmailUsername,
-		[]string{to},
-		[]byte(msg),
-	)
-}
-
 func generateRandomCode() string {
 	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
 	if err != nil {
@@ -1225,6 +1236,10 @@ func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword strin
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
 		return errors.New("原密码错误")
 	}
+	// 新密码强度验证
+	if err := utils.ValidateUserPassword(newPassword, user.Username); err != nil {
+		return err
+	}
 	// 加密新密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
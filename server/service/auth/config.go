@@ -60,6 +60,14 @@ func (s *ConfigService) UpdateConfig(req configModel.UpdateConfigRequest) error
 				return fmt.Errorf("等级 %d 的最大实例数不能为空或小于等于0", level)
 			}
 
+			if modelLimit.MaxRunning < 0 {
+				return fmt.Errorf("等级 %d 的同时运行实例数不能小于0", level)
+			}
+
+			if modelLimit.MaxSnapshots < 0 {
+				return fmt.Errorf("等级 %d 的定时快照保留数量不能小于0", level)
+			}
+
 			if modelLimit.MaxTraffic <= 0 {
 				return fmt.Errorf("等级 %d 的流量限制不能为空或小于等于0", level)
 			}
@@ -104,6 +112,8 @@ func (s *ConfigService) UpdateConfig(req configModel.UpdateConfigRequest) error
 
 			levelLimits[levelKey] = map[string]interface{}{
 				"max-instances": modelLimit.MaxInstances,
+				"max-running":   modelLimit.MaxRunning,
+				"max-snapshots": modelLimit.MaxSnapshots,
 				"max-resources": modelLimit.MaxResources,
 				"max-traffic":   modelLimit.MaxTraffic,
 			}
@@ -144,7 +154,7 @@ func (s *ConfigService) GetConfig() map[string]interface{} {
 	}
 
 	// 从配置管理器获取扁平化配置
-	flatConfig := configManager.GetAllConfig()
+	flatConfig := config.MaskSecretConfig(configManager.GetAllConfig())
 	global.APP_LOG.Info("获取扁平化配置", zap.Int("count", len(flatConfig)))
 
 	// 记录所有auth相关的配置
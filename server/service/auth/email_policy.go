@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/auth"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/emailpolicy"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// checkRegistrationEmail 按注册邮箱策略检查邮箱，被拒绝时记录以便统计
+func (s *AuthService) checkRegistrationEmail(req auth.RegisterRequest, ip string) error {
+	rejection := emailpolicy.FromConfig().Check(context.Background(), req.Email)
+	if rejection == nil {
+		return nil
+	}
+	record := userModel.RegistrationRejection{
+		Username: utils.TruncateString(req.Username, 64),
+		Email:    utils.TruncateString(req.Email, 100),
+		Domain:   utils.TruncateString(rejection.Domain, 100),
+		Reason:   rejection.Reason,
+		IP:       ip,
+	}
+	if err := global.APP_DB.Create(&record).Error; err != nil {
+		global.APP_LOG.Warn("记录注册拒绝失败", zap.Error(err))
+	}
+	global.APP_LOG.Info("注册邮箱未通过策略检查",
+		zap.String("username", req.Username),
+		zap.String("domain", rejection.Domain),
+		zap.String("reason", rejection.Reason),
+		zap.String("ip", ip))
+	return common.NewError(common.CodeInvalidParam, rejection.Message)
+}
+
+// RegistrationRejectionCount 按维度统计的拒绝次数
+type RegistrationRejectionCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// RegistrationRejectionStats 注册拒绝统计
+type RegistrationRejectionStats struct {
+	Days       int                               `json:"days"`
+	Total      int64                             `json:"total"`
+	ByReason   []RegistrationRejectionCount      `json:"byReason"`
+	TopDomains []RegistrationRejectionCount      `json:"topDomains"`
+	TopIPs     []RegistrationRejectionCount      `json:"topIps"`
+	Disposable emailpolicy.DisposableListInfo    `json:"disposable"`
+	Recent     []userModel.RegistrationRejection `json:"recent"`
+}
+
+// RejectionStats 统计最近days天因邮箱策略被拒绝的注册
+func (s *RegistrationService) RejectionStats(days int) (*RegistrationRejectionStats, error) {
+	if days <= 0 || days > 365 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	query := func() *gorm.DB {
+		return global.APP_DB.Model(&userModel.RegistrationRejection{}).Where("created_at > ?", since)
+	}
+
+	stats := &RegistrationRejectionStats{Days: days, Disposable: emailpolicy.DisposableInfo()}
+	if err := query().Count(&stats.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Select("reason AS name, COUNT(*) AS count").
+		Group("reason").Order("count DESC").Scan(&stats.ByReason).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Select("domain AS name, COUNT(*) AS count").Where("domain != ''").
+		Group("domain").Order("count DESC").Limit(10).Scan(&stats.TopDomains).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Select("ip AS name, COUNT(*) AS count").Where("ip != ''").
+		Group("ip").Order("count DESC").Limit(10).Scan(&stats.TopIPs).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Order("id DESC").Limit(20).Find(&stats.Recent).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -314,7 +314,7 @@ func (s *JWTKeyService) ShouldRotateKey() (bool, error) {
 // getActiveKeyVersion 获取活跃密钥版本
 func (s *JWTKeyService) getActiveKeyVersion() (int, error) {
 	var config adminModel.SystemConfig
-	err := global.APP_DB.Where("category = ? AND `key` = ?", JWT_KEY_CATEGORY, JWT_ACTIVE_KEY).First(&config).Error
+	err := global.APP_DB.Where(map[string]interface{}{"category": JWT_KEY_CATEGORY, "key": JWT_ACTIVE_KEY}).First(&config).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			global.APP_LOG.Debug("未找到活跃密钥配置")
@@ -346,7 +346,7 @@ func (s *JWTKeyService) setActiveKeyVersion(version int) error {
 	}
 
 	// 使用upsert逻辑
-	err := global.APP_DB.Where("category = ? AND `key` = ?", JWT_KEY_CATEGORY, JWT_ACTIVE_KEY).
+	err := global.APP_DB.Where(map[string]interface{}{"category": JWT_KEY_CATEGORY, "key": JWT_ACTIVE_KEY}).
 		Assign(config).
 		FirstOrCreate(&config).Error
 
@@ -366,10 +366,11 @@ func (s *JWTKeyService) getNextKeyVersion() (int, error) {
 	var maxVersion int
 
 	// 查询最大版本号
+	keyColumn := utils.QuoteIdent(global.APP_DB, "key")
 	row := global.APP_DB.Raw(`
-		SELECT COALESCE(MAX(CAST(SUBSTR(`+"`key`"+`, LENGTH(?) + 1) AS SIGNED)), 0)
+		SELECT COALESCE(MAX(`+utils.SQLCastInt(global.APP_DB, "SUBSTR("+keyColumn+", LENGTH(?) + 1)")+`), 0)
 		FROM system_configs 
-		WHERE category = ? AND `+"`key`"+` LIKE ?
+		WHERE category = ? AND `+keyColumn+` LIKE ?
 	`, JWT_KEY_PREFIX, JWT_KEY_CATEGORY, JWT_KEY_PREFIX+"%").Row()
 
 	if err := row.Scan(&maxVersion); err != nil {
@@ -391,7 +392,7 @@ func (s *JWTKeyService) getKeyByVersion(version int) (*JWTKey, error) {
 	var config adminModel.SystemConfig
 	keyName := fmt.Sprintf("%s%d", JWT_KEY_PREFIX, version)
 
-	err := global.APP_DB.Where("category = ? AND `key` = ?", JWT_KEY_CATEGORY, keyName).First(&config).Error
+	err := global.APP_DB.Where(map[string]interface{}{"category": JWT_KEY_CATEGORY, "key": keyName}).First(&config).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			global.APP_LOG.Warn("密钥版本不存在", zap.Int("version", version))
@@ -446,8 +447,9 @@ func (s *JWTKeyService) cleanupExpiredKeys() error {
 
 	// 获取所有密钥版本，按版本号降序排列
 	var configs []adminModel.SystemConfig
-	err := global.APP_DB.Where("category = ? AND `key` LIKE ?", JWT_KEY_CATEGORY, JWT_KEY_PREFIX+"%").
-		Order("`key` DESC").
+	keyColumn := utils.QuoteIdent(global.APP_DB, "key")
+	err := global.APP_DB.Where("category = ? AND "+keyColumn+" LIKE ?", JWT_KEY_CATEGORY, JWT_KEY_PREFIX+"%").
+		Order(keyColumn + " DESC").
 		Limit(100). // 限制最多100条配置
 		Find(&configs).Error
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"oneclickvirt/global"
+	authModel "oneclickvirt/model/auth"
 	"oneclickvirt/model/permission"
 	"oneclickvirt/model/user"
 
@@ -40,7 +41,7 @@ func (s *PermissionService) GetUserEffectivePermission(userID uint) (*UserEffect
 	}
 
 	// 验证用户基础权限类型的合法性
-	validTypes := map[string]bool{"user": true, "admin": true}
+	validTypes := map[string]bool{"user": true, "admin": true, authModel.UserTypeAuditor: true}
 	if !validTypes[user.UserType] {
 		global.APP_LOG.Error("用户基础权限类型无效",
 			zap.Uint("userID", userID),
@@ -151,6 +152,23 @@ func (s *PermissionService) HasPermission(userID uint, requiredType string) bool
 	return false
 }
 
+// CanViewAdminData 检查是否可以查看管理数据，管理员和只读审计员均可
+func (s *PermissionService) CanViewAdminData(userID uint) bool {
+	return s.HasPermission(userID, "admin") || s.HasPermission(userID, authModel.UserTypeAuditor)
+}
+
+// GetAdminProviderScope 获取子管理员可管理的Provider ID，返回空表示不限制
+func (s *PermissionService) GetAdminProviderScope(userID uint) ([]uint, error) {
+	var providerIDs []uint
+	if err := global.APP_DB.Model(&permission.AdminProviderScope{}).
+		Where("user_id = ?", userID).
+		Order("provider_id").
+		Pluck("provider_id", &providerIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询管理范围失败: %v", err)
+	}
+	return providerIDs, nil
+}
+
 // RequireAdminPermission 检查是否具有管理员权限
 func (s *PermissionService) RequireAdminPermission(userID uint) bool {
 	return s.HasPermission(userID, "admin")
@@ -321,6 +339,16 @@ func (s *PermissionService) VerifyAdminPrivilege(userID uint) bool {
 	return false
 }
 
+// CanRevealSecrets 检查用户是否可以查看敏感配置明文
+// 仅基础账户类型为管理员且状态正常的用户可以查看，通过权限组合获得的管理员权限不包含此项
+func (s *PermissionService) CanRevealSecrets(userID uint) bool {
+	var user user.User
+	if err := global.APP_DB.Select("user_type, status").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.Status == 1 && user.UserType == "admin"
+}
+
 // ClearUserPermissionCache 清除指定用户的权限缓存（兼容性方法，现在是空操作）
 func (s *PermissionService) ClearUserPermissionCache(userID uint) {
 	// 无缓存，无需操作
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/auth"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ErrRegistrationPending 注册申请已进入待审核队列，调用方应视为提交成功
+var ErrRegistrationPending = errors.New("注册申请已提交，请等待管理员审核")
+
+const maxRegistrationReasonLength = 512
+
+// checkRegistrationApplication 在消费验证码之前检查申请是否可以提交
+func (s *AuthService) checkRegistrationApplication(req auth.RegisterRequest, ip string) error {
+	cfg := global.APP_CONFIG.Registration
+	if cfg.RequireReason && req.Reason == "" {
+		return common.NewError(common.CodeInvalidParam, "请填写申请理由")
+	}
+	if len([]rune(req.Reason)) > maxRegistrationReasonLength {
+		return common.NewError(common.CodeInvalidParam, fmt.Sprintf("申请理由不能超过%d个字符", maxRegistrationReasonLength))
+	}
+
+	var count int64
+	if err := global.APP_DB.Model(&userModel.RegistrationApplication{}).
+		Where("username = ? AND status = ?", req.Username, userModel.RegistrationStatusPending).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return common.NewError(common.CodeUsernameExists, "该用户名已有待审核的注册申请")
+	}
+
+	if cfg.MaxPerIPPerDay > 0 && ip != "" {
+		if err := global.APP_DB.Model(&userModel.RegistrationApplication{}).
+			Where("ip = ? AND created_at > ?", ip, time.Now().Add(-24*time.Hour)).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(cfg.MaxPerIPPerDay) {
+			return common.NewError(common.CodeTooManyRequests, "注册申请过于频繁，请稍后再试")
+		}
+	}
+
+	if cfg.MaxPending > 0 {
+		if err := global.APP_DB.Model(&userModel.RegistrationApplication{}).
+			Where("status = ?", userModel.RegistrationStatusPending).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(cfg.MaxPending) {
+			return common.NewError(common.CodeTooManyRequests, "待审核的注册申请过多，请稍后再试")
+		}
+	}
+	return nil
+}
+
+// submitRegistrationApplication 保存注册申请，成功时返回 ErrRegistrationPending
+func (s *AuthService) submitRegistrationApplication(req auth.RegisterRequest, hashedPassword string, ip string, userAgent string) error {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	application := userModel.RegistrationApplication{
+		Username:     req.Username,
+		PasswordHash: hashedPassword,
+		Nickname:     req.Nickname,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Telegram:     req.Telegram,
+		QQ:           req.QQ,
+		Reason:       req.Reason,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Status:       userModel.RegistrationStatusPending,
+	}
+	if err := global.APP_DB.Create(&application).Error; err != nil {
+		return err
+	}
+	global.APP_LOG.Info("收到注册申请",
+		zap.Uint("applicationId", application.ID),
+		zap.String("username", application.Username),
+		zap.String("ip", ip))
+	return ErrRegistrationPending
+}
+
+// hasPendingApplication 用户名是否有待审核的注册申请，用于登录时给出明确提示
+func hasPendingApplication(username string) bool {
+	var count int64
+	global.APP_DB.Model(&userModel.RegistrationApplication{}).
+		Where("username = ? AND status = ?", username, userModel.RegistrationStatusPending).
+		Count(&count)
+	return count > 0
+}
+
+// RegistrationReviewResult 批量审核中单个申请的结果
+type RegistrationReviewResult struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+}
+
+// RegistrationService 注册申请审核服务
+type RegistrationService struct {
+	authService AuthService
+}
+
+// NewRegistrationService 创建注册申请审核服务
+func NewRegistrationService() *RegistrationService {
+	return &RegistrationService{}
+}
+
+// ListApplications 分页获取注册申请
+func (s *RegistrationService) ListApplications(req adminModel.RegistrationApplicationListRequest) ([]userModel.RegistrationApplication, int64, error) {
+	req.Normalize()
+	query := global.APP_DB.Model(&userModel.RegistrationApplication{})
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if req.Keyword != "" {
+		like := "%" + req.Keyword + "%"
+		query = query.Where("username LIKE ? OR email LIKE ? OR ip LIKE ?", like, like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	applications := make([]userModel.RegistrationApplication, 0)
+	if err := query.Order("created_at DESC").Offset(req.Offset()).Limit(req.PageSize).Find(&applications).Error; err != nil {
+		return nil, 0, err
+	}
+	return applications, total, nil
+}
+
+// Approve 批量通过注册申请并创建用户，单个申请失败不影响其他申请
+func (s *RegistrationService) Approve(ids []uint, note string, adminID uint) []RegistrationReviewResult {
+	results := make([]RegistrationReviewResult, 0, len(ids))
+	for _, id := range ids {
+		result := RegistrationReviewResult{ID: id}
+		application, err := s.claim(id, userModel.RegistrationStatusApproved, note, adminID)
+		if err != nil {
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Username = application.Username
+
+		user, err := s.createUser(application)
+		if err != nil {
+			// 创建失败时恢复为待审核，管理员可以处理冲突后重试或拒绝
+			s.release(id)
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		global.APP_DB.Model(&userModel.RegistrationApplication{}).Where("id = ?", id).Update("user_id", user.ID)
+
+		result.Success = true
+		result.Message = "已通过"
+		results = append(results, result)
+		s.notify(application, true)
+	}
+	global.APP_LOG.Info("批量通过注册申请", zap.Uint("adminId", adminID), zap.Int("count", len(ids)))
+	return results
+}
+
+// Reject 批量拒绝注册申请
+func (s *RegistrationService) Reject(ids []uint, note string, adminID uint) []RegistrationReviewResult {
+	results := make([]RegistrationReviewResult, 0, len(ids))
+	for _, id := range ids {
+		result := RegistrationReviewResult{ID: id}
+		application, err := s.claim(id, userModel.RegistrationStatusRejected, note, adminID)
+		if err != nil {
+			result.Message = err.Error()
+		} else {
+			result.Username = application.Username
+			result.Success = true
+			result.Message = "已拒绝"
+			s.notify(application, false)
+		}
+		results = append(results, result)
+	}
+	global.APP_LOG.Info("批量拒绝注册申请", zap.Uint("adminId", adminID), zap.Int("count", len(ids)))
+	return results
+}
+
+// claim 将待审核申请更新为审核结果，条件更新保证同一申请不会被重复处理
+func (s *RegistrationService) claim(id uint, status, note string, adminID uint) (*userModel.RegistrationApplication, error) {
+	now := time.Now()
+	result := global.APP_DB.Model(&userModel.RegistrationApplication{}).
+		Where("id = ? AND status = ?", id, userModel.RegistrationStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"review_note": note,
+			"reviewed_by": adminID,
+			"reviewed_at": &now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("申请不存在或已处理")
+	}
+	var application userModel.RegistrationApplication
+	if err := global.APP_DB.First(&application, id).Error; err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
+// release 将申请恢复为待审核
+func (s *RegistrationService) release(id uint) {
+	if err := global.APP_DB.Model(&userModel.RegistrationApplication{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      userModel.RegistrationStatusPending,
+		"review_note": "",
+		"reviewed_by": 0,
+		"reviewed_at": nil,
+	}).Error; err != nil {
+		global.APP_LOG.Error("恢复注册申请状态失败", zap.Uint("applicationId", id), zap.Error(err))
+	}
+}
+
+// createUser 根据申请创建用户，申请期间用户名或邮箱可能已被占用
+func (s *RegistrationService) createUser(application *userModel.RegistrationApplication) (*userModel.User, error) {
+	var count int64
+	global.APP_DB.Model(&userModel.User{}).Where("username = ?", application.Username).Count(&count)
+	if count > 0 {
+		return nil, errors.New("用户名已存在")
+	}
+	if application.Email != "" {
+		global.APP_DB.Model(&userModel.User{}).Where("email = ?", application.Email).Count(&count)
+		if count > 0 {
+			return nil, errors.New("邮箱已被使用")
+		}
+	}
+	return s.authService.createRegisteredUser(auth.RegisterRequest{
+		Username: application.Username,
+		Nickname: application.Nickname,
+		Email:    application.Email,
+		Phone:    application.Phone,
+		Telegram: application.Telegram,
+		QQ:       application.QQ,
+	}, application.PasswordHash, application.IP, application.UserAgent)
+}
+
+// notify 通过邮件通知申请人审核结果，未填写邮箱或未配置邮件服务时仅记录日志
+// 只有实际发送邮件时才在后台进行，不阻塞审核请求
+func (s *RegistrationService) notify(application *userModel.RegistrationApplication, approved bool) {
+	if application.Email == "" || !utils.MailConfigured() {
+		global.APP_LOG.Debug("注册申请未发送审核通知",
+			zap.Uint("applicationId", application.ID),
+			zap.Bool("hasEmail", application.Email != ""))
+		return
+	}
+
+	subject := "注册申请未通过"
+	body := fmt.Sprintf("您好 %s，您的注册申请未通过审核。", html.EscapeString(application.Username))
+	if approved {
+		subject = "注册申请已通过"
+		body = fmt.Sprintf("您好 %s，您的注册申请已通过审核，现在可以使用注册时设置的密码登录。", html.EscapeString(application.Username))
+	}
+	if application.ReviewNote != "" {
+		body += "<br>备注：" + html.EscapeString(application.ReviewNote)
+	}
+	go func(id uint, email string) {
+		if err := utils.SendMail(email, subject, body); err != nil {
+			global.APP_LOG.Warn("发送注册审核通知失败",
+				zap.Uint("applicationId", id),
+				zap.String("email", email),
+				zap.Error(err))
+		}
+	}(application.ID, application.Email)
+}
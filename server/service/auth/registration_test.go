@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	"oneclickvirt/model/auth"
+	"oneclickvirt/model/common"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+func useRegistrationDB(t *testing.T, cfg config.Registration) *gorm.DB {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &userModel.User{}, &userModel.RegistrationApplication{})
+	testutil.RestoreConfig(t)
+	global.APP_CONFIG.Registration = cfg
+	// 未配置邮件服务时审核通知同步跳过，不会在测试结束后再写日志
+	global.APP_CONFIG.Auth.EmailSMTPHost = ""
+	return db
+}
+
+func TestCheckRegistrationApplication(t *testing.T) {
+	db := useRegistrationDB(t, config.Registration{RequireReason: true, MaxPerIPPerDay: 2, MaxPending: 3})
+	for _, app := range []userModel.RegistrationApplication{
+		{Username: "pending", IP: "198.51.100.1", Status: userModel.RegistrationStatusPending},
+		{Username: "rejected", IP: "198.51.100.1", Status: userModel.RegistrationStatusRejected},
+		{Username: "other", IP: "198.51.100.2", Status: userModel.RegistrationStatusPending},
+	} {
+		if err := db.Create(&app).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &AuthService{}
+	tests := []struct {
+		name     string
+		req      auth.RegisterRequest
+		ip       string
+		wantCode int // 0 表示允许提交
+	}{
+		{name: "允许提交", req: auth.RegisterRequest{Username: "alice", Reason: "学习"}, ip: "198.51.100.3"},
+		{name: "被拒绝后可以重新申请", req: auth.RegisterRequest{Username: "rejected", Reason: "再试"}, ip: "198.51.100.3"},
+		{name: "缺少理由", req: auth.RegisterRequest{Username: "alice"}, ip: "198.51.100.3", wantCode: common.CodeInvalidParam},
+		{name: "理由过长", req: auth.RegisterRequest{Username: "alice", Reason: strings.Repeat("长", maxRegistrationReasonLength+1)}, ip: "198.51.100.3", wantCode: common.CodeInvalidParam},
+		{name: "用户名已有待审核申请", req: auth.RegisterRequest{Username: "pending", Reason: "x"}, ip: "198.51.100.3", wantCode: common.CodeUsernameExists},
+		{name: "同一IP申请过多", req: auth.RegisterRequest{Username: "bob", Reason: "x"}, ip: "198.51.100.1", wantCode: common.CodeTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkRegistrationApplication(tt.req, tt.ip)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("应允许提交: %v", err)
+				}
+				return
+			}
+			var appErr *common.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+				t.Fatalf("err = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+
+	// 待审核申请达到上限后暂停接受新申请
+	if err := s.submitRegistrationApplication(auth.RegisterRequest{Username: "carol"}, "hash", "198.51.100.4", ""); !errors.Is(err, ErrRegistrationPending) {
+		t.Fatalf("submit = %v", err)
+	}
+	var appErr *common.AppError
+	if err := s.checkRegistrationApplication(auth.RegisterRequest{Username: "dave", Reason: "x"}, "198.51.100.5"); !errors.As(err, &appErr) || appErr.Code != common.CodeTooManyRequests {
+		t.Errorf("待审核已满时 err = %v", err)
+	}
+}
+
+func TestRegistrationReview(t *testing.T) {
+	db := useRegistrationDB(t, config.Registration{})
+	apps := []userModel.RegistrationApplication{
+		{Username: "taken", Status: userModel.RegistrationStatusPending},
+		{Username: "spam", Status: userModel.RegistrationStatusPending},
+	}
+	if err := db.Create(&apps).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 申请期间用户名已被占用
+	if err := db.Create(&userModel.User{Username: "taken", Password: "x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewRegistrationService()
+	approved := s.Approve([]uint{apps[0].ID, 999}, "", 1)
+	if len(approved) != 2 || approved[0].Success || !strings.Contains(approved[0].Message, "用户名已存在") || approved[1].Success {
+		t.Fatalf("Approve = %+v", approved)
+	}
+	// 创建用户失败的申请恢复为待审核
+	var app userModel.RegistrationApplication
+	if err := db.First(&app, apps[0].ID).Error; err != nil || app.Status != userModel.RegistrationStatusPending || app.ReviewedBy != 0 {
+		t.Errorf("申请状态 = %+v, %v", app, err)
+	}
+
+	tests := []struct {
+		name    string
+		id      uint
+		success bool
+	}{
+		{name: "拒绝待审核申请", id: apps[1].ID, success: true},
+		{name: "已处理的申请不能重复审核", id: apps[1].ID},
+		{name: "不存在的申请", id: 999},
+	}
+	for _, tt := range tests {
+		results := s.Reject([]uint{tt.id}, "重复注册", 1)
+		if len(results) != 1 || results[0].Success != tt.success {
+			t.Errorf("%s: Reject = %+v", tt.name, results)
+		}
+	}
+	var rejected userModel.RegistrationApplication
+	if err := db.First(&rejected, apps[1].ID).Error; err != nil || rejected.Status != userModel.RegistrationStatusRejected || rejected.ReviewNote != "重复注册" {
+		t.Errorf("拒绝后申请 = %+v, %v", rejected, err)
+	}
+}
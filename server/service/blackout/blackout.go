@@ -0,0 +1,254 @@
+// Package blackout 维护窗口判断
+// 窗口内推迟自动执行的破坏性操作（流量超限停机、到期删除、健康检查自动重启），
+// 调用方在窗口内跳过本轮操作，窗口结束后的下一轮检查自然会重新执行
+package blackout
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window 一个维护窗口，Start/End 为当天零点起的分钟数
+// End 小于等于 Start 时窗口跨越午夜，属于开始的那一天
+type Window struct {
+	Days  [7]bool
+	Start int
+	End   int
+}
+
+// Parse 解析窗口，格式为 "[星期] HH:MM-HH:MM"
+// 星期可写为 mon-fri、sat,sun 或 *，省略表示每天
+func Parse(spec string) (Window, error) {
+	var w Window
+	fields := strings.Fields(spec)
+	var days, hours string
+	switch len(fields) {
+	case 1:
+		days, hours = "*", fields[0]
+	case 2:
+		days, hours = fields[0], fields[1]
+	default:
+		return w, fmt.Errorf("维护窗口格式错误: %q，应为 \"mon-fri 19:00-23:00\"", spec)
+	}
+
+	if err := parseDays(strings.ToLower(days), &w.Days); err != nil {
+		return w, fmt.Errorf("维护窗口 %q: %v", spec, err)
+	}
+	start, end, ok := strings.Cut(hours, "-")
+	if !ok {
+		return w, fmt.Errorf("维护窗口 %q: 时间段应为 HH:MM-HH:MM", spec)
+	}
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return w, fmt.Errorf("维护窗口 %q: %v", spec, err)
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("维护窗口 %q: %v", spec, err)
+	}
+	return w, nil
+}
+
+// ParseAll 解析多个窗口，任一格式错误都返回错误
+func ParseAll(specs []string) ([]Window, error) {
+	windows := make([]Window, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		w, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func parseDays(days string, out *[7]bool) error {
+	if days == "*" {
+		for i := range out {
+			out[i] = true
+		}
+		return nil
+	}
+	for _, part := range strings.Split(days, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return fmt.Errorf("无效的星期: %s", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return fmt.Errorf("无效的星期: %s", to)
+			}
+		}
+		// 范围可以跨周，如 fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			out[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseClock(value string) (int, error) {
+	hour, minute, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	h, err1 := strconv.Atoi(hour)
+	m, err2 := strconv.Atoi(minute)
+	if err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("无效的时间: %s", value)
+	}
+	return h*60 + m, nil
+}
+
+// Contains 判断时间是否落在窗口内，t 应已转换到窗口所在时区
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.End > w.Start {
+		return w.Days[t.Weekday()] && minute >= w.Start && minute < w.End
+	}
+	// 跨越午夜：开始当天的 Start 之后，或前一天开始的窗口在今天 End 之前
+	if w.Days[t.Weekday()] && minute >= w.Start {
+		return true
+	}
+	return w.Days[(t.Weekday()+6)%7] && minute < w.End
+}
+
+// location 返回配置的时区，无效或未配置时使用系统默认时区
+func location() *time.Location {
+	return LoadLocation(global.APP_CONFIG.Blackout.Timezone)
+}
+
+// LoadLocation 加载窗口使用的时区，无效或为空时使用系统默认时区
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return utils.DefaultLocation()
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		global.APP_LOG.Warn("窗口时区无效，使用默认时区", zap.String("timezone", name), zap.Error(err))
+		return utils.DefaultLocation()
+	}
+	return loc
+}
+
+// EncodeProviderWindows 校验并序列化Provider的维护窗口，为空时返回空字符串
+func EncodeProviderWindows(specs []string) (string, error) {
+	var cleaned []string
+	for _, spec := range specs {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			cleaned = append(cleaned, spec)
+		}
+	}
+	if len(cleaned) == 0 {
+		return "", nil
+	}
+	if _, err := ParseAll(cleaned); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Checker 判断当前是否处于维护窗口，按Provider缓存结果，适合在一轮检查中重复调用
+type Checker struct {
+	now       time.Time
+	global    bool
+	providers map[uint]bool
+}
+
+// NewChecker 创建以 now 为判断时间的检查器
+func NewChecker(now time.Time) *Checker {
+	now = now.In(location())
+	c := &Checker{now: now, providers: make(map[uint]bool)}
+	windows, err := ParseAll(global.APP_CONFIG.Blackout.Windows)
+	if err != nil {
+		global.APP_LOG.Warn("全局维护窗口配置错误，已忽略", zap.Error(err))
+	}
+	c.global = anyContains(windows, now)
+	return c
+}
+
+// Global 是否处于全局维护窗口
+func (c *Checker) Global() bool {
+	return c.global
+}
+
+// Active 指定Provider当前是否处于维护窗口（全局窗口或Provider自己的窗口）
+func (c *Checker) Active(providerID uint) bool {
+	if c.global {
+		return true
+	}
+	if active, ok := c.providers[providerID]; ok {
+		return active
+	}
+	var p providerModel.Provider
+	active := false
+	if err := global.APP_DB.Select("id, blackout_windows, timezone").First(&p, providerID).Error; err == nil {
+		windows, err := ParseAll(p.GetBlackoutWindows())
+		if err != nil {
+			global.APP_LOG.Warn("Provider维护窗口配置错误，已忽略", zap.Uint("providerId", providerID), zap.Error(err))
+		}
+		// Provider设置了时区时，自己的窗口按Provider时区判断
+		now := c.now
+		if p.Timezone != "" {
+			now = now.In(utils.LoadLocation(p.Timezone))
+		}
+		active = anyContains(windows, now)
+	}
+	c.providers[providerID] = active
+	return active
+}
+
+// ActiveProviderIDs 返回给定Provider中当前处于维护窗口的ID
+func (c *Checker) ActiveProviderIDs(providerIDs []uint) []uint {
+	var active []uint
+	for _, id := range providerIDs {
+		if c.Active(id) {
+			active = append(active, id)
+		}
+	}
+	return active
+}
+
+// Active 以当前时间判断指定Provider是否处于维护窗口
+func Active(providerID uint) bool {
+	return NewChecker(time.Now()).Active(providerID)
+}
+
+func anyContains(windows []Window, t time.Time) bool {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package blackout
+
+import (
+	"testing"
+	"time"
+)
+
+func at(weekday time.Weekday, hour, minute int) time.Time {
+	// 2024-01-07 是星期日
+	return time.Date(2024, 1, 7+int(weekday), hour, minute, 0, 0, time.UTC)
+}
+
+func TestWindowContains(t *testing.T) {
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"mon-fri 19:00-23:00", at(time.Monday, 19, 0), true},
+		{"mon-fri 19:00-23:00", at(time.Friday, 22, 59), true},
+		{"mon-fri 19:00-23:00", at(time.Friday, 23, 0), false},
+		{"mon-fri 19:00-23:00", at(time.Saturday, 20, 0), false},
+		{"sat,sun 10:00-12:00", at(time.Sunday, 11, 0), true},
+		{"20:00-21:00", at(time.Wednesday, 20, 30), true},
+		{"* 00:00-24:00", at(time.Tuesday, 23, 59), true},
+		// 跨越午夜的窗口属于开始的那一天
+		{"fri 22:00-02:00", at(time.Friday, 23, 0), true},
+		{"fri 22:00-02:00", at(time.Saturday, 1, 59), true},
+		{"fri 22:00-02:00", at(time.Saturday, 2, 0), false},
+		{"fri 22:00-02:00", at(time.Friday, 1, 0), false},
+		// 星期范围可以跨周
+		{"fri-mon 09:00-10:00", at(time.Sunday, 9, 30), true},
+		{"fri-mon 09:00-10:00", at(time.Tuesday, 9, 30), false},
+	}
+	for _, tt := range tests {
+		w, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("%q contains %s = %v, want %v", tt.spec, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "mon", "funday 10:00-11:00", "mon 10:00", "mon 25:00-26:00", "mon 10:60-11:00", "mon 10:00-11:00 extra"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) expected error", spec)
+		}
+	}
+}
+
+func TestEncodeProviderWindows(t *testing.T) {
+	got, err := EncodeProviderWindows([]string{" mon-fri 19:00-23:00 ", ""})
+	if err != nil || got != `["mon-fri 19:00-23:00"]` {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if got, err := EncodeProviderWindows(nil); err != nil || got != "" {
+		t.Fatalf("empty: got %q, %v", got, err)
+	}
+	if _, err := EncodeProviderWindows([]string{"bad"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
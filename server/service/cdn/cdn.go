@@ -0,0 +1,226 @@
+// Package cdn 静态资源CDN改写与私有资源签名
+// 配置了 cdn.asset-domain 后，前端静态资源和头像的URL改写到CDN域名并追加版本号，CDN回源到本服务；
+// 私有资源（上传目录下的文件）只能通过带有效期和HMAC签名的URL访问
+package cdn
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+)
+
+const (
+	// 头像回源路径前缀，头像公开访问不需要签名
+	AvatarPathPrefix = "/api/v1/public/avatars/"
+	// 私有资源回源路径前缀，需要签名
+	PrivateAssetPathPrefix = "/api/v1/public/assets/"
+	// 未配置时私有资源签名的有效期（秒）
+	defaultSignExpire = 3600
+	// 签名有效期上限（秒）
+	maxSignExpire = 604800
+)
+
+var (
+	// ErrSignDisabled 未配置签名密钥
+	ErrSignDisabled = errors.New("未配置私有资源签名密钥")
+	// ErrSignExpired 签名已过期
+	ErrSignExpired = errors.New("签名已过期")
+	// ErrSignInvalid 签名无效
+	ErrSignInvalid = errors.New("签名无效")
+)
+
+// 前端构建产物中引用 /assets/ 下资源的属性，如 src="/assets/index-abc.js"
+var htmlAssetPattern = regexp.MustCompile(`((?:src|href)=["'])(/assets/[^"'?#]+)`)
+
+// Options 静态资源CDN设置
+type Options struct {
+	AssetDomain  string
+	AssetVersion string
+	SignKey      string
+	SignExpire   int
+}
+
+// Current 返回当前生效的CDN设置
+func Current() Options {
+	cfg := global.APP_CONFIG.CDN
+	domain, err := config.NormalizeAssetDomain(cfg.AssetDomain)
+	if err != nil {
+		domain = ""
+	}
+	return Options{
+		AssetDomain:  domain,
+		AssetVersion: strings.TrimSpace(cfg.AssetVersion),
+		SignKey:      cfg.SignKey,
+		SignExpire:   cfg.SignExpire,
+	}
+}
+
+// Enabled 是否配置了静态资源CDN域名
+func (o Options) Enabled() bool {
+	return o.AssetDomain != ""
+}
+
+// expire 签名有效期
+func (o Options) expire() time.Duration {
+	seconds := o.SignExpire
+	if seconds <= 0 {
+		seconds = defaultSignExpire
+	}
+	if seconds > maxSignExpire {
+		seconds = maxSignExpire
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// AssetURL 将站内资源路径改写为CDN地址并追加版本号
+// 绝对地址、协议相对地址和 data: 地址保持不变；未配置CDN域名时只追加版本号
+func (o Options) AssetURL(p string) string {
+	if p == "" || isExternal(p) {
+		return p
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return o.AssetDomain + withVersion(p, o.AssetVersion)
+}
+
+// AvatarURL 返回头像地址，只有文件名时按头像目录处理
+func (o Options) AvatarURL(avatar string) string {
+	if avatar == "" || isExternal(avatar) {
+		return avatar
+	}
+	if !strings.Contains(avatar, "/") {
+		avatar = AvatarPathPrefix + url.PathEscape(avatar)
+	}
+	return o.AssetURL(avatar)
+}
+
+// SignURL 为上传目录下的私有资源生成带有效期和签名的地址
+func (o Options) SignURL(filePath string, now time.Time) (string, error) {
+	if o.SignKey == "" {
+		return "", ErrSignDisabled
+	}
+	clean, ok := CleanAssetPath(filePath)
+	if !ok {
+		return "", ErrSignInvalid
+	}
+	expires := now.Add(o.expire()).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sign", o.signature(clean, expires))
+	if o.AssetVersion != "" {
+		query.Set("v", o.AssetVersion)
+	}
+	return o.AssetDomain + PrivateAssetPathPrefix + escapePath(clean) + "?" + query.Encode(), nil
+}
+
+// Verify 校验私有资源签名
+func (o Options) Verify(filePath, expires, sign string, now time.Time) error {
+	if o.SignKey == "" {
+		return ErrSignDisabled
+	}
+	clean, ok := CleanAssetPath(filePath)
+	if !ok {
+		return ErrSignInvalid
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrSignInvalid
+	}
+	if !hmac.Equal([]byte(sign), []byte(o.signature(clean, exp))) {
+		return ErrSignInvalid
+	}
+	if now.Unix() > exp {
+		return ErrSignExpired
+	}
+	return nil
+}
+
+// RewriteHTML 将前端入口页面中引用的 /assets/ 资源改写为CDN地址
+func (o Options) RewriteHTML(html []byte) []byte {
+	if !o.Enabled() && o.AssetVersion == "" {
+		return html
+	}
+	return htmlAssetPattern.ReplaceAllFunc(html, func(match []byte) []byte {
+		parts := htmlAssetPattern.FindSubmatch(match)
+		return append(append([]byte{}, parts[1]...), o.AssetURL(string(parts[2]))...)
+	})
+}
+
+// signature 计算资源路径和过期时间的HMAC-SHA256签名
+func (o Options) signature(clean string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(o.SignKey))
+	mac.Write([]byte(clean + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CleanAssetPath 规范化上传目录内的相对路径，拒绝越出目录的路径
+func CleanAssetPath(p string) (string, bool) {
+	p = strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "/")
+	if p == "" {
+		return "", false
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+	clean := path.Clean(p)
+	if clean == "." || strings.HasPrefix(clean, "../") {
+		return "", false
+	}
+	return clean, true
+}
+
+// AssetURL 使用当前配置改写资源地址
+func AssetURL(p string) string {
+	return Current().AssetURL(p)
+}
+
+// AvatarURL 使用当前配置返回头像地址
+func AvatarURL(avatar string) string {
+	return Current().AvatarURL(avatar)
+}
+
+// SignURL 使用当前配置为私有资源生成签名地址
+func SignURL(filePath string) (string, error) {
+	return Current().SignURL(filePath, time.Now())
+}
+
+// isExternal 判断是否为不需要改写的外部地址
+func isExternal(p string) bool {
+	lower := strings.ToLower(p)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "//") || strings.HasPrefix(lower, "data:")
+}
+
+// withVersion 追加版本号参数
+func withVersion(p, version string) string {
+	if version == "" {
+		return p
+	}
+	sep := "?"
+	if strings.Contains(p, "?") {
+		sep = "&"
+	}
+	return p + sep + "v=" + url.QueryEscape(version)
+}
+
+// escapePath 逐段转义路径
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
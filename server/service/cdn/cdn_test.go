@@ -0,0 +1,115 @@
+package cdn
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssetURL(t *testing.T) {
+	opts := Options{AssetDomain: "https://cdn.example.com", AssetVersion: "1.2"}
+	cases := map[string]string{
+		"/favicon.ico":               "https://cdn.example.com/favicon.ico?v=1.2",
+		"assets/a.js":                "https://cdn.example.com/assets/a.js?v=1.2",
+		"/assets/a.js?x=1":           "https://cdn.example.com/assets/a.js?x=1&v=1.2",
+		"https://other.example/a.js": "https://other.example/a.js",
+		"//other.example/a.js":       "//other.example/a.js",
+		"data:image/png;base64,AAAA": "data:image/png;base64,AAAA",
+		"":                           "",
+	}
+	for in, want := range cases {
+		if got := opts.AssetURL(in); got != want {
+			t.Errorf("AssetURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := (Options{}).AssetURL("/a.js"); got != "/a.js" {
+		t.Errorf("未配置时不应改写, got %q", got)
+	}
+}
+
+func TestAvatarURL(t *testing.T) {
+	opts := Options{AssetDomain: "https://cdn.example.com"}
+	if got := opts.AvatarURL("u 1.png"); got != "https://cdn.example.com/api/v1/public/avatars/u%201.png" {
+		t.Errorf("AvatarURL = %q", got)
+	}
+	if got := opts.AvatarURL("https://avatars.example/u.png"); got != "https://avatars.example/u.png" {
+		t.Errorf("外部头像不应改写, got %q", got)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	opts := Options{AssetDomain: "https://cdn.example.com", SignKey: "secret", SignExpire: 60}
+	now := time.Unix(1700000000, 0)
+
+	signed, err := opts.SignURL("/exports/a b.zip", now)
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	filePath := strings.TrimPrefix(u.Path, PrivateAssetPathPrefix)
+	if filePath != "exports/a b.zip" {
+		t.Fatalf("path = %q", filePath)
+	}
+	expires, sign := u.Query().Get("expires"), u.Query().Get("sign")
+
+	if err := opts.Verify(filePath, expires, sign, now); err != nil {
+		t.Errorf("有效签名校验失败: %v", err)
+	}
+	if err := opts.Verify("exports/other.zip", expires, sign, now); err != ErrSignInvalid {
+		t.Errorf("篡改路径应失败, got %v", err)
+	}
+	if err := opts.Verify(filePath, expires, sign, now.Add(2*time.Minute)); err != ErrSignExpired {
+		t.Errorf("过期签名应失败, got %v", err)
+	}
+	if err := (Options{SignKey: "other"}).Verify(filePath, expires, sign, now); err != ErrSignInvalid {
+		t.Errorf("不同密钥应失败, got %v", err)
+	}
+	if _, err := (Options{}).SignURL("a.txt", now); err != ErrSignDisabled {
+		t.Errorf("未配置密钥应返回 ErrSignDisabled, got %v", err)
+	}
+	if _, err := opts.SignURL("../etc/passwd", now); err == nil {
+		t.Error("越出目录的路径不应签名")
+	}
+}
+
+func TestCleanAssetPath(t *testing.T) {
+	cases := map[string]bool{
+		"a.txt":         true,
+		"/dir/a.txt":    true,
+		"dir//a.txt":    true,
+		"../a.txt":      false,
+		"dir/../../a":   false,
+		"dir\\..\\a":    false,
+		"":              false,
+		"/":             false,
+		"dir/./a.txt":   true,
+		"dir/..foo.txt": true,
+	}
+	for in, want := range cases {
+		if _, ok := CleanAssetPath(in); ok != want {
+			t.Errorf("CleanAssetPath(%q) ok = %v, want %v", in, ok, want)
+		}
+	}
+}
+
+func TestRewriteHTML(t *testing.T) {
+	opts := Options{AssetDomain: "https://cdn.example.com", AssetVersion: "7"}
+	html := `<script type="module" crossorigin src="/assets/index-abc.js"></script>` +
+		`<link rel="stylesheet" href="/assets/index-abc.css">` +
+		`<link rel="icon" href="/favicon.ico">`
+	got := string(opts.RewriteHTML([]byte(html)))
+	for _, want := range []string{
+		`src="https://cdn.example.com/assets/index-abc.js?v=7"`,
+		`href="https://cdn.example.com/assets/index-abc.css?v=7"`,
+		`href="/favicon.ico"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RewriteHTML 结果缺少 %s: %s", want, got)
+		}
+	}
+}
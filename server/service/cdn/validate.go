@@ -0,0 +1,112 @@
+package cdn
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// 校验时默认的探测路径
+	defaultSamplePath = "/favicon.ico"
+	// 校验时默认的私有资源路径
+	defaultPrivatePath = "cdn-validate.txt"
+	// 探测请求超时
+	probeTimeout = 10 * time.Second
+)
+
+// 探测结果中记录的缓存相关响应头
+var cacheHeaders = []string{"Cache-Control", "Age", "Via", "X-Cache", "X-Cache-Status", "CF-Cache-Status", "ETag", "Last-Modified"}
+
+// ValidationResult CDN配置校验结果
+type ValidationResult struct {
+	Enabled         bool         `json:"enabled"`         // 是否配置了CDN域名
+	AssetURL        string       `json:"assetUrl"`        // 样例资源改写后的地址
+	AvatarURL       string       `json:"avatarUrl"`       // 样例头像地址
+	SignedURL       string       `json:"signedUrl"`       // 样例私有资源签名地址，未配置签名密钥时为空
+	SignatureValid  bool         `json:"signatureValid"`  // 生成的签名能否通过校验
+	TamperRejected  bool         `json:"tamperRejected"`  // 篡改路径后签名是否被拒绝
+	SignError       string       `json:"signError"`       // 签名相关错误
+	Probe           *ProbeResult `json:"probe,omitempty"` // 通过CDN请求样例地址的结果
+	RewrittenSample string       `json:"rewrittenSample"` // 入口页面资源引用的改写示例
+}
+
+// ProbeResult 探测请求结果
+type ProbeResult struct {
+	URL        string            `json:"url"`
+	StatusCode int               `json:"statusCode"`
+	LatencyMs  int64             `json:"latencyMs"`
+	Headers    map[string]string `json:"headers"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// Validate 按给定设置生成样例地址并校验签名，probe 为 true 时通过CDN实际请求样例地址
+func (o Options) Validate(ctx context.Context, samplePath, privatePath string, probe bool) ValidationResult {
+	if samplePath == "" {
+		samplePath = defaultSamplePath
+	}
+	if privatePath == "" {
+		privatePath = defaultPrivatePath
+	}
+
+	result := ValidationResult{
+		Enabled:         o.Enabled(),
+		AssetURL:        o.AssetURL(samplePath),
+		AvatarURL:       o.AvatarURL("example.png"),
+		RewrittenSample: string(o.RewriteHTML([]byte(`<script type="module" src="/assets/index.js"></script>`))),
+	}
+
+	now := time.Now()
+	if signed, err := o.SignURL(privatePath, now); err != nil {
+		result.SignError = err.Error()
+	} else {
+		result.SignedURL = signed
+		// 按回源接口的方式解析签名地址并校验，确认生成和校验一致
+		if u, err := url.Parse(signed); err == nil && strings.Contains(u.Path, PrivateAssetPathPrefix) {
+			filePath := u.Path[strings.Index(u.Path, PrivateAssetPathPrefix)+len(PrivateAssetPathPrefix):]
+			expires, sign := u.Query().Get("expires"), u.Query().Get("sign")
+			result.SignatureValid = o.Verify(filePath, expires, sign, now) == nil
+			result.TamperRejected = o.Verify(filePath+".tampered", expires, sign, now) != nil
+		}
+	}
+
+	if probe {
+		if !o.Enabled() {
+			result.Probe = &ProbeResult{URL: result.AssetURL, Error: "未配置CDN域名"}
+		} else {
+			result.Probe = probeURL(ctx, result.AssetURL)
+		}
+	}
+	return result
+}
+
+// probeURL 以 HEAD 请求探测地址，记录状态码和缓存相关响应头
+func probeURL(ctx context.Context, target string) *ProbeResult {
+	result := &ProbeResult{URL: target, Headers: map[string]string{}}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	for _, name := range cacheHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			result.Headers[name] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+)
+
+// 故障注入点，配置在 task.failure-points 中
+const (
+	// PointCreateAfterPrepare 实例记录已创建、Provider资源已分配，尚未调用Provider创建
+	PointCreateAfterPrepare = "create.after-prepare"
+	// PointCreateAfterProviderCreate Provider已创建实例，尚未写回数据库
+	PointCreateAfterProviderCreate = "create.after-provider-create"
+	// PointCreateBeforeQuotaConfirm 最终化事务中，确认用户配额之前
+	PointCreateBeforeQuotaConfirm = "create.before-quota-confirm"
+	// PointDeleteBeforeDBCleanup Provider已删除实例，尚未清理数据库记录和释放资源
+	PointDeleteBeforeDBCleanup = "delete.before-db-cleanup"
+)
+
+// VerifyDelay 注入故障后等待回滚（含创建失败实例的延迟删除）完成再做一致性检查
+var VerifyDelay = 60 * time.Second
+
+// InjectedError 注入的故障
+type InjectedError struct {
+	Point string
+}
+
+func (e *InjectedError) Error() string {
+	return fmt.Sprintf("故障注入: %s", e.Point)
+}
+
+// Enabled 故障注入是否启用，仅开发环境且配置了故障点时启用
+func Enabled() bool {
+	if global.APP_CONFIG.System.Env != "development" && global.APP_CONFIG.System.Env != "debug" {
+		return false
+	}
+	return len(global.APP_CONFIG.Task.FailurePoints) > 0
+}
+
+// Inject 命中已配置的故障点时返回注入的错误，否则返回nil
+func Inject(point string) error {
+	if !Enabled() {
+		return nil
+	}
+	for _, p := range global.APP_CONFIG.Task.FailurePoints {
+		if p == point {
+			global.APP_LOG.Warn("触发故障注入", zap.String("point", point))
+			return &InjectedError{Point: point}
+		}
+	}
+	return nil
+}
+
+// InjectedPoint 判断错误是否由故障注入产生，是则返回对应的故障点
+func InjectedPoint(err error) (string, bool) {
+	var injected *InjectedError
+	if errors.As(err, &injected) {
+		return injected.Point, true
+	}
+	return "", false
+}
+
+// VerifyAfterRollback 注入故障后延迟检查用户配额和Provider计数是否恢复一致，不一致时记录错误日志
+func VerifyAfterRollback(point string, userID, providerID uint) {
+	go func() {
+		time.Sleep(VerifyDelay)
+
+		issues, err := resources.VerifyConsistency(userID, providerID)
+		if err != nil {
+			global.APP_LOG.Error("故障注入后一致性检查失败",
+				zap.String("point", point),
+				zap.Error(err))
+			return
+		}
+		if len(issues) == 0 {
+			global.APP_LOG.Info("故障注入后资源计数一致",
+				zap.String("point", point),
+				zap.Uint("userId", userID),
+				zap.Uint("providerId", providerID))
+			return
+		}
+		for _, issue := range issues {
+			global.APP_LOG.Error("故障注入后资源计数不一致",
+				zap.String("point", point),
+				zap.String("scope", issue.Scope),
+				zap.String("field", issue.Field),
+				zap.Int64("stored", issue.Stored),
+				zap.Int64("expected", issue.Expected))
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package chaos
+
+import (
+	"fmt"
+	"testing"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+func TestInject(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	saved := global.APP_CONFIG
+	defer func() { global.APP_CONFIG = saved }()
+
+	global.APP_CONFIG.Task.FailurePoints = []string{PointCreateBeforeQuotaConfirm}
+
+	// 生产环境即使配置了故障点也不生效
+	global.APP_CONFIG.System.Env = "production"
+	if err := Inject(PointCreateBeforeQuotaConfirm); err != nil {
+		t.Fatalf("production env should not inject: %v", err)
+	}
+
+	global.APP_CONFIG.System.Env = "development"
+	if err := Inject(PointCreateAfterPrepare); err != nil {
+		t.Fatalf("unconfigured point should not inject: %v", err)
+	}
+	err := Inject(PointCreateBeforeQuotaConfirm)
+	if err == nil {
+		t.Fatal("expected injected error")
+	}
+
+	// 经过包装的错误依然可以识别故障点
+	point, ok := InjectedPoint(fmt.Errorf("finalize: %w", err))
+	if !ok || point != PointCreateBeforeQuotaConfirm {
+		t.Fatalf("InjectedPoint = %q, %v", point, ok)
+	}
+	if _, ok := InjectedPoint(fmt.Errorf("other")); ok {
+		t.Fatal("plain error should not be reported as injected")
+	}
+}
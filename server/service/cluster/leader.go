@@ -0,0 +1,236 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oneclickvirt/global"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// schedulerLeaseName 单例调度器共用的租约名称
+	schedulerLeaseName = "scheduler"
+	// defaultLeaseTTL 默认租约有效期
+	defaultLeaseTTL = 30 * time.Second
+	// minLeaseTTL 租约有效期下限，避免续约过于频繁
+	minLeaseTTL = 6 * time.Second
+)
+
+// LeaderElector 基于数据库租约的主节点选举器
+// 未启用集群模式时本节点始终为主节点；启用后各节点每 TTL/3 尝试获取或续约租约，
+// 主节点故障或退出后租约过期，其他节点在下一次尝试时接管，实现自动故障转移
+type LeaderElector struct {
+	nodeID    string
+	ttl       time.Duration
+	isLeader  atomic.Bool
+	lastRenew time.Time
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	isRunning bool
+}
+
+var (
+	elector     *LeaderElector
+	electorOnce sync.Once
+)
+
+// GetLeaderElector 获取主节点选举器单例
+func GetLeaderElector() *LeaderElector {
+	electorOnce.Do(func() {
+		cfg := global.APP_CONFIG.Cluster
+		nodeID := cfg.NodeID
+		if nodeID == "" {
+			hostname, err := os.Hostname()
+			if err != nil || hostname == "" {
+				hostname = "unknown"
+			}
+			nodeID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+		ttl := time.Duration(cfg.LeaseTTL) * time.Second
+		if ttl <= 0 {
+			ttl = defaultLeaseTTL
+		} else if ttl < minLeaseTTL {
+			ttl = minLeaseTTL
+		}
+		elector = &LeaderElector{
+			nodeID:   nodeID,
+			ttl:      ttl,
+			stopChan: make(chan struct{}),
+		}
+	})
+	return elector
+}
+
+// IsLeader 当前节点是否应运行单例调度任务
+func IsLeader() bool {
+	if !global.APP_CONFIG.Cluster.Enabled {
+		return true
+	}
+	return GetLeaderElector().IsLeader()
+}
+
+// NodeID 返回当前节点ID
+func (e *LeaderElector) NodeID() string {
+	return e.nodeID
+}
+
+// IsLeader 当前节点是否持有租约
+func (e *LeaderElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Start 启动选举循环，启动时同步尝试一次，使调度器首次执行前即可确定身份
+func (e *LeaderElector) Start(ctx context.Context) {
+	if !global.APP_CONFIG.Cluster.Enabled {
+		global.APP_LOG.Info("未启用集群模式，本节点运行所有调度任务")
+		return
+	}
+	if utils.IsSQLite(global.APP_DB) {
+		global.APP_LOG.Warn("SQLite 数据库文件只能由单个节点使用，集群模式下请使用MySQL或PostgreSQL")
+	}
+	if e.isRunning {
+		global.APP_LOG.Warn("主节点选举器已在运行中")
+		return
+	}
+	e.isRunning = true
+
+	global.APP_LOG.Info("启动主节点选举",
+		zap.String("nodeID", e.nodeID),
+		zap.Duration("leaseTTL", e.ttl))
+
+	e.elect()
+	e.wg.Add(1)
+	go e.electionLoop(ctx)
+}
+
+// Stop 停止选举并主动释放租约，其他节点无需等待租约过期即可接管
+// 应在各调度器停止之后调用，避免新旧主节点同时运行任务
+func (e *LeaderElector) Stop() {
+	if !e.isRunning {
+		return
+	}
+	e.stopOnce.Do(func() {
+		close(e.stopChan)
+	})
+	e.wg.Wait()
+	e.isRunning = false
+
+	if e.isLeader.Load() && global.APP_DB != nil {
+		if err := global.APP_DB.Model(&systemModel.SchedulerLease{}).
+			Where("name = ? AND holder = ?", schedulerLeaseName, e.nodeID).
+			Update("expires_at", gorm.Expr(utils.SQLNow(global.APP_DB))).Error; err != nil {
+			global.APP_LOG.Warn("释放主节点租约失败", zap.Error(err))
+		} else {
+			global.APP_LOG.Info("已释放主节点租约", zap.String("nodeID", e.nodeID))
+		}
+	}
+	e.isLeader.Store(false)
+}
+
+// electionLoop 按 TTL/3 的间隔获取或续约租约
+func (e *LeaderElector) electionLoop(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("主节点选举goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		e.wg.Done()
+		global.APP_LOG.Info("主节点选举已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopChan:
+			return
+		case <-ticker.C:
+			e.elect()
+		}
+	}
+}
+
+// elect 执行一次选举并记录身份变化
+func (e *LeaderElector) elect() {
+	attemptAt := time.Now()
+	acquired, err := e.tryAcquire()
+	wasLeader := e.isLeader.Load()
+
+	if err != nil {
+		// 数据库不可用时无法确认租约仍然有效，超过有效期后主动退出主节点身份
+		if wasLeader && time.Since(e.lastRenew) >= e.ttl {
+			e.isLeader.Store(false)
+			global.APP_LOG.Warn("租约续约失败且已过期，退出主节点身份",
+				zap.String("nodeID", e.nodeID),
+				zap.Error(err))
+		} else {
+			global.APP_LOG.Warn("主节点租约获取失败", zap.Error(err))
+		}
+		return
+	}
+
+	if acquired {
+		e.lastRenew = attemptAt
+	}
+	e.isLeader.Store(acquired)
+
+	switch {
+	case acquired && !wasLeader:
+		global.APP_LOG.Info("当前节点成为调度器主节点", zap.String("nodeID", e.nodeID))
+	case !acquired && wasLeader:
+		global.APP_LOG.Warn("当前节点失去调度器主节点身份", zap.String("nodeID", e.nodeID))
+	}
+}
+
+// tryAcquire 原子地获取或续约租约
+// 使用数据库时间判断过期，避免各节点时钟不一致
+func (e *LeaderElector) tryAcquire() (bool, error) {
+	if global.APP_DB == nil {
+		return false, fmt.Errorf("数据库未初始化")
+	}
+	ttlSeconds := int(e.ttl / time.Second)
+
+	// 续约自己的租约，或接管已过期的租约；主动释放的租约到期时间为当前时间，同一时刻即可接管
+	result := global.APP_DB.Model(&systemModel.SchedulerLease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= "+utils.SQLNow(global.APP_DB)+")", schedulerLeaseName, e.nodeID).
+		Updates(map[string]interface{}{
+			"holder":     e.nodeID,
+			"expires_at": gorm.Expr(utils.SQLNowPlusSeconds(global.APP_DB), ttlSeconds),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 租约不存在时创建，并发创建时只有一个节点成功
+	now := time.Now()
+	result = global.APP_DB.Model(&systemModel.SchedulerLease{}).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]interface{}{
+			"name":       schedulerLeaseName,
+			"holder":     e.nodeID,
+			"expires_at": gorm.Expr(utils.SQLNowPlusSeconds(global.APP_DB), ttlSeconds),
+			"created_at": now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
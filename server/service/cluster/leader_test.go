@@ -0,0 +1,102 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+// useLeaseDB 使用生产环境的SQLite驱动，租约过期按数据库时间判断
+func useLeaseDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testutil.UseProductionSQLiteDB(t, &systemModel.SchedulerLease{})
+	testutil.RestoreConfig(t)
+	global.APP_CONFIG.Cluster.Enabled = true
+	return db
+}
+
+func newTestElector(nodeID string) *LeaderElector {
+	return &LeaderElector{nodeID: nodeID, ttl: 30 * time.Second, stopChan: make(chan struct{})}
+}
+
+// expireLease 将租约改为已过期，模拟主节点故障后租约到期
+func expireLease(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Model(&systemModel.SchedulerLease{}).Where("name = ?", schedulerLeaseName).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	db := useLeaseDB(t)
+	a, b := newTestElector("node-a"), newTestElector("node-b")
+
+	steps := []struct {
+		name    string
+		action  func()
+		aLeader bool
+		bLeader bool
+		holder  string // 期望的租约持有者，为空表示不检查
+	}{
+		{name: "首个节点创建租约", action: a.elect, aLeader: true, holder: "node-a"},
+		{name: "租约有效时其他节点不能获取", action: b.elect, aLeader: true, holder: "node-a"},
+		{name: "持有者续约", action: a.elect, aLeader: true, holder: "node-a"},
+		// 原主节点在下一次选举前仍认为自己是主节点，租约TTL内会完成下一次选举
+		{name: "租约过期后其他节点接管", action: func() { expireLease(t, db); b.elect() }, aLeader: true, bLeader: true, holder: "node-b"},
+		{name: "原主节点续约失败后退出", action: a.elect, bLeader: true, holder: "node-b"},
+		{name: "主动释放租约", action: func() { b.isRunning = true; b.Stop() }},
+		{name: "释放后立即接管", action: a.elect, aLeader: true, holder: "node-a"},
+	}
+	for _, step := range steps {
+		step.action()
+		if got := a.IsLeader(); got != step.aLeader {
+			t.Fatalf("%s: node-a IsLeader = %v, want %v", step.name, got, step.aLeader)
+		}
+		if got := b.IsLeader(); got != step.bLeader {
+			t.Fatalf("%s: node-b IsLeader = %v, want %v", step.name, got, step.bLeader)
+		}
+		if step.holder != "" {
+			var lease systemModel.SchedulerLease
+			if err := db.First(&lease, "name = ?", schedulerLeaseName).Error; err != nil || lease.Holder != step.holder {
+				t.Fatalf("%s: 租约持有者 = %q, %v, want %q", step.name, lease.Holder, err, step.holder)
+			}
+		}
+	}
+}
+
+func TestElectDatabaseUnavailable(t *testing.T) {
+	useLeaseDB(t)
+	e := newTestElector("node-a")
+	e.elect()
+	if !e.IsLeader() {
+		t.Fatal("应获得租约")
+	}
+
+	global.APP_DB = nil
+	// 租约仍在有效期内时保持身份
+	e.elect()
+	if !e.IsLeader() {
+		t.Error("租约未过期时不应退出主节点身份")
+	}
+	// 超过有效期仍无法续约时退出
+	e.lastRenew = time.Now().Add(-e.ttl)
+	e.elect()
+	if e.IsLeader() {
+		t.Error("租约过期后应退出主节点身份")
+	}
+}
+
+func TestIsLeaderClusterDisabled(t *testing.T) {
+	oldConfig := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = oldConfig })
+	global.APP_CONFIG.Cluster.Enabled = false
+	if !IsLeader() {
+		t.Error("未启用集群模式时应始终为主节点")
+	}
+}
@@ -0,0 +1,440 @@
+// Package consolerecord 管理员终端会话录制
+// 管理员通过网页终端连接用户实例时，会话内容按 asciicast v2 格式写入临时文件，结束后写入对象存储，
+// 录像只对完整权限的管理员开放，下载写入审计日志，超过保留天数后删除
+package consolerecord
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/storage"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetentionDays = 90
+	defaultMaxSizeMB     = 20
+
+	// NotifyNone 不通知实例所有者
+	NotifyNone = "none"
+	// NotifyEmail 会话开始时邮件通知实例所有者
+	NotifyEmail = "email"
+
+	// 管理员终端会话最长24小时，超过该时间仍处于录制中的记录视为异常中断
+	staleRecordingAge = 25 * time.Hour
+)
+
+// ErrRecordingNotFound 录像不存在或已删除
+var ErrRecordingNotFound = errors.New("录像不存在或已删除")
+
+// Enabled 是否录制管理员终端会话
+func Enabled() bool {
+	return global.APP_CONFIG.ConsoleRecording.Enabled
+}
+
+// RetentionDays 返回录像保留天数
+func RetentionDays() int {
+	if days := global.APP_CONFIG.ConsoleRecording.RetentionDays; days > 0 {
+		return days
+	}
+	return defaultRetentionDays
+}
+
+// maxSize 返回单个录像的最大字节数
+func maxSize() int64 {
+	sizeMB := global.APP_CONFIG.ConsoleRecording.MaxSizeMB
+	if sizeMB <= 0 {
+		sizeMB = defaultMaxSizeMB
+	}
+	return int64(sizeMB) * 1024 * 1024
+}
+
+// SessionInfo 终端会话信息
+type SessionInfo struct {
+	Instance  *providerModel.Instance
+	AdminID   uint
+	AdminName string
+	ClientIP  string
+	Cols      int
+	Rows      int
+}
+
+// Recorder 单个终端会话的录制器，方法可并发调用，nil Recorder 的方法不执行任何操作
+type Recorder struct {
+	mu         sync.Mutex
+	record     *adminModel.ConsoleRecording
+	file       *os.File
+	w          *bufio.Writer
+	start      time.Time
+	limit      int64
+	size       int64
+	input      bool
+	truncated  bool
+	closed     bool
+	outPending []byte
+	errPending []byte
+	inPending  []byte
+}
+
+// asciicastHeader asciicast v2 文件头
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Start 开始录制终端会话，未启用录制时返回nil
+func Start(info SessionInfo) (*Recorder, error) {
+	if !Enabled() || info.Instance == nil {
+		return nil, nil
+	}
+	cfg := global.APP_CONFIG.ConsoleRecording
+	now := time.Now()
+	record := &adminModel.ConsoleRecording{
+		InstanceID:    info.Instance.ID,
+		InstanceName:  info.Instance.Name,
+		OwnerID:       info.Instance.UserID,
+		AdminID:       info.AdminID,
+		AdminName:     info.AdminName,
+		ClientIP:      info.ClientIP,
+		Status:        adminModel.ConsoleRecordingStatusRecording,
+		StartedAt:     now,
+		InputRecorded: cfg.RecordInput,
+	}
+	if err := global.APP_DB.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("创建录像记录失败: %w", err)
+	}
+
+	tempDir := storage.GetStorageService().GetTempPath()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		markFailed(record, fmt.Sprintf("创建临时目录失败: %v", err))
+		return nil, err
+	}
+	file, err := os.CreateTemp(tempDir, fmt.Sprintf("console-%d-*.cast", record.ID))
+	if err != nil {
+		markFailed(record, fmt.Sprintf("创建临时录像文件失败: %v", err))
+		return nil, err
+	}
+
+	r := &Recorder{
+		record: record,
+		file:   file,
+		w:      bufio.NewWriter(file),
+		start:  now,
+		limit:  maxSize(),
+		input:  cfg.RecordInput,
+	}
+	cols, rows := info.Cols, info.Rows
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+	header, _ := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: now.Unix(),
+		Title:     fmt.Sprintf("%s @ %s", info.AdminName, info.Instance.Name),
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	r.writeLine(header)
+
+	if cfg.NotifyPolicy != NotifyNone {
+		go notifyOwner(record)
+	}
+	return r, nil
+}
+
+// ID 返回录像记录ID
+func (r *Recorder) ID() uint {
+	if r == nil {
+		return 0
+	}
+	return r.record.ID
+}
+
+// Stdout 记录终端标准输出
+func (r *Recorder) Stdout(p []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outPending = r.event("o", r.outPending, p)
+}
+
+// Stderr 记录终端错误输出
+func (r *Recorder) Stderr(p []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errPending = r.event("o", r.errPending, p)
+}
+
+// Input 记录管理员的键盘输入，未开启输入录制时忽略
+func (r *Recorder) Input(p []byte) {
+	if r == nil || !r.input {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inPending = r.event("i", r.inPending, p)
+}
+
+// Resize 记录终端大小变化
+func (r *Recorder) Resize(cols, rows int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeEvent("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// event 写入一个事件，末尾不完整的UTF-8字符留到下次写入，返回剩余的字节
+func (r *Recorder) event(kind string, pending, p []byte) []byte {
+	data := append(pending, p...)
+	n := completeUTF8(data)
+	if n > 0 {
+		r.writeEvent(kind, string(data[:n]))
+	}
+	return append([]byte(nil), data[n:]...)
+}
+
+// writeEvent 按 [时间, 类型, 数据] 写入一行事件
+func (r *Recorder) writeEvent(kind, data string) {
+	elapsed := time.Since(r.start).Seconds()
+	line, err := json.Marshal([]interface{}{float64(int64(elapsed*1e6)) / 1e6, kind, data})
+	if err != nil {
+		return
+	}
+	r.writeLine(line)
+}
+
+// writeLine 写入一行，超过大小上限后停止录制
+func (r *Recorder) writeLine(line []byte) {
+	if r.closed || r.truncated {
+		return
+	}
+	if r.size+int64(len(line))+1 > r.limit {
+		r.truncated = true
+		return
+	}
+	r.w.Write(line)
+	r.w.WriteByte('\n')
+	r.size += int64(len(line)) + 1
+}
+
+// Close 结束录制并将录像写入对象存储，可重复调用
+func (r *Recorder) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	flushErr := r.w.Flush()
+	closeErr := r.file.Close()
+	r.mu.Unlock()
+	defer os.Remove(r.file.Name())
+
+	endedAt := time.Now()
+	updates := map[string]interface{}{
+		"ended_at":  endedAt,
+		"size":      r.size,
+		"truncated": r.truncated,
+	}
+	if err := errors.Join(flushErr, closeErr); err != nil {
+		updates["status"] = adminModel.ConsoleRecordingStatusFailed
+		updates["error_message"] = utils.TruncateString(fmt.Sprintf("写入临时录像文件失败: %v", err), 500)
+		r.update(updates)
+		return
+	}
+
+	key := storage.ObjectKey(storage.ObjectPrefixConsole, r.start.Format("2006-01-02"),
+		fmt.Sprintf("%d_%d.cast", r.record.InstanceID, r.record.ID))
+	store, err := storage.GetObjectStorage()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		err = storage.PutFile(ctx, store, key, r.file.Name())
+		cancel()
+	}
+	if err != nil {
+		global.APP_LOG.Warn("保存终端会话录像失败", zap.Uint("recordingId", r.record.ID), zap.Error(err))
+		updates["status"] = adminModel.ConsoleRecordingStatusFailed
+		updates["error_message"] = utils.TruncateString(fmt.Sprintf("写入对象存储失败: %v", err), 500)
+		r.update(updates)
+		return
+	}
+	updates["status"] = adminModel.ConsoleRecordingStatusCompleted
+	updates["object_key"] = key
+	r.update(updates)
+}
+
+// update 更新录像记录
+func (r *Recorder) update(updates map[string]interface{}) {
+	if err := global.APP_DB.Model(&adminModel.ConsoleRecording{}).Where("id = ?", r.record.ID).Updates(updates).Error; err != nil {
+		global.APP_LOG.Error("更新终端会话录像记录失败", zap.Uint("recordingId", r.record.ID), zap.Error(err))
+	}
+}
+
+// completeUTF8 返回不以不完整UTF-8字符结尾的前缀长度
+func completeUTF8(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
+
+// markFailed 将录像记录标记为失败
+func markFailed(record *adminModel.ConsoleRecording, reason string) {
+	now := time.Now()
+	global.APP_DB.Model(record).Updates(map[string]interface{}{
+		"status":        adminModel.ConsoleRecordingStatusFailed,
+		"ended_at":      now,
+		"error_message": utils.TruncateString(reason, 500),
+	})
+}
+
+// notifyOwner 邮件通知实例所有者有管理员登录了实例，未绑定邮箱或未配置邮件服务时跳过
+func notifyOwner(record *adminModel.ConsoleRecording) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("通知实例所有者panic", zap.Any("panic", r))
+		}
+	}()
+	if record.OwnerID == 0 || record.OwnerID == record.AdminID {
+		return
+	}
+	var user userModel.User
+	if err := global.APP_DB.Select("id, username, email").First(&user, record.OwnerID).Error; err != nil {
+		return
+	}
+	if user.Email == "" || !utils.MailConfigured() {
+		return
+	}
+	subject := fmt.Sprintf("实例 %s 管理员终端会话通知", record.InstanceName)
+	body := fmt.Sprintf("您好 %s，管理员于 %s 通过网页终端登录了您的实例 %s。<br>本次会话已录制，仅用于运维审计。如有疑问请联系管理员。",
+		html.EscapeString(user.Username), record.StartedAt.Format("2006-01-02 15:04:05"), html.EscapeString(record.InstanceName))
+	if err := utils.SendMail(user.Email, subject, body); err != nil {
+		global.APP_LOG.Warn("发送管理员终端会话通知失败", zap.Uint("recordingId", record.ID), zap.Error(err))
+		return
+	}
+	global.APP_DB.Model(&adminModel.ConsoleRecording{}).Where("id = ?", record.ID).Update("owner_notified", true)
+}
+
+// List 分页查询录像记录
+func List(req adminModel.ConsoleRecordingListRequest) ([]adminModel.ConsoleRecording, int64, error) {
+	query := global.APP_DB.Model(&adminModel.ConsoleRecording{})
+	if req.InstanceID != 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.AdminID != 0 {
+		query = query.Where("admin_id = ?", req.AdminID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计录像数量失败: %v", err)
+	}
+	var recordings []adminModel.ConsoleRecording
+	if err := utils.ApplyListPage(query.Order("id DESC"), req.PageInfo).Find(&recordings).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询录像失败: %v", err)
+	}
+	return recordings, total, nil
+}
+
+// Open 打开已保存的录像，调用方负责关闭
+func Open(ctx context.Context, id uint) (*adminModel.ConsoleRecording, io.ReadCloser, error) {
+	var recording adminModel.ConsoleRecording
+	if err := global.APP_DB.First(&recording, id).Error; err != nil ||
+		recording.Status != adminModel.ConsoleRecordingStatusCompleted || recording.ObjectKey == "" {
+		return nil, nil, ErrRecordingNotFound
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := store.Get(ctx, recording.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, ErrRecordingNotFound
+		}
+		return nil, nil, err
+	}
+	return &recording, reader, nil
+}
+
+// CleanupExpired 删除超过保留天数的录像并标记为过期，异常中断的录制标记为失败
+func CleanupExpired() (int, error) {
+	now := time.Now()
+	global.APP_DB.Model(&adminModel.ConsoleRecording{}).
+		Where("status = ? AND started_at < ?", adminModel.ConsoleRecordingStatusRecording, now.Add(-staleRecordingAge)).
+		Updates(map[string]interface{}{
+			"status":        adminModel.ConsoleRecordingStatusFailed,
+			"error_message": "会话未正常结束，录像未保存",
+		})
+
+	var recordings []adminModel.ConsoleRecording
+	if err := global.APP_DB.Where("status IN (?) AND started_at < ?",
+		[]string{adminModel.ConsoleRecordingStatusCompleted, adminModel.ConsoleRecordingStatusFailed},
+		now.AddDate(0, 0, -RetentionDays())).Find(&recordings).Error; err != nil {
+		return 0, fmt.Errorf("查询过期录像失败: %w", err)
+	}
+	if len(recordings) == 0 {
+		return 0, nil
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	cleaned := 0
+	for _, recording := range recordings {
+		if recording.ObjectKey != "" {
+			if err := store.Delete(ctx, recording.ObjectKey); err != nil {
+				global.APP_LOG.Warn("删除过期录像失败", zap.Uint("recordingId", recording.ID), zap.Error(err))
+				continue
+			}
+		}
+		if err := global.APP_DB.Model(&recording).Updates(map[string]interface{}{
+			"status":     adminModel.ConsoleRecordingStatusExpired,
+			"object_key": "",
+		}).Error; err == nil {
+			cleaned++
+		}
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,107 @@
+package consolerecord
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRecorder(t *testing.T, limit int64, input bool) (*Recorder, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.cast")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return &Recorder{file: file, w: bufio.NewWriter(file), start: time.Now(), limit: limit, input: input}, path
+}
+
+func readEvents(t *testing.T, r *Recorder, path string) [][]interface{} {
+	t.Helper()
+	if err := r.w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events [][]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var event []interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("无效的事件行 %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestRecorderEvents(t *testing.T) {
+	r, path := newTestRecorder(t, 1<<20, false)
+
+	// 多字节字符被拆分到两次输出时合并写入
+	euro := []byte("€")
+	r.Stdout([]byte("a"))
+	r.Stdout(euro[:1])
+	r.Stdout(euro[1:])
+	r.Input([]byte("ls\r"))
+	r.Resize(120, 40)
+	r.Stderr([]byte("err"))
+
+	events := readEvents(t, r, path)
+	want := [][2]string{{"o", "a"}, {"o", "€"}, {"r", "120x40"}, {"o", "err"}}
+	if len(events) != len(want) {
+		t.Fatalf("事件数量 = %d, want %d: %v", len(events), len(want), events)
+	}
+	for i, event := range events {
+		if event[1] != want[i][0] || event[2] != want[i][1] {
+			t.Errorf("事件 %d = %v, want %v", i, event, want[i])
+		}
+	}
+}
+
+func TestRecorderInputAndLimit(t *testing.T) {
+	r, path := newTestRecorder(t, 64, true)
+	r.Input([]byte("whoami\r"))
+	r.Stdout([]byte(strings.Repeat("x", 100)))
+	r.Stdout([]byte("y"))
+	if !r.truncated {
+		t.Fatal("超过大小上限后应标记截断")
+	}
+	events := readEvents(t, r, path)
+	if len(events) != 1 || events[0][1] != "i" || events[0][2] != "whoami\r" {
+		t.Fatalf("截断后不应继续写入, got %v", events)
+	}
+	if r.size > r.limit {
+		t.Errorf("录像大小 %d 超过上限 %d", r.size, r.limit)
+	}
+
+	var nilRecorder *Recorder
+	nilRecorder.Stdout([]byte("a"))
+	nilRecorder.Close()
+}
+
+func TestCompleteUTF8(t *testing.T) {
+	zh := []byte("中")
+	cases := map[string]int{
+		"abc":                3,
+		string(zh[:1]):       0,
+		"a" + string(zh[:2]): 1,
+		"a中":                 4,
+		string([]byte{0xff}): 1,
+		"":                   0,
+	}
+	for in, want := range cases {
+		if got := completeUTF8([]byte(in)); got != want {
+			t.Errorf("completeUTF8(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package counters
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const callbackName = "oneclickvirt:counters_invalidate"
+
+// countedColumns 各表参与分组计数的列，更新只涉及其他列（流量、同步时间等）时计数不变，不需要失效
+var countedColumns = map[string]map[string]bool{
+	"instances": {"provider_id": true, "instance_type": true, "status": true, "deleted_at": true},
+	"ports":     {"provider_id": true, "status": true, "deleted_at": true},
+	"users":     {"level": true, "status": true, "user_type": true, "deleted_at": true},
+}
+
+// rawWritePattern 识别原生SQL写入的目标表，只匹配 INSERT/REPLACE/UPDATE/DELETE 后紧跟的表名
+var rawWritePattern = regexp.MustCompile("(?is)^\\s*(insert\\s+(?:ignore\\s+)?into|replace\\s+into|delete\\s+from|update)\\s+[`\"]?(\\w+)[`\"]?(?:\\s|\\(|$)")
+
+// rawSetPattern 截取原生UPDATE的SET子句
+var rawSetPattern = regexp.MustCompile(`(?is)\bset\b(.*?)(?:\bwhere\b|$)`)
+
+// countedAssignPatterns 匹配SET子句中对参与计数的列的赋值
+var countedAssignPatterns = func() map[string][]*regexp.Regexp {
+	patterns := make(map[string][]*regexp.Regexp, len(countedColumns))
+	for table, columns := range countedColumns {
+		for column := range columns {
+			patterns[table] = append(patterns[table], regexp.MustCompile("(?i)(?:^|[\\s,.`\"])"+column+"[`\"]?\\s*="))
+		}
+	}
+	return patterns
+}()
+
+// RegisterCallbacks 在数据库连接上注册写入回调，实例、端口、用户表中参与计数的数据变化后使对应计数失效
+// 回调排在事务提交之后，隐式事务中的写入提交后才会失效；每个新建的连接都需要注册
+func RegisterCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:commit_or_rollback_transaction").Register(callbackName, afterWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:commit_or_rollback_transaction").Register(callbackName, afterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register(callbackName, afterWrite); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register(callbackName, afterRaw)
+}
+
+// afterWrite 新增和删除总会改变计数
+func afterWrite(db *gorm.DB) {
+	if db.Error != nil || db.Statement == nil || db.RowsAffected == 0 {
+		return
+	}
+	Invalidate(statementTable(db.Statement))
+}
+
+// afterUpdate 只有更新了参与计数的列时才使计数失效
+func afterUpdate(db *gorm.DB) {
+	if db.Error != nil || db.Statement == nil || db.RowsAffected == 0 {
+		return
+	}
+	table := statementTable(db.Statement)
+	columns, ok := countedColumns[table]
+	if !ok {
+		return
+	}
+	updated, known := updatedColumns(db.Statement)
+	if known && !containsAny(columns, updated) {
+		return
+	}
+	Invalidate(table)
+}
+
+// afterRaw 原生SQL没有模型信息，从SQL文本中识别写入的表和列
+func afterRaw(db *gorm.DB) {
+	if db.Error != nil || db.Statement == nil || db.RowsAffected == 0 {
+		return
+	}
+	if table, counted := rawWrite(db.Statement.SQL.String()); counted {
+		Invalidate(table)
+	}
+}
+
+func statementTable(stmt *gorm.Statement) string {
+	if stmt.Table != "" {
+		return stmt.Table
+	}
+	if stmt.Schema != nil {
+		return stmt.Schema.Table
+	}
+	return ""
+}
+
+// updatedColumns 返回更新语句写入的列，以结构体整体保存时无法确定，返回 known=false
+func updatedColumns(stmt *gorm.Statement) (columns []string, known bool) {
+	if len(stmt.Selects) > 0 {
+		for _, name := range stmt.Selects {
+			if name == "*" {
+				return nil, false
+			}
+			columns = append(columns, dbName(stmt, name))
+		}
+		return columns, true
+	}
+	if v := reflect.Indirect(reflect.ValueOf(stmt.Dest)); v.Kind() == reflect.Map {
+		for _, key := range v.MapKeys() {
+			columns = append(columns, dbName(stmt, key.String()))
+		}
+		return columns, true
+	}
+	return nil, false
+}
+
+// dbName 把字段名或列名统一为列名
+func dbName(stmt *gorm.Statement, name string) string {
+	if stmt.Schema != nil {
+		if field := stmt.Schema.LookUpField(name); field != nil {
+			return field.DBName
+		}
+	}
+	return strings.ToLower(name)
+}
+
+func containsAny(set map[string]bool, columns []string) bool {
+	for _, column := range columns {
+		if set[column] {
+			return true
+		}
+	}
+	return false
+}
+
+// rawWrite 返回原生SQL写入的被计数表，UPDATE 只在 SET 子句涉及参与计数的列时视为影响计数
+func rawWrite(sql string) (string, bool) {
+	m := rawWritePattern.FindStringSubmatch(sql)
+	if m == nil {
+		return "", false
+	}
+	table := strings.ToLower(m[2])
+	if _, ok := countedColumns[table]; !ok {
+		return "", false
+	}
+	if !strings.EqualFold(m[1], "update") {
+		return table, true
+	}
+	set := rawSetPattern.FindStringSubmatch(sql[len(m[0]):])
+	if set == nil {
+		return table, true
+	}
+	for _, pattern := range countedAssignPatterns[table] {
+		if pattern.MatchString(set[1]) {
+			return table, true
+		}
+	}
+	return "", false
+}
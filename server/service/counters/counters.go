@@ -0,0 +1,198 @@
+package counters
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/dashboard"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+)
+
+// 仪表板计数器：按维度缓存分组计数，实例、端口、用户表中参与计数的列（状态、类型、所属Provider、等级等）
+// 发生变化时由gorm回调使对应维度失效，下次读取时用一次分组查询重新计算，替代仪表板每次加载时的多次COUNT(*)扫描。
+// 缓存在进程内，不是随写入事务维护的计数行：多个实例部署时其他进程的写入只能在兜底有效期后反映
+
+const (
+	// settleWindow 失效后此时间内计算的结果不视为最新：显式事务中回调先于提交触发，此时可能读到提交前的数据
+	settleWindow = 2 * time.Second
+	// maxAge 计数器的兜底有效期，覆盖绕过gorm的写入和其他进程的写入
+	maxAge = time.Minute
+)
+
+// counter 单个维度的计数缓存
+type counter struct {
+	table string
+	load  func() (interface{}, error)
+
+	version       atomic.Uint64 // 每次失效加一
+	invalidatedAt atomic.Int64  // 最近一次失效时间（UnixNano）
+
+	mu          sync.Mutex // 串行化重新计算，避免并发请求同时扫描表
+	data        interface{}
+	dataVersion uint64
+	computedAt  time.Time
+}
+
+// invalidate 使计数失效
+func (c *counter) invalidate(now time.Time) {
+	c.version.Add(1)
+	c.invalidatedAt.Store(now.UnixNano())
+}
+
+// fresh 缓存的计数是否可直接使用，需持有 c.mu
+func (c *counter) fresh(now time.Time) bool {
+	if c.data == nil || c.dataVersion != c.version.Load() || now.Sub(c.computedAt) >= maxAge {
+		return false
+	}
+	return c.computedAt.Sub(time.Unix(0, c.invalidatedAt.Load())) >= settleWindow
+}
+
+// get 返回缓存的计数，失效时重新计算
+func (c *counter) get() (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.fresh(now) {
+		return c.data, nil
+	}
+	// 先记录版本和时间再查询，查询期间发生的写入会使本次结果失效
+	version := c.version.Load()
+	data, err := c.load()
+	if err != nil {
+		return nil, fmt.Errorf("统计%s失败: %w", c.table, err)
+	}
+	c.data, c.dataVersion, c.computedAt = data, version, now
+	return data, nil
+}
+
+var (
+	instanceCounter = &counter{table: "instances", load: loadInstances}
+	portCounter     = &counter{table: "ports", load: loadPorts}
+	userCounter     = &counter{table: "users", load: loadUsers}
+
+	countersByTable = map[string]*counter{
+		instanceCounter.table: instanceCounter,
+		portCounter.table:     portCounter,
+		userCounter.table:     userCounter,
+	}
+)
+
+func loadInstances() (interface{}, error) {
+	rows := make([]dashboard.InstanceCounter, 0)
+	err := global.APP_DB.Model(&providerModel.Instance{}).
+		Select("provider_id, instance_type, status, COUNT(*) as count").
+		Group("provider_id, instance_type, status").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func loadPorts() (interface{}, error) {
+	rows := make([]dashboard.PortCounter, 0)
+	err := global.APP_DB.Model(&providerModel.Port{}).
+		Select("provider_id, status, COUNT(*) as count").
+		Group("provider_id, status").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func loadUsers() (interface{}, error) {
+	rows := make([]dashboard.UserCounter, 0)
+	err := global.APP_DB.Model(&userModel.User{}).
+		Select("level, status, user_type, COUNT(*) as count").
+		Group("level, status, user_type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// Instances 返回按Provider、实例类型和状态分组的实例数（不含已软删除的实例）
+func Instances() ([]dashboard.InstanceCounter, error) {
+	data, err := instanceCounter.get()
+	if err != nil {
+		return nil, err
+	}
+	return data.([]dashboard.InstanceCounter), nil
+}
+
+// Ports 返回按Provider和状态分组的端口映射数
+func Ports() ([]dashboard.PortCounter, error) {
+	data, err := portCounter.get()
+	if err != nil {
+		return nil, err
+	}
+	return data.([]dashboard.PortCounter), nil
+}
+
+// Users 返回按等级、状态和用户类型分组的用户数
+func Users() ([]dashboard.UserCounter, error) {
+	data, err := userCounter.get()
+	if err != nil {
+		return nil, err
+	}
+	return data.([]dashboard.UserCounter), nil
+}
+
+// Snapshot 返回全部计数器
+func Snapshot() (*dashboard.Counters, error) {
+	instances, err := Instances()
+	if err != nil {
+		return nil, err
+	}
+	ports, err := Ports()
+	if err != nil {
+		return nil, err
+	}
+	users, err := Users()
+	if err != nil {
+		return nil, err
+	}
+	return &dashboard.Counters{Instances: instances, Ports: ports, Users: users}, nil
+}
+
+// Invalidate 使指定表的计数失效，未知的表名会被忽略
+// gorm回调已覆盖常规写入，仅在绕过gorm直接修改数据库时需要手动调用
+func Invalidate(tables ...string) {
+	now := time.Now()
+	for _, table := range tables {
+		if c, ok := countersByTable[table]; ok {
+			c.invalidate(now)
+		}
+	}
+}
+
+// CountInstances 汇总满足条件的实例数，match为nil时汇总全部
+func CountInstances(rows []dashboard.InstanceCounter, match func(dashboard.InstanceCounter) bool) int64 {
+	var total int64
+	for _, row := range rows {
+		if match == nil || match(row) {
+			total += row.Count
+		}
+	}
+	return total
+}
+
+// CountUsers 汇总满足条件的用户数，match为nil时汇总全部
+func CountUsers(rows []dashboard.UserCounter, match func(dashboard.UserCounter) bool) int64 {
+	var total int64
+	for _, row := range rows {
+		if match == nil || match(row) {
+			total += row.Count
+		}
+	}
+	return total
+}
+
+// CountPorts 汇总满足条件的端口映射数，match为nil时汇总全部
+func CountPorts(rows []dashboard.PortCounter, match func(dashboard.PortCounter) bool) int64 {
+	var total int64
+	for _, row := range rows {
+		if match == nil || match(row) {
+			total += row.Count
+		}
+	}
+	return total
+}
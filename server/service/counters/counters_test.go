@@ -0,0 +1,111 @@
+package counters
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"oneclickvirt/model/dashboard"
+	providerModel "oneclickvirt/model/provider"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestCounterFresh(t *testing.T) {
+	now := time.Now()
+	c := &counter{}
+	if c.fresh(now) {
+		t.Fatal("未计算的计数不应有效")
+	}
+
+	c.data, c.computedAt = []dashboard.InstanceCounter{}, now
+	if !c.fresh(now) {
+		t.Fatal("刚计算且未失效的计数应有效")
+	}
+	if c.fresh(now.Add(maxAge)) {
+		t.Error("超过兜底有效期的计数不应有效")
+	}
+
+	c.invalidate(now)
+	if c.fresh(now) {
+		t.Error("失效后的计数不应有效")
+	}
+
+	// 失效后立即重新计算的结果可能读到未提交的数据，需要在稳定窗口之后再计算一次
+	c.dataVersion, c.computedAt = c.version.Load(), now.Add(settleWindow/2)
+	if c.fresh(now.Add(settleWindow / 2)) {
+		t.Error("稳定窗口内计算的计数不应视为最新")
+	}
+	c.computedAt = now.Add(settleWindow)
+	if !c.fresh(now.Add(settleWindow)) {
+		t.Error("稳定窗口之后计算的计数应有效")
+	}
+}
+
+func TestRawWrite(t *testing.T) {
+	cases := []struct {
+		sql   string
+		table string
+	}{
+		{"INSERT INTO `instances` (name) VALUES ('a')", "instances"},
+		{"delete from ports where id = 1", "ports"},
+		{"UPDATE `instances` SET status = 'stopped' WHERE id = 1", "instances"},
+		{"UPDATE users SET `level` = 2, updated_at = NOW()", "users"},
+		{"UPDATE instances i SET i.status = 'running' WHERE i.provider_id = 1", "instances"},
+		// 只更新流量等不参与计数的列
+		{"UPDATE instances SET used_traffic = used_traffic + 10 WHERE status = 'running'", ""},
+		{"UPDATE instances SET traffic_status = 'limited' WHERE id = 1", ""},
+		// 表名只在写入位置匹配
+		{"UPDATE user_instances SET status = 1 WHERE user_id IN (SELECT id FROM users)", ""},
+		{"DELETE FROM tasks WHERE instance_id IN (SELECT id FROM instances)", ""},
+		{"SELECT COUNT(*) FROM instances", ""},
+	}
+	for _, tc := range cases {
+		table, counted := rawWrite(tc.sql)
+		if counted != (tc.table != "") || table != tc.table {
+			t.Errorf("rawWrite(%q) = %q, %v, want %q", tc.sql, table, counted, tc.table)
+		}
+	}
+}
+
+func TestUpdatedColumns(t *testing.T) {
+	s, err := schema.Parse(&providerModel.Instance{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse: %v", err)
+	}
+	columns := countedColumns[s.Table]
+
+	cases := []struct {
+		name   string
+		stmt   *gorm.Statement
+		affect bool
+	}{
+		{"流量列", &gorm.Statement{Schema: s, Dest: map[string]interface{}{"used_traffic": 1, "updated_at": 0}}, false},
+		{"状态列", &gorm.Statement{Schema: s, Dest: map[string]interface{}{"status": "running"}}, true},
+		{"字段名", &gorm.Statement{Schema: s, Dest: map[string]interface{}{"InstanceType": "vm"}}, true},
+		{"Select列", &gorm.Statement{Schema: s, Selects: []string{"Name"}, Dest: &providerModel.Instance{}}, false},
+		{"整体保存", &gorm.Statement{Schema: s, Dest: &providerModel.Instance{}}, true},
+		{"Select*", &gorm.Statement{Schema: s, Selects: []string{"*"}, Dest: &providerModel.Instance{}}, true},
+	}
+	for _, tc := range cases {
+		updated, known := updatedColumns(tc.stmt)
+		if affect := !known || containsAny(columns, updated); affect != tc.affect {
+			t.Errorf("%s: affect = %v (columns %v, known %v)", tc.name, affect, updated, known)
+		}
+	}
+}
+
+func TestCountInstances(t *testing.T) {
+	rows := []dashboard.InstanceCounter{
+		{ProviderID: 1, InstanceType: "vm", Status: "running", Count: 3},
+		{ProviderID: 1, InstanceType: "container", Status: "stopped", Count: 2},
+		{ProviderID: 2, InstanceType: "vm", Status: "failed", Count: 1},
+	}
+	if got := CountInstances(rows, nil); got != 6 {
+		t.Errorf("total = %d", got)
+	}
+	if got := CountInstances(rows, func(c dashboard.InstanceCounter) bool { return c.InstanceType == "vm" }); got != 4 {
+		t.Errorf("vm = %d", got)
+	}
+}
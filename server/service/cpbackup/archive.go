@@ -0,0 +1,413 @@
+package cpbackup
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	configPkg "oneclickvirt/config"
+	adminModel "oneclickvirt/model/admin"
+	authModel "oneclickvirt/model/auth"
+	oauth2Model "oneclickvirt/model/oauth2"
+	permissionModel "oneclickvirt/model/permission"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 导出范围
+const (
+	ScopeCore = "core" // 用户、权限、Provider、实例、端口、配额相关配置
+	ScopeFull = "full" // 额外包含公告、邀请码、分组、分享、健康检查等业务表
+)
+
+// archiveVersion 归档数据格式版本，表结构变化由恢复时的AutoMigrate兼容，只有编码方式变化时才需要递增
+const archiveVersion = 1
+
+// batchSize 导出和恢复时每批处理的行数
+const batchSize = 500
+
+// table 可导出的表，按外键依赖顺序排列：被引用的表在前
+type table struct {
+	model interface{}
+	scope string
+}
+
+// tables 导出的表，监控时序数据、任务、验证码、会话等可重建或临时数据不导出
+var tables = []table{
+	{&userModel.User{}, ScopeCore},
+	{&authModel.Role{}, ScopeCore},
+	{&userModel.UserRole{}, ScopeCore},
+	{&permissionModel.UserPermission{}, ScopeCore},
+	{&oauth2Model.OAuth2Provider{}, ScopeCore},
+	{&providerModel.Provider{}, ScopeCore},
+	{&permissionModel.AdminProviderScope{}, ScopeCore},
+	{&providerModel.ProviderPortRange{}, ScopeCore},
+	{&systemModel.SystemImage{}, ScopeCore},
+	{&providerModel.Instance{}, ScopeCore},
+	{&providerModel.Port{}, ScopeCore},
+	{&adminModel.SystemConfig{}, ScopeCore},
+	{&configPkg.ConfigMigration{}, ScopeCore},
+	{&userModel.UserAPIToken{}, ScopeCore},
+
+	{&userModel.InstanceGroup{}, ScopeFull},
+	{&userModel.InstanceDefaults{}, ScopeFull},
+	{&userModel.UserHook{}, ScopeFull},
+	{&userModel.UserWebhook{}, ScopeFull},
+	{&providerModel.InstanceHealthCheck{}, ScopeFull},
+	{&providerModel.InstanceShare{}, ScopeFull},
+	{&providerModel.InstanceSnapshotSchedule{}, ScopeFull},
+	{&providerModel.InstanceRDNS{}, ScopeFull},
+	{&systemModel.Announcement{}, ScopeFull},
+	{&systemModel.ImagePolicy{}, ScopeFull},
+	{&systemModel.AppTemplate{}, ScopeFull},
+	{&systemModel.InstanceApp{}, ScopeFull},
+	{&systemModel.InviteCode{}, ScopeFull},
+	{&systemModel.InviteCodeUsage{}, ScopeFull},
+	{&userModel.RegistrationApplication{}, ScopeFull},
+	{&adminModel.AbuseReport{}, ScopeFull},
+	{&adminModel.AbuseReportAction{}, ScopeFull},
+	{&adminModel.HostScript{}, ScopeFull},
+	{&adminModel.HostScriptVersion{}, ScopeFull},
+}
+
+var schemaCache sync.Map
+
+// NormalizeScope 返回有效的导出范围，未知值按core处理
+func NormalizeScope(scope string) string {
+	if scope == ScopeFull {
+		return ScopeFull
+	}
+	return ScopeCore
+}
+
+// tablesForScope 返回导出范围包含的表
+func tablesForScope(scope string) []table {
+	if NormalizeScope(scope) == ScopeFull {
+		return tables
+	}
+	result := make([]table, 0, len(tables))
+	for _, t := range tables {
+		if t.scope == ScopeCore {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// parseSchema 解析模型的表结构
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	return schema.Parse(model, &schemaCache, db.NamingStrategy)
+}
+
+// Manifest 归档概要
+type Manifest struct {
+	Version   int              `json:"version"`
+	Scope     string           `json:"scope"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Tables    []string         `json:"tables"`
+	Rows      map[string]int64 `json:"rows"`
+}
+
+// TotalRows 返回归档的总行数
+func (m *Manifest) TotalRows() int64 {
+	var total int64
+	for _, n := range m.Rows {
+		total += n
+	}
+	return total
+}
+
+// archiveHeader 归档数据流的开头，列出之后依次出现的表
+type archiveHeader struct {
+	Version   int
+	Scope     string
+	CreatedAt time.Time
+	Tables    []string
+}
+
+// batchHeader 每批数据之前的行数，为0表示当前表结束
+type batchHeader struct {
+	Rows int
+}
+
+// Export 在一致性快照中导出控制面数据，写入加密归档
+func Export(ctx context.Context, db *gorm.DB, w io.Writer, passphrase, scope string) (*Manifest, error) {
+	if passphrase == "" {
+		return nil, errors.New("未配置加密口令")
+	}
+	ew, err := newEncryptWriter(w, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(ew)
+	enc := gob.NewEncoder(gz)
+
+	selected := tablesForScope(scope)
+	manifest := &Manifest{
+		Version:   archiveVersion,
+		Scope:     NormalizeScope(scope),
+		CreatedAt: time.Now(),
+		Rows:      make(map[string]int64, len(selected)),
+	}
+	schemas := make([]*schema.Schema, 0, len(selected))
+	for _, t := range selected {
+		s, err := parseSchema(db, t.model)
+		if err != nil {
+			return nil, fmt.Errorf("解析表结构失败: %w", err)
+		}
+		schemas = append(schemas, s)
+		manifest.Tables = append(manifest.Tables, s.Table)
+	}
+	if err := enc.Encode(archiveHeader{
+		Version:   manifest.Version,
+		Scope:     manifest.Scope,
+		CreatedAt: manifest.CreatedAt,
+		Tables:    manifest.Tables,
+	}); err != nil {
+		return nil, err
+	}
+
+	// 所有表在同一个只读事务中读取，保证实例、端口、用户之间的引用一致
+	var txOpts []*sql.TxOptions
+	if !utils.IsSQLite(db) {
+		txOpts = append(txOpts, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, s := range schemas {
+			rows, err := exportTable(tx, enc, s)
+			if err != nil {
+				return fmt.Errorf("导出表%s失败: %w", s.Table, err)
+			}
+			manifest.Rows[s.Table] = rows
+		}
+		return nil
+	}, txOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// exportTable 分批导出一张表（包括已软删除的行），返回行数
+func exportTable(tx *gorm.DB, enc *gob.Encoder, s *schema.Schema) (int64, error) {
+	order := make([]string, 0, len(s.PrimaryFieldDBNames))
+	for _, name := range s.PrimaryFieldDBNames {
+		order = append(order, utils.QuoteIdent(tx, name))
+	}
+	var total int64
+	for offset := 0; ; offset += batchSize {
+		batch := reflect.New(reflect.SliceOf(s.ModelType))
+		query := tx.Unscoped().Table(s.Table).Limit(batchSize).Offset(offset)
+		if len(order) > 0 {
+			query = query.Order(strings.Join(order, ", "))
+		}
+		if err := query.Find(batch.Interface()).Error; err != nil {
+			return total, err
+		}
+		n := batch.Elem().Len()
+		if err := enc.Encode(batchHeader{Rows: n}); err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, nil
+		}
+		if err := enc.EncodeValue(batch.Elem()); err != nil {
+			return total, err
+		}
+		total += int64(n)
+		if n < batchSize {
+			return total, enc.Encode(batchHeader{})
+		}
+	}
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// Force 目标数据库已有用户时仍然恢复，归档中包含的表会先被清空
+	Force bool
+}
+
+// ErrTargetNotEmpty 目标面板已初始化
+var ErrTargetNotEmpty = errors.New("目标数据库中已有用户，恢复会覆盖现有数据，确认后使用 --force")
+
+// Restore 解密归档并在一个事务中写入目标数据库，目标数据库需已完成表结构迁移
+// 归档中包含的表会先被清空再写入，保留原有主键，外键引用保持不变
+func Restore(ctx context.Context, db *gorm.DB, r io.Reader, passphrase string, opts RestoreOptions) (*Manifest, error) {
+	dr, err := newDecryptReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(dr)
+	if err != nil {
+		if errors.Is(err, ErrDecryptFailed) || errors.Is(err, ErrTruncated) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	dec := gob.NewDecoder(gz)
+
+	var header archiveHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, decodeError(err)
+	}
+	if header.Version != archiveVersion {
+		return nil, fmt.Errorf("%w: 不支持的格式版本 %d", ErrInvalidArchive, header.Version)
+	}
+
+	byTable := make(map[string]*schema.Schema, len(tables))
+	for _, t := range tables {
+		s, err := parseSchema(db, t.model)
+		if err != nil {
+			return nil, fmt.Errorf("解析表结构失败: %w", err)
+		}
+		byTable[s.Table] = s
+	}
+	schemas := make([]*schema.Schema, 0, len(header.Tables))
+	for _, name := range header.Tables {
+		s, ok := byTable[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: 未知的表 %s", ErrInvalidArchive, name)
+		}
+		schemas = append(schemas, s)
+	}
+
+	if !opts.Force {
+		var users int64
+		if err := db.WithContext(ctx).Model(&userModel.User{}).Unscoped().Count(&users).Error; err != nil {
+			return nil, fmt.Errorf("检查目标数据库失败: %w", err)
+		}
+		if users > 0 {
+			return nil, ErrTargetNotEmpty
+		}
+	}
+
+	manifest := &Manifest{
+		Version:   header.Version,
+		Scope:     header.Scope,
+		CreatedAt: header.CreatedAt,
+		Tables:    header.Tables,
+		Rows:      make(map[string]int64, len(schemas)),
+	}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先按依赖的反序清空，再按顺序写入
+		for i := len(schemas) - 1; i >= 0; i-- {
+			if err := tx.Exec("DELETE FROM " + utils.QuoteIdent(tx, schemas[i].Table)).Error; err != nil {
+				return fmt.Errorf("清空表%s失败: %w", schemas[i].Table, err)
+			}
+		}
+		for _, s := range schemas {
+			rows, err := restoreTable(tx, dec, s)
+			if err != nil {
+				return fmt.Errorf("恢复表%s失败: %w", s.Table, err)
+			}
+			manifest.Rows[s.Table] = rows
+			if err := resetSequence(tx, s); err != nil {
+				return fmt.Errorf("重置表%s的自增序列失败: %w", s.Table, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 读到结尾才能确认最后一块的完整性
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return nil, decodeError(err)
+	}
+	return manifest, nil
+}
+
+// restoreTable 读取一张表的全部批次并写入，返回行数
+// 以列名映射写入而不是直接写入模型：模型写入时零值字段会被替换为数据库默认值（如 false 被写成 true）
+func restoreTable(tx *gorm.DB, dec *gob.Decoder, s *schema.Schema) (int64, error) {
+	var total int64
+	for {
+		var bh batchHeader
+		if err := dec.Decode(&bh); err != nil {
+			return total, decodeError(err)
+		}
+		if bh.Rows == 0 {
+			return total, nil
+		}
+		batch := reflect.New(reflect.SliceOf(s.ModelType))
+		if err := dec.DecodeValue(batch); err != nil {
+			return total, decodeError(err)
+		}
+		rows := rowMaps(tx, s, batch.Elem())
+		if len(rows) > 0 {
+			if err := tx.Table(s.Table).Create(&rows).Error; err != nil {
+				return total, err
+			}
+		}
+		total += int64(len(rows))
+		if bh.Rows < batchSize {
+			var end batchHeader
+			if err := dec.Decode(&end); err != nil {
+				return total, decodeError(err)
+			}
+			if end.Rows != 0 {
+				return total, fmt.Errorf("%w: 批次结构错误", ErrInvalidArchive)
+			}
+			return total, nil
+		}
+	}
+}
+
+// rowMaps 将模型切片转换为列名到值的映射
+func rowMaps(tx *gorm.DB, s *schema.Schema, batch reflect.Value) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, batch.Len())
+	for i := 0; i < batch.Len(); i++ {
+		rv := batch.Index(i)
+		row := make(map[string]interface{}, len(s.DBNames))
+		for _, name := range s.DBNames {
+			field := s.FieldsByDBName[name]
+			value, _ := field.ValueOf(tx.Statement.Context, rv)
+			row[name] = value
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// resetSequence PostgreSQL写入显式主键后不会推进序列，需要手动设置为当前最大值
+func resetSequence(tx *gorm.DB, s *schema.Schema) error {
+	if !utils.IsPostgres(tx) || s.PrioritizedPrimaryField == nil || !s.PrioritizedPrimaryField.AutoIncrement {
+		return nil
+	}
+	column := s.PrioritizedPrimaryField.DBName
+	return tx.Exec(fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), COALESCE((SELECT MAX(%[3]s) FROM %[4]s), 0) + 1, false)",
+		s.Table, column, utils.QuoteIdent(tx, column), utils.QuoteIdent(tx, s.Table))).Error
+}
+
+// decodeError 将解密和解码错误转换为易于理解的错误
+func decodeError(err error) error {
+	switch {
+	case errors.Is(err, ErrDecryptFailed), errors.Is(err, ErrTruncated), errors.Is(err, ErrInvalidArchive):
+		return err
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrTruncated
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+}
@@ -0,0 +1,300 @@
+// Package cpbackup 控制面数据灾备导出与恢复
+// 定时将用户、Provider、实例、端口、配额配置等控制面数据在一致性快照中导出，压缩并用口令加密后写入对象存储，
+// 备用面板通过 restore 命令解密归档写入新数据库，并重新检查各Provider的连通性
+package cpbackup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/service/storage"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultInterval       = 24
+	defaultRetentionCount = 7
+
+	// exportTimeout 单次导出（含上传）的超时时间
+	exportTimeout = 30 * time.Minute
+	// 导出最长不超过exportTimeout，超过该时间仍处于导出中的记录视为异常中断
+	staleBackupAge = 2 * exportTimeout
+)
+
+var (
+	// ErrBackupNotFound 导出记录不存在或归档已删除
+	ErrBackupNotFound = errors.New("导出记录不存在或归档已删除")
+	// ErrBackupRunning 已有导出在进行中
+	ErrBackupRunning = errors.New("已有控制面导出正在进行")
+	// ErrNoPassphrase 未配置加密口令
+	ErrNoPassphrase = errors.New("未配置 control-plane-backup.passphrase，无法导出")
+)
+
+// running 同一节点同一时间只执行一次导出
+var running sync.Mutex
+
+// Interval 返回定时导出间隔
+func Interval() time.Duration {
+	hours := global.APP_CONFIG.ControlPlaneBackup.Interval
+	if hours <= 0 {
+		hours = defaultInterval
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// retentionCount 返回保留的成功导出份数
+func retentionCount() int {
+	if count := global.APP_CONFIG.ControlPlaneBackup.RetentionCount; count > 0 {
+		return count
+	}
+	return defaultRetentionCount
+}
+
+// LastSuccessAt 返回最近一次成功导出的时间，没有时为零值
+func LastSuccessAt() time.Time {
+	var backup adminModel.ControlPlaneBackup
+	if err := global.APP_DB.Where("status IN (?)",
+		[]string{adminModel.ControlPlaneBackupStatusCompleted, adminModel.ControlPlaneBackupStatusPruned}).
+		Order("id DESC").First(&backup).Error; err != nil {
+		return time.Time{}
+	}
+	return backup.CreatedAt
+}
+
+// Run 导出控制面数据并写入对象存储，成功后按保留份数清理旧归档
+func Run(trigger string) (*adminModel.ControlPlaneBackup, error) {
+	record, err := begin(trigger)
+	if err != nil {
+		return nil, err
+	}
+	defer running.Unlock()
+	return record, complete(record)
+}
+
+// RunAsync 创建导出记录后在后台导出，用于管理员手动触发
+func RunAsync(trigger string) (*adminModel.ControlPlaneBackup, error) {
+	record, err := begin(trigger)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				global.APP_LOG.Error("控制面数据导出panic", zap.Any("panic", r))
+			}
+		}()
+		defer running.Unlock()
+		complete(record)
+	}()
+	return record, nil
+}
+
+// begin 检查配置并创建导出记录，成功时持有导出锁
+func begin(trigger string) (*adminModel.ControlPlaneBackup, error) {
+	cfg := global.APP_CONFIG.ControlPlaneBackup
+	if cfg.Passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+	if !running.TryLock() {
+		return nil, ErrBackupRunning
+	}
+	record := &adminModel.ControlPlaneBackup{
+		Trigger: trigger,
+		Scope:   NormalizeScope(cfg.Scope),
+		Status:  adminModel.ControlPlaneBackupStatusRunning,
+	}
+	if err := global.APP_DB.Create(record).Error; err != nil {
+		running.Unlock()
+		return nil, fmt.Errorf("创建导出记录失败: %w", err)
+	}
+	return record, nil
+}
+
+// complete 执行导出并更新记录
+func complete(record *adminModel.ControlPlaneBackup) error {
+	if err := export(record, global.APP_CONFIG.ControlPlaneBackup.Passphrase); err != nil {
+		global.APP_LOG.Error("控制面数据导出失败", zap.Uint("backupId", record.ID), zap.Error(err))
+		update(record, map[string]interface{}{
+			"status":        adminModel.ControlPlaneBackupStatusFailed,
+			"finished_at":   time.Now(),
+			"error_message": utils.TruncateString(err.Error(), 500),
+		})
+		return err
+	}
+	global.APP_LOG.Info("控制面数据导出完成",
+		zap.Uint("backupId", record.ID),
+		zap.String("objectKey", record.ObjectKey),
+		zap.Int64("rows", record.Rows),
+		zap.Int64("size", record.Size))
+
+	if pruned, err := Prune(); err != nil {
+		global.APP_LOG.Warn("清理旧的控制面归档失败", zap.Error(err))
+	} else if pruned > 0 {
+		global.APP_LOG.Info("已清理旧的控制面归档", zap.Int("count", pruned))
+	}
+	return nil
+}
+
+// export 导出到临时文件后写入对象存储，并更新记录
+func export(record *adminModel.ControlPlaneBackup, passphrase string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	tempDir := storage.GetStorageService().GetTempPath()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	file, err := os.CreateTemp(tempDir, fmt.Sprintf("cpbackup-%d-*.ocvbak", record.ID))
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	manifest, err := Export(ctx, global.APP_DB, io.MultiWriter(file, hash, counter), passphrase, record.Scope)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("写入临时文件失败: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	key := storage.ObjectKey(storage.ObjectPrefixBackups, "control-plane",
+		fmt.Sprintf("%s_%d.ocvbak", manifest.CreatedAt.Format("20060102_150405"), record.ID))
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return err
+	}
+	if err := storage.PutFile(ctx, store, key, file.Name()); err != nil {
+		return fmt.Errorf("写入对象存储失败: %w", err)
+	}
+
+	now := time.Now()
+	record.Status = adminModel.ControlPlaneBackupStatusCompleted
+	record.Tables = len(manifest.Tables)
+	record.Rows = manifest.TotalRows()
+	record.Size = counter.n
+	record.Checksum = hex.EncodeToString(hash.Sum(nil))
+	record.ObjectKey = key
+	record.FinishedAt = &now
+	update(record, map[string]interface{}{
+		"status":      record.Status,
+		"tables":      record.Tables,
+		"rows":        record.Rows,
+		"size":        record.Size,
+		"checksum":    record.Checksum,
+		"object_key":  record.ObjectKey,
+		"finished_at": now,
+	})
+	return nil
+}
+
+// update 更新导出记录
+func update(record *adminModel.ControlPlaneBackup, updates map[string]interface{}) {
+	if err := global.APP_DB.Model(&adminModel.ControlPlaneBackup{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+		global.APP_LOG.Error("更新控制面导出记录失败", zap.Uint("backupId", record.ID), zap.Error(err))
+	}
+}
+
+// countingWriter 统计写入的字节数
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// List 分页查询导出记录
+func List(req adminModel.ControlPlaneBackupListRequest) ([]adminModel.ControlPlaneBackup, int64, error) {
+	query := global.APP_DB.Model(&adminModel.ControlPlaneBackup{})
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计导出记录失败: %v", err)
+	}
+	var backups []adminModel.ControlPlaneBackup
+	if err := utils.ApplyListPage(query.Order("id DESC"), req.PageInfo).Find(&backups).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询导出记录失败: %v", err)
+	}
+	return backups, total, nil
+}
+
+// Open 打开已保存的归档，调用方负责关闭
+func Open(ctx context.Context, id uint) (*adminModel.ControlPlaneBackup, io.ReadCloser, error) {
+	var backup adminModel.ControlPlaneBackup
+	if err := global.APP_DB.First(&backup, id).Error; err != nil ||
+		backup.Status != adminModel.ControlPlaneBackupStatusCompleted || backup.ObjectKey == "" {
+		return nil, nil, ErrBackupNotFound
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := store.Get(ctx, backup.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, ErrBackupNotFound
+		}
+		return nil, nil, err
+	}
+	return &backup, reader, nil
+}
+
+// Prune 删除超出保留份数的成功归档，异常中断的导出标记为失败
+func Prune() (int, error) {
+	global.APP_DB.Model(&adminModel.ControlPlaneBackup{}).
+		Where("status = ? AND created_at < ?", adminModel.ControlPlaneBackupStatusRunning, time.Now().Add(-staleBackupAge)).
+		Updates(map[string]interface{}{
+			"status":        adminModel.ControlPlaneBackupStatusFailed,
+			"error_message": "导出未正常结束",
+		})
+
+	var expired []adminModel.ControlPlaneBackup
+	if err := global.APP_DB.Where("status = ?", adminModel.ControlPlaneBackupStatusCompleted).
+		Order("id DESC").Offset(retentionCount()).Find(&expired).Error; err != nil {
+		return 0, fmt.Errorf("查询旧的控制面归档失败: %w", err)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	pruned := 0
+	for _, backup := range expired {
+		if backup.ObjectKey != "" {
+			if err := store.Delete(ctx, backup.ObjectKey); err != nil {
+				global.APP_LOG.Warn("删除旧的控制面归档失败", zap.Uint("backupId", backup.ID), zap.Error(err))
+				continue
+			}
+		}
+		if err := global.APP_DB.Model(&backup).Updates(map[string]interface{}{
+			"status":     adminModel.ControlPlaneBackupStatusPruned,
+			"object_key": "",
+		}).Error; err == nil {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,148 @@
+package cpbackup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		plain := bytes.Repeat([]byte("oneclickvirt"), size/12+1)[:size]
+		var buf bytes.Buffer
+		w, err := newEncryptWriter(&buf, "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(plain); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		archive := buf.Bytes()
+
+		r, err := newDecryptReader(bytes.NewReader(archive), "secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: 解密结果不一致", size)
+		}
+
+		if r, err := newDecryptReader(bytes.NewReader(archive), "wrong"); err == nil {
+			if _, err := io.ReadAll(r); !errors.Is(err, ErrDecryptFailed) {
+				t.Errorf("size %d: 错误口令应解密失败，得到 %v", size, err)
+			}
+		}
+		// 截掉最后一块：剩余部分本身能解密，但缺少最后一块标记
+		if size > chunkSize {
+			lastLen := 4 + (size-1)%chunkSize + 1 + 16
+			r, _ := newDecryptReader(bytes.NewReader(archive[:len(archive)-lastLen]), "secret")
+			if _, err := io.ReadAll(r); !errors.Is(err, ErrTruncated) {
+				t.Errorf("size %d: 截断的归档应报告不完整，得到 %v", size, err)
+			}
+		}
+	}
+
+	if _, err := newDecryptReader(bytes.NewReader([]byte("not an archive at all, really")), "secret"); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("非归档文件应被拒绝，得到 %v", err)
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接相互独立
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	for _, tbl := range tables {
+		if err := db.AutoMigrate(tbl.model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestExportRestore(t *testing.T) {
+	source := openTestDB(t)
+	user := userModel.User{Username: "alice", Password: "hash", Level: 2, TotalQuota: 10}
+	if err := source.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	prov := providerModel.Provider{Name: "node-1", Type: "docker", Endpoint: "10.0.0.1"}
+	if err := source.Create(&prov).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 数据库默认值为true的字段显式设置为false，恢复后需要保持false
+	if err := source.Model(&prov).Update("allow_claim", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < batchSize+3; i++ {
+		port := providerModel.Port{ProviderID: prov.ID, HostPort: 20000 + i, GuestPort: 22}
+		if err := source.Create(&port).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	source.Delete(&providerModel.Port{}, "host_port = ?", 20000)
+
+	var archive bytes.Buffer
+	manifest, err := Export(context.Background(), source, &archive, "secret", ScopeCore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Rows["ports"] != batchSize+3 {
+		t.Errorf("应导出包括软删除在内的全部端口，得到 %d", manifest.Rows["ports"])
+	}
+
+	target := openTestDB(t)
+	restored, err := Restore(context.Background(), target, bytes.NewReader(archive.Bytes()), "secret", RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.TotalRows() != manifest.TotalRows() {
+		t.Errorf("恢复行数 %d，导出行数 %d", restored.TotalRows(), manifest.TotalRows())
+	}
+
+	var gotProvider providerModel.Provider
+	if err := target.First(&gotProvider, prov.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if gotProvider.AllowClaim || gotProvider.Name != "node-1" {
+		t.Errorf("Provider恢复不正确: allowClaim=%v name=%s", gotProvider.AllowClaim, gotProvider.Name)
+	}
+	var gotUser userModel.User
+	if err := target.First(&gotUser, user.ID).Error; err != nil || gotUser.Password != "hash" || gotUser.TotalQuota != 10 {
+		t.Errorf("用户恢复不正确: %+v, %v", gotUser, err)
+	}
+	var active, all int64
+	target.Model(&providerModel.Port{}).Count(&active)
+	target.Unscoped().Model(&providerModel.Port{}).Count(&all)
+	if active != batchSize+2 || all != batchSize+3 {
+		t.Errorf("端口恢复不正确: active=%d all=%d", active, all)
+	}
+
+	// 目标已有用户时需要显式确认
+	if _, err := Restore(context.Background(), target, bytes.NewReader(archive.Bytes()), "secret", RestoreOptions{}); !errors.Is(err, ErrTargetNotEmpty) {
+		t.Errorf("目标非空时应拒绝恢复，得到 %v", err)
+	}
+	if _, err := Restore(context.Background(), target, bytes.NewReader(archive.Bytes()), "secret", RestoreOptions{Force: true}); err != nil {
+		t.Errorf("--force 重复恢复失败: %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package cpbackup
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 归档加密格式：
+//
+//	magic(8) | salt(16) | noncePrefix(7) | 分块...
+//
+// 密钥由口令经scrypt派生，每个分块单独用AES-256-GCM加密，分块前为4字节长度，最高位标记最后一块；
+// nonce由前缀、分块序号和最后一块标记组成，分块被截断、重排或替换时解密失败
+const (
+	archiveMagic    = "OCVCPB1\n"
+	saltSize        = 16
+	noncePrefixSize = 7
+	chunkSize       = 64 * 1024
+	lastChunkFlag   = uint32(1) << 31
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	// ErrInvalidArchive 不是灾备归档或格式版本不支持
+	ErrInvalidArchive = errors.New("不是有效的控制面灾备归档")
+	// ErrDecryptFailed 口令错误或归档已损坏
+	ErrDecryptFailed = errors.New("解密失败，口令错误或归档已损坏")
+	// ErrTruncated 归档不完整
+	ErrTruncated = errors.New("归档不完整，可能未上传或下载完成")
+)
+
+// deriveKey 由口令派生AES-256密钥
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+}
+
+// newAEAD 创建AES-256-GCM
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce 返回分块的nonce
+func chunkNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter 分块加密写入器，必须调用Close写入最后一块
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	buf    []byte
+	index  uint32
+	closed bool
+}
+
+// newEncryptWriter 写入归档头并返回加密写入器
+func newEncryptWriter(w io.Writer, passphrase string) (*encryptWriter, error) {
+	header := make([]byte, len(archiveMagic)+saltSize+noncePrefixSize)
+	copy(header, archiveMagic)
+	if _, err := rand.Read(header[len(archiveMagic):]); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	salt := header[len(archiveMagic) : len(archiveMagic)+saltSize]
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: header[len(archiveMagic)+saltSize:],
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("加密写入器已关闭")
+	}
+	written := 0
+	for len(p) > 0 {
+		n := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+		// 缓冲区满时不立即写出，保证Close时至少还有一块可以标记为最后一块
+		if len(e.buf) == cap(e.buf) && len(p) > 0 {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush 加密并写出缓冲区中的分块
+func (e *encryptWriter) flush(last bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.prefix, e.index, last), e.buf, e.header)
+	length := uint32(len(sealed))
+	if last {
+		length |= lastChunkFlag
+	}
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], length)
+	if _, err := e.w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// Close 写入最后一块，不关闭底层写入器
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.flush(true)
+}
+
+// decryptReader 分块解密读取器，读到最后一块之前遇到EOF时返回ErrTruncated
+type decryptReader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	plain  []byte
+	index  uint32
+	done   bool
+}
+
+// newDecryptReader 校验归档头并返回解密读取器
+func newDecryptReader(r io.Reader, passphrase string) (*decryptReader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(archiveMagic)+saltSize+noncePrefixSize)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(archiveMagic)]) != archiveMagic {
+		return nil, ErrInvalidArchive
+	}
+	key, err := deriveKey(passphrase, header[len(archiveMagic):len(archiveMagic)+saltSize])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		r:      br,
+		aead:   aead,
+		header: header,
+		prefix: header[len(archiveMagic)+saltSize:],
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next 读取并解密下一块
+func (d *decryptReader) next() error {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(d.r, lenBuf[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+	length := binary.BigEndian.Uint32(lenBuf[:])
+	last := length&lastChunkFlag != 0
+	length &^= lastChunkFlag
+	if length > chunkSize+uint32(d.aead.Overhead()) {
+		return ErrDecryptFailed
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return err
+	}
+	plain, err := d.aead.Open(nil, chunkNonce(d.prefix, d.index, last), sealed, d.header)
+	if err != nil {
+		return ErrDecryptFailed
+	}
+	d.index++
+	d.plain = plain
+	if last {
+		d.done = true
+		// 最后一块之后不应再有数据
+		if _, err := d.r.Peek(1); err == nil {
+			return ErrInvalidArchive
+		}
+	}
+	return nil
+}
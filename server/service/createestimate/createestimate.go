@@ -0,0 +1,315 @@
+// Package createestimate 估算新建实例的等待时间
+// 总耗时 = 排队时长 + 镜像下载耗时 + 创建耗时。排队时长按节点上排队和执行中的任务、节点并发数模拟得出；
+// 创建耗时取节点近期已完成创建任务的中位数，节点上首次使用某镜像的创建任务包含镜像下载时间，单独统计
+package createestimate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 统计历史耗时的时间窗口和最多样本数
+	historyWindow     = 30 * 24 * time.Hour
+	historyMaxSamples = 500
+
+	// 无历史数据时的默认创建耗时（秒），与任务预计执行时长一致
+	defaultCreateVM        = 300
+	defaultCreateContainer = 180
+	// 无历史数据时的默认镜像下载耗时（秒），以及按镜像大小估算时的下载速度
+	defaultDownloadSeconds     = 120
+	defaultDownloadBytesPerSec = 10 * 1024 * 1024
+)
+
+// 估算依据
+const (
+	BasisHistory = "history" // 按节点历史耗时
+	BasisDefault = "default" // 节点没有可用的历史数据，按默认耗时
+)
+
+var (
+	// ErrProviderUnavailable 节点不存在或已冻结
+	ErrProviderUnavailable = errors.New("节点不存在或不可用")
+	// ErrImageUnavailable 镜像不存在、未启用或与节点类型不匹配
+	ErrImageUnavailable = errors.New("镜像不存在或不适用于该节点")
+)
+
+// queuedTask 节点上排队或执行中的任务
+type queuedTask struct {
+	Running   bool
+	StartedAt *time.Time
+	Duration  int // 预计执行时长（秒）
+}
+
+// history 节点近期任务的执行耗时（秒）
+type history struct {
+	ByType map[string][]float64 // 各任务类型的执行耗时
+	Warm   []float64            // 镜像已在节点上时的创建耗时（与所选镜像实例类型相同）
+	Cold   []float64            // 节点上首次使用镜像的创建耗时（与所选镜像实例类型相同）
+}
+
+// inputs 估算所需的数据
+type inputs struct {
+	Provider    providerModel.Provider
+	Image       systemModel.SystemImage
+	Queue       []queuedTask
+	ImageCached bool
+	History     history
+}
+
+// Estimate 估算在节点上用镜像新建实例的等待时间
+func Estimate(providerID, imageID uint) (*userModel.CreateEstimateResponse, error) {
+	in, err := collect(providerID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	return build(in, time.Now()), nil
+}
+
+// collect 读取节点、镜像、任务队列、镜像缓存状态和历史耗时
+func collect(providerID, imageID uint) (*inputs, error) {
+	in := &inputs{}
+	if err := global.APP_DB.Select("id", "name", "type", "is_frozen", "allow_concurrent_tasks", "max_concurrent_tasks").
+		First(&in.Provider, providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProviderUnavailable
+		}
+		return nil, fmt.Errorf("查询节点失败: %w", err)
+	}
+	if in.Provider.IsFrozen {
+		return nil, ErrProviderUnavailable
+	}
+	if err := global.APP_DB.Select("id", "name", "provider_type", "instance_type", "status", "size").
+		First(&in.Image, imageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImageUnavailable
+		}
+		return nil, fmt.Errorf("查询镜像失败: %w", err)
+	}
+	if in.Image.Status != "active" || in.Image.ProviderType != in.Provider.Type {
+		return nil, ErrImageUnavailable
+	}
+
+	hist, err := loadHistory(providerID, in.Image.InstanceType)
+	if err != nil {
+		return nil, err
+	}
+	in.History = hist
+
+	// 排队任务的执行时长优先使用节点上同类任务的历史中位数
+	var tasks []adminModel.Task
+	if err := global.APP_DB.Select("id", "task_type", "status", "started_at", "estimated_duration").
+		Where("provider_id = ? AND status IN (?)", providerID, []string{"pending", "processing", "running"}).
+		Order("created_at ASC").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("查询任务队列失败: %w", err)
+	}
+	for _, t := range tasks {
+		duration := t.EstimatedDuration
+		if samples := hist.ByType[t.TaskType]; len(samples) > 0 {
+			duration = int(median(samples))
+		}
+		in.Queue = append(in.Queue, queuedTask{Running: t.Status != "pending", StartedAt: t.StartedAt, Duration: duration})
+	}
+
+	// 节点上用该镜像创建过实例（包括已删除的实例）即认为镜像已缓存
+	var count int64
+	if err := global.APP_DB.Unscoped().Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND image_id = ? AND status <> ?", providerID, imageID, "failed").
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询镜像缓存状态失败: %w", err)
+	}
+	in.ImageCached = count > 0
+	return in, nil
+}
+
+// loadHistory 读取节点近期已完成任务的执行耗时，创建任务按实例类型筛选并区分是否首次使用镜像
+func loadHistory(providerID uint, instanceType string) (history, error) {
+	hist := history{ByType: make(map[string][]float64)}
+	var tasks []adminModel.Task
+	if err := global.APP_DB.Select("id", "task_type", "instance_id", "started_at", "completed_at").
+		Where("provider_id = ? AND status = ? AND started_at IS NOT NULL AND completed_at IS NOT NULL AND completed_at > ?",
+			providerID, "completed", time.Now().Add(-historyWindow)).
+		Order("id DESC").Limit(historyMaxSamples).Find(&tasks).Error; err != nil {
+		return hist, fmt.Errorf("查询历史任务失败: %w", err)
+	}
+
+	createDurations := make(map[uint]float64)
+	var instanceIDs []uint
+	for _, t := range tasks {
+		seconds := t.CompletedAt.Sub(*t.StartedAt).Seconds()
+		if seconds <= 0 {
+			continue
+		}
+		hist.ByType[t.TaskType] = append(hist.ByType[t.TaskType], seconds)
+		if t.TaskType == "create" && t.InstanceID != nil {
+			createDurations[*t.InstanceID] = seconds
+			instanceIDs = append(instanceIDs, *t.InstanceID)
+		}
+	}
+	if len(instanceIDs) == 0 {
+		return hist, nil
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Unscoped().Select("id", "image_id", "instance_type").
+		Where("id IN ? AND image_id <> 0 AND instance_type = ?", instanceIDs, instanceType).
+		Find(&instances).Error; err != nil {
+		return hist, fmt.Errorf("查询历史实例失败: %w", err)
+	}
+	if len(instances) == 0 {
+		return hist, nil
+	}
+	imageIDs := make([]uint, 0, len(instances))
+	for _, inst := range instances {
+		imageIDs = append(imageIDs, inst.ImageID)
+	}
+
+	// 每个镜像在节点上的第一个实例，其创建任务包含镜像下载
+	var rows []struct {
+		ImageID uint
+		FirstID uint
+	}
+	if err := global.APP_DB.Unscoped().Model(&providerModel.Instance{}).
+		Select("image_id, MIN(id) AS first_id").
+		Where("provider_id = ? AND image_id IN ?", providerID, imageIDs).
+		Group("image_id").Scan(&rows).Error; err != nil {
+		return hist, fmt.Errorf("查询镜像首次使用记录失败: %w", err)
+	}
+	firstByImage := make(map[uint]uint, len(rows))
+	for _, row := range rows {
+		firstByImage[row.ImageID] = row.FirstID
+	}
+
+	for _, inst := range instances {
+		if firstByImage[inst.ImageID] == inst.ID {
+			hist.Cold = append(hist.Cold, createDurations[inst.ID])
+		} else {
+			hist.Warm = append(hist.Warm, createDurations[inst.ID])
+		}
+	}
+	return hist, nil
+}
+
+// build 根据收集的数据计算预计耗时
+func build(in *inputs, now time.Time) *userModel.CreateEstimateResponse {
+	concurrency := 1
+	if in.Provider.AllowConcurrentTasks && in.Provider.MaxConcurrentTasks > 1 {
+		concurrency = in.Provider.MaxConcurrentTasks
+	}
+	resp := &userModel.CreateEstimateResponse{
+		ProviderID:   in.Provider.ID,
+		ProviderName: in.Provider.Name,
+		ImageID:      in.Image.ID,
+		ImageName:    in.Image.Name,
+		InstanceType: in.Image.InstanceType,
+		Concurrency:  concurrency,
+		ImageCached:  in.ImageCached,
+		SampleSize:   len(in.History.Warm) + len(in.History.Cold),
+		Basis:        BasisDefault,
+	}
+	for _, t := range in.Queue {
+		if t.Running {
+			resp.RunningTasks++
+		} else {
+			resp.PendingTasks++
+		}
+	}
+	resp.QueueWaitSeconds = queueWait(in.Queue, concurrency, now)
+
+	create := float64(defaultCreateContainer)
+	if in.Image.InstanceType == "vm" {
+		create = defaultCreateVM
+	}
+	download := float64(downloadFallback(in.Image.Size))
+	warm, cold := in.History.Warm, in.History.Cold
+	switch {
+	case len(warm) > 0 && len(cold) > 0:
+		create = median(warm)
+		if extra := median(cold) - create; extra > 0 {
+			download = extra
+		}
+		resp.Basis = BasisHistory
+	case len(warm) > 0:
+		create = median(warm)
+		resp.Basis = BasisHistory
+	case len(cold) > 0:
+		// 只有首次使用镜像的样本时，扣除估算的下载耗时作为创建耗时
+		create = max(median(cold)-download, create/2)
+		resp.Basis = BasisHistory
+	}
+	resp.CreateSeconds = int(create)
+	if !in.ImageCached {
+		resp.ImageDownloadSeconds = int(download)
+	}
+	resp.TotalSeconds = resp.QueueWaitSeconds + resp.ImageDownloadSeconds + resp.CreateSeconds
+	return resp
+}
+
+// queueWait 模拟节点按并发数依次执行任务，返回新任务开始执行前的等待时长（秒）
+func queueWait(queue []queuedTask, concurrency int, now time.Time) int {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// slots 为每个执行槽位空闲的时间点（距现在的秒数）
+	slots := make([]float64, 0, concurrency)
+	var pending []queuedTask
+	for _, t := range queue {
+		if !t.Running {
+			pending = append(pending, t)
+			continue
+		}
+		remaining := float64(t.Duration)
+		if t.StartedAt != nil {
+			remaining -= now.Sub(*t.StartedAt).Seconds()
+		}
+		slots = append(slots, max(remaining, 0))
+	}
+	// 执行中的任务多于并发数时（如刚调小了并发数），多出的任务排在最早空闲的槽位之后
+	sort.Float64s(slots)
+	for len(slots) > concurrency {
+		slots[1] += slots[0]
+		slots = slots[1:]
+		sort.Float64s(slots)
+	}
+	for len(slots) < concurrency {
+		slots = append(slots, 0)
+	}
+	for _, t := range pending {
+		sort.Float64s(slots)
+		slots[0] += float64(t.Duration)
+	}
+	sort.Float64s(slots)
+	return int(slots[0])
+}
+
+// downloadFallback 无法按历史数据估算时的镜像下载耗时（秒）
+func downloadFallback(size int64) int {
+	if size > 0 {
+		return int(size/defaultDownloadBytesPerSec) + 1
+	}
+	return defaultDownloadSeconds
+}
+
+// median 返回样本的中位数，样本为空时返回0
+func median(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
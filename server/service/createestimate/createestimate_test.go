@@ -0,0 +1,94 @@
+package createestimate
+
+import (
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+)
+
+func TestQueueWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	startedAgo := func(seconds int) *time.Time {
+		ts := now.Add(-time.Duration(seconds) * time.Second)
+		return &ts
+	}
+
+	cases := []struct {
+		name        string
+		queue       []queuedTask
+		concurrency int
+		want        int
+	}{
+		{"空队列", nil, 1, 0},
+		{"执行中剩余时间", []queuedTask{{Running: true, StartedAt: startedAgo(60), Duration: 200}}, 1, 140},
+		{"超时任务不计负数", []queuedTask{{Running: true, StartedAt: startedAgo(500), Duration: 200}}, 1, 0},
+		{"串行排队", []queuedTask{
+			{Running: true, StartedAt: startedAgo(0), Duration: 100},
+			{Duration: 50},
+			{Duration: 30},
+		}, 1, 180},
+		{"并发执行", []queuedTask{
+			{Running: true, StartedAt: startedAgo(0), Duration: 100},
+			{Duration: 50},
+			{Duration: 30},
+		}, 2, 80},
+		{"空闲槽位", []queuedTask{{Running: true, StartedAt: startedAgo(0), Duration: 100}}, 2, 0},
+		{"执行中多于并发数", []queuedTask{
+			{Running: true, StartedAt: startedAgo(0), Duration: 100},
+			{Running: true, StartedAt: startedAgo(0), Duration: 40},
+		}, 1, 140},
+	}
+	for _, c := range cases {
+		if got := queueWait(c.queue, c.concurrency, now); got != c.want {
+			t.Errorf("%s: queueWait = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Now()
+	base := inputs{
+		Provider: providerModel.Provider{Name: "node", AllowConcurrentTasks: true, MaxConcurrentTasks: 2},
+		Image:    systemModel.SystemImage{Name: "debian", InstanceType: "container"},
+		Queue:    []queuedTask{{Duration: 100}, {Duration: 60}, {Duration: 30}},
+	}
+
+	// 无历史数据，镜像未缓存
+	in := base
+	resp := build(&in, now)
+	if resp.Basis != BasisDefault || resp.CreateSeconds != defaultCreateContainer || resp.ImageDownloadSeconds != defaultDownloadSeconds {
+		t.Fatalf("默认估算错误: %+v", resp)
+	}
+	if resp.Concurrency != 2 || resp.PendingTasks != 3 || resp.QueueWaitSeconds != 90 {
+		t.Fatalf("排队估算错误: %+v", resp)
+	}
+	if resp.TotalSeconds != 90+defaultDownloadSeconds+defaultCreateContainer {
+		t.Fatalf("总耗时错误: %+v", resp)
+	}
+
+	// 有历史数据，首次使用镜像比已缓存时慢
+	in = base
+	in.History = history{Warm: []float64{40, 60, 50}, Cold: []float64{200}}
+	resp = build(&in, now)
+	if resp.Basis != BasisHistory || resp.CreateSeconds != 50 || resp.ImageDownloadSeconds != 150 || resp.SampleSize != 4 {
+		t.Fatalf("历史估算错误: %+v", resp)
+	}
+
+	// 镜像已缓存时不计下载耗时
+	in.ImageCached = true
+	resp = build(&in, now)
+	if resp.ImageDownloadSeconds != 0 || resp.TotalSeconds != 90+50 {
+		t.Fatalf("镜像已缓存时估算错误: %+v", resp)
+	}
+
+	// 只有首次使用镜像的样本，按镜像大小扣除下载耗时
+	in = base
+	in.Image.Size = 100 * defaultDownloadBytesPerSec
+	in.History = history{Cold: []float64{400}}
+	resp = build(&in, now)
+	if resp.CreateSeconds != 299 || resp.ImageDownloadSeconds != 101 {
+		t.Fatalf("仅首次使用样本时估算错误: %+v", resp)
+	}
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// ErrDatabaseUnavailable 数据库持续故障、熔断器打开时快速失败返回的错误
+var ErrDatabaseUnavailable = errors.New("数据库暂时不可用（已熔断），请稍后重试")
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"    // 正常
+	BreakerOpen     = "open"      // 熔断中，所有操作快速失败
+	BreakerHalfOpen = "half-open" // 冷却结束，放行一个探测操作
+)
+
+const (
+	breakerFailureThreshold = 3                // 连续多少次操作（已含重试）因连接错误失败后熔断
+	breakerCooldown         = 30 * time.Second // 熔断后多久放行探测操作
+	statusUpdateQueueSize   = 1000             // 待重试的状态更新最大数量
+)
+
+// BreakerStatus 熔断器状态快照
+type BreakerStatus struct {
+	State           string     `json:"state"`
+	ConsecutiveFail int        `json:"consecutiveFail"`
+	Trips           int        `json:"trips"` // 累计熔断次数
+	OpenedAt        *time.Time `json:"openedAt,omitempty"`
+	QueuedUpdates   int        `json:"queuedUpdates"`
+}
+
+// circuitBreaker 数据库熔断器，只统计连接类错误，业务错误（记录不存在、约束冲突等）不影响熔断
+type circuitBreaker struct {
+	mu              sync.Mutex
+	state           string
+	consecutiveFail int
+	trips           int
+	openedAt        time.Time
+	probing         bool
+
+	// 熔断期间的轻量状态更新，按key合并，只保留最新一次
+	pendingKeys    []string
+	pendingUpdates map[string]func() error
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{
+		state:          BreakerClosed,
+		pendingUpdates: make(map[string]func() error),
+	}
+}
+
+// allow 判断是否放行本次操作
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < breakerCooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		global.APP_LOG.Info("数据库熔断冷却结束，放行探测操作")
+		return true
+	case BreakerHalfOpen:
+		// 探测进行中，其余操作继续快速失败
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record 记录操作结果
+func (b *circuitBreaker) record(err error) {
+	if utils.IsConnectionError(err) {
+		b.recordFailure(err)
+		return
+	}
+	b.recordSuccess()
+}
+
+func (b *circuitBreaker) recordFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFail++
+	b.probing = false
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		global.APP_LOG.Warn("数据库探测操作失败，继续熔断", zap.Error(err))
+		return
+	}
+	if b.state == BreakerClosed && b.consecutiveFail >= breakerFailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.trips++
+		// 告警：熔断期间新任务快速失败，状态更新进入重试队列
+		global.APP_LOG.Error("【告警】数据库持续故障，熔断器已打开",
+			zap.Int("consecutiveFailures", b.consecutiveFail),
+			zap.Duration("cooldown", breakerCooldown),
+			zap.Int("trips", b.trips),
+			zap.Error(err))
+	}
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	recovered := b.state != BreakerClosed
+	b.state = BreakerClosed
+	b.consecutiveFail = 0
+	b.probing = false
+	b.mu.Unlock()
+
+	if recovered {
+		global.APP_LOG.Warn("【告警恢复】数据库已恢复，熔断器关闭")
+		go b.flushPendingUpdates()
+	}
+}
+
+// isOpen 熔断器是否处于打开或半开状态
+func (b *circuitBreaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state != BreakerClosed
+}
+
+// enqueue 加入待重试的状态更新，相同key只保留最新一次
+func (b *circuitBreaker) enqueue(key string, fn func() error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.pendingUpdates[key]; !exists {
+		if len(b.pendingKeys) >= statusUpdateQueueSize {
+			dropped := b.pendingKeys[0]
+			b.pendingKeys = b.pendingKeys[1:]
+			delete(b.pendingUpdates, dropped)
+			global.APP_LOG.Warn("状态更新重试队列已满，丢弃最早的更新", zap.String("key", dropped))
+		}
+		b.pendingKeys = append(b.pendingKeys, key)
+	}
+	b.pendingUpdates[key] = fn
+}
+
+// flushPendingUpdates 数据库恢复后按入队顺序重放状态更新，再次遇到连接错误时停止并保留剩余更新
+func (b *circuitBreaker) flushPendingUpdates() {
+	for {
+		b.mu.Lock()
+		if len(b.pendingKeys) == 0 || b.state != BreakerClosed {
+			b.mu.Unlock()
+			return
+		}
+		key := b.pendingKeys[0]
+		fn := b.pendingUpdates[key]
+		b.pendingKeys = b.pendingKeys[1:]
+		delete(b.pendingUpdates, key)
+		b.mu.Unlock()
+
+		err := fn()
+		if utils.IsConnectionError(err) {
+			b.enqueue(key, fn)
+			b.recordFailure(err)
+			return
+		}
+		if err != nil {
+			global.APP_LOG.Warn("重放状态更新失败", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// status 返回熔断器状态快照
+func (b *circuitBreaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStatus{
+		State:           b.state,
+		ConsecutiveFail: b.consecutiveFail,
+		Trips:           b.trips,
+		QueuedUpdates:   len(b.pendingKeys),
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	ds := &DatabaseService{breaker: newCircuitBreaker()}
+	connErr := errors.New("dial tcp 127.0.0.1:3306: connect: connection refused")
+
+	// 业务错误不计入熔断
+	for i := 0; i < breakerFailureThreshold+1; i++ {
+		ds.breaker.record(errors.New("record not found"))
+	}
+	if !ds.Available() {
+		t.Fatal("non-connection errors should not trip the breaker")
+	}
+
+	for i := 0; i < breakerFailureThreshold; i++ {
+		ds.breaker.record(connErr)
+	}
+	if ds.Available() {
+		t.Fatal("breaker should be open after consecutive connection errors")
+	}
+	if err := ds.ExecuteQuery(t.Context(), func() error { return nil }); !errors.Is(err, ErrDatabaseUnavailable) {
+		t.Fatalf("expected fast fail, got %v", err)
+	}
+
+	// 熔断期间的状态更新按key合并，只保留最新一次
+	applied := make(chan int, 3)
+	for _, progress := range []int{10, 20, 30} {
+		progress := progress
+		_ = ds.SubmitStatusUpdate("task:1:progress", func() error {
+			applied <- progress
+			return nil
+		})
+	}
+	if got := ds.BreakerStatus().QueuedUpdates; got != 1 {
+		t.Fatalf("queued updates = %d", got)
+	}
+
+	// 冷却结束后探测成功，熔断器关闭并重放队列
+	ds.breaker.mu.Lock()
+	ds.breaker.openedAt = time.Now().Add(-breakerCooldown)
+	ds.breaker.mu.Unlock()
+	if err := ds.ExecuteQuery(t.Context(), func() error { return nil }); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if !ds.Available() {
+		t.Fatal("breaker should close after successful probe")
+	}
+	select {
+	case progress := <-applied:
+		if progress != 30 {
+			t.Fatalf("replayed progress = %d", progress)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued update was not replayed")
+	}
+	select {
+	case progress := <-applied:
+		t.Fatalf("unexpected extra replay %d", progress)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -2,18 +2,22 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"oneclickvirt/global"
 	"oneclickvirt/utils"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // DatabaseService 数据库服务抽象层
 type DatabaseService struct {
-	mutex sync.RWMutex
+	mutex   sync.RWMutex
+	breaker *circuitBreaker
 }
 
 var (
@@ -24,7 +28,7 @@ var (
 // GetDatabaseService 获取数据库服务单例
 func GetDatabaseService() *DatabaseService {
 	dbServiceOnce.Do(func() {
-		dbService = &DatabaseService{}
+		dbService = &DatabaseService{breaker: newCircuitBreaker()}
 	})
 	return dbService
 }
@@ -43,22 +47,59 @@ func (ds *DatabaseService) ExecuteWithTimeout(db *gorm.DB, timeout time.Duration
 }
 
 // ExecuteTransaction 执行事务（带指数退避重试，避免嵌套事务）
+// 数据库持续故障熔断期间直接返回 ErrDatabaseUnavailable
 func (ds *DatabaseService) ExecuteTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
 	db := ds.getDB()
 	if db == nil {
 		global.APP_LOG.Error("数据库连接不可用")
 		return gorm.ErrInvalidDB
 	}
+	if !ds.breaker.allow() {
+		return ErrDatabaseUnavailable
+	}
 
 	// 使用指数退避重试机制
-	return utils.RetryableDBOperation(ctx, func() error {
+	err := utils.RetryableDBOperation(ctx, func() error {
 		return ds.ExecuteInTransaction(db, fn)
 	}, 8) // 最多重试8次，配合指数退避可以处理更复杂的并发场景
+	ds.breaker.record(err)
+	return err
 }
 
 // ExecuteQuery 执行查询操作（带指数退避重试）
+// 数据库持续故障熔断期间直接返回 ErrDatabaseUnavailable
 func (ds *DatabaseService) ExecuteQuery(ctx context.Context, fn func() error) error {
-	return utils.RetryableDBOperation(ctx, fn, 6) // 查询操作重试6次
+	if !ds.breaker.allow() {
+		return ErrDatabaseUnavailable
+	}
+	err := utils.RetryableDBOperation(ctx, fn, 6) // 查询操作重试6次
+	ds.breaker.record(err)
+	return err
+}
+
+// Available 数据库是否可用（熔断器未打开）
+func (ds *DatabaseService) Available() bool {
+	return !ds.breaker.isOpen()
+}
+
+// BreakerStatus 获取熔断器状态
+func (ds *DatabaseService) BreakerStatus() BreakerStatus {
+	return ds.breaker.status()
+}
+
+// SubmitStatusUpdate 提交轻量状态更新（如任务进度）
+// 熔断期间或因连接错误失败时进入重试队列，数据库恢复后按顺序重放；相同key只保留最新一次
+func (ds *DatabaseService) SubmitStatusUpdate(key string, fn func() error) error {
+	if ds.breaker.isOpen() {
+		ds.breaker.enqueue(key, fn)
+		return ErrDatabaseUnavailable
+	}
+	err := fn()
+	if utils.IsConnectionError(err) {
+		ds.breaker.enqueue(key, fn)
+		ds.breaker.recordFailure(err)
+	}
+	return err
 }
 
 // getDB 获取数据库连接（内部使用）
@@ -66,3 +107,27 @@ func (ds *DatabaseService) getDB() *gorm.DB {
 	// 导入全局包以获取数据库连接
 	return global.APP_DB
 }
+
+// SubmitTaskProgress 更新任务进度，数据库故障时进入重试队列而不是丢弃
+func (ds *DatabaseService) SubmitTaskProgress(taskID uint, progress int, message string) {
+	err := ds.SubmitStatusUpdate(fmt.Sprintf("task:%d:progress", taskID), func() error {
+		return utils.SaveTaskProgress(taskID, progress, message)
+	})
+	switch {
+	case err == nil:
+		global.APP_LOG.Debug("任务进度更新成功",
+			zap.Uint("taskId", taskID),
+			zap.Int("progress", progress),
+			zap.String("message", message))
+	case errors.Is(err, ErrDatabaseUnavailable) || utils.IsConnectionError(err):
+		global.APP_LOG.Warn("数据库不可用，任务进度已加入重试队列",
+			zap.Uint("taskId", taskID),
+			zap.Int("progress", progress))
+	default:
+		global.APP_LOG.Error("更新任务进度失败",
+			zap.Uint("taskId", taskID),
+			zap.Int("progress", progress),
+			zap.String("message", message),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,269 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 各数据库类型的默认连接参数（mysql.config 为空时使用）
+const (
+	DefaultMysqlConfig    = "charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%27%2B00%3A00%27"
+	DefaultPostgresConfig = "sslmode=disable&TimeZone=UTC"
+)
+
+// SQLite 默认配置
+const (
+	DefaultSQLitePath        = "./storage/oneclickvirt.db"
+	DefaultSQLiteBusyTimeout = 5000
+	DefaultSQLiteJournalMode = "WAL"
+)
+
+// ConnOptions 数据库连接参数，PostgreSQL 与 MySQL 共用 mysql 配置段
+// SQLite 时 Dbname 为数据库文件路径，Host/Port/Username/Password 不使用
+type ConnOptions struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Dbname   string // 为空时连接到服务器（PostgreSQL 连接 postgres 维护库，SQLite 使用内存库）
+	Config   string // DSN 附加参数，格式为 URL query
+}
+
+// IsPostgresType 判断 System.DbType 是否为 PostgreSQL
+func IsPostgresType(dbType string) bool {
+	return dbType == utils.DBTypePostgres
+}
+
+// IsSQLiteType 判断 System.DbType 是否为 SQLite
+func IsSQLiteType(dbType string) bool {
+	return dbType == utils.DBTypeSQLite
+}
+
+// SupportedTypes 支持的数据库类型
+func SupportedTypes() []string {
+	return []string{"mysql", "mariadb", utils.DBTypePostgres, utils.DBTypeSQLite}
+}
+
+// IsSupportedType 判断数据库类型是否受支持
+func IsSupportedType(dbType string) bool {
+	for _, t := range SupportedTypes() {
+		if t == dbType {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPort 数据库类型的默认端口，SQLite 没有端口
+func DefaultPort(dbType string) string {
+	if IsPostgresType(dbType) {
+		return "5432"
+	}
+	if IsSQLiteType(dbType) {
+		return ""
+	}
+	return "3306"
+}
+
+// DefaultConfig 数据库类型的默认连接参数
+func DefaultConfig(dbType string) string {
+	if IsPostgresType(dbType) {
+		return DefaultPostgresConfig
+	}
+	if IsSQLiteType(dbType) {
+		return SQLiteConfig(DefaultSQLiteBusyTimeout, DefaultSQLiteJournalMode)
+	}
+	return DefaultMysqlConfig
+}
+
+// SQLiteConfig 生成SQLite驱动参数
+// busy_timeout 让并发写入等待写锁而不是立即返回 database is locked；
+// WAL 模式下读操作不会被写事务阻塞；时间按 utils.SQLiteTimeLayout 存储
+func SQLiteConfig(busyTimeout int, journalMode string) string {
+	if busyTimeout <= 0 {
+		busyTimeout = DefaultSQLiteBusyTimeout
+	}
+	if journalMode == "" {
+		journalMode = DefaultSQLiteJournalMode
+	}
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout))
+	q.Add("_pragma", fmt.Sprintf("journal_mode(%s)", journalMode))
+	q.Set("_time_format", "sqlite")
+	return q.Encode()
+}
+
+// NewDialector 按数据库类型构建 gorm Dialector
+func NewDialector(dbType string, o ConnOptions) gorm.Dialector {
+	if o.Port == "" {
+		o.Port = DefaultPort(dbType)
+	}
+	if o.Config == "" {
+		o.Config = DefaultConfig(dbType)
+	}
+
+	if IsSQLiteType(dbType) {
+		path := o.Dbname
+		if path == "" {
+			path = ":memory:"
+		}
+		return &sqlite.Dialector{DriverName: sqliteDriverName, DSN: path + "?" + o.Config}
+	}
+
+	o.Config = utcConfig(dbType, o.Config)
+
+	if IsPostgresType(dbType) {
+		dbname := o.Dbname
+		if dbname == "" {
+			dbname = "postgres"
+		}
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(o.Username, o.Password),
+			Host:     o.Host + ":" + o.Port,
+			Path:     "/" + dbname,
+			RawQuery: o.Config,
+		}
+		return postgres.New(postgres.Config{DSN: dsn.String()})
+	}
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
+		o.Username, o.Password, o.Host, o.Port, o.Dbname, o.Config)
+	return mysql.New(mysql.Config{
+		DSN:                       dsn,
+		DefaultStringSize:         191,   // string 类型字段的默认长度
+		SkipInitializeWithVersion: false, // 根据版本自动配置
+	})
+}
+
+// utcConfig 覆盖连接参数中的时区设置，时间统一按UTC读写，与配置文件中填写的值无关
+// MySQL 的 loc 决定驱动如何转换 DATETIME，time_zone 决定 NOW() 的结果；PostgreSQL 的 TimeZone 决定 date_trunc 等按哪个时区取整
+// 配置中填写了其他时区时记录警告
+func utcConfig(dbType, config string) string {
+	q, err := url.ParseQuery(config)
+	if err != nil {
+		return config
+	}
+	utc := map[string]string{"loc": "UTC", "time_zone": "'+00:00'"}
+	if IsPostgresType(dbType) {
+		utc = map[string]string{"TimeZone": "UTC"}
+	}
+	for key, value := range utc {
+		if old := q.Get(key); old != "" && old != value {
+			warnTimezoneOverride(key, old, value)
+		}
+		q.Set(key, value)
+	}
+	return q.Encode()
+}
+
+// warnedTimezoneOverrides 已警告过的参数，重复建立连接时不再记录
+var warnedTimezoneOverrides sync.Map
+
+func warnTimezoneOverride(key, old, value string) {
+	if global.APP_LOG == nil {
+		return
+	}
+	if _, warned := warnedTimezoneOverrides.LoadOrStore(key+"="+old, true); warned {
+		return
+	}
+	global.APP_LOG.Warn("数据库连接参数中的时区设置已被忽略，时间统一按UTC读写",
+		zap.String("param", key),
+		zap.String("configured", old),
+		zap.String("effective", value))
+}
+
+// LegacyLocation 旧版本写入MySQL时间时使用的时区，由配置中的 loc 参数决定
+// 未配置参数时旧版本默认使用 loc=Local；填写了参数但没有 loc 时驱动默认为UTC
+func LegacyLocation(config string) *time.Location {
+	if config == "" {
+		return utils.ServerLocation()
+	}
+	q, err := url.ParseQuery(config)
+	if err != nil {
+		return utils.ServerLocation()
+	}
+	switch loc := q.Get("loc"); loc {
+	case "", "UTC":
+		return time.UTC
+	case "Local":
+		return utils.ServerLocation()
+	default:
+		if l, err := time.LoadLocation(loc); err == nil {
+			return l
+		}
+		return utils.ServerLocation()
+	}
+}
+
+// EnsureDatabase 检查数据库是否存在，不存在时创建，db 需为不指定数据库的服务器连接
+// SQLite 的 name 为数据库文件路径，只需创建所在目录，文件在首次连接时自动创建
+func EnsureDatabase(db *gorm.DB, name string) (bool, error) {
+	if utils.IsSQLite(db) {
+		return EnsureSQLiteFile(name)
+	}
+
+	var count int64
+	var checkSQL, createSQL string
+	if utils.IsPostgres(db) {
+		checkSQL = "SELECT COUNT(*) FROM pg_database WHERE datname = ?"
+		createSQL = fmt.Sprintf(`CREATE DATABASE "%s" ENCODING 'UTF8'`, name)
+	} else {
+		checkSQL = "SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?"
+		createSQL = fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", name)
+	}
+
+	if err := db.Raw(checkSQL, name).Scan(&count).Error; err != nil {
+		return false, fmt.Errorf("检查数据库是否存在失败: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := db.Exec(createSQL).Error; err != nil {
+		return false, fmt.Errorf("创建数据库失败: %w", err)
+	}
+	return true, nil
+}
+
+// PrepareSchema 在表结构迁移前执行方言相关的准备工作
+// 模型中的 longtext 为 MySQL 类型，PostgreSQL 中创建同名域映射到 text
+func PrepareSchema(db *gorm.DB) error {
+	if !utils.IsPostgres(db) {
+		return nil
+	}
+	return db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'longtext') THEN
+				CREATE DOMAIN longtext AS text;
+			END IF;
+		END
+		$$`).Error
+}
+
+// EnsureSQLiteFile 创建SQLite数据库文件所在目录，返回文件此前是否不存在
+func EnsureSQLiteFile(path string) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("检查数据库文件失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("创建数据库目录失败: %w", err)
+	}
+	return true, nil
+}
@@ -3,11 +3,24 @@ package database
 import (
 	"fmt"
 	"oneclickvirt/global"
+	"oneclickvirt/utils"
+	"strings"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-// FixDuplicateTrafficHistory 修复 instance_traffic_histories 表中的重复数据
+// trafficHistoryUniqueKeys 流量历史表及其唯一索引列，添加唯一索引前需要先清理重复数据
+var trafficHistoryUniqueKeys = []struct {
+	table   string
+	columns []string
+}{
+	{"instance_traffic_histories", []string{"instance_id", "year", "month", "day", "hour"}},
+	{"provider_traffic_histories", []string{"provider_id", "year", "month", "day", "hour"}},
+	{"user_traffic_histories", []string{"user_id", "year", "month", "day", "hour"}},
+}
+
+// FixDuplicateTrafficHistory 修复流量历史表中的重复数据
 // 这个函数用于清理老数据库中可能存在的重复记录
 // 保留 ID 最小的记录，删除其他重复项
 func (ds *DatabaseService) FixDuplicateTrafficHistory() error {
@@ -16,47 +29,80 @@ func (ds *DatabaseService) FixDuplicateTrafficHistory() error {
 		return fmt.Errorf("数据库连接不可用")
 	}
 
-	global.APP_LOG.Info("开始检查并修复 instance_traffic_histories 表中的重复数据...")
+	for _, t := range trafficHistoryUniqueKeys {
+		// 新数据库尚未建表，无需修复
+		if !db.Migrator().HasTable(t.table) {
+			continue
+		}
+		if err := fixDuplicateRows(db, t.table, t.columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixDuplicateRows 按唯一键删除重复行，保留ID最小的记录
+func fixDuplicateRows(db *gorm.DB, table string, columns []string) error {
+	global.APP_LOG.Info("开始检查并修复表中的重复数据...", zap.String("table", table))
+
+	keys := strings.Join(columns, ", ")
 
 	// 检查是否存在重复数据
 	var duplicateCount int64
-	checkSQL := `
+	checkSQL := fmt.Sprintf(`
 		SELECT COUNT(*) as count FROM (
-			SELECT instance_id, year, month, day, hour, COUNT(*) as cnt
-			FROM instance_traffic_histories
-			GROUP BY instance_id, year, month, day, hour
-			HAVING cnt > 1
+			SELECT %s
+			FROM %s
+			GROUP BY %s
+			HAVING COUNT(*) > 1
 		) as duplicates
-	`
+	`, keys, table, keys)
 	err := db.Raw(checkSQL).Scan(&duplicateCount).Error
 	if err != nil {
 		return fmt.Errorf("检查重复数据失败: %w", err)
 	}
 
 	if duplicateCount == 0 {
-		global.APP_LOG.Info("未发现重复数据，无需修复")
+		global.APP_LOG.Info("未发现重复数据，无需修复", zap.String("table", table))
 		return nil
 	}
 
-	global.APP_LOG.Warn("发现重复数据组", zap.Int64("count", duplicateCount))
+	global.APP_LOG.Warn("发现重复数据组", zap.String("table", table), zap.Int64("count", duplicateCount))
+
+	conditions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, fmt.Sprintf("t1.%[1]s = t2.%[1]s", column))
+	}
+	duplicates := fmt.Sprintf(`
+		SELECT %s, MIN(id) as min_id
+		FROM %s
+		GROUP BY %s
+		HAVING COUNT(*) > 1
+	`, keys, table, keys)
 
 	// 删除重复数据，保留ID最小的记录
-	// 使用临时表方法，兼容性更好
-	deleteSQL := `
-		DELETE t1 FROM instance_traffic_histories t1
-		INNER JOIN (
-			SELECT instance_id, year, month, day, hour, MIN(id) as min_id
-			FROM instance_traffic_histories
-			GROUP BY instance_id, year, month, day, hour
-			HAVING COUNT(*) > 1
-		) t2 
-		ON t1.instance_id = t2.instance_id 
-		AND t1.year = t2.year 
-		AND t1.month = t2.month 
-		AND t1.day = t2.day 
-		AND t1.hour = t2.hour
+	// MySQL 使用多表 DELETE JOIN，PostgreSQL 使用 DELETE ... USING，SQLite 使用子查询
+	var deleteSQL string
+	if utils.IsSQLite(db) {
+		deleteSQL = fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id NOT IN (SELECT MIN(id) FROM %[1]s GROUP BY %[2]s)
+	`, table, keys)
+	} else if utils.IsPostgres(db) {
+		deleteSQL = fmt.Sprintf(`
+		DELETE FROM %s t1
+		USING (%s) t2
+		WHERE %s
+		AND t1.id > t2.min_id
+	`, table, duplicates, strings.Join(conditions, " AND "))
+	} else {
+		deleteSQL = fmt.Sprintf(`
+		DELETE t1 FROM %s t1
+		INNER JOIN (%s) t2
+		ON %s
 		WHERE t1.id > t2.min_id
-	`
+	`, table, duplicates, strings.Join(conditions, " AND "))
+	}
 
 	result := db.Exec(deleteSQL)
 	if result.Error != nil {
@@ -64,6 +110,7 @@ func (ds *DatabaseService) FixDuplicateTrafficHistory() error {
 	}
 
 	global.APP_LOG.Info("重复数据清理完成",
+		zap.String("table", table),
 		zap.Int64("deleted_rows", result.RowsAffected))
 
 	return nil
@@ -71,7 +118,7 @@ func (ds *DatabaseService) FixDuplicateTrafficHistory() error {
 
 // FixAllDuplicateData 修复所有可能存在重复数据的表
 func (ds *DatabaseService) FixAllDuplicateData() error {
-	// 目前只有 instance_traffic_histories 表有此问题
+	// 目前只有流量历史表有此问题
 	// 如果将来有其他表也需要修复，可以在这里添加
 	return ds.FixDuplicateTrafficHistory()
 }
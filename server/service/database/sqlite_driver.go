@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"time"
+
+	"oneclickvirt/utils"
+
+	gosqlite "github.com/glebarez/go-sqlite"
+)
+
+// sqliteDriverName 包装后的SQLite驱动名称
+// 在原驱动基础上处理三处与MySQL行为不一致、会影响业务代码的地方：
+//  1. 写事务以 BEGIN IMMEDIATE 开始，开始时即持有写锁。SQLite会忽略 SELECT ... FOR UPDATE，
+//     任务引擎等依赖行锁的并发写入在这里串行执行，等待时间由 busy_timeout 控制
+//     （驱动的 _txlock 参数与 _time_format 同时设置时不生效，因此在这里处理）
+//  2. 时间参数统一转换为UTC后写入。SQLite按字符串比较时间，带不同时区偏移的值无法正确比较
+//  3. SQLite只对声明为时间类型的列返回 time.Time，MAX(timestamp) 等表达式返回的是文本，
+//     这里将符合 utils.SQLiteTimeLayout 的表达式结果转换为 time.Time，与MySQL一致
+const sqliteDriverName = "sqlite-oneclickvirt"
+
+func init() {
+	sql.Register(sqliteDriverName, sqliteDriver{&gosqlite.Driver{}})
+}
+
+type sqliteDriver struct {
+	base driver.Driver
+}
+
+func (d sqliteDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{Conn: conn}, nil
+}
+
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	mode := "BEGIN IMMEDIATE"
+	if opts.ReadOnly {
+		mode = "BEGIN"
+	}
+	if _, err := c.ExecContext(ctx, mode, nil); err != nil {
+		return nil, err
+	}
+	return &sqliteTx{conn: c}, nil
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStmt{Stmt: stmt}, nil
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, utcArgs(args))
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, utcArgs(args))
+	if err != nil {
+		return nil, err
+	}
+	return wrapSQLiteRows(rows), nil
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+type sqliteTx struct {
+	conn *sqliteConn
+}
+
+func (t *sqliteTx) Commit() error {
+	_, err := t.conn.ExecContext(context.Background(), "COMMIT", nil)
+	return err
+}
+
+func (t *sqliteTx) Rollback() error {
+	_, err := t.conn.ExecContext(context.Background(), "ROLLBACK", nil)
+	return err
+}
+
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, utcArgs(args))
+}
+
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, utcArgs(args))
+	if err != nil {
+		return nil, err
+	}
+	return wrapSQLiteRows(rows), nil
+}
+
+// utcArgs 将时间参数转换为UTC，其余参数原样返回
+func utcArgs(args []driver.NamedValue) []driver.NamedValue {
+	for i, arg := range args {
+		if t, ok := arg.Value.(time.Time); ok && t.Location() != time.UTC {
+			args[i].Value = t.UTC()
+		}
+	}
+	return args
+}
+
+// sqliteRowsColumnTypes 原驱动结果集提供的列类型信息
+type sqliteRowsColumnTypes interface {
+	driver.RowsColumnTypeDatabaseTypeName
+	driver.RowsColumnTypeLength
+	driver.RowsColumnTypeNullable
+	driver.RowsColumnTypePrecisionScale
+	driver.RowsColumnTypeScanType
+}
+
+type sqliteRows struct {
+	driver.Rows
+	types sqliteRowsColumnTypes
+}
+
+func wrapSQLiteRows(rows driver.Rows) driver.Rows {
+	types, ok := rows.(sqliteRowsColumnTypes)
+	if !ok {
+		return rows
+	}
+	return &sqliteRows{Rows: rows, types: types}
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		return err
+	}
+	for i, v := range dest {
+		text, ok := v.(string)
+		// 只处理没有声明类型的表达式列，普通文本列原样返回
+		if !ok || len(text) < len("2006-01-02 15:04:05-07:00") || r.types.ColumnTypeDatabaseTypeName(i) != "" {
+			continue
+		}
+		if t, err := time.Parse(utils.SQLiteTimeLayout, text); err == nil {
+			dest[i] = t
+		}
+	}
+	return nil
+}
+
+func (r *sqliteRows) ColumnTypeDatabaseTypeName(index int) string {
+	return r.types.ColumnTypeDatabaseTypeName(index)
+}
+
+func (r *sqliteRows) ColumnTypeLength(index int) (int64, bool) {
+	return r.types.ColumnTypeLength(index)
+}
+
+func (r *sqliteRows) ColumnTypeNullable(index int) (bool, bool) {
+	return r.types.ColumnTypeNullable(index)
+}
+
+func (r *sqliteRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	return r.types.ColumnTypePrecisionScale(index)
+}
+
+func (r *sqliteRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.types.ColumnTypeScanType(index)
+}
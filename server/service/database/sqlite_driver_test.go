@@ -0,0 +1,86 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSQLiteDriver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "test.db")
+	if created, err := EnsureSQLiteFile(path); err != nil || !created {
+		t.Fatalf("EnsureSQLiteFile = %v, %v", created, err)
+	}
+	db, err := gorm.Open(NewDialector(utils.DBTypeSQLite, ConnOptions{Dbname: path}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsSQLite(db) {
+		t.Fatalf("dialector name = %s", db.Dialector.Name())
+	}
+
+	type record struct {
+		ID        uint
+		Timestamp time.Time
+	}
+	if err := db.AutoMigrate(&record{}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	if err := db.Create(&record{Timestamp: now}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 表达式结果按时间格式返回 time.Time，与MySQL一致
+	var result struct {
+		Latest time.Time
+		Day    time.Time
+	}
+	if err := db.Model(&record{}).Select("MAX(timestamp) AS latest, " + utils.SQLDate(db, "timestamp") + " AS day").Scan(&result).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !result.Latest.Equal(now) {
+		t.Fatalf("MAX(timestamp) = %v, want %v", result.Latest, now)
+	}
+	// 时间按UTC写入，日期也按UTC取整
+	if y, m, d := now.UTC().Date(); !result.Day.Equal(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date = %v, want start of %v", result.Day, now)
+	}
+
+	// 数据库当前时间与Go当前时间一致
+	var dbNow time.Time
+	if err := db.Raw("SELECT " + utils.SQLNow(db)).Scan(&dbNow).Error; err != nil {
+		t.Fatal(err)
+	}
+	if diff := time.Since(dbNow); diff < -time.Second || diff > time.Minute {
+		t.Fatalf("SQLNow = %v, local now %v", dbNow, time.Now())
+	}
+
+	// 写事务开始时即持有写锁，其他写事务在 busy_timeout 内等待
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatal(tx.Error)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- db.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(&record{Timestamp: now}).Error
+		})
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("second writer did not wait for the lock: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("second writer failed after lock release: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// timestampsUTCMigration 旧数据时间转换为UTC的迁移名称
+const timestampsUTCMigration = "timestamps-utc"
+
+// legacyTimeFloor 早于该时间的值是零值或占位值，转换时跳过
+const legacyTimeFloor = "1971-01-01"
+
+// utcBatchSize 每批转换的主键范围，每批在独立事务中执行
+var utcBatchSize uint64 = 5000
+
+// timeColumn 需要转换的时间列
+type timeColumn struct {
+	table  string
+	column string
+	keyed  bool // 表有整数主键 id，可按主键范围分批
+}
+
+// progressName 时间列转换进度的记录名称
+func (c timeColumn) progressName() string {
+	return timestampsUTCMigration + ":" + c.table + "." + c.column
+}
+
+// MigrateTimestampsToUTC 将旧版本按本地时区写入的时间转换为UTC，只执行一次
+// MySQL 的 DATETIME 不带时区，旧版本按连接参数 loc（默认为服务器本地时区）写入，需要按写入时的时区偏移换算；
+// SQLite 的时间带有写入时的时区偏移，统一改写为UTC后才能按字符串比较；
+// PostgreSQL 的 timestamptz 存储的是绝对时间，无需转换
+// 按列逐个转换，有整数主键的表按主键范围分批，每批在独立事务中执行并记录进度，中断后下次启动从记录的位置继续
+func MigrateTimestampsToUTC(db *gorm.DB, models []interface{}) error {
+	if utils.IsPostgres(db) {
+		return recordDataMigration(db, timestampsUTCMigration)
+	}
+	var applied int64
+	if err := db.Model(&systemModel.DataMigration{}).Where("name = ?", timestampsUTCMigration).Count(&applied).Error; err != nil {
+		return fmt.Errorf("查询数据迁移记录失败: %w", err)
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	sqlite := utils.IsSQLite(db)
+	var legacy *time.Location
+	if !sqlite {
+		legacy = LegacyLocation(global.APP_CONFIG.Mysql.Config)
+		if mysqlUTCExpr("c", legacy, time.Now()) == "" {
+			// 旧数据本来就是UTC
+			return recordDataMigration(db, timestampsUTCMigration)
+		}
+		global.APP_LOG.Info("开始将已有时间数据转换为UTC", zap.String("legacyTimezone", legacy.String()))
+	}
+
+	columns, err := timeColumns(db, models)
+	if err != nil {
+		return err
+	}
+	// 先为全部列记录当前最大主键，中断期间按UTC新写入的行不会在继续时被重复换算
+	for _, col := range columns {
+		if err := planTimeColumn(db, col); err != nil {
+			return err
+		}
+	}
+
+	var converted int64
+	for _, col := range columns {
+		var set, where string
+		if sqlite {
+			set, where = sqliteUTCExpr(col.column)
+		} else {
+			set = mysqlUTCExpr(col.column, legacy, time.Now())
+			where = fmt.Sprintf("`%s` >= '%s'", col.column, legacyTimeFloor)
+		}
+		rows, err := convertTimeColumn(db, col, set, where)
+		if err != nil {
+			return err
+		}
+		converted += rows
+	}
+	if err := recordDataMigration(db, timestampsUTCMigration); err != nil {
+		return fmt.Errorf("写入数据迁移记录失败: %w", err)
+	}
+	global.APP_LOG.Info("已有时间数据已转换为UTC",
+		zap.Int("columns", len(columns)),
+		zap.Int64("rows", converted))
+	return nil
+}
+
+// planTimeColumn 创建时间列的转换进度，已存在时保留原有进度
+func planTimeColumn(db *gorm.DB, col timeColumn) error {
+	progress := systemModel.DataMigrationProgress{Name: col.progressName()}
+	if col.keyed {
+		var maxID uint64
+		if err := db.Table(col.table).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+			return fmt.Errorf("查询 %s 最大主键失败: %w", col.table, err)
+		}
+		progress.MaxID = maxID
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&progress).Error; err != nil {
+		return fmt.Errorf("写入 %s.%s 转换进度失败: %w", col.table, col.column, err)
+	}
+	return nil
+}
+
+// convertTimeColumn 分批转换一列，返回本次转换的行数
+// 每批锁定进度记录后执行，多节点同时启动时按进度依次处理，不会重复换算
+func convertTimeColumn(db *gorm.DB, col timeColumn, set, where string) (int64, error) {
+	update := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s",
+		utils.QuoteIdent(db, col.table), utils.QuoteIdent(db, col.column), set, where)
+	var converted int64
+	for done := false; !done; {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var progress systemModel.DataMigrationProgress
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("name = ?", col.progressName()).First(&progress).Error; err != nil {
+				return fmt.Errorf("读取 %s.%s 转换进度失败: %w", col.table, col.column, err)
+			}
+			if progress.Done {
+				done = true
+				return nil
+			}
+
+			var result *gorm.DB
+			upper := progress.MaxID
+			if col.keyed {
+				if progress.MaxID-progress.Cursor > utcBatchSize {
+					upper = progress.Cursor + utcBatchSize
+				}
+				result = tx.Exec(update+" AND id > ? AND id <= ?", progress.Cursor, upper)
+			} else {
+				// 没有整数主键的表整列一次转换
+				result = tx.Exec(update)
+			}
+			if result.Error != nil {
+				return fmt.Errorf("转换 %s.%s 失败: %w", col.table, col.column, result.Error)
+			}
+			converted += result.RowsAffected
+			done = upper >= progress.MaxID
+			return tx.Model(&progress).Updates(map[string]interface{}{"cursor": upper, "done": done}).Error
+		})
+		if err != nil {
+			return converted, err
+		}
+	}
+	return converted, nil
+}
+
+// recordDataMigration 写入迁移完成记录
+func recordDataMigration(db *gorm.DB, name string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&systemModel.DataMigration{Name: name, AppliedAt: time.Now()}).Error
+}
+
+// timeColumns 列出模型中的全部时间列
+func timeColumns(db *gorm.DB, models []interface{}) ([]timeColumn, error) {
+	var columns []timeColumn
+	seen := make(map[timeColumn]bool)
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("解析模型失败: %w", err)
+		}
+		// 迁移记录和进度由新版本写入，已经是UTC
+		switch stmt.Schema.Table {
+		case (systemModel.DataMigration{}).TableName(), (systemModel.DataMigrationProgress{}).TableName():
+			continue
+		}
+		id := stmt.Schema.PrioritizedPrimaryField
+		keyed := id != nil && id.DBName == "id" && (id.DataType == schema.Uint || id.DataType == schema.Int)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.DataType != schema.Time {
+				continue
+			}
+			col := timeColumn{table: stmt.Schema.Table, column: field.DBName, keyed: keyed}
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+		}
+	}
+	return columns, nil
+}
+
+// sqliteUTCExpr 返回SQLite中将带时区偏移的时间改写为UTC的表达式和条件
+// 值的格式为 utils.SQLiteTimeLayout，datetime() 按偏移换算为UTC，原有的小数秒保留
+func sqliteUTCExpr(column string) (string, string) {
+	col := `"` + column + `"`
+	set := fmt.Sprintf("datetime(%[1]s) || substr(%[1]s, 20, length(%[1]s) - 25) || '+00:00'", col)
+	where := fmt.Sprintf("%[1]s >= '%[2]s' AND length(%[1]s) >= 25 AND substr(%[1]s, -6) <> '+00:00' AND substr(%[1]s, -6, 1) IN ('+', '-')",
+		col, legacyTimeFloor)
+	return set, where
+}
+
+// mysqlUTCExpr 返回MySQL中将 loc 时区的本地时间换算为UTC的表达式，loc 与UTC始终一致时返回空字符串
+// 有夏令时的时区按 2000 年至 until 之后一年内的每段偏移分别换算，不依赖MySQL的时区表；
+// 夏令时结束时重复的一小时按夏令时换算
+func mysqlUTCExpr(column string, loc *time.Location, until time.Time) string {
+	col := "`" + column + "`"
+	t := time.Date(2000, 1, 1, 0, 0, 0, 0, loc)
+	end := until.AddDate(1, 0, 0)
+
+	var b strings.Builder
+	branches := 0
+	for {
+		_, offset := t.Zone()
+		_, zoneEnd := t.ZoneBounds()
+		if zoneEnd.IsZero() || !zoneEnd.Before(end) {
+			if branches == 0 {
+				if offset == 0 {
+					return ""
+				}
+				return fmt.Sprintf("%s - INTERVAL %d SECOND", col, offset)
+			}
+			fmt.Fprintf(&b, " ELSE %s - INTERVAL %d SECOND END", col, offset)
+			return "CASE" + b.String()
+		}
+		// 本段在旧数据中的本地时间上界
+		wallEnd := zoneEnd.UTC().Add(time.Duration(offset) * time.Second)
+		fmt.Fprintf(&b, " WHEN %s < '%s' THEN %s - INTERVAL %d SECOND",
+			col, wallEnd.Format("2006-01-02 15:04:05"), col, offset)
+		branches++
+		t = zoneEnd
+	}
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type record struct {
+	ID        uint
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+var recordModels = []interface{}{&record{}, &systemModel.DataMigration{}, &systemModel.DataMigrationProgress{}}
+
+func openRecordsDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	useLogger(t, zap.NewNop())
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(NewDialector(utils.DBTypeSQLite, ConnOptions{Dbname: path}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(recordModels...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// useLogger 替换全局日志，测试结束后恢复
+func useLogger(t *testing.T, log *zap.Logger) {
+	old := global.APP_LOG
+	global.APP_LOG = log
+	t.Cleanup(func() { global.APP_LOG = old })
+}
+
+func setBatchSize(t *testing.T, size uint64) {
+	old := utcBatchSize
+	utcBatchSize = size
+	t.Cleanup(func() { utcBatchSize = old })
+}
+
+func TestMigrateTimestampsToUTCSQLite(t *testing.T) {
+	db := openRecordsDB(t)
+	// 分多批执行
+	setBatchSize(t, 2)
+	models := recordModels
+	columns, err := timeColumns(db, models)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 3 || !columns[0].keyed {
+		t.Fatalf("时间列 = %v", columns)
+	}
+
+	// 旧版本写入的带本地时区偏移的值
+	legacy := []string{
+		"2024-03-01 08:30:00+08:00",
+		"2024-03-01 08:30:00.123456789+08:00",
+		"2024-03-01 08:30:00-05:00",
+		"2024-03-01 00:30:00+00:00",
+	}
+	for _, v := range legacy {
+		if err := db.Exec("INSERT INTO records (created_at, expires_at) VALUES (?, ?)", v, v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Exec("INSERT INTO records (created_at) VALUES (?)", "0001-01-01 00:00:00+08:05").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateTimestampsToUTC(db, models); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2024-03-01 00:30:00+00:00",
+		"2024-03-01 00:30:00.123456789+00:00",
+		"2024-03-01 13:30:00+00:00",
+		"2024-03-01 00:30:00+00:00",
+		"0001-01-01 00:00:00+08:05",
+	}
+	if got := rawColumn(db, "created_at"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("转换结果 = %v, want %v", got, want)
+	}
+	if expires := rawColumn(db, "expires_at"); expires[4] != "" || expires[1] != want[1] {
+		t.Fatalf("expires_at 转换不正确: %v", expires)
+	}
+
+	// 只执行一次
+	db.Exec("INSERT INTO records (created_at) VALUES (?)", "2024-03-01 08:30:00+08:00")
+	if err := MigrateTimestampsToUTC(db, models); err != nil {
+		t.Fatal(err)
+	}
+	if last := lastValue(db); last != "2024-03-01 08:30:00+08:00" {
+		t.Fatalf("迁移重复执行: %s", last)
+	}
+
+	// 新写入的时间统一为UTC
+	db.Create(&record{CreatedAt: time.Date(2024, 3, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600))})
+	if last := lastValue(db); last != "2024-03-01 00:30:00+00:00" {
+		t.Fatalf("新写入的时间 = %s", last)
+	}
+}
+
+func TestMigrateTimestampsToUTCResume(t *testing.T) {
+	db := openRecordsDB(t)
+	setBatchSize(t, 1)
+	for i := 0; i < 4; i++ {
+		db.Exec("INSERT INTO records (created_at) VALUES (?)", "2024-03-01 08:30:00+08:00")
+	}
+	// 上次执行在 created_at 转换完前两行后中断，之后写入的第5行不在处理范围内
+	db.Create(&systemModel.DataMigrationProgress{Name: "timestamps-utc:records.created_at", MaxID: 4, Cursor: 2})
+	db.Exec("INSERT INTO records (created_at) VALUES (?)", "2024-03-01 08:30:00+08:00")
+
+	if err := MigrateTimestampsToUTC(db, recordModels); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2024-03-01 08:30:00+08:00",
+		"2024-03-01 08:30:00+08:00",
+		"2024-03-01 00:30:00+00:00",
+		"2024-03-01 00:30:00+00:00",
+		"2024-03-01 08:30:00+08:00",
+	}
+	if got := rawColumn(db, "created_at"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("继续执行的结果 = %v, want %v", got, want)
+	}
+	var progress []systemModel.DataMigrationProgress
+	db.Order("name").Find(&progress)
+	if len(progress) != 3 {
+		t.Fatalf("进度记录 = %+v", progress)
+	}
+	for _, p := range progress {
+		if !p.Done || p.Cursor != p.MaxID {
+			t.Errorf("进度未完成: %+v", p)
+		}
+	}
+	var applied int64
+	db.Model(&systemModel.DataMigration{}).Where("name = ?", timestampsUTCMigration).Count(&applied)
+	if applied != 1 {
+		t.Error("全部列完成后应写入迁移记录")
+	}
+}
+
+func TestUTCConfigWarnsOnOverride(t *testing.T) {
+	// 已警告记录是进程级的，清空后测试才能重复运行
+	warnedTimezoneOverrides.Clear()
+	t.Cleanup(warnedTimezoneOverrides.Clear)
+	core, logs := observer.New(zap.WarnLevel)
+	useLogger(t, zap.New(core))
+
+	got := utcConfig("mysql", "charset=utf8mb4&loc=Asia%2FShanghai&parseTime=True")
+	if got != "charset=utf8mb4&loc=UTC&parseTime=True&time_zone=%27%2B00%3A00%27" {
+		t.Errorf("utcConfig = %s", got)
+	}
+	if logs.Len() != 1 {
+		t.Fatalf("覆盖 loc 应记录一条警告，得到 %d 条", logs.Len())
+	}
+	utcConfig("mysql", "loc=Asia%2FShanghai")
+	utcConfig("mysql", DefaultMysqlConfig)
+	utcConfig(utils.DBTypePostgres, DefaultPostgresConfig)
+	if logs.Len() != 1 {
+		t.Errorf("重复或与UTC一致的设置不应再记录警告，共 %d 条", logs.Len())
+	}
+}
+
+// rawColumn 按存储的原始文本读取时间列，NULL 返回空字符串
+func rawColumn(db *gorm.DB, column string) []string {
+	var values []string
+	// 加前缀避免驱动把表达式结果转换为 time.Time
+	db.Raw("SELECT COALESCE('v' || " + column + ", 'v') FROM records ORDER BY id").Scan(&values)
+	for i := range values {
+		values[i] = strings.TrimPrefix(values[i], "v")
+	}
+	return values
+}
+
+func lastValue(db *gorm.DB) string {
+	values := rawColumn(db, "created_at")
+	return values[len(values)-1]
+}
+
+func TestMySQLUTCExpr(t *testing.T) {
+	until := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	if expr := mysqlUTCExpr("created_at", time.UTC, until); expr != "" {
+		t.Errorf("UTC 不需要转换，得到 %s", expr)
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+	if expr := mysqlUTCExpr("created_at", shanghai, until); expr != "`created_at` - INTERVAL 28800 SECOND" {
+		t.Errorf("固定偏移时区 = %s", expr)
+	}
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	expr := mysqlUTCExpr("created_at", newYork, until)
+	if !strings.HasPrefix(expr, "CASE WHEN `created_at` < '2000-04-02 02:00:00' THEN `created_at` - INTERVAL -18000 SECOND") ||
+		!strings.HasSuffix(expr, "ELSE `created_at` - INTERVAL -14400 SECOND END") {
+		t.Errorf("夏令时时区 = %s", expr)
+	}
+	// 2026年3月8日夏令时开始，11月1日结束
+	if !strings.Contains(expr, "WHEN `created_at` < '2026-03-08 02:00:00' THEN `created_at` - INTERVAL -18000 SECOND WHEN `created_at` < '2026-11-01 02:00:00' THEN `created_at` - INTERVAL -14400 SECOND") {
+		t.Errorf("缺少2026年的夏令时分段: %s", expr)
+	}
+}
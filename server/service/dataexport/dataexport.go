@@ -0,0 +1,479 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/storage"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExpireHours = 24
+	defaultMinInterval = 60
+	// 流量历史按批次读取，避免一次性加载全部记录
+	trafficBatchSize = 1000
+)
+
+var (
+	ErrExportInProgress  = errors.New("已有正在生成的数据导出，请等待完成")
+	ErrExportTooFrequent = errors.New("导出过于频繁")
+	ErrExportNotFound    = errors.New("导出文件不存在或已过期")
+)
+
+// ExpireDuration 返回导出文件下载链接的有效期
+func ExpireDuration() time.Duration {
+	if hours := global.APP_CONFIG.DataExport.ExpireHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultExpireHours * time.Hour
+}
+
+// minInterval 返回同一用户两次导出的最小间隔
+func minInterval() time.Duration {
+	if minutes := global.APP_CONFIG.DataExport.MinInterval; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultMinInterval * time.Minute
+}
+
+// DownloadPath 返回导出文件的下载路径
+func DownloadPath(export *userModel.DataExport) string {
+	return "/api/v1/public/data-exports/" + export.Token
+}
+
+// newToken 生成下载令牌
+func newToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Create 为用户创建一条待生成的导出记录，检查是否有进行中的导出以及导出频率
+func Create(userID uint) (*userModel.DataExport, error) {
+	var pending []userModel.DataExport
+	if err := global.APP_DB.Where("user_id = ? AND status = ?", userID, userModel.DataExportStatusPending).
+		Find(&pending).Error; err != nil {
+		return nil, fmt.Errorf("查询导出记录失败: %w", err)
+	}
+	for _, p := range pending {
+		// 任务被取消或超时后导出记录不会再被更新，此时视为失败
+		var task adminModel.Task
+		if p.TaskID != 0 && global.APP_DB.Select("status").First(&task, p.TaskID).Error == nil &&
+			task.Status != "pending" && task.Status != "running" {
+			MarkFailed(p.ID, "导出任务已"+task.Status)
+			continue
+		}
+		return nil, ErrExportInProgress
+	}
+
+	var last userModel.DataExport
+	if err := global.APP_DB.Where("user_id = ? AND status <> ?", userID, userModel.DataExportStatusFailed).
+		Order("created_at DESC").First(&last).Error; err == nil {
+		if wait := minInterval() - time.Since(last.CreatedAt); wait > 0 {
+			return nil, fmt.Errorf("%w，请在 %d 分钟后重试", ErrExportTooFrequent, int(wait.Minutes())+1)
+		}
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("生成下载令牌失败: %w", err)
+	}
+	export := &userModel.DataExport{
+		UserID: userID,
+		Status: userModel.DataExportStatusPending,
+		Token:  token,
+	}
+	if err := global.APP_DB.Create(export).Error; err != nil {
+		return nil, fmt.Errorf("创建导出记录失败: %w", err)
+	}
+	return export, nil
+}
+
+// AttachTask 记录生成导出文件的任务ID
+func AttachTask(exportID, taskID uint) error {
+	return global.APP_DB.Model(&userModel.DataExport{}).Where("id = ?", exportID).Update("task_id", taskID).Error
+}
+
+// MarkFailed 标记导出失败
+func MarkFailed(exportID uint, reason string) {
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	if err := global.APP_DB.Model(&userModel.DataExport{}).Where("id = ?", exportID).
+		Updates(map[string]interface{}{
+			"status":        userModel.DataExportStatusFailed,
+			"error_message": reason,
+		}).Error; err != nil {
+		global.APP_LOG.Warn("更新导出记录失败", zap.Uint("exportId", exportID), zap.Error(err))
+	}
+}
+
+// Generate 生成导出压缩包并写入对象存储，progress 用于汇报进度
+func Generate(ctx context.Context, exportID uint, progress func(percent int, message string)) (*userModel.DataExport, error) {
+	var export userModel.DataExport
+	if err := global.APP_DB.First(&export, exportID).Error; err != nil {
+		return nil, fmt.Errorf("导出记录不存在: %w", err)
+	}
+
+	tempDir := storage.GetStorageService().GetTempPath()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	tmp, err := os.CreateTemp(tempDir, "data-export-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeArchive(ctx, tmp, export.UserID, progress); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("关闭临时文件失败: %v", err)
+	}
+	stat, err := os.Stat(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("读取导出文件信息失败: %v", err)
+	}
+
+	progress(90, "正在保存导出文件...")
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	fileName := fmt.Sprintf("oneclickvirt_export_%d_%s.zip", export.UserID, now.Format("20060102150405"))
+	key := storage.ObjectKey(storage.ObjectPrefixExports, "users", strconv.FormatUint(uint64(export.UserID), 10), fileName)
+	if err := storage.PutFile(ctx, store, key, tmp.Name()); err != nil {
+		return nil, fmt.Errorf("写入对象存储失败: %v", err)
+	}
+
+	expiresAt := now.Add(ExpireDuration())
+	if err := global.APP_DB.Model(&export).Updates(map[string]interface{}{
+		"status":     userModel.DataExportStatusCompleted,
+		"file_name":  fileName,
+		"size":       stat.Size(),
+		"object_key": key,
+		"expires_at": expiresAt,
+	}).Error; err != nil {
+		// 记录未更新时文件无法下载，直接删除
+		_ = store.Delete(ctx, key)
+		return nil, fmt.Errorf("更新导出记录失败: %v", err)
+	}
+	export.Status = userModel.DataExportStatusCompleted
+	export.FileName = fileName
+	export.Size = stat.Size()
+	export.ObjectKey = key
+	export.ExpiresAt = &expiresAt
+	return &export, nil
+}
+
+// writeArchive 将用户数据写入zip：实例配置、端口映射、流量历史和任务历史
+func writeArchive(ctx context.Context, w io.Writer, userID uint, progress func(percent int, message string)) error {
+	zw := zip.NewWriter(w)
+
+	// 包含已删除的实例，流量历史和任务历史中引用的实例也能对应到名称
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Unscoped().Where("user_id = ?", userID).Order("id ASC").Find(&instances).Error; err != nil {
+		return fmt.Errorf("查询实例失败: %w", err)
+	}
+	names := make(map[uint]string, len(instances))
+	for _, instance := range instances {
+		names[instance.ID] = instance.Name
+	}
+
+	progress(15, "正在导出实例配置...")
+	if err := writeInstances(zw, instances); err != nil {
+		return err
+	}
+
+	progress(30, "正在导出端口映射...")
+	if err := writePortMappings(zw, userID, names); err != nil {
+		return err
+	}
+
+	progress(45, "正在导出流量历史...")
+	if err := writeTrafficHistory(ctx, zw, userID, names); err != nil {
+		return err
+	}
+
+	progress(75, "正在导出任务历史...")
+	if err := writeTasks(zw, userID); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("写入压缩包失败: %w", err)
+	}
+	return nil
+}
+
+// instanceExport 导出的实例配置，不包含登录密码等凭据
+type instanceExport struct {
+	ID           uint       `json:"id"`
+	UUID         string     `json:"uuid"`
+	Name         string     `json:"name"`
+	Provider     string     `json:"provider"`
+	Region       string     `json:"region"`
+	InstanceType string     `json:"instanceType"`
+	Image        string     `json:"image"`
+	OSType       string     `json:"osType"`
+	CPU          int        `json:"cpu"`
+	MemoryMB     int64      `json:"memoryMB"`
+	DiskMB       int64      `json:"diskMB"`
+	Bandwidth    int        `json:"bandwidthMbps"`
+	MaxTrafficMB int64      `json:"maxTrafficMB"`
+	Status       string     `json:"status"`
+	PrivateIP    string     `json:"privateIP"`
+	PublicIP     string     `json:"publicIP"`
+	IPv6Address  string     `json:"ipv6Address"`
+	PublicIPv6   string     `json:"publicIPv6"`
+	SSHPort      int        `json:"sshPort"`
+	Username     string     `json:"username"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	DeletedAt    *time.Time `json:"deletedAt"`
+}
+
+func writeInstances(zw *zip.Writer, instances []providerModel.Instance) error {
+	list := make([]instanceExport, 0, len(instances))
+	for _, instance := range instances {
+		item := instanceExport{
+			ID:           instance.ID,
+			UUID:         instance.UUID,
+			Name:         instance.Name,
+			Provider:     instance.Provider,
+			Region:       instance.Region,
+			InstanceType: instance.InstanceType,
+			Image:        instance.Image,
+			OSType:       instance.OSType,
+			CPU:          instance.CPU,
+			MemoryMB:     instance.Memory,
+			DiskMB:       instance.Disk,
+			Bandwidth:    instance.Bandwidth,
+			MaxTrafficMB: instance.MaxTraffic,
+			Status:       instance.Status,
+			PrivateIP:    instance.PrivateIP,
+			PublicIP:     instance.PublicIP,
+			IPv6Address:  instance.IPv6Address,
+			PublicIPv6:   instance.PublicIPv6,
+			SSHPort:      instance.SSHPort,
+			Username:     instance.Username,
+			CreatedAt:    instance.CreatedAt,
+			ExpiresAt:    instance.ExpiresAt,
+		}
+		if instance.DeletedAt.Valid {
+			deletedAt := instance.DeletedAt.Time
+			item.DeletedAt = &deletedAt
+		}
+		list = append(list, item)
+	}
+
+	f, err := zw.Create("instances.json")
+	if err != nil {
+		return fmt.Errorf("写入实例配置失败: %w", err)
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+func writePortMappings(zw *zip.Writer, userID uint, names map[uint]string) error {
+	var ports []providerModel.Port
+	if err := global.APP_DB.Where("instance_id IN (?)",
+		global.APP_DB.Model(&providerModel.Instance{}).Select("id").Where("user_id = ?", userID)).
+		Order("instance_id ASC, host_port ASC").Find(&ports).Error; err != nil {
+		return fmt.Errorf("查询端口映射失败: %w", err)
+	}
+
+	f, err := zw.Create("port_mappings.csv")
+	if err != nil {
+		return fmt.Errorf("写入端口映射失败: %w", err)
+	}
+	writer := csv.NewWriter(f)
+	_ = writer.Write([]string{
+		"instance_id", "instance_name", "host_port", "host_port_end", "guest_port", "guest_port_end",
+		"protocol", "status", "is_ssh", "ipv6_address", "description",
+	})
+	for _, port := range ports {
+		_ = writer.Write([]string{
+			strconv.FormatUint(uint64(port.InstanceID), 10),
+			names[port.InstanceID],
+			strconv.Itoa(port.HostPort),
+			strconv.Itoa(port.HostPortEnd),
+			strconv.Itoa(port.GuestPort),
+			strconv.Itoa(port.GuestPortEnd),
+			port.Protocol,
+			port.Status,
+			strconv.FormatBool(port.IsSSH),
+			port.IPv6Address,
+			port.Description,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeTrafficHistory 导出日度和月度流量汇总（day为0表示月度汇总），单位MB
+func writeTrafficHistory(ctx context.Context, zw *zip.Writer, userID uint, names map[uint]string) error {
+	f, err := zw.Create("traffic_history.csv")
+	if err != nil {
+		return fmt.Errorf("写入流量历史失败: %w", err)
+	}
+	writer := csv.NewWriter(f)
+	_ = writer.Write([]string{
+		"instance_id", "instance_name", "year", "month", "day", "traffic_in_mb", "traffic_out_mb", "total_mb",
+	})
+
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var batch []monitoring.InstanceTrafficHistory
+		if err := global.APP_DB.Where("user_id = ? AND hour = 0 AND id > ?", userID, lastID).
+			Order("id ASC").Limit(trafficBatchSize).Find(&batch).Error; err != nil {
+			return fmt.Errorf("查询流量历史失败: %w", err)
+		}
+		for _, h := range batch {
+			_ = writer.Write([]string{
+				strconv.FormatUint(uint64(h.InstanceID), 10),
+				names[h.InstanceID],
+				strconv.Itoa(h.Year),
+				strconv.Itoa(h.Month),
+				strconv.Itoa(h.Day),
+				strconv.FormatInt(h.TrafficIn, 10),
+				strconv.FormatInt(h.TrafficOut, 10),
+				strconv.FormatInt(h.TotalUsed, 10),
+			})
+		}
+		if len(batch) < trafficBatchSize {
+			break
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeTasks(zw *zip.Writer, userID uint) error {
+	var tasks []adminModel.Task
+	if err := global.APP_DB.Select("id, uuid, task_type, status, instance_id, provider_id, error_message, created_at, started_at, completed_at").
+		Where("user_id = ?", userID).Order("id ASC").Find(&tasks).Error; err != nil {
+		return fmt.Errorf("查询任务历史失败: %w", err)
+	}
+
+	f, err := zw.Create("tasks.csv")
+	if err != nil {
+		return fmt.Errorf("写入任务历史失败: %w", err)
+	}
+	writer := csv.NewWriter(f)
+	_ = writer.Write([]string{
+		"id", "uuid", "task_type", "status", "instance_id", "provider_id", "error_message", "created_at", "started_at", "completed_at",
+	})
+	for _, task := range tasks {
+		_ = writer.Write([]string{
+			strconv.FormatUint(uint64(task.ID), 10),
+			task.UUID,
+			task.TaskType,
+			task.Status,
+			formatOptionalID(task.InstanceID),
+			formatOptionalID(task.ProviderID),
+			task.ErrorMessage,
+			task.CreatedAt.Format(time.RFC3339),
+			formatOptionalTime(task.StartedAt),
+			formatOptionalTime(task.CompletedAt),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Open 按下载令牌打开导出文件并累加下载次数，调用方负责关闭
+func Open(ctx context.Context, token string) (*userModel.DataExport, io.ReadCloser, error) {
+	var export userModel.DataExport
+	if token == "" || global.APP_DB.Where("token = ?", token).First(&export).Error != nil {
+		return nil, nil, ErrExportNotFound
+	}
+	if !export.IsDownloadable(time.Now()) {
+		return nil, nil, ErrExportNotFound
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := store.Get(ctx, export.ObjectKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, ErrExportNotFound
+		}
+		return nil, nil, err
+	}
+	global.APP_DB.Model(&export).UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+	return &export, reader, nil
+}
+
+// CleanupExpired 删除已过期的导出文件并标记为过期
+func CleanupExpired() (int, error) {
+	var exports []userModel.DataExport
+	if err := global.APP_DB.Where("status = ? AND expires_at < ?", userModel.DataExportStatusCompleted, time.Now()).
+		Find(&exports).Error; err != nil {
+		return 0, fmt.Errorf("查询过期导出失败: %w", err)
+	}
+	if len(exports) == 0 {
+		return 0, nil
+	}
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	cleaned := 0
+	for _, export := range exports {
+		if err := store.Delete(ctx, export.ObjectKey); err != nil {
+			global.APP_LOG.Warn("删除过期导出文件失败", zap.Uint("exportId", export.ID), zap.Error(err))
+			continue
+		}
+		if err := global.APP_DB.Model(&export).Update("status", userModel.DataExportStatusExpired).Error; err == nil {
+			cleaned++
+		}
+	}
+	return cleaned, nil
+}
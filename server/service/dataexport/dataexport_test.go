@@ -0,0 +1,75 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+
+	"gorm.io/gorm"
+)
+
+func TestWriteInstancesOmitsPassword(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	instances := []providerModel.Instance{
+		{Name: "web-1", Username: "root", Password: "s3cret", CPU: 2},
+		{Name: "old", Password: "gone", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeInstances(zw, instances); err != nil {
+		t.Fatalf("writeInstances: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "instances.json" {
+		t.Fatalf("unexpected archive: %v", err)
+	}
+	f, _ := zr.File[0].Open()
+	data, _ := io.ReadAll(f)
+	f.Close()
+
+	if strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "gone") {
+		t.Fatalf("instances.json must not contain passwords: %s", data)
+	}
+	var list []instanceExport
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("decode instances.json: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "web-1" || list[0].CPU != 2 || list[0].DeletedAt != nil {
+		t.Errorf("unexpected first instance: %+v", list)
+	}
+	if list[1].DeletedAt == nil || !list[1].DeletedAt.Equal(deletedAt) {
+		t.Errorf("deleted instance should keep deletedAt, got %+v", list[1].DeletedAt)
+	}
+}
+
+func TestIsDownloadable(t *testing.T) {
+	now := time.Now()
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	tests := []struct {
+		name   string
+		export userModel.DataExport
+		want   bool
+	}{
+		{"completed", userModel.DataExport{Status: userModel.DataExportStatusCompleted, ExpiresAt: &future}, true},
+		{"expired", userModel.DataExport{Status: userModel.DataExportStatusCompleted, ExpiresAt: &past}, false},
+		{"pending", userModel.DataExport{Status: userModel.DataExportStatusPending, ExpiresAt: &future}, false},
+		{"no expiry", userModel.DataExport{Status: userModel.DataExportStatusCompleted}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.export.IsDownloadable(now); got != tt.want {
+			t.Errorf("%s: IsDownloadable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
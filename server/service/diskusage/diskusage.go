@@ -0,0 +1,102 @@
+// Package diskusage 采集实例内根分区的实际使用量
+// 精简置备的磁盘在宿主机上只能看到分配大小，需要在实例内执行 df 才能得到真实占用
+package diskusage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+)
+
+// dfCommand 以1MB为单位输出根分区使用情况，-P 保证每个文件系统只占一行，兼容 busybox
+const dfCommand = "df -Pm / 2>/dev/null"
+
+// defaultWarnPercent 未配置时的提示阈值
+const defaultWarnPercent = 90
+
+// ParseDF 解析 df -Pm 的输出，返回根分区已用和总大小（MB）
+func ParseDF(output string) (usedMB, totalMB int64, err error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return 0, 0, errors.New("df 输出为空")
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("无法解析 df 输出: %q", lines[len(lines)-1])
+	}
+	totalMB, err1 := strconv.ParseInt(fields[1], 10, 64)
+	usedMB, err2 := strconv.ParseInt(fields[2], 10, 64)
+	if err1 != nil || err2 != nil || totalMB <= 0 || usedMB < 0 {
+		return 0, 0, fmt.Errorf("无法解析 df 输出: %q", lines[len(lines)-1])
+	}
+	return usedMB, totalMB, nil
+}
+
+// Percent 计算已用空间占分配大小的百分比
+// 优先以实例规格的磁盘大小为准，未限制磁盘大小时（如部分容器直接看到宿主机分区）使用实例内可见的大小
+func Percent(usedMB, allocatedMB, totalMB int64) float64 {
+	base := allocatedMB
+	if base <= 0 {
+		base = totalMB
+	}
+	if base <= 0 {
+		return 0
+	}
+	return float64(usedMB) * 100 / float64(base)
+}
+
+// WarnPercent 返回配置的提示阈值
+func WarnPercent() int {
+	if p := global.APP_CONFIG.DiskUsage.WarnPercent; p > 0 && p <= 100 {
+		return p
+	}
+	return defaultWarnPercent
+}
+
+// InstancePercent 返回实例最近一次采集的使用百分比，未采集时 ok 为 false
+func InstancePercent(instance *providerModel.Instance) (percent float64, ok bool) {
+	if instance.DiskUsageAt == nil {
+		return 0, false
+	}
+	return Percent(instance.DiskUsedMB, instance.Disk, instance.DiskTotalMB), true
+}
+
+// Collect 通过映射的SSH端口登录实例执行 df，返回根分区已用和总大小（MB）
+func Collect(instance *providerModel.Instance, provider *providerModel.Provider) (usedMB, totalMB int64, err error) {
+	if constant.IsWindowsOSType(instance.OSType) {
+		return 0, 0, errors.New("Windows 实例不支持采集磁盘使用量")
+	}
+	if instance.Username == "" || instance.Password == "" {
+		return 0, 0, errors.New("实例缺少登录凭据")
+	}
+	host, port := resources.ResolveInstanceSSHEndpoint(instance, provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无法通过 %s:%d 登录SSH: %v", host, port, err)
+	}
+	defer client.Close()
+	defer session.Close()
+
+	output, err := session.Output(dfCommand)
+	if err != nil && len(output) == 0 {
+		return 0, 0, fmt.Errorf("执行 df 失败: %v", err)
+	}
+	return ParseDF(string(output))
+}
+
+// Save 保存采集结果
+func Save(instanceID uint, usedMB, totalMB int64, at time.Time) error {
+	return global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instanceID).Updates(map[string]interface{}{
+		"disk_used_mb":  usedMB,
+		"disk_total_mb": totalMB,
+		"disk_usage_at": &at,
+	}).Error
+}
@@ -0,0 +1,54 @@
+package diskusage
+
+import "testing"
+
+func TestParseDF(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		wantUsed  int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{
+			name:      "gnu",
+			output:    "Filesystem     1048576-blocks  Used Available Capacity Mounted on\n/dev/sda1               9952  2315      7109      25% /\n",
+			wantUsed:  2315,
+			wantTotal: 9952,
+		},
+		{
+			name:      "busybox overlay",
+			output:    "Filesystem           1M-blocks      Used Available Use% Mounted on\noverlay                  20030      1204     17786   6% /\n",
+			wantUsed:  1204,
+			wantTotal: 20030,
+		},
+		{name: "empty", output: "", wantErr: true},
+		{name: "header only", output: "Filesystem 1M-blocks Used Available Use% Mounted on", wantErr: true},
+		{name: "garbage", output: "Filesystem\nsh: df: not found", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, total, err := ParseDF(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if used != tt.wantUsed || total != tt.wantTotal {
+				t.Errorf("got used=%d total=%d, want used=%d total=%d", used, total, tt.wantUsed, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	// 以分配大小为准
+	if got := Percent(900, 1000, 20000); got != 90 {
+		t.Errorf("allocated: got %v", got)
+	}
+	// 未分配大小时使用实例内可见大小
+	if got := Percent(500, 0, 2000); got != 25 {
+		t.Errorf("fallback: got %v", got)
+	}
+	if got := Percent(500, 0, 0); got != 0 {
+		t.Errorf("zero: got %v", got)
+	}
+}
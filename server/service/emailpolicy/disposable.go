@@ -0,0 +1,176 @@
+package emailpolicy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/utils"
+)
+
+// maxDisposableListSize 远程列表的最大下载大小
+const maxDisposableListSize = 8 << 20
+
+// builtinDisposableDomains 内置的常见一次性邮箱域名，远程列表不可用时仍能拦截
+var builtinDisposableDomains = []string{
+	"10minutemail.com",
+	"20minutemail.com",
+	"33mail.com",
+	"dispostable.com",
+	"dropmail.me",
+	"emailondeck.com",
+	"fakeinbox.com",
+	"getairmail.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"guerrillamail.net",
+	"guerrillamail.org",
+	"guerrillamailblock.com",
+	"harakirimail.com",
+	"inboxkitten.com",
+	"mail.tm",
+	"mailcatch.com",
+	"maildrop.cc",
+	"mailinator.com",
+	"mailnesia.com",
+	"mintemail.com",
+	"mohmal.com",
+	"moakt.com",
+	"mytemp.email",
+	"sharklasers.com",
+	"spam4.me",
+	"spamgourmet.com",
+	"temp-mail.io",
+	"temp-mail.org",
+	"tempail.com",
+	"tempmail.dev",
+	"tempmailo.com",
+	"tempr.email",
+	"throwawaymail.com",
+	"tmpmail.net",
+	"trashmail.com",
+	"yopmail.com",
+	"yopmail.net",
+}
+
+type disposableList struct {
+	mu        sync.RWMutex
+	domains   map[string]struct{}
+	remote    int
+	updatedAt time.Time
+}
+
+var disposable = newDisposableList()
+
+func newDisposableList() *disposableList {
+	l := &disposableList{}
+	l.set(nil)
+	return l
+}
+
+// set 用内置列表加远程列表替换当前列表
+func (l *disposableList) set(remote []string) {
+	domains := make(map[string]struct{}, len(builtinDisposableDomains)+len(remote))
+	for _, d := range builtinDisposableDomains {
+		domains[d] = struct{}{}
+	}
+	for _, d := range remote {
+		domains[d] = struct{}{}
+	}
+	l.mu.Lock()
+	l.domains = domains
+	l.remote = len(remote)
+	if remote != nil {
+		l.updatedAt = time.Now()
+	}
+	l.mu.Unlock()
+}
+
+// contains 判断域名或其任一上级域名是否在列表中
+func (l *disposableList) contains(domain string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for {
+		if _, ok := l.domains[domain]; ok {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// IsDisposable 判断域名是否为一次性邮箱
+func IsDisposable(domain string) bool {
+	return disposable.contains(strings.ToLower(domain))
+}
+
+// DisposableListInfo 一次性邮箱列表状态
+type DisposableListInfo struct {
+	Total     int        `json:"total"`     // 当前生效的域名数
+	Remote    int        `json:"remote"`    // 来自远程列表的域名数
+	UpdatedAt *time.Time `json:"updatedAt"` // 远程列表最近刷新时间，未刷新过为空
+}
+
+// DisposableInfo 返回一次性邮箱列表状态
+func DisposableInfo() DisposableListInfo {
+	disposable.mu.RLock()
+	defer disposable.mu.RUnlock()
+	info := DisposableListInfo{Total: len(disposable.domains), Remote: disposable.remote}
+	if !disposable.updatedAt.IsZero() {
+		updatedAt := disposable.updatedAt
+		info.UpdatedAt = &updatedAt
+	}
+	return info
+}
+
+// RefreshDisposable 下载远程一次性邮箱列表并替换内存中的列表，返回远程列表的域名数
+// 下载失败时保留原有列表
+func RefreshDisposable(ctx context.Context, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := utils.GetHTTPClientWithTimeout(60 * time.Second).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("下载一次性邮箱列表失败: HTTP %d", resp.StatusCode)
+	}
+	domains, err := parseDomainList(io.LimitReader(resp.Body, maxDisposableListSize))
+	if err != nil {
+		return 0, err
+	}
+	if len(domains) == 0 {
+		return 0, fmt.Errorf("一次性邮箱列表为空")
+	}
+	disposable.set(domains)
+	return len(domains), nil
+}
+
+// parseDomainList 解析每行一个域名的列表，忽略空行和#开头的注释
+func parseDomainList(r io.Reader) ([]string, error) {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		line = strings.ToLower(strings.Trim(line, ".@"))
+		if line == "" || strings.ContainsAny(line, " \t/:") || !strings.Contains(line, ".") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	return domains, scanner.Err()
+}
@@ -0,0 +1,157 @@
+package emailpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+)
+
+// 注册邮箱被拒绝的原因，用于统计
+const (
+	ReasonRequired   = "required"    // 未填写邮箱
+	ReasonInvalid    = "invalid"     // 邮箱格式错误
+	ReasonNotAllowed = "not_allowed" // 域名不在允许列表中
+	ReasonDenied     = "denied"      // 域名在禁止列表中
+	ReasonDisposable = "disposable"  // 一次性邮箱
+	ReasonNoMX       = "no_mx"       // 域名无法接收邮件
+)
+
+// Rejection 邮箱未通过策略检查
+type Rejection struct {
+	Reason  string
+	Domain  string
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	return r.Message
+}
+
+// Policy 注册邮箱策略
+type Policy struct {
+	RequireEmail    bool
+	AllowDomains    []string
+	DenyDomains     []string
+	CheckMX         bool
+	BlockDisposable bool
+}
+
+// FromConfig 从注册配置构造邮箱策略
+func FromConfig() Policy {
+	cfg := global.APP_CONFIG.Registration
+	return Policy{
+		RequireEmail:    cfg.RequireEmail,
+		AllowDomains:    normalizeDomains(cfg.EmailAllowDomains),
+		DenyDomains:     normalizeDomains(cfg.EmailDenyDomains),
+		CheckMX:         cfg.CheckEmailMX,
+		BlockDisposable: cfg.BlockDisposableEmail,
+	}
+}
+
+// mxLookup 检查域名能否接收邮件，测试中可替换
+var mxLookup = lookupMailDomain
+
+// Check 检查注册邮箱，通过返回nil，未通过返回 *Rejection
+func (p Policy) Check(ctx context.Context, email string) *Rejection {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		if p.RequireEmail || len(p.AllowDomains) > 0 {
+			return &Rejection{Reason: ReasonRequired, Message: "注册需要填写邮箱"}
+		}
+		return nil
+	}
+
+	domain, ok := Domain(email)
+	if !ok {
+		return &Rejection{Reason: ReasonInvalid, Message: "邮箱格式不正确"}
+	}
+	if len(p.AllowDomains) > 0 && !matchDomain(domain, p.AllowDomains) {
+		return &Rejection{Reason: ReasonNotAllowed, Domain: domain, Message: "仅支持使用以下域名的邮箱注册: " + strings.Join(p.AllowDomains, ", ")}
+	}
+	if matchDomain(domain, p.DenyDomains) {
+		return &Rejection{Reason: ReasonDenied, Domain: domain, Message: "不支持使用该域名的邮箱注册"}
+	}
+	if p.BlockDisposable && IsDisposable(domain) {
+		return &Rejection{Reason: ReasonDisposable, Domain: domain, Message: "不支持使用一次性邮箱注册"}
+	}
+	if p.CheckMX {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if ok, err := mxLookup(ctx, domain); err == nil && !ok {
+			return &Rejection{Reason: ReasonNoMX, Domain: domain, Message: "该邮箱域名无法接收邮件"}
+		}
+		// DNS查询出错（超时、服务器故障）时放行，避免解析故障阻断所有注册
+	}
+	return nil
+}
+
+// Domain 解析邮箱地址并返回小写域名
+func Domain(email string) (string, bool) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", false
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	domain := strings.ToLower(strings.TrimSuffix(addr.Address[at+1:], "."))
+	if domain == "" || !strings.Contains(domain, ".") || strings.HasPrefix(domain, "[") {
+		return "", false
+	}
+	return domain, true
+}
+
+// matchDomain 判断域名是否等于列表中的某个域名或为其子域名
+func matchDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.Trim(strings.TrimSpace(d), ".@"))
+		if d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// lookupMailDomain 查询域名的MX记录，没有MX时按RFC 5321回退到A/AAAA记录
+// 返回false表示域名确定无法接收邮件，查询失败时返回错误
+func lookupMailDomain(ctx context.Context, domain string) (bool, error) {
+	mxs, err := net.DefaultResolver.LookupMX(ctx, domain)
+	if err == nil {
+		// 空MX（RFC 7505，单条记录且主机为"."）表示域名明确不接收邮件
+		if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+			return false, nil
+		}
+		if len(mxs) > 0 {
+			return true, nil
+		}
+	} else if !isNotFound(err) {
+		return false, err
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, domain)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(addrs) > 0, nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,105 @@
+package emailpolicy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDomain(t *testing.T) {
+	cases := map[string]string{
+		"user@Example.COM":     "example.com",
+		"a.b+tag@mail.corp.io": "mail.corp.io",
+		"user@localhost":       "",
+		"not-an-email":         "",
+		"User <user@a.com>":    "",
+		"user@[127.0.0.1]":     "",
+	}
+	for email, want := range cases {
+		got, ok := Domain(email)
+		if got != want || ok != (want != "") {
+			t.Errorf("Domain(%q) = %q, %v, want %q", email, got, ok, want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	defer func(orig func(context.Context, string) (bool, error)) { mxLookup = orig }(mxLookup)
+	mxLookup = func(_ context.Context, domain string) (bool, error) {
+		switch domain {
+		case "nomail.example":
+			return false, nil
+		case "timeout.example":
+			return false, errors.New("i/o timeout")
+		}
+		return true, nil
+	}
+
+	p := Policy{
+		AllowDomains:    normalizeDomains([]string{" Corp.com ", "@partner.org"}),
+		DenyDomains:     normalizeDomains([]string{"blocked.corp.com"}),
+		BlockDisposable: true,
+	}
+	cases := map[string]string{
+		"":                     ReasonRequired,
+		"bad":                  ReasonInvalid,
+		"a@corp.com":           "",
+		"a@dev.corp.com":       "",
+		"a@partner.org":        "",
+		"a@notcorp.com":        ReasonNotAllowed,
+		"a@gmail.com":          ReasonNotAllowed,
+		"a@x.blocked.corp.com": ReasonDenied,
+	}
+	for email, want := range cases {
+		got := ""
+		if r := p.Check(context.Background(), email); r != nil {
+			got = r.Reason
+		}
+		if got != want {
+			t.Errorf("Check(%q) = %q, want %q", email, got, want)
+		}
+	}
+
+	p = Policy{BlockDisposable: true, CheckMX: true}
+	cases = map[string]string{
+		"":                    "",
+		"a@gmail.com":         "",
+		"a@mailinator.com":    ReasonDisposable,
+		"a@eu.mailinator.com": ReasonDisposable,
+		"a@nomail.example":    ReasonNoMX,
+		"a@timeout.example":   "",
+	}
+	for email, want := range cases {
+		got := ""
+		if r := p.Check(context.Background(), email); r != nil {
+			got = r.Reason
+		}
+		if got != want {
+			t.Errorf("Check(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestDisposableList(t *testing.T) {
+	defer disposable.set(nil)
+
+	domains, err := parseDomainList(strings.NewReader("# comment\n\nTempBox.Example\nbad line here\nlocalhost\nspam.example # trailing\n"))
+	if err != nil {
+		t.Fatalf("parseDomainList: %v", err)
+	}
+	if strings.Join(domains, ",") != "tempbox.example,spam.example" {
+		t.Fatalf("domains = %v", domains)
+	}
+
+	if IsDisposable("tempbox.example") {
+		t.Error("remote domain should not match before refresh")
+	}
+	disposable.set(domains)
+	if !IsDisposable("TempBox.example") || !IsDisposable("yopmail.com") || IsDisposable("example") {
+		t.Error("remote list should be merged with the builtin list")
+	}
+	if info := DisposableInfo(); info.Remote != 2 || info.UpdatedAt == nil || info.Total < len(builtinDisposableDomains)+2 {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
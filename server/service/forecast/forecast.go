@@ -0,0 +1,352 @@
+// Package forecast 资源用量趋势预测
+// 定期采样Provider的物理占用率和用户当月流量，按线性回归预测Provider何时达到容量阈值，
+// 按EWMA预测用户何时用完当月流量；进入预警期时通知管理员或用户
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"time"
+
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/traffic"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSampleInterval = 60
+	defaultHistoryDays    = 14
+	defaultThreshold      = 90
+	defaultWarnDays       = 7
+	defaultAlpha          = 0.3
+	// sampleKeepDays 采样保留天数，需覆盖一个完整的流量月
+	sampleKeepDays = 35
+)
+
+// Settings 生效的预测参数
+type Settings struct {
+	HistoryDays int
+	Threshold   float64
+	WarnDays    int
+	Alpha       float64
+}
+
+// CurrentSettings 读取配置并填充默认值
+func CurrentSettings() Settings {
+	cfg := global.APP_CONFIG.Forecast
+	s := Settings{HistoryDays: cfg.HistoryDays, Threshold: cfg.Threshold, WarnDays: cfg.WarnDays, Alpha: cfg.Alpha}
+	if s.HistoryDays <= 0 {
+		s.HistoryDays = defaultHistoryDays
+	}
+	if s.Threshold <= 0 || s.Threshold > 100 {
+		s.Threshold = defaultThreshold
+	}
+	if s.WarnDays <= 0 {
+		s.WarnDays = defaultWarnDays
+	}
+	if s.Alpha <= 0 || s.Alpha > 1 {
+		s.Alpha = defaultAlpha
+	}
+	return s
+}
+
+// Interval 返回采样间隔
+func Interval() time.Duration {
+	if minutes := global.APP_CONFIG.Forecast.SampleInterval; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultSampleInterval * time.Minute
+}
+
+// ProviderForecast 按线性回归预测Provider资源占用率何时达到阈值，预计在预警天数内达到时进入预警
+func ProviderForecast(points []Point, s Settings, now time.Time) monitoringModel.UsageForecast {
+	f := monitoringModel.UsageForecast{Method: "linear", Threshold: s.Threshold}
+	if len(points) == 0 {
+		return f
+	}
+	f.Current = points[len(points)-1].Value
+	rate, ok := LinearRate(points)
+	if !ok && f.Current < s.Threshold {
+		return f
+	}
+	f.DailyRate = round(rate)
+	f.ExhaustAt = ExhaustAt(f.Current, s.Threshold, rate, now)
+	f.Warning = f.ExhaustAt != nil && f.ExhaustAt.Sub(now) <= time.Duration(s.WarnDays)*24*time.Hour
+	return f
+}
+
+// TrafficForecast 按EWMA预测用户何时用完当月流量，预计在月底前用完时进入预警
+// 当月采样不足时按月初以来的平均速率估算
+func TrafficForecast(points []Point, limit float64, s Settings, now time.Time) monitoringModel.UsageForecast {
+	f := monitoringModel.UsageForecast{Method: "ewma", Threshold: limit}
+	if len(points) == 0 || limit <= 0 {
+		return f
+	}
+	f.Current = points[len(points)-1].Value
+	rate, ok := EWMARate(points, s.Alpha)
+	if !ok {
+		elapsed := now.Sub(monthStart(now)).Hours() / 24
+		if elapsed < minSpan.Hours()/24 {
+			return f
+		}
+		f.Method = "linear"
+		rate = f.Current / elapsed
+	}
+	f.DailyRate = round(rate)
+	f.ExhaustAt = ExhaustAt(f.Current, limit, rate, now)
+	f.Warning = f.ExhaustAt != nil && f.ExhaustAt.Before(monthStart(now).AddDate(0, 1, 0))
+	return f
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// subject 一个预测对象的一项指标
+type subject struct {
+	kind      string
+	id        uint
+	metric    string
+	name      string
+	email     string  // 用户预测的通知邮箱
+	threshold float64 // 用户流量预测的当月配额（MB）
+}
+
+// Run 采样并更新全部预测结果，进入预警期时发送通知
+func Run(ctx context.Context, now time.Time) error {
+	s := CurrentSettings()
+	subjects, err := sample(now)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subjects {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := update(sub, s, now); err != nil {
+			global.APP_LOG.Warn("更新用量预测失败",
+				zap.String("kind", sub.kind),
+				zap.Uint("subjectId", sub.id),
+				zap.String("metric", sub.metric),
+				zap.Error(err))
+		}
+	}
+
+	// 已删除的Provider或不再统计流量的用户不会再更新，清理其过期的预测
+	if err := global.APP_DB.Where("updated_at < ?", now.Add(-2*Interval()-time.Hour)).
+		Delete(&monitoringModel.UsageForecast{}).Error; err != nil {
+		return err
+	}
+	return global.APP_DB.Where("sampled_at < ?", now.AddDate(0, 0, -sampleKeepDays)).
+		Delete(&monitoringModel.UsageSample{}).Error
+}
+
+// sample 记录本轮采样并返回需要更新预测的对象
+func sample(now time.Time) ([]subject, error) {
+	var samples []monitoringModel.UsageSample
+	var subjects []subject
+
+	capacities, err := (&resources.ResourceService{}).GetProvidersCapacity()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range capacities {
+		values := map[string]float64{
+			monitoringModel.ForecastMetricCPU:    c.CPU.PhysicalUsage,
+			monitoringModel.ForecastMetricMemory: c.Memory.PhysicalUsage,
+		}
+		if c.Disk.Allocatable > 0 {
+			values[monitoringModel.ForecastMetricDisk] = round(float64(c.Disk.Used) / float64(c.Disk.Allocatable) * 100)
+		}
+		for _, metric := range []string{monitoringModel.ForecastMetricCPU, monitoringModel.ForecastMetricMemory, monitoringModel.ForecastMetricDisk} {
+			value, ok := values[metric]
+			if !ok {
+				continue
+			}
+			samples = append(samples, monitoringModel.UsageSample{
+				Kind: monitoringModel.ForecastKindProvider, SubjectID: c.ProviderID, Metric: metric, Value: value, SampledAt: now,
+			})
+			subjects = append(subjects, subject{kind: monitoringModel.ForecastKindProvider, id: c.ProviderID, metric: metric, name: c.Name})
+		}
+	}
+
+	// 只预测有流量配额且有实例位于启用流量统计的Provider上的用户
+	var userIDs []uint
+	if err := global.APP_DB.Table("instances").
+		Joins("INNER JOIN providers ON instances.provider_id = providers.id").
+		Where("providers.enable_traffic_control = ?", true).
+		Distinct().Pluck("instances.user_id", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询流量统计用户失败: %w", err)
+	}
+	var users []userModel.User
+	if len(userIDs) > 0 {
+		if err := global.APP_DB.Select("id, username, email, total_traffic, timezone").
+			Where("id IN ? AND status = ? AND total_traffic > 0", userIDs, 1).
+			Find(&users).Error; err != nil {
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+	}
+	queryService := traffic.NewQueryService()
+	for _, u := range users {
+		userNow := now.In(utils.LoadLocation(u.Timezone))
+		stats, err := queryService.GetUserMonthlyTraffic(u.ID, userNow.Year(), int(userNow.Month()))
+		if err != nil {
+			global.APP_LOG.Warn("获取用户当月流量失败", zap.Uint("userId", u.ID), zap.Error(err))
+			continue
+		}
+		samples = append(samples, monitoringModel.UsageSample{
+			Kind: monitoringModel.ForecastKindUser, SubjectID: u.ID, Metric: monitoringModel.ForecastMetricTraffic,
+			Value: round(stats.ActualUsageMB), SampledAt: now,
+		})
+		subjects = append(subjects, subject{
+			kind: monitoringModel.ForecastKindUser, id: u.ID, metric: monitoringModel.ForecastMetricTraffic,
+			name: u.Username, email: u.Email, threshold: float64(u.TotalTraffic),
+		})
+	}
+
+	if len(samples) > 0 {
+		if err := global.APP_DB.CreateInBatches(samples, 200).Error; err != nil {
+			return nil, fmt.Errorf("保存用量采样失败: %w", err)
+		}
+	}
+	return subjects, nil
+}
+
+// update 重新计算对象的预测结果，新进入预警期时发送通知
+func update(sub subject, s Settings, now time.Time) error {
+	since := now.AddDate(0, 0, -s.HistoryDays)
+	if sub.kind == monitoringModel.ForecastKindUser {
+		// 流量按自然月重置，只使用本月的采样
+		since = monthStart(now)
+	}
+	var samples []monitoringModel.UsageSample
+	if err := global.APP_DB.Where("kind = ? AND subject_id = ? AND metric = ? AND sampled_at >= ?",
+		sub.kind, sub.id, sub.metric, since).Order("sampled_at ASC").Find(&samples).Error; err != nil {
+		return err
+	}
+	points := make([]Point, len(samples))
+	for i, sample := range samples {
+		points[i] = Point{At: sample.SampledAt, Value: sample.Value}
+	}
+
+	var result monitoringModel.UsageForecast
+	if sub.kind == monitoringModel.ForecastKindUser {
+		result = TrafficForecast(points, sub.threshold, s, now)
+	} else {
+		result = ProviderForecast(points, s, now)
+	}
+
+	var existing monitoringModel.UsageForecast
+	global.APP_DB.Where("kind = ? AND subject_id = ? AND metric = ?", sub.kind, sub.id, sub.metric).First(&existing)
+	result.ID, result.NotifiedAt = existing.ID, existing.NotifiedAt
+	result.Kind, result.SubjectID, result.Metric, result.Name = sub.kind, sub.id, sub.metric, sub.name
+
+	if result.Warning && shouldNotify(&result, s, now) {
+		sent, err := notify(sub, &result)
+		if err != nil {
+			global.APP_LOG.Warn("发送用量预警通知失败",
+				zap.String("kind", sub.kind),
+				zap.Uint("subjectId", sub.id),
+				zap.Error(err))
+		}
+		if sent {
+			result.NotifiedAt = &now
+		}
+	}
+	return global.APP_DB.Save(&result).Error
+}
+
+// shouldNotify 判断是否需要发送预警：用户每月最多一次，Provider在预警天数内最多一次
+func shouldNotify(f *monitoringModel.UsageForecast, s Settings, now time.Time) bool {
+	if f.NotifiedAt == nil {
+		return true
+	}
+	if f.Kind == monitoringModel.ForecastKindUser {
+		return f.NotifiedAt.Before(monthStart(now))
+	}
+	return now.Sub(*f.NotifiedAt) >= time.Duration(s.WarnDays)*24*time.Hour
+}
+
+// notify 按配置发送预警邮件，返回是否已发送；未启用通知或未配置邮件服务时不发送
+func notify(sub subject, f *monitoringModel.UsageForecast) (bool, error) {
+	if !utils.MailConfigured() {
+		return false, nil
+	}
+	exhaustAt := f.ExhaustAt.Format("2006-01-02 15:04")
+
+	if sub.kind == monitoringModel.ForecastKindUser {
+		if !global.APP_CONFIG.Forecast.NotifyUsers || sub.email == "" {
+			return false, nil
+		}
+		subject := "本月流量预计将提前用完"
+		body := fmt.Sprintf("您好 %s，您本月已使用流量 %.0f MB（配额 %.0f MB），按近期平均每天 %.0f MB 的用量，预计将在 %s 左右用完本月流量。"+
+			"流量用完后实例将被限制，请注意控制用量。",
+			html.EscapeString(sub.name), f.Current, f.Threshold, f.DailyRate, exhaustAt)
+		if err := utils.SendMail(sub.email, subject, body); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if !global.APP_CONFIG.Forecast.NotifyAdmins {
+		return false, nil
+	}
+	var admins []userModel.User
+	if err := global.APP_DB.Select("id", "email").
+		Where("user_type = ? AND status = ? AND email <> ''", "admin", 1).Find(&admins).Error; err != nil {
+		return false, fmt.Errorf("查询管理员邮箱失败: %w", err)
+	}
+	subject := fmt.Sprintf("Provider %s 容量预警", sub.name)
+	body := fmt.Sprintf("节点 %s 的 %s 占用率当前为 %.1f%%，按近期每天增长 %.2f%% 的趋势，预计在 %s 左右达到 %.0f%%，请提前扩容或调整放置策略。",
+		html.EscapeString(sub.name), sub.metric, f.Current, f.DailyRate, exhaustAt, f.Threshold)
+	sent := false
+	for _, admin := range admins {
+		if err := utils.SendMail(admin.Email, subject, body); err != nil {
+			global.APP_LOG.Warn("发送容量预警邮件失败",
+				zap.Uint("providerId", sub.id),
+				zap.Uint("adminId", admin.ID),
+				zap.Error(err))
+			continue
+		}
+		sent = true
+	}
+	return sent, nil
+}
+
+// List 返回预测结果，kind 为空时返回全部类型；warningOnly 为true时只返回处于预警期的预测
+func List(kind string, warningOnly bool) ([]monitoringModel.UsageForecast, error) {
+	query := global.APP_DB.Order("warning DESC, kind ASC, subject_id ASC, metric ASC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if warningOnly {
+		query = query.Where("warning = ?", true)
+	}
+	forecasts := []monitoringModel.UsageForecast{}
+	if err := query.Find(&forecasts).Error; err != nil {
+		return nil, err
+	}
+	return forecasts, nil
+}
+
+// Samples 返回对象某项指标最近 days 天的采样，用于绘制趋势图
+func Samples(kind string, subjectID uint, metric string, days int) ([]monitoringModel.UsageSample, error) {
+	if days <= 0 || days > sampleKeepDays {
+		days = sampleKeepDays
+	}
+	samples := []monitoringModel.UsageSample{}
+	err := global.APP_DB.Where("kind = ? AND subject_id = ? AND metric = ? AND sampled_at >= ?",
+		kind, subjectID, metric, time.Now().AddDate(0, 0, -days)).
+		Order("sampled_at ASC").Find(&samples).Error
+	return samples, err
+}
@@ -0,0 +1,87 @@
+package forecast
+
+import (
+	"time"
+)
+
+// minSpan 计算增长速率所需的最短采样跨度，跨度太短时速率误差过大
+const minSpan = time.Hour
+
+// Point 一次采样
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+// LinearRate 用最小二乘法拟合采样点，返回每天的增长量，采样不足时 ok 为false
+func LinearRate(points []Point) (float64, bool) {
+	if len(points) < 2 || points[len(points)-1].At.Sub(points[0].At) < minSpan {
+		return 0, false
+	}
+	origin := points[0].At
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.At.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += p.Value
+		sumXY += x * p.Value
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// EWMARate 对相邻采样间的增长速率做指数加权移动平均，返回每天的增长量
+// alpha 越大越偏重近期速率；数值下降（如计数重置）按0增长处理
+func EWMARate(points []Point, alpha float64) (float64, bool) {
+	if len(points) < 2 || points[len(points)-1].At.Sub(points[0].At) < minSpan {
+		return 0, false
+	}
+	if alpha <= 0 || alpha > 1 {
+		alpha = defaultAlpha
+	}
+	var rate float64
+	started := false
+	for i := 1; i < len(points); i++ {
+		days := points[i].At.Sub(points[i-1].At).Hours() / 24
+		if days <= 0 {
+			continue
+		}
+		delta := points[i].Value - points[i-1].Value
+		if delta < 0 {
+			delta = 0
+		}
+		if !started {
+			rate, started = delta/days, true
+			continue
+		}
+		rate = alpha*delta/days + (1-alpha)*rate
+	}
+	return rate, started
+}
+
+// ExhaustAt 按当前值和每日增长量预测达到阈值的时间，已达到时返回now，不增长时返回nil
+func ExhaustAt(current, threshold, dailyRate float64, now time.Time) *time.Time {
+	if current >= threshold {
+		return &now
+	}
+	if dailyRate <= 0 {
+		return nil
+	}
+	days := (threshold - current) / dailyRate
+	// 超过十年的预测没有意义，也避免 time.Duration 溢出
+	if days > 3650 {
+		return nil
+	}
+	at := now.Add(time.Duration(days * 24 * float64(time.Hour)))
+	return &at
+}
+
+// monthStart 返回 t 所在月份的第一天零点
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,76 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func series(start time.Time, step time.Duration, values ...float64) []Point {
+	points := make([]Point, len(values))
+	for i, v := range values {
+		points[i] = Point{At: start.Add(time.Duration(i) * step), Value: v}
+	}
+	return points
+}
+
+func TestLinearRate(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	rate, ok := LinearRate(series(start, 24*time.Hour, 50, 52, 54, 56))
+	if !ok || math.Abs(rate-2) > 1e-9 {
+		t.Errorf("LinearRate = %v, %v, want 2 per day", rate, ok)
+	}
+	if _, ok := LinearRate(series(start, time.Minute, 50, 60)); ok {
+		t.Error("span shorter than an hour should be rejected")
+	}
+}
+
+func TestEWMARate(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	// 速率 10 -> 20，alpha=0.5 时结果为 15；计数下降按0增长
+	rate, ok := EWMARate(series(start, 24*time.Hour, 0, 10, 30), 0.5)
+	if !ok || math.Abs(rate-15) > 1e-9 {
+		t.Errorf("EWMARate = %v, %v, want 15", rate, ok)
+	}
+	rate, _ = EWMARate(series(start, 24*time.Hour, 100, 0, 0), 0.5)
+	if rate != 0 {
+		t.Errorf("reset should not produce negative rate, got %v", rate)
+	}
+}
+
+func TestExhaustAt(t *testing.T) {
+	now := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	if at := ExhaustAt(80, 90, 2, now); at == nil || !at.Equal(now.Add(5*24*time.Hour)) {
+		t.Errorf("ExhaustAt = %v, want 5 days later", at)
+	}
+	if at := ExhaustAt(95, 90, 0, now); at == nil || !at.Equal(now) {
+		t.Errorf("already above threshold should return now, got %v", at)
+	}
+	if at := ExhaustAt(50, 90, -1, now); at != nil {
+		t.Errorf("decreasing usage should not exhaust, got %v", at)
+	}
+}
+
+func TestForecasts(t *testing.T) {
+	s := Settings{HistoryDays: 14, Threshold: 90, WarnDays: 7, Alpha: 0.3}
+	now := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	f := ProviderForecast(series(now.Add(-72*time.Hour), 24*time.Hour, 74, 76, 78, 80), s, now)
+	if !f.Warning || f.DailyRate != 2 {
+		t.Errorf("provider reaching 90%% in 5 days should warn: %+v", f)
+	}
+	f = ProviderForecast(series(now.Add(-72*time.Hour), 24*time.Hour, 40, 41, 42, 43), s, now)
+	if f.Warning || f.ExhaustAt == nil {
+		t.Errorf("slow growth should forecast without warning: %+v", f)
+	}
+
+	// 15天用了 600MB，配额 1000MB，月底前会用完
+	f = TrafficForecast([]Point{{At: now, Value: 600}}, 1000, s, now)
+	if !f.Warning || f.Method != "linear" {
+		t.Errorf("traffic without history should fall back to month average and warn: %+v", f)
+	}
+	f = TrafficForecast(series(now.Add(-48*time.Hour), 24*time.Hour, 100, 110, 120), 1000, s, now)
+	if f.Warning || f.Method != "ewma" {
+		t.Errorf("low traffic rate should not warn: %+v", f)
+	}
+}
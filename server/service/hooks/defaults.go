@@ -0,0 +1,83 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+)
+
+// buildTimezoneScript 生成设置实例时区的脚本，优先使用timedatectl，不可用时直接替换 /etc/localtime
+func buildTimezoneScript(timezone string) string {
+	return fmt.Sprintf(`tz=%s
+if command -v timedatectl >/dev/null 2>&1 && timedatectl set-timezone "$tz" 2>/dev/null; then
+  exit 0
+fi
+if [ ! -f "/usr/share/zoneinfo/$tz" ]; then
+  echo "时区文件不存在: /usr/share/zoneinfo/$tz"
+  exit 1
+fi
+ln -sf "/usr/share/zoneinfo/$tz" /etc/localtime && echo "$tz" > /etc/timezone
+`, utils.ShellQuote(timezone))
+}
+
+// ApplyUserInstanceDefaults 将实例所有者默认设置中的SSH公钥和时区写入新建的实例，返回失败原因
+// 用户未保存默认设置或未设置这两项时直接跳过
+func ApplyUserInstanceDefaults(taskID, instanceID uint) string {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return ""
+	}
+	var defaults userModel.InstanceDefaults
+	if err := global.APP_DB.Where("user_id = ?", instance.UserID).First(&defaults).Error; err != nil {
+		return ""
+	}
+
+	var failures []string
+	if defaults.SSHPublicKey != "" {
+		if reason := installSSHKey(taskID, &instance, defaults.SSHPublicKey, "defaults", "默认设置中的SSH公钥"); reason != "" {
+			failures = append(failures, reason)
+		}
+	}
+	if defaults.Timezone != "" {
+		if reason := setInstanceTimezone(taskID, &instance, defaults.Timezone); reason != "" {
+			failures = append(failures, reason)
+		}
+	}
+	return strings.Join(failures, "，")
+}
+
+// setInstanceTimezone 通过SSH设置实例时区，返回失败原因
+func setInstanceTimezone(taskID uint, instance *providerModel.Instance, timezone string) string {
+	if constant.IsWindowsOSType(instance.OSType) {
+		appendTaskLog(taskID, "[defaults] Windows 实例不支持设置时区，已跳过\n")
+		return ""
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return "获取Provider信息失败，时区未设置"
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(instance, &provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[defaults] 无法连接实例设置时区: %v\n", err))
+		return fmt.Sprintf("无法连接实例设置时区: %v", err)
+	}
+	defer client.Close()
+	defer session.Close()
+	session.Stdin = strings.NewReader(buildTimezoneScript(timezone))
+
+	if output, err := session.CombinedOutput("sh -s"); err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[defaults] 设置时区 %s 失败: %v\n%s\n", timezone, err, strings.TrimSpace(string(output))))
+		return fmt.Sprintf("设置时区失败: %v", err)
+	}
+	appendTaskLog(taskID, fmt.Sprintf("[defaults] 已将时区设置为 %s\n", timezone))
+	return ""
+}
@@ -0,0 +1,236 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const defaultHardeningTimeoutSeconds = 600
+
+// hardeningSSHDConfig 加固时写入的sshd配置，不包含禁用密码登录
+var hardeningSSHDConfig = []string{
+	"PermitEmptyPasswords no",
+	"MaxAuthTries 3",
+	"LoginGraceTime 30",
+	"X11Forwarding no",
+	"ClientAliveInterval 300",
+	"ClientAliveCountMax 2",
+}
+
+// hardeningTimeout 返回加固脚本的执行超时
+func hardeningTimeout() time.Duration {
+	if timeout := global.APP_CONFIG.Hardening.Timeout; timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return defaultHardeningTimeoutSeconds * time.Second
+}
+
+// HardeningPolicyFor 返回指定用户等级生效的加固策略，等级未单独配置时使用全局策略
+func HardeningPolicyFor(level int) config.HardeningPolicy {
+	cfg := global.APP_CONFIG.Hardening
+	if policy, ok := cfg.Levels[level]; ok {
+		return policy
+	}
+	return cfg.HardeningPolicy
+}
+
+// buildHardeningScript 根据策略生成加固脚本，兼容apt、dnf/yum和apk系发行版
+// 每一项独立执行，单项失败只输出提示并继续，最后以失败项数量作为退出码
+func buildHardeningScript(policy config.HardeningPolicy) string {
+	var b strings.Builder
+	b.WriteString(`failed=0
+step() { echo "==> $1"; }
+fail() { echo "!! $1"; failed=$((failed+1)); }
+SUDO=""; [ "$(id -u)" -ne 0 ] && command -v sudo >/dev/null 2>&1 && SUDO="sudo -n"
+if command -v apt-get >/dev/null 2>&1; then PM=apt
+elif command -v dnf >/dev/null 2>&1; then PM=dnf
+elif command -v yum >/dev/null 2>&1; then PM=yum
+elif command -v apk >/dev/null 2>&1; then PM=apk
+else PM=""; fi
+pkg_install() {
+  case "$PM" in
+    apt) $SUDO env DEBIAN_FRONTEND=noninteractive apt-get update -qq >/dev/null 2>&1; $SUDO env DEBIAN_FRONTEND=noninteractive apt-get install -y -qq "$@" >/dev/null ;;
+    dnf|yum) $SUDO $PM install -y -q epel-release >/dev/null 2>&1; $SUDO $PM install -y -q "$@" >/dev/null ;;
+    apk) $SUDO apk add -q "$@" >/dev/null ;;
+    *) return 1 ;;
+  esac
+}
+svc_enable() {
+  if command -v systemctl >/dev/null 2>&1; then $SUDO systemctl enable --now "$1" >/dev/null 2>&1
+  elif command -v rc-update >/dev/null 2>&1; then $SUDO rc-update add "$1" default >/dev/null 2>&1 && $SUDO rc-service "$1" start >/dev/null 2>&1
+  else $SUDO service "$1" start >/dev/null 2>&1; fi
+}
+`)
+
+	if policy.Fail2ban {
+		b.WriteString(`step "fail2ban"
+if pkg_install fail2ban; then
+  if [ ! -f /etc/fail2ban/jail.local ]; then
+    printf '[DEFAULT]\nbantime = 1h\nfindtime = 10m\nmaxretry = 5\n\n[sshd]\nenabled = true\n' | $SUDO tee /etc/fail2ban/jail.local >/dev/null
+  fi
+  svc_enable fail2ban && echo "fail2ban 已启用" || fail "fail2ban 服务启动失败"
+else
+  fail "fail2ban 安装失败"
+fi
+`)
+	}
+
+	if policy.UnattendedUpgrades {
+		b.WriteString(`step "unattended-upgrades"
+case "$PM" in
+  apt)
+    if pkg_install unattended-upgrades; then
+      printf 'APT::Periodic::Update-Package-Lists "1";\nAPT::Periodic::Unattended-Upgrade "1";\n' | $SUDO tee /etc/apt/apt.conf.d/20auto-upgrades >/dev/null
+      svc_enable unattended-upgrades; echo "unattended-upgrades 已启用"
+    else fail "unattended-upgrades 安装失败"; fi ;;
+  dnf|yum)
+    if [ "$PM" = dnf ] && pkg_install dnf-automatic; then
+      $SUDO sed -i 's/^apply_updates.*/apply_updates = yes/; s/^upgrade_type.*/upgrade_type = security/' /etc/dnf/automatic.conf 2>/dev/null
+      svc_enable dnf-automatic.timer && echo "dnf-automatic 已启用" || fail "dnf-automatic 启用失败"
+    elif [ "$PM" = yum ] && pkg_install yum-cron; then
+      $SUDO sed -i 's/^apply_updates.*/apply_updates = yes/; s/^update_cmd.*/update_cmd = security/' /etc/yum/yum-cron.conf 2>/dev/null
+      svc_enable yum-cron && echo "yum-cron 已启用" || fail "yum-cron 启用失败"
+    else fail "自动更新组件安装失败"; fi ;;
+  *) echo "当前系统不支持自动安全更新，已跳过" ;;
+esac
+`)
+	}
+
+	if policy.SSHD || policy.DisablePasswordAuth {
+		var lines []string
+		if policy.SSHD {
+			lines = append(lines, hardeningSSHDConfig...)
+		}
+		conf := `""`
+		if len(lines) > 0 {
+			conf = utils.ShellQuote(strings.Join(lines, "\n") + "\n")
+		}
+		b.WriteString(`step "sshd"
+CONF=` + conf + `
+`)
+		if policy.DisablePasswordAuth {
+			// 仅在登录用户已有公钥时禁用密码登录，避免把实例锁死
+			b.WriteString(`if [ -s "$HOME/.ssh/authorized_keys" ]; then
+  CONF="${CONF}PasswordAuthentication no
+KbdInteractiveAuthentication no
+"
+else
+  echo "登录用户未配置SSH公钥，保留密码登录"
+fi
+`)
+		}
+		b.WriteString(`if [ -z "$CONF" ]; then
+  echo "无需修改sshd配置"
+elif [ ! -f /etc/ssh/sshd_config ]; then
+  fail "未找到sshd配置文件"
+else
+  if [ -d /etc/ssh/sshd_config.d ] && grep -qiE '^\s*Include\s+/etc/ssh/sshd_config\.d' /etc/ssh/sshd_config; then
+    TARGET=/etc/ssh/sshd_config.d/00-oneclickvirt-hardening.conf
+    printf '%s' "$CONF" | $SUDO tee "$TARGET" >/dev/null
+  else
+    TARGET=/etc/ssh/sshd_config
+    $SUDO cp "$TARGET" "$TARGET.oneclickvirt.bak"
+    printf '%s' "$CONF" | while read -r key _; do [ -n "$key" ] && $SUDO sed -i "/^[[:space:]]*$key[[:space:]]/d" "$TARGET"; done
+    printf '%s' "$CONF" | $SUDO tee -a "$TARGET" >/dev/null
+  fi
+  if $SUDO sshd -t 2>/dev/null || $SUDO /usr/sbin/sshd -t 2>/dev/null; then
+    ($SUDO systemctl reload sshd || $SUDO systemctl reload ssh || $SUDO rc-service sshd reload || $SUDO service ssh reload) >/dev/null 2>&1
+    echo "sshd 配置已收紧"
+  else
+    if [ "$TARGET" = /etc/ssh/sshd_config ]; then $SUDO mv "$TARGET.oneclickvirt.bak" "$TARGET"; else $SUDO rm -f "$TARGET"; fi
+    fail "sshd 配置校验失败，已回滚"
+  fi
+fi
+`)
+	}
+
+	b.WriteString(`exit $failed
+`)
+	return b.String()
+}
+
+// RunInstanceHardening 按实例所有者等级生效的加固策略在实例内执行加固脚本
+// 输出写入任务日志，返回失败原因；未启用加固时直接跳过
+func RunInstanceHardening(taskID, instanceID uint) string {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return ""
+	}
+	var user userModel.User
+	if err := global.APP_DB.Select("level").First(&user, instance.UserID).Error; err != nil {
+		return ""
+	}
+	policy := HardeningPolicyFor(user.Level)
+	if !policy.Enabled || !(policy.Fail2ban || policy.UnattendedUpgrades || policy.SSHD || policy.DisablePasswordAuth) {
+		return ""
+	}
+	if constant.IsWindowsOSType(instance.OSType) {
+		appendTaskLog(taskID, "[hardening] Windows 实例不支持自动加固，已跳过\n")
+		return ""
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return "获取Provider信息失败，安全加固未执行"
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(&instance, &provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[hardening] 无法连接实例: %v\n", err))
+		return fmt.Sprintf("无法连接实例执行安全加固: %v", err)
+	}
+	defer client.Close()
+	defer session.Close()
+	session.Stdin = strings.NewReader(buildHardeningScript(policy))
+
+	type hardeningResult struct {
+		output []byte
+		err    error
+	}
+	done := make(chan hardeningResult, 1)
+	go func() {
+		output, err := session.CombinedOutput("sh -s")
+		done <- hardeningResult{output: output, err: err}
+	}()
+
+	var result hardeningResult
+	select {
+	case result = <-done:
+	case <-time.After(hardeningTimeout()):
+		session.Close()
+		result = hardeningResult{err: fmt.Errorf("执行超时（%s）", hardeningTimeout())}
+	}
+
+	output := string(result.output)
+	if len(output) > maxHookLogBytes {
+		output = "...\n" + output[len(output)-maxHookLogBytes:]
+	}
+	status := "成功"
+	if result.err != nil {
+		status = fmt.Sprintf("部分失败: %v", result.err)
+	}
+	appendTaskLog(taskID, fmt.Sprintf("[hardening] ==== 安全加固 (%s) ====\n%s\n", status, strings.TrimRight(output, "\n")))
+
+	global.APP_LOG.Info("实例安全加固完成",
+		zap.Uint("taskId", taskID),
+		zap.Uint("instanceId", instanceID),
+		zap.Int("userLevel", user.Level),
+		zap.Bool("success", result.err == nil))
+
+	if result.err != nil {
+		return fmt.Sprintf("安全加固未全部完成: %v", result.err)
+	}
+	return ""
+}
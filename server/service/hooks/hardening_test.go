@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+)
+
+func TestHardeningPolicyFor(t *testing.T) {
+	saved := global.APP_CONFIG.Hardening
+	t.Cleanup(func() { global.APP_CONFIG.Hardening = saved })
+
+	global.APP_CONFIG.Hardening = config.Hardening{
+		HardeningPolicy: config.HardeningPolicy{Enabled: true, Fail2ban: true},
+		Levels: map[int]config.HardeningPolicy{
+			3: {Enabled: true, SSHD: true, DisablePasswordAuth: true},
+			5: {Enabled: false},
+		},
+	}
+
+	if got := HardeningPolicyFor(1); !got.Enabled || !got.Fail2ban || got.SSHD {
+		t.Errorf("level 1 should use global policy, got %+v", got)
+	}
+	if got := HardeningPolicyFor(3); got.Fail2ban || !got.SSHD || !got.DisablePasswordAuth {
+		t.Errorf("level 3 should use its override, got %+v", got)
+	}
+	if got := HardeningPolicyFor(5); got.Enabled {
+		t.Errorf("level 5 override should disable hardening, got %+v", got)
+	}
+}
+
+func TestBuildHardeningScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   config.HardeningPolicy
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "all steps",
+			policy:   config.HardeningPolicy{Fail2ban: true, UnattendedUpgrades: true, SSHD: true, DisablePasswordAuth: true},
+			contains: []string{`step "fail2ban"`, `step "unattended-upgrades"`, `step "sshd"`, "MaxAuthTries 3", "PasswordAuthentication no", "authorized_keys", "sshd -t"},
+		},
+		{
+			name:     "sshd without password change",
+			policy:   config.HardeningPolicy{SSHD: true},
+			contains: []string{"PermitEmptyPasswords no"},
+			absent:   []string{"PasswordAuthentication no", "fail2ban"},
+		},
+		{
+			name:     "only disable password auth",
+			policy:   config.HardeningPolicy{DisablePasswordAuth: true},
+			contains: []string{`CONF=""`, "PasswordAuthentication no"},
+			absent:   []string{"MaxAuthTries"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := buildHardeningScript(tt.policy)
+			for _, s := range tt.contains {
+				if !strings.Contains(script, s) {
+					t.Errorf("script missing %q", s)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(script, s) {
+					t.Errorf("script should not contain %q", s)
+				}
+			}
+			if !strings.HasSuffix(script, "exit $failed\n") {
+				t.Errorf("script should exit with the failure count")
+			}
+			// 有sh时校验脚本语法
+			if sh, err := exec.LookPath("sh"); err == nil {
+				cmd := exec.Command(sh, "-n")
+				cmd.Stdin = strings.NewReader(script)
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("script syntax error: %v\n%s", err, output)
+				}
+			}
+		})
+	}
+}
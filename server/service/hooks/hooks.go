@@ -0,0 +1,247 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxHooksPerUser 每个用户最多可注册的钩子数量
+	MaxHooksPerUser = 10
+
+	defaultMaxScriptSizeKB = 16
+	defaultTimeoutSeconds  = 300
+	// 每个钩子写入任务日志的最大输出长度
+	maxHookLogBytes = 8 * 1024
+)
+
+// MaxScriptSize 返回单个钩子脚本的最大字节数
+func MaxScriptSize() int {
+	if size := global.APP_CONFIG.Task.UserHookMaxSize; size > 0 {
+		return size * 1024
+	}
+	return defaultMaxScriptSizeKB * 1024
+}
+
+// hookTimeout 返回单个钩子脚本的执行超时
+func hookTimeout() time.Duration {
+	if timeout := global.APP_CONFIG.Task.UserHookTimeout; timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return defaultTimeoutSeconds * time.Second
+}
+
+// CheckUserAllowed 检查钩子功能是否开启以及用户等级是否满足要求
+func CheckUserAllowed(userID uint) error {
+	if !global.APP_CONFIG.Task.UserHooksEnabled {
+		return errors.New("钩子脚本功能未开启")
+	}
+	minLevel := global.APP_CONFIG.Task.UserHookMinLevel
+	if minLevel <= 0 {
+		return nil
+	}
+	var user userModel.User
+	if err := global.APP_DB.Select("level").First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Level < minLevel {
+		return fmt.Errorf("钩子脚本功能需要用户等级达到 %d", minLevel)
+	}
+	return nil
+}
+
+// RunInstanceHooks 在实例内依次执行用户订阅了指定事件的钩子脚本
+// 输出写入任务日志，返回执行失败的钩子说明；功能未开启或用户无权限时直接跳过
+func RunInstanceHooks(taskID, instanceID uint, event string) []string {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return nil
+	}
+	if CheckUserAllowed(instance.UserID) != nil {
+		return nil
+	}
+
+	var userHooks []userModel.UserHook
+	if err := global.APP_DB.Where("user_id = ? AND enabled = ?", instance.UserID, true).
+		Order("sort ASC, id ASC").Find(&userHooks).Error; err != nil || len(userHooks) == 0 {
+		return nil
+	}
+	var hooksToRun []userModel.UserHook
+	for _, hook := range userHooks {
+		if hook.HasEvent(event) {
+			hooksToRun = append(hooksToRun, hook)
+		}
+	}
+	if len(hooksToRun) == 0 {
+		return nil
+	}
+
+	if constant.IsWindowsOSType(instance.OSType) {
+		appendTaskLog(taskID, "[hooks] Windows 实例不支持钩子脚本，已跳过\n")
+		return []string{"Windows 实例不支持钩子脚本"}
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return []string{"获取Provider信息失败，钩子未执行"}
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(&instance, &provider)
+	client, initSession, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[hooks] 无法连接实例: %v\n", err))
+		return []string{fmt.Sprintf("无法连接实例执行钩子: %v", err)}
+	}
+	defer client.Close()
+	// 每个钩子使用独立会话执行
+	initSession.Close()
+
+	var failures []string
+	for _, hook := range hooksToRun {
+		session, err := client.NewSession()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("钩子 %s 创建会话失败: %v", hook.Name, err))
+			continue
+		}
+		session.Stdin = strings.NewReader(hook.Script)
+
+		type hookResult struct {
+			output []byte
+			err    error
+		}
+		done := make(chan hookResult, 1)
+		go func() {
+			output, err := session.CombinedOutput("sh -s")
+			done <- hookResult{output: output, err: err}
+		}()
+
+		var result hookResult
+		select {
+		case result = <-done:
+		case <-time.After(hookTimeout()):
+			session.Close()
+			result = hookResult{err: fmt.Errorf("执行超时（%s）", hookTimeout())}
+		}
+		session.Close()
+
+		output := string(result.output)
+		if len(output) > maxHookLogBytes {
+			output = "...\n" + output[len(output)-maxHookLogBytes:]
+		}
+		status := "成功"
+		if result.err != nil {
+			status = fmt.Sprintf("失败: %v", result.err)
+			failures = append(failures, fmt.Sprintf("钩子 %s 执行失败: %v", hook.Name, result.err))
+		}
+		appendTaskLog(taskID, fmt.Sprintf("[hooks] ==== %s (%s) ====\n%s\n", hook.Name, status, strings.TrimRight(output, "\n")))
+
+		global.APP_LOG.Info("用户钩子执行完成",
+			zap.Uint("taskId", taskID),
+			zap.Uint("instanceId", instanceID),
+			zap.Uint("hookId", hook.ID),
+			zap.String("event", event),
+			zap.Bool("success", result.err == nil))
+	}
+	return failures
+}
+
+// appendTaskLog 追加任务日志
+func appendTaskLog(taskID uint, text string) {
+	if err := global.APP_DB.Model(&adminModel.Task{}).Where("id = ?", taskID).
+		Update("log_output", gorm.Expr(utils.SQLAppendText(global.APP_DB, "log_output"), text)).Error; err != nil {
+		global.APP_LOG.Warn("写入任务日志失败", zap.Uint("taskId", taskID), zap.Error(err))
+	}
+}
+
+// InstallGroupSSHKey 将实例所在分组的默认SSH公钥写入实例登录用户的 authorized_keys
+// 实例未分组或分组未设置公钥时直接跳过，返回失败原因
+func InstallGroupSSHKey(taskID, instanceID uint) string {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil || instance.GroupID == 0 {
+		return ""
+	}
+	var group userModel.InstanceGroup
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instance.GroupID, instance.UserID).First(&group).Error; err != nil || group.SSHPublicKey == "" {
+		return ""
+	}
+	return installSSHKey(taskID, &instance, group.SSHPublicKey, "group", fmt.Sprintf("分组 %s 的SSH公钥", group.Name))
+}
+
+// installSSHKey 将SSH公钥写入实例登录用户的 authorized_keys，优先使用执行通道，失败时回退到SSH
+// tag 为任务日志前缀，what 描述写入的公钥，返回失败原因
+func installSSHKey(taskID uint, instance *providerModel.Instance, key, tag, what string) string {
+	if constant.IsWindowsOSType(instance.OSType) {
+		appendTaskLog(taskID, fmt.Sprintf("[%s] Windows 实例不支持写入SSH公钥，已跳过\n", tag))
+		return "Windows 实例不支持写入SSH公钥"
+	}
+
+	if installSSHKeyViaAgent(instance, key) {
+		appendTaskLog(taskID, fmt.Sprintf("[%s] 已通过执行通道写入%s\n", tag, what))
+		return ""
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return "获取Provider信息失败，SSH公钥未写入"
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(instance, &provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[%s] 无法连接实例写入SSH公钥: %v\n", tag, err))
+		return fmt.Sprintf("无法连接实例写入SSH公钥: %v", err)
+	}
+	defer client.Close()
+	defer session.Close()
+
+	quoted := utils.ShellQuote(key)
+	script := fmt.Sprintf("umask 077 && mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys && "+
+		"(grep -qxF %s ~/.ssh/authorized_keys || echo %s >> ~/.ssh/authorized_keys)", quoted, quoted)
+	if output, err := session.CombinedOutput(script); err != nil {
+		appendTaskLog(taskID, fmt.Sprintf("[%s] 写入%s失败: %v\n%s\n", tag, what, err, strings.TrimSpace(string(output))))
+		return fmt.Sprintf("写入SSH公钥失败: %v", err)
+	}
+	appendTaskLog(taskID, fmt.Sprintf("[%s] 已写入%s\n", tag, what))
+	return ""
+}
+
+// installSSHKeyViaAgent 通过LXD/Incus的执行通道写入SSH公钥，不依赖实例SSH可达，返回是否已写入
+// Provider不支持或虚拟机Agent未运行时返回false，由调用方回退到SSH方式
+func installSSHKeyViaAgent(instance *providerModel.Instance, key string) bool {
+	prov, ok := providerService.GetProviderService().GetProviderByID(instance.ProviderID)
+	if !ok {
+		return false
+	}
+	agent, ok := prov.(provider.VMAgentCapable)
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if !agent.VMAgentAvailable(ctx, instance.Name) {
+		return false
+	}
+	if err := agent.InjectInstanceSSHKeys(ctx, instance.Name, instance.Username, []string{key}); err != nil {
+		global.APP_LOG.Warn("通过执行通道写入SSH公钥失败，回退到SSH方式",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
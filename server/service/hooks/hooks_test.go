@@ -0,0 +1,74 @@
+package hooks
+
+import (
+	"testing"
+
+	"oneclickvirt/global"
+	userModel "oneclickvirt/model/user"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCheckUserAllowed(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&userModel.User{}); err != nil {
+		t.Fatal(err)
+	}
+	low := userModel.User{Username: "low", Level: 1}
+	high := userModel.User{Username: "high", Level: 3}
+	for _, u := range []*userModel.User{&low, &high} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldDB, oldConfig := global.APP_DB, global.APP_CONFIG
+	global.APP_DB = db
+	t.Cleanup(func() { global.APP_DB, global.APP_CONFIG = oldDB, oldConfig })
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		minLevel int
+		userID   uint
+		allowed  bool
+	}{
+		{name: "功能未开启", enabled: false, userID: high.ID},
+		{name: "不限等级", enabled: true, userID: low.ID, allowed: true},
+		{name: "等级不足", enabled: true, minLevel: 2, userID: low.ID},
+		{name: "等级满足", enabled: true, minLevel: 3, userID: high.ID, allowed: true},
+		{name: "用户不存在", enabled: true, minLevel: 1, userID: 999},
+	}
+	for _, tt := range tests {
+		global.APP_CONFIG.Task.UserHooksEnabled = tt.enabled
+		global.APP_CONFIG.Task.UserHookMinLevel = tt.minLevel
+		if err := CheckUserAllowed(tt.userID); (err == nil) != tt.allowed {
+			t.Errorf("%s: err = %v, allowed = %v", tt.name, err, tt.allowed)
+		}
+	}
+}
+
+func TestHookEventsAndLimits(t *testing.T) {
+	hook := userModel.UserHook{Events: "create, reset"}
+	if !hook.HasEvent(userModel.HookEventCreate) || !hook.HasEvent(userModel.HookEventReset) || hook.HasEvent("delete") {
+		t.Errorf("HasEvent 不正确: %q", hook.Events)
+	}
+	if (&userModel.UserHook{Events: "created"}).HasEvent(userModel.HookEventCreate) {
+		t.Error("事件应完整匹配")
+	}
+
+	oldConfig := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = oldConfig })
+	global.APP_CONFIG.Task.UserHookMaxSize = 0
+	if MaxScriptSize() != defaultMaxScriptSizeKB*1024 {
+		t.Errorf("默认脚本大小 = %d", MaxScriptSize())
+	}
+	global.APP_CONFIG.Task.UserHookMaxSize = 4
+	if MaxScriptSize() != 4096 {
+		t.Errorf("配置的脚本大小 = %d", MaxScriptSize())
+	}
+}
@@ -0,0 +1,241 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"text/template"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	motdHostsBegin = "# BEGIN oneclickvirt"
+	motdHostsEnd   = "# END oneclickvirt"
+)
+
+// defaultMOTDTemplate 未配置模板时使用的内置MOTD模板
+const defaultMOTDTemplate = `欢迎使用 {{.InstanceName}}
+{{if .PanelURL}}控制面板: {{.PanelURL}}
+{{end}}到期时间: {{.ExpiresAt}}
+流量配额: {{.TrafficQuota}}
+{{if .SupportContact}}技术支持: {{.SupportContact}}
+{{end}}`
+
+// MOTDData MOTD和hosts模板可引用的字段
+type MOTDData struct {
+	InstanceName   string
+	Username       string
+	OSType         string
+	PrivateIP      string
+	PublicIP       string
+	PublicIPv6     string
+	PanelURL       string
+	SupportContact string
+	ExpiresAt      string // 到期时间，未设置时为“永久”
+	TrafficQuota   string // 流量配额，实例未单独限制时使用用户等级配额，0表示不限
+}
+
+// motdDataFor 根据实例和所有者信息生成模板数据
+func motdDataFor(instance *providerModel.Instance, user *userModel.User) MOTDData {
+	cfg := global.APP_CONFIG.MOTD
+	data := MOTDData{
+		InstanceName:   instance.Name,
+		Username:       instance.Username,
+		OSType:         instance.OSType,
+		PrivateIP:      instance.PrivateIP,
+		PublicIP:       instance.PublicIP,
+		PublicIPv6:     instance.PublicIPv6,
+		PanelURL:       cfg.PanelURL,
+		SupportContact: cfg.SupportContact,
+		ExpiresAt:      "永久",
+		TrafficQuota:   "不限",
+	}
+	if instance.ExpiresAt != nil {
+		data.ExpiresAt = instance.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	quota := instance.MaxTraffic
+	if quota <= 0 && user != nil {
+		quota = user.TotalTraffic
+	}
+	if quota > 0 {
+		data.TrafficQuota = utils.FormatMB(float64(quota))
+	}
+	return data
+}
+
+// renderMOTD 渲染MOTD内容和hosts条目，hosts中渲染后不是“IP 主机名...”格式的行会被跳过
+func renderMOTD(tmpl string, hostTemplates []string, data MOTDData) (string, []string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = defaultMOTDTemplate
+	}
+	t, err := template.New("motd").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", nil, fmt.Errorf("解析MOTD模板失败: %w", err)
+	}
+	var motd bytes.Buffer
+	if err := t.Execute(&motd, data); err != nil {
+		return "", nil, fmt.Errorf("渲染MOTD模板失败: %w", err)
+	}
+
+	var hosts []string
+	for i, hostTmpl := range hostTemplates {
+		t, err := template.New(fmt.Sprintf("hosts%d", i)).Parse(hostTmpl)
+		if err != nil {
+			return "", nil, fmt.Errorf("解析hosts模板失败: %w", err)
+		}
+		var line bytes.Buffer
+		if err := t.Execute(&line, data); err != nil {
+			return "", nil, fmt.Errorf("渲染hosts模板失败: %w", err)
+		}
+		entry := line.String()
+		fields := strings.Fields(entry)
+		if strings.ContainsAny(entry, "\r\n") || len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			continue
+		}
+		hosts = append(hosts, strings.Join(fields, " "))
+	}
+
+	content := motd.String()
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content, hosts, nil
+}
+
+// buildMOTDScript 生成写入 /etc/motd 和 /etc/hosts 托管区块的脚本
+// hosts 为空时移除已有的托管区块；通过临时文件回写 /etc/hosts，兼容容器中以bind mount挂载的hosts文件
+func buildMOTDScript(motd string, hosts []string) string {
+	block := ""
+	if len(hosts) > 0 {
+		block = motdHostsBegin + "\n" + strings.Join(hosts, "\n") + "\n" + motdHostsEnd + "\n"
+	}
+	return fmt.Sprintf(`failed=0
+SUDO=""; [ "$(id -u)" -ne 0 ] && command -v sudo >/dev/null 2>&1 && SUDO="sudo -n"
+if printf '%%s' %s | $SUDO tee /etc/motd >/dev/null; then echo "/etc/motd 已更新"; else echo "!! 写入 /etc/motd 失败"; failed=1; fi
+TMP=$(mktemp) || exit 1
+if [ ! -f /etc/hosts ] || sed '/^%s$/,/^%s$/d' /etc/hosts > "$TMP"; then
+  printf '%%s' %s >> "$TMP"
+  if $SUDO tee /etc/hosts < "$TMP" >/dev/null; then echo "/etc/hosts 已更新"; else echo "!! 写入 /etc/hosts 失败"; failed=1; fi
+else
+  echo "!! 读取 /etc/hosts 失败"; failed=1
+fi
+rm -f "$TMP"
+exit $failed
+`, utils.ShellQuote(motd), motdHostsBegin, motdHostsEnd, utils.ShellQuote(block))
+}
+
+// ApplyInstanceMOTD 按配置模板在实例内写入MOTD和hosts条目，返回失败原因；未启用时直接跳过
+// taskID 为0时表示后台刷新，输出只记录到系统日志
+func ApplyInstanceMOTD(taskID, instanceID uint) string {
+	cfg := global.APP_CONFIG.MOTD
+	if !cfg.Enabled {
+		return ""
+	}
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return ""
+	}
+	if constant.IsWindowsOSType(instance.OSType) {
+		if taskID != 0 {
+			appendTaskLog(taskID, "[motd] Windows 实例不支持写入MOTD，已跳过\n")
+		}
+		return ""
+	}
+
+	var user userModel.User
+	if err := global.APP_DB.Select("id", "total_traffic").First(&user, instance.UserID).Error; err != nil {
+		global.APP_LOG.Debug("获取实例所有者失败，流量配额按实例限制显示", zap.Uint("instanceId", instanceID), zap.Error(err))
+	}
+	motd, hosts, err := renderMOTD(cfg.Template, cfg.Hosts, motdDataFor(&instance, &user))
+	if err != nil {
+		global.APP_LOG.Warn("渲染实例MOTD失败", zap.Uint("instanceId", instanceID), zap.Error(err))
+		return err.Error()
+	}
+
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return "获取Provider信息失败，MOTD未写入"
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(&instance, &provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		if taskID != 0 {
+			appendTaskLog(taskID, fmt.Sprintf("[motd] 无法连接实例写入MOTD: %v\n", err))
+		}
+		return fmt.Sprintf("无法连接实例写入MOTD: %v", err)
+	}
+	defer client.Close()
+	defer session.Close()
+	session.Stdin = strings.NewReader(buildMOTDScript(motd, hosts))
+
+	output, err := session.CombinedOutput("sh -s")
+	if taskID != 0 {
+		status := "成功"
+		if err != nil {
+			status = fmt.Sprintf("失败: %v", err)
+		}
+		appendTaskLog(taskID, fmt.Sprintf("[motd] ==== 写入MOTD和hosts (%s) ====\n%s\n", status, strings.TrimSpace(string(output))))
+	}
+	if err != nil {
+		global.APP_LOG.Warn("写入实例MOTD失败",
+			zap.Uint("instanceId", instanceID),
+			zap.String("output", utils.TruncateString(string(output), 500)),
+			zap.Error(err))
+		return fmt.Sprintf("写入MOTD失败: %v", err)
+	}
+	return ""
+}
+
+// RefreshInstanceMOTD 到期时间或流量配额变化后重新写入运行中实例的MOTD，应在goroutine中调用
+func RefreshInstanceMOTD(instanceIDs []uint) {
+	if !global.APP_CONFIG.MOTD.Enabled || len(instanceIDs) == 0 {
+		return
+	}
+	var ids []uint
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("id IN ? AND status = ?", instanceIDs, "running").Pluck("id", &ids).Error; err != nil {
+		global.APP_LOG.Warn("查询待刷新MOTD的实例失败", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		ApplyInstanceMOTD(0, id)
+	}
+}
+
+// RefreshUserMOTD 用户流量配额变化后刷新其运行中实例的MOTD，应在goroutine中调用
+func RefreshUserMOTD(userIDs []uint) {
+	if !global.APP_CONFIG.MOTD.Enabled || len(userIDs) == 0 {
+		return
+	}
+	var ids []uint
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("user_id IN ?", userIDs).Pluck("id", &ids).Error; err != nil {
+		global.APP_LOG.Warn("查询用户实例失败", zap.Error(err))
+		return
+	}
+	RefreshInstanceMOTD(ids)
+}
+
+// RefreshProviderMOTD Provider到期时间变化后刷新跟随节点到期时间的实例MOTD，应在goroutine中调用
+func RefreshProviderMOTD(providerID uint) {
+	if !global.APP_CONFIG.MOTD.Enabled {
+		return
+	}
+	var ids []uint
+	if err := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("provider_id = ? AND is_manual_expiry = ?", providerID, false).Pluck("id", &ids).Error; err != nil {
+		global.APP_LOG.Warn("查询Provider实例失败", zap.Uint("providerId", providerID), zap.Error(err))
+		return
+	}
+	RefreshInstanceMOTD(ids)
+}
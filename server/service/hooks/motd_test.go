@@ -0,0 +1,80 @@
+package hooks
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+)
+
+func TestMOTDDataFor(t *testing.T) {
+	saved := global.APP_CONFIG.MOTD
+	t.Cleanup(func() { global.APP_CONFIG.MOTD = saved })
+	global.APP_CONFIG.MOTD.PanelURL = "https://panel.example.com"
+
+	expires := time.Date(2026, 12, 31, 8, 0, 0, 0, time.Local)
+	instance := &providerModel.Instance{Name: "vm1", ExpiresAt: &expires}
+	user := &userModel.User{TotalTraffic: 102400}
+
+	data := motdDataFor(instance, user)
+	if data.ExpiresAt != "2026-12-31 08:00" || data.TrafficQuota != "100.00 GB" || data.PanelURL != "https://panel.example.com" {
+		t.Errorf("unexpected data %+v", data)
+	}
+
+	instance.MaxTraffic = 2048
+	instance.ExpiresAt = nil
+	if data := motdDataFor(instance, user); data.TrafficQuota != "2.00 GB" || data.ExpiresAt != "永久" {
+		t.Errorf("instance limit should take precedence, got %+v", data)
+	}
+	if data := motdDataFor(&providerModel.Instance{}, &userModel.User{}); data.TrafficQuota != "不限" {
+		t.Errorf("zero quota should be unlimited, got %q", data.TrafficQuota)
+	}
+}
+
+func TestRenderMOTD(t *testing.T) {
+	data := MOTDData{InstanceName: "vm1", PublicIP: "203.0.113.5", PrivateIP: "10.0.0.2", ExpiresAt: "永久", TrafficQuota: "不限"}
+
+	motd, hosts, err := renderMOTD("", []string{
+		"{{.PrivateIP}} {{.InstanceName}}",
+		"{{.PublicIPv6}} {{.InstanceName}}",
+		"not-an-ip {{.InstanceName}}",
+		"  {{.PublicIP}}   {{.InstanceName}}.local  ",
+	}, data)
+	if err != nil {
+		t.Fatalf("renderMOTD: %v", err)
+	}
+	if !strings.Contains(motd, "欢迎使用 vm1") || strings.Contains(motd, "控制面板") || !strings.HasSuffix(motd, "\n") {
+		t.Errorf("unexpected default motd %q", motd)
+	}
+	want := []string{"10.0.0.2 vm1", "203.0.113.5 vm1.local"}
+	if strings.Join(hosts, "|") != strings.Join(want, "|") {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+
+	if _, _, err := renderMOTD("{{.Unknown}}", nil, data); err == nil {
+		t.Error("unknown field should fail")
+	}
+}
+
+func TestBuildMOTDScript(t *testing.T) {
+	script := buildMOTDScript("it's $HOME\n", []string{"10.0.0.2 vm1"})
+	for _, want := range []string{`'it'\''s $HOME`, motdHostsBegin, "10.0.0.2 vm1", "/etc/hosts"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q", want)
+		}
+	}
+	if _, err := exec.LookPath("sh"); err == nil {
+		cmd := exec.Command("sh", "-n")
+		cmd.Stdin = strings.NewReader(script)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("script syntax error: %v\n%s", err, out)
+		}
+	}
+	if strings.Contains(buildMOTDScript("", nil), motdHostsBegin+"\n") {
+		t.Error("empty hosts should not write a managed block")
+	}
+}
@@ -0,0 +1,350 @@
+// Package hostcompat 检测Provider宿主机的内核与cgroup兼容性
+// 连接Provider时探测cgroup版本、内核版本、网卡和所需的内核功能，按Provider启用的功能（LXCFS、流量统计、NAT等）
+// 生成兼容性报告并保存；修改Provider配置时拒绝启用宿主机无法支持的功能
+package hostcompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+)
+
+// 依赖宿主机内核能力的功能
+const (
+	FeatureMemoryLimit     = "memory-limit"     // 容器内存限制
+	FeatureLXCFS           = "lxcfs"            // LXCFS资源视图
+	FeatureDiskIOLimit     = "disk-io-limit"    // 容器磁盘IO限制
+	FeatureNAT             = "nat"              // NAT网络
+	FeatureIptablesMapping = "iptables-mapping" // iptables端口映射
+	FeatureTrafficControl  = "traffic-control"  // 流量统计（pmacct抓包）
+	FeatureUplink          = "uplink"           // 公网网卡与附加上行网卡
+)
+
+// 内核模块状态
+const (
+	ModuleLoaded    = "loaded"    // 已加载或编译进内核
+	ModuleAvailable = "available" // 可加载但尚未加载
+	ModuleMissing   = "missing"   // 内核不支持
+	ModuleUnknown   = "unknown"   // 宿主机缺少modinfo，无法判断
+)
+
+// maxIssuesLength 不兼容项摘要的最大长度，与Provider.HostCompatIssues字段长度一致
+const maxIssuesLength = 1024
+
+// Script 在宿主机上执行的探测命令，每行输出 "<项> <值>"
+const Script = `echo "kernel $(uname -r)"
+if [ -f /sys/fs/cgroup/cgroup.controllers ]; then
+  echo "cgroup v2"
+  echo "controllers $(cat /sys/fs/cgroup/cgroup.controllers)"
+else
+  if [ -f /sys/fs/cgroup/unified/cgroup.controllers ]; then echo "cgroup hybrid"; else echo "cgroup v1"; fi
+  echo "controllers $(awk '!/^#/ && $4 == 1 {printf "%s ", $1}' /proc/cgroups 2>/dev/null)"
+fi
+for m in nf_nat br_netfilter; do
+  if grep -q "^$m " /proc/modules 2>/dev/null || grep -q "/$m.ko" "/lib/modules/$(uname -r)/modules.builtin" 2>/dev/null || { [ "$m" = br_netfilter ] && [ -d /proc/sys/net/bridge ]; }; then
+    echo "module $m loaded"
+  elif ! command -v modinfo >/dev/null 2>&1; then
+    echo "module $m unknown"
+  elif modinfo "$m" >/dev/null 2>&1; then
+    echo "module $m available"
+  else
+    echo "module $m missing"
+  fi
+done
+if [ -e /proc/net/packet ]; then echo "packet yes"; else echo "packet no"; fi
+if [ -d /var/lib/lxcfs/proc ] || [ -d /var/snap/lxd/common/var/lib/lxcfs/proc ] || pgrep -x lxcfs >/dev/null 2>&1; then echo "lxcfs yes"; else echo "lxcfs no"; fi
+if [ -c /dev/fuse ]; then echo "fuse yes"; else echo "fuse no"; fi
+if command -v iptables >/dev/null 2>&1; then echo "iptables yes"; else echo "iptables no"; fi
+echo "interfaces $(ls /sys/class/net 2>/dev/null | tr '\n' ' ')"
+`
+
+// Probe 宿主机探测结果
+type Probe struct {
+	KernelVersion string            `json:"kernelVersion"`
+	CgroupVersion string            `json:"cgroupVersion"` // v1, v2, hybrid
+	Controllers   []string          `json:"controllers"`   // 已启用的cgroup控制器
+	Modules       map[string]string `json:"modules"`       // 内核模块状态：loaded, available, missing, unknown
+	PacketSocket  bool              `json:"packetSocket"`  // 是否支持AF_PACKET抓包（CONFIG_PACKET）
+	LXCFS         bool              `json:"lxcfs"`         // lxcfs是否在运行
+	FUSE          bool              `json:"fuse"`          // 是否存在 /dev/fuse
+	Iptables      bool              `json:"iptables"`      // 是否安装了iptables
+	Interfaces    []string          `json:"interfaces"`    // 宿主机网卡列表，旧版本的探测结果为空
+}
+
+// Issue 一个不兼容项及处理方法
+type Issue struct {
+	Feature string `json:"feature"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+}
+
+// Report 兼容性报告，Probe为nil表示尚未检测
+type Report struct {
+	Probe     *Probe     `json:"probe"`
+	Issues    []Issue    `json:"issues"`
+	CheckedAt *time.Time `json:"checkedAt"`
+}
+
+// ParseProbe 解析Script的输出
+func ParseProbe(output string) (*Probe, error) {
+	probe := &Probe{Modules: make(map[string]string)}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "kernel":
+			probe.KernelVersion = value
+		case "cgroup":
+			probe.CgroupVersion = value
+		case "controllers":
+			probe.Controllers = strings.Fields(value)
+		case "module":
+			if name, state, ok := strings.Cut(value, " "); ok {
+				probe.Modules[name] = strings.TrimSpace(state)
+			}
+		case "packet":
+			probe.PacketSocket = value == "yes"
+		case "lxcfs":
+			probe.LXCFS = value == "yes"
+		case "fuse":
+			probe.FUSE = value == "yes"
+		case "iptables":
+			probe.Iptables = value == "yes"
+		case "interfaces":
+			probe.Interfaces = strings.Fields(value)
+		}
+	}
+	if probe.KernelVersion == "" || probe.CgroupVersion == "" {
+		return nil, fmt.Errorf("无法解析宿主机探测结果: %s", truncate(strings.TrimSpace(output), 200))
+	}
+	return probe, nil
+}
+
+// hasController 是否启用了任一指定的cgroup控制器
+func (p *Probe) hasController(names ...string) bool {
+	for _, c := range p.Controllers {
+		for _, name := range names {
+			if c == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasInterface 宿主机是否存在指定网卡
+func (p *Probe) hasInterface(name string) bool {
+	for _, iface := range p.Interfaces {
+		if iface == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runsContainers Provider是否会在宿主机上直接运行容器（依赖宿主机cgroup）
+func runsContainers(p *providerModel.Provider) bool {
+	switch p.Type {
+	case "docker", "lxc":
+		return true
+	case "lxd", "incus":
+		return p.ContainerEnabled
+	}
+	return false
+}
+
+// Evaluate 按Provider启用的功能检查宿主机是否满足要求，返回不兼容项
+func Evaluate(probe *Probe, p *providerModel.Provider) []Issue {
+	var issues []Issue
+	containers := runsContainers(p)
+
+	if containers && !probe.hasController("memory") {
+		issues = append(issues, Issue{
+			Feature: FeatureMemoryLimit,
+			Message: "宿主机cgroup未启用memory控制器，容器内存限制不会生效",
+			Fix:     "在内核启动参数中加入 cgroup_enable=memory cgroup_memory=1（GRUB_CMDLINE_LINUX 或树莓派的 /boot/cmdline.txt）后重启宿主机",
+		})
+	}
+
+	if containers && p.Type != "docker" && p.ContainerEnableLXCFS {
+		if !probe.FUSE {
+			issues = append(issues, Issue{
+				Feature: FeatureLXCFS,
+				Message: "宿主机缺少 /dev/fuse，无法使用LXCFS资源视图",
+				Fix:     "执行 modprobe fuse 并写入 /etc/modules-load.d/fuse.conf，或关闭LXCFS资源视图",
+			})
+		} else if !probe.LXCFS {
+			issues = append(issues, Issue{
+				Feature: FeatureLXCFS,
+				Message: "宿主机未运行lxcfs，容器内看到的仍是宿主机资源",
+				Fix:     "安装并启动lxcfs（apt install lxcfs && systemctl enable --now lxcfs），或关闭LXCFS资源视图",
+			})
+		}
+	}
+
+	if containers && p.ContainerDiskIOLimit != "" && !probe.hasController("io", "blkio") {
+		issues = append(issues, Issue{
+			Feature: FeatureDiskIOLimit,
+			Message: fmt.Sprintf("宿主机cgroup %s 未启用io/blkio控制器，磁盘IO限制不会生效", probe.CgroupVersion),
+			Fix:     "确认内核启用了 CONFIG_BLK_CGROUP 并挂载io控制器，或清空磁盘IO限制",
+		})
+	}
+
+	natMissing := probe.Modules["nf_nat"] == ModuleMissing
+	if strings.HasPrefix(p.NetworkType, "nat") && natMissing {
+		issues = append(issues, Issue{
+			Feature: FeatureNAT,
+			Message: "宿主机内核不支持NAT（缺少 CONFIG_NF_NAT），NAT网络的实例无法访问外网",
+			Fix:     "更换为包含netfilter NAT支持的内核（如发行版通用内核），或改用独立IP网络类型",
+		})
+	}
+
+	if p.IPv4PortMappingMethod == "iptables" || p.IPv6PortMappingMethod == "iptables" || p.Type == "docker" {
+		feature, target := FeatureIptablesMapping, "iptables端口映射"
+		if p.Type == "docker" {
+			target = "Docker桥接网络"
+		}
+		if !probe.Iptables && p.Type != "docker" {
+			issues = append(issues, Issue{
+				Feature: feature,
+				Message: "宿主机未安装iptables，无法使用" + target,
+				Fix:     "安装iptables（apt install iptables 或 dnf install iptables），或改用device_proxy端口映射",
+			})
+		}
+		if natMissing && !strings.HasPrefix(p.NetworkType, "nat") {
+			issues = append(issues, Issue{
+				Feature: feature,
+				Message: "宿主机内核不支持NAT（缺少 CONFIG_NF_NAT），无法使用" + target,
+				Fix:     "更换为包含netfilter NAT支持的内核，或改用device_proxy端口映射",
+			})
+		}
+		switch probe.Modules["br_netfilter"] {
+		case ModuleAvailable:
+			issues = append(issues, Issue{
+				Feature: feature,
+				Message: "宿主机未加载br_netfilter模块，" + target + "的桥接流量不经过iptables",
+				Fix:     "执行 modprobe br_netfilter && echo br_netfilter > /etc/modules-load.d/br_netfilter.conf 后重新连接Provider",
+			})
+		case ModuleMissing:
+			issues = append(issues, Issue{
+				Feature: feature,
+				Message: "宿主机内核不支持br_netfilter（缺少 CONFIG_BRIDGE_NETFILTER），无法使用" + target,
+				Fix:     "更换为启用 CONFIG_BRIDGE_NETFILTER 的内核，或改用device_proxy端口映射",
+			})
+		}
+	}
+
+	if len(probe.Interfaces) > 0 {
+		checked := make(map[string]bool)
+		check := func(iface, usage string) {
+			if iface == "" || checked[iface] || probe.hasInterface(iface) {
+				return
+			}
+			checked[iface] = true
+			issues = append(issues, Issue{
+				Feature: FeatureUplink,
+				Message: fmt.Sprintf("宿主机不存在%s %s", usage, iface),
+				Fix:     fmt.Sprintf("确认网卡名称（宿主机现有网卡：%s），或修改Provider的网卡配置", strings.Join(probe.Interfaces, " ")),
+			})
+		}
+		check(p.PublicInterface, "公网网卡")
+		for _, uplink := range p.GetUplinks() {
+			check(uplink.Interface, "上行网卡")
+		}
+	}
+
+	if p.EnableTrafficControl && !probe.PacketSocket {
+		issues = append(issues, Issue{
+			Feature: FeatureTrafficControl,
+			Message: "宿主机内核不支持AF_PACKET（缺少 CONFIG_PACKET），pmacct无法抓包统计流量",
+			Fix:     "更换为启用 CONFIG_PACKET 的内核，或关闭流量统计",
+		})
+	}
+	return issues
+}
+
+// Summary 返回不兼容项摘要，为空表示兼容
+func Summary(issues []Issue) string {
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return truncate(strings.Join(messages, "；"), maxIssuesLength)
+}
+
+// truncate 按字符截断，避免截断多字节字符
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// loadProbe 读取已保存的探测结果，尚未检测时返回nil
+func loadProbe(p *providerModel.Provider) *Probe {
+	if p.HostCompatProbe == "" {
+		return nil
+	}
+	var probe Probe
+	if err := json.Unmarshal([]byte(p.HostCompatProbe), &probe); err != nil {
+		return nil
+	}
+	return &probe
+}
+
+// ReportFor 按Provider当前配置评估已保存的探测结果
+func ReportFor(p *providerModel.Provider) *Report {
+	report := &Report{Probe: loadProbe(p), Issues: []Issue{}, CheckedAt: p.HostCompatCheckedAt}
+	if report.Probe != nil {
+		report.Issues = Evaluate(report.Probe, p)
+	}
+	return report
+}
+
+// ValidateChange 校验Provider配置变更，宿主机无法支持新启用的功能时返回包含处理方法的错误
+// 尚未检测过的Provider以及变更前已存在的不兼容项不做拦截；通过后同步更新不兼容项摘要
+func ValidateChange(old, updated *providerModel.Provider) error {
+	probe := loadProbe(updated)
+	if probe == nil {
+		return nil
+	}
+	existing := make(map[string]bool)
+	for _, issue := range Evaluate(probe, old) {
+		existing[issue.Feature+issue.Message] = true
+	}
+	issues := Evaluate(probe, updated)
+	var blocked []string
+	for _, issue := range issues {
+		if !existing[issue.Feature+issue.Message] {
+			blocked = append(blocked, fmt.Sprintf("%s（处理方法：%s）", issue.Message, issue.Fix))
+		}
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("宿主机不支持所选功能：%s", strings.Join(blocked, "；"))
+	}
+	updated.HostCompatIssues = Summary(issues)
+	return nil
+}
+
+// Save 保存探测结果和按当前配置评估的不兼容项
+func Save(p *providerModel.Provider, probe *Probe) ([]Issue, error) {
+	data, err := json.Marshal(probe)
+	if err != nil {
+		return nil, err
+	}
+	issues := Evaluate(probe, p)
+	err = global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", p.ID).
+		Updates(map[string]interface{}{
+			"host_kernel_version":    probe.KernelVersion,
+			"host_cgroup_version":    probe.CgroupVersion,
+			"host_compat_probe":      string(data),
+			"host_compat_issues":     Summary(issues),
+			"host_compat_checked_at": time.Now(),
+		}).Error
+	return issues, err
+}
@@ -0,0 +1,131 @@
+package hostcompat
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+)
+
+const probeOutput = `kernel 6.1.0-18-amd64
+cgroup v2
+controllers cpuset cpu io memory pids
+module nf_nat loaded
+module br_netfilter available
+packet yes
+lxcfs no
+fuse yes
+iptables yes
+interfaces lo eth0 vmbr0
+`
+
+func TestParseProbe(t *testing.T) {
+	probe, err := ParseProbe(probeOutput)
+	if err != nil {
+		t.Fatalf("ParseProbe: %v", err)
+	}
+	if probe.KernelVersion != "6.1.0-18-amd64" || probe.CgroupVersion != "v2" || !probe.hasController("memory") ||
+		probe.Modules["br_netfilter"] != ModuleAvailable || probe.LXCFS || !probe.FUSE || !probe.Iptables ||
+		!probe.hasInterface("vmbr0") {
+		t.Errorf("unexpected probe %+v", probe)
+	}
+	if _, err := ParseProbe("sh: uname: not found"); err == nil {
+		t.Error("incomplete output should fail")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	probe, _ := ParseProbe(probeOutput)
+	p := &providerModel.Provider{Type: "lxd", ContainerEnabled: true, NetworkType: "nat_ipv4", IPv4PortMappingMethod: "device_proxy"}
+	if issues := Evaluate(probe, p); len(issues) != 0 {
+		t.Errorf("default config should be compatible, got %+v", issues)
+	}
+
+	p.ContainerEnableLXCFS = true
+	p.IPv4PortMappingMethod = "iptables"
+	issues := Evaluate(probe, p)
+	features := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		features = append(features, issue.Feature)
+		if issue.Fix == "" {
+			t.Errorf("issue %q should have a fix", issue.Message)
+		}
+	}
+	if strings.Join(features, ",") != FeatureLXCFS+","+FeatureIptablesMapping {
+		t.Errorf("features = %v", features)
+	}
+
+	// cgroup v1 未启用memory和blkio，内核缺少NAT
+	v1 := &Probe{CgroupVersion: "v1", Controllers: []string{"cpu", "cpuacct"}, Modules: map[string]string{"nf_nat": ModuleMissing}}
+	p = &providerModel.Provider{Type: "docker", NetworkType: "nat_ipv4", ContainerDiskIOLimit: "10MB", EnableTrafficControl: true}
+	features = features[:0]
+	for _, issue := range Evaluate(v1, p) {
+		features = append(features, issue.Feature)
+	}
+	want := []string{FeatureMemoryLimit, FeatureDiskIOLimit, FeatureNAT, FeatureTrafficControl}
+	if strings.Join(features, ",") != strings.Join(want, ",") {
+		t.Errorf("features = %v, want %v", features, want)
+	}
+
+	// 配置的公网网卡和上行网卡必须存在，旧版本探测结果没有网卡列表时不检查
+	p = &providerModel.Provider{Type: "proxmox", NetworkType: "dedicated_ipv4", PublicInterface: "vmbr0",
+		Uplinks: `[{"interface":"eth1","ipv4Pool":"203.0.113.0/27"}]`}
+	issues = Evaluate(probe, p)
+	if len(issues) != 1 || issues[0].Feature != FeatureUplink || !strings.Contains(issues[0].Message, "eth1") {
+		t.Errorf("missing uplink should be reported, got %+v", issues)
+	}
+	if issues := Evaluate(&Probe{CgroupVersion: "v2"}, p); len(issues) != 0 {
+		t.Errorf("probe without interfaces should skip the check, got %+v", issues)
+	}
+
+	// 虚拟机Provider不依赖宿主机cgroup
+	if issues := Evaluate(v1, &providerModel.Provider{Type: "proxmox", NetworkType: "dedicated_ipv4"}); len(issues) != 0 {
+		t.Errorf("proxmox with dedicated ip should be compatible, got %+v", issues)
+	}
+}
+
+func TestValidateChange(t *testing.T) {
+	probe, _ := ParseProbe(probeOutput)
+	data, _ := json.Marshal(probe)
+	old := providerModel.Provider{Type: "lxd", ContainerEnabled: true, NetworkType: "nat_ipv4", HostCompatProbe: string(data)}
+
+	updated := old
+	updated.ContainerEnableLXCFS = true
+	if err := ValidateChange(&old, &updated); err == nil || !strings.Contains(err.Error(), "lxcfs") {
+		t.Errorf("enabling lxcfs should be blocked with a fix, got %v", err)
+	}
+
+	// 变更前已存在的不兼容项不拦截其他修改
+	old.ContainerEnableLXCFS = true
+	updated.MaxContainerInstances = 10
+	if err := ValidateChange(&old, &updated); err != nil {
+		t.Errorf("existing issue should not block, got %v", err)
+	}
+	if updated.HostCompatIssues == "" {
+		t.Error("issues summary should be refreshed")
+	}
+
+	unchecked := providerModel.Provider{Type: "lxd", ContainerEnabled: true, ContainerEnableLXCFS: true}
+	if err := ValidateChange(&providerModel.Provider{}, &unchecked); err != nil {
+		t.Errorf("unchecked provider should not be blocked, got %v", err)
+	}
+}
+
+func TestScript(t *testing.T) {
+	if err := utils.NewCommandGuard("test", "block", nil, nil).Check(Script); err != nil {
+		t.Errorf("script should pass the default command guard: %v", err)
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out, err := exec.Command("sh", "-c", Script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	if _, err := ParseProbe(string(out)); err != nil {
+		t.Errorf("script output should parse: %v", err)
+	}
+}
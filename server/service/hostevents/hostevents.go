@@ -0,0 +1,418 @@
+// Package hostevents 宿主机日志事件采集
+// 随Provider健康检查按间隔通过SSH增量读取宿主机内核日志和虚拟化服务日志，识别OOM、磁盘错误和服务错误，
+// 尽量关联到受影响的实例后保存，供管理员排查宿主机上不易察觉的问题
+package hostevents
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultIntervalMinutes = 5
+	defaultMaxLines        = 2000
+	defaultRetentionDays   = 30
+	// 首次采集时回溯的时间，以及两次采集间隔过长时最多回溯的时间
+	initialLookback = time.Hour
+	maxLookback     = 24 * time.Hour
+	// 两次采集之间的重叠时间，重复读取的日志按指纹去重
+	cursorOverlap = time.Minute
+	// 每次采集最多保存的事件数，日志刷屏时只保留最近的事件
+	maxEventsPerRun = 200
+)
+
+var allSources = []string{monitoringModel.HostEventSourceKernel, monitoringModel.HostEventSourceDaemon}
+
+// 各类型Provider的虚拟化服务
+var daemonUnits = map[string][]string{
+	"lxd":        {"snap.lxd.daemon", "lxd"},
+	"incus":      {"incus"},
+	"docker":     {"docker"},
+	"proxmox":    {"pvedaemon", "pveproxy", "pvestatd", "qmeventd"},
+	"lxc":        {"lxc", "lxc-net"},
+	"kubernetes": {"kubelet", "k3s", "containerd"},
+}
+
+var (
+	// journalctl -o short-unix：1700000000.123456 host kernel: message
+	shortUnixPattern = regexp.MustCompile(`^(\d{9,})(?:\.(\d{1,9}))?\s+\S+\s+([^:\[\s]+)(?:\[\d+\])?:\s?(.*)$`)
+	// dmesg --time-format iso：2026-10-15T10:00:00,123456+08:00 message
+	dmesgISOPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})(?:[,.](\d{1,9}))?([+-]\d{2}:?\d{2}|Z)\s+(.*)$`)
+
+	oomKillPattern   = regexp.MustCompile(`oom-kill:.*task_memcg=([^,\s]+)`)
+	oomKilledPattern = regexp.MustCompile(`(Memory cgroup out of memory|Out of memory): Killed process (\d+) \(([^)]+)\)`)
+	diskErrorPattern = regexp.MustCompile(`I/O error|EXT4-fs error|XFS \(\S+\): .*(?:error|[Cc]orrupt)|BTRFS (?:error|critical)|critical medium error|Medium Error|` +
+		`ata\d+(?:\.\d+)?: (?:failed command|exception Emask|hard resetting link)|nvme\d+\S*: .*(?:timeout|I/O|resetting controller|failed)|Remounting filesystem read-only`)
+
+	// 按顺序尝试，从cgroup路径中提取实例
+	memcgSubjectPatterns = []struct {
+		pattern *regexp.Regexp
+		format  string
+	}{
+		{regexp.MustCompile(`lxc\.payload\.([^/,\s]+)`), "%s"},
+		{regexp.MustCompile(`/lxc/(\d+)`), "CT %s"},
+		{regexp.MustCompile(`qemu\.slice/(\d+)\.scope`), "VM %s"},
+		{regexp.MustCompile(`docker[-/]([0-9a-f]{12})`), "%s"},
+	}
+	diskSubjectPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\(device ([\w.-]+)\)`),
+		regexp.MustCompile(`\bdev(?:ice)? ([\w.-]+)`),
+		regexp.MustCompile(`^XFS \(([\w.-]+)\)`),
+		regexp.MustCompile(`^(ata\d+(?:\.\d+)?|nvme\d+(?:n\d+)?)`),
+	}
+	daemonSubjectPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\binstance="?([\w.-]+)`),
+		regexp.MustCompile(`\bcontainer="?([\w.-]+)`),
+		regexp.MustCompile(`\b((?:VM|CT) \d+)\b`),
+	}
+)
+
+// Interval 返回采集间隔
+func Interval() time.Duration {
+	if minutes := global.APP_CONFIG.HostEvents.Interval; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultIntervalMinutes * time.Minute
+}
+
+func maxLines() int {
+	if n := global.APP_CONFIG.HostEvents.MaxLines; n > 0 {
+		return n
+	}
+	return defaultMaxLines
+}
+
+func retention() time.Duration {
+	days := global.APP_CONFIG.HostEvents.RetentionDays
+	if days <= 0 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ParseSources 解析逗号分隔的日志来源，去重并校验，空字符串表示不采集
+func ParseSources(value string) ([]string, error) {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		valid := false
+		for _, source := range allSources {
+			if item == source {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("不支持的宿主机日志来源: %s，可选值为 %s", item, strings.Join(allSources, ", "))
+		}
+		seen[item] = true
+		sources = append(sources, item)
+	}
+	return sources, nil
+}
+
+// NormalizeSources 校验日志来源并返回规范化后的逗号分隔字符串
+func NormalizeSources(value string) (string, error) {
+	sources, err := ParseSources(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(sources, ","), nil
+}
+
+// Since 返回本次采集的起始时间
+func Since(checkedAt *time.Time, now time.Time) time.Time {
+	if checkedAt == nil {
+		return now.Add(-initialLookback)
+	}
+	since := checkedAt.Add(-cursorOverlap)
+	if since.Before(now.Add(-maxLookback)) {
+		since = now.Add(-maxLookback)
+	}
+	return since
+}
+
+// BuildScript 生成在宿主机上执行的读取命令，每个来源以 "@@<来源>" 开头输出一段日志
+func BuildScript(sources []string, providerType string, since time.Time) string {
+	lines := maxLines()
+	var b strings.Builder
+	for _, source := range sources {
+		b.WriteString("echo '@@" + source + "'\n")
+		switch source {
+		case monitoringModel.HostEventSourceKernel:
+			fmt.Fprintf(&b, `if command -v journalctl >/dev/null 2>&1 && [ -n "$(journalctl -k -q -n 1 --no-pager 2>/dev/null)" ]; then
+  journalctl -k -q --no-pager -o short-unix --since @%d 2>/dev/null | tail -n %d
+elif dmesg --time-format iso >/dev/null 2>&1; then
+  dmesg --time-format iso 2>/dev/null | tail -n %d
+else echo unavailable; fi
+`, since.Unix(), lines, lines)
+		case monitoringModel.HostEventSourceDaemon:
+			units := daemonUnits[providerType]
+			if len(units) == 0 {
+				b.WriteString("echo unavailable\n")
+				continue
+			}
+			var unitArgs strings.Builder
+			for _, unit := range units {
+				unitArgs.WriteString(" -u " + utils.ShellQuote(unit))
+			}
+			fmt.Fprintf(&b, `if command -v journalctl >/dev/null 2>&1; then
+  journalctl -q --no-pager -o short-unix -p err --since @%d%s 2>/dev/null | tail -n %d
+else echo unavailable; fi
+`, since.Unix(), unitArgs.String(), lines)
+		}
+	}
+	return b.String()
+}
+
+// Parse 解析读取命令的输出，返回 since 之后的事件和宿主机上无法读取的来源
+func Parse(output string, since time.Time) (events []monitoringModel.HostEvent, unavailable []string) {
+	section := ""
+	pendingMemcg := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "@@") {
+			section = strings.TrimPrefix(line, "@@")
+			pendingMemcg = ""
+			continue
+		}
+		if section == "" || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "unavailable" {
+			unavailable = append(unavailable, section)
+			continue
+		}
+
+		occurredAt, ident, message, ok := parseLine(line)
+		if !ok || occurredAt.Before(since) {
+			continue
+		}
+		switch section {
+		case monitoringModel.HostEventSourceKernel:
+			if m := oomKillPattern.FindStringSubmatch(message); m != nil {
+				pendingMemcg = m[1]
+				continue
+			}
+			if event, ok := classifyKernel(message, pendingMemcg); ok {
+				if event.Category == monitoringModel.HostEventCategoryOOM {
+					pendingMemcg = ""
+				}
+				event.OccurredAt = occurredAt
+				events = append(events, event)
+			}
+		case monitoringModel.HostEventSourceDaemon:
+			events = append(events, monitoringModel.HostEvent{
+				Source:     monitoringModel.HostEventSourceDaemon,
+				Category:   monitoringModel.HostEventCategoryDaemon,
+				Severity:   monitoringModel.HostEventSeverityError,
+				Subject:    firstSubmatch(daemonSubjectPatterns, message, ident),
+				Message:    utils.TruncateString(ident+": "+message, 1024),
+				OccurredAt: occurredAt,
+			})
+		}
+	}
+	if len(events) > maxEventsPerRun {
+		events = events[len(events)-maxEventsPerRun:]
+	}
+	return events, unavailable
+}
+
+// classifyKernel 识别内核日志中的OOM和磁盘错误
+func classifyKernel(message, memcg string) (monitoringModel.HostEvent, bool) {
+	event := monitoringModel.HostEvent{
+		Source:  monitoringModel.HostEventSourceKernel,
+		Message: utils.TruncateString(message, 1024),
+	}
+	if m := oomKilledPattern.FindStringSubmatch(message); m != nil {
+		event.Category = monitoringModel.HostEventCategoryOOM
+		// 实例触发自身的内存限制属于预期行为，宿主机整体内存不足更严重
+		event.Severity = monitoringModel.HostEventSeverityWarning
+		if m[1] == "Out of memory" {
+			event.Severity = monitoringModel.HostEventSeverityCritical
+		}
+		event.Subject = m[3]
+		for _, p := range memcgSubjectPatterns {
+			if sm := p.pattern.FindStringSubmatch(memcg); sm != nil {
+				event.Subject = fmt.Sprintf(p.format, sm[1])
+				break
+			}
+		}
+		return event, true
+	}
+	if diskErrorPattern.MatchString(message) {
+		event.Category = monitoringModel.HostEventCategoryDisk
+		event.Severity = monitoringModel.HostEventSeverityCritical
+		event.Subject = firstSubmatch(diskSubjectPatterns, message, "")
+		return event, true
+	}
+	return event, false
+}
+
+// parseLine 解析 journalctl short-unix 或 dmesg iso 格式的一行日志
+func parseLine(line string) (time.Time, string, string, bool) {
+	if m := shortUnixPattern.FindStringSubmatch(line); m != nil {
+		sec, _ := strconv.ParseInt(m[1], 10, 64)
+		return time.Unix(sec, fractionNanos(m[2])), m[3], m[4], true
+	}
+	if m := dmesgISOPattern.FindStringSubmatch(line); m != nil {
+		offset := m[3]
+		if offset != "Z" && !strings.Contains(offset, ":") {
+			offset = offset[:3] + ":" + offset[3:]
+		}
+		t, err := time.Parse(time.RFC3339, m[1]+offset)
+		if err != nil {
+			return time.Time{}, "", "", false
+		}
+		return t.Add(time.Duration(fractionNanos(m[2]))), "kernel", m[4], true
+	}
+	return time.Time{}, "", "", false
+}
+
+// fractionNanos 将小数部分的数字转换为纳秒
+func fractionNanos(fraction string) int64 {
+	if fraction == "" {
+		return 0
+	}
+	fraction = (fraction + "000000000")[:9]
+	n, _ := strconv.ParseInt(fraction, 10, 64)
+	return n
+}
+
+func firstSubmatch(patterns []*regexp.Regexp, s, fallback string) string {
+	for _, p := range patterns {
+		if m := p.FindStringSubmatch(s); m != nil {
+			return utils.TruncateString(m[1], 128)
+		}
+	}
+	return utils.TruncateString(fallback, 128)
+}
+
+// Save 关联实例并保存事件，已保存过的日志按指纹跳过，返回新增的事件
+func Save(provider *providerModel.Provider, events []monitoringModel.HostEvent) ([]monitoringModel.HostEvent, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	attachInstances(provider.ID, events)
+	for i := range events {
+		events[i].ProviderID = provider.ID
+		events[i].Fingerprint = fingerprint(provider.ID, &events[i])
+	}
+
+	var fingerprints []string
+	for _, e := range events {
+		fingerprints = append(fingerprints, e.Fingerprint)
+	}
+	var existing []string
+	if err := global.APP_DB.Model(&monitoringModel.HostEvent{}).
+		Where("fingerprint IN ?", fingerprints).Pluck("fingerprint", &existing).Error; err != nil {
+		return nil, fmt.Errorf("查询已保存的宿主机事件失败: %w", err)
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, fp := range existing {
+		seen[fp] = true
+	}
+	var added []monitoringModel.HostEvent
+	for _, e := range events {
+		if !seen[e.Fingerprint] {
+			seen[e.Fingerprint] = true
+			added = append(added, e)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if err := global.APP_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&added).Error; err != nil {
+		return nil, fmt.Errorf("保存宿主机事件失败: %w", err)
+	}
+	return added, nil
+}
+
+// attachInstances 按实例名关联事件涉及的实例
+func attachInstances(providerID uint, events []monitoringModel.HostEvent) {
+	var names []string
+	for _, e := range events {
+		if e.Subject != "" {
+			names = append(names, e.Subject)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id", "name").
+		Where("provider_id = ? AND name IN ?", providerID, names).Find(&instances).Error; err != nil {
+		return
+	}
+	ids := make(map[string]uint, len(instances))
+	for _, inst := range instances {
+		ids[inst.Name] = inst.ID
+	}
+	for i := range events {
+		if id, ok := ids[events[i].Subject]; ok {
+			events[i].InstanceID = &id
+		}
+	}
+}
+
+func fingerprint(providerID uint, e *monitoringModel.HostEvent) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%d|%s", providerID, e.Source, e.OccurredAt.UnixNano(), e.Message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// List 按条件分页查询事件，按时间倒序
+func List(req monitoringModel.HostEventListRequest) ([]monitoringModel.HostEvent, int64, error) {
+	query := global.APP_DB.Model(&monitoringModel.HostEvent{})
+	if req.ProviderID != 0 {
+		query = query.Where("provider_id = ?", req.ProviderID)
+	}
+	if req.InstanceID != 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+	if req.Severity != "" {
+		query = query.Where("severity = ?", req.Severity)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计宿主机事件数量失败: %w", err)
+	}
+	var events []monitoringModel.HostEvent
+	if err := utils.ApplyListPage(query.Order("occurred_at DESC, id DESC"), req.PageInfo).Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询宿主机事件失败: %w", err)
+	}
+	return events, total, nil
+}
+
+// CountSince 统计Provider在指定时间之后的事件数
+func CountSince(providerID uint, since time.Time) int64 {
+	var count int64
+	global.APP_DB.Model(&monitoringModel.HostEvent{}).
+		Where("provider_id = ? AND occurred_at >= ?", providerID, since).Count(&count)
+	return count
+}
+
+// CleanupExpired 删除超过保留天数的事件，返回删除的数量
+func CleanupExpired() (int64, error) {
+	result := global.APP_DB.Where("occurred_at < ?", time.Now().Add(-retention())).Delete(&monitoringModel.HostEvent{})
+	return result.RowsAffected, result.Error
+}
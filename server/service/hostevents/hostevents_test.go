@@ -0,0 +1,104 @@
+package hostevents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/utils"
+)
+
+func TestParseSources(t *testing.T) {
+	got, err := NormalizeSources(" Kernel, daemon,kernel ")
+	if err != nil || got != "kernel,daemon" {
+		t.Fatalf("NormalizeSources = %q, %v", got, err)
+	}
+	if got, err := NormalizeSources(""); err != nil || got != "" {
+		t.Fatalf("空字符串应表示不采集: %q, %v", got, err)
+	}
+	if _, err := NormalizeSources("kernel,syslog"); err == nil {
+		t.Fatal("不支持的来源应该被拒绝")
+	}
+}
+
+func TestSince(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	if got := Since(nil, now); !got.Equal(now.Add(-initialLookback)) {
+		t.Errorf("首次采集起始时间 = %v", got)
+	}
+	checked := now.Add(-5 * time.Minute)
+	if got := Since(&checked, now); !got.Equal(checked.Add(-cursorOverlap)) {
+		t.Errorf("增量采集起始时间 = %v", got)
+	}
+	old := now.Add(-72 * time.Hour)
+	if got := Since(&old, now); !got.Equal(now.Add(-maxLookback)) {
+		t.Errorf("回溯时间应有上限 = %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	output := strings.Join([]string{
+		"@@kernel",
+		"1699999000.000001 node1 kernel: I/O error, dev sdb, sector 1234 op 0x0:(READ)",
+		"1700000001.500000 node1 kernel: oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,oom_memcg=/lxc.payload.web1,task_memcg=/lxc.payload.web1/system.slice/app.service,task=node,pid=4242,uid=1000000",
+		"1700000001.600000 node1 kernel: Memory cgroup out of memory: Killed process 4242 (node) total-vm:1000kB, anon-rss:900kB",
+		"1700000002.000000 node1 kernel: Out of memory: Killed process 99 (qemu-system-x86) total-vm:8000000kB",
+		"1700000003.000000 node1 kernel: EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0",
+		"1700000004.000000 node1 kernel: eth0: link up",
+		"2023-11-14T22:13:25,000000+0000 blk_update_request: I/O error, dev nvme0n1, sector 42",
+		"@@daemon",
+		`1700000005.000000 node1 lxd[812]: time="2023-11-14T22:13:25Z" level=error msg="Failed to start" instance=db2 project=default`,
+		"@@zpool",
+		"unavailable",
+	}, "\n")
+
+	events, unavailable := Parse(output, time.Unix(1700000000, 0))
+	if len(unavailable) != 1 || unavailable[0] != "zpool" {
+		t.Errorf("unavailable = %v", unavailable)
+	}
+	if len(events) != 5 {
+		for _, e := range events {
+			t.Logf("%+v", e)
+		}
+		t.Fatalf("事件数量 = %d, want 5", len(events))
+	}
+
+	oom := events[0]
+	if oom.Category != monitoringModel.HostEventCategoryOOM || oom.Severity != monitoringModel.HostEventSeverityWarning || oom.Subject != "web1" {
+		t.Errorf("cgroup OOM 解析错误: %+v", oom)
+	}
+	if !oom.OccurredAt.Equal(time.Unix(1700000001, 600000000)) {
+		t.Errorf("时间解析错误: %v", oom.OccurredAt)
+	}
+	hostOOM := events[1]
+	if hostOOM.Severity != monitoringModel.HostEventSeverityCritical || hostOOM.Subject != "qemu-system-x86" {
+		t.Errorf("宿主机 OOM 解析错误: %+v", hostOOM)
+	}
+	if events[2].Category != monitoringModel.HostEventCategoryDisk || events[2].Subject != "sda1" {
+		t.Errorf("文件系统错误解析错误: %+v", events[2])
+	}
+	if events[3].Subject != "nvme0n1" || !events[3].OccurredAt.Equal(time.Date(2023, 11, 14, 22, 13, 25, 0, time.UTC)) {
+		t.Errorf("dmesg 格式解析错误: %+v", events[3])
+	}
+	daemon := events[4]
+	if daemon.Category != monitoringModel.HostEventCategoryDaemon || daemon.Subject != "db2" || !strings.HasPrefix(daemon.Message, "lxd: ") {
+		t.Errorf("服务日志解析错误: %+v", daemon)
+	}
+}
+
+func TestBuildScript(t *testing.T) {
+	since := time.Unix(1700000000, 0)
+	script := BuildScript([]string{"kernel", "daemon"}, "incus", since)
+	for _, want := range []string{"@@kernel", "journalctl -k", "--since @1700000000", "dmesg --time-format iso", "@@daemon", "-u 'incus'"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("脚本缺少 %q:\n%s", want, script)
+		}
+	}
+	if violations, err := utils.NewCommandGuard("test", "block", nil, nil).Violations(script); err != nil || len(violations) > 0 {
+		t.Errorf("脚本应通过默认命令白名单: %v %v", violations, err)
+	}
+	if script := BuildScript([]string{"daemon"}, "unknown", since); !strings.Contains(script, "echo unavailable") {
+		t.Errorf("未知类型的服务日志应标记为不可用:\n%s", script)
+	}
+}
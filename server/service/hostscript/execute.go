@@ -0,0 +1,281 @@
+package hostscript
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/task"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// exitMarker 脚本结束后输出退出码的标记，SSH执行失败时输出会丢失，因此命令本身总是成功退出
+	exitMarker = "__OCV_SCRIPT_EXIT__="
+	// timeoutExitCode coreutils timeout 超时退出码
+	timeoutExitCode = 124
+
+	defaultMaxOutputKB = 64
+)
+
+var exitMarkerPattern = regexp.MustCompile(`(?m)^` + exitMarker + `(\d+)\s*$`)
+
+func init() {
+	task.MustRegisterTaskType(adminModel.TaskTypeHostScript, task.TaskHandler{
+		Execute:           execute,
+		Validate:          validateTaskData,
+		DefaultTimeout:    defaultTimeout + 60,
+		EstimatedDuration: 60,
+	})
+}
+
+// maxOutputBytes 返回保存的输出上限
+func maxOutputBytes() int {
+	if kb := global.APP_CONFIG.HostScript.MaxOutputKB; kb > 0 {
+		return kb * 1024
+	}
+	return defaultMaxOutputKB * 1024
+}
+
+func validateTaskData(taskData string) error {
+	var data adminModel.HostScriptTaskData
+	if err := json.Unmarshal([]byte(taskData), &data); err != nil {
+		return err
+	}
+	if data.RunID == 0 {
+		return errors.New("缺少执行记录ID")
+	}
+	return nil
+}
+
+// startRun 为执行记录创建并启动任务，任务超时比脚本超时多留出连接和清理时间
+func startRun(run *adminModel.HostScriptRun) error {
+	var script adminModel.HostScript
+	if err := global.APP_DB.Unscoped().Select("id, timeout").First(&script, run.ScriptID).Error; err != nil {
+		return errors.New("脚本不存在")
+	}
+	taskData, err := json.Marshal(adminModel.HostScriptTaskData{RunID: run.ID})
+	if err != nil {
+		return fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	taskService := task.GetTaskService()
+	providerID := run.ProviderID
+	created, err := taskService.CreateTask(run.RequestedBy, &providerID, nil, adminModel.TaskTypeHostScript, string(taskData), scriptTimeout(script.Timeout)+60)
+	if err != nil {
+		markFailed(run.ID, fmt.Sprintf("创建任务失败: %v", err))
+		return fmt.Errorf("创建脚本执行任务失败: %v", err)
+	}
+	if err := global.APP_DB.Model(&adminModel.HostScriptRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":  adminModel.HostScriptRunQueued,
+		"task_id": created.ID,
+	}).Error; err != nil {
+		return fmt.Errorf("更新执行记录失败: %v", err)
+	}
+	run.Status = adminModel.HostScriptRunQueued
+	run.TaskID = &created.ID
+	if err := taskService.StartTask(created.ID); err != nil {
+		return fmt.Errorf("启动脚本执行任务失败: %v", err)
+	}
+	return nil
+}
+
+// scriptTimeout 返回限制在上限内的执行超时（秒）
+func scriptTimeout(timeout int) int {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if timeout > maxTimeout() {
+		timeout = maxTimeout()
+	}
+	return timeout
+}
+
+// execute 任务执行函数：在宿主机上执行记录的脚本版本，保存输出和退出码
+func execute(ctx context.Context, t *adminModel.Task, progress task.ProgressFunc) error {
+	var data adminModel.HostScriptTaskData
+	if err := json.Unmarshal([]byte(t.TaskData), &data); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	var run adminModel.HostScriptRun
+	if err := global.APP_DB.First(&run, data.RunID).Error; err != nil {
+		return fmt.Errorf("执行记录不存在")
+	}
+	if run.Status != adminModel.HostScriptRunQueued {
+		return fmt.Errorf("执行记录状态为 %s，不能执行", run.Status)
+	}
+	var script adminModel.HostScript
+	if err := global.APP_DB.Unscoped().Select("id, timeout").First(&script, run.ScriptID).Error; err != nil {
+		markFailed(run.ID, "脚本不存在")
+		return fmt.Errorf("脚本不存在")
+	}
+	version, err := GetVersion(run.ScriptID, run.Version)
+	if err != nil {
+		markFailed(run.ID, err.Error())
+		return err
+	}
+	var params map[string]string
+	if run.Params != "" {
+		if err := json.Unmarshal([]byte(run.Params), &params); err != nil {
+			markFailed(run.ID, "解析执行参数失败")
+			return fmt.Errorf("解析执行参数失败: %v", err)
+		}
+	}
+
+	progress(10, "正在连接宿主机...")
+	providerApiService := &provider2.ProviderApiService{}
+	providerInstance, dbProvider, err := providerApiService.GetProviderByID(run.ProviderID)
+	if err != nil {
+		markFailed(run.ID, fmt.Sprintf("获取Provider实例失败: %v", err))
+		return fmt.Errorf("获取Provider实例失败: %v", err)
+	}
+	if err := checkCommandGuard(dbProvider, version.Content); err != nil {
+		markFailed(run.ID, err.Error())
+		return err
+	}
+
+	startedAt := time.Now()
+	global.APP_DB.Model(&adminModel.HostScriptRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":     adminModel.HostScriptRunRunning,
+		"started_at": startedAt,
+	})
+	timeout := scriptTimeout(script.Timeout)
+	progress(20, fmt.Sprintf("正在执行脚本 %s（第 %d 版）...", run.ScriptName, run.Version))
+
+	output, execErr := providerInstance.ExecuteSSHCommand(ctx, buildCommand(version.Content, params, timeout))
+	output, exitCode, exited := parseExitCode(output)
+	output = tailOutput(output, maxOutputBytes())
+
+	status := adminModel.HostScriptRunSucceeded
+	var errMsg string
+	switch {
+	case execErr != nil:
+		errMsg = fmt.Sprintf("执行失败: %v", execErr)
+	case !exited:
+		errMsg = "未获取到脚本退出码，连接可能已中断"
+	case exitCode == timeoutExitCode:
+		errMsg = fmt.Sprintf("执行超时（%d秒）", timeout)
+	case exitCode != 0:
+		errMsg = fmt.Sprintf("脚本退出码 %d", exitCode)
+	}
+	if errMsg != "" {
+		status = adminModel.HostScriptRunFailed
+	}
+
+	finishedAt := time.Now()
+	if err := global.APP_DB.Model(&adminModel.HostScriptRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":      status,
+		"output":      output,
+		"error":       utils.TruncateString(errMsg, 1024),
+		"finished_at": finishedAt,
+	}).Error; err != nil {
+		global.APP_LOG.Warn("保存脚本执行结果失败", zap.Uint("runId", run.ID), zap.Error(err))
+	}
+	// 输出同时写入任务日志，任务详情中可直接查看
+	global.APP_DB.Model(&adminModel.Task{}).Where("id = ?", t.ID).
+		Update("log_output", gorm.Expr(utils.SQLAppendText(global.APP_DB, "log_output"), output))
+
+	global.APP_LOG.Info("宿主机脚本执行完成",
+		zap.Uint("runId", run.ID),
+		zap.Uint("scriptId", run.ScriptID),
+		zap.Int("version", run.Version),
+		zap.Uint("providerId", run.ProviderID),
+		zap.String("status", status),
+		zap.Duration("duration", finishedAt.Sub(startedAt)))
+
+	if errMsg != "" {
+		return errors.New(errMsg)
+	}
+	progress(100, "脚本执行完成")
+	return nil
+}
+
+// reconcileRuns 任务在开始执行前被取消或超时时执行函数不会运行，按任务状态把记录标记为失败
+func reconcileRuns(runs []adminModel.HostScriptRun) {
+	for i := range runs {
+		run := &runs[i]
+		if run.TaskID == nil || (run.Status != adminModel.HostScriptRunQueued && run.Status != adminModel.HostScriptRunRunning) {
+			continue
+		}
+		var t adminModel.Task
+		if err := global.APP_DB.Select("id, status, error_message").First(&t, *run.TaskID).Error; err != nil {
+			continue
+		}
+		switch t.Status {
+		case "cancelled", "failed", "timeout":
+		default:
+			continue
+		}
+		reason := "任务已" + map[string]string{"cancelled": "取消", "failed": "失败", "timeout": "超时"}[t.Status]
+		if t.ErrorMessage != "" {
+			reason += ": " + t.ErrorMessage
+		}
+		markFailed(run.ID, reason)
+		run.Status = adminModel.HostScriptRunFailed
+		run.Error = utils.TruncateString(reason, 1024)
+	}
+}
+
+// markFailed 将未执行的记录标记为失败
+func markFailed(runID uint, reason string) {
+	now := time.Now()
+	global.APP_DB.Model(&adminModel.HostScriptRun{}).Where("id = ?", runID).Updates(map[string]interface{}{
+		"status":      adminModel.HostScriptRunFailed,
+		"error":       utils.TruncateString(reason, 1024),
+		"finished_at": now,
+	})
+}
+
+// buildCommand 生成在宿主机上执行脚本的命令
+// 脚本以base64传输后从标准输入交给 sh 执行，参数以环境变量传入，timeout 限制执行时间，最后输出退出码标记
+func buildCommand(content string, params map[string]string, timeout int) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "printf '%%s' %s | base64 -d | env", utils.ShellQuote(base64.StdEncoding.EncodeToString([]byte(content))))
+	for _, name := range names {
+		fmt.Fprintf(&b, " %s=%s", name, utils.ShellQuote(params[name]))
+	}
+	fmt.Fprintf(&b, " timeout -k 10 %d sh -s 2>&1; rc=$?; echo; echo \"%s$rc\"", timeout, exitMarker)
+	return b.String()
+}
+
+// parseExitCode 从输出中取出退出码标记，返回去掉标记的输出
+func parseExitCode(output string) (string, int, bool) {
+	matches := exitMarkerPattern.FindAllStringSubmatchIndex(output, -1)
+	if len(matches) == 0 {
+		return output, 0, false
+	}
+	last := matches[len(matches)-1]
+	code, err := strconv.Atoi(output[last[2]:last[3]])
+	if err != nil {
+		return output, 0, false
+	}
+	// 去掉标记及其前面补充的空行
+	return strings.TrimRight(output[:last[0]], "\n"), code, true
+}
+
+// tailOutput 输出超出上限时只保留末尾
+func tailOutput(output string, limit int) string {
+	if len(output) <= limit {
+		return output
+	}
+	return "...\n" + output[len(output)-limit:]
+}
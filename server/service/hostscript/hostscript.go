@@ -0,0 +1,628 @@
+// Package hostscript 宿主机脚本库
+// 管理员维护版本化的脚本，通过任务系统带参数在Provider宿主机上执行并保存输出，
+// 需要审批的脚本由其他管理员批准后才会执行，执行记录作为宿主机操作的审计记录长期保留
+package hostscript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxScriptSize 脚本内容的最大字节数
+	maxScriptSize = 64 * 1024
+	// maxParams 每个脚本最多定义的参数数量
+	maxParams = 20
+	// maxParamValueLength 参数值的最大长度
+	maxParamValueLength = 1024
+
+	defaultTimeout    = 300
+	defaultMaxTimeout = 3600
+)
+
+// paramNamePattern 参数名以环境变量传给脚本，只允许大写字母、数字和下划线
+var paramNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// reservedParamNames 会改变脚本执行环境的变量名，不能作为参数名
+var reservedParamNames = map[string]bool{
+	"PATH": true, "HOME": true, "SHELL": true, "USER": true, "IFS": true, "ENV": true,
+	"BASH_ENV": true, "LD_PRELOAD": true, "LD_LIBRARY_PATH": true, "PS4": true,
+}
+
+// maxTimeout 返回脚本执行超时上限（秒）
+func maxTimeout() int {
+	if t := global.APP_CONFIG.HostScript.MaxTimeout; t > 0 {
+		return t
+	}
+	return defaultMaxTimeout
+}
+
+// List 分页查询脚本，指定Provider时只返回允许在该Provider上执行的脚本
+func List(req adminModel.HostScriptListRequest) ([]adminModel.HostScript, int64, error) {
+	query := global.APP_DB.Model(&adminModel.HostScript{})
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+
+	var scripts []adminModel.HostScript
+	if req.ProviderID != 0 {
+		// 允许的Provider以逗号分隔保存，在内存中过滤
+		if err := query.Order("id DESC").Find(&scripts).Error; err != nil {
+			return nil, 0, fmt.Errorf("查询脚本失败: %v", err)
+		}
+		filtered := scripts[:0]
+		for _, script := range scripts {
+			if script.AllowsProvider(req.ProviderID) {
+				filtered = append(filtered, script)
+			}
+		}
+		total := int64(len(filtered))
+		start, end := req.Offset(), req.Offset()+req.PageSize
+		if start > len(filtered) {
+			start = len(filtered)
+		}
+		if end > len(filtered) {
+			end = len(filtered)
+		}
+		return filtered[start:end], total, nil
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计脚本数量失败: %v", err)
+	}
+	if err := utils.ApplyListPage(query.Order("id DESC"), req.PageInfo).Find(&scripts).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询脚本失败: %v", err)
+	}
+	return scripts, total, nil
+}
+
+// Get 返回脚本及其当前版本
+func Get(id uint) (*adminModel.HostScriptDetail, error) {
+	var detail adminModel.HostScriptDetail
+	if err := global.APP_DB.First(&detail.HostScript, id).Error; err != nil {
+		return nil, errors.New("脚本不存在")
+	}
+	version, err := GetVersion(id, detail.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	detail.Current = version
+	return &detail, nil
+}
+
+// Create 创建脚本，内容保存为第1版
+func Create(operatorID uint, req adminModel.SaveHostScriptRequest) (*adminModel.HostScriptDetail, error) {
+	if err := validateScript(&req); err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(req.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("序列化参数定义失败: %v", err)
+	}
+
+	script := adminModel.HostScript{
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		ProviderIDs:     joinIDs(req.ProviderIDs),
+		RequireApproval: req.RequireApproval == nil || *req.RequireApproval,
+		Timeout:         req.Timeout,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		CurrentVersion:  1,
+		CreatedBy:       operatorID,
+		UpdatedBy:       operatorID,
+	}
+	err = global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		// 布尔字段带有默认值，零值需要显式写入
+		if err := tx.Select("*").Omit("id", "deleted_at").Create(&script).Error; err != nil {
+			return err
+		}
+		return tx.Create(&adminModel.HostScriptVersion{
+			ScriptID:   script.ID,
+			Version:    1,
+			Content:    req.Content,
+			Parameters: string(params),
+			Comment:    req.Comment,
+			CreatedBy:  operatorID,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存脚本失败: %v", err)
+	}
+
+	global.APP_LOG.Info("创建宿主机脚本",
+		zap.Uint("scriptId", script.ID),
+		zap.String("name", script.Name),
+		zap.Uint("operatorId", operatorID))
+	return Get(script.ID)
+}
+
+// Update 修改脚本，内容或参数定义变化时保存为新版本，已发起的执行仍使用发起时的版本
+func Update(id, operatorID uint, req adminModel.SaveHostScriptRequest) (*adminModel.HostScriptDetail, error) {
+	if err := validateScript(&req); err != nil {
+		return nil, err
+	}
+	current, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(req.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("序列化参数定义失败: %v", err)
+	}
+
+	updates := map[string]interface{}{
+		"name":         strings.TrimSpace(req.Name),
+		"description":  req.Description,
+		"provider_ids": joinIDs(req.ProviderIDs),
+		"timeout":      req.Timeout,
+		"updated_by":   operatorID,
+	}
+	if req.RequireApproval != nil {
+		updates["require_approval"] = *req.RequireApproval
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	changed := req.Content != current.Current.Content || string(params) != current.Current.Parameters
+	err = global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		if changed {
+			next := current.CurrentVersion + 1
+			if err := tx.Create(&adminModel.HostScriptVersion{
+				ScriptID:   id,
+				Version:    next,
+				Content:    req.Content,
+				Parameters: string(params),
+				Comment:    req.Comment,
+				CreatedBy:  operatorID,
+			}).Error; err != nil {
+				return err
+			}
+			updates["current_version"] = next
+		}
+		return tx.Model(&adminModel.HostScript{}).Where("id = ?", id).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存脚本失败: %v", err)
+	}
+
+	if changed {
+		global.APP_LOG.Info("宿主机脚本已更新为新版本",
+			zap.Uint("scriptId", id),
+			zap.Int("version", current.CurrentVersion+1),
+			zap.Uint("operatorId", operatorID))
+	}
+	return Get(id)
+}
+
+// Delete 删除脚本，版本和执行记录保留；等待审批的执行申请一并拒绝
+func Delete(id, operatorID uint) error {
+	result := global.APP_DB.Delete(&adminModel.HostScript{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除脚本失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("脚本不存在")
+	}
+	now := time.Now()
+	global.APP_DB.Model(&adminModel.HostScriptRun{}).
+		Where("script_id = ? AND status = ?", id, adminModel.HostScriptRunPending).
+		Updates(map[string]interface{}{
+			"status":      adminModel.HostScriptRunRejected,
+			"reviewed_by": operatorID,
+			"reviewed_at": now,
+			"review_note": "脚本已删除",
+		})
+	global.APP_LOG.Info("删除宿主机脚本", zap.Uint("scriptId", id), zap.Uint("operatorId", operatorID))
+	return nil
+}
+
+// ListVersions 返回脚本的全部版本，按版本号倒序
+func ListVersions(id uint) ([]adminModel.HostScriptVersion, error) {
+	var script adminModel.HostScript
+	if err := global.APP_DB.Unscoped().Select("id").First(&script, id).Error; err != nil {
+		return nil, errors.New("脚本不存在")
+	}
+	var versions []adminModel.HostScriptVersion
+	if err := global.APP_DB.Where("script_id = ?", id).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("查询脚本版本失败: %v", err)
+	}
+	return versions, nil
+}
+
+// GetVersion 返回脚本的指定版本，已删除脚本的版本仍可查看
+func GetVersion(id uint, version int) (*adminModel.HostScriptVersion, error) {
+	var v adminModel.HostScriptVersion
+	if err := global.APP_DB.Where("script_id = ? AND version = ?", id, version).First(&v).Error; err != nil {
+		return nil, errors.New("脚本版本不存在")
+	}
+	return &v, nil
+}
+
+// validateScript 校验并规范化脚本的保存请求
+func validateScript(req *adminModel.SaveHostScriptRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("脚本名称不能为空")
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return errors.New("脚本内容不能为空")
+	}
+	if len(req.Content) > maxScriptSize {
+		return fmt.Errorf("脚本内容不能超过 %d KB", maxScriptSize/1024)
+	}
+	if req.Timeout <= 0 {
+		req.Timeout = defaultTimeout
+	}
+	if req.Timeout > maxTimeout() {
+		return fmt.Errorf("执行超时不能超过 %d 秒", maxTimeout())
+	}
+	return validateParamDefs(req.Parameters)
+}
+
+// validateParamDefs 校验参数定义：名称合法且不重复，正则可编译，默认值满足正则
+func validateParamDefs(defs []adminModel.HostScriptParam) error {
+	if len(defs) > maxParams {
+		return fmt.Errorf("参数不能超过 %d 个", maxParams)
+	}
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if !paramNamePattern.MatchString(def.Name) {
+			return fmt.Errorf("参数名 %q 无效，需以大写字母开头，只含大写字母、数字和下划线，最长32个字符", def.Name)
+		}
+		if reservedParamNames[def.Name] {
+			return fmt.Errorf("参数名 %s 为保留的环境变量名", def.Name)
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("参数名 %s 重复", def.Name)
+		}
+		seen[def.Name] = true
+		if def.Pattern != "" {
+			re, err := compilePattern(def.Pattern)
+			if err != nil {
+				return fmt.Errorf("参数 %s 的正则表达式无效: %v", def.Name, err)
+			}
+			if def.Default != "" && !re.MatchString(def.Default) {
+				return fmt.Errorf("参数 %s 的默认值不满足正则表达式", def.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// compilePattern 编译参数取值正则，要求完整匹配
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// resolveParams 按参数定义校验传入的参数并补全默认值，不允许未定义的参数
+func resolveParams(defs []adminModel.HostScriptParam, values map[string]string) (map[string]string, error) {
+	defined := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		defined[def.Name] = true
+	}
+	for name := range values {
+		if !defined[name] {
+			return nil, fmt.Errorf("脚本没有定义参数 %s", name)
+		}
+	}
+
+	resolved := make(map[string]string, len(defs))
+	for _, def := range defs {
+		value, ok := values[def.Name]
+		if !ok || value == "" {
+			value = def.Default
+		}
+		if value == "" {
+			if def.Required {
+				return nil, fmt.Errorf("缺少必填参数 %s", def.Name)
+			}
+			continue
+		}
+		if len(value) > maxParamValueLength {
+			return nil, fmt.Errorf("参数 %s 的值不能超过 %d 个字符", def.Name, maxParamValueLength)
+		}
+		if strings.ContainsRune(value, 0) {
+			return nil, fmt.Errorf("参数 %s 的值包含非法字符", def.Name)
+		}
+		if def.Pattern != "" {
+			re, err := compilePattern(def.Pattern)
+			if err != nil || !re.MatchString(value) {
+				return nil, fmt.Errorf("参数 %s 的值不满足格式要求", def.Name)
+			}
+		}
+		resolved[def.Name] = value
+	}
+	return resolved, nil
+}
+
+// checkCommandGuard 按Provider的命令白名单检查脚本内容
+// 脚本以base64传到宿主机后交给 sh 执行，连接层的白名单只能看到 base64 和 sh，因此在发起、审批和执行前检查脚本本身
+func checkCommandGuard(prov *providerModel.Provider, content string) error {
+	if err := utils.ProviderCommandGuard(prov).Check(content); err != nil {
+		return fmt.Errorf("Provider %s: %v", prov.Name, err)
+	}
+	return nil
+}
+
+// joinIDs 将Provider ID去重排序后以逗号连接
+func joinIDs(ids []uint) string {
+	seen := make(map[uint]bool, len(ids))
+	sorted := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			sorted = append(sorted, int(id))
+		}
+	}
+	sort.Ints(sorted)
+	parts := make([]string, 0, len(sorted))
+	for _, id := range sorted {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// RequestRun 发起脚本执行，每个Provider生成一条执行记录
+// 需要审批时记录为等待审批，否则立即创建任务；canManage 用于校验子管理员的Provider范围
+func RequestRun(scriptID, operatorID uint, canManage func(uint) bool, req adminModel.RunHostScriptRequest) ([]adminModel.HostScriptRun, error) {
+	var script adminModel.HostScript
+	if err := global.APP_DB.First(&script, scriptID).Error; err != nil {
+		return nil, errors.New("脚本不存在")
+	}
+	if !script.Enabled {
+		return nil, errors.New("脚本已停用")
+	}
+	versionNumber := req.Version
+	if versionNumber == 0 {
+		versionNumber = script.CurrentVersion
+	}
+	version, err := GetVersion(scriptID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+	params, err := resolveParams(version.GetParameters(), req.Params)
+	if err != nil {
+		return nil, err
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("序列化参数失败: %v", err)
+	}
+
+	seen := make(map[uint]bool, len(req.ProviderIDs))
+	var providers []providerModel.Provider
+	for _, providerID := range req.ProviderIDs {
+		if seen[providerID] {
+			continue
+		}
+		seen[providerID] = true
+		if !canManage(providerID) {
+			return nil, fmt.Errorf("Provider %d 不在您的管理范围内", providerID)
+		}
+		if !script.AllowsProvider(providerID) {
+			return nil, fmt.Errorf("脚本不允许在Provider %d 上执行", providerID)
+		}
+		var prov providerModel.Provider
+		if err := global.APP_DB.First(&prov, providerID).Error; err != nil {
+			return nil, fmt.Errorf("Provider %d 不存在", providerID)
+		}
+		if prov.IsFrozen {
+			return nil, fmt.Errorf("Provider %s 已冻结", prov.Name)
+		}
+		if err := checkCommandGuard(&prov, version.Content); err != nil {
+			return nil, err
+		}
+		providers = append(providers, prov)
+	}
+
+	needApproval := script.RequireApproval || global.APP_CONFIG.HostScript.RequireApproval
+	runs := make([]adminModel.HostScriptRun, 0, len(providers))
+	for _, prov := range providers {
+		run := adminModel.HostScriptRun{
+			ScriptID:    script.ID,
+			ScriptName:  script.Name,
+			Version:     version.Version,
+			ProviderID:  prov.ID,
+			Params:      string(paramsJSON),
+			Reason:      req.Reason,
+			Status:      adminModel.HostScriptRunPending,
+			RequestedBy: operatorID,
+		}
+		if err := global.APP_DB.Create(&run).Error; err != nil {
+			return runs, fmt.Errorf("保存执行记录失败: %v", err)
+		}
+		if !needApproval {
+			if err := startRun(&run); err != nil {
+				return runs, err
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	global.APP_LOG.Info("发起宿主机脚本执行",
+		zap.Uint("scriptId", script.ID),
+		zap.Int("version", version.Version),
+		zap.Int("providers", len(runs)),
+		zap.Bool("needApproval", needApproval),
+		zap.Uint("operatorId", operatorID))
+	return runs, nil
+}
+
+// Approve 批准执行申请并创建任务，默认不能批准自己发起的申请
+func Approve(id, operatorID uint, note string) (*adminModel.HostScriptRun, error) {
+	run, err := reviewable(id, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	var script adminModel.HostScript
+	if err := global.APP_DB.Select("id, enabled").First(&script, run.ScriptID).Error; err != nil {
+		return nil, errors.New("脚本已删除")
+	}
+	if !script.Enabled {
+		return nil, errors.New("脚本已停用")
+	}
+	// 审批时按Provider当前的命令白名单重新检查
+	version, err := GetVersion(run.ScriptID, run.Version)
+	if err != nil {
+		return nil, err
+	}
+	var prov providerModel.Provider
+	if err := global.APP_DB.First(&prov, run.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("Provider %d 不存在", run.ProviderID)
+	}
+	if err := checkCommandGuard(&prov, version.Content); err != nil {
+		return nil, err
+	}
+	if err := review(run, operatorID, note, adminModel.HostScriptRunQueued); err != nil {
+		return nil, err
+	}
+	if err := startRun(run); err != nil {
+		return nil, err
+	}
+	global.APP_LOG.Info("批准宿主机脚本执行", zap.Uint("runId", id), zap.Uint("operatorId", operatorID))
+	return run, nil
+}
+
+// Reject 拒绝执行申请
+func Reject(id, operatorID uint, note string) (*adminModel.HostScriptRun, error) {
+	run, err := reviewable(id, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := review(run, operatorID, note, adminModel.HostScriptRunRejected); err != nil {
+		return nil, err
+	}
+	global.APP_LOG.Info("拒绝宿主机脚本执行", zap.Uint("runId", id), zap.Uint("operatorId", operatorID))
+	return run, nil
+}
+
+// reviewable 返回可由该管理员审批的执行申请
+func reviewable(id, operatorID uint) (*adminModel.HostScriptRun, error) {
+	var run adminModel.HostScriptRun
+	if err := global.APP_DB.First(&run, id).Error; err != nil {
+		return nil, errors.New("执行记录不存在")
+	}
+	if run.Status != adminModel.HostScriptRunPending {
+		return nil, errors.New("该执行申请不在等待审批状态")
+	}
+	if run.RequestedBy == operatorID && !global.APP_CONFIG.HostScript.AllowSelfApproval {
+		return nil, errors.New("不能审批自己发起的执行申请")
+	}
+	return &run, nil
+}
+
+// review 写入审批结果，以状态为条件更新，避免重复审批
+func review(run *adminModel.HostScriptRun, operatorID uint, note, status string) error {
+	now := time.Now()
+	result := global.APP_DB.Model(&adminModel.HostScriptRun{}).
+		Where("id = ? AND status = ?", run.ID, adminModel.HostScriptRunPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": operatorID,
+			"reviewed_at": now,
+			"review_note": note,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新执行记录失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("该执行申请已被处理")
+	}
+	run.Status = status
+	run.ReviewedBy = operatorID
+	run.ReviewedAt = &now
+	run.ReviewNote = note
+	return nil
+}
+
+// ListRuns 分页查询执行记录，scope 非空时只返回这些Provider的记录
+func ListRuns(req adminModel.HostScriptRunListRequest, scope []uint) ([]adminModel.HostScriptRun, int64, error) {
+	query := global.APP_DB.Model(&adminModel.HostScriptRun{})
+	if req.ScriptID != 0 {
+		query = query.Where("script_id = ?", req.ScriptID)
+	}
+	if req.ProviderID != 0 {
+		query = query.Where("provider_id = ?", req.ProviderID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+	if len(scope) > 0 {
+		query = query.Where("provider_id IN ?", scope)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计执行记录失败: %v", err)
+	}
+	var runs []adminModel.HostScriptRun
+	// 列表不返回输出，详情中查看
+	if err := utils.ApplyListPage(query.Omit("output").Order("id DESC"), req.PageInfo).Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询执行记录失败: %v", err)
+	}
+	reconcileRuns(runs)
+	fillNames(runs)
+	return runs, total, nil
+}
+
+// GetRun 返回执行记录详情，包含输出
+func GetRun(id uint) (*adminModel.HostScriptRun, error) {
+	var run adminModel.HostScriptRun
+	if err := global.APP_DB.First(&run, id).Error; err != nil {
+		return nil, errors.New("执行记录不存在")
+	}
+	runs := []adminModel.HostScriptRun{run}
+	reconcileRuns(runs)
+	fillNames(runs)
+	return &runs[0], nil
+}
+
+// fillNames 填充执行记录的Provider名称和发起人、审批人用户名
+func fillNames(runs []adminModel.HostScriptRun) {
+	if len(runs) == 0 {
+		return
+	}
+	providerIDs := make([]uint, 0, len(runs))
+	userIDs := make([]uint, 0, len(runs)*2)
+	for _, run := range runs {
+		providerIDs = append(providerIDs, run.ProviderID)
+		userIDs = append(userIDs, run.RequestedBy)
+		if run.ReviewedBy != 0 {
+			userIDs = append(userIDs, run.ReviewedBy)
+		}
+	}
+
+	var providers []providerModel.Provider
+	global.APP_DB.Unscoped().Select("id, name").Where("id IN ?", providerIDs).Find(&providers)
+	providerNames := make(map[uint]string, len(providers))
+	for _, p := range providers {
+		providerNames[p.ID] = p.Name
+	}
+	var users []userModel.User
+	global.APP_DB.Unscoped().Select("id, username").Where("id IN ?", userIDs).Find(&users)
+	usernames := make(map[uint]string, len(users))
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+	}
+
+	for i := range runs {
+		runs[i].ProviderName = providerNames[runs[i].ProviderID]
+		runs[i].Requester = usernames[runs[i].RequestedBy]
+		runs[i].Reviewer = usernames[runs[i].ReviewedBy]
+	}
+}
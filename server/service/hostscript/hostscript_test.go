@@ -0,0 +1,125 @@
+package hostscript
+
+import (
+	"strings"
+	"testing"
+
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/utils"
+)
+
+func TestValidateParamDefs(t *testing.T) {
+	valid := []adminModel.HostScriptParam{
+		{Name: "SERVICE", Required: true, Pattern: "[a-z0-9-]+"},
+		{Name: "LINES", Default: "100", Pattern: `\d+`},
+	}
+	if err := validateParamDefs(valid); err != nil {
+		t.Fatalf("valid definitions rejected: %v", err)
+	}
+
+	for name, defs := range map[string][]adminModel.HostScriptParam{
+		"lowercase": {{Name: "service"}},
+		"reserved":  {{Name: "PATH"}},
+		"duplicate": {{Name: "A"}, {Name: "A"}},
+		"pattern":   {{Name: "A", Pattern: "("}},
+		"default":   {{Name: "A", Default: "abc", Pattern: `\d+`}},
+	} {
+		if err := validateParamDefs(defs); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	defs := []adminModel.HostScriptParam{
+		{Name: "SERVICE", Required: true, Pattern: "[a-z0-9-]+"},
+		{Name: "LINES", Default: "100", Pattern: `\d+`},
+		{Name: "FILTER"},
+	}
+
+	got, err := resolveParams(defs, map[string]string{"SERVICE": "nginx"})
+	if err != nil {
+		t.Fatalf("resolveParams: %v", err)
+	}
+	if got["SERVICE"] != "nginx" || got["LINES"] != "100" {
+		t.Errorf("unexpected params: %v", got)
+	}
+	if _, ok := got["FILTER"]; ok {
+		t.Errorf("empty optional param should be omitted: %v", got)
+	}
+
+	for name, values := range map[string]map[string]string{
+		"missing required": {"LINES": "5"},
+		"pattern mismatch": {"SERVICE": "nginx; rm -rf /"},
+		"partial match":    {"SERVICE": "nginx", "LINES": "10x"},
+		"undefined":        {"SERVICE": "nginx", "OTHER": "1"},
+	} {
+		if _, err := resolveParams(defs, values); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	cmd := buildCommand("echo hi\n", map[string]string{"B": "it's", "A": "1"}, 60)
+	for _, want := range []string{
+		"printf '%s' 'ZWNobyBoaQo=' | base64 -d | env A='1' B='it'\\''s' timeout -k 10 60 sh -s 2>&1",
+		`echo "__OCV_SCRIPT_EXIT__=$rc"`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("command %q missing %q", cmd, want)
+		}
+	}
+}
+
+func TestScriptCommandGuard(t *testing.T) {
+	guard := utils.NewCommandGuard("test", "block", nil, nil)
+	script := "#!/bin/sh\nset -e\njournalctl -u \"$SERVICE\" -n \"$LINES\" --no-pager\nsystemctl restart \"$SERVICE\"\n"
+	if violations, err := guard.Violations(script); err != nil || len(violations) > 0 {
+		t.Errorf("常规维护脚本应通过默认白名单: %v %v", violations, err)
+	}
+	violations, err := guard.Violations("df -h\nnmap -sS 10.0.0.0/24\n")
+	if err != nil || len(violations) != 1 || violations[0] != "nmap" {
+		t.Errorf("白名单外的程序应被检出: %v %v", violations, err)
+	}
+}
+
+func TestParseExitCode(t *testing.T) {
+	output, code, ok := parseExitCode("line1\nline2\n\n__OCV_SCRIPT_EXIT__=3\n")
+	if !ok || code != 3 || output != "line1\nline2" {
+		t.Errorf("got (%q, %d, %v)", output, code, ok)
+	}
+
+	// 脚本自身输出的同名标记不影响结果，以最后一个为准
+	output, code, ok = parseExitCode("__OCV_SCRIPT_EXIT__=1\ndone\n\n__OCV_SCRIPT_EXIT__=0")
+	if !ok || code != 0 || output != "__OCV_SCRIPT_EXIT__=1\ndone" {
+		t.Errorf("got (%q, %d, %v)", output, code, ok)
+	}
+
+	if _, _, ok := parseExitCode("connection reset"); ok {
+		t.Error("expected missing marker")
+	}
+}
+
+func TestTailOutput(t *testing.T) {
+	if got := tailOutput("short", 10); got != "short" {
+		t.Errorf("got %q", got)
+	}
+	if got := tailOutput("0123456789", 4); got != "...\n6789" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestJoinIDsAndAllowsProvider(t *testing.T) {
+	ids := joinIDs([]uint{3, 1, 3, 0, 12})
+	if ids != "1,3,12" {
+		t.Fatalf("joinIDs = %q", ids)
+	}
+	script := adminModel.HostScript{ProviderIDs: ids}
+	if !script.AllowsProvider(12) || script.AllowsProvider(2) {
+		t.Errorf("AllowsProvider mismatch for %q", ids)
+	}
+	if !(&adminModel.HostScript{}).AllowsProvider(2) {
+		t.Error("empty provider list should allow all providers")
+	}
+}
@@ -0,0 +1,323 @@
+package hwhealth
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 支持的硬件检查项
+const (
+	CheckSMART   = "smart"   // 磁盘SMART健康状态
+	CheckRAID    = "raid"    // 软RAID（mdadm）阵列状态
+	CheckSensors = "sensors" // 主板/CPU/GPU温度传感器
+	CheckZpool   = "zpool"   // ZFS存储池状态
+)
+
+const (
+	defaultIntervalMinutes = 30
+	defaultTempThreshold   = 85
+	// MaxAlertLength 告警信息的最大长度，与Provider.HardwareAlert字段长度一致
+	MaxAlertLength = 1024
+)
+
+var allChecks = []string{CheckSMART, CheckRAID, CheckSensors, CheckZpool}
+
+// mdstatDevicePattern 匹配 [UU_] 形式的阵列成员状态
+var mdstatDevicePattern = regexp.MustCompile(`\[([U_]+)\]`)
+
+// Report 一次硬件检查的结果
+type Report struct {
+	Alerts      []string // 告警项，为空表示硬件正常
+	Unavailable []string // 宿主机缺少对应工具而未执行的检查项
+}
+
+// Alert 返回合并后的告警信息，为空表示正常
+func (r *Report) Alert() string {
+	return Truncate(strings.Join(r.Alerts, "；"), MaxAlertLength)
+}
+
+// Interval 返回硬件检查的间隔
+func Interval() time.Duration {
+	if minutes := global.APP_CONFIG.HardwareCheck.Interval; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultIntervalMinutes * time.Minute
+}
+
+// TempThreshold 返回Provider生效的温度告警阈值（℃）
+func TempThreshold(provider *providerModel.Provider) int {
+	if provider.HardwareTempThreshold > 0 {
+		return provider.HardwareTempThreshold
+	}
+	if threshold := global.APP_CONFIG.HardwareCheck.TempThreshold; threshold > 0 {
+		return threshold
+	}
+	return defaultTempThreshold
+}
+
+// ParseChecks 解析逗号分隔的检查项，去重并校验，空字符串表示不检查
+func ParseChecks(value string) ([]string, error) {
+	var checks []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		valid := false
+		for _, check := range allChecks {
+			if item == check {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("不支持的硬件检查项: %s，可选值为 %s", item, strings.Join(allChecks, ", "))
+		}
+		seen[item] = true
+		checks = append(checks, item)
+	}
+	return checks, nil
+}
+
+// NormalizeChecks 校验检查项并返回规范化后的逗号分隔字符串
+func NormalizeChecks(value string) (string, error) {
+	checks, err := ParseChecks(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(checks, ","), nil
+}
+
+// BuildScript 生成在宿主机上执行的检查命令，每个检查项以 "@@<项>" 开头输出一段结果
+func BuildScript(checks []string) string {
+	var b strings.Builder
+	for _, check := range checks {
+		b.WriteString("echo '@@" + check + "'\n")
+		switch check {
+		case CheckSMART:
+			b.WriteString(`if command -v smartctl >/dev/null 2>&1; then
+  for dev in $(smartctl --scan 2>/dev/null | awk '{print $1}'); do
+    echo "dev $dev"; smartctl -H "$dev" 2>/dev/null | grep -iE 'overall-health|health status'
+  done
+else echo unavailable; fi
+`)
+		case CheckRAID:
+			b.WriteString(`if [ -r /proc/mdstat ]; then cat /proc/mdstat; else echo unavailable; fi
+`)
+		case CheckSensors:
+			b.WriteString(`for f in /sys/class/hwmon/hwmon*/temp*_input; do
+  [ -r "$f" ] || continue
+  n=$(cat "${f%/*}/name" 2>/dev/null); l=$(cat "${f%_input}_label" 2>/dev/null)
+  echo "temp ${n:-hwmon}${l:+/$l} $(cat "$f")"
+done
+if command -v nvidia-smi >/dev/null 2>&1; then
+  nvidia-smi --query-gpu=index,temperature.gpu --format=csv,noheader,nounits 2>/dev/null | while IFS=', ' read -r i t; do echo "gpu gpu$i $t"; done
+fi
+`)
+		case CheckZpool:
+			b.WriteString(`if command -v zpool >/dev/null 2>&1; then zpool list -H -o name,health 2>/dev/null; else echo unavailable; fi
+`)
+		}
+	}
+	// 单项命令失败不影响整体输出的解析
+	b.WriteString("exit 0\n")
+	return b.String()
+}
+
+// Parse 解析BuildScript的输出，tempThreshold 为温度告警阈值（℃）
+func Parse(output string, tempThreshold int) *Report {
+	sections := make(map[string][]string)
+	var order []string
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "@@") {
+			current = strings.TrimSpace(strings.TrimPrefix(line, "@@"))
+			order = append(order, current)
+			continue
+		}
+		if current != "" && strings.TrimSpace(line) != "" {
+			sections[current] = append(sections[current], line)
+		}
+	}
+
+	report := &Report{}
+	for _, check := range order {
+		lines := sections[check]
+		if len(lines) == 1 && strings.TrimSpace(lines[0]) == "unavailable" {
+			report.Unavailable = append(report.Unavailable, check)
+			continue
+		}
+		switch check {
+		case CheckSMART:
+			report.Alerts = append(report.Alerts, parseSMART(lines)...)
+		case CheckRAID:
+			report.Alerts = append(report.Alerts, parseMdstat(lines)...)
+		case CheckSensors:
+			report.Alerts = append(report.Alerts, parseSensors(lines, tempThreshold)...)
+		case CheckZpool:
+			report.Alerts = append(report.Alerts, parseZpool(lines)...)
+		}
+	}
+	return report
+}
+
+// parseSMART 找出自检结果不是PASSED/OK的磁盘
+func parseSMART(lines []string) []string {
+	var alerts []string
+	dev := ""
+	for _, line := range lines {
+		if strings.HasPrefix(line, "dev ") {
+			dev = strings.TrimSpace(strings.TrimPrefix(line, "dev "))
+			continue
+		}
+		idx := strings.LastIndex(line, ":")
+		if idx < 0 || dev == "" {
+			continue
+		}
+		result := strings.TrimSpace(line[idx+1:])
+		if !strings.EqualFold(result, "PASSED") && !strings.EqualFold(result, "OK") {
+			alerts = append(alerts, fmt.Sprintf("磁盘 %s SMART状态异常: %s", dev, result))
+		}
+	}
+	return alerts
+}
+
+// parseMdstat 找出降级或有故障成员的RAID阵列
+func parseMdstat(lines []string) []string {
+	var alerts []string
+	array := ""
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.HasPrefix(fields[0], "md") && fields[1] == ":" {
+			array = fields[0]
+			var failed []string
+			for _, member := range fields[3:] {
+				if strings.HasSuffix(member, "(F)") {
+					failed = append(failed, strings.SplitN(member, "[", 2)[0])
+				}
+			}
+			if len(failed) > 0 {
+				alerts = append(alerts, fmt.Sprintf("RAID %s 成员故障: %s", array, strings.Join(failed, ", ")))
+			}
+			if fields[2] == "inactive" {
+				alerts = append(alerts, fmt.Sprintf("RAID %s 未激活", array))
+			}
+			continue
+		}
+		if array == "" {
+			continue
+		}
+		if m := mdstatDevicePattern.FindStringSubmatch(line); m != nil && strings.Contains(m[1], "_") {
+			alerts = append(alerts, fmt.Sprintf("RAID %s 已降级 [%s]", array, m[1]))
+			array = ""
+		}
+	}
+	return alerts
+}
+
+// parseSensors 找出超过阈值的温度传感器，同名传感器只保留最高温度
+func parseSensors(lines []string, threshold int) []string {
+	if threshold <= 0 {
+		threshold = defaultTempThreshold
+	}
+	hottest := make(map[string]float64)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || (fields[0] != "temp" && fields[0] != "gpu") {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			continue
+		}
+		// hwmon以毫摄氏度输出，nvidia-smi直接输出摄氏度
+		if fields[0] == "temp" {
+			value /= 1000
+		}
+		name := strings.Join(fields[1:len(fields)-1], " ")
+		if value >= float64(threshold) && value > hottest[name] {
+			hottest[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(hottest))
+	for name := range hottest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	alerts := make([]string, 0, len(names))
+	for _, name := range names {
+		alerts = append(alerts, fmt.Sprintf("%s 温度 %.0f℃ 超过阈值 %d℃", name, hottest[name], threshold))
+	}
+	return alerts
+}
+
+// parseZpool 找出状态不是ONLINE的ZFS存储池
+func parseZpool(lines []string) []string {
+	var alerts []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if fields[1] != "ONLINE" {
+			alerts = append(alerts, fmt.Sprintf("ZFS存储池 %s 状态 %s", fields[0], fields[1]))
+		}
+	}
+	return alerts
+}
+
+// Truncate 按字符截断字符串，避免截断多字节字符
+func Truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	end := 0
+	for i := range s {
+		if i > max {
+			break
+		}
+		end = i
+	}
+	return s[:end]
+}
+
+// NotifyAdmins 邮件通知管理员Provider出现新的硬件告警，未配置邮件服务时仅记录日志
+func NotifyAdmins(provider *providerModel.Provider, alert string) {
+	if !utils.MailConfigured() {
+		return
+	}
+	var admins []userModel.User
+	if err := global.APP_DB.Select("id", "email").
+		Where("user_type = ? AND status = ? AND email <> ''", "admin", 1).Find(&admins).Error; err != nil {
+		global.APP_LOG.Warn("查询管理员邮箱失败", zap.Error(err))
+		return
+	}
+
+	subject := fmt.Sprintf("Provider %s 硬件健康告警", provider.Name)
+	body := fmt.Sprintf("节点 %s（%s）的硬件检查发现以下问题，请尽快处理以免实例数据丢失：<br>%s",
+		html.EscapeString(provider.Name), html.EscapeString(provider.Endpoint),
+		strings.ReplaceAll(html.EscapeString(alert), "；", "<br>"))
+	for _, admin := range admins {
+		if err := utils.SendMail(admin.Email, subject, body); err != nil {
+			global.APP_LOG.Warn("发送硬件告警邮件失败",
+				zap.Uint("providerId", provider.ID),
+				zap.Uint("adminId", admin.ID),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package hwhealth
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"oneclickvirt/utils"
+)
+
+func TestParseChecks(t *testing.T) {
+	got, err := ParseChecks(" SMART, zpool,smart,,raid ")
+	if err != nil {
+		t.Fatalf("ParseChecks: %v", err)
+	}
+	if want := []string{"smart", "zpool", "raid"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseChecks = %v, want %v", got, want)
+	}
+	if _, err := ParseChecks("smart,ipmi"); err == nil {
+		t.Error("unknown check should be rejected")
+	}
+	if got, _ := NormalizeChecks(""); got != "" {
+		t.Errorf("empty checks should stay empty, got %q", got)
+	}
+}
+
+func TestBuildScript(t *testing.T) {
+	script := BuildScript(allChecks)
+	for _, check := range allChecks {
+		if !strings.Contains(script, "echo '@@"+check+"'") {
+			t.Errorf("script missing section %s", check)
+		}
+	}
+	if err := utils.NewCommandGuard("test", "block", nil, nil).Check(script); err != nil {
+		t.Errorf("script should pass the default command guard: %v", err)
+	}
+	// 有sh时校验脚本语法
+	if sh, err := exec.LookPath("sh"); err == nil {
+		cmd := exec.Command(sh, "-n")
+		cmd.Stdin = strings.NewReader(script)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("script syntax error: %v\n%s", err, output)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	output := `@@smart
+dev /dev/sda
+SMART overall-health self-assessment test result: PASSED
+dev /dev/sdb
+SMART overall-health self-assessment test result: FAILED!
+dev /dev/nvme0
+SMART Health Status: OK
+@@raid
+Personalities : [raid1]
+md0 : active raid1 sdb1[1](F) sda1[0]
+      1046528 blocks super 1.2 [2/1] [U_]
+
+md1 : active raid1 sdd1[1] sdc1[0]
+      2094080 blocks super 1.2 [2/2] [UU]
+
+unused devices: <none>
+@@sensors
+temp coretemp/Package id 0 91000
+temp coretemp/Core 0 88000
+temp coretemp/Core 0 92500
+temp nvme/Composite 45850
+gpu gpu0 87
+@@zpool
+rpool	ONLINE
+tank	DEGRADED
+`
+	report := Parse(output, 90)
+	want := []string{
+		"磁盘 /dev/sdb SMART状态异常: FAILED!",
+		"RAID md0 成员故障: sdb1",
+		"RAID md0 已降级 [U_]",
+		"coretemp/Core 0 温度 92℃ 超过阈值 90℃",
+		"coretemp/Package id 0 温度 91℃ 超过阈值 90℃",
+		"ZFS存储池 tank 状态 DEGRADED",
+	}
+	if !reflect.DeepEqual(report.Alerts, want) {
+		t.Errorf("Alerts =\n%q\nwant\n%q", report.Alerts, want)
+	}
+	if len(report.Unavailable) != 0 {
+		t.Errorf("Unavailable = %v, want none", report.Unavailable)
+	}
+}
+
+func TestParseHealthyAndUnavailable(t *testing.T) {
+	report := Parse("@@smart\nunavailable\n@@zpool\nunavailable\n@@raid\nPersonalities :\nunused devices: <none>\n@@sensors\n", 85)
+	if len(report.Alerts) != 0 || report.Alert() != "" {
+		t.Errorf("expected no alerts, got %v", report.Alerts)
+	}
+	if want := []string{"smart", "zpool"}; !reflect.DeepEqual(report.Unavailable, want) {
+		t.Errorf("Unavailable = %v, want %v", report.Unavailable, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("磁盘异常", 7); got != "磁盘" {
+		t.Errorf("Truncate = %q, want %q", got, "磁盘")
+	}
+	if got := Truncate("ok", 10); got != "ok" {
+		t.Errorf("Truncate = %q", got)
+	}
+}
@@ -0,0 +1,299 @@
+// Package idlestop 闲置实例检测与自动停机
+// 实例连续多天没有SSH登录且流量接近零时通知所有者，宽限期内仍无活动则停机，
+// 通知中附带一键保持运行的链接，点击后重新开始闲置计时
+package idlestop
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/traffic"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDays             = 7
+	defaultTrafficThreshold = 10
+	defaultIntervalHours    = 6
+	defaultGraceHours       = 48
+)
+
+// ErrKeepAliveNotFound 保持运行令牌不存在或实例已不在闲置预告中
+var ErrKeepAliveNotFound = errors.New("链接已失效或实例未处于闲置停机预告中")
+
+// Action 一次闲置检测后对实例采取的动作
+type Action int
+
+const (
+	ActionNone   Action = iota // 无需处理
+	ActionClear                // 预告后检测到活动，撤销预告
+	ActionNotify               // 达到闲置天数，发送停机预告
+	ActionStop                 // 预告宽限期结束仍闲置，停机
+)
+
+// activityScript 输出当前登录会话数和wtmp最后修改时间（每次SSH登录/登出都会更新）
+// 平台自身的非交互SSH命令不分配终端，不会写入wtmp
+const activityScript = `echo "sessions $(who 2>/dev/null | grep -c .)"
+echo "wtmp $(stat -c %Y /var/log/wtmp 2>/dev/null || echo 0)"
+`
+
+// PolicyFor 返回指定用户等级生效的闲置策略，等级未单独配置时使用全局策略
+func PolicyFor(level int) config.IdleStopPolicy {
+	cfg := global.APP_CONFIG.IdleStop
+	policy := cfg.IdleStopPolicy
+	if override, ok := cfg.Levels[level]; ok {
+		policy = override
+	}
+	if policy.Days <= 0 {
+		policy.Days = defaultDays
+	}
+	if policy.TrafficThreshold <= 0 {
+		policy.TrafficThreshold = defaultTrafficThreshold
+	}
+	return policy
+}
+
+// AnyEnabled 全局策略或任一等级策略启用时返回true
+func AnyEnabled() bool {
+	cfg := global.APP_CONFIG.IdleStop
+	if cfg.Enabled {
+		return true
+	}
+	for _, policy := range cfg.Levels {
+		if policy.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Interval 返回闲置检测的间隔
+func Interval() time.Duration {
+	if hours := global.APP_CONFIG.IdleStop.Interval; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultIntervalHours * time.Hour
+}
+
+// GracePeriod 返回停机预告到实际停机之间的宽限期
+func GracePeriod() time.Duration {
+	if hours := global.APP_CONFIG.IdleStop.GraceHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultGraceHours * time.Hour
+}
+
+// Decide 根据最近活动时间和预告状态决定本轮动作
+func Decide(now, lastActive time.Time, noticeAt *time.Time, days int, grace time.Duration) Action {
+	if now.Sub(lastActive) < time.Duration(days)*24*time.Hour {
+		if noticeAt != nil {
+			return ActionClear
+		}
+		return ActionNone
+	}
+	if noticeAt == nil {
+		return ActionNotify
+	}
+	if now.Sub(*noticeAt) >= grace {
+		return ActionStop
+	}
+	return ActionNone
+}
+
+// LatestActivity 返回 base 与各活动时间中最晚的一个，nil 表示该来源没有活动记录
+func LatestActivity(base time.Time, activities ...*time.Time) time.Time {
+	latest := base
+	for _, at := range activities {
+		if at != nil && at.After(latest) {
+			latest = *at
+		}
+	}
+	return latest
+}
+
+// BaseActivity 返回实例已记录的最近活动时间，从未记录时以创建时间为准
+func BaseActivity(instance *providerModel.Instance) time.Time {
+	if instance.LastActiveAt != nil && instance.LastActiveAt.After(instance.CreatedAt) {
+		return *instance.LastActiveAt
+	}
+	return instance.CreatedAt
+}
+
+// ParseActivity 解析activityScript的输出，有登录会话时返回now，否则返回wtmp最后修改时间
+func ParseActivity(output string, now time.Time) (*time.Time, error) {
+	sessions, wtmp := -1, int64(-1)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "sessions":
+			if n, err := strconv.Atoi(fields[1]); err == nil {
+				sessions = n
+			}
+		case "wtmp":
+			if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				wtmp = n
+			}
+		}
+	}
+	if sessions < 0 || wtmp < 0 {
+		return nil, fmt.Errorf("无法解析实例登录信息: %s", utils.TruncateString(strings.TrimSpace(output), 200))
+	}
+	if sessions > 0 {
+		return &now, nil
+	}
+	if wtmp == 0 {
+		return nil, nil
+	}
+	at := time.Unix(wtmp, 0)
+	return &at, nil
+}
+
+// SSHActivity 通过SSH读取实例内最近的登录活动
+func SSHActivity(instance *providerModel.Instance, provider *providerModel.Provider, now time.Time) (*time.Time, error) {
+	host, port := resources.ResolveInstanceSSHEndpoint(instance, provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		return nil, fmt.Errorf("连接实例失败: %w", err)
+	}
+	defer client.Close()
+	defer session.Close()
+
+	output, err := session.CombinedOutput(activityScript)
+	if err != nil {
+		return nil, fmt.Errorf("读取实例登录信息失败: %w", err)
+	}
+	return ParseActivity(string(output), now)
+}
+
+// TrafficActivity 返回最近一天流量不低于阈值的时间，Provider未启用流量统计时 ok 为false
+func TrafficActivity(instance *providerModel.Instance, provider *providerModel.Provider, days int, thresholdMB int64, now time.Time) (at *time.Time, ok bool) {
+	if !provider.EnableTrafficControl {
+		return nil, false
+	}
+	history, err := traffic.NewQueryService().GetInstanceTrafficHistory(instance.ID, days)
+	if err != nil {
+		global.APP_LOG.Debug("查询实例流量历史失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		return nil, false
+	}
+	for _, point := range history {
+		if point.ActualUsageMB < float64(thresholdMB) {
+			continue
+		}
+		// 当天流量达标，视为当天结束时仍有活动
+		end := point.Date.Add(24 * time.Hour)
+		if end.After(now) {
+			end = now
+		}
+		if at == nil || end.After(*at) {
+			at = &end
+		}
+	}
+	return at, true
+}
+
+// KeepAlivePath 返回保持运行链接的路径
+func KeepAlivePath(token string) string {
+	return "/api/v1/public/instances/keep-alive/" + token
+}
+
+// newToken 生成保持运行令牌
+func newToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Notify 记录停机预告并通知实例所有者，未绑定邮箱或未配置邮件服务时只记录日志
+func Notify(instance *providerModel.Instance, days int, now time.Time) error {
+	token, err := newToken()
+	if err != nil {
+		return fmt.Errorf("生成保持运行令牌失败: %w", err)
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).
+		Updates(map[string]interface{}{
+			"idle_notice_at":        now,
+			"idle_keep_alive_token": token,
+		}).Error; err != nil {
+		return fmt.Errorf("记录闲置停机预告失败: %w", err)
+	}
+
+	stopAt := now.Add(GracePeriod())
+	global.APP_LOG.Info("实例闲置，已发出停机预告",
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.Uint("userId", instance.UserID),
+		zap.Time("stopAt", stopAt))
+
+	var user userModel.User
+	if err := global.APP_DB.Select("id, username, email").First(&user, instance.UserID).Error; err != nil {
+		return nil
+	}
+	if user.Email == "" || !utils.MailConfigured() {
+		return nil
+	}
+	link := strings.TrimRight(global.APP_CONFIG.System.FrontendURL, "/") + KeepAlivePath(token)
+	subject := fmt.Sprintf("实例 %s 闲置停机提醒", instance.Name)
+	body := fmt.Sprintf("您好 %s，您的实例 %s 已连续 %d 天没有SSH登录且几乎没有流量，将于 %s 自动停机以释放资源。<br>"+
+		"如需继续使用，请登录实例或点击以下链接保持运行：<br><a href=\"%s\">%s</a><br>停机后可随时在控制台重新启动。",
+		html.EscapeString(user.Username), html.EscapeString(instance.Name), days,
+		stopAt.Format("2006-01-02 15:04"), html.EscapeString(link), html.EscapeString(link))
+	return utils.SendMail(user.Email, subject, body)
+}
+
+// ClearNotice 撤销停机预告，并把最近活动时间更新为 activeAt
+func ClearNotice(instanceID uint, activeAt time.Time) error {
+	return global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instanceID).
+		Updates(map[string]interface{}{
+			"last_active_at":        activeAt,
+			"idle_notice_at":        nil,
+			"idle_keep_alive_token": "",
+		}).Error
+}
+
+// MarkActive 记录实例最近活动时间，用于用户启动实例等显式操作
+func MarkActive(instanceID uint) {
+	if err := ClearNotice(instanceID, time.Now()); err != nil {
+		global.APP_LOG.Warn("记录实例活动时间失败", zap.Uint("instanceId", instanceID), zap.Error(err))
+	}
+}
+
+// KeepAlive 通过通知中的链接保持实例运行，重新开始闲置计时
+func KeepAlive(token string) (*providerModel.Instance, error) {
+	if token == "" {
+		return nil, ErrKeepAliveNotFound
+	}
+	var instance providerModel.Instance
+	if err := global.APP_DB.Select("id, name, user_id").
+		Where("idle_keep_alive_token = ? AND idle_notice_at IS NOT NULL", token).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKeepAliveNotFound
+		}
+		return nil, err
+	}
+	if err := ClearNotice(instance.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	global.APP_LOG.Info("用户通过链接保持实例运行",
+		zap.Uint("instanceId", instance.ID),
+		zap.Uint("userId", instance.UserID))
+	return &instance, nil
+}
@@ -0,0 +1,124 @@
+package idlestop
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestPolicyFor(t *testing.T) {
+	saved := global.APP_CONFIG.IdleStop
+	t.Cleanup(func() { global.APP_CONFIG.IdleStop = saved })
+
+	global.APP_CONFIG.IdleStop = config.IdleStop{
+		IdleStopPolicy: config.IdleStopPolicy{Enabled: true, Days: 14},
+		Levels: map[int]config.IdleStopPolicy{
+			1: {Enabled: true, Days: 3, TrafficThreshold: 50},
+			5: {Enabled: false},
+		},
+	}
+
+	if got := PolicyFor(2); !got.Enabled || got.Days != 14 || got.TrafficThreshold != defaultTrafficThreshold {
+		t.Errorf("level 2 should use global policy with default threshold, got %+v", got)
+	}
+	if got := PolicyFor(1); got.Days != 3 || got.TrafficThreshold != 50 {
+		t.Errorf("level 1 should use its override, got %+v", got)
+	}
+	if got := PolicyFor(5); got.Enabled || got.Days != defaultDays {
+		t.Errorf("level 5 override should disable idle stop with default days, got %+v", got)
+	}
+
+	global.APP_CONFIG.IdleStop.Enabled = false
+	if !AnyEnabled() {
+		t.Error("level override should count as enabled")
+	}
+}
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	grace := 48 * time.Hour
+	noticed := now.Add(-24 * time.Hour)
+	expired := now.Add(-72 * time.Hour)
+
+	tests := []struct {
+		name       string
+		lastActive time.Time
+		noticeAt   *time.Time
+		want       Action
+	}{
+		{"recently active", now.Add(-2 * 24 * time.Hour), nil, ActionNone},
+		{"idle long enough", now.Add(-8 * 24 * time.Hour), nil, ActionNotify},
+		{"within grace", now.Add(-8 * 24 * time.Hour), &noticed, ActionNone},
+		{"grace expired", now.Add(-10 * 24 * time.Hour), &expired, ActionStop},
+		{"active after notice", now.Add(-time.Hour), &noticed, ActionClear},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decide(now, tt.lastActive, tt.noticeAt, 7, grace); got != tt.want {
+				t.Errorf("Decide() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestActivity(t *testing.T) {
+	base := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := base.Add(48 * time.Hour)
+	earlier := base.Add(-48 * time.Hour)
+
+	if got := LatestActivity(base, nil, &earlier); !got.Equal(base) {
+		t.Errorf("LatestActivity should keep base, got %v", got)
+	}
+	if got := LatestActivity(base, &earlier, &later); !got.Equal(later) {
+		t.Errorf("LatestActivity should pick latest, got %v", got)
+	}
+
+	instance := &providerModel.Instance{}
+	instance.CreatedAt = base
+	if got := BaseActivity(instance); !got.Equal(base) {
+		t.Errorf("BaseActivity without record should use creation time, got %v", got)
+	}
+	instance.LastActiveAt = &later
+	if got := BaseActivity(instance); !got.Equal(later) {
+		t.Errorf("BaseActivity should use last active time, got %v", got)
+	}
+}
+
+func TestParseActivity(t *testing.T) {
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	at, err := ParseActivity("sessions 1\nwtmp 1700000000\n", now)
+	if err != nil || at == nil || !at.Equal(now) {
+		t.Errorf("active session should return now, got %v, %v", at, err)
+	}
+	at, err = ParseActivity("sessions 0\nwtmp 1700000000\n", now)
+	if err != nil || at == nil || at.Unix() != 1700000000 {
+		t.Errorf("should return wtmp time, got %v, %v", at, err)
+	}
+	at, err = ParseActivity("sessions 0\nwtmp 0\n", now)
+	if err != nil || at != nil {
+		t.Errorf("missing wtmp should return nil, got %v, %v", at, err)
+	}
+	if _, err := ParseActivity("Permission denied", now); err == nil {
+		t.Error("unparseable output should fail")
+	}
+}
+
+func TestActivityScriptSyntax(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	cmd := exec.Command("sh", "-c", activityScript)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("activity script failed: %v\n%s", err, out)
+	}
+	if _, err := ParseActivity(string(out), time.Now()); err != nil {
+		t.Errorf("activity script output should parse: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
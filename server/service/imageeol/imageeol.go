@@ -0,0 +1,237 @@
+package imageeol
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 实例操作系统EOL状态
+const (
+	StatusNone        = ""            // 未到提示期或未知
+	StatusApproaching = "approaching" // 即将EOL
+	StatusEOL         = "eol"         // 已EOL
+)
+
+// WarnDays 返回EOL前开始提示的天数，未配置时默认30
+func WarnDays() int {
+	if days := global.APP_CONFIG.OSEOL.WarnDays; days > 0 {
+		return days
+	}
+	return 30
+}
+
+// Status 计算EOL状态，eolDate为空返回StatusNone
+func Status(eolDate *time.Time, now time.Time) string {
+	return status(eolDate, now, WarnDays())
+}
+
+func status(eolDate *time.Time, now time.Time, warnDays int) string {
+	if eolDate == nil {
+		return StatusNone
+	}
+	if !now.Before(*eolDate) {
+		return StatusEOL
+	}
+	if now.AddDate(0, 0, warnDays).After(*eolDate) {
+		return StatusApproaching
+	}
+	return StatusNone
+}
+
+// CheckCreationAllowed 开启禁止后拒绝使用已EOL的镜像创建新实例
+func CheckCreationAllowed(image *system.SystemImage) error {
+	if !global.APP_CONFIG.OSEOL.BlockCreation {
+		return nil
+	}
+	eol := image.EffectiveEOLDate()
+	if status(eol, time.Now(), 0) != StatusEOL {
+		return nil
+	}
+	global.APP_LOG.Info("镜像已EOL，拒绝创建实例",
+		zap.Uint("imageId", image.ID),
+		zap.String("imageName", image.Name),
+		zap.Time("eolDate", *eol))
+	return fmt.Errorf("镜像 %s 的操作系统已于 %s 停止维护，请选择其他镜像", image.Name, eol.Format("2006-01-02"))
+}
+
+// FilterCreatable 开启禁止后从用户可选镜像列表中移除已EOL的镜像
+func FilterCreatable(images []system.SystemImage) []system.SystemImage {
+	if !global.APP_CONFIG.OSEOL.BlockCreation {
+		return images
+	}
+	now := time.Now()
+	filtered := make([]system.SystemImage, 0, len(images))
+	for _, image := range images {
+		if status(image.EffectiveEOLDate(), now, 0) == StatusEOL {
+			continue
+		}
+		filtered = append(filtered, image)
+	}
+	return filtered
+}
+
+// imageIndex 按ID和名称索引系统镜像，用于解析实例使用的镜像
+type imageIndex struct {
+	byID   map[uint]*system.SystemImage
+	byName map[string]*system.SystemImage
+}
+
+func newImageIndex(images []system.SystemImage) *imageIndex {
+	idx := &imageIndex{
+		byID:   make(map[uint]*system.SystemImage, len(images)),
+		byName: make(map[string]*system.SystemImage, len(images)),
+	}
+	for i := range images {
+		image := &images[i]
+		idx.byID[image.ID] = image
+		// 同名镜像（不同Provider类型/架构）优先取有EOL日期的一条
+		key := image.Name + "|" + image.InstanceType
+		if existing, ok := idx.byName[key]; !ok || (existing.EffectiveEOLDate() == nil && image.EffectiveEOLDate() != nil) {
+			idx.byName[key] = image
+		}
+	}
+	return idx
+}
+
+// eolDateFor 解析实例使用的镜像的EOL日期，旧实例没有镜像ID时按镜像名称和实例类型匹配
+func (idx *imageIndex) eolDateFor(instance *providerModel.Instance) *time.Time {
+	if image, ok := idx.byID[instance.ImageID]; ok && instance.ImageID != 0 {
+		return image.EffectiveEOLDate()
+	}
+	if image, ok := idx.byName[instance.Image+"|"+instance.InstanceType]; ok {
+		return image.EffectiveEOLDate()
+	}
+	return nil
+}
+
+// sameDate 判断两个可空日期是否相同
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// Sync 从系统镜像同步所有实例的EOL日期，日期变化时清空通知记录以便重新通知
+func Sync() (int, error) {
+	var images []system.SystemImage
+	// 包含已删除的镜像，删除镜像不影响已创建实例的EOL判断
+	if err := global.APP_DB.Unscoped().Find(&images).Error; err != nil {
+		return 0, fmt.Errorf("查询系统镜像失败: %w", err)
+	}
+	idx := newImageIndex(images)
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id, image, image_id, instance_type, os_eol_date").
+		Where("status NOT IN ?", []string{"deleting", "deleted", "failed"}).
+		Find(&instances).Error; err != nil {
+		return 0, fmt.Errorf("查询实例失败: %w", err)
+	}
+
+	updated := 0
+	for i := range instances {
+		instance := &instances[i]
+		eol := idx.eolDateFor(instance)
+		if sameDate(eol, instance.OSEOLDate) {
+			continue
+		}
+		if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).
+			Updates(map[string]interface{}{
+				"os_eol_date":        eol,
+				"os_eol_notified_at": nil,
+			}).Error; err != nil {
+			global.APP_LOG.Warn("更新实例EOL日期失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// NotifyOwners 通知运行已EOL或即将EOL系统的实例所有者，每个实例只通知一次
+// 同一用户的多个实例合并为一封邮件，用户未绑定邮箱或未配置邮件服务时只记录日志
+func NotifyOwners() (int, error) {
+	now := time.Now()
+	deadline := now.AddDate(0, 0, WarnDays())
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id, name, user_id, image, os_eol_date").
+		Where("os_eol_date IS NOT NULL AND os_eol_date <= ? AND os_eol_notified_at IS NULL", deadline).
+		Where("status NOT IN ?", []string{"deleting", "deleted", "failed"}).
+		Find(&instances).Error; err != nil {
+		return 0, fmt.Errorf("查询EOL实例失败: %w", err)
+	}
+	if len(instances) == 0 {
+		return 0, nil
+	}
+
+	byUser := make(map[uint][]providerModel.Instance)
+	for _, instance := range instances {
+		byUser[instance.UserID] = append(byUser[instance.UserID], instance)
+	}
+
+	notified := 0
+	for userID, list := range byUser {
+		var user userModel.User
+		if err := global.APP_DB.Select("id, username, email").First(&user, userID).Error; err != nil {
+			continue
+		}
+		if err := notifyUser(&user, list, now); err != nil {
+			global.APP_LOG.Warn("发送操作系统EOL通知失败",
+				zap.Uint("userId", userID),
+				zap.Error(err))
+		}
+
+		ids := make([]uint, 0, len(list))
+		for _, instance := range list {
+			ids = append(ids, instance.ID)
+		}
+		// 发送失败也记录，避免每轮重复尝试；EOL日期变化后会重新通知
+		if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id IN ?", ids).
+			Update("os_eol_notified_at", now).Error; err != nil {
+			global.APP_LOG.Warn("记录EOL通知时间失败", zap.Uint("userId", userID), zap.Error(err))
+			continue
+		}
+		notified += len(list)
+	}
+	return notified, nil
+}
+
+// notifyUser 向用户发送其实例的EOL提醒
+func notifyUser(user *userModel.User, instances []providerModel.Instance, now time.Time) error {
+	sort.Slice(instances, func(i, j int) bool { return instances[i].OSEOLDate.Before(*instances[j].OSEOLDate) })
+
+	var lines []string
+	for _, instance := range instances {
+		state := "即将停止维护"
+		if status(instance.OSEOLDate, now, 0) == StatusEOL {
+			state = "已停止维护"
+		}
+		lines = append(lines, fmt.Sprintf("%s（镜像 %s）%s，EOL日期 %s",
+			html.EscapeString(instance.Name), html.EscapeString(instance.Image), state, instance.OSEOLDate.Format("2006-01-02")))
+		global.APP_LOG.Info("实例操作系统EOL提醒",
+			zap.Uint("userId", user.ID),
+			zap.Uint("instanceId", instance.ID),
+			zap.String("image", instance.Image),
+			zap.Time("eolDate", *instance.OSEOLDate))
+	}
+
+	if user.Email == "" || !utils.MailConfigured() {
+		return nil
+	}
+	subject := "实例操作系统停止维护提醒"
+	body := fmt.Sprintf("您好 %s，以下实例使用的操作系统已经或即将停止安全更新，建议备份数据后重装为受支持的系统：<br>%s",
+		html.EscapeString(user.Username), strings.Join(lines, "<br>"))
+	return utils.SendMail(user.Email, subject, body)
+}
@@ -0,0 +1,95 @@
+package imageeol
+
+import (
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/system"
+)
+
+func date(value string) *time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+	return &t
+}
+
+func TestStatus(t *testing.T) {
+	now := *date("2026-05-01")
+	tests := []struct {
+		name string
+		eol  *time.Time
+		want string
+	}{
+		{"unknown", nil, StatusNone},
+		{"already eol", date("2026-04-30"), StatusEOL},
+		{"eol today", date("2026-05-01"), StatusEOL},
+		{"within warn window", date("2026-05-20"), StatusApproaching},
+		{"outside warn window", date("2026-07-01"), StatusNone},
+	}
+	for _, tt := range tests {
+		if got := status(tt.eol, now, 30); got != tt.want {
+			t.Errorf("%s: status = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKnownOSEOLDate(t *testing.T) {
+	tests := []struct {
+		osType, osVersion string
+		want              string
+	}{
+		{"ubuntu", "22.04", "2027-06-01"},
+		{"Ubuntu", "20.04.6", "2025-05-31"},
+		{"debian", "bookworm", "2028-06-30"},
+		{"debian", "12.5", "2028-06-30"},
+		{"centos", "7", "2024-06-30"},
+		{"alpine", "3.19.1", "2025-11-01"},
+		{"ubuntu", "", ""},
+		{"windows", "2022", ""},
+	}
+	for _, tt := range tests {
+		got := system.KnownOSEOLDate(tt.osType, tt.osVersion)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("KnownOSEOLDate(%q, %q) = %v, want nil", tt.osType, tt.osVersion, got)
+			}
+			continue
+		}
+		if got == nil || got.Format("2006-01-02") != tt.want {
+			t.Errorf("KnownOSEOLDate(%q, %q) = %v, want %s", tt.osType, tt.osVersion, got, tt.want)
+		}
+	}
+}
+
+func TestImageIndexEOLDateFor(t *testing.T) {
+	images := []system.SystemImage{
+		{Name: "debian11", InstanceType: "container", OSType: "debian", OSVersion: "11"},
+		{Name: "custom", InstanceType: "vm", EOLDate: date("2026-01-01")},
+		{Name: "custom", InstanceType: "container"},
+	}
+	images[0].ID, images[1].ID, images[2].ID = 1, 2, 3
+	idx := newImageIndex(images)
+
+	tests := []struct {
+		name     string
+		instance providerModel.Instance
+		want     string
+	}{
+		{"by id", providerModel.Instance{ImageID: 2, Image: "other", InstanceType: "container"}, "2026-01-01"},
+		{"by name fallback", providerModel.Instance{Image: "debian11", InstanceType: "container"}, "2026-08-31"},
+		{"name with different type", providerModel.Instance{Image: "custom", InstanceType: "container"}, ""},
+		{"unknown image", providerModel.Instance{ImageID: 99, Image: "missing", InstanceType: "vm"}, ""},
+	}
+	for _, tt := range tests {
+		got := idx.eolDateFor(&tt.instance)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("%s: eolDateFor = %v, want nil", tt.name, got)
+			}
+			continue
+		}
+		if got == nil || got.Format("2006-01-02") != tt.want {
+			t.Errorf("%s: eolDateFor = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package images
+
+import (
+	"fmt"
+	"strings"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+
+	"go.uber.org/zap"
+)
+
+// supportsProviderType 判断镜像是否支持指定的Provider类型（ProviderType 可能为逗号分隔的列表）
+func supportsProviderType(image *system.SystemImage, providerType string) bool {
+	for _, t := range strings.Split(image.ProviderType, ",") {
+		if strings.TrimSpace(t) == providerType {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveImageForArchitecture 将用户选择的镜像映射到节点架构对应的镜像
+//
+// 同一操作系统、版本和实例类型的镜像视为一个逻辑镜像，不同架构各有一条记录。
+// 用户选择的镜像架构与节点一致时直接返回；否则查找同一逻辑镜像下节点架构的镜像，
+// 找不到时返回带有提示信息的错误，避免任务下发后才在节点上失败。
+func (s *ImageService) ResolveImageForArchitecture(image *system.SystemImage, providerType, providerArch string) (*system.SystemImage, error) {
+	targetArch := constant.NormalizeArchitecture(providerArch)
+	if constant.NormalizeArchitecture(image.Architecture) == targetArch {
+		return image, nil
+	}
+
+	var candidates []system.SystemImage
+	if err := global.APP_DB.Where("status = ? AND instance_type = ? AND LOWER(os_type) = LOWER(?) AND os_version = ?",
+		"active", image.InstanceType, image.OSType, image.OSVersion).
+		Order("created_at DESC").
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("查询架构对应镜像失败: %v", err)
+	}
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		if constant.NormalizeArchitecture(candidate.Architecture) == targetArch && supportsProviderType(candidate, providerType) {
+			global.APP_LOG.Info("已按节点架构映射镜像",
+				zap.Uint("requestedImageId", image.ID),
+				zap.String("requestedArch", image.Architecture),
+				zap.Uint("resolvedImageId", candidate.ID),
+				zap.String("providerArch", targetArch))
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("所选镜像 %s 没有适用于 %s 架构节点的版本，请选择 %s 架构的镜像或其他节点",
+		image.Name, targetArch, targetArch)
+}
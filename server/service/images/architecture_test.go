@@ -0,0 +1,73 @@
+package images
+
+import (
+	"fmt"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestResolveImageForArchitecture(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&system.SystemImage{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDB, oldLog := global.APP_DB, global.APP_LOG
+	global.APP_DB, global.APP_LOG = db, zap.NewNop()
+	t.Cleanup(func() { global.APP_DB, global.APP_LOG = oldDB, oldLog })
+
+	images := []system.SystemImage{
+		{Name: "ubuntu-amd64", Architecture: "amd64", ProviderType: "proxmox,incus"},
+		{Name: "ubuntu-arm64", Architecture: "aarch64", ProviderType: "proxmox, incus"},
+		{Name: "ubuntu-arm64-docker", Architecture: "arm64", ProviderType: "docker"},
+		{Name: "ubuntu-s390x-inactive", Architecture: "s390x", ProviderType: "proxmox", Status: "inactive"},
+	}
+	for i := range images {
+		img := &images[i]
+		img.UUID = fmt.Sprintf("uuid-%d", i)
+		img.URL = "https://example.com/" + img.Name
+		img.OSType, img.OSVersion, img.InstanceType = "ubuntu", "24.04", "vm"
+		if img.Status == "" {
+			img.Status = "active"
+		}
+		if err := db.Create(img).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := &ImageService{}
+	requested := &images[0]
+	tests := []struct {
+		name         string
+		providerType string
+		providerArch string
+		want         string // 为空表示应返回错误
+	}{
+		{name: "架构一致", providerType: "proxmox", providerArch: "x86_64", want: "ubuntu-amd64"},
+		{name: "节点未设置架构", providerType: "incus", providerArch: "", want: "ubuntu-amd64"},
+		{name: "映射到arm64镜像", providerType: "incus", providerArch: "arm64", want: "ubuntu-arm64"},
+		{name: "Provider类型不支持", providerType: "lxd", providerArch: "arm64"},
+		{name: "对应镜像已停用", providerType: "proxmox", providerArch: "s390x"},
+	}
+	for _, tt := range tests {
+		got, err := svc.ResolveImageForArchitecture(requested, tt.providerType, tt.providerArch)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: 应返回错误，得到 %s", tt.name, got.Name)
+			}
+			continue
+		}
+		if err != nil || got.Name != tt.want {
+			t.Errorf("%s: got %v, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -86,6 +86,8 @@ func (s *ImageDownloadService) getDownloadDir(providerType string) string {
 		return filepath.Join(baseDir, "incus_images")
 	case "proxmox":
 		return filepath.Join(baseDir, "proxmox_images")
+	case "lxc":
+		return filepath.Join(baseDir, "lxc_images")
 	default:
 		return filepath.Join(baseDir, "docker_ct_images")
 	}
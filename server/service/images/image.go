@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/model/image"
 	providerModel "oneclickvirt/model/provider"
@@ -137,6 +138,8 @@ func (s *ImageService) GetImageDownloadPath(providerType, instanceType string) s
 		return filepath.Join(baseDir, "incus_container_images")
 	case "docker":
 		return filepath.Join(baseDir, "docker_images")
+	case "lxc":
+		return filepath.Join(baseDir, "lxc_container_images")
 	default:
 		return filepath.Join(baseDir, "images")
 	}
@@ -340,10 +343,7 @@ func (s *ImageService) GetFilteredImages(providerID uint, instanceType string) (
 	}
 
 	// 设置默认架构
-	architecture := provider.Architecture
-	if architecture == "" {
-		architecture = "amd64" // 默认amd64架构
-	}
+	architecture := constant.NormalizeArchitecture(provider.Architecture)
 
 	// 根据Provider类型、实例类型和架构过滤镜像
 	return s.GetAvailableImages(provider.Type, instanceType, architecture)
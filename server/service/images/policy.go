@@ -0,0 +1,71 @@
+package images
+
+import (
+	"fmt"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+)
+
+// getActiveImagePolicies 获取所有启用的镜像禁用策略
+func getActiveImagePolicies() []system.ImagePolicy {
+	var policies []system.ImagePolicy
+	if err := global.APP_DB.Where("status = ?", 1).Find(&policies).Error; err != nil {
+		global.APP_LOG.Warn("查询镜像禁用策略失败", zap.Error(err))
+		return nil
+	}
+	return policies
+}
+
+// findBlockingPolicy 返回禁用该镜像的第一条策略，未命中返回nil
+func findBlockingPolicy(policies []system.ImagePolicy, image *system.SystemImage, userLevel int, providerID uint) *system.ImagePolicy {
+	for i := range policies {
+		if policies[i].AppliesTo(userLevel, providerID) && policies[i].MatchesImage(image) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// GetUserLevel 获取用户等级，查询失败返回0
+func GetUserLevel(userID uint) int {
+	var u userModel.User
+	if err := global.APP_DB.Select("level").First(&u, userID).Error; err != nil {
+		return 0
+	}
+	return u.Level
+}
+
+// FilterBannedImages 过滤掉被禁用策略命中的镜像
+// userLevel 为 0 表示未知用户，providerID 为 0 表示未知节点
+func (s *ImageService) FilterBannedImages(images []system.SystemImage, userLevel int, providerID uint) []system.SystemImage {
+	policies := getActiveImagePolicies()
+	if len(policies) == 0 {
+		return images
+	}
+
+	filtered := make([]system.SystemImage, 0, len(images))
+	for i := range images {
+		if findBlockingPolicy(policies, &images[i], userLevel, providerID) == nil {
+			filtered = append(filtered, images[i])
+		}
+	}
+	return filtered
+}
+
+// CheckImageAllowed 检查镜像是否允许在指定用户等级和节点上使用
+func (s *ImageService) CheckImageAllowed(image *system.SystemImage, userLevel int, providerID uint) error {
+	if policy := findBlockingPolicy(getActiveImagePolicies(), image, userLevel, providerID); policy != nil {
+		global.APP_LOG.Info("镜像被禁用策略拦截",
+			zap.Uint("imageId", image.ID),
+			zap.String("imageName", image.Name),
+			zap.Uint("policyId", policy.ID),
+			zap.Int("userLevel", userLevel),
+			zap.Uint("providerId", providerID))
+		return fmt.Errorf("镜像 %s 已被管理员禁用: %s", image.Name, policy.Name)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package images
+
+import (
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	ubuntuVM  = system.SystemImage{ID: 1, Name: "ubuntu-vm", OSType: "ubuntu", InstanceType: "vm"}
+	windowsVM = system.SystemImage{ID: 2, Name: "windows-vm", OSType: "Windows", InstanceType: "vm"}
+	debianCT  = system.SystemImage{ID: 3, Name: "debian-ct", OSType: "debian", InstanceType: "container"}
+)
+
+func TestFindBlockingPolicy(t *testing.T) {
+	policies := []system.ImagePolicy{
+		{ID: 1, Name: "低等级禁用Windows", OSType: "windows", MinUserLevel: 3},
+		{ID: 2, Name: "节点5禁用虚拟机", InstanceType: "vm", ProviderID: 5},
+		{ID: 3, Name: "全局禁用镜像3", ImageID: 3},
+	}
+	tests := []struct {
+		name       string
+		image      system.SystemImage
+		userLevel  int
+		providerID uint
+		want       uint // 命中的策略ID，0表示允许
+	}{
+		{name: "普通镜像", image: ubuntuVM, userLevel: 1, providerID: 1},
+		{name: "低等级用户使用Windows", image: windowsVM, userLevel: 2, providerID: 1, want: 1},
+		{name: "达到等级的用户使用Windows", image: windowsVM, userLevel: 3, providerID: 1},
+		{name: "未知用户不受等级策略限制", image: windowsVM, userLevel: 0, providerID: 1},
+		{name: "指定节点上的虚拟机", image: ubuntuVM, userLevel: 5, providerID: 5, want: 2},
+		{name: "指定节点上的容器", image: system.SystemImage{ID: 4, OSType: "ubuntu", InstanceType: "container"}, userLevel: 5, providerID: 5},
+		{name: "未知节点不受节点策略限制", image: ubuntuVM, userLevel: 5, providerID: 0},
+		{name: "全局禁用的镜像", image: debianCT, userLevel: 0, providerID: 0, want: 3},
+	}
+	for _, tt := range tests {
+		policy := findBlockingPolicy(policies, &tt.image, tt.userLevel, tt.providerID)
+		var got uint
+		if policy != nil {
+			got = policy.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: 命中策略 %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterBannedImages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&system.ImagePolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDB, oldLog := global.APP_DB, global.APP_LOG
+	global.APP_DB, global.APP_LOG = db, zap.NewNop()
+	t.Cleanup(func() { global.APP_DB, global.APP_LOG = oldDB, oldLog })
+
+	db.Create(&system.ImagePolicy{Name: "禁用Windows", OSType: "windows", Status: 1})
+	// 停用的策略不生效
+	disabled := system.ImagePolicy{Name: "禁用容器", InstanceType: "container", Status: 1}
+	db.Create(&disabled)
+	db.Model(&disabled).Update("status", 0)
+
+	svc := &ImageService{}
+	filtered := svc.FilterBannedImages([]system.SystemImage{ubuntuVM, windowsVM, debianCT}, 1, 1)
+	if len(filtered) != 2 || filtered[0].ID != ubuntuVM.ID || filtered[1].ID != debianCT.ID {
+		t.Errorf("过滤结果 = %+v", filtered)
+	}
+	if err := svc.CheckImageAllowed(&windowsVM, 1, 1); err == nil {
+		t.Error("被禁用的镜像应被拒绝")
+	}
+	if err := svc.CheckImageAllowed(&debianCT, 1, 1); err != nil {
+		t.Errorf("停用策略不应拦截: %v", err)
+	}
+}
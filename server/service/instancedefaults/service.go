@@ -0,0 +1,141 @@
+// Package instancedefaults 用户的实例创建默认设置
+// 用户可保存首选镜像、首选节点或地区、SSH公钥、标签和时区，创建实例时未填写的节点、镜像和标签使用默认设置，
+// SSH公钥和时区在实例创建完成后写入实例
+package instancedefaults
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/placement"
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrProviderRequired 未选择节点且默认设置中没有可用的首选节点或地区
+	ErrProviderRequired = errors.New("请选择节点，或在实例默认设置中设置首选节点或地区")
+	// ErrImageRequired 未选择镜像且默认设置中没有首选镜像
+	ErrImageRequired = errors.New("请选择镜像，或在实例默认设置中设置首选镜像")
+	// ErrImageUnavailable 首选镜像不存在或未启用
+	ErrImageUnavailable = errors.New("首选镜像不存在或不可用")
+	// ErrProviderUnavailable 首选节点不存在或不允许申请
+	ErrProviderUnavailable = errors.New("首选节点不存在或不可用")
+)
+
+// Service 实例默认设置服务
+type Service struct{}
+
+// NewService 创建实例默认设置服务
+func NewService() *Service {
+	return &Service{}
+}
+
+// NormalizeTimezone 校验IANA时区名称，空字符串表示不设置
+func NormalizeTimezone(name string) (string, error) {
+	return utils.NormalizeTimezone(name)
+}
+
+// Get 获取用户的默认设置，尚未保存过时返回空设置
+func (s *Service) Get(userID uint) (*userModel.InstanceDefaults, error) {
+	var defaults userModel.InstanceDefaults
+	err := global.APP_DB.Where("user_id = ?", userID).First(&defaults).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &userModel.InstanceDefaults{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &defaults, nil
+}
+
+// Save 校验首选镜像和节点并保存用户的默认设置，每个用户只有一份
+// SSH公钥、标签和时区由调用方规范化后传入
+func (s *Service) Save(defaults *userModel.InstanceDefaults) error {
+	defaults.Region = strings.TrimSpace(defaults.Region)
+
+	if defaults.ImageID != 0 {
+		var count int64
+		global.APP_DB.Model(&systemModel.SystemImage{}).Where("id = ? AND status = ?", defaults.ImageID, "active").Count(&count)
+		if count == 0 {
+			return ErrImageUnavailable
+		}
+	}
+	if defaults.ProviderID != 0 {
+		var count int64
+		global.APP_DB.Model(&providerModel.Provider{}).Where("id = ? AND allow_claim = ?", defaults.ProviderID, true).Count(&count)
+		if count == 0 {
+			return ErrProviderUnavailable
+		}
+	}
+
+	return global.APP_DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "image_id", "provider_id", "region", "ssh_public_key", "tags", "timezone"}),
+	}).Create(defaults).Error
+}
+
+// ApplyToRequest 用默认设置补全创建实例请求中未填写的镜像、节点和标签，请求中的标签应已规范化
+// 未设置首选节点时，在首选地区中选择第一个可申请且与镜像类型匹配的节点；要求分散放置时由放置引擎选择节点
+func (s *Service) ApplyToRequest(userID uint, req *userModel.CreateInstanceRequest) error {
+	if req.ProviderId != 0 && req.ImageId != 0 && req.Tags != "" {
+		return nil
+	}
+
+	defaults, err := s.Get(userID)
+	if err != nil {
+		return fmt.Errorf("获取实例默认设置失败: %w", err)
+	}
+	if req.Tags == "" {
+		req.Tags = defaults.Tags
+	}
+	if req.ImageId == 0 {
+		req.ImageId = defaults.ImageID
+	}
+	if req.ImageId == 0 {
+		return ErrImageRequired
+	}
+	if req.ProviderId == 0 && req.Spread != "" {
+		// 分散放置时不使用首选节点，首选地区只在同等条件下优先
+		providerID, _, err := placement.Pick(userID, req.ImageId, req.GroupId, req.Spread, defaults.Region)
+		if err != nil && !errors.Is(err, placement.ErrNoCandidate) {
+			return err
+		}
+		req.ProviderId = providerID
+	}
+	if req.ProviderId == 0 {
+		req.ProviderId = defaults.ProviderID
+	}
+	if req.ProviderId == 0 && defaults.Region != "" {
+		req.ProviderId = pickProviderInRegion(defaults.Region, req.ImageId)
+	}
+	if req.ProviderId == 0 {
+		return ErrProviderRequired
+	}
+	return nil
+}
+
+// pickProviderInRegion 在地区中选择第一个可申请的节点，未找到时返回0
+func pickProviderInRegion(region string, imageID uint) uint {
+	query := global.APP_DB.Model(&providerModel.Provider{}).
+		Where("region = ? AND allow_claim = ? AND is_frozen = ? AND traffic_limited = ? AND status IN (?)",
+			region, true, false, false, []string{"active", "partial"})
+
+	var image systemModel.SystemImage
+	if err := global.APP_DB.Select("provider_type").First(&image, imageID).Error; err == nil && image.ProviderType != "" {
+		query = query.Where("type = ?", image.ProviderType)
+	}
+
+	var provider providerModel.Provider
+	if err := query.Select("id").Order("id ASC").First(&provider).Error; err != nil {
+		return 0
+	}
+	return provider.ID
+}
@@ -0,0 +1,114 @@
+package instancedefaults
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestNormalizeTimezone(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" Asia/Shanghai ", "Asia/Shanghai", false},
+		{"UTC", "UTC", false},
+		{"America/Argentina/Buenos_Aires", "America/Argentina/Buenos_Aires", false},
+		{"Local", "", true},
+		{"Asia/Nowhere", "", true},
+		{"Asia/Shanghai; reboot", "", true},
+		{"../etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeTimezone(tt.input)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("NormalizeTimezone(%q) = %q, %v", tt.input, got, err)
+			}
+		})
+	}
+}
+
+func TestApplyToRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if _, err := database.EnsureSQLiteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{Dbname: path}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&providerModel.Provider{}, &systemModel.SystemImage{}, &userModel.InstanceDefaults{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDB := global.APP_DB
+	global.APP_DB = db
+	defer func() { global.APP_DB = oldDB }()
+
+	image := systemModel.SystemImage{Name: "debian", ProviderType: "lxd", Status: "active"}
+	if err := db.Create(&image).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []providerModel.Provider{
+		{Name: "hk-docker", Type: "docker", Region: "hk", Status: "active", AllowClaim: true},
+		{Name: "hk-frozen", Type: "lxd", Region: "hk", Status: "active", AllowClaim: true, IsFrozen: true},
+		{Name: "hk-lxd", Type: "lxd", Region: "hk", Status: "active", AllowClaim: true},
+	} {
+		if err := db.Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewService()
+	req := userModel.CreateInstanceRequest{}
+	if err := service.ApplyToRequest(1, &req); !errors.Is(err, ErrImageRequired) {
+		t.Fatalf("没有默认设置时应要求选择镜像, got %v", err)
+	}
+
+	if err := service.Save(&userModel.InstanceDefaults{UserID: 1, ImageID: image.ID, Region: "hk", Tags: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	req = userModel.CreateInstanceRequest{}
+	if err := service.ApplyToRequest(1, &req); err != nil {
+		t.Fatal(err)
+	}
+	var picked providerModel.Provider
+	db.First(&picked, req.ProviderId)
+	if req.ImageId != image.ID || picked.Name != "hk-lxd" || req.Tags != "web" {
+		t.Errorf("应使用默认镜像和地区内匹配的节点, got image=%d provider=%s tags=%q", req.ImageId, picked.Name, req.Tags)
+	}
+
+	// 请求中填写的值优先，更新默认设置不会新增记录
+	if err := service.Save(&userModel.InstanceDefaults{UserID: 1, ImageID: image.ID, Region: "sg"}); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&userModel.InstanceDefaults{}).Count(&count)
+	if count != 1 {
+		t.Errorf("每个用户只应有一份默认设置, got %d", count)
+	}
+	req = userModel.CreateInstanceRequest{ProviderId: picked.ID, Tags: "db"}
+	if err := service.ApplyToRequest(1, &req); err != nil || req.ProviderId != picked.ID || req.Tags != "db" {
+		t.Errorf("请求中填写的值应优先, got %+v, %v", req, err)
+	}
+	req = userModel.CreateInstanceRequest{}
+	if err := service.ApplyToRequest(1, &req); !errors.Is(err, ErrProviderRequired) {
+		t.Errorf("地区内没有可用节点时应要求选择节点, got %v", err)
+	}
+
+	if err := service.Save(&userModel.InstanceDefaults{UserID: 1, ImageID: image.ID + 100}); !errors.Is(err, ErrImageUnavailable) {
+		t.Errorf("不存在的首选镜像应拒绝, got %v", err)
+	}
+}
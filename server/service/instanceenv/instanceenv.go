@@ -0,0 +1,231 @@
+// Package instanceenv 创建和重置实例时注入的环境变量与元数据
+// 系统元数据（用户等级、带宽规格、网络类型等）由Provider用于配置网络和端口，始终写入且不能被覆盖；
+// 管理员可通过 instance-defaults 配置按Provider、用户等级和实例类型追加模板化的环境变量与元数据，以及 LXD/Incus 配置文件
+package instanceenv
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/config"
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// Vars 实例上下文，字段同时作为模板变量，变量名见 Values
+type Vars struct {
+	Operation           string // create 或 reset
+	UserID              uint
+	Username            string
+	UserLevel           int
+	InstanceID          uint
+	InstanceName        string
+	InstanceType        string
+	Image               string
+	CPU                 int
+	MemoryMB            int64
+	DiskMB              int64
+	BandwidthMbps       int
+	GuestSSHPort        int  // 实例内sshd监听的端口
+	ResetFromInstanceID uint // 重置时的原实例ID
+
+	Provider *providerModel.Provider
+}
+
+// Values 返回模板中可使用的变量
+func (v Vars) Values() map[string]string {
+	values := map[string]string{
+		"operation":      v.Operation,
+		"user_id":        strconv.FormatUint(uint64(v.UserID), 10),
+		"username":       v.Username,
+		"user_level":     strconv.Itoa(v.UserLevel),
+		"instance_id":    strconv.FormatUint(uint64(v.InstanceID), 10),
+		"instance_name":  v.InstanceName,
+		"instance_type":  v.InstanceType,
+		"image":          v.Image,
+		"cpu":            strconv.Itoa(v.CPU),
+		"memory_mb":      strconv.FormatInt(v.MemoryMB, 10),
+		"disk_mb":        strconv.FormatInt(v.DiskMB, 10),
+		"bandwidth_mbps": strconv.Itoa(v.BandwidthMbps),
+		"guest_ssh_port": strconv.Itoa(constant.GuestSSHPortOrDefault(v.GuestSSHPort)),
+	}
+	if v.Provider != nil {
+		values["provider_id"] = strconv.FormatUint(uint64(v.Provider.ID), 10)
+		values["provider_name"] = v.Provider.Name
+		values["provider_type"] = v.Provider.Type
+	}
+	return values
+}
+
+// systemMetadata 系统写入的元数据
+func (v Vars) systemMetadata() map[string]string {
+	metadata := map[string]string{
+		"user_level":     strconv.Itoa(v.UserLevel),     // 用户等级，用于带宽限制配置
+		"bandwidth_spec": strconv.Itoa(v.BandwidthMbps), // 用户选择的带宽规格
+		"instance_id":    strconv.FormatUint(uint64(v.InstanceID), 10),
+		"guest_ssh_port": strconv.Itoa(constant.GuestSSHPortOrDefault(v.GuestSSHPort)), // 实例内sshd监听的端口，由Provider在配置SSH时应用
+	}
+	if v.Provider != nil {
+		metadata["ipv4_port_mapping_method"] = v.Provider.IPv4PortMappingMethod
+		metadata["ipv6_port_mapping_method"] = v.Provider.IPv6PortMappingMethod
+		metadata["network_type"] = v.Provider.NetworkType // nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
+		metadata["provider_id"] = strconv.FormatUint(uint64(v.Provider.ID), 10)
+	}
+	if v.ResetFromInstanceID > 0 {
+		metadata["reset_from_instance_id"] = strconv.FormatUint(uint64(v.ResetFromInstanceID), 10)
+	}
+	return metadata
+}
+
+// envKeyPattern 环境变量名格式
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Apply 将系统元数据和匹配规则渲染出的环境变量、元数据写入实例配置
+// 配置中已有的项（如重置时的 RESET_OPERATION）保持不变，渲染失败的单项记录警告后跳过，不影响实例创建
+func Apply(cfg *providerModel.ProviderInstanceConfig, vars Vars) {
+	if cfg.Env == nil {
+		cfg.Env = make(map[string]string)
+	}
+	if cfg.Metadata == nil {
+		cfg.Metadata = make(map[string]string)
+	}
+	system := vars.systemMetadata()
+	for key, value := range system {
+		cfg.Metadata[key] = value
+	}
+
+	env, metadata, errs := Render(global.APP_CONFIG.InstanceDefaults.Rules, vars)
+	for _, err := range errs {
+		global.APP_LOG.Warn("实例默认配置项渲染失败，已跳过",
+			zap.String("instance", vars.InstanceName), zap.Error(err))
+	}
+	for key, value := range env {
+		if _, exists := cfg.Env[key]; !exists {
+			cfg.Env[key] = value
+		}
+	}
+	for key, value := range metadata {
+		if _, reserved := system[key]; reserved {
+			global.APP_LOG.Warn("实例默认元数据不能覆盖系统元数据，已跳过", zap.String("key", key))
+			continue
+		}
+		if _, exists := cfg.Metadata[key]; !exists {
+			cfg.Metadata[key] = value
+		}
+	}
+	for _, profile := range Profiles(global.APP_CONFIG.InstanceDefaults.Rules, vars) {
+		if !slices.Contains(cfg.Profiles, profile) {
+			cfg.Profiles = append(cfg.Profiles, profile)
+		}
+	}
+}
+
+// Profiles 按顺序返回匹配规则中的配置文件，名称由Provider创建实例时校验
+func Profiles(rules []config.InstanceDefaultsRule, vars Vars) []string {
+	var profiles []string
+	for _, rule := range rules {
+		if !matches(rule, vars) {
+			continue
+		}
+		for _, profile := range rule.Profiles {
+			if profile = strings.TrimSpace(profile); profile != "" && !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles
+}
+
+// Render 按顺序渲染匹配的规则，后匹配的规则覆盖先匹配规则的同名项
+func Render(rules []config.InstanceDefaultsRule, vars Vars) (env, metadata map[string]string, errs []error) {
+	env = make(map[string]string)
+	metadata = make(map[string]string)
+	values := vars.Values()
+	for i, rule := range rules {
+		if !matches(rule, vars) {
+			continue
+		}
+		for _, entry := range rule.Env {
+			key, value, err := renderEntry(entry, values)
+			if err == nil && !envKeyPattern.MatchString(key) {
+				err = fmt.Errorf("环境变量名 %q 不合法", key)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("规则%d env: %w", i+1, err))
+				continue
+			}
+			env[key] = value
+		}
+		for _, entry := range rule.Metadata {
+			key, value, err := renderEntry(entry, values)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("规则%d metadata: %w", i+1, err))
+				continue
+			}
+			metadata[key] = value
+		}
+	}
+	return env, metadata, errs
+}
+
+// matches 判断规则是否适用于当前实例
+func matches(rule config.InstanceDefaultsRule, vars Vars) bool {
+	if len(rule.Providers) > 0 {
+		if vars.Provider == nil || !containsFold(rule.Providers, vars.Provider.Name) && !containsFold(rule.Providers, vars.Provider.Type) {
+			return false
+		}
+	}
+	if len(rule.Levels) > 0 {
+		matched := false
+		for _, level := range rule.Levels {
+			if level == vars.UserLevel {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(rule.InstanceTypes) > 0 && !containsFold(rule.InstanceTypes, vars.InstanceType) {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderEntry 解析 KEY=VALUE 并替换值中的 ${变量}，引用未定义的变量时返回错误
+func renderEntry(entry string, values map[string]string) (string, string, error) {
+	key, template, ok := strings.Cut(entry, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("%q 格式应为 KEY=VALUE", entry)
+	}
+	var unknown []string
+	value := os.Expand(template, func(name string) string {
+		v, exists := values[name]
+		if !exists {
+			unknown = append(unknown, name)
+		}
+		return v
+	})
+	if len(unknown) > 0 {
+		return "", "", fmt.Errorf("%s 引用了未定义的变量 %s", key, strings.Join(unknown, ", "))
+	}
+	return key, value, nil
+}
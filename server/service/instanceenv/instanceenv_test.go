@@ -0,0 +1,67 @@
+package instanceenv
+
+import (
+	"reflect"
+	"testing"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+func TestRender(t *testing.T) {
+	vars := Vars{
+		Operation:     "create",
+		Username:      "alice",
+		UserLevel:     2,
+		InstanceName:  "web1",
+		InstanceType:  "container",
+		BandwidthMbps: 100,
+		Provider:      &providerModel.Provider{Name: "hk-1", Type: "docker"},
+	}
+	rules := []config.InstanceDefaultsRule{
+		{Env: []string{"TZ=Asia/Shanghai", "OWNER=${username}"}},
+		{Providers: []string{"docker"}, Levels: []int{2}, Env: []string{"TZ=UTC"}, Metadata: []string{"in_speed=${bandwidth_mbps}"}},
+		{Providers: []string{"other"}, Env: []string{"SKIPPED=1"}},
+		{InstanceTypes: []string{"vm"}, Env: []string{"SKIPPED=1"}},
+		{Env: []string{"BAD=${missing}", "1BAD=x", "NOEQUALS"}},
+	}
+
+	env, metadata, errs := Render(rules, vars)
+	if env["TZ"] != "UTC" || env["OWNER"] != "alice" || env["SKIPPED"] != "" || env["BAD"] != "" {
+		t.Fatalf("env = %v", env)
+	}
+	if metadata["in_speed"] != "100" {
+		t.Fatalf("metadata = %v", metadata)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("errs = %v, want 3", errs)
+	}
+}
+
+func TestApplyKeepsSystemValues(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	saved := global.APP_CONFIG.InstanceDefaults
+	global.APP_CONFIG.InstanceDefaults.Rules = []config.InstanceDefaultsRule{
+		{Env: []string{"RESET_OPERATION=false", "LEVEL=${user_level}"}, Metadata: []string{"user_level=5", "storage=fast"}, Profiles: []string{"ocv-base"}},
+		{Levels: []int{1}, Profiles: []string{"ocv-base", "ocv-level1"}},
+		{Levels: []int{2}, Profiles: []string{"ocv-level2"}},
+	}
+	t.Cleanup(func() { global.APP_CONFIG.InstanceDefaults = saved })
+
+	cfg := providerModel.ProviderInstanceConfig{Env: map[string]string{"RESET_OPERATION": "true"}}
+	Apply(&cfg, Vars{Operation: "reset", UserLevel: 1, InstanceID: 7, ResetFromInstanceID: 3, Provider: &providerModel.Provider{NetworkType: "nat_ipv4"}})
+
+	if cfg.Env["RESET_OPERATION"] != "true" || cfg.Env["LEVEL"] != "1" {
+		t.Fatalf("env = %v", cfg.Env)
+	}
+	if cfg.Metadata["user_level"] != "1" || cfg.Metadata["storage"] != "fast" ||
+		cfg.Metadata["network_type"] != "nat_ipv4" || cfg.Metadata["reset_from_instance_id"] != "3" {
+		t.Fatalf("metadata = %v", cfg.Metadata)
+	}
+	if !reflect.DeepEqual(cfg.Profiles, []string{"ocv-base", "ocv-level1"}) {
+		t.Fatalf("profiles = %v", cfg.Profiles)
+	}
+}
@@ -0,0 +1,256 @@
+package instancegroup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/cache"
+	"oneclickvirt/service/database"
+	trafficService "oneclickvirt/service/traffic"
+	"oneclickvirt/service/user/instance"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxGroupsPerUser 每个用户最多可创建的分组数量
+	MaxGroupsPerUser = 50
+
+	maxTagCount  = 10
+	maxTagLength = 32
+)
+
+// ErrGroupNotFound 分组不存在或不属于当前用户
+var ErrGroupNotFound = errors.New("实例分组不存在")
+
+// 分组批量操作只允许启停类操作，删除、重置仍需逐个确认
+var groupActions = map[string]string{
+	"start":   "stopped",
+	"stop":    "running",
+	"restart": "running",
+}
+
+// ActionResult 分组批量操作中单个实例的结果
+type ActionResult struct {
+	InstanceID uint   `json:"instanceId"`
+	Name       string `json:"name"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped"`
+	Message    string `json:"message"`
+}
+
+// Service 实例分组服务
+type Service struct{}
+
+// NewService 创建实例分组服务
+func NewService() *Service {
+	return &Service{}
+}
+
+// NormalizeSSHPublicKey 校验SSH公钥并规范为单行 authorized_keys 格式，空字符串表示不设置
+func NormalizeSSHPublicKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", nil
+	}
+	pub, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return "", errors.New("SSH公钥格式无效")
+	}
+	if len(options) > 0 {
+		return "", errors.New("SSH公钥不能包含选项")
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return "", errors.New("只能设置一个SSH公钥")
+	}
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment = strings.TrimSpace(comment); comment != "" {
+		line += " " + comment
+	}
+	return line, nil
+}
+
+// NormalizeTags 去除空白和重复标签，返回逗号分隔的标签
+func NormalizeTags(tags string) (string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return "", fmt.Errorf("标签 %s 超过 %d 个字符", tag, maxTagLength)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTagCount {
+		return "", fmt.Errorf("最多只能设置 %d 个标签", maxTagCount)
+	}
+	joined := strings.Join(result, ",")
+	if len(joined) > 255 {
+		return "", errors.New("标签总长度过长")
+	}
+	return joined, nil
+}
+
+// GetOwnedGroup 获取属于用户的分组
+func (s *Service) GetOwnedGroup(userID, groupID uint) (*userModel.InstanceGroup, error) {
+	var group userModel.InstanceGroup
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", groupID, userID).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListGroups 获取用户的分组列表，附带组内实例数量和当月流量汇总
+func (s *Service) ListGroups(userID uint) ([]userModel.InstanceGroupResponse, error) {
+	var groups []userModel.InstanceGroup
+	if err := global.APP_DB.Where("user_id = ?", userID).Order("sort ASC, id ASC").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	result := make([]userModel.InstanceGroupResponse, 0, len(groups))
+	if len(groups) == 0 {
+		return result, nil
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id, group_id, status").
+		Where("user_id = ? AND group_id > 0 AND deleted_at IS NULL AND status NOT IN (?)", userID, []string{"deleting", "deleted", "failed"}).
+		Find(&instances).Error; err != nil {
+		return nil, err
+	}
+
+	instanceIDs := make([]uint, 0, len(instances))
+	for _, inst := range instances {
+		instanceIDs = append(instanceIDs, inst.ID)
+	}
+	now := time.Now()
+	trafficStats, err := trafficService.NewQueryService().BatchGetInstancesMonthlyTraffic(instanceIDs, now.Year(), int(now.Month()))
+	if err != nil {
+		// 流量统计失败不影响分组列表
+		global.APP_LOG.Warn("获取分组流量汇总失败", zap.Uint("userId", userID), zap.Error(err))
+		trafficStats = nil
+	}
+
+	index := make(map[uint]int, len(groups))
+	for i, group := range groups {
+		index[group.ID] = i
+		result = append(result, userModel.InstanceGroupResponse{InstanceGroup: group})
+	}
+	for _, inst := range instances {
+		i, ok := index[inst.GroupID]
+		if !ok {
+			continue
+		}
+		result[i].InstanceCount++
+		if inst.Status == "running" {
+			result[i].RunningCount++
+		}
+		if stats, ok := trafficStats[inst.ID]; ok && stats != nil {
+			result[i].RxBytes += stats.RxBytes
+			result[i].TxBytes += stats.TxBytes
+			result[i].TrafficMB += stats.ActualUsageMB
+		}
+	}
+	return result, nil
+}
+
+// DeleteGroup 删除分组，组内实例变为未分组
+func (s *Service) DeleteGroup(userID, groupID uint) error {
+	group, err := s.GetOwnedGroup(userID, groupID)
+	if err != nil {
+		return err
+	}
+	dbService := database.GetDatabaseService()
+	err = dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		if err := tx.Model(&providerModel.Instance{}).
+			Where("user_id = ? AND group_id = ?", userID, group.ID).
+			Update("group_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(group).Error
+	})
+	if err == nil {
+		cache.GetUserCacheService().InvalidateUserCache(userID)
+	}
+	return err
+}
+
+// AssignInstances 将实例移入分组，groupID为0时移出分组，返回实际更新的实例数
+func (s *Service) AssignInstances(userID, groupID uint, instanceIDs []uint) (int64, error) {
+	if groupID > 0 {
+		if _, err := s.GetOwnedGroup(userID, groupID); err != nil {
+			return 0, err
+		}
+	}
+	result := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("user_id = ? AND id IN ? AND deleted_at IS NULL", userID, instanceIDs).
+		Update("group_id", groupID)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	cache.GetUserCacheService().InvalidateUserCache(userID)
+	return result.RowsAffected, nil
+}
+
+// GroupAction 对分组内的实例批量执行启动、停止或重启
+// 状态不满足的实例跳过，单个实例失败不影响其他实例
+func (s *Service) GroupAction(userID, groupID uint, action string) ([]ActionResult, error) {
+	requiredStatus, ok := groupActions[action]
+	if !ok {
+		return nil, errors.New("分组只支持 start、stop、restart 操作")
+	}
+	if _, err := s.GetOwnedGroup(userID, groupID); err != nil {
+		return nil, err
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("user_id = ? AND group_id = ? AND deleted_at IS NULL AND status NOT IN (?)",
+		userID, groupID, []string{"deleting", "deleted", "failed"}).
+		Order("id ASC").Find(&instances).Error; err != nil {
+		return nil, err
+	}
+
+	instanceService := instance.NewService()
+	results := make([]ActionResult, 0, len(instances))
+	for _, inst := range instances {
+		result := ActionResult{InstanceID: inst.ID, Name: inst.Name}
+		switch {
+		case inst.Status != requiredStatus:
+			result.Skipped = true
+			result.Message = fmt.Sprintf("当前状态为 %s，已跳过", inst.Status)
+		case inst.IsFrozen:
+			result.Skipped = true
+			result.Message = "实例已冻结，已跳过"
+		default:
+			if err := instanceService.InstanceAction(userID, userModel.InstanceActionRequest{InstanceID: inst.ID, Action: action}); err != nil {
+				result.Message = err.Error()
+			} else {
+				result.Success = true
+				result.Message = "操作已提交"
+			}
+		}
+		results = append(results, result)
+	}
+
+	global.APP_LOG.Info("分组批量操作完成",
+		zap.Uint("userId", userID),
+		zap.Uint("groupId", groupID),
+		zap.String("action", action),
+		zap.Int("instances", len(instances)))
+	return results, nil
+}
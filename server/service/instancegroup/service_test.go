@@ -0,0 +1,57 @@
+package instancegroup
+
+import (
+	"strings"
+	"testing"
+)
+
+const testPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGv39UUjVXpRHhiaGaFFYAJSEldwuqiiE9ibAu5oZOP8"
+
+func TestNormalizeSSHPublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", input: "  ", want: ""},
+		{name: "plain", input: testPublicKey, want: testPublicKey},
+		{name: "comment and whitespace", input: "\n" + testPublicKey + "   me@host \n", want: testPublicKey + " me@host"},
+		{name: "invalid", input: "ssh-ed25519 not-base64", wantErr: true},
+		{name: "options", input: `command="rm -rf /" ` + testPublicKey, wantErr: true},
+		{name: "multiple keys", input: testPublicKey + "\n" + testPublicKey, wantErr: true},
+		{name: "quote in comment", input: testPublicKey + " x'; rm -rf ~; echo '", want: testPublicKey + " x'; rm -rf ~; echo '"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeSSHPublicKey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags(" web, prod ,,web,测试 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "web,prod,测试" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := NormalizeTags(strings.Repeat("x", maxTagLength+1)); err == nil {
+		t.Error("expected error for long tag")
+	}
+	many := make([]string, maxTagCount+1)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	if _, err := NormalizeTags(strings.Join(many, ",")); err == nil {
+		t.Error("expected error for too many tags")
+	}
+}
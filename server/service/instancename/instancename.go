@@ -0,0 +1,184 @@
+// Package instancename 实例名称唯一性
+// 实例表上 name+provider_id 的唯一索引同样覆盖软删除的记录。删除或重置后的旧记录会在需要时改名为
+// <原名>_deleted_<时间戳>_<ID> 释放索引，新实例在写入前于同一事务内预留名称：锁定Provider行串行化同一节点上的预留，
+// 检查未删除的同名实例和冷却期内删除的同名实例，再为仍占用原名的旧记录改名
+package instancename
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 实例名称最大长度，与实例表 name 字段一致
+const maxNameLength = 128
+
+var (
+	// ErrNameTaken 同一节点上已有同名实例
+	ErrNameTaken = errors.New("该节点上已存在同名实例")
+	// ErrNameCoolingDown 同名实例删除后仍在重用冷却期内
+	ErrNameCoolingDown = errors.New("同名实例删除后仍在重用冷却期内")
+)
+
+// 已释放名称的后缀，兼容旧版本只带时间戳的格式
+var deletedSuffixPattern = regexp.MustCompile(`_deleted_\d+(_\d+)?$`)
+
+// ReserveOptions 预留选项
+type ReserveOptions struct {
+	// IgnoreCooldown 不检查重用冷却期，用于重置实例沿用原名和导入宿主机上已存在的实例
+	IgnoreCooldown bool
+}
+
+// Conflict 名称冲突详情
+type Conflict struct {
+	Available   bool       `json:"available"`             // 名称是否可用
+	Reason      string     `json:"reason,omitempty"`      // 不可用原因
+	InstanceID  uint       `json:"instanceId,omitempty"`  // 冲突的实例ID
+	AvailableAt *time.Time `json:"availableAt,omitempty"` // 冷却期结束时间
+}
+
+// Cooldown 返回同名实例的重用冷却时间
+func Cooldown() time.Duration {
+	if minutes := global.APP_CONFIG.Task.NameReuseCooldown; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 0
+}
+
+// DeletedName 返回软删除记录释放名称后使用的名称，带上记录ID避免同一秒内多次释放同名记录时冲突
+func DeletedName(name string, id uint, now time.Time) string {
+	suffix := fmt.Sprintf("_deleted_%d_%d", now.Unix(), id)
+	if len(name)+len(suffix) > maxNameLength {
+		name = name[:maxNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// OriginalName 返回释放名称前的原始名称，未释放的名称原样返回
+func OriginalName(name string) string {
+	return deletedSuffixPattern.ReplaceAllString(name, "")
+}
+
+// Release 在事务内将即将软删除的实例改名，释放 name+provider_id 唯一索引
+func Release(tx *gorm.DB, instance *providerModel.Instance) error {
+	return tx.Model(instance).Update("name", DeletedName(instance.Name, instance.ID, time.Now())).Error
+}
+
+// Check 检查名称在节点上是否可用，不修改任何记录
+func Check(db *gorm.DB, providerID uint, name string, opts ReserveOptions) (*Conflict, error) {
+	var live providerModel.Instance
+	err := db.Select("id").Where("provider_id = ? AND name = ?", providerID, name).First(&live).Error
+	if err == nil {
+		return &Conflict{Reason: ErrNameTaken.Error(), InstanceID: live.ID}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询同名实例失败: %w", err)
+	}
+
+	cooldown := Cooldown()
+	if opts.IgnoreCooldown || cooldown <= 0 {
+		return &Conflict{Available: true}, nil
+	}
+
+	deleted, err := deletedWithName(db, providerID, name)
+	if err != nil {
+		return nil, err
+	}
+	var latest *providerModel.Instance
+	for i := range deleted {
+		if latest == nil || deleted[i].DeletedAt.Time.After(latest.DeletedAt.Time) {
+			latest = &deleted[i]
+		}
+	}
+	if latest != nil {
+		availableAt := latest.DeletedAt.Time.Add(cooldown)
+		if time.Now().Before(availableAt) {
+			return &Conflict{Reason: ErrNameCoolingDown.Error(), InstanceID: latest.ID, AvailableAt: &availableAt}, nil
+		}
+	}
+	return &Conflict{Available: true}, nil
+}
+
+// Reserve 在事务内为节点预留实例名称，必须在创建实例记录的同一事务中调用
+func Reserve(tx *gorm.DB, providerID uint, name string, opts ReserveOptions) error {
+	// 锁定Provider行，同一节点上的名称预留串行执行（SQLite写事务本身已串行）
+	var provider providerModel.Provider
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&provider, providerID).Error; err != nil {
+		return fmt.Errorf("锁定节点失败: %w", err)
+	}
+
+	conflict, err := Check(tx, providerID, name, opts)
+	if err != nil {
+		return err
+	}
+	if !conflict.Available {
+		if conflict.AvailableAt != nil {
+			return fmt.Errorf("%w，可在 %s 后使用", ErrNameCoolingDown, conflict.AvailableAt.Format("2006-01-02 15:04:05"))
+		}
+		return ErrNameTaken
+	}
+
+	// 释放仍占用原名的软删除记录
+	var holders []providerModel.Instance
+	if err := tx.Unscoped().Select("id", "name").
+		Where("provider_id = ? AND name = ? AND deleted_at IS NOT NULL", providerID, name).
+		Find(&holders).Error; err != nil {
+		return fmt.Errorf("查询已删除的同名实例失败: %w", err)
+	}
+	now := time.Now()
+	for _, holder := range holders {
+		if err := tx.Unscoped().Model(&providerModel.Instance{}).Where("id = ?", holder.ID).
+			Update("name", DeletedName(holder.Name, holder.ID, now)).Error; err != nil {
+			return fmt.Errorf("释放已删除实例的名称失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReserveGenerated 使用生成函数为新实例预留名称，名称冲突时重新生成，最多尝试 attempts 次
+func ReserveGenerated(tx *gorm.DB, providerID uint, generate func() string, attempts int) (string, error) {
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		name := generate()
+		err := Reserve(tx, providerID, name, ReserveOptions{})
+		if err == nil {
+			return name, nil
+		}
+		if !errors.Is(err, ErrNameTaken) && !errors.Is(err, ErrNameCoolingDown) {
+			return "", err
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("生成可用的实例名称失败: %w", lastErr)
+}
+
+// deletedWithName 查询节点上原名为 name 的软删除记录，包括已改名释放的记录
+func deletedWithName(db *gorm.DB, providerID uint, name string) ([]providerModel.Instance, error) {
+	var candidates []providerModel.Instance
+	if err := db.Unscoped().Select("id", "name", "deleted_at").
+		Where("provider_id = ? AND deleted_at IS NOT NULL AND (name = ? OR name LIKE ? ESCAPE '!')",
+			providerID, name, escapeLike(name)+"!_deleted!_%").
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("查询已删除的同名实例失败: %w", err)
+	}
+	result := candidates[:0]
+	for _, inst := range candidates {
+		if OriginalName(inst.Name) == name {
+			result = append(result, inst)
+		}
+	}
+	return result, nil
+}
+
+// escapeLike 转义 LIKE 模式中的通配符，转义字符为 !
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,121 @@
+package instancename
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDeletedAndOriginalName(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	deleted := DeletedName("node-ab12", 42, now)
+	if deleted != "node-ab12_deleted_1700000000_42" {
+		t.Fatalf("DeletedName = %q", deleted)
+	}
+	for _, name := range []string{deleted, "node-ab12_deleted_1700000000", "node-ab12"} {
+		if got := OriginalName(name); got != "node-ab12" {
+			t.Errorf("OriginalName(%q) = %q", name, got)
+		}
+	}
+	long := DeletedName(strings.Repeat("a", 128), 1, now)
+	if len(long) > maxNameLength {
+		t.Errorf("DeletedName 超出长度限制: %d", len(long))
+	}
+	if got := escapeLike("a_b%c!"); got != "a!_b!%c!!" {
+		t.Errorf("escapeLike = %q", got)
+	}
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	if _, err := database.EnsureSQLiteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{Dbname: path}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&providerModel.Provider{}, &providerModel.Instance{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestReserve(t *testing.T) {
+	db := openTestDB(t)
+	provider := providerModel.Provider{Name: "node", Type: "lxd"}
+	if err := db.Create(&provider).Error; err != nil {
+		t.Fatal(err)
+	}
+	create := func(name string) providerModel.Instance {
+		inst := providerModel.Instance{Name: name, Provider: provider.Name, ProviderID: provider.ID}
+		if err := db.Create(&inst).Error; err != nil {
+			t.Fatalf("创建实例 %s 失败: %v", name, err)
+		}
+		return inst
+	}
+
+	live := create("web")
+	if err := Reserve(db, provider.ID, "web", ReserveOptions{}); !errors.Is(err, ErrNameTaken) {
+		t.Fatalf("同名未删除实例应冲突, got %v", err)
+	}
+
+	// 软删除后仍占用原名，预留时释放
+	if err := db.Delete(&live).Error; err != nil {
+		t.Fatal(err)
+	}
+	global.APP_CONFIG.Task.NameReuseCooldown = 0
+	if err := Reserve(db, provider.ID, "web", ReserveOptions{}); err != nil {
+		t.Fatalf("无冷却期时应可重用, got %v", err)
+	}
+	reused := create("web")
+
+	// 冷却期内不可重用，重置和导入不受限制
+	if err := Release(db, &reused); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&reused).Error; err != nil {
+		t.Fatal(err)
+	}
+	global.APP_CONFIG.Task.NameReuseCooldown = 10
+	defer func() { global.APP_CONFIG.Task.NameReuseCooldown = 0 }()
+
+	conflict, err := Check(db, provider.ID, "web", ReserveOptions{})
+	if err != nil || conflict.Available || conflict.AvailableAt == nil {
+		t.Fatalf("冷却期内应不可用, got %+v, %v", conflict, err)
+	}
+	if err := Reserve(db, provider.ID, "web", ReserveOptions{}); !errors.Is(err, ErrNameCoolingDown) {
+		t.Fatalf("冷却期内应拒绝, got %v", err)
+	}
+	if err := Reserve(db, provider.ID, "web", ReserveOptions{IgnoreCooldown: true}); err != nil {
+		t.Fatalf("忽略冷却期时应可预留, got %v", err)
+	}
+	create("web")
+
+	// 其他名称不受影响，相似名称不会误判
+	if err := Reserve(db, provider.ID, "web2", ReserveOptions{}); err != nil {
+		t.Fatalf("其他名称应可用, got %v", err)
+	}
+
+	// 生成名称冲突时重新生成
+	names := []string{"web", "api"}
+	name, err := ReserveGenerated(db, provider.ID, func() string {
+		n := names[0]
+		names = names[1:]
+		return n
+	}, 2)
+	if err != nil || name != "api" {
+		t.Fatalf("ReserveGenerated = %q, %v", name, err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package livestate 查询实例时从节点获取实时状态
+// 同一节点上的实例通过一次 ListInstances 获取，多个节点并行查询并共用一个等待时限；
+// 节点数据短时间缓存，数据库中的状态与节点明显不一致时按节点数据修正
+package livestate
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+)
+
+// 实时状态的来源
+const (
+	SourceLive        = "live"        // 本次从节点获取
+	SourceCached      = "cached"      // 使用几秒内从节点获取的数据
+	SourceUnavailable = "unavailable" // 节点未能在时限内返回
+)
+
+// 取自节点的字段
+const (
+	FieldStatus    = "status"
+	FieldPrivateIP = "privateIP"
+	FieldIPv6      = "ipv6Address"
+)
+
+const (
+	// Budget 一次查询等待节点返回的总时限，超时的节点按不可用处理，返回后的数据仍会写入缓存
+	Budget = 5 * time.Second
+	// fetchTimeout 单个节点获取实例列表的超时时间
+	fetchTimeout = 30 * time.Second
+	// cacheTTL 节点数据的缓存时间，避免频繁刷新列表时反复连接节点
+	cacheTTL = 15 * time.Second
+	// maxParallel 同时查询的节点数
+	maxParallel = 8
+)
+
+// snapshot 某一时刻节点上的实例列表
+type snapshot struct {
+	instances map[string]provider.Instance // 按实例名称索引
+	fetchedAt time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[uint]*snapshot)
+)
+
+// listProviderInstances 读取节点上的实例列表
+var listProviderInstances = func(ctx context.Context, providerID uint) ([]provider.Instance, error) {
+	prov, _, err := (&providerService.ProviderApiService{}).GetProviderByID(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return prov.ListInstances(ctx)
+}
+
+// Refresh 从节点获取实例的实时状态，用节点数据覆盖 instances 中的状态和IP，返回按实例ID索引的来源说明
+// 数据库中的状态与节点明显不一致（如记录为运行中而节点上已停止，且实例没有进行中的任务）时同时修正数据库
+func Refresh(instances []providerModel.Instance) map[uint]*userModel.InstanceLiveState {
+	result := make(map[uint]*userModel.InstanceLiveState, len(instances))
+	if len(instances) == 0 {
+		return result
+	}
+
+	var providerIDs, instanceIDs []uint
+	seen := make(map[uint]bool)
+	for _, inst := range instances {
+		instanceIDs = append(instanceIDs, inst.ID)
+		if inst.ProviderID > 0 && !seen[inst.ProviderID] {
+			seen[inst.ProviderID] = true
+			providerIDs = append(providerIDs, inst.ProviderID)
+		}
+	}
+	snaps, sources, errs := fetchAll(providerIDs, time.Now())
+	busy := busyInstances(instanceIDs)
+
+	for i := range instances {
+		inst := &instances[i]
+		state := &userModel.InstanceLiveState{Source: SourceUnavailable, Fields: []string{}}
+		result[inst.ID] = state
+
+		snap, ok := snaps[inst.ProviderID]
+		if !ok {
+			state.Error = errs[inst.ProviderID]
+			if state.Error == "" {
+				state.Error = "节点未在时限内返回"
+			}
+			continue
+		}
+		fetchedAt := snap.fetchedAt
+		state.Source = sources[inst.ProviderID]
+		state.FetchedAt = &fetchedAt
+
+		remote, found := snap.instances[inst.Name]
+		if !found {
+			state.Error = "节点上未找到该实例"
+			continue
+		}
+		oldStatus := inst.Status
+		updates := overlay(inst, remote, state, busy[inst.ID])
+		if len(updates) == 0 {
+			continue
+		}
+		// 以读取时的状态为条件更新，期间状态已被其他操作修改时不覆盖
+		if err := global.APP_DB.Model(&providerModel.Instance{}).
+			Where("id = ? AND status = ?", inst.ID, oldStatus).Updates(updates).Error; err != nil {
+			global.APP_LOG.Warn("按节点实时状态修正实例失败", zap.Uint("instanceId", inst.ID), zap.Error(err))
+			state.Reconciled = nil
+			continue
+		}
+		global.APP_LOG.Info("按节点实时状态修正实例",
+			zap.Uint("instanceId", inst.ID),
+			zap.String("name", inst.Name),
+			zap.Strings("fields", state.Reconciled),
+			zap.String("oldStatus", oldStatus),
+			zap.String("status", inst.Status))
+	}
+	return result
+}
+
+// fetchAll 并行获取各节点的实例列表，最多等待 Budget，返回节点数据、数据来源和获取失败的原因
+func fetchAll(providerIDs []uint, now time.Time) (map[uint]*snapshot, map[uint]string, map[uint]string) {
+	snaps := make(map[uint]*snapshot, len(providerIDs))
+	sources := make(map[uint]string, len(providerIDs))
+	errs := make(map[uint]string)
+
+	type outcome struct {
+		providerID uint
+		snap       *snapshot
+		err        error
+	}
+	ch := make(chan outcome, len(providerIDs))
+	sem := make(chan struct{}, maxParallel)
+	pending := 0
+	for _, id := range providerIDs {
+		if snap := cached(id, now); snap != nil {
+			snaps[id] = snap
+			sources[id] = SourceCached
+			continue
+		}
+		pending++
+		go func(id uint) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			snap, err := fetch(id)
+			ch <- outcome{providerID: id, snap: snap, err: err}
+		}(id)
+	}
+
+	timer := time.NewTimer(Budget)
+	defer timer.Stop()
+	for ; pending > 0; pending-- {
+		select {
+		case o := <-ch:
+			if o.err != nil {
+				errs[o.providerID] = o.err.Error()
+				continue
+			}
+			snaps[o.providerID] = o.snap
+			sources[o.providerID] = SourceLive
+		case <-timer.C:
+			return snaps, sources, errs
+		}
+	}
+	return snaps, sources, errs
+}
+
+// cached 返回未过期的节点数据
+func cached(providerID uint, now time.Time) *snapshot {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if snap, ok := cache[providerID]; ok && now.Sub(snap.fetchedAt) < cacheTTL {
+		return snap
+	}
+	return nil
+}
+
+// fetch 从节点获取实例列表并写入缓存
+func fetch(providerID uint) (*snapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	list, err := listProviderInstances(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	snap := &snapshot{instances: make(map[string]provider.Instance, len(list)), fetchedAt: time.Now()}
+	for _, inst := range list {
+		snap.instances[inst.Name] = inst
+	}
+	cacheMu.Lock()
+	cache[providerID] = snap
+	cacheMu.Unlock()
+	return snap, nil
+}
+
+// busyInstances 返回有进行中任务的实例，这些实例的状态由任务负责更新，不做修正
+func busyInstances(instanceIDs []uint) map[uint]bool {
+	busy := make(map[uint]bool)
+	var ids []uint
+	if err := global.APP_DB.Model(&adminModel.Task{}).
+		Where("instance_id IN ? AND status IN ?", instanceIDs, []string{"pending", "processing", "running"}).
+		Distinct().Pluck("instance_id", &ids).Error; err != nil {
+		// 无法确认时按全部有任务处理，只展示不修正
+		for _, id := range instanceIDs {
+			busy[id] = true
+		}
+		return busy
+	}
+	for _, id := range ids {
+		busy[id] = true
+	}
+	return busy
+}
+
+// overlay 用节点数据覆盖实例的状态和IP并记录来源，返回需要写回数据库的修正
+// 状态只在运行中、已停止之间（以及节点恢复后的不可用状态）修正；IP只补充数据库中为空的值
+func overlay(inst *providerModel.Instance, remote provider.Instance, state *userModel.InstanceLiveState, busy bool) map[string]interface{} {
+	updates := make(map[string]interface{})
+
+	if status := normalizeStatus(remote.Status); status != "" {
+		state.Fields = append(state.Fields, FieldStatus)
+		if status != inst.Status {
+			if !busy && reconcilable(inst.Status) && reconcilable(status) {
+				updates["status"] = status
+				state.Reconciled = append(state.Reconciled, FieldStatus)
+			}
+			inst.Status = status
+		}
+	}
+
+	privateIP := remote.PrivateIP
+	if privateIP == "" {
+		privateIP = remote.IP
+	}
+	if privateIP != "" {
+		state.Fields = append(state.Fields, FieldPrivateIP)
+		if inst.PrivateIP == "" {
+			updates["private_ip"] = privateIP
+			state.Reconciled = append(state.Reconciled, FieldPrivateIP)
+		}
+		inst.PrivateIP = privateIP
+	}
+
+	if remote.IPv6Address != "" {
+		state.Fields = append(state.Fields, FieldIPv6)
+		if inst.IPv6Address == "" {
+			updates["ipv6_address"] = remote.IPv6Address
+			state.Reconciled = append(state.Reconciled, FieldIPv6)
+		}
+		inst.IPv6Address = remote.IPv6Address
+	}
+	return updates
+}
+
+// reconcilable 可以按节点数据修正的状态
+func reconcilable(status string) bool {
+	switch status {
+	case constant.InstanceStatusRunning, constant.InstanceStatusStopped, constant.InstanceStatusUnavailable:
+		return true
+	}
+	return false
+}
+
+// normalizeStatus 将各虚拟化平台返回的状态统一为实例状态，无法识别时返回空
+func normalizeStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "running", "up":
+		return constant.InstanceStatusRunning
+	case "stopped", "exited", "shutoff", "stop":
+		return constant.InstanceStatusStopped
+	case "paused", "frozen":
+		return constant.InstanceStatusPaused
+	}
+	return ""
+}
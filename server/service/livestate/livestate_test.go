@@ -0,0 +1,90 @@
+package livestate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+)
+
+func TestOverlay(t *testing.T) {
+	// 数据库记录为运行中，节点上已停止：修正状态并补充缺失的IP
+	inst := providerModel.Instance{Status: "running", IPv6Address: "fd00::2"}
+	state := &userModel.InstanceLiveState{}
+	updates := overlay(&inst, provider.Instance{Status: "Stopped", IP: "10.0.0.2", IPv6Address: "fd00::3"}, state, false)
+	if inst.Status != "stopped" || inst.PrivateIP != "10.0.0.2" || inst.IPv6Address != "fd00::3" {
+		t.Fatalf("覆盖字段错误: %+v", inst)
+	}
+	if updates["status"] != "stopped" || updates["private_ip"] != "10.0.0.2" || updates["ipv6_address"] != nil {
+		t.Fatalf("修正内容错误: %v", updates)
+	}
+	if !slices.Equal(state.Fields, []string{FieldStatus, FieldPrivateIP, FieldIPv6}) ||
+		!slices.Equal(state.Reconciled, []string{FieldStatus, FieldPrivateIP}) {
+		t.Fatalf("来源说明错误: %+v", state)
+	}
+
+	// 有进行中的任务时只展示不修正
+	inst = providerModel.Instance{Status: "stopped", PrivateIP: "10.0.0.2"}
+	state = &userModel.InstanceLiveState{}
+	updates = overlay(&inst, provider.Instance{Status: "running"}, state, true)
+	if inst.Status != "running" || len(updates) != 0 || len(state.Reconciled) != 0 {
+		t.Fatalf("有任务时不应修正: %v %+v", updates, state)
+	}
+
+	// 过渡状态和无法识别的状态不修正
+	inst = providerModel.Instance{Status: "restarting"}
+	if updates = overlay(&inst, provider.Instance{Status: "running"}, &userModel.InstanceLiveState{}, false); len(updates) != 0 {
+		t.Fatalf("过渡状态不应修正: %v", updates)
+	}
+	inst = providerModel.Instance{Status: "running"}
+	state = &userModel.InstanceLiveState{}
+	if updates = overlay(&inst, provider.Instance{Status: "weird"}, state, false); len(updates) != 0 || inst.Status != "running" || len(state.Fields) != 0 {
+		t.Fatalf("无法识别的状态不应覆盖: %v %+v", updates, state)
+	}
+	// 暂停状态只展示
+	if updates = overlay(&inst, provider.Instance{Status: "FROZEN"}, &userModel.InstanceLiveState{}, false); len(updates) != 0 || inst.Status != "paused" {
+		t.Fatalf("暂停状态处理错误: %v %+v", updates, inst)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	var calls atomic.Int32
+	old := listProviderInstances
+	listProviderInstances = func(ctx context.Context, providerID uint) ([]provider.Instance, error) {
+		calls.Add(1)
+		if providerID == 2 {
+			return nil, errors.New("连接失败")
+		}
+		return []provider.Instance{{Name: "web", Status: "running"}}, nil
+	}
+	defer func() {
+		listProviderInstances = old
+		cacheMu.Lock()
+		cache = make(map[uint]*snapshot)
+		cacheMu.Unlock()
+	}()
+
+	snaps, sources, errs := fetchAll([]uint{1, 2}, time.Now())
+	if sources[1] != SourceLive || snaps[1].instances["web"].Status != "running" {
+		t.Fatalf("获取节点数据错误: %v %v", sources, snaps)
+	}
+	if _, ok := snaps[2]; ok || errs[2] != "连接失败" {
+		t.Fatalf("失败的节点处理错误: %v", errs)
+	}
+
+	// 缓存期内不再连接节点，失败的节点不缓存
+	snaps, sources, _ = fetchAll([]uint{1, 2}, time.Now())
+	if sources[1] != SourceCached || snaps[1] == nil || calls.Load() != 3 {
+		t.Fatalf("缓存处理错误: sources=%v calls=%d", sources, calls.Load())
+	}
+	// 缓存过期后重新获取
+	if _, sources, _ = fetchAll([]uint{1}, time.Now().Add(cacheTTL)); sources[1] != SourceLive {
+		t.Fatalf("缓存过期后应重新获取: %v", sources)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/csv"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/storage"
 	"strconv"
 
 	"oneclickvirt/global"
@@ -77,6 +78,7 @@ func (s *ExportService) ExportUsers(req auth.ExportUsersRequest) ([]byte, string
 			zap.String("format", "csv"),
 			zap.Int("userCount", len(users)),
 			zap.Int("dataSize", len(data)))
+		storage.ArchiveExport("users.csv", data)
 		return data, "users.csv", err
 	default:
 		global.APP_LOG.Warn("不支持的导出格式", zap.String("format", req.Format))
@@ -261,6 +263,7 @@ func (s *ExportService) ExportOperationLogs(req auth.ExportOperationLogsRequest)
 			zap.String("format", "csv"),
 			zap.Int("logCount", len(logs)),
 			zap.Int("dataSize", len(data)))
+		storage.ArchiveExport("operation_logs.csv", data)
 		return data, "operation_logs.csv", err
 	default:
 		global.APP_LOG.Warn("不支持的日志导出格式", zap.String("format", req.Format))
@@ -527,6 +527,15 @@ func (s *LogRotationService) CleanupOldLogs() error {
 		errorCount := 0
 		for dateDir := range toDelete {
 			dirPath := filepath.Join(logConfig.BaseDir, dateDir)
+			// 配置了远程对象存储时，删除前先归档，归档失败则保留目录等待下次清理
+			if storage.IsRemoteObjectStorage() {
+				if err := archiveLogDir(dateDir, dirPath); err != nil {
+					global.APP_LOG.Warn("归档过期日志目录失败，暂不删除",
+						zap.String("dir", dirPath),
+						zap.Error(err))
+					continue
+				}
+			}
 			if err := os.RemoveAll(dirPath); err != nil {
 				errorCount++
 				if errorCount == 1 {
@@ -549,7 +558,25 @@ func (s *LogRotationService) CleanupOldLogs() error {
 
 		return nil
 	})
-} // GetLogFiles 获取日志文件列表（按日期分文件夹结构）
+}
+
+// archiveLogDir 将日期日志目录打包归档到对象存储
+func archiveLogDir(dateDir, dirPath string) error {
+	store, err := storage.GetObjectStorage()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	key := storage.ObjectKey(storage.ObjectPrefixLogs, dateDir+".tar.gz")
+	if err := storage.ArchiveDirectory(ctx, store, key, dirPath); err != nil {
+		return err
+	}
+	global.APP_LOG.Info("日志目录已归档到对象存储", zap.String("key", key))
+	return nil
+}
+
+// GetLogFiles 获取日志文件列表（按日期分文件夹结构）
 func (s *LogRotationService) GetLogFiles() ([]LogFileInfo, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -0,0 +1,381 @@
+// Package netprobe 节点网络质量测试
+// 在Provider宿主机上执行限量限时的下载测速，并对配置的目标执行ping和mtr（宿主机已安装时），
+// 结果按次保存，最近一次成功的结果以摘要形式展示给用户
+package netprobe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/utils"
+)
+
+const (
+	defaultSpeedTestMaxMB   = 100
+	defaultSpeedTestSeconds = 15
+	maxSpeedTestSeconds     = 60
+	defaultPingCount        = 10
+	maxPingCount            = 50
+	maxTargets              = 10
+	mtrCycles               = 3
+
+	// 测试记录保留时间
+	recordRetention = 90 * 24 * time.Hour
+)
+
+var (
+	// ErrNothingToProbe 没有配置探测目标和测速地址
+	ErrNothingToProbe = errors.New("未配置探测目标和测速地址")
+
+	hostPattern       = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.:-]*[A-Za-z0-9])?$`)
+	pingCountPattern  = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	pingLossPattern   = regexp.MustCompile(`([\d.]+)% packet loss`)
+	pingRttPattern    = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)`)
+	mtrHopLinePattern = regexp.MustCompile(`^\s*(\d+)\.\|--\s+(\S+)\s+([\d.]+)%?\s+\d+\s+[\d.]+\s+([\d.]+)`)
+)
+
+// Target 探测目标
+type Target struct {
+	Name string `json:"name"`
+	Host string `json:"host"`
+}
+
+// Options 一次测试的参数
+type Options struct {
+	Targets          []Target
+	SpeedTestURL     string
+	SpeedTestMaxMB   int
+	SpeedTestSeconds int
+	PingCount        int
+}
+
+// CommandRunner 在宿主机上执行命令，provider.Provider 满足该接口
+type CommandRunner interface {
+	ExecuteSSHCommand(ctx context.Context, command string) (string, error)
+}
+
+// ParseTargets 解析 "名称=地址" 或 "地址" 格式的目标列表，地址只允许域名或IP
+func ParseTargets(items []string) ([]Target, error) {
+	targets := make([]Target, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, host, ok := strings.Cut(item, "=")
+		if !ok {
+			name, host = item, item
+		}
+		name, host = strings.TrimSpace(name), strings.TrimSpace(host)
+		if len(host) > 253 || !hostPattern.MatchString(host) {
+			return nil, fmt.Errorf("探测目标 %q 的地址无效", item)
+		}
+		if name == "" {
+			name = host
+		}
+		targets = append(targets, Target{Name: utils.TruncateString(name, 64), Host: host})
+	}
+	if len(targets) > maxTargets {
+		return nil, fmt.Errorf("探测目标最多 %d 个", maxTargets)
+	}
+	return targets, nil
+}
+
+// ResolveOptions 用任务指定的目标和测速地址覆盖配置，返回本次测试的参数
+func ResolveOptions(targets []string, speedTestURL string) (Options, error) {
+	cfg := global.APP_CONFIG.NetworkProbe
+	if len(targets) == 0 {
+		targets = cfg.Targets
+	}
+	if speedTestURL == "" {
+		speedTestURL = cfg.SpeedTestURL
+	}
+	parsed, err := ParseTargets(targets)
+	if err != nil {
+		return Options{}, err
+	}
+	speedTestURL = strings.TrimSpace(speedTestURL)
+	if speedTestURL != "" && !isHTTPURL(speedTestURL) {
+		return Options{}, fmt.Errorf("测速地址必须是 http(s) 地址")
+	}
+	if len(parsed) == 0 && speedTestURL == "" {
+		return Options{}, ErrNothingToProbe
+	}
+
+	opts := Options{
+		Targets:          parsed,
+		SpeedTestURL:     speedTestURL,
+		SpeedTestMaxMB:   cfg.SpeedTestMaxMB,
+		SpeedTestSeconds: cfg.SpeedTestSeconds,
+		PingCount:        cfg.PingCount,
+	}
+	if opts.SpeedTestMaxMB <= 0 {
+		opts.SpeedTestMaxMB = defaultSpeedTestMaxMB
+	}
+	if opts.SpeedTestSeconds <= 0 {
+		opts.SpeedTestSeconds = defaultSpeedTestSeconds
+	}
+	opts.SpeedTestSeconds = min(opts.SpeedTestSeconds, maxSpeedTestSeconds)
+	if opts.PingCount <= 0 {
+		opts.PingCount = defaultPingCount
+	}
+	opts.PingCount = min(opts.PingCount, maxPingCount)
+	return opts, nil
+}
+
+// Run 在宿主机上执行测速和各目标的探测，progress 报告0-100的进度
+// 返回的记录未保存，测速和所有目标都失败时状态为 failed
+func Run(ctx context.Context, runner CommandRunner, opts Options, progress func(percent int, message string)) (*monitoringModel.NetworkProbeDetail, error) {
+	detail := &monitoringModel.NetworkProbeDetail{Targets: []monitoringModel.NetworkProbeTargetResult{}}
+	probe := &detail.NetworkProbe
+	probe.TargetCount = len(opts.Targets)
+	steps := len(opts.Targets)
+	if opts.SpeedTestURL != "" {
+		steps++
+	}
+	step := 0
+
+	if opts.SpeedTestURL != "" {
+		progress(5, "正在下载测速...")
+		probe.SpeedTestURL = opts.SpeedTestURL
+		executor := ctxExecutor{ctx: ctx, runner: runner}
+		result := utils.ProbeDownload(executor, opts.SpeedTestURL, int64(opts.SpeedTestMaxMB)*1024*1024, opts.SpeedTestSeconds)
+		if result.OK {
+			probe.DownloadBytes = result.Bytes
+			probe.DownloadMbps = round2(result.Speed * 8 / 1e6)
+		} else {
+			probe.SpeedTestError = utils.TruncateString(result.Error, 500)
+		}
+		step++
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, target := range opts.Targets {
+		progress(5+90*step/steps, fmt.Sprintf("正在探测 %s...", target.Name))
+		detail.Targets = append(detail.Targets, probeTarget(ctx, runner, target, opts.PingCount))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		step++
+	}
+
+	summarize(detail)
+	return detail, nil
+}
+
+// probeTarget 对单个目标执行ping和mtr
+func probeTarget(ctx context.Context, runner CommandRunner, target Target, pingCount int) monitoringModel.NetworkProbeTargetResult {
+	result := monitoringModel.NetworkProbeTargetResult{Name: target.Name, Host: target.Host}
+
+	pingCtx, cancel := context.WithTimeout(ctx, time.Duration(pingCount+15)*time.Second)
+	output, err := runner.ExecuteSSHCommand(pingCtx,
+		fmt.Sprintf("ping -c %d -i 0.2 -W 2 -q %s 2>&1 || true", pingCount, utils.ShellQuote(target.Host)))
+	cancel()
+	if err != nil {
+		result.Error = utils.TruncateString(err.Error(), 200)
+		return result
+	}
+	if !parsePing(output, &result) {
+		result.Error = utils.TruncateString(strings.TrimSpace(output), 200)
+		if result.Error == "" {
+			result.Error = "无法解析ping输出"
+		}
+		return result
+	}
+
+	// mtr 不是必需的，宿主机未安装时输出为空
+	mtrCtx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	output, err = runner.ExecuteSSHCommand(mtrCtx,
+		fmt.Sprintf("command -v mtr >/dev/null 2>&1 && mtr -r -n -w -c %d %s 2>/dev/null || true", mtrCycles, utils.ShellQuote(target.Host)))
+	cancel()
+	if err == nil {
+		result.Hops = parseMtr(output)
+	}
+	return result
+}
+
+// parsePing 解析 iputils 和 busybox 的ping统计，解析失败时返回false
+func parsePing(output string, result *monitoringModel.NetworkProbeTargetResult) bool {
+	counts := pingCountPattern.FindStringSubmatch(output)
+	if counts == nil {
+		return false
+	}
+	result.Sent, _ = strconv.Atoi(counts[1])
+	result.Received, _ = strconv.Atoi(counts[2])
+	if loss := pingLossPattern.FindStringSubmatch(output); loss != nil {
+		result.LossPercent, _ = strconv.ParseFloat(loss[1], 64)
+	} else if result.Sent > 0 {
+		result.LossPercent = round2(float64(result.Sent-result.Received) / float64(result.Sent) * 100)
+	}
+	if rtt := pingRttPattern.FindStringSubmatch(output); rtt != nil {
+		result.MinRttMs, _ = strconv.ParseFloat(rtt[1], 64)
+		result.AvgRttMs, _ = strconv.ParseFloat(rtt[2], 64)
+		result.MaxRttMs, _ = strconv.ParseFloat(rtt[3], 64)
+	}
+	return true
+}
+
+// parseMtr 解析 mtr -r -w 报告中的各跳
+func parseMtr(output string) []monitoringModel.NetworkProbeHop {
+	var hops []monitoringModel.NetworkProbeHop
+	for _, line := range strings.Split(output, "\n") {
+		m := mtrHopLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		hop := monitoringModel.NetworkProbeHop{Host: m[2]}
+		hop.Index, _ = strconv.Atoi(m[1])
+		hop.LossPercent, _ = strconv.ParseFloat(m[3], 64)
+		hop.AvgRttMs, _ = strconv.ParseFloat(m[4], 64)
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// summarize 计算汇总指标和状态
+func summarize(detail *monitoringModel.NetworkProbeDetail) {
+	probe := &detail.NetworkProbe
+	var latencySum, lossSum float64
+	probed := 0
+	for _, target := range detail.Targets {
+		if target.Error != "" {
+			continue
+		}
+		probed++
+		lossSum += target.LossPercent
+		if target.Received > 0 {
+			probe.Reachable++
+			latencySum += target.AvgRttMs
+		}
+	}
+	if probe.Reachable > 0 {
+		probe.AvgLatencyMs = round2(latencySum / float64(probe.Reachable))
+	}
+	if probed > 0 {
+		probe.AvgLossPercent = round2(lossSum / float64(probed))
+	}
+
+	probe.Status = monitoringModel.NetworkProbeStatusCompleted
+	if probe.DownloadBytes == 0 && probed == 0 {
+		probe.Status = monitoringModel.NetworkProbeStatusFailed
+		probe.Error = "测速和所有目标的探测均失败"
+	}
+}
+
+// Save 保存测试记录，并删除该Provider超过保留时间的记录
+func Save(detail *monitoringModel.NetworkProbeDetail) error {
+	data, err := json.Marshal(detail.Targets)
+	if err != nil {
+		return fmt.Errorf("序列化探测结果失败: %w", err)
+	}
+	detail.Result = string(data)
+	if err := global.APP_DB.Create(&detail.NetworkProbe).Error; err != nil {
+		return fmt.Errorf("保存网络质量测试记录失败: %w", err)
+	}
+	global.APP_DB.Where("provider_id = ? AND created_at < ?", detail.ProviderID, time.Now().Add(-recordRetention)).
+		Delete(&monitoringModel.NetworkProbe{})
+	return nil
+}
+
+// List 返回Provider最近的测试记录，按时间倒序
+func List(providerID uint, limit int) ([]monitoringModel.NetworkProbeDetail, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, 200)
+	var probes []monitoringModel.NetworkProbe
+	if err := global.APP_DB.Where("provider_id = ?", providerID).Order("id DESC").Limit(limit).Find(&probes).Error; err != nil {
+		return nil, fmt.Errorf("查询网络质量测试记录失败: %w", err)
+	}
+	details := make([]monitoringModel.NetworkProbeDetail, 0, len(probes))
+	for _, probe := range probes {
+		details = append(details, toDetail(probe))
+	}
+	return details, nil
+}
+
+// LatestSummaries 返回各Provider最近一次成功测试的摘要
+func LatestSummaries(providerIDs []uint) map[uint]*monitoringModel.NetworkQualitySummary {
+	summaries := make(map[uint]*monitoringModel.NetworkQualitySummary)
+	if len(providerIDs) == 0 {
+		return summaries
+	}
+	latest := global.APP_DB.Model(&monitoringModel.NetworkProbe{}).Select("MAX(id)").
+		Where("provider_id IN ? AND status = ?", providerIDs, monitoringModel.NetworkProbeStatusCompleted).
+		Group("provider_id")
+	var probes []monitoringModel.NetworkProbe
+	if err := global.APP_DB.Where("id IN (?)", latest).Find(&probes).Error; err != nil {
+		return summaries
+	}
+	for _, probe := range probes {
+		summaries[probe.ProviderID] = toSummary(toDetail(probe))
+	}
+	return summaries
+}
+
+// LastProbedAt 返回Provider最近一次测试的时间，没有记录时返回零值
+func LastProbedAt(providerID uint) time.Time {
+	var probe monitoringModel.NetworkProbe
+	if err := global.APP_DB.Select("created_at").Where("provider_id = ?", providerID).
+		Order("id DESC").First(&probe).Error; err != nil {
+		return time.Time{}
+	}
+	return probe.CreatedAt
+}
+
+func toDetail(probe monitoringModel.NetworkProbe) monitoringModel.NetworkProbeDetail {
+	detail := monitoringModel.NetworkProbeDetail{NetworkProbe: probe, Targets: []monitoringModel.NetworkProbeTargetResult{}}
+	if probe.Result != "" {
+		_ = json.Unmarshal([]byte(probe.Result), &detail.Targets)
+	}
+	return detail
+}
+
+// toSummary 转换为用户可见的摘要，去掉目标地址和路由
+func toSummary(detail monitoringModel.NetworkProbeDetail) *monitoringModel.NetworkQualitySummary {
+	summary := &monitoringModel.NetworkQualitySummary{
+		ProbedAt:       detail.CreatedAt,
+		DownloadMbps:   detail.DownloadMbps,
+		AvgLatencyMs:   detail.AvgLatencyMs,
+		AvgLossPercent: detail.AvgLossPercent,
+		Targets:        make([]monitoringModel.NetworkQualityTarget, 0, len(detail.Targets)),
+	}
+	for _, target := range detail.Targets {
+		summary.Targets = append(summary.Targets, monitoringModel.NetworkQualityTarget{
+			Name:        target.Name,
+			Reachable:   target.Error == "" && target.Received > 0,
+			AvgRttMs:    target.AvgRttMs,
+			LossPercent: target.LossPercent,
+		})
+	}
+	return summary
+}
+
+// ctxExecutor 把 CommandRunner 适配为 utils.SSHExecutor
+type ctxExecutor struct {
+	ctx    context.Context
+	runner CommandRunner
+}
+
+func (e ctxExecutor) Execute(cmd string) (string, error) {
+	return e.runner.ExecuteSSHCommand(e.ctx, cmd)
+}
+
+func isHTTPURL(raw string) bool {
+	return (strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://")) && len(raw) <= 512
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
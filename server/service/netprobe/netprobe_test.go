@@ -0,0 +1,149 @@
+package netprobe
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/utils"
+)
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets([]string{"Cloudflare=1.1.1.1", " example.com ", "", "v6=2606:4700::1111"})
+	if err != nil {
+		t.Fatalf("解析目标失败: %v", err)
+	}
+	want := []Target{{"Cloudflare", "1.1.1.1"}, {"example.com", "example.com"}, {"v6", "2606:4700::1111"}}
+	if len(targets) != len(want) {
+		t.Fatalf("目标数量错误: %+v", targets)
+	}
+	for i := range want {
+		if targets[i] != want[i] {
+			t.Errorf("目标 %d = %+v, want %+v", i, targets[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"x=1.1.1.1;reboot", "x=$(id)", "-c 1 host", "x="} {
+		if _, err := ParseTargets([]string{bad}); err == nil {
+			t.Errorf("目标 %q 应该被拒绝", bad)
+		}
+	}
+	many := make([]string, maxTargets+1)
+	for i := range many {
+		many[i] = "1.1.1.1"
+	}
+	if _, err := ParseTargets(many); err == nil {
+		t.Error("超过目标数量上限应该被拒绝")
+	}
+}
+
+func TestParsePing(t *testing.T) {
+	iputils := `PING 1.1.1.1 (1.1.1.1) 56(84) bytes of data.
+
+--- 1.1.1.1 ping statistics ---
+10 packets transmitted, 9 received, 10% packet loss, time 1810ms
+rtt min/avg/max/mdev = 1.203/1.512/2.004/0.201 ms`
+	var result monitoringModel.NetworkProbeTargetResult
+	if !parsePing(iputils, &result) {
+		t.Fatal("无法解析iputils输出")
+	}
+	if result.Sent != 10 || result.Received != 9 || result.LossPercent != 10 || result.MinRttMs != 1.203 || result.AvgRttMs != 1.512 || result.MaxRttMs != 2.004 {
+		t.Errorf("iputils解析结果错误: %+v", result)
+	}
+
+	busybox := `--- 8.8.8.8 ping statistics ---
+5 packets transmitted, 5 packets received, 0% packet loss
+round-trip min/avg/max = 10.1/11.2/12.3 ms`
+	result = monitoringModel.NetworkProbeTargetResult{}
+	if !parsePing(busybox, &result) || result.Received != 5 || result.AvgRttMs != 11.2 {
+		t.Errorf("busybox解析结果错误: %+v", result)
+	}
+
+	unreachable := `--- 10.0.0.1 ping statistics ---
+4 packets transmitted, 0 received, 100% packet loss, time 3060ms`
+	result = monitoringModel.NetworkProbeTargetResult{}
+	if !parsePing(unreachable, &result) || result.LossPercent != 100 || result.AvgRttMs != 0 {
+		t.Errorf("不可达目标解析结果错误: %+v", result)
+	}
+
+	if parsePing("ping: unknown host nowhere", &result) {
+		t.Error("错误输出不应解析成功")
+	}
+}
+
+func TestParseMtr(t *testing.T) {
+	output := `Start: 2026-10-15T10:00:00+0000
+HOST: node1                 Loss%   Snt   Last   Avg  Best  Wrst StDev
+  1.|-- 10.0.0.1             0.0%     3    0.3   0.4   0.3   0.5   0.1
+  2.|-- ???                 100.0     3    0.0   0.0   0.0   0.0   0.0
+  3.|-- 1.1.1.1              0.0%     3    1.2   1.3   1.2   1.4   0.1`
+	hops := parseMtr(output)
+	if len(hops) != 3 {
+		t.Fatalf("跳数错误: %+v", hops)
+	}
+	if hops[0].Index != 1 || hops[0].Host != "10.0.0.1" || hops[0].AvgRttMs != 0.4 {
+		t.Errorf("第1跳解析错误: %+v", hops[0])
+	}
+	if hops[1].Host != "???" || hops[1].LossPercent != 100 {
+		t.Errorf("第2跳解析错误: %+v", hops[1])
+	}
+	if hops[2].AvgRttMs != 1.3 {
+		t.Errorf("第3跳解析错误: %+v", hops[2])
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	detail := &monitoringModel.NetworkProbeDetail{Targets: []monitoringModel.NetworkProbeTargetResult{
+		{Name: "a", Sent: 10, Received: 10, AvgRttMs: 10},
+		{Name: "b", Sent: 10, Received: 8, LossPercent: 20, AvgRttMs: 30},
+		{Name: "c", Sent: 10, Received: 0, LossPercent: 100},
+		{Name: "d", Error: "timeout"},
+	}}
+	summarize(detail)
+	if detail.Status != monitoringModel.NetworkProbeStatusCompleted || detail.Reachable != 2 || detail.AvgLatencyMs != 20 || detail.AvgLossPercent != 40 {
+		t.Errorf("汇总结果错误: %+v", detail.NetworkProbe)
+	}
+
+	failed := &monitoringModel.NetworkProbeDetail{Targets: []monitoringModel.NetworkProbeTargetResult{{Name: "d", Error: "timeout"}}}
+	summarize(failed)
+	if failed.Status != monitoringModel.NetworkProbeStatusFailed {
+		t.Errorf("全部失败时状态应为failed: %+v", failed.NetworkProbe)
+	}
+}
+
+// recordingRunner 记录执行的命令，ping 返回正常的统计输出
+type recordingRunner struct {
+	commands []string
+}
+
+func (r *recordingRunner) ExecuteSSHCommand(_ context.Context, command string) (string, error) {
+	r.commands = append(r.commands, command)
+	if strings.HasPrefix(command, "ping ") {
+		return "4 packets transmitted, 4 received, 0% packet loss, time 600ms\nrtt min/avg/max/mdev = 1.0/1.5/2.0/0.3 ms", nil
+	}
+	return "", nil
+}
+
+func TestProbeCommandsPassCommandGuard(t *testing.T) {
+	opts, err := ResolveOptions([]string{"1.1.1.1", "example.com"}, "https://example.com/10MB.bin")
+	if err != nil {
+		t.Fatalf("ResolveOptions: %v", err)
+	}
+	runner := &recordingRunner{}
+	if _, err := Run(context.Background(), runner, opts, func(int, string) {}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	guard := utils.NewCommandGuard("test", "block", nil, nil)
+	var sawMtr bool
+	for _, command := range runner.commands {
+		if violations, err := guard.Violations(command); err != nil || len(violations) > 0 {
+			t.Errorf("命令应通过默认命令白名单: %q %v %v", command, violations, err)
+		}
+		sawMtr = sawMtr || strings.Contains(command, "mtr -r")
+	}
+	if !sawMtr {
+		t.Errorf("未执行路由探测: %q", runner.commands)
+	}
+}
@@ -3,6 +3,7 @@ package oauth2
 import (
 	"encoding/json"
 	"errors"
+	"oneclickvirt/config"
 	"oneclickvirt/global"
 	"oneclickvirt/model/common"
 	oauth2Model "oneclickvirt/model/oauth2"
@@ -116,7 +117,8 @@ func (s *ProviderService) UpdateProvider(id uint, req *UpdateProviderRequest) er
 	if req.ClientID != nil {
 		updates["client_id"] = *req.ClientID
 	}
-	if req.ClientSecret != nil {
+	// 提交脱敏占位值表示保持原密钥不变
+	if req.ClientSecret != nil && !config.IsSecretMask(*req.ClientSecret) {
 		updates["client_secret"] = *req.ClientSecret
 	}
 	if req.RedirectURL != nil {
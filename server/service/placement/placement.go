@@ -0,0 +1,190 @@
+// Package placement 实例分散放置
+// 用户要求分散放置且未指定节点时，优先选择用户尚无实例的节点（或地区），使单台宿主机故障不会影响用户的全部实例；
+// 所有候选节点上都已有实例时退而选择实例最少的节点
+package placement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+
+	"go.uber.org/zap"
+)
+
+// 分散放置方式
+const (
+	SpreadProvider = "provider" // 分散到不同节点
+	SpreadRegion   = "region"   // 分散到不同地区
+)
+
+// ErrNoCandidate 没有可申请且与镜像匹配的节点
+var ErrNoCandidate = errors.New("没有可用于分散放置的节点")
+
+// candidate 候选节点
+type candidate struct {
+	ID        uint
+	Region    string
+	Instances int // 节点上的实例总数，用于同等条件下优先选择负载较低的节点
+}
+
+// footprint 用户现有实例（包括创建中的）在各节点和地区的分布
+type footprint struct {
+	providers map[uint]int
+	regions   map[string]int
+}
+
+// Pick 为用户选择节点，groupID 不为0时只与同一分组的实例分散，preferredRegion 在同等条件下优先
+// 返回的 spread 为false表示所有候选节点都与现有实例冲突，只能退而求其次
+func Pick(userID, imageID, groupID uint, spreadMode, preferredRegion string) (providerID uint, spread bool, err error) {
+	var image systemModel.SystemImage
+	if err := global.APP_DB.Select("id", "provider_type", "instance_type").First(&image, imageID).Error; err != nil {
+		return 0, false, fmt.Errorf("无效的镜像ID")
+	}
+
+	candidates, err := loadCandidates(image)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(candidates) == 0 {
+		return 0, false, ErrNoCandidate
+	}
+	fp, err := loadFootprint(userID, groupID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	rank(candidates, fp, spreadMode, preferredRegion)
+	best := candidates[0]
+	spread = conflicts(best, fp, spreadMode) == 0
+	if !spread {
+		global.APP_LOG.Info("所有候选节点上已有用户实例，分散放置退而选择实例最少的节点",
+			zap.Uint("userID", userID),
+			zap.String("spread", spreadMode),
+			zap.Uint("providerId", best.ID))
+	}
+	return best.ID, spread, nil
+}
+
+// loadCandidates 读取可申请、未冻结、与镜像类型匹配且未达到实例上限的节点
+func loadCandidates(image systemModel.SystemImage) ([]candidate, error) {
+	query := global.APP_DB.Model(&providerModel.Provider{}).
+		Where("allow_claim = ? AND is_frozen = ? AND traffic_limited = ? AND status IN (?)",
+			true, false, false, []string{"active", "partial"})
+	if image.ProviderType != "" {
+		query = query.Where("type = ?", image.ProviderType)
+	}
+	switch image.InstanceType {
+	case "vm":
+		query = query.Where("virtual_machine_enabled = ? AND (max_vm_instances = 0 OR vm_count < max_vm_instances)", true)
+	case "container":
+		query = query.Where("container_enabled = ? AND (max_container_instances = 0 OR container_count < max_container_instances)", true)
+	}
+
+	var providers []providerModel.Provider
+	if err := query.Select("id", "region", "container_count", "vm_count").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("查询候选节点失败: %w", err)
+	}
+	candidates := make([]candidate, 0, len(providers))
+	for _, p := range providers {
+		candidates = append(candidates, candidate{ID: p.ID, Region: p.Region, Instances: p.ContainerCount + p.VMCount})
+	}
+	return candidates, nil
+}
+
+// loadFootprint 统计用户现有实例和进行中的创建任务所在的节点和地区
+func loadFootprint(userID, groupID uint) (footprint, error) {
+	fp := footprint{providers: make(map[uint]int), regions: make(map[string]int)}
+
+	query := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("user_id = ? AND status NOT IN (?)", userID, []string{"deleted", "deleting", "failed"})
+	if groupID != 0 {
+		query = query.Where("group_id = ?", groupID)
+	}
+	var providerIDs []uint
+	if err := query.Pluck("provider_id", &providerIDs).Error; err != nil {
+		return fp, fmt.Errorf("查询用户实例失败: %w", err)
+	}
+
+	// 创建任务执行前还没有实例记录，批量创建时需要计入已提交的任务
+	var tasks []adminModel.Task
+	if err := global.APP_DB.Select("id", "provider_id", "task_data").
+		Where("user_id = ? AND task_type = ? AND status IN (?)", userID, "create", []string{"pending", "processing", "running"}).
+		Find(&tasks).Error; err != nil {
+		return fp, fmt.Errorf("查询创建任务失败: %w", err)
+	}
+	for _, t := range tasks {
+		if t.ProviderID == nil {
+			continue
+		}
+		if groupID != 0 {
+			var data struct {
+				GroupID uint `json:"groupId"`
+			}
+			if json.Unmarshal([]byte(t.TaskData), &data) != nil || data.GroupID != groupID {
+				continue
+			}
+		}
+		providerIDs = append(providerIDs, *t.ProviderID)
+	}
+	if len(providerIDs) == 0 {
+		return fp, nil
+	}
+
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Unscoped().Select("id", "region").Where("id IN ?", providerIDs).Find(&providers).Error; err != nil {
+		return fp, fmt.Errorf("查询实例所在节点失败: %w", err)
+	}
+	regions := make(map[uint]string, len(providers))
+	for _, p := range providers {
+		regions[p.ID] = p.Region
+	}
+	for _, id := range providerIDs {
+		fp.providers[id]++
+		fp.regions[regionKey(id, regions[id])]++
+	}
+	return fp, nil
+}
+
+// rank 按冲突数、节点上的用户实例数、是否首选地区、节点负载排序，排在最前的为最佳节点
+func rank(candidates []candidate, fp footprint, spreadMode, preferredRegion string) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ca, cb := conflicts(a, fp, spreadMode), conflicts(b, fp, spreadMode); ca != cb {
+			return ca < cb
+		}
+		if pa, pb := fp.providers[a.ID], fp.providers[b.ID]; pa != pb {
+			return pa < pb
+		}
+		if preferredRegion != "" {
+			if ra, rb := a.Region == preferredRegion, b.Region == preferredRegion; ra != rb {
+				return ra
+			}
+		}
+		if a.Instances != b.Instances {
+			return a.Instances < b.Instances
+		}
+		return a.ID < b.ID
+	})
+}
+
+// conflicts 返回候选节点与用户现有实例的冲突数
+func conflicts(c candidate, fp footprint, spreadMode string) int {
+	if spreadMode == SpreadRegion {
+		return fp.regions[regionKey(c.ID, c.Region)]
+	}
+	return fp.providers[c.ID]
+}
+
+// regionKey 未设置地区的节点各自视为一个地区
+func regionKey(providerID uint, region string) string {
+	if region == "" {
+		return fmt.Sprintf("#%d", providerID)
+	}
+	return region
+}
@@ -0,0 +1,131 @@
+package placement
+
+import (
+	"path/filepath"
+	"testing"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRank(t *testing.T) {
+	fp := footprint{
+		providers: map[uint]int{1: 2, 2: 1},
+		regions:   map[string]int{"hk": 3, "#4": 1},
+	}
+	candidates := func() []candidate {
+		return []candidate{
+			{ID: 1, Region: "hk", Instances: 1},
+			{ID: 2, Region: "hk", Instances: 1},
+			{ID: 3, Region: "jp", Instances: 9},
+			{ID: 4, Instances: 0},
+			{ID: 5, Region: "us", Instances: 2},
+		}
+	}
+	ids := func(cs []candidate) []uint {
+		out := make([]uint, len(cs))
+		for i, c := range cs {
+			out[i] = c.ID
+		}
+		return out
+	}
+	equal := func(a, b []uint) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	cs := candidates()
+	rank(cs, fp, SpreadProvider, "")
+	if got := ids(cs); !equal(got, []uint{4, 5, 3, 2, 1}) {
+		t.Errorf("按节点分散排序 = %v", got)
+	}
+
+	// 首选地区在冲突数相同时优先，优先于节点负载
+	cs = candidates()
+	rank(cs, fp, SpreadProvider, "jp")
+	if cs[0].ID != 3 {
+		t.Errorf("首选地区应优先: %v", ids(cs))
+	}
+
+	// 按地区分散时，同地区已有实例的节点和已有实例的无地区节点排在后面
+	cs = candidates()
+	rank(cs, fp, SpreadRegion, "")
+	if got := ids(cs); !equal(got, []uint{5, 3, 4, 2, 1}) {
+		t.Errorf("按地区分散排序 = %v", got)
+	}
+}
+
+func TestPick(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if _, err := database.EnsureSQLiteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{Dbname: path}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&providerModel.Provider{}, &providerModel.Instance{}, &systemModel.SystemImage{}, &adminModel.Task{}); err != nil {
+		t.Fatal(err)
+	}
+	oldDB, oldLog := global.APP_DB, global.APP_LOG
+	global.APP_DB, global.APP_LOG = db, zap.NewNop()
+	defer func() { global.APP_DB, global.APP_LOG = oldDB, oldLog }()
+
+	image := systemModel.SystemImage{Name: "debian", ProviderType: "lxd", InstanceType: "container", Status: "active"}
+	if err := db.Create(&image).Error; err != nil {
+		t.Fatal(err)
+	}
+	providers := []providerModel.Provider{
+		{Name: "hk-1", Type: "lxd", Region: "hk", Status: "active", AllowClaim: true, ContainerEnabled: true},
+		{Name: "hk-2", Type: "lxd", Region: "hk", Status: "active", AllowClaim: true, ContainerEnabled: true},
+		{Name: "jp-1", Type: "lxd", Region: "jp", Status: "active", AllowClaim: true, ContainerEnabled: true},
+		{Name: "jp-frozen", Type: "lxd", Region: "jp", Status: "active", AllowClaim: true, ContainerEnabled: true, IsFrozen: true},
+		{Name: "us-docker", Type: "docker", Region: "us", Status: "active", AllowClaim: true, ContainerEnabled: true},
+	}
+	for i := range providers {
+		if err := db.Create(&providers[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	hk1, hk2, jp1 := providers[0].ID, providers[1].ID, providers[2].ID
+
+	if err := db.Create(&providerModel.Instance{Name: "a", UserID: 1, ProviderID: hk1, Status: "running"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// 创建任务尚未生成实例记录，也计入分布
+	if err := db.Create(&adminModel.Task{UserID: 1, ProviderID: &jp1, TaskType: "create", Status: "pending", TaskData: `{"groupId":0}`}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got, spread, err := Pick(1, image.ID, 0, SpreadProvider, "")
+	if err != nil || got != hk2 || !spread {
+		t.Fatalf("按节点分散应选择 hk-2, got %d %v %v", got, spread, err)
+	}
+
+	// hk和jp都已有实例，可用地区只剩被冻结和类型不匹配的节点，只能退而选择
+	got, spread, err = Pick(1, image.ID, 0, SpreadRegion, "")
+	if err != nil || spread || (got != hk2 && got != jp1) {
+		t.Fatalf("按地区分散应退而选择实例最少的节点, got %d %v %v", got, spread, err)
+	}
+
+	// 按分组分散时只考虑同一分组的实例
+	got, spread, err = Pick(1, image.ID, 7, SpreadProvider, "")
+	if err != nil || got != hk1 || !spread {
+		t.Fatalf("分组内没有实例时应按ID选择第一个节点, got %d %v %v", got, spread, err)
+	}
+}
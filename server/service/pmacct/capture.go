@@ -0,0 +1,582 @@
+package pmacct
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// captureTopN 明细中保留的端口和对端条数
+	captureTopN = 20
+	// captureFlushGrace 抓取到期后等待pmacctd退出并写完最后一批数据的时间
+	captureFlushGrace = 30 * time.Second
+)
+
+// captureFlow 抓取数据库中按五元组汇总的一行
+type captureFlow struct {
+	SrcHost string
+	DstHost string
+	SrcPort int
+	DstPort int
+	Proto   string
+	Packets int64
+	Bytes   int64
+}
+
+// captureDir 抓取文件所在目录，路径中不含实例名，避免被 pgrep 'pmacctd.*<实例名>' 误判为常驻监控进程
+func captureDir(captureID uint) string {
+	return fmt.Sprintf("/var/lib/pmacct/capture-%d", captureID)
+}
+
+// StartTrafficCapture 为实例开始一次限时的流量明细抓取
+// 复用常驻监控的网卡和BPF过滤器，另起一个按端口和对端聚合的pmacctd，由timeout在到期时结束
+func (s *Service) StartTrafficCapture(instanceID, startedBy uint, minutes int) (*monitoringModel.TrafficCapture, error) {
+	if minutes <= 0 {
+		minutes = monitoringModel.TrafficCaptureDefaultMinutes
+	}
+	if minutes > monitoringModel.TrafficCaptureMaxMinutes {
+		minutes = monitoringModel.TrafficCaptureMaxMinutes
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return nil, fmt.Errorf("实例不存在")
+	}
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Select("id", "enable_traffic_control").First(&providerRecord, instance.ProviderID).Error; err != nil {
+		return nil, fmt.Errorf("节点不存在")
+	}
+	if !providerRecord.EnableTrafficControl {
+		return nil, fmt.Errorf("该节点未启用流量统计")
+	}
+	var monitorCount int64
+	global.APP_DB.Model(&monitoringModel.PmacctMonitor{}).
+		Where("instance_id = ? AND is_enabled = ?", instanceID, true).
+		Count(&monitorCount)
+	if monitorCount == 0 {
+		return nil, fmt.Errorf("实例未启用流量监控")
+	}
+
+	var running int64
+	global.APP_DB.Model(&monitoringModel.TrafficCapture{}).
+		Where("instance_id = ? AND status = ?", instanceID, monitoringModel.TrafficCaptureRunning).
+		Count(&running)
+	if running > 0 {
+		return nil, fmt.Errorf("该实例已有正在进行的流量明细抓取")
+	}
+
+	now := time.Now()
+	capture := &monitoringModel.TrafficCapture{
+		InstanceID: instanceID,
+		ProviderID: instance.ProviderID,
+		StartedBy:  startedBy,
+		Status:     monitoringModel.TrafficCaptureRunning,
+		StartedAt:  now,
+		ExpiresAt:  now.Add(time.Duration(minutes) * time.Minute),
+	}
+	if err := global.APP_DB.Create(capture).Error; err != nil {
+		return nil, fmt.Errorf("创建抓取记录失败: %w", err)
+	}
+
+	if err := s.launchTrafficCapture(&instance, capture, minutes); err != nil {
+		global.APP_LOG.Warn("启动流量明细抓取失败",
+			zap.Uint("instanceID", instanceID),
+			zap.Uint("captureID", capture.ID),
+			zap.Error(err))
+		stoppedAt := time.Now()
+		capture.Status = monitoringModel.TrafficCaptureFailed
+		capture.StoppedAt = &stoppedAt
+		capture.Error = truncateCaptureError(err.Error())
+		global.APP_DB.Model(capture).Updates(map[string]interface{}{
+			"status":     capture.Status,
+			"stopped_at": stoppedAt,
+			"error":      capture.Error,
+		})
+		return nil, fmt.Errorf("启动流量明细抓取失败: %w", err)
+	}
+
+	global.APP_LOG.Info("开始流量明细抓取",
+		zap.Uint("instanceID", instanceID),
+		zap.Uint("captureID", capture.ID),
+		zap.Uint("startedBy", startedBy),
+		zap.Int("minutes", minutes))
+	return capture, nil
+}
+
+// launchTrafficCapture 在宿主机上写入抓取配置并启动临时pmacctd
+func (s *Service) launchTrafficCapture(instance *providerModel.Instance, capture *monitoringModel.TrafficCapture, minutes int) error {
+	providerInstance, err := s.captureProvider(instance.ProviderID)
+	if err != nil {
+		return err
+	}
+
+	// 从常驻监控配置中读取网卡和BPF过滤器，保证抓取范围与计费统计一致
+	mainConfig := fmt.Sprintf("/var/lib/pmacct/%s/pmacctd.conf", instance.Name)
+	readCtx, readCancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer readCancel()
+	output, err := providerInstance.ExecuteSSHCommand(readCtx,
+		fmt.Sprintf("grep -E '^(pcap_interface|pcap_interfaces_map|pcap_filter):' %s 2>/dev/null || true", mainConfig))
+	if err != nil {
+		return fmt.Errorf("读取监控配置失败: %w", err)
+	}
+	pcapLines := capturePcapLines(output)
+	if len(pcapLines) == 0 {
+		return fmt.Errorf("宿主机上未找到实例的流量监控配置")
+	}
+
+	bandwidth := instance.Bandwidth
+	if bandwidth == 0 {
+		bandwidth = 100
+	}
+	pluginBufferSize, pluginPipeSize, _, sqlCacheEntries := s.calculatePmacctBufferSizes(bandwidth)
+
+	dir := captureDir(capture.ID)
+	configFile := dir + "/pmacctd.conf"
+	dbPath := dir + "/capture.db"
+	config := buildCaptureConfig(capture.ID, instance.Name, pcapLines, dir, dbPath,
+		sqlCacheEntries*4, pluginBufferSize, pluginPipeSize)
+
+	mkdirCtx, mkdirCancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer mkdirCancel()
+	if _, err := providerInstance.ExecuteSSHCommand(mkdirCtx, fmt.Sprintf("mkdir -p %s && chmod 755 %s", dir, dir)); err != nil {
+		return fmt.Errorf("创建抓取目录失败: %w", err)
+	}
+	if err := s.uploadFileViaSFTP(providerInstance, config, configFile, 0644); err != nil {
+		return err
+	}
+	if err := s.initializePmacctDatabase(providerInstance, dbPath); err != nil {
+		return err
+	}
+
+	// timeout保证面板不可用时抓取也会按时结束
+	startCmd := fmt.Sprintf(`
+nohup timeout %d pmacctd -f %s > %s/pmacctd.log 2>&1 &
+sleep 2
+if pgrep -f "pmacctd -f %s" > /dev/null; then
+    echo "capture started"
+else
+    tail -n 20 %s/pmacctd.log 2>/dev/null
+    exit 1
+fi
+`, minutes*60, configFile, dir, configFile, dir)
+
+	startCtx, startCancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer startCancel()
+	if output, err := providerInstance.ExecuteSSHCommand(startCtx, startCmd); err != nil {
+		s.removeCaptureFiles(providerInstance, capture.ID)
+		return fmt.Errorf("%w, output: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// StopTrafficCapture 提前结束实例的流量明细抓取并保存结果
+func (s *Service) StopTrafficCapture(instanceID, captureID uint) (*monitoringModel.TrafficCaptureDetail, error) {
+	capture, err := findTrafficCapture(instanceID, captureID)
+	if err != nil {
+		return nil, err
+	}
+	if capture.Status == monitoringModel.TrafficCaptureRunning {
+		capture = s.finishTrafficCapture(capture, true)
+	}
+	return captureDetail(capture), nil
+}
+
+// GetTrafficCapture 获取抓取详情，抓取中时实时读取宿主机上的明细
+func (s *Service) GetTrafficCapture(instanceID, captureID uint) (*monitoringModel.TrafficCaptureDetail, error) {
+	capture, err := findTrafficCapture(instanceID, captureID)
+	if err != nil {
+		return nil, err
+	}
+	if capture.Status != monitoringModel.TrafficCaptureRunning {
+		return captureDetail(capture), nil
+	}
+
+	detail := &monitoringModel.TrafficCaptureDetail{TrafficCapture: *capture}
+	breakdown, err := s.readTrafficCapture(capture)
+	if err != nil {
+		return nil, fmt.Errorf("读取抓取数据失败: %w", err)
+	}
+	detail.Breakdown = breakdown
+	return detail, nil
+}
+
+// ListTrafficCaptures 获取实例最近的抓取记录
+func (s *Service) ListTrafficCaptures(instanceID uint, limit int) ([]monitoringModel.TrafficCapture, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	var captures []monitoringModel.TrafficCapture
+	err := global.APP_DB.Where("instance_id = ?", instanceID).
+		Order("id DESC").Limit(limit).Find(&captures).Error
+	return captures, err
+}
+
+// FinishExpiredTrafficCaptures 收尾已到期的抓取，读取最终结果并清理宿主机文件
+func (s *Service) FinishExpiredTrafficCaptures() int {
+	var captures []monitoringModel.TrafficCapture
+	if err := global.APP_DB.Where("status = ? AND expires_at < ?",
+		monitoringModel.TrafficCaptureRunning, time.Now().Add(-captureFlushGrace)).
+		Find(&captures).Error; err != nil {
+		global.APP_LOG.Error("查询到期的流量明细抓取失败", zap.Error(err))
+		return 0
+	}
+	for i := range captures {
+		s.finishTrafficCapture(&captures[i], false)
+	}
+	return len(captures)
+}
+
+// finishTrafficCapture 读取最终明细保存到记录中并删除宿主机上的抓取文件
+// stop为true时先结束宿主机上的pmacctd，退出时会写入缓存中的最后一批数据
+func (s *Service) finishTrafficCapture(capture *monitoringModel.TrafficCapture, stop bool) *monitoringModel.TrafficCapture {
+	updates := map[string]interface{}{}
+	breakdown, err := s.collectFinalCapture(capture, stop)
+	if err != nil {
+		updates["status"] = monitoringModel.TrafficCaptureFailed
+		updates["error"] = truncateCaptureError(err.Error())
+		global.APP_LOG.Warn("流量明细抓取收尾失败",
+			zap.Uint("captureID", capture.ID),
+			zap.Uint("instanceID", capture.InstanceID),
+			zap.Error(err))
+	} else {
+		result, _ := json.Marshal(breakdown)
+		updates["status"] = monitoringModel.TrafficCaptureFinished
+		updates["result"] = string(result)
+	}
+	stoppedAt := time.Now()
+	updates["stopped_at"] = stoppedAt
+
+	// 只更新仍在抓取中的记录，手动停止与到期收尾并发时以先完成的为准
+	global.APP_DB.Model(&monitoringModel.TrafficCapture{}).
+		Where("id = ? AND status = ?", capture.ID, monitoringModel.TrafficCaptureRunning).
+		Updates(updates)
+
+	var latest monitoringModel.TrafficCapture
+	if err := global.APP_DB.First(&latest, capture.ID).Error; err != nil {
+		return capture
+	}
+	return &latest
+}
+
+// collectFinalCapture 结束抓取进程、读取明细并清理抓取目录
+func (s *Service) collectFinalCapture(capture *monitoringModel.TrafficCapture, stop bool) (*monitoringModel.TrafficCaptureBreakdown, error) {
+	providerInstance, err := s.captureProvider(capture.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.removeCaptureFiles(providerInstance, capture.ID)
+
+	if stop {
+		stopCtx, stopCancel := context.WithTimeout(s.ctx, 15*time.Second)
+		defer stopCancel()
+		providerInstance.ExecuteSSHCommand(stopCtx,
+			fmt.Sprintf("pkill -f 'pmacctd -f %s/pmacctd.conf' 2>/dev/null; sleep 3", captureDir(capture.ID)))
+	}
+	return s.readTrafficCapture(capture)
+}
+
+// readTrafficCapture 从宿主机的抓取数据库读取五元组汇总并按实例地址归类
+func (s *Service) readTrafficCapture(capture *monitoringModel.TrafficCapture) (*monitoringModel.TrafficCaptureBreakdown, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, capture.InstanceID).Error; err != nil {
+		return nil, fmt.Errorf("实例不存在")
+	}
+	var monitor monitoringModel.PmacctMonitor
+	global.APP_DB.Where("instance_id = ?", capture.InstanceID).First(&monitor)
+
+	providerInstance, err := s.captureProvider(capture.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPath := captureDir(capture.ID) + "/capture.db"
+	query := fmt.Sprintf(`test -f %s || exit 0; sqlite3 -separator '|' %s "
+SELECT COALESCE(src_host, ip_src), COALESCE(dst_host, ip_dst),
+       COALESCE(src_port, port_src, 0), COALESCE(dst_port, port_dst, 0),
+       COALESCE(proto, ip_proto, ''), SUM(packets), SUM(bytes)
+FROM acct_v9
+GROUP BY 1, 2, 3, 4, 5
+ORDER BY 7 DESC
+LIMIT 20000;
+"`, dbPath, dbPath)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 60*time.Second)
+	defer cancel()
+	output, err := providerInstance.ExecuteSSHCommand(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w, output: %s", err, strings.TrimSpace(output))
+	}
+
+	localIPs := instanceCaptureIPs(&instance, &monitor)
+	return buildTrafficBreakdown(parseCaptureFlows(output), localIPs, captureTopN), nil
+}
+
+// captureProvider 获取Provider连接，缓存不存在时重新加载
+func (s *Service) captureProvider(providerID uint) (provider.Provider, error) {
+	providerInstance, exists := providerService.GetProviderService().GetProviderByID(providerID)
+	if !exists {
+		var providerRecord providerModel.Provider
+		if err := global.APP_DB.First(&providerRecord, providerID).Error; err != nil {
+			return nil, fmt.Errorf("failed to find provider: %w", err)
+		}
+		if err := s.refreshProviderCache(providerID, &providerRecord); err != nil {
+			return nil, fmt.Errorf("failed to refresh provider cache: %w", err)
+		}
+		providerInstance, exists = providerService.GetProviderService().GetProviderByID(providerID)
+		if !exists {
+			return nil, fmt.Errorf("provider ID %d still not found after refresh", providerID)
+		}
+	}
+	s.SetProviderID(providerID)
+	return providerInstance, nil
+}
+
+// removeCaptureFiles 结束可能残留的抓取进程并删除抓取目录
+func (s *Service) removeCaptureFiles(providerInstance provider.Provider, captureID uint) {
+	dir := captureDir(captureID)
+	ctx, cancel := context.WithTimeout(s.ctx, 15*time.Second)
+	defer cancel()
+	if _, err := providerInstance.ExecuteSSHCommand(ctx,
+		fmt.Sprintf("pkill -f 'pmacctd -f %s/pmacctd.conf' 2>/dev/null; rm -rf %s", dir, dir)); err != nil {
+		global.APP_LOG.Warn("清理流量明细抓取文件失败",
+			zap.Uint("captureID", captureID),
+			zap.Error(err))
+	}
+}
+
+func findTrafficCapture(instanceID, captureID uint) (*monitoringModel.TrafficCapture, error) {
+	var capture monitoringModel.TrafficCapture
+	if err := global.APP_DB.Where("id = ? AND instance_id = ?", captureID, instanceID).First(&capture).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("抓取记录不存在")
+		}
+		return nil, err
+	}
+	return &capture, nil
+}
+
+// captureDetail 组装已结束抓取的详情，结果为空时返回空明细
+func captureDetail(capture *monitoringModel.TrafficCapture) *monitoringModel.TrafficCaptureDetail {
+	detail := &monitoringModel.TrafficCaptureDetail{TrafficCapture: *capture}
+	if capture.Result != "" {
+		var breakdown monitoringModel.TrafficCaptureBreakdown
+		if err := json.Unmarshal([]byte(capture.Result), &breakdown); err == nil {
+			detail.Breakdown = &breakdown
+		}
+	}
+	return detail
+}
+
+func truncateCaptureError(msg string) string {
+	if len(msg) > 500 {
+		return msg[:500]
+	}
+	return msg
+}
+
+// instanceCaptureIPs 实例在宿主机上可见的地址，与流量采集使用的地址一致
+func instanceCaptureIPs(instance *providerModel.Instance, monitor *monitoringModel.PmacctMonitor) []string {
+	var ips []string
+	if instance.PrivateIP != "" {
+		ips = append(ips, instance.PrivateIP)
+	} else if monitor.MappedIP != "" {
+		ips = append(ips, monitor.MappedIP)
+	}
+	return append(ips, normalizeIPv6List(monitor.MappedIPv6, instance.PublicIPv6, instance.IPv6Address)...)
+}
+
+// capturePcapLines 从常驻监控配置中提取网卡和BPF过滤器配置行
+func capturePcapLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "pcap_interface:") ||
+			strings.HasPrefix(line, "pcap_interfaces_map:") ||
+			strings.HasPrefix(line, "pcap_filter:") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// buildCaptureConfig 生成抓取用的pmacctd配置，按五元组聚合并缩短刷新间隔
+func buildCaptureConfig(captureID uint, instanceName string, pcapLines []string, dir, dbPath string, cacheEntries, pluginBufferSize, pluginPipeSize int) string {
+	return fmt.Sprintf(`# pmacct traffic capture %d for instance: %s
+
+daemonize: false
+pidfile: %s/pmacctd.pid
+syslog: daemon
+
+%s
+
+# 按源/目标地址、端口和协议聚合，用于分析流量来源
+aggregate: src_host, dst_host, src_port, dst_port, proto
+
+plugins: sqlite3[capture]
+sql_db[capture]: %s
+sql_table[capture]: acct_v9
+sql_optimize_clauses[capture]: true
+sql_refresh_time[capture]: 10
+sql_history[capture]: 1m
+sql_history_roundoff[capture]: m
+sql_dont_try_update[capture]: true
+sql_cache_entries[capture]: %d
+plugin_buffer_size[capture]: %d
+plugin_pipe_size[capture]: %d
+`, captureID, instanceName, dir, strings.Join(pcapLines, "\n"), dbPath,
+		cacheEntries, pluginBufferSize, pluginPipeSize)
+}
+
+// parseCaptureFlows 解析sqlite3输出的 src|dst|sport|dport|proto|packets|bytes 行
+func parseCaptureFlows(output string) []captureFlow {
+	var flows []captureFlow
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) != 7 {
+			continue
+		}
+		srcPort, _ := strconv.Atoi(fields[2])
+		dstPort, _ := strconv.Atoi(fields[3])
+		packets, _ := strconv.ParseInt(fields[5], 10, 64)
+		bytes, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil {
+			continue
+		}
+		flows = append(flows, captureFlow{
+			SrcHost: fields[0],
+			DstHost: fields[1],
+			SrcPort: srcPort,
+			DstPort: dstPort,
+			Proto:   strings.ToLower(fields[4]),
+			Packets: packets,
+			Bytes:   bytes,
+		})
+	}
+	return flows
+}
+
+// buildTrafficBreakdown 按实例视角汇总流量：源地址为实例时计为发送，目标地址为实例时计为接收
+// 端口取通信双方中较小的一个作为服务端口（临时端口通常较大），并标记服务在实例一侧还是远端
+func buildTrafficBreakdown(flows []captureFlow, localIPs []string, limit int) *monitoringModel.TrafficCaptureBreakdown {
+	local := make(map[string]bool, len(localIPs))
+	for _, ip := range localIPs {
+		local[canonicalIP(ip)] = true
+	}
+
+	type portKey struct {
+		proto string
+		port  int
+		local bool
+	}
+	ports := make(map[portKey]*monitoringModel.TrafficTalkerPort)
+	portPeers := make(map[portKey]map[string]bool)
+	peers := make(map[string]*monitoringModel.TrafficTalkerPeer)
+	breakdown := &monitoringModel.TrafficCaptureBreakdown{SampledAt: time.Now()}
+
+	for _, flow := range flows {
+		src, dst := canonicalIP(flow.SrcHost), canonicalIP(flow.DstHost)
+		var outbound bool
+		switch {
+		case local[src] && !local[dst]:
+			outbound = true
+		case local[dst] && !local[src]:
+			outbound = false
+		default:
+			continue
+		}
+
+		peer, localPort, remotePort := src, flow.DstPort, flow.SrcPort
+		if outbound {
+			peer, localPort, remotePort = dst, flow.SrcPort, flow.DstPort
+		}
+		key := portKey{proto: flow.Proto, port: remotePort}
+		if localPort != 0 && (remotePort == 0 || localPort <= remotePort) {
+			key = portKey{proto: flow.Proto, port: localPort, local: true}
+		}
+
+		entry := ports[key]
+		if entry == nil {
+			entry = &monitoringModel.TrafficTalkerPort{Proto: key.proto, Port: key.port, Local: key.local}
+			ports[key] = entry
+			portPeers[key] = make(map[string]bool)
+		}
+		portPeers[key][peer] = true
+		peerEntry := peers[peer]
+		if peerEntry == nil {
+			peerEntry = &monitoringModel.TrafficTalkerPeer{Host: peer}
+			peers[peer] = peerEntry
+		}
+
+		if outbound {
+			entry.TxBytes += flow.Bytes
+			peerEntry.TxBytes += flow.Bytes
+			breakdown.TxBytes += flow.Bytes
+		} else {
+			entry.RxBytes += flow.Bytes
+			peerEntry.RxBytes += flow.Bytes
+			breakdown.RxBytes += flow.Bytes
+		}
+		entry.Packets += flow.Packets
+		peerEntry.Packets += flow.Packets
+	}
+
+	breakdown.Ports = make([]monitoringModel.TrafficTalkerPort, 0, len(ports))
+	for key, entry := range ports {
+		entry.Peers = len(portPeers[key])
+		breakdown.Ports = append(breakdown.Ports, *entry)
+	}
+	sort.Slice(breakdown.Ports, func(i, j int) bool {
+		a, b := breakdown.Ports[i], breakdown.Ports[j]
+		if a.RxBytes+a.TxBytes != b.RxBytes+b.TxBytes {
+			return a.RxBytes+a.TxBytes > b.RxBytes+b.TxBytes
+		}
+		return a.Port < b.Port
+	})
+
+	breakdown.Peers = make([]monitoringModel.TrafficTalkerPeer, 0, len(peers))
+	for _, entry := range peers {
+		breakdown.Peers = append(breakdown.Peers, *entry)
+	}
+	sort.Slice(breakdown.Peers, func(i, j int) bool {
+		a, b := breakdown.Peers[i], breakdown.Peers[j]
+		if a.RxBytes+a.TxBytes != b.RxBytes+b.TxBytes {
+			return a.RxBytes+a.TxBytes > b.RxBytes+b.TxBytes
+		}
+		return a.Host < b.Host
+	})
+
+	if limit > 0 {
+		if len(breakdown.Ports) > limit {
+			breakdown.Ports = breakdown.Ports[:limit]
+		}
+		if len(breakdown.Peers) > limit {
+			breakdown.Peers = breakdown.Peers[:limit]
+		}
+	}
+	return breakdown
+}
+
+// canonicalIP 统一地址格式，便于与pmacct写入的地址比较
+func canonicalIP(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String()
+	}
+	return addr
+}
@@ -0,0 +1,71 @@
+package pmacct
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCaptureFlows(t *testing.T) {
+	output := "10.0.0.2|1.1.1.1|443|51000|TCP|10|1500\n" +
+		"bad line\n" +
+		"2001:db8::1|2001:db8::2|0|0|icmp|1|notanumber\n"
+	flows := parseCaptureFlows(output)
+	if len(flows) != 1 {
+		t.Fatalf("parseCaptureFlows returned %d flows, want 1", len(flows))
+	}
+	if f := flows[0]; f.SrcPort != 443 || f.DstPort != 51000 || f.Proto != "tcp" || f.Bytes != 1500 {
+		t.Errorf("unexpected flow %+v", f)
+	}
+}
+
+func TestBuildTrafficBreakdown(t *testing.T) {
+	flows := []captureFlow{
+		// 实例对外提供的443服务：响应出站，请求入站
+		{SrcHost: "10.0.0.2", DstHost: "1.1.1.1", SrcPort: 443, DstPort: 51000, Proto: "tcp", Packets: 10, Bytes: 9000},
+		{SrcHost: "1.1.1.1", DstHost: "10.0.0.2", SrcPort: 51000, DstPort: 443, Proto: "tcp", Packets: 5, Bytes: 500},
+		{SrcHost: "2.2.2.2", DstHost: "10.0.0.2", SrcPort: 52000, DstPort: 443, Proto: "tcp", Packets: 2, Bytes: 200},
+		// 实例从远端下载（远端80端口）
+		{SrcHost: "3.3.3.3", DstHost: "2001:db8:0:0::5", SrcPort: 80, DstPort: 40000, Proto: "tcp", Packets: 20, Bytes: 3000},
+		// ICMP没有端口
+		{SrcHost: "10.0.0.2", DstHost: "3.3.3.3", Proto: "icmp", Packets: 1, Bytes: 84},
+		// 与实例无关的记录被忽略
+		{SrcHost: "4.4.4.4", DstHost: "5.5.5.5", SrcPort: 1, DstPort: 2, Proto: "udp", Packets: 1, Bytes: 100000},
+	}
+	b := buildTrafficBreakdown(flows, []string{"10.0.0.2", "2001:db8::5"}, 2)
+
+	if b.TxBytes != 9084 || b.RxBytes != 3700 {
+		t.Errorf("totals rx=%d tx=%d, want rx=3700 tx=9084", b.RxBytes, b.TxBytes)
+	}
+	if len(b.Ports) != 2 || len(b.Peers) != 2 {
+		t.Fatalf("limit not applied: %d ports, %d peers", len(b.Ports), len(b.Peers))
+	}
+	top := b.Ports[0]
+	if top.Port != 443 || !top.Local || top.TxBytes != 9000 || top.RxBytes != 700 || top.Peers != 2 {
+		t.Errorf("unexpected top port %+v", top)
+	}
+	second := b.Ports[1]
+	if second.Port != 80 || second.Local || second.RxBytes != 3000 {
+		t.Errorf("unexpected second port %+v", second)
+	}
+	if b.Peers[0].Host != "1.1.1.1" || b.Peers[1].Host != "3.3.3.3" || b.Peers[1].TxBytes != 84 {
+		t.Errorf("unexpected peers %+v", b.Peers)
+	}
+}
+
+func TestBuildCaptureConfig(t *testing.T) {
+	config := buildCaptureConfig(7, "vm1", []string{"pcap_interface: vmbr1", "pcap_filter: host 10.0.0.2"},
+		"/var/lib/pmacct/capture-7", "/var/lib/pmacct/capture-7/capture.db", 256, 1024, 2048)
+	for _, want := range []string{
+		"pcap_interface: vmbr1",
+		"pcap_filter: host 10.0.0.2",
+		"aggregate: src_host, dst_host, src_port, dst_port, proto",
+		"sql_db[capture]: /var/lib/pmacct/capture-7/capture.db",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config missing %q", want)
+		}
+	}
+	if strings.Contains(config, "vm1/") {
+		t.Error("capture paths should not contain the instance name")
+	}
+}
@@ -257,6 +257,7 @@ echo "=== pmacct 资源清理完成: %s ==="
 		PrivateKey:     providerRecord.SSHKey,
 		ConnectTimeout: 30 * time.Second,
 		ExecuteTimeout: 60 * time.Second,
+		Guard:          utils.ProviderCommandGuard(&providerRecord),
 	}
 
 	sshClient, err := s.sshPool.GetOrCreate(providerID, sshConfig)
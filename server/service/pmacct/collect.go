@@ -7,6 +7,8 @@ import (
 	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
 	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/service/traffic"
+	"oneclickvirt/utils"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +29,8 @@ func (s *Service) CollectTrafficFromSQLite(instance *providerModel.Instance, mon
 	if err := global.APP_DB.First(&providerRecord, instance.ProviderID).Error; err != nil {
 		return fmt.Errorf("failed to find provider: %w", err)
 	}
+	// pmacct 按宿主机时钟记录时间，按Provider时区解析并分桶
+	loc := utils.LoadLocation(providerRecord.Timezone)
 
 	// 获取provider实例（如果缓存不存在则刷新）
 	providerInstance, exists := providerService.GetProviderService().GetProviderByID(instance.ProviderID)
@@ -92,10 +96,11 @@ func (s *Service) CollectTrafficFromSQLite(instance *providerModel.Instance, mon
 		queryIPv4 = monitor.MappedIP
 	}
 
-	queryIPv6 := monitor.MappedIPv6 // IPv6直接使用公网IP
+	// IPv6同时查询公网IPv6和内网IPv6（Proxmox NAT映射IPv6时网卡上看到的是内网地址）
+	queryIPv6 := normalizeIPv6List(monitor.MappedIPv6, instance.PublicIPv6, instance.IPv6Address)
 
 	// 如果两个IP都为空，无法查询
-	if queryIPv4 == "" && queryIPv6 == "" {
+	if queryIPv4 == "" && len(queryIPv6) == 0 {
 		return fmt.Errorf("实例没有可用的IP地址：PrivateIP=%s, MappedIP=%s, MappedIPv6=%s",
 			instance.PrivateIP, monitor.MappedIP, monitor.MappedIPv6)
 	}
@@ -105,12 +110,26 @@ func (s *Service) CollectTrafficFromSQLite(instance *providerModel.Instance, mon
 	if queryIPv4 != "" {
 		ipList = append(ipList, queryIPv4)
 	}
-	if queryIPv6 != "" {
-		ipList = append(ipList, queryIPv6)
-	}
+	ipList = append(ipList, queryIPv6...)
 
 	// 构建SQL IN子句
-	ipInClause := "'" + strings.Join(ipList, "','") + "'"
+	ipInClause := sqlInList(ipList)
+
+	// IPv6流量单独累计，用于按地址族拆分（无IPv6地址时恒为0）
+	txV6Expr, rxV6Expr := "0", "0"
+	if len(queryIPv6) > 0 {
+		ipv6InClause := sqlInList(queryIPv6)
+		txV6Expr = fmt.Sprintf(`CASE 
+            WHEN COALESCE(src_host, ip_src) IN (%s)
+             AND COALESCE(dst_host, ip_dst) NOT IN (%s)
+            THEN bytes ELSE 0 
+        END`, ipv6InClause, ipInClause)
+		rxV6Expr = fmt.Sprintf(`CASE 
+            WHEN COALESCE(dst_host, ip_dst) IN (%s)
+             AND COALESCE(src_host, ip_src) NOT IN (%s)
+            THEN bytes ELSE 0 
+        END`, ipv6InClause, ipInClause)
+	}
 
 	// 核心策略：直接查询每个时间点的累积值，不按时间分组
 	// - pmacct的acct_v9表中每条记录的bytes字段是该记录的流量增量
@@ -121,7 +140,7 @@ func (s *Service) CollectTrafficFromSQLite(instance *providerModel.Instance, mon
 		zap.Uint("instanceID", instanceID),
 		zap.String("instanceName", instance.Name),
 		zap.String("queryIPv4", queryIPv4),
-		zap.String("queryIPv6", queryIPv6),
+		zap.Strings("queryIPv6", queryIPv6),
 		zap.String("ipInClause", ipInClause),
 		zap.String("dbPath", dbPath),
 		zap.String("strategy", "窗口函数累加计算累积值"))
@@ -150,7 +169,9 @@ WITH time_slots AS (
             WHEN COALESCE(dst_host, ip_dst) IN (%s)
              AND COALESCE(src_host, ip_src) NOT IN (%s)
             THEN bytes ELSE 0 
-        END) as rx_increment
+        END) as rx_increment,
+        SUM(%s) as tx_v6_increment,
+        SUM(%s) as rx_v6_increment
     FROM acct_v9
     WHERE (
         COALESCE(src_host, ip_src) IN (%s)
@@ -167,13 +188,16 @@ SELECT
     minute,
     timestamp,
     SUM(tx_increment) OVER (ORDER BY timestamp) as tx_bytes,
-    SUM(rx_increment) OVER (ORDER BY timestamp) as rx_bytes
+    SUM(rx_increment) OVER (ORDER BY timestamp) as rx_bytes,
+    SUM(tx_v6_increment) OVER (ORDER BY timestamp) as tx_v6_bytes,
+    SUM(rx_v6_increment) OVER (ORDER BY timestamp) as rx_v6_bytes
 FROM time_slots
 ORDER BY timestamp
 LIMIT 10000;
 "`, dbPath,
 		ipInClause, ipInClause,
 		ipInClause, ipInClause,
+		txV6Expr, rxV6Expr,
 		ipInClause, ipInClause)
 
 	ctx, cancel := context.WithTimeout(s.ctx, 60*time.Second)
@@ -228,6 +252,8 @@ LIMIT 10000;
 		txBytes    int64
 		rxBytes    int64
 		totalBytes int64
+		txBytesV6  int64
+		rxBytesV6  int64
 	}
 	var dataList []trafficData
 
@@ -236,9 +262,9 @@ LIMIT 10000;
 			continue
 		}
 
-		// 解析数据行: year|month|day|hour|minute|timestamp|tx_bytes|rx_bytes
+		// 解析数据行: year|month|day|hour|minute|timestamp|tx_bytes|rx_bytes|tx_v6_bytes|rx_v6_bytes
 		parts := strings.Split(line, "|")
-		if len(parts) != 8 {
+		if len(parts) != 10 {
 			global.APP_LOG.Warn("跳过无效数据行",
 				zap.String("line", line),
 				zap.Int("parts", len(parts)))
@@ -253,9 +279,11 @@ LIMIT 10000;
 		timestampStr := parts[5]
 		txBytes, _ := strconv.ParseInt(parts[6], 10, 64)
 		rxBytes, _ := strconv.ParseInt(parts[7], 10, 64)
+		txBytesV6, _ := strconv.ParseInt(parts[8], 10, 64)
+		rxBytesV6, _ := strconv.ParseInt(parts[9], 10, 64)
 
 		// 解析时间戳
-		timestamp, err := time.Parse("2006-01-02 15:04:05", timestampStr)
+		timestamp, err := time.ParseInLocation("2006-01-02 15:04:05", timestampStr, loc)
 		if err != nil {
 			global.APP_LOG.Warn("解析时间戳失败",
 				zap.String("timestamp", timestampStr),
@@ -273,6 +301,8 @@ LIMIT 10000;
 			txBytes:    txBytes,
 			rxBytes:    rxBytes,
 			totalBytes: txBytes + rxBytes,
+			txBytesV6:  txBytesV6,
+			rxBytesV6:  rxBytesV6,
 		})
 	}
 
@@ -297,6 +327,8 @@ LIMIT 10000;
 			RxBytes:      data.rxBytes,
 			TxBytes:      data.txBytes,
 			TotalBytes:   data.totalBytes,
+			RxBytesV6:    data.rxBytesV6,
+			TxBytesV6:    data.txBytesV6,
 			Timestamp:    data.timestamp,
 			Year:         data.year,
 			Month:        data.month,
@@ -312,6 +344,8 @@ LIMIT 10000;
 		MaxRxBytes    int64
 		MaxTxBytes    int64
 		MaxTotalBytes int64
+		MaxRxBytesV6  int64
+		MaxTxBytesV6  int64
 		LastTimestamp time.Time
 	}
 	var lastMax lastMaxTraffic
@@ -323,6 +357,8 @@ LIMIT 10000;
 			COALESCE(MAX(rx_bytes), 0) as max_rx_bytes,
 			COALESCE(MAX(tx_bytes), 0) as max_tx_bytes,
 			COALESCE(MAX(total_bytes), 0) as max_total_bytes,
+			COALESCE(MAX(rx_bytes_v6), 0) as max_rx_bytes_v6,
+			COALESCE(MAX(tx_bytes_v6), 0) as max_tx_bytes_v6,
 			COALESCE(MAX(timestamp), ?) as last_timestamp
 		FROM pmacct_traffic_records
 		WHERE instance_id = ? 
@@ -391,6 +427,7 @@ LIMIT 10000;
 			// 生成填补数据
 			var fillRecords []monitoringModel.PmacctTrafficRecord
 			for current := fillStart; current.Before(dataList[0].timestamp); current = current.Add(time.Minute) {
+				local := current.In(loc)
 				fillRecords = append(fillRecords, monitoringModel.PmacctTrafficRecord{
 					InstanceID:   instanceID,
 					UserID:       instance.UserID,
@@ -400,12 +437,14 @@ LIMIT 10000;
 					RxBytes:      lastMax.MaxRxBytes,
 					TxBytes:      lastMax.MaxTxBytes,
 					TotalBytes:   lastMax.MaxTotalBytes,
+					RxBytesV6:    lastMax.MaxRxBytesV6,
+					TxBytesV6:    lastMax.MaxTxBytesV6,
 					Timestamp:    current,
-					Year:         current.Year(),
-					Month:        int(current.Month()),
-					Day:          current.Day(),
-					Hour:         current.Hour(),
-					Minute:       current.Minute(),
+					Year:         local.Year(),
+					Month:        int(local.Month()),
+					Day:          local.Day(),
+					Hour:         local.Hour(),
+					Minute:       local.Minute(),
 				})
 			}
 
@@ -422,10 +461,10 @@ LIMIT 10000;
 					// 每批使用独立的短事务
 					err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
 						values := make([]string, 0, len(batch))
-						args := make([]interface{}, 0, len(batch)*15)
+						args := make([]interface{}, 0, len(batch)*17)
 
 						for _, record := range batch {
-							values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+							values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 							args = append(args,
 								record.InstanceID,
 								record.UserID,
@@ -435,6 +474,8 @@ LIMIT 10000;
 								record.RxBytes,
 								record.TxBytes,
 								record.TotalBytes,
+								record.RxBytesV6,
+								record.TxBytesV6,
 								record.Timestamp,
 								record.Year,
 								record.Month,
@@ -445,13 +486,11 @@ LIMIT 10000;
 							)
 						}
 
-						insertSQL := fmt.Sprintf(`
-							INSERT IGNORE INTO pmacct_traffic_records 
+						insertSQL := utils.SQLInsertIgnore(tx, fmt.Sprintf(`pmacct_traffic_records 
 							(instance_id, user_id, provider_id, provider_type, mapped_ip, 
-							 rx_bytes, tx_bytes, total_bytes, timestamp, 
+							 rx_bytes, tx_bytes, total_bytes, rx_bytes_v6, tx_bytes_v6, timestamp, 
 							 year, month, day, hour, minute, record_time)
-							VALUES %s
-						`, strings.Join(values, ","))
+							VALUES %s`, strings.Join(values, ",")))
 
 						return tx.Exec(insertSQL, args...).Error
 					})
@@ -485,10 +524,10 @@ LIMIT 10000;
 		// 每批使用独立的短事务
 		err := global.APP_DB.Transaction(func(tx *gorm.DB) error {
 			values := make([]string, 0, len(batch))
-			args := make([]interface{}, 0, len(batch)*15)
+			args := make([]interface{}, 0, len(batch)*17)
 
 			for _, record := range batch {
-				values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+				values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 				args = append(args,
 					record.InstanceID,
 					record.UserID,
@@ -498,6 +537,8 @@ LIMIT 10000;
 					record.RxBytes,
 					record.TxBytes,
 					record.TotalBytes,
+					record.RxBytesV6,
+					record.TxBytesV6,
 					record.Timestamp,
 					record.Year,
 					record.Month,
@@ -511,31 +552,11 @@ LIMIT 10000;
 			insertSQL := fmt.Sprintf(`
 				INSERT INTO pmacct_traffic_records 
 				(instance_id, user_id, provider_id, provider_type, mapped_ip, 
-				 rx_bytes, tx_bytes, total_bytes, timestamp, 
+				 rx_bytes, tx_bytes, total_bytes, rx_bytes_v6, tx_bytes_v6, timestamp, 
 				 year, month, day, hour, minute, record_time)
 				VALUES %s
-				ON DUPLICATE KEY UPDATE
-					rx_bytes = IF(
-						TIMESTAMPDIFF(MINUTE, pmacct_traffic_records.timestamp, NOW()) <= 5 OR VALUES(rx_bytes) > pmacct_traffic_records.rx_bytes,
-						VALUES(rx_bytes),
-						pmacct_traffic_records.rx_bytes
-					),
-					tx_bytes = IF(
-						TIMESTAMPDIFF(MINUTE, pmacct_traffic_records.timestamp, NOW()) <= 5 OR VALUES(tx_bytes) > pmacct_traffic_records.tx_bytes,
-						VALUES(tx_bytes),
-						pmacct_traffic_records.tx_bytes
-					),
-					total_bytes = IF(
-						TIMESTAMPDIFF(MINUTE, pmacct_traffic_records.timestamp, NOW()) <= 5 OR VALUES(total_bytes) > pmacct_traffic_records.total_bytes,
-						VALUES(total_bytes),
-						pmacct_traffic_records.total_bytes
-					),
-					record_time = IF(
-						TIMESTAMPDIFF(MINUTE, pmacct_traffic_records.timestamp, NOW()) <= 5 OR VALUES(total_bytes) > pmacct_traffic_records.total_bytes,
-						VALUES(record_time),
-						pmacct_traffic_records.record_time
-					)
-			`, strings.Join(values, ","))
+				%s
+			`, strings.Join(values, ","), collectUpsertClause(tx))
 
 			return tx.Exec(insertSQL, args...).Error
 		})
@@ -564,7 +585,7 @@ LIMIT 10000;
 	// pmacct_traffic_records存储的是累积值快照，历史表应存储时间段内的最大累积值
 	// 前端/API查询时通过相邻时间点的差值计算实际使用量
 	if imported > 0 {
-		now := time.Now()
+		now := time.Now().In(loc)
 		year, month := now.Year(), int(now.Month())
 		day, hour := now.Day(), now.Hour()
 
@@ -739,8 +760,9 @@ LIMIT 10000;
 				zap.Error(err))
 		}
 
+		db := global.APP_DB
 		// 更新Provider流量历史表（小时级，聚合所有实例）
-		if err := global.APP_DB.Exec(`
+		if err := db.Exec(fmt.Sprintf(`
 			INSERT INTO provider_traffic_histories 
 				(provider_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 			SELECT 
@@ -750,27 +772,21 @@ LIMIT 10000;
 				SUM(total_used) as total_used,
 				COUNT(DISTINCT instance_id) as instance_count,
 				year, month, day, hour,
-				? as record_time,
-				? as created_at,
-				? as updated_at
+				%[1]s as record_time,
+				%[1]s as created_at,
+				%[1]s as updated_at
 			FROM instance_traffic_histories
 			WHERE provider_id = ? AND year = ? AND month = ? AND day = ? AND hour = ? AND deleted_at IS NULL
 			GROUP BY provider_id, year, month, day, hour
-			ON DUPLICATE KEY UPDATE
-				traffic_in = VALUES(traffic_in),
-				traffic_out = VALUES(traffic_out),
-				total_used = VALUES(total_used),
-				instance_count = VALUES(instance_count),
-				record_time = VALUES(record_time),
-				updated_at = VALUES(updated_at)
-		`, now, now, now, instance.ProviderID, year, month, day, hour).Error; err != nil {
+			%[2]s
+		`, utils.SQLTimeArg(db), traffic.HistoryUpsertClause(db, "provider_id")), now, now, now, instance.ProviderID, year, month, day, hour).Error; err != nil {
 			global.APP_LOG.Warn("更新Provider流量历史失败",
 				zap.Uint("providerID", instance.ProviderID),
 				zap.Error(err))
 		}
 
 		// 更新Provider月度汇总（day=0, hour=0）
-		if err := global.APP_DB.Exec(`
+		if err := db.Exec(fmt.Sprintf(`
 			INSERT INTO provider_traffic_histories 
 				(provider_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 			SELECT 
@@ -780,27 +796,21 @@ LIMIT 10000;
 				SUM(total_used) as total_used,
 				COUNT(DISTINCT instance_id) as instance_count,
 				year, month, 0 as day, 0 as hour,
-				? as record_time,
-				? as created_at,
-				? as updated_at
+				%[1]s as record_time,
+				%[1]s as created_at,
+				%[1]s as updated_at
 			FROM instance_traffic_histories
 			WHERE provider_id = ? AND year = ? AND month = ? AND day = 0 AND hour = 0 AND deleted_at IS NULL
 			GROUP BY provider_id, year, month
-			ON DUPLICATE KEY UPDATE
-				traffic_in = VALUES(traffic_in),
-				traffic_out = VALUES(traffic_out),
-				total_used = VALUES(total_used),
-				instance_count = VALUES(instance_count),
-				record_time = VALUES(record_time),
-				updated_at = VALUES(updated_at)
-		`, now, now, now, instance.ProviderID, year, month).Error; err != nil {
+			%[2]s
+		`, utils.SQLTimeArg(db), traffic.HistoryUpsertClause(db, "provider_id")), now, now, now, instance.ProviderID, year, month).Error; err != nil {
 			global.APP_LOG.Warn("更新Provider月度汇总失败",
 				zap.Uint("providerID", instance.ProviderID),
 				zap.Error(err))
 		}
 
 		// 更新用户流量历史表（小时级，聚合所有实例）
-		if err := global.APP_DB.Exec(`
+		if err := db.Exec(fmt.Sprintf(`
 			INSERT INTO user_traffic_histories 
 				(user_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 			SELECT 
@@ -810,27 +820,21 @@ LIMIT 10000;
 				SUM(total_used) as total_used,
 				COUNT(DISTINCT instance_id) as instance_count,
 				year, month, day, hour,
-				? as record_time,
-				? as created_at,
-				? as updated_at
+				%[1]s as record_time,
+				%[1]s as created_at,
+				%[1]s as updated_at
 			FROM instance_traffic_histories
 			WHERE user_id = ? AND year = ? AND month = ? AND day = ? AND hour = ? AND deleted_at IS NULL
 			GROUP BY user_id, year, month, day, hour
-			ON DUPLICATE KEY UPDATE
-				traffic_in = VALUES(traffic_in),
-				traffic_out = VALUES(traffic_out),
-				total_used = VALUES(total_used),
-				instance_count = VALUES(instance_count),
-				record_time = VALUES(record_time),
-				updated_at = VALUES(updated_at)
-		`, now, now, now, instance.UserID, year, month, day, hour).Error; err != nil {
+			%[2]s
+		`, utils.SQLTimeArg(db), traffic.HistoryUpsertClause(db, "user_id")), now, now, now, instance.UserID, year, month, day, hour).Error; err != nil {
 			global.APP_LOG.Warn("更新用户流量历史失败",
 				zap.Uint("userID", instance.UserID),
 				zap.Error(err))
 		}
 
 		// 更新用户月度汇总（day=0, hour=0）
-		if err := global.APP_DB.Exec(`
+		if err := db.Exec(fmt.Sprintf(`
 			INSERT INTO user_traffic_histories 
 				(user_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 			SELECT 
@@ -840,20 +844,14 @@ LIMIT 10000;
 				SUM(total_used) as total_used,
 				COUNT(DISTINCT instance_id) as instance_count,
 				year, month, 0 as day, 0 as hour,
-				? as record_time,
-				? as created_at,
-				? as updated_at
+				%[1]s as record_time,
+				%[1]s as created_at,
+				%[1]s as updated_at
 			FROM instance_traffic_histories
 			WHERE user_id = ? AND year = ? AND month = ? AND day = 0 AND hour = 0 AND deleted_at IS NULL
 			GROUP BY user_id, year, month
-			ON DUPLICATE KEY UPDATE
-				traffic_in = VALUES(traffic_in),
-				traffic_out = VALUES(traffic_out),
-				total_used = VALUES(total_used),
-				instance_count = VALUES(instance_count),
-				record_time = VALUES(record_time),
-				updated_at = VALUES(updated_at)
-		`, now, now, now, instance.UserID, year, month).Error; err != nil {
+			%[2]s
+		`, utils.SQLTimeArg(db), traffic.HistoryUpsertClause(db, "user_id")), now, now, now, instance.UserID, year, month).Error; err != nil {
 			global.APP_LOG.Warn("更新用户月度汇总失败",
 				zap.Uint("userID", instance.UserID),
 				zap.Error(err))
@@ -874,3 +872,21 @@ LIMIT 10000;
 
 	return nil
 }
+
+// collectUpsertClause 采集记录与已有记录冲突时的更新规则：
+// 5分钟内的记录或新值更大时覆盖，否则保留已有值（避免pmacct重置后的较小值覆盖累积值）
+func collectUpsertClause(db *gorm.DB) string {
+	minutesSince := utils.SQLMinutesSince(db, "pmacct_traffic_records.timestamp")
+	pick := func(column, compareColumn string) string {
+		return fmt.Sprintf("%[1]s = CASE WHEN %[3]s <= 5 OR %[4]s > pmacct_traffic_records.%[2]s THEN %[5]s ELSE pmacct_traffic_records.%[1]s END",
+			column, compareColumn, minutesSince, utils.SQLInserted(db, compareColumn), utils.SQLInserted(db, column))
+	}
+	return utils.SQLUpsert(db, []string{"instance_id", "timestamp"},
+		pick("rx_bytes", "rx_bytes"),
+		pick("tx_bytes", "tx_bytes"),
+		// IPv6拆分值跟随总流量是否覆盖，需在total_bytes更新前计算（MySQL按顺序求值）
+		pick("rx_bytes_v6", "total_bytes"),
+		pick("tx_bytes_v6", "total_bytes"),
+		pick("total_bytes", "total_bytes"),
+		pick("record_time", "total_bytes"))
+}
@@ -52,7 +52,17 @@ func (s *Service) checkPmacctVersion(providerInstance provider.Provider) error {
 }
 
 // detectNetworkInterface 检测宿主机的主网络接口
-func (s *Service) detectNetworkInterface(providerInstance provider.Provider) (string, error) {
+// Provider配置了公网网卡或附加上行网卡时，优先使用承载实例公网IP的网卡，否则按默认路由检测
+func (s *Service) detectNetworkInterface(providerInstance provider.Provider, instance *providerModel.Instance) (string, error) {
+	if iface := s.configuredUplinkInterface(instance); iface != "" {
+		if s.verifyInterfaceExists(providerInstance, iface) {
+			return iface, nil
+		}
+		global.APP_LOG.Warn("Provider配置的网卡在宿主机上不存在，回退到自动检测",
+			zap.String("instance", instance.Name),
+			zap.String("interface", iface))
+	}
+
 	// 尝试多种方法检测主网络接口
 	// 方法1: 通过默认路由检测
 	detectCmd := `
@@ -115,6 +125,18 @@ echo "eth0"  # 使用默认值作为后备
 	return networkInterface, nil
 }
 
+// configuredUplinkInterface 返回Provider配置中承载实例公网IP的网卡，未配置时返回空
+func (s *Service) configuredUplinkInterface(instance *providerModel.Instance) string {
+	if instance == nil || instance.ProviderID == 0 {
+		return ""
+	}
+	var providerRecord providerModel.Provider
+	if err := global.APP_DB.Select("id", "public_interface", "uplinks").First(&providerRecord, instance.ProviderID).Error; err != nil {
+		return ""
+	}
+	return providerRecord.InterfaceForIP(instance.PublicIP)
+}
+
 // verifyInterfaceExists 验证网络接口是否存在于宿主机上
 // 用于检查数据库中保存的网卡是否仍然有效（避免容器重启后网卡名变化）
 func (s *Service) verifyInterfaceExists(providerInstance provider.Provider, interfaceName string) bool {
@@ -168,6 +190,24 @@ func (s *Service) detectVethInterface(providerInstance provider.Provider, instan
 				zap.String("instance", instanceName),
 				zap.Error(err))
 		}
+	} else if providerType == "lxc" || providerType == "kubernetes" {
+		// LXC容器的veth接口名称在创建时写入容器配置，Kubernetes通过Pod网卡的iflink定位宿主机veth
+		// 两者都没有可靠的备用检测方法，失败时直接返回错误
+		vethProv, ok := providerInstance.(interface {
+			GetVethInterfaceName(string) (string, error)
+		})
+		if !ok {
+			return "", fmt.Errorf("%s Provider不支持获取veth接口", providerType)
+		}
+		vethName, err := vethProv.GetVethInterfaceName(instanceName)
+		if err != nil {
+			return "", fmt.Errorf("获取%s实例veth接口失败: %w", providerType, err)
+		}
+		global.APP_LOG.Info("通过Provider方法成功获取veth接口",
+			zap.String("providerType", providerType),
+			zap.String("instance", instanceName),
+			zap.String("veth", vethName))
+		return vethName, nil
 	} else if providerType == "incus" {
 		if incusProv, ok := providerInstance.(interface {
 			GetVethInterfaceName(context.Context, string) (string, error)
@@ -478,6 +518,32 @@ exit 1
 	return interfaceName, nil
 }
 
+// proxmoxInterfacePattern 匹配Proxmox实例网卡名：veth<ctid>i<n> 或 tap<vmid>i<n>
+var proxmoxInterfacePattern = regexp.MustCompile(`^((?:veth|tap)\d+i)(\d+)$`)
+
+// proxmoxSecondaryInterface 返回Proxmox实例第二块网卡的接口名（veth<id>i0 -> veth<id>i1, tap<id>i0 -> tap<id>i1）
+// 非Proxmox命名格式返回空字符串
+func proxmoxSecondaryInterface(primary string) string {
+	matches := proxmoxInterfacePattern.FindStringSubmatch(primary)
+	if matches == nil || matches[2] != "0" {
+		return ""
+	}
+	return matches[1] + "1"
+}
+
+// proxmoxIPv6Interface 检测Proxmox实例承载IPv6流量的接口
+// nat_ipv4_ipv6/dedicated_ipv4_ipv6/ipv6_only 模式下IPv6位于net1（vmbr2），对应 i1 接口
+func (s *Service) proxmoxIPv6Interface(providerInstance provider.Provider, primary string) string {
+	secondary := proxmoxSecondaryInterface(primary)
+	if secondary != "" && s.verifyInterfaceExists(providerInstance, secondary) {
+		global.APP_LOG.Info("检测到Proxmox实例独立的IPv6网络接口",
+			zap.String("ipv4Interface", primary),
+			zap.String("ipv6Interface", secondary))
+		return secondary
+	}
+	return primary
+}
+
 // NetworkInterfaceInfo 网络接口信息
 type NetworkInterfaceInfo struct {
 	IPv4Interface string // IPv4流量监控的网络接口
@@ -525,6 +591,10 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 
 	// 如果数据库中已有完整的接口信息且都验证通过，直接返回
 	if !needRedetect && info.IPv4Interface != "" && (!hasIPv6 || info.IPv6Interface != "") {
+		// 旧版本将Proxmox的IPv6接口记录为与IPv4相同的i0接口，IPv6实际经过独立的i1接口（vmbr2）
+		if providerType == "proxmox" && hasIPv6 && info.IPv6Interface == info.IPv4Interface {
+			info.IPv6Interface = s.proxmoxIPv6Interface(providerInstance, info.IPv4Interface)
+		}
 		return info, nil
 	}
 
@@ -533,8 +603,8 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 		zap.String("instance", instanceName),
 		zap.Bool("hasIPv6", hasIPv6))
 
-	// Docker/LXD/Incus 容器: 优先检测veth接口
-	if providerType == "docker" || providerType == "lxd" || providerType == "incus" {
+	// Docker/LXD/Incus/LXC 容器: 优先检测veth接口
+	if providerType == "docker" || providerType == "lxd" || providerType == "incus" || providerType == "lxc" {
 		// 尝试检测veth接口
 		vethInterface, err := s.detectVethInterface(providerInstance, instanceName)
 		if err != nil {
@@ -543,7 +613,7 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 				zap.String("providerType", providerType),
 				zap.Error(err))
 			// 回退到主网络接口
-			mainInterface, err := s.detectNetworkInterface(providerInstance)
+			mainInterface, err := s.detectNetworkInterface(providerInstance, instance)
 			if err != nil {
 				return nil, fmt.Errorf("failed to detect network interface: %w", err)
 			}
@@ -560,6 +630,16 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 				info.IPv6Interface = vethInterface
 			}
 		}
+	} else if providerType == "kubernetes" {
+		// Kubernetes: 只能统计调度到SSH节点上的Pod，主网络接口包含整个节点的流量，不能作为回退
+		vethInterface, err := s.detectVethInterface(providerInstance, instanceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect kubernetes pod interface: %w", err)
+		}
+		info.IPv4Interface = vethInterface
+		if hasIPv6 {
+			info.IPv6Interface = vethInterface
+		}
 	} else if providerType == "proxmox" {
 		// Proxmox VE: 使用专门的检测方法
 		// 通过实例ID或MAC地址精确识别 veth/tap 接口
@@ -584,7 +664,7 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 		if proxmoxInterface == "" {
 			global.APP_LOG.Info("使用通用方法检测Proxmox网络接口",
 				zap.String("instance", instanceName))
-			mainInterface, err := s.detectNetworkInterface(providerInstance)
+			mainInterface, err := s.detectNetworkInterface(providerInstance, instance)
 			if err != nil {
 				return nil, fmt.Errorf("failed to detect network interface: %w", err)
 			}
@@ -595,12 +675,12 @@ func (s *Service) detectNetworkInterfaces(providerInstance provider.Provider, in
 			info.IPv4Interface = proxmoxInterface
 		}
 		if hasIPv6 && info.IPv6Interface == "" {
-			// Proxmox 的 IPv4 和 IPv6 通常使用同一个 tap/veth 接口
-			info.IPv6Interface = proxmoxInterface
+			// 配置了IPv6的Proxmox实例使用独立的第二块网卡（vmbr2），不存在时与IPv4共用同一个 tap/veth 接口
+			info.IPv6Interface = s.proxmoxIPv6Interface(providerInstance, proxmoxInterface)
 		}
 	} else {
 		// 其他虚拟化类型: 使用主网络接口
-		mainInterface, err := s.detectNetworkInterface(providerInstance)
+		mainInterface, err := s.detectNetworkInterface(providerInstance, instance)
 		if err != nil {
 			return nil, fmt.Errorf("failed to detect network interface: %w", err)
 		}
@@ -0,0 +1,73 @@
+package pmacct
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ipv4InternalNetFilter 排除IPv4内网互访、多播、广播和链路本地流量
+const ipv4InternalNetFilter = "not ((src net 10.0.0.0/8 and dst net 10.0.0.0/8) or " +
+	"(src net 172.16.0.0/12 and dst net 172.16.0.0/12) or " +
+	"(src net 192.168.0.0/16 and dst net 192.168.0.0/16) or " +
+	"(src net 127.0.0.0/8 and dst net 127.0.0.0/8) or " +
+	"(dst net 224.0.0.0/4) or " +
+	"(dst host 255.255.255.255) or " +
+	"(src net 169.254.0.0/16 or dst net 169.254.0.0/16))"
+
+// ipv6InternalNetFilter 排除IPv6链路本地（邻居发现等）和多播流量
+const ipv6InternalNetFilter = "not ((src net fe80::/10 or dst net fe80::/10) or (dst net ff00::/8))"
+
+// normalizeIPv6List 规范化并去重IPv6地址（去掉前缀长度，统一为pmacct写入SQLite时的压缩格式）
+// 非IPv6地址会被忽略
+func normalizeIPv6List(addrs ...string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if idx := strings.Index(addr, "/"); idx != -1 {
+			addr = addr[:idx]
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil || ip.To4() != nil {
+			continue
+		}
+		canonical := ip.String()
+		if seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		result = append(result, canonical)
+	}
+	return result
+}
+
+// buildPcapFilter 构建pmacct的BPF过滤器，IPv4与IPv6分别限定实例地址并排除各自的内网/链路本地流量
+// ipv6可包含多个地址：Proxmox NAT映射IPv6时宿主机网桥上看到的是实例内网IPv6，需要与公网IPv6一起纳入
+func buildPcapFilter(ipv4 string, ipv6 []string) string {
+	var parts []string
+	if ipv4 != "" {
+		parts = append(parts, fmt.Sprintf("(host %s and %s)", ipv4, ipv4InternalNetFilter))
+	}
+	if len(ipv6) > 0 {
+		hosts := make([]string, 0, len(ipv6))
+		for _, addr := range ipv6 {
+			hosts = append(hosts, "host "+addr)
+		}
+		parts = append(parts, fmt.Sprintf("((%s) and %s)", strings.Join(hosts, " or "), ipv6InternalNetFilter))
+	}
+
+	switch len(parts) {
+	case 0:
+		return ipv4InternalNetFilter
+	case 1:
+		return strings.TrimSuffix(strings.TrimPrefix(parts[0], "("), ")")
+	default:
+		return strings.Join(parts, " or ")
+	}
+}
+
+// sqlInList 将IP列表拼接为SQL IN子句内容，地址已经过校验不含引号
+func sqlInList(ips []string) string {
+	return "'" + strings.Join(ips, "','") + "'"
+}
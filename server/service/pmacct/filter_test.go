@@ -0,0 +1,104 @@
+package pmacct
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	monitoringModel "oneclickvirt/model/monitoring"
+)
+
+func TestNormalizeIPv6List(t *testing.T) {
+	got := normalizeIPv6List(
+		"2001:DB8:1::0065/64",
+		"2001:db8:1::65",
+		"",
+		"203.0.113.10",
+		"2001:db8:abcd::1",
+		"not-an-ip",
+	)
+	want := []string{"2001:db8:1::65", "2001:db8:abcd::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("normalizeIPv6List = %v, want %v", got, want)
+	}
+}
+
+func TestBuildPcapFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		ipv4     string
+		ipv6     []string
+		contains []string
+		absent   []string
+	}{
+		{
+			name:     "dual stack with NAT-mapped IPv6",
+			ipv4:     "10.0.0.5",
+			ipv6:     []string{"2001:db8:abcd::1", "2001:db8:1::65"},
+			contains: []string{"(host 10.0.0.5 and not (", "((host 2001:db8:abcd::1 or host 2001:db8:1::65) and not (", "fe80::/10", ") or ("},
+		},
+		{
+			name:     "ipv4 only",
+			ipv4:     "10.0.0.5",
+			contains: []string{"host 10.0.0.5 and not ("},
+			absent:   []string{"fe80::/10", " or ((host"},
+		},
+		{
+			name:     "ipv6 only",
+			ipv6:     []string{"2001:db8:abcd::1"},
+			contains: []string{"(host 2001:db8:abcd::1) and not ((src net fe80::/10"},
+			absent:   []string{"10.0.0.0/8"},
+		},
+		{
+			name:     "no address",
+			contains: []string{ipv4InternalNetFilter},
+			absent:   []string{"host 2001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildPcapFilter(tt.ipv4, tt.ipv6)
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("filter %q missing %q", got, s)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(got, s) {
+					t.Errorf("filter %q should not contain %q", got, s)
+				}
+			}
+			if strings.Count(got, "(") != strings.Count(got, ")") {
+				t.Errorf("filter %q has unbalanced parentheses", got)
+			}
+		})
+	}
+}
+
+func TestProxmoxSecondaryInterface(t *testing.T) {
+	tests := map[string]string{
+		"tap101i0":  "tap101i1",
+		"veth178i0": "veth178i1",
+		"tap101i1":  "",
+		"eth0":      "",
+		"vmbr0":     "",
+	}
+	for in, want := range tests {
+		if got := proxmoxSecondaryInterface(in); got != want {
+			t.Errorf("proxmoxSecondaryInterface(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNewTrafficFamilyBreakdown(t *testing.T) {
+	got := monitoringModel.NewTrafficFamilyBreakdown(1000, 500, 300, 600)
+	want := &monitoringModel.TrafficFamilyBreakdown{
+		IPv4RxBytes: 700,
+		IPv4TxBytes: 0,
+		IPv6RxBytes: 300,
+		IPv6TxBytes: 500,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("NewTrafficFamilyBreakdown = %+v, want %+v", got, want)
+	}
+}
@@ -211,11 +211,12 @@ func (s *Service) InitializePmacctForInstance(instanceID uint) error {
 	// 确定用于BPF过滤器的IP
 	// IPv4: 使用PrivateIP（NAT场景），如果为空则不限制host
 	bpfIPv4 := instance.PrivateIP
-	// IPv6: 直接使用公网IPv6（不经过NAT）
-	bpfIPv6 := monitorIPv6
+	// IPv6: 公网IPv6与内网IPv6都纳入过滤器
+	// Proxmox NAT映射IPv6时，实例网卡上看到的是内网IPv6（宿主机ip6tables DNAT/SNAT到公网IPv6）
+	bpfIPv6 := normalizeIPv6List(monitorIPv6, instance.IPv6Address)
 
 	// 即使 bpfIPv4 和 bpfIPv6 都为空，也可以继续（只过滤内网流量）
-	if bpfIPv4 == "" && bpfIPv6 == "" {
+	if bpfIPv4 == "" && len(bpfIPv6) == 0 {
 		global.APP_LOG.Warn("无法获取实例的监控IP，将监控所有非内网流量",
 			zap.String("instanceName", instance.Name),
 			zap.String("PrivateIP", instance.PrivateIP),
@@ -225,7 +226,7 @@ func (s *Service) InitializePmacctForInstance(instanceID uint) error {
 
 	global.APP_LOG.Info("配置pmacct监控",
 		zap.String("bpfIPv4", bpfIPv4),
-		zap.String("bpfIPv6", bpfIPv6),
+		zap.Strings("bpfIPv6", bpfIPv6),
 		zap.String("mappedIPv4", monitorIPv4),
 		zap.String("mappedIPv6", monitorIPv6))
 
@@ -423,18 +424,18 @@ func (s *Service) versionToString(version []int) string {
 }
 
 // configurePmacctForIPs 配置pmacct监控特定IP的流量（支持IPv4和IPv6）
-// bpfIPv4/bpfIPv6: BPF过滤器使用的IP（容器用内网IP，虚拟机用公网IP）
+// bpfIPv4/bpfIPv6: BPF过滤器使用的IP（容器用内网IP，虚拟机用公网IP；IPv6可包含公网和NAT映射的内网地址）
 // publicIPv4/publicIPv6: 记录用的公网IP（用于数据库存储和显示）
-func (s *Service) configurePmacctForIPs(providerInstance provider.Provider, instanceName, bpfIPv4, bpfIPv6, publicIPv4, publicIPv6 string) error {
+func (s *Service) configurePmacctForIPs(providerInstance provider.Provider, instanceName, bpfIPv4 string, bpfIPv6 []string, publicIPv4, publicIPv6 string) error {
 	global.APP_LOG.Info("配置pmacct监控",
 		zap.String("instance", instanceName),
 		zap.String("bpfIPv4", bpfIPv4),
-		zap.String("bpfIPv6", bpfIPv6),
+		zap.Strings("bpfIPv6", bpfIPv6),
 		zap.String("publicIPv4", publicIPv4),
 		zap.String("publicIPv6", publicIPv6))
 
 	// 检测网络接口（支持IPv4和IPv6）
-	hasIPv6 := bpfIPv6 != "" || publicIPv6 != ""
+	hasIPv6 := len(bpfIPv6) > 0 || publicIPv6 != ""
 
 	// 从数据库获取实例信息（用于获取已保存的网络接口）
 	var instance providerModel.Instance
@@ -492,30 +493,24 @@ func (s *Service) configurePmacctForIPs(providerInstance provider.Provider, inst
 		monitorInfo += fmt.Sprintf(" (BPF Monitor: %s)", bpfIPv4)
 	}
 
-	// 构建BPF过滤器
-	var bpfFilter string
-	internalNetFilter := "not ((src net 10.0.0.0/8 and dst net 10.0.0.0/8) or " +
-		"(src net 172.16.0.0/12 and dst net 172.16.0.0/12) or " +
-		"(src net 192.168.0.0/16 and dst net 192.168.0.0/16) or " +
-		"(src net 127.0.0.0/8 and dst net 127.0.0.0/8) or " +
-		"(dst net 224.0.0.0/4) or " +
-		"(dst host 255.255.255.255) or " +
-		"(src net 169.254.0.0/16 or dst net 169.254.0.0/16))"
-
-	if bpfIPv4 != "" && bpfIPv6 != "" {
-		bpfFilter = fmt.Sprintf(
-			"(host %s and %s) or (host %s)",
-			bpfIPv4, internalNetFilter, bpfIPv6)
-	} else if bpfIPv4 != "" {
-		bpfFilter = fmt.Sprintf("host %s and %s", bpfIPv4, internalNetFilter)
-	} else if bpfIPv6 != "" {
-		bpfFilter = fmt.Sprintf("host %s", bpfIPv6)
-	} else {
-		bpfFilter = internalNetFilter
+	// 构建BPF过滤器（IPv4与IPv6分别排除内网/链路本地流量）
+	bpfFilter := buildPcapFilter(bpfIPv4, bpfIPv6)
+	if bpfIPv4 == "" && len(bpfIPv6) == 0 {
 		global.APP_LOG.Warn("BPF过滤器未指定监控IP，将捕获所有非内网流量",
 			zap.String("instance", instanceName))
 	}
 
+	// IPv4与IPv6经过不同接口时（如Proxmox的vmbr1/vmbr2双网卡），通过接口映射文件同时监听两个接口
+	interfaceLine := fmt.Sprintf("pcap_interface: %s", networkInterface)
+	interfacesMapFile := fmt.Sprintf("%s/interfaces.map", configDir)
+	interfacesMap := ""
+	if networkInterfaces.IPv4Interface != "" && networkInterfaces.IPv6Interface != "" &&
+		networkInterfaces.IPv4Interface != networkInterfaces.IPv6Interface {
+		interfacesMap = fmt.Sprintf("ifindex=100 ifname=%s\nifindex=200 ifname=%s\n",
+			networkInterfaces.IPv4Interface, networkInterfaces.IPv6Interface)
+		interfaceLine = fmt.Sprintf("pcap_interfaces_map: %s", interfacesMapFile)
+	}
+
 	config := fmt.Sprintf(`# pmacct configuration for instance: %s
 # Monitoring: %s
 # Bandwidth: %d Mbps
@@ -528,9 +523,9 @@ pidfile: %s/pmacctd.pid
 syslog: daemon
 
 # 监听的网络接口
-pcap_interface: %s
+%s
 
-# BPF过滤器：捕获外部流量，排除内网通信（10.x, 172.16-31.x, 192.168.x, 224.x多播, 255.255.255.255广播）
+# BPF过滤器：捕获外部流量，排除内网通信（10.x, 172.16-31.x, 192.168.x, 224.x多播, 255.255.255.255广播, IPv6链路本地/多播）
 pcap_filter: %s
 
 # 聚合方式：仅按源IP和目标IP聚合
@@ -560,7 +555,7 @@ sql_cache_entries[sqlite]: %d
 plugin_buffer_size[sqlite]: %d
 # 插件管道大小（字节）
 plugin_pipe_size[sqlite]: %d
-`, instanceName, monitorInfo, instance.Bandwidth, configDir, networkInterface,
+`, instanceName, monitorInfo, instance.Bandwidth, configDir, interfaceLine,
 		bpfFilter,
 		dataFile,
 		sqlCacheEntries, pluginBufferSize, pluginPipeSize)
@@ -597,6 +592,17 @@ WantedBy=multi-user.target
 		return fmt.Errorf("failed to upload pmacct config file: %w", err)
 	}
 
+	// 双接口监听时上传接口映射文件，单接口时删除可能残留的旧映射
+	if interfacesMap != "" {
+		if err := s.uploadFileViaSFTP(providerInstance, interfacesMap, interfacesMapFile, 0644); err != nil {
+			return fmt.Errorf("failed to upload pmacct interfaces map: %w", err)
+		}
+	} else {
+		rmCtx, rmCancel := context.WithTimeout(s.ctx, 10*time.Second)
+		defer rmCancel()
+		providerInstance.ExecuteSSHCommand(rmCtx, fmt.Sprintf("rm -f %s", interfacesMapFile))
+	}
+
 	// 步骤3: 初始化SQLite数据库表结构
 	// pmacct不会自动创建表，需要手动创建acct_v9表
 	if err := s.initializePmacctDatabase(providerInstance, dataFile); err != nil {
@@ -987,7 +993,7 @@ func (s *Service) GetPmacctSummary(instanceID uint) (*monitoringModel.PmacctSumm
 	}
 
 	var providerRecord providerModel.Provider
-	if err := global.APP_DB.Select("enable_traffic_control").First(&providerRecord, instance.ProviderID).Error; err != nil {
+	if err := global.APP_DB.Select("enable_traffic_control, timezone").First(&providerRecord, instance.ProviderID).Error; err != nil {
 		return nil, fmt.Errorf("provider not found: %w", err)
 	}
 
@@ -1015,11 +1021,12 @@ func (s *Service) GetPmacctSummary(instanceID uint) (*monitoringModel.PmacctSumm
 				TxBytes:    0,
 				TotalBytes: 0,
 			},
-			History: []*monitoringModel.PmacctTrafficRecord{},
+			Families: &monitoringModel.TrafficFamilyBreakdown{},
+			History:  []*monitoringModel.PmacctTrafficRecord{},
 		}, nil
 	}
 
-	now := time.Now()
+	now := time.Now().In(utils.LoadLocation(providerRecord.Timezone))
 	year := now.Year()
 	month := int(now.Month())
 	day := now.Day()
@@ -1043,6 +1050,7 @@ func (s *Service) GetPmacctSummary(instanceID uint) (*monitoringModel.PmacctSumm
 		Today:      today,
 		ThisMonth:  thisMonth,
 		AllTime:    allTime,
+		Families:   monitoringModel.NewTrafficFamilyBreakdown(thisMonth.RxBytes, thisMonth.TxBytes, thisMonth.RxBytesV6, thisMonth.TxBytesV6),
 		History:    history,
 	}, nil
 }
@@ -15,6 +15,7 @@ import (
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // PmacctTrafficData pmacct流量数据结构
@@ -80,6 +81,7 @@ func (s *Service) uploadFileViaSFTP(providerInstance provider.Provider, content,
 		PrivateKey:     providerRecord.SSHKey,
 		ConnectTimeout: 30 * time.Second,
 		ExecuteTimeout: 60 * time.Second,
+		Guard:          utils.ProviderCommandGuard(&providerRecord),
 	}
 
 	sshClient, err := s.sshPool.GetOrCreate(s.providerID, sshConfig)
@@ -265,10 +267,11 @@ func (s *Service) aggregateToDailyBetween(startTime, endTime time.Time) error {
 		zap.Time("startTime", startTime),
 		zap.Time("endTime", endTime))
 
-	aggregateSQL := `
+	db := global.APP_DB
+	aggregateSQL := fmt.Sprintf(`
 		INSERT INTO pmacct_traffic_records (
 			instance_id, user_id, provider_id, provider_type, mapped_ip,
-			rx_bytes, tx_bytes, total_bytes,
+			rx_bytes, tx_bytes, total_bytes, rx_bytes_v6, tx_bytes_v6,
 			timestamp, year, month, day, hour, minute,
 			record_time, created_at, updated_at
 		)
@@ -281,27 +284,25 @@ func (s *Service) aggregateToDailyBetween(startTime, endTime time.Time) error {
 			MAX(rx_bytes) as rx_bytes,
 			MAX(tx_bytes) as tx_bytes,
 			MAX(total_bytes) as total_bytes,
-			DATE_FORMAT(timestamp, '%Y-%m-%d 00:00:00') as timestamp,
+			MAX(rx_bytes_v6) as rx_bytes_v6,
+			MAX(tx_bytes_v6) as tx_bytes_v6,
+			MIN(%[1]s) as timestamp,
 			year,
 			month,
 			day,
 			0 as hour,
 			0 as minute,
 			MAX(record_time) as record_time,
-			NOW() as created_at,
-			NOW() as updated_at
+			%[3]s as created_at,
+			%[3]s as updated_at
 		FROM pmacct_traffic_records
 		WHERE record_time >= ? AND record_time < ?
 			AND (hour > 0 OR minute > 0)
 		GROUP BY instance_id, user_id, provider_id, provider_type, mapped_ip, year, month, day
-		ON DUPLICATE KEY UPDATE
-			rx_bytes = VALUES(rx_bytes),
-			tx_bytes = VALUES(tx_bytes),
-			total_bytes = VALUES(total_bytes),
-			updated_at = VALUES(updated_at)
-	`
+		%[2]s
+	`, utils.SQLTruncTime(db, "timestamp", "day"), aggregateUpsertClause(db), utils.SQLNow(db))
 
-	result := global.APP_DB.Exec(aggregateSQL, startTime, endTime)
+	result := db.Exec(aggregateSQL, startTime, endTime)
 	if result.Error != nil {
 		return fmt.Errorf("failed to aggregate to daily: %w", result.Error)
 	}
@@ -318,10 +319,11 @@ func (s *Service) aggregateToHourlyBetween(startTime, endTime time.Time) error {
 		zap.Time("startTime", startTime),
 		zap.Time("endTime", endTime))
 
-	aggregateSQL := `
+	db := global.APP_DB
+	aggregateSQL := fmt.Sprintf(`
 		INSERT INTO pmacct_traffic_records (
 			instance_id, user_id, provider_id, provider_type, mapped_ip,
-			rx_bytes, tx_bytes, total_bytes,
+			rx_bytes, tx_bytes, total_bytes, rx_bytes_v6, tx_bytes_v6,
 			timestamp, year, month, day, hour, minute,
 			record_time, created_at, updated_at
 		)
@@ -334,27 +336,25 @@ func (s *Service) aggregateToHourlyBetween(startTime, endTime time.Time) error {
 			MAX(rx_bytes) as rx_bytes,
 			MAX(tx_bytes) as tx_bytes,
 			MAX(total_bytes) as total_bytes,
-			DATE_FORMAT(timestamp, '%Y-%m-%d %H:00:00') as timestamp,
+			MAX(rx_bytes_v6) as rx_bytes_v6,
+			MAX(tx_bytes_v6) as tx_bytes_v6,
+			MIN(%[1]s) as timestamp,
 			year,
 			month,
 			day,
 			hour,
 			0 as minute,
 			MAX(record_time) as record_time,
-			NOW() as created_at,
-			NOW() as updated_at
+			%[3]s as created_at,
+			%[3]s as updated_at
 		FROM pmacct_traffic_records
 		WHERE record_time >= ? AND record_time < ?
 			AND minute > 0
 		GROUP BY instance_id, user_id, provider_id, provider_type, mapped_ip, year, month, day, hour
-		ON DUPLICATE KEY UPDATE
-			rx_bytes = VALUES(rx_bytes),
-			tx_bytes = VALUES(tx_bytes),
-			total_bytes = VALUES(total_bytes),
-			updated_at = VALUES(updated_at)
-	`
+		%[2]s
+	`, utils.SQLTruncTime(db, "timestamp", "hour"), aggregateUpsertClause(db), utils.SQLNow(db))
 
-	result := global.APP_DB.Exec(aggregateSQL, startTime, endTime)
+	result := db.Exec(aggregateSQL, startTime, endTime)
 	if result.Error != nil {
 		return fmt.Errorf("failed to aggregate to hourly: %w", result.Error)
 	}
@@ -365,6 +365,17 @@ func (s *Service) aggregateToHourlyBetween(startTime, endTime time.Time) error {
 	return nil
 }
 
+// aggregateUpsertClause 聚合记录与已有记录冲突时覆盖流量值
+func aggregateUpsertClause(db *gorm.DB) string {
+	return utils.SQLUpsert(db, []string{"instance_id", "timestamp"},
+		"rx_bytes = "+utils.SQLInserted(db, "rx_bytes"),
+		"tx_bytes = "+utils.SQLInserted(db, "tx_bytes"),
+		"total_bytes = "+utils.SQLInserted(db, "total_bytes"),
+		"rx_bytes_v6 = "+utils.SQLInserted(db, "rx_bytes_v6"),
+		"tx_bytes_v6 = "+utils.SQLInserted(db, "tx_bytes_v6"),
+		"updated_at = "+utils.SQLInserted(db, "updated_at"))
+}
+
 // aggregateTrafficRecords 聚合指定条件的流量记录
 func (s *Service) aggregateTrafficRecords(instanceID uint, year, month, day, hour int) *monitoringModel.PmacctTrafficRecord {
 	query := global.APP_DB.Model(&monitoringModel.PmacctTrafficRecord{}).
@@ -385,8 +396,10 @@ func (s *Service) aggregateTrafficRecords(instanceID uint, year, month, day, hou
 
 	// 处理pmacct重启导致的累积值重置问题
 	var result struct {
-		RxBytes int64
-		TxBytes int64
+		RxBytes   int64
+		TxBytes   int64
+		RxBytesV6 int64
+		TxBytesV6 int64
 	}
 
 	// 构建查询条件
@@ -413,17 +426,23 @@ func (s *Service) aggregateTrafficRecords(instanceID uint, year, month, day, hou
 	sql := fmt.Sprintf(`
 		SELECT 
 			COALESCE(SUM(segment_max_rx), 0) as rx_bytes,
-			COALESCE(SUM(segment_max_tx), 0) as tx_bytes
+			COALESCE(SUM(segment_max_tx), 0) as tx_bytes,
+			COALESCE(SUM(segment_max_rx_v6), 0) as rx_bytes_v6,
+			COALESCE(SUM(segment_max_tx_v6), 0) as tx_bytes_v6
 		FROM (
 			SELECT 
 				segment_id,
 				MAX(rx_bytes) as segment_max_rx,
-				MAX(tx_bytes) as segment_max_tx
+				MAX(tx_bytes) as segment_max_tx,
+				MAX(rx_bytes_v6) as segment_max_rx_v6,
+				MAX(tx_bytes_v6) as segment_max_tx_v6
 			FROM (
 				SELECT 
 					t1.timestamp,
 					t1.rx_bytes,
 					t1.tx_bytes,
+					t1.rx_bytes_v6,
+					t1.tx_bytes_v6,
 					(SELECT COUNT(*)
 					 FROM pmacct_traffic_records t2
 					 WHERE %s
@@ -454,6 +473,8 @@ func (s *Service) aggregateTrafficRecords(instanceID uint, year, month, day, hou
 		RxBytes:    result.RxBytes,
 		TxBytes:    result.TxBytes,
 		TotalBytes: result.RxBytes + result.TxBytes,
+		RxBytesV6:  result.RxBytesV6,
+		TxBytesV6:  result.TxBytesV6,
 		Year:       year,
 		Month:      month,
 		Day:        day,
@@ -479,6 +500,8 @@ func (s *Service) getAggregatedHistory(instanceID uint, days int) []*monitoringM
 			SUM(segment_max_rx) as rx_bytes,
 			SUM(segment_max_tx) as tx_bytes,
 			SUM(segment_max_total) as total_bytes,
+			SUM(segment_max_rx_v6) as rx_bytes_v6,
+			SUM(segment_max_tx_v6) as tx_bytes_v6,
 			MAX(record_time) as record_time
 		FROM (
 			SELECT 
@@ -493,6 +516,8 @@ func (s *Service) getAggregatedHistory(instanceID uint, days int) []*monitoringM
 				MAX(rx_bytes) as segment_max_rx,
 				MAX(tx_bytes) as segment_max_tx,
 				MAX(total_bytes) as segment_max_total,
+				MAX(rx_bytes_v6) as segment_max_rx_v6,
+				MAX(tx_bytes_v6) as segment_max_tx_v6,
 				MAX(record_time) as record_time
 			FROM (
 				SELECT 
@@ -507,6 +532,8 @@ func (s *Service) getAggregatedHistory(instanceID uint, days int) []*monitoringM
 					t1.rx_bytes,
 					t1.tx_bytes,
 					t1.total_bytes,
+					t1.rx_bytes_v6,
+					t1.tx_bytes_v6,
 					t1.record_time,
 					(SELECT COUNT(*)
 					 FROM pmacct_traffic_records t2
@@ -0,0 +1,230 @@
+// Package portprobe 探测端口映射上运行的服务类型
+// 依次读取服务端主动发送的欢迎信息（SSH、FTP、SMTP等）、尝试TLS握手读取证书名称、发送HTTP请求读取网页标题；
+// 只建立普通的TCP连接，不做端口扫描或认证尝试
+package portprobe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 服务类型
+const (
+	ServiceSSH         = "ssh"
+	ServiceHTTP        = "http"
+	ServiceHTTPS       = "https"
+	ServiceTLS         = "tls" // TLS握手成功但不是HTTP
+	ServiceFTP         = "ftp"
+	ServiceSMTP        = "smtp"
+	ServicePOP3        = "pop3"
+	ServiceIMAP        = "imap"
+	ServiceMySQL       = "mysql"
+	ServiceVNC         = "vnc"
+	ServiceUnknown     = "unknown"     // 端口可连接但无法识别
+	ServiceUnreachable = "unreachable" // 端口无法连接
+)
+
+const (
+	// dialTimeout 建立连接的超时时间
+	dialTimeout = 5 * time.Second
+	// bannerTimeout 等待服务端主动发送欢迎信息的时间
+	bannerTimeout = 2 * time.Second
+	// readTimeout TLS握手和读取HTTP响应的超时时间
+	readTimeout = 5 * time.Second
+	// maxResponseBytes 读取HTTP响应的最大字节数，只用于解析标题
+	maxResponseBytes = 64 * 1024
+	// maxInfoRunes 欢迎信息和标题保存的最大字符数
+	maxInfoRunes = 128
+)
+
+// Result 探测结果
+type Result struct {
+	ServiceType string `json:"serviceType"`
+	Banner      string `json:"banner,omitempty"`  // 欢迎信息或网页标题
+	TLSName     string `json:"tlsName,omitempty"` // TLS证书的通用名称
+	Error       string `json:"error,omitempty"`
+}
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Detect 探测 host:port 上运行的服务
+func Detect(ctx context.Context, host string, port int) Result {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	banner, err := readBanner(ctx, addr)
+	if err != nil {
+		return Result{ServiceType: ServiceUnreachable, Error: err.Error()}
+	}
+	if len(banner) > 0 {
+		return Result{ServiceType: classifyBanner(banner), Banner: sanitize(firstLine(banner))}
+	}
+
+	if name, title, isHTTP, err := probeTLS(ctx, addr, host); err == nil {
+		if isHTTP {
+			return Result{ServiceType: ServiceHTTPS, Banner: title, TLSName: name}
+		}
+		return Result{ServiceType: ServiceTLS, TLSName: name}
+	}
+
+	if title, ok := probeHTTP(ctx, addr, host); ok {
+		return Result{ServiceType: ServiceHTTP, Banner: title}
+	}
+	return Result{ServiceType: ServiceUnknown}
+}
+
+// dial 建立TCP连接并设置读写截止时间
+func dial(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error) {
+	d := net.Dialer{Timeout: dialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+// readBanner 连接后等待服务端主动发送的数据，超时未收到时返回空
+func readBanner(ctx context.Context, addr string) ([]byte, error) {
+	conn, err := dial(ctx, addr, bannerTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 512)
+	// 读取超时或连接被关闭说明服务端不会主动发送数据，继续按TLS和HTTP探测
+	n, _ := conn.Read(buf)
+	return buf[:n], nil
+}
+
+// probeTLS 尝试TLS握手，成功时返回证书名称，并在TLS连接上发送HTTP请求判断是否为HTTPS
+func probeTLS(ctx context.Context, addr, host string) (string, string, bool, error) {
+	conn, err := dial(ctx, addr, readTimeout)
+	if err != nil {
+		return "", "", false, err
+	}
+	defer conn.Close()
+
+	cfg := &tls.Config{InsecureSkipVerify: true} // 只读取证书信息，不校验证书
+	if net.ParseIP(host) == nil {
+		cfg.ServerName = host
+	}
+	tlsConn := tls.Client(conn, cfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return "", "", false, err
+	}
+
+	name := ""
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		name = certs[0].Subject.CommonName
+		if name == "" && len(certs[0].DNSNames) > 0 {
+			name = certs[0].DNSNames[0]
+		}
+	}
+	title, isHTTP := requestTitle(tlsConn, host)
+	return sanitize(name), title, isHTTP, nil
+}
+
+// probeHTTP 发送HTTP请求，返回网页标题和是否为HTTP服务
+func probeHTTP(ctx context.Context, addr, host string) (string, bool) {
+	conn, err := dial(ctx, addr, readTimeout)
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+	return requestTitle(conn, host)
+}
+
+// requestTitle 在连接上发送 GET / 请求并解析响应中的网页标题，没有标题时返回Server响应头
+func requestTitle(conn net.Conn, host string) (string, bool) {
+	req := fmt.Sprintf("GET / HTTP/1.0\r\nHost: %s\r\nUser-Agent: oneclickvirt-portprobe\r\nAccept: text/html\r\nConnection: close\r\n\r\n", host)
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return "", false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(io.LimitReader(conn, maxResponseBytes)), nil)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if title := parseTitle(body); title != "" {
+		return title, true
+	}
+	return sanitize(resp.Header.Get("Server")), true
+}
+
+// parseTitle 从HTML中提取标题
+func parseTitle(body []byte) string {
+	m := titlePattern.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return sanitize(strings.Join(strings.Fields(string(m[1])), " "))
+}
+
+// classifyBanner 根据服务端主动发送的欢迎信息识别服务
+func classifyBanner(banner []byte) string {
+	line := strings.ToLower(firstLine(banner))
+	switch {
+	case bytes.HasPrefix(banner, []byte("SSH-")):
+		return ServiceSSH
+	case bytes.HasPrefix(banner, []byte("RFB ")):
+		return ServiceVNC
+	case bytes.HasPrefix(banner, []byte("+OK")):
+		return ServicePOP3
+	case bytes.HasPrefix(banner, []byte("* OK")):
+		return ServiceIMAP
+	case strings.HasPrefix(line, "220"):
+		if strings.Contains(line, "ftp") {
+			return ServiceFTP
+		}
+		if strings.Contains(line, "smtp") || strings.Contains(line, "mail") {
+			return ServiceSMTP
+		}
+	case bytes.Contains(banner, []byte("mysql_native_password")) || bytes.Contains(banner, []byte("caching_sha2_password")):
+		return ServiceMySQL
+	}
+	return ServiceUnknown
+}
+
+// firstLine 返回欢迎信息的第一行
+func firstLine(banner []byte) string {
+	if i := bytes.IndexAny(banner, "\r\n"); i >= 0 {
+		banner = banner[:i]
+	}
+	return string(banner)
+}
+
+// sanitize 去掉不可打印字符并限制长度
+func sanitize(s string) string {
+	var b strings.Builder
+	count := 0
+	for _, r := range s {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			continue
+		}
+		if count >= maxInfoRunes {
+			break
+		}
+		b.WriteRune(r)
+		count++
+	}
+	return strings.TrimSpace(b.String())
+}
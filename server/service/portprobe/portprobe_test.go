@@ -0,0 +1,90 @@
+package portprobe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func hostPort(t *testing.T, addr string) (string, int) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func TestDetect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("SSH-2.0-OpenSSH_9.2p1 Debian-2\r\n"))
+			conn.Close()
+		}
+	}()
+
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>\n  My   Blog </title></head></html>")
+	})
+	httpSrv := httptest.NewServer(page)
+	defer httpSrv.Close()
+	tlsSrv := httptest.NewTLSServer(page)
+	defer tlsSrv.Close()
+
+	cases := []struct {
+		name    string
+		addr    string
+		want    string
+		banner  string
+		tlsName bool
+	}{
+		{"ssh", ln.Addr().String(), ServiceSSH, "SSH-2.0-OpenSSH_9.2p1 Debian-2", false},
+		{"http", httpSrv.Listener.Addr().String(), ServiceHTTP, "My Blog", false},
+		{"https", tlsSrv.Listener.Addr().String(), ServiceHTTPS, "My Blog", true},
+	}
+	for _, tc := range cases {
+		host, port := hostPort(t, tc.addr)
+		res := Detect(context.Background(), host, port)
+		if res.ServiceType != tc.want || res.Banner != tc.banner || (res.TLSName != "") != tc.tlsName {
+			t.Errorf("%s: got %+v", tc.name, res)
+		}
+	}
+
+	// 关闭的端口
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, port := hostPort(t, closed.Addr().String())
+	closed.Close()
+	if res := Detect(context.Background(), host, port); res.ServiceType != ServiceUnreachable || res.Error == "" {
+		t.Errorf("closed: got %+v", res)
+	}
+}
+
+func TestClassifyBanner(t *testing.T) {
+	cases := map[string]string{
+		"220 (vsFTPd 3.0.3)\r\n":                         ServiceFTP,
+		"220 mail.example.com ESMTP Postfix\r\n":         ServiceSMTP,
+		"+OK Dovecot ready.\r\n":                         ServicePOP3,
+		"* OK [CAPABILITY IMAP4rev1] ready\r\n":          ServiceIMAP,
+		"RFB 003.008\n":                                  ServiceVNC,
+		"J\x00\x00\x00\n8.0.36\x00mysql_native_password": ServiceMySQL,
+		"hello\n": ServiceUnknown,
+	}
+	for banner, want := range cases {
+		if got := classifyBanner([]byte(banner)); got != want {
+			t.Errorf("classifyBanner(%q) = %s, want %s", banner, got, want)
+		}
+	}
+}
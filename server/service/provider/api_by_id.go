@@ -92,12 +92,15 @@ func (s *ProviderApiService) GetProviderStatusByID(providerIDStr string) (map[st
 		return nil, fmt.Errorf("Provider不存在")
 	}
 
-	// Docker 类型固定使用 native 端口映射方式
+	// Docker 类型固定使用 native 端口映射方式，LXC 类型固定使用 iptables
 	ipv4Method := dbProvider.IPv4PortMappingMethod
 	ipv6Method := dbProvider.IPv6PortMappingMethod
-	if dbProvider.Type == "docker" {
+	if dbProvider.Type == "docker" || dbProvider.Type == "kubernetes" {
 		ipv4Method = "native"
 		ipv6Method = "native"
+	} else if dbProvider.Type == "lxc" {
+		ipv4Method = "iptables"
+		ipv6Method = "iptables"
 	}
 
 	// 检查Provider是否已连接（不尝试新连接）
@@ -142,6 +145,8 @@ func (s *ProviderApiService) GetProviderStatusByID(providerIDStr string) (map[st
 		"portRangeStart":        dbProvider.PortRangeStart,
 		"portRangeEnd":          dbProvider.PortRangeEnd,
 		"defaultPortCount":      dbProvider.DefaultPortCount,
+		"guestSshPort":          dbProvider.GuestSSHPort,
+		"randomGuestSshPort":    dbProvider.RandomGuestSSHPort,
 		"ipv4PortMappingMethod": ipv4Method,
 		"ipv6PortMappingMethod": ipv6Method,
 		"maxTraffic":            dbProvider.MaxTraffic,
@@ -169,12 +174,15 @@ func (s *ProviderApiService) GetProviderCapabilitiesByID(providerIDStr string) (
 		return nil, fmt.Errorf("Provider不存在")
 	}
 
-	// Docker 类型固定使用 native 端口映射方式
+	// Docker 类型固定使用 native 端口映射方式，LXC 类型固定使用 iptables
 	ipv4Method := dbProvider.IPv4PortMappingMethod
 	ipv6Method := dbProvider.IPv6PortMappingMethod
-	if dbProvider.Type == "docker" {
+	if dbProvider.Type == "docker" || dbProvider.Type == "kubernetes" {
 		ipv4Method = "native"
 		ipv6Method = "native"
+	} else if dbProvider.Type == "lxc" {
+		ipv4Method = "iptables"
+		ipv6Method = "iptables"
 	}
 
 	// 检查Provider是否已连接（不尝试新连接）
@@ -202,9 +210,9 @@ func (s *ProviderApiService) GetProviderCapabilitiesByID(providerIDStr string) (
 		"containerEnabled":      dbProvider.ContainerEnabled,
 		"vmEnabled":             dbProvider.VirtualMachineEnabled,
 		"architecture":          dbProvider.Architecture,
-		"maxCpu":                dbProvider.NodeCPUCores,
-		"maxMemory":             dbProvider.NodeMemoryTotal,
-		"maxDisk":               dbProvider.NodeDiskTotal,
+		"maxCpu":                dbProvider.AllocatableCPUCores(),
+		"maxMemory":             dbProvider.AllocatableMemory(),
+		"maxDisk":               dbProvider.AllocatableDisk(),
 		"region":                dbProvider.Region,
 		"country":               dbProvider.Country,
 		"status":                dbProvider.Status,
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// consoleLogProvider 支持串口控制台日志的Provider（目前仅Proxmox虚拟机）
+type consoleLogProvider interface {
+	CaptureConsoleLog(ctx context.Context, instanceName string, duration time.Duration) error
+	GetConsoleLog(ctx context.Context, instanceName string, maxBytes int) (string, error)
+}
+
+// getConsoleLogProvider 获取实例所在Provider并校验是否支持控制台日志
+func (s *ProviderApiService) getConsoleLogProvider(instance *providerModel.Instance) (consoleLogProvider, error) {
+	if instance.InstanceType != "vm" {
+		return nil, fmt.Errorf("仅虚拟机支持控制台日志")
+	}
+
+	prov, _, err := s.GetProviderByID(instance.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckProviderConnection(prov); err != nil {
+		return nil, err
+	}
+
+	consoleProv, ok := prov.(consoleLogProvider)
+	if !ok {
+		return nil, fmt.Errorf("当前Provider类型不支持控制台日志")
+	}
+	return consoleProv, nil
+}
+
+// GetInstanceConsoleLog 读取虚拟机控制台日志的最后 maxBytes 字节
+func (s *ProviderApiService) GetInstanceConsoleLog(ctx context.Context, instance *providerModel.Instance, maxBytes int) (string, error) {
+	consoleProv, err := s.getConsoleLogProvider(instance)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := consoleProv.GetConsoleLog(ctx, instance.Name, maxBytes)
+	if err != nil {
+		global.APP_LOG.Warn("读取实例控制台日志失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("instanceName", instance.Name),
+			zap.Error(err))
+		return "", err
+	}
+	return content, nil
+}
+
+// CaptureInstanceConsoleLog 重新开始抓取虚拟机控制台输出，用于排查启动后网络不可用等问题
+func (s *ProviderApiService) CaptureInstanceConsoleLog(ctx context.Context, instance *providerModel.Instance, duration time.Duration) error {
+	consoleProv, err := s.getConsoleLogProvider(instance)
+	if err != nil {
+		return err
+	}
+
+	if err := consoleProv.CaptureConsoleLog(ctx, instance.Name, duration); err != nil {
+		global.APP_LOG.Warn("抓取实例控制台日志失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("instanceName", instance.Name),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
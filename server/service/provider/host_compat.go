@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/hostcompat"
+
+	"go.uber.org/zap"
+)
+
+// checkHostCompatibility 探测宿主机内核与cgroup兼容性并保存报告，应在goroutine中调用
+func checkHostCompatibility(dbProvider providerModel.Provider, prov provider.Provider) {
+	if global.APP_DB == nil || dbProvider.Type == "fake" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	output, err := prov.ExecuteSSHCommand(ctx, hostcompat.Script)
+	if err != nil {
+		global.APP_LOG.Warn("检测宿主机兼容性失败",
+			zap.Uint("providerId", dbProvider.ID),
+			zap.Error(err))
+		return
+	}
+	probe, err := hostcompat.ParseProbe(output)
+	if err != nil {
+		global.APP_LOG.Warn("检测宿主机兼容性失败",
+			zap.Uint("providerId", dbProvider.ID),
+			zap.Error(err))
+		return
+	}
+	issues, err := hostcompat.Save(&dbProvider, probe)
+	if err != nil {
+		global.APP_LOG.Warn("保存宿主机兼容性报告失败",
+			zap.Uint("providerId", dbProvider.ID),
+			zap.Error(err))
+		return
+	}
+	if len(issues) > 0 {
+		global.APP_LOG.Warn("宿主机不支持Provider已启用的部分功能",
+			zap.Uint("providerId", dbProvider.ID),
+			zap.String("provider", dbProvider.Name),
+			zap.String("kernel", probe.KernelVersion),
+			zap.String("cgroup", probe.CgroupVersion),
+			zap.String("issues", hostcompat.Summary(issues)))
+	}
+}
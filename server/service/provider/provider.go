@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/provider"
 	"oneclickvirt/utils"
@@ -110,13 +111,36 @@ func (ps *ProviderService) LoadProviderWithOptions(dbProvider providerModel.Prov
 		return nil
 	}
 
+	prov, err := newConnectedProvider(dbProvider)
+	if err != nil {
+		return err
+	}
+
+	// 存储Provider实例（使用ID作为key）
+	// 此时已经持有ps.mutex.Lock()，不需要再次加锁
+	ps.providers[dbProvider.ID] = prov
+
+	global.APP_LOG.Info("Provider加载成功",
+		zap.String("name", dbProvider.Name),
+		zap.Uint("id", dbProvider.ID),
+		zap.String("type", dbProvider.Type),
+		zap.Bool("autoConfigured", dbProvider.AutoConfigured))
+
+	// 连接成功后检测宿主机内核与cgroup兼容性
+	go checkHostCompatibility(dbProvider, prov)
+
+	return nil
+}
+
+// newConnectedProvider 根据数据库记录创建Provider实例并建立连接
+func newConnectedProvider(dbProvider providerModel.Provider) (provider.Provider, error) {
 	global.APP_LOG.Debug("开始连接Provider", zap.String("name", dbProvider.Name), zap.String("type", dbProvider.Type), zap.String("host", utils.ExtractHost(dbProvider.Endpoint)), zap.Int("port", dbProvider.SSHPort))
 
-	// 创建Provider实例（仅在未加载时创建）
+	// 创建Provider实例
 	prov, err := provider.GetProvider(dbProvider.Type)
 	if err != nil {
 		global.APP_LOG.Error("获取Provider实例失败", zap.String("name", dbProvider.Name), zap.String("type", dbProvider.Type), zap.String("error", utils.FormatError(err)))
-		return err
+		return nil, err
 	}
 
 	// 构建NodeConfig
@@ -143,6 +167,7 @@ func (ps *ProviderService) LoadProviderWithOptions(dbProvider providerModel.Prov
 		ContainerEnabled:      dbProvider.ContainerEnabled,
 		VirtualMachineEnabled: dbProvider.VirtualMachineEnabled,
 		NetworkType:           dbProvider.NetworkType,
+		PublicInterface:       dbProvider.PublicInterface, // 端口映射DNAT规则的入口网卡
 		ExecutionRule:         dbProvider.ExecutionRule,
 		SSHConnectTimeout:     dbProvider.SSHConnectTimeout,
 		SSHExecuteTimeout:     dbProvider.SSHExecuteTimeout,
@@ -162,6 +187,21 @@ func (ps *ProviderService) LoadProviderWithOptions(dbProvider providerModel.Prov
 		ContainerMemorySwap:   dbProvider.ContainerMemorySwap,
 		ContainerMaxProcesses: dbProvider.ContainerMaxProcesses,
 		ContainerDiskIOLimit:  dbProvider.ContainerDiskIOLimit,
+		// 项目与配置文件（仅 LXD/Incus）
+		Project:  dbProvider.Project,
+		Profiles: dbProvider.GetProfiles(),
+		// SSH命令白名单
+		CommandGuardMode: dbProvider.CommandGuardMode,
+		CommandAllowlist: dbProvider.GetCommandAllowlist(),
+		CommandDenylist:  dbProvider.GetCommandDenylist(),
+	}
+
+	// 镜像源规则解析失败不影响连接，下载镜像时回退到CDN或原始地址
+	if mirrors, err := dbProvider.GetImageMirrors(); err != nil {
+		global.APP_LOG.Warn("解析Provider镜像源规则失败，已忽略",
+			zap.Uint("providerId", dbProvider.ID), zap.Error(err))
+	} else {
+		config.ImageMirrors = mirrors
 	}
 
 	// 如果Provider已自动配置，尝试加载完整配置
@@ -217,20 +257,10 @@ func (ps *ProviderService) LoadProviderWithOptions(dbProvider providerModel.Prov
 			zap.Uint("id", dbProvider.ID),
 			zap.String("type", dbProvider.Type),
 			zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	// 存储Provider实例（使用ID作为key）
-	// 此时已经持有ps.mutex.Lock()，不需要再次加锁
-	ps.providers[dbProvider.ID] = prov
-
-	global.APP_LOG.Info("Provider加载成功",
-		zap.String("name", dbProvider.Name),
-		zap.Uint("id", dbProvider.ID),
-		zap.String("type", dbProvider.Type),
-		zap.Bool("autoConfigured", dbProvider.AutoConfigured))
-
-	return nil
+	return prov, nil
 }
 
 // GetProviderByID 根据ID获取已加载的Provider（推荐使用）
@@ -270,6 +300,85 @@ func (ps *ProviderService) ReloadProvider(providerID uint) error {
 	return ps.LoadProvider(dbProvider)
 }
 
+// SwapProvider 使用数据库中的最新配置建立新连接并替换已加载的实例（用于凭据轮换、镜像源及项目配置变更）
+// 新连接建立失败时保留旧实例；替换后新任务使用新实例，旧实例待进行中的任务结束后再断开
+func (ps *ProviderService) SwapProvider(providerID uint) error {
+	var dbProvider providerModel.Provider
+	if err := global.APP_DB.First(&dbProvider, providerID).Error; err != nil {
+		return err
+	}
+
+	ps.mutex.RLock()
+	_, loaded := ps.providers[providerID]
+	ps.mutex.RUnlock()
+	if !loaded {
+		return ps.LoadProvider(dbProvider)
+	}
+
+	prov, err := newConnectedProvider(dbProvider)
+	if err != nil {
+		return err
+	}
+
+	ps.mutex.Lock()
+	oldProv := ps.providers[providerID]
+	ps.providers[providerID] = prov
+	ps.mutex.Unlock()
+
+	global.APP_LOG.Info("Provider实例已替换为新连接",
+		zap.Uint("id", providerID),
+		zap.String("name", dbProvider.Name))
+
+	if oldProv != nil {
+		go drainProvider(providerID, oldProv)
+	}
+	return nil
+}
+
+// providerDrainTimeout 被替换的Provider实例最长保留时间
+const providerDrainTimeout = 30 * time.Minute
+
+// drainProvider 等待该Provider没有进行中的任务后断开旧实例，最长等待providerDrainTimeout
+func drainProvider(providerID uint, oldProv provider.Provider) {
+	defer func() {
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("断开旧Provider实例时发生panic",
+				zap.Uint("id", providerID),
+				zap.Any("panic", r))
+		}
+	}()
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	deadline := time.Now().Add(providerDrainTimeout)
+
+wait:
+	for time.Now().Before(deadline) {
+		var activeTasks int64
+		err := global.APP_DB.Model(&adminModel.Task{}).
+			Where("provider_id = ? AND status IN (?)", providerID, []string{"processing", "running", "cancelling"}).
+			Count(&activeTasks).Error
+		if err == nil && activeTasks == 0 {
+			break
+		}
+		select {
+		case <-global.APP_SHUTDOWN_CONTEXT.Done():
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := oldProv.Disconnect(ctx); err != nil {
+		global.APP_LOG.Warn("断开旧Provider实例失败",
+			zap.Uint("id", providerID),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("旧Provider实例已断开", zap.Uint("id", providerID))
+}
+
 // RemoveProvider 移除Provider并清理资源
 func (ps *ProviderService) RemoveProvider(providerID uint) {
 	ps.mutex.Lock()
@@ -27,13 +27,8 @@ func (s *RequestProcessService) ProcessUserListRequest(c *gin.Context, req *admi
 		}
 	}
 
-	// 设置默认值
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 {
-		req.PageSize = 10
-	}
+	// 解析游标并设置默认值
+	req.Normalize()
 
 	return nil
 }
@@ -49,13 +44,8 @@ func (s *RequestProcessService) ProcessInstanceListRequest(c *gin.Context, req *
 		global.APP_LOG.Warn("实例列表查询参数绑定失败，使用默认值", zap.Error(err))
 	}
 
-	// 确保页码和页大小的合理性
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.PageSize <= 0 || req.PageSize > 100 {
-		req.PageSize = 10
-	}
+	// 解析游标并确保页码和页大小的合理性
+	req.Normalize()
 
 	return nil
 }
@@ -0,0 +1,170 @@
+package rdns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+)
+
+// 内置的维护方式
+const (
+	BackendHost    = "host"
+	BackendWebhook = "webhook"
+)
+
+// Record 一条待写入或删除的PTR记录
+type Record struct {
+	Instance *providerModel.Instance
+	IP       string
+	PTRName  string // 反向解析域名，如 4.3.2.1.in-addr.arpa
+	Hostname string // 删除时为空
+}
+
+// Backend PTR记录的维护方式
+type Backend interface {
+	SetPTR(ctx context.Context, record Record) error
+	DeletePTR(ctx context.Context, record Record) error
+}
+
+var (
+	backends   = make(map[string]func() Backend)
+	backendsMu sync.RWMutex
+)
+
+// RegisterBackend 注册PTR维护方式，扩展包可以注册对接其他上游服务商的实现
+func RegisterBackend(name string, factory func() Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = factory
+}
+
+// GetBackend 获取指定名称的维护方式
+func GetBackend(name string) (Backend, error) {
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的反向解析维护方式: %s", name)
+	}
+	return factory(), nil
+}
+
+// Backends 返回所有已注册的维护方式
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterBackend(BackendHost, func() Backend { return hostBackend{} })
+	RegisterBackend(BackendWebhook, func() Backend { return webhookBackend{} })
+}
+
+// renderCommand 替换命令中的占位符，替换值已做shell转义
+func renderCommand(command string, record Record) string {
+	return strings.NewReplacer(
+		"{ip}", utils.ShellQuote(record.IP),
+		"{ptr}", utils.ShellQuote(record.PTRName),
+		"{hostname}", utils.ShellQuote(record.Hostname),
+	).Replace(command)
+}
+
+// hostBackend 在实例所在节点上执行管理员配置的命令维护PTR区域，如 nsupdate、pdnsutil
+type hostBackend struct{}
+
+func (hostBackend) SetPTR(ctx context.Context, record Record) error {
+	return runHostCommand(ctx, global.APP_CONFIG.RDNS.SetCommand, record)
+}
+
+func (hostBackend) DeletePTR(ctx context.Context, record Record) error {
+	return runHostCommand(ctx, global.APP_CONFIG.RDNS.DeleteCommand, record)
+}
+
+func runHostCommand(ctx context.Context, command string, record Record) error {
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("未配置反向解析命令")
+	}
+	apiService := &providerService.ProviderApiService{}
+	prov, _, err := apiService.GetProviderByIDForOperation(record.Instance.ProviderID, "delete")
+	if err != nil {
+		return err
+	}
+	if output, err := prov.ExecuteSSHCommand(ctx, renderCommand(command, record)); err != nil {
+		return fmt.Errorf("执行反向解析命令失败: %v %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// webhookBackend 把PTR变更以JSON POST到上游服务商API或对接脚本，2xx视为成功
+type webhookBackend struct{}
+
+// webhookPayload webhook请求体
+type webhookPayload struct {
+	Action     string `json:"action"` // set, delete
+	IP         string `json:"ip"`
+	PTR        string `json:"ptr"`
+	Hostname   string `json:"hostname,omitempty"`
+	InstanceID uint   `json:"instanceId"`
+	ProviderID uint   `json:"providerId"`
+}
+
+func (webhookBackend) SetPTR(ctx context.Context, record Record) error {
+	return postWebhook(ctx, "set", record)
+}
+
+func (webhookBackend) DeletePTR(ctx context.Context, record Record) error {
+	return postWebhook(ctx, "delete", record)
+}
+
+func postWebhook(ctx context.Context, action string, record Record) error {
+	cfg := global.APP_CONFIG.RDNS
+	if cfg.WebhookURL == "" {
+		return fmt.Errorf("未配置反向解析webhook地址")
+	}
+	body, err := json.Marshal(webhookPayload{
+		Action:     action,
+		IP:         record.IP,
+		PTR:        record.PTRName,
+		Hostname:   record.Hostname,
+		InstanceID: record.Instance.ID,
+		ProviderID: record.Instance.ProviderID,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.WebhookToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.WebhookToken)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用反向解析接口失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("反向解析接口返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Package rdns 实例反向解析（PTR）管理
+// 独立公网IP实例的所有者可以为IP设置PTR记录，写入前校验主机名的正向解析指向该IP，
+// PTR记录通过可插拔的维护方式写入：节点上的PTR区域或上游服务商API
+package rdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// ErrNotAllowed 用户等级不足
+var ErrNotAllowed = errors.New("当前用户等级不支持设置反向解析")
+
+// hostnameLabel 主机名中单个标签的格式
+var hostnameLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// lookupIP 正向解析主机名，测试时替换
+var lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+// IPEntry 实例可设置反向解析的IP及当前记录
+type IPEntry struct {
+	IP        string     `json:"ip"`
+	Version   int        `json:"version"`             // 4 或 6
+	Hostname  string     `json:"hostname"`            // 当前PTR主机名，未设置时为空
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"` // 最近一次设置时间
+}
+
+// CheckUserAllowed 检查功能是否启用以及用户等级是否满足要求
+func CheckUserAllowed(userID uint) error {
+	cfg := global.APP_CONFIG.RDNS
+	if !cfg.Enabled {
+		return errors.New("反向解析功能未启用")
+	}
+	if cfg.MinLevel <= 0 {
+		return nil
+	}
+	var user userModel.User
+	if err := global.APP_DB.Select("level").First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Level < cfg.MinLevel {
+		return fmt.Errorf("%w，需要等级达到 %d", ErrNotAllowed, cfg.MinLevel)
+	}
+	return nil
+}
+
+// DedicatedIPs 返回实例独占的公网IP：独立IPv4网络的公网IPv4，以及启用IPv6网络的公网IPv6
+// NAT网络下的IPv4由多个实例共享，不能设置反向解析
+func DedicatedIPs(instance *providerModel.Instance, networkType string) []string {
+	var ips []string
+	if strings.HasPrefix(networkType, "dedicated_ipv4") && net.ParseIP(instance.PublicIP).To4() != nil {
+		ips = append(ips, instance.PublicIP)
+	}
+	if strings.Contains(networkType, "ipv6") {
+		if ip := net.ParseIP(instance.PublicIPv6); ip != nil && ip.To4() == nil {
+			ips = append(ips, instance.PublicIPv6)
+		}
+	}
+	return ips
+}
+
+// NormalizeHostname 校验并规范化主机名：转为小写、去掉末尾的点，至少包含两个标签
+func NormalizeHostname(hostname string) (string, error) {
+	h := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+	if h == "" || len(h) > 253 {
+		return "", fmt.Errorf("主机名长度需在 1-253 之间")
+	}
+	labels := strings.Split(h, ".")
+	if len(labels) < 2 {
+		return "", fmt.Errorf("主机名需要是完整域名，如 mail.example.com")
+	}
+	for _, label := range labels {
+		if !hostnameLabel.MatchString(label) {
+			return "", fmt.Errorf("主机名格式无效: %s", hostname)
+		}
+	}
+	return h, nil
+}
+
+// PTRName 返回IP对应的反向解析域名
+func PTRName(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("无效的IP地址: %s", ip)
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	const hexDigits = "0123456789abcdef"
+	nibbles := make([]string, 0, 32)
+	for i := len(parsed) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hexDigits[parsed[i]&0x0f]), string(hexDigits[parsed[i]>>4]))
+	}
+	return strings.Join(nibbles, ".") + ".ip6.arpa", nil
+}
+
+// VerifyForward 校验主机名的正向解析（A/AAAA）包含该IP，避免为他人的域名设置反向解析
+func VerifyForward(ctx context.Context, hostname, ip string) error {
+	target := net.ParseIP(ip)
+	ips, err := lookupIP(ctx, hostname)
+	if err != nil {
+		return fmt.Errorf("无法解析主机名 %s，请先添加指向 %s 的A/AAAA记录", hostname, ip)
+	}
+	for _, resolved := range ips {
+		if resolved.Equal(target) {
+			return nil
+		}
+	}
+	return fmt.Errorf("主机名 %s 的正向解析不包含 %s，请先添加对应的A/AAAA记录", hostname, ip)
+}
+
+func timeout() time.Duration {
+	if t := global.APP_CONFIG.RDNS.Timeout; t > 0 {
+		return time.Duration(t) * time.Second
+	}
+	return defaultTimeout
+}
+
+func networkTypeOf(instance *providerModel.Instance) (string, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.Select("id, network_type").First(&provider, instance.ProviderID).Error; err != nil {
+		return "", fmt.Errorf("节点不存在")
+	}
+	return provider.NetworkType, nil
+}
+
+// List 返回实例可设置反向解析的IP及当前记录
+func List(instance *providerModel.Instance) ([]IPEntry, error) {
+	networkType, err := networkTypeOf(instance)
+	if err != nil {
+		return nil, err
+	}
+	var records []providerModel.InstanceRDNS
+	if err := global.APP_DB.Where("instance_id = ?", instance.ID).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	byIP := make(map[string]providerModel.InstanceRDNS, len(records))
+	for _, r := range records {
+		byIP[r.IP] = r
+	}
+
+	entries := make([]IPEntry, 0, 2)
+	for _, ip := range DedicatedIPs(instance, networkType) {
+		entry := IPEntry{IP: ip, Version: 6}
+		if net.ParseIP(ip).To4() != nil {
+			entry.Version = 4
+		}
+		if r, ok := byIP[net.ParseIP(ip).String()]; ok {
+			updatedAt := r.UpdatedAt
+			entry.Hostname, entry.UpdatedAt = r.Hostname, &updatedAt
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// dedicatedIP 校验IP属于实例独占的公网IP
+func dedicatedIP(instance *providerModel.Instance, ip string) error {
+	networkType, err := networkTypeOf(instance)
+	if err != nil {
+		return err
+	}
+	parsed := net.ParseIP(ip)
+	for _, candidate := range DedicatedIPs(instance, networkType) {
+		if parsed != nil && parsed.Equal(net.ParseIP(candidate)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s 不是该实例的独立公网IP", ip)
+}
+
+// Set 为实例的独立公网IP设置反向解析，正向解析校验通过后写入PTR记录
+func Set(ctx context.Context, instance *providerModel.Instance, ip, hostname string) (*providerModel.InstanceRDNS, error) {
+	if err := dedicatedIP(instance, ip); err != nil {
+		return nil, err
+	}
+	ip = net.ParseIP(ip).String()
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return nil, err
+	}
+	ptrName, err := PTRName(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout())
+	defer cancel()
+	if err := VerifyForward(ctx, hostname, ip); err != nil {
+		return nil, err
+	}
+
+	backendName := global.APP_CONFIG.RDNS.Backend
+	backend, err := GetBackend(backendName)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.SetPTR(ctx, Record{Instance: instance, IP: ip, PTRName: ptrName, Hostname: hostname}); err != nil {
+		return nil, err
+	}
+
+	var record providerModel.InstanceRDNS
+	global.APP_DB.Where("instance_id = ? AND ip = ?", instance.ID, ip).First(&record)
+	record.InstanceID = instance.ID
+	record.UserID = instance.UserID
+	record.IP = ip
+	record.Hostname = hostname
+	record.Backend = backendName
+	if err := global.APP_DB.Save(&record).Error; err != nil {
+		return nil, fmt.Errorf("保存反向解析记录失败: %v", err)
+	}
+
+	global.APP_LOG.Info("已设置实例反向解析",
+		zap.Uint("instanceId", instance.ID),
+		zap.String("ip", ip),
+		zap.String("hostname", hostname),
+		zap.String("backend", backendName))
+	return &record, nil
+}
+
+// Delete 删除实例IP的反向解析记录，使用写入时的维护方式
+func Delete(ctx context.Context, instance *providerModel.Instance, ip string) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return fmt.Errorf("无效的IP地址: %s", ip)
+	}
+	var record providerModel.InstanceRDNS
+	if err := global.APP_DB.Where("instance_id = ? AND ip = ?", instance.ID, parsed.String()).First(&record).Error; err != nil {
+		return fmt.Errorf("该IP未设置反向解析")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout())
+	defer cancel()
+	return deleteRecord(ctx, instance, &record)
+}
+
+func deleteRecord(ctx context.Context, instance *providerModel.Instance, record *providerModel.InstanceRDNS) error {
+	ptrName, err := PTRName(record.IP)
+	if err != nil {
+		return err
+	}
+	backend, err := GetBackend(record.Backend)
+	if err != nil {
+		return err
+	}
+	if err := backend.DeletePTR(ctx, Record{Instance: instance, IP: record.IP, PTRName: ptrName}); err != nil {
+		return err
+	}
+	if err := global.APP_DB.Delete(record).Error; err != nil {
+		return fmt.Errorf("删除反向解析记录失败: %v", err)
+	}
+	global.APP_LOG.Info("已删除实例反向解析",
+		zap.Uint("instanceId", instance.ID),
+		zap.String("ip", record.IP))
+	return nil
+}
+
+// CleanupInstance 删除实例时清理其所有反向解析记录，避免IP分配给其他实例后仍指向旧主机名
+// 单条记录清理失败只记录日志，数据库记录同样删除
+func CleanupInstance(ctx context.Context, instance *providerModel.Instance) {
+	var records []providerModel.InstanceRDNS
+	if err := global.APP_DB.Where("instance_id = ?", instance.ID).Find(&records).Error; err != nil || len(records) == 0 {
+		return
+	}
+	for i := range records {
+		if err := deleteRecord(ctx, instance, &records[i]); err != nil {
+			global.APP_LOG.Warn("清理实例反向解析失败，请手动删除PTR记录",
+				zap.Uint("instanceId", instance.ID),
+				zap.String("ip", records[i].IP),
+				zap.String("hostname", records[i].Hostname),
+				zap.Error(err))
+			global.APP_DB.Delete(&records[i])
+		}
+	}
+}
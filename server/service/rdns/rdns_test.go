@@ -0,0 +1,74 @@
+package rdns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestPTRName(t *testing.T) {
+	cases := map[string]string{
+		"192.0.2.10":  "10.2.0.192.in-addr.arpa",
+		"2001:db8::1": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+	}
+	for ip, want := range cases {
+		if got, err := PTRName(ip); err != nil || got != want {
+			t.Errorf("PTRName(%s) = %q, %v, want %q", ip, got, err, want)
+		}
+	}
+	if _, err := PTRName("not-an-ip"); err == nil {
+		t.Error("expected error for invalid IP")
+	}
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	if got, err := NormalizeHostname(" Mail.Example.COM. "); err != nil || got != "mail.example.com" {
+		t.Errorf("NormalizeHostname = %q, %v", got, err)
+	}
+	for _, bad := range []string{"", "localhost", "-bad.example.com", "a..example.com", "foo_bar.example.com", "x.example.com;reboot"} {
+		if _, err := NormalizeHostname(bad); err == nil {
+			t.Errorf("NormalizeHostname(%q) should fail", bad)
+		}
+	}
+}
+
+func TestDedicatedIPs(t *testing.T) {
+	inst := &providerModel.Instance{PublicIP: "192.0.2.10", PublicIPv6: "2001:db8::1"}
+	cases := map[string][]string{
+		"nat_ipv4":            nil,
+		"nat_ipv4_ipv6":       {"2001:db8::1"},
+		"dedicated_ipv4":      {"192.0.2.10"},
+		"dedicated_ipv4_ipv6": {"192.0.2.10", "2001:db8::1"},
+		"ipv6_only":           {"2001:db8::1"},
+	}
+	for networkType, want := range cases {
+		if got := DedicatedIPs(inst, networkType); !reflect.DeepEqual(got, want) {
+			t.Errorf("DedicatedIPs(%s) = %v, want %v", networkType, got, want)
+		}
+	}
+}
+
+func TestVerifyForward(t *testing.T) {
+	orig := lookupIP
+	defer func() { lookupIP = orig }()
+	lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("192.0.2.10"), net.ParseIP("2001:db8::1")}, nil
+	}
+
+	if err := VerifyForward(context.Background(), "mail.example.com", "2001:0db8::0001"); err != nil {
+		t.Errorf("matching AAAA record rejected: %v", err)
+	}
+	if err := VerifyForward(context.Background(), "mail.example.com", "192.0.2.11"); err == nil {
+		t.Error("non-matching forward record accepted")
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	got := renderCommand("ptr-set {ptr} {hostname}", Record{IP: "192.0.2.10", PTRName: "10.2.0.192.in-addr.arpa", Hostname: "mail.example.com"})
+	if want := "ptr-set '10.2.0.192.in-addr.arpa' 'mail.example.com'"; got != want {
+		t.Errorf("renderCommand = %q, want %q", got, want)
+	}
+}
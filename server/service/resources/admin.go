@@ -1,11 +1,15 @@
 package resources
 
 import (
+	"time"
+
 	"oneclickvirt/global"
 	"oneclickvirt/model/admin"
+	dashboardModel "oneclickvirt/model/dashboard"
+	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
-	userModel "oneclickvirt/model/user"
 	"oneclickvirt/service/auth"
+	"oneclickvirt/service/counters"
 )
 
 // AdminDashboardService 管理员仪表板服务
@@ -15,24 +19,39 @@ type AdminDashboardService struct{}
 func (s *AdminDashboardService) GetAdminDashboard() (*admin.AdminDashboardResponse, error) {
 	dashboard := &admin.AdminDashboardResponse{}
 
-	var totalUsers, activeUsers, totalVMs, totalContainers, totalProviders, activeProviders int64
+	var totalProviders, activeProviders int64
 
-	// 统计用户
-	global.APP_DB.Model(&userModel.User{}).Count(&totalUsers)
-	global.APP_DB.Model(&userModel.User{}).Where("status = ?", 1).Count(&activeUsers)
+	// 用户和实例数量取自计数器，避免每次加载仪表板都扫描全表
+	userCounts, err := counters.Users()
+	if err != nil {
+		return nil, err
+	}
+	instanceCounts, err := counters.Instances()
+	if err != nil {
+		return nil, err
+	}
+	totalUsers := counters.CountUsers(userCounts, nil)
+	activeUsers := counters.CountUsers(userCounts, func(c dashboardModel.UserCounter) bool { return c.Status == 1 })
 
 	// 统计虚拟机和容器（排除deleted、deleting、failed状态）
-	global.APP_DB.Model(&providerModel.Instance{}).Where("instance_type = ? AND status NOT IN (?)", "vm", []string{"deleted", "deleting", "failed"}).Count(&totalVMs)
-	global.APP_DB.Model(&providerModel.Instance{}).Where("instance_type = ? AND status NOT IN (?)", "container", []string{"deleted", "deleting", "failed"}).Count(&totalContainers)
+	totalVMs := counters.CountInstances(instanceCounts, func(c dashboardModel.InstanceCounter) bool {
+		return c.InstanceType == "vm" && countedInstanceStatus(c.Status)
+	})
+	totalContainers := counters.CountInstances(instanceCounts, func(c dashboardModel.InstanceCounter) bool {
+		return c.InstanceType == "container" && countedInstanceStatus(c.Status)
+	})
+	runningInstances := counters.CountInstances(instanceCounts, func(c dashboardModel.InstanceCounter) bool {
+		return c.Status == "running"
+	})
 
 	// 统计服务器 (Provider表)
 	global.APP_DB.Model(&providerModel.Provider{}).Count(&totalProviders)
 	// 统计活跃Provider（包括 active 和 partial 状态，因为它们都可以被用户使用）
 	global.APP_DB.Model(&providerModel.Provider{}).Where("status = ? OR status = ?", "active", "partial").Count(&activeProviders)
 
-	// 统计运行中的实例
-	var runningInstances int64
-	global.APP_DB.Model(&providerModel.Instance{}).Where("status = ? AND soft_deleted = ?", "running", false).Count(&runningInstances)
+	// 统计运行已EOL操作系统的实例
+	var eolInstances int64
+	global.APP_DB.Model(&providerModel.Instance{}).Where("os_eol_date <= ? AND status NOT IN (?)", time.Now(), []string{"deleted", "deleting", "failed"}).Count(&eolInstances)
 
 	// 返回前端需要的字段名
 	dashboard.Statistics.TotalUsers = int(totalUsers)
@@ -46,6 +65,7 @@ func (s *AdminDashboardService) GetAdminDashboard() (*admin.AdminDashboardRespon
 	dashboard.Statistics.RunningInstances = int(runningInstances) // 使用真实的运行实例统计
 	dashboard.Statistics.TotalProviders = int(totalProviders)
 	dashboard.Statistics.ActiveProviders = int(activeProviders)
+	dashboard.Statistics.EOLInstances = int(eolInstances)
 
 	// 系统监控状态
 	monitoringService := &MonitoringService{}
@@ -56,9 +76,18 @@ func (s *AdminDashboardService) GetAdminDashboard() (*admin.AdminDashboardRespon
 	dashboard.SystemStatus.DiskUsage = systemStats.Disk.Usage
 	dashboard.SystemStatus.Uptime = systemStats.Runtime.Uptime
 
+	// 趋势预测预警
+	dashboard.Forecasts = []monitoringModel.UsageForecast{}
+	global.APP_DB.Where("warning = ?", true).Order("exhaust_at ASC").Limit(20).Find(&dashboard.Forecasts)
+
 	return dashboard, nil
 }
 
+// countedInstanceStatus 实例是否计入总数，deleted、deleting、failed状态的实例不计入
+func countedInstanceStatus(status string) bool {
+	return status != "deleted" && status != "deleting" && status != "failed"
+}
+
 // GetInstanceTypePermissions 获取实例类型权限配置
 func (s *AdminDashboardService) GetInstanceTypePermissions() map[string]interface{} {
 	permissions := global.APP_CONFIG.Quota.InstanceTypePermissions
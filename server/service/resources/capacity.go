@@ -0,0 +1,139 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+
+	"gorm.io/gorm"
+)
+
+// instanceTypeUsage 单一实例类型的资源分配统计
+type instanceTypeUsage struct {
+	Count        int64
+	UsedCPUCores int64
+	UsedMemory   int64
+}
+
+// instanceUsageByType 按实例类型统计Provider上已分配的资源（排除deleted、deleting、failed状态）
+func (s *ResourceService) instanceUsageByType(db *gorm.DB, providerID uint) (vm, container instanceTypeUsage, err error) {
+	for _, item := range []struct {
+		instanceType string
+		usage        *instanceTypeUsage
+	}{{"vm", &vm}, {"container", &container}} {
+		err = db.Model(&providerModel.Instance{}).
+			Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
+				providerID, item.instanceType, []string{"deleted", "deleting", "failed"}).
+			Select("COUNT(*) as count, COALESCE(SUM(cpu), 0) as used_cpu_cores, COALESCE(SUM(memory), 0) as used_memory").
+			Scan(item.usage).Error
+		if err != nil {
+			return vm, container, fmt.Errorf("统计%s资源失败: %v", item.instanceType, err)
+		}
+	}
+	return vm, container, nil
+}
+
+// physicalUsage 将各类型已售资源按超售比例折算为物理占用，仅统计计入总量预算的资源
+func physicalUsage(provider *providerModel.Provider, vm, container instanceTypeUsage) (cpu, memory float64) {
+	if provider.VMLimitCPU {
+		cpu += float64(vm.UsedCPUCores) / provider.CPUOvercommitRatio("vm")
+	}
+	if provider.ContainerLimitCPU {
+		cpu += float64(container.UsedCPUCores) / provider.CPUOvercommitRatio("container")
+	}
+	if provider.VMLimitMemory {
+		memory += float64(vm.UsedMemory) / provider.MemoryOvercommitRatio("vm")
+	}
+	if provider.ContainerLimitMemory {
+		memory += float64(container.UsedMemory) / provider.MemoryOvercommitRatio("container")
+	}
+	return cpu, memory
+}
+
+// sellable 按超售比例将剩余物理量换算为可售量
+func sellable(allocatable, used, ratio float64) int64 {
+	remaining := (allocatable - used) * ratio
+	if remaining < 0 {
+		return 0
+	}
+	return int64(math.Floor(remaining + 1e-9))
+}
+
+// overcommitAvailability 计算指定实例类型在超售比例下的剩余可用CPU和内存
+func (s *ResourceService) overcommitAvailability(db *gorm.DB, provider *providerModel.Provider, instanceType string) (int, int64, error) {
+	vm, container, err := s.instanceUsageByType(db, provider.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	usedCPU, usedMemory := physicalUsage(provider, vm, container)
+	availableCPU := sellable(float64(provider.AllocatableCPUCores()), usedCPU, provider.CPUOvercommitRatio(instanceType))
+	availableMemory := sellable(float64(provider.AllocatableMemory()), usedMemory, provider.MemoryOvercommitRatio(instanceType))
+	return int(availableCPU), availableMemory, nil
+}
+
+// GetProvidersCapacity 获取所有Provider的物理资源与已售资源对比
+func (s *ResourceService) GetProvidersCapacity() ([]resource.ProviderCapacity, error) {
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Order("id ASC").Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("获取Provider列表失败: %v", err)
+	}
+
+	result := make([]resource.ProviderCapacity, 0, len(providers))
+	for i := range providers {
+		provider := &providers[i]
+		vm, container, err := s.instanceUsageByType(global.APP_DB, provider.ID)
+		if err != nil {
+			return nil, err
+		}
+		usedCPU, usedMemory := physicalUsage(provider, vm, container)
+
+		capacity := resource.ProviderCapacity{
+			ProviderID:     provider.ID,
+			Name:           provider.Name,
+			Type:           provider.Type,
+			ContainerCount: container.Count,
+			VMCount:        vm.Count,
+			CPU: buildCapacityItem(int64(provider.NodeCPUCores), int64(provider.ReservedCPUCores()), int64(provider.AllocatableCPUCores()),
+				usedCPU, provider.CPUOvercommitRatio("container"), provider.CPUOvercommitRatio("vm"),
+				container.UsedCPUCores, vm.UsedCPUCores),
+			Memory: buildCapacityItem(provider.NodeMemoryTotal, provider.SystemReservedMemory, provider.AllocatableMemory(),
+				usedMemory, provider.MemoryOvercommitRatio("container"), provider.MemoryOvercommitRatio("vm"),
+				container.UsedMemory, vm.UsedMemory),
+			Disk: resource.DiskCapacity{
+				Physical:    provider.NodeDiskTotal,
+				Reserved:    provider.ReservedDisk(),
+				Allocatable: provider.AllocatableDisk(),
+				Used:        provider.UsedDisk,
+				Available:   provider.AllocatableDisk() - provider.UsedDisk,
+			},
+		}
+		if capacity.Disk.Available < 0 {
+			capacity.Disk.Available = 0
+		}
+		result = append(result, capacity)
+	}
+	return result, nil
+}
+
+// buildCapacityItem 组装单项资源容量
+func buildCapacityItem(physical, reserved, allocatable int64, physicalUsed, containerRatio, vmRatio float64, containerSold, vmSold int64) resource.CapacityItem {
+	item := resource.CapacityItem{
+		Physical:           physical,
+		Reserved:           reserved,
+		Allocatable:        allocatable,
+		PhysicalUsed:       math.Round(physicalUsed*100) / 100,
+		ContainerRatio:     containerRatio,
+		VMRatio:            vmRatio,
+		ContainerSold:      containerSold,
+		VMSold:             vmSold,
+		ContainerAvailable: sellable(float64(allocatable), physicalUsed, containerRatio),
+		VMAvailable:        sellable(float64(allocatable), physicalUsed, vmRatio),
+	}
+	if allocatable > 0 {
+		item.PhysicalUsage = math.Round(physicalUsed/float64(allocatable)*10000) / 100
+	}
+	return item
+}
@@ -0,0 +1,199 @@
+package resources
+
+import (
+	"fmt"
+	"math"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+)
+
+// simResource 模拟中单项资源的物理占用，cost 为每个实例折算后的物理占用（不计入总量时为0）
+type simResource struct {
+	allocatable float64
+	used        float64
+	cost        float64
+}
+
+// usage 放置 n 个实例后的物理占用率（%）
+func (r simResource) usage(n int) float64 {
+	if r.allocatable <= 0 {
+		return 0
+	}
+	return (r.used + float64(n)*r.cost) / r.allocatable * 100
+}
+
+// projection 返回放置 n 个实例前后的占用率
+func (r simResource) projection(n int) resource.UsageProjection {
+	return resource.UsageProjection{
+		Before: math.Round(r.usage(0)*100) / 100,
+		After:  math.Round(r.usage(n)*100) / 100,
+	}
+}
+
+// simCandidate 参与批量放置模拟的Provider
+type simCandidate struct {
+	capacity int
+	cpu      simResource
+	memory   simResource
+	disk     simResource
+}
+
+// score 放置 n 个实例后CPU与内存中较高的占用率，用于分散放置时挑选负载最低的Provider
+func (c simCandidate) score(n int) float64 {
+	return math.Max(c.cpu.usage(n), c.memory.usage(n))
+}
+
+// placementLimit 放置引擎对单项资源的限制，limited 为false时该项允许超分配不做检查
+type placementLimit struct {
+	name      string
+	limited   bool
+	available int64
+	need      int64
+	reason    string
+}
+
+// capacityFor 计算在各项限制下最多还能放置的实例数（不超过 max），并返回起限制作用的资源
+func capacityFor(limits []placementLimit, max int) (int, *placementLimit) {
+	capacity := max
+	var limiting *placementLimit
+	for i := range limits {
+		l := &limits[i]
+		if !l.limited || l.need <= 0 {
+			continue
+		}
+		n := int64(0)
+		if l.available > 0 {
+			n = l.available / l.need
+		}
+		if n < int64(capacity) {
+			capacity, limiting = int(n), l
+		}
+	}
+	return capacity, limiting
+}
+
+// spreadPlacement 逐个把实例放到放置后占用率最低的Provider上，返回各Provider分配的数量
+func spreadPlacement(candidates []simCandidate, count int) []int {
+	assigned := make([]int, len(candidates))
+	for placed := 0; placed < count; placed++ {
+		best, bestScore := -1, 0.0
+		for i, c := range candidates {
+			if assigned[i] >= c.capacity {
+				continue
+			}
+			if score := c.score(assigned[i] + 1); best < 0 || score < bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		assigned[best]++
+	}
+	return assigned
+}
+
+// unplaceableReason 按放置引擎规则检查Provider能否创建该类型实例
+func unplaceableReason(provider *providerModel.Provider, instanceType string) string {
+	switch {
+	case provider.Status != "active" && provider.Status != "partial":
+		return "节点未启用"
+	case provider.IsFrozen:
+		return "节点已冻结"
+	case instanceType == "container" && !provider.ContainerEnabled:
+		return "该节点不支持容器类型"
+	case instanceType == "vm" && !provider.VirtualMachineEnabled:
+		return "该节点不支持虚拟机类型"
+	}
+	return ""
+}
+
+// SimulateCapacity 模拟批量创建同规格实例，按当前预留与超售规则报告各Provider能容纳的数量及放置后的占用率
+// 不会预留或修改任何资源
+func (s *ResourceService) SimulateCapacity(req resource.CapacitySimulateRequest) (*resource.CapacitySimulateResult, error) {
+	query := global.APP_DB.Order("id ASC")
+	if len(req.ProviderIDs) > 0 {
+		query = query.Where("id IN ?", req.ProviderIDs)
+	}
+	var providers []providerModel.Provider
+	if err := query.Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("获取Provider列表失败: %v", err)
+	}
+
+	sims := make([]resource.ProviderSimulation, len(providers))
+	candidates := make([]simCandidate, len(providers))
+	for i := range providers {
+		provider := &providers[i]
+		vm, container, err := s.instanceUsageByType(global.APP_DB, provider.ID)
+		if err != nil {
+			return nil, err
+		}
+		usedCPU, usedMemory := physicalUsage(provider, vm, container)
+
+		limitCPU, limitMemory, limitDisk := provider.ContainerLimitCPU, provider.ContainerLimitMemory, provider.ContainerLimitDisk
+		maxCount, currentCount := provider.MaxContainerInstances, provider.ContainerCount
+		if req.InstanceType == "vm" {
+			limitCPU, limitMemory, limitDisk = provider.VMLimitCPU, provider.VMLimitMemory, provider.VMLimitDisk
+			maxCount, currentCount = provider.MaxVMInstances, provider.VMCount
+		}
+
+		candidate := simCandidate{
+			cpu:    simResource{allocatable: float64(provider.AllocatableCPUCores()), used: usedCPU},
+			memory: simResource{allocatable: float64(provider.AllocatableMemory()), used: usedMemory},
+			disk:   simResource{allocatable: float64(provider.AllocatableDisk()), used: float64(provider.UsedDisk)},
+		}
+		if limitCPU {
+			candidate.cpu.cost = float64(req.CPU) / provider.CPUOvercommitRatio(req.InstanceType)
+		}
+		if limitMemory {
+			candidate.memory.cost = float64(req.Memory) / provider.MemoryOvercommitRatio(req.InstanceType)
+		}
+		if limitDisk {
+			candidate.disk.cost = float64(req.Disk)
+		}
+
+		sim := resource.ProviderSimulation{
+			ProviderID: provider.ID,
+			Name:       provider.Name,
+			Type:       provider.Type,
+			Reason:     unplaceableReason(provider, req.InstanceType),
+		}
+		if sim.Reason == "" {
+			availableCPU, availableMemory, availableDisk := s.availableResources(global.APP_DB, provider, req.InstanceType)
+			capacity, limiting := capacityFor([]placementLimit{
+				{name: "count", limited: maxCount > 0, available: int64(maxCount - currentCount), need: 1,
+					reason: fmt.Sprintf("实例数量已达上限：%d/%d", currentCount, maxCount)},
+				{name: "cpu", limited: limitCPU, available: int64(availableCPU), need: int64(req.CPU),
+					reason: fmt.Sprintf("CPU资源不足：需要 %d 核，可用 %d 核", req.CPU, availableCPU)},
+				{name: "memory", limited: limitMemory, available: availableMemory, need: req.Memory,
+					reason: fmt.Sprintf("内存资源不足：需要 %d MB，可用 %d MB", req.Memory, availableMemory)},
+				{name: "disk", limited: limitDisk, available: availableDisk, need: req.Disk,
+					reason: fmt.Sprintf("磁盘资源不足：需要 %d MB，可用 %d MB", req.Disk, availableDisk)},
+			}, req.Count)
+			candidate.capacity = capacity
+			sim.Capacity = capacity
+			sim.Eligible = capacity > 0
+			if limiting != nil {
+				sim.Limit = limiting.name
+				if capacity == 0 {
+					sim.Reason = limiting.reason
+				}
+			}
+		}
+		sims[i] = sim
+		candidates[i] = candidate
+	}
+
+	result := &resource.CapacitySimulateResult{Requested: req.Count, Providers: sims}
+	for i, n := range spreadPlacement(candidates, req.Count) {
+		sims[i].Assigned = n
+		sims[i].CPU = candidates[i].cpu.projection(n)
+		sims[i].Memory = candidates[i].memory.projection(n)
+		sims[i].Disk = candidates[i].disk.projection(n)
+		result.Placed += n
+	}
+	result.Fits = result.Placed == req.Count
+	return result, nil
+}
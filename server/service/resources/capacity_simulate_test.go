@@ -0,0 +1,62 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestCapacityFor(t *testing.T) {
+	limits := []placementLimit{
+		{name: "count", limited: true, available: 10, need: 1},
+		{name: "cpu", limited: true, available: 7, need: 2},
+		{name: "memory", limited: false, available: 0, need: 1024},
+		{name: "disk", limited: true, available: 100, need: 0},
+	}
+	if n, l := capacityFor(limits, 20); n != 3 || l == nil || l.name != "cpu" {
+		t.Errorf("capacityFor = %d, %v, want 3 limited by cpu", n, l)
+	}
+	if n, l := capacityFor(limits, 2); n != 2 || l != nil {
+		t.Errorf("request count should cap capacity, got %d, %v", n, l)
+	}
+
+	limits[0].available = -1
+	if n, l := capacityFor(limits, 5); n != 0 || l == nil || l.name != "count" {
+		t.Errorf("exceeded instance limit should give zero capacity, got %d, %v", n, l)
+	}
+}
+
+func TestSpreadPlacement(t *testing.T) {
+	candidates := []simCandidate{
+		{capacity: 10, cpu: simResource{allocatable: 10, used: 5, cost: 1}, memory: simResource{allocatable: 100}},
+		{capacity: 2, cpu: simResource{allocatable: 10, used: 0, cost: 1}, memory: simResource{allocatable: 100}},
+		{capacity: 0, cpu: simResource{allocatable: 10, cost: 1}},
+	}
+	// 先填满空闲的第二个节点，余下的放到第一个节点
+	if got, want := spreadPlacement(candidates, 5), []int{3, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("spreadPlacement = %v, want %v", got, want)
+	}
+	if got, want := spreadPlacement(candidates, 20), []int{10, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("spreadPlacement should stop at capacity, got %v, want %v", got, want)
+	}
+
+	r := simResource{allocatable: 8, used: 2, cost: 0.5}
+	if p := r.projection(4); p.Before != 25 || p.After != 50 {
+		t.Errorf("projection = %+v, want 25 -> 50", p)
+	}
+}
+
+func TestUnplaceableReason(t *testing.T) {
+	provider := &providerModel.Provider{Status: "active", ContainerEnabled: true}
+	if reason := unplaceableReason(provider, "container"); reason != "" {
+		t.Errorf("active container provider should be eligible, got %q", reason)
+	}
+	if reason := unplaceableReason(provider, "vm"); reason == "" {
+		t.Error("vm should be rejected when disabled")
+	}
+	provider.IsFrozen = true
+	if reason := unplaceableReason(provider, "container"); reason == "" {
+		t.Error("frozen provider should be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"testing"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSellable(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocatable float64
+		used        float64
+		ratio       float64
+		want        int64
+	}{
+		{name: "不超售", allocatable: 8, used: 3, ratio: 1, want: 5},
+		{name: "两倍超售", allocatable: 8, used: 3, ratio: 2, want: 10},
+		{name: "小数向下取整", allocatable: 8, used: 6.5, ratio: 1.5, want: 2},
+		{name: "浮点误差不少算", allocatable: 1, used: 0.7, ratio: 10, want: 3},
+		{name: "已超过可分配", allocatable: 4, used: 5, ratio: 2, want: 0},
+	}
+	for _, tt := range tests {
+		if got := sellable(tt.allocatable, tt.used, tt.ratio); got != tt.want {
+			t.Errorf("%s: sellable(%v, %v, %v) = %d, want %d", tt.name, tt.allocatable, tt.used, tt.ratio, got, tt.want)
+		}
+	}
+}
+
+func TestPhysicalUsage(t *testing.T) {
+	vm := instanceTypeUsage{UsedCPUCores: 8, UsedMemory: 8192}
+	container := instanceTypeUsage{UsedCPUCores: 12, UsedMemory: 6144}
+	provider := &providerModel.Provider{
+		VMLimitCPU: true, VMLimitMemory: true, ContainerLimitCPU: true, ContainerLimitMemory: true,
+		VMCPUOvercommit: 2, ContainerCPUOvercommit: 4, ContainerMemoryOvercommit: 1.5,
+	}
+	if cpu, memory := physicalUsage(provider, vm, container); cpu != 7 || memory != 12288 {
+		t.Errorf("physicalUsage = %v, %v, want 7, 12288", cpu, memory)
+	}
+	// 不限制的资源不计入物理占用
+	provider.ContainerLimitCPU, provider.VMLimitMemory = false, false
+	if cpu, memory := physicalUsage(provider, vm, container); cpu != 4 || memory != 4096 {
+		t.Errorf("部分不限制时 physicalUsage = %v, %v, want 4, 4096", cpu, memory)
+	}
+}
+
+func TestBuildCapacityItem(t *testing.T) {
+	item := buildCapacityItem(16, 2, 14, 7, 4, 1, 20, 2)
+	if item.ContainerAvailable != 28 || item.VMAvailable != 7 || item.PhysicalUsage != 50 {
+		t.Errorf("buildCapacityItem = %+v", item)
+	}
+	if item := buildCapacityItem(0, 0, 0, 1, 1, 1, 0, 0); item.PhysicalUsage != 0 || item.VMAvailable != 0 {
+		t.Errorf("无可分配资源时 = %+v", item)
+	}
+}
+
+func TestCheckProviderResourceAvailabilityOvercommit(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&providerModel.Instance{}); err != nil {
+		t.Fatal(err)
+	}
+	oldLog := global.APP_LOG
+	global.APP_LOG = zap.NewNop()
+	t.Cleanup(func() { global.APP_LOG = oldLog })
+
+	// 4核8G，容器CPU超售4倍、内存超售2倍，虚拟机不超售；已售出容器4核4G、虚拟机2核2G
+	provider := &providerModel.Provider{
+		ID: 1, ContainerEnabled: true, VirtualMachineEnabled: true,
+		NodeCPUCores: 4, NodeMemoryTotal: 8192, NodeDiskTotal: 102400,
+		ContainerLimitCPU: true, ContainerLimitMemory: true, VMLimitCPU: true, VMLimitMemory: true,
+		ContainerCPUOvercommit: 4, ContainerMemoryOvercommit: 2,
+	}
+	instances := []providerModel.Instance{
+		{Name: "ct", ProviderID: 1, InstanceType: "container", Status: "running", CPU: 4, Memory: 4096},
+		{Name: "vm", ProviderID: 1, InstanceType: "vm", Status: "running", CPU: 2, Memory: 2048},
+		{Name: "gone", ProviderID: 1, InstanceType: "vm", Status: "deleted", CPU: 100, Memory: 100000},
+	}
+	if err := db.Create(&instances).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 物理占用：CPU 4/4+2=3核，内存 4096/2+2048=4096MB
+	tests := []struct {
+		name    string
+		req     resource.ResourceCheckRequest
+		allowed bool
+		cpu     int
+		memory  int64
+	}{
+		{name: "容器按超售比例可售", req: resource.ResourceCheckRequest{InstanceType: "container", CPU: 4, Memory: 8192}, allowed: true, cpu: 4, memory: 8192},
+		{name: "容器超出超售可售量", req: resource.ResourceCheckRequest{InstanceType: "container", CPU: 5, Memory: 1024}, allowed: false, cpu: 4, memory: 8192},
+		{name: "虚拟机不超售", req: resource.ResourceCheckRequest{InstanceType: "vm", CPU: 1, Memory: 4096}, allowed: true, cpu: 1, memory: 4096},
+		{name: "虚拟机超出物理剩余", req: resource.ResourceCheckRequest{InstanceType: "vm", CPU: 2, Memory: 1024}, allowed: false, cpu: 1, memory: 4096},
+	}
+	s := &ResourceService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.checkProviderResourceAvailability(db, provider, tt.req)
+			if result.Allowed != tt.allowed {
+				t.Errorf("Allowed = %v (%s), want %v", result.Allowed, result.Reason, tt.allowed)
+			}
+			if result.AvailableCPU != tt.cpu || result.AvailableMemory != tt.memory {
+				t.Errorf("可用资源 = %d核 %dMB, want %d核 %dMB", result.AvailableCPU, result.AvailableMemory, tt.cpu, tt.memory)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package resources
+
+import (
+	"fmt"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/user"
+
+	"gorm.io/gorm"
+)
+
+// ConsistencyIssue 计数字段与实例记录不一致的项
+type ConsistencyIssue struct {
+	Scope    string `json:"scope"`    // user:<id> 或 provider:<id>
+	Field    string `json:"field"`    // 不一致的字段名
+	Stored   int64  `json:"stored"`   // 数据库中记录的值
+	Expected int64  `json:"expected"` // 根据实例记录计算的值
+}
+
+// providerUsage 根据实例记录计算的Provider资源占用
+type providerUsage struct {
+	VMCount        int64
+	ContainerCount int64
+	UsedCPUCores   int64
+	UsedMemory     int64
+	UsedDisk       int64
+}
+
+// calculateProviderUsage 根据实例记录计算Provider资源占用
+// CPU/内存/磁盘是否计入与 AllocateResourcesInTx/ReleaseResourcesInTx 一致，按实例类型的资源限制配置决定
+func calculateProviderUsage(db *gorm.DB, provider *providerModel.Provider) (providerUsage, error) {
+	var usage providerUsage
+	for _, instanceType := range []string{"vm", "container"} {
+		var stats struct {
+			Count  int64
+			CPU    int64
+			Memory int64
+			Disk   int64
+		}
+		// deleted、deleting、failed 状态的实例不占用资源
+		err := db.Model(&providerModel.Instance{}).
+			Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
+				provider.ID, instanceType, []string{"deleted", "deleting", "failed"}).
+			Select("COUNT(*) as count, COALESCE(SUM(cpu), 0) as cpu, COALESCE(SUM(memory), 0) as memory, COALESCE(SUM(disk), 0) as disk").
+			Scan(&stats).Error
+		if err != nil {
+			return usage, fmt.Errorf("统计%s资源失败: %v", instanceType, err)
+		}
+
+		limitCPU, limitMemory, limitDisk := provider.ContainerLimitCPU, provider.ContainerLimitMemory, provider.ContainerLimitDisk
+		if instanceType == "vm" {
+			limitCPU, limitMemory, limitDisk = provider.VMLimitCPU, provider.VMLimitMemory, provider.VMLimitDisk
+			usage.VMCount = stats.Count
+		} else {
+			usage.ContainerCount = stats.Count
+		}
+		if limitCPU {
+			usage.UsedCPUCores += stats.CPU
+		}
+		if limitMemory {
+			usage.UsedMemory += stats.Memory
+		}
+		if limitDisk {
+			usage.UsedDisk += stats.Disk
+		}
+	}
+	return usage, nil
+}
+
+// CheckUserQuotaConsistency 检查用户的已使用/待确认配额是否与实例记录一致（只读，不做修正）
+func (s *QuotaService) CheckUserQuotaConsistency(userID uint) ([]ConsistencyIssue, error) {
+	var u user.User
+	if err := global.APP_DB.First(&u, userID).Error; err != nil {
+		return nil, fmt.Errorf("用户不存在: %v", err)
+	}
+
+	_, stableResources, pendingResources, err := s.getCurrentResourceUsageWithPending(global.APP_DB, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前资源使用情况失败: %v", err)
+	}
+
+	scope := fmt.Sprintf("user:%d", userID)
+	var issues []ConsistencyIssue
+	if expected := stableResources.GetResourceUsage(); u.UsedQuota != expected {
+		issues = append(issues, ConsistencyIssue{Scope: scope, Field: "used_quota", Stored: int64(u.UsedQuota), Expected: int64(expected)})
+	}
+	if expected := pendingResources.GetResourceUsage(); u.PendingQuota != expected {
+		issues = append(issues, ConsistencyIssue{Scope: scope, Field: "pending_quota", Stored: int64(u.PendingQuota), Expected: int64(expected)})
+	}
+	return issues, nil
+}
+
+// CheckProviderResourceConsistency 检查Provider的实例计数和资源占用是否与实例记录一致（只读，不做修正）
+func (s *ResourceService) CheckProviderResourceConsistency(providerID uint) ([]ConsistencyIssue, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在: %v", err)
+	}
+
+	usage, err := calculateProviderUsage(global.APP_DB, &provider)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := fmt.Sprintf("provider:%d", providerID)
+	fields := []struct {
+		name     string
+		stored   int64
+		expected int64
+	}{
+		{"vm_count", int64(provider.VMCount), usage.VMCount},
+		{"container_count", int64(provider.ContainerCount), usage.ContainerCount},
+		{"used_cpu_cores", int64(provider.UsedCPUCores), usage.UsedCPUCores},
+		{"used_memory", provider.UsedMemory, usage.UsedMemory},
+		{"used_disk", provider.UsedDisk, usage.UsedDisk},
+	}
+	var issues []ConsistencyIssue
+	for _, f := range fields {
+		if f.stored != f.expected {
+			issues = append(issues, ConsistencyIssue{Scope: scope, Field: f.name, Stored: f.stored, Expected: f.expected})
+		}
+	}
+	return issues, nil
+}
+
+// VerifyConsistency 检查用户配额和Provider资源计数，返回所有不一致项
+func VerifyConsistency(userID, providerID uint) ([]ConsistencyIssue, error) {
+	issues, err := NewQuotaService().CheckUserQuotaConsistency(userID)
+	if err != nil {
+		return nil, err
+	}
+	providerIssues, err := (&ResourceService{}).CheckProviderResourceConsistency(providerID)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, providerIssues...), nil
+}
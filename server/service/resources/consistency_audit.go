@@ -0,0 +1,193 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+)
+
+// ErrConsistencyAuditRunning 已有审计正在执行
+var ErrConsistencyAuditRunning = errors.New("一致性审计正在执行中")
+
+// ConsistencyAuditReport 一次一致性审计的结果
+type ConsistencyAuditReport struct {
+	StartedAt        time.Time          `json:"startedAt"`
+	DurationMs       int64              `json:"durationMs"`
+	Trigger          string             `json:"trigger"` // scheduled 或 manual
+	AutoCorrect      bool               `json:"autoCorrect"`
+	CheckedUsers     int                `json:"checkedUsers"`
+	CheckedProviders int                `json:"checkedProviders"`
+	Issues           []ConsistencyIssue `json:"issues"`
+	CorrectedScopes  []string           `json:"correctedScopes"` // 已修正的对象
+	Errors           []string           `json:"errors"`          // 检查或修正失败的对象
+}
+
+// ConsistencyAuditOptions 审计参数
+type ConsistencyAuditOptions struct {
+	Trigger     string
+	AutoCorrect bool
+	OperatorID  *uint  // 手动触发时的管理员ID，定时任务为空
+	Operator    string // 写入审计日志的操作人
+}
+
+// ConsistencyAuditService 配额与资源计数一致性审计服务
+type ConsistencyAuditService struct {
+	mu         sync.Mutex
+	running    bool
+	lastReport *ConsistencyAuditReport
+}
+
+var (
+	consistencyAuditService     *ConsistencyAuditService
+	consistencyAuditServiceOnce sync.Once
+)
+
+// GetConsistencyAuditService 获取一致性审计服务单例
+func GetConsistencyAuditService() *ConsistencyAuditService {
+	consistencyAuditServiceOnce.Do(func() {
+		consistencyAuditService = &ConsistencyAuditService{}
+	})
+	return consistencyAuditService
+}
+
+// LastReport 返回最近一次审计结果，尚未执行过时返回nil
+func (s *ConsistencyAuditService) LastReport() *ConsistencyAuditReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastReport
+}
+
+// Run 执行一次审计：按实例记录重新计算所有用户配额和Provider资源占用并与已记录值比较
+// AutoCorrect 为true时修正存在偏差的对象，并写入一条审计日志
+func (s *ConsistencyAuditService) Run(opts ConsistencyAuditOptions) (*ConsistencyAuditReport, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrConsistencyAuditRunning
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	report := &ConsistencyAuditReport{
+		StartedAt:   time.Now(),
+		Trigger:     opts.Trigger,
+		AutoCorrect: opts.AutoCorrect,
+		Issues:      []ConsistencyIssue{},
+	}
+
+	var userIDs []uint
+	if err := global.APP_DB.Model(&user.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	var providerIDs []uint
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Pluck("id", &providerIDs).Error; err != nil {
+		return nil, err
+	}
+
+	quotaService := NewQuotaService()
+	for i, userID := range userIDs {
+		issues, err := quotaService.CheckUserQuotaConsistency(userID)
+		s.collect(report, issues, err, "user", userID, func() error {
+			return quotaService.RecalculateUserQuota(userID)
+		})
+		// 每20个用户休眠一次，避免对数据库造成过大压力
+		if (i+1)%20 == 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	report.CheckedUsers = len(userIDs)
+
+	resourceService := &ResourceService{}
+	for _, providerID := range providerIDs {
+		issues, err := resourceService.CheckProviderResourceConsistency(providerID)
+		s.collect(report, issues, err, "provider", providerID, func() error {
+			return resourceService.SyncProviderResources(providerID)
+		})
+	}
+	report.CheckedProviders = len(providerIDs)
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	if len(report.Issues) > 0 {
+		global.APP_LOG.Warn("一致性审计发现计数偏差",
+			zap.String("trigger", opts.Trigger),
+			zap.Int("issues", len(report.Issues)),
+			zap.Int("corrected", len(report.CorrectedScopes)),
+			zap.Any("details", report.Issues))
+	} else {
+		global.APP_LOG.Info("一致性审计完成，未发现偏差",
+			zap.String("trigger", opts.Trigger),
+			zap.Int("users", report.CheckedUsers),
+			zap.Int("providers", report.CheckedProviders))
+	}
+	if len(report.CorrectedScopes) > 0 {
+		s.writeAuditLog(opts, report)
+	}
+
+	s.mu.Lock()
+	s.lastReport = report
+	s.mu.Unlock()
+	return report, nil
+}
+
+// collect 汇总单个对象的检查结果，需要时执行修正
+func (s *ConsistencyAuditService) collect(report *ConsistencyAuditReport, issues []ConsistencyIssue, err error, kind string, id uint, correct func() error) {
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return
+	}
+	if len(issues) == 0 {
+		return
+	}
+	report.Issues = append(report.Issues, issues...)
+	if !report.AutoCorrect {
+		return
+	}
+	scope := issues[0].Scope
+	if err := correct(); err != nil {
+		global.APP_LOG.Warn("修正计数偏差失败",
+			zap.String("kind", kind),
+			zap.Uint("id", id),
+			zap.Error(err))
+		report.Errors = append(report.Errors, scope+": "+err.Error())
+		return
+	}
+	report.CorrectedScopes = append(report.CorrectedScopes, scope)
+}
+
+// writeAuditLog 将自动修正写入审计日志
+func (s *ConsistencyAuditService) writeAuditLog(opts ConsistencyAuditOptions, report *ConsistencyAuditReport) {
+	requestJSON, _ := json.Marshal(map[string]interface{}{
+		"trigger": opts.Trigger,
+		"issues":  report.Issues,
+	})
+	responseJSON, _ := json.Marshal(map[string]interface{}{
+		"correctedScopes": report.CorrectedScopes,
+		"errors":          report.Errors,
+	})
+	auditLog := adminModel.AuditLog{
+		UserID:     opts.OperatorID,
+		Username:   opts.Operator,
+		Method:     "AUDIT",
+		Path:       "consistency-audit",
+		StatusCode: 200,
+		Latency:    report.DurationMs,
+		Request:    string(requestJSON),
+		Response:   string(responseJSON),
+	}
+	if err := global.APP_DB.Create(&auditLog).Error; err != nil {
+		global.APP_LOG.Error("写入一致性审计日志失败", zap.Error(err))
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"oneclickvirt/global"
 	"oneclickvirt/model/dashboard"
 	"oneclickvirt/model/provider"
-	"oneclickvirt/model/user"
+	"oneclickvirt/service/counters"
 	"oneclickvirt/utils"
 	"sync"
 	"time"
@@ -178,16 +178,15 @@ func (s *DashboardService) getQuotaStats() (*dashboard.QuotaStat, error) {
 }
 
 func (s *DashboardService) getUserStats() (*dashboard.UserStat, error) {
-	var totalUsers, activeUsers, adminUsers int64
-
-	global.APP_DB.Model(&user.User{}).Count(&totalUsers)
-	global.APP_DB.Model(&user.User{}).Where("status = ?", 1).Count(&activeUsers)
-	global.APP_DB.Model(&user.User{}).Where("user_type = ?", "admin").Count(&adminUsers)
+	userCounts, err := counters.Users()
+	if err != nil {
+		return nil, err
+	}
 
 	return &dashboard.UserStat{
-		TotalUsers:  int(totalUsers),
-		ActiveUsers: int(activeUsers),
-		AdminUsers:  int(adminUsers),
+		TotalUsers:  int(counters.CountUsers(userCounts, nil)),
+		ActiveUsers: int(counters.CountUsers(userCounts, func(c dashboard.UserCounter) bool { return c.Status == 1 })),
+		AdminUsers:  int(counters.CountUsers(userCounts, func(c dashboard.UserCounter) bool { return c.UserType == "admin" })),
 	}, nil
 }
 
@@ -200,15 +199,18 @@ func (s *DashboardService) getResourceUsageStats() (*dashboard.ResourceUsageStat
 		UsedDisk       int64
 	}
 
-	var instanceCounts struct {
-		VMCount        int64
-		ContainerCount int64
+	instanceCounts, err := counters.Instances()
+	if err != nil {
+		return nil, err
 	}
 
-	// 使用条件聚合一次性获取两种实例数量（排除deleted、deleting、failed状态）
-	global.APP_DB.Model(&provider.Instance{}).
-		Select("SUM(CASE WHEN instance_type = 'vm' AND status NOT IN ('deleting', 'deleted', 'failed') THEN 1 ELSE 0 END) as vm_count, SUM(CASE WHEN instance_type = 'container' AND status NOT IN ('deleting', 'deleted', 'failed') THEN 1 ELSE 0 END) as container_count").
-		Scan(&instanceCounts)
+	// 实例数量取自计数器（排除deleted、deleting、failed状态）
+	vmCount := counters.CountInstances(instanceCounts, func(c dashboard.InstanceCounter) bool {
+		return c.InstanceType == "vm" && countedInstanceStatus(c.Status)
+	})
+	containerCount := counters.CountInstances(instanceCounts, func(c dashboard.InstanceCounter) bool {
+		return c.InstanceType == "container" && countedInstanceStatus(c.Status)
+	})
 
 	var providerStats struct {
 		UsedCPUCores int64
@@ -222,8 +224,8 @@ func (s *DashboardService) getResourceUsageStats() (*dashboard.ResourceUsageStat
 		Scan(&providerStats)
 
 	return &dashboard.ResourceUsageStats{
-		VMCount:        instanceCounts.VMCount,
-		ContainerCount: instanceCounts.ContainerCount,
+		VMCount:        vmCount,
+		ContainerCount: containerCount,
 		UsedCPUCores:   providerStats.UsedCPUCores,
 		UsedMemory:     providerStats.UsedMemory,
 		UsedDisk:       providerStats.UsedDisk,
@@ -0,0 +1,294 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/model/resource"
+
+	"gorm.io/gorm"
+)
+
+// planInstance 参与下线规划的实例规格
+type planInstance struct {
+	instanceType string
+	cpu          int
+	memory       int64
+	disk         int64
+}
+
+// planTypeBudget 目标节点对某一实例类型的剩余可售量
+type planTypeBudget struct {
+	reason      string // 不能创建该类型实例的原因
+	limitCPU    bool
+	limitMemory bool
+	limitDisk   bool
+	cpuRatio    float64
+	memoryRatio float64
+	count       int64 // 剩余实例数量，小于0表示不限制
+	cpu         int64
+	memory      int64
+	disk        int64
+}
+
+// planTarget 下线规划中的目标节点，物理占用随放置累加
+type planTarget struct {
+	cpu    simResource
+	memory simResource
+	disk   simResource
+	types  map[string]*planTypeBudget
+}
+
+// fits 判断目标节点能否再容纳该实例
+func (t *planTarget) fits(inst planInstance) bool {
+	budget := t.types[inst.instanceType]
+	if budget == nil || budget.reason != "" {
+		return false
+	}
+	n, _ := capacityFor([]placementLimit{
+		{limited: budget.count >= 0, available: budget.count, need: 1},
+		{limited: budget.limitCPU, available: budget.cpu, need: int64(inst.cpu)},
+		{limited: budget.limitMemory, available: budget.memory, need: inst.memory},
+		{limited: budget.limitDisk, available: budget.disk, need: inst.disk},
+	}, 1)
+	return n == 1
+}
+
+// physical 返回实例在目标节点上折算后的物理占用，不计入总量的资源为0
+func (t *planTarget) physical(inst planInstance) (cpu, memory, disk float64) {
+	budget := t.types[inst.instanceType]
+	if budget.limitCPU {
+		cpu = float64(inst.cpu) / budget.cpuRatio
+	}
+	if budget.limitMemory {
+		memory = float64(inst.memory) / budget.memoryRatio
+	}
+	if budget.limitDisk {
+		disk = float64(inst.disk)
+	}
+	return cpu, memory, disk
+}
+
+// score 放置该实例后CPU与内存中较高的占用率
+func (t *planTarget) score(inst planInstance) float64 {
+	cpu, memory, _ := t.physical(inst)
+	after := simCandidate{
+		cpu:    simResource{allocatable: t.cpu.allocatable, used: t.cpu.used + cpu},
+		memory: simResource{allocatable: t.memory.allocatable, used: t.memory.used + memory},
+	}
+	return after.score(0)
+}
+
+// place 扣减实例占用的资源，物理占用按各类型的超售比例同步扣减其他类型的可售量
+func (t *planTarget) place(inst planInstance) {
+	cpu, memory, disk := t.physical(inst)
+	t.cpu.used += cpu
+	t.memory.used += memory
+	t.disk.used += disk
+	for instanceType, budget := range t.types {
+		if instanceType == inst.instanceType && budget.count > 0 {
+			budget.count--
+		}
+		if budget.limitCPU {
+			budget.cpu -= int64(cpu * budget.cpuRatio)
+		}
+		if budget.limitMemory {
+			budget.memory -= int64(memory * budget.memoryRatio)
+		}
+		if budget.limitDisk {
+			budget.disk -= int64(disk)
+		}
+	}
+}
+
+// planPlacement 按顺序把实例放到放置后占用率最低且能容纳的目标节点，返回各实例的目标下标，无法放置为-1
+func planPlacement(instances []planInstance, targets []*planTarget) []int {
+	result := make([]int, len(instances))
+	for i, inst := range instances {
+		best, bestScore := -1, 0.0
+		for j, t := range targets {
+			if !t.fits(inst) {
+				continue
+			}
+			if score := t.score(inst); best < 0 || score < bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best >= 0 {
+			targets[best].place(inst)
+		}
+		result[i] = best
+	}
+	return result
+}
+
+// decommissionOrder 迁移顺序：已停止的实例先迁移（不影响用户使用），其余按内存从大到小，
+// 大实例优先放置更容易找到足够的连续空间
+func decommissionOrder(instances []providerModel.Instance) {
+	sort.SliceStable(instances, func(i, j int) bool {
+		a, b := instances[i], instances[j]
+		aStopped, bStopped := a.Status == constant.InstanceStatusStopped, b.Status == constant.InstanceStatusStopped
+		if aStopped != bStopped {
+			return aStopped
+		}
+		if a.Memory != b.Memory {
+			return a.Memory > b.Memory
+		}
+		return a.ID < b.ID
+	})
+}
+
+// newPlanTarget 按当前预留与超售规则构造目标节点的剩余容量
+func (s *ResourceService) newPlanTarget(provider *providerModel.Provider) (*planTarget, error) {
+	vm, container, err := s.instanceUsageByType(global.APP_DB, provider.ID)
+	if err != nil {
+		return nil, err
+	}
+	usedCPU, usedMemory := physicalUsage(provider, vm, container)
+	target := &planTarget{
+		cpu:    simResource{allocatable: float64(provider.AllocatableCPUCores()), used: usedCPU},
+		memory: simResource{allocatable: float64(provider.AllocatableMemory()), used: usedMemory},
+		disk:   simResource{allocatable: float64(provider.AllocatableDisk()), used: float64(provider.UsedDisk)},
+		types:  make(map[string]*planTypeBudget, 2),
+	}
+	for _, instanceType := range []string{"container", "vm"} {
+		budget := &planTypeBudget{
+			reason:      unplaceableReason(provider, instanceType),
+			limitCPU:    provider.ContainerLimitCPU,
+			limitMemory: provider.ContainerLimitMemory,
+			limitDisk:   provider.ContainerLimitDisk,
+			cpuRatio:    provider.CPUOvercommitRatio(instanceType),
+			memoryRatio: provider.MemoryOvercommitRatio(instanceType),
+			count:       int64(provider.MaxContainerInstances - provider.ContainerCount),
+		}
+		maxCount := provider.MaxContainerInstances
+		if instanceType == "vm" {
+			budget.limitCPU, budget.limitMemory, budget.limitDisk = provider.VMLimitCPU, provider.VMLimitMemory, provider.VMLimitDisk
+			budget.count = int64(provider.MaxVMInstances - provider.VMCount)
+			maxCount = provider.MaxVMInstances
+		}
+		if maxCount <= 0 {
+			budget.count = -1
+		} else if budget.count < 0 {
+			budget.count = 0
+		}
+		availableCPU, availableMemory, availableDisk := s.availableResources(global.APP_DB, provider, instanceType)
+		budget.cpu, budget.memory, budget.disk = int64(availableCPU), availableMemory, availableDisk
+		target.types[instanceType] = budget
+	}
+	return target, nil
+}
+
+// PlanDecommission 为下线节点生成迁移规划：按迁移顺序列出节点上的全部实例，
+// 在同类型的目标节点中按当前预留与超售规则分散放置，报告能否全部容纳。不会预留或修改任何资源
+func (s *ResourceService) PlanDecommission(providerID uint, req resource.DecommissionPlanRequest) (*resource.DecommissionPlan, error) {
+	var source providerModel.Provider
+	if err := global.APP_DB.First(&source, providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("节点不存在")
+		}
+		return nil, fmt.Errorf("获取节点失败: %v", err)
+	}
+
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("provider_id = ? AND status NOT IN (?)",
+		providerID, []string{"deleted", "deleting", "failed"}).Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("获取节点实例失败: %v", err)
+	}
+	decommissionOrder(instances)
+
+	query := global.APP_DB.Where("id <> ?", providerID).Order("id ASC")
+	if len(req.TargetProviderIDs) > 0 {
+		query = query.Where("id IN ?", req.TargetProviderIDs)
+	}
+	var providers []providerModel.Provider
+	if err := query.Find(&providers).Error; err != nil {
+		return nil, fmt.Errorf("获取目标节点失败: %v", err)
+	}
+
+	plan := &resource.DecommissionPlan{
+		ProviderID: source.ID,
+		Name:       source.Name,
+		Type:       source.Type,
+		Total:      len(instances),
+		Instances:  make([]resource.DecommissionInstancePlan, len(instances)),
+		Targets:    make([]resource.DecommissionTarget, len(providers)),
+	}
+
+	// 实例只能迁移到同类型的节点，其他类型的节点保留在结果中并注明原因
+	var targets []*planTarget
+	var targetIndex []int
+	for i := range providers {
+		provider := &providers[i]
+		plan.Targets[i] = resource.DecommissionTarget{ProviderID: provider.ID, Name: provider.Name}
+		if provider.Type != source.Type {
+			plan.Targets[i].Reason = fmt.Sprintf("节点类型不同：%s", provider.Type)
+			continue
+		}
+		target, err := s.newPlanTarget(provider)
+		if err != nil {
+			return nil, err
+		}
+		if reason := target.types["container"].reason; reason != "" && target.types["vm"].reason != "" {
+			plan.Targets[i].Reason = reason
+		} else {
+			plan.Targets[i].Eligible = true
+		}
+		targets = append(targets, target)
+		targetIndex = append(targetIndex, i)
+	}
+
+	before := make([]planTarget, len(targets))
+	for i, t := range targets {
+		before[i] = *t
+	}
+
+	specs := make([]planInstance, len(instances))
+	for i, inst := range instances {
+		specs[i] = planInstance{instanceType: inst.InstanceType, cpu: inst.CPU, memory: inst.Memory, disk: inst.Disk}
+	}
+	for i, j := range planPlacement(specs, targets) {
+		inst := instances[i]
+		item := resource.DecommissionInstancePlan{
+			Order:        i + 1,
+			InstanceID:   inst.ID,
+			Name:         inst.Name,
+			UserID:       inst.UserID,
+			InstanceType: inst.InstanceType,
+			Status:       inst.Status,
+			CPU:          inst.CPU,
+			Memory:       inst.Memory,
+			Disk:         inst.Disk,
+		}
+		if j < 0 {
+			item.Reason = "没有能容纳该实例的目标节点"
+		} else {
+			target := &plan.Targets[targetIndex[j]]
+			target.Assigned++
+			item.TargetID, item.TargetName = target.ProviderID, target.Name
+			plan.Placed++
+		}
+		plan.Instances[i] = item
+	}
+
+	for i, t := range targets {
+		target := &plan.Targets[targetIndex[i]]
+		target.CPU = usageChange(before[i].cpu, t.cpu)
+		target.Memory = usageChange(before[i].memory, t.memory)
+		target.Disk = usageChange(before[i].disk, t.disk)
+	}
+	plan.Fits = plan.Placed == plan.Total
+	return plan, nil
+}
+
+// usageChange 返回规划前后的占用率
+func usageChange(before, after simResource) resource.UsageProjection {
+	return resource.UsageProjection{
+		Before: before.projection(0).Before,
+		After:  after.projection(0).Before,
+	}
+}
@@ -0,0 +1,73 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	"oneclickvirt/constant"
+	providerModel "oneclickvirt/model/provider"
+)
+
+func newTestTarget(cpu, memory int64, count int64) *planTarget {
+	return &planTarget{
+		cpu:    simResource{allocatable: float64(cpu)},
+		memory: simResource{allocatable: float64(memory)},
+		disk:   simResource{allocatable: 100000},
+		types: map[string]*planTypeBudget{
+			"container": {limitCPU: true, limitMemory: true, cpuRatio: 1, memoryRatio: 1, count: count, cpu: cpu, memory: memory},
+			"vm":        {reason: "该节点不支持虚拟机类型"},
+		},
+	}
+}
+
+func TestPlanPlacement(t *testing.T) {
+	targets := []*planTarget{newTestTarget(4, 4096, -1), newTestTarget(8, 8192, 2)}
+	instances := []planInstance{
+		{instanceType: "container", cpu: 4, memory: 4096},
+		{instanceType: "container", cpu: 2, memory: 2048},
+		{instanceType: "container", cpu: 4, memory: 4096},
+		{instanceType: "container", cpu: 1, memory: 1024},
+		{instanceType: "vm", cpu: 1, memory: 512},
+	}
+	// 每个实例放到放置后占用率最低的节点，第二个节点放满2个实例后余下的放到第一个节点，虚拟机没有可用节点
+	got := planPlacement(instances, targets)
+	if want := []int{1, 0, 1, 0, -1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("planPlacement = %v, want %v", got, want)
+	}
+	if targets[1].types["container"].count != 0 || targets[1].types["container"].cpu != 0 || targets[0].types["container"].cpu != 1 {
+		t.Errorf("budget not deducted: %+v", targets[1].types["container"])
+	}
+}
+
+func TestPlanTargetOvercommit(t *testing.T) {
+	target := newTestTarget(4, 4096, -1)
+	target.types["vm"] = &planTypeBudget{limitCPU: true, cpuRatio: 1, count: -1, cpu: 4}
+	target.types["container"].cpuRatio = 2
+	target.types["container"].cpu = 8
+
+	// 2核容器按2倍超售折算为1核物理占用，虚拟机的可售量同步减少1核
+	target.place(planInstance{instanceType: "container", cpu: 2, memory: 1024})
+	if target.types["container"].cpu != 6 || target.types["vm"].cpu != 3 || target.cpu.used != 1 {
+		t.Errorf("unexpected budgets: container=%d vm=%d used=%v",
+			target.types["container"].cpu, target.types["vm"].cpu, target.cpu.used)
+	}
+}
+
+func TestDecommissionOrder(t *testing.T) {
+	instances := []providerModel.Instance{
+		{Status: constant.InstanceStatusRunning, Memory: 512},
+		{Status: constant.InstanceStatusRunning, Memory: 2048},
+		{Status: constant.InstanceStatusStopped, Memory: 256},
+	}
+	for i := range instances {
+		instances[i].ID = uint(i + 1)
+	}
+	decommissionOrder(instances)
+	var got []uint
+	for _, inst := range instances {
+		got = append(got, inst.ID)
+	}
+	if want := []uint{3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("decommissionOrder = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package resources
+
+import (
+	"strings"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	"oneclickvirt/model/provider"
+)
+
+// ResolveInstanceSSHEndpoint 获取从外部访问实例SSH的地址和端口
+// 优先使用已激活的SSH端口映射，地址优先使用Provider的端口映射IP
+func ResolveInstanceSSHEndpoint(instance *provider.Instance, providerInfo *provider.Provider) (string, int) {
+	// 获取SSH端口映射
+	var sshPort int
+	var sshPortMapping provider.Port
+	if err := global.APP_DB.Where("instance_id = ? AND is_ssh = true AND status = 'active'", instance.ID).First(&sshPortMapping).Error; err == nil {
+		sshPort = sshPortMapping.HostPort
+	} else {
+		sshPort = instance.SSHPort
+		if sshPort == 0 {
+			sshPort = constant.GuestSSHPortOrDefault(instance.GuestSSHPort)
+		}
+	}
+
+	return ProviderPublicHost(providerInfo), sshPort
+}
+
+// ProviderPublicHost 返回用户访问端口映射使用的地址：优先使用Provider的端口映射IP，否则使用SSH地址，去掉端口部分
+func ProviderPublicHost(providerInfo *provider.Provider) string {
+	host := providerInfo.PortIP
+	if host == "" {
+		host = providerInfo.Endpoint
+	}
+
+	// 如果地址包含端口，去掉端口部分
+	if colonIndex := strings.LastIndex(host, ":"); colonIndex > 0 {
+		if strings.Count(host, ":") == 1 || strings.HasPrefix(host, "[") {
+			host = host[:colonIndex]
+		}
+	}
+	return host
+}
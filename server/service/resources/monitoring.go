@@ -10,6 +10,7 @@ import (
 
 	"oneclickvirt/global"
 	"oneclickvirt/model/system"
+	"oneclickvirt/service/database"
 
 	"go.uber.org/zap"
 )
@@ -35,9 +36,11 @@ func (s *MonitoringService) GetSystemStats() system.SystemStats {
 func (s *MonitoringService) CheckHealth() map[string]string {
 	return map[string]string{
 		"database": s.checkDatabaseHealth(),
-		"disk":     s.checkDiskHealth(),
-		"memory":   s.checkMemoryHealth(),
-		"status":   "healthy",
+		// 数据库熔断器状态：closed 正常，open/half-open 表示数据库持续故障，新任务快速失败
+		"databaseBreaker": database.GetDatabaseService().BreakerStatus().State,
+		"disk":            s.checkDiskHealth(),
+		"memory":          s.checkMemoryHealth(),
+		"status":          "healthy",
 	}
 }
 
@@ -3,6 +3,7 @@ package resources
 import (
 	"errors"
 	"fmt"
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	"oneclickvirt/model/admin"
 	"oneclickvirt/model/provider"
@@ -51,12 +52,18 @@ func (s *PortMappingService) GetPortMappingList(req admin.PortMappingListRequest
 	if req.InstanceID > 0 {
 		query = query.Where("instance_id = ?", req.InstanceID)
 	}
+	if len(req.ProviderIDs) > 0 {
+		query = query.Where("provider_id IN ?", req.ProviderIDs)
+	}
 	if req.Protocol != "" {
 		query = query.Where("protocol = ?", req.Protocol)
 	}
 	if req.Status != "" {
 		query = query.Where("status = ?", req.Status)
 	}
+	if req.ServiceType != "" {
+		query = query.Where("service_type = ?", req.ServiceType)
+	}
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -90,9 +97,9 @@ func (s *PortMappingService) CreatePortMappingWithTask(req admin.CreatePortMappi
 		return 0, nil, fmt.Errorf("Provider不存在")
 	}
 
-	// 只支持 LXD/Incus/Proxmox 手动添加端口
-	if providerInfo.Type != "lxd" && providerInfo.Type != "incus" && providerInfo.Type != "proxmox" {
-		return 0, nil, fmt.Errorf("不支持的 Provider 类型，手动添加端口仅支持 LXD/Incus/Proxmox")
+	// 只支持 LXD/Incus/Proxmox/LXC/Kubernetes 手动添加端口
+	if providerInfo.Type != "lxd" && providerInfo.Type != "incus" && providerInfo.Type != "proxmox" && providerInfo.Type != "lxc" && providerInfo.Type != "kubernetes" {
+		return 0, nil, fmt.Errorf("不支持的 Provider 类型，手动添加端口仅支持 LXD/Incus/Proxmox/LXC/Kubernetes")
 	}
 
 	// 检查是否为独立IPv4模式或纯IPv6模式
@@ -187,7 +194,7 @@ func (s *PortMappingService) CreatePortMappingWithTask(req admin.CreatePortMappi
 		Protocol:      req.Protocol,
 		Description:   req.Description,
 		Status:        "pending", // 初始状态为 pending
-		IsSSH:         req.GuestPort == 22,
+		IsSSH:         req.GuestPort == constant.GuestSSHPortOrDefault(instance.GuestSSHPort),
 		IsAutomatic:   false,
 		PortType:      "batch", // 标记为批量添加（即使是单个端口也用batch类型）
 		IPv6Enabled:   false,   // 手动添加的端口映射默认不启用IPv6
@@ -303,6 +310,15 @@ func (s *PortMappingService) CreateDefaultPortMappings(instanceID uint, provider
 		defaultPortCount = availablePortCount
 	}
 
+	// Windows 实例的登录端口为RDP，其余为实例内SSH端口
+	var instanceInfo provider.Instance
+	global.APP_DB.Select("os_type", "guest_ssh_port").Where("id = ?", instanceID).First(&instanceInfo)
+	loginGuestPort := constant.InstanceLoginPort(instanceInfo.OSType, instanceInfo.GuestSSHPort)
+	loginDescription := "SSH"
+	if constant.IsWindowsOSType(instanceInfo.OSType) {
+		loginDescription = "RDP"
+	}
+
 	// 使用事务确保端口分配的原子性，防止并发创建时的端口冲突
 	return global.APP_DB.Transaction(func(tx *gorm.DB) error {
 		var createdPorts []provider.Port
@@ -319,9 +335,9 @@ func (s *PortMappingService) CreateDefaultPortMappings(instanceID uint, provider
 			InstanceID:  instanceID,
 			ProviderID:  providerID,
 			HostPort:    sshHostPort,
-			GuestPort:   22,     // SSH端口固定为22
-			Protocol:    "both", // SSH 使用 TCP/UDP 通用协议
-			Description: "SSH",
+			GuestPort:   loginGuestPort, // Linux为实例内SSH端口，Windows为RDP(3389)
+			Protocol:    "both",         // SSH/RDP 使用 TCP/UDP 通用协议
+			Description: loginDescription,
 			Status:      "active",
 			IsSSH:       true,
 			IsAutomatic: true,
@@ -344,6 +360,10 @@ func (s *PortMappingService) CreateDefaultPortMappings(instanceID uint, provider
 			var portRecords []provider.Port
 			for i := 1; i < len(allocatedPorts); i++ {
 				port := allocatedPorts[i]
+				if port == loginGuestPort {
+					// 实例内登录端口已由第一个端口映射，跳过同号的1:1映射
+					continue
+				}
 				portRecord := provider.Port{
 					InstanceID:  instanceID,
 					ProviderID:  providerID,
@@ -1055,3 +1075,12 @@ func (s *PortMappingService) GetProviderPortUsage(providerID uint) (map[string]i
 		"enableIPv6":        providerInfo.NetworkType == "nat_ipv4_ipv6" || providerInfo.NetworkType == "dedicated_ipv4_ipv6" || providerInfo.NetworkType == "ipv6_only",
 	}, nil
 }
+
+// UpdatePortDescription 修改端口映射的用途描述，只更新数据库记录，不影响节点上的映射
+func (s *PortMappingService) UpdatePortDescription(instanceID, portID uint, description string) error {
+	var port provider.Port
+	if err := global.APP_DB.Select("id").Where("id = ? AND instance_id = ?", portID, instanceID).First(&port).Error; err != nil {
+		return err
+	}
+	return global.APP_DB.Model(&port).Update("description", strings.TrimSpace(description)).Error
+}
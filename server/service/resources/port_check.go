@@ -394,6 +394,7 @@ func (s *PortMappingService) createSSHClientForProvider(providerInfo *provider.P
 		Port:     providerInfo.SSHPort,
 		Username: providerInfo.Username,
 		Password: providerInfo.Password,
+		Guard:    utils.ProviderCommandGuard(providerInfo),
 	}
 
 	// 如果有SSH密钥，优先使用密钥
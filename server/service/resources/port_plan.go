@@ -0,0 +1,340 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/admin"
+	"oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// portBlockAllocMu 串行化端口块分配，避免并发请求选中同一空闲块
+var portBlockAllocMu sync.Mutex
+
+// portInterval 闭区间端口段
+type portInterval struct {
+	start int
+	end   int
+}
+
+// size 端口段包含的端口数
+func (p portInterval) size() int {
+	return p.end - p.start + 1
+}
+
+// portRecordInterval 端口映射记录占用的主机端口段
+func portRecordInterval(port provider.Port) portInterval {
+	end := port.HostPort
+	if port.HostPortEnd > port.HostPort {
+		end = port.HostPortEnd
+	}
+	return portInterval{start: port.HostPort, end: end}
+}
+
+// mergePortIntervals 排序并合并重叠或相邻的端口段
+func mergePortIntervals(intervals []portInterval) []portInterval {
+	if len(intervals) == 0 {
+		return nil
+	}
+	sorted := make([]portInterval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	merged := []portInterval{sorted[0]}
+	for _, iv := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if iv.start <= last.end+1 {
+			if iv.end > last.end {
+				last.end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// freePortIntervals 计算 [start, end] 内未被占用的端口段，used 须已合并
+func freePortIntervals(start, end int, used []portInterval) []portInterval {
+	free := make([]portInterval, 0)
+	cursor := start
+	for _, iv := range used {
+		if iv.end < cursor {
+			continue
+		}
+		if iv.start > end {
+			break
+		}
+		if iv.start > cursor {
+			free = append(free, portInterval{start: cursor, end: iv.start - 1})
+		}
+		cursor = iv.end + 1
+	}
+	if cursor <= end {
+		free = append(free, portInterval{start: cursor, end: end})
+	}
+	return free
+}
+
+// firstFitPortBlock 返回第一个能容纳 count 个连续端口的空闲段起始端口
+func firstFitPortBlock(free []portInterval, count int) (int, bool) {
+	for _, iv := range free {
+		if iv.size() >= count {
+			return iv.start, true
+		}
+	}
+	return 0, false
+}
+
+// loadOccupiedPorts 查询Provider已占用（生效中或创建中）的端口映射
+func (s *PortMappingService) loadOccupiedPorts(providerID uint) ([]provider.Port, error) {
+	var ports []provider.Port
+	err := global.APP_DB.Where("provider_id = ? AND status IN (?)", providerID, []string{"active", "pending"}).
+		Order("host_port ASC").
+		Find(&ports).Error
+	return ports, err
+}
+
+// providerAllocatableRanges 返回Provider的可分配端口段，未定义时使用整体端口映射范围
+func (s *PortMappingService) providerAllocatableRanges(providerInfo provider.Provider) ([]provider.ProviderPortRange, error) {
+	var ranges []provider.ProviderPortRange
+	if err := global.APP_DB.Where("provider_id = ?", providerInfo.ID).
+		Order("start_port ASC").
+		Find(&ranges).Error; err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 && providerInfo.PortRangeEnd >= providerInfo.PortRangeStart && providerInfo.PortRangeStart > 0 {
+		ranges = append(ranges, provider.ProviderPortRange{
+			ProviderID: providerInfo.ID,
+			StartPort:  providerInfo.PortRangeStart,
+			EndPort:    providerInfo.PortRangeEnd,
+		})
+	}
+	return ranges, nil
+}
+
+// ListProviderPortRanges 获取Provider定义的可分配端口段
+func (s *PortMappingService) ListProviderPortRanges(providerID uint) ([]provider.ProviderPortRange, error) {
+	var ranges []provider.ProviderPortRange
+	if err := global.APP_DB.Where("provider_id = ?", providerID).
+		Order("start_port ASC").
+		Find(&ranges).Error; err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+// CreateProviderPortRange 定义Provider可分配端口段
+// 端口段必须位于Provider端口映射范围之内，且不能与已定义的端口段重叠
+func (s *PortMappingService) CreateProviderPortRange(providerID uint, req admin.ProviderPortRangeRequest) (*provider.ProviderPortRange, error) {
+	if req.StartPort > req.EndPort {
+		return nil, fmt.Errorf("%w: 起始端口不能大于结束端口", ErrPortRangeValidation)
+	}
+
+	var providerInfo provider.Provider
+	if err := global.APP_DB.Where("id = ?", providerID).First(&providerInfo).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+	if req.StartPort < providerInfo.PortRangeStart || req.EndPort > providerInfo.PortRangeEnd {
+		return nil, fmt.Errorf("%w: 端口段 %d-%d 不在节点端口映射范围内 (%d-%d)",
+			ErrPortRangeValidation, req.StartPort, req.EndPort, providerInfo.PortRangeStart, providerInfo.PortRangeEnd)
+	}
+
+	var overlapCount int64
+	if err := global.APP_DB.Model(&provider.ProviderPortRange{}).
+		Where("provider_id = ? AND start_port <= ? AND end_port >= ?", providerID, req.EndPort, req.StartPort).
+		Count(&overlapCount).Error; err != nil {
+		return nil, fmt.Errorf("检查端口段重叠失败: %v", err)
+	}
+	if overlapCount > 0 {
+		return nil, fmt.Errorf("%w: 端口段 %d-%d 与已定义的端口段重叠", ErrPortRangeValidation, req.StartPort, req.EndPort)
+	}
+
+	portRange := &provider.ProviderPortRange{
+		ProviderID:  providerID,
+		StartPort:   req.StartPort,
+		EndPort:     req.EndPort,
+		Description: req.Description,
+	}
+	if err := global.APP_DB.Create(portRange).Error; err != nil {
+		return nil, fmt.Errorf("创建端口段失败: %v", err)
+	}
+
+	global.APP_LOG.Info("定义Provider可分配端口段",
+		zap.Uint("providerID", providerID),
+		zap.Int("startPort", req.StartPort),
+		zap.Int("endPort", req.EndPort))
+	return portRange, nil
+}
+
+// DeleteProviderPortRange 删除Provider可分配端口段，不影响已分配的端口映射
+func (s *PortMappingService) DeleteProviderPortRange(providerID, rangeID uint) error {
+	result := global.APP_DB.Where("id = ? AND provider_id = ?", rangeID, providerID).
+		Delete(&provider.ProviderPortRange{})
+	if result.Error != nil {
+		return fmt.Errorf("删除端口段失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("端口段不存在")
+	}
+	return nil
+}
+
+// GetProviderPortPlan 获取Provider各端口段的占用、空闲块和碎片情况，用于端口规划可视化
+func (s *PortMappingService) GetProviderPortPlan(providerID uint) (*admin.ProviderPortPlanResponse, error) {
+	var providerInfo provider.Provider
+	if err := global.APP_DB.Where("id = ?", providerID).First(&providerInfo).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+
+	ranges, err := s.providerAllocatableRanges(providerInfo)
+	if err != nil {
+		return nil, fmt.Errorf("获取端口段失败: %v", err)
+	}
+	ports, err := s.loadOccupiedPorts(providerID)
+	if err != nil {
+		return nil, fmt.Errorf("获取端口映射失败: %v", err)
+	}
+
+	usedIntervals := make([]portInterval, 0, len(ports))
+	for _, port := range ports {
+		usedIntervals = append(usedIntervals, portRecordInterval(port))
+	}
+	merged := mergePortIntervals(usedIntervals)
+
+	resp := &admin.ProviderPortPlanResponse{
+		ProviderID:     providerID,
+		PortRangeStart: providerInfo.PortRangeStart,
+		PortRangeEnd:   providerInfo.PortRangeEnd,
+		Ranges:         make([]admin.PortRangePlan, 0, len(ranges)),
+	}
+
+	for _, r := range ranges {
+		plan := admin.PortRangePlan{
+			RangeID:     r.ID,
+			StartPort:   r.StartPort,
+			EndPort:     r.EndPort,
+			Description: r.Description,
+			TotalPorts:  r.EndPort - r.StartPort + 1,
+			Segments:    make([]admin.PortPlanSegment, 0),
+		}
+
+		// 占用段：按端口映射记录展示，裁剪到端口段范围内
+		for _, port := range ports {
+			iv := portRecordInterval(port)
+			if iv.end < r.StartPort || iv.start > r.EndPort {
+				continue
+			}
+			plan.Segments = append(plan.Segments, admin.PortPlanSegment{
+				StartPort:  max(iv.start, r.StartPort),
+				EndPort:    min(iv.end, r.EndPort),
+				Status:     "used",
+				PortID:     port.ID,
+				InstanceID: port.InstanceID,
+			})
+		}
+
+		freeIntervals := freePortIntervals(r.StartPort, r.EndPort, merged)
+		for _, iv := range freeIntervals {
+			plan.FreePorts += iv.size()
+			plan.LargestFreeBlock = max(plan.LargestFreeBlock, iv.size())
+			plan.Segments = append(plan.Segments, admin.PortPlanSegment{
+				StartPort: iv.start,
+				EndPort:   iv.end,
+				Status:    "free",
+			})
+		}
+		sort.SliceStable(plan.Segments, func(i, j int) bool {
+			return plan.Segments[i].StartPort < plan.Segments[j].StartPort
+		})
+
+		plan.FreeBlocks = len(freeIntervals)
+		plan.UsedPorts = plan.TotalPorts - plan.FreePorts
+		if plan.TotalPorts > 0 {
+			plan.UsageRate = float64(plan.UsedPorts) / float64(plan.TotalPorts) * 100
+		}
+		if plan.FreePorts > 0 {
+			plan.Fragmentation = (1 - float64(plan.LargestFreeBlock)/float64(plan.FreePorts)) * 100
+		}
+		resp.Ranges = append(resp.Ranges, plan)
+	}
+
+	return resp, nil
+}
+
+// AllocatePortBlock 在Provider的可分配端口段中为实例分配连续端口块
+// 按端口段顺序首次适配选出空闲块，再通过 CreatePortMappingWithTask 创建端口段映射
+func (s *PortMappingService) AllocatePortBlock(req admin.AllocatePortBlockRequest) (uint, *admin.CreatePortMappingTaskRequest, error) {
+	var instance provider.Instance
+	if err := global.APP_DB.Where("id = ?", req.InstanceID).First(&instance).Error; err != nil {
+		return 0, nil, fmt.Errorf("实例不存在")
+	}
+	var providerInfo provider.Provider
+	if err := global.APP_DB.Where("id = ?", instance.ProviderID).First(&providerInfo).Error; err != nil {
+		return 0, nil, fmt.Errorf("Provider不存在")
+	}
+
+	portBlockAllocMu.Lock()
+	defer portBlockAllocMu.Unlock()
+
+	ranges, err := s.providerAllocatableRanges(providerInfo)
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取端口段失败: %v", err)
+	}
+	if req.RangeID != 0 {
+		filtered := ranges[:0]
+		for _, r := range ranges {
+			if r.ID == req.RangeID {
+				filtered = append(filtered, r)
+			}
+		}
+		if len(filtered) == 0 {
+			return 0, nil, fmt.Errorf("%w: 端口段不存在或不属于该实例所在节点", ErrPortRangeValidation)
+		}
+		ranges = filtered
+	}
+
+	ports, err := s.loadOccupiedPorts(providerInfo.ID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取端口映射失败: %v", err)
+	}
+	usedIntervals := make([]portInterval, 0, len(ports))
+	for _, port := range ports {
+		usedIntervals = append(usedIntervals, portRecordInterval(port))
+	}
+	merged := mergePortIntervals(usedIntervals)
+
+	hostPort := 0
+	for _, r := range ranges {
+		if start, ok := firstFitPortBlock(freePortIntervals(r.StartPort, r.EndPort, merged), req.PortCount); ok {
+			hostPort = start
+			break
+		}
+	}
+	if hostPort == 0 {
+		return 0, nil, fmt.Errorf("%w: 可分配端口段中没有 %d 个连续空闲端口", ErrPortRangeValidation, req.PortCount)
+	}
+
+	portID, taskData, err := s.CreatePortMappingWithTask(admin.CreatePortMappingRequest{
+		InstanceID:  req.InstanceID,
+		GuestPort:   req.GuestPort,
+		PortCount:   req.PortCount,
+		Protocol:    req.Protocol,
+		Description: req.Description,
+		HostPort:    hostPort,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	global.APP_LOG.Info("为实例分配连续端口块",
+		zap.Uint("instanceID", req.InstanceID),
+		zap.Uint("providerID", providerInfo.ID),
+		zap.Int("hostPort", hostPort),
+		zap.Int("portCount", req.PortCount))
+	return portID, taskData, nil
+}
@@ -0,0 +1,37 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortIntervalPlanning(t *testing.T) {
+	// 重叠与相邻的端口段应合并
+	used := mergePortIntervals([]portInterval{
+		{start: 20010, end: 20019},
+		{start: 20000, end: 20004},
+		{start: 20005, end: 20005},
+		{start: 20015, end: 20030},
+	})
+	wantUsed := []portInterval{{start: 20000, end: 20005}, {start: 20010, end: 20030}}
+	if !reflect.DeepEqual(used, wantUsed) {
+		t.Fatalf("mergePortIntervals = %v, want %v", used, wantUsed)
+	}
+
+	free := freePortIntervals(20000, 20100, used)
+	wantFree := []portInterval{{start: 20006, end: 20009}, {start: 20031, end: 20100}}
+	if !reflect.DeepEqual(free, wantFree) {
+		t.Fatalf("freePortIntervals = %v, want %v", free, wantFree)
+	}
+
+	// 首次适配：4个端口可放入第一个空隙，5个端口需跳到后面的空闲段
+	if start, ok := firstFitPortBlock(free, 4); !ok || start != 20006 {
+		t.Errorf("firstFitPortBlock(4) = %d, %v, want 20006, true", start, ok)
+	}
+	if start, ok := firstFitPortBlock(free, 5); !ok || start != 20031 {
+		t.Errorf("firstFitPortBlock(5) = %d, %v, want 20031, true", start, ok)
+	}
+	if _, ok := firstFitPortBlock(free, 71); ok {
+		t.Error("firstFitPortBlock(71) should fail")
+	}
+}
@@ -17,6 +17,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrRunningLimitExceeded 同时运行的实例数已达用户等级上限
+var ErrRunningLimitExceeded = errors.New("同时运行的实例数已达上限")
+
 // QuotaService 资源配额验证服务
 type QuotaService struct {
 	dbService *database.DatabaseService // 数据库服务
@@ -46,6 +49,8 @@ type QuotaCheckResult struct {
 	Reason            string
 	CurrentInstances  int
 	MaxInstances      int
+	RunningInstances  int           // 同时运行（含启动中、创建中）的实例数
+	MaxRunning        int           // 可同时运行的实例数上限
 	CurrentResources  ResourceUsage // 已确认使用的资源（稳定状态）
 	PendingResources  ResourceUsage // 待确认的资源（创建中/重置中）
 	MaxResources      ResourceUsage
@@ -129,6 +134,10 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 		}, nil
 	}
 
+	// 运行数上限只按用户等级计算，合并Provider限制后不再保留
+	runningLimit := levelLimits.RunningLimit()
+	limitRunning := runningLimit < levelLimits.MaxInstances
+
 	// 如果提供了 ProviderID，需要获取并合并 Provider 的等级限制
 	var providerLevelLimits *config.LevelLimitInfo
 	var prov *provider.Provider
@@ -195,6 +204,21 @@ func (s *QuotaService) validateInTransaction(tx *gorm.DB, req ResourceRequest) (
 		return result, nil
 	}
 
+	// 1.2 新实例创建后会自动运行，单独配置了运行数上限时检查同时运行的实例数
+	if limitRunning {
+		runningInstances, err := s.countRunningInstances(tx, req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("获取运行中实例数量失败: %v", err)
+		}
+		result.RunningInstances = runningInstances
+		result.MaxRunning = runningLimit
+		if runningInstances >= runningLimit {
+			result.Allowed = false
+			result.Reason = fmt.Sprintf("同时运行的实例数已达上限：当前 %d/%d，新实例创建后会自动运行，请先停止其他实例", runningInstances, runningLimit)
+			return result, nil
+		}
+	}
+
 	// 1.5 如果有 Provider 限制，还需要检查用户在该节点的实例数量
 	if req.ProviderID > 0 && providerLevelLimits != nil && providerLevelLimits.MaxInstances > 0 {
 		// 这里使用的是合并前的 providerLevelLimits，因为要检查节点本身的限制
@@ -354,6 +378,35 @@ func (s *QuotaService) getCurrentProviderInstanceCount(tx *gorm.DB, userID uint,
 	return int(count), nil
 }
 
+// countRunningInstances 统计用户同时运行（含启动中、创建中等即将运行）的实例数
+func (s *QuotaService) countRunningInstances(db *gorm.DB, userID uint) (int, error) {
+	var count int64
+	err := db.Model(&provider.Instance{}).
+		Where("user_id = ? AND status IN ?", userID, constant.GetRunningCountableStatuses()).
+		Count(&count).Error
+	return int(count), err
+}
+
+// CheckRunningLimit 检查用户是否还能再运行一个实例，超出等级的同时运行上限时返回错误
+func (s *QuotaService) CheckRunningLimit(userID uint) error {
+	var u user.User
+	if err := global.APP_DB.Select("id", "level").First(&u, userID).Error; err != nil {
+		return fmt.Errorf("用户不存在: %v", err)
+	}
+	levelLimits, exists := global.APP_CONFIG.Quota.LevelLimits[u.Level]
+	if !exists || levelLimits.RunningLimit() >= levelLimits.MaxInstances {
+		return nil
+	}
+	running, err := s.countRunningInstances(global.APP_DB, userID)
+	if err != nil {
+		return fmt.Errorf("获取运行中实例数量失败: %v", err)
+	}
+	if limit := levelLimits.RunningLimit(); running >= limit {
+		return fmt.Errorf("%w：当前 %d/%d，请先停止其他实例", ErrRunningLimitExceeded, running, limit)
+	}
+	return nil
+}
+
 // GetCurrentResourceUsageInTx 公开方法：在事务中获取当前资源使用情况
 func (s *QuotaService) GetCurrentResourceUsageInTx(tx *gorm.DB, userID uint) (int, ResourceUsage, error) {
 	return s.getCurrentResourceUsage(tx, userID)
@@ -604,6 +657,11 @@ func (s *QuotaService) GetUserQuotaInfo(userID uint) (*QuotaCheckResult, error)
 		return nil, fmt.Errorf("获取当前资源使用情况失败: %v", err)
 	}
 
+	runningInstances, err := s.countRunningInstances(global.APP_DB, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取运行中实例数量失败: %v", err)
+	}
+
 	maxResources := s.GetLevelMaxResources(levelLimits)
 
 	return &QuotaCheckResult{
@@ -611,6 +669,8 @@ func (s *QuotaService) GetUserQuotaInfo(userID uint) (*QuotaCheckResult, error)
 		Reason:           "配额信息查询成功",
 		CurrentInstances: currentInstances,
 		MaxInstances:     levelLimits.MaxInstances,
+		RunningInstances: runningInstances,
+		MaxRunning:       levelLimits.RunningLimit(),
 		CurrentResources: currentResources,
 		MaxResources:     maxResources,
 		MaxQuota:         maxResources, // 设置MaxQuota
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"oneclickvirt/global"
-	dashboardModel "oneclickvirt/model/dashboard"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/model/resource"
 	"oneclickvirt/utils"
@@ -83,7 +82,7 @@ func (s *ResourceService) CheckProviderResourcesWithTx(tx *gorm.DB, req resource
 		return nil, fmt.Errorf("Provider不存在: %v", err)
 	}
 
-	result := s.checkProviderResourceAvailability(&provider, req)
+	result := s.checkProviderResourceAvailability(tx, &provider, req)
 
 	if result.Allowed {
 		global.APP_LOG.Debug("事务中资源检查通过",
@@ -111,12 +110,12 @@ func (s *ResourceService) checkResourcesInTransaction(req resource.ResourceCheck
 		return nil, fmt.Errorf("Provider不存在: %v", err)
 	}
 
-	result := s.checkProviderResourceAvailability(&provider, req)
+	result := s.checkProviderResourceAvailability(global.APP_DB, &provider, req)
 	return result, nil
 }
 
 // checkProviderResourceAvailability 检查Provider资源可用性
-func (s *ResourceService) checkProviderResourceAvailability(provider *providerModel.Provider, req resource.ResourceCheckRequest) *resource.ResourceCheckResult {
+func (s *ResourceService) checkProviderResourceAvailability(db *gorm.DB, provider *providerModel.Provider, req resource.ResourceCheckRequest) *resource.ResourceCheckResult {
 	result := &resource.ResourceCheckResult{
 		Allowed: true,
 	}
@@ -134,12 +133,7 @@ func (s *ResourceService) checkProviderResourceAvailability(provider *providerMo
 		return result
 	}
 
-	// 计算可用资源（考虑Provider的资源限制配置）
-	// 如果资源类型配置为不限制（false），则不计入总量，允许超分配
-	availableCPU := provider.NodeCPUCores - provider.UsedCPUCores
-	availableMemory := provider.NodeMemoryTotal - provider.UsedMemory
-	availableDisk := provider.NodeDiskTotal - provider.UsedDisk
-
+	availableCPU, availableMemory, availableDisk := s.availableResources(db, provider, req.InstanceType)
 	result.AvailableCPU = availableCPU
 	result.AvailableMemory = availableMemory
 	result.AvailableDisk = availableDisk
@@ -206,6 +200,29 @@ func (s *ResourceService) checkProviderResourceAvailability(provider *providerMo
 	return result
 }
 
+// availableResources 计算指定实例类型在Provider上的剩余可用CPU、内存和磁盘
+func (s *ResourceService) availableResources(db *gorm.DB, provider *providerModel.Provider, instanceType string) (int, int64, int64) {
+	// 计算可用资源（考虑Provider的资源限制配置）
+	// 如果资源类型配置为不限制（false），则不计入总量，允许超分配
+	// 系统预留资源不计入可分配资源池
+	availableCPU := provider.AllocatableCPUCores() - provider.UsedCPUCores
+	availableMemory := provider.AllocatableMemory() - provider.UsedMemory
+	availableDisk := provider.AllocatableDisk() - provider.UsedDisk
+
+	// 配置了超售比例时，按实例类型将已售资源折算为物理占用后计算剩余可售量
+	if provider.HasOvercommit() {
+		cpu, memory, err := s.overcommitAvailability(db, provider, instanceType)
+		if err != nil {
+			global.APP_LOG.Warn("计算超售可用资源失败，按未超售计算",
+				zap.Uint("providerId", provider.ID),
+				zap.Error(err))
+		} else {
+			availableCPU, availableMemory = cpu, memory
+		}
+	}
+	return availableCPU, availableMemory, availableDisk
+}
+
 // AllocateResourcesInTx 在事务中分配资源（不创建新事务，使用悲观锁）
 // 根据Provider的资源限制配置决定是否扣减资源
 func (s *ResourceService) AllocateResourcesInTx(tx *gorm.DB, providerID uint, instanceType string, cpu int, memory, disk int64) error {
@@ -447,48 +464,22 @@ func (s *ResourceService) SyncProviderResources(providerID uint) error {
 			return fmt.Errorf("Provider不存在: %v", err)
 		}
 
-		// 统计当前实例资源使用
-		var stats dashboardModel.ResourceUsageStats
-
-		// 统计虚拟机资源（排除deleted、deleting、failed状态）
-		err := tx.Model(&providerModel.Instance{}).
-			Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
-				providerID, "vm", []string{"deleted", "deleting", "failed"}).
-			Select("COUNT(*) as vm_count, COALESCE(SUM(cpu), 0) as used_cpu_cores, COALESCE(SUM(memory), 0) as used_memory, COALESCE(SUM(disk), 0) as used_disk").
-			Scan(&stats).Error
-		if err != nil {
-			return fmt.Errorf("统计虚拟机资源失败: %v", err)
-		}
-
-		vmCPU := stats.UsedCPUCores
-		vmMemory := stats.UsedMemory
-		vmDisk := stats.UsedDisk
-		vmCount := stats.VMCount
-
-		// 统计容器资源（排除deleted、deleting、failed状态）
-		err = tx.Model(&providerModel.Instance{}).
-			Where("provider_id = ? AND instance_type = ? AND status NOT IN (?)",
-				providerID, "container", []string{"deleted", "deleting", "failed"}).
-			Select("COUNT(*) as container_count, COALESCE(SUM(memory), 0) as used_memory, COALESCE(SUM(disk), 0) as used_disk").
-			Scan(&stats).Error
+		// 统计当前实例资源使用（与资源分配的计入规则一致）
+		usage, err := calculateProviderUsage(tx, &provider)
 		if err != nil {
-			return fmt.Errorf("统计容器资源失败: %v", err)
+			return err
 		}
 
-		containerMemory := stats.UsedMemory
-		containerDisk := stats.UsedDisk
-		containerCount := stats.ContainerCount
-
 		// 设置缓存过期时间（5分钟后）
 		cacheExpiry := time.Now().Add(5 * time.Minute)
 
 		// 更新Provider资源统计
-		totalInstances := int(vmCount + containerCount)
-		availableCPU := provider.NodeCPUCores - int(vmCPU)
+		totalInstances := int(usage.VMCount + usage.ContainerCount)
+		availableCPU := provider.AllocatableCPUCores() - int(usage.UsedCPUCores)
 		if availableCPU < 0 {
 			availableCPU = 0
 		}
-		availableMemory := provider.NodeMemoryTotal - (vmMemory + containerMemory)
+		availableMemory := provider.AllocatableMemory() - usage.UsedMemory
 		if availableMemory < 0 {
 			availableMemory = 0
 		}
@@ -512,11 +503,11 @@ func (s *ResourceService) SyncProviderResources(providerID uint) error {
 
 		now := time.Now()
 		updates := map[string]interface{}{
-			"used_cpu_cores":      int(vmCPU), // 只有虚拟机占用CPU核心
-			"used_memory":         vmMemory + containerMemory,
-			"used_disk":           vmDisk + containerDisk,
-			"vm_count":            int(vmCount),
-			"container_count":     int(containerCount),
+			"used_cpu_cores":      int(usage.UsedCPUCores),
+			"used_memory":         usage.UsedMemory,
+			"used_disk":           usage.UsedDisk,
+			"vm_count":            int(usage.VMCount),
+			"container_count":     int(usage.ContainerCount),
 			"available_cpu_cores": availableCPU,
 			"available_memory":    availableMemory,
 			"used_instances":      totalInstances,
@@ -548,18 +539,21 @@ func (s *ResourceService) GetProviderResourceStatus(providerID uint) (map[string
 		"resources": map[string]interface{}{
 			"cpu": map[string]interface{}{
 				"total":     provider.NodeCPUCores,
+				"reserved":  provider.ReservedCPUCores(),
 				"used":      provider.UsedCPUCores,
-				"available": provider.NodeCPUCores - provider.UsedCPUCores,
+				"available": provider.AllocatableCPUCores() - provider.UsedCPUCores,
 			},
 			"memory": map[string]interface{}{
 				"total":     provider.NodeMemoryTotal,
+				"reserved":  provider.SystemReservedMemory,
 				"used":      provider.UsedMemory,
-				"available": provider.NodeMemoryTotal - provider.UsedMemory,
+				"available": provider.AllocatableMemory() - provider.UsedMemory,
 			},
 			"disk": map[string]interface{}{
 				"total":     provider.NodeDiskTotal,
+				"reserved":  provider.ReservedDisk(),
 				"used":      provider.UsedDisk,
-				"available": provider.NodeDiskTotal - provider.UsedDisk,
+				"available": provider.AllocatableDisk() - provider.UsedDisk,
 			},
 		},
 		"instances": map[string]interface{}{
@@ -567,8 +561,13 @@ func (s *ResourceService) GetProviderResourceStatus(providerID uint) (map[string
 			"vms":        provider.VMCount,
 			"total":      provider.ContainerCount + provider.VMCount,
 		},
-		"resourceSynced":   provider.ResourceSynced,
-		"resourceSyncedAt": provider.ResourceSyncedAt,
+		"resourceSynced":        provider.ResourceSynced,
+		"resourceSyncedAt":      provider.ResourceSyncedAt,
+		"systemReservedAlert":   provider.SystemReservedAlert,
+		"systemReservedAlertAt": provider.SystemReservedAlertAt,
+		"hardwareAlert":         provider.HardwareAlert,
+		"hardwareAlertAt":       provider.HardwareAlertAt,
+		"hardwareCheckedAt":     provider.HardwareCheckedAt,
 	}
 
 	return status, nil
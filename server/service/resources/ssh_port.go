@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"math/rand"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/model/provider"
+)
+
+// 随机实例内SSH端口的取值范围，避开特权端口
+const (
+	randomGuestSSHPortMin = 1025
+	randomGuestSSHPortMax = 65535
+)
+
+// ResolveGuestSSHPort 按Provider配置为新实例确定实例内sshd监听的端口
+// 开启随机端口时在Provider端口映射范围之外随机选择，避免与区间映射的1:1端口冲突
+// Proxmox虚拟机由cloud-init镜像自行管理sshd，无法在创建后改写端口，固定使用22
+func ResolveGuestSSHPort(providerInfo *provider.Provider, instanceType string) int {
+	if providerInfo.Type == "proxmox" && instanceType == "vm" {
+		return constant.SSHGuestPort
+	}
+	if providerInfo.RandomGuestSSHPort {
+		if port := randomGuestSSHPort(providerInfo.PortRangeStart, providerInfo.PortRangeEnd, rand.Intn); port > 0 {
+			return port
+		}
+	}
+	return constant.GuestSSHPortOrDefault(providerInfo.GuestSSHPort)
+}
+
+// randomGuestSSHPort 在 [randomGuestSSHPortMin, randomGuestSSHPortMax] 中排除 [excludeStart, excludeEnd] 后随机选择端口
+// 可选端口为空时返回0
+func randomGuestSSHPort(excludeStart, excludeEnd int, intn func(int) int) int {
+	if excludeStart > excludeEnd {
+		excludeStart, excludeEnd = excludeEnd, excludeStart
+	}
+	lowStart, lowEnd := randomGuestSSHPortMin, min(excludeStart-1, randomGuestSSHPortMax)
+	highStart, highEnd := max(excludeEnd+1, randomGuestSSHPortMin), randomGuestSSHPortMax
+
+	lowCount := max(lowEnd-lowStart+1, 0)
+	highCount := max(highEnd-highStart+1, 0)
+	if lowCount+highCount == 0 {
+		return 0
+	}
+
+	n := intn(lowCount + highCount)
+	if n < lowCount {
+		return lowStart + n
+	}
+	return highStart + n - lowCount
+}
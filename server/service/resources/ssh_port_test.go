@@ -0,0 +1,29 @@
+package resources
+
+import "testing"
+
+func TestRandomGuestSSHPort(t *testing.T) {
+	first := func(int) int { return 0 }
+	last := func(n int) int { return n - 1 }
+
+	// 默认端口范围 10000-65535，只能在 1025-9999 中选择
+	if got := randomGuestSSHPort(10000, 65535, first); got != 1025 {
+		t.Errorf("first = %d, want 1025", got)
+	}
+	if got := randomGuestSSHPort(10000, 65535, last); got != 9999 {
+		t.Errorf("last = %d, want 9999", got)
+	}
+
+	// 端口范围在中间时，两侧都可以选择
+	if got := randomGuestSSHPort(20000, 30000, func(int) int { return 20000 - 1025 }); got != 30001 {
+		t.Errorf("high side = %d, want 30001", got)
+	}
+	if got := randomGuestSSHPort(20000, 30000, last); got != 65535 {
+		t.Errorf("last = %d, want 65535", got)
+	}
+
+	// 端口范围覆盖全部可选端口
+	if got := randomGuestSSHPort(1024, 65535, first); got != 0 {
+		t.Errorf("no candidates = %d, want 0", got)
+	}
+}
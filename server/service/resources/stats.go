@@ -2,8 +2,9 @@ package resources
 
 import (
 	"oneclickvirt/global"
-	"oneclickvirt/model/provider"
+	"oneclickvirt/model/dashboard"
 	"oneclickvirt/model/user"
+	"oneclickvirt/service/counters"
 
 	"go.uber.org/zap"
 )
@@ -12,53 +13,29 @@ type SystemStatsService struct{}
 
 // GetInstanceStats 获取实例统计信息
 func (s *SystemStatsService) GetInstanceStats() (map[string]interface{}, error) {
-	var total, running, stopped int64
-
-	// 统计总实例数
-	if err := global.APP_DB.Model(&provider.Instance{}).Where("soft_deleted = ?", false).Count(&total).Error; err != nil {
-		return nil, err
-	}
-
-	// 统计运行中的实例
-	if err := global.APP_DB.Model(&provider.Instance{}).Where("soft_deleted = ? AND status = ?", false, "running").Count(&running).Error; err != nil {
-		return nil, err
-	}
-
-	// 统计已停止的实例
-	if err := global.APP_DB.Model(&provider.Instance{}).Where("soft_deleted = ? AND status = ?", false, "stopped").Count(&stopped).Error; err != nil {
+	instanceCounts, err := counters.Instances()
+	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"total":   total,
-		"running": running,
-		"stopped": stopped,
+		"total":   counters.CountInstances(instanceCounts, nil),
+		"running": counters.CountInstances(instanceCounts, func(c dashboard.InstanceCounter) bool { return c.Status == "running" }),
+		"stopped": counters.CountInstances(instanceCounts, func(c dashboard.InstanceCounter) bool { return c.Status == "stopped" }),
 	}, nil
 }
 
 // GetUserStats 获取用户统计信息
 func (s *SystemStatsService) GetUserStats() (map[string]interface{}, error) {
-	var totalUsers, activeUsers, disabledUsers int64
-
-	// 统计总用户数
-	if err := global.APP_DB.Model(&user.User{}).Count(&totalUsers).Error; err != nil {
-		return nil, err
-	}
-
-	// 统计活跃用户数
-	if err := global.APP_DB.Model(&user.User{}).Where("status = ?", 1).Count(&activeUsers).Error; err != nil {
-		return nil, err
-	}
-
-	// 统计禁用用户数
-	if err := global.APP_DB.Model(&user.User{}).Where("status = ?", 0).Count(&disabledUsers).Error; err != nil {
+	userCounts, err := counters.Users()
+	if err != nil {
 		return nil, err
 	}
 
 	return map[string]interface{}{
-		"total":    totalUsers,
-		"active":   activeUsers,
-		"disabled": disabledUsers,
+		"total":    counters.CountUsers(userCounts, nil),
+		"active":   counters.CountUsers(userCounts, func(c dashboard.UserCounter) bool { return c.Status == 1 }),
+		"disabled": counters.CountUsers(userCounts, func(c dashboard.UserCounter) bool { return c.Status == 0 }),
 	}, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
 	userModel "oneclickvirt/model/user"
 	"oneclickvirt/service/cache"
@@ -57,6 +58,7 @@ func (s *UserDashboardService) fetchUserDashboard(userID uint) (*userModel.UserD
 		StoppedInstances int64
 		Containers       int64
 		VMs              int64
+		EOLInstances     int64
 	}
 
 	var stats InstanceStats
@@ -67,12 +69,13 @@ func (s *UserDashboardService) fetchUserDashboard(userID uint) (*userModel.UserD
 			SUM(CASE WHEN status = 'running' THEN 1 ELSE 0 END) as running_instances,
 			SUM(CASE WHEN status = 'stopped' THEN 1 ELSE 0 END) as stopped_instances,
 			SUM(CASE WHEN instance_type = 'container' AND status NOT IN ('deleting', 'deleted', 'failed') THEN 1 ELSE 0 END) as containers,
-			SUM(CASE WHEN instance_type = 'vm' AND status NOT IN ('deleting', 'deleted', 'failed') THEN 1 ELSE 0 END) as vms
+			SUM(CASE WHEN instance_type = 'vm' AND status NOT IN ('deleting', 'deleted', 'failed') THEN 1 ELSE 0 END) as vms,
+			SUM(CASE WHEN os_eol_date IS NOT NULL AND os_eol_date <= ? THEN 1 ELSE 0 END) as eol_instances
 		FROM instances
 		WHERE user_id = ? 
 		  AND deleted_at IS NULL
 		  AND status NOT IN ('deleting', 'deleted', 'failed')
-	`, userID).Scan(&stats).Error
+	`, time.Now(), userID).Scan(&stats).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("统计用户实例失败: %v", err)
@@ -129,6 +132,22 @@ func (s *UserDashboardService) fetchUserDashboard(userID uint) (*userModel.UserD
 	dashboard.Instances.Stopped = int(stats.StoppedInstances)
 	dashboard.Instances.Containers = int(stats.Containers)
 	dashboard.Instances.VMs = int(stats.VMs)
+	dashboard.Instances.EOL = int(stats.EOLInstances)
+
+	// 按分组统计实例数量
+	groups, err := s.fetchGroupSummaries(userID)
+	if err != nil {
+		return nil, err
+	}
+	dashboard.Groups = groups
+
+	// 当月流量用量预测，未启用预测或用户无流量配额时没有记录
+	var trafficForecast monitoringModel.UsageForecast
+	if err := global.APP_DB.Where("kind = ? AND subject_id = ? AND metric = ?",
+		monitoringModel.ForecastKindUser, userID, monitoringModel.ForecastMetricTraffic).
+		Limit(1).Find(&trafficForecast).Error; err == nil && trafficForecast.ID != 0 {
+		dashboard.TrafficForecast = &trafficForecast
+	}
 
 	// 详细的资源使用信息（只包含实际实例，不包含临时预留）
 	dashboard.ResourceUsage = &userModel.ResourceUsageInfo{
@@ -145,6 +164,53 @@ func (s *UserDashboardService) fetchUserDashboard(userID uint) (*userModel.UserD
 	return dashboard, nil
 }
 
+// fetchGroupSummaries 统计用户每个实例分组的实例数量和运行数量
+func (s *UserDashboardService) fetchGroupSummaries(userID uint) ([]userModel.InstanceGroupSummary, error) {
+	var groups []userModel.InstanceGroup
+	if err := global.APP_DB.Select("id, name").Where("user_id = ?", userID).Order("sort ASC, id ASC").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("查询实例分组失败: %v", err)
+	}
+	summaries := make([]userModel.InstanceGroupSummary, 0, len(groups))
+	if len(groups) == 0 {
+		return summaries, nil
+	}
+
+	type groupStats struct {
+		GroupID uint
+		Total   int
+		Running int
+	}
+	var stats []groupStats
+	if err := global.APP_DB.Raw(`
+		SELECT group_id,
+			COUNT(*) as total,
+			SUM(CASE WHEN status = 'running' THEN 1 ELSE 0 END) as running
+		FROM instances
+		WHERE user_id = ?
+		  AND group_id > 0
+		  AND deleted_at IS NULL
+		  AND status NOT IN ('deleting', 'deleted', 'failed')
+		GROUP BY group_id
+	`, userID).Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("统计分组实例失败: %v", err)
+	}
+	statsByGroup := make(map[uint]groupStats, len(stats))
+	for _, st := range stats {
+		statsByGroup[st.GroupID] = st
+	}
+
+	for _, group := range groups {
+		st := statsByGroup[group.ID]
+		summaries = append(summaries, userModel.InstanceGroupSummary{
+			ID:      group.ID,
+			Name:    group.Name,
+			Total:   st.Total,
+			Running: st.Running,
+		})
+	}
+	return summaries, nil
+}
+
 // GetUserLimits 获取用户资源限制
 // 注意：资源统计中不包含预留资源，预留是临时的防并发机制
 // 用户实际使用量仅统计已创建的实例，不包含临时预留
@@ -224,22 +290,30 @@ func (s *UserDashboardService) GetUserLimits(userID uint) (*userModel.UserLimits
 		usedTrafficMB = int64(monthlyTrafficStats.ActualUsageMB)
 	}
 
+	runningInstances, err := quotaService.countRunningInstances(global.APP_DB, userID)
+	if err != nil {
+		return nil, fmt.Errorf("统计运行中实例失败: %v", err)
+	}
+
 	response := &userModel.UserLimitsResponse{
-		Level:          user.Level,
-		MaxInstances:   levelLimits.MaxInstances,
-		UsedInstances:  usedInstances,
-		ContainerCount: containerCount,
-		VMCount:        vmCount,
-		MaxCpu:         maxResources.CPU,
-		UsedCpu:        usedCPU,
-		MaxMemory:      int(maxResources.Memory),
-		UsedMemory:     usedMemory,
-		MaxDisk:        int(maxResources.Disk),
-		UsedDisk:       usedDisk,
-		MaxBandwidth:   maxResources.Bandwidth,
-		UsedBandwidth:  usedBandwidth,
-		MaxTraffic:     levelLimits.MaxTraffic, // 使用等级配置的流量限制
-		UsedTraffic:    usedTrafficMB,          // 使用实时查询的流量数据
+		Level:            user.Level,
+		MaxInstances:     levelLimits.MaxInstances,
+		UsedInstances:    usedInstances,
+		MaxRunning:       levelLimits.RunningLimit(),
+		MaxSnapshots:     levelLimits.MaxSnapshots,
+		RunningInstances: runningInstances,
+		ContainerCount:   containerCount,
+		VMCount:          vmCount,
+		MaxCpu:           maxResources.CPU,
+		UsedCpu:          usedCPU,
+		MaxMemory:        int(maxResources.Memory),
+		UsedMemory:       usedMemory,
+		MaxDisk:          int(maxResources.Disk),
+		UsedDisk:         usedDisk,
+		MaxBandwidth:     maxResources.Bandwidth,
+		UsedBandwidth:    usedBandwidth,
+		MaxTraffic:       levelLimits.MaxTraffic, // 使用等级配置的流量限制
+		UsedTraffic:      usedTrafficMB,          // 使用实时查询的流量数据
 	}
 
 	return response, nil
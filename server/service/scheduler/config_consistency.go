@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+// ConfigConsistencySchedulerService 配置一致性检查调度服务
+// 每个节点都有自己的config.yaml和生效配置，检查在所有节点执行，不依赖主节点选举
+type ConfigConsistencySchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewConfigConsistencySchedulerService 创建配置一致性检查调度服务
+func NewConfigConsistencySchedulerService() *ConfigConsistencySchedulerService {
+	return &ConfigConsistencySchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动配置一致性检查调度器
+func (s *ConfigConsistencySchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("配置一致性检查调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动配置一致性检查调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止配置一致性检查调度器
+func (s *ConfigConsistencySchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止配置一致性检查调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *ConfigConsistencySchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每分钟检查一次是否到达检查间隔
+func (s *ConfigConsistencySchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("配置一致性检查goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("配置一致性检查任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !s.shouldRun(now) {
+				continue
+			}
+			cm := config.GetConfigManager()
+			if cm == nil {
+				continue
+			}
+			s.lastRunAt = now
+			if _, err := cm.CheckConsistency(global.APP_CONFIG, "scheduled"); err != nil {
+				global.APP_LOG.Warn("定时配置一致性检查失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// shouldRun 启用且距上次执行已超过配置的间隔
+func (s *ConfigConsistencySchedulerService) shouldRun(now time.Time) bool {
+	cfg := global.APP_CONFIG.ConfigConsistency
+	if !cfg.Enabled {
+		return false
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 30
+	}
+	return now.Sub(s.lastRunAt) >= time.Duration(interval)*time.Minute
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/resources"
+
+	"go.uber.org/zap"
+)
+
+// ConsistencyAuditSchedulerService 配额与资源计数一致性审计调度服务
+type ConsistencyAuditSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewConsistencyAuditSchedulerService 创建一致性审计调度服务
+func NewConsistencyAuditSchedulerService() *ConsistencyAuditSchedulerService {
+	return &ConsistencyAuditSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动一致性审计调度器
+func (s *ConsistencyAuditSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("一致性审计调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动一致性审计调度器")
+	go s.startAuditLoop(ctx)
+}
+
+// Stop 停止一致性审计调度器
+func (s *ConsistencyAuditSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止一致性审计调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *ConsistencyAuditSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startAuditLoop 每分钟检查一次是否到达审计间隔
+func (s *ConsistencyAuditSchedulerService) startAuditLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("一致性审计goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("一致性审计任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() || !s.shouldRun(now) {
+				continue
+			}
+			s.lastRunAt = now
+			cfg := global.APP_CONFIG.ConsistencyAudit
+			if _, err := resources.GetConsistencyAuditService().Run(resources.ConsistencyAuditOptions{
+				Trigger:     "scheduled",
+				AutoCorrect: cfg.AutoCorrect,
+				Operator:    "system",
+			}); err != nil {
+				global.APP_LOG.Warn("定时一致性审计失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+// shouldRun 启用且距上次执行已超过配置的间隔
+func (s *ConsistencyAuditSchedulerService) shouldRun(now time.Time) bool {
+	cfg := global.APP_CONFIG.ConsistencyAudit
+	if !cfg.Enabled {
+		return false
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 60
+	}
+	return now.Sub(s.lastRunAt) >= time.Duration(interval)*time.Minute
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/cpbackup"
+
+	"go.uber.org/zap"
+)
+
+// ControlPlaneBackupSchedulerService 控制面数据定时灾备导出调度服务
+// control-plane-backup.enabled 开启时，距上次成功导出超过间隔后执行一次导出
+type ControlPlaneBackupSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewControlPlaneBackupSchedulerService 创建控制面导出调度服务
+func NewControlPlaneBackupSchedulerService() *ControlPlaneBackupSchedulerService {
+	return &ControlPlaneBackupSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动控制面导出调度器
+func (s *ControlPlaneBackupSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("控制面导出调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动控制面导出调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止控制面导出调度器
+func (s *ControlPlaneBackupSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止控制面导出调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *ControlPlaneBackupSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每15分钟检查一次是否需要导出
+func (s *ControlPlaneBackupSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("控制面导出goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("控制面导出任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			if global.APP_DB == nil || !global.APP_CONFIG.ControlPlaneBackup.Enabled || !cluster.IsLeader() {
+				continue
+			}
+			if time.Since(cpbackup.LastSuccessAt()) < cpbackup.Interval() {
+				continue
+			}
+			if _, err := cpbackup.Run(adminModel.ControlPlaneBackupTriggerScheduled); err != nil && !errors.Is(err, cpbackup.ErrBackupRunning) {
+				global.APP_LOG.Warn("定时控制面导出失败", zap.Error(err))
+			}
+		}
+	}
+}
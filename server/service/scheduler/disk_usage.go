@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/diskusage"
+
+	"go.uber.org/zap"
+)
+
+// DiskUsageSchedulerService 实例内磁盘使用量采集调度服务
+type DiskUsageSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewDiskUsageSchedulerService 创建磁盘使用量采集调度服务
+func NewDiskUsageSchedulerService() *DiskUsageSchedulerService {
+	return &DiskUsageSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动磁盘使用量采集调度器
+func (s *DiskUsageSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("磁盘使用量采集调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动磁盘使用量采集调度器")
+	go s.startCollectLoop(ctx)
+}
+
+// Stop 停止磁盘使用量采集调度器
+func (s *DiskUsageSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止磁盘使用量采集调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *DiskUsageSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCollectLoop 每分钟检查一次是否到达采集间隔
+func (s *DiskUsageSchedulerService) startCollectLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("磁盘使用量采集goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("磁盘使用量采集任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() || !s.shouldRun(now) {
+				continue
+			}
+			s.lastRunAt = now
+			s.collectAll(ctx)
+		}
+	}
+}
+
+// shouldRun 启用且距上次执行已超过配置的间隔
+func (s *DiskUsageSchedulerService) shouldRun(now time.Time) bool {
+	cfg := global.APP_CONFIG.DiskUsage
+	if !cfg.Enabled {
+		return false
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = 60
+	}
+	return now.Sub(s.lastRunAt) >= time.Duration(interval)*time.Minute
+}
+
+// collectAll 采集所有运行中实例的磁盘使用量，单个实例失败只记录日志
+func (s *DiskUsageSchedulerService) collectAll(ctx context.Context) {
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("status = ? AND deleted_at IS NULL", "running").Find(&instances).Error; err != nil {
+		global.APP_LOG.Error("获取实例列表失败，跳过磁盘使用量采集", zap.Error(err))
+		return
+	}
+
+	providers := make(map[uint]*providerModel.Provider)
+	concurrency := global.APP_CONFIG.DiskUsage.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	semaphore := make(chan struct{}, concurrency)
+	warnPercent := float64(diskusage.WarnPercent())
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	collected, failed := 0, 0
+	for i := range instances {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		default:
+		}
+
+		instance := &instances[i]
+		provider, ok := providers[instance.ProviderID]
+		if !ok {
+			var p providerModel.Provider
+			if err := global.APP_DB.First(&p, instance.ProviderID).Error; err == nil {
+				provider = &p
+			}
+			providers[instance.ProviderID] = provider
+		}
+		if provider == nil {
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			usedMB, totalMB, err := diskusage.Collect(instance, provider)
+			if err != nil {
+				global.APP_LOG.Debug("采集实例磁盘使用量失败",
+					zap.Uint("instanceId", instance.ID),
+					zap.String("instanceName", instance.Name),
+					zap.Error(err))
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
+			if err := diskusage.Save(instance.ID, usedMB, totalMB, time.Now()); err != nil {
+				global.APP_LOG.Error("保存实例磁盘使用量失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+				return
+			}
+			mu.Lock()
+			collected++
+			mu.Unlock()
+
+			// 仅在首次越过阈值时记录，避免每轮重复告警
+			before, sampled := diskusage.InstancePercent(instance)
+			after := diskusage.Percent(usedMB, instance.Disk, totalMB)
+			if after >= warnPercent && (!sampled || before < warnPercent) {
+				global.APP_LOG.Warn("实例磁盘使用量接近分配大小",
+					zap.Uint("instanceId", instance.ID),
+					zap.String("instanceName", instance.Name),
+					zap.Uint("userId", instance.UserID),
+					zap.Int64("usedMB", usedMB),
+					zap.Int64("allocatedMB", instance.Disk),
+					zap.Float64("percent", after))
+			}
+		}()
+	}
+	wg.Wait()
+
+	global.APP_LOG.Info("磁盘使用量采集完成",
+		zap.Int("instances", len(instances)),
+		zap.Int("collected", collected),
+		zap.Int("failed", failed))
+}
@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/emailpolicy"
+
+	"go.uber.org/zap"
+)
+
+// DisposableEmailSchedulerService 一次性邮箱域名列表刷新调度服务
+// 列表保存在每个节点的内存中，因此所有节点都会刷新，不依赖leader
+type DisposableEmailSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+	lastURL   string
+}
+
+// NewDisposableEmailSchedulerService 创建一次性邮箱列表刷新调度服务
+func NewDisposableEmailSchedulerService() *DisposableEmailSchedulerService {
+	return &DisposableEmailSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动一次性邮箱列表刷新调度器
+func (s *DisposableEmailSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("一次性邮箱列表刷新调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动一次性邮箱列表刷新调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止一次性邮箱列表刷新调度器
+func (s *DisposableEmailSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止一次性邮箱列表刷新调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *DisposableEmailSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每分钟检查一次是否需要刷新，启动后首次检查即刷新
+func (s *DisposableEmailSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("一次性邮箱列表刷新goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("一次性邮箱列表刷新任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			cfg := global.APP_CONFIG.Registration
+			if !cfg.BlockDisposableEmail || cfg.DisposableListURL == "" || !s.shouldRun(now, cfg.DisposableListURL) {
+				continue
+			}
+			s.lastRunAt = now
+			s.lastURL = cfg.DisposableListURL
+			s.run(ctx, cfg.DisposableListURL)
+		}
+	}
+}
+
+// shouldRun 距上次刷新已超过配置的间隔（小时），或列表地址已修改
+func (s *DisposableEmailSchedulerService) shouldRun(now time.Time, url string) bool {
+	if url != s.lastURL {
+		return true
+	}
+	interval := global.APP_CONFIG.Registration.DisposableRefreshHours
+	if interval <= 0 {
+		interval = 24
+	}
+	return now.Sub(s.lastRunAt) >= time.Duration(interval)*time.Hour
+}
+
+// run 下载远程列表，失败时继续使用原有列表
+func (s *DisposableEmailSchedulerService) run(ctx context.Context, url string) {
+	count, err := emailpolicy.RefreshDisposable(ctx, url)
+	if err != nil {
+		global.APP_LOG.Warn("刷新一次性邮箱列表失败", zap.String("url", url), zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("一次性邮箱列表刷新完成", zap.Int("domains", count))
+}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/forecast"
+
+	"go.uber.org/zap"
+)
+
+// ForecastSchedulerService 资源用量采样与趋势预测调度服务
+type ForecastSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewForecastSchedulerService 创建趋势预测调度服务
+func NewForecastSchedulerService() *ForecastSchedulerService {
+	return &ForecastSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动趋势预测调度器
+func (s *ForecastSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("趋势预测调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动趋势预测调度器")
+	go s.startSampleLoop(ctx)
+}
+
+// Stop 停止趋势预测调度器
+func (s *ForecastSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止趋势预测调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *ForecastSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startSampleLoop 每分钟检查一次是否到达采样间隔
+func (s *ForecastSchedulerService) startSampleLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("趋势预测goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("趋势预测任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !global.APP_CONFIG.Forecast.Enabled || !cluster.IsLeader() ||
+				now.Sub(s.lastRunAt) < forecast.Interval() {
+				continue
+			}
+			s.lastRunAt = now
+			if err := forecast.Run(ctx, now); err != nil {
+				global.APP_LOG.Error("资源用量趋势预测失败", zap.Error(err))
+			}
+		}
+	}
+}
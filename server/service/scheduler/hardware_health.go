@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/hwhealth"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+)
+
+// checkHardwareHealth 按间隔通过SSH检查宿主机硬件（SMART、RAID、温度、ZFS），并更新硬件告警状态
+func (s *ProviderHealthSchedulerService) checkHardwareHealth(provider providerModel.Provider) {
+	checks, err := hwhealth.ParseChecks(provider.HardwareChecks)
+	if err != nil || len(checks) == 0 || provider.SSHStatus != "online" {
+		return
+	}
+	if provider.HardwareCheckedAt != nil && time.Since(*provider.HardwareCheckedAt) < hwhealth.Interval() {
+		return
+	}
+
+	prov, exists := providerService.GetProviderService().GetProviderByID(provider.ID)
+	if !exists {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	output, err := prov.ExecuteSSHCommand(ctx, hwhealth.BuildScript(checks))
+	if err != nil {
+		global.APP_LOG.Debug("执行宿主机硬件检查失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+		return
+	}
+
+	report := hwhealth.Parse(output, hwhealth.TempThreshold(&provider))
+	if len(report.Unavailable) > 0 {
+		global.APP_LOG.Debug("宿主机缺少硬件检查工具，已跳过",
+			zap.Uint("providerId", provider.ID),
+			zap.String("checks", strings.Join(report.Unavailable, ",")))
+	}
+	s.updateHardwareAlert(provider, report.Alert())
+}
+
+// updateHardwareAlert 记录检查时间，告警内容变化时更新Provider硬件告警状态
+func (s *ProviderHealthSchedulerService) updateHardwareAlert(provider providerModel.Provider, alert string) {
+	now := time.Now()
+	updates := map[string]interface{}{"hardware_checked_at": &now}
+
+	if alert != provider.HardwareAlert {
+		updates["hardware_alert"] = alert
+		if alert != "" {
+			updates["hardware_alert_at"] = &now
+			global.APP_LOG.Warn("宿主机硬件健康告警",
+				zap.Uint("providerId", provider.ID),
+				zap.String("provider", provider.Name),
+				zap.String("alert", alert))
+			if global.APP_CONFIG.HardwareCheck.NotifyAdmins {
+				go hwhealth.NotifyAdmins(&provider, alert)
+			}
+		} else {
+			updates["hardware_alert_at"] = nil
+			global.APP_LOG.Info("宿主机硬件健康告警已解除",
+				zap.Uint("providerId", provider.ID),
+				zap.String("provider", provider.Name))
+		}
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", provider.ID).Updates(updates).Error; err != nil {
+		global.APP_LOG.Error("更新硬件告警状态失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+	}
+}
@@ -2,34 +2,120 @@ package scheduler
 
 import (
 	"context"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	adminProviderService "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 )
 
+// healthCheckTick 调度循环的检查周期，Provider到期后最多延迟该时间开始检查
+const healthCheckTick = 15 * time.Second
+
+// 健康检查调度默认值
+const (
+	defaultHealthCheckInterval    = 180
+	defaultHealthCheckJitter      = 20
+	defaultHealthCheckMaxBackoff  = 1800
+	defaultHealthCheckConcurrency = 3
+	defaultHealthCheckHistorySize = 50
+)
+
+// healthCheckSettings 生效的健康检查调度参数
+type healthCheckSettings struct {
+	Interval       time.Duration
+	Jitter         int
+	MaxBackoff     time.Duration
+	MaxConcurrency int
+	HistorySize    int
+}
+
+// currentHealthCheckSettings 读取配置并填充默认值
+func currentHealthCheckSettings() healthCheckSettings {
+	cfg := global.APP_CONFIG.HealthCheck
+	hs := healthCheckSettings{
+		Interval:       time.Duration(cfg.Interval) * time.Second,
+		Jitter:         cfg.Jitter,
+		MaxBackoff:     time.Duration(cfg.MaxBackoff) * time.Second,
+		MaxConcurrency: cfg.MaxConcurrency,
+		HistorySize:    cfg.HistorySize,
+	}
+	if hs.Interval <= 0 {
+		hs.Interval = defaultHealthCheckInterval * time.Second
+	}
+	if hs.Jitter < 0 || hs.Jitter > 50 {
+		hs.Jitter = defaultHealthCheckJitter
+	}
+	if hs.MaxBackoff <= 0 {
+		hs.MaxBackoff = defaultHealthCheckMaxBackoff * time.Second
+	}
+	if hs.MaxConcurrency <= 0 {
+		hs.MaxConcurrency = defaultHealthCheckConcurrency
+	}
+	if hs.HistorySize <= 0 {
+		hs.HistorySize = defaultHealthCheckHistorySize
+	}
+	return hs
+}
+
+// providerInterval 返回Provider的检查间隔，未单独设置时使用全局配置
+func (hs healthCheckSettings) providerInterval(provider *providerModel.Provider) time.Duration {
+	if provider.HealthCheckInterval > 0 {
+		return time.Duration(provider.HealthCheckInterval) * time.Second
+	}
+	return hs.Interval
+}
+
+// healthCheckDelay 计算距离下次检查的间隔：Provider连续离线时按失败次数指数退避，不超过maxBackoff，
+// 再在间隔上下jitter%内随机抖动，r为[0,1)的随机数
+func healthCheckDelay(interval time.Duration, jitter, failures int, maxBackoff time.Duration, r float64) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if failures > 0 && delay > maxBackoff {
+		delay = maxBackoff
+		if delay < interval {
+			delay = interval
+		}
+	}
+	return delay + time.Duration(float64(delay)*float64(jitter)/100*(2*r-1))
+}
+
+// providerHealthState 单个Provider的检查计划
+type providerHealthState struct {
+	nextCheck time.Time
+	failures  int  // 连续离线次数
+	running   bool // 是否正在检查
+}
+
 // ProviderHealthSchedulerService Provider健康检查调度服务
+// 每个Provider按各自的间隔和随机抖动独立调度，同时检查的数量受配置限制
 type ProviderHealthSchedulerService struct {
 	providerService *adminProviderService.Service
 	stopChan        chan struct{}
 	isRunning       bool
-	maxConcurrency  int           // 最大并发数
-	semaphore       chan struct{} // 信号量，用于限制并发
+
+	mu       sync.Mutex
+	states   map[uint]*providerHealthState
+	inFlight int
+	wg       sync.WaitGroup
 }
 
 // NewProviderHealthSchedulerService 创建Provider健康检查调度服务
 func NewProviderHealthSchedulerService() *ProviderHealthSchedulerService {
-	maxConcurrency := 3 // 最多同时检查3个provider
 	return &ProviderHealthSchedulerService{
 		providerService: adminProviderService.NewService(),
 		stopChan:        make(chan struct{}),
 		isRunning:       false,
-		maxConcurrency:  maxConcurrency,
-		semaphore:       make(chan struct{}, maxConcurrency),
+		states:          make(map[uint]*providerHealthState),
 	}
 }
 
@@ -63,13 +149,10 @@ func (s *ProviderHealthSchedulerService) IsRunning() bool {
 	return s.isRunning
 }
 
-// startHealthCheckTask 启动自适应健康检查任务
+// startHealthCheckTask 启动健康检查调度循环
 func (s *ProviderHealthSchedulerService) startHealthCheckTask(ctx context.Context) {
-	// 启动后立即执行一次
-	s.checkAllProvidersHealth()
-
 	// 确俟ticker在panic时也能停止，防止goroutine泄漏
-	ticker := time.NewTicker(3 * time.Minute)
+	ticker := time.NewTicker(healthCheckTick)
 	defer func() {
 		ticker.Stop()
 		if r := recover(); r != nil {
@@ -77,9 +160,13 @@ func (s *ProviderHealthSchedulerService) startHealthCheckTask(ctx context.Contex
 				zap.Any("panic", r),
 				zap.Stack("stack"))
 		}
+		s.wg.Wait()
 		global.APP_LOG.Info("Provider健康检查任务已停止")
 	}()
 
+	// 启动后立即调度一次，各Provider的首次检查在抖动范围内错开
+	s.dispatchDueChecks()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -87,75 +174,122 @@ func (s *ProviderHealthSchedulerService) startHealthCheckTask(ctx context.Contex
 		case <-s.stopChan:
 			return
 		case <-ticker.C:
-			// 动态调整检查间隔
-			if global.APP_DB == nil {
-				continue
-			}
-
-			var providerCount int64
-			global.APP_DB.Model(&providerModel.Provider{}).
-				Where("is_frozen = ? AND (expires_at IS NULL OR expires_at > ?)", false, time.Now()).
-				Count(&providerCount)
-
-			// 有Provider时3分钟检查，无Provider时10分钟检查（节省资源）
-			newInterval := 10 * time.Minute
-			if providerCount > 0 {
-				newInterval = 3 * time.Minute
-			}
-			ticker.Reset(newInterval)
-
-			s.checkAllProvidersHealth()
+			s.dispatchDueChecks()
 		}
 	}
 }
 
-// checkAllProvidersHealth 检查所有Provider的健康状态
-func (s *ProviderHealthSchedulerService) checkAllProvidersHealth() {
+// dispatchDueChecks 为到期的Provider发起检查，最早到期的优先，正在检查的数量不超过并发上限
+func (s *ProviderHealthSchedulerService) dispatchDueChecks() {
+	// 集群模式下只在主节点执行
+	if global.APP_DB == nil || !cluster.IsLeader() {
+		return
+	}
+
 	// 获取所有需要检查的Provider（非冻结、未过期）
 	var providers []providerModel.Provider
-	err := global.APP_DB.Where("is_frozen = ? AND (expires_at IS NULL OR expires_at > ?)", false, time.Now()).
-		Find(&providers).Error
-
-	if err != nil {
+	if err := global.APP_DB.Where("is_frozen = ? AND (expires_at IS NULL OR expires_at > ?)", false, time.Now()).
+		Find(&providers).Error; err != nil {
 		global.APP_LOG.Error("获取Provider列表失败", zap.Error(err))
 		return
 	}
 
-	if len(providers) == 0 {
-		global.APP_LOG.Debug("没有需要检查的Provider")
-		return
-	}
+	settings := currentHealthCheckSettings()
+	now := time.Now()
 
-	global.APP_LOG.Debug("开始检查Provider健康状态", zap.Int("count", len(providers)))
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// 使用worker池模式避免创建过多goroutine
-	// 对于provider数量较少的情况，直接并发处理
-	// 对于provider数量较多的情况，分批处理
-	providerChan := make(chan providerModel.Provider, len(providers))
+	active := make(map[uint]bool, len(providers))
+	var due []providerModel.Provider
 	for _, provider := range providers {
-		providerChan <- provider
+		active[provider.ID] = true
+		state, ok := s.states[provider.ID]
+		if !ok {
+			// 首次调度在抖动范围内随机错开，避免大量Provider同时检查
+			spread := settings.providerInterval(&provider) * time.Duration(settings.Jitter) / 100
+			state = &providerHealthState{nextCheck: now.Add(time.Duration(rand.Int63n(int64(spread) + 1)))}
+			s.states[provider.ID] = state
+		}
+		if !state.running && !now.Before(state.nextCheck) {
+			due = append(due, provider)
+		}
+	}
+	// 已删除、冻结或过期的Provider不再调度
+	for id, state := range s.states {
+		if !active[id] && !state.running {
+			delete(s.states, id)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return s.states[due[i].ID].nextCheck.Before(s.states[due[j].ID].nextCheck)
+	})
+	for i, provider := range due {
+		if s.inFlight >= settings.MaxConcurrency {
+			global.APP_LOG.Debug("Provider健康检查达到并发上限，剩余Provider顺延",
+				zap.Int("maxConcurrency", settings.MaxConcurrency),
+				zap.Int("waiting", len(due)-i))
+			break
+		}
+		s.states[provider.ID].running = true
+		s.inFlight++
+		s.wg.Add(1)
+		go s.runProviderCheck(provider, settings)
+	}
+}
+
+// runProviderCheck 检查单个Provider，记录检查历史并安排下次检查
+func (s *ProviderHealthSchedulerService) runProviderCheck(provider providerModel.Provider, settings healthCheckSettings) {
+	defer s.wg.Done()
+
+	record := s.checkSingleProviderHealth(provider)
+
+	s.mu.Lock()
+	state, ok := s.states[provider.ID]
+	if !ok {
+		state = &providerHealthState{}
+		s.states[provider.ID] = state
 	}
-	close(providerChan)
-
-	// 启动固定数量的worker
-	var wg sync.WaitGroup
-	for i := 0; i < s.maxConcurrency; i++ {
-		wg.Add(1)
-		go func(workerID int) {
-			defer wg.Done()
-			for provider := range providerChan {
-				s.checkSingleProviderHealth(provider)
-			}
-		}(i)
+	if record.Status == "inactive" {
+		state.failures++
+	} else {
+		state.failures = 0
+	}
+	delay := healthCheckDelay(settings.providerInterval(&provider), settings.Jitter, state.failures, settings.MaxBackoff, rand.Float64())
+	state.nextCheck = time.Now().Add(delay)
+	state.running = false
+	s.inFlight--
+	failures := state.failures
+	s.mu.Unlock()
+
+	if failures > 0 {
+		global.APP_LOG.Debug("Provider离线，退避后再检查",
+			zap.Uint("provider_id", provider.ID),
+			zap.Int("failures", failures),
+			zap.Duration("delay", delay))
 	}
 
-	// 等待所有检查完成
-	wg.Wait()
-	global.APP_LOG.Debug("所有Provider健康检查完成")
+	record.NextDelay = int(delay / time.Second)
+	saveHealthCheckRecord(&record, settings.HistorySize)
+}
+
+// saveHealthCheckRecord 保存检查记录并删除超出保留条数的旧记录
+func saveHealthCheckRecord(record *providerModel.ProviderHealthCheck, keep int) {
+	if err := global.APP_DB.Create(record).Error; err != nil {
+		global.APP_LOG.Warn("保存Provider健康检查记录失败", zap.Uint("provider_id", record.ProviderID), zap.Error(err))
+		return
+	}
+	var boundary providerModel.ProviderHealthCheck
+	if err := global.APP_DB.Select("id").Where("provider_id = ?", record.ProviderID).
+		Order("id DESC").Offset(keep - 1).Limit(1).Find(&boundary).Error; err != nil || boundary.ID == 0 {
+		return
+	}
+	global.APP_DB.Where("provider_id = ? AND id < ?", record.ProviderID, boundary.ID).Delete(&providerModel.ProviderHealthCheck{})
 }
 
-// checkSingleProviderHealth 检查单个Provider的健康状态
-func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider providerModel.Provider) {
+// checkSingleProviderHealth 检查单个Provider的健康状态，返回本次检查的记录
+func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider providerModel.Provider) providerModel.ProviderHealthCheck {
 	// 复制副本避免共享状态，立即创建所有参数的本地副本
 	// 这些变量在整个函数执行期间保持不变
 	providerID := provider.ID
@@ -172,6 +306,9 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 		zap.String("providerType", providerType),
 		zap.String("endpoint", providerEndpoint))
 
+	record := providerModel.ProviderHealthCheck{ProviderID: providerID}
+	startedAt := time.Now()
+
 	// 添加整体超时控制（2分钟）
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
@@ -187,6 +324,7 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 	select {
 	case err = <-errChan:
 		if err != nil {
+			record.Error = utils.TruncateString(err.Error(), 512)
 			global.APP_LOG.Warn("Provider健康检查执行出错（可能是超时或网络问题）",
 				zap.Uint("provider_id", providerID),
 				zap.String("provider_name", providerName),
@@ -197,6 +335,7 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 				zap.String("provider_name", providerName))
 		}
 	case <-ctx.Done():
+		record.Error = "健康检查超时"
 		global.APP_LOG.Warn("Provider健康检查超时，强制继续",
 			zap.Uint("provider_id", providerID),
 			zap.String("provider_name", providerName),
@@ -206,16 +345,30 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 
 	// 重新获取Provider以获得最新状态
 	var updatedProvider providerModel.Provider
+	record.DurationMs = time.Since(startedAt).Milliseconds()
 	if err := global.APP_DB.First(&updatedProvider, providerID).Error; err != nil {
 		global.APP_LOG.Error("获取更新后的Provider失败", zap.Uint("provider_id", providerID), zap.Error(err))
-		return
+		record.Status = oldStatus
+		return record
 	}
+	record.Status = updatedProvider.Status
+	record.SSHStatus = updatedProvider.SSHStatus
+	record.APIStatus = updatedProvider.APIStatus
 
 	// 检测同类型Provider的hostname冲突（仅记录警告，不做任何处理）
 	if updatedProvider.HostName != "" {
 		s.detectHostnameConflicts(providerID, providerName, providerType, updatedProvider.HostName, updatedProvider.Endpoint)
 	}
 
+	// 检查宿主机实际占用是否侵占系统预留资源
+	s.checkSystemReserved(updatedProvider)
+
+	// 按间隔检查宿主机硬件健康状态
+	s.checkHardwareHealth(updatedProvider)
+
+	// 按间隔采集宿主机日志事件
+	s.collectHostEvents(updatedProvider)
+
 	// 检查Provider状态是否发生变化
 	statusChanged := oldSSHStatus != updatedProvider.SSHStatus ||
 		oldAPIStatus != updatedProvider.APIStatus ||
@@ -263,6 +416,7 @@ func (s *ProviderHealthSchedulerService) checkSingleProviderHealth(provider prov
 				zap.String("api_status", updatedProvider.APIStatus))
 		}
 	}
+	return record
 }
 
 // updateProviderAllowClaim 更新Provider的allow_claim字段
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthCheckDelay(t *testing.T) {
+	interval := 3 * time.Minute
+	maxBackoff := 30 * time.Minute
+	cases := []struct {
+		name     string
+		jitter   int
+		failures int
+		r        float64
+		want     time.Duration
+	}{
+		{"healthy no jitter", 0, 0, 0.5, 3 * time.Minute},
+		{"jitter low end", 20, 0, 0, 144 * time.Second},
+		{"jitter high end", 20, 0, 1, 216 * time.Second},
+		{"backoff doubles", 0, 2, 0.5, 12 * time.Minute},
+		{"backoff capped", 0, 10, 0.5, 30 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := healthCheckDelay(interval, tc.jitter, tc.failures, maxBackoff, tc.r); got != tc.want {
+			t.Errorf("%s: healthCheckDelay = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// 单独设置的间隔大于退避上限时不应被缩短
+	if got := healthCheckDelay(time.Hour, 0, 3, maxBackoff, 0.5); got != time.Hour {
+		t.Errorf("long interval shortened by backoff cap: %v", got)
+	}
+}
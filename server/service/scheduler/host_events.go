@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/hostevents"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+)
+
+// collectHostEvents 按间隔通过SSH读取宿主机内核日志和虚拟化服务日志，保存识别出的事件
+func (s *ProviderHealthSchedulerService) collectHostEvents(provider providerModel.Provider) {
+	sources, err := hostevents.ParseSources(provider.HostEventSources)
+	if err != nil || len(sources) == 0 || provider.SSHStatus != "online" {
+		return
+	}
+	if provider.HostEventsCheckedAt != nil && time.Since(*provider.HostEventsCheckedAt) < hostevents.Interval() {
+		return
+	}
+
+	prov, exists := providerService.GetProviderService().GetProviderByID(provider.ID)
+	if !exists {
+		return
+	}
+
+	now := time.Now()
+	since := hostevents.Since(provider.HostEventsCheckedAt, now)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	output, err := prov.ExecuteSSHCommand(ctx, hostevents.BuildScript(sources, provider.Type, since))
+	if err != nil {
+		global.APP_LOG.Debug("读取宿主机日志失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+		return
+	}
+
+	events, unavailable := hostevents.Parse(output, since)
+	if len(unavailable) > 0 {
+		global.APP_LOG.Debug("宿主机无法读取部分日志，已跳过",
+			zap.Uint("providerId", provider.ID),
+			zap.String("sources", strings.Join(unavailable, ",")))
+	}
+	added, err := hostevents.Save(&provider, events)
+	if err != nil {
+		global.APP_LOG.Error("保存宿主机事件失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+		return
+	}
+	for _, event := range added {
+		if event.Severity == monitoringModel.HostEventSeverityCritical {
+			global.APP_LOG.Warn("宿主机日志中出现严重事件",
+				zap.Uint("providerId", provider.ID),
+				zap.String("provider", provider.Name),
+				zap.String("category", event.Category),
+				zap.String("subject", event.Subject),
+				zap.String("message", event.Message))
+		}
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", provider.ID).
+		Update("host_events_checked_at", &now).Error; err != nil {
+		global.APP_LOG.Error("更新宿主机日志采集时间失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/idlestop"
+	"oneclickvirt/service/task"
+
+	"go.uber.org/zap"
+)
+
+// idleCheckConcurrency 同时检测的实例数量
+const idleCheckConcurrency = 5
+
+// IdleStopSchedulerService 闲置实例检测与自动停机调度服务
+type IdleStopSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewIdleStopSchedulerService 创建闲置实例停机调度服务
+func NewIdleStopSchedulerService() *IdleStopSchedulerService {
+	return &IdleStopSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动闲置实例停机调度器
+func (s *IdleStopSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("闲置实例停机调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动闲置实例停机调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止闲置实例停机调度器
+func (s *IdleStopSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止闲置实例停机调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *IdleStopSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每分钟检查一次是否到达检测间隔
+func (s *IdleStopSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("闲置实例检测goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("闲置实例检测任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() || !idlestop.AnyEnabled() || now.Sub(s.lastRunAt) < idlestop.Interval() {
+				continue
+			}
+			s.lastRunAt = now
+			s.checkAll(ctx)
+		}
+	}
+}
+
+// checkAll 检测所有运行中实例的活动情况，按策略预告或停机
+func (s *IdleStopSchedulerService) checkAll(ctx context.Context) {
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Where("status = ? AND is_frozen = ? AND deleted_at IS NULL", "running", false).
+		Find(&instances).Error; err != nil {
+		global.APP_LOG.Error("获取实例列表失败，跳过闲置检测", zap.Error(err))
+		return
+	}
+
+	levels := make(map[uint]int)
+	providers := make(map[uint]*providerModel.Provider)
+	semaphore := make(chan struct{}, idleCheckConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := make(map[idlestop.Action]int)
+
+	for i := range instances {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		default:
+		}
+
+		instance := &instances[i]
+		level, ok := levels[instance.UserID]
+		if !ok {
+			var user userModel.User
+			if err := global.APP_DB.Select("id, level").First(&user, instance.UserID).Error; err == nil {
+				level = user.Level
+			}
+			levels[instance.UserID] = level
+		}
+		policy := idlestop.PolicyFor(level)
+		if !policy.Enabled {
+			continue
+		}
+
+		provider, ok := providers[instance.ProviderID]
+		if !ok {
+			var p providerModel.Provider
+			if err := global.APP_DB.First(&p, instance.ProviderID).Error; err == nil {
+				provider = &p
+			}
+			providers[instance.ProviderID] = provider
+		}
+		if provider == nil {
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			action := s.checkInstance(instance, provider, policy.Days, policy.TrafficThreshold)
+			mu.Lock()
+			counts[action]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	global.APP_LOG.Info("闲置实例检测完成",
+		zap.Int("instances", len(instances)),
+		zap.Int("notified", counts[idlestop.ActionNotify]),
+		zap.Int("stopped", counts[idlestop.ActionStop]),
+		zap.Int("cleared", counts[idlestop.ActionClear]))
+}
+
+// checkInstance 检测单个实例，无法确认SSH登录情况时不做处理，避免误停
+func (s *IdleStopSchedulerService) checkInstance(instance *providerModel.Instance, provider *providerModel.Provider, days int, thresholdMB int64) idlestop.Action {
+	if constant.IsWindowsOSType(instance.OSType) {
+		return idlestop.ActionNone
+	}
+	now := time.Now()
+	sshActivity, err := idlestop.SSHActivity(instance, provider, now)
+	if err != nil {
+		global.APP_LOG.Debug("闲置检测无法读取实例登录信息，跳过",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		return idlestop.ActionNone
+	}
+	trafficActivity, _ := idlestop.TrafficActivity(instance, provider, days, thresholdMB, now)
+
+	base := idlestop.BaseActivity(instance)
+	lastActive := idlestop.LatestActivity(base, sshActivity, trafficActivity)
+	action := idlestop.Decide(now, lastActive, instance.IdleNoticeAt, days, idlestop.GracePeriod())
+
+	switch action {
+	case idlestop.ActionClear:
+		if err := idlestop.ClearNotice(instance.ID, lastActive); err != nil {
+			global.APP_LOG.Warn("撤销闲置停机预告失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		}
+		return action
+	case idlestop.ActionNotify:
+		if err := idlestop.Notify(instance, days, now); err != nil {
+			global.APP_LOG.Warn("发送闲置停机预告失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+		}
+		return action
+	case idlestop.ActionStop:
+		if blackout.Active(instance.ProviderID) {
+			global.APP_LOG.Info("处于维护窗口，推迟闲置实例停机",
+				zap.Uint("instanceId", instance.ID),
+				zap.Uint("providerId", instance.ProviderID))
+			return idlestop.ActionNone
+		}
+		if !s.stopIdleInstance(instance, days) {
+			return idlestop.ActionNone
+		}
+		return action
+	}
+
+	// 记录较新的活动时间，下次检测可以少看一段流量历史
+	if lastActive.After(base) {
+		global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("last_active_at", lastActive)
+	}
+	return action
+}
+
+// stopIdleInstance 创建停机任务并清除预告状态，返回是否已创建任务
+func (s *IdleStopSchedulerService) stopIdleInstance(instance *providerModel.Instance, days int) bool {
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type = 'stop' AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+		return false
+	}
+
+	taskData := fmt.Sprintf(`{"instanceId":%d,"providerId":%d}`, instance.ID, instance.ProviderID)
+	if _, err := task.GetTaskService().CreateTask(instance.UserID, &instance.ProviderID, &instance.ID, "stop", taskData, 1800); err != nil {
+		global.APP_LOG.Error("创建闲置停机任务失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		return false
+	}
+	global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("status", constant.InstanceStatusStopping)
+
+	// 停机后重新开始计时，用户重新启动实例后再次闲置满天数才会预告
+	if err := idlestop.ClearNotice(instance.ID, time.Now()); err != nil {
+		global.APP_LOG.Warn("清除闲置停机预告失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+	}
+	global.APP_LOG.Info("实例持续闲置，已自动停机",
+		zap.Uint("instanceId", instance.ID),
+		zap.String("instanceName", instance.Name),
+		zap.Uint("userId", instance.UserID),
+		zap.Int("idleDays", days))
+	return true
+}
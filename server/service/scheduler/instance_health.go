@@ -0,0 +1,334 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/task"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 调度器扫描到期检查的间隔
+	instanceHealthTickInterval = 30 * time.Second
+	// 单轮最多并发探测的实例数
+	instanceHealthMaxConcurrency = 10
+)
+
+// InstanceHealthSchedulerService 实例健康检查调度服务
+type InstanceHealthSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	semaphore chan struct{}
+}
+
+// NewInstanceHealthSchedulerService 创建实例健康检查调度服务
+func NewInstanceHealthSchedulerService() *InstanceHealthSchedulerService {
+	return &InstanceHealthSchedulerService{
+		stopChan:  make(chan struct{}),
+		semaphore: make(chan struct{}, instanceHealthMaxConcurrency),
+	}
+}
+
+// Start 启动实例健康检查调度器
+func (s *InstanceHealthSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("实例健康检查调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动实例健康检查调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止实例健康检查调度器
+func (s *InstanceHealthSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止实例健康检查调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *InstanceHealthSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 定期执行到期的健康检查
+func (s *InstanceHealthSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(instanceHealthTickInterval)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("实例健康检查goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("实例健康检查任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() {
+				continue
+			}
+			s.runDueChecks()
+		}
+	}
+}
+
+// runDueChecks 执行所有到期的健康检查
+func (s *InstanceHealthSchedulerService) runDueChecks() {
+	var checks []providerModel.InstanceHealthCheck
+	if err := global.APP_DB.Where("enabled = ?", true).Find(&checks).Error; err != nil {
+		global.APP_LOG.Error("获取实例健康检查配置失败", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := range checks {
+		check := checks[i]
+		interval := time.Duration(check.IntervalSeconds) * time.Second
+		if check.LastCheckedAt != nil && now.Sub(*check.LastCheckedAt) < interval {
+			continue
+		}
+
+		wg.Add(1)
+		s.semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-s.semaphore
+				wg.Done()
+			}()
+			s.checkInstance(&check)
+		}()
+	}
+	wg.Wait()
+}
+
+// checkInstance 探测单个实例并根据结果更新状态、执行重启策略
+func (s *InstanceHealthSchedulerService) checkInstance(check *providerModel.InstanceHealthCheck) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, check.InstanceID).Error; err != nil {
+		return
+	}
+	// 仅检查运行中的实例，启动/重启/重置过程中跳过
+	if instance.Status != "running" {
+		return
+	}
+
+	probeErr := probeInstance(check, &instance)
+	now := time.Now()
+	updates := map[string]interface{}{"last_checked_at": &now}
+
+	if probeErr == nil {
+		if check.Status == providerModel.HealthStatusUnhealthy || check.Status == providerModel.HealthStatusFlapping {
+			recordHealthEvent(check, providerModel.HealthEventRecovered, "健康检查恢复正常")
+		}
+		s.resetWindowIfExpired(check, now, updates)
+		updates["consecutive_failures"] = 0
+		updates["last_error"] = ""
+		updates["status"] = providerModel.HealthStatusHealthy
+		global.APP_DB.Model(check).Updates(updates)
+		return
+	}
+
+	failures := check.ConsecutiveFailures + 1
+	updates["consecutive_failures"] = failures
+	updates["last_error"] = truncateHealthError(probeErr.Error())
+
+	if failures < check.FailureThreshold {
+		global.APP_DB.Model(check).Updates(updates)
+		return
+	}
+
+	if check.Status != providerModel.HealthStatusUnhealthy && check.Status != providerModel.HealthStatusFlapping {
+		updates["status"] = providerModel.HealthStatusUnhealthy
+		recordHealthEvent(check, providerModel.HealthEventUnhealthy,
+			fmt.Sprintf("连续 %d 次健康检查失败: %s", failures, probeErr.Error()))
+	}
+
+	if check.RestartPolicy == providerModel.RestartPolicyOnFailure {
+		// 维护窗口内推迟自动重启，失败计数保留，窗口结束后的下一次检查再重启
+		if blackout.Active(instance.ProviderID) {
+			global.APP_LOG.Debug("处于维护窗口，推迟健康检查自动重启",
+				zap.Uint("instanceId", instance.ID))
+		} else {
+			s.tryRestart(check, &instance, now, updates)
+		}
+	}
+	global.APP_DB.Model(check).Updates(updates)
+}
+
+// resetWindowIfExpired 防抖时间窗口结束后清零重启计数，并解除 flapping 状态
+func (s *InstanceHealthSchedulerService) resetWindowIfExpired(check *providerModel.InstanceHealthCheck, now time.Time, updates map[string]interface{}) {
+	window := time.Duration(check.RestartWindowMinutes) * time.Minute
+	if check.WindowStartedAt != nil && now.Sub(*check.WindowStartedAt) < window {
+		return
+	}
+	if check.WindowRestarts == 0 && check.Status != providerModel.HealthStatusFlapping {
+		return
+	}
+	updates["window_restarts"] = 0
+	updates["window_started_at"] = nil
+	check.WindowRestarts = 0
+	check.WindowStartedAt = nil
+	if check.Status == providerModel.HealthStatusFlapping {
+		updates["status"] = providerModel.HealthStatusUnknown
+		check.Status = providerModel.HealthStatusUnknown
+	}
+}
+
+// tryRestart 在防抖限制内为不健康的实例创建重启任务
+func (s *InstanceHealthSchedulerService) tryRestart(check *providerModel.InstanceHealthCheck, instance *providerModel.Instance, now time.Time, updates map[string]interface{}) {
+	s.resetWindowIfExpired(check, now, updates)
+
+	if check.MaxRestarts > 0 && check.WindowRestarts >= check.MaxRestarts {
+		if check.Status != providerModel.HealthStatusFlapping {
+			updates["status"] = providerModel.HealthStatusFlapping
+			recordHealthEvent(check, providerModel.HealthEventFlapping,
+				fmt.Sprintf("%d 分钟内已自动重启 %d 次仍不健康，暂停自动重启", check.RestartWindowMinutes, check.WindowRestarts))
+		}
+		return
+	}
+
+	// 已有进行中的重启任务时不重复创建
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND task_type = 'restart' AND status IN ('pending', 'running')", instance.ID).First(&existingTask).Error; err == nil {
+		return
+	}
+
+	taskData := fmt.Sprintf(`{"instanceId":%d,"providerId":%d}`, instance.ID, instance.ProviderID)
+	if _, err := task.GetTaskService().CreateTask(instance.UserID, &instance.ProviderID, &instance.ID, "restart", taskData, 1800); err != nil {
+		global.APP_LOG.Error("创建健康检查重启任务失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.Error(err))
+		return
+	}
+	global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instance.ID).Update("status", "restarting")
+
+	if check.WindowStartedAt == nil {
+		updates["window_started_at"] = &now
+	}
+	updates["window_restarts"] = check.WindowRestarts + 1
+	updates["last_restart_at"] = &now
+	updates["consecutive_failures"] = 0
+	recordHealthEvent(check, providerModel.HealthEventRestarted,
+		fmt.Sprintf("健康检查连续失败，已自动重启实例（窗口内第 %d 次）", check.WindowRestarts+1))
+}
+
+// probeInstance 通过实例的端口映射执行一次TCP或HTTP探测
+func probeInstance(check *providerModel.InstanceHealthCheck, instance *providerModel.Instance) error {
+	address, err := resolveHealthCheckAddress(check, instance)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(check.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	if check.CheckType != providerModel.HealthCheckTypeHTTP {
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return fmt.Errorf("TCP连接 %s 失败: %v", address, err)
+		}
+		conn.Close()
+		return nil
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	url := fmt.Sprintf("http://%s%s", address, check.HTTPPath)
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("HTTP请求 %s 失败: %v", url, err)
+	}
+	resp.Body.Close()
+
+	if check.ExpectStatus > 0 {
+		if resp.StatusCode != check.ExpectStatus {
+			return fmt.Errorf("HTTP状态码 %d，期望 %d", resp.StatusCode, check.ExpectStatus)
+		}
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// resolveHealthCheckAddress 将实例内端口转换为外部可访问的地址
+func resolveHealthCheckAddress(check *providerModel.InstanceHealthCheck, instance *providerModel.Instance) (string, error) {
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, instance.ProviderID).Error; err != nil {
+		return "", fmt.Errorf("获取Provider信息失败")
+	}
+
+	var port providerModel.Port
+	if err := global.APP_DB.Where("instance_id = ? AND guest_port = ? AND status = ?", instance.ID, check.GuestPort, "active").
+		First(&port).Error; err == nil {
+		host, _ := resources.ResolveInstanceSSHEndpoint(instance, &provider)
+		return net.JoinHostPort(host, strconv.Itoa(port.HostPort)), nil
+	}
+
+	// 独立IP实例没有端口映射，直接访问公网IP
+	if instance.PublicIP != "" {
+		return net.JoinHostPort(instance.PublicIP, strconv.Itoa(check.GuestPort)), nil
+	}
+	return "", fmt.Errorf("端口 %d 没有可用的端口映射", check.GuestPort)
+}
+
+// recordHealthEvent 记录健康检查事件，作为站内通知展示给用户
+func recordHealthEvent(check *providerModel.InstanceHealthCheck, event, message string) {
+	record := providerModel.InstanceHealthEvent{
+		InstanceID: check.InstanceID,
+		UserID:     check.UserID,
+		Event:      event,
+		Message:    truncateHealthError(message),
+	}
+	if err := global.APP_DB.Create(&record).Error; err != nil {
+		global.APP_LOG.Warn("记录健康检查事件失败", zap.Uint("instanceId", check.InstanceID), zap.Error(err))
+	}
+	global.APP_LOG.Info("实例健康检查事件",
+		zap.Uint("instanceId", check.InstanceID),
+		zap.Uint("userId", check.UserID),
+		zap.String("event", event),
+		zap.String("message", message))
+}
+
+// truncateHealthError 截断错误信息以适应字段长度
+func truncateHealthError(msg string) string {
+	if len(msg) > 250 {
+		return msg[:250]
+	}
+	return msg
+}
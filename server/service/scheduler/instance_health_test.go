@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+func useHealthTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	return testutil.UseSQLiteDB(t, &providerModel.Provider{}, &providerModel.Port{}, &providerModel.InstanceHealthEvent{})
+}
+
+func TestProbeInstance(t *testing.T) {
+	db := useHealthTestDB(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/redirect":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	serverPort, _ := strconv.Atoi(portStr)
+
+	// 找一个未监听的端口
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	prov := providerModel.Provider{Name: "p1", Endpoint: "127.0.0.1:22"}
+	if err := db.Create(&prov).Error; err != nil {
+		t.Fatal(err)
+	}
+	mapped := providerModel.Instance{ID: 1, ProviderID: prov.ID}
+	db.Create(&providerModel.Port{InstanceID: mapped.ID, ProviderID: prov.ID, GuestPort: 80, HostPort: serverPort, Status: "active"})
+	publicIP := providerModel.Instance{ID: 2, ProviderID: prov.ID, PublicIP: "127.0.0.1"}
+	private := providerModel.Instance{ID: 3, ProviderID: prov.ID}
+
+	tests := []struct {
+		name     string
+		instance providerModel.Instance
+		check    providerModel.InstanceHealthCheck
+		healthy  bool
+	}{
+		{name: "端口映射TCP", instance: mapped, check: providerModel.InstanceHealthCheck{CheckType: "tcp", GuestPort: 80}, healthy: true},
+		{name: "端口映射HTTP", instance: mapped, check: providerModel.InstanceHealthCheck{CheckType: "http", GuestPort: 80, HTTPPath: "/health"}, healthy: true},
+		{name: "HTTP 5xx", instance: mapped, check: providerModel.InstanceHealthCheck{CheckType: "http", GuestPort: 80, HTTPPath: "/fail"}},
+		{name: "重定向视为健康", instance: mapped, check: providerModel.InstanceHealthCheck{CheckType: "http", GuestPort: 80, HTTPPath: "/redirect"}, healthy: true},
+		{name: "状态码与期望不符", instance: mapped, check: providerModel.InstanceHealthCheck{CheckType: "http", GuestPort: 80, ExpectStatus: 204}},
+		{name: "独立IP直接访问", instance: publicIP, check: providerModel.InstanceHealthCheck{CheckType: "tcp", GuestPort: serverPort}, healthy: true},
+		{name: "端口未监听", instance: publicIP, check: providerModel.InstanceHealthCheck{CheckType: "tcp", GuestPort: closedPort, TimeoutSeconds: 1}},
+		{name: "没有端口映射", instance: private, check: providerModel.InstanceHealthCheck{CheckType: "tcp", GuestPort: 8080}},
+	}
+	for _, tt := range tests {
+		err := probeInstance(&tt.check, &tt.instance)
+		if (err == nil) != tt.healthy {
+			t.Errorf("%s: err = %v, healthy = %v", tt.name, err, tt.healthy)
+		}
+	}
+}
+
+func TestHealthRestartDebounce(t *testing.T) {
+	db := useHealthTestDB(t)
+	s := NewInstanceHealthSchedulerService()
+	now := time.Now()
+	recent := now.Add(-10 * time.Minute)
+	expired := now.Add(-2 * time.Hour)
+
+	// 窗口内已达到重启上限：暂停自动重启并标记为 flapping
+	check := &providerModel.InstanceHealthCheck{InstanceID: 1, UserID: 1, Status: providerModel.HealthStatusUnhealthy,
+		MaxRestarts: 3, RestartWindowMinutes: 60, WindowRestarts: 3, WindowStartedAt: &recent}
+	updates := map[string]interface{}{}
+	s.tryRestart(check, &providerModel.Instance{ID: 1}, now, updates)
+	if updates["status"] != providerModel.HealthStatusFlapping || updates["window_restarts"] != nil {
+		t.Errorf("达到上限后的更新 = %v", updates)
+	}
+	var events int64
+	db.Model(&providerModel.InstanceHealthEvent{}).Where("event = ?", providerModel.HealthEventFlapping).Count(&events)
+	if events != 1 {
+		t.Errorf("flapping 事件数量 = %d", events)
+	}
+
+	// 窗口结束后清零计数并解除 flapping
+	check = &providerModel.InstanceHealthCheck{Status: providerModel.HealthStatusFlapping,
+		RestartWindowMinutes: 60, WindowRestarts: 3, WindowStartedAt: &expired}
+	updates = map[string]interface{}{}
+	s.resetWindowIfExpired(check, now, updates)
+	if check.WindowRestarts != 0 || check.Status != providerModel.HealthStatusUnknown || updates["window_restarts"] != 0 {
+		t.Errorf("窗口结束后 check = %+v, updates = %v", check, updates)
+	}
+
+	// 窗口内不重置
+	check = &providerModel.InstanceHealthCheck{Status: providerModel.HealthStatusFlapping,
+		RestartWindowMinutes: 60, WindowRestarts: 3, WindowStartedAt: &recent}
+	updates = map[string]interface{}{}
+	s.resetWindowIfExpired(check, now, updates)
+	if len(updates) != 0 || check.WindowRestarts != 3 {
+		t.Errorf("窗口内不应重置: %v", updates)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"oneclickvirt/global"
 	providerModel "oneclickvirt/model/provider"
 	adminProviderService "oneclickvirt/service/admin/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/startup"
 
 	"go.uber.org/zap"
 )
@@ -102,7 +104,7 @@ func (s *InstanceSyncSchedulerService) startSyncTask(ctx context.Context) {
 		case <-s.stopChan:
 			return
 		case <-ticker.C:
-			if global.APP_DB == nil {
+			if global.APP_DB == nil || !cluster.IsLeader() {
 				continue
 			}
 
@@ -121,7 +123,7 @@ func (s *InstanceSyncSchedulerService) syncAllProvidersInstances() {
 	var providers []providerModel.Provider
 	if err := global.APP_DB.Where("status = ? AND is_frozen = ? AND (expires_at IS NULL OR expires_at > ?)",
 		"active", false, time.Now()).
-		Select("id", "name", "type").
+		Select("id", "name", "type", "recover_stopped_instances").
 		Find(&providers).Error; err != nil {
 		global.APP_LOG.Error("查询Provider列表失败", zap.Error(err))
 		return
@@ -213,6 +215,10 @@ func (s *InstanceSyncSchedulerService) syncAllProvidersInstances() {
 					}
 				}
 
+				if provider.RecoverStoppedInstances {
+					s.recoverStoppedInstances(provider, report)
+				}
+
 				// TODO: 可以在这里添加告警通知（邮件、Webhook等）
 				// 例如：s.sendAlert(provider.ID, report)
 			}
@@ -231,6 +237,26 @@ func (s *InstanceSyncSchedulerService) syncAllProvidersInstances() {
 		zap.Duration("duration", duration))
 }
 
+// recoverStoppedInstances 按启动顺序启动面板记录为运行中、宿主机上已停止的实例
+func (s *InstanceSyncSchedulerService) recoverStoppedInstances(provider providerModel.Provider, report *adminProviderService.InstanceSyncReport) {
+	ids := adminProviderService.StoppedUnexpectedly(report)
+	if len(ids) == 0 {
+		return
+	}
+	count, err := startup.Recover(provider.ID, ids, "实例同步")
+	if err != nil {
+		global.APP_LOG.Warn("按启动顺序恢复实例失败",
+			zap.Uint("providerId", provider.ID),
+			zap.String("providerName", provider.Name),
+			zap.Error(err))
+		return
+	}
+	global.APP_LOG.Info("已按启动顺序恢复意外停止的实例",
+		zap.Uint("providerId", provider.ID),
+		zap.String("providerName", provider.Name),
+		zap.Int("count", count))
+}
+
 // sendAlert 发送告警通知（预留接口）
 func (s *InstanceSyncSchedulerService) sendAlert(providerID uint, report *adminProviderService.InstanceSyncReport) {
 	// TODO: 实现告警逻辑
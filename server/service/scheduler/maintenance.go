@@ -7,6 +7,11 @@ import (
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/provider"
+	"oneclickvirt/service/consolerecord"
+	"oneclickvirt/service/dataexport"
+	"oneclickvirt/service/hostevents"
+	"oneclickvirt/service/pmacct"
+	"oneclickvirt/service/storage"
 	"oneclickvirt/service/system"
 	"oneclickvirt/utils"
 
@@ -40,10 +45,81 @@ func (s *SchedulerService) performMaintenance() {
 	s.cleanupExpiredInstances()
 
 	// 修复用户配额（定期运行，修复因重置、删除等操作导致的配额不准确）
-	s.repairUserQuotas()
+	// 启用一致性审计时由审计负责报告和修正偏差，不再静默修复
+	if !global.APP_CONFIG.ConsistencyAudit.Enabled {
+		s.repairUserQuotas()
+	}
 
 	// 清理旧的任务记录（可选）
 	s.cleanupOldTasks()
+
+	// 清理过期的分片上传会话
+	s.cleanupExpiredUploads()
+
+	// 清理过期的用户数据导出文件
+	s.cleanupExpiredDataExports()
+	s.cleanupExpiredConsoleRecordings()
+	s.cleanupExpiredHostEvents()
+}
+
+// cleanupExpiredUploads 清理过期未完成的分片上传会话
+func (s *SchedulerService) cleanupExpiredUploads() {
+	if global.APP_DB == nil {
+		return
+	}
+	count, err := storage.GetUploadService().CleanupExpiredSessions()
+	if err != nil {
+		global.APP_LOG.Error("清理过期上传会话时发生错误", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		global.APP_LOG.Info("清理过期上传会话完成", zap.Int("count", count))
+	}
+}
+
+// cleanupExpiredDataExports 删除已过期的用户数据导出文件
+func (s *SchedulerService) cleanupExpiredDataExports() {
+	if global.APP_DB == nil {
+		return
+	}
+	count, err := dataexport.CleanupExpired()
+	if err != nil {
+		global.APP_LOG.Error("清理过期数据导出时发生错误", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		global.APP_LOG.Info("清理过期数据导出完成", zap.Int("count", count))
+	}
+}
+
+// cleanupExpiredConsoleRecordings 删除超过保留天数的管理员终端会话录像
+func (s *SchedulerService) cleanupExpiredConsoleRecordings() {
+	if global.APP_DB == nil {
+		return
+	}
+	count, err := consolerecord.CleanupExpired()
+	if err != nil {
+		global.APP_LOG.Error("清理过期终端会话录像时发生错误", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		global.APP_LOG.Info("清理过期终端会话录像完成", zap.Int("count", count))
+	}
+}
+
+// cleanupExpiredHostEvents 删除超过保留天数的宿主机日志事件
+func (s *SchedulerService) cleanupExpiredHostEvents() {
+	if global.APP_DB == nil {
+		return
+	}
+	count, err := hostevents.CleanupExpired()
+	if err != nil {
+		global.APP_LOG.Error("清理过期宿主机事件时发生错误", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		global.APP_LOG.Info("清理过期宿主机事件完成", zap.Int64("count", count))
+	}
 }
 
 // cleanupExpiredInstances 清理过期实例
@@ -148,3 +224,13 @@ func (s *SchedulerService) cleanupOldTasks() {
 			zap.Int64("count", result.RowsAffected))
 	}
 }
+
+// finishExpiredTrafficCaptures 收尾已到期的流量明细抓取，保存结果并清理宿主机文件
+func (s *SchedulerService) finishExpiredTrafficCaptures() {
+	if global.APP_DB == nil {
+		return
+	}
+	if count := pmacct.NewService().FinishExpiredTrafficCaptures(); count > 0 {
+		global.APP_LOG.Info("已收尾到期的流量明细抓取", zap.Int("count", count))
+	}
+}
@@ -10,6 +10,8 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/service/cluster"
 	"oneclickvirt/service/system"
 
 	"go.uber.org/zap"
@@ -182,6 +184,11 @@ func (s *MonitoringSchedulerService) startPmacctCollection(ctx context.Context)
 			s.cleanupDeletedInstanceResetTime()
 
 		case <-checkTicker.C:
+			// 集群模式下只在主节点采集，避免重复采集
+			if !cluster.IsLeader() {
+				continue
+			}
+
 			// 获取所有启用流量控制的Provider（只查询必要字段）
 			var providers []struct {
 				ID                      uint
@@ -352,6 +359,9 @@ func (s *MonitoringSchedulerService) startCleanupTask(ctx context.Context) {
 		case <-s.stopChan:
 			return
 		case <-ticker.C:
+			if !cluster.IsLeader() {
+				continue
+			}
 			now := time.Now()
 
 			// 每小时执行实例状态修复
@@ -361,8 +371,8 @@ func (s *MonitoringSchedulerService) startCleanupTask(ctx context.Context) {
 				global.APP_LOG.Error("修复卡住的实例状态失败", zap.Error(err))
 			}
 
-			// 只在凌晨3点执行数据清理
-			if now.Hour() == 3 {
+			// 只在凌晨3点执行数据清理，启用保留策略管理器时由其接管
+			if now.Hour() == 3 && !global.APP_CONFIG.Retention.Enabled {
 				global.APP_LOG.Info("开始清理过期的pmacct数据")
 				if err := s.pmacctService.CleanupOldPmacctData(90); err != nil {
 					global.APP_LOG.Error("清理过期pmacct数据失败", zap.Error(err))
@@ -418,7 +428,7 @@ func (s *MonitoringSchedulerService) startPmacctResetTask(ctx context.Context) {
 			now := time.Now()
 
 			// 只在凌晨4点执行（与清理任务错开1小时）
-			if now.Hour() != 4 {
+			if now.Hour() != 4 || !cluster.IsLeader() {
 				continue
 			}
 
@@ -760,11 +770,18 @@ func (s *MonitoringSchedulerService) checkProviderTrafficLimits(ctx context.Cont
 	}
 
 	// 检查每个实例的流量限制
+	inBlackout := blackout.Active(providerID)
 	for _, instance := range instances {
 		usedTraffic := trafficMap[instance.ID] // 从实时查询获取流量
 		if instance.MaxTraffic > 0 && usedTraffic >= instance.MaxTraffic {
-			// 流量超限，需要暂停实例
+			// 流量超限，需要暂停实例，维护窗口内推迟到下一轮检查
 			if !instance.TrafficLimited && instance.Status != "stopped" && instance.Status != "suspended" {
+				if inBlackout {
+					global.APP_LOG.Info("处于维护窗口，推迟实例流量超限停机",
+						zap.Uint("instanceID", instance.ID),
+						zap.String("instanceName", instance.Name))
+					continue
+				}
 				global.APP_LOG.Warn("实例流量超限",
 					zap.Uint("instanceID", instance.ID),
 					zap.String("instanceName", instance.Name),
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	monitoringModel "oneclickvirt/model/monitoring"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/netprobe"
+	"oneclickvirt/service/task"
+
+	"go.uber.org/zap"
+)
+
+// NetworkProbeSchedulerService 节点网络质量定时测试调度服务
+// network-probe.interval 大于0时，为超过间隔未测试的节点创建测试任务
+type NetworkProbeSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewNetworkProbeSchedulerService 创建网络质量测试调度服务
+func NewNetworkProbeSchedulerService() *NetworkProbeSchedulerService {
+	return &NetworkProbeSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动网络质量测试调度器
+func (s *NetworkProbeSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("网络质量测试调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动网络质量测试调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止网络质量测试调度器
+func (s *NetworkProbeSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止网络质量测试调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *NetworkProbeSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每小时检查一次需要重新测试的节点
+func (s *NetworkProbeSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("网络质量测试调度goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("网络质量测试调度任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			interval := global.APP_CONFIG.NetworkProbe.Interval
+			if global.APP_DB == nil || interval <= 0 || !cluster.IsLeader() {
+				continue
+			}
+			s.scheduleDue(time.Duration(interval) * time.Hour)
+		}
+	}
+}
+
+// scheduleDue 为超过间隔未测试、且没有进行中测试任务的节点创建测试任务
+func (s *NetworkProbeSchedulerService) scheduleDue(interval time.Duration) {
+	if _, err := netprobe.ResolveOptions(nil, ""); err != nil {
+		global.APP_LOG.Debug("网络质量测试配置无效，跳过定时测试", zap.Error(err))
+		return
+	}
+
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Select("id", "name").
+		Where("status = ? AND is_frozen = ?", "active", false).Find(&providers).Error; err != nil {
+		global.APP_LOG.Error("获取Provider列表失败，跳过网络质量测试", zap.Error(err))
+		return
+	}
+
+	created := 0
+	for _, prov := range providers {
+		if time.Since(netprobe.LastProbedAt(prov.ID)) < interval {
+			continue
+		}
+		var running int64
+		global.APP_DB.Model(&adminModel.Task{}).
+			Where("provider_id = ? AND task_type = ? AND status IN (?)", prov.ID, adminModel.TaskTypeNetworkProbe, []string{"pending", "processing", "running"}).
+			Count(&running)
+		if running > 0 {
+			continue
+		}
+		req := adminModel.NetworkProbeTaskRequest{Trigger: monitoringModel.NetworkProbeTriggerScheduled}
+		if _, err := task.GetTaskService().CreateNetworkProbeTask(0, prov.ID, req); err != nil {
+			global.APP_LOG.Warn("创建定时网络质量测试任务失败",
+				zap.Uint("providerId", prov.ID),
+				zap.String("providerName", prov.Name),
+				zap.Error(err))
+			continue
+		}
+		created++
+	}
+	if created > 0 {
+		global.APP_LOG.Info("已创建定时网络质量测试任务", zap.Int("count", created))
+	}
+}
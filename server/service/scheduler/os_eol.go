@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/imageeol"
+
+	"go.uber.org/zap"
+)
+
+// OSEOLSchedulerService 实例操作系统EOL同步与通知调度服务
+type OSEOLSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewOSEOLSchedulerService 创建操作系统EOL调度服务
+func NewOSEOLSchedulerService() *OSEOLSchedulerService {
+	return &OSEOLSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动操作系统EOL调度器
+func (s *OSEOLSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("操作系统EOL调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动操作系统EOL调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止操作系统EOL调度器
+func (s *OSEOLSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止操作系统EOL调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *OSEOLSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每分钟检查一次是否到达执行间隔
+func (s *OSEOLSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("操作系统EOL检查goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("操作系统EOL检查任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() || !s.shouldRun(now) {
+				continue
+			}
+			s.lastRunAt = now
+			s.run()
+		}
+	}
+}
+
+// shouldRun 距上次执行已超过配置的间隔（小时）
+func (s *OSEOLSchedulerService) shouldRun(now time.Time) bool {
+	interval := global.APP_CONFIG.OSEOL.Interval
+	if interval <= 0 {
+		interval = 24
+	}
+	return now.Sub(s.lastRunAt) >= time.Duration(interval)*time.Hour
+}
+
+// run 同步实例EOL日期，按配置通知实例所有者
+func (s *OSEOLSchedulerService) run() {
+	updated, err := imageeol.Sync()
+	if err != nil {
+		global.APP_LOG.Error("同步实例操作系统EOL日期失败", zap.Error(err))
+		return
+	}
+
+	notified := 0
+	if global.APP_CONFIG.OSEOL.NotifyOwners {
+		notified, err = imageeol.NotifyOwners()
+		if err != nil {
+			global.APP_LOG.Error("发送操作系统EOL通知失败", zap.Error(err))
+		}
+	}
+
+	global.APP_LOG.Info("操作系统EOL检查完成",
+		zap.Int("updated", updated),
+		zap.Int("notified", notified))
+}
@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/traffic"
+
+	"go.uber.org/zap"
+)
+
+// RetentionSchedulerService 监控数据保留策略调度服务
+// 低优先级任务：每天在配置的时刻执行一次，单次执行期间不会重入
+type RetentionSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+	lastRunAt time.Time
+}
+
+// NewRetentionSchedulerService 创建监控数据保留策略调度服务
+func NewRetentionSchedulerService() *RetentionSchedulerService {
+	return &RetentionSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动保留策略调度器
+func (s *RetentionSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("保留策略调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动保留策略调度器")
+	go s.startRetentionLoop(ctx)
+}
+
+// Stop 停止保留策略调度器
+func (s *RetentionSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止保留策略调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *RetentionSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startRetentionLoop 每10分钟检查一次是否到达执行时刻
+func (s *RetentionSchedulerService) startRetentionLoop(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("保留策略goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("保留策略任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() || !s.shouldRun(now) {
+				continue
+			}
+			s.lastRunAt = now
+			s.run(ctx)
+		}
+	}
+}
+
+// shouldRun 启用且到达执行时刻，并且当天尚未执行
+func (s *RetentionSchedulerService) shouldRun(now time.Time) bool {
+	cfg := global.APP_CONFIG.Retention
+	if !cfg.Enabled || len(cfg.Tables) == 0 {
+		return false
+	}
+	runHour := cfg.RunHour
+	if runHour < 0 || runHour > 23 {
+		runHour = 3
+	}
+	if now.Hour() != runHour {
+		return false
+	}
+	y1, m1, d1 := s.lastRunAt.Date()
+	y2, m2, d2 := now.Date()
+	return y1 != y2 || m1 != m2 || d1 != d2
+}
+
+// run 执行一次保留策略，停止调度器时中断
+func (s *RetentionSchedulerService) run(ctx context.Context) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-runCtx.Done():
+		}
+	}()
+
+	if err := traffic.NewRetentionService().Run(runCtx); err != nil {
+		global.APP_LOG.Warn("保留策略执行中断", zap.Error(err))
+	}
+}
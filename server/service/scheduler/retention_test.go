@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+)
+
+func TestRetentionShouldRun(t *testing.T) {
+	oldConfig := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = oldConfig })
+
+	tables := map[string]config.RetentionPolicy{"pmacct_traffic_records": {KeepDays: 30}}
+	at := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 10, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		cfg     config.Retention
+		lastRun time.Time
+		now     time.Time
+		want    bool
+	}{
+		{name: "到达执行时刻", cfg: config.Retention{Enabled: true, RunHour: 4, Tables: tables}, now: at(1, 4), want: true},
+		{name: "未启用", cfg: config.Retention{RunHour: 4, Tables: tables}, now: at(1, 4)},
+		{name: "未配置表", cfg: config.Retention{Enabled: true, RunHour: 4}, now: at(1, 4)},
+		{name: "未到执行时刻", cfg: config.Retention{Enabled: true, RunHour: 4, Tables: tables}, now: at(1, 5)},
+		{name: "当天已执行", cfg: config.Retention{Enabled: true, RunHour: 4, Tables: tables}, lastRun: at(1, 4), now: at(1, 4).Add(10 * time.Minute)},
+		{name: "前一天执行过", cfg: config.Retention{Enabled: true, RunHour: 4, Tables: tables}, lastRun: at(1, 4), now: at(2, 4), want: true},
+		{name: "无效时刻按3点", cfg: config.Retention{Enabled: true, RunHour: 24, Tables: tables}, now: at(1, 3), want: true},
+	}
+	for _, tt := range tests {
+		global.APP_CONFIG.Retention = tt.cfg
+		s := &RetentionSchedulerService{lastRunAt: tt.lastRun}
+		if got := s.shouldRun(tt.now); got != tt.want {
+			t.Errorf("%s: shouldRun = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	dashboardModel "oneclickvirt/model/dashboard"
 	"oneclickvirt/model/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/database"
 	"oneclickvirt/service/traffic"
 
 	"go.uber.org/zap"
@@ -129,35 +131,46 @@ func (s *SchedulerService) runTaskScheduler() {
 	global.APP_LOG.Info("Task scheduler main loop started with traffic aggregation and expiry check")
 
 	// 启动时立即执行一次过期检查
-	s.checkExpiredResources()
+	if cluster.IsLeader() {
+		s.checkExpiredResources()
+	}
 
 	for {
+		var job func()
 		select {
 		case <-s.ctx.Done():
 			global.APP_LOG.Info("Task scheduler context cancelled, exiting")
 			return
 
 		case <-taskTicker.C:
-			s.processPendingTasks()
+			job = s.processPendingTasks
 
 		case <-s.triggerChan:
 			// 立即处理pending任务
 			global.APP_LOG.Debug("Scheduler triggered immediately")
-			s.processPendingTasks()
+			job = s.processPendingTasks
 
 		case <-cleanupTicker.C:
-			s.cleanupTimeoutTasks()
+			job = func() {
+				s.cleanupTimeoutTasks()
+				s.finishExpiredTrafficCaptures()
+			}
 
 		case <-maintenanceTicker.C:
-			s.performMaintenance()
+			job = s.performMaintenance
 
 		case <-expiryCheckTicker.C:
 			// 定期检查过期资源并冻结
-			s.checkExpiredResources()
+			job = s.checkExpiredResources
 
 		case <-trafficAggTicker.C:
 			// 定期聚合流量数据，更新缓存
-			s.aggregateTrafficData()
+			job = s.aggregateTrafficData
+		}
+
+		// 集群模式下只有主节点执行，其他节点只消费定时器，主节点切换后自动接管
+		if cluster.IsLeader() {
+			job()
 		}
 	}
 }
@@ -170,6 +183,12 @@ func (s *SchedulerService) processPendingTasks() {
 		return
 	}
 
+	// 数据库熔断期间不启动新任务，待处理任务保持pending，恢复后继续
+	if !database.GetDatabaseService().Available() {
+		global.APP_LOG.Debug("数据库熔断中，跳过任务处理")
+		return
+	}
+
 	// 获取所有待处理任务，按创建时间排序
 	// 优化：添加LIMIT限制，避免一次性加载过多任务，减少内存和数据库压力
 	var pendingTasks []adminModel.Task
@@ -209,6 +228,16 @@ func (s *SchedulerService) tryStartTask(task adminModel.Task) {
 		return
 	}
 
+	// 系统任务不依赖Provider，直接交给TaskService
+	if task.IsSystemTask() {
+		if err := s.taskService.StartTask(task.ID); err != nil {
+			global.APP_LOG.Debug("System task start attempt failed",
+				zap.Uint("task_id", task.ID),
+				zap.Error(err))
+		}
+		return
+	}
+
 	// 检查ProviderID是否为空
 	if task.ProviderID == nil {
 		global.APP_LOG.Error("Task has no provider ID", zap.Uint("task_id", task.ID))
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/snapshot"
+
+	"go.uber.org/zap"
+)
+
+// snapshotTimeout 单个实例创建和清理快照的超时时间
+const snapshotTimeout = 10 * time.Minute
+
+// SnapshotSchedulerService 实例定时快照调度服务
+type SnapshotSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewSnapshotSchedulerService 创建定时快照调度服务
+func NewSnapshotSchedulerService() *SnapshotSchedulerService {
+	return &SnapshotSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动定时快照调度器
+func (s *SnapshotSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("定时快照调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动定时快照调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止定时快照调度器
+func (s *SnapshotSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止定时快照调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *SnapshotSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每5分钟检查一次到期的快照计划
+func (s *SnapshotSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("定时快照goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("定时快照任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case now := <-ticker.C:
+			if global.APP_DB == nil || !global.APP_CONFIG.Snapshot.Enabled || !cluster.IsLeader() {
+				continue
+			}
+			s.runDue(ctx, now)
+		}
+	}
+}
+
+// runDue 在快照窗口内执行所有到期的计划
+func (s *SnapshotSchedulerService) runDue(ctx context.Context, now time.Time) {
+	windows, err := snapshot.Windows()
+	if err != nil {
+		global.APP_LOG.Warn("快照窗口配置错误，跳过定时快照", zap.Error(err))
+		return
+	}
+	if !snapshot.InWindow(windows, now) {
+		return
+	}
+	tolerance := snapshot.Tolerance(windows)
+
+	var schedules []providerModel.InstanceSnapshotSchedule
+	if err := global.APP_DB.Where("enabled = ?", true).Find(&schedules).Error; err != nil {
+		global.APP_LOG.Error("获取快照计划失败", zap.Error(err))
+		return
+	}
+
+	concurrency := global.APP_CONFIG.Snapshot.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 3
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	due := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		if !snapshot.Due(schedule, now, tolerance) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		default:
+		}
+
+		due++
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+				if r := recover(); r != nil {
+					global.APP_LOG.Error("执行定时快照panic", zap.Uint("instanceId", schedule.InstanceID), zap.Any("panic", r))
+				}
+			}()
+			runCtx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+			defer cancel()
+			if err := snapshot.Run(runCtx, schedule, time.Now()); err != nil {
+				global.APP_LOG.Error("保存定时快照结果失败", zap.Uint("instanceId", schedule.InstanceID), zap.Error(err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if due > 0 {
+		global.APP_LOG.Info("定时快照执行完成", zap.Int("schedules", due))
+	}
+}
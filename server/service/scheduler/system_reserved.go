@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	providerService "oneclickvirt/service/provider"
+
+	"go.uber.org/zap"
+)
+
+// hostUsage 宿主机实际资源使用情况
+type hostUsage struct {
+	load1           float64 // 1分钟平均负载
+	memAvailableMB  int64   // 可用内存（MB）
+	diskAvailableMB int64   // 存储池可用空间（MB）
+}
+
+// checkSystemReserved 检查宿主机实际占用是否侵占了系统预留资源，并更新告警状态
+func (s *ProviderHealthSchedulerService) checkSystemReserved(provider providerModel.Provider) {
+	noReservation := provider.SystemReservedCPUPercent <= 0 && provider.SystemReservedMemory <= 0 && provider.SystemReservedDiskGB <= 0
+	if noReservation || provider.SSHStatus != "online" {
+		if noReservation && provider.SystemReservedAlert != "" {
+			s.updateSystemReservedAlert(provider, "")
+		}
+		return
+	}
+
+	prov, exists := providerService.GetProviderService().GetProviderByID(provider.ID)
+	if !exists {
+		return
+	}
+
+	diskPath := provider.StoragePoolPath
+	if diskPath == "" {
+		diskPath = "/"
+	}
+	cmd := fmt.Sprintf("awk '{print $1}' /proc/loadavg; awk '/MemAvailable/{print int($2/1024)}' /proc/meminfo; df -Pm '%s' | awk 'NR==2{print $4}'", diskPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := prov.ExecuteSSHCommand(ctx, cmd)
+	if err != nil {
+		global.APP_LOG.Debug("获取宿主机资源使用情况失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+		return
+	}
+	usage, err := parseHostUsage(output)
+	if err != nil {
+		global.APP_LOG.Debug("解析宿主机资源使用情况失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+		return
+	}
+
+	s.updateSystemReservedAlert(provider, systemReservedAlert(provider, usage))
+}
+
+// systemReservedAlert 根据宿主机实际占用生成告警内容，未侵占预留资源时返回空字符串
+func systemReservedAlert(provider providerModel.Provider, usage *hostUsage) string {
+	var alerts []string
+	if provider.SystemReservedCPUPercent > 0 {
+		allocatable := provider.AllocatableCPUCores()
+		if usage.load1 > float64(allocatable) {
+			alerts = append(alerts, fmt.Sprintf("CPU负载 %.2f 超过可分配核心数 %d", usage.load1, allocatable))
+		}
+	}
+	if provider.SystemReservedMemory > 0 && usage.memAvailableMB < provider.SystemReservedMemory {
+		alerts = append(alerts, fmt.Sprintf("可用内存 %dMB 低于预留 %dMB", usage.memAvailableMB, provider.SystemReservedMemory))
+	}
+	if provider.SystemReservedDiskGB > 0 && usage.diskAvailableMB < provider.ReservedDisk() {
+		alerts = append(alerts, fmt.Sprintf("可用磁盘 %dMB 低于预留 %dGB", usage.diskAvailableMB, provider.SystemReservedDiskGB))
+	}
+
+	return strings.Join(alerts, "；")
+}
+
+// updateSystemReservedAlert 告警内容变化时更新Provider告警状态
+func (s *ProviderHealthSchedulerService) updateSystemReservedAlert(provider providerModel.Provider, alert string) {
+	if len(alert) > 255 {
+		alert = alert[:255]
+	}
+	if alert == provider.SystemReservedAlert {
+		return
+	}
+
+	updates := map[string]interface{}{"system_reserved_alert": alert}
+	if alert != "" {
+		now := time.Now()
+		updates["system_reserved_alert_at"] = &now
+		global.APP_LOG.Warn("宿主机实际占用侵占系统预留资源",
+			zap.Uint("providerId", provider.ID),
+			zap.String("provider", provider.Name),
+			zap.String("alert", alert))
+	} else {
+		updates["system_reserved_alert_at"] = nil
+		global.APP_LOG.Info("宿主机系统预留资源告警已解除",
+			zap.Uint("providerId", provider.ID),
+			zap.String("provider", provider.Name))
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", provider.ID).Updates(updates).Error; err != nil {
+		global.APP_LOG.Error("更新系统预留资源告警失败",
+			zap.Uint("providerId", provider.ID),
+			zap.Error(err))
+	}
+}
+
+// parseHostUsage 解析负载、可用内存、可用磁盘三行输出
+func parseHostUsage(output string) (*hostUsage, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("输出格式不正确: %q", output)
+	}
+	load1, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析负载失败: %v", err)
+	}
+	memAvailable, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析可用内存失败: %v", err)
+	}
+	diskAvailable, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("解析可用磁盘失败: %v", err)
+	}
+	return &hostUsage{
+		load1:           load1,
+		memAvailableMB:  memAvailable,
+		diskAvailableMB: diskAvailable,
+	}, nil
+}
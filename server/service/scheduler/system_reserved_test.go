@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestParseHostUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    hostUsage
+		wantErr bool
+	}{
+		{name: "正常输出", output: "1.25\n2048\n10240\n", want: hostUsage{load1: 1.25, memAvailableMB: 2048, diskAvailableMB: 10240}},
+		{name: "多余空白", output: "  0.5 \n 512\n\n100 ", want: hostUsage{load1: 0.5, memAvailableMB: 512, diskAvailableMB: 100}},
+		{name: "行数不足", output: "1.0\n2048", wantErr: true},
+		{name: "负载非数字", output: "n/a\n2048\n100", wantErr: true},
+		{name: "内存非整数", output: "1.0\n20.5\n100", wantErr: true},
+		{name: "磁盘非数字", output: "1.0\n2048\n-", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHostUsage(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("应解析失败，得到 %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseHostUsage = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemReservedAlert(t *testing.T) {
+	// 8核预留25%（2核），预留内存1024MB，预留磁盘10GB
+	provider := providerModel.Provider{
+		NodeCPUCores:             8,
+		SystemReservedCPUPercent: 25,
+		SystemReservedMemory:     1024,
+		SystemReservedDiskGB:     10,
+	}
+	tests := []struct {
+		name     string
+		provider providerModel.Provider
+		usage    hostUsage
+		want     []string // 告警中应包含的内容，为空表示不告警
+	}{
+		{name: "未侵占预留", provider: provider, usage: hostUsage{load1: 6, memAvailableMB: 1024, diskAvailableMB: 10240}},
+		{name: "负载超过可分配核心", provider: provider, usage: hostUsage{load1: 6.5, memAvailableMB: 4096, diskAvailableMB: 20480}, want: []string{"CPU负载 6.50 超过可分配核心数 6"}},
+		{name: "可用内存低于预留", provider: provider, usage: hostUsage{load1: 1, memAvailableMB: 1023, diskAvailableMB: 20480}, want: []string{"可用内存 1023MB 低于预留 1024MB"}},
+		{name: "可用磁盘低于预留", provider: provider, usage: hostUsage{load1: 1, memAvailableMB: 4096, diskAvailableMB: 10239}, want: []string{"可用磁盘 10239MB 低于预留 10GB"}},
+		{name: "多项同时告警", provider: provider, usage: hostUsage{load1: 7, memAvailableMB: 100, diskAvailableMB: 100}, want: []string{"CPU负载", "可用内存", "可用磁盘"}},
+		{name: "未配置预留不告警", provider: providerModel.Provider{NodeCPUCores: 8}, usage: hostUsage{load1: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := tt.usage
+			got := systemReservedAlert(tt.provider, &usage)
+			if len(tt.want) == 0 {
+				if got != "" {
+					t.Errorf("不应告警，得到 %q", got)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("告警 %q 缺少 %q", got, want)
+				}
+			}
+			if n := strings.Count(got, "；") + 1; n != len(tt.want) {
+				t.Errorf("告警条数 = %d, want %d: %q", n, len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestUpdateSystemReservedAlert(t *testing.T) {
+	db := useHealthTestDB(t)
+	provider := providerModel.Provider{Name: "node", Endpoint: "127.0.0.1"}
+	if err := db.Create(&provider).Error; err != nil {
+		t.Fatal(err)
+	}
+	s := &ProviderHealthSchedulerService{}
+	reload := func() providerModel.Provider {
+		var p providerModel.Provider
+		if err := db.First(&p, provider.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	s.updateSystemReservedAlert(reload(), "可用内存 100MB 低于预留 1024MB")
+	if p := reload(); p.SystemReservedAlert == "" || p.SystemReservedAlertAt == nil {
+		t.Fatalf("告警未写入: %q %v", p.SystemReservedAlert, p.SystemReservedAlertAt)
+	}
+	s.updateSystemReservedAlert(reload(), strings.Repeat("x", 300))
+	if p := reload(); len(p.SystemReservedAlert) != 255 {
+		t.Errorf("告警应截断为255字节，得到 %d", len(p.SystemReservedAlert))
+	}
+	s.updateSystemReservedAlert(reload(), "")
+	if p := reload(); p.SystemReservedAlert != "" || p.SystemReservedAlertAt != nil {
+		t.Errorf("告警未解除: %q %v", p.SystemReservedAlert, p.SystemReservedAlertAt)
+	}
+}
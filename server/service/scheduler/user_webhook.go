@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/userwebhook"
+
+	"go.uber.org/zap"
+)
+
+// UserWebhookSchedulerService 用户Webhook调度服务
+// 定时投递实例即将到期事件，并清理过期的投递记录
+type UserWebhookSchedulerService struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewUserWebhookSchedulerService 创建用户Webhook调度服务
+func NewUserWebhookSchedulerService() *UserWebhookSchedulerService {
+	return &UserWebhookSchedulerService{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动用户Webhook调度器
+func (s *UserWebhookSchedulerService) Start(ctx context.Context) {
+	if s.isRunning {
+		global.APP_LOG.Warn("用户Webhook调度器已在运行中")
+		return
+	}
+	s.isRunning = true
+	global.APP_LOG.Info("启动用户Webhook调度器")
+	go s.startCheckLoop(ctx)
+}
+
+// Stop 停止用户Webhook调度器
+func (s *UserWebhookSchedulerService) Stop() {
+	if !s.isRunning {
+		return
+	}
+	global.APP_LOG.Info("停止用户Webhook调度器")
+	close(s.stopChan)
+	s.isRunning = false
+}
+
+// IsRunning 检查调度器是否正在运行
+func (s *UserWebhookSchedulerService) IsRunning() bool {
+	return s.isRunning
+}
+
+// startCheckLoop 每小时检查一次即将到期的实例，每天清理一次投递记录
+func (s *UserWebhookSchedulerService) startCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Hour)
+	var lastCleanup time.Time
+	defer func() {
+		ticker.Stop()
+		if r := recover(); r != nil {
+			global.APP_LOG.Error("用户Webhook调度goroutine panic",
+				zap.Any("panic", r),
+				zap.Stack("stack"))
+		}
+		global.APP_LOG.Info("用户Webhook调度任务已停止")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			if global.APP_DB == nil || !cluster.IsLeader() {
+				continue
+			}
+			userwebhook.CheckExpiring()
+			if time.Since(lastCleanup) >= 24*time.Hour {
+				userwebhook.Cleanup()
+				lastCleanup = time.Now()
+			}
+		}
+	}
+}
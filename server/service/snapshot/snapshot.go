@@ -0,0 +1,240 @@
+// Package snapshot 实例定时快照
+// 用户为实例设置每日或每周快照计划，调度器在快照窗口内为到期的计划创建快照，
+// 并按保留数量删除旧快照；连续失败时在首次失败通知实例所有者
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/blackout"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// retryInterval 执行失败后的重试间隔
+const retryInterval = time.Hour
+
+// windowTolerance 配置了窗口时允许提前执行的时间，避免每次执行时间在窗口内逐渐后移
+const windowTolerance = 2 * time.Hour
+
+// ErrNotAllowed 用户等级不允许定时快照
+var ErrNotAllowed = errors.New("当前用户等级不支持定时快照")
+
+// Period 返回计划的执行周期
+func Period(frequency string) time.Duration {
+	if frequency == providerModel.SnapshotFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Due 判断计划是否到期，tolerance 为允许提前执行的时间
+func Due(s *providerModel.InstanceSnapshotSchedule, now time.Time, tolerance time.Duration) bool {
+	if s.LastStatus == providerModel.SnapshotStatusFailed && s.LastAttemptAt != nil && now.Sub(*s.LastAttemptAt) < retryInterval {
+		return false
+	}
+	if s.LastSuccessAt == nil {
+		return true
+	}
+	return now.Sub(*s.LastSuccessAt) >= Period(s.Frequency)-tolerance
+}
+
+// Windows 解析快照窗口，未配置窗口时返回nil
+func Windows() ([]blackout.Window, error) {
+	return blackout.ParseAll(global.APP_CONFIG.Snapshot.Windows)
+}
+
+// InWindow 判断当前是否允许执行快照，未配置窗口时总是允许
+func InWindow(windows []blackout.Window, now time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	now = now.In(blackout.LoadLocation(global.APP_CONFIG.Snapshot.Timezone))
+	for _, w := range windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tolerance 配置了窗口时允许提前执行，未配置时严格按周期执行
+func Tolerance(windows []blackout.Window) time.Duration {
+	if len(windows) == 0 {
+		return 0
+	}
+	return windowTolerance
+}
+
+// LevelLimit 返回用户等级允许保留的定时快照数量
+func LevelLimit(userID uint) (int, error) {
+	var user userModel.User
+	if err := global.APP_DB.Select("id, level").First(&user, userID).Error; err != nil {
+		return 0, fmt.Errorf("用户不存在: %w", err)
+	}
+	return global.APP_CONFIG.Quota.LevelLimits[user.Level].MaxSnapshots, nil
+}
+
+// Validate 校验计划参数，limit 为用户等级允许保留的数量
+func Validate(frequency string, keep, limit int) error {
+	if limit <= 0 {
+		return ErrNotAllowed
+	}
+	if frequency != providerModel.SnapshotFrequencyDaily && frequency != providerModel.SnapshotFrequencyWeekly {
+		return fmt.Errorf("无效的快照频率: %s", frequency)
+	}
+	if keep < 1 || keep > limit {
+		return fmt.Errorf("保留数量需在 1-%d 之间", limit)
+	}
+	return nil
+}
+
+// Supported 判断Provider类型是否支持快照
+func Supported(providerType string) bool {
+	switch providerType {
+	case "lxd", "incus", "proxmox":
+		return true
+	}
+	return false
+}
+
+// Name 生成快照名，同一实例每秒最多一个定时快照
+func Name(now time.Time) string {
+	return "auto-" + now.UTC().Format("20060102-150405")
+}
+
+// Run 为计划创建快照并清理旧快照，执行结果写回计划
+func Run(ctx context.Context, schedule *providerModel.InstanceSnapshotSchedule, now time.Time) error {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, schedule.InstanceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 实例已删除，快照随实例一起删除，清理计划和记录
+			return Remove(schedule.InstanceID)
+		}
+		return err
+	}
+	if instance.Status != constant.InstanceStatusRunning && instance.Status != constant.InstanceStatusStopped {
+		// 实例正在操作中，等待下一轮
+		return nil
+	}
+
+	err := create(ctx, schedule, &instance, now)
+	return record(schedule, &instance, err, now)
+}
+
+// create 创建快照并按保留数量清理旧快照
+func create(ctx context.Context, schedule *providerModel.InstanceSnapshotSchedule, instance *providerModel.Instance, now time.Time) error {
+	limit, err := LevelLimit(instance.UserID)
+	if err != nil {
+		return err
+	}
+	if limit <= 0 {
+		return ErrNotAllowed
+	}
+	keep := schedule.Keep
+	if keep > limit {
+		keep = limit
+	}
+
+	apiService := &providerService.ProviderApiService{}
+	prov, _, err := apiService.GetProviderByID(instance.ProviderID)
+	if err != nil {
+		return err
+	}
+	snapshotter, ok := prov.(provider.SnapshotCapable)
+	if !ok {
+		return fmt.Errorf("%s 类型的节点不支持快照", prov.GetType())
+	}
+
+	name := Name(now)
+	if err := snapshotter.CreateSnapshot(ctx, instance.Name, name); err != nil {
+		return err
+	}
+	if err := global.APP_DB.Create(&providerModel.InstanceSnapshot{
+		InstanceID: instance.ID,
+		UserID:     instance.UserID,
+		Name:       name,
+	}).Error; err != nil {
+		return fmt.Errorf("保存快照记录失败: %w", err)
+	}
+
+	var expired []providerModel.InstanceSnapshot
+	if err := global.APP_DB.Where("instance_id = ?", instance.ID).Order("id DESC").Offset(keep).Find(&expired).Error; err != nil {
+		return fmt.Errorf("查询旧快照失败: %w", err)
+	}
+	for _, old := range expired {
+		// 删除失败不影响本次结果，记录保留到下一次执行时重试
+		if err := snapshotter.DeleteSnapshot(ctx, instance.Name, old.Name); err != nil {
+			global.APP_LOG.Warn("删除旧快照失败",
+				zap.Uint("instanceId", instance.ID),
+				zap.String("snapshot", old.Name),
+				zap.Error(err))
+			continue
+		}
+		global.APP_DB.Delete(&old)
+	}
+	return nil
+}
+
+// record 保存执行结果，首次失败时通知实例所有者
+func record(schedule *providerModel.InstanceSnapshotSchedule, instance *providerModel.Instance, runErr error, now time.Time) error {
+	updates := map[string]interface{}{"last_attempt_at": now}
+	if runErr == nil {
+		updates["last_success_at"] = now
+		updates["last_status"] = providerModel.SnapshotStatusSuccess
+		updates["last_error"] = ""
+		updates["consecutive_failures"] = 0
+	} else {
+		updates["last_status"] = providerModel.SnapshotStatusFailed
+		updates["last_error"] = utils.TruncateString(runErr.Error(), 255)
+		updates["consecutive_failures"] = schedule.ConsecutiveFailures + 1
+		global.APP_LOG.Warn("定时快照失败",
+			zap.Uint("instanceId", instance.ID),
+			zap.String("instanceName", instance.Name),
+			zap.Error(runErr))
+		if schedule.ConsecutiveFailures == 0 {
+			if err := notifyFailure(instance, runErr); err != nil {
+				global.APP_LOG.Warn("发送定时快照失败通知失败", zap.Uint("instanceId", instance.ID), zap.Error(err))
+			}
+		}
+	}
+	return global.APP_DB.Model(schedule).Updates(updates).Error
+}
+
+// Remove 删除实例的快照计划和快照记录，不删除Provider上的快照
+func Remove(instanceID uint) error {
+	return global.APP_DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("instance_id = ?", instanceID).Delete(&providerModel.InstanceSnapshotSchedule{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("instance_id = ?", instanceID).Delete(&providerModel.InstanceSnapshot{}).Error
+	})
+}
+
+// notifyFailure 通知实例所有者定时快照失败，未绑定邮箱或未配置邮件服务时只记录日志
+func notifyFailure(instance *providerModel.Instance, runErr error) error {
+	var user userModel.User
+	if err := global.APP_DB.Select("id, username, email").First(&user, instance.UserID).Error; err != nil {
+		return nil
+	}
+	if user.Email == "" || !utils.MailConfigured() {
+		return nil
+	}
+	subject := fmt.Sprintf("实例 %s 定时快照失败", instance.Name)
+	body := fmt.Sprintf("您好 %s，您的实例 %s 定时快照创建失败：%s<br>"+
+		"系统会在一小时后重试，期间如持续失败不会重复通知，可在控制台查看快照计划的最近状态。",
+		html.EscapeString(user.Username), html.EscapeString(instance.Name), html.EscapeString(runErr.Error()))
+	return utils.SendMail(user.Email, subject, body)
+}
@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/blackout"
+)
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 10, 15, 3, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		schedule  providerModel.InstanceSnapshotSchedule
+		tolerance time.Duration
+		want      bool
+	}{
+		{"never run", providerModel.InstanceSnapshotSchedule{Frequency: "daily"}, 0, true},
+		{"daily not yet", providerModel.InstanceSnapshotSchedule{Frequency: "daily", LastSuccessAt: ago(23 * time.Hour)}, 0, false},
+		{"daily due", providerModel.InstanceSnapshotSchedule{Frequency: "daily", LastSuccessAt: ago(24 * time.Hour)}, 0, true},
+		{"daily early within window", providerModel.InstanceSnapshotSchedule{Frequency: "daily", LastSuccessAt: ago(23 * time.Hour)}, 2 * time.Hour, true},
+		{"weekly not yet", providerModel.InstanceSnapshotSchedule{Frequency: "weekly", LastSuccessAt: ago(6 * 24 * time.Hour)}, 2 * time.Hour, false},
+		{"weekly due", providerModel.InstanceSnapshotSchedule{Frequency: "weekly", LastSuccessAt: ago(7 * 24 * time.Hour)}, 0, true},
+		{"failed retry wait", providerModel.InstanceSnapshotSchedule{Frequency: "daily", LastStatus: "failed", LastAttemptAt: ago(30 * time.Minute)}, 0, false},
+		{"failed retry", providerModel.InstanceSnapshotSchedule{Frequency: "daily", LastStatus: "failed", LastAttemptAt: ago(time.Hour)}, 0, true},
+	}
+	for _, tt := range tests {
+		if got := Due(&tt.schedule, now, tt.tolerance); got != tt.want {
+			t.Errorf("%s: Due = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("daily", 1, 0); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("level without snapshots should be rejected, got %v", err)
+	}
+	if err := Validate("hourly", 1, 3); err == nil {
+		t.Error("unknown frequency should be rejected")
+	}
+	if err := Validate("weekly", 4, 3); err == nil {
+		t.Error("keep above level limit should be rejected")
+	}
+	if err := Validate("weekly", 3, 3); err != nil {
+		t.Errorf("valid schedule rejected: %v", err)
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	if !InWindow(nil, time.Now()) {
+		t.Error("no windows should always allow")
+	}
+	windows, err := blackout.ParseAll([]string{"02:00-06:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !InWindow(windows, time.Date(2026, 10, 15, 3, 0, 0, 0, time.Local)) ||
+		InWindow(windows, time.Date(2026, 10, 15, 7, 0, 0, 0, time.Local)) {
+		t.Error("window check mismatch")
+	}
+}
+
+func TestName(t *testing.T) {
+	name := Name(time.Date(2026, 10, 15, 3, 4, 5, 0, time.UTC))
+	if name != "auto-20261015-030405" || !provider.ValidSnapshotName(name) {
+		t.Errorf("unexpected name %q", name)
+	}
+	if provider.ValidSnapshotName("x; rm -rf /") || provider.ValidSnapshotName("1abc") {
+		t.Error("unsafe names should be rejected")
+	}
+}
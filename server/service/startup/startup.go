@@ -0,0 +1,201 @@
+// Package startup 按实例启动顺序批量启动实例
+// 宿主机重启后大量实例同时处于停止状态，按实例的启动顺序分批创建启动任务，
+// 每批内限制同时启动的数量，一批全部结束并等待其启动延迟后再启动下一批，避免所有实例同时开机压垮节点
+package startup
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/task"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 未配置时每个Provider同时启动的实例数
+	defaultConcurrency = 2
+	// 单个实例的启动延迟上限
+	MaxStartupDelay = 600
+	// 启动任务超时（秒），与用户手动启动一致
+	startTaskTimeout = 1800
+	// 等待启动任务结束时的轮询间隔
+	taskPollInterval = 5 * time.Second
+)
+
+// ErrInProgress 该Provider已有批量启动在进行
+var ErrInProgress = errors.New("该节点已有批量启动正在进行")
+
+// running 正在批量启动的Provider，避免实例同步与手动恢复重复启动
+var running sync.Map // map[uint]struct{}
+
+// Concurrency 返回每个Provider同时启动的实例数
+func Concurrency() int {
+	if n := global.APP_CONFIG.System.StartupConcurrency; n > 0 {
+		return n
+	}
+	return defaultConcurrency
+}
+
+// PlanWaves 按启动顺序将实例分批，顺序值小的批次在前，同一批内按实例ID排序
+func PlanWaves(instances []providerModel.Instance) [][]providerModel.Instance {
+	sorted := make([]providerModel.Instance, len(instances))
+	copy(sorted, instances)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StartupOrder != sorted[j].StartupOrder {
+			return sorted[i].StartupOrder < sorted[j].StartupOrder
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	var waves [][]providerModel.Instance
+	for i, inst := range sorted {
+		if i == 0 || inst.StartupOrder != sorted[i-1].StartupOrder {
+			waves = append(waves, nil)
+		}
+		waves[len(waves)-1] = append(waves[len(waves)-1], inst)
+	}
+	return waves
+}
+
+// waveDelay 一批实例全部启动后需要等待的时间，取批内最大的启动延迟
+func waveDelay(wave []providerModel.Instance) time.Duration {
+	delay := 0
+	for _, inst := range wave {
+		if inst.StartupDelay > delay {
+			delay = inst.StartupDelay
+		}
+	}
+	if delay > MaxStartupDelay {
+		delay = MaxStartupDelay
+	}
+	return time.Duration(delay) * time.Second
+}
+
+// Recover 在后台按启动顺序启动Provider上的指定实例，返回实际排队启动的实例数
+// 冻结、因流量超限停机以及已有进行中操作的实例会被跳过
+func Recover(providerID uint, instanceIDs []uint, reason string) (int, error) {
+	if len(instanceIDs) == 0 {
+		return 0, nil
+	}
+	if _, loaded := running.LoadOrStore(providerID, struct{}{}); loaded {
+		return 0, ErrInProgress
+	}
+
+	var instances []providerModel.Instance
+	err := global.APP_DB.Where("provider_id = ? AND id IN ? AND is_frozen = ? AND traffic_limited = ?",
+		providerID, instanceIDs, false, false).
+		Find(&instances).Error
+	if err != nil {
+		running.Delete(providerID)
+		return 0, fmt.Errorf("查询实例失败: %w", err)
+	}
+	candidates := instances[:0]
+	for _, inst := range instances {
+		if !constant.IsInstanceOperationInProgress(inst.Status) {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(candidates) == 0 {
+		running.Delete(providerID)
+		return 0, nil
+	}
+
+	waves := PlanWaves(candidates)
+	global.APP_LOG.Info("开始按启动顺序恢复实例",
+		zap.Uint("providerId", providerID),
+		zap.String("reason", reason),
+		zap.Int("instances", len(candidates)),
+		zap.Int("waves", len(waves)),
+		zap.Int("concurrency", Concurrency()))
+
+	go func() {
+		defer func() {
+			running.Delete(providerID)
+			if r := recover(); r != nil {
+				global.APP_LOG.Error("按启动顺序恢复实例panic",
+					zap.Uint("providerId", providerID),
+					zap.Any("panic", r))
+			}
+		}()
+		runWaves(providerID, waves)
+	}()
+	return len(candidates), nil
+}
+
+// runWaves 依次启动各批实例
+func runWaves(providerID uint, waves [][]providerModel.Instance) {
+	sem := make(chan struct{}, Concurrency())
+	for i, wave := range waves {
+		if !cluster.IsLeader() {
+			global.APP_LOG.Warn("已不是集群主节点，停止按启动顺序恢复实例", zap.Uint("providerId", providerID))
+			return
+		}
+		var wg sync.WaitGroup
+		for _, inst := range wave {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(inst providerModel.Instance) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				startAndWait(&inst)
+			}(inst)
+		}
+		wg.Wait()
+
+		if delay := waveDelay(wave); delay > 0 && i < len(waves)-1 {
+			global.APP_LOG.Debug("等待实例启动延迟后启动下一批",
+				zap.Uint("providerId", providerID),
+				zap.Int("startupOrder", wave[0].StartupOrder),
+				zap.Duration("delay", delay))
+			time.Sleep(delay)
+		}
+	}
+	global.APP_LOG.Info("按启动顺序恢复实例完成", zap.Uint("providerId", providerID))
+}
+
+// startAndWait 为实例创建启动任务并等待任务结束
+func startAndWait(inst *providerModel.Instance) {
+	var existingTask adminModel.Task
+	if err := global.APP_DB.Where("instance_id = ? AND status IN ('pending', 'running')", inst.ID).First(&existingTask).Error; err == nil {
+		return
+	}
+
+	taskData := fmt.Sprintf(`{"instanceId":%d,"providerId":%d}`, inst.ID, inst.ProviderID)
+	created, err := task.GetTaskService().CreateTask(inst.UserID, &inst.ProviderID, &inst.ID, "start", taskData, startTaskTimeout)
+	if err != nil {
+		global.APP_LOG.Error("创建恢复启动任务失败",
+			zap.Uint("instanceId", inst.ID),
+			zap.Error(err))
+		return
+	}
+	global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", inst.ID).Update("status", constant.InstanceStatusStarting)
+
+	deadline := time.Now().Add(startTaskTimeout * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(taskPollInterval)
+		var current adminModel.Task
+		if err := global.APP_DB.Select("id", "status").First(&current, created.ID).Error; err != nil {
+			return
+		}
+		if current.Status != "pending" && current.Status != "running" {
+			if current.Status != "completed" {
+				global.APP_LOG.Warn("恢复启动任务未成功",
+					zap.Uint("instanceId", inst.ID),
+					zap.Uint("taskId", created.ID),
+					zap.String("status", current.Status))
+			}
+			return
+		}
+	}
+}
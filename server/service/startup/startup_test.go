@@ -0,0 +1,46 @@
+package startup
+
+import (
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestPlanWaves(t *testing.T) {
+	instances := []providerModel.Instance{
+		{ID: 5, StartupOrder: 10},
+		{ID: 3, StartupOrder: 0},
+		{ID: 1, StartupOrder: 10, StartupDelay: 30},
+		{ID: 2, StartupOrder: 0, StartupDelay: 60},
+		{ID: 4, StartupOrder: 20},
+	}
+	waves := PlanWaves(instances)
+	want := [][]uint{{2, 3}, {1, 5}, {4}}
+	if len(waves) != len(want) {
+		t.Fatalf("got %d waves, want %d", len(waves), len(want))
+	}
+	for i, wave := range waves {
+		if len(wave) != len(want[i]) {
+			t.Fatalf("wave %d has %d instances, want %d", i, len(wave), len(want[i]))
+		}
+		for j, inst := range wave {
+			if inst.ID != want[i][j] {
+				t.Errorf("wave %d[%d] = instance %d, want %d", i, j, inst.ID, want[i][j])
+			}
+		}
+	}
+	if instances[0].ID != 5 {
+		t.Error("PlanWaves should not reorder the input")
+	}
+
+	if d := waveDelay(waves[0]); d != 60*time.Second {
+		t.Errorf("waveDelay = %v, want 60s", d)
+	}
+	if d := waveDelay([]providerModel.Instance{{StartupDelay: 3600}}); d != MaxStartupDelay*time.Second {
+		t.Errorf("waveDelay should be capped, got %v", d)
+	}
+	if waves := PlanWaves(nil); len(waves) != 0 {
+		t.Errorf("PlanWaves(nil) = %v", waves)
+	}
+}
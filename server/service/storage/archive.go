@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+// ArchiveExport 将导出文件归档到远程对象存储（仅配置远程存储时生效，失败不影响导出）
+func ArchiveExport(fileName string, data []byte) {
+	if !IsRemoteObjectStorage() {
+		return
+	}
+	store, err := GetObjectStorage()
+	if err != nil {
+		global.APP_LOG.Warn("获取对象存储失败，跳过导出文件归档", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	key := ObjectKey(ObjectPrefixExports, now.Format("2006-01-02"), now.Format("150405")+"_"+filepath.Base(fileName))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data))); err != nil {
+		global.APP_LOG.Warn("导出文件归档失败", zap.String("key", key), zap.Error(err))
+		return
+	}
+	global.APP_LOG.Debug("导出文件已归档到对象存储", zap.String("key", key))
+}
+
+// ArchiveDirectory 将目录打包为 tar.gz 后写入对象存储
+func ArchiveDirectory(ctx context.Context, store ObjectStorage, key, dir string) error {
+	tempDir := GetStorageService().GetTempPath()
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	tmp, err := os.CreateTemp(tempDir, "archive-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("创建临时归档文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeTarGz(tmp, dir); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时归档文件失败: %v", err)
+	}
+	return PutFile(ctx, store, key, tmp.Name())
+}
+
+// writeTarGz 将目录内容以相对路径写入 tar.gz
+func writeTarGz(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("打包目录失败: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("写入归档失败: %v", err)
+	}
+	return gz.Close()
+}
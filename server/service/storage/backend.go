@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+	"oneclickvirt/utils"
+)
+
+// 对象存储类型
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendMinIO = "minio"
+)
+
+// 对象键前缀，按用途划分
+const (
+	ObjectPrefixArtifacts = "artifacts"
+	ObjectPrefixExports   = "exports"
+	ObjectPrefixLogs      = "logs"
+	ObjectPrefixBackups   = "backups"
+	ObjectPrefixConsole   = "console-recordings"
+)
+
+var ErrObjectNotFound = errors.New("对象不存在")
+
+// ObjectStorage 对象存储抽象，用于备份、导出文件、日志归档和自定义镜像
+type ObjectStorage interface {
+	// Type 返回存储类型
+	Type() string
+	// Put 写入对象，size 为 -1 时表示未知大小（仅本地存储支持）
+	Put(ctx context.Context, key string, reader io.Reader, size int64) error
+	// Get 读取对象，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Exists 判断对象是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+	// HealthCheck 检查存储是否可用
+	HealthCheck(ctx context.Context) error
+}
+
+// GetObjectStorage 根据 system.oss-type 返回当前对象存储
+// 每次调用都会读取最新配置，通过ConfigManager修改的凭据可立即生效
+func GetObjectStorage() (ObjectStorage, error) {
+	switch strings.ToLower(global.APP_CONFIG.System.OssType) {
+	case "", BackendLocal:
+		return NewLocalObjectStorage(system.DefaultStorageDir), nil
+	case BackendS3, BackendMinIO:
+		return NewS3ObjectStorage(global.APP_CONFIG.Oss)
+	default:
+		return nil, fmt.Errorf("不支持的对象存储类型: %s", global.APP_CONFIG.System.OssType)
+	}
+}
+
+// IsRemoteObjectStorage 当前是否配置了远程对象存储
+func IsRemoteObjectStorage() bool {
+	ossType := strings.ToLower(global.APP_CONFIG.System.OssType)
+	return ossType == BackendS3 || ossType == BackendMinIO
+}
+
+// ObjectKey 拼接对象键
+func ObjectKey(parts ...string) string {
+	return strings.TrimPrefix(path.Join(parts...), "/")
+}
+
+// PutFile 将本地文件写入对象存储
+func PutFile(ctx context.Context, store ObjectStorage, key, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+	return store.Put(ctx, key, f, stat.Size())
+}
+
+// LocalObjectStorage 本地磁盘存储，对象键映射为存储目录下的相对路径
+type LocalObjectStorage struct {
+	baseDir string
+}
+
+// NewLocalObjectStorage 创建本地存储
+func NewLocalObjectStorage(baseDir string) *LocalObjectStorage {
+	return &LocalObjectStorage{baseDir: baseDir}
+}
+
+// Type 返回存储类型
+func (l *LocalObjectStorage) Type() string {
+	return BackendLocal
+}
+
+// resolve 将对象键转换为本地路径，并防止路径穿越
+func (l *LocalObjectStorage) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", fmt.Errorf("对象键不能为空")
+	}
+	return filepath.Join(l.baseDir, cleaned), nil
+}
+
+// Put 写入对象
+func (l *LocalObjectStorage) Put(ctx context.Context, key string, reader io.Reader, size int64) error {
+	target, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := utils.EnsureDir(filepath.Dir(target)); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	// 先写入临时文件再重命名，避免读取到写了一半的文件
+	tmp := target + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("关闭文件失败: %v", err)
+	}
+	return os.Rename(tmp, target)
+}
+
+// Get 读取对象
+func (l *LocalObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := l.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete 删除对象
+func (l *LocalObjectStorage) Delete(ctx context.Context, key string) error {
+	target, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Exists 判断对象是否存在
+func (l *LocalObjectStorage) Exists(ctx context.Context, key string) (bool, error) {
+	target, err := l.resolve(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(target); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// HealthCheck 检查存储目录是否可写
+func (l *LocalObjectStorage) HealthCheck(ctx context.Context) error {
+	if err := utils.EnsureDir(l.baseDir); err != nil {
+		return fmt.Errorf("存储目录不可用: %v", err)
+	}
+	probe := filepath.Join(l.baseDir, ".health_check")
+	if err := os.WriteFile(probe, []byte("ok"), 0644); err != nil {
+		return fmt.Errorf("存储目录不可写: %v", err)
+	}
+	return os.Remove(probe)
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+)
+
+func TestLocalObjectStorage(t *testing.T) {
+	base := t.TempDir()
+	store := NewLocalObjectStorage(base)
+	ctx := t.Context()
+
+	// 路径穿越的键被限制在存储目录内
+	for _, key := range []string{"backups/a.tar.gz", "../../etc/passwd", "/abs/key", "a/../../b"} {
+		target, err := store.resolve(key)
+		if err != nil {
+			t.Fatalf("resolve(%q): %v", key, err)
+		}
+		if rel, err := filepath.Rel(base, target); err != nil || strings.HasPrefix(rel, "..") {
+			t.Errorf("resolve(%q) = %s 超出存储目录", key, target)
+		}
+	}
+	for _, key := range []string{"", "/", "..", "a/.."} {
+		if _, err := store.resolve(key); err == nil {
+			t.Errorf("resolve(%q) 应拒绝空键", key)
+		}
+	}
+
+	if err := store.Put(ctx, "logs/app.log", strings.NewReader("data"), -1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Exists(ctx, "logs/app.log"); err != nil || !ok {
+		t.Fatalf("Exists = %v, %v", ok, err)
+	}
+	reader, err := store.Get(ctx, "logs/app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "data" {
+		t.Errorf("Get = %q", data)
+	}
+	if err := store.Delete(ctx, "logs/app.log"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "logs/app.log"); err != ErrObjectNotFound {
+		t.Errorf("删除后 Get = %v", err)
+	}
+	if err := store.Delete(ctx, "logs/app.log"); err != nil {
+		t.Errorf("删除不存在的对象应成功: %v", err)
+	}
+}
+
+func TestNewS3ObjectStorage(t *testing.T) {
+	valid := config.Oss{Endpoint: "https://minio.example.com:9000/", Bucket: "b", AccessKey: "ak", SecretKey: "sk", BasePath: "/ocv/"}
+	tests := []struct {
+		name    string
+		modify  func(*config.Oss)
+		wantErr bool
+	}{
+		{name: "完整配置", modify: func(*config.Oss) {}},
+		{name: "缺少endpoint", modify: func(c *config.Oss) { c.Endpoint = " " }, wantErr: true},
+		{name: "缺少bucket", modify: func(c *config.Oss) { c.Bucket = "" }, wantErr: true},
+		{name: "缺少密钥", modify: func(c *config.Oss) { c.SecretKey = "" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		store, err := NewS3ObjectStorage(cfg)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应拒绝", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if store.cfg.Endpoint != "minio.example.com:9000" || store.cfg.Region != s3DefaultRegion || store.cfg.BasePath != "ocv" {
+			t.Errorf("%s: 规范化后的配置 = %+v", tt.name, store.cfg)
+		}
+	}
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	cfg := config.Oss{Endpoint: "s3.example.com", Bucket: "bkt", BasePath: "ocv", UseSSL: true}
+	virtual := &S3ObjectStorage{cfg: cfg}
+	u, uri := virtual.objectURL("logs/a b+c.log")
+	if u.String() != "https://bkt.s3.example.com/ocv/logs/a%20b%2Bc.log" || uri != "/ocv/logs/a%20b%2Bc.log" {
+		t.Errorf("虚拟主机风格 = %s %s", u, uri)
+	}
+
+	cfg.PathStyle = true
+	cfg.UseSSL = false
+	pathStyle := &S3ObjectStorage{cfg: cfg}
+	if u, _ := pathStyle.objectURL("k"); u.String() != "http://s3.example.com/bkt/ocv/k" {
+		t.Errorf("路径风格 = %s", u)
+	}
+	// 空键为存储桶本身，不加前缀
+	if u, _ := pathStyle.objectURL(""); u.String() != "http://s3.example.com/bkt/" {
+		t.Errorf("存储桶地址 = %s", u)
+	}
+}
+
+func TestS3Sign(t *testing.T) {
+	store := &S3ObjectStorage{cfg: config.Oss{Endpoint: "s3.example.com", Bucket: "bkt", Region: "us-east-1", AccessKey: "AK", SecretKey: "SK"}}
+	now := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	sign := func(key string) string {
+		u, uri := store.objectURL(key)
+		req, _ := http.NewRequest(http.MethodGet, u.String(), nil)
+		store.sign(req, uri, now)
+		return req.Header.Get("Authorization")
+	}
+	auth := sign("a.txt")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AK/20240301/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Authorization = %s", auth)
+	}
+	if auth != sign("a.txt") {
+		t.Error("相同请求的签名应一致")
+	}
+	if auth == sign("b.txt") {
+		t.Error("不同对象的签名不应相同")
+	}
+}
+
+// fakeS3 按路径保存对象的最小S3服务，拒绝未签名的请求
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), s3SigningAlgorithm+" Credential=AK/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = string(data)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, data)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3ObjectStorageRoundTrip(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string]string{}})
+	defer server.Close()
+	store, err := NewS3ObjectStorage(config.Oss{Endpoint: server.URL, Bucket: "bkt", AccessKey: "AK", SecretKey: "SK", PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+
+	if err := store.Put(ctx, "k", strings.NewReader("v"), -1); err == nil {
+		t.Error("未知大小的写入应被拒绝")
+	}
+	if err := store.Put(ctx, "k", strings.NewReader("v"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Exists(ctx, "k"); err != nil || !ok {
+		t.Fatalf("Exists = %v, %v", ok, err)
+	}
+	reader, err := store.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "v" {
+		t.Errorf("Get = %q", data)
+	}
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := store.Exists(ctx, "k"); err != nil || ok {
+		t.Errorf("删除后 Exists = %v, %v", ok, err)
+	}
+	if _, err := store.Get(ctx, "k"); err != ErrObjectNotFound {
+		t.Errorf("删除后 Get = %v", err)
+	}
+
+	// 密钥错误时服务端拒绝
+	bad, _ := NewS3ObjectStorage(config.Oss{Endpoint: server.URL, Bucket: "bkt", AccessKey: "other", SecretKey: "SK", PathStyle: true})
+	if err := bad.Put(ctx, "k", strings.NewReader("v"), 1); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("未授权的写入 = %v", err)
+	}
+}
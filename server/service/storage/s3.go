@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/config"
+)
+
+const (
+	s3DefaultRegion    = "us-east-1"
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+)
+
+var (
+	s3HTTPClient     *http.Client
+	s3HTTPClientOnce sync.Once
+)
+
+// getS3HTTPClient 获取对象存储专用HTTP客户端
+// 不设置整体超时，避免大文件传输被中断，仅限制连接和响应头等待时间
+func getS3HTTPClient() *http.Client {
+	s3HTTPClientOnce.Do(func() {
+		s3HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				DialContext: (&net.Dialer{
+					Timeout:   30 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				MaxIdleConns:          20,
+				MaxIdleConnsPerHost:   10,
+				IdleConnTimeout:       90 * time.Second,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 60 * time.Second,
+			},
+		}
+	})
+	return s3HTTPClient
+}
+
+// S3ObjectStorage S3兼容对象存储（AWS S3、MinIO等），使用SigV4签名
+type S3ObjectStorage struct {
+	cfg    config.Oss
+	client *http.Client
+}
+
+// NewS3ObjectStorage 创建S3兼容存储
+func NewS3ObjectStorage(cfg config.Oss) (*S3ObjectStorage, error) {
+	cfg.Endpoint = strings.TrimSpace(cfg.Endpoint)
+	cfg.Endpoint = strings.TrimPrefix(strings.TrimPrefix(cfg.Endpoint, "https://"), "http://")
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("对象存储配置不完整：endpoint 和 bucket 不能为空")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("对象存储配置不完整：access-key 和 secret-key 不能为空")
+	}
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+	cfg.BasePath = strings.Trim(cfg.BasePath, "/")
+	return &S3ObjectStorage{cfg: cfg, client: getS3HTTPClient()}, nil
+}
+
+// Type 返回存储类型
+func (s *S3ObjectStorage) Type() string {
+	return BackendS3
+}
+
+// objectURL 构造对象地址，返回请求URL和规范化的URI路径
+func (s *S3ObjectStorage) objectURL(key string) (*url.URL, string) {
+	scheme := "http"
+	if s.cfg.UseSSL {
+		scheme = "https"
+	}
+
+	fullKey := key
+	if s.cfg.BasePath != "" && key != "" {
+		fullKey = s.cfg.BasePath + "/" + key
+	}
+
+	host := s.cfg.Endpoint
+	rawPath := "/" + fullKey
+	if s.cfg.PathStyle {
+		rawPath = "/" + s.cfg.Bucket + "/" + fullKey
+	} else {
+		host = s.cfg.Bucket + "." + s.cfg.Endpoint
+	}
+
+	escaped := s3EscapePath(rawPath)
+	return &url.URL{Scheme: scheme, Host: host, Path: rawPath, RawPath: escaped}, escaped
+}
+
+// newRequest 创建已签名的请求
+func (s *S3ObjectStorage) newRequest(ctx context.Context, method, key string, body io.Reader, size int64) (*http.Request, error) {
+	u, canonicalURI := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, canonicalURI, time.Now().UTC())
+	return req, nil
+}
+
+// sign 使用AWS Signature Version 4对请求签名
+func (s *S3ObjectStorage) sign(req *http.Request, canonicalURI string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	shortDate := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", s3UnsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + s3UnsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := shortDate + "/" + s.cfg.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := s3SigningAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), shortDate)
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgorithm, s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// do 发送请求并检查响应状态码
+func (s *S3ObjectStorage) do(req *http.Request, okStatus ...int) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求对象存储失败: %v", err)
+	}
+	for _, code := range okStatus {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("对象存储返回错误状态 %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// Put 写入对象
+func (s *S3ObjectStorage) Put(ctx context.Context, key string, reader io.Reader, size int64) error {
+	if size < 0 {
+		return fmt.Errorf("S3存储写入对象时必须指定大小")
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, reader, size)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get 读取对象
+func (s *S3ObjectStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete 删除对象
+func (s *S3ObjectStorage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, http.StatusOK, http.StatusNoContent)
+	if err == ErrObjectNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists 判断对象是否存在
+func (s *S3ObjectStorage) Exists(ctx context.Context, key string) (bool, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err == ErrObjectNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// HealthCheck 通过 HEAD Bucket 检查存储桶是否可访问
+func (s *S3ObjectStorage) HealthCheck(ctx context.Context) error {
+	req, err := s.newRequest(ctx, http.MethodHead, "", nil, 0)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, http.StatusOK)
+	if err == ErrObjectNotFound {
+		return fmt.Errorf("存储桶 %s 不存在", s.cfg.Bucket)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3EscapePath 按照SigV4规范对路径进行URI编码（保留 /）
+func s3EscapePath(p string) string {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,476 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+	"oneclickvirt/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUploadChunkSizeMB     = 8
+	defaultUploadMaxFileSizeMB   = 20480
+	defaultUploadSessionExpireHr = 24
+
+	// 分片上传临时文件目录（位于 temp 目录下）
+	uploadPartsDir = "uploads"
+	// 分片上传完成后的归档目录（位于 uploads 目录下）
+	uploadArtifactsDir = "artifacts"
+)
+
+var (
+	ErrUploadSessionNotFound = errors.New("上传会话不存在")
+	ErrUploadOffsetMismatch  = errors.New("上传偏移量不匹配")
+	ErrUploadChecksumInvalid = errors.New("分片校验和不匹配")
+)
+
+// CreateUploadRequest 创建上传会话请求
+type CreateUploadRequest struct {
+	FileName  string `json:"fileName" binding:"required,max=255"`
+	Purpose   string `json:"purpose" binding:"required,oneof=image backup"`
+	TotalSize int64  `json:"totalSize" binding:"required,min=1"`
+	Checksum  string `json:"checksum" binding:"omitempty,len=64,hexadecimal"` // 整个文件的SHA256（可选）
+}
+
+// UploadService 分片上传服务，负责大文件的断点续传与完整性校验
+type UploadService struct {
+	locks sync.Map // uuid -> *sync.Mutex，防止同一会话并发写入
+}
+
+var (
+	uploadService     *UploadService
+	uploadServiceOnce sync.Once
+)
+
+// GetUploadService 获取分片上传服务单例
+func GetUploadService() *UploadService {
+	uploadServiceOnce.Do(func() {
+		uploadService = &UploadService{}
+	})
+	return uploadService
+}
+
+// getUploadLimits 读取上传配置，未配置时使用默认值
+func getUploadLimits() (chunkSize int64, maxFileSize int64, expire time.Duration) {
+	cfg := global.APP_CONFIG.Upload
+	chunkMB := cfg.ChunkSize
+	if chunkMB <= 0 {
+		chunkMB = defaultUploadChunkSizeMB
+	}
+	maxMB := cfg.MaxFileSize
+	if maxMB <= 0 {
+		maxMB = defaultUploadMaxFileSizeMB
+	}
+	expireHours := cfg.SessionExpireHours
+	if expireHours <= 0 {
+		expireHours = defaultUploadSessionExpireHr
+	}
+	return int64(chunkMB) * 1024 * 1024, int64(maxMB) * 1024 * 1024, time.Duration(expireHours) * time.Hour
+}
+
+// partPath 返回上传中的临时文件路径
+func (s *UploadService) partPath(sessionUUID string) string {
+	return filepath.Join(system.DefaultStorageDir, system.TempDir, uploadPartsDir, sessionUUID+".part")
+}
+
+// artifactPath 返回完成上传后的文件路径
+func (s *UploadService) artifactPath(session *system.UploadSession) string {
+	return filepath.Join(system.DefaultStorageDir, system.UploadsDir, uploadArtifactsDir, session.Purpose,
+		session.UUID+"_"+filepath.Base(session.FileName))
+}
+
+func (s *UploadService) lock(sessionUUID string) func() {
+	v, _ := s.locks.LoadOrStore(sessionUUID, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// CreateSession 创建上传会话
+func (s *UploadService) CreateSession(userID uint, req CreateUploadRequest) (*system.UploadSession, error) {
+	chunkSize, maxFileSize, expire := getUploadLimits()
+	if req.TotalSize > maxFileSize {
+		return nil, fmt.Errorf("文件大小超过限制（最大 %d MB）", maxFileSize/1024/1024)
+	}
+
+	fileName := filepath.Base(strings.TrimSpace(req.FileName))
+	if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+		return nil, fmt.Errorf("文件名无效")
+	}
+
+	session := &system.UploadSession{
+		UUID:      uuid.New().String(),
+		UserID:    userID,
+		FileName:  fileName,
+		Purpose:   req.Purpose,
+		TotalSize: req.TotalSize,
+		ChunkSize: chunkSize,
+		Checksum:  strings.ToLower(req.Checksum),
+		Status:    system.UploadStatusUploading,
+		ExpiresAt: time.Now().Add(expire),
+	}
+	session.StoragePath = s.partPath(session.UUID)
+
+	if err := utils.EnsureDir(filepath.Dir(session.StoragePath)); err != nil {
+		return nil, fmt.Errorf("创建上传临时目录失败: %v", err)
+	}
+	// 预先创建空文件，便于续传时直接按偏移写入
+	f, err := os.OpenFile(session.StoragePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建上传临时文件失败: %v", err)
+	}
+	f.Close()
+
+	if err := global.APP_DB.Create(session).Error; err != nil {
+		os.Remove(session.StoragePath)
+		return nil, fmt.Errorf("创建上传会话失败: %v", err)
+	}
+
+	global.APP_LOG.Info("创建分片上传会话",
+		zap.String("uuid", session.UUID),
+		zap.Uint("userId", userID),
+		zap.String("fileName", fileName),
+		zap.String("purpose", req.Purpose),
+		zap.Int64("totalSize", req.TotalSize))
+	return session, nil
+}
+
+// GetSession 获取上传会话
+func (s *UploadService) GetSession(sessionUUID string) (*system.UploadSession, error) {
+	var session system.UploadSession
+	if err := global.APP_DB.Where("uuid = ?", sessionUUID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadSessionNotFound
+		}
+		return nil, fmt.Errorf("查询上传会话失败: %v", err)
+	}
+	return &session, nil
+}
+
+// ListSessions 分页获取上传会话
+func (s *UploadService) ListSessions(purpose, status string, page, pageSize int) ([]system.UploadSession, int64, error) {
+	query := global.APP_DB.Model(&system.UploadSession{})
+	if purpose != "" {
+		query = query.Where("purpose = ?", purpose)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计上传会话失败: %v", err)
+	}
+
+	var sessions []system.UploadSession
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&sessions).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询上传会话失败: %v", err)
+	}
+	return sessions, total, nil
+}
+
+// AppendChunk 在指定偏移量写入一个分片
+// offset 必须等于服务端已记录的上传大小，checksum 为可选的分片SHA256（十六进制）
+func (s *UploadService) AppendChunk(sessionUUID string, offset int64, data io.Reader, checksum string) (*system.UploadSession, error) {
+	unlock := s.lock(sessionUUID)
+	defer unlock()
+
+	session, err := s.GetSession(sessionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != system.UploadStatusUploading {
+		return nil, fmt.Errorf("上传会话状态为 %s，无法继续上传", session.Status)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, fmt.Errorf("上传会话已过期")
+	}
+	if offset != session.UploadedSize {
+		return session, ErrUploadOffsetMismatch
+	}
+
+	remaining := session.TotalSize - session.UploadedSize
+	limit := session.ChunkSize
+	if remaining < limit {
+		limit = remaining
+	}
+
+	f, err := os.OpenFile(session.StoragePath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开上传临时文件失败: %v", err)
+	}
+	defer f.Close()
+
+	// 丢弃上次中断时可能残留的未确认数据
+	if err := f.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("截断上传临时文件失败: %v", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("定位上传临时文件失败: %v", err)
+	}
+
+	hasher := sha256.New()
+	// 多读取一个字节用于判断分片是否超出限制
+	written, err := io.Copy(io.MultiWriter(f, hasher), io.LimitReader(data, limit+1))
+	if err != nil {
+		f.Truncate(offset)
+		return nil, fmt.Errorf("写入分片失败: %v", err)
+	}
+	if written > limit {
+		f.Truncate(offset)
+		return nil, fmt.Errorf("分片大小超过限制（最大 %d 字节）", limit)
+	}
+	if written == 0 {
+		return nil, fmt.Errorf("分片内容为空")
+	}
+	if checksum != "" && !strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), checksum) {
+		f.Truncate(offset)
+		return nil, ErrUploadChecksumInvalid
+	}
+	if err := f.Sync(); err != nil {
+		f.Truncate(offset)
+		return nil, fmt.Errorf("同步分片数据失败: %v", err)
+	}
+
+	newOffset := offset + written
+	if err := global.APP_DB.Model(session).Update("uploaded_size", newOffset).Error; err != nil {
+		f.Truncate(offset)
+		return nil, fmt.Errorf("更新上传进度失败: %v", err)
+	}
+	session.UploadedSize = newOffset
+	return session, nil
+}
+
+// CompleteSession 完成上传：校验文件完整性并移动到归档目录
+func (s *UploadService) CompleteSession(sessionUUID string) (*system.UploadSession, error) {
+	unlock := s.lock(sessionUUID)
+	defer unlock()
+
+	session, err := s.GetSession(sessionUUID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == system.UploadStatusCompleted {
+		return session, nil
+	}
+	if session.Status != system.UploadStatusUploading {
+		return nil, fmt.Errorf("上传会话状态为 %s，无法完成", session.Status)
+	}
+	if session.UploadedSize != session.TotalSize {
+		return nil, fmt.Errorf("文件尚未上传完成（%d/%d 字节）", session.UploadedSize, session.TotalSize)
+	}
+
+	actual, err := fileSHA256(session.StoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("计算文件校验和失败: %v", err)
+	}
+	if session.Checksum != "" && session.Checksum != actual {
+		errMsg := fmt.Sprintf("文件校验和不匹配，期望 %s，实际 %s", session.Checksum, actual)
+		global.APP_DB.Model(session).Updates(map[string]interface{}{
+			"status":        system.UploadStatusFailed,
+			"error_message": errMsg,
+		})
+		os.Remove(session.StoragePath)
+		global.APP_LOG.Warn("分片上传完整性校验失败",
+			zap.String("uuid", session.UUID),
+			zap.String("expected", session.Checksum),
+			zap.String("actual", actual))
+		return nil, errors.New(errMsg)
+	}
+
+	backend, target, err := s.archiveArtifact(session)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := global.APP_DB.Model(session).Updates(map[string]interface{}{
+		"status":       system.UploadStatusCompleted,
+		"checksum":     actual,
+		"backend":      backend,
+		"storage_path": target,
+		"completed_at": now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("更新上传会话失败: %v", err)
+	}
+	session.Status = system.UploadStatusCompleted
+	session.Checksum = actual
+	session.Backend = backend
+	session.StoragePath = target
+	session.CompletedAt = &now
+
+	global.APP_LOG.Info("分片上传完成",
+		zap.String("uuid", session.UUID),
+		zap.String("fileName", session.FileName),
+		zap.Int64("size", session.TotalSize))
+	return session, nil
+}
+
+// archiveArtifact 将校验通过的文件归档
+// 配置了远程对象存储时上传到对象存储并删除本地文件，否则移动到本地归档目录
+func (s *UploadService) archiveArtifact(session *system.UploadSession) (string, string, error) {
+	if IsRemoteObjectStorage() {
+		store, err := GetObjectStorage()
+		if err != nil {
+			return "", "", err
+		}
+		key := ObjectKey(ObjectPrefixArtifacts, session.Purpose, session.UUID+"_"+filepath.Base(session.FileName))
+		if err := PutFile(context.Background(), store, key, session.StoragePath); err != nil {
+			return "", "", fmt.Errorf("上传文件到对象存储失败: %v", err)
+		}
+		os.Remove(session.StoragePath)
+		return store.Type(), key, nil
+	}
+
+	target := s.artifactPath(session)
+	if err := utils.EnsureDir(filepath.Dir(target)); err != nil {
+		return "", "", fmt.Errorf("创建上传归档目录失败: %v", err)
+	}
+	if err := os.Rename(session.StoragePath, target); err != nil {
+		return "", "", fmt.Errorf("移动上传文件失败: %v", err)
+	}
+	return BackendLocal, target, nil
+}
+
+// removeArtifact 删除会话对应的文件（临时文件或已归档文件）
+func (s *UploadService) removeArtifact(session *system.UploadSession) error {
+	if session.StoragePath == "" {
+		return nil
+	}
+	if session.Backend != "" && session.Backend != BackendLocal {
+		store, err := GetObjectStorage()
+		if err != nil {
+			return err
+		}
+		return store.Delete(context.Background(), session.StoragePath)
+	}
+	if err := os.Remove(session.StoragePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// OpenArtifact 打开已完成上传的文件，调用方负责关闭
+func (s *UploadService) OpenArtifact(ctx context.Context, session *system.UploadSession) (io.ReadCloser, error) {
+	if session.Status != system.UploadStatusCompleted {
+		return nil, fmt.Errorf("上传文件尚未完成校验")
+	}
+	if session.Backend != "" && session.Backend != BackendLocal {
+		store, err := GetObjectStorage()
+		if err != nil {
+			return nil, err
+		}
+		return store.Get(ctx, session.StoragePath)
+	}
+	return os.Open(session.StoragePath)
+}
+
+// CancelSession 取消上传会话并删除已上传的数据
+func (s *UploadService) CancelSession(sessionUUID string) error {
+	unlock := s.lock(sessionUUID)
+	defer unlock()
+
+	session, err := s.GetSession(sessionUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.removeArtifact(session); err != nil {
+		global.APP_LOG.Warn("删除上传文件失败", zap.String("path", session.StoragePath), zap.Error(err))
+	}
+	if session.Status == system.UploadStatusUploading {
+		if err := global.APP_DB.Model(session).Update("status", system.UploadStatusCancelled).Error; err != nil {
+			return fmt.Errorf("更新上传会话失败: %v", err)
+		}
+	}
+	if err := global.APP_DB.Delete(session).Error; err != nil {
+		return fmt.Errorf("删除上传会话失败: %v", err)
+	}
+	s.locks.Delete(sessionUUID)
+	return nil
+}
+
+// CleanupExpiredSessions 清理过期未完成的上传会话
+func (s *UploadService) CleanupExpiredSessions() (int, error) {
+	var sessions []system.UploadSession
+	if err := global.APP_DB.Where("status = ? AND expires_at < ?", system.UploadStatusUploading, time.Now()).
+		Limit(100).Find(&sessions).Error; err != nil {
+		return 0, fmt.Errorf("查询过期上传会话失败: %v", err)
+	}
+
+	cleaned := 0
+	for _, session := range sessions {
+		unlock := s.lock(session.UUID)
+		if err := os.Remove(session.StoragePath); err != nil && !os.IsNotExist(err) {
+			global.APP_LOG.Warn("删除过期上传文件失败", zap.String("path", session.StoragePath), zap.Error(err))
+		}
+		if err := global.APP_DB.Model(&session).Update("status", system.UploadStatusExpired).Error; err == nil {
+			cleaned++
+		}
+		unlock()
+		s.locks.Delete(session.UUID)
+	}
+	return cleaned, nil
+}
+
+// ResolveReference 解析 upload://<uuid> 引用，返回已完成的上传会话
+func (s *UploadService) ResolveReference(ref string, purpose string) (*system.UploadSession, error) {
+	if !IsUploadReference(ref) {
+		return nil, fmt.Errorf("无效的上传引用: %s", ref)
+	}
+	session, err := s.GetSession(strings.TrimPrefix(ref, system.UploadReferencePrefix))
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != system.UploadStatusCompleted {
+		return nil, fmt.Errorf("上传文件尚未完成校验")
+	}
+	if purpose != "" && session.Purpose != purpose {
+		return nil, fmt.Errorf("上传文件用途不匹配，期望 %s，实际 %s", purpose, session.Purpose)
+	}
+	return session, nil
+}
+
+// ArtifactDownloadURL 返回已完成上传文件的公开下载地址，供Provider节点拉取镜像
+// 需要配置 system.frontend-url 且节点能够访问该地址
+func ArtifactDownloadURL(session *system.UploadSession) (string, error) {
+	baseURL := strings.TrimRight(global.APP_CONFIG.System.FrontendURL, "/")
+	if baseURL == "" {
+		return "", fmt.Errorf("未配置 system.frontend-url，无法生成上传文件的下载地址")
+	}
+	return fmt.Sprintf("%s/api/v1/public/artifacts/%s/%s", baseURL, session.UUID, session.FileName), nil
+}
+
+// IsUploadReference 判断地址是否为上传文件引用
+func IsUploadReference(ref string) bool {
+	return strings.HasPrefix(ref, system.UploadReferencePrefix)
+}
+
+// fileSHA256 计算文件SHA256
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+	"oneclickvirt/testutil"
+)
+
+// setupUploadTest 在临时目录中使用内存数据库，分片大小为 1 MB
+func setupUploadTest(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	testutil.UseSQLiteDB(t, &system.UploadSession{})
+	testutil.RestoreConfig(t)
+	global.APP_CONFIG.Upload.ChunkSize = 1
+	global.APP_CONFIG.Upload.MaxFileSize = 2
+	global.APP_CONFIG.System.OssType = ""
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreateUploadSession(t *testing.T) {
+	setupUploadTest(t)
+	svc := &UploadService{}
+	tests := []struct {
+		name    string
+		req     CreateUploadRequest
+		wantErr bool
+	}{
+		{name: "正常", req: CreateUploadRequest{FileName: "disk.qcow2", Purpose: system.UploadPurposeImage, TotalSize: 10}},
+		{name: "路径只保留文件名", req: CreateUploadRequest{FileName: "../../etc/disk.qcow2", Purpose: system.UploadPurposeImage, TotalSize: 10}},
+		{name: "超过大小限制", req: CreateUploadRequest{FileName: "big.img", Purpose: system.UploadPurposeImage, TotalSize: 3 << 20}, wantErr: true},
+		{name: "空文件名", req: CreateUploadRequest{FileName: "  ", Purpose: system.UploadPurposeImage, TotalSize: 10}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := svc.CreateSession(1, tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("应拒绝创建")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if session.FileName != "disk.qcow2" || session.ChunkSize != 1<<20 {
+				t.Errorf("session = %+v", session)
+			}
+		})
+	}
+}
+
+func TestAppendChunkAndComplete(t *testing.T) {
+	setupUploadTest(t)
+	svc := &UploadService{}
+	content := "hello world"
+	session, err := svc.CreateSession(1, CreateUploadRequest{
+		FileName: "restore.tar.gz", Purpose: system.UploadPurposeBackup,
+		TotalSize: int64(len(content)), Checksum: sha256Hex(content),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name     string
+		offset   int64
+		data     string
+		checksum string
+		wantErr  error // nil 表示期望成功
+		anyErr   bool
+		uploaded int64
+	}{
+		{name: "首个分片", offset: 0, data: "hello", checksum: sha256Hex("hello"), uploaded: 5},
+		{name: "偏移量不匹配", offset: 0, data: " world", wantErr: ErrUploadOffsetMismatch},
+		{name: "分片校验和错误", offset: 5, data: " world", checksum: sha256Hex("other"), wantErr: ErrUploadChecksumInvalid},
+		{name: "超出文件大小", offset: 5, data: " world!!", anyErr: true},
+		{name: "空分片", offset: 5, data: "", anyErr: true},
+		{name: "续传剩余部分", offset: 5, data: " world", uploaded: 11},
+	}
+	for _, step := range steps {
+		got, err := svc.AppendChunk(session.UUID, step.offset, strings.NewReader(step.data), step.checksum)
+		switch {
+		case step.wantErr != nil:
+			if !errors.Is(err, step.wantErr) {
+				t.Fatalf("%s: err = %v, want %v", step.name, err, step.wantErr)
+			}
+		case step.anyErr:
+			if err == nil {
+				t.Fatalf("%s: 应拒绝", step.name)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("%s: %v", step.name, err)
+			}
+			if got.UploadedSize != step.uploaded {
+				t.Fatalf("%s: uploaded = %d, want %d", step.name, got.UploadedSize, step.uploaded)
+			}
+		}
+	}
+
+	// 完成前不能被引用
+	ref := system.UploadReferencePrefix + session.UUID
+	if _, err := svc.ResolveReference(ref, system.UploadPurposeBackup); err == nil {
+		t.Fatal("未完成的上传不应被引用")
+	}
+	if _, err := svc.CompleteSession(session.UUID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AppendChunk(session.UUID, 11, strings.NewReader("x"), ""); err == nil {
+		t.Fatal("已完成的会话不应继续写入")
+	}
+
+	resolveTests := []struct {
+		name    string
+		ref     string
+		purpose string
+		wantErr bool
+	}{
+		{name: "用途一致", ref: ref, purpose: system.UploadPurposeBackup},
+		{name: "不限用途", ref: ref},
+		{name: "用途不一致", ref: ref, purpose: system.UploadPurposeImage, wantErr: true},
+		{name: "缺少前缀", ref: session.UUID, wantErr: true},
+		{name: "不存在的会话", ref: system.UploadReferencePrefix + "missing", wantErr: true},
+	}
+	for _, tt := range resolveTests {
+		resolved, err := svc.ResolveReference(tt.ref, tt.purpose)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应拒绝", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		reader, err := svc.OpenArtifact(t.Context(), resolved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(data) != content {
+			t.Errorf("%s: 读取内容 = %q", tt.name, data)
+		}
+	}
+}
+
+func TestCompleteSessionChecksumMismatch(t *testing.T) {
+	setupUploadTest(t)
+	svc := &UploadService{}
+	session, err := svc.CreateSession(1, CreateUploadRequest{
+		FileName: "disk.img", Purpose: system.UploadPurposeImage, TotalSize: 3, Checksum: sha256Hex("abd"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CompleteSession(session.UUID); err == nil {
+		t.Fatal("未上传完成时不应完成")
+	}
+	if _, err := svc.AppendChunk(session.UUID, 0, strings.NewReader("abc"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CompleteSession(session.UUID); err == nil {
+		t.Fatal("整体校验和不一致时应失败")
+	}
+	failed, err := svc.GetSession(session.UUID)
+	if err != nil || failed.Status != system.UploadStatusFailed {
+		t.Fatalf("会话状态 = %+v, %v", failed, err)
+	}
+}
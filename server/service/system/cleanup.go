@@ -9,6 +9,7 @@ import (
 	userModel "oneclickvirt/model/user"
 
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	"oneclickvirt/service/blackout"
 	"oneclickvirt/service/resources"
 
 	"go.uber.org/zap"
@@ -256,8 +257,14 @@ func (s *InstanceCleanupService) CleanupExpiredInstances() error {
 		}
 	}
 
-	// 逐个清理过期实例
+	// 逐个清理过期实例，处于维护窗口的Provider上的实例推迟到窗口结束后删除
+	checker := blackout.NewChecker(now)
+	deferred := 0
 	for _, instance := range expiredInstances {
+		if checker.Active(instance.ProviderID) {
+			deferred++
+			continue
+		}
 		if err := s.cleanupSingleExpiredInstance(&instance, providerMap); err != nil {
 			global.APP_LOG.Error("清理过期实例时发生错误",
 				zap.Uint("instanceId", instance.ID),
@@ -267,7 +274,9 @@ func (s *InstanceCleanupService) CleanupExpiredInstances() error {
 		}
 	}
 
-	global.APP_LOG.Info("过期实例清理完成", zap.Int("processedCount", len(expiredInstances)))
+	global.APP_LOG.Info("过期实例清理完成",
+		zap.Int("processedCount", len(expiredInstances)-deferred),
+		zap.Int("deferredCount", deferred))
 	return nil
 }
 
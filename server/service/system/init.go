@@ -15,13 +15,13 @@ import (
 	"oneclickvirt/model/resource"
 	"oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/database"
 	"oneclickvirt/utils"
 
 	configManager "oneclickvirt/config"
 
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -49,16 +49,22 @@ func (s *InitService) CheckDatabaseConnection() error {
 
 // TestDatabaseConnection 测试数据库连接（不需要全局DB连接）
 func (s *InitService) TestDatabaseConnection(config config.DatabaseConfig) error {
-	if config.Type != "mysql" && config.Type != "mariadb" {
-		return fmt.Errorf("不支持的数据库类型: %s，仅支持mysql和mariadb", config.Type)
+	if !database.IsSupportedType(config.Type) {
+		return fmt.Errorf("不支持的数据库类型: %s，仅支持mysql、mariadb、postgres和sqlite", config.Type)
+	}
+	if database.IsSQLiteType(config.Type) {
+		return testSQLiteConnection(config.Database)
 	}
 
-	// 构建DSN，先不指定数据库名
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=utf8mb4&parseTime=True&loc=Local&time_zone=%%27%%2B08%%3A00%%27",
-		config.Username, config.Password, config.Host, config.Port)
+	opts := database.ConnOptions{
+		Host:     config.Host,
+		Port:     strconv.Itoa(config.Port),
+		Username: config.Username,
+		Password: config.Password,
+	}
 
-	// 尝试连接数据库服务器（MySQL或MariaDB使用相同的连接方式）
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	// 尝试连接数据库服务器，先不指定数据库名
+	db, err := gorm.Open(database.NewDialector(config.Type, opts), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
@@ -77,26 +83,17 @@ func (s *InitService) TestDatabaseConnection(config config.DatabaseConfig) error
 	}
 
 	// 检查数据库是否存在，如果不存在则创建
-	var count int64
-	err = db.Raw("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", config.Database).Scan(&count).Error
+	created, err := database.EnsureDatabase(db, config.Database)
 	if err != nil {
-		return fmt.Errorf("检查数据库是否存在失败: %v", err)
+		return err
 	}
-
-	if count == 0 {
-		// 数据库不存在，尝试创建
-		err = db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", config.Database)).Error
-		if err != nil {
-			return fmt.Errorf("创建数据库失败: %v", err)
-		}
+	if created {
 		global.APP_LOG.Info("数据库不存在，已自动创建", zap.String("database", config.Database))
 	}
 
 	// 测试连接到具体数据库
-	dsnWithDB := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local&time_zone=%%27%%2B08%%3A00%%27",
-		config.Username, config.Password, config.Host, config.Port, config.Database)
-
-	dbWithDB, err := gorm.Open(mysql.Open(dsnWithDB), &gorm.Config{
+	opts.Dbname = config.Database
+	dbWithDB, err := gorm.Open(database.NewDialector(config.Type, opts), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
@@ -116,6 +113,41 @@ func (s *InitService) TestDatabaseConnection(config config.DatabaseConfig) error
 	return nil
 }
 
+// testSQLiteConnection 测试SQLite数据库文件是否可以打开并写入，文件不存在时自动创建
+func testSQLiteConnection(path string) error {
+	if path == "" {
+		return fmt.Errorf("SQLite数据库文件路径不能为空")
+	}
+	created, err := database.EnsureSQLiteFile(path)
+	if err != nil {
+		return err
+	}
+
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{
+		Dbname: path,
+		Config: database.DefaultConfig(utils.DBTypeSQLite),
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("打开SQLite数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库实例失败: %v", err)
+	}
+	defer sqlDB.Close()
+
+	// 只读挂载或没有写权限时在这里暴露问题
+	if err := db.Exec("CREATE TABLE IF NOT EXISTS connection_test (id INTEGER); DROP TABLE connection_test").Error; err != nil {
+		return fmt.Errorf("SQLite数据库不可写: %v", err)
+	}
+	if created {
+		global.APP_LOG.Info("SQLite数据库文件不存在，已自动创建", zap.String("path", path))
+	}
+	return nil
+}
+
 // AutoMigrateTables 自动迁移所有表结构
 func (s *InitService) AutoMigrateTables() error {
 	if global.APP_DB == nil {
@@ -124,6 +156,10 @@ func (s *InitService) AutoMigrateTables() error {
 
 	global.APP_LOG.Debug("开始执行数据库表结构自动迁移")
 
+	if err := database.PrepareSchema(global.APP_DB); err != nil {
+		return fmt.Errorf("表结构迁移准备失败: %v", err)
+	}
+
 	// 执行表结构迁移
 	err := global.APP_DB.AutoMigrate(
 		// 用户相关表
@@ -205,14 +241,19 @@ func (s *InitService) UpdateDatabaseConfig(dbConfig config.DatabaseConfig) error
 		// 更新系统配置
 		updates["system.db-type"] = dbConfig.Type
 
-		// 对于MySQL和MariaDB，都使用相同的配置结构
-		if dbConfig.Type == "mysql" || dbConfig.Type == "mariadb" {
+		// SQLite 使用独立的配置段，database 字段为数据库文件路径
+		// MySQL、MariaDB和PostgreSQL都使用相同的配置结构
+		if database.IsSQLiteType(dbConfig.Type) {
+			for key, value := range sqliteConfigUpdates(dbConfig) {
+				updates[key] = value
+			}
+		} else if database.IsSupportedType(dbConfig.Type) {
 			updates["mysql.path"] = dbConfig.Host
 			updates["mysql.port"] = strconv.Itoa(dbConfig.Port)
 			updates["mysql.db-name"] = dbConfig.Database
 			updates["mysql.username"] = dbConfig.Username
 			updates["mysql.password"] = dbConfig.Password
-			updates["mysql.config"] = "charset=utf8mb4&parseTime=True&loc=Local&time_zone=%27%2B08%3A00%27"
+			updates["mysql.config"] = database.DefaultConfig(dbConfig.Type)
 			updates["mysql.prefix"] = ""
 			updates["mysql.singular"] = false
 			updates["mysql.engine"] = "InnoDB"
@@ -245,15 +286,21 @@ func (s *InitService) UpdateDatabaseConfig(dbConfig config.DatabaseConfig) error
 		global.APP_LOG.Warn("更新 system.db-type 失败", zap.Error(err))
 	}
 
-	// 对于MySQL和MariaDB，更新配置
-	if dbConfig.Type == "mysql" || dbConfig.Type == "mariadb" {
+	// MySQL、MariaDB和PostgreSQL都使用相同的配置结构
+	if database.IsSQLiteType(dbConfig.Type) {
+		for key, value := range sqliteConfigUpdates(dbConfig) {
+			if err := updateYAMLNodeValue(&node, key, fmt.Sprint(value)); err != nil {
+				global.APP_LOG.Warn("更新配置失败", zap.String("key", key), zap.Error(err))
+			}
+		}
+	} else if database.IsSupportedType(dbConfig.Type) {
 		mysqlUpdates := map[string]interface{}{
 			"mysql.path":           dbConfig.Host,
 			"mysql.port":           strconv.Itoa(dbConfig.Port),
 			"mysql.db-name":        dbConfig.Database,
 			"mysql.username":       dbConfig.Username,
 			"mysql.password":       dbConfig.Password,
-			"mysql.config":         "charset=utf8mb4&parseTime=True&loc=Local&time_zone=%27%2B08%3A00%27",
+			"mysql.config":         database.DefaultConfig(dbConfig.Type),
 			"mysql.prefix":         "",
 			"mysql.singular":       "false",
 			"mysql.engine":         "InnoDB",
@@ -303,6 +350,21 @@ func (s *InitService) UpdateDatabaseConfig(dbConfig config.DatabaseConfig) error
 	return nil
 }
 
+// sqliteConfigUpdates SQLite配置项，未指定文件路径时使用默认路径
+func sqliteConfigUpdates(dbConfig config.DatabaseConfig) map[string]interface{} {
+	path := dbConfig.Database
+	if path == "" {
+		path = database.DefaultSQLitePath
+	}
+	return map[string]interface{}{
+		"sqlite.path":           path,
+		"sqlite.busy-timeout":   database.DefaultSQLiteBusyTimeout,
+		"sqlite.journal-mode":   database.DefaultSQLiteJournalMode,
+		"sqlite.max-open-conns": 8,
+		"sqlite.log-mode":       "error",
+	}
+}
+
 // updateYAMLNodeValue 更新YAML节点的值（辅助函数）
 func updateYAMLNodeValue(node *yaml.Node, path string, value interface{}) error {
 	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 {
@@ -443,6 +505,17 @@ func (s *InitService) ReinitializeDatabase() error {
 		return fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
+	// 读取数据库类型，默认为MySQL
+	dbType := "mysql"
+	if systemConfig, ok := c["system"].(map[string]interface{}); ok {
+		if v, ok := systemConfig["db-type"].(string); ok && v != "" {
+			dbType = v
+		}
+	}
+	if database.IsSQLiteType(dbType) {
+		return s.reinitializeSQLite()
+	}
+
 	// 获取 MySQL 配置
 	mysqlConfig, ok := c["mysql"].(map[string]interface{})
 	if !ok {
@@ -472,37 +545,33 @@ func (s *InitService) ReinitializeDatabase() error {
 			portStr = fmt.Sprintf("%d", v)
 		case float64:
 			portStr = fmt.Sprintf("%.0f", v)
-		default:
-			portStr = "3306" // 默认端口
 		}
-	} else {
-		portStr = "3306" // 默认端口
 	}
 
-	// 如果端口为空，设置默认值
+	// 如果端口为空，使用数据库类型的默认端口
 	if portStr == "" {
-		portStr = "3306"
+		portStr = database.DefaultPort(dbType)
 	}
 
 	if host == "" || username == "" || dbname == "" {
 		return fmt.Errorf("数据库配置不完整")
 	}
 
-	// 构建DSN并连接数据库
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?%s",
-		username, password, host, portStr, dbname, config)
-
-	mysqlDriverConfig := mysql.Config{
-		DSN:                       dsn,
-		DefaultStringSize:         191,
-		SkipInitializeWithVersion: false,
-	}
+	// 构建Dialector并连接数据库
+	dialector := database.NewDialector(dbType, database.ConnOptions{
+		Host:     host,
+		Port:     portStr,
+		Username: username,
+		Password: password,
+		Dbname:   dbname,
+		Config:   config,
+	})
 
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	}
 
-	db, err := gorm.Open(mysql.New(mysqlDriverConfig), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		return fmt.Errorf("重新连接数据库失败: %v", err)
 	}
@@ -514,6 +583,35 @@ func (s *InitService) ReinitializeDatabase() error {
 	return nil
 }
 
+// reinitializeSQLite 按 sqlite 配置段重新打开SQLite数据库
+func (s *InitService) reinitializeSQLite() error {
+	cfg := global.APP_CONFIG.Sqlite
+	if cfg.Path == "" {
+		cfg.Path = database.DefaultSQLitePath
+	}
+	if _, err := database.EnsureSQLiteFile(cfg.Path); err != nil {
+		return err
+	}
+
+	db, err := gorm.Open(database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{
+		Dbname: cfg.Path,
+		Config: database.SQLiteConfig(cfg.BusyTimeout, cfg.JournalMode),
+	}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("重新连接数据库失败: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil && cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxOpenConns)
+	}
+
+	global.APP_DB = db
+	global.APP_LOG.Info("数据库连接已更新", zap.String("path", cfg.Path))
+	return nil
+}
+
 // reloadConfig 重新加载配置文件到 global.APP_CONFIG
 // 手动修改 config.yaml 后调用此方法，会：
 // 1. 将 YAML 配置同步到数据库
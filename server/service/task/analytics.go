@@ -0,0 +1,418 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalyticsHours = 24
+	maxAnalyticsHours     = 90 * 24
+	defaultTopErrors      = 10
+	// 参与错误归类的失败任务上限，取时间窗口内最近的记录
+	maxErrorSamples = 5000
+	// 归一化后错误信息的最大长度
+	maxErrorPatternLength = 200
+)
+
+// analyticsTask 统计所需的任务字段，避免加载 task_data、log_output 等大字段
+type analyticsTask struct {
+	ID              uint
+	TaskType        string
+	Status          string
+	ProviderID      *uint
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	CompletedAt     *time.Time
+	TimeoutDuration int
+}
+
+// GetTaskAnalytics 按时间窗口聚合任务数量、失败原因和阶段耗时
+// 聚合在内存中完成，MySQL、PostgreSQL 和 SQLite 的结果一致
+func (s *TaskService) GetTaskAnalytics(req adminModel.TaskAnalyticsRequest) (*adminModel.TaskAnalyticsResponse, error) {
+	hours := req.Hours
+	if hours <= 0 {
+		hours = defaultAnalyticsHours
+	}
+	if hours > maxAnalyticsHours {
+		hours = maxAnalyticsHours
+	}
+	bucket := req.Bucket
+	if bucket != "hour" && bucket != "day" {
+		bucket = "hour"
+		if hours > 72 {
+			bucket = "day"
+		}
+	}
+	topN := req.TopErrors
+	if topN <= 0 {
+		topN = defaultTopErrors
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Duration(hours) * time.Hour)
+	resp := &adminModel.TaskAnalyticsResponse{
+		Start:    start,
+		End:      end,
+		Bucket:   bucket,
+		Statuses: make(map[string]int64),
+	}
+
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("created_at >= ?", start)
+		if req.ProviderID > 0 {
+			db = db.Where("provider_id = ?", req.ProviderID)
+		}
+		if req.TaskType != "" {
+			db = db.Where("task_type = ?", req.TaskType)
+		}
+		return db
+	}
+
+	byType := make(map[string]*adminModel.TaskGroupStats)
+	byProvider := make(map[string]*adminModel.TaskGroupStats)
+	timeline := make(map[time.Time]*adminModel.TaskTimelinePoint)
+	durations := make(map[string]*durationAccumulator)
+
+	var batch []analyticsTask
+	err := global.APP_DB.Model(&adminModel.Task{}).
+		Scopes(filter).
+		Select("id, task_type, status, provider_id, created_at, started_at, completed_at, timeout_duration").
+		FindInBatches(&batch, 1000, func(tx *gorm.DB, _ int) error {
+			for _, t := range batch {
+				resp.Total++
+				resp.Statuses[t.Status]++
+				addGroupStats(byType, t.TaskType, t.Status)
+				providerKey := "0"
+				if t.ProviderID != nil {
+					providerKey = strconv.FormatUint(uint64(*t.ProviderID), 10)
+				}
+				addGroupStats(byProvider, providerKey, t.Status)
+				addTimelinePoint(timeline, truncateBucket(t.CreatedAt, bucket), t.Status)
+				if t.StartedAt != nil && t.CompletedAt != nil {
+					acc := durations[t.TaskType]
+					if acc == nil {
+						acc = &durationAccumulator{}
+						durations[t.TaskType] = acc
+					}
+					acc.add(t)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计任务失败: %w", err)
+	}
+
+	resp.ByType = sortedGroups(byType)
+	resp.ByProvider = sortedGroups(byProvider)
+	if err := fillProviderNames(resp.ByProvider); err != nil {
+		return nil, err
+	}
+	resp.Timeline = fillTimeline(timeline, start, end, bucket)
+	resp.Durations = make([]adminModel.TaskPhaseDuration, 0, len(durations))
+	for taskType, acc := range durations {
+		resp.Durations = append(resp.Durations, acc.result(taskType))
+	}
+	sort.Slice(resp.Durations, func(i, j int) bool { return resp.Durations[i].TaskType < resp.Durations[j].TaskType })
+
+	if resp.TopErrors, err = topTaskErrors(filter, topN); err != nil {
+		return nil, err
+	}
+	if resp.StuckTasks, err = s.GetStuckTasks(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetStuckTasks 查询运行时间已超过任务超时时间但仍未结束的任务
+// 超过宽限期后会被看门狗（EnforceTaskTimeouts）强制终止，持续出现在这里通常意味着看门狗未运行或数据库写入失败
+func (s *TaskService) GetStuckTasks() ([]adminModel.StuckTask, error) {
+	var running []adminModel.Task
+	if err := global.APP_DB.
+		Select("id, task_type, status, user_id, provider_id, instance_id, started_at, timeout_duration, progress, status_message").
+		Where("status IN ? AND started_at IS NOT NULL", []string{"running", "processing", "cancelling"}).
+		Order("started_at ASC").
+		Find(&running).Error; err != nil {
+		return nil, fmt.Errorf("查询运行中任务失败: %w", err)
+	}
+
+	now := time.Now()
+	stuck := make([]adminModel.StuckTask, 0)
+	for _, t := range running {
+		timeout := t.TimeoutDuration
+		if timeout <= 0 {
+			timeout = s.getDefaultTimeout(t.TaskType)
+		}
+		overdue := now.Sub(t.StartedAt.Add(time.Duration(timeout) * time.Second))
+		if overdue <= 0 {
+			continue
+		}
+		stuck = append(stuck, adminModel.StuckTask{
+			ID:              t.ID,
+			TaskType:        t.TaskType,
+			Status:          t.Status,
+			UserID:          t.UserID,
+			ProviderID:      t.ProviderID,
+			InstanceID:      t.InstanceID,
+			StartedAt:       *t.StartedAt,
+			TimeoutDuration: timeout,
+			OverdueSeconds:  int64(overdue / time.Second),
+			Progress:        t.Progress,
+			StatusMessage:   t.StatusMessage,
+		})
+	}
+	return stuck, nil
+}
+
+// isFailureStatus failed 和 timeout 计为失败
+func isFailureStatus(status string) bool {
+	return status == "failed" || status == "timeout"
+}
+
+// isFinishedStatus 任务是否已结束
+func isFinishedStatus(status string) bool {
+	return status == "completed" || status == "cancelled" || isFailureStatus(status)
+}
+
+func addGroupStats(groups map[string]*adminModel.TaskGroupStats, key, status string) {
+	g := groups[key]
+	if g == nil {
+		g = &adminModel.TaskGroupStats{Key: key, Statuses: make(map[string]int64)}
+		groups[key] = g
+	}
+	g.Total++
+	g.Statuses[status]++
+}
+
+// sortedGroups 按任务数降序输出并计算失败率
+func sortedGroups(groups map[string]*adminModel.TaskGroupStats) []adminModel.TaskGroupStats {
+	result := make([]adminModel.TaskGroupStats, 0, len(groups))
+	for _, g := range groups {
+		var finished, failed int64
+		for status, count := range g.Statuses {
+			if isFinishedStatus(status) {
+				finished += count
+			}
+			if isFailureStatus(status) {
+				failed += count
+			}
+		}
+		if finished > 0 {
+			g.FailureRate = float64(failed) / float64(finished)
+		}
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// fillProviderNames 补充Provider名称，已删除的Provider保留ID
+func fillProviderNames(groups []adminModel.TaskGroupStats) error {
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if g.Key != "0" {
+			ids = append(ids, g.Key)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var providers []providerModel.Provider
+	if err := global.APP_DB.Unscoped().Select("id, name").Where("id IN ?", ids).Find(&providers).Error; err != nil {
+		return fmt.Errorf("查询Provider名称失败: %w", err)
+	}
+	names := make(map[string]string, len(providers))
+	for _, p := range providers {
+		names[strconv.FormatUint(uint64(p.ID), 10)] = p.Name
+	}
+	for i := range groups {
+		groups[i].Name = names[groups[i].Key]
+	}
+	return nil
+}
+
+func truncateBucket(t time.Time, bucket string) time.Time {
+	t = t.Local()
+	if bucket == "day" {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(time.Hour)
+}
+
+func addTimelinePoint(points map[time.Time]*adminModel.TaskTimelinePoint, at time.Time, status string) {
+	p := points[at]
+	if p == nil {
+		p = &adminModel.TaskTimelinePoint{Time: at}
+		points[at] = p
+	}
+	p.Total++
+	switch {
+	case status == "completed":
+		p.Completed++
+	case isFailureStatus(status):
+		p.Failed++
+	case status == "cancelled":
+		p.Cancelled++
+	}
+}
+
+// fillTimeline 输出连续的时间桶，没有任务的时间桶计数为0
+func fillTimeline(points map[time.Time]*adminModel.TaskTimelinePoint, start, end time.Time, bucket string) []adminModel.TaskTimelinePoint {
+	result := make([]adminModel.TaskTimelinePoint, 0)
+	for at := truncateBucket(start, bucket); !at.After(end); {
+		if p := points[at]; p != nil {
+			result = append(result, *p)
+		} else {
+			result = append(result, adminModel.TaskTimelinePoint{Time: at})
+		}
+		if bucket == "day" {
+			at = at.AddDate(0, 0, 1)
+		} else {
+			at = at.Add(time.Hour)
+		}
+	}
+	return result
+}
+
+// durationAccumulator 累计单个任务类型的阶段耗时
+type durationAccumulator struct {
+	count, success, failure         int64
+	queue, run, runSuccess, runFail float64
+	maxRun                          float64
+}
+
+func (a *durationAccumulator) add(t analyticsTask) {
+	queue := t.StartedAt.Sub(t.CreatedAt).Seconds()
+	run := t.CompletedAt.Sub(*t.StartedAt).Seconds()
+	if queue < 0 || run < 0 {
+		return
+	}
+	a.count++
+	a.queue += queue
+	a.run += run
+	if run > a.maxRun {
+		a.maxRun = run
+	}
+	switch {
+	case t.Status == "completed":
+		a.success++
+		a.runSuccess += run
+	case isFailureStatus(t.Status):
+		a.failure++
+		a.runFail += run
+	}
+}
+
+func (a *durationAccumulator) result(taskType string) adminModel.TaskPhaseDuration {
+	d := adminModel.TaskPhaseDuration{TaskType: taskType, Count: a.count, MaxRunSeconds: a.maxRun}
+	if a.count > 0 {
+		d.AvgQueueSeconds = a.queue / float64(a.count)
+		d.AvgRunSeconds = a.run / float64(a.count)
+	}
+	if a.success > 0 {
+		d.AvgRunSecondsSuccess = a.runSuccess / float64(a.success)
+	}
+	if a.failure > 0 {
+		d.AvgRunSecondsFailure = a.runFail / float64(a.failure)
+	}
+	return d
+}
+
+// topTaskErrors 对时间窗口内最近的失败任务按归一化后的错误信息分组
+func topTaskErrors(filter func(*gorm.DB) *gorm.DB, topN int) ([]adminModel.TaskErrorGroup, error) {
+	var failed []struct {
+		ID           uint
+		TaskType     string
+		ErrorMessage string
+		UpdatedAt    time.Time
+	}
+	if err := global.APP_DB.Model(&adminModel.Task{}).
+		Scopes(filter).
+		Select("id, task_type, error_message, updated_at").
+		Where("status IN ?", []string{"failed", "timeout"}).
+		Order("id DESC").
+		Limit(maxErrorSamples).
+		Find(&failed).Error; err != nil {
+		return nil, fmt.Errorf("查询失败任务失败: %w", err)
+	}
+
+	groups := make(map[string]*adminModel.TaskErrorGroup)
+	types := make(map[string]map[string]bool)
+	for _, f := range failed {
+		pattern := NormalizeTaskError(f.ErrorMessage)
+		g := groups[pattern]
+		if g == nil {
+			// 按ID降序遍历，首次出现即为最近一次
+			g = &adminModel.TaskErrorGroup{Pattern: pattern, LastSeen: f.UpdatedAt, SampleTaskID: f.ID, Sample: f.ErrorMessage}
+			groups[pattern] = g
+			types[pattern] = make(map[string]bool)
+		}
+		g.Count++
+		if !types[pattern][f.TaskType] {
+			types[pattern][f.TaskType] = true
+			g.TaskTypes = append(g.TaskTypes, f.TaskType)
+		}
+	}
+
+	result := make([]adminModel.TaskErrorGroup, 0, len(groups))
+	for _, g := range groups {
+		sort.Strings(g.TaskTypes)
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	if len(result) > topN {
+		result = result[:topN]
+	}
+	return result, nil
+}
+
+// 错误信息中随任务变化的部分，按顺序替换为占位符
+var errorNormalizers = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){2,7}[0-9a-fA-F]{1,4}\b`), "<ip>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{12,}\b`), "<hex>"},
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`\d+(?:\.\d+)?`), "<n>"},
+	{regexp.MustCompile(`\s+`), " "},
+}
+
+// NormalizeTaskError 去除错误信息中的ID、IP、数字、引号内容等变化部分，使同类错误归为一组
+func NormalizeTaskError(message string) string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return "(无错误信息)"
+	}
+	for _, n := range errorNormalizers {
+		message = n.pattern.ReplaceAllString(message, n.repl)
+	}
+	if runes := []rune(message); len(runes) > maxErrorPatternLength {
+		message = string(runes[:maxErrorPatternLength]) + "..."
+	}
+	return message
+}
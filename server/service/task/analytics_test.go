@@ -0,0 +1,22 @@
+package task
+
+import "testing"
+
+func TestNormalizeTaskError(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"连接 10.0.0.5:22 超时，已重试3次", "连接 192.168.1.9:2222 超时，已重试5次"},
+		{`容器 "web-123" 不存在: 6f1c2a9b8d7e6f5a`, `容器 "db" 不存在: 0a1b2c3d4e5f6a7b`},
+		{"instance 3f2504e0-4f89-11d3-9a0c-0305e82c3301 not found", "instance 9b2f1a7c-0000-4000-8000-123456789abc not found"},
+	}
+	for _, c := range cases {
+		if a, b := NormalizeTaskError(c.a), NormalizeTaskError(c.b); a != b {
+			t.Errorf("NormalizeTaskError(%q) = %q, NormalizeTaskError(%q) = %q, want equal", c.a, a, c.b, b)
+		}
+	}
+	if got := NormalizeTaskError("  "); got != "(无错误信息)" {
+		t.Errorf("empty message = %q", got)
+	}
+	if a, b := NormalizeTaskError("磁盘空间不足"), NormalizeTaskError("镜像下载失败"); a == b {
+		t.Errorf("different errors normalized to %q", a)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"oneclickvirt/constant"
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
@@ -302,9 +303,9 @@ func (s *TaskService) handleCancelledTaskCleanup(taskID uint) {
 		}
 
 		// 恢复实例状态（如果是deleting状态）
-		if instance.Status == "deleting" {
+		if instance.Status == constant.InstanceStatusDeleting {
 			// 尝试恢复到之前的状态，如果无法确定则设为stopped
-			newStatus := "stopped"
+			newStatus := constant.InstanceStatusStopped
 			if err := global.APP_DB.Model(&instance).Update("status", newStatus).Error; err != nil {
 				global.APP_LOG.Error("恢复实例状态失败",
 					zap.Uint("instanceId", instance.ID),
@@ -341,9 +342,9 @@ func (s *TaskService) handleCancelledTaskCleanup(taskID uint) {
 		}
 
 		// 恢复实例状态（如果是resetting状态）
-		if instance.Status == "resetting" {
+		if instance.Status == constant.InstanceStatusResetting {
 			// 尝试从任务数据中获取原始状态
-			originalStatus := "stopped"
+			originalStatus := constant.InstanceStatusStopped
 			if origStatus, ok := taskData["originalStatus"].(string); ok && origStatus != "" {
 				originalStatus = origStatus
 			}
@@ -378,18 +379,18 @@ func (s *TaskService) handleCancelledTaskCleanup(taskID uint) {
 
 		switch task.TaskType {
 		case "start":
-			if instance.Status == "starting" {
-				originalStatus = "stopped"
+			if instance.Status == constant.InstanceStatusStarting {
+				originalStatus = constant.InstanceStatusStopped
 				shouldRevert = true
 			}
 		case "stop":
-			if instance.Status == "stopping" {
-				originalStatus = "running"
+			if instance.Status == constant.InstanceStatusStopping {
+				originalStatus = constant.InstanceStatusRunning
 				shouldRevert = true
 			}
 		case "restart":
-			if instance.Status == "restarting" {
-				originalStatus = "running"
+			if instance.Status == constant.InstanceStatusRestarting {
+				originalStatus = constant.InstanceStatusRunning
 				shouldRevert = true
 			}
 		}
@@ -10,8 +10,10 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	"oneclickvirt/service/chaos"
 	"oneclickvirt/service/database"
 	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/rdns"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
 	"time"
@@ -162,6 +164,9 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 			zap.Error(err))
 	}
 
+	// 清理反向解析记录（可能包含SSH或外部API调用）
+	rdns.CleanupInstance(deleteCtx, &instance)
+
 	// 更新进度 (90%)
 	s.updateTaskProgress(task.ID, 90, "正在清理数据库记录...")
 
@@ -191,6 +196,18 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 			// 端口映射删除失败不阻止整个流程
 		}
 
+		if err := chaos.Inject(chaos.PointDeleteBeforeDBCleanup); err != nil {
+			return err
+		}
+
+		// 创建失败回滚时已释放资源和配额，直接删除记录
+		if taskReq.ResourcesReleased {
+			if err := tx.Delete(&instance).Error; err != nil {
+				return fmt.Errorf("删除实例记录失败: %v", err)
+			}
+			return nil
+		}
+
 		// 2. 释放Provider资源
 		resourceService := &resources.ResourceService{}
 		if err := resourceService.ReleaseResourcesInTx(tx, instanceProviderID, instanceType,
@@ -249,15 +266,22 @@ func (s *TaskService) executeDeleteInstanceTask(ctx context.Context, task *admin
 			zap.Uint("instanceId", instanceID),
 			zap.Error(err))
 
-		// 恢复实例状态为stopped，避免卡在deleting状态
+		// 恢复实例状态为stopped，避免卡在deleting状态；已释放资源的失败实例恢复为failed，不再计入资源占用
+		recoverStatus := "stopped"
+		if taskReq.ResourcesReleased {
+			recoverStatus = "failed"
+		}
 		if recoverErr := global.APP_DB.Model(&providerModel.Instance{}).
 			Where("id = ?", instanceID).
-			Update("status", "stopped").Error; recoverErr != nil {
+			Update("status", recoverStatus).Error; recoverErr != nil {
 			global.APP_LOG.Error("恢复实例状态失败",
 				zap.Uint("instanceId", instanceID),
 				zap.Error(recoverErr))
 		}
 
+		if point, ok := chaos.InjectedPoint(err); ok {
+			chaos.VerifyAfterRollback(point, instanceUserID, instanceProviderID)
+		}
 		return err
 	}
 
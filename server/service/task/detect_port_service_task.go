@@ -0,0 +1,141 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/portprobe"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// maxDetectPorts 单个探测任务最多探测的端口映射数
+const maxDetectPorts = 50
+
+// ErrPortDetectInProgress 实例已有进行中的服务类型探测任务
+var ErrPortDetectInProgress = errors.New("该实例已有进行中的端口服务探测任务")
+
+// CreateDetectPortServiceTask 创建端口映射服务类型探测任务，需要实例所有者同意后才能探测
+// portIDs 为空时探测实例的全部TCP端口映射
+func (s *TaskService) CreateDetectPortServiceTask(userID uint, inst *providerModel.Instance, portIDs []uint, consent bool) (*adminModel.Task, error) {
+	if !consent {
+		return nil, fmt.Errorf("探测会从面板连接实例的映射端口，请确认同意后再提交")
+	}
+	if inst.PublicIP == "" {
+		return nil, fmt.Errorf("实例没有公网地址，无法探测")
+	}
+	ports, err := detectablePorts(inst.ID, portIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("没有可探测的TCP端口映射")
+	}
+
+	var running int64
+	if err := global.APP_DB.Model(&adminModel.Task{}).
+		Where("instance_id = ? AND task_type = ? AND status IN ?", inst.ID, adminModel.TaskTypeDetectPortService, []string{"pending", "processing", "running"}).
+		Count(&running).Error; err != nil {
+		return nil, err
+	}
+	if running > 0 {
+		return nil, ErrPortDetectInProgress
+	}
+
+	taskData, err := json.Marshal(adminModel.DetectPortServiceTaskRequest{InstanceID: inst.ID, PortIDs: portIDs, Consent: consent})
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+	providerID := inst.ProviderID
+	task, err := s.CreateTask(userID, &providerID, &inst.ID, adminModel.TaskTypeDetectPortService, string(taskData), utils.GetDefaultTaskTimeout(adminModel.TaskTypeDetectPortService))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.StartTask(task.ID); err != nil {
+		global.APP_LOG.Warn("启动端口服务探测任务失败，等待调度器重试", zap.Uint("taskId", task.ID), zap.Error(err))
+	}
+
+	global.APP_LOG.Info("创建端口服务探测任务",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("instanceId", inst.ID),
+		zap.Int("ports", len(ports)))
+	return task, nil
+}
+
+// detectablePorts 返回实例可探测的端口映射：启用中的TCP映射，端口段只探测起始端口
+func detectablePorts(instanceID uint, portIDs []uint) ([]providerModel.Port, error) {
+	query := global.APP_DB.Where("instance_id = ? AND status = ? AND protocol IN ?", instanceID, "active", []string{"tcp", "both"})
+	if len(portIDs) > 0 {
+		query = query.Where("id IN ?", portIDs)
+	}
+	var ports []providerModel.Port
+	if err := query.Order("is_ssh DESC, host_port ASC").Limit(maxDetectPorts).Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("查询端口映射失败: %v", err)
+	}
+	return ports, nil
+}
+
+// executeDetectPortServiceTask 执行端口映射服务类型探测任务，逐个连接映射端口并保存识别结果
+func (s *TaskService) executeDetectPortServiceTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 5, "正在解析任务数据...")
+
+	var taskReq adminModel.DetectPortServiceTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	if !taskReq.Consent {
+		return fmt.Errorf("未获得实例所有者同意，不执行探测")
+	}
+
+	var inst providerModel.Instance
+	if err := global.APP_DB.First(&inst, taskReq.InstanceID).Error; err != nil {
+		return fmt.Errorf("查询实例失败: %v", err)
+	}
+	if inst.PublicIP == "" {
+		return fmt.Errorf("实例没有公网地址，无法探测")
+	}
+	ports, err := detectablePorts(inst.ID, taskReq.PortIDs)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("没有可探测的TCP端口映射")
+	}
+
+	identified := 0
+	for i, port := range ports {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("探测已中止: %v", err)
+		}
+		s.updateTaskProgress(task.ID, 10+80*i/len(ports), fmt.Sprintf("正在探测端口 %d (%d/%d)...", port.HostPort, i+1, len(ports)))
+
+		res := portprobe.Detect(ctx, inst.PublicIP, port.HostPort)
+		now := time.Now()
+		if err := global.APP_DB.Model(&providerModel.Port{}).Where("id = ?", port.ID).Updates(map[string]interface{}{
+			"service_type":        res.ServiceType,
+			"service_banner":      res.Banner,
+			"service_tls_name":    res.TLSName,
+			"service_detected_at": &now,
+		}).Error; err != nil {
+			return fmt.Errorf("保存端口 %d 的探测结果失败: %v", port.HostPort, err)
+		}
+		if res.ServiceType != portprobe.ServiceUnknown && res.ServiceType != portprobe.ServiceUnreachable {
+			identified++
+		}
+		global.APP_LOG.Debug("端口服务探测结果",
+			zap.Uint("portId", port.ID),
+			zap.Int("hostPort", port.HostPort),
+			zap.String("serviceType", res.ServiceType),
+			zap.String("error", res.Error))
+	}
+
+	s.updateTaskProgress(task.ID, 100, fmt.Sprintf("端口服务探测完成：%d 个端口中识别出 %d 个服务", len(ports), identified))
+	return nil
+}
@@ -0,0 +1,118 @@
+package task
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// taskEventsResyncInterval 回源数据库的间隔，补齐未经过进度函数的状态变化（开始执行、取消、超时等）和其他节点写入的进度
+	taskEventsResyncInterval = 5 * time.Second
+	// taskEventsKeepAlive 无事件时发送心跳的间隔，防止反向代理断开空闲连接
+	taskEventsKeepAlive = 15 * time.Second
+)
+
+// isTaskFinished 任务是否已进入终态
+func isTaskFinished(status string) bool {
+	switch status {
+	case adminModel.TaskStatusCompleted, adminModel.TaskStatusFailed, adminModel.TaskStatusCancelled, "timeout":
+		return true
+	}
+	return false
+}
+
+// taskSnapshot 由数据库中的任务生成事件
+func taskSnapshot(task *adminModel.Task) utils.TaskProgressEvent {
+	message := task.StatusMessage
+	if task.Status == adminModel.TaskStatusFailed && task.ErrorMessage != "" {
+		message = task.ErrorMessage
+	}
+	return utils.TaskProgressEvent{
+		TaskID:   task.ID,
+		Status:   task.Status,
+		Progress: task.Progress,
+		Message:  message,
+		Time:     task.UpdatedAt,
+	}
+}
+
+// StreamTaskEvents 以SSE推送任务进度，直到任务结束或客户端断开
+// 连接建立后先推送一次当前状态（snapshot事件），之后每次进度变化推送progress事件，任务结束时推送done事件后关闭连接
+func StreamTaskEvents(c *gin.Context, task *adminModel.Task) {
+	events, unsubscribe := utils.SubscribeTaskEvents(task.ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// 服务器的WriteTimeout会中断长连接，每次推送前顺延写超时
+	rc := http.NewResponseController(c.Writer)
+	extendDeadline := func() {
+		_ = rc.SetWriteDeadline(time.Now().Add(2 * taskEventsKeepAlive))
+	}
+	extendDeadline()
+
+	last := taskSnapshot(task)
+	c.SSEvent("snapshot", last)
+	if isTaskFinished(last.Status) {
+		c.SSEvent("done", last)
+		return
+	}
+
+	resync := time.NewTicker(taskEventsResyncInterval)
+	defer resync.Stop()
+	keepAlive := time.NewTicker(taskEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	// send 推送与上次不同的事件，返回是否继续推送
+	send := func(event utils.TaskProgressEvent) bool {
+		if event.Status == "" {
+			event.Status = last.Status
+		}
+		if event.Status == last.Status && event.Progress == last.Progress && event.Message == last.Message {
+			return true
+		}
+		if event.Status != adminModel.TaskStatusCompleted && event.Progress < last.Progress {
+			// 失败事件不携带进度，保留失败前的进度
+			event.Progress = last.Progress
+		}
+		last = event
+		keepAlive.Reset(taskEventsKeepAlive)
+		if isTaskFinished(event.Status) {
+			c.SSEvent("done", event)
+			return false
+		}
+		c.SSEvent("progress", event)
+		return true
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		extendDeadline()
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-global.APP_SHUTDOWN_CONTEXT.Done():
+			return false
+		case event := <-events:
+			return send(event)
+		case <-resync.C:
+			var current adminModel.Task
+			if err := global.APP_DB.First(&current, task.ID).Error; err != nil {
+				return false
+			}
+			return send(taskSnapshot(&current))
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
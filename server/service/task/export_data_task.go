@@ -0,0 +1,40 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	"oneclickvirt/service/dataexport"
+
+	"go.uber.org/zap"
+)
+
+// executeExportDataTask 执行用户数据导出任务，生成的压缩包写入对象存储
+func (s *TaskService) executeExportDataTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 5, "正在解析任务数据...")
+
+	var taskReq adminModel.ExportDataTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+
+	export, err := dataexport.Generate(ctx, taskReq.ExportID, func(percent int, message string) {
+		s.updateTaskProgress(task.ID, percent, message)
+	})
+	if err != nil {
+		dataexport.MarkFailed(taskReq.ExportID, err.Error())
+		return fmt.Errorf("生成导出文件失败: %v", err)
+	}
+
+	s.updateTaskProgress(task.ID, 100, fmt.Sprintf("数据导出完成，下载链接有效期至 %s", export.ExpiresAt.Format("2006-01-02 15:04")))
+
+	global.APP_LOG.Info("用户数据导出完成",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("exportId", export.ID),
+		zap.Uint("userId", export.UserID),
+		zap.Int64("size", export.Size))
+	return nil
+}
@@ -2,46 +2,48 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
+	userprovider "oneclickvirt/service/user/provider"
 	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
-// updateTaskProgress 更新任务进度（使用全局工具函数）
+// updateTaskProgress 更新任务进度（数据库故障时进入重试队列）
 func (s *TaskService) updateTaskProgress(taskID uint, progress int, message string) {
-	utils.UpdateTaskProgress(taskID, progress, message)
+	s.dbService.SubmitTaskProgress(taskID, progress, message)
 }
 
-// getDefaultTimeout 获取默认超时时间（使用全局工具函数）
+// getDefaultTimeout 获取默认超时时间，自定义任务类型优先使用注册时指定的值
 func (s *TaskService) getDefaultTimeout(taskType string) int {
+	if handler, ok := getTaskHandler(taskType); ok && handler.DefaultTimeout > 0 {
+		return handler.DefaultTimeout
+	}
 	return utils.GetDefaultTaskTimeout(taskType)
 }
 
+// taskTimeoutGrace 任务超过超时时间后的宽限期
+// 任务上下文在超时时已被取消，宽限期内由任务自身完成失败处理和补偿，之后仍未结束才由看门狗强制终止
+const taskTimeoutGrace = 2 * time.Minute
+
 // CleanupTimeoutTasksWithLockRelease 清理超时任务并释放锁
+// running/processing 任务按各自的超时时间强制终止；cancelling 任务在 timeoutThreshold 之前未结束的直接标记为已取消
 func (s *TaskService) CleanupTimeoutTasksWithLockRelease(timeoutThreshold time.Time) (int64, int64) {
-	var timeoutRunningTasks []adminModel.Task
-	var timeoutCancellingTasks []adminModel.Task
+	count1 := s.EnforceTaskTimeouts()
 
-	// 获取超时的running任务
-	global.APP_DB.Where("status = ? AND updated_at < ?", "running", timeoutThreshold).Find(&timeoutRunningTasks)
+	var timeoutCancellingTasks []adminModel.Task
 
 	// 获取超时的cancelling任务
 	global.APP_DB.Where("status = ? AND updated_at < ?", "cancelling", timeoutThreshold).Find(&timeoutCancellingTasks)
 
-	// 更新超时的running任务
-	result1 := global.APP_DB.Model(&adminModel.Task{}).
-		Where("status = ? AND updated_at < ?", "running", timeoutThreshold).
-		Updates(map[string]interface{}{
-			"status":        "timeout",
-			"cancel_reason": "Task timeout - exceeded 30 minutes",
-			"updated_at":    time.Now(),
-		})
-
 	// 更新超时的cancelling任务
-	result2 := global.APP_DB.Model(&adminModel.Task{}).
+	result := global.APP_DB.Model(&adminModel.Task{}).
 		Where("status = ? AND updated_at < ?", "cancelling", timeoutThreshold).
 		Updates(map[string]interface{}{
 			"status":        "cancelled",
@@ -49,23 +51,15 @@ func (s *TaskService) CleanupTimeoutTasksWithLockRelease(timeoutThreshold time.T
 			"updated_at":    time.Now(),
 		})
 
-	var count1, count2 int64
-	if result1.Error == nil {
-		count1 = result1.RowsAffected
-	}
-	if result2.Error == nil {
-		count2 = result2.RowsAffected
+	var count2 int64
+	if result.Error == nil {
+		count2 = result.RowsAffected
 	}
 
 	// 异步清理超时任务的实例状态
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		// 清理running超时任务的实例状态
-		for _, task := range timeoutRunningTasks {
-			s.handleCancelledTaskCleanup(task.ID)
-		}
-		// 清理cancelling超时任务的实例状态
 		for _, task := range timeoutCancellingTasks {
 			s.handleCancelledTaskCleanup(task.ID)
 		}
@@ -74,6 +68,100 @@ func (s *TaskService) CleanupTimeoutTasksWithLockRelease(timeoutThreshold time.T
 	return count1, count2
 }
 
+// EnforceTaskTimeouts 看门狗：将执行时间超过超时时间的任务标记为失败，取消其上下文并执行补偿
+// 返回被终止的任务数
+func (s *TaskService) EnforceTaskTimeouts() int64 {
+	stuck, err := s.GetStuckTasks()
+	if err != nil {
+		global.APP_LOG.Error("查询超时任务失败", zap.Error(err))
+		return 0
+	}
+
+	var count int64
+	for _, t := range stuck {
+		// cancelling 任务由取消超时处理
+		if t.Status == "cancelling" || time.Duration(t.OverdueSeconds)*time.Second < taskTimeoutGrace {
+			continue
+		}
+		if s.timeoutTask(t) {
+			count++
+		}
+	}
+
+	if count > 0 && global.APP_SCHEDULER != nil {
+		global.APP_SCHEDULER.TriggerTaskProcessing()
+	}
+	return count
+}
+
+// timeoutTask 终止单个超时任务，任务已被其他流程结束时返回false
+func (s *TaskService) timeoutTask(t adminModel.StuckTask) bool {
+	errorMessage := fmt.Sprintf("任务执行超时：运行超过 %d 秒仍未结束，已被强制终止", t.TimeoutDuration)
+	now := time.Now()
+
+	var affected int64
+	err := s.dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+		result := tx.Model(&adminModel.Task{}).
+			Where("id = ? AND status IN ?", t.ID, []string{"running", "processing"}).
+			Updates(map[string]interface{}{
+				"status":        "failed",
+				"error_message": errorMessage,
+				"cancel_reason": "执行超时",
+				"completed_at":  &now,
+			})
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		global.APP_LOG.Error("标记超时任务失败", zap.Uint("taskId", t.ID), zap.Error(err))
+		return false
+	}
+	if affected == 0 {
+		return false
+	}
+
+	global.APP_LOG.Warn("任务执行超时，已强制终止",
+		zap.Uint("taskId", t.ID),
+		zap.String("taskType", t.TaskType),
+		zap.Int("timeoutSeconds", t.TimeoutDuration),
+		zap.Int64("overdueSeconds", t.OverdueSeconds))
+
+	// 取消本节点上运行的任务上下文，其他节点上的任务由其自身的上下文超时结束，完成时因状态已为failed不再覆盖
+	if taskCtx, exists := s.contextManager.Get(t.ID); exists {
+		taskCtx.CancelFunc()
+		s.contextManager.Delete(t.ID)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.compensateTimedOutTask(t.ID, errors.New(errorMessage))
+	}()
+	return true
+}
+
+// compensateTimedOutTask 超时任务的补偿：回滚创建中的实例、恢复操作中实例的状态并释放预留资源
+func (s *TaskService) compensateTimedOutTask(taskID uint, cause error) {
+	var task adminModel.Task
+	if err := global.APP_DB.First(&task, taskID).Error; err != nil {
+		global.APP_LOG.Error("获取超时任务信息失败", zap.Uint("taskId", taskID), zap.Error(err))
+		return
+	}
+
+	if task.TaskType == "create" {
+		if err := userprovider.NewService().RollbackCreateInstanceTask(&task, cause); err != nil {
+			global.APP_LOG.Error("回滚超时创建任务失败", zap.Uint("taskId", taskID), zap.Error(err))
+		}
+	} else {
+		s.handleCancelledTaskCleanup(taskID)
+	}
+
+	// 与任务失败的处理一致，尚未关联实例的任务释放预留资源
+	if task.InstanceID == nil {
+		s.releaseTaskResources(taskID)
+	}
+}
+
 // executeTaskLogic 执行具体的任务逻辑
 func (s *TaskService) executeTaskLogic(ctx context.Context, task *adminModel.Task) error {
 	switch task.TaskType {
@@ -97,7 +185,22 @@ func (s *TaskService) executeTaskLogic(ctx context.Context, task *adminModel.Tas
 		return s.executeDeletePortMappingTask(ctx, task)
 	case "sync-port-mappings":
 		return s.executeSyncPortMappingsTask(ctx, task)
+	case adminModel.TaskTypeExportData:
+		return s.executeExportDataTask(ctx, task)
+	case adminModel.TaskTypeMigratePortIP:
+		return s.executePortIPMigrationTask(ctx, task)
+	case adminModel.TaskTypeMigratePortMethod:
+		return s.executePortMethodMigrationTask(ctx, task)
+	case adminModel.TaskTypeNetworkProbe:
+		return s.executeNetworkProbeTask(ctx, task)
+	case adminModel.TaskTypeDetectPortService:
+		return s.executeDetectPortServiceTask(ctx, task)
 	default:
+		if handler, ok := getTaskHandler(task.TaskType); ok {
+			return handler.Execute(ctx, task, func(percent int, message string) {
+				s.updateTaskProgress(task.ID, percent, message)
+			})
+		}
 		return fmt.Errorf("未知的任务类型: %s", task.TaskType)
 	}
 }
@@ -12,6 +12,7 @@ import (
 	provider2 "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
+	"oneclickvirt/service/userwebhook"
 	"oneclickvirt/utils"
 	"time"
 
@@ -325,6 +326,7 @@ func (s *TaskService) executeStopInstanceTask(ctx context.Context, task *adminMo
 	if err := stateManager.CompleteMainTask(task.ID, true, "实例停止成功", nil); err != nil {
 		global.APP_LOG.Error("完成任务失败", zap.Uint("taskId", task.ID), zap.Error(err))
 	}
+	userwebhook.InstanceStopped(instance.ID, task.ID)
 
 	global.APP_LOG.Info("用户实例停止成功",
 		zap.Uint("taskId", task.ID),
@@ -604,7 +606,7 @@ func (s *TaskService) executeResetPasswordTask(ctx context.Context, task *adminM
 	s.updateTaskProgress(task.ID, 35, "正在生成新密码...")
 
 	// 生成新密码
-	newPassword := utils.GenerateStrongPassword(12)
+	newPassword := utils.GenerateStrongPassword(utils.InstancePasswordLength())
 
 	global.APP_LOG.Info("开始重置实例密码",
 		zap.Uint("taskId", task.ID),
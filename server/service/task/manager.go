@@ -13,6 +13,8 @@ import (
 	dashboardModel "oneclickvirt/model/dashboard"
 	providerModel "oneclickvirt/model/provider"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -57,6 +59,9 @@ func (s *TaskService) calculateEstimatedDuration(taskType string, instanceType s
 	case "reset-password":
 		return 30 // 30秒 - 密码重置操作快
 	default:
+		if handler, ok := getTaskHandler(taskType); ok && handler.EstimatedDuration > 0 {
+			return handler.EstimatedDuration
+		}
 		return 120 // 默认2分钟 - 保守估计
 	}
 }
@@ -100,6 +105,15 @@ func (s *TaskService) parseTaskDataForConfig(taskData string) (cpu int, memory i
 
 // CreateTask 创建任务
 func (s *TaskService) CreateTask(userID uint, providerID *uint, instanceID *uint, taskType string, taskData string, timeoutDuration int) (*adminModel.Task, error) {
+	// 数据库熔断期间新任务快速失败，避免在超时重试中空耗
+	if !s.dbService.Available() {
+		return nil, database.ErrDatabaseUnavailable
+	}
+
+	if err := validateTaskData(taskType, taskData); err != nil {
+		return nil, err
+	}
+
 	if timeoutDuration <= 0 {
 		timeoutDuration = s.getDefaultTimeout(taskType)
 	}
@@ -392,6 +406,21 @@ func (s *TaskService) GetUserTasks(userID uint, req userModel.UserTasksRequest)
 	return taskResponses, total, nil
 }
 
+// adminTaskListSpec 管理员任务列表可排序、过滤的字段
+var adminTaskListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":         "tasks.id",
+		"status":     "tasks.status",
+		"taskType":   "tasks.task_type",
+		"userId":     "tasks.user_id",
+		"providerId": "tasks.provider_id",
+		"instanceId": "tasks.instance_id",
+		"createdAt":  "tasks.created_at",
+		"updatedAt":  "tasks.updated_at",
+	},
+	DefaultSort: "-createdAt",
+}
+
 // GetAdminTasks 获取管理员任务列表
 func (s *TaskService) GetAdminTasks(req adminModel.AdminTaskListRequest) ([]adminModel.AdminTaskResponse, int64, error) {
 	var tasks []adminModel.Task
@@ -404,6 +433,9 @@ func (s *TaskService) GetAdminTasks(req adminModel.AdminTaskListRequest) ([]admi
 	if req.ProviderID != 0 {
 		query = query.Where("tasks.provider_id = ?", req.ProviderID)
 	}
+	if len(req.ProviderIDs) > 0 {
+		query = query.Where("tasks.provider_id IN ?", req.ProviderIDs)
+	}
 	if req.Username != "" {
 		// 通过用户名搜索，需要连接 users 表
 		query = query.Joins("LEFT JOIN users ON users.id = tasks.user_id").
@@ -419,6 +451,10 @@ func (s *TaskService) GetAdminTasks(req adminModel.AdminTaskListRequest) ([]admi
 		query = query.Joins("LEFT JOIN instances ON instances.id = tasks.instance_id").
 			Where("instances.instance_type = ?", req.InstanceType)
 	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, adminTaskListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -426,9 +462,11 @@ func (s *TaskService) GetAdminTasks(req adminModel.AdminTaskListRequest) ([]admi
 	}
 
 	// 获取任务列表 - 只查询必要字段，避免加载大字段
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Select("tasks.*").Order("tasks.created_at DESC").
-		Offset(offset).Limit(req.PageSize).
+	query, err = utils.ApplyListSort(query, req.PageInfo, adminTaskListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Select("tasks.*").
 		Find(&tasks).Error; err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,90 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/service/netprobe"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// CreateNetworkProbeTask 创建Provider网络质量测试任务，参数在创建时校验，避免任务执行后才发现配置无效
+func (s *TaskService) CreateNetworkProbeTask(userID, providerID uint, req adminModel.NetworkProbeTaskRequest) (*adminModel.Task, error) {
+	if _, err := netprobe.ResolveOptions(req.Targets, req.SpeedTestURL); err != nil {
+		return nil, err
+	}
+	if req.Trigger == "" {
+		req.Trigger = monitoringModel.NetworkProbeTriggerManual
+	}
+	taskData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	task, err := s.CreateTask(userID, &providerID, nil, adminModel.TaskTypeNetworkProbe, string(taskData), utils.GetDefaultTaskTimeout(adminModel.TaskTypeNetworkProbe))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.StartTask(task.ID); err != nil {
+		return nil, fmt.Errorf("启动网络质量测试任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("创建网络质量测试任务",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("providerId", providerID),
+		zap.String("trigger", req.Trigger))
+	return task, nil
+}
+
+// executeNetworkProbeTask 执行网络质量测试任务
+func (s *TaskService) executeNetworkProbeTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 2, "正在解析任务数据...")
+
+	var taskReq adminModel.NetworkProbeTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	if task.ProviderID == nil {
+		return fmt.Errorf("任务没有关联Provider")
+	}
+	opts, err := netprobe.ResolveOptions(taskReq.Targets, taskReq.SpeedTestURL)
+	if err != nil {
+		return err
+	}
+
+	providerApiService := &provider2.ProviderApiService{}
+	providerInstance, _, err := providerApiService.GetProviderByID(*task.ProviderID)
+	if err != nil {
+		return fmt.Errorf("获取Provider实例失败: %v", err)
+	}
+
+	detail, err := netprobe.Run(ctx, providerInstance, opts, func(percent int, message string) {
+		s.updateTaskProgress(task.ID, percent, message)
+	})
+	if err != nil {
+		return err
+	}
+	detail.ProviderID = *task.ProviderID
+	detail.TaskID = task.ID
+	detail.Trigger = taskReq.Trigger
+	if err := netprobe.Save(detail); err != nil {
+		return err
+	}
+	if detail.Status == monitoringModel.NetworkProbeStatusFailed {
+		return fmt.Errorf("%s", detail.Error)
+	}
+
+	message := fmt.Sprintf("网络质量测试完成：%d/%d 个目标可达", detail.Reachable, detail.TargetCount)
+	if detail.DownloadBytes > 0 {
+		message += fmt.Sprintf("，下载速度 %.2f Mbps", detail.DownloadMbps)
+	}
+	s.updateTaskProgress(task.ID, 100, message)
+	return nil
+}
@@ -211,10 +211,12 @@ func (m *ProviderPoolManager) cleanupOrphaned() {
 
 // CleanupDeleted 清理已删除的Provider工作池
 func (m *ProviderPoolManager) CleanupDeleted(validIDs []uint) int {
-	validSet := make(map[uint]bool, len(validIDs))
+	validSet := make(map[uint]bool, len(validIDs)+1)
 	for _, id := range validIDs {
 		validSet[id] = true
 	}
+	// 系统任务池不对应任何Provider
+	validSet[systemTaskPoolID] = true
 
 	cleaned := 0
 	m.pools.Range(func(key, value interface{}) bool {
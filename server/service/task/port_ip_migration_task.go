@@ -0,0 +1,187 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"strings"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// CreatePortIPMigrationTask Provider端口映射地址变更后创建迁移任务：
+// 更新实例公网地址、把绑定旧地址的device proxy改为监听新地址，并通知受影响的实例所有者
+func (s *TaskService) CreatePortIPMigrationTask(userID, providerID uint, oldHost, newHost string) (*adminModel.Task, error) {
+	taskData, err := json.Marshal(adminModel.MigratePortIPTaskRequest{OldHost: oldHost, NewHost: newHost})
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	task, err := s.CreateTask(userID, &providerID, nil, adminModel.TaskTypeMigratePortIP, string(taskData), utils.GetDefaultTaskTimeout(adminModel.TaskTypeMigratePortIP))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.StartTask(task.ID); err != nil {
+		return nil, fmt.Errorf("启动端口映射地址迁移任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("创建端口映射地址迁移任务",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("providerId", providerID),
+		zap.String("oldHost", oldHost),
+		zap.String("newHost", newHost))
+	return task, nil
+}
+
+// executePortIPMigrationTask 执行端口映射地址迁移任务
+func (s *TaskService) executePortIPMigrationTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 5, "正在解析任务数据...")
+
+	var taskReq adminModel.MigratePortIPTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	if task.ProviderID == nil {
+		return fmt.Errorf("任务没有关联Provider")
+	}
+
+	var prov providerModel.Provider
+	if err := global.APP_DB.First(&prov, *task.ProviderID).Error; err != nil {
+		return fmt.Errorf("查询Provider失败: %v", err)
+	}
+
+	// 公网地址等于旧地址的实例通过端口映射访问，需要更新连接信息；独立IP实例的地址不受影响
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id, name, user_id, public_ip").
+		Where("provider_id = ? AND public_ip = ?", prov.ID, taskReq.OldHost).
+		Where("status NOT IN ?", []string{"deleting", "deleted", "failed"}).
+		Find(&instances).Error; err != nil {
+		return fmt.Errorf("查询受影响实例失败: %v", err)
+	}
+	if len(instances) == 0 {
+		s.updateTaskProgress(task.ID, 100, "没有使用旧地址的实例，无需迁移")
+		return nil
+	}
+
+	s.updateTaskProgress(task.ID, 15, fmt.Sprintf("正在更新 %d 个实例的连接地址...", len(instances)))
+	ids := make([]uint, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.ID)
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id IN ?", ids).
+		Update("public_ip", taskReq.NewHost).Error; err != nil {
+		return fmt.Errorf("更新实例公网地址失败: %v", err)
+	}
+
+	// LXD/Incus 的 device proxy 监听在具体地址上，需要改为新地址，否则端口映射失效
+	var failed []string
+	cli := proxyDeviceCLI(prov.Type)
+	oldIP, newIP := net.ParseIP(taskReq.OldHost).To4(), net.ParseIP(taskReq.NewHost).To4()
+	switch {
+	case cli == "":
+	case oldIP == nil || newIP == nil:
+		global.APP_LOG.Warn("端口映射地址不是IPv4地址，跳过device proxy监听地址迁移，请手动检查",
+			zap.Uint("providerId", prov.ID),
+			zap.String("oldHost", taskReq.OldHost),
+			zap.String("newHost", taskReq.NewHost))
+	default:
+		providerApiService := &provider2.ProviderApiService{}
+		providerInstance, _, err := providerApiService.GetProviderByID(prov.ID)
+		if err != nil {
+			return fmt.Errorf("获取Provider实例失败: %v", err)
+		}
+		for i, instance := range instances {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.updateTaskProgress(task.ID, 20+60*i/len(instances), fmt.Sprintf("正在迁移实例 %s 的端口映射监听地址...", instance.Name))
+			cmd := rebindProxyDevicesCommand(cli, instance.Name, oldIP.String(), newIP.String())
+			if output, err := providerInstance.ExecuteSSHCommand(ctx, cmd); err != nil {
+				global.APP_LOG.Warn("迁移device proxy监听地址失败",
+					zap.Uint("taskId", task.ID),
+					zap.Uint("instanceId", instance.ID),
+					zap.String("instanceName", instance.Name),
+					zap.String("output", utils.TruncateString(output, 512)),
+					zap.Error(err))
+				failed = append(failed, instance.Name)
+			}
+		}
+	}
+
+	s.updateTaskProgress(task.ID, 85, "正在通知实例所有者...")
+	notifyPortIPChange(&prov, instances, taskReq.NewHost)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个实例的端口映射监听地址迁移失败，请手动检查：%s", len(failed), strings.Join(failed, ", "))
+	}
+	s.updateTaskProgress(task.ID, 100, fmt.Sprintf("已将 %d 个实例的端口映射地址从 %s 迁移到 %s", len(instances), taskReq.OldHost, taskReq.NewHost))
+	return nil
+}
+
+// proxyDeviceCLI 返回使用device proxy做端口映射的Provider类型对应的命令行工具
+func proxyDeviceCLI(providerType string) string {
+	switch providerType {
+	case "lxd":
+		return "lxc"
+	case "incus":
+		return "incus"
+	}
+	return ""
+}
+
+// rebindProxyDevicesCommand 生成把实例上监听旧地址的proxy设备改为监听新地址的命令，
+// listen 格式为 <协议>:<地址>:<端口>，监听 0.0.0.0 的设备不受影响
+func rebindProxyDevicesCommand(cli, instanceName, oldIP, newIP string) string {
+	name := utils.ShellQuote(instanceName)
+	oldPattern := strings.ReplaceAll(oldIP, ".", `\.`)
+	return fmt.Sprintf(`for d in $(%[1]s config device list %[2]s); do `+
+		`l=$(%[1]s config device get %[2]s "$d" listen 2>/dev/null) || continue; `+
+		`case "$l" in *:%[3]s:*) %[1]s config device set %[2]s "$d" listen="$(echo "$l" | sed 's/:%[4]s:/:%[5]s:/')" || exit 1;; esac; `+
+		`done`, cli, name, oldIP, oldPattern, newIP)
+}
+
+// notifyPortIPChange 通知实例所有者连接地址已变更，同一用户的多个实例合并为一封邮件
+// 用户未绑定邮箱或未配置邮件服务时只记录日志
+func notifyPortIPChange(prov *providerModel.Provider, instances []providerModel.Instance, newHost string) {
+	byUser := make(map[uint][]string)
+	for _, instance := range instances {
+		byUser[instance.UserID] = append(byUser[instance.UserID], instance.Name)
+	}
+
+	for userID, names := range byUser {
+		global.APP_LOG.Info("实例连接地址已变更",
+			zap.Uint("userId", userID),
+			zap.Uint("providerId", prov.ID),
+			zap.Strings("instances", names),
+			zap.String("newHost", newHost))
+
+		var user userModel.User
+		if err := global.APP_DB.Select("id, username, email").First(&user, userID).Error; err != nil {
+			continue
+		}
+		if user.Email == "" || !utils.MailConfigured() {
+			continue
+		}
+		escaped := make([]string, 0, len(names))
+		for _, name := range names {
+			escaped = append(escaped, html.EscapeString(name))
+		}
+		subject := "实例连接地址变更通知"
+		body := fmt.Sprintf("您好 %s，节点 %s 的公网地址已变更为 %s，以下实例的SSH和端口映射请改用新地址连接，端口保持不变：<br>%s",
+			html.EscapeString(user.Username), html.EscapeString(prov.Name), html.EscapeString(newHost), strings.Join(escaped, "<br>"))
+		if err := utils.SendMail(user.Email, subject, body); err != nil {
+			global.APP_LOG.Warn("发送连接地址变更通知失败",
+				zap.Uint("userId", userID),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package task
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRebindProxyDevicesCommand(t *testing.T) {
+	cmd := rebindProxyDevicesCommand("incus", "web;1", "192.0.2.1", "198.51.100.7")
+	for _, want := range []string{
+		"incus config device list 'web;1'",
+		`*:192.0.2.1:*)`,
+		`s/:192\.0\.2\.1:/:198.51.100.7:/`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("command %q missing %q", cmd, want)
+		}
+	}
+}
+
+func TestProxyDeviceCLI(t *testing.T) {
+	cases := map[string]string{"lxd": "lxc", "incus": "incus", "docker": "", "proxmox": ""}
+	for providerType, want := range cases {
+		if got := proxyDeviceCLI(providerType); got != want {
+			t.Errorf("proxyDeviceCLI(%s) = %q, want %q", providerType, got, want)
+		}
+	}
+}
@@ -195,6 +195,20 @@ func (s *TaskService) executeCreatePortMappingTask(ctx context.Context, task *ad
 				currentPrivateIP = instance.PrivateIP
 			}
 		}
+	case "lxc":
+		if lxcProv, ok := prov.(interface {
+			GetInstanceIPv4(context.Context, string) (string, error)
+		}); ok {
+			if ip, err := lxcProv.GetInstanceIPv4(ctx, instance.Name); err == nil {
+				currentPrivateIP = ip
+			} else {
+				global.APP_LOG.Warn("获取LXC实例内网IP失败，使用数据库中的IP",
+					zap.String("instanceName", instance.Name),
+					zap.String("dbPrivateIP", instance.PrivateIP),
+					zap.Error(err))
+				currentPrivateIP = instance.PrivateIP
+			}
+		}
 	case "docker":
 		// Docker通常不需要内网IP映射
 		currentPrivateIP = instance.PrivateIP
@@ -229,7 +243,7 @@ func (s *TaskService) executeCreatePortMappingTask(ctx context.Context, task *ad
 
 	// 确定使用的 portmapping provider 类型
 	portMappingType := localProviderType
-	if portMappingType == "proxmox" {
+	if portMappingType == "proxmox" || portMappingType == "lxc" {
 		portMappingType = "iptables"
 	}
 
@@ -415,7 +429,7 @@ func (s *TaskService) executeDeletePortMappingTask(ctx context.Context, task *ad
 		})
 
 		portMappingType := localProviderType
-		if portMappingType == "proxmox" {
+		if portMappingType == "proxmox" || portMappingType == "lxc" {
 			portMappingType = "iptables"
 		}
 
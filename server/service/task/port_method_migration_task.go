@@ -0,0 +1,538 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	provider2 "oneclickvirt/service/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 端口映射方式迁移每批同时处理的实例数
+const (
+	defaultPortMethodBatchSize = 5
+	maxPortMethodBatchSize     = 20
+)
+
+// CreatePortMethodMigrationTask 创建端口映射方式迁移任务，把Provider上已有实例的IPv4端口映射在线切换为目标方式
+func (s *TaskService) CreatePortMethodMigrationTask(userID, providerID uint, req adminModel.MigratePortMethodTaskRequest) (*adminModel.Task, error) {
+	var prov providerModel.Provider
+	if err := global.APP_DB.First(&prov, providerID).Error; err != nil {
+		return nil, fmt.Errorf("Provider不存在")
+	}
+	if err := validatePortMethodMigration(&prov, req.TargetMethod); err != nil {
+		return nil, err
+	}
+
+	var running int64
+	if err := global.APP_DB.Model(&adminModel.Task{}).
+		Where("provider_id = ? AND task_type = ? AND status IN ?", providerID, adminModel.TaskTypeMigratePortMethod,
+			[]string{"pending", "processing", "running"}).
+		Count(&running).Error; err != nil {
+		return nil, fmt.Errorf("查询迁移任务失败: %v", err)
+	}
+	if running > 0 {
+		return nil, fmt.Errorf("该Provider已有进行中的端口映射方式迁移任务")
+	}
+
+	req.BatchSize = normalizePortMethodBatchSize(req.BatchSize)
+	req.SourceMethod = prov.IPv4PortMappingMethod
+	taskData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务数据失败: %v", err)
+	}
+
+	task, err := s.CreateTask(userID, &providerID, nil, adminModel.TaskTypeMigratePortMethod, string(taskData), utils.GetDefaultTaskTimeout(adminModel.TaskTypeMigratePortMethod))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.StartTask(task.ID); err != nil {
+		return nil, fmt.Errorf("启动端口映射方式迁移任务失败: %v", err)
+	}
+
+	global.APP_LOG.Info("创建端口映射方式迁移任务",
+		zap.Uint("taskId", task.ID),
+		zap.Uint("providerId", providerID),
+		zap.String("sourceMethod", req.SourceMethod),
+		zap.String("targetMethod", req.TargetMethod),
+		zap.Int("batchSize", req.BatchSize))
+	return task, nil
+}
+
+// validatePortMethodMigration 校验Provider是否支持切换到目标映射方式
+// 只有LXD/Incus同时支持device proxy和iptables两种方式，独立IP和纯IPv6节点不使用IPv4端口映射
+func validatePortMethodMigration(prov *providerModel.Provider, target string) error {
+	if proxyDeviceCLI(prov.Type) == "" {
+		return fmt.Errorf("仅LXD和Incus类型的Provider支持切换端口映射方式")
+	}
+	switch target {
+	case string(constant.PortMappingMethodDeviceProxy), string(constant.PortMappingMethodIptables):
+	case "nftables":
+		return fmt.Errorf("当前版本不支持nftables端口映射方式")
+	default:
+		return fmt.Errorf("无效的目标映射方式: %s", target)
+	}
+	if strings.HasPrefix(prov.NetworkType, "dedicated") || prov.NetworkType == "ipv6_only" {
+		return fmt.Errorf("独立IP或纯IPv6节点不使用IPv4端口映射，无需迁移")
+	}
+	return nil
+}
+
+// normalizePortMethodBatchSize 限制每批实例数在 1 到 maxPortMethodBatchSize 之间，未设置时使用默认值
+func normalizePortMethodBatchSize(size int) int {
+	if size <= 0 {
+		return defaultPortMethodBatchSize
+	}
+	if size > maxPortMethodBatchSize {
+		return maxPortMethodBatchSize
+	}
+	return size
+}
+
+// executePortMethodMigrationTask 执行端口映射方式迁移任务
+// 每个实例先创建并校验新方式的映射，两种映射并存期间不中断访问；校验通过后再删除旧映射，失败时回滚新映射并保留旧映射
+func (s *TaskService) executePortMethodMigrationTask(ctx context.Context, task *adminModel.Task) error {
+	s.updateTaskProgress(task.ID, 2, "正在解析任务数据...")
+
+	var taskReq adminModel.MigratePortMethodTaskRequest
+	if err := json.Unmarshal([]byte(task.TaskData), &taskReq); err != nil {
+		return fmt.Errorf("解析任务数据失败: %v", err)
+	}
+	if task.ProviderID == nil {
+		return fmt.Errorf("任务没有关联Provider")
+	}
+
+	var prov providerModel.Provider
+	if err := global.APP_DB.First(&prov, *task.ProviderID).Error; err != nil {
+		return fmt.Errorf("查询Provider失败: %v", err)
+	}
+	if err := validatePortMethodMigration(&prov, taskReq.TargetMethod); err != nil {
+		return err
+	}
+
+	m := &portMethodMigration{
+		cli:    proxyDeviceCLI(prov.Type),
+		iface:  prov.PublicInterface,
+		source: taskReq.SourceMethod,
+		target: taskReq.TargetMethod,
+	}
+	if m.target == string(constant.PortMappingMethodDeviceProxy) {
+		hostIP, err := resolvePortHostIPv4(&prov)
+		if err != nil {
+			return err
+		}
+		m.hostIP = hostIP
+	}
+
+	s.updateTaskProgress(task.ID, 5, "正在查询需要迁移的端口映射...")
+	var instances []providerModel.Instance
+	if err := global.APP_DB.Select("id, name, private_ip").
+		Where("provider_id = ?", prov.ID).
+		Where("status NOT IN ?", []string{"deleting", "deleted", "failed"}).
+		Find(&instances).Error; err != nil {
+		return fmt.Errorf("查询实例失败: %v", err)
+	}
+	var ports []providerModel.Port
+	if err := global.APP_DB.Where("provider_id = ? AND status = ?", prov.ID, "active").
+		Where("mapping_method IS NULL OR mapping_method <> ?", m.target).
+		Find(&ports).Error; err != nil {
+		return fmt.Errorf("查询端口映射失败: %v", err)
+	}
+	portsByInstance := make(map[uint][]providerModel.Port)
+	for _, port := range ports {
+		portsByInstance[port.InstanceID] = append(portsByInstance[port.InstanceID], port)
+	}
+	pending := make([]providerModel.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if len(portsByInstance[instance.ID]) > 0 {
+			pending = append(pending, instance)
+		}
+	}
+
+	providerApiService := &provider2.ProviderApiService{}
+	providerInstance, _, err := providerApiService.GetProviderByID(prov.ID)
+	if err != nil {
+		return fmt.Errorf("获取Provider实例失败: %v", err)
+	}
+	m.exec = providerInstance.ExecuteSSHCommand
+	if ipGetter, ok := providerInstance.(interface {
+		GetInstanceIPv4(context.Context, string) (string, error)
+	}); ok {
+		m.instanceIP = ipGetter.GetInstanceIPv4
+	}
+
+	var failed, unclean []string
+	migratedPorts := 0
+	batchSize := normalizePortMethodBatchSize(taskReq.BatchSize)
+	for start := 0; start < len(pending); start += batchSize {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		end := start + batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		s.updateTaskProgress(task.ID, 10+80*start/len(pending),
+			fmt.Sprintf("正在迁移第 %d-%d 个实例（共 %d 个）...", start+1, end, len(pending)))
+
+		results := make([]portMethodResult, end-start)
+		var wg sync.WaitGroup
+		for i, instance := range pending[start:end] {
+			wg.Add(1)
+			go func(i int, instance providerModel.Instance) {
+				defer wg.Done()
+				results[i] = m.migrateInstance(ctx, instance, portsByInstance[instance.ID])
+			}(i, instance)
+		}
+		wg.Wait()
+
+		for i, result := range results {
+			instance := pending[start+i]
+			if result.err != nil {
+				global.APP_LOG.Warn("实例端口映射方式迁移失败，已保留原映射",
+					zap.Uint("taskId", task.ID),
+					zap.Uint("instanceId", instance.ID),
+					zap.String("instanceName", instance.Name),
+					zap.Error(result.err))
+				failed = append(failed, instance.Name)
+				continue
+			}
+			migratedPorts += len(portsByInstance[instance.ID])
+			if !result.cleaned {
+				unclean = append(unclean, instance.Name)
+			}
+		}
+	}
+
+	if m.touchedIptables() {
+		s.updateTaskProgress(task.ID, 92, "正在保存iptables规则...")
+		if _, err := m.exec(ctx, "mkdir -p /etc/iptables && iptables-save > /etc/iptables/rules.v4"); err != nil {
+			global.APP_LOG.Warn("保存iptables规则失败，宿主机重启后端口映射可能丢失", zap.Uint("providerId", prov.ID), zap.Error(err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个实例的端口映射方式迁移失败，已保留原映射，Provider映射方式未修改，可重新执行迁移：%s",
+			len(failed), strings.Join(failed, ", "))
+	}
+
+	// 全部实例迁移成功后再修改Provider的映射方式，之后创建的端口映射使用新方式
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", prov.ID).
+		Update("ipv4_port_mapping_method", m.target).Error; err != nil {
+		return fmt.Errorf("更新Provider映射方式失败: %v", err)
+	}
+	if err := provider2.GetProviderService().SwapProvider(prov.ID); err != nil {
+		global.APP_LOG.Warn("Provider映射方式已更新，但重新加载Provider失败，将在下次连接时生效",
+			zap.Uint("providerId", prov.ID),
+			zap.Error(err))
+	}
+
+	message := fmt.Sprintf("已将 %d 个实例的 %d 条端口映射切换为 %s", len(pending), migratedPorts, m.target)
+	if len(unclean) > 0 {
+		message += fmt.Sprintf("，%d 个实例的旧映射未能完全清理，请手动检查：%s", len(unclean), strings.Join(unclean, ", "))
+	}
+	s.updateTaskProgress(task.ID, 100, message)
+	return nil
+}
+
+// resolvePortHostIPv4 返回device proxy监听的宿主机IPv4地址，端口映射地址为域名时解析后使用
+func resolvePortHostIPv4(prov *providerModel.Provider) (string, error) {
+	host := resources.ProviderPublicHost(prov)
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return "", fmt.Errorf("端口映射地址 %s 不是IPv4地址", host)
+		}
+		return ip.String(), nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("解析端口映射地址 %s 失败: %v", host, err)
+	}
+	for _, ip := range ips {
+		if v4 := ip.To4(); v4 != nil {
+			return v4.String(), nil
+		}
+	}
+	return "", fmt.Errorf("端口映射地址 %s 没有IPv4地址", host)
+}
+
+// portMethodMigration 一次端口映射方式迁移的参数
+type portMethodMigration struct {
+	cli        string // lxc 或 incus
+	iface      string // DNAT规则匹配的入口网卡，为空时不限定
+	hostIP     string // device proxy监听的宿主机地址
+	source     string // 迁移前Provider的映射方式，端口记录没有有效映射方式时按此处理
+	target     string
+	exec       func(ctx context.Context, command string) (string, error)
+	instanceIP func(ctx context.Context, instanceName string) (string, error)
+
+	mu       sync.Mutex
+	iptables bool // 是否增删过iptables规则
+}
+
+// portMethodResult 单个实例的迁移结果
+type portMethodResult struct {
+	err     error
+	cleaned bool // 旧映射是否全部删除
+}
+
+// migrateInstance 迁移单个实例的端口映射：创建新映射、校验、删除旧映射、更新端口记录
+func (m *portMethodMigration) migrateInstance(ctx context.Context, instance providerModel.Instance, ports []providerModel.Port) portMethodResult {
+	ip := instance.PrivateIP
+	if m.instanceIP != nil {
+		if current, err := m.instanceIP(ctx, instance.Name); err == nil {
+			ip = current
+		}
+	}
+	ip = cleanInstanceIPv4(ip)
+	if ip == "" {
+		return portMethodResult{err: fmt.Errorf("无法获取实例内网IPv4地址")}
+	}
+
+	var rules []portMappingRule
+	var sources []string
+	ids := make([]uint, 0, len(ports))
+	for _, port := range ports {
+		ids = append(ids, port.ID)
+		source := portSourceMethod(port.MappingMethod, m.source)
+		if source == m.target {
+			// 端口记录的映射方式与宿主机实际不一致，宿主机上已是目标方式，只更新记录
+			continue
+		}
+		for _, rule := range portMappingRules(port) {
+			rules = append(rules, rule)
+			sources = append(sources, source)
+		}
+	}
+
+	if m.target == string(constant.PortMappingMethodIptables) && len(rules) > 0 {
+		m.markIptables()
+	}
+	for i, rule := range rules {
+		if output, err := m.exec(ctx, m.addCommand(instance.Name, ip, rule)); err != nil {
+			m.rollback(ctx, instance.Name, ip, rules[:i+1])
+			return portMethodResult{err: fmt.Errorf("创建 %s 映射失败: %v %s", rule, err, utils.TruncateString(output, 256))}
+		}
+	}
+	for _, rule := range rules {
+		if _, err := m.exec(ctx, m.checkCommand(instance.Name, ip, rule)); err != nil {
+			m.rollback(ctx, instance.Name, ip, rules)
+			return portMethodResult{err: fmt.Errorf("校验 %s 映射失败: %v", rule, err)}
+		}
+	}
+
+	// 新映射已生效，旧映射删除失败不影响访问，只提示手动检查
+	cleaned := true
+	var staleProxy []portMappingRule
+	for i, rule := range rules {
+		switch sources[i] {
+		case string(constant.PortMappingMethodIptables):
+			m.markIptables()
+			if _, err := m.exec(ctx, iptablesRemoveCommand(m.iface, ip, rule)); err != nil {
+				cleaned = false
+			}
+		default:
+			staleProxy = append(staleProxy, rule)
+		}
+	}
+	if len(staleProxy) > 0 {
+		if _, err := m.exec(ctx, removeProxyDevicesCommand(m.cli, instance.Name, staleProxy)); err != nil {
+			cleaned = false
+		}
+	}
+
+	if err := global.APP_DB.Model(&providerModel.Port{}).Where("id IN ?", ids).
+		Update("mapping_method", m.target).Error; err != nil {
+		return portMethodResult{err: fmt.Errorf("新映射已生效，但更新端口记录失败: %v", err), cleaned: cleaned}
+	}
+	return portMethodResult{cleaned: cleaned}
+}
+
+// rollback 删除已创建的新映射，错误只记录日志
+func (m *portMethodMigration) rollback(ctx context.Context, instanceName, ip string, rules []portMappingRule) {
+	for _, rule := range rules {
+		var cmd string
+		if m.target == string(constant.PortMappingMethodIptables) {
+			cmd = iptablesRemoveCommand(m.iface, ip, rule)
+		} else {
+			cmd = fmt.Sprintf("%s config device remove %s %s", m.cli, utils.ShellQuote(instanceName), rule.deviceName())
+		}
+		if _, err := m.exec(ctx, cmd); err != nil {
+			global.APP_LOG.Warn("回滚新端口映射失败",
+				zap.String("instanceName", instanceName),
+				zap.String("rule", rule.String()),
+				zap.Error(err))
+		}
+	}
+}
+
+// addCommand 生成创建目标方式映射的命令，已存在时不重复创建
+func (m *portMethodMigration) addCommand(instanceName, ip string, rule portMappingRule) string {
+	if m.target == string(constant.PortMappingMethodIptables) {
+		return iptablesAddCommand(m.iface, ip, rule)
+	}
+	name := utils.ShellQuote(instanceName)
+	return fmt.Sprintf("%[1]s config device get %[2]s %[3]s listen >/dev/null 2>&1 || "+
+		"%[1]s config device add %[2]s %[3]s proxy listen=%[4]s connect=%[5]s:%[6]s:%[7]s nat=true",
+		m.cli, name, rule.deviceName(), rule.listen(m.hostIP), rule.Protocol, ip, rule.guestSpec("-"))
+}
+
+// checkCommand 生成校验目标方式映射已生效的命令
+func (m *portMethodMigration) checkCommand(instanceName, ip string, rule portMappingRule) string {
+	if m.target == string(constant.PortMappingMethodIptables) {
+		return iptablesCheckCommand(m.iface, ip, rule)
+	}
+	return fmt.Sprintf(`[ "$(%s config device get %s %s listen)" = %s ]`,
+		m.cli, utils.ShellQuote(instanceName), rule.deviceName(), utils.ShellQuote(rule.listen(m.hostIP)))
+}
+
+func (m *portMethodMigration) markIptables() {
+	m.mu.Lock()
+	m.iptables = true
+	m.mu.Unlock()
+}
+
+func (m *portMethodMigration) touchedIptables() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.iptables
+}
+
+// portSourceMethod 返回端口当前在宿主机上的映射方式，早期记录可能为 native 或为空，此时按Provider迁移前的方式处理
+func portSourceMethod(portMethod, providerMethod string) string {
+	switch portMethod {
+	case string(constant.PortMappingMethodDeviceProxy), string(constant.PortMappingMethodIptables):
+		return portMethod
+	}
+	if providerMethod == string(constant.PortMappingMethodIptables) {
+		return providerMethod
+	}
+	return string(constant.PortMappingMethodDeviceProxy)
+}
+
+// cleanInstanceIPv4 从 "10.0.0.2 (eth0)"、"10.0.0.2/24" 等格式中提取IPv4地址，无效时返回空
+func cleanInstanceIPv4(raw string) string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool { return r == ' ' || r == '(' || r == '/' })
+	if len(fields) == 0 {
+		return ""
+	}
+	if ip := net.ParseIP(fields[0]).To4(); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// portMappingRule 一条端口映射在单个协议上的规则，End为0表示单端口
+type portMappingRule struct {
+	Protocol     string
+	HostPort     int
+	HostPortEnd  int
+	GuestPort    int
+	GuestPortEnd int
+}
+
+// portMappingRules 展开端口记录，协议为 both 时拆分为tcp和udp两条规则
+func portMappingRules(port providerModel.Port) []portMappingRule {
+	protocols := []string{port.Protocol}
+	if port.Protocol == "both" || port.Protocol == "" {
+		protocols = []string{"tcp", "udp"}
+	}
+	rules := make([]portMappingRule, 0, len(protocols))
+	for _, proto := range protocols {
+		rule := portMappingRule{Protocol: proto, HostPort: port.HostPort, GuestPort: port.GuestPort}
+		if port.HostPortEnd > port.HostPort {
+			rule.HostPortEnd = port.HostPortEnd
+			rule.GuestPortEnd = port.GuestPort + port.HostPortEnd - port.HostPort
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r portMappingRule) String() string {
+	return fmt.Sprintf("%s/%s->%s", r.Protocol, r.hostSpec("-"), r.guestSpec("-"))
+}
+
+// hostSpec 宿主机端口，端口段用sep连接（iptables用":"，device proxy用"-"）
+func (r portMappingRule) hostSpec(sep string) string {
+	if r.HostPortEnd > 0 {
+		return fmt.Sprintf("%d%s%d", r.HostPort, sep, r.HostPortEnd)
+	}
+	return fmt.Sprintf("%d", r.HostPort)
+}
+
+func (r portMappingRule) guestSpec(sep string) string {
+	if r.GuestPortEnd > 0 {
+		return fmt.Sprintf("%d%s%d", r.GuestPort, sep, r.GuestPortEnd)
+	}
+	return fmt.Sprintf("%d", r.GuestPort)
+}
+
+// deviceName 与创建端口映射时的proxy设备命名一致
+func (r portMappingRule) deviceName() string {
+	if r.HostPortEnd > 0 {
+		return fmt.Sprintf("%s-range-%d-%d", r.Protocol, r.HostPort, r.HostPortEnd)
+	}
+	return fmt.Sprintf("proxy-%s-%d", r.Protocol, r.HostPort)
+}
+
+func (r portMappingRule) listen(hostIP string) string {
+	return fmt.Sprintf("%s:%s:%s", r.Protocol, hostIP, r.hostSpec("-"))
+}
+
+// iptablesRuleArgs 返回DNAT、FORWARD和MASQUERADE规则的匹配参数，与创建端口映射时的规则一致
+func iptablesRuleArgs(ip string, r portMappingRule) (dnat, forward, masquerade string) {
+	dnat = fmt.Sprintf("-p %s --dport %s -j DNAT --to-destination %s:%s", r.Protocol, r.hostSpec(":"), ip, r.guestSpec("-"))
+	forward = fmt.Sprintf("-p %s -d %s --dport %s -j ACCEPT", r.Protocol, ip, r.guestSpec(":"))
+	masquerade = fmt.Sprintf("-p %s -s %s --sport %s -j MASQUERADE", r.Protocol, ip, r.guestSpec(":"))
+	return
+}
+
+// iptablesAddCommand 生成添加iptables映射规则的命令，规则已存在时跳过
+func iptablesAddCommand(iface, ip string, r portMappingRule) string {
+	dnat, forward, masquerade := iptablesRuleArgs(ip, r)
+	dnatCheck := strings.Replace(utils.DNATAddCommand(iface, dnat), " -A PREROUTING", " -C PREROUTING", 1)
+	return strings.Join([]string{
+		fmt.Sprintf("{ %s 2>/dev/null || %s; }", dnatCheck, utils.DNATAddCommand(iface, dnat)),
+		fmt.Sprintf("{ iptables -C FORWARD %[1]s 2>/dev/null || iptables -A FORWARD %[1]s; }", forward),
+		fmt.Sprintf("{ iptables -t nat -C POSTROUTING %[1]s 2>/dev/null || iptables -t nat -A POSTROUTING %[1]s; }", masquerade),
+	}, " && ")
+}
+
+// iptablesCheckCommand 生成校验DNAT和FORWARD规则都已存在的命令
+func iptablesCheckCommand(iface, ip string, r portMappingRule) string {
+	dnat, forward, _ := iptablesRuleArgs(ip, r)
+	dnatCheck := strings.Replace(utils.DNATAddCommand(iface, dnat), " -A PREROUTING", " -C PREROUTING", 1)
+	return fmt.Sprintf("%s && iptables -C FORWARD %s", dnatCheck, forward)
+}
+
+// iptablesRemoveCommand 生成删除iptables映射规则的命令，DNAT规则依次尝试限定和不限定入口网卡的写法
+func iptablesRemoveCommand(iface, ip string, r portMappingRule) string {
+	dnat, forward, masquerade := iptablesRuleArgs(ip, r)
+	return fmt.Sprintf("{ %s 2>/dev/null; iptables -D FORWARD %s 2>/dev/null; iptables -t nat -D POSTROUTING %s 2>/dev/null; true; }",
+		utils.DNATDeleteCommand(dnat, iface, ""), forward, masquerade)
+}
+
+// removeProxyDevicesCommand 生成删除实例上监听这些宿主机端口的IPv4 proxy设备的命令，
+// 按 listen 匹配而不是设备名，以覆盖不同版本创建的设备命名
+func removeProxyDevicesCommand(cli, instanceName string, rules []portMappingRule) string {
+	patterns := make([]string, 0, len(rules))
+	for _, r := range rules {
+		patterns = append(patterns, fmt.Sprintf("%s:*.*:%s", r.Protocol, r.hostSpec("-")))
+	}
+	return fmt.Sprintf(`for d in $(%[1]s config device list %[2]s); do `+
+		`l=$(%[1]s config device get %[2]s "$d" listen 2>/dev/null) || continue; `+
+		`case "$l" in %[3]s) %[1]s config device remove %[2]s "$d" || exit 1;; esac; `+
+		`done`, cli, utils.ShellQuote(instanceName), strings.Join(patterns, "|"))
+}
@@ -0,0 +1,111 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+func TestPortMappingRules(t *testing.T) {
+	rules := portMappingRules(providerModel.Port{Protocol: "both", HostPort: 20000, HostPortEnd: 20009, GuestPort: 30000})
+	if len(rules) != 2 || rules[0].Protocol != "tcp" || rules[1].Protocol != "udp" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if got := rules[0].guestSpec("-"); got != "30000-30009" {
+		t.Errorf("guestSpec = %q", got)
+	}
+	if got := rules[1].deviceName(); got != "udp-range-20000-20009" {
+		t.Errorf("deviceName = %q", got)
+	}
+
+	single := portMappingRules(providerModel.Port{Protocol: "tcp", HostPort: 10022, GuestPort: 22})
+	if len(single) != 1 || single[0].deviceName() != "proxy-tcp-10022" || single[0].hostSpec(":") != "10022" {
+		t.Errorf("unexpected single rule: %+v", single)
+	}
+}
+
+func TestIptablesCommands(t *testing.T) {
+	rule := portMappingRule{Protocol: "tcp", HostPort: 20000, HostPortEnd: 20009, GuestPort: 30000, GuestPortEnd: 30009}
+
+	add := iptablesAddCommand("eth0", "10.0.0.2", rule)
+	for _, want := range []string{
+		"iptables -t nat -C PREROUTING -i eth0 -p tcp --dport 20000:20009 -j DNAT --to-destination 10.0.0.2:30000-30009 2>/dev/null || iptables -t nat -A PREROUTING -i eth0",
+		"iptables -A FORWARD -p tcp -d 10.0.0.2 --dport 30000:30009 -j ACCEPT",
+	} {
+		if !strings.Contains(add, want) {
+			t.Errorf("add command %q missing %q", add, want)
+		}
+	}
+
+	check := iptablesCheckCommand("", "10.0.0.2", rule)
+	if !strings.HasPrefix(check, "iptables -t nat -C PREROUTING -p tcp") || !strings.Contains(check, "&& iptables -C FORWARD") {
+		t.Errorf("unexpected check command %q", check)
+	}
+
+	remove := iptablesRemoveCommand("eth0", "10.0.0.2", rule)
+	if !strings.Contains(remove, "-D PREROUTING -i eth0") || !strings.Contains(remove, "iptables -t nat -D PREROUTING -p tcp") {
+		t.Errorf("remove command %q should try with and without interface", remove)
+	}
+}
+
+func TestRemoveProxyDevicesCommand(t *testing.T) {
+	cmd := removeProxyDevicesCommand("lxc", "web;1", []portMappingRule{
+		{Protocol: "tcp", HostPort: 10022},
+		{Protocol: "udp", HostPort: 20000, HostPortEnd: 20009},
+	})
+	for _, want := range []string{
+		"lxc config device list 'web;1'",
+		"tcp:*.*:10022|udp:*.*:20000-20009)",
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("command %q missing %q", cmd, want)
+		}
+	}
+}
+
+func TestPortSourceMethod(t *testing.T) {
+	cases := []struct{ port, provider, want string }{
+		{"iptables", "device_proxy", "iptables"},
+		{"device_proxy", "iptables", "device_proxy"},
+		{"native", "iptables", "iptables"},
+		{"", "device_proxy", "device_proxy"},
+		{"native", "native", "device_proxy"},
+	}
+	for _, c := range cases {
+		if got := portSourceMethod(c.port, c.provider); got != c.want {
+			t.Errorf("portSourceMethod(%q, %q) = %q, want %q", c.port, c.provider, got, c.want)
+		}
+	}
+}
+
+func TestCleanInstanceIPv4(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.2":         "10.0.0.2",
+		" 10.0.0.2 (eth0)": "10.0.0.2",
+		"10.0.0.2/24":      "10.0.0.2",
+		"fd42::2":          "",
+		"":                 "",
+	}
+	for raw, want := range cases {
+		if got := cleanInstanceIPv4(raw); got != want {
+			t.Errorf("cleanInstanceIPv4(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestValidatePortMethodMigration(t *testing.T) {
+	nat := &providerModel.Provider{Type: "lxd", NetworkType: "nat_ipv4"}
+	if err := validatePortMethodMigration(nat, "iptables"); err != nil {
+		t.Errorf("lxd to iptables: %v", err)
+	}
+	if err := validatePortMethodMigration(nat, "nftables"); err == nil {
+		t.Error("nftables should be rejected")
+	}
+	if err := validatePortMethodMigration(&providerModel.Provider{Type: "docker"}, "iptables"); err == nil {
+		t.Error("docker should be rejected")
+	}
+	if err := validatePortMethodMigration(&providerModel.Provider{Type: "incus", NetworkType: "dedicated_ipv4"}, "device_proxy"); err == nil {
+		t.Error("dedicated network should be rejected")
+	}
+}
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	adminModel "oneclickvirt/model/admin"
+)
+
+// ProgressFunc 报告任务进度，percent 取值0-100
+type ProgressFunc func(percent int, message string)
+
+// TaskHandler 自定义任务类型的执行器
+type TaskHandler struct {
+	// Execute 执行任务，返回错误时任务标记为失败；需要响应 ctx 取消以支持超时和取消
+	Execute func(ctx context.Context, task *adminModel.Task, progress ProgressFunc) error
+	// Validate 校验TaskData，创建任务时调用，为空时不校验
+	Validate func(taskData string) error
+	// DefaultTimeout 默认超时时间（秒），为0时使用全局默认值
+	DefaultTimeout int
+	// EstimatedDuration 预计执行时长（秒），用于计算排队等待时间，为0时使用默认值
+	EstimatedDuration int
+	// System 为true时任务不依赖Provider，在系统任务池中串行执行
+	System bool
+}
+
+// builtinTaskTypes 内置任务类型，由 executeTaskLogic 直接处理，不允许注册覆盖
+var builtinTaskTypes = map[string]bool{
+	"create":                             true,
+	"start":                              true,
+	"stop":                               true,
+	"restart":                            true,
+	"delete":                             true,
+	"reset":                              true,
+	"reset-password":                     true,
+	"create-port-mapping":                true,
+	"delete-port-mapping":                true,
+	"sync-port-mappings":                 true,
+	adminModel.TaskTypeExportData:        true,
+	adminModel.TaskTypeMigratePortIP:     true,
+	adminModel.TaskTypeMigratePortMethod: true,
+	adminModel.TaskTypeNetworkProbe:      true,
+	adminModel.TaskTypeDetectPortService: true,
+}
+
+var (
+	taskHandlers   = make(map[string]TaskHandler)
+	taskHandlersMu sync.RWMutex
+)
+
+// RegisterTaskType 注册自定义任务类型，通常在扩展包的 init 中调用
+// 任务类型不能与内置类型或已注册的类型重复，长度不超过32个字符
+func RegisterTaskType(taskType string, handler TaskHandler) error {
+	if taskType == "" || len(taskType) > 32 {
+		return fmt.Errorf("任务类型不能为空且长度不超过32个字符")
+	}
+	if handler.Execute == nil {
+		return fmt.Errorf("任务类型 %s 缺少执行函数", taskType)
+	}
+	if builtinTaskTypes[taskType] {
+		return fmt.Errorf("任务类型 %s 为内置类型，不能重复注册", taskType)
+	}
+
+	taskHandlersMu.Lock()
+	defer taskHandlersMu.Unlock()
+	if _, exists := taskHandlers[taskType]; exists {
+		return fmt.Errorf("任务类型 %s 已注册", taskType)
+	}
+	taskHandlers[taskType] = handler
+	if handler.System {
+		adminModel.RegisterSystemTaskType(taskType)
+	}
+	return nil
+}
+
+// unregisterTaskType 移除已注册的自定义任务类型，供测试清理全局注册表
+func unregisterTaskType(taskType string) {
+	taskHandlersMu.Lock()
+	defer taskHandlersMu.Unlock()
+	handler, ok := taskHandlers[taskType]
+	if !ok {
+		return
+	}
+	delete(taskHandlers, taskType)
+	if handler.System {
+		adminModel.UnregisterSystemTaskType(taskType)
+	}
+}
+
+// MustRegisterTaskType 注册自定义任务类型，失败时panic
+func MustRegisterTaskType(taskType string, handler TaskHandler) {
+	if err := RegisterTaskType(taskType, handler); err != nil {
+		panic(err)
+	}
+}
+
+// getTaskHandler 获取已注册的自定义任务类型
+func getTaskHandler(taskType string) (TaskHandler, bool) {
+	taskHandlersMu.RLock()
+	defer taskHandlersMu.RUnlock()
+	handler, ok := taskHandlers[taskType]
+	return handler, ok
+}
+
+// RegisteredTaskTypes 返回所有已注册的自定义任务类型
+func RegisteredTaskTypes() []string {
+	taskHandlersMu.RLock()
+	defer taskHandlersMu.RUnlock()
+	types := make([]string, 0, len(taskHandlers))
+	for taskType := range taskHandlers {
+		types = append(types, taskType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// validateTaskData 按任务类型校验TaskData，内置类型由各自的创建流程校验
+func validateTaskData(taskType, taskData string) error {
+	if builtinTaskTypes[taskType] {
+		return nil
+	}
+	handler, ok := getTaskHandler(taskType)
+	if !ok {
+		return fmt.Errorf("未知的任务类型: %s", taskType)
+	}
+	if handler.Validate == nil {
+		return nil
+	}
+	if err := handler.Validate(taskData); err != nil {
+		return fmt.Errorf("任务数据校验失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	adminModel "oneclickvirt/model/admin"
+)
+
+func TestRegisterTaskType(t *testing.T) {
+	execute := func(ctx context.Context, task *adminModel.Task, progress ProgressFunc) error { return nil }
+	validate := func(taskData string) error {
+		var data struct {
+			Target string `json:"target"`
+		}
+		if err := json.Unmarshal([]byte(taskData), &data); err != nil {
+			return err
+		}
+		if data.Target == "" {
+			return errors.New("target 不能为空")
+		}
+		return nil
+	}
+
+	if err := RegisterTaskType("test-provision", TaskHandler{Execute: execute, Validate: validate, System: true}); err != nil {
+		t.Fatalf("RegisterTaskType: %v", err)
+	}
+	t.Cleanup(func() { unregisterTaskType("test-provision") })
+	if err := RegisterTaskType("test-provision", TaskHandler{Execute: execute}); err == nil {
+		t.Error("duplicate registration should fail")
+	}
+	if err := RegisterTaskType("create", TaskHandler{Execute: execute}); err == nil {
+		t.Error("overriding builtin type should fail")
+	}
+	if err := RegisterTaskType("test-no-execute", TaskHandler{}); err == nil {
+		t.Error("registration without Execute should fail")
+	}
+
+	if err := validateTaskData("test-provision", `{"target":"a"}`); err != nil {
+		t.Errorf("valid task data rejected: %v", err)
+	}
+	if err := validateTaskData("test-provision", `{}`); err == nil {
+		t.Error("invalid task data accepted")
+	}
+	if err := validateTaskData("test-unknown", `{}`); err == nil {
+		t.Error("unknown task type accepted")
+	}
+	if err := validateTaskData("create", `not json`); err != nil {
+		t.Errorf("builtin type should not be validated here: %v", err)
+	}
+
+	task := adminModel.Task{TaskType: "test-provision"}
+	if !task.IsSystemTask() {
+		t.Error("system task type without provider should be a system task")
+	}
+
+	unregisterTaskType("test-provision")
+	if _, ok := getTaskHandler("test-provision"); ok {
+		t.Error("unregistered task type should be removed")
+	}
+	if task.IsSystemTask() {
+		t.Error("unregistered task type should no longer be a system task")
+	}
+}
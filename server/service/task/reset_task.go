@@ -15,6 +15,9 @@ import (
 	userModel "oneclickvirt/model/user"
 	"oneclickvirt/provider/portmapping"
 	traffic_monitor "oneclickvirt/service/admin/traffic_monitor"
+	"oneclickvirt/service/hooks"
+	"oneclickvirt/service/instanceenv"
+	"oneclickvirt/service/instancename"
 	provider2 "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/utils"
@@ -110,6 +113,35 @@ func (s *TaskService) executeResetTask(ctx context.Context, task *adminModel.Tas
 		global.APP_LOG.Warn("重置系统：监控初始化失败", zap.Error(err))
 	}
 
+	// 阶段9: 写入分组默认SSH公钥、用户默认设置和MOTD，并执行用户订阅了重置事件的钩子脚本，失败不影响重置结果
+	if reason := hooks.InstallGroupSSHKey(task.ID, resetCtx.NewInstanceID); reason != "" {
+		global.APP_LOG.Warn("重置系统：写入分组SSH公钥失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("reason", reason))
+	}
+	if reason := hooks.ApplyUserInstanceDefaults(task.ID, resetCtx.NewInstanceID); reason != "" {
+		global.APP_LOG.Warn("重置系统：应用用户实例默认设置失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("reason", reason))
+	}
+	if reason := hooks.ApplyInstanceMOTD(task.ID, resetCtx.NewInstanceID); reason != "" {
+		global.APP_LOG.Warn("重置系统：写入MOTD失败",
+			zap.Uint("taskId", task.ID),
+			zap.String("reason", reason))
+	}
+	if failures := hooks.RunInstanceHooks(task.ID, resetCtx.NewInstanceID, userModel.HookEventReset); len(failures) > 0 {
+		global.APP_LOG.Warn("重置系统：用户钩子执行失败",
+			zap.Uint("taskId", task.ID),
+			zap.Strings("failures", failures))
+	}
+
+	// 阶段10: 按策略执行安全加固，失败不影响重置结果
+	if reason := hooks.RunInstanceHardening(task.ID, resetCtx.NewInstanceID); reason != "" {
+		global.APP_LOG.Warn("重置系统：安全加固未全部完成",
+			zap.Uint("taskId", task.ID),
+			zap.String("reason", reason))
+	}
+
 	s.updateTaskProgress(task.ID, 100, "重置完成")
 
 	global.APP_LOG.Info("用户实例重置成功",
@@ -285,9 +317,8 @@ func (s *TaskService) resetTask_CleanupOldInstance(ctx context.Context, task *ad
 		}
 
 		// 4. 重命名并软删除实例记录（避免唯一索引冲突，同时保留流量统计）
-		// 在旧实例名后添加时间戳，释放 name+provider_id 的唯一索引
-		deletedName := fmt.Sprintf("%s_deleted_%d", resetCtx.Instance.Name, time.Now().Unix())
-		if err := tx.Model(&resetCtx.Instance).Update("name", deletedName).Error; err != nil {
+		// 在旧实例名后添加时间戳和ID，释放 name+provider_id 的唯一索引
+		if err := instancename.Release(tx, &resetCtx.Instance); err != nil {
 			return fmt.Errorf("重命名实例失败: %v", err)
 		}
 
@@ -327,20 +358,30 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 			Provider:       resetCtx.Provider.Name,
 			ProviderID:     resetCtx.Provider.ID,
 			Image:          resetCtx.Instance.Image,
+			ImageID:        resetCtx.SystemImage.ID,
+			OSEOLDate:      resetCtx.SystemImage.EffectiveEOLDate(),
 			InstanceType:   resetCtx.Instance.InstanceType,
 			CPU:            resetCtx.Instance.CPU,
 			Memory:         resetCtx.Instance.Memory,
 			Disk:           resetCtx.Instance.Disk,
 			Bandwidth:      resetCtx.Instance.Bandwidth,
 			UserID:         resetCtx.OriginalUserID,
+			GroupID:        resetCtx.Instance.GroupID, // 保留原实例所在分组
+			Tags:           resetCtx.Instance.Tags,    // 保留原实例的标签
 			Status:         "creating",
 			OSType:         resetCtx.Instance.OSType,
+			GuestSSHPort:   resetCtx.Instance.GuestSSHPort, // 沿用原实例的SSH端口，恢复的端口映射指向同一端口
 			ExpiresAt:      resetCtx.OriginalExpiresAt,
 			IsManualExpiry: resetCtx.OriginalIsManualExpiry, // 继承原实例的手动过期时间设置
 			PublicIP:       resetCtx.Provider.Endpoint,
 			MaxTraffic:     int64(resetCtx.OriginalMaxTraffic),
 		}
 
+		// 沿用原实例名称，不受重用冷却期限制
+		if err := instancename.Reserve(tx, resetCtx.Provider.ID, resetCtx.OldInstanceName, instancename.ReserveOptions{IgnoreCooldown: true}); err != nil {
+			return fmt.Errorf("预留实例名称失败: %w", err)
+		}
+
 		if err := tx.Create(&newInstance).Error; err != nil {
 			return fmt.Errorf("创建新实例记录失败: %v", err)
 		}
@@ -390,16 +431,6 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 			Memory:       fmt.Sprintf("%dm", resetCtx.Instance.Memory),
 			Disk:         fmt.Sprintf("%dm", resetCtx.Instance.Disk),
 			Env:          map[string]string{"RESET_OPERATION": "true"},
-			Metadata: map[string]string{
-				"user_level":               fmt.Sprintf("%d", user.Level),
-				"bandwidth_spec":           fmt.Sprintf("%d", resetCtx.Instance.Bandwidth),
-				"ipv4_port_mapping_method": resetCtx.Provider.IPv4PortMappingMethod,
-				"ipv6_port_mapping_method": resetCtx.Provider.IPv6PortMappingMethod,
-				"network_type":             resetCtx.Provider.NetworkType,
-				"instance_id":              fmt.Sprintf("%d", resetCtx.NewInstanceID),
-				"provider_id":              fmt.Sprintf("%d", resetCtx.Provider.ID),
-				"reset_from_instance_id":   fmt.Sprintf("%d", resetCtx.OldInstanceID),
-			},
 			Privileged:   boolPtr(resetCtx.Provider.ContainerPrivileged),
 			AllowNesting: boolPtr(resetCtx.Provider.ContainerAllowNesting),
 			EnableLXCFS:  boolPtr(resetCtx.Provider.ContainerEnableLXCFS),
@@ -410,6 +441,24 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 		},
 		SystemImageID: resetCtx.SystemImage.ID,
 	}
+	// 与创建流程相同的系统元数据和默认环境变量/元数据，重置前后的实例配置保持一致
+	instanceenv.Apply(&createReq.InstanceConfig, instanceenv.Vars{
+		Operation:           "reset",
+		UserID:              user.ID,
+		Username:            user.Username,
+		UserLevel:           user.Level,
+		InstanceID:          resetCtx.NewInstanceID,
+		InstanceName:        resetCtx.OldInstanceName,
+		InstanceType:        resetCtx.Instance.InstanceType,
+		Image:               resetCtx.Instance.Image,
+		CPU:                 resetCtx.Instance.CPU,
+		MemoryMB:            resetCtx.Instance.Memory,
+		DiskMB:              resetCtx.Instance.Disk,
+		BandwidthMbps:       resetCtx.Instance.Bandwidth,
+		GuestSSHPort:        resetCtx.Instance.GuestSSHPort,
+		ResetFromInstanceID: resetCtx.OldInstanceID,
+		Provider:            &resetCtx.Provider,
+	})
 
 	// Docker端口映射特殊处理
 	if resetCtx.Provider.Type == "docker" && len(resetCtx.OldPortMappings) > 0 {
@@ -438,8 +487,12 @@ func (s *TaskService) resetTask_CreateNewInstance(ctx context.Context, task *adm
 		return fmt.Errorf("Provider创建实例失败: %v", err)
 	}
 
-	// 等待实例启动
-	time.Sleep(15 * time.Second)
+	// 等待实例启动，Windows 首次启动需要完成 sysprep，等待时间更长
+	if constant.IsWindowsOSType(resetCtx.Instance.OSType) {
+		time.Sleep(60 * time.Second)
+	} else {
+		time.Sleep(15 * time.Second)
+	}
 
 	// 确保实例运行
 	if prov, _, err := providerApiService.GetProviderByID(resetCtx.Provider.ID); err == nil {
@@ -469,7 +522,7 @@ func (s *TaskService) resetTask_SetPassword(ctx context.Context, task *adminMode
 	s.updateTaskProgress(task.ID, 70, "正在设置新密码...")
 
 	// 生成新密码
-	resetCtx.NewPassword = utils.GenerateStrongPassword(12)
+	resetCtx.NewPassword = utils.GenerateStrongPassword(utils.InstancePasswordLength())
 
 	// 获取内网IP
 	providerApiService := &provider2.ProviderApiService{}
@@ -519,7 +572,7 @@ func (s *TaskService) resetTask_UpdateInstanceInfo(ctx context.Context, task *ad
 	err := s.dbService.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
 		updates := map[string]interface{}{
 			"status":   "running",
-			"username": "root",
+			"username": constant.DefaultLoginUser(resetCtx.Instance.OSType),
 			"password": resetCtx.NewPassword,
 		}
 
@@ -562,8 +615,8 @@ func (s *TaskService) resetTask_UpdateInstanceInfo(ctx context.Context, task *ad
 func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *adminModel.Task, resetCtx *ResetTaskContext) error {
 	s.updateTaskProgress(task.ID, 88, "正在恢复端口映射...")
 
-	// 对于LXD/Incus，等待实例获取IP地址
-	if resetCtx.Provider.Type == "lxd" || resetCtx.Provider.Type == "incus" {
+	// 对于LXD/Incus/LXC/Kubernetes，等待实例获取IP地址
+	if resetCtx.Provider.Type == "lxd" || resetCtx.Provider.Type == "incus" || resetCtx.Provider.Type == "lxc" || resetCtx.Provider.Type == "kubernetes" {
 		if resetCtx.NewPrivateIP == "" {
 			providerApiService := &provider2.ProviderApiService{}
 			prov, _, err := providerApiService.GetProviderByID(resetCtx.Provider.ID)
@@ -700,7 +753,7 @@ func (s *TaskService) resetTask_RestorePortMappings(ctx context.Context, task *a
 				Update("ssh_port", sshPort.HostPort)
 		} else {
 			global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", resetCtx.NewInstanceID).
-				Update("ssh_port", 22)
+				Update("ssh_port", constant.GuestSSHPortOrDefault(resetCtx.Instance.GuestSSHPort))
 		}
 		return nil
 	})
@@ -719,7 +772,7 @@ func (s *TaskService) createPortMappingDirect(ctx context.Context, resetCtx *Res
 
 	// 确定端口映射类型
 	portMappingType := resetCtx.Provider.Type
-	if portMappingType == "proxmox" {
+	if portMappingType == "proxmox" || portMappingType == "lxc" {
 		portMappingType = "iptables"
 	}
 
@@ -945,20 +998,21 @@ func (s *TaskService) configureProviderPortMappings(ctx context.Context, prov in
 
 		return nil
 
-	case "proxmox":
-		// Proxmox 使用 iptables，通过 SetupPortMappingWithIP 在远程服务器上创建端口映射规则
+	case "proxmox", "lxc":
+		// Proxmox/LXC 使用 iptables，通过 SetupPortMappingWithIP 在远程服务器上创建端口映射规则
 		// 注意：数据库记录已在 Step 1 中创建，此处仅配置 iptables 规则
 		proxmoxProv, ok := prov.(interface {
 			SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error
 		})
 		if !ok {
-			return fmt.Errorf("Provider类型断言失败: proxmox")
+			return fmt.Errorf("Provider类型断言失败: %s", resetCtx.Provider.Type)
 		}
 
 		// 逐个配置端口映射
 		for _, port := range resetCtx.OldPortMappings {
 			if err := proxmoxProv.SetupPortMappingWithIP(ctx, resetCtx.OldInstanceName, port.HostPort, port.GuestPort, port.Protocol, resetCtx.Provider.IPv4PortMappingMethod, instanceIP); err != nil {
-				global.APP_LOG.Warn("配置Proxmox端口映射失败",
+				global.APP_LOG.Warn("配置iptables端口映射失败",
+					zap.String("providerType", resetCtx.Provider.Type),
 					zap.Int("hostPort", port.HostPort),
 					zap.Int("guestPort", port.GuestPort),
 					zap.Error(err))
@@ -979,6 +1033,28 @@ func (s *TaskService) configureProviderPortMappings(ctx context.Context, prov in
 
 		return nil
 
+	case "kubernetes":
+		// Kubernetes 为每条端口映射创建 NodePort Service，Service 按标签选择Pod，无需保存iptables规则
+		k8sProv, ok := prov.(interface {
+			SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error
+		})
+		if !ok {
+			return fmt.Errorf("Provider类型断言失败: kubernetes")
+		}
+
+		// 逐个配置端口映射
+		for _, port := range resetCtx.OldPortMappings {
+			if err := k8sProv.SetupPortMappingWithIP(ctx, resetCtx.OldInstanceName, port.HostPort, port.GuestPort, port.Protocol, resetCtx.Provider.IPv4PortMappingMethod, instanceIP); err != nil {
+				global.APP_LOG.Warn("配置Kubernetes端口映射失败",
+					zap.Int("hostPort", port.HostPort),
+					zap.Int("guestPort", port.GuestPort),
+					zap.Error(err))
+				// 继续配置其他端口
+			}
+		}
+
+		return nil
+
 	default:
 		return fmt.Errorf("不支持的Provider类型: %s", resetCtx.Provider.Type)
 	}
@@ -1003,7 +1079,7 @@ func getInstancePrivateIP(ctx context.Context, prov interface{}, providerType, i
 				return ip
 			}
 		}
-	case "proxmox":
+	case "proxmox", "lxc", "kubernetes":
 		if p, ok := prov.(interface {
 			GetInstanceIPv4(context.Context, string) (string, error)
 		}); ok {
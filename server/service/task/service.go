@@ -59,6 +59,7 @@ const (
 	maxContextAge          = 15 * time.Minute // 超时强制清理
 	poolCleanupInterval    = 5 * time.Minute  // Provider工作池清理间隔
 	maxPoolIdleTime        = 30 * time.Minute // 工作池最大空闲时间
+	systemTaskPoolID       = 0                // 不依赖Provider的系统任务（如数据导出）使用的工作池ID
 )
 
 var (
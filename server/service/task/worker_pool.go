@@ -100,7 +100,7 @@ func (pool *ProviderWorkerPool) executeTask(taskReq TaskRequest) {
 
 	// 更新任务状态为运行中 - 使用SELECT FOR UPDATE确保原子性
 	updateErr := pool.TaskService.dbService.ExecuteTransaction(taskCtx, func(tx *gorm.DB) error {
-		// 使用行锁查询任务，确保原子性
+		// 使用行锁查询任务，确保原子性（SQLite 忽略行锁，由写事务开始时持有的库级写锁保证）
 		var currentTask adminModel.Task
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 			Where("id = ?", task.ID).
@@ -188,28 +188,34 @@ func (s *TaskService) StartTaskWithPool(taskID uint) error {
 		return fmt.Errorf("查询任务失败: %v", err)
 	}
 
-	if task.ProviderID == nil {
-		return fmt.Errorf("任务没有关联Provider")
-	}
+	var pool *ProviderWorkerPool
+	if task.IsSystemTask() {
+		// 系统任务不依赖Provider，在独立的串行工作池中执行
+		pool = s.getOrCreateProviderPool(systemTaskPoolID, 1)
+	} else {
+		if task.ProviderID == nil {
+			return fmt.Errorf("任务没有关联Provider")
+		}
 
-	// 获取Provider配置
-	var provider providerModel.Provider
-	err = s.dbService.ExecuteQuery(context.Background(), func() error {
-		return global.APP_DB.First(&provider, *task.ProviderID).Error
-	})
+		// 获取Provider配置
+		var provider providerModel.Provider
+		err = s.dbService.ExecuteQuery(context.Background(), func() error {
+			return global.APP_DB.First(&provider, *task.ProviderID).Error
+		})
 
-	if err != nil {
-		return fmt.Errorf("查询Provider失败: %v", err)
-	}
+		if err != nil {
+			return fmt.Errorf("查询Provider失败: %v", err)
+		}
 
-	// 确定并发数
-	concurrency := 1 // 默认串行
-	if provider.AllowConcurrentTasks && provider.MaxConcurrentTasks > 0 {
-		concurrency = provider.MaxConcurrentTasks
-	}
+		// 确定并发数
+		concurrency := 1 // 默认串行
+		if provider.AllowConcurrentTasks && provider.MaxConcurrentTasks > 0 {
+			concurrency = provider.MaxConcurrentTasks
+		}
 
-	// 获取或创建工作池
-	pool := s.getOrCreateProviderPool(*task.ProviderID, concurrency)
+		// 获取或创建工作池
+		pool = s.getOrCreateProviderPool(*task.ProviderID, concurrency)
+	}
 
 	// 创建任务请求，使用带缓冲的channel防止阻塞
 	taskReq := TaskRequest{
@@ -262,7 +268,7 @@ func (s *TaskService) StartTaskWithPool(taskID uint) error {
 	case pool.TaskQueue <- taskReq:
 		global.APP_LOG.Info("任务已发送到工作池",
 			zap.Uint("taskId", taskID),
-			zap.Uint("providerId", *task.ProviderID),
+			zap.Uint("providerId", pool.ProviderID),
 			zap.Int("queueLength", len(pool.TaskQueue)))
 	case <-timer.C:
 		// 发送失败，关闭ResponseCh防止泄漏
@@ -0,0 +1,93 @@
+// Package timezone 用户和Provider的时区
+// 时间统一按UTC存储，流量重置时间、按月统计和定时计划按所属用户或Provider的时区计算，
+// 未设置时区时使用 system.timezone，仍未配置时使用服务器本地时区
+package timezone
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/utils"
+)
+
+// cacheTTL 时区缓存有效期，修改时区后主动失效，过期只用于兜底
+const cacheTTL = 5 * time.Minute
+
+type cacheEntry struct {
+	name      string
+	expiresAt time.Time
+}
+
+var (
+	mu    sync.RWMutex
+	cache = make(map[string]cacheEntry)
+)
+
+// ForUser 返回用户的时区
+func ForUser(userID uint) *time.Location {
+	return utils.LoadLocation(lookup(fmt.Sprintf("user:%d", userID), func() (string, error) {
+		var u userModel.User
+		err := global.APP_DB.Select("id", "timezone").First(&u, userID).Error
+		return u.Timezone, err
+	}))
+}
+
+// ForProvider 返回Provider的时区
+func ForProvider(providerID uint) *time.Location {
+	return utils.LoadLocation(lookup(fmt.Sprintf("provider:%d", providerID), func() (string, error) {
+		var p providerModel.Provider
+		err := global.APP_DB.Select("id", "timezone").First(&p, providerID).Error
+		return p.Timezone, err
+	}))
+}
+
+// UserNow 返回用户时区的当前时间
+func UserNow(userID uint) time.Time {
+	return time.Now().In(ForUser(userID))
+}
+
+// ProviderNow 返回Provider时区的当前时间
+func ProviderNow(providerID uint) time.Time {
+	return time.Now().In(ForProvider(providerID))
+}
+
+// InvalidateUser 用户修改时区后清除缓存
+func InvalidateUser(userID uint) {
+	invalidate(fmt.Sprintf("user:%d", userID))
+}
+
+// InvalidateProvider Provider修改时区后清除缓存
+func InvalidateProvider(providerID uint) {
+	invalidate(fmt.Sprintf("provider:%d", providerID))
+}
+
+// lookup 读取缓存的时区名称，查询失败时返回空字符串（使用默认时区）且不缓存
+func lookup(key string, load func() (string, error)) string {
+	mu.RLock()
+	entry, ok := cache[key]
+	mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.name
+	}
+	if global.APP_DB == nil {
+		return ""
+	}
+	name, err := load()
+	if err != nil {
+		return ""
+	}
+	mu.Lock()
+	cache[key] = cacheEntry{name: name, expiresAt: time.Now().Add(cacheTTL)}
+	mu.Unlock()
+	return name
+}
+
+func invalidate(key string) {
+	mu.Lock()
+	delete(cache, key)
+	mu.Unlock()
+}
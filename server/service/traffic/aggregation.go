@@ -6,8 +6,10 @@ import (
 
 	"oneclickvirt/global"
 	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // AggregationService 流量聚合服务 - 定期将pmacct原始数据聚合到缓存表
@@ -122,7 +124,7 @@ func (s *AggregationService) AggregateMonthlyTraffic(year, month int) error {
 
 // saveToCacheWithInfo 保存流量统计到缓存表（使用预加载的实例信息）
 func (s *AggregationService) saveToCacheWithInfo(instanceID, providerID, userID uint, year, month int, stats *TrafficStats) error {
-	// 使用UPSERT逻辑（ON DUPLICATE KEY UPDATE）
+	// 使用UPSERT逻辑
 	record := monitoringModel.InstanceTrafficHistory{
 		InstanceID: instanceID,
 		ProviderID: providerID,
@@ -138,21 +140,13 @@ func (s *AggregationService) saveToCacheWithInfo(instanceID, providerID, userID
 	}
 
 	// 使用原生SQL实现真正的 UPSERT，避免并发问题和重复数据错误
-	// 兼容 MySQL 5.x/9.x 和 MariaDB 的 ON DUPLICATE KEY UPDATE 语法
+	// 冲突子句按方言生成（MySQL/MariaDB 为 ON DUPLICATE KEY UPDATE，PostgreSQL/SQLite 为 ON CONFLICT）
 	sql := `
 		INSERT INTO instance_traffic_histories 
 			(instance_id, provider_id, user_id, traffic_in, traffic_out, total_used, 
 			 year, month, day, hour, record_time, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
-		ON DUPLICATE KEY UPDATE
-			provider_id = VALUES(provider_id),
-			user_id = VALUES(user_id),
-			traffic_in = VALUES(traffic_in),
-			traffic_out = VALUES(traffic_out),
-			total_used = VALUES(total_used),
-			record_time = VALUES(record_time),
-			updated_at = NOW()
-	`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ` + utils.SQLNow(global.APP_DB) + `, ` + utils.SQLNow(global.APP_DB) + `)
+	` + instanceHistoryUpsertClause(global.APP_DB)
 
 	return global.APP_DB.Exec(sql,
 		record.InstanceID, record.ProviderID, record.UserID,
@@ -162,6 +156,18 @@ func (s *AggregationService) saveToCacheWithInfo(instanceID, providerID, userID
 	).Error
 }
 
+// instanceHistoryUpsertClause 实例流量缓存写入时的UPSERT子句
+func instanceHistoryUpsertClause(db *gorm.DB) string {
+	return utils.SQLUpsert(db, []string{"instance_id", "year", "month", "day", "hour"},
+		"provider_id = "+utils.SQLInserted(db, "provider_id"),
+		"user_id = "+utils.SQLInserted(db, "user_id"),
+		"traffic_in = "+utils.SQLInserted(db, "traffic_in"),
+		"traffic_out = "+utils.SQLInserted(db, "traffic_out"),
+		"total_used = "+utils.SQLInserted(db, "total_used"),
+		"record_time = "+utils.SQLInserted(db, "record_time"),
+		"updated_at = "+utils.SQLNow(db))
+}
+
 // saveToCache 保存流量统计到缓存表（保留用于单独调用）
 func (s *AggregationService) saveToCache(instanceID uint, year, month int, stats *TrafficStats) error {
 	// 获取instance的provider_id和user_id
@@ -181,9 +187,9 @@ func (s *AggregationService) saveToCache(instanceID uint, year, month int, stats
 	return s.saveToCacheWithInfo(instanceID, instance.ProviderID, instance.UserID, year, month, stats)
 }
 
-// AggregateCurrentMonth 聚合当月流量数据（定时任务调用）
+// AggregateCurrentMonth 聚合当月流量数据（定时任务调用），月份按默认时区确定
 func (s *AggregationService) AggregateCurrentMonth() error {
-	now := time.Now()
+	now := time.Now().In(utils.DefaultLocation())
 	return s.AggregateMonthlyTraffic(now.Year(), int(now.Month()))
 }
 
@@ -322,13 +328,15 @@ func (s *AggregationService) computeDailyTraffic(instanceID uint, year, month, d
 
 	// 获取Provider配置用于计算实际使用量
 	var providerConfig struct {
-		TrafficCountMode  string
-		TrafficMultiplier float64
+		TrafficCountMode         string
+		TrafficMultiplier        float64
+		TrafficMultiplierWindows string
+		Timezone                 string
 	}
 
 	err = global.APP_DB.Table("instances i").
 		Joins("INNER JOIN providers p ON i.provider_id = p.id").
-		Select("COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier").
+		Select("COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier, p.traffic_multiplier_windows, p.timezone").
 		Where("i.id = ?", instanceID).
 		Scan(&providerConfig).Error
 	if err != nil {
@@ -341,6 +349,16 @@ func (s *AggregationService) computeDailyTraffic(instanceID uint, year, month, d
 		TotalBytes: result.RxBytes + result.TxBytes,
 	}
 
+	// 配置了分时段倍率时按采样点增量所在时段计费
+	if schedule := newMultiplierSchedule(providerConfig.TrafficMultiplier, providerConfig.TrafficMultiplierWindows, providerConfig.Timezone); schedule != nil {
+		samples, err := loadUsageSamples(instanceID, "year = ? AND month = ? AND day = ?", year, month, day)
+		if err != nil {
+			return nil, err
+		}
+		stats.ActualUsageMB = schedule.weightedUsageMB(samples, providerConfig.TrafficCountMode)
+		return stats, nil
+	}
+
 	// 应用流量计算模式
 	stats.ActualUsageMB = s.queryService.calculateActualUsage(
 		result.RxBytes,
@@ -364,16 +382,8 @@ func (s *AggregationService) saveDailyCacheWithInfo(instanceID, providerID, user
 		INSERT INTO instance_traffic_histories 
 			(instance_id, provider_id, user_id, traffic_in, traffic_out, total_used, 
 			 year, month, day, hour, record_time, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
-		ON DUPLICATE KEY UPDATE
-			provider_id = VALUES(provider_id),
-			user_id = VALUES(user_id),
-			traffic_in = VALUES(traffic_in),
-			traffic_out = VALUES(traffic_out),
-			total_used = VALUES(total_used),
-			record_time = VALUES(record_time),
-			updated_at = NOW()
-	`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ` + utils.SQLNow(global.APP_DB) + `, ` + utils.SQLNow(global.APP_DB) + `)
+	` + instanceHistoryUpsertClause(global.APP_DB)
 
 	return global.APP_DB.Exec(sql,
 		instanceID, providerID, userID,
@@ -8,6 +8,7 @@ import (
 	monitoringModel "oneclickvirt/model/monitoring"
 	providerModel "oneclickvirt/model/provider"
 	"oneclickvirt/model/system"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -148,8 +149,9 @@ func (h *HistoryService) AggregateProviderTrafficHistory(providerID uint) error
 	day := now.Day()
 	hour := now.Hour()
 
+	db := global.APP_DB
 	// 聚合该Provider所有实例的当前小时流量
-	return global.APP_DB.Exec(`
+	return db.Exec(fmt.Sprintf(`
 		INSERT INTO provider_traffic_histories 
 			(provider_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 		SELECT 
@@ -162,20 +164,14 @@ func (h *HistoryService) AggregateProviderTrafficHistory(providerID uint) error
 			month,
 			day,
 			hour,
-			? as record_time,
-			? as created_at,
-			? as updated_at
+			%[1]s as record_time,
+			%[1]s as created_at,
+			%[1]s as updated_at
 		FROM instance_traffic_histories
 		WHERE provider_id = ? AND year = ? AND month = ? AND day = ? AND hour = ? AND deleted_at IS NULL
 		GROUP BY provider_id, year, month, day, hour
-		ON DUPLICATE KEY UPDATE
-			traffic_in = VALUES(traffic_in),
-			traffic_out = VALUES(traffic_out),
-			total_used = VALUES(total_used),
-			instance_count = VALUES(instance_count),
-			record_time = VALUES(record_time),
-			updated_at = VALUES(updated_at)
-	`, now, time.Now(), time.Now(), providerID, year, month, day, hour).Error
+		%[2]s
+	`, utils.SQLTimeArg(db), HistoryUpsertClause(db, "provider_id")), now, time.Now(), time.Now(), providerID, year, month, day, hour).Error
 }
 
 // AggregateDailyProviderTraffic 聚合Provider每日流量
@@ -184,8 +180,9 @@ func (h *HistoryService) AggregateDailyProviderTraffic(providerID uint, date tim
 	month := int(date.Month())
 	day := date.Day()
 
+	db := global.APP_DB
 	// 从小时级数据聚合到日级
-	return global.APP_DB.Exec(`
+	return db.Exec(fmt.Sprintf(`
 		INSERT INTO provider_traffic_histories 
 			(provider_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 		SELECT 
@@ -198,20 +195,14 @@ func (h *HistoryService) AggregateDailyProviderTraffic(providerID uint, date tim
 			month,
 			day,
 			0 as hour,
-			? as record_time,
-			? as created_at,
-			? as updated_at
+			%[1]s as record_time,
+			%[1]s as created_at,
+			%[1]s as updated_at
 		FROM provider_traffic_histories
 		WHERE provider_id = ? AND year = ? AND month = ? AND day = ? AND hour > 0 AND deleted_at IS NULL
 		GROUP BY provider_id, year, month, day
-		ON DUPLICATE KEY UPDATE
-			traffic_in = VALUES(traffic_in),
-			traffic_out = VALUES(traffic_out),
-			total_used = VALUES(total_used),
-			instance_count = VALUES(instance_count),
-			record_time = VALUES(record_time),
-			updated_at = VALUES(updated_at)
-	`, date, time.Now(), time.Now(), providerID, year, month, day).Error
+		%[2]s
+	`, utils.SQLTimeArg(db), HistoryUpsertClause(db, "provider_id")), date, time.Now(), time.Now(), providerID, year, month, day).Error
 }
 
 // AggregateUserTrafficHistory 聚合用户流量历史（小时级）
@@ -223,8 +214,9 @@ func (h *HistoryService) AggregateUserTrafficHistory(userID uint) error {
 	day := now.Day()
 	hour := now.Hour()
 
+	db := global.APP_DB
 	// 聚合该用户所有实例的当前小时流量
-	return global.APP_DB.Exec(`
+	return db.Exec(fmt.Sprintf(`
 		INSERT INTO user_traffic_histories 
 			(user_id, traffic_in, traffic_out, total_used, instance_count, year, month, day, hour, record_time, created_at, updated_at)
 		SELECT 
@@ -237,20 +229,25 @@ func (h *HistoryService) AggregateUserTrafficHistory(userID uint) error {
 			month,
 			day,
 			hour,
-			? as record_time,
-			? as created_at,
-			? as updated_at
+			%[1]s as record_time,
+			%[1]s as created_at,
+			%[1]s as updated_at
 		FROM instance_traffic_histories
 		WHERE user_id = ? AND year = ? AND month = ? AND day = ? AND hour = ? AND deleted_at IS NULL
 		GROUP BY user_id, year, month, day, hour
-		ON DUPLICATE KEY UPDATE
-			traffic_in = VALUES(traffic_in),
-			traffic_out = VALUES(traffic_out),
-			total_used = VALUES(total_used),
-			instance_count = VALUES(instance_count),
-			record_time = VALUES(record_time),
-			updated_at = VALUES(updated_at)
-	`, now, time.Now(), time.Now(), userID, year, month, day, hour).Error
+		%[2]s
+	`, utils.SQLTimeArg(db), HistoryUpsertClause(db, "user_id")), now, time.Now(), time.Now(), userID, year, month, day, hour).Error
+}
+
+// HistoryUpsertClause Provider/用户流量历史聚合写入时的UPSERT子句，ownerColumn 为 provider_id 或 user_id
+func HistoryUpsertClause(db *gorm.DB, ownerColumn string) string {
+	return utils.SQLUpsert(db, []string{ownerColumn, "year", "month", "day", "hour"},
+		"traffic_in = "+utils.SQLInserted(db, "traffic_in"),
+		"traffic_out = "+utils.SQLInserted(db, "traffic_out"),
+		"total_used = "+utils.SQLInserted(db, "total_used"),
+		"instance_count = "+utils.SQLInserted(db, "instance_count"),
+		"record_time = "+utils.SQLInserted(db, "record_time"),
+		"updated_at = "+utils.SQLInserted(db, "updated_at"))
 }
 
 // GetInstanceTrafficHistory 获取实例流量历史（用于图表展示）
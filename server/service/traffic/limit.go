@@ -9,6 +9,7 @@ import (
 	dashboardModel "oneclickvirt/model/dashboard"
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/user"
+	"oneclickvirt/service/timezone"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -34,7 +35,7 @@ func NewLimitService() *LimitService {
 // 只统计启用了流量统计的Provider
 // pmacct重启会导致累积值重置，需要检测并分段计算
 func (s *LimitService) getUserMonthlyTrafficFromPmacct(userID uint) (int64, error) {
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	year := now.Year()
 	month := int(now.Month())
 
@@ -57,7 +58,7 @@ func (s *LimitService) getUserMonthlyTrafficFromPmacct(userID uint) (int64, erro
 // getProviderMonthlyTrafficFromPmacct 从聚合表获取Provider当月流量使用量
 // 使用provider_traffic_histories聚合表，大幅提升性能
 func (s *LimitService) getProviderMonthlyTrafficFromPmacct(providerID uint) (int64, error) {
-	now := time.Now()
+	now := timezone.ProviderNow(providerID)
 	year := now.Year()
 	month := int(now.Month())
 
@@ -233,7 +234,7 @@ func (s *LimitService) getUserYearlyTrafficFromPmacct(userID uint) (int64, error
 	// 一次性批量查询所有实例的年度流量（当前年度）
 	// 处理pmacct重启导致的累积值重置问题
 	var totalTrafficMB float64
-	currentYear := time.Now().Year()
+	currentYear := timezone.UserNow(userID).Year()
 	err = global.APP_DB.Raw(`
 		SELECT COALESCE(SUM(segment_total), 0) / 1048576.0
 		FROM (
@@ -293,7 +294,7 @@ func (s *LimitService) getUserYearlyTrafficFromPmacct(userID uint) (int64, error
 // getUserTrafficHistoryFromPmacct 从聚合表获取用户流量历史
 // 使用user_traffic_histories聚合表，大幅提升性能
 func (s *LimitService) getUserTrafficHistoryFromPmacct(userID uint, months int) ([]map[string]interface{}, error) {
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	history := make([]map[string]interface{}, 0, months)
 
 	// 获取最近N个月的数据
@@ -345,8 +346,8 @@ func (s *LimitService) getUserTrafficHistoryFromPmacct(userID uint, months int)
 
 // GetSystemTrafficStats 获取系统全局流量统计
 func (s *LimitService) GetSystemTrafficStats() (map[string]interface{}, error) {
-	// 获取当前时间
-	now := time.Now()
+	// 获取当前时间（按默认时区）
+	now := time.Now().In(utils.DefaultLocation())
 	year, month, _ := now.Date()
 
 	// 获取系统总流量（所有实例本月流量总和）
@@ -497,8 +498,8 @@ func (s *LimitService) GetProviderTrafficUsageWithPmacct(providerID uint) (map[s
 
 // GetUsersTrafficRanking 获取用户流量排行榜
 func (s *LimitService) GetUsersTrafficRanking(page, pageSize int, username, nickname string) ([]map[string]interface{}, int64, error) {
-	// 获取当前月份
-	now := time.Now()
+	// 获取当前月份（按默认时区）
+	now := time.Now().In(utils.DefaultLocation())
 	year, month, _ := now.Date()
 
 	// 查询用户本月流量使用排行
@@ -0,0 +1,194 @@
+package traffic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/utils"
+)
+
+const (
+	maxWindowMultiplier = 10.0
+	// 两次采样间隔超过该时长（采集中断）时不再逐分钟拆分，按结束时刻的倍率计算
+	maxWeightedSpan = 31 * 24 * time.Hour
+)
+
+// multiplierWindow 解析后的计费时段
+type multiplierWindow struct {
+	window     blackout.Window
+	multiplier float64
+}
+
+// multiplierSchedule 流量计费倍率表：时段内使用时段倍率，其余时间使用基础倍率
+// 时段按Provider时区判断，与流量记录的年月日划分一致
+type multiplierSchedule struct {
+	base    float64
+	windows []multiplierWindow
+	loc     *time.Location
+}
+
+// usageSample 流量采样点，RxBytes/TxBytes 为当月（或当天）累积值
+type usageSample struct {
+	Timestamp time.Time
+	RxBytes   int64
+	TxBytes   int64
+}
+
+// EncodeMultiplierWindows 校验并序列化分时段计费倍率，为空时返回空字符串
+func EncodeMultiplierWindows(windows []providerModel.TrafficMultiplierWindow) (string, error) {
+	var cleaned []providerModel.TrafficMultiplierWindow
+	for _, w := range windows {
+		w.Window = strings.TrimSpace(w.Window)
+		if w.Window == "" {
+			continue
+		}
+		if _, err := blackout.Parse(w.Window); err != nil {
+			return "", err
+		}
+		if w.Multiplier < 0 || w.Multiplier > maxWindowMultiplier {
+			return "", fmt.Errorf("时段 %q 的计费倍率需在 0-%.0f 之间", w.Window, maxWindowMultiplier)
+		}
+		cleaned = append(cleaned, w)
+	}
+	if len(cleaned) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// newMultiplierSchedule 根据Provider配置构建倍率表，时段按Provider时区判断，未配置时段时返回nil，调用方按基础倍率计算
+func newMultiplierSchedule(base float64, windowsJSON, timezone string) *multiplierSchedule {
+	if windowsJSON == "" {
+		return nil
+	}
+	p := providerModel.Provider{TrafficMultiplierWindows: windowsJSON}
+	schedule := &multiplierSchedule{base: base, loc: utils.LoadLocation(timezone)}
+	for _, w := range p.GetTrafficMultiplierWindows() {
+		parsed, err := blackout.Parse(w.Window)
+		if err != nil {
+			continue
+		}
+		schedule.windows = append(schedule.windows, multiplierWindow{window: parsed, multiplier: w.Multiplier})
+	}
+	if len(schedule.windows) == 0 {
+		return nil
+	}
+	return schedule
+}
+
+// at 返回某一时刻的计费倍率
+func (m *multiplierSchedule) at(t time.Time) float64 {
+	local := t.In(m.loc)
+	for _, w := range m.windows {
+		if w.window.Contains(local) {
+			return w.multiplier
+		}
+	}
+	return m.base
+}
+
+// average 返回 (from, to] 区间内的平均倍率
+// 两次采样之间的流量视为均匀产生，跨越时段边界时按各分钟的倍率加权
+func (m *multiplierSchedule) average(from, to time.Time) float64 {
+	span := to.Sub(from)
+	if span <= 0 || span > maxWeightedSpan {
+		return m.at(to)
+	}
+	var weighted float64
+	for cursor := from; cursor.Before(to); {
+		next := cursor.Truncate(time.Minute).Add(time.Minute)
+		if next.After(to) {
+			next = to
+		}
+		weighted += m.at(cursor) * next.Sub(cursor).Seconds()
+		cursor = next
+	}
+	return weighted / span.Seconds()
+}
+
+// weightedUsageMB 按采样点之间的增量和对应时段的倍率计算实际使用量（MB）
+// 累积值下降视为pmacct重启，与分段SQL一致：重启后的第一个采样点和首个采样点按其自身值计入
+func (m *multiplierSchedule) weightedUsageMB(samples []usageSample, countMode string) float64 {
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+
+	var total float64
+	for i, cur := range samples {
+		rx, tx := cur.RxBytes, cur.TxBytes
+		multiplier := m.at(cur.Timestamp)
+		if i > 0 {
+			prev := samples[i-1]
+			if cur.RxBytes >= prev.RxBytes && cur.TxBytes >= prev.TxBytes {
+				rx, tx = cur.RxBytes-prev.RxBytes, cur.TxBytes-prev.TxBytes
+				multiplier = m.average(prev.Timestamp, cur.Timestamp)
+			}
+		}
+
+		var bytes float64
+		switch countMode {
+		case "out":
+			bytes = float64(tx)
+		case "in":
+			bytes = float64(rx)
+		default: // "both"
+			bytes = float64(rx + tx)
+		}
+		total += bytes * multiplier
+	}
+	return total / 1048576.0
+}
+
+// loadUsageSamples 读取实例的流量采样点，conditions 为附加在 instance_id 之后的过滤条件
+func loadUsageSamples(instanceID uint, conditions string, args ...interface{}) ([]usageSample, error) {
+	var samples []usageSample
+	err := global.APP_DB.Table("pmacct_traffic_records").
+		Select("timestamp, rx_bytes, tx_bytes").
+		Where("instance_id = ?", instanceID).
+		Where(conditions, args...).
+		Order("timestamp ASC").
+		Scan(&samples).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询流量采样点失败: %w", err)
+	}
+	return samples, nil
+}
+
+// windowedMonthlyUsageMB 按分时段倍率计算实例当月实际使用量
+func (s *QueryService) windowedMonthlyUsageMB(instanceID uint, year, month int, countMode string, schedule *multiplierSchedule) (float64, error) {
+	samples, err := loadUsageSamples(instanceID, "year = ? AND month = ?", year, month)
+	if err != nil {
+		return 0, err
+	}
+	return schedule.weightedUsageMB(samples, countMode), nil
+}
+
+// windowedDailyUsageMB 按分时段倍率计算实例自 since 起每天的实际使用量，键为 2006-01-02
+// 与按天分段的SQL一致，每天单独计算增量
+func (s *QueryService) windowedDailyUsageMB(instanceID uint, since time.Time, countMode string, schedule *multiplierSchedule) (map[string]float64, error) {
+	samples, err := loadUsageSamples(instanceID, "timestamp >= ?", since)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[string][]usageSample)
+	for _, sample := range samples {
+		// 与 utils.SQLDate 一致按UTC日期分组
+		key := sample.Timestamp.UTC().Format("2006-01-02")
+		byDay[key] = append(byDay[key], sample)
+	}
+	usage := make(map[string]float64, len(byDay))
+	for key, daySamples := range byDay {
+		usage[key] = schedule.weightedUsageMB(daySamples, countMode)
+	}
+	return usage, nil
+}
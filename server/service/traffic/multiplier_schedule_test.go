@@ -0,0 +1,83 @@
+package traffic
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	providerModel "oneclickvirt/model/provider"
+)
+
+const mb = 1048576
+
+func clock(hour, minute int) time.Time {
+	return time.Date(2024, 5, 6, hour, minute, 0, 0, time.Local) // 周一
+}
+
+func offPeakSchedule(t *testing.T) *multiplierSchedule {
+	t.Helper()
+	schedule := newMultiplierSchedule(1, `[{"window":"01:00-07:00","multiplier":0.5}]`, "")
+	if schedule == nil {
+		t.Fatal("schedule should not be nil")
+	}
+	return schedule
+}
+
+func TestMultiplierScheduleAverage(t *testing.T) {
+	schedule := offPeakSchedule(t)
+	if got := schedule.at(clock(3, 0)); got != 0.5 {
+		t.Errorf("at(03:00) = %v, want 0.5", got)
+	}
+	if got := schedule.at(clock(7, 0)); got != 1 {
+		t.Errorf("at(07:00) = %v, want 1", got)
+	}
+	// 06:55-07:05 一半在闲时一半在忙时
+	if got := schedule.average(clock(6, 55), clock(7, 5)); math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("average across boundary = %v, want 0.75", got)
+	}
+}
+
+func TestWeightedUsageMB(t *testing.T) {
+	schedule := offPeakSchedule(t)
+	samples := []usageSample{
+		{Timestamp: clock(6, 50), RxBytes: 10 * mb, TxBytes: 0},
+		{Timestamp: clock(6, 55), RxBytes: 20 * mb, TxBytes: 0},     // 闲时 +10MB
+		{Timestamp: clock(7, 5), RxBytes: 40 * mb, TxBytes: 0},      // 跨边界 +20MB
+		{Timestamp: clock(7, 10), RxBytes: 4 * mb, TxBytes: 0},      // 重启，按自身值计入
+		{Timestamp: clock(7, 15), RxBytes: 6 * mb, TxBytes: 8 * mb}, // 忙时 +2MB/+8MB
+	}
+	// 5 + 5 + 15 + 4 + 10
+	want := 39.0
+	if got := schedule.weightedUsageMB(samples, "both"); math.Abs(got-want) > 1e-9 {
+		t.Errorf("weightedUsageMB(both) = %v, want %v", got, want)
+	}
+	// 仅出向：只有最后一段的 8MB
+	if got := schedule.weightedUsageMB(samples, "out"); math.Abs(got-8) > 1e-9 {
+		t.Errorf("weightedUsageMB(out) = %v, want 8", got)
+	}
+}
+
+func TestEncodeMultiplierWindows(t *testing.T) {
+	got, err := EncodeMultiplierWindows([]providerModel.TrafficMultiplierWindow{
+		{Window: " sat,sun 00:00-24:00 ", Multiplier: 0},
+		{Window: "", Multiplier: 2},
+	})
+	if err != nil || got != `[{"window":"sat,sun 00:00-24:00","multiplier":0}]` {
+		t.Errorf("EncodeMultiplierWindows = %q, %v", got, err)
+	}
+	if got, err := EncodeMultiplierWindows(nil); err != nil || got != "" {
+		t.Errorf("empty windows = %q, %v", got, err)
+	}
+	for _, bad := range []providerModel.TrafficMultiplierWindow{
+		{Window: "25:00-26:00", Multiplier: 1},
+		{Window: "01:00-07:00", Multiplier: -1},
+		{Window: "01:00-07:00", Multiplier: 11},
+	} {
+		if _, err := EncodeMultiplierWindows([]providerModel.TrafficMultiplierWindow{bad}); err == nil {
+			t.Errorf("EncodeMultiplierWindows(%+v) should fail", bad)
+		}
+	}
+	if newMultiplierSchedule(1, "", "") != nil {
+		t.Error("schedule without windows should be nil")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/utils"
 
 	"gorm.io/gorm"
 )
@@ -25,6 +27,9 @@ type TrafficStats struct {
 	TxBytes       int64   `json:"tx_bytes"`        // 发送字节数
 	TotalBytes    int64   `json:"total_bytes"`     // 总字节数
 	ActualUsageMB float64 `json:"actual_usage_mb"` // 实际使用量（MB，已应用流量计算模式）
+
+	// 按地址族拆分的流量（仅实例查询填充）
+	Families *monitoringModel.TrafficFamilyBreakdown `json:"families,omitempty"`
 }
 
 // GetInstanceMonthlyTraffic 获取实例当月流量统计
@@ -33,19 +38,25 @@ func (s *QueryService) GetInstanceMonthlyTraffic(instanceID uint, year, month in
 	query := `
 		SELECT 
 			COALESCE(SUM(max_rx), 0) as rx_bytes,
-			COALESCE(SUM(max_tx), 0) as tx_bytes
+			COALESCE(SUM(max_tx), 0) as tx_bytes,
+			COALESCE(SUM(max_rx_v6), 0) as rx_bytes_v6,
+			COALESCE(SUM(max_tx_v6), 0) as tx_bytes_v6
 		FROM (
 			-- 检测重启并分段
 			SELECT 
 				segment_id,
 				MAX(rx_bytes) as max_rx,
-				MAX(tx_bytes) as max_tx
+				MAX(tx_bytes) as max_tx,
+				MAX(rx_bytes_v6) as max_rx_v6,
+				MAX(tx_bytes_v6) as max_tx_v6
 			FROM (
 				-- 计算累积重启次数作为segment_id
 				SELECT 
 					t1.timestamp,
 					t1.rx_bytes,
 					t1.tx_bytes,
+					t1.rx_bytes_v6,
+					t1.tx_bytes_v6,
 					(
 						SELECT COUNT(*)
 						FROM pmacct_traffic_records t2
@@ -74,8 +85,10 @@ func (s *QueryService) GetInstanceMonthlyTraffic(instanceID uint, year, month in
 	`
 
 	var result struct {
-		RxBytes int64
-		TxBytes int64
+		RxBytes   int64
+		TxBytes   int64
+		RxBytesV6 int64
+		TxBytesV6 int64
 	}
 
 	err := global.APP_DB.Raw(query, year, month, instanceID, year, month, instanceID, year, month).Scan(&result).Error
@@ -85,13 +98,15 @@ func (s *QueryService) GetInstanceMonthlyTraffic(instanceID uint, year, month in
 
 	// 获取Provider配置用于计算实际使用量
 	var providerConfig struct {
-		TrafficCountMode  string
-		TrafficMultiplier float64
+		TrafficCountMode         string
+		TrafficMultiplier        float64
+		TrafficMultiplierWindows string
+		Timezone                 string
 	}
 
 	err = global.APP_DB.Table("instances i").
 		Joins("INNER JOIN providers p ON i.provider_id = p.id").
-		Select("COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier").
+		Select("COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier, p.traffic_multiplier_windows, p.timezone").
 		Where("i.id = ?", instanceID).
 		Scan(&providerConfig).Error
 	if err != nil {
@@ -102,6 +117,15 @@ func (s *QueryService) GetInstanceMonthlyTraffic(instanceID uint, year, month in
 		RxBytes:    result.RxBytes,
 		TxBytes:    result.TxBytes,
 		TotalBytes: result.RxBytes + result.TxBytes,
+		Families:   monitoringModel.NewTrafficFamilyBreakdown(result.RxBytes, result.TxBytes, result.RxBytesV6, result.TxBytesV6),
+	}
+
+	// 配置了分时段倍率时按采样点增量所在时段计费
+	if schedule := newMultiplierSchedule(providerConfig.TrafficMultiplier, providerConfig.TrafficMultiplierWindows, providerConfig.Timezone); schedule != nil {
+		if stats.ActualUsageMB, err = s.windowedMonthlyUsageMB(instanceID, year, month, providerConfig.TrafficCountMode, schedule); err != nil {
+			return nil, err
+		}
+		return stats, nil
 	}
 
 	// 应用流量计算模式
@@ -379,14 +403,16 @@ func (s *QueryService) computeBatchMonthlyTraffic(instanceIDs []uint, year, mont
 
 	// 批量获取Provider配置
 	var providerConfigs []struct {
-		InstanceID        uint
-		TrafficCountMode  string
-		TrafficMultiplier float64
+		InstanceID               uint
+		TrafficCountMode         string
+		TrafficMultiplier        float64
+		TrafficMultiplierWindows string
+		Timezone                 string
 	}
 
 	err = global.APP_DB.Table("instances i").
 		Joins("INNER JOIN providers p ON i.provider_id = p.id").
-		Select("i.id as instance_id, COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier").
+		Select("i.id as instance_id, COALESCE(p.traffic_count_mode, 'both') as traffic_count_mode, COALESCE(p.traffic_multiplier, 1.0) as traffic_multiplier, p.traffic_multiplier_windows, p.timezone").
 		Where("i.id IN ?", instanceIDs).
 		Find(&providerConfigs).Error
 	if err != nil {
@@ -397,14 +423,17 @@ func (s *QueryService) computeBatchMonthlyTraffic(instanceIDs []uint, year, mont
 	configMap := make(map[uint]struct {
 		CountMode  string
 		Multiplier float64
+		Schedule   *multiplierSchedule
 	})
 	for _, cfg := range providerConfigs {
 		configMap[cfg.InstanceID] = struct {
 			CountMode  string
 			Multiplier float64
+			Schedule   *multiplierSchedule
 		}{
 			CountMode:  cfg.TrafficCountMode,
 			Multiplier: cfg.TrafficMultiplier,
+			Schedule:   newMultiplierSchedule(cfg.TrafficMultiplier, cfg.TrafficMultiplierWindows, cfg.Timezone),
 		}
 	}
 
@@ -417,8 +446,14 @@ func (s *QueryService) computeBatchMonthlyTraffic(instanceIDs []uint, year, mont
 			TotalBytes: raw.RxBytes + raw.TxBytes,
 		}
 
-		// 应用流量计算模式
-		if config, ok := configMap[raw.InstanceID]; ok {
+		// 应用流量计算模式，配置了分时段倍率时按采样点增量所在时段计费
+		if config, ok := configMap[raw.InstanceID]; ok && config.Schedule != nil {
+			usage, err := s.windowedMonthlyUsageMB(raw.InstanceID, year, month, config.CountMode, config.Schedule)
+			if err != nil {
+				return nil, err
+			}
+			stats.ActualUsageMB = usage
+		} else if ok {
 			stats.ActualUsageMB = s.calculateActualUsage(
 				raw.RxBytes,
 				raw.TxBytes,
@@ -445,12 +480,14 @@ func (s *QueryService) computeBatchMonthlyTraffic(instanceIDs []uint, year, mont
 func (s *QueryService) GetInstanceTrafficHistory(instanceID uint, days int) ([]*HistoryPoint, error) {
 	// 获取实例和Provider配置（用于计算实际用量）
 	var config struct {
-		TrafficCountMode  string
-		TrafficMultiplier float64
+		TrafficCountMode         string
+		TrafficMultiplier        float64
+		TrafficMultiplierWindows string
+		Timezone                 string
 	}
 	if err := global.APP_DB.Table("instances i").
 		Joins("INNER JOIN providers p ON i.provider_id = p.id").
-		Select("p.traffic_count_mode, p.traffic_multiplier").
+		Select("p.traffic_count_mode, p.traffic_multiplier, p.traffic_multiplier_windows, p.timezone").
 		Where("i.id = ?", instanceID).
 		Scan(&config).Error; err != nil {
 		return nil, fmt.Errorf("查询实例配置失败: %w", err)
@@ -468,7 +505,8 @@ func (s *QueryService) GetInstanceTrafficHistory(instanceID uint, days int) ([]*
 
 	// 兼容 MySQL 5.x - 不使用 CTE (WITH AS) 和窗口函数
 	// MySQL 5.x 不支持 CTE，改用派生表（子查询）实现相同逻辑
-	query := `
+	db := global.APP_DB
+	query := fmt.Sprintf(`
 		SELECT 
 			date,
 			SUM(max_rx) as rx_bytes,
@@ -483,26 +521,26 @@ func (s *QueryService) GetInstanceTrafficHistory(instanceID uint, days int) ([]*
 			FROM (
 				-- 检测累积值重置点（使用相关子查询，兼容MySQL 5.x）
 				SELECT 
-					DATE(t1.timestamp) as date,
+					%[1]s as date,
 					t1.timestamp,
 					t1.rx_bytes,
 					t1.tx_bytes,
 					(SELECT COUNT(*)
 					 FROM pmacct_traffic_records t2
 					 WHERE t2.instance_id = ? 
-					   AND DATE(t2.timestamp) = DATE(t1.timestamp)
+					   AND %[2]s = %[1]s
 					   AND t2.timestamp <= t1.timestamp
 					   AND (
 						 (t2.rx_bytes < (SELECT COALESCE(MAX(t3.rx_bytes), 0)
 										 FROM pmacct_traffic_records t3
 										 WHERE t3.instance_id = ?
-										   AND DATE(t3.timestamp) = DATE(t1.timestamp)
+										   AND %[3]s = %[1]s
 										   AND t3.timestamp < t2.timestamp))
 						 OR
 						 (t2.tx_bytes < (SELECT COALESCE(MAX(t3.tx_bytes), 0)
 										 FROM pmacct_traffic_records t3
 										 WHERE t3.instance_id = ?
-										   AND DATE(t3.timestamp) = DATE(t1.timestamp)
+										   AND %[3]s = %[1]s
 										   AND t3.timestamp < t2.timestamp))
 					   )
 					) as segment_id
@@ -513,16 +551,28 @@ func (s *QueryService) GetInstanceTrafficHistory(instanceID uint, days int) ([]*
 		) AS daily_segment_max
 		GROUP BY date
 		ORDER BY date ASC
-	`
+	`, utils.SQLDate(db, "t1.timestamp"), utils.SQLDate(db, "t2.timestamp"), utils.SQLDate(db, "t3.timestamp"))
 
-	if err := global.APP_DB.Raw(query, instanceID, instanceID, instanceID, instanceID, startDate).Scan(&results).Error; err != nil {
+	if err := db.Raw(query, instanceID, instanceID, instanceID, instanceID, startDate).Scan(&results).Error; err != nil {
 		return nil, fmt.Errorf("查询实例流量历史失败: %w", err)
 	}
 
+	// 配置了分时段倍率时按采样点增量所在时段计算每天的实际用量
+	var windowedUsage map[string]float64
+	if schedule := newMultiplierSchedule(config.TrafficMultiplier, config.TrafficMultiplierWindows, config.Timezone); schedule != nil {
+		var err error
+		if windowedUsage, err = s.windowedDailyUsageMB(instanceID, startDate, config.TrafficCountMode, schedule); err != nil {
+			return nil, err
+		}
+	}
+
 	// 转换为历史点
 	history := make([]*HistoryPoint, 0, len(results))
 	for _, r := range results {
 		actualUsageMB := s.calculateActualUsage(r.RxBytes, r.TxBytes, config.TrafficCountMode, config.TrafficMultiplier)
+		if windowedUsage != nil {
+			actualUsageMB = windowedUsage[r.Date.Format("2006-01-02")]
+		}
 		history = append(history, &HistoryPoint{
 			Date:          r.Date,
 			Year:          r.Date.Year(),
@@ -580,9 +630,10 @@ func (s *QueryService) GetUserTrafficHistory(userID uint, days int) ([]*HistoryP
 		TxBytes    int64
 	}
 
-	query := `
+	db := global.APP_DB
+	query := fmt.Sprintf(`
 		SELECT 
-			DATE(t1.timestamp) as date,
+			%[1]s as date,
 			instance_id,
 			SUM(max_rx) as rx_bytes,
 			SUM(max_tx) as tx_bytes
@@ -610,13 +661,13 @@ func (s *QueryService) GetUserTrafficHistory(userID uint, days int) ([]*HistoryP
 								FROM pmacct_traffic_records 
 								WHERE instance_id = t2.instance_id 
 									AND timestamp < t2.timestamp
-									AND DATE(timestamp) = DATE(t2.timestamp)
+									AND %[1]s = %[2]s
 							)
 						WHERE t2.instance_id = t1.instance_id
 							AND t2.user_id = ?
 							AND t2.timestamp >= ?
 							AND t2.timestamp <= t1.timestamp
-							AND DATE(t2.timestamp) = DATE(t1.timestamp)
+							AND %[2]s = %[3]s
 							AND (
 								(t3.rx_bytes IS NOT NULL AND t2.rx_bytes < t3.rx_bytes)
 								OR
@@ -626,13 +677,13 @@ func (s *QueryService) GetUserTrafficHistory(userID uint, days int) ([]*HistoryP
 				FROM pmacct_traffic_records t1
 				WHERE t1.user_id = ? AND t1.timestamp >= ?
 			) AS segments
-			GROUP BY instance_id, DATE(timestamp), segment_id, timestamp
+			GROUP BY instance_id, %[1]s, segment_id, timestamp
 		) AS daily_segments
-		GROUP BY DATE(timestamp), instance_id
+		GROUP BY %[1]s, instance_id
 		ORDER BY date ASC, instance_id
-	`
+	`, utils.SQLDate(db, "timestamp"), utils.SQLDate(db, "t2.timestamp"), utils.SQLDate(db, "t1.timestamp"))
 
-	if err := global.APP_DB.Raw(query, userID, startDate, userID, startDate).Scan(&rawResults).Error; err != nil {
+	if err := db.Raw(query, userID, startDate, userID, startDate).Scan(&rawResults).Error; err != nil {
 		return nil, fmt.Errorf("查询用户流量历史失败: %w", err)
 	}
 
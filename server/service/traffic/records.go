@@ -0,0 +1,131 @@
+package traffic
+
+import (
+	"fmt"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/monitoring"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRecordPageSize  = 50
+	maxRecordPageSize      = 500
+	recordExportBatchSize  = 1000
+	maxRecordExportBatches = 10000 // 单次导出最多 1000 万行，防止误操作长时间占用连接
+)
+
+// RecordService 原始流量记录查询服务
+// pmacct_traffic_records 数据量可达百万级，统一使用主键键集分页（WHERE id < ?）代替 OFFSET
+type RecordService struct{}
+
+// NewRecordService 创建原始流量记录查询服务
+func NewRecordService() *RecordService {
+	return &RecordService{}
+}
+
+// RecordQuery 原始流量记录查询条件
+type RecordQuery struct {
+	InstanceID uint
+	UserID     uint
+	ProviderID uint
+	StartTime  *time.Time
+	EndTime    *time.Time
+	Cursor     string // 上一页返回的 nextCursor
+	PageSize   int
+}
+
+// RecordPage 原始流量记录分页结果
+type RecordPage struct {
+	List       []monitoring.PmacctTrafficRecord `json:"list"`
+	PageSize   int                              `json:"pageSize"`
+	HasMore    bool                             `json:"hasMore"`
+	NextCursor string                           `json:"nextCursor,omitempty"`
+}
+
+// buildQuery 构建带过滤条件的基础查询，按主键倒序
+func (s *RecordService) buildQuery(q RecordQuery) *gorm.DB {
+	query := global.APP_DB.Model(&monitoring.PmacctTrafficRecord{})
+	if q.InstanceID > 0 {
+		query = query.Where("instance_id = ?", q.InstanceID)
+	}
+	if q.UserID > 0 {
+		query = query.Where("user_id = ?", q.UserID)
+	}
+	if q.ProviderID > 0 {
+		query = query.Where("provider_id = ?", q.ProviderID)
+	}
+	if q.StartTime != nil {
+		query = query.Where("timestamp >= ?", *q.StartTime)
+	}
+	if q.EndTime != nil {
+		query = query.Where("timestamp < ?", *q.EndTime)
+	}
+	return query.Order("id DESC")
+}
+
+// ListRecords 按游标分页查询原始流量记录
+// 不返回总数：在大表上 COUNT(*) 与深度分页一样昂贵
+func (s *RecordService) ListRecords(q RecordQuery) (*RecordPage, error) {
+	lastID, err := common.DecodeIDCursor(q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = defaultRecordPageSize
+	}
+	if q.PageSize > maxRecordPageSize {
+		q.PageSize = maxRecordPageSize
+	}
+
+	query := s.buildQuery(q)
+	if lastID > 0 {
+		query = query.Where("id < ?", lastID)
+	}
+
+	// 多取一条用于判断是否还有下一页
+	var records []monitoring.PmacctTrafficRecord
+	if err := query.Limit(q.PageSize + 1).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("查询流量记录失败: %w", err)
+	}
+
+	page := &RecordPage{PageSize: q.PageSize}
+	if len(records) > q.PageSize {
+		records = records[:q.PageSize]
+		page.HasMore = true
+		page.NextCursor = common.EncodeIDCursor(records[len(records)-1].ID)
+	}
+	page.List = records
+	return page, nil
+}
+
+// StreamRecords 按批次遍历符合条件的原始流量记录，每批回调一次
+// 调用方在回调中增量写出数据，内存中同时只保留一个批次
+func (s *RecordService) StreamRecords(q RecordQuery, fn func(records []monitoring.PmacctTrafficRecord) error) error {
+	var lastID uint
+	for i := 0; i < maxRecordExportBatches; i++ {
+		query := s.buildQuery(q)
+		if lastID > 0 {
+			query = query.Where("id < ?", lastID)
+		}
+
+		var batch []monitoring.PmacctTrafficRecord
+		if err := query.Limit(recordExportBatchSize).Find(&batch).Error; err != nil {
+			return fmt.Errorf("查询流量记录失败: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < recordExportBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+	return fmt.Errorf("导出记录数超过上限 %d 行，请缩小时间范围", recordExportBatchSize*maxRecordExportBatches)
+}
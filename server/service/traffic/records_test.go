@@ -0,0 +1,132 @@
+package traffic
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"oneclickvirt/model/common"
+	"oneclickvirt/model/monitoring"
+	"oneclickvirt/testutil"
+)
+
+// setupRecordsDB 写入7条记录：ID 1-5 属于实例1，ID 6-7 属于实例2，时间按ID递增
+func setupRecordsDB(t *testing.T) time.Time {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &monitoring.PmacctTrafficRecord{})
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for id := uint(1); id <= 7; id++ {
+		instanceID := uint(1)
+		if id > 5 {
+			instanceID = 2
+		}
+		record := monitoring.PmacctTrafficRecord{
+			ID: id, InstanceID: instanceID, UserID: 1, ProviderID: 1, ProviderType: "lxd", MappedIP: "10.0.0.1",
+			Timestamp: base.Add(time.Duration(id) * 5 * time.Minute),
+		}
+		if err := db.Create(&record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base
+}
+
+func recordIDs(records []monitoring.PmacctTrafficRecord) []uint {
+	ids := make([]uint, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestListRecordsCursor(t *testing.T) {
+	setupRecordsDB(t)
+	s := NewRecordService()
+
+	var pages [][]uint
+	cursor := ""
+	for {
+		page, err := s.ListRecords(RecordQuery{InstanceID: 1, PageSize: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, recordIDs(page.List))
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("最后一页不应返回游标: %q", page.NextCursor)
+			}
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if want := [][]uint{{5, 4}, {3, 2}, {1}}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("分页结果 = %v, want %v", pages, want)
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		wantErr bool
+	}{
+		{name: "空游标从头开始"},
+		{name: "有效游标", cursor: common.EncodeIDCursor(6)},
+		{name: "非base64", cursor: "not a cursor!", wantErr: true},
+		{name: "页码令牌不能当游标", cursor: common.NextPageToken(1, 10, 100), wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := s.ListRecords(RecordQuery{Cursor: tt.cursor})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListRecordsFilters(t *testing.T) {
+	base := setupRecordsDB(t)
+	s := NewRecordService()
+	start, end := base.Add(10*time.Minute), base.Add(30*time.Minute)
+	tests := []struct {
+		name  string
+		query RecordQuery
+		want  []uint
+	}{
+		{name: "按实例", query: RecordQuery{InstanceID: 2}, want: []uint{7, 6}},
+		{name: "时间范围左闭右开", query: RecordQuery{StartTime: &start, EndTime: &end}, want: []uint{5, 4, 3, 2}},
+		{name: "不匹配的用户", query: RecordQuery{UserID: 9}, want: []uint{}},
+		{name: "页大小上限", query: RecordQuery{PageSize: maxRecordPageSize + 1}, want: []uint{7, 6, 5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		page, err := s.ListRecords(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := recordIDs(page.List); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ids = %v, want %v", tt.name, got, tt.want)
+		}
+		if page.PageSize > maxRecordPageSize {
+			t.Errorf("%s: PageSize = %d 超过上限", tt.name, page.PageSize)
+		}
+	}
+}
+
+func TestStreamRecords(t *testing.T) {
+	setupRecordsDB(t)
+	s := NewRecordService()
+	var got []uint
+	if err := s.StreamRecords(RecordQuery{InstanceID: 1}, func(batch []monitoring.PmacctTrafficRecord) error {
+		got = append(got, recordIDs(batch)...)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint{5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("导出 = %v, want %v", got, want)
+	}
+
+	// 回调出错时停止导出并返回该错误
+	stop := errors.New("写出失败")
+	if err := s.StreamRecords(RecordQuery{}, func([]monitoring.PmacctTrafficRecord) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+}
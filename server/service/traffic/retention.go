@@ -0,0 +1,279 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetentionBatchSize  = 5000
+	defaultRetentionBatchPause = 200 * time.Millisecond
+)
+
+// retentionTable 保留策略可管理的表
+type retentionTable struct {
+	timeColumn string // 判断数据新旧的时间列
+	downsample bool   // 是否支持降采样（表中包含 year/month/day/hour/minute 维度）
+}
+
+// retentionTables 允许配置保留策略的表白名单，表名会直接拼入SQL
+var retentionTables = map[string]retentionTable{
+	"pmacct_traffic_records":     {timeColumn: "record_time", downsample: true},
+	"instance_traffic_histories": {timeColumn: "record_time"},
+	"provider_traffic_histories": {timeColumn: "record_time"},
+	"user_traffic_histories":     {timeColumn: "record_time"},
+}
+
+// RetentionService 监控数据保留与降采样服务
+// 按表执行：原始数据 -> 小时级 -> 日级 -> 删除，所有删除均按批次进行并在批次间暂停，避免长时间锁表
+type RetentionService struct {
+	batchSize  int
+	batchPause time.Duration
+}
+
+// NewRetentionService 根据配置创建保留策略服务
+func NewRetentionService() *RetentionService {
+	cfg := global.APP_CONFIG.Retention
+	s := &RetentionService{
+		batchSize:  cfg.BatchSize,
+		batchPause: time.Duration(cfg.BatchPauseMs) * time.Millisecond,
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultRetentionBatchSize
+	}
+	if cfg.BatchPauseMs <= 0 {
+		s.batchPause = defaultRetentionBatchPause
+	}
+	return s
+}
+
+// Run 按配置对所有表执行保留策略
+func (s *RetentionService) Run(ctx context.Context) error {
+	policies := global.APP_CONFIG.Retention.Tables
+	tables := make([]string, 0, len(policies))
+	for table := range policies {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	start := time.Now()
+	global.APP_LOG.Info("开始执行监控数据保留策略", zap.Strings("tables", tables))
+	for i, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		global.APP_LOG.Info("保留策略进度",
+			zap.String("table", table),
+			zap.Int("current", i+1),
+			zap.Int("total", len(tables)))
+		if err := s.applyPolicy(ctx, table, policies[table]); err != nil {
+			// 单表失败不影响其他表
+			global.APP_LOG.Error("执行保留策略失败", zap.String("table", table), zap.Error(err))
+		}
+	}
+	global.APP_LOG.Info("监控数据保留策略执行完成", zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// applyPolicy 对单表执行保留策略
+func (s *RetentionService) applyPolicy(ctx context.Context, table string, policy config.RetentionPolicy) error {
+	spec, ok := retentionTables[table]
+	if !ok {
+		return fmt.Errorf("不支持的表: %s", table)
+	}
+	if !spec.downsample && (policy.HourlyAfterDays > 0 || policy.DailyAfterDays > 0) {
+		global.APP_LOG.Warn("该表不支持降采样，仅执行过期删除", zap.String("table", table))
+	}
+
+	now := time.Now()
+	cutoff := func(days int) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -days)
+	}
+
+	// 先删除，避免对即将删除的数据做无用的降采样
+	if policy.KeepDays > 0 {
+		deleted, err := s.deleteInBatches(ctx, table, fmt.Sprintf("%s < ?", spec.timeColumn), cutoff(policy.KeepDays))
+		if err != nil {
+			return err
+		}
+		global.APP_LOG.Info("过期数据删除完成",
+			zap.String("table", table),
+			zap.Int("keepDays", policy.KeepDays),
+			zap.Int64("deleted", deleted))
+	}
+	if !spec.downsample {
+		return nil
+	}
+
+	if policy.DailyAfterDays > 0 {
+		if err := s.downsample(ctx, table, spec, cutoff(policy.DailyAfterDays), pmacctDaily); err != nil {
+			return err
+		}
+	}
+	if policy.HourlyAfterDays > 0 {
+		if err := s.downsample(ctx, table, spec, cutoff(policy.HourlyAfterDays), pmacctHourly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pmacctGranularity 降采样粒度
+type pmacctGranularity struct {
+	name       string
+	truncUnit  string // 聚合后的时间戳截断单位（hour/day）
+	hourExpr   string // 聚合后的 hour 列
+	groupBy    string // 额外的分组列
+	fineFilter string // 需要被聚合的细粒度记录条件
+}
+
+var (
+	pmacctHourly = pmacctGranularity{
+		name:       "hourly",
+		truncUnit:  "hour",
+		hourExpr:   "hour",
+		groupBy:    ", hour",
+		fineFilter: "minute > 0",
+	}
+	pmacctDaily = pmacctGranularity{
+		name:       "daily",
+		truncUnit:  "day",
+		hourExpr:   "0",
+		fineFilter: "(hour > 0 OR minute > 0)",
+	}
+)
+
+// downsample 将截止时间之前的细粒度记录逐日聚合，然后分批删除已聚合的细粒度记录
+// pmacct 记录为累积值，聚合时与 CleanupOldPmacctData 一致取区间内最大值
+func (s *RetentionService) downsample(ctx context.Context, table string, spec retentionTable, before time.Time, g pmacctGranularity) error {
+	var oldest struct {
+		Oldest *time.Time
+	}
+	if err := global.APP_DB.Table(table).
+		Select(fmt.Sprintf("MIN(%s) AS oldest", spec.timeColumn)).
+		Where(fmt.Sprintf("%s < ? AND %s", spec.timeColumn, g.fineFilter), before).
+		Scan(&oldest).Error; err != nil {
+		return fmt.Errorf("查询最早待降采样记录失败: %w", err)
+	}
+	if oldest.Oldest == nil {
+		return nil
+	}
+
+	day := time.Date(oldest.Oldest.Year(), oldest.Oldest.Month(), oldest.Oldest.Day(), 0, 0, 0, 0, before.Location())
+	totalDays := int(before.Sub(day).Hours()/24) + 1
+	var aggregated, deleted int64
+	for i := 1; day.Before(before); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next := day.AddDate(0, 0, 1)
+		if next.After(before) {
+			next = before
+		}
+
+		rows, err := s.rollupPmacct(table, spec, day, next, g)
+		if err != nil {
+			return err
+		}
+		n, err := s.deleteInBatches(ctx, table,
+			fmt.Sprintf("%s >= ? AND %s < ? AND %s", spec.timeColumn, spec.timeColumn, g.fineFilter), day, next)
+		if err != nil {
+			return err
+		}
+		aggregated += rows
+		deleted += n
+
+		if n > 0 {
+			global.APP_LOG.Info("降采样进度",
+				zap.String("table", table),
+				zap.String("granularity", g.name),
+				zap.String("day", day.Format("2006-01-02")),
+				zap.Int("current", i),
+				zap.Int("total", totalDays),
+				zap.Int64("deleted", n))
+		}
+		day = next
+	}
+
+	global.APP_LOG.Info("降采样完成",
+		zap.String("table", table),
+		zap.String("granularity", g.name),
+		zap.Time("before", before),
+		zap.Int64("aggregatedRows", aggregated),
+		zap.Int64("deletedRows", deleted))
+	return nil
+}
+
+// rollupPmacct 将时间窗口内的细粒度记录聚合为指定粒度，聚合结果按唯一键覆盖写入
+func (s *RetentionService) rollupPmacct(table string, spec retentionTable, start, end time.Time, g pmacctGranularity) (int64, error) {
+	db := global.APP_DB
+	greatest := func(column string) string {
+		return column + " = " + utils.SQLGreatest(db, table+"."+column, utils.SQLInserted(db, column))
+	}
+	sql := fmt.Sprintf(`
+		INSERT INTO %[1]s (
+			instance_id, user_id, provider_id, provider_type, mapped_ip,
+			rx_bytes, tx_bytes, total_bytes, rx_bytes_v6, tx_bytes_v6,
+			timestamp, year, month, day, hour, minute,
+			record_time, created_at, updated_at
+		)
+		SELECT
+			instance_id, user_id, provider_id, provider_type, mapped_ip,
+			MAX(rx_bytes), MAX(tx_bytes), MAX(total_bytes), MAX(rx_bytes_v6), MAX(tx_bytes_v6),
+			MIN(%[2]s), year, month, day, %[3]s, 0,
+			MAX(%[4]s), %[8]s, %[8]s
+		FROM %[1]s
+		WHERE %[4]s >= ? AND %[4]s < ? AND %[5]s AND deleted_at IS NULL
+		GROUP BY instance_id, user_id, provider_id, provider_type, mapped_ip, year, month, day%[6]s
+		%[7]s
+	`, table, utils.SQLTruncTime(db, "timestamp", g.truncUnit), g.hourExpr, spec.timeColumn, g.fineFilter, g.groupBy,
+		utils.SQLUpsert(db, []string{"instance_id", "timestamp"},
+			greatest("rx_bytes"),
+			greatest("tx_bytes"),
+			greatest("total_bytes"),
+			greatest("rx_bytes_v6"),
+			greatest("tx_bytes_v6"),
+			"deleted_at = NULL",
+			"updated_at = "+utils.SQLInserted(db, "updated_at")),
+		utils.SQLNow(db))
+
+	result := db.Exec(sql, start, end)
+	if result.Error != nil {
+		return 0, fmt.Errorf("聚合%s数据失败: %w", g.name, result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// deleteInBatches 分批物理删除满足条件的记录，返回删除总数
+func (s *RetentionService) deleteInBatches(ctx context.Context, table, where string, args ...interface{}) (int64, error) {
+	sql := fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT %d", table, where, s.batchSize)
+	if utils.IsPostgres(global.APP_DB) || utils.IsSQLite(global.APP_DB) {
+		// PostgreSQL/SQLite 的 DELETE 不支持 LIMIT，通过主键子查询限定批次
+		sql = fmt.Sprintf("DELETE FROM %[1]s WHERE id IN (SELECT id FROM %[1]s WHERE %[2]s LIMIT %[3]d)", table, where, s.batchSize)
+	}
+	var total int64
+	for {
+		result := global.APP_DB.Exec(sql, args...)
+		if result.Error != nil {
+			return total, fmt.Errorf("删除%s数据失败: %w", table, result.Error)
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(s.batchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(s.batchPause):
+		}
+	}
+}
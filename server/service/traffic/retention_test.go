@@ -0,0 +1,118 @@
+package traffic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/model/monitoring"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+func useRetentionDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	// 使用生产环境的SQLite驱动，聚合表达式返回的时间文本会被转换为 time.Time
+	return testutil.UseProductionSQLiteDB(t, &monitoring.PmacctTrafficRecord{})
+}
+
+// createPmacctRecord 写入一条5分钟精度的pmacct记录
+func createPmacctRecord(t *testing.T, db *gorm.DB, instanceID uint, at time.Time, total int64) {
+	t.Helper()
+	record := monitoring.PmacctTrafficRecord{
+		InstanceID: instanceID, UserID: 1, ProviderID: 1, ProviderType: "lxd", MappedIP: "10.0.0.1",
+		TotalBytes: total, Timestamp: at, RecordTime: at,
+		Year: at.Year(), Month: int(at.Month()), Day: at.Day(), Hour: at.Hour(), Minute: at.Minute(),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetentionApplyPolicy(t *testing.T) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	old := today.AddDate(0, 0, -40).Add(10 * time.Hour)    // 超过保留期
+	hourly := today.AddDate(0, 0, -10).Add(10 * time.Hour) // 需要聚合为小时级
+	recent := today.AddDate(0, 0, -1).Add(10 * time.Hour)  // 保留原始精度
+	policy := config.RetentionPolicy{KeepDays: 30, HourlyAfterDays: 7}
+
+	tests := []struct {
+		name    string
+		table   string
+		wantErr bool
+	}{
+		{name: "白名单中的表", table: "pmacct_traffic_records"},
+		{name: "不在白名单的表", table: "users", wantErr: true},
+		{name: "注入表名", table: "pmacct_traffic_records; DROP TABLE users", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useRetentionDB(t)
+			for _, at := range []time.Time{old, old.Add(5 * time.Minute), recent, recent.Add(5 * time.Minute)} {
+				createPmacctRecord(t, db, 1, at, at.Unix())
+			}
+			for i, minutes := range []int{5, 10, 15} {
+				createPmacctRecord(t, db, 1, hourly.Add(time.Duration(minutes)*time.Minute), int64(100*(i+1)))
+			}
+
+			s := &RetentionService{batchSize: 2}
+			err := s.applyPolicy(t.Context(), tt.table, policy)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("应拒绝")
+				}
+				var count int64
+				db.Model(&monitoring.PmacctTrafficRecord{}).Count(&count)
+				if count != 7 {
+					t.Errorf("拒绝后不应删除数据，剩余 %d 条", count)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var records []monitoring.PmacctTrafficRecord
+			if err := db.Order("record_time").Find(&records).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 3 {
+				t.Fatalf("剩余 %d 条记录, want 3: %+v", len(records), records)
+			}
+			// 小时级记录取区间内累积值的最大值
+			if r := records[0]; r.Minute != 0 || r.Hour != 10 || r.TotalBytes != 300 {
+				t.Errorf("小时级记录 = %+v", r)
+			}
+			if !records[1].RecordTime.Equal(recent) || !records[2].RecordTime.Equal(recent.Add(5*time.Minute)) {
+				t.Errorf("近期记录不应变化: %v %v", records[1].RecordTime, records[2].RecordTime)
+			}
+		})
+	}
+}
+
+func TestRetentionDeleteInBatches(t *testing.T) {
+	db := useRetentionDB(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		createPmacctRecord(t, db, 1, base.Add(time.Duration(i)*5*time.Minute), 1)
+	}
+
+	s := &RetentionService{batchSize: 2}
+	deleted, err := s.deleteInBatches(t.Context(), "pmacct_traffic_records", "record_time < ?", base.Add(20*time.Minute))
+	if err != nil || deleted != 4 {
+		t.Fatalf("deleteInBatches = %d, %v, want 4", deleted, err)
+	}
+
+	// 上下文取消时在批次间停止
+	createPmacctRecord(t, db, 2, base, 1)
+	createPmacctRecord(t, db, 2, base.Add(5*time.Minute), 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	s.batchPause = time.Hour
+	if deleted, err := s.deleteInBatches(ctx, "pmacct_traffic_records", "instance_id = ?", 2); err == nil || deleted != 2 {
+		t.Errorf("取消后 deleteInBatches = %d, %v", deleted, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/user"
+	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
 )
@@ -45,8 +46,7 @@ func (s *Service) InitUserTrafficQuota(userID uint) error {
 	}
 
 	trafficLimit := s.GetUserTrafficLimitByLevel(u.Level)
-	now := time.Now()
-	resetTime := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	resetTime := utils.NextMonthStart(time.Now(), utils.LoadLocation(u.Timezone))
 
 	return global.APP_DB.Model(&u).Updates(map[string]interface{}{
 		"total_traffic":    trafficLimit,
@@ -64,9 +64,12 @@ func (s *Service) CheckProviderTrafficLimit(providerID uint) (bool, error) {
 
 	now := time.Now()
 
+	// 流量按Provider时区的自然月重置
+	loc := utils.LoadLocation(p.Timezone)
+
 	// 初始化TrafficResetAt
 	if p.TrafficResetAt == nil {
-		nextReset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+		nextReset := utils.NextMonthStart(now, loc)
 		p.TrafficResetAt = &nextReset
 		if err := global.APP_DB.Model(&p).Update("traffic_reset_at", nextReset).Error; err != nil {
 			global.APP_LOG.Error("初始化Provider流量重置时间失败",
@@ -78,7 +81,7 @@ func (s *Service) CheckProviderTrafficLimit(providerID uint) (bool, error) {
 
 	// 检查是否到了重置时间
 	if !now.Before(*p.TrafficResetAt) {
-		nextReset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+		nextReset := utils.NextMonthStart(now, loc)
 		updates := map[string]interface{}{
 			"traffic_reset_at": nextReset,
 			"traffic_limited":  false,
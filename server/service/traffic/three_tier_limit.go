@@ -9,6 +9,9 @@ import (
 	adminModel "oneclickvirt/model/admin"
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/user"
+	"oneclickvirt/service/blackout"
+	"oneclickvirt/service/timezone"
+	"oneclickvirt/service/userwebhook"
 
 	"go.uber.org/zap"
 )
@@ -140,8 +143,8 @@ func (s *ThreeTierLimitService) CheckInstanceTrafficLimit(instanceID uint) (bool
 		return false, nil
 	}
 
-	// 获取实例当月流量
-	now := time.Now()
+	// 获取实例当月流量，按Provider时区确定月份
+	now := timezone.ProviderNow(instance.ProviderID)
 	year := now.Year()
 	month := int(now.Month())
 
@@ -159,6 +162,14 @@ func (s *ThreeTierLimitService) CheckInstanceTrafficLimit(instanceID uint) (bool
 
 	// 不再更新instance.used_traffic字段（已删除）
 
+	userwebhook.TrafficChecked(instance.UserID, userwebhook.TrafficUsage{
+		Scope:      "instance",
+		InstanceID: instanceID,
+		UsedMB:     usedTraffic,
+		LimitMB:    instance.MaxTraffic,
+		Period:     fmt.Sprintf("%04d-%02d", year, month),
+	})
+
 	// 检查是否超限
 	if usedTraffic >= instance.MaxTraffic {
 		// 实例超限，仅停止该实例
@@ -168,6 +179,14 @@ func (s *ThreeTierLimitService) CheckInstanceTrafficLimit(instanceID uint) (bool
 			zap.Int64("usedTraffic", usedTraffic),
 			zap.Int64("maxTraffic", instance.MaxTraffic))
 
+		// 维护窗口内推迟停机，窗口结束后的下一轮检查再执行
+		if !instance.TrafficLimited && blackout.Active(instance.ProviderID) {
+			global.APP_LOG.Info("处于维护窗口，推迟实例流量超限停机",
+				zap.Uint("instanceID", instanceID),
+				zap.Uint("providerID", instance.ProviderID))
+			return false, nil
+		}
+
 		return s.limitInstance(instanceID, "instance", fmt.Sprintf("实例流量超限: %dMB/%dMB", usedTraffic, instance.MaxTraffic))
 	}
 
@@ -321,7 +340,7 @@ func (s *ThreeTierLimitService) CheckUserTrafficLimit(userID uint) (bool, error)
 	}
 
 	// 从pmacct_traffic_records实时汇总用户当月总流量（已包含流量模式和倍率计算）
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	year := now.Year()
 	month := int(now.Month())
 
@@ -339,6 +358,13 @@ func (s *ThreeTierLimitService) CheckUserTrafficLimit(userID uint) (bool, error)
 		return false, fmt.Errorf("更新用户流量失败: %w", err)
 	}
 
+	userwebhook.TrafficChecked(userID, userwebhook.TrafficUsage{
+		Scope:   "user",
+		UsedMB:  totalUsedMB,
+		LimitMB: u.TotalTraffic,
+		Period:  fmt.Sprintf("%04d-%02d", year, month),
+	})
+
 	// 检查是否超限
 	if totalUsedMB >= u.TotalTraffic {
 		// 用户超限，停止用户所有实例
@@ -373,9 +399,22 @@ func (s *ThreeTierLimitService) limitUserInstances(userID uint, message string)
 		"status":               "stopped",
 	}
 
-	result := global.APP_DB.Model(&provider.Instance{}).
+	query := global.APP_DB.Model(&provider.Instance{}).
+		Where("user_id = ? AND status = ?", userID, "running")
+
+	// 处于维护窗口的Provider上的实例推迟停机，用户仍超限时下一轮检查再停止
+	var providerIDs []uint
+	global.APP_DB.Model(&provider.Instance{}).
 		Where("user_id = ? AND status = ?", userID, "running").
-		Updates(updates)
+		Distinct().Pluck("provider_id", &providerIDs)
+	if deferred := blackout.NewChecker(time.Now()).ActiveProviderIDs(providerIDs); len(deferred) > 0 {
+		global.APP_LOG.Info("处于维护窗口，推迟部分实例的用户流量超限停机",
+			zap.Uint("userID", userID),
+			zap.Any("providerIDs", deferred))
+		query = query.Where("provider_id NOT IN ?", deferred)
+	}
+
+	result := query.Updates(updates)
 
 	if result.Error != nil {
 		return false, fmt.Errorf("批量标记实例为受限状态失败: %w", result.Error)
@@ -504,7 +543,7 @@ func (s *ThreeTierLimitService) CheckProviderTrafficLimit(providerID uint) (bool
 	}
 
 	// 使用统一的流量查询服务获取Provider当月流量
-	now := time.Now()
+	now := timezone.ProviderNow(providerID)
 	year := now.Year()
 	month := int(now.Month())
 	queryService := NewQueryService()
@@ -551,6 +590,13 @@ func (s *ThreeTierLimitService) limitProviderInstances(providerID uint, message
 		return false, fmt.Errorf("标记Provider为受限状态失败: %w", err)
 	}
 
+	// 维护窗口内只禁止申请新实例，推迟停机，Provider仍超限时下一轮检查再停止
+	if blackout.Active(providerID) {
+		global.APP_LOG.Info("处于维护窗口，推迟Provider流量超限停机",
+			zap.Uint("providerID", providerID))
+		return true, nil
+	}
+
 	// 批量更新实例状态，避免逐个UPDATE
 	updates := map[string]interface{}{
 		"traffic_limited":      true,
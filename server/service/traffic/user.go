@@ -2,7 +2,6 @@ package traffic
 
 import (
 	"fmt"
-	"time"
 
 	"oneclickvirt/global"
 	dashboardModel "oneclickvirt/model/dashboard"
@@ -10,6 +9,7 @@ import (
 	"oneclickvirt/model/provider"
 	"oneclickvirt/model/user"
 	"oneclickvirt/service/cache"
+	"oneclickvirt/service/timezone"
 	"oneclickvirt/utils"
 
 	"go.uber.org/zap"
@@ -93,7 +93,7 @@ func (s *UserTrafficService) fetchUserTrafficOverview(userID uint) (map[string]i
 	}
 
 	// 从QueryService获取当月流量统计
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	stats, err := s.queryService.GetUserMonthlyTraffic(userID, now.Year(), int(now.Month()))
 	if err != nil {
 		return nil, fmt.Errorf("查询用户月度流量失败: %w", err)
@@ -180,7 +180,7 @@ func (s *UserTrafficService) fetchInstanceTrafficDetail(userID, instanceID uint)
 
 	// 获取Provider配置
 	var prov provider.Provider
-	if err := global.APP_DB.Select("id, enable_traffic_control, traffic_count_mode, traffic_multiplier").
+	if err := global.APP_DB.Select("id, enable_traffic_control, traffic_count_mode, traffic_multiplier, traffic_multiplier_windows").
 		First(&prov, instance.ProviderID).Error; err != nil {
 		return nil, fmt.Errorf("查询Provider配置失败: %w", err)
 	}
@@ -200,7 +200,7 @@ func (s *UserTrafficService) fetchInstanceTrafficDetail(userID, instanceID uint)
 	}
 
 	// 从QueryService获取当月流量数据
-	now := time.Now()
+	now := timezone.ProviderNow(instance.ProviderID)
 	stats, err := s.queryService.GetInstanceMonthlyTraffic(instanceID, now.Year(), int(now.Month()))
 	if err != nil {
 		return nil, fmt.Errorf("查询实例流量失败: %w", err)
@@ -228,8 +228,10 @@ func (s *UserTrafficService) fetchInstanceTrafficDetail(userID, instanceID uint)
 		"rx_bytes":                stats.RxBytes,
 		"tx_bytes":                stats.TxBytes,
 		"total_bytes":             stats.TotalBytes,
+		"families":                stats.Families,
 		"traffic_count_mode":      prov.TrafficCountMode,
 		"traffic_multiplier":      prov.TrafficMultiplier,
+		"multiplier_windows":      prov.GetTrafficMultiplierWindows(),
 		"year":                    now.Year(),
 		"month":                   int(now.Month()),
 		"history":                 history,
@@ -244,7 +246,7 @@ func (s *UserTrafficService) fetchInstanceTrafficDetail(userID, instanceID uint)
 
 // GetUserInstancesTrafficSummary 获取用户所有实例的流量汇总（带缓存）
 func (s *UserTrafficService) GetUserInstancesTrafficSummary(userID uint) (map[string]interface{}, error) {
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	cacheService := cache.GetUserCacheService()
 	cacheKey := cache.MakeUserTrafficSummaryKey(userID, now.Year(), int(now.Month()))
 
@@ -297,7 +299,7 @@ func (s *UserTrafficService) fetchUserInstancesTrafficSummary(userID uint) (map[
 	}
 
 	// 批量查询流量数据
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	statsMap, err := s.queryService.BatchGetInstancesMonthlyTraffic(instanceIDs, now.Year(), int(now.Month()))
 	if err != nil {
 		return nil, fmt.Errorf("批量查询实例流量失败: %w", err)
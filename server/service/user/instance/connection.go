@@ -0,0 +1,169 @@
+package instance
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+)
+
+// connectionBundleMessages 连接信息包的本地化文案
+var connectionBundleMessages = map[string]map[string]string{
+	"zh-CN": {
+		"title":      "实例连接信息",
+		"host":       "连接地址",
+		"sshPort":    "SSH端口",
+		"username":   "用户名",
+		"password":   "密码",
+		"ipv6":       "IPv6地址",
+		"sshCommand": "SSH命令",
+		"internal":   "内网SSH命令（同节点实例间）",
+		"ports":      "端口映射",
+		"sshPortDes": "SSH端口",
+		"noPorts":    "无",
+	},
+	"en-US": {
+		"title":      "Instance connection info",
+		"host":       "Host",
+		"sshPort":    "SSH port",
+		"username":   "Username",
+		"password":   "Password",
+		"ipv6":       "IPv6 address",
+		"sshCommand": "SSH command",
+		"internal":   "Internal SSH command (from instances on the same node)",
+		"ports":      "Port mappings",
+		"sshPortDes": "SSH port",
+		"noPorts":    "none",
+	},
+}
+
+// NormalizeLanguage 将 lang 参数或 Accept-Language 转换为支持的语言
+// 中文返回 zh-CN，其他语言返回 en-US，为空时使用系统默认语言
+func NormalizeLanguage(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		lang = global.APP_CONFIG.Other.DefaultLanguage
+	}
+	if lang == "" {
+		return "zh-CN"
+	}
+	// Accept-Language 取第一个语言标签，如 "en-US,en;q=0.9"
+	if idx := strings.IndexAny(lang, ",;"); idx >= 0 {
+		lang = lang[:idx]
+	}
+	if strings.HasPrefix(strings.ToLower(lang), "zh") {
+		return "zh-CN"
+	}
+	return "en-US"
+}
+
+// formatPortRange 格式化端口或端口段
+func formatPortRange(start, end int) string {
+	if end > start {
+		return fmt.Sprintf("%d-%d", start, end)
+	}
+	return strconv.Itoa(start)
+}
+
+// GetConnectionBundle 生成实例连接信息包：SSH命令、ssh config片段、PuTTY命令、端口映射表和二维码内容
+func (s *Service) GetConnectionBundle(userID, instanceID uint, lang string) (*userModel.InstanceConnectionBundle, error) {
+	// 复用实例详情的地址与SSH端口解析逻辑（含权限校验）
+	detail, err := s.GetInstanceDetail(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	lang = NormalizeLanguage(lang)
+	msg := connectionBundleMessages[lang]
+
+	ipv6 := detail.PublicIPv6
+	if ipv6 == "" {
+		ipv6 = detail.IPv6Address
+	}
+	host := detail.PublicIP
+	if host == "" {
+		host = ipv6 // 纯IPv6实例
+	}
+
+	bundle := &userModel.InstanceConnectionBundle{
+		InstanceID:   detail.ID,
+		InstanceName: detail.Name,
+		Language:     lang,
+		Host:         host,
+		SSHPort:      detail.SSHPort,
+		GuestSSHPort: detail.GuestSSHPort,
+		PrivateIP:    detail.PrivateIP,
+		Username:     detail.Username,
+		Password:     detail.Password,
+		IPv6Address:  ipv6,
+		Ports:        make([]userModel.ConnectionBundlePort, 0),
+	}
+
+	bundle.SSHCommand = fmt.Sprintf("ssh %s@%s -p %d", detail.Username, host, detail.SSHPort)
+	bundle.SSHConfig = fmt.Sprintf("Host %s\n    HostName %s\n    Port %d\n    User %s\n",
+		detail.Name, host, detail.SSHPort, detail.Username)
+	bundle.PuTTYCommand = fmt.Sprintf("putty -ssh %s@%s -P %d", detail.Username, host, detail.SSHPort)
+	bundle.QRCodePayload = fmt.Sprintf("ssh://%s@%s", detail.Username, net.JoinHostPort(host, strconv.Itoa(detail.SSHPort)))
+	// 内网直连实例内SSH端口，不经过公网端口映射，适合作为跳板在同节点实例间访问
+	if detail.PrivateIP != "" {
+		bundle.InternalSSHCommand = fmt.Sprintf("ssh %s@%s -p %d", detail.Username, detail.PrivateIP, detail.GuestSSHPort)
+	}
+
+	var ports []providerModel.Port
+	if err := global.APP_DB.Where("instance_id = ? AND status = ?", instanceID, "active").
+		Order("is_ssh DESC, host_port ASC").
+		Find(&ports).Error; err != nil {
+		return nil, fmt.Errorf("获取端口映射失败: %v", err)
+	}
+	for _, port := range ports {
+		description := port.Description
+		if port.IsSSH {
+			description = msg["sshPortDes"]
+		}
+		bundle.Ports = append(bundle.Ports, userModel.ConnectionBundlePort{
+			PublicPort:  formatPortRange(port.HostPort, port.HostPortEnd),
+			GuestPort:   formatPortRange(port.GuestPort, port.GuestPortEnd),
+			Protocol:    port.Protocol,
+			Address:     net.JoinHostPort(host, formatPortRange(port.HostPort, port.HostPortEnd)),
+			Description: description,
+			IsSSH:       port.IsSSH,
+		})
+	}
+
+	bundle.Text = buildConnectionBundleText(bundle, msg)
+	return bundle, nil
+}
+
+// buildConnectionBundleText 生成本地化的纯文本连接信息
+func buildConnectionBundleText(bundle *userModel.InstanceConnectionBundle, msg map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s - %s\n", msg["title"], bundle.InstanceName)
+	fmt.Fprintf(&b, "%s: %s\n", msg["host"], bundle.Host)
+	fmt.Fprintf(&b, "%s: %d\n", msg["sshPort"], bundle.SSHPort)
+	fmt.Fprintf(&b, "%s: %s\n", msg["username"], bundle.Username)
+	fmt.Fprintf(&b, "%s: %s\n", msg["password"], bundle.Password)
+	if bundle.IPv6Address != "" {
+		fmt.Fprintf(&b, "%s: %s\n", msg["ipv6"], bundle.IPv6Address)
+	}
+	fmt.Fprintf(&b, "%s: %s\n", msg["sshCommand"], bundle.SSHCommand)
+	if bundle.InternalSSHCommand != "" {
+		fmt.Fprintf(&b, "%s: %s\n", msg["internal"], bundle.InternalSSHCommand)
+	}
+
+	fmt.Fprintf(&b, "%s:\n", msg["ports"])
+	if len(bundle.Ports) == 0 {
+		fmt.Fprintf(&b, "  %s\n", msg["noPorts"])
+	}
+	for _, port := range bundle.Ports {
+		line := fmt.Sprintf("  %s -> %s/%s", port.Address, port.GuestPort, port.Protocol)
+		if port.Description != "" {
+			line += " (" + port.Description + ")"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
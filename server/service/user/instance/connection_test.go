@@ -0,0 +1,122 @@
+package instance
+
+import (
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/testutil"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	oldConfig := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = oldConfig })
+
+	tests := []struct {
+		lang        string
+		defaultLang string
+		want        string
+	}{
+		{lang: "zh-CN", want: "zh-CN"},
+		{lang: "zh-TW,zh;q=0.9", want: "zh-CN"},
+		{lang: "en-US,en;q=0.9", want: "en-US"},
+		{lang: "ja", want: "en-US"},
+		{lang: "", defaultLang: "en-US", want: "en-US"},
+		{lang: "  ", want: "zh-CN"},
+	}
+	for _, tt := range tests {
+		global.APP_CONFIG.Other.DefaultLanguage = tt.defaultLang
+		if got := NormalizeLanguage(tt.lang); got != tt.want {
+			t.Errorf("NormalizeLanguage(%q) 默认 %q = %s, want %s", tt.lang, tt.defaultLang, got, tt.want)
+		}
+	}
+}
+
+// setupConnectionDB 用户1拥有实例1（IPv4，有SSH和端口段映射）和实例2（纯IPv6，Provider无IPv4地址）
+func setupConnectionDB(t *testing.T) {
+	t.Helper()
+	db := testutil.UseSQLiteDB(t, &providerModel.Provider{}, &providerModel.Instance{}, &providerModel.Port{}, &adminModel.Task{})
+	testutil.Seed(t, db,
+		&providerModel.Provider{ID: 1, Name: "node", Endpoint: "203.0.113.1:22"},
+		&providerModel.Instance{ID: 1, Name: "web", ProviderID: 1, UserID: 1, PublicIP: "198.51.100.7", PrivateIP: "10.0.0.7",
+			Username: "root", Password: "pw", SSHPort: 22},
+		&providerModel.Provider{ID: 2, Name: "v6-node"},
+		&providerModel.Instance{ID: 2, Name: "v6", ProviderID: 2, UserID: 1, PublicIPv6: "2001:db8::7", Username: "root", SSHPort: 22},
+		&providerModel.Port{ID: 1, InstanceID: 1, ProviderID: 1, HostPort: 20022, GuestPort: 22, Protocol: "tcp", IsSSH: true, Status: "active"},
+		&providerModel.Port{ID: 2, InstanceID: 1, ProviderID: 1, HostPort: 30000, HostPortEnd: 30009, GuestPort: 30000, GuestPortEnd: 30009,
+			Protocol: "both", Description: "game", Status: "active"},
+		&providerModel.Port{ID: 3, InstanceID: 1, ProviderID: 1, HostPort: 40000, GuestPort: 80, Protocol: "tcp", Status: "deleted"},
+	)
+}
+
+func TestGetConnectionBundle(t *testing.T) {
+	setupConnectionDB(t)
+	s := NewService()
+
+	tests := []struct {
+		name       string
+		userID     uint
+		instanceID uint
+		lang       string
+		wantErr    bool
+		check      func(t *testing.T, text, sshCommand, qr, internal string, ports int)
+	}{
+		{
+			name: "实例所有者", userID: 1, instanceID: 1, lang: "zh-CN",
+			check: func(t *testing.T, text, sshCommand, qr, internal string, ports int) {
+				if sshCommand != "ssh root@198.51.100.7 -p 20022" || qr != "ssh://root@198.51.100.7:20022" {
+					t.Errorf("SSH命令 = %q, 二维码 = %q", sshCommand, qr)
+				}
+				if internal != "ssh root@10.0.0.7 -p 22" {
+					t.Errorf("内网SSH命令 = %q", internal)
+				}
+				if ports != 2 {
+					t.Errorf("只应包含有效的端口映射，得到 %d 条", ports)
+				}
+				for _, want := range []string{"实例连接信息 - web", "198.51.100.7:30000-30009 -> 30000-30009/both (game)", "(SSH端口)"} {
+					if !strings.Contains(text, want) {
+						t.Errorf("文本缺少 %q:\n%s", want, text)
+					}
+				}
+			},
+		},
+		{
+			name: "英文文本", userID: 1, instanceID: 1, lang: "en",
+			check: func(t *testing.T, text, _, _, _ string, _ int) {
+				if !strings.HasPrefix(text, "Instance connection info - web") || !strings.Contains(text, "(SSH port)") {
+					t.Errorf("英文文本 = %s", text)
+				}
+			},
+		},
+		{
+			name: "纯IPv6实例", userID: 1, instanceID: 2, lang: "en-US",
+			check: func(t *testing.T, text, sshCommand, qr, internal string, ports int) {
+				if qr != "ssh://root@[2001:db8::7]:22" || internal != "" || ports != 0 {
+					t.Errorf("二维码 = %q, 内网命令 = %q, 端口 = %d", qr, internal, ports)
+				}
+				if !strings.Contains(text, "Port mappings:\n  none\n") {
+					t.Errorf("无端口映射时文本 = %s", text)
+				}
+			},
+		},
+		{name: "其他用户的实例", userID: 2, instanceID: 1, wantErr: true},
+		{name: "不存在的实例", userID: 1, instanceID: 99, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundle, err := s.GetConnectionBundle(tt.userID, tt.instanceID, tt.lang)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("应拒绝，得到 %+v", bundle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, bundle.Text, bundle.SSHCommand, bundle.QRCodePayload, bundle.InternalSSHCommand, len(bundle.Ports))
+		})
+	}
+}
@@ -5,19 +5,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"oneclickvirt/constant"
 	"oneclickvirt/service/auth"
 	"oneclickvirt/service/cache"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/diskusage"
+	"oneclickvirt/service/idlestop"
+	"oneclickvirt/service/imageeol"
+	"oneclickvirt/service/livestate"
+	"oneclickvirt/service/resources"
 	"oneclickvirt/service/task"
 	trafficService "oneclickvirt/service/traffic"
 	"oneclickvirt/utils"
+	"slices"
 	"time"
 
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/timezone"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -31,6 +39,22 @@ func NewService() *Service {
 	return &Service{}
 }
 
+// userInstanceListSpec 用户实例列表可排序、过滤的字段
+var userInstanceListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":           "id",
+		"name":         "name",
+		"provider":     "provider",
+		"providerId":   "provider_id",
+		"status":       "status",
+		"instanceType": "instance_type",
+		"groupId":      "group_id",
+		"expiresAt":    "expires_at",
+		"createdAt":    "created_at",
+	},
+	DefaultSort: "id",
+}
+
 // GetUserInstances 获取用户实例列表
 func (s *Service) GetUserInstances(userID uint, req userModel.UserInstanceListRequest) ([]userModel.UserInstanceResponse, int64, error) {
 	var instances []providerModel.Instance
@@ -60,16 +84,33 @@ func (s *Service) GetUserInstances(userID uint, req userModel.UserInstanceListRe
 	if req.ProviderName != "" {
 		query = query.Where("provider LIKE ?", "%"+req.ProviderName+"%")
 	}
+	// 支持分组筛选，0表示未分组
+	if req.GroupID != nil {
+		query = query.Where("group_id = ?", *req.GroupID)
+	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, userInstanceListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (req.Page - 1) * req.PageSize
-	if err := query.Offset(offset).Limit(req.PageSize).Find(&instances).Error; err != nil {
+	query, err = utils.ApplyListSort(query, req.PageInfo, userInstanceListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Find(&instances).Error; err != nil {
 		return nil, 0, err
 	}
 
+	// 按需从节点获取实时状态，覆盖状态和IP后再计算可执行的操作
+	var liveStates map[uint]*userModel.InstanceLiveState
+	if req.Live {
+		liveStates = livestate.Refresh(instances)
+	}
+
 	// 批量预加载端口映射
 	var instanceIDs []uint
 	for _, instance := range instances {
@@ -113,6 +154,23 @@ func (s *Service) GetUserInstances(userID uint, req userModel.UserInstanceListRe
 		providerMap[provider.ID] = provider
 	}
 
+	// 批量查询实例所在分组，实例继承分组的标签
+	groupMap := make(map[uint]userModel.InstanceGroup)
+	var groupIDs []uint
+	for _, instance := range instances {
+		if instance.GroupID > 0 {
+			groupIDs = append(groupIDs, instance.GroupID)
+		}
+	}
+	if len(groupIDs) > 0 {
+		var groups []userModel.InstanceGroup
+		global.APP_DB.Select("id, name, tags").Where("id IN ? AND user_id = ?", groupIDs, userID).Find(&groups)
+		for _, group := range groups {
+			groupMap[group.ID] = group
+		}
+	}
+
+	now := time.Now()
 	var userInstances []userModel.UserInstanceResponse
 	for _, instance := range instances {
 		// 从预加载的数据中获取端口映射信息
@@ -176,6 +234,17 @@ func (s *Service) GetUserInstances(userID uint, req userModel.UserInstanceListRe
 			PublicIP:       instance.PublicIP, // 直接使用实例的PublicIP字段
 			ProviderType:   providerType,
 			ProviderStatus: providerStatus,
+			Tags:           userModel.SplitTags(instance.Tags),
+			OSEOLStatus:    imageeol.Status(instance.OSEOLDate, now),
+			Live:           liveStates[instance.ID],
+		}
+		if group, ok := groupMap[instance.GroupID]; ok {
+			userInstance.GroupName = group.Name
+			for _, tag := range group.TagList() {
+				if !slices.Contains(userInstance.Tags, tag) {
+					userInstance.Tags = append(userInstance.Tags, tag)
+				}
+			}
 		}
 		userInstances = append(userInstances, userInstance)
 	}
@@ -212,6 +281,11 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 			return errors.New("实例已有启动任务正在进行")
 		}
 
+		// 拥有的实例数可以多于同时运行的上限，启动前检查运行名额
+		if err := resources.NewQuotaService().CheckRunningLimit(userID); err != nil {
+			return err
+		}
+
 		// 创建启动任务
 		taskService := getTaskService()
 		taskData := fmt.Sprintf(`{"instanceId":%d,"providerId":%d}`, instance.ID, instance.ProviderID)
@@ -219,8 +293,10 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 		if err != nil {
 			return fmt.Errorf("创建启动任务失败: %v", err)
 		}
+		// 用户启动实例视为一次活动，重新开始闲置计时
+		idlestop.MarkActive(instance.ID)
 
-		instance.Status = "starting"
+		instance.Status = constant.InstanceStatusStarting
 	case "stop":
 		if instance.Status != "running" {
 			return errors.New("实例状态不允许停止")
@@ -240,7 +316,7 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 			return fmt.Errorf("创建停止任务失败: %v", err)
 		}
 
-		instance.Status = "stopping"
+		instance.Status = constant.InstanceStatusStopping
 	case "restart":
 		if instance.Status != "running" {
 			return errors.New("实例状态不允许重启")
@@ -260,7 +336,7 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 			return fmt.Errorf("创建重启任务失败: %v", err)
 		}
 
-		instance.Status = "restarting"
+		instance.Status = constant.InstanceStatusRestarting
 	case "reset":
 		if instance.Status != "running" && instance.Status != "stopped" {
 			return errors.New("实例状态不允许重置")
@@ -287,10 +363,10 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 			return fmt.Errorf("创建重置任务失败: %v", err)
 		}
 
-		instance.Status = "resetting"
+		instance.Status = constant.InstanceStatusResetting
 	case "delete":
-		if instance.Status == "deleting" {
-			return errors.New("实例正在删除中")
+		if err := constant.ValidateInstanceAction(instance.Status, req.Action); err != nil {
+			return err
 		}
 
 		// 检查用户删除权限
@@ -313,7 +389,7 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 			return fmt.Errorf("创建删除任务失败: %v", err)
 		}
 
-		instance.Status = "deleting"
+		instance.Status = constant.InstanceStatusDeleting
 	default:
 		return errors.New("不支持的操作")
 	}
@@ -325,6 +401,29 @@ func (s *Service) InstanceAction(userID uint, req userModel.InstanceActionReques
 	})
 }
 
+// GetInstanceDetailLive 获取实例详情，状态和IP取自节点的实时数据
+func (s *Service) GetInstanceDetailLive(userID, instanceID uint) (*userModel.UserInstanceDetailResponse, error) {
+	var instance providerModel.Instance
+	if err := global.APP_DB.Where("id = ? AND user_id = ?", instanceID, userID).First(&instance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("实例不存在")
+		}
+		return nil, err
+	}
+	instances := []providerModel.Instance{instance}
+	liveStates := livestate.Refresh(instances)
+
+	detail, err := s.GetInstanceDetail(userID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	detail.Status = instances[0].Status
+	detail.PrivateIP = instances[0].PrivateIP
+	detail.IPv6Address = instances[0].IPv6Address
+	detail.Live = liveStates[instanceID]
+	return detail, nil
+}
+
 // GetInstanceDetail 获取实例详情
 func (s *Service) GetInstanceDetail(userID, instanceID uint) (*userModel.UserInstanceDetailResponse, error) {
 	var instance providerModel.Instance
@@ -347,24 +446,32 @@ func (s *Service) GetInstanceDetail(userID, instanceID uint) (*userModel.UserIns
 	}
 
 	detail := &userModel.UserInstanceDetailResponse{
-		ID:          instance.ID,
-		Name:        instance.Name,
-		Type:        instance.InstanceType,
-		Status:      instance.Status,
-		CPU:         instance.CPU,
-		Memory:      int(instance.Memory),
-		Disk:        int(instance.Disk),
-		Bandwidth:   instance.Bandwidth,
-		OsType:      instance.OSType,
-		PrivateIP:   instance.PrivateIP,   // 使用实例的内网IP
-		PublicIP:    instance.PublicIP,    // 使用实例的公网IP
-		IPv6Address: instance.IPv6Address, // 内网IPv6地址
-		PublicIPv6:  instance.PublicIPv6,  // 公网IPv6地址
-		SSHPort:     sshPort,              // 使用映射的公网端口
-		Username:    instance.Username,
-		Password:    instance.Password,
-		CreatedAt:   instance.CreatedAt,
-		ExpiresAt:   instance.ExpiresAt,
+		ID:           instance.ID,
+		Name:         instance.Name,
+		Type:         instance.InstanceType,
+		Status:       instance.Status,
+		CPU:          instance.CPU,
+		Memory:       int(instance.Memory),
+		Disk:         int(instance.Disk),
+		Bandwidth:    instance.Bandwidth,
+		OsType:       instance.OSType,
+		PrivateIP:    instance.PrivateIP,   // 使用实例的内网IP
+		PublicIP:     instance.PublicIP,    // 使用实例的公网IP
+		IPv6Address:  instance.IPv6Address, // 内网IPv6地址
+		PublicIPv6:   instance.PublicIPv6,  // 公网IPv6地址
+		SSHPort:      sshPort,              // 使用映射的公网端口
+		GuestSSHPort: constant.GuestSSHPortOrDefault(instance.GuestSSHPort),
+		Username:     instance.Username,
+		Password:     instance.Password,
+		CreatedAt:    instance.CreatedAt,
+		ExpiresAt:    instance.ExpiresAt,
+	}
+
+	if percent, ok := diskusage.InstancePercent(&instance); ok {
+		detail.DiskUsedMB = instance.DiskUsedMB
+		detail.DiskUsagePercent = math.Round(percent*10) / 10
+		detail.DiskUsageAt = instance.DiskUsageAt
+		detail.DiskUsageWarning = percent >= float64(diskusage.WarnPercent())
 	}
 
 	// 查询关联的 Provider 信息
@@ -439,7 +546,7 @@ func (s *Service) GetInstanceMonitoring(userID, instanceID uint) (*userModel.Ins
 
 	// 计算用户总流量使用情况 - 用于流量限制判断
 	trafficQueryService := trafficService.NewQueryService()
-	year, month, _ := time.Now().Date()
+	year, month, _ := timezone.UserNow(userID).Date()
 	userMonthlyTrafficStats, err := trafficQueryService.GetUserMonthlyTraffic(userID, year, int(month))
 
 	var userTotalMonthTraffic int64
@@ -547,7 +654,7 @@ func (s *Service) GetUserTrafficUsageWithPmacct(userID uint) (map[string]interfa
 	trafficLimit := tService.GetUserTrafficLimitByLevel(user.Level)
 
 	// 简化的流量使用查询（包含已删除实例，保证累计值准确）
-	now := time.Now()
+	now := timezone.UserNow(userID)
 	year := now.Year()
 	month := int(now.Month())
 
@@ -42,7 +42,7 @@ type ResourceServiceInterface interface {
 
 // ProviderServiceInterface 提供商服务接口
 type ProviderServiceInterface interface {
-	GetAvailableProviders(userID uint) ([]userModel.AvailableProviderResponse, error)
+	GetAvailableProviders(userID uint, architecture string) ([]userModel.AvailableProviderResponse, error)
 	GetSystemImages(userID uint, req userModel.SystemImagesRequest) ([]userModel.SystemImageResponse, error)
 	GetInstanceConfig(userID uint, providerID uint) (*userModel.InstanceConfigResponse, error)
 	GetFilteredSystemImages(userID uint, providerID uint, instanceType string) ([]userModel.SystemImageResponse, error)
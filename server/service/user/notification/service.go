@@ -27,11 +27,11 @@ func (s *Service) ResetPassword(userID uint) (string, error) {
 		return "", errors.New("用户不存在")
 	}
 
-	// 生成强密码（12位）
-	newPassword := utils.GenerateStrongPassword(12)
+	// 生成强密码，长度由密码策略配置
+	newPassword := utils.GenerateUserPassword()
 
 	// 密码强度验证（确保生成的密码符合策略）
-	if err := utils.ValidatePasswordStrength(newPassword, utils.DefaultPasswordPolicy, user.Username); err != nil {
+	if err := utils.ValidatePasswordStrength(newPassword, utils.GeneratedPasswordPolicy(), user.Username); err != nil {
 		return "", err
 	}
 
@@ -56,11 +56,11 @@ func (s *Service) ResetPasswordAndNotify(userID uint) (string, error) {
 		return "", errors.New("用户不存在")
 	}
 
-	// 生成强密码（12位）
-	newPassword := utils.GenerateStrongPassword(12)
+	// 生成强密码，长度由密码策略配置
+	newPassword := utils.GenerateUserPassword()
 
 	// 密码强度验证（确保生成的密码符合策略）
-	if err := utils.ValidatePasswordStrength(newPassword, utils.DefaultPasswordPolicy, user.Username); err != nil {
+	if err := utils.ValidatePasswordStrength(newPassword, utils.GeneratedPasswordPolicy(), user.Username); err != nil {
 		return "", err
 	}
 
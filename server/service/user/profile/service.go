@@ -10,6 +10,8 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	userModel "oneclickvirt/model/user"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/timezone"
+	"oneclickvirt/utils"
 	"time"
 
 	"go.uber.org/zap"
@@ -37,11 +39,27 @@ func (s *Service) UpdateProfile(userID uint, req userModel.UpdateProfileRequest)
 	user.Phone = req.Phone
 	user.Telegram = req.Telegram
 
+	// 修改时区后按新时区重新计算下次流量重置时间
+	timezoneChanged := req.Timezone != nil && *req.Timezone != user.Timezone
+	if timezoneChanged {
+		user.Timezone = *req.Timezone
+		if user.TrafficResetAt != nil {
+			resetAt := utils.NextMonthStart(time.Now(), utils.LoadLocation(user.Timezone))
+			user.TrafficResetAt = &resetAt
+		}
+	}
+
 	// 使用数据库抽象层保存
 	dbService := database.GetDatabaseService()
-	return dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
+	if err := dbService.ExecuteTransaction(context.Background(), func(tx *gorm.DB) error {
 		return tx.Save(&user).Error
-	})
+	}); err != nil {
+		return err
+	}
+	if timezoneChanged {
+		timezone.InvalidateUser(userID)
+	}
+	return nil
 }
 
 // ChangePassword 修改密码
@@ -55,6 +73,10 @@ func (s *Service) ChangePassword(userID uint, oldPassword, newPassword string) e
 		return errors.New("原密码错误")
 	}
 
+	if err := utils.ValidateUserPassword(newPassword, user.Username); err != nil {
+		return err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -216,6 +238,20 @@ func (s *Service) SearchUsers(req auth.SearchUsersRequest) ([]userModel.User, in
 	return users, total, nil
 }
 
+// userTaskListSpec 用户任务列表可排序、过滤的字段
+var userTaskListSpec = utils.ListSpec{
+	Columns: map[string]string{
+		"id":         "id",
+		"status":     "status",
+		"taskType":   "task_type",
+		"providerId": "provider_id",
+		"instanceId": "instance_id",
+		"createdAt":  "created_at",
+		"updatedAt":  "updated_at",
+	},
+	DefaultSort: "-createdAt",
+}
+
 // GetUserTasks 获取用户任务列表
 func (s *Service) GetUserTasks(userID uint, req userModel.UserTasksRequest) ([]userModel.UserTaskResponse, int64, error) {
 	var tasks []adminModel.Task
@@ -238,25 +274,23 @@ func (s *Service) GetUserTasks(userID uint, req userModel.UserTasksRequest) ([]u
 	if req.TaskType != "" {
 		query = query.Where("task_type = ?", req.TaskType)
 	}
+	query, err := utils.ApplyListFilters(query, req.PageInfo, userTaskListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// 统计总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("统计任务数量失败: %v", err)
 	}
 
-	// 设置分页默认值
-	page := req.Page
-	pageSize := req.PageSize
-	if page <= 0 {
-		page = 1
-	}
-	if pageSize <= 0 {
-		pageSize = 10
-	}
-
 	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&tasks).Error; err != nil {
+	req.Normalize()
+	query, err = utils.ApplyListSort(query, req.PageInfo, userTaskListSpec)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.ApplyListPage(query, req.PageInfo).Find(&tasks).Error; err != nil {
 		return nil, 0, fmt.Errorf("查询用户任务失败: %v", err)
 	}
 
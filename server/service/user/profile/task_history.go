@@ -0,0 +1,285 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/user/instance"
+	"oneclickvirt/utils"
+)
+
+// userVisibleTaskTypes 出现在用户任务历史中的任务类型，端口映射同步等内部任务不展示
+var userVisibleTaskTypes = []string{
+	"create", "start", "stop", "restart", "delete", "reset", "reset-password", adminModel.TaskTypeExportData,
+	adminModel.TaskTypeDetectPortService,
+}
+
+// taskHistoryText 任务历史的本地化文案
+var taskHistoryText = map[string]map[string]string{
+	"zh-CN": {
+		"create":              "创建实例",
+		"start":               "启动实例",
+		"stop":                "停止实例",
+		"restart":             "重启实例",
+		"delete":              "删除实例",
+		"reset":               "重装系统",
+		"reset-password":      "重置密码",
+		"export-data":         "导出账户数据",
+		"detect-port-service": "探测端口服务",
+
+		"status.pending":    "等待中",
+		"status.running":    "进行中",
+		"status.completed":  "已完成",
+		"status.failed":     "失败",
+		"status.cancelled":  "已取消",
+		"status.cancelling": "取消中",
+		"status.timeout":    "已超时",
+
+		"summary.pending":    "排队等待执行",
+		"summary.running":    "正在执行（%d%%）",
+		"summary.completed":  "已完成，用时%s",
+		"summary.failed":     "执行失败：%s",
+		"summary.cancelled":  "任务已取消",
+		"summary.cancelling": "正在取消任务",
+		"summary.timeout":    "超过%s仍未完成，已终止",
+	},
+	"en-US": {
+		"create":              "Create instance",
+		"start":               "Start instance",
+		"stop":                "Stop instance",
+		"restart":             "Restart instance",
+		"delete":              "Delete instance",
+		"reset":               "Reinstall instance",
+		"reset-password":      "Reset password",
+		"export-data":         "Export account data",
+		"detect-port-service": "Detect port services",
+
+		"status.pending":    "Queued",
+		"status.running":    "In progress",
+		"status.completed":  "Completed",
+		"status.failed":     "Failed",
+		"status.cancelled":  "Cancelled",
+		"status.cancelling": "Cancelling",
+		"status.timeout":    "Timed out",
+
+		"summary.pending":    "Waiting in queue",
+		"summary.running":    "Running (%d%%)",
+		"summary.completed":  "Completed in %s",
+		"summary.failed":     "Failed: %s",
+		"summary.cancelled":  "The task was cancelled",
+		"summary.cancelling": "Cancelling the task",
+		"summary.timeout":    "Stopped after running longer than %s",
+	},
+}
+
+// taskErrorRule 按关键字匹配的错误说明，按顺序匹配第一条
+type taskErrorRule struct {
+	keywords []string
+	zh, en   string
+}
+
+var taskErrorRules = []taskErrorRule{
+	{[]string{"配额", "quota"},
+		"超出账户配额，请删除不再使用的实例或联系管理员提升等级",
+		"Your account quota was exceeded. Delete unused instances or ask an administrator for a higher level."},
+	{[]string{"资源不足", "空间不足", "insufficient", "no space"},
+		"节点资源不足，请选择其他节点或更小的配置",
+		"The node does not have enough resources. Try another node or a smaller plan."},
+	{[]string{"镜像", "image"},
+		"系统镜像下载或导入失败，请稍后重试或换用其他镜像",
+		"The system image could not be downloaded or imported. Retry later or choose another image."},
+	{[]string{"维护", "冻结", "blackout", "frozen"},
+		"节点处于维护或冻结状态，暂时无法操作",
+		"The node is under maintenance or frozen. Try again later."},
+	{[]string{"超时", "timeout", "deadline exceeded"},
+		"操作未在规定时间内完成，节点可能繁忙，请稍后重试",
+		"The operation did not finish in time. The node may be busy, please retry later."},
+	{[]string{"ssh", "连接", "connection refused", "unreachable", "no route"},
+		"无法连接到节点，节点可能正在维护，请稍后重试",
+		"The node could not be reached. It may be under maintenance, please retry later."},
+	{[]string{"不存在", "not found"},
+		"实例在节点上不存在，可能已被删除",
+		"The instance no longer exists on the node. It may have been deleted."},
+}
+
+// explainTaskError 把原始错误信息转换为面向用户的原因说明和处理建议
+func explainTaskError(message string, taskID uint, lang string) string {
+	if strings.TrimSpace(message) == "" {
+		return ""
+	}
+	lower := strings.ToLower(message)
+	for _, rule := range taskErrorRules {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(lower, keyword) {
+				if lang == "en-US" {
+					return rule.en
+				}
+				return rule.zh
+			}
+		}
+	}
+	if lang == "en-US" {
+		return fmt.Sprintf("An error occurred during the operation. If retrying does not help, contact an administrator with task ID %d.", taskID)
+	}
+	return fmt.Sprintf("执行过程中发生错误，如重试仍失败请联系管理员并提供任务ID %d", taskID)
+}
+
+// formatTaskDuration 格式化时长，如"1小时2分3秒"或"1h 2m 3s"
+func formatTaskDuration(seconds int, lang string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	h, m, s := seconds/3600, seconds%3600/60, seconds%60
+	units := [3]string{"小时", "分", "秒"}
+	sep := ""
+	if lang == "en-US" {
+		units, sep = [3]string{"h", "m", "s"}, " "
+	}
+	var parts []string
+	if h > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", h, units[0]))
+	}
+	if m > 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", m, units[1]))
+	}
+	if s > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d%s", s, units[2]))
+	}
+	return strings.Join(parts, sep)
+}
+
+// taskStatusKey 统一任务状态，processing 视为 running
+func taskStatusKey(status string) string {
+	if status == "processing" {
+		return "running"
+	}
+	return status
+}
+
+// buildTaskHistoryEntry 生成单条任务历史，instanceName 为空表示实例已删除或不存在
+func buildTaskHistoryEntry(task *adminModel.Task, instanceName string, instanceExists bool, lang string, now time.Time) userModel.TaskHistoryEntry {
+	text := taskHistoryText[lang]
+	status := taskStatusKey(task.Status)
+
+	entry := userModel.TaskHistoryEntry{
+		ID:           task.ID,
+		TaskType:     task.TaskType,
+		Title:        text[task.TaskType],
+		Status:       task.Status,
+		StatusText:   text["status."+status],
+		Progress:     task.Progress,
+		CreatedAt:    task.CreatedAt,
+		StartedAt:    task.StartedAt,
+		CompletedAt:  task.CompletedAt,
+		ErrorMessage: task.ErrorMessage,
+		InstanceID:   task.InstanceID,
+		InstanceName: instanceName,
+	}
+	if entry.Title == "" {
+		entry.Title = task.TaskType
+	}
+	if entry.StatusText == "" {
+		entry.StatusText = task.Status
+	}
+	if instanceName != "" {
+		entry.Title += " " + instanceName
+	}
+	if instanceExists && task.InstanceID != nil {
+		entry.InstanceLink = fmt.Sprintf("/user/instances/%d", *task.InstanceID)
+	}
+
+	if task.StartedAt != nil {
+		entry.WaitSeconds = int(task.StartedAt.Sub(task.CreatedAt).Seconds())
+		end := now
+		if task.CompletedAt != nil {
+			end = *task.CompletedAt
+		}
+		entry.DurationSeconds = int(end.Sub(*task.StartedAt).Seconds())
+		entry.DurationText = formatTaskDuration(entry.DurationSeconds, lang)
+	}
+
+	switch status {
+	case "running":
+		entry.Summary = fmt.Sprintf(text["summary.running"], task.Progress)
+		// 进度描述只有中文，英文界面只显示百分比
+		if lang == "zh-CN" && task.StatusMessage != "" {
+			entry.Summary += "：" + task.StatusMessage
+		}
+	case "completed":
+		entry.Summary = fmt.Sprintf(text["summary.completed"], formatTaskDuration(entry.DurationSeconds, lang))
+	case "failed":
+		entry.ErrorExplanation = explainTaskError(task.ErrorMessage, task.ID, lang)
+		entry.Summary = fmt.Sprintf(text["summary.failed"], entry.ErrorExplanation)
+	case "timeout":
+		entry.ErrorExplanation = explainTaskError(task.ErrorMessage, task.ID, lang)
+		entry.Summary = fmt.Sprintf(text["summary.timeout"], formatTaskDuration(task.TimeoutDuration, lang))
+	case "cancelled":
+		entry.Summary = text["summary.cancelled"]
+		if task.CancelReason != "" {
+			entry.Summary += "（" + task.CancelReason + "）"
+		}
+	default:
+		entry.Summary = text["summary."+status]
+	}
+	return entry
+}
+
+// GetUserTaskHistory 获取用户任务历史，只包含用户可见的实例操作，不包含管理员对实例的操作和系统内部任务
+func (s *Service) GetUserTaskHistory(userID uint, req userModel.UserTaskHistoryRequest) ([]userModel.TaskHistoryEntry, int64, error) {
+	lang := instance.NormalizeLanguage(req.Lang)
+
+	query := global.APP_DB.Model(&adminModel.Task{}).
+		Where("user_id = ? AND task_type IN ?", userID, userVisibleTaskTypes).
+		Where("task_data NOT LIKE ?", `%"adminOperation":true%`)
+	if req.InstanceID != 0 {
+		query = query.Where("instance_id = ?", req.InstanceID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计任务数量失败: %v", err)
+	}
+
+	var tasks []adminModel.Task
+	if err := utils.ApplyListPage(query.Order("id DESC"), req.PageInfo).Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询任务历史失败: %v", err)
+	}
+
+	// 已删除的实例仍显示名称，但不提供详情链接
+	var instanceIDs []uint
+	for _, task := range tasks {
+		if task.InstanceID != nil {
+			instanceIDs = append(instanceIDs, *task.InstanceID)
+		}
+	}
+	var instances []providerModel.Instance
+	if len(instanceIDs) > 0 {
+		global.APP_DB.Unscoped().Select("id, name, deleted_at").Where("id IN ?", instanceIDs).Find(&instances)
+	}
+	instanceMap := make(map[uint]providerModel.Instance, len(instances))
+	for _, inst := range instances {
+		instanceMap[inst.ID] = inst
+	}
+
+	now := time.Now()
+	entries := make([]userModel.TaskHistoryEntry, 0, len(tasks))
+	for i := range tasks {
+		var name string
+		exists := false
+		if tasks[i].InstanceID != nil {
+			if inst, ok := instanceMap[*tasks[i].InstanceID]; ok {
+				name, exists = inst.Name, !inst.DeletedAt.Valid
+			}
+		}
+		entries = append(entries, buildTaskHistoryEntry(&tasks[i], name, exists, lang, now))
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	adminModel "oneclickvirt/model/admin"
+)
+
+func TestFormatTaskDuration(t *testing.T) {
+	cases := []struct {
+		seconds int
+		lang    string
+		want    string
+	}{
+		{0, "zh-CN", "0秒"},
+		{59, "zh-CN", "59秒"},
+		{3723, "zh-CN", "1小时2分3秒"},
+		{3600, "zh-CN", "1小时"},
+		{3723, "en-US", "1h 2m 3s"},
+		{120, "en-US", "2m"},
+		{-5, "en-US", "0s"},
+	}
+	for _, tc := range cases {
+		if got := formatTaskDuration(tc.seconds, tc.lang); got != tc.want {
+			t.Errorf("formatTaskDuration(%d, %s) = %q, want %q", tc.seconds, tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestExplainTaskError(t *testing.T) {
+	if got := explainTaskError("", 1, "zh-CN"); got != "" {
+		t.Errorf("empty message explained as %q", got)
+	}
+	if got := explainTaskError("SSH connection refused", 1, "en-US"); !strings.Contains(got, "could not be reached") {
+		t.Errorf("connection error explained as %q", got)
+	}
+	if got := explainTaskError("超出用户配额", 1, "zh-CN"); !strings.Contains(got, "配额") {
+		t.Errorf("quota error explained as %q", got)
+	}
+	if got := explainTaskError("unexpected exit code 2", 42, "en-US"); !strings.Contains(got, "task ID 42") {
+		t.Errorf("unknown error should reference task ID, got %q", got)
+	}
+}
+
+func TestBuildTaskHistoryEntry(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	started := created.Add(10 * time.Second)
+	completed := started.Add(95 * time.Second)
+	instanceID := uint(7)
+	task := &adminModel.Task{
+		TaskType:    "restart",
+		Status:      "completed",
+		InstanceID:  &instanceID,
+		StartedAt:   &started,
+		CompletedAt: &completed,
+	}
+	task.ID = 3
+	task.CreatedAt = created
+
+	entry := buildTaskHistoryEntry(task, "web-1", true, "en-US", completed)
+	if entry.Title != "Restart instance web-1" || entry.StatusText != "Completed" {
+		t.Errorf("unexpected title/status: %q / %q", entry.Title, entry.StatusText)
+	}
+	if entry.WaitSeconds != 10 || entry.DurationSeconds != 95 || entry.Summary != "Completed in 1m 35s" {
+		t.Errorf("unexpected timing: wait=%d duration=%d summary=%q", entry.WaitSeconds, entry.DurationSeconds, entry.Summary)
+	}
+	if entry.InstanceLink != "/user/instances/7" {
+		t.Errorf("InstanceLink = %q", entry.InstanceLink)
+	}
+
+	if deleted := buildTaskHistoryEntry(task, "web-1", false, "en-US", completed); deleted.InstanceLink != "" {
+		t.Errorf("deleted instance should have no link, got %q", deleted.InstanceLink)
+	}
+}
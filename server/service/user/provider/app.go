@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 应用安装脚本的最长执行时间
+const appInstallTimeout = 20 * time.Minute
+
+// validateAppForInstance 验证一键应用与所选镜像、规格的兼容性
+func (s *Service) validateAppForInstance(appID uint, image *systemModel.SystemImage, cpuSpec *constant.CPUSpec, memorySpec *constant.MemorySpec, diskSpec *constant.DiskSpec) (*systemModel.AppTemplate, error) {
+	var app systemModel.AppTemplate
+	if err := global.APP_DB.Where("id = ? AND status = ?", appID, 1).First(&app).Error; err != nil {
+		return nil, errors.New("所选应用不存在或已下架")
+	}
+
+	if constant.IsWindowsOSType(image.OSType) {
+		return nil, fmt.Errorf("应用 %s 不支持 Windows 镜像", app.Name)
+	}
+	if app.InstanceType != "" && app.InstanceType != image.InstanceType {
+		return nil, fmt.Errorf("应用 %s 仅支持 %s 类型实例", app.Name, app.InstanceType)
+	}
+	if !app.SupportsOSType(image.OSType) {
+		return nil, fmt.Errorf("应用 %s 不支持 %s 系统，支持的系统: %s", app.Name, image.OSType, app.OSTypes)
+	}
+
+	if app.MinCPU > 0 && cpuSpec.Cores < app.MinCPU {
+		return nil, fmt.Errorf("应用 %s 至少需要 %d 核CPU", app.Name, app.MinCPU)
+	}
+	if app.MinMemoryMB > 0 && memorySpec.SizeMB < app.MinMemoryMB {
+		return nil, fmt.Errorf("应用 %s 至少需要 %dMB 内存", app.Name, app.MinMemoryMB)
+	}
+	if app.MinDiskMB > 0 && diskSpec.SizeMB < app.MinDiskMB {
+		return nil, fmt.Errorf("应用 %s 至少需要 %dMB 硬盘", app.Name, app.MinDiskMB)
+	}
+
+	return &app, nil
+}
+
+// installInstanceApp 在实例创建完成后安装所选应用
+// 应用端口依次使用实例的非SSH端口映射（区间映射内外端口一致），无端口映射时（独立IP）使用应用默认端口
+// 返回失败原因，未选择应用或安装成功时返回空字符串
+func (s *Service) installInstanceApp(instanceID, providerID uint) string {
+	var instanceApp systemModel.InstanceApp
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).First(&instanceApp).Error; err != nil {
+		return ""
+	}
+
+	failInstall := func(reason string) string {
+		global.APP_LOG.Warn("实例应用安装失败",
+			zap.Uint("instanceId", instanceID),
+			zap.String("app", instanceApp.AppName),
+			zap.String("reason", reason))
+		global.APP_DB.Model(&instanceApp).Updates(map[string]interface{}{
+			"status":        systemModel.InstanceAppStatusFailed,
+			"error_message": utils.TruncateString(reason, 500),
+		})
+		return reason
+	}
+
+	var app systemModel.AppTemplate
+	if err := global.APP_DB.Unscoped().First(&app, instanceApp.AppID).Error; err != nil {
+		return failInstall("应用模板不存在")
+	}
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return failInstall("获取实例信息失败")
+	}
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		return failInstall("获取Provider信息失败")
+	}
+	if instance.Username == "" || instance.Password == "" {
+		return failInstall("实例缺少登录凭据，无法安装应用")
+	}
+
+	global.APP_DB.Model(&instanceApp).Update("status", systemModel.InstanceAppStatusInstalling)
+
+	host, sshPort := resources.ResolveInstanceSSHEndpoint(&instance, &provider)
+
+	// 为应用端口分配端口映射
+	var mappings []providerModel.Port
+	global.APP_DB.Where("instance_id = ? AND is_ssh = ? AND status = ?", instanceID, false, "active").
+		Order("host_port ASC").Find(&mappings)
+
+	publicHost := host
+	if len(mappings) == 0 && instance.PublicIP != "" {
+		publicHost = instance.PublicIP
+	}
+
+	vars := map[string]string{
+		"HOST":         publicHost,
+		"APP_PASSWORD": utils.GenerateInstancePassword(),
+	}
+	var env strings.Builder
+	for i, appPort := range app.ParsePorts() {
+		guestPort, externalPort := appPort.Port, appPort.Port
+		if i < len(mappings) {
+			guestPort, externalPort = mappings[i].GuestPort, mappings[i].HostPort
+			global.APP_DB.Model(&mappings[i]).Update("description", fmt.Sprintf("%s %s", app.Name, strings.ToLower(appPort.Name)))
+		}
+		vars["PORT_"+appPort.Name] = strconv.Itoa(externalPort)
+		fmt.Fprintf(&env, "export APP_PORT_%s=%d\n", appPort.Name, guestPort)
+	}
+	fmt.Fprintf(&env, "export APP_PASSWORD='%s'\nexport APP_HOST='%s'\n", vars["APP_PASSWORD"], publicHost)
+
+	client, session, err := utils.CreateSSHConnection(host, sshPort, instance.Username, instance.Password)
+	if err != nil {
+		return failInstall(fmt.Sprintf("无法通过SSH连接实例: %v", err))
+	}
+	defer client.Close()
+	defer session.Close()
+
+	global.APP_LOG.Info("开始安装实例应用",
+		zap.Uint("instanceId", instanceID),
+		zap.String("app", app.Slug))
+
+	session.Stdin = strings.NewReader(env.String() + app.InstallScript)
+	type installResult struct {
+		output []byte
+		err    error
+	}
+	done := make(chan installResult, 1)
+	go func() {
+		output, err := session.CombinedOutput("bash -s")
+		done <- installResult{output: output, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			output := strings.TrimSpace(string(result.output))
+			if len(output) > 400 {
+				output = output[len(output)-400:]
+			}
+			return failInstall(fmt.Sprintf("安装脚本执行失败: %v %s", result.err, output))
+		}
+	case <-time.After(appInstallTimeout):
+		client.Close()
+		return failInstall("安装脚本执行超时")
+	}
+
+	info := app.PostInstallInfo
+	for key, value := range vars {
+		info = strings.ReplaceAll(info, "${"+key+"}", value)
+	}
+	now := time.Now()
+	global.APP_DB.Model(&instanceApp).Updates(map[string]interface{}{
+		"status":        systemModel.InstanceAppStatusInstalled,
+		"info":          info,
+		"error_message": "",
+		"installed_at":  &now,
+	})
+
+	global.APP_LOG.Info("实例应用安装完成",
+		zap.Uint("instanceId", instanceID),
+		zap.String("app", app.Slug))
+	return ""
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"testing"
+
+	"oneclickvirt/constant"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/testutil"
+
+	"gorm.io/gorm"
+)
+
+// useTestDB 使用内存数据库替换全局连接
+func useTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	return testutil.UseSQLiteDB(t, models...)
+}
+
+func TestValidateAppForInstance(t *testing.T) {
+	db := useTestDB(t, &systemModel.AppTemplate{})
+	wordpress := systemModel.AppTemplate{Slug: "wordpress", Name: "WordPress", Status: 1, OSTypes: "ubuntu, debian",
+		MinCPU: 1, MinMemoryMB: 1024, MinDiskMB: 10240}
+	vmOnly := systemModel.AppTemplate{Slug: "docker-host", Name: "Docker", Status: 1, InstanceType: "vm"}
+	offline := systemModel.AppTemplate{Slug: "old", Name: "Old", Status: 1}
+	for _, app := range []*systemModel.AppTemplate{&wordpress, &vmOnly, &offline} {
+		if err := db.Create(app).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Model(&offline).Update("status", 0)
+
+	ubuntuVM := &systemModel.SystemImage{OSType: "Ubuntu", InstanceType: "vm"}
+	tests := []struct {
+		name    string
+		appID   uint
+		image   *systemModel.SystemImage
+		cpu     int
+		memory  int
+		disk    int
+		wantErr bool
+	}{
+		{name: "满足要求", appID: wordpress.ID, image: ubuntuVM, cpu: 1, memory: 1024, disk: 10240},
+		{name: "不存在的应用", appID: 999, image: ubuntuVM, cpu: 1, memory: 1024, disk: 10240, wantErr: true},
+		{name: "已下架的应用", appID: offline.ID, image: ubuntuVM, cpu: 1, memory: 1024, disk: 10240, wantErr: true},
+		{name: "Windows镜像", appID: vmOnly.ID, image: &systemModel.SystemImage{OSType: "windows", InstanceType: "vm"}, cpu: 2, memory: 4096, disk: 40960, wantErr: true},
+		{name: "不兼容的系统", appID: wordpress.ID, image: &systemModel.SystemImage{OSType: "alpine", InstanceType: "vm"}, cpu: 1, memory: 1024, disk: 10240, wantErr: true},
+		{name: "实例类型不符", appID: vmOnly.ID, image: &systemModel.SystemImage{OSType: "debian", InstanceType: "container"}, cpu: 1, memory: 512, disk: 5120, wantErr: true},
+		{name: "CPU不足", appID: wordpress.ID, image: ubuntuVM, cpu: 0, memory: 1024, disk: 10240, wantErr: true},
+		{name: "内存不足", appID: wordpress.ID, image: ubuntuVM, cpu: 1, memory: 512, disk: 10240, wantErr: true},
+		{name: "硬盘不足", appID: wordpress.ID, image: ubuntuVM, cpu: 1, memory: 1024, disk: 5120, wantErr: true},
+	}
+	s := &Service{}
+	for _, tt := range tests {
+		app, err := s.validateAppForInstance(tt.appID, tt.image,
+			&constant.CPUSpec{Cores: tt.cpu}, &constant.MemorySpec{SizeMB: tt.memory}, &constant.DiskSpec{SizeMB: tt.disk})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应拒绝", tt.name)
+			}
+			continue
+		}
+		if err != nil || app.ID != tt.appID {
+			t.Errorf("%s: app = %v, err = %v", tt.name, app, err)
+		}
+	}
+}
+
+func TestAppTemplateParsePorts(t *testing.T) {
+	app := systemModel.AppTemplate{Ports: "http:80, admin : 8443,bad,udp:70000,:22"}
+	ports := app.ParsePorts()
+	if len(ports) != 3 || ports[0] != (systemModel.AppPort{Name: "HTTP", Port: 80}) || ports[1] != (systemModel.AppPort{Name: "ADMIN", Port: 8443}) {
+		t.Errorf("ParsePorts = %+v", ports)
+	}
+}
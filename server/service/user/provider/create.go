@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"oneclickvirt/constant"
@@ -10,8 +11,12 @@ import (
 	providerModel "oneclickvirt/model/provider"
 	systemModel "oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/abuse"
 	"oneclickvirt/service/cache"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/imageeol"
+	"oneclickvirt/service/images"
+	"oneclickvirt/service/instancedefaults"
 	"oneclickvirt/service/resources"
 	"time"
 
@@ -35,6 +40,16 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		zap.String("bandwidthId", req.BandwidthId),
 		zap.String("description", req.Description))
 
+	// 因滥用举报被限制的用户不能申请新实例
+	if err := abuse.CheckCreationAllowed(userID); err != nil {
+		return nil, err
+	}
+
+	// 未填写的镜像、节点和标签使用用户的实例默认设置
+	if err := instancedefaults.NewService().ApplyToRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
 	// 快速验证基本参数
 	var provider providerModel.Provider
 	if err := global.APP_DB.First(&provider, req.ProviderId).Error; err != nil {
@@ -72,6 +87,31 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		return nil, errors.New("所选镜像不可用")
 	}
 
+	// 同一逻辑镜像可对应多个架构，按节点架构映射到实际使用的镜像
+	imageService := &images.ImageService{}
+	resolvedImage, err := imageService.ResolveImageForArchitecture(&systemImage, provider.Type, provider.Architecture)
+	if err != nil {
+		global.APP_LOG.Error("镜像架构与节点不兼容",
+			zap.Uint("providerId", req.ProviderId),
+			zap.Uint("imageId", req.ImageId),
+			zap.String("providerArch", provider.Architecture),
+			zap.String("imageArch", systemImage.Architecture),
+			zap.Error(err))
+		return nil, err
+	}
+	systemImage = *resolvedImage
+	req.ImageId = systemImage.ID
+
+	// 检查镜像是否被管理员禁用策略限制
+	if err := imageService.CheckImageAllowed(&systemImage, images.GetUserLevel(userID), provider.ID); err != nil {
+		return nil, err
+	}
+
+	// 检查镜像操作系统是否已EOL
+	if err := imageeol.CheckCreationAllowed(&systemImage); err != nil {
+		return nil, err
+	}
+
 	// 验证Provider和Image的匹配性
 	if err := s.validateProviderImageCompatibility(&provider, &systemImage); err != nil {
 		global.APP_LOG.Error("Provider和镜像不匹配",
@@ -149,6 +189,26 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 		return nil, err
 	}
 
+	// 验证一键应用与镜像、规格的兼容性
+	if req.AppId != 0 {
+		if _, err := s.validateAppForInstance(req.AppId, &systemImage, cpuSpec, memorySpec, diskSpec); err != nil {
+			global.APP_LOG.Error("一键应用验证失败",
+				zap.Uint("appId", req.AppId),
+				zap.Uint("imageId", req.ImageId),
+				zap.Error(err))
+			return nil, err
+		}
+	}
+
+	// 验证实例分组归属
+	if req.GroupId != 0 {
+		var groupCount int64
+		global.APP_DB.Model(&userModel.InstanceGroup{}).Where("id = ? AND user_id = ?", req.GroupId, userID).Count(&groupCount)
+		if groupCount == 0 {
+			return nil, errors.New("实例分组不存在")
+		}
+	}
+
 	global.APP_LOG.Info("所有验证通过，开始创建实例",
 		zap.Uint("userID", userID),
 		zap.Uint("providerId", req.ProviderId),
@@ -161,6 +221,29 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 	return s.createInstanceWithMinimalTransaction(userID, &req, sessionID, &systemImage, cpuSpec, memorySpec, diskSpec, bandwidthSpec)
 }
 
+// BatchCreateUserInstances 批量创建用户实例，逐个提交创建任务
+// 要求分散放置时每个实例单独选择节点，已提交的创建任务计入分布；遇到失败时停止提交，
+// 第一个实例就失败时返回错误，否则返回已提交的结果和失败项
+func (s *Service) BatchCreateUserInstances(userID uint, req userModel.BatchCreateInstanceRequest) ([]userModel.BatchCreateInstanceResult, error) {
+	results := make([]userModel.BatchCreateInstanceResult, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		task, err := s.CreateUserInstance(userID, req.CreateInstanceRequest)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			results = append(results, userModel.BatchCreateInstanceResult{Index: i, Error: err.Error()})
+			break
+		}
+		result := userModel.BatchCreateInstanceResult{Index: i, TaskID: task.ID, Status: task.Status}
+		if task.ProviderID != nil {
+			result.ProviderID = *task.ProviderID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 // createInstanceWithMinimalTransaction 原子化实例创建流程
 // 只在真正需要原子性的操作中持有事务和行锁，最小化锁持有时间
 // 资源规格限制（CPU、内存、磁盘、带宽）已在事务外的 validateUserSpecPermissions 中验证
@@ -282,8 +365,9 @@ func (s *Service) createInstanceWithMinimalTransaction(userID uint, req *userMod
 		}
 
 		// 2. 创建任务
-		taskData := fmt.Sprintf(`{"providerId":%d,"imageId":%d,"cpuId":"%s","memoryId":"%s","diskId":"%s","bandwidthId":"%s","description":"%s","sessionId":"%s"}`,
-			req.ProviderId, req.ImageId, req.CPUId, req.MemoryId, req.DiskId, req.BandwidthId, req.Description, sessionID)
+		tagsJSON, _ := json.Marshal(req.Tags)
+		taskData := fmt.Sprintf(`{"providerId":%d,"imageId":%d,"cpuId":"%s","memoryId":"%s","diskId":"%s","bandwidthId":"%s","description":"%s","sessionId":"%s","appId":%d,"groupId":%d,"tags":%s}`,
+			req.ProviderId, req.ImageId, req.CPUId, req.MemoryId, req.DiskId, req.BandwidthId, req.Description, sessionID, req.AppId, req.GroupId, tagsJSON)
 
 		// 计算预计执行时长
 		estimatedDuration := 300 // 默认5分钟
@@ -10,6 +10,7 @@ import (
 	"oneclickvirt/global"
 	adminModel "oneclickvirt/model/admin"
 	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/database"
 	"oneclickvirt/service/interfaces"
 	providerService "oneclickvirt/service/provider"
 	"oneclickvirt/utils"
@@ -18,9 +19,9 @@ import (
 	"gorm.io/gorm"
 )
 
-// updateTaskProgress 更新任务进度（使用全局工具函数）
+// updateTaskProgress 更新任务进度（数据库故障时进入重试队列）
 func (s *Service) updateTaskProgress(taskID uint, progress int, message string) {
-	utils.UpdateTaskProgress(taskID, progress, message)
+	database.GetDatabaseService().SubmitTaskProgress(taskID, progress, message)
 }
 
 // markTaskCompleted 标记任务最终完成（使用全局工具函数）
@@ -120,6 +121,10 @@ func (s *Service) executeAdminDeleteInstance(instanceID uint, taskService interf
 		"providerId":     instance.ProviderID,
 		"adminOperation": true, // 标记为管理员操作
 	}
+	// 创建失败的实例在回滚时已释放Provider资源，删除任务不能重复释放
+	if instance.Status == "failed" {
+		taskData["resourcesReleased"] = true
+	}
 
 	taskDataJSON, err := json.Marshal(taskData)
 	if err != nil {
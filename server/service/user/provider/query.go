@@ -12,14 +12,17 @@ import (
 	resourceModel "oneclickvirt/model/resource"
 	systemModel "oneclickvirt/model/system"
 	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/imageeol"
 	"oneclickvirt/service/images"
+	"oneclickvirt/service/netprobe"
 	"oneclickvirt/service/resources"
 
 	"go.uber.org/zap"
 )
 
 // GetAvailableProviders 获取可用节点列表
-func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProviderResponse, error) {
+// architecture 不为空时只返回该架构的节点
+func (s *Service) GetAvailableProviders(userID uint, architecture string) ([]userModel.AvailableProviderResponse, error) {
 	var dbProviders []providerModel.Provider
 
 	// 获取允许申领且未冻结的Provider，包括部分在线的服务器
@@ -31,6 +34,17 @@ func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProvi
 		return nil, err
 	}
 
+	if architecture != "" {
+		architecture = constant.NormalizeArchitecture(architecture)
+		filtered := dbProviders[:0]
+		for _, provider := range dbProviders {
+			if constant.NormalizeArchitecture(provider.Architecture) == architecture {
+				filtered = append(filtered, provider)
+			}
+		}
+		dbProviders = filtered
+	}
+
 	global.APP_LOG.Info("开始处理Provider列表",
 		zap.Int("totalProviders", len(dbProviders)),
 		zap.Uint("userID", userID))
@@ -104,10 +118,10 @@ func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProvi
 				reservedDisk += reservation.Disk
 			}
 
-			// 使用真实的资源数据
-			nodeCPU := provider.NodeCPUCores
-			nodeMemory := provider.NodeMemoryTotal
-			nodeDisk := provider.NodeDiskTotal
+			// 使用真实的资源数据（扣除系统预留）
+			nodeCPU := provider.AllocatableCPUCores()
+			nodeMemory := provider.AllocatableMemory()
+			nodeDisk := provider.AllocatableDisk()
 
 			// 计算实际使用的资源 = 已分配的 + 预留的
 			actualUsedCPU := provider.UsedCPUCores + reservedCPU
@@ -164,6 +178,7 @@ func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProvi
 				ID:                      provider.ID,
 				Name:                    provider.Name,
 				Type:                    provider.Type,
+				Architecture:            constant.NormalizeArchitecture(provider.Architecture),
 				Region:                  provider.Region,
 				Country:                 provider.Country,
 				CountryCode:             provider.CountryCode,
@@ -185,6 +200,18 @@ func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProvi
 		}
 	}
 
+	// 附加各节点最近一次网络质量测试摘要，供用户按网络质量选择节点
+	if len(providers) > 0 {
+		providerIDs := make([]uint, 0, len(providers))
+		for _, p := range providers {
+			providerIDs = append(providerIDs, p.ID)
+		}
+		summaries := netprobe.LatestSummaries(providerIDs)
+		for i := range providers {
+			providers[i].NetworkQuality = summaries[providers[i].ID]
+		}
+	}
+
 	global.APP_LOG.Info("Provider列表处理完成",
 		zap.Int("totalProviders", len(dbProviders)),
 		zap.Int("availableProviders", len(providers)),
@@ -196,15 +223,35 @@ func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProvi
 
 // GetSystemImages 获取系统镜像列表
 func (s *Service) GetSystemImages(userID uint, req userModel.SystemImagesRequest) ([]userModel.SystemImageResponse, error) {
+	imageService := &images.ImageService{}
+	userLevel := images.GetUserLevel(userID)
+
 	var images []systemModel.SystemImage
 
 	// 从数据库获取镜像
 	query := global.APP_DB.Where("status = ?", "active")
+	if req.ProviderType != "" {
+		query = query.Where("provider_type LIKE ?", "%"+req.ProviderType+"%")
+	}
+	if req.InstanceType != "" {
+		query = query.Where("instance_type = ?", req.InstanceType)
+	}
+	if req.OsType != "" {
+		query = query.Where("LOWER(os_type) = LOWER(?)", req.OsType)
+	}
+	if req.Architecture != "" {
+		query = query.Where("architecture = ?", constant.NormalizeArchitecture(req.Architecture))
+	}
 
 	if err := query.Order("os_type ASC, name ASC").Find(&images).Error; err != nil {
 		return nil, err
 	}
 
+	// 按用户等级排除被禁用的镜像
+	images = imageService.FilterBannedImages(images, userLevel, 0)
+	// 开启EOL禁止创建时排除已停止维护的镜像
+	images = imageeol.FilterCreatable(images)
+
 	var response []userModel.SystemImageResponse
 	for _, img := range images {
 		response = append(response, userModel.SystemImageResponse{
@@ -393,10 +440,14 @@ func (s *Service) GetFilteredSystemImages(userID uint, providerID uint, instance
 
 	// 使用镜像服务获取过滤后的镜像
 	imageService := &images.ImageService{}
+	userLevel := images.GetUserLevel(userID)
 	images, err := imageService.GetFilteredImages(providerID, instanceType)
 	if err != nil {
 		return nil, err
 	}
+	// 按用户等级和节点排除被禁用的镜像
+	images = imageService.FilterBannedImages(images, userLevel, providerID)
+	images = imageeol.FilterCreatable(images)
 
 	var response []userModel.SystemImageResponse
 	for _, img := range images {
@@ -441,9 +492,9 @@ func (s *Service) GetProviderCapabilities(userID uint, providerID uint) (map[str
 		"containerEnabled": provider.ContainerEnabled,
 		"vmEnabled":        provider.VirtualMachineEnabled,
 		"supportedTypes":   supportedTypes,
-		"maxCpu":           provider.NodeCPUCores,
-		"maxMemory":        provider.NodeMemoryTotal,
-		"maxDisk":          provider.NodeDiskTotal,
+		"maxCpu":           provider.AllocatableCPUCores(),
+		"maxMemory":        provider.AllocatableMemory(),
+		"maxDisk":          provider.AllocatableDisk(),
 		"region":           provider.Region,
 		"country":          provider.Country,
 		"city":             provider.City,
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,11 +19,16 @@ import (
 	"oneclickvirt/provider"
 	"oneclickvirt/provider/incus"
 	"oneclickvirt/provider/lxd"
+	"oneclickvirt/service/chaos"
 	"oneclickvirt/service/database"
+	"oneclickvirt/service/hooks"
+	"oneclickvirt/service/instanceenv"
+	"oneclickvirt/service/instancename"
 	"oneclickvirt/service/interfaces"
 	providerService "oneclickvirt/service/provider"
 	"oneclickvirt/service/resources"
 	"oneclickvirt/service/traffic"
+	"oneclickvirt/service/userwebhook"
 
 	"go.uber.org/zap"
 	"golang.org/x/crypto/ssh"
@@ -97,13 +104,30 @@ func (s *Service) ProcessCreateInstanceTask(ctx context.Context, task *adminMode
 	s.updateTaskProgress(task.ID, 30, "正在调用Provider API...")
 
 	// 阶段2: Provider API调用（无事务）(30% -> 60%)
-	apiError := s.executeProviderCreation(ctx, task, instance)
+	apiError := chaos.Inject(chaos.PointCreateAfterPrepare)
+	if apiError == nil {
+		apiError = s.executeProviderCreation(ctx, task, instance)
+		if apiError == nil {
+			apiError = chaos.Inject(chaos.PointCreateAfterProviderCreate)
+		}
+	}
 
 	// 阶段3: 结果处理（快速事务）
 	global.APP_LOG.Info("开始处理实例创建结果", zap.Uint("taskId", task.ID), zap.Bool("hasApiError", apiError != nil))
 	if finalizeErr := s.finalizeInstanceCreation(context.Background(), task, instance, apiError); finalizeErr != nil {
 		global.APP_LOG.Error("实例创建最终化失败", zap.Uint("taskId", task.ID), zap.Error(finalizeErr))
-		return finalizeErr
+		if apiError != nil {
+			return finalizeErr
+		}
+		// 成功结果写回失败时按创建失败回滚，避免实例停留在creating状态且Provider资源不释放
+		apiError = finalizeErr
+		if rollbackErr := s.finalizeInstanceCreation(context.Background(), task, instance, apiError); rollbackErr != nil {
+			global.APP_LOG.Error("实例创建回滚失败", zap.Uint("taskId", task.ID), zap.Error(rollbackErr))
+			return finalizeErr
+		}
+	}
+	if point, ok := chaos.InjectedPoint(apiError); ok {
+		chaos.VerifyAfterRollback(point, task.UserID, instance.ProviderID)
 	}
 	global.APP_LOG.Info("实例创建结果处理完成", zap.Uint("taskId", task.ID), zap.Bool("hasApiError", apiError != nil))
 
@@ -116,6 +140,31 @@ func (s *Service) ProcessCreateInstanceTask(ctx context.Context, task *adminMode
 	return nil
 }
 
+// RollbackCreateInstanceTask 回滚执行超时的创建任务
+// 任务协程未响应取消时实例会停留在creating状态，这里按创建失败处理：标记实例失败、释放端口和Provider资源并延迟删除实例
+func (s *Service) RollbackCreateInstanceTask(task *adminModel.Task, cause error) error {
+	if task.InstanceID == nil {
+		return nil
+	}
+
+	// 先将实例从creating改为failed，实例已被任务协程自行处理时不再重复回滚
+	result := global.APP_DB.Model(&providerModel.Instance{}).
+		Where("id = ? AND status = ?", *task.InstanceID, constant.InstanceStatusCreating).
+		Update("status", constant.InstanceStatusFailed)
+	if result.Error != nil {
+		return fmt.Errorf("更新实例状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, *task.InstanceID).Error; err != nil {
+		return fmt.Errorf("获取实例信息失败: %w", err)
+	}
+	return s.finalizeInstanceCreation(context.Background(), task, &instance, cause)
+}
+
 // prepareInstanceCreation 阶段1: 数据库预处理（不依赖预留资源）
 func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.Task) (*providerModel.Instance, error) {
 	// 解析任务数据
@@ -177,8 +226,13 @@ func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.
 			return fmt.Errorf("服务器已过期")
 		}
 
-		// 生成实例名称
-		instanceName := s.generateInstanceName(provider.Name)
+		// 生成并预留实例名称，与已有实例或冷却期内删除的实例重名时重新生成
+		instanceName, err := instancename.ReserveGenerated(tx, provider.ID, func() string {
+			return s.generateInstanceName(provider.Name)
+		}, 5)
+		if err != nil {
+			return err
+		}
 
 		// 设置实例到期时间
 		// 默认与Provider的到期时间同步，但如果Provider没有到期时间则使用1年后
@@ -195,14 +249,19 @@ func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.
 			Provider:           provider.Name,
 			ProviderID:         provider.ID,
 			Image:              systemImage.Name,
+			ImageID:            systemImage.ID,
+			OSEOLDate:          systemImage.EffectiveEOLDate(),
 			CPU:                cpuSpec.Cores,
 			Memory:             int64(memorySpec.SizeMB),
 			Disk:               int64(diskSpec.SizeMB),
 			Bandwidth:          bandwidthSpec.SpeedMbps,
 			InstanceType:       systemImage.InstanceType,
 			UserID:             task.UserID,
+			GroupID:            taskReq.GroupId,
+			Tags:               taskReq.Tags,
 			Status:             "creating",
 			OSType:             systemImage.OSType,
+			GuestSSHPort:       resources.ResolveGuestSSHPort(&provider, systemImage.InstanceType),
 			ExpiresAt:          expiredAt,
 			IsManualExpiry:     false, // 默认非手动设置，跟随节点
 			MaxTraffic:         0,     // 默认为0，表示继承用户等级限制，不单独限制实例
@@ -215,6 +274,23 @@ func (s *Service) prepareInstanceCreation(ctx context.Context, task *adminModel.
 			return fmt.Errorf("创建实例失败: %v", err)
 		}
 
+		// 记录待安装的一键应用，实例创建完成后安装
+		if taskReq.AppId != 0 {
+			var app systemModel.AppTemplate
+			if err := tx.Where("id = ? AND status = ?", taskReq.AppId, 1).First(&app).Error; err != nil {
+				return fmt.Errorf("所选应用不存在或已下架")
+			}
+			instanceApp := systemModel.InstanceApp{
+				InstanceID: instance.ID,
+				AppID:      app.ID,
+				AppName:    app.Name,
+				Status:     systemModel.InstanceAppStatusPending,
+			}
+			if err := tx.Create(&instanceApp).Error; err != nil {
+				return fmt.Errorf("创建应用安装记录失败: %v", err)
+			}
+		}
+
 		// 更新任务关联的实例ID和状态
 		if err := tx.Model(task).Updates(map[string]interface{}{
 			"instance_id": instance.ID,
@@ -296,9 +372,6 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 	localProviderType := dbProvider.Type
 	localProviderIsFrozen := dbProvider.IsFrozen
 	localProviderExpiresAt := dbProvider.ExpiresAt
-	localProviderIPv4PortMappingMethod := dbProvider.IPv4PortMappingMethod
-	localProviderIPv6PortMappingMethod := dbProvider.IPv6PortMappingMethod
-	localProviderNetworkType := dbProvider.NetworkType
 
 	// 检查Provider是否过期或冻结
 	if localProviderIsFrozen {
@@ -398,15 +471,6 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 		Disk:         fmt.Sprintf("%dm", diskSpec.SizeMB),   // 使用实际磁盘大小（MB格式）
 		InstanceType: instance.InstanceType,
 		ImageURL:     systemImage.URL, // 镜像URL用于下载
-		Metadata: map[string]string{
-			"user_level":               fmt.Sprintf("%d", user.Level),              // 用户等级，用于带宽限制配置
-			"bandwidth_spec":           fmt.Sprintf("%d", bandwidthSpec.SpeedMbps), // 用户选择的带宽规格
-			"ipv4_port_mapping_method": localProviderIPv4PortMappingMethod,         // IPv4端口映射方式（从Provider配置获取）
-			"ipv6_port_mapping_method": localProviderIPv6PortMappingMethod,         // IPv6端口映射方式（从Provider配置获取）
-			"network_type":             localProviderNetworkType,                   // 网络配置类型：nat_ipv4, nat_ipv4_ipv6, dedicated_ipv4, dedicated_ipv4_ipv6, ipv6_only
-			"instance_id":              fmt.Sprintf("%d", instance.ID),             // 实例ID，用于端口分配
-			"provider_id":              fmt.Sprintf("%d", localProviderID),         // Provider ID，用于端口区间分配
-		},
 		// 容器特殊配置选项（从Provider继承，仅用于LXD/Incus容器）
 		Privileged:   boolPtr(dbProvider.ContainerPrivileged),
 		AllowNesting: boolPtr(dbProvider.ContainerAllowNesting),
@@ -417,6 +481,24 @@ func (s *Service) executeProviderCreation(ctx context.Context, task *adminModel.
 		DiskIOLimit:  stringPtr(dbProvider.ContainerDiskIOLimit),
 	}
 
+	// 注入系统元数据（用户等级、带宽规格、网络类型等）和管理员配置的默认环境变量/元数据
+	instanceenv.Apply(&instanceConfig, instanceenv.Vars{
+		Operation:     "create",
+		UserID:        user.ID,
+		Username:      user.Username,
+		UserLevel:     user.Level,
+		InstanceID:    instance.ID,
+		InstanceName:  instance.Name,
+		InstanceType:  instance.InstanceType,
+		Image:         systemImage.Name,
+		CPU:           cpuSpec.Cores,
+		MemoryMB:      int64(memorySpec.SizeMB),
+		DiskMB:        int64(diskSpec.SizeMB),
+		BandwidthMbps: bandwidthSpec.SpeedMbps,
+		GuestSSHPort:  instance.GuestSSHPort,
+		Provider:      &dbProvider,
+	})
+
 	// 预分配端口映射（所有Provider类型都需要）
 	portMappingService := &resources.PortMappingService{}
 
@@ -574,7 +656,7 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 		// 构建实例更新数据
 		instanceUpdates := map[string]interface{}{
 			"status":   "running",
-			"username": "root",
+			"username": constant.DefaultLoginUser(instance.OSType),
 		}
 
 		// 获取Provider信息以设置公网IP
@@ -626,18 +708,13 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 			if actualInstance.IPv6Address != "" {
 				instanceUpdates["ipv6_address"] = actualInstance.IPv6Address
 			}
-			// SSH端口使用默认值22
-			instanceUpdates["ssh_port"] = 22
-			// 标准化实例状态：将Provider返回的各种运行状态统一为"running"
+			// 无端口映射时直接访问实例内SSH端口
+			instanceUpdates["ssh_port"] = constant.GuestSSHPortOrDefault(instance.GuestSSHPort)
+			// 标准化实例状态：仅接受running/stopped，其他状态保持默认的running
 			if actualInstance.Status != "" {
-				// 将Provider返回的状态转换为小写进行比较
-				providerStatus := strings.ToLower(actualInstance.Status)
-				// 如果Provider返回的是运行状态（running/active），统一设置为running
-				// 其他状态（如stopped）保持原样
-				if providerStatus == "running" || providerStatus == "active" {
-					instanceUpdates["status"] = "running"
-				} else if providerStatus == "stopped" {
-					instanceUpdates["status"] = "stopped"
+				providerStatus := constant.NormalizeProviderStatus(dbProvider.Type, actualInstance.Status)
+				if providerStatus == constant.InstanceStatusRunning || providerStatus == constant.InstanceStatusStopped {
+					instanceUpdates["status"] = providerStatus
 				} else {
 					// 对于其他未知状态，记录日志但保持默认的running状态
 					global.APP_LOG.Warn("Provider返回了非标准状态",
@@ -647,8 +724,8 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 				}
 			}
 		} else {
-			// 使用默认值
-			instanceUpdates["ssh_port"] = 22
+			// 使用实例内SSH端口
+			instanceUpdates["ssh_port"] = constant.GuestSSHPortOrDefault(instance.GuestSSHPort)
 		}
 
 		// 尝试获取IPv4和IPv6地址（针对LXD、Incus和Proxmox Provider）
@@ -866,6 +943,9 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 			Disk:      instance.Disk,
 			Bandwidth: instance.Bandwidth,
 		}
+		if err := chaos.Inject(chaos.PointCreateBeforeQuotaConfirm); err != nil {
+			return err
+		}
 		// 实例创建成功，将待确认配额转为已使用配额
 		if err := quotaService.ConfirmPendingQuota(tx, task.UserID, resourceUsage); err != nil {
 			global.APP_LOG.Error("确认用户配额失败",
@@ -936,7 +1016,12 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 			s.updateTaskProgress(taskID, 75, "等待实例SSH服务就绪...")
 
 			// 智能等待实例SSH服务就绪，传入taskID以便更新进度
-			if err := s.waitForInstanceSSHReady(instanceID, providerID, taskID, 120*time.Second); err != nil {
+			// Windows 首次启动需要完成 sysprep 和 cloudbase-init，等待时间更长
+			readyTimeout := 120 * time.Second
+			if constant.IsWindowsOSType(instance.OSType) {
+				readyTimeout = 600 * time.Second
+			}
+			if err := s.waitForInstanceSSHReady(instanceID, providerID, taskID, readyTimeout); err != nil {
 				global.APP_LOG.Warn("等待实例SSH就绪超时",
 					zap.Uint("instanceId", instanceID),
 					zap.Error(err))
@@ -1086,6 +1171,38 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 					zap.String("instanceName", currentInstance.Name))
 			}
 
+			// 6. 安装用户选择的一键应用，失败不影响实例本身
+			if reason := s.installInstanceApp(instanceID, providerID); reason != "" {
+				completionMessage = fmt.Sprintf("%s，但应用安装失败: %s", completionMessage, reason)
+			}
+
+			// 7. 写入实例分组的默认SSH公钥、用户默认设置中的SSH公钥和时区以及MOTD，再执行用户订阅了创建事件的钩子脚本，输出写入任务日志
+			if reason := hooks.InstallGroupSSHKey(taskID, instanceID); reason != "" {
+				completionMessage = fmt.Sprintf("%s，%s", completionMessage, reason)
+			}
+			if reason := hooks.ApplyUserInstanceDefaults(taskID, instanceID); reason != "" {
+				completionMessage = fmt.Sprintf("%s，%s", completionMessage, reason)
+			}
+			if reason := hooks.ApplyInstanceMOTD(taskID, instanceID); reason != "" {
+				completionMessage = fmt.Sprintf("%s，%s", completionMessage, reason)
+			}
+			if failures := hooks.RunInstanceHooks(taskID, instanceID, userModel.HookEventCreate); len(failures) > 0 {
+				completionMessage = fmt.Sprintf("%s，钩子脚本执行失败: %s", completionMessage, strings.Join(failures, "; "))
+			}
+
+			// 8. 可选的创建后冒烟验证，结果记录在实例上，不影响任务成功状态
+			if global.APP_CONFIG.Task.PostCreateVerify {
+				s.updateTaskProgress(taskID, 99, "正在验证实例可用性...")
+				if warnings := s.verifyInstanceAfterCreate(instanceID, providerID); len(warnings) > 0 {
+					completionMessage = fmt.Sprintf("%s，验证警告: %s", completionMessage, strings.Join(warnings, "; "))
+				}
+			}
+
+			// 9. 可选的安全加固，放在最后执行，避免禁用密码登录后影响前面基于密码的SSH操作
+			if reason := hooks.RunInstanceHardening(taskID, instanceID); reason != "" {
+				completionMessage = fmt.Sprintf("%s，%s", completionMessage, reason)
+			}
+
 			// 标记任务最终完成
 			// 使用统一状态管理器
 			stateManager := s.taskService.GetStateManager()
@@ -1097,6 +1214,8 @@ func (s *Service) finalizeInstanceCreation(ctx context.Context, task *adminModel
 				global.APP_LOG.Error("状态管理器未初始化", zap.Uint("taskId", taskID))
 			}
 
+			userwebhook.InstanceCreated(instanceID, taskID)
+
 			global.APP_LOG.Info("实例创建后处理任务完成",
 				zap.Uint("instanceId", instanceID),
 				zap.Bool("passwordSetSuccess", passwordSetSuccess))
@@ -1121,32 +1240,7 @@ func (s *Service) waitForInstanceSSHReady(instanceID, providerID, taskID uint, m
 		return fmt.Errorf("获取Provider信息失败: %w", err)
 	}
 
-	// 获取SSH端口映射
-	var sshPort int
-	var sshPortMapping providerModel.Port
-	if err := global.APP_DB.Where("instance_id = ? AND is_ssh = true AND status = 'active'", instanceID).First(&sshPortMapping).Error; err == nil {
-		sshPort = sshPortMapping.HostPort
-	} else {
-		sshPort = instance.SSHPort
-		if sshPort == 0 {
-			sshPort = 22 // 默认端口
-		}
-	}
-
-	// 确定SSH连接地址
-	var sshHost string
-	if provider.PortIP != "" {
-		sshHost = provider.PortIP
-	} else {
-		sshHost = provider.Endpoint
-	}
-
-	// 如果sshHost包含端口，去掉端口部分
-	if colonIndex := strings.LastIndex(sshHost, ":"); colonIndex > 0 {
-		if strings.Count(sshHost, ":") == 1 || strings.HasPrefix(sshHost, "[") {
-			sshHost = sshHost[:colonIndex]
-		}
-	}
+	sshHost, sshPort := resources.ResolveInstanceSSHEndpoint(&instance, &provider)
 
 	global.APP_LOG.Info("开始等待实例SSH服务就绪",
 		zap.Uint("instanceId", instanceID),
@@ -1183,8 +1277,23 @@ func (s *Service) waitForInstanceSSHReady(instanceID, providerID, taskID uint, m
 		waitMsg := fmt.Sprintf("等待实例SSH服务就绪... (尝试 %d次, 已等待 %ds)", attemptCount, int(elapsed.Seconds()))
 		s.updateTaskProgress(taskID, currentProgress, waitMsg)
 
+		// Windows 实例使用RDP登录，仅检查端口可连接
+		address := net.JoinHostPort(sshHost, strconv.Itoa(sshPort))
+		if constant.IsWindowsOSType(instance.OSType) {
+			if conn, err := net.DialTimeout("tcp", address, 5*time.Second); err == nil {
+				conn.Close()
+				global.APP_LOG.Info("Windows实例RDP端口已就绪",
+					zap.Uint("instanceId", instanceID),
+					zap.String("instanceName", instance.Name),
+					zap.Duration("waitTime", elapsed))
+				s.updateTaskProgress(taskID, progressEnd, "实例RDP服务已就绪")
+				return nil
+			}
+			time.Sleep(checkInterval)
+			continue
+		}
+
 		// 尝试连接SSH
-		address := fmt.Sprintf("%s:%d", sshHost, sshPort)
 		config := &ssh.ClientConfig{
 			User: instance.Username,
 			Auth: []ssh.AuthMethod{
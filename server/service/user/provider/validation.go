@@ -36,8 +36,10 @@ func (s *Service) validateProviderImageCompatibility(provider *providerModel.Pro
 	}
 
 	// 验证架构兼容性
-	if provider.Architecture != "" && image.Architecture != "" && provider.Architecture != image.Architecture {
-		return fmt.Errorf("架构不匹配：Provider架构为 %s，镜像架构为 %s", provider.Architecture, image.Architecture)
+	providerArch := constant.NormalizeArchitecture(provider.Architecture)
+	imageArch := constant.NormalizeArchitecture(image.Architecture)
+	if providerArch != imageArch {
+		return fmt.Errorf("架构不匹配：Provider架构为 %s，镜像架构为 %s", providerArch, imageArch)
 	}
 
 	// 验证实例类型支持
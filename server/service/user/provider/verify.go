@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 实例创建后验证状态
+const (
+	InstanceVerifyStatusVerified = "verified"
+	InstanceVerifyStatusWarning  = "warning"
+)
+
+// 磁盘大小允许的误差比例（文件系统元数据、保留块等会占用部分空间）
+const verifyDiskTolerance = 0.85
+
+// instanceVerifyScript 在实例内执行的检查脚本，每项输出一行 key=value
+const instanceVerifyScript = `(getent hosts github.com || nslookup github.com || host github.com) >/dev/null 2>&1 && echo dns=ok || echo dns=fail
+(curl -s -m 8 -o /dev/null https://1.1.1.1 || wget -q -T 8 -O /dev/null https://1.1.1.1 || ping -c 1 -W 5 1.1.1.1 || ping -6 -c 1 -W 5 2606:4700:4700::1111) >/dev/null 2>&1 && echo outbound=ok || echo outbound=fail
+echo disk_mb=$(df -BM / 2>/dev/null | awk 'NR==2{gsub("M","",$2);print $2}')`
+
+// verifyInstanceAfterCreate 对新建实例执行冒烟验证：
+// 通过映射端口SSH登录、验证DNS解析、外网连通性以及根分区大小是否符合规格
+// 结果写回实例记录，返回警告列表（为空表示验证通过）
+func (s *Service) verifyInstanceAfterCreate(instanceID, providerID uint) []string {
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		global.APP_LOG.Warn("获取实例信息失败，跳过创建后验证", zap.Uint("instanceId", instanceID), zap.Error(err))
+		return nil
+	}
+	var provider providerModel.Provider
+	if err := global.APP_DB.First(&provider, providerID).Error; err != nil {
+		global.APP_LOG.Warn("获取Provider信息失败，跳过创建后验证", zap.Uint("instanceId", instanceID), zap.Error(err))
+		return nil
+	}
+
+	warnings := runInstanceVerifyChecks(&instance, &provider)
+
+	status := InstanceVerifyStatusVerified
+	if len(warnings) > 0 {
+		status = InstanceVerifyStatusWarning
+	}
+	now := time.Now()
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", instanceID).Updates(map[string]interface{}{
+		"verify_status":   status,
+		"verify_warnings": strings.Join(warnings, "\n"),
+		"verified_at":     &now,
+	}).Error; err != nil {
+		global.APP_LOG.Error("保存实例验证结果失败", zap.Uint("instanceId", instanceID), zap.Error(err))
+	}
+
+	global.APP_LOG.Info("实例创建后验证完成",
+		zap.Uint("instanceId", instanceID),
+		zap.String("instanceName", instance.Name),
+		zap.String("status", status),
+		zap.Strings("warnings", warnings))
+	return warnings
+}
+
+// runInstanceVerifyChecks 执行具体检查并返回警告列表
+func runInstanceVerifyChecks(instance *providerModel.Instance, provider *providerModel.Provider) []string {
+	// Windows 实例无法执行Shell检查，仅验证RDP端口可连接
+	if constant.IsWindowsOSType(instance.OSType) {
+		host, port := resources.ResolveInstanceSSHEndpoint(instance, provider)
+		address := net.JoinHostPort(host, strconv.Itoa(port))
+		conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+		if err != nil {
+			return []string{fmt.Sprintf("无法通过映射端口 %s 连接RDP: %v", address, err)}
+		}
+		conn.Close()
+		return nil
+	}
+
+	if instance.Username == "" || instance.Password == "" {
+		return []string{"实例缺少登录凭据，无法通过SSH验证"}
+	}
+
+	host, port := resources.ResolveInstanceSSHEndpoint(instance, provider)
+	client, session, err := utils.CreateSSHConnection(host, port, instance.Username, instance.Password)
+	if err != nil {
+		return []string{fmt.Sprintf("无法通过映射端口 %s:%d 登录SSH: %v", host, port, err)}
+	}
+	defer client.Close()
+	defer session.Close()
+
+	output, err := session.CombinedOutput(instanceVerifyScript)
+	if err != nil && len(output) == 0 {
+		return []string{fmt.Sprintf("执行验证命令失败: %v", err)}
+	}
+	return evaluateVerifyResults(parseVerifyOutput(string(output)), instance.Disk)
+}
+
+// evaluateVerifyResults 根据检查输出生成警告，specDiskMB 为实例规格的磁盘大小
+func evaluateVerifyResults(results map[string]string, specDiskMB int64) []string {
+	var warnings []string
+	if results["dns"] != "ok" {
+		warnings = append(warnings, "实例内DNS解析失败")
+	}
+	if results["outbound"] != "ok" {
+		warnings = append(warnings, "实例无法访问外网")
+	}
+
+	diskMB, err := strconv.ParseInt(results["disk_mb"], 10, 64)
+	switch {
+	case err != nil || diskMB <= 0:
+		warnings = append(warnings, "无法获取实例根分区大小")
+	case specDiskMB > 0 && float64(diskMB) < float64(specDiskMB)*verifyDiskTolerance:
+		warnings = append(warnings, fmt.Sprintf("根分区大小 %dMB 小于规格 %dMB", diskMB, specDiskMB))
+	}
+	return warnings
+}
+
+// parseVerifyOutput 解析 key=value 格式的检查输出
+func parseVerifyOutput(output string) map[string]string {
+	results := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok {
+			results[key] = strings.TrimSpace(value)
+		}
+	}
+	return results
+}
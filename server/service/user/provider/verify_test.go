@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateVerifyResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		spec   int64
+		want   []string
+	}{
+		{name: "全部通过", output: "dns=ok\noutbound=ok\ndisk_mb=9800\n", spec: 10240},
+		{name: "未指定磁盘规格", output: "dns=ok\noutbound=ok\ndisk_mb=500", spec: 0},
+		{name: "DNS失败", output: "dns=fail\noutbound=ok\ndisk_mb=9800", spec: 10240, want: []string{"实例内DNS解析失败"}},
+		{name: "无法访问外网", output: " dns=ok \r\noutbound=fail\r\ndisk_mb=9800\r\n", spec: 10240, want: []string{"实例无法访问外网"}},
+		{name: "磁盘小于规格", output: "dns=ok\noutbound=ok\ndisk_mb=4000", spec: 10240, want: []string{"根分区大小 4000MB 小于规格 10240MB"}},
+		{name: "缺少磁盘大小", output: "dns=ok\noutbound=ok\ndisk_mb=", spec: 10240, want: []string{"无法获取实例根分区大小"}},
+		{name: "没有输出", output: "", spec: 10240, want: []string{"实例内DNS解析失败", "实例无法访问外网", "无法获取实例根分区大小"}},
+	}
+	for _, tt := range tests {
+		got := evaluateVerifyResults(parseVerifyOutput(tt.output), tt.spec)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: warnings = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -272,6 +272,7 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 		InstanceType: req.InstanceType,
 		UserID:       userID,
 		Status:       "creating",
+		GuestSSHPort: resources.ResolveGuestSSHPort(&provider, req.InstanceType),
 		ExpiresAt:    &expiredAt,
 	}
 
@@ -66,6 +66,16 @@ func (s *Service) GetInstanceDetail(userID, instanceID uint) (*userModel.UserIns
 	return s.instance.GetInstanceDetail(userID, instanceID)
 }
 
+// GetInstanceDetailLive 获取实例详情，状态和IP取自节点的实时数据
+func (s *Service) GetInstanceDetailLive(userID, instanceID uint) (*userModel.UserInstanceDetailResponse, error) {
+	return s.instance.GetInstanceDetailLive(userID, instanceID)
+}
+
+// GetInstanceConnectionBundle 获取实例连接信息包
+func (s *Service) GetInstanceConnectionBundle(userID, instanceID uint, lang string) (*userModel.InstanceConnectionBundle, error) {
+	return s.instance.GetConnectionBundle(userID, instanceID, lang)
+}
+
 // GetInstanceMonitoring 获取实例监控数据
 func (s *Service) GetInstanceMonitoring(userID, instanceID uint) (*userModel.InstanceMonitoringResponse, error) {
 	return s.instance.GetInstanceMonitoring(userID, instanceID)
@@ -103,6 +113,11 @@ func (s *Service) GetUserTasks(userID uint, req userModel.UserTasksRequest) ([]u
 	return s.profile.GetUserTasks(userID, req)
 }
 
+// GetUserTaskHistory 获取用户任务历史
+func (s *Service) GetUserTaskHistory(userID uint, req userModel.UserTaskHistoryRequest) ([]userModel.TaskHistoryEntry, int64, error) {
+	return s.profile.GetUserTaskHistory(userID, req)
+}
+
 // CancelUserTask 取消用户任务
 func (s *Service) CancelUserTask(userID, taskID uint) error {
 	return s.profile.CancelUserTask(userID, taskID)
@@ -135,8 +150,8 @@ func (s *Service) ClaimResource(userID uint, req userModel.ClaimResourceRequest)
 // ===== 提供商和配置相关方法 =====
 
 // GetAvailableProviders 获取可用节点列表
-func (s *Service) GetAvailableProviders(userID uint) ([]userModel.AvailableProviderResponse, error) {
-	return s.provider.GetAvailableProviders(userID)
+func (s *Service) GetAvailableProviders(userID uint, architecture string) ([]userModel.AvailableProviderResponse, error) {
+	return s.provider.GetAvailableProviders(userID, architecture)
 }
 
 // GetSystemImages 获取系统镜像列表
@@ -159,6 +174,11 @@ func (s *Service) CreateUserInstance(userID uint, req userModel.CreateInstanceRe
 	return s.provider.CreateUserInstance(userID, req)
 }
 
+// BatchCreateUserInstances 批量创建用户实例
+func (s *Service) BatchCreateUserInstances(userID uint, req userModel.BatchCreateInstanceRequest) ([]userModel.BatchCreateInstanceResult, error) {
+	return s.provider.BatchCreateUserInstances(userID, req)
+}
+
 // GetProviderCapabilities 获取Provider能力
 func (s *Service) GetProviderCapabilities(userID uint, providerID uint) (map[string]interface{}, error) {
 	return s.provider.GetProviderCapabilities(userID, providerID)
@@ -0,0 +1,168 @@
+package userwebhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"oneclickvirt/global"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/apiquota"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+)
+
+// 投递最多尝试的次数，网络错误、429和5xx会重试
+const maxAttempts = 3
+
+// errPrivateAddress 域名解析到了不允许的内网地址
+var errPrivateAddress = errors.New("接收地址解析到了内网地址")
+
+// retryDelays 第n次重试前的等待时间
+var retryDelays = []time.Duration{5 * time.Second, 30 * time.Second}
+
+// newClient 创建投递用的HTTP客户端
+// 不走代理、不跟随重定向，未开启 allow-private-network 时在建立连接前检查实际连接的地址
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout()}
+	if !global.APP_CONFIG.UserWebhook.AllowPrivateNetwork {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: requestTimeout(),
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout(),
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deliver 投递一条记录并写回结果，retry 为 false 时只尝试一次
+func deliver(webhook *userModel.UserWebhook, delivery *userModel.UserWebhookDelivery, retry bool) {
+	if !allowDelivery(webhook.UserID) {
+		delivery.Status = userModel.WebhookDeliveryRateLimited
+		delivery.Error = "超过Webhook投递频率限制"
+		saveResult(webhook, delivery)
+		return
+	}
+
+	client := newClient()
+	attempts := 1
+	if retry {
+		attempts = maxAttempts
+	}
+	for delivery.Attempts < attempts {
+		if delivery.Attempts > 0 {
+			select {
+			case <-time.After(retryDelays[delivery.Attempts-1]):
+			case <-global.APP_SHUTDOWN_CONTEXT.Done():
+				delivery.Status = userModel.WebhookDeliveryFailed
+				delivery.Error = "服务关闭，投递中止"
+				saveResult(webhook, delivery)
+				return
+			}
+		}
+		delivery.Attempts++
+		start := time.Now()
+		code, err := send(client, webhook, delivery)
+		delivery.DurationMs = time.Since(start).Milliseconds()
+		delivery.StatusCode = code
+		if err == nil {
+			delivery.Status = userModel.WebhookDeliverySuccess
+			delivery.Error = ""
+			break
+		}
+		delivery.Status = userModel.WebhookDeliveryFailed
+		delivery.Error = utils.TruncateString(err.Error(), maxErrorLength)
+		if code != 0 && code != http.StatusTooManyRequests && code < 500 {
+			break
+		}
+	}
+	if delivery.Status == userModel.WebhookDeliveryFailed {
+		global.APP_LOG.Debug("Webhook投递失败",
+			zap.Uint("webhookId", webhook.ID),
+			zap.Uint("deliveryId", delivery.ID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", delivery.Error))
+	}
+	saveResult(webhook, delivery)
+}
+
+// allowDelivery 扣减用户的投递配额，用户不存在时不投递，配额更新失败时放行
+func allowDelivery(userID uint) bool {
+	var u userModel.User
+	if err := global.APP_DB.Select("id, level").First(&u, userID).Error; err != nil {
+		return false
+	}
+	res, err := apiquota.Take(userID, u.Level, apiquota.ActionWebhookDelivery, 1)
+	if err != nil {
+		global.APP_LOG.Warn("扣减Webhook投递配额失败", zap.Uint("userId", userID), zap.Error(err))
+	}
+	return res.Allowed
+}
+
+// send 发送一次请求，2xx视为成功
+func send(client *http.Client, webhook *userModel.UserWebhook, delivery *userModel.UserWebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(global.APP_SHUTDOWN_CONTEXT, requestTimeout())
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OneClickVirt-Webhook/1.0")
+	req.Header.Set("X-OCV-Event", delivery.Event)
+	req.Header.Set("X-OCV-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-OCV-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-OCV-Signature", Sign(webhook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("接收方返回 %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return resp.StatusCode, nil
+}
+
+// saveResult 写回投递结果并更新Webhook的最近投递状态
+func saveResult(webhook *userModel.UserWebhook, delivery *userModel.UserWebhookDelivery) {
+	if err := global.APP_DB.Model(delivery).Select("status", "status_code", "error", "attempts", "duration_ms").
+		Updates(delivery).Error; err != nil {
+		global.APP_LOG.Warn("更新Webhook投递记录失败", zap.Uint("deliveryId", delivery.ID), zap.Error(err))
+	}
+	now := time.Now()
+	global.APP_DB.Model(&userModel.UserWebhook{}).Where("id = ?", webhook.ID).Updates(map[string]interface{}{
+		"last_delivery_at": now,
+		"last_status":      delivery.Status,
+		"last_error":       delivery.Error,
+	})
+}
@@ -0,0 +1,382 @@
+// Package userwebhook 用户Webhook
+// 用户注册的接收地址只收到自己实例的事件：创建完成、停止、流量达到阈值和即将到期。
+// 每次投递先写入投递记录，同一Webhook的同一去重键只投递一次；请求体以Webhook密钥做HMAC-SHA256签名，
+// 投递次数按 quota.api-limits 的 webhook-delivery 配额限制
+package userwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultMaxPerUser       = 5
+	defaultTimeoutSeconds   = 10
+	defaultRetentionDays    = 30
+	defaultTrafficThreshold = 80
+	defaultExpiringDays     = 3
+	// 即将到期事件最多提前的天数
+	maxExpiringDays = 30
+	// 投递记录中保存的错误信息最大长度
+	maxErrorLength = 512
+)
+
+// Events 用户可订阅的事件
+var Events = []string{
+	userModel.WebhookEventInstanceCreated,
+	userModel.WebhookEventInstanceStopped,
+	userModel.WebhookEventTrafficThreshold,
+	userModel.WebhookEventInstanceExpiring,
+}
+
+// Enabled 返回是否允许用户注册Webhook
+func Enabled() bool {
+	return global.APP_CONFIG.UserWebhook.Enabled
+}
+
+// MaxPerUser 返回每个用户最多可注册的Webhook数量
+func MaxPerUser() int {
+	if n := global.APP_CONFIG.UserWebhook.MaxPerUser; n > 0 {
+		return n
+	}
+	return defaultMaxPerUser
+}
+
+// requestTimeout 返回单次请求超时
+func requestTimeout() time.Duration {
+	if timeout := global.APP_CONFIG.UserWebhook.Timeout; timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return defaultTimeoutSeconds * time.Second
+}
+
+// GenerateSecret 生成签名密钥
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign 计算请求签名：HMAC-SHA256(密钥, "时间戳.请求体")，接收方用相同方式计算后比较，并拒绝时间戳过旧的请求
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizeEvents 校验并规范化订阅的事件列表
+func NormalizeEvents(events []string) (string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == "" || seen[event] {
+			continue
+		}
+		supported := false
+		for _, e := range Events {
+			if e == event {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return "", fmt.Errorf("不支持的事件: %s", event)
+		}
+		seen[event] = true
+		result = append(result, event)
+	}
+	if len(result) == 0 {
+		return "", errors.New("至少需要订阅一个事件")
+	}
+	return strings.Join(result, ","), nil
+}
+
+// ValidateURL 校验接收地址，未开启 allow-private-network 时不允许内网和本机地址
+// 域名解析结果在发起连接时再次检查
+func ValidateURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return errors.New("接收地址格式无效")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("接收地址只支持 http 和 https")
+	}
+	if u.User != nil {
+		return errors.New("接收地址不能包含用户名和密码")
+	}
+	if global.APP_CONFIG.UserWebhook.AllowPrivateNetwork {
+		return nil
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("不允许使用本机地址")
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return errors.New("不允许使用内网地址")
+	}
+	return nil
+}
+
+// publicIP 判断是否为公网地址
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	// 运营商级NAT地址 100.64.0.0/10
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
+
+// trafficThreshold 返回Webhook的流量阈值百分比
+func trafficThreshold(w *userModel.UserWebhook) int {
+	if w.TrafficThreshold <= 0 || w.TrafficThreshold > 100 {
+		return defaultTrafficThreshold
+	}
+	return w.TrafficThreshold
+}
+
+// expiringDays 返回Webhook提前发送即将到期事件的天数
+func expiringDays(w *userModel.UserWebhook) int {
+	if w.ExpiringDays <= 0 {
+		return defaultExpiringDays
+	}
+	if w.ExpiringDays > maxExpiringDays {
+		return maxExpiringDays
+	}
+	return w.ExpiringDays
+}
+
+// payload 请求体
+type payload struct {
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// instanceData 实例事件中的实例信息
+func instanceData(instance *providerModel.Instance) map[string]interface{} {
+	return map[string]interface{}{
+		"instanceId":   instance.ID,
+		"name":         instance.Name,
+		"instanceType": instance.InstanceType,
+		"status":       instance.Status,
+		"providerId":   instance.ProviderID,
+		"provider":     instance.Provider,
+		"publicIP":     instance.PublicIP,
+		"expiresAt":    instance.ExpiresAt,
+	}
+}
+
+// dispatch 向用户订阅了事件的Webhook异步投递
+// dedupKey 返回该Webhook的去重键，返回空字符串表示不需要投递
+func dispatch(userID uint, event string, instanceID uint, data interface{}, dedupKey func(w *userModel.UserWebhook) string) {
+	if !Enabled() || global.APP_DB == nil || userID == 0 {
+		return
+	}
+	var webhooks []userModel.UserWebhook
+	if err := global.APP_DB.Where("user_id = ? AND enabled = ?", userID, true).Find(&webhooks).Error; err != nil {
+		global.APP_LOG.Warn("查询用户Webhook失败", zap.Uint("userId", userID), zap.Error(err))
+		return
+	}
+	for i := range webhooks {
+		webhook := webhooks[i]
+		if !webhook.HasEvent(event) {
+			continue
+		}
+		key := dedupKey(&webhook)
+		if key == "" {
+			continue
+		}
+		delivery, err := createDelivery(&webhook, event, instanceID, key, data)
+		if err != nil {
+			global.APP_LOG.Warn("创建Webhook投递记录失败",
+				zap.Uint("webhookId", webhook.ID),
+				zap.String("event", event),
+				zap.Error(err))
+			continue
+		}
+		if delivery == nil {
+			continue
+		}
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					global.APP_LOG.Error("Webhook投递panic", zap.Any("panic", r), zap.Stack("stack"))
+				}
+			}()
+			deliver(&webhook, delivery, true)
+		}()
+	}
+}
+
+// createDelivery 写入投递记录，去重键已存在时返回nil
+func createDelivery(webhook *userModel.UserWebhook, event string, instanceID uint, key string, data interface{}) (*userModel.UserWebhookDelivery, error) {
+	body, err := json.Marshal(payload{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+	delivery := &userModel.UserWebhookDelivery{
+		WebhookID:  webhook.ID,
+		UserID:     webhook.UserID,
+		Event:      event,
+		InstanceID: instanceID,
+		DedupKey:   key,
+		Payload:    string(body),
+		Status:     userModel.WebhookDeliveryPending,
+	}
+	result := global.APP_DB.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return delivery, nil
+}
+
+// InstanceCreated 实例创建完成
+func InstanceCreated(instanceID, taskID uint) {
+	instanceEvent(instanceID, userModel.WebhookEventInstanceCreated, fmt.Sprintf("created:%d:%d", instanceID, taskID), nil)
+}
+
+// InstanceStopped 实例停止任务完成，流量超限停机时附带限制原因
+func InstanceStopped(instanceID, taskID uint) {
+	instanceEvent(instanceID, userModel.WebhookEventInstanceStopped, fmt.Sprintf("stopped:%d:%d", instanceID, taskID),
+		func(instance *providerModel.Instance, data map[string]interface{}) {
+			if instance.TrafficLimited {
+				data["trafficLimitReason"] = instance.TrafficLimitReason
+			}
+		})
+}
+
+func instanceEvent(instanceID uint, event, key string, extend func(*providerModel.Instance, map[string]interface{})) {
+	if !Enabled() || global.APP_DB == nil {
+		return
+	}
+	var instance providerModel.Instance
+	if err := global.APP_DB.First(&instance, instanceID).Error; err != nil {
+		return
+	}
+	data := instanceData(&instance)
+	if extend != nil {
+		extend(&instance, data)
+	}
+	dispatch(instance.UserID, event, instance.ID, data, func(*userModel.UserWebhook) string { return key })
+}
+
+// TrafficUsage 当月流量使用情况
+type TrafficUsage struct {
+	Scope      string // user 用户总流量，instance 单个实例流量
+	InstanceID uint   // Scope 为 instance 时的实例ID
+	UsedMB     int64
+	LimitMB    int64
+	Period     string // 统计周期，如 2026-10，同一周期同一阈值只通知一次
+}
+
+// TrafficChecked 流量检查后调用，使用百分比达到Webhook阈值时投递流量阈值事件
+func TrafficChecked(userID uint, usage TrafficUsage) {
+	if usage.LimitMB <= 0 {
+		return
+	}
+	percent := int(usage.UsedMB * 100 / usage.LimitMB)
+	data := map[string]interface{}{
+		"scope":   usage.Scope,
+		"usedMB":  usage.UsedMB,
+		"limitMB": usage.LimitMB,
+		"percent": percent,
+		"period":  usage.Period,
+	}
+	if usage.InstanceID != 0 {
+		data["instanceId"] = usage.InstanceID
+	}
+	dispatch(userID, userModel.WebhookEventTrafficThreshold, usage.InstanceID, data, func(w *userModel.UserWebhook) string {
+		threshold := trafficThreshold(w)
+		if percent < threshold {
+			return ""
+		}
+		return fmt.Sprintf("traffic:%s:%d:%s:%d", usage.Scope, usage.InstanceID, usage.Period, threshold)
+	})
+}
+
+// CheckExpiring 检查即将到期的实例并投递即将到期事件，由调度器定时调用
+func CheckExpiring() {
+	if !Enabled() || global.APP_DB == nil {
+		return
+	}
+	now := time.Now()
+	var instances []providerModel.Instance
+	err := global.APP_DB.
+		Where("expires_at IS NOT NULL AND expires_at > ? AND expires_at <= ?", now, now.AddDate(0, 0, maxExpiringDays)).
+		Where("status NOT IN ?", []string{constant.InstanceStatusDeleting, constant.InstanceStatusDeleted, constant.InstanceStatusFailed}).
+		Where("user_id IN (?)", global.APP_DB.Model(&userModel.UserWebhook{}).Select("user_id").Where("enabled = ?", true)).
+		Find(&instances).Error
+	if err != nil {
+		global.APP_LOG.Warn("查询即将到期的实例失败", zap.Error(err))
+		return
+	}
+	for i := range instances {
+		instance := &instances[i]
+		remaining := instance.ExpiresAt.Sub(now)
+		data := instanceData(instance)
+		data["remainingHours"] = int(remaining.Hours())
+		key := fmt.Sprintf("expiring:%d:%d", instance.ID, instance.ExpiresAt.Unix())
+		dispatch(instance.UserID, userModel.WebhookEventInstanceExpiring, instance.ID, data, func(w *userModel.UserWebhook) string {
+			if remaining > time.Duration(expiringDays(w))*24*time.Hour {
+				return ""
+			}
+			return key
+		})
+	}
+}
+
+// Ping 向Webhook投递一次测试事件并返回投递结果，不受事件订阅和启用状态影响
+func Ping(webhook *userModel.UserWebhook) (*userModel.UserWebhookDelivery, error) {
+	data := map[string]interface{}{"webhookId": webhook.ID, "name": webhook.Name}
+	delivery, err := createDelivery(webhook, userModel.WebhookEventPing, 0, fmt.Sprintf("ping:%d", time.Now().UnixNano()), data)
+	if err != nil {
+		return nil, err
+	}
+	deliver(webhook, delivery, false)
+	return delivery, nil
+}
+
+// Cleanup 删除超过保留天数的投递记录
+func Cleanup() {
+	if global.APP_DB == nil {
+		return
+	}
+	days := global.APP_CONFIG.UserWebhook.RetentionDays
+	if days <= 0 {
+		days = defaultRetentionDays
+	}
+	result := global.APP_DB.Where("created_at < ?", time.Now().AddDate(0, 0, -days)).Delete(&userModel.UserWebhookDelivery{})
+	if result.Error != nil {
+		global.APP_LOG.Warn("清理Webhook投递记录失败", zap.Error(result.Error))
+	} else if result.RowsAffected > 0 {
+		global.APP_LOG.Info("已清理过期的Webhook投递记录", zap.Int64("count", result.RowsAffected))
+	}
+}
@@ -0,0 +1,138 @@
+package source
+
+import (
+	"oneclickvirt/global"
+	"oneclickvirt/model/system"
+
+	"go.uber.org/zap"
+)
+
+// 安装脚本可使用的环境变量：APP_PORT_<NAME>（应用在实例内监听的端口）、APP_PASSWORD（随机生成的应用密码）、APP_HOST（访问地址）
+
+const wordpressInstallScript = `set -e
+export DEBIAN_FRONTEND=noninteractive
+apt-get update -y
+apt-get install -y apache2 mariadb-server php libapache2-mod-php php-mysql php-curl php-gd php-xml php-mbstring php-zip curl
+systemctl enable --now mariadb
+mysql -e "CREATE DATABASE IF NOT EXISTS wordpress DEFAULT CHARACTER SET utf8mb4;"
+mysql -e "CREATE USER IF NOT EXISTS 'wordpress'@'localhost' IDENTIFIED BY '${APP_PASSWORD}';"
+mysql -e "GRANT ALL PRIVILEGES ON wordpress.* TO 'wordpress'@'localhost'; FLUSH PRIVILEGES;"
+curl -fsSL https://wordpress.org/latest.tar.gz | tar -xz -C /var/www/
+chown -R www-data:www-data /var/www/wordpress
+cp /var/www/wordpress/wp-config-sample.php /var/www/wordpress/wp-config.php
+sed -i "s/database_name_here/wordpress/;s/username_here/wordpress/;s/password_here/${APP_PASSWORD}/" /var/www/wordpress/wp-config.php
+echo "Listen ${APP_PORT_HTTP}" > /etc/apache2/ports.conf
+cat > /etc/apache2/sites-available/000-default.conf <<EOF
+<VirtualHost *:${APP_PORT_HTTP}>
+    DocumentRoot /var/www/wordpress
+    <Directory /var/www/wordpress>
+        AllowOverride All
+    </Directory>
+</VirtualHost>
+EOF
+a2enmod rewrite
+systemctl enable apache2
+systemctl restart apache2
+`
+
+const codeServerInstallScript = `set -e
+curl -fsSL https://code-server.dev/install.sh | sh
+mkdir -p /root/.config/code-server
+cat > /root/.config/code-server/config.yaml <<EOF
+bind-addr: 0.0.0.0:${APP_PORT_HTTP}
+auth: password
+password: ${APP_PASSWORD}
+cert: false
+EOF
+systemctl enable --now code-server@root
+systemctl restart code-server@root
+`
+
+const minioInstallScript = `set -e
+ARCH=amd64
+case "$(uname -m)" in aarch64|arm64) ARCH=arm64 ;; esac
+curl -fsSL -o /usr/local/bin/minio https://dl.min.io/server/minio/release/linux-${ARCH}/minio
+chmod +x /usr/local/bin/minio
+mkdir -p /var/lib/minio
+cat > /etc/default/minio <<EOF
+MINIO_ROOT_USER=admin
+MINIO_ROOT_PASSWORD=${APP_PASSWORD}
+EOF
+cat > /etc/systemd/system/minio.service <<EOF
+[Unit]
+Description=MinIO
+After=network-online.target
+
+[Service]
+EnvironmentFile=/etc/default/minio
+ExecStart=/usr/local/bin/minio server /var/lib/minio --address :${APP_PORT_API} --console-address :${APP_PORT_CONSOLE}
+Restart=always
+LimitNOFILE=65536
+
+[Install]
+WantedBy=multi-user.target
+EOF
+systemctl daemon-reload
+systemctl enable --now minio
+`
+
+// initDefaultApps 初始化默认的一键应用，已存在的应用不会被覆盖
+func initDefaultApps() {
+	apps := []system.AppTemplate{
+		{
+			Slug:            "wordpress",
+			Name:            "WordPress",
+			Category:        "cms",
+			Description:     "基于 Apache + MariaDB + PHP 的 WordPress 博客/建站系统",
+			Status:          1,
+			Sort:            10,
+			OSTypes:         "debian,ubuntu",
+			MinCPU:          1,
+			MinMemoryMB:     512,
+			MinDiskMB:       5120,
+			Ports:           "http:80",
+			InstallScript:   wordpressInstallScript,
+			PostInstallInfo: "访问地址: http://${HOST}:${PORT_HTTP}\n首次访问按向导完成站点设置\n数据库: wordpress / 用户: wordpress / 密码: ${APP_PASSWORD}",
+		},
+		{
+			Slug:            "code-server",
+			Name:            "code-server",
+			Category:        "devtools",
+			Description:     "浏览器中运行的 VS Code 开发环境",
+			Status:          1,
+			Sort:            20,
+			OSTypes:         "debian,ubuntu,centos,rocky,almalinux,fedora",
+			MinCPU:          1,
+			MinMemoryMB:     1024,
+			MinDiskMB:       5120,
+			Ports:           "http:8080",
+			InstallScript:   codeServerInstallScript,
+			PostInstallInfo: "访问地址: http://${HOST}:${PORT_HTTP}\n登录密码: ${APP_PASSWORD}",
+		},
+		{
+			Slug:            "minio",
+			Name:            "MinIO",
+			Category:        "storage",
+			Description:     "兼容 S3 协议的对象存储服务",
+			Status:          1,
+			Sort:            30,
+			MinCPU:          1,
+			MinMemoryMB:     1024,
+			MinDiskMB:       10240,
+			Ports:           "api:9000,console:9001",
+			InstallScript:   minioInstallScript,
+			PostInstallInfo: "S3 API: http://${HOST}:${PORT_API}\n控制台: http://${HOST}:${PORT_CONSOLE}\n用户名: admin\n密码: ${APP_PASSWORD}",
+		},
+	}
+
+	for _, app := range apps {
+		var count int64
+		global.APP_DB.Model(&system.AppTemplate{}).Unscoped().Where("slug = ?", app.Slug).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if err := global.APP_DB.Create(&app).Error; err != nil {
+			global.APP_LOG.Warn("初始化默认应用失败", zap.String("slug", app.Slug), zap.Error(err))
+		}
+	}
+}
@@ -27,6 +27,7 @@ func InitSeedData() {
 	initDefaultAnnouncements()
 	initLevelConfigurations()
 	initOtherConfigurations()
+	initDefaultApps()
 	// OAuth2 providers are not automatically initialized
 	// Admin should configure them manually based on their needs
 }
@@ -35,6 +36,7 @@ func initDefaultRoles() {
 	roles := []auth.Role{
 		{Name: "admin", Code: "admin", Description: "系统管理员角色", Status: 1},
 		{Name: "user", Code: "user", Description: "普通用户角色", Status: 1},
+		{Name: "auditor", Code: "auditor", Description: "只读审计员角色，可查看管理数据和导出报表，不能修改", Status: 1},
 	}
 
 	for _, role := range roles {
@@ -0,0 +1,279 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/service/chaos"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/task"
+
+	_ "oneclickvirt/provider/fake"
+	_ "oneclickvirt/provider/portmapping/fake"
+
+	"gorm.io/gorm"
+)
+
+// counters Provider资源计数与用户配额的快照
+type counters struct {
+	UsedCPUCores   int
+	UsedMemory     int64
+	UsedDisk       int64
+	ContainerCount int
+	UsedQuota      int
+	PendingQuota   int
+}
+
+func snapshotCounters(t *testing.T, providerID uint) counters {
+	t.Helper()
+	var p providerModel.Provider
+	if err := global.APP_DB.First(&p, providerID).Error; err != nil {
+		t.Fatalf("读取Provider失败: %v", err)
+	}
+	var u userModel.User
+	if err := global.APP_DB.First(&u, testUserID).Error; err != nil {
+		t.Fatalf("读取用户失败: %v", err)
+	}
+	return counters{p.UsedCPUCores, p.UsedMemory, p.UsedDisk, p.ContainerCount, u.UsedQuota, u.PendingQuota}
+}
+
+// withFailurePoints 在开发环境配置下启用指定故障点
+func withFailurePoints(t *testing.T, points ...string) {
+	t.Helper()
+	saved := global.APP_CONFIG
+	global.APP_CONFIG.System.Env = "development"
+	global.APP_CONFIG.Task.FailurePoints = points
+	t.Cleanup(func() { global.APP_CONFIG = saved })
+}
+
+// runCreateTask 预留资源并通过任务引擎执行创建任务
+func runCreateTask(t *testing.T, record *providerModel.Provider, image *systemModel.SystemImage) *adminModel.Task {
+	t.Helper()
+	cpu, memory, disk, bandwidth := constant.PredefinedCPUSpecs[0], constant.PredefinedMemorySpecs[0], constant.PredefinedDiskSpecs[0], constant.PredefinedBandwidthSpecs[0]
+	sessionID := resources.GenerateSessionID()
+	if _, err := resources.GetResourceReservationService().ReserveResources(testUserID, record.ID, sessionID, "container",
+		cpu.Cores, int64(memory.SizeMB), int64(disk.SizeMB), bandwidth.SpeedMbps, 30); err != nil {
+		t.Fatalf("预留资源失败: %v", err)
+	}
+
+	taskData, _ := json.Marshal(adminModel.CreateInstanceTaskRequest{
+		ProviderId:  record.ID,
+		ImageId:     image.ID,
+		CPUId:       cpu.ID,
+		MemoryId:    memory.ID,
+		DiskId:      disk.ID,
+		BandwidthId: bandwidth.ID,
+		SessionId:   sessionID,
+	})
+	service := task.GetTaskService()
+	created, err := service.CreateTask(testUserID, &record.ID, nil, "create", string(taskData), 600)
+	if err != nil {
+		t.Fatalf("创建create任务失败: %v", err)
+	}
+	if err := service.StartTask(created.ID); err != nil {
+		t.Fatalf("启动create任务失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	finished, err := WaitTask(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("create任务未结束: %v", err)
+	}
+	return finished
+}
+
+// waitInstanceGone 等待失败实例被自动清理
+// 测试中没有运行调度器，延迟创建的删除任务在这里启动
+func waitInstanceGone(t *testing.T, instanceID uint) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Minute)
+	for time.Now().Before(deadline) {
+		var instance providerModel.Instance
+		if err := global.APP_DB.First(&instance, instanceID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return
+		}
+		var pending []adminModel.Task
+		global.APP_DB.Where("instance_id = ? AND status = ?", instanceID, adminModel.TaskStatusPending).Find(&pending)
+		for _, p := range pending {
+			_ = task.GetTaskService().StartTask(p.ID)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	t.Fatalf("实例 %d 未被清理", instanceID)
+}
+
+func assertConsistent(t *testing.T, providerID uint, before counters) {
+	t.Helper()
+	if after := snapshotCounters(t, providerID); after != before {
+		t.Fatalf("资源计数未恢复: before=%+v after=%+v", before, after)
+	}
+	issues, err := resources.VerifyConsistency(testUserID, providerID)
+	if err != nil {
+		t.Fatalf("一致性检查失败: %v", err)
+	}
+	if len(issues) > 0 {
+		t.Fatalf("资源计数与实例记录不一致: %+v", issues)
+	}
+}
+
+// TestCreateRollbackFailurePoints 在创建任务的各阶段注入故障，回滚后配额和Provider计数必须恢复
+func TestCreateRollbackFailurePoints(t *testing.T) {
+	record, image, _, err := SeedFakeProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := []string{
+		chaos.PointCreateAfterPrepare,
+		chaos.PointCreateAfterProviderCreate,
+		chaos.PointCreateBeforeQuotaConfirm,
+	}
+	for _, point := range points {
+		t.Run(point, func(t *testing.T) {
+			withFailurePoints(t, point)
+			before := snapshotCounters(t, record.ID)
+
+			finished := runCreateTask(t, record, image)
+			if finished.Status != adminModel.TaskStatusFailed {
+				t.Fatalf("任务状态 = %s，期望failed", finished.Status)
+			}
+			if finished.InstanceID == nil {
+				t.Fatal("任务未关联实例")
+			}
+			waitInstanceGone(t, *finished.InstanceID)
+			assertConsistent(t, record.ID, before)
+		})
+	}
+}
+
+// TestDeleteRollbackFailurePoint 删除任务在清理数据库前失败时，实例恢复且资源计数保持不变
+func TestDeleteRollbackFailurePoint(t *testing.T) {
+	record, image, _, err := SeedFakeProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := snapshotCounters(t, record.ID)
+
+	created := runCreateTask(t, record, image)
+	if created.Status != adminModel.TaskStatusCompleted || created.InstanceID == nil {
+		t.Fatalf("创建任务失败: %s %s", created.Status, created.ErrorMessage)
+	}
+	instanceID := *created.InstanceID
+	before := snapshotCounters(t, record.ID)
+
+	withFailurePoints(t, chaos.PointDeleteBeforeDBCleanup)
+	deleteData := adminModel.DeleteInstanceTaskRequest{InstanceId: instanceID, ProviderId: record.ID}
+	if finished := runTask(t, record, instanceID, "delete", deleteData); finished.Status != adminModel.TaskStatusFailed {
+		t.Fatalf("删除任务状态 = %s，期望failed", finished.Status)
+	}
+	assertConsistent(t, record.ID, before)
+
+	global.APP_CONFIG.Task.FailurePoints = nil
+	if finished := runTask(t, record, instanceID, "delete", deleteData); finished.Status != adminModel.TaskStatusCompleted {
+		t.Fatalf("删除任务失败: %s", finished.ErrorMessage)
+	}
+	assertConsistent(t, record.ID, initial)
+}
+
+// TestConsistencyAuditCorrectsDrift 人为制造计数偏差，审计必须报告并在自动修正后写入审计日志
+func TestConsistencyAuditCorrectsDrift(t *testing.T) {
+	record, _, _, err := SeedFakeProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := global.APP_DB.Model(&userModel.User{}).Where("id = ?", testUserID).Update("used_quota", gorm.Expr("used_quota + ?", 7)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", record.ID).Update("container_count", 3).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	audit := resources.GetConsistencyAuditService()
+	report, err := audit.Run(resources.ConsistencyAuditOptions{Trigger: "manual"})
+	if err != nil {
+		t.Fatalf("审计失败: %v", err)
+	}
+	if len(report.Issues) < 2 || len(report.CorrectedScopes) != 0 {
+		t.Fatalf("只读审计结果异常: %+v", report)
+	}
+	if issues, _ := resources.VerifyConsistency(testUserID, record.ID); len(issues) != 2 {
+		t.Fatalf("只读审计不应修改计数: %+v", issues)
+	}
+
+	var auditLogsBefore int64
+	global.APP_DB.Model(&adminModel.AuditLog{}).Where("path = ?", "consistency-audit").Count(&auditLogsBefore)
+	report, err = audit.Run(resources.ConsistencyAuditOptions{Trigger: "manual", AutoCorrect: true, Operator: "itest"})
+	if err != nil {
+		t.Fatalf("审计失败: %v", err)
+	}
+	if len(report.CorrectedScopes) < 2 {
+		t.Fatalf("偏差未修正: %+v", report)
+	}
+	if issues, _ := resources.VerifyConsistency(testUserID, record.ID); len(issues) != 0 {
+		t.Fatalf("修正后仍不一致: %+v", issues)
+	}
+	var auditLogsAfter int64
+	global.APP_DB.Model(&adminModel.AuditLog{}).Where("path = ?", "consistency-audit").Count(&auditLogsAfter)
+	if auditLogsAfter != auditLogsBefore+1 {
+		t.Fatalf("审计日志条数 %d -> %d，期望新增1条", auditLogsBefore, auditLogsAfter)
+	}
+}
+
+// TestWatchdogRollsBackStuckCreate 任务协程卡住时创建任务停留在running、实例停留在creating，
+// 看门狗超时后必须将任务标记为失败、回滚实例并恢复资源计数
+func TestWatchdogRollsBackStuckCreate(t *testing.T) {
+	record, image, _, err := SeedFakeProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotCounters(t, record.ID)
+
+	created := runCreateTask(t, record, image)
+	if created.Status != adminModel.TaskStatusCompleted || created.InstanceID == nil {
+		t.Fatalf("创建任务失败: %s %s", created.Status, created.ErrorMessage)
+	}
+
+	// 模拟任务协程卡在Provider调用中：任务仍为running且已超过超时时间，实例停留在creating，配额尚未确认
+	startedAt := time.Now().Add(-10 * time.Minute)
+	if err := global.APP_DB.Model(&adminModel.Task{}).Where("id = ?", created.ID).Updates(map[string]interface{}{
+		"status":           adminModel.TaskStatusRunning,
+		"started_at":       startedAt,
+		"completed_at":     nil,
+		"timeout_duration": 60,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := global.APP_DB.Model(&providerModel.Instance{}).Where("id = ?", *created.InstanceID).
+		Update("status", constant.InstanceStatusCreating).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := global.APP_DB.Model(&userModel.User{}).Where("id = ?", testUserID).Update("used_quota", before.UsedQuota).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if count := task.GetTaskService().EnforceTaskTimeouts(); count != 1 {
+		t.Fatalf("EnforceTaskTimeouts = %d，期望1", count)
+	}
+	var finished adminModel.Task
+	if err := global.APP_DB.First(&finished, created.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if finished.Status != adminModel.TaskStatusFailed || finished.ErrorMessage == "" {
+		t.Fatalf("任务状态 = %s (%s)，期望failed", finished.Status, finished.ErrorMessage)
+	}
+	waitInstanceGone(t, *created.InstanceID)
+	assertConsistent(t, record.ID, before)
+}
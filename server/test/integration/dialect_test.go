@@ -0,0 +1,161 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oneclickvirt/config"
+	"oneclickvirt/global"
+	monitoringModel "oneclickvirt/model/monitoring"
+	"oneclickvirt/service/auth"
+	"oneclickvirt/service/cluster"
+	"oneclickvirt/service/traffic"
+)
+
+// 以下用例覆盖按方言生成的原生SQL（UPSERT、时间截断、分批删除、保留字列名、时间运算），
+// 只需要数据库，可通过 INTEGRATION_DB_TYPE 分别在MySQL、PostgreSQL和SQLite上运行
+
+// uniqueID 生成不与其他用例冲突的ID
+func uniqueID() uint {
+	return uint(time.Now().UnixNano()%1000000000) + 1000000
+}
+
+// TestTrafficHistoryUpsert 重复聚合同一小时的Provider流量时覆盖而不是新增记录
+func TestTrafficHistoryUpsert(t *testing.T) {
+	providerID := uniqueID()
+	now := time.Now()
+	records := []monitoringModel.InstanceTrafficHistory{
+		{InstanceID: providerID + 1, ProviderID: providerID, UserID: testUserID, TotalUsed: 100},
+		{InstanceID: providerID + 2, ProviderID: providerID, UserID: testUserID, TotalUsed: 50},
+	}
+	for i := range records {
+		records[i].Year, records[i].Month, records[i].Day, records[i].Hour = now.Year(), int(now.Month()), now.Day(), now.Hour()
+		records[i].RecordTime = now
+		if err := global.APP_DB.Create(&records[i]).Error; err != nil {
+			t.Fatalf("写入实例流量历史失败: %v", err)
+		}
+	}
+
+	history := traffic.NewHistoryService()
+	for i := 0; i < 2; i++ {
+		if err := history.AggregateProviderTrafficHistory(providerID); err != nil {
+			t.Fatalf("聚合Provider流量失败: %v", err)
+		}
+	}
+	assertProviderHistory(t, providerID, now.Hour(), 150)
+
+	if err := global.APP_DB.Model(&records[0]).Update("total_used", 300).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := history.AggregateProviderTrafficHistory(providerID); err != nil {
+		t.Fatalf("聚合Provider流量失败: %v", err)
+	}
+	assertProviderHistory(t, providerID, now.Hour(), 350)
+
+	if err := history.AggregateDailyProviderTraffic(providerID, now); err != nil {
+		t.Fatalf("聚合Provider日流量失败: %v", err)
+	}
+}
+
+func assertProviderHistory(t *testing.T, providerID uint, hour int, totalUsed int64) {
+	t.Helper()
+	var rows []monitoringModel.ProviderTrafficHistory
+	if err := global.APP_DB.Where("provider_id = ? AND hour = ?", providerID, hour).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].TotalUsed != totalUsed || rows[0].InstanceCount != 2 {
+		t.Fatalf("Provider流量历史 = %+v，期望1条 total_used=%d", rows, totalUsed)
+	}
+}
+
+// TestRetentionRollup 降采样将同一小时内的5分钟记录聚合为一条并删除原始记录
+func TestRetentionRollup(t *testing.T) {
+	instanceID := uniqueID()
+	base := time.Now().AddDate(0, 0, -10).Truncate(time.Hour)
+	for i, minute := range []int{5, 10, 15} {
+		ts := base.Add(time.Duration(minute) * time.Minute)
+		record := monitoringModel.PmacctTrafficRecord{
+			InstanceID: instanceID, UserID: testUserID, ProviderID: 1, ProviderType: "fake", MappedIP: "127.0.0.1",
+			RxBytes: int64(100 * (i + 1)), TxBytes: int64(10 * (i + 1)), TotalBytes: int64(110 * (i + 1)),
+			Timestamp: ts, Year: ts.Year(), Month: int(ts.Month()), Day: ts.Day(), Hour: ts.Hour(), Minute: ts.Minute(),
+			RecordTime: ts,
+		}
+		if err := global.APP_DB.Create(&record).Error; err != nil {
+			t.Fatalf("写入pmacct记录失败: %v", err)
+		}
+	}
+
+	saved := global.APP_CONFIG.Retention
+	global.APP_CONFIG.Retention = config.Retention{
+		Tables: map[string]config.RetentionPolicy{
+			"pmacct_traffic_records": {HourlyAfterDays: 1},
+		},
+	}
+	t.Cleanup(func() { global.APP_CONFIG.Retention = saved })
+
+	if err := traffic.NewRetentionService().Run(context.Background()); err != nil {
+		t.Fatalf("执行保留策略失败: %v", err)
+	}
+
+	var rows []monitoringModel.PmacctTrafficRecord
+	if err := global.APP_DB.Where("instance_id = ?", instanceID).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Minute != 0 || rows[0].RxBytes != 300 || !rows[0].Timestamp.Equal(base) {
+		t.Fatalf("降采样结果 = %+v，期望1条小时级记录 rx_bytes=300", rows)
+	}
+}
+
+// TestJWTKeyRotation 密钥版本号基于 key 列解析，轮换后版本递增
+func TestJWTKeyRotation(t *testing.T) {
+	service := &auth.JWTKeyService{}
+	if err := service.InitializeJWTKeys(); err != nil {
+		t.Fatalf("初始化JWT密钥失败: %v", err)
+	}
+	before, err := service.GetAllKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RotateKey(); err != nil {
+		t.Fatalf("轮换JWT密钥失败: %v", err)
+	}
+	after, err := service.GetAllKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) == 0 || len(before) == 0 || maxKeyVersion(after) != maxKeyVersion(before)+1 {
+		t.Fatalf("轮换后密钥版本未递增: before=%d after=%d", maxKeyVersion(before), maxKeyVersion(after))
+	}
+}
+
+func maxKeyVersion(keys []auth.JWTKey) int {
+	version := 0
+	for _, key := range keys {
+		if key.Version > version {
+			version = key.Version
+		}
+	}
+	return version
+}
+
+// TestLeaderLease 使用数据库时间计算的租约可以获取并释放
+func TestLeaderLease(t *testing.T) {
+	saved := global.APP_CONFIG.Cluster
+	global.APP_CONFIG.Cluster.Enabled = true
+	t.Cleanup(func() { global.APP_CONFIG.Cluster = saved })
+
+	elector := cluster.GetLeaderElector()
+	elector.Start(context.Background())
+	if !elector.IsLeader() {
+		elector.Stop()
+		t.Fatal("未能获取主节点租约")
+	}
+	elector.Stop()
+	if elector.IsLeader() {
+		t.Fatal("停止后仍持有主节点租约")
+	}
+}
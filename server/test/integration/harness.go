@@ -0,0 +1,489 @@
+//go:build integration
+// +build integration
+
+// Package integration 端到端集成测试：在Docker中启动LXD/Incus守护进程作为测试节点，
+// 通过真实的SSH命令路径验证实例创建、重置、删除和端口映射等流程。
+// 使用 go test -tags integration ./test/integration/... 运行，需要本机可用的docker
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+	"oneclickvirt/initialize"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	systemModel "oneclickvirt/model/system"
+	"oneclickvirt/provider"
+	databaseService "oneclickvirt/service/database"
+	providerService "oneclickvirt/service/provider"
+	"oneclickvirt/utils"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// TestImageName 测试节点启动时预导入的镜像名，节点上的别名为 oneclickvirt_itest_container
+	TestImageName = "itest"
+
+	targetRootPassword = "oneclickvirt-itest"
+	mysqlRootPassword  = "oneclickvirt-itest"
+	readyTimeout       = 10 * time.Minute
+)
+
+// Target 一个容器化的LXD/Incus测试节点
+type Target struct {
+	Kind      string // lxd 或 incus
+	Container string // docker容器名，使用外部节点时为空
+	Host      string
+	SSHPort   int
+	Username  string
+	Password  string
+}
+
+// targetsDir 返回测试节点Dockerfile所在目录
+func targetsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "targets")
+}
+
+// docker 执行docker命令并返回去除首尾空白的输出
+func docker(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// DockerAvailable 检查本机docker是否可用
+func DockerAvailable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := docker(ctx, "info", "--format", "{{.ServerVersion}}")
+	return err == nil
+}
+
+// StartTarget 启动指定类型的测试节点
+// 设置 INTEGRATION_<KIND>_HOST 时直接使用已有节点（端口和密码分别读取 _PORT、_PASSWORD），不启动容器
+func StartTarget(ctx context.Context, kind string) (*Target, error) {
+	prefix := "INTEGRATION_" + strings.ToUpper(kind) + "_"
+	if host := os.Getenv(prefix + "HOST"); host != "" {
+		port, _ := strconv.Atoi(os.Getenv(prefix + "PORT"))
+		if port == 0 {
+			port = 22
+		}
+		target := &Target{Kind: kind, Host: host, SSHPort: port, Username: "root", Password: os.Getenv(prefix + "PASSWORD")}
+		return target, target.waitReady(ctx)
+	}
+
+	image := "oneclickvirt-itest-" + kind
+	if _, err := docker(ctx, "build", "-t", image, "-f", filepath.Join(targetsDir(), "Dockerfile."+kind), targetsDir()); err != nil {
+		return nil, fmt.Errorf("构建%s测试节点镜像失败: %w", kind, err)
+	}
+
+	name := fmt.Sprintf("oneclickvirt-itest-%s-%d", kind, time.Now().UnixNano())
+	if _, err := docker(ctx, "run", "-d", "--name", name,
+		"--privileged", "--cgroupns=host",
+		"-v", "/lib/modules:/lib/modules:ro",
+		"-e", "ROOT_PASSWORD="+targetRootPassword,
+		"-p", "127.0.0.1::22",
+		image); err != nil {
+		return nil, fmt.Errorf("启动%s测试节点失败: %w", kind, err)
+	}
+
+	target := &Target{Kind: kind, Container: name, Host: "127.0.0.1", Username: "root", Password: targetRootPassword}
+	port, err := publishedPort(ctx, name, "22/tcp")
+	if err != nil {
+		target.Stop()
+		return nil, err
+	}
+	target.SSHPort = port
+
+	if err := target.waitReady(ctx); err != nil {
+		logs, _ := docker(context.Background(), "logs", "--tail", "50", name)
+		target.Stop()
+		return nil, fmt.Errorf("%w\n容器日志:\n%s", err, logs)
+	}
+	return target, nil
+}
+
+// publishedPort 查询容器端口映射到宿主机的端口
+func publishedPort(ctx context.Context, container, port string) (int, error) {
+	out, err := docker(ctx, "port", container, port)
+	if err != nil {
+		return 0, err
+	}
+	// 输出形如 127.0.0.1:49153，可能有多行
+	line := strings.Split(out, "\n")[0]
+	_, portStr, err := net.SplitHostPort(strings.TrimSpace(line))
+	if err != nil {
+		return 0, fmt.Errorf("解析端口映射失败: %s", out)
+	}
+	return strconv.Atoi(portStr)
+}
+
+// cli 返回节点上的命令行工具名
+func (t *Target) cli() string {
+	if t.Kind == "lxd" {
+		return "lxc"
+	}
+	return "incus"
+}
+
+// waitReady 等待SSH可登录、守护进程就绪且测试镜像导入完成
+func (t *Target) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	check := fmt.Sprintf("%s image info oneclickvirt_%s_container >/dev/null", t.cli(), TestImageName)
+	var lastErr error
+	for {
+		if _, err := t.Exec(check); err == nil {
+			return nil
+		} else {
+			lastErr = err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待%s测试节点就绪超时: %v", t.Kind, lastErr)
+		case <-time.After(3 * time.Second):
+		}
+	}
+}
+
+// Exec 通过SSH在节点上执行命令
+func (t *Target) Exec(command string) (string, error) {
+	client, err := utils.NewSSHClient(utils.SSHConfig{
+		Host:           t.Host,
+		Port:           t.SSHPort,
+		Username:       t.Username,
+		Password:       t.Password,
+		ConnectTimeout: 10 * time.Second,
+		ExecuteTimeout: 2 * time.Minute,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	return client.Execute(command)
+}
+
+// DropSSH 断开节点上所有SSH连接，并在指定时长内拒绝新连接，用于模拟任务执行中SSH中断
+// 外部节点不支持此操作
+func (t *Target) DropSSH(ctx context.Context, downtime time.Duration) error {
+	if t.Container == "" {
+		return fmt.Errorf("外部节点不支持模拟SSH中断")
+	}
+	script := fmt.Sprintf("pkill sshd; sleep %d; /usr/sbin/sshd", int(downtime.Seconds()))
+	_, err := docker(ctx, "exec", "-d", t.Container, "sh", "-c", script)
+	return err
+}
+
+// Stop 删除测试节点容器
+func (t *Target) Stop() {
+	if t.Container == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := docker(ctx, "rm", "-f", "-v", t.Container); err != nil {
+		fmt.Fprintf(os.Stderr, "清理测试节点失败: %v\n", err)
+	}
+}
+
+// Database 测试数据库，使用 INTEGRATION_MYSQL_DSN 或临时启动的MySQL容器
+// INTEGRATION_DB_TYPE=postgres 时使用 INTEGRATION_POSTGRES_DSN 或临时启动的PostgreSQL容器
+// INTEGRATION_DB_TYPE=sqlite 时使用 INTEGRATION_SQLITE_PATH 或临时目录中的数据库文件，不需要docker
+type Database struct {
+	Container string
+	TempDir   string // 临时SQLite数据库所在目录
+}
+
+// DBType 返回测试使用的数据库类型，通过 INTEGRATION_DB_TYPE 指定，默认 mysql
+func DBType() string {
+	if dbType := os.Getenv("INTEGRATION_DB_TYPE"); dbType != "" {
+		return dbType
+	}
+	return "mysql"
+}
+
+// ExternalDSN 返回已有测试数据库的DSN，未设置时返回空串
+func ExternalDSN() string {
+	switch DBType() {
+	case utils.DBTypePostgres:
+		return os.Getenv("INTEGRATION_POSTGRES_DSN")
+	case utils.DBTypeSQLite:
+		return os.Getenv("INTEGRATION_SQLITE_PATH")
+	}
+	return os.Getenv("INTEGRATION_MYSQL_DSN")
+}
+
+// SetupDatabase 初始化全局日志和数据库，并完成表结构迁移
+func SetupDatabase(ctx context.Context) (*Database, error) {
+	if global.APP_LOG == nil {
+		global.APP_LOG, _ = zap.NewDevelopment()
+	}
+	if global.APP_SHUTDOWN_CONTEXT == nil {
+		global.APP_SHUTDOWN_CONTEXT, global.APP_SHUTDOWN_CANCEL = context.WithCancel(context.Background())
+	}
+
+	dbType := DBType()
+	global.APP_CONFIG.System.DbType = dbType
+	isPostgres := dbType == utils.DBTypePostgres
+	isSQLite := dbType == utils.DBTypeSQLite
+
+	database := &Database{}
+	dsn := ExternalDSN()
+	if dsn == "" {
+		var err error
+		if isSQLite {
+			dsn, err = database.tempSQLite()
+		} else if isPostgres {
+			dsn, err = database.startPostgres(ctx)
+		} else {
+			dsn, err = database.startMysql(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deadline := time.Now().Add(3 * time.Minute)
+	for {
+		dialector := mysql.Open(dsn)
+		if isSQLite {
+			dialector = databaseService.NewDialector(dbType, databaseService.ConnOptions{Dbname: dsn})
+		} else if isPostgres {
+			dialector = postgres.Open(dsn)
+		}
+		db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err == nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil && sqlDB.PingContext(ctx) == nil {
+				global.APP_DB = db
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			database.Close()
+			return nil, fmt.Errorf("连接测试数据库超时: %v", err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	initialize.RegisterTables(global.APP_DB)
+	return database, nil
+}
+
+// startMysql 临时启动MySQL容器并返回DSN
+func (d *Database) startMysql(ctx context.Context) (string, error) {
+	name := fmt.Sprintf("oneclickvirt-itest-mysql-%d", time.Now().UnixNano())
+	if _, err := docker(ctx, "run", "-d", "--name", name,
+		"-e", "MYSQL_ROOT_PASSWORD="+mysqlRootPassword,
+		"-e", "MYSQL_DATABASE=oneclickvirt",
+		"-p", "127.0.0.1::3306",
+		"mysql:8.0"); err != nil {
+		return "", fmt.Errorf("启动MySQL容器失败: %w", err)
+	}
+	d.Container = name
+	port, err := publishedPort(ctx, name, "3306/tcp")
+	if err != nil {
+		d.Close()
+		return "", err
+	}
+	return fmt.Sprintf("root:%s@tcp(127.0.0.1:%d)/oneclickvirt?charset=utf8mb4&parseTime=True&loc=UTC", mysqlRootPassword, port), nil
+}
+
+// startPostgres 临时启动PostgreSQL容器并返回DSN
+func (d *Database) startPostgres(ctx context.Context) (string, error) {
+	name := fmt.Sprintf("oneclickvirt-itest-postgres-%d", time.Now().UnixNano())
+	if _, err := docker(ctx, "run", "-d", "--name", name,
+		"-e", "POSTGRES_PASSWORD="+mysqlRootPassword,
+		"-e", "POSTGRES_DB=oneclickvirt",
+		"-p", "127.0.0.1::5432",
+		"postgres:16"); err != nil {
+		return "", fmt.Errorf("启动PostgreSQL容器失败: %w", err)
+	}
+	d.Container = name
+	port, err := publishedPort(ctx, name, "5432/tcp")
+	if err != nil {
+		d.Close()
+		return "", err
+	}
+	return fmt.Sprintf("postgres://postgres:%s@127.0.0.1:%d/oneclickvirt?sslmode=disable", mysqlRootPassword, port), nil
+}
+
+// tempSQLite 在临时目录中创建SQLite数据库并返回文件路径
+func (d *Database) tempSQLite() (string, error) {
+	dir, err := os.MkdirTemp("", "oneclickvirt-itest-sqlite-")
+	if err != nil {
+		return "", fmt.Errorf("创建SQLite临时目录失败: %w", err)
+	}
+	d.TempDir = dir
+	return filepath.Join(dir, "oneclickvirt.db"), nil
+}
+
+// Close 删除临时数据库容器或SQLite临时目录
+func (d *Database) Close() {
+	if d.TempDir != "" {
+		_ = os.RemoveAll(d.TempDir)
+	}
+	if d.Container == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, _ = docker(ctx, "rm", "-f", "-v", d.Container)
+}
+
+// SeedProvider 为测试节点写入Provider和系统镜像记录，并加载到Provider服务中
+// 强制使用SSH执行规则，覆盖SSH命令路径
+func SeedProvider(t *Target) (*providerModel.Provider, provider.Provider, error) {
+	record := providerModel.Provider{
+		Name:                  fmt.Sprintf("itest-%s-%d", t.Kind, time.Now().UnixNano()),
+		Type:                  t.Kind,
+		Endpoint:              t.Host,
+		SSHPort:               t.SSHPort,
+		Username:              t.Username,
+		Password:              t.Password,
+		Status:                "active",
+		Architecture:          "amd64",
+		NetworkType:           "nat_ipv4",
+		ExecutionRule:         "ssh_only",
+		ContainerEnabled:      true,
+		VirtualMachineEnabled: false,
+		AllowClaim:            true,
+		IPv4PortMappingMethod: "device_proxy",
+		PortRangeStart:        20000,
+		PortRangeEnd:          20999,
+		SSHConnectTimeout:     15,
+		SSHExecuteTimeout:     300,
+	}
+	if err := global.APP_DB.Create(&record).Error; err != nil {
+		return nil, nil, fmt.Errorf("创建Provider记录失败: %w", err)
+	}
+
+	// 镜像URL为空时Provider直接使用节点上预导入的镜像别名
+	image := systemModel.SystemImage{
+		Name:         TestImageName,
+		URL:          "",
+		Status:       "active",
+		ProviderType: t.Kind,
+		InstanceType: "container",
+		Architecture: "amd64",
+		OSType:       TestImageName,
+	}
+	if err := global.APP_DB.Create(&image).Error; err != nil {
+		return nil, nil, fmt.Errorf("创建系统镜像记录失败: %w", err)
+	}
+
+	service := providerService.GetProviderService()
+	if err := service.LoadProvider(record); err != nil {
+		return nil, nil, fmt.Errorf("连接Provider失败: %w", err)
+	}
+	prov, ok := service.GetProviderByID(record.ID)
+	if !ok {
+		return nil, nil, fmt.Errorf("Provider未加载")
+	}
+	return &record, prov, nil
+}
+
+// SeedFakeProvider 写入fake类型的Provider和系统镜像记录并加载，用于不依赖测试节点的任务引擎测试
+func SeedFakeProvider() (*providerModel.Provider, *systemModel.SystemImage, provider.Provider, error) {
+	record := providerModel.Provider{
+		Name:                  fmt.Sprintf("itest-fake-%d", time.Now().UnixNano()),
+		Type:                  "fake",
+		Endpoint:              "192.0.2.10",
+		SSHPort:               22,
+		Username:              "root",
+		Status:                "active",
+		Architecture:          "amd64",
+		NetworkType:           "nat_ipv4",
+		ContainerEnabled:      true,
+		VirtualMachineEnabled: false,
+		AllowClaim:            true,
+		IPv4PortMappingMethod: "fake",
+		PortRangeStart:        21000,
+		PortRangeEnd:          21999,
+	}
+	if err := global.APP_DB.Create(&record).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("创建Provider记录失败: %w", err)
+	}
+
+	image := systemModel.SystemImage{
+		Name:         TestImageName,
+		Status:       "active",
+		ProviderType: "fake",
+		InstanceType: "container",
+		Architecture: "amd64",
+		OSType:       TestImageName,
+	}
+	if err := global.APP_DB.Create(&image).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("创建系统镜像记录失败: %w", err)
+	}
+
+	service := providerService.GetProviderService()
+	if err := service.LoadProvider(record); err != nil {
+		return nil, nil, nil, fmt.Errorf("连接Provider失败: %w", err)
+	}
+	prov, ok := service.GetProviderByID(record.ID)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("Provider未加载")
+	}
+	return &record, &image, prov, nil
+}
+
+// SeedInstance 为Provider上已存在的实例写入数据库记录
+func SeedInstance(record *providerModel.Provider, inst *provider.Instance, userID uint) (*providerModel.Instance, error) {
+	instance := providerModel.Instance{
+		Name:         inst.Name,
+		ProviderID:   record.ID,
+		Provider:     record.Name,
+		UserID:       userID,
+		Status:       "running",
+		Image:        TestImageName,
+		InstanceType: "container",
+		OSType:       TestImageName,
+		PrivateIP:    inst.PrivateIP,
+		Username:     "root",
+		CPU:          1,
+		Memory:       512,
+		Disk:         2048,
+	}
+	if err := global.APP_DB.Create(&instance).Error; err != nil {
+		return nil, fmt.Errorf("创建实例记录失败: %w", err)
+	}
+	return &instance, nil
+}
+
+// WaitTask 轮询任务直到结束（完成、失败或取消）
+func WaitTask(ctx context.Context, taskID uint) (*adminModel.Task, error) {
+	for {
+		var task adminModel.Task
+		if err := global.APP_DB.First(&task, taskID).Error; err != nil {
+			return nil, err
+		}
+		switch task.Status {
+		case adminModel.TaskStatusCompleted, adminModel.TaskStatusFailed, adminModel.TaskStatusCancelled:
+			return &task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return &task, fmt.Errorf("等待任务 %d 超时，当前状态: %s", taskID, task.Status)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,316 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"oneclickvirt/constant"
+	"oneclickvirt/global"
+	adminModel "oneclickvirt/model/admin"
+	providerModel "oneclickvirt/model/provider"
+	userModel "oneclickvirt/model/user"
+	"oneclickvirt/provider"
+	"oneclickvirt/service/resources"
+	"oneclickvirt/service/task"
+	userProviderService "oneclickvirt/service/user/provider"
+	"oneclickvirt/utils"
+
+	_ "oneclickvirt/provider/incus"
+	_ "oneclickvirt/provider/lxd"
+	_ "oneclickvirt/provider/portmapping/incus"
+	_ "oneclickvirt/provider/portmapping/lxd"
+)
+
+// portMappingProvider LXD/Incus Provider公开的远程端口映射方法
+type portMappingProvider interface {
+	SetupPortMappingWithIP(ctx context.Context, instanceName string, hostPort, guestPort int, protocol, method, instanceIP string) error
+	RemovePortMapping(instanceName string, hostPort int, protocol string, method string) error
+}
+
+var testUserID uint
+
+func TestMain(m *testing.M) {
+	if !DockerAvailable() && ExternalDSN() == "" && DBType() != utils.DBTypeSQLite {
+		fmt.Println("docker不可用，跳过集成测试")
+		os.Exit(0)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	database, err := SetupDatabase(ctx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化测试数据库失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	user := userModel.User{Username: fmt.Sprintf("itest-%d", time.Now().UnixNano()), Password: "itest", Level: 5, Status: 1}
+	if err := global.APP_DB.Create(&user).Error; err != nil {
+		database.Close()
+		fmt.Fprintf(os.Stderr, "创建测试用户失败: %v\n", err)
+		os.Exit(1)
+	}
+	testUserID = user.ID
+	// 与 initializeSchedulers 一致，失败实例的延迟删除依赖全局任务服务
+	userProviderService.SetGlobalTaskService(task.GetTaskService())
+
+	code := m.Run()
+	task.GetTaskService().Shutdown()
+	database.Close()
+	os.Exit(code)
+}
+
+// targetKinds 返回需要测试的节点类型，通过 INTEGRATION_TARGETS 指定，默认 incus,lxd
+func targetKinds() []string {
+	kinds := os.Getenv("INTEGRATION_TARGETS")
+	if kinds == "" {
+		kinds = "incus,lxd"
+	}
+	return strings.Split(kinds, ",")
+}
+
+// forEachTarget 为每种节点启动独立容器并执行测试
+func forEachTarget(t *testing.T, fn func(t *testing.T, target *Target, record *providerModel.Provider, prov provider.Provider)) {
+	for _, kind := range targetKinds() {
+		kind := strings.TrimSpace(kind)
+		t.Run(kind, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+			defer cancel()
+			target, err := StartTarget(ctx, kind)
+			if err != nil {
+				t.Fatalf("启动测试节点失败: %v", err)
+			}
+			t.Cleanup(target.Stop)
+
+			record, prov, err := SeedProvider(target)
+			if err != nil {
+				t.Fatalf("初始化Provider失败: %v", err)
+			}
+			fn(t, target, record, prov)
+		})
+	}
+}
+
+// createTestInstance 在节点上创建一个测试容器
+func createTestInstance(t *testing.T, prov provider.Provider) *provider.Instance {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	name := fmt.Sprintf("itest%d", time.Now().UnixNano()%1000000)
+	if err := prov.CreateInstanceWithProgress(ctx, provider.InstanceConfig{
+		Name:         name,
+		Image:        TestImageName,
+		InstanceType: "container",
+		CPU:          "1",
+		Memory:       "512MiB",
+		Disk:         "2GiB",
+	}, nil); err != nil {
+		t.Fatalf("创建实例失败: %v", err)
+	}
+
+	inst, err := prov.GetInstance(ctx, name)
+	if err != nil {
+		t.Fatalf("查询实例失败: %v", err)
+	}
+	return inst
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	forEachTarget(t, func(t *testing.T, target *Target, record *providerModel.Provider, prov provider.Provider) {
+		ctx := context.Background()
+		inst := createTestInstance(t, prov)
+		if inst.Status != constant.InstanceStatusRunning {
+			t.Fatalf("新建实例状态 = %s, 期望 running", inst.Status)
+		}
+		if inst.PrivateIP == "" {
+			t.Fatal("新建实例未分配内网IP")
+		}
+
+		if err := prov.StopInstance(ctx, inst.Name); err != nil {
+			t.Fatalf("停止实例失败: %v", err)
+		}
+		if got, _ := prov.GetInstance(ctx, inst.Name); got == nil || got.Status != constant.InstanceStatusStopped {
+			t.Fatalf("停止后实例状态不正确: %+v", got)
+		}
+		if err := prov.StartInstance(ctx, inst.Name); err != nil {
+			t.Fatalf("启动实例失败: %v", err)
+		}
+		if err := prov.RestartInstance(ctx, inst.Name); err != nil {
+			t.Fatalf("重启实例失败: %v", err)
+		}
+		if err := prov.SetInstancePassword(ctx, inst.Name, "ItestPass123"); err != nil {
+			t.Fatalf("设置实例密码失败: %v", err)
+		}
+
+		if err := prov.DeleteInstance(ctx, inst.Name); err != nil {
+			t.Fatalf("删除实例失败: %v", err)
+		}
+		if _, err := prov.GetInstance(ctx, inst.Name); err == nil {
+			t.Fatal("删除后实例仍然存在")
+		}
+	})
+}
+
+func TestPortMapping(t *testing.T) {
+	forEachTarget(t, func(t *testing.T, target *Target, record *providerModel.Provider, prov provider.Provider) {
+		ctx := context.Background()
+		inst := createTestInstance(t, prov)
+		defer prov.DeleteInstance(ctx, inst.Name)
+
+		pm, ok := prov.(portMappingProvider)
+		if !ok {
+			t.Fatalf("%s Provider未实现端口映射方法", target.Kind)
+		}
+
+		hostPort := record.PortRangeStart + 1
+		if err := pm.SetupPortMappingWithIP(ctx, inst.Name, hostPort, 80, "tcp", "device_proxy", inst.PrivateIP); err != nil {
+			t.Fatalf("创建端口映射失败: %v", err)
+		}
+		devices, err := target.Exec(fmt.Sprintf("%s config device show %s", target.cli(), inst.Name))
+		if err != nil {
+			t.Fatalf("查询实例设备失败: %v", err)
+		}
+		if !strings.Contains(devices, fmt.Sprintf("tcp:0.0.0.0:%d", hostPort)) {
+			t.Fatalf("未找到端口 %d 的proxy设备:\n%s", hostPort, devices)
+		}
+
+		if err := pm.RemovePortMapping(inst.Name, hostPort, "tcp", "device_proxy"); err != nil {
+			t.Fatalf("删除端口映射失败: %v", err)
+		}
+		devices, _ = target.Exec(fmt.Sprintf("%s config device show %s", target.cli(), inst.Name))
+		if strings.Contains(devices, fmt.Sprintf("tcp:0.0.0.0:%d", hostPort)) {
+			t.Fatalf("端口 %d 的proxy设备未删除:\n%s", hostPort, devices)
+		}
+	})
+}
+
+// runTask 通过任务引擎执行任务并等待结束
+func runTask(t *testing.T, record *providerModel.Provider, instanceID uint, taskType string, data interface{}) *adminModel.Task {
+	t.Helper()
+	taskData, _ := json.Marshal(data)
+	service := task.GetTaskService()
+	created, err := service.CreateTask(testUserID, &record.ID, &instanceID, taskType, string(taskData), 1800)
+	if err != nil {
+		t.Fatalf("创建%s任务失败: %v", taskType, err)
+	}
+	if err := service.StartTask(created.ID); err != nil {
+		t.Fatalf("启动%s任务失败: %v", taskType, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	finished, err := WaitTask(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("%s任务未结束: %v", taskType, err)
+	}
+	return finished
+}
+
+func TestResetAndDeleteTasks(t *testing.T) {
+	forEachTarget(t, func(t *testing.T, target *Target, record *providerModel.Provider, prov provider.Provider) {
+		inst := createTestInstance(t, prov)
+		instance, err := SeedInstance(record, inst, testUserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reset := runTask(t, record, instance.ID, "reset", map[string]interface{}{
+			"instanceId": instance.ID, "providerId": record.ID, "originalStatus": instance.Status,
+		})
+		if reset.Status != adminModel.TaskStatusCompleted {
+			t.Fatalf("重置任务状态 = %s: %s", reset.Status, reset.ErrorMessage)
+		}
+
+		// 重置会以相同名称重建实例并生成新的数据库记录
+		var newInstance providerModel.Instance
+		if err := global.APP_DB.Where("name = ? AND provider_id = ?", inst.Name, record.ID).First(&newInstance).Error; err != nil {
+			t.Fatalf("查询重置后的实例失败: %v", err)
+		}
+		if _, err := prov.GetInstance(context.Background(), inst.Name); err != nil {
+			t.Fatalf("重置后节点上实例不存在: %v", err)
+		}
+
+		// 与管理员接口相同：先写入pending端口记录，再由任务在节点上创建映射
+		portService := &resources.PortMappingService{}
+		_, portReq, err := portService.CreatePortMappingWithTask(adminModel.CreatePortMappingRequest{
+			InstanceID: newInstance.ID, GuestPort: 8080, PortCount: 1, Protocol: "tcp", Description: "itest",
+		})
+		if err != nil {
+			t.Fatalf("创建端口记录失败: %v", err)
+		}
+		portTask := runTask(t, record, newInstance.ID, "create-port-mapping", portReq)
+		if portTask.Status != adminModel.TaskStatusCompleted {
+			t.Fatalf("端口映射任务状态 = %s: %s", portTask.Status, portTask.ErrorMessage)
+		}
+		var mapping providerModel.Port
+		if err := global.APP_DB.First(&mapping, portReq.PortID).Error; err != nil || mapping.Status != "active" {
+			t.Fatalf("端口映射未激活: %+v, err=%v", mapping, err)
+		}
+
+		del := runTask(t, record, newInstance.ID, "delete", adminModel.DeleteInstanceTaskRequest{
+			InstanceId: newInstance.ID, ProviderId: record.ID,
+		})
+		if del.Status != adminModel.TaskStatusCompleted {
+			t.Fatalf("删除任务状态 = %s: %s", del.Status, del.ErrorMessage)
+		}
+		if _, err := prov.GetInstance(context.Background(), inst.Name); err == nil {
+			t.Fatal("删除任务完成后节点上实例仍然存在")
+		}
+	})
+}
+
+// TestSSHDropMidTask 任务执行中断开SSH：任务必须在超时前结束，不能卡在running；
+// SSH恢复后Provider应自动重连，实例不能停留在操作中状态
+func TestSSHDropMidTask(t *testing.T) {
+	forEachTarget(t, func(t *testing.T, target *Target, record *providerModel.Provider, prov provider.Provider) {
+		if target.Container == "" {
+			t.Skip("外部节点不支持模拟SSH中断")
+		}
+		inst := createTestInstance(t, prov)
+		instance, err := SeedInstance(record, inst, testUserID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer prov.DeleteInstance(context.Background(), inst.Name)
+
+		// 任务开始后立即断开SSH 20秒
+		go func() {
+			time.Sleep(2 * time.Second)
+			if err := target.DropSSH(context.Background(), 20*time.Second); err != nil {
+				t.Errorf("模拟SSH中断失败: %v", err)
+			}
+		}()
+
+		finished := runTask(t, record, instance.ID, "restart", adminModel.InstanceOperationTaskRequest{
+			InstanceId: instance.ID, ProviderId: record.ID,
+		})
+		t.Logf("SSH中断后的重启任务状态: %s %s", finished.Status, finished.ErrorMessage)
+
+		var after providerModel.Instance
+		if err := global.APP_DB.First(&after, instance.ID).Error; err != nil {
+			t.Fatalf("查询实例失败: %v", err)
+		}
+		if constant.IsInstanceOperationInProgress(after.Status) {
+			t.Fatalf("任务结束后实例仍处于操作中状态: %s", after.Status)
+		}
+
+		// SSH恢复后，Provider应能重新执行命令
+		deadline := time.Now().Add(2 * time.Minute)
+		for {
+			if _, err := prov.ListInstances(context.Background()); err == nil {
+				break
+			} else if time.Now().After(deadline) {
+				t.Fatalf("SSH恢复后Provider未能重连: %v", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+#!/bin/sh
+# 测试节点启动脚本：启动守护进程、最小化初始化、预导入测试镜像，最后前台运行sshd
+set -e
+
+echo "root:${ROOT_PASSWORD:-oneclickvirt-itest}" | chpasswd
+mkdir -p /run/sshd
+ssh-keygen -A >/dev/null
+
+if [ "$TARGET_KIND" = "lxd" ]; then
+    CLI=lxc
+    DAEMON=$(command -v lxd || echo /usr/sbin/lxd)
+    "$DAEMON" --group root &
+    "$DAEMON" waitready --timeout=120
+    "$DAEMON" init --minimal
+else
+    CLI=incus
+    DAEMON=$(command -v incusd || echo /usr/libexec/incus/incusd)
+    "$DAEMON" --group root &
+    incus admin waitready --timeout=120
+    incus admin init --minimal
+fi
+
+# 与Provider创建实例时使用的镜像别名一致：oneclickvirt_<镜像名>_container
+ALIAS="oneclickvirt_${TEST_IMAGE_NAME:-itest}_container"
+if ! $CLI image info "$ALIAS" >/dev/null 2>&1; then
+    $CLI image copy "$TEST_IMAGE_SOURCE" local: --alias "$ALIAS"
+fi
+
+exec /usr/sbin/sshd -D -e
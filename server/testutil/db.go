@@ -0,0 +1,65 @@
+// Package testutil 单元测试共用的辅助函数，只应在 _test.go 文件中引用
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"oneclickvirt/global"
+	"oneclickvirt/service/database"
+	"oneclickvirt/utils"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// UseSQLiteDB 打开内存SQLite数据库并迁移 models，替换 global.APP_DB 和 global.APP_LOG，测试结束后恢复
+func UseSQLiteDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	return UseDB(t, sqlite.Open(":memory:"), models...)
+}
+
+// UseProductionSQLiteDB 与 UseSQLiteDB 相同，但使用生产环境的SQLite方言在临时目录建库，
+// 时间按UTC读写，MIN/MAX 等聚合表达式返回的时间文本会被转换为 time.Time
+func UseProductionSQLiteDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	return UseDB(t, database.NewDialector(utils.DBTypeSQLite, database.ConnOptions{Dbname: path}), models...)
+}
+
+// UseDB 用 dialector 打开数据库并迁移 models，替换 global.APP_DB 和 global.APP_LOG，测试结束后恢复
+func UseDB(t testing.TB, dialector gorm.Dialector, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldDB, oldLog := global.APP_DB, global.APP_LOG
+	global.APP_DB, global.APP_LOG = db, zap.NewNop()
+	t.Cleanup(func() { global.APP_DB, global.APP_LOG = oldDB, oldLog })
+	return db
+}
+
+// Seed 依次插入测试数据，任一插入失败时终止测试
+func Seed(t testing.TB, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+}
+
+// RestoreConfig 保存当前的 global.APP_CONFIG，测试结束后恢复，调用后测试可以直接修改全局配置
+func RestoreConfig(t testing.TB) {
+	t.Helper()
+	old := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = old })
+}
@@ -0,0 +1,609 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// 命令白名单模式
+const (
+	CommandGuardOff   = ""      // 不检查
+	CommandGuardLog   = "log"   // 只记录不在白名单中的命令，用于上线前观察
+	CommandGuardBlock = "block" // 拒绝执行包含不在白名单中程序的命令
+)
+
+// DefaultAllowedCommands 发往Provider宿主机的命令中预期会出现的程序和shell内建命令
+var DefaultAllowedCommands = []string{
+	// shell 内建命令
+	"[", "[[", ":", ".", "source", "test", "echo", "printf", "true", "false", "cd", "export", "read", "set", "unset",
+	"local", "declare", "let", "return", "exit", "trap", "wait", "break", "continue", "shift", "type", "hash",
+	"command", "exec", "ulimit", "umask", "bash", "sh",
+	// 虚拟化
+	"qm", "pct", "pvesh", "pvesm", "pveversion", "pveam", "pvecm", "vzdump", "qemu-img",
+	"lxc", "lxd", "incus", "docker", "virsh", "zfs", "zpool", "lvs", "vgs", "pvs", "btrfs",
+	"lxc-ls", "lxc-info", "lxc-start", "lxc-stop", "lxc-destroy", "lxc-attach", "lxc-wait", "lxc-config", "lxc-checkconfig",
+	"kubectl",
+	// 网络与防火墙
+	"ip", "iptables", "ip6tables", "iptables-save", "iptables-restore", "ip6tables-save", "ip6tables-restore",
+	"iptables-legacy", "ip6tables-legacy", "ipset", "nft", "netfilter-persistent", "ufw", "firewall-cmd", "sysctl",
+	"ss", "netstat", "ping", "ping6", "mtr", "dig", "nslookup", "host", "tc", "brctl", "bridge", "ethtool", "conntrack", "sipcalc", "socat",
+	// 下载与校验
+	"curl", "wget", "tar", "gzip", "gunzip", "xz", "unxz", "zstd", "unzip", "sha256sum", "sha512sum", "md5sum",
+	"base64", "openssl", "ssh-keygen",
+	// 文本处理
+	"cat", "grep", "egrep", "fgrep", "awk", "gawk", "sed", "cut", "tr", "head", "tail", "sort", "uniq", "wc", "xargs",
+	"tee", "jq", "tac", "column", "paste", "diff", "seq", "bc", "expr", "od", "basename", "dirname", "realpath",
+	"readlink",
+	// 文件与系统信息
+	"ls", "find", "stat", "file", "du", "df", "free", "nproc", "lscpu", "lsblk", "lshw", "blkid", "uname", "hostname",
+	"hostnamectl", "date", "uptime", "whoami", "id", "which", "getent", "ps", "pgrep", "pkill", "kill", "killall",
+	"mount", "umount", "findmnt", "mkdir", "rm", "rmdir", "mv", "cp", "ln", "touch", "chmod", "chown", "chattr",
+	"truncate", "dd", "sync", "mktemp", "install", "flock", "sleep", "timeout", "nohup", "setsid", "env", "nice",
+	"sudo", "smartctl", "nvidia-smi", "modinfo", "dmesg",
+	// 服务与软件包
+	"systemctl", "service", "journalctl", "rc-update", "rc-service", "chkconfig", "crontab", "setenforce",
+	"apt", "apt-get", "dpkg", "yum", "dnf", "rpm", "apk", "pacman", "pacman-key", "zypper", "opkg",
+	// 流量统计
+	"pmacctd", "sqlite3",
+}
+
+// CommandGuard 校验发往Provider宿主机的命令只调用预期的程序
+// 作为纵深防御，防止用户输入经由拼接的命令注入到宿主机执行
+type CommandGuard struct {
+	name    string
+	mode    string
+	allowed map[string]bool
+	denied  map[string]bool
+}
+
+// NewCommandGuard 创建命令白名单检查器，允许列表在默认列表基础上追加，拒绝列表优先于允许列表
+// mode 为空或未知值时返回nil，nil检查器不做任何检查
+func NewCommandGuard(name, mode string, allow, deny []string) *CommandGuard {
+	if mode != CommandGuardLog && mode != CommandGuardBlock {
+		return nil
+	}
+	g := &CommandGuard{
+		name:    name,
+		mode:    mode,
+		allowed: make(map[string]bool),
+		denied:  make(map[string]bool),
+	}
+	for _, list := range [][]string{DefaultAllowedCommands, allow} {
+		for _, cmd := range list {
+			if cmd = strings.TrimSpace(cmd); cmd != "" {
+				g.allowed[cmd] = true
+			}
+		}
+	}
+	for _, cmd := range deny {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			g.denied[cmd] = true
+		}
+	}
+	return g
+}
+
+// ProviderCommandGuard 根据Provider配置创建命令白名单检查器，未启用时返回nil
+func ProviderCommandGuard(p *providerModel.Provider) *CommandGuard {
+	return NewCommandGuard(p.Name, p.CommandGuardMode, p.GetCommandAllowlist(), p.GetCommandDenylist())
+}
+
+// Violations 返回命令中不允许执行的程序，无法解析的命令返回解析错误
+func (g *CommandGuard) Violations(command string) ([]string, error) {
+	binaries, err := CommandBinaries(command)
+	if err != nil {
+		return nil, err
+	}
+	var violations []string
+	for _, bin := range binaries {
+		if g.denied[bin] || !g.allowed[bin] {
+			violations = append(violations, bin)
+		}
+	}
+	return violations, nil
+}
+
+// Check 检查命令，记录模式下只输出警告，拦截模式下返回错误；nil检查器总是放行
+func (g *CommandGuard) Check(command string) error {
+	if g == nil {
+		return nil
+	}
+	violations, err := g.Violations(command)
+	if err == nil && len(violations) == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("包含未允许的程序: %s", strings.Join(violations, ", "))
+	if err != nil {
+		reason = fmt.Sprintf("无法解析: %v", err)
+	}
+	global.APP_LOG.Warn("Provider命令未通过白名单检查",
+		zap.String("provider", g.name),
+		zap.String("mode", g.mode),
+		zap.String("reason", reason),
+		zap.String("command", truncateForLog(command, 512)))
+	if g.mode == CommandGuardBlock {
+		return fmt.Errorf("命令已被白名单拦截，%s", reason)
+	}
+	return nil
+}
+
+func truncateForLog(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
+
+// 动态生成的命令名（变量或命令替换）在结果中的表示
+const dynamicCommand = "$(...)"
+
+var assignmentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\[[^]]*\])?\+?=`)
+
+// 出现在命令开头、其后才是实际命令的关键字
+var shellPrefixKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "do": true, "while": true, "until": true,
+	"!": true, "{": true, "}": true, "fi": true, "done": true, "esac": true, "time": true,
+}
+
+// 包装其他命令执行的程序，及其需要参数的选项
+var commandWrappers = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true},
+	"command": {},
+	"exec":    {"-a": true},
+	"nohup":   {},
+	"setsid":  {},
+	"nice":    {"-n": true},
+	"env":     {"-u": true},
+	"timeout": {"-s": true, "-k": true},
+	"flock":   {"-w": true, "-E": true},
+}
+
+// CommandBinaries 解析shell命令，返回其中调用的所有程序名（去除路径，按出现顺序去重）
+// 覆盖管道、命令列表、子shell、命令替换、函数定义、heredoc 以及 bash -c 中的命令；
+// 命令名来自变量或命令替换时无法静态确定，以 "$(...)" 表示
+func CommandBinaries(command string) ([]string, error) {
+	p := &shellParser{funcs: make(map[string]bool), seen: make(map[string]bool)}
+	if err := p.parse(command); err != nil {
+		return nil, err
+	}
+	return p.found, nil
+}
+
+type heredoc struct {
+	delim string
+	strip bool
+}
+
+type shellParser struct {
+	src   string
+	pos   int
+	err   error
+	found []string
+	seen  map[string]bool
+	funcs map[string]bool
+	depth int
+}
+
+// parse 解析一段完整的命令，可递归用于 bash -c 的参数
+func (p *shellParser) parse(src string) error {
+	if p.depth > 8 {
+		return fmt.Errorf("命令嵌套层数过多")
+	}
+	saved, savedPos := p.src, p.pos
+	p.src, p.pos = src, 0
+	p.depth++
+	p.parseList(0)
+	p.depth--
+	p.src, p.pos = saved, savedPos
+	return p.err
+}
+
+func (p *shellParser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (p *shellParser) peek(offset int) byte {
+	if p.pos+offset < len(p.src) {
+		return p.src[p.pos+offset]
+	}
+	return 0
+}
+
+// parseList 解析命令列表直到遇到终止符（0表示到末尾）
+func (p *shellParser) parseList(term byte) {
+	var words []string
+	var word strings.Builder
+	inWord, skipNext := false, false
+	var pending []heredoc
+
+	flushWord := func() {
+		if inWord {
+			if skipNext {
+				skipNext = false
+			} else {
+				words = append(words, word.String())
+			}
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		flushWord()
+		p.emit(words)
+		words = nil
+		skipNext = false
+	}
+
+	for p.pos < len(p.src) && p.err == nil {
+		c := p.src[p.pos]
+		switch {
+		case term != 0 && c == term:
+			p.pos++
+			endCommand()
+			return
+		case c == '\\':
+			if next := p.peek(1); next == '\n' {
+				p.pos += 2
+			} else if next != 0 {
+				word.WriteByte(next)
+				inWord = true
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+		case c == '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				p.fail("未闭合的单引号")
+				return
+			}
+			word.WriteString(p.src[p.pos+1 : p.pos+1+end])
+			inWord = true
+			p.pos += end + 2
+		case c == '"':
+			p.pos++
+			p.parseDoubleQuoted(&word)
+			inWord = true
+		case c == '`':
+			p.pos++
+			p.parseList('`')
+			word.WriteString(dynamicCommand)
+			inWord = true
+		case c == '$':
+			p.parseDollar(&word)
+			inWord = true
+		case c == '#' && !inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			flushWord()
+			p.pos++
+		case c == '\n':
+			endCommand()
+			p.pos++
+			p.skipHeredocs(pending)
+			pending = nil
+		case c == ';' || c == '|':
+			endCommand()
+			p.pos++
+		case c == '&':
+			if p.peek(1) == '>' {
+				// &> 和 &>> 重定向
+				flushWord()
+				p.pos += 2
+				if p.peek(0) == '>' {
+					p.pos++
+				}
+				skipNext = true
+			} else {
+				endCommand()
+				p.pos++
+			}
+		case c == '>' || c == '<':
+			// 重定向前的文件描述符编号（如 2>）不是命令参数
+			if inWord && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			} else {
+				flushWord()
+			}
+			if c == '<' && p.peek(1) == '<' {
+				if p.peek(2) == '<' {
+					p.pos += 3
+					skipNext = true
+					continue
+				}
+				p.pos += 2
+				strip := false
+				if p.peek(0) == '-' {
+					strip = true
+					p.pos++
+				}
+				pending = append(pending, heredoc{delim: p.readHeredocDelimiter(), strip: strip})
+				continue
+			}
+			p.pos++
+			if next := p.peek(0); next == '>' || next == '&' || next == '|' {
+				p.pos++
+			}
+			skipNext = true
+		case c == '(':
+			if !inWord && len(words) == 0 {
+				if p.peek(1) == '(' {
+					p.skipArithmetic()
+				} else {
+					p.pos++
+					p.parseList(')')
+				}
+				continue
+			}
+			if inWord && len(words) == 0 && p.peek(1) == ')' {
+				// 函数定义 name() { ... }，函数体在后续正常解析
+				p.funcs[word.String()] = true
+				word.Reset()
+				inWord = false
+				p.pos += 2
+				continue
+			}
+			if inWord && strings.HasSuffix(word.String(), "=") {
+				// 数组赋值 arr=(a b c)
+				end := strings.IndexByte(p.src[p.pos:], ')')
+				if end < 0 {
+					p.fail("未闭合的括号")
+					return
+				}
+				word.WriteString(p.src[p.pos : p.pos+end+1])
+				p.pos += end + 1
+				continue
+			}
+			endCommand()
+			p.pos++
+		case c == ')':
+			// case 语句的模式结束符
+			endCommand()
+			p.pos++
+		default:
+			word.WriteByte(c)
+			inWord = true
+			p.pos++
+		}
+	}
+	if p.err != nil {
+		return
+	}
+	endCommand()
+	if term != 0 {
+		p.fail("未闭合的命令替换或子shell")
+	}
+}
+
+// parseDoubleQuoted 解析双引号内容，其中的命令替换仍会执行
+func (p *shellParser) parseDoubleQuoted(word *strings.Builder) {
+	for p.pos < len(p.src) && p.err == nil {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return
+		case '\\':
+			next := p.peek(1)
+			switch next {
+			case '"', '\\', '$', '`':
+				word.WriteByte(next)
+			case '\n':
+			default:
+				word.WriteByte('\\')
+				if next != 0 {
+					word.WriteByte(next)
+				}
+			}
+			p.pos += 2
+		case '`':
+			p.pos++
+			p.parseList('`')
+			word.WriteString(dynamicCommand)
+		case '$':
+			p.parseDollar(word)
+		default:
+			word.WriteByte(c)
+			p.pos++
+		}
+	}
+	if p.err == nil {
+		p.fail("未闭合的双引号")
+	}
+}
+
+// parseDollar 处理 $(...)、$((...))、${...} 和普通变量
+func (p *shellParser) parseDollar(word *strings.Builder) {
+	switch p.peek(1) {
+	case '(':
+		p.pos++
+		if p.peek(1) == '(' {
+			p.skipArithmetic()
+		} else {
+			p.pos++
+			p.parseList(')')
+		}
+		word.WriteString(dynamicCommand)
+	case '{':
+		depth := 0
+		start := p.pos
+		for p.pos < len(p.src) {
+			switch p.src[p.pos] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			p.pos++
+			if depth == 0 {
+				break
+			}
+		}
+		if depth != 0 {
+			p.fail("未闭合的变量引用")
+			return
+		}
+		word.WriteString(p.src[start:p.pos])
+	default:
+		word.WriteByte('$')
+		p.pos++
+	}
+}
+
+// skipArithmetic 跳过 ((...)) 算术表达式，p.pos 指向第一个括号
+func (p *shellParser) skipArithmetic() {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+		if depth == 0 {
+			return
+		}
+	}
+	p.fail("未闭合的算术表达式")
+}
+
+// readHeredocDelimiter 读取 heredoc 结束标记，去除引号
+func (p *shellParser) readHeredocDelimiter() string {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	var delim strings.Builder
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n;|&<>()", rune(p.src[p.pos])) {
+		if c := p.src[p.pos]; c != '\'' && c != '"' && c != '\\' {
+			delim.WriteByte(c)
+		}
+		p.pos++
+	}
+	return delim.String()
+}
+
+// skipHeredocs 跳过当前行之后的 heredoc 正文，正文是数据而不是命令
+func (p *shellParser) skipHeredocs(pending []heredoc) {
+	for _, h := range pending {
+		for p.pos < len(p.src) {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			line := p.src[p.pos:]
+			if end >= 0 {
+				line = p.src[p.pos : p.pos+end]
+				p.pos += end + 1
+			} else {
+				p.pos = len(p.src)
+			}
+			if h.strip {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delim {
+				break
+			}
+		}
+	}
+}
+
+// emit 从一条简单命令的单词中找出实际执行的程序
+func (p *shellParser) emit(words []string) {
+	// command 会绕过同名函数直接执行程序
+	bypassFuncs := false
+	for len(words) > 0 {
+		w := words[0]
+		switch {
+		case assignmentPattern.MatchString(w), shellPrefixKeywords[w]:
+			words = words[1:]
+		case w == "for" || w == "case" || w == "select":
+			return
+		case w == "function":
+			if len(words) > 1 {
+				p.funcs[strings.TrimSuffix(words[1], "()")] = true
+			}
+			return
+		case commandWrappers[w] != nil:
+			p.add(w, bypassFuncs)
+			bypassFuncs = w == "command"
+			words = skipOptions(words[1:], commandWrappers[w])
+			if w == "env" {
+				for len(words) > 0 && assignmentPattern.MatchString(words[0]) {
+					words = words[1:]
+				}
+			}
+			if w == "timeout" && len(words) > 0 {
+				words = words[1:]
+			}
+			if w == "flock" && len(words) > 0 {
+				words = words[1:]
+			}
+		case w == "bash" || w == "sh":
+			p.add(w, bypassFuncs)
+			for i := 1; i < len(words)-1; i++ {
+				if opt := words[i]; strings.HasPrefix(opt, "-") && !strings.HasPrefix(opt, "--") && strings.Contains(opt, "c") {
+					if err := p.parse(words[i+1]); err != nil {
+						return
+					}
+					break
+				}
+			}
+			return
+		default:
+			p.add(w, bypassFuncs)
+			return
+		}
+	}
+}
+
+func (p *shellParser) add(name string, bypassFuncs bool) {
+	if name == "" || (p.funcs[name] && !bypassFuncs) {
+		return
+	}
+	if strings.Contains(name, "$") {
+		name = dynamicCommand
+	} else if strings.Contains(name, "/") {
+		name = path.Base(name)
+	}
+	if !p.seen[name] {
+		p.seen[name] = true
+		p.found = append(p.found, name)
+	}
+}
+
+// skipOptions 跳过包装命令的选项，withArg 中的选项会同时跳过其参数
+func skipOptions(words []string, withArg map[string]bool) []string {
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		if words[0] == "--" {
+			return words[1:]
+		}
+		if withArg[words[0]] && len(words) > 1 {
+			words = words[1:]
+		}
+		words = words[1:]
+	}
+	return words
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
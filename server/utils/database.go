@@ -51,6 +51,8 @@ func IsConnectionError(err error) bool {
 		strings.Contains(errMsg, "connection reset") ||
 		strings.Contains(errMsg, "broken pipe") ||
 		strings.Contains(errMsg, "connection lost") ||
+		strings.Contains(errMsg, "failed to connect") || // PostgreSQL(pgx)
+		strings.Contains(errMsg, "conn closed") ||
 		errors.Is(err, gorm.ErrInvalidDB)
 }
 
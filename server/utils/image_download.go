@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// ImageSourceOrigin 原始下载地址的来源标识
+const ImageSourceOrigin = "origin"
+
+// partialVerifyBytes 切换来源续传前比对的已下载末尾字节数
+const partialVerifyBytes = 64 * 1024
+
+// DownloadSource 镜像的一个下载来源
+type DownloadSource struct {
+	URL  string // 完整下载地址
+	Base string // 来源标识：镜像源前缀、CDN端点或 origin，用于记录Provider的来源偏好
+}
+
+// ImageDownloadSources 返回镜像的候选下载来源，顺序为Provider镜像源、CDN端点（useCDN时）、原始地址
+// preferred 为Provider上次成功的来源标识，存在于候选中时排在最前
+func ImageDownloadSources(originalURL string, rules []providerModel.ImageMirrorRule, useCDN bool, preferred string) []DownloadSource {
+	var sources []DownloadSource
+	seen := make(map[string]bool)
+	add := func(url, base string) {
+		if url == "" || seen[url] {
+			return
+		}
+		seen[url] = true
+		sources = append(sources, DownloadSource{URL: url, Base: base})
+	}
+
+	for _, rule := range rules {
+		if rule.Prefix == "" || !strings.HasPrefix(originalURL, rule.Prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(originalURL, rule.Prefix)
+		for _, mirror := range rule.Mirrors {
+			if mirror = strings.TrimSpace(mirror); mirror != "" {
+				add(mirror+rest, mirror)
+			}
+		}
+	}
+	if useCDN {
+		for _, endpoint := range GetCDNEndpoints() {
+			add(endpoint+originalURL, endpoint)
+		}
+	}
+	add(originalURL, ImageSourceOrigin)
+
+	if preferred == "" {
+		return sources
+	}
+	ordered := make([]DownloadSource, 0, len(sources))
+	for _, source := range sources {
+		if source.Base == preferred {
+			ordered = append(ordered, source)
+		}
+	}
+	for _, source := range sources {
+		if source.Base != preferred {
+			ordered = append(ordered, source)
+		}
+	}
+	return ordered
+}
+
+// DownloadToRemote 依次尝试各来源在宿主机上下载文件到 remotePath，支持断点续传
+// 续传前校验已下载部分与当前来源的内容一致，不一致时丢弃重新下载；返回下载成功的来源
+func DownloadToRemote(sshClient SSHExecutor, sources []DownloadSource, remotePath string) (*DownloadSource, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("没有可用的下载来源")
+	}
+	tmpPath := remotePath + ".tmp"
+	// 只有一个来源时由curl多重试几次，多个来源时尽快切换
+	retries := 2
+	if len(sources) == 1 {
+		retries = 5
+	}
+
+	var lastErr error
+	for i := range sources {
+		source := &sources[i]
+		if !verifyPartialDownload(sshClient, tmpPath, source.URL) {
+			global.APP_LOG.Warn("已下载部分与当前来源不一致，重新下载",
+				zap.String("url", TruncateString(source.URL, 100)),
+				zap.String("tmpPath", tmpPath))
+			sshClient.Execute(fmt.Sprintf("rm -f %s", ShellQuote(tmpPath)))
+		}
+
+		global.APP_LOG.Info("执行远程下载命令",
+			zap.String("url", TruncateString(source.URL, 100)),
+			zap.Int("source", i+1),
+			zap.Int("total", len(sources)))
+		curlCmd := fmt.Sprintf(
+			"curl -4 -L -f -C - --connect-timeout 30 --retry %d --retry-delay 10 --retry-max-time 0 -o %s %s",
+			retries, ShellQuote(tmpPath), ShellQuote(source.URL),
+		)
+		output, err := sshClient.Execute(curlCmd)
+		if err != nil {
+			lastErr = err
+			global.APP_LOG.Warn("远程下载失败，尝试下一个来源",
+				zap.String("url", TruncateString(source.URL, 100)),
+				zap.String("output", TruncateString(output, 500)),
+				zap.Error(err))
+			continue
+		}
+
+		if _, err := sshClient.Execute(fmt.Sprintf("mv %s %s", ShellQuote(tmpPath), ShellQuote(remotePath))); err != nil {
+			global.APP_LOG.Error("移动文件失败",
+				zap.String("tmpPath", tmpPath),
+				zap.String("remotePath", remotePath),
+				zap.Error(err))
+			return nil, fmt.Errorf("移动文件失败: %w", err)
+		}
+		global.APP_LOG.Info("远程下载成功",
+			zap.String("url", TruncateString(source.URL, 100)),
+			zap.String("source", source.Base),
+			zap.String("remotePath", remotePath))
+		return source, nil
+	}
+
+	sshClient.Execute(fmt.Sprintf("rm -f %s", ShellQuote(tmpPath)))
+	return nil, fmt.Errorf("所有下载来源均失败: %w", lastErr)
+}
+
+// verifyPartialDownload 比对已下载部分的末尾与来源同一区间的内容，没有已下载部分或内容一致时返回true
+// 来源不支持Range时返回的是文件开头，比对失败后重新下载
+func verifyPartialDownload(sshClient SSHExecutor, tmpPath, url string) bool {
+	path := ShellQuote(tmpPath)
+	cmd := fmt.Sprintf(`size=$(wc -c 2>/dev/null < %[1]s || echo 0); size=$((size+0))
+if [ "$size" -le 0 ]; then echo empty; exit 0; fi
+n=%[2]d; [ "$size" -lt "$n" ] && n=$size
+a=$(tail -c "$n" %[1]s | sha256sum | cut -d' ' -f1)
+b=$(curl -sL -k -f --max-time 60 -r "$((size-n))-$((size-1))" %[3]s 2>/dev/null | head -c "$n" | sha256sum | cut -d' ' -f1)
+[ "$a" = "$b" ] && echo match || echo mismatch`, path, partialVerifyBytes, ShellQuote(url))
+	output, err := sshClient.Execute(cmd)
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(output) != "mismatch"
+}
+
+// PreferredImageSource 返回Provider上次成功下载镜像的来源标识
+func PreferredImageSource(providerID uint) string {
+	if global.APP_DB == nil || providerID == 0 {
+		return ""
+	}
+	var provider providerModel.Provider
+	if err := global.APP_DB.Select("preferred_image_source").First(&provider, providerID).Error; err != nil {
+		return ""
+	}
+	return provider.PreferredImageSource
+}
+
+// RecordImageSource 记录Provider下载镜像成功的来源，下次下载时优先使用
+func RecordImageSource(providerID uint, base string) {
+	if global.APP_DB == nil || providerID == 0 || base == "" {
+		return
+	}
+	if err := global.APP_DB.Model(&providerModel.Provider{}).Where("id = ?", providerID).
+		Update("preferred_image_source", base).Error; err != nil {
+		global.APP_LOG.Warn("记录镜像下载来源失败", zap.Uint("providerId", providerID), zap.Error(err))
+	}
+}
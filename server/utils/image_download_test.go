@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+func TestImageDownloadSources(t *testing.T) {
+	saved := global.APP_CONFIG.CDN
+	t.Cleanup(func() { global.APP_CONFIG.CDN = saved })
+	global.APP_CONFIG.CDN.Endpoints = []string{"https://cdn1.example/"}
+	global.APP_CONFIG.CDN.BaseEndpoint = "https://cdn0.example/"
+
+	original := "https://github.com/oneclickvirt/lxd_images/releases/download/x/debian.zip"
+	rules := []providerModel.ImageMirrorRule{{Prefix: "https://github.com/", Mirrors: []string{"https://mirror.example/gh/"}}}
+
+	urls := func(sources []DownloadSource) []string {
+		var out []string
+		for _, s := range sources {
+			out = append(out, s.URL)
+		}
+		return out
+	}
+
+	got := ImageDownloadSources(original, rules, true, "")
+	want := []string{
+		"https://mirror.example/gh/oneclickvirt/lxd_images/releases/download/x/debian.zip",
+		"https://cdn1.example/" + original,
+		"https://cdn0.example/" + original,
+		original,
+	}
+	if !reflect.DeepEqual(urls(got), want) {
+		t.Errorf("sources = %v, want %v", urls(got), want)
+	}
+	if got[0].Base != "https://mirror.example/gh/" || got[3].Base != ImageSourceOrigin {
+		t.Errorf("unexpected bases: %+v", got)
+	}
+
+	got = ImageDownloadSources(original, rules, true, "https://cdn0.example/")
+	if got[0].URL != "https://cdn0.example/"+original || len(got) != 4 {
+		t.Errorf("preferred source should come first, got %v", urls(got))
+	}
+
+	got = ImageDownloadSources(original, nil, false, "https://gone.example/")
+	if !reflect.DeepEqual(urls(got), []string{original}) {
+		t.Errorf("without mirrors and CDN only the original should remain, got %v", urls(got))
+	}
+}
+
+// fakeDownloadExecutor 模拟宿主机执行：指定的地址下载失败，其余成功
+type fakeDownloadExecutor struct {
+	failing  map[string]bool
+	partial  string // verifyPartialDownload 的输出
+	commands []string
+}
+
+func (e *fakeDownloadExecutor) Execute(cmd string) (string, error) {
+	e.commands = append(e.commands, cmd)
+	if strings.Contains(cmd, "sha256sum") {
+		return e.partial, nil
+	}
+	if strings.HasPrefix(cmd, "curl ") {
+		for url := range e.failing {
+			if strings.Contains(cmd, ShellQuote(url)) {
+				return "curl: (22) 404", fmt.Errorf("exit status 22")
+			}
+		}
+	}
+	return "", nil
+}
+
+func (e *fakeDownloadExecutor) count(prefix string) int {
+	n := 0
+	for _, cmd := range e.commands {
+		if strings.HasPrefix(cmd, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDownloadToRemoteRotatesSources(t *testing.T) {
+	global.APP_LOG = zap.NewNop()
+	sources := []DownloadSource{
+		{URL: "https://a.example/img", Base: "https://a.example/"},
+		{URL: "https://b.example/img", Base: ImageSourceOrigin},
+	}
+
+	exec := &fakeDownloadExecutor{failing: map[string]bool{"https://a.example/img": true}, partial: "match"}
+	source, err := DownloadToRemote(exec, sources, "/tmp/img")
+	if err != nil || source.URL != "https://b.example/img" {
+		t.Fatalf("expected fallback to second source, got %+v, %v", source, err)
+	}
+	if exec.count("curl ") != 2 || exec.count("mv ") != 1 || exec.count("rm ") != 0 {
+		t.Errorf("unexpected commands: %q", exec.commands)
+	}
+
+	// 已下载部分与新来源不一致时先删除再下载
+	exec = &fakeDownloadExecutor{partial: "mismatch"}
+	if _, err := DownloadToRemote(exec, sources[:1], "/tmp/img"); err != nil {
+		t.Fatalf("DownloadToRemote: %v", err)
+	}
+	if exec.count("rm -f '/tmp/img.tmp'") != 1 || !strings.Contains(strings.Join(exec.commands, "\n"), "--retry 5") {
+		t.Errorf("mismatched partial file should be removed first: %q", exec.commands)
+	}
+
+	exec = &fakeDownloadExecutor{failing: map[string]bool{"https://a.example/img": true, "https://b.example/img": true}}
+	if _, err := DownloadToRemote(exec, sources, "/tmp/img"); err == nil {
+		t.Error("expected error when all sources fail")
+	}
+}
+
+func TestVerifyPartialDownloadCommandAllowed(t *testing.T) {
+	exec := &fakeDownloadExecutor{partial: "empty"}
+	if !verifyPartialDownload(exec, "/tmp/img.tmp", "https://a.example/img") {
+		t.Error("no partial file should allow download")
+	}
+	if err := NewCommandGuard("test", CommandGuardBlock, nil, nil).Check(exec.commands[0]); err != nil {
+		t.Errorf("verify command should pass the default command guard: %v", err)
+	}
+}
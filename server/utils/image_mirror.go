@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"oneclickvirt/global"
+	providerModel "oneclickvirt/model/provider"
+
+	"go.uber.org/zap"
+)
+
+// mirrorProbeBytes 检测镜像可用性时试下载的字节数
+const mirrorProbeBytes = 1024 * 1024
+
+// DownloadProbe 在宿主机上试下载的结果
+type DownloadProbe struct {
+	URL      string  `json:"url"`
+	OK       bool    `json:"ok"`
+	HTTPCode int     `json:"httpCode"`
+	Bytes    int64   `json:"bytes"`   // 实际下载字节数
+	Speed    float64 `json:"speed"`   // 平均下载速度（字节/秒）
+	Seconds  float64 `json:"seconds"` // 耗时（秒）
+	Error    string  `json:"error,omitempty"`
+}
+
+// MirrorCandidates 按规则顺序返回原始地址对应的镜像地址，没有匹配的规则时返回空
+func MirrorCandidates(originalURL string, rules []providerModel.ImageMirrorRule) []string {
+	var candidates []string
+	for _, rule := range rules {
+		if rule.Prefix == "" || !strings.HasPrefix(originalURL, rule.Prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(originalURL, rule.Prefix)
+		for _, mirror := range rule.Mirrors {
+			if mirror = strings.TrimSpace(mirror); mirror != "" {
+				candidates = append(candidates, mirror+rest)
+			}
+		}
+	}
+	return candidates
+}
+
+// GetImageMirrorURL 按Provider的镜像源规则改写下载地址
+// 返回第一个在宿主机上可以下载的镜像地址，没有匹配规则或镜像均不可用时返回空字符串，由调用方继续使用CDN或原始地址
+func GetImageMirrorURL(sshClient SSHExecutor, originalURL string, rules []providerModel.ImageMirrorRule, providerType string) string {
+	candidates := MirrorCandidates(originalURL, rules)
+	for _, candidate := range candidates {
+		probe := ProbeDownloadURL(sshClient, candidate, 10)
+		if probe.OK {
+			global.APP_LOG.Info(fmt.Sprintf("使用Provider镜像源下载%s镜像", providerType),
+				zap.String("originalURL", TruncateString(originalURL, 100)),
+				zap.String("mirrorURL", TruncateString(candidate, 100)))
+			return candidate
+		}
+		global.APP_LOG.Warn("Provider镜像源不可用，尝试下一个",
+			zap.String("mirrorURL", TruncateString(candidate, 100)),
+			zap.Int("httpCode", probe.HTTPCode),
+			zap.String("error", probe.Error))
+	}
+	if len(candidates) > 0 {
+		global.APP_LOG.Warn("Provider镜像源均不可用，回退到默认下载方式",
+			zap.String("originalURL", TruncateString(originalURL, 100)))
+	}
+	return ""
+}
+
+// ProbeDownloadURL 在宿主机上试下载地址的前1MB，检查镜像源是否可用
+func ProbeDownloadURL(sshClient SSHExecutor, url string, timeoutSeconds int) DownloadProbe {
+	return ProbeDownload(sshClient, url, mirrorProbeBytes, timeoutSeconds)
+}
+
+// ProbeDownload 在宿主机上最多下载 maxBytes 字节或 timeoutSeconds 秒，返回下载量和平均速度
+func ProbeDownload(sshClient SSHExecutor, url string, maxBytes int64, timeoutSeconds int) DownloadProbe {
+	probe := DownloadProbe{URL: url}
+	// 不支持Range的服务器会返回完整文件，由 --max-time 截断，此时curl退出码非0但仍会输出统计信息
+	cmd := fmt.Sprintf("curl -sL -k -r 0-%d --max-time %d -o /dev/null -w '%%{http_code} %%{size_download} %%{speed_download} %%{time_total}' %s 2>/dev/null || true",
+		maxBytes-1, timeoutSeconds, ShellQuote(url))
+	output, err := sshClient.Execute(cmd)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	fields := strings.Fields(output)
+	if len(fields) < 4 {
+		probe.Error = fmt.Sprintf("无法解析curl输出: %q", strings.TrimSpace(output))
+		return probe
+	}
+	probe.HTTPCode, _ = strconv.Atoi(fields[0])
+	bytes, _ := strconv.ParseFloat(fields[1], 64)
+	probe.Bytes = int64(bytes)
+	probe.Speed, _ = strconv.ParseFloat(fields[2], 64)
+	probe.Seconds, _ = strconv.ParseFloat(fields[3], 64)
+
+	switch {
+	case probe.HTTPCode == 0:
+		probe.Error = "连接失败"
+	case probe.HTTPCode != 200 && probe.HTTPCode != 206:
+		probe.Error = fmt.Sprintf("HTTP %d", probe.HTTPCode)
+	case probe.Bytes == 0:
+		probe.Error = "未下载到数据"
+	default:
+		probe.OK = true
+	}
+	return probe
+}
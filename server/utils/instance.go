@@ -14,6 +14,13 @@ func GenerateInstanceName(providerName string) string {
 	// 清理provider名称，移除特殊字符
 	cleanName := strings.ReplaceAll(strings.ToLower(providerName), " ", "-")
 	cleanName = strings.ReplaceAll(cleanName, "_", "-")
+	// 实例名会拼接到各类虚拟化命令中，只保留小写字母、数字和中划线
+	cleanName = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return -1
+	}, cleanName)
 
 	return fmt.Sprintf("%s-%s", cleanName, randomStr)
 }
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"oneclickvirt/model/common"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidListQuery 列表查询参数（排序、过滤）不合法
+var ErrInvalidListQuery = errors.New("无效的列表查询参数")
+
+// ListSpec 列表接口允许排序和过滤的字段
+// Columns 为 参数字段名 -> 数据库列名 的映射，只应包含已建索引的列
+type ListSpec struct {
+	Columns     map[string]string
+	DefaultSort string // 默认排序，格式同 PageInfo.Sort，如 -createdAt
+}
+
+// 过滤操作符 -> SQL 条件模板
+var listFilterOperators = map[string]string{
+	"eq":   "%s = ?",
+	"ne":   "%s <> ?",
+	"gt":   "%s > ?",
+	"gte":  "%s >= ?",
+	"lt":   "%s < ?",
+	"lte":  "%s <= ?",
+	"like": "%s LIKE ?",
+	"in":   "%s IN ?",
+}
+
+// ApplyListFilters 将 PageInfo.Filter 中的过滤条件应用到查询
+// 过滤格式为 字段:操作符:值，省略操作符时按 eq 处理；in 操作符的值以逗号分隔
+func ApplyListFilters(query *gorm.DB, info common.PageInfo, spec ListSpec) (*gorm.DB, error) {
+	for _, raw := range info.Filter {
+		if raw == "" {
+			continue
+		}
+		parts := strings.SplitN(raw, ":", 3)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%w: 过滤条件格式错误 %s", ErrInvalidListQuery, raw)
+		}
+		field, op, value := parts[0], "eq", parts[1]
+		if len(parts) == 3 {
+			op, value = parts[1], parts[2]
+		}
+
+		column, ok := spec.Columns[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: 不支持过滤字段 %s", ErrInvalidListQuery, field)
+		}
+		if op == "null" {
+			if value == "false" {
+				query = query.Where(column + " IS NOT NULL")
+			} else {
+				query = query.Where(column + " IS NULL")
+			}
+			continue
+		}
+		tmpl, ok := listFilterOperators[op]
+		if !ok {
+			return nil, fmt.Errorf("%w: 不支持的过滤操作符 %s", ErrInvalidListQuery, op)
+		}
+
+		switch op {
+		case "in":
+			query = query.Where(fmt.Sprintf(tmpl, column), strings.Split(value, ","))
+		case "like":
+			query = query.Where(fmt.Sprintf(tmpl, column), "%"+value+"%")
+		default:
+			query = query.Where(fmt.Sprintf(tmpl, column), value)
+		}
+	}
+	return query, nil
+}
+
+// ApplyListSort 将 PageInfo.Sort 应用到查询，未指定时使用默认排序
+func ApplyListSort(query *gorm.DB, info common.PageInfo, spec ListSpec) (*gorm.DB, error) {
+	sort := info.Sort
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		direction := "ASC"
+		if strings.HasPrefix(field, "-") {
+			direction = "DESC"
+			field = field[1:]
+		} else {
+			field = strings.TrimPrefix(field, "+")
+		}
+		column, ok := spec.Columns[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: 不支持排序字段 %s", ErrInvalidListQuery, field)
+		}
+		query = query.Order(column + " " + direction)
+	}
+	return query, nil
+}
+
+// ApplyListPage 应用分页
+func ApplyListPage(query *gorm.DB, info common.PageInfo) *gorm.DB {
+	return query.Offset(info.Offset()).Limit(info.PageSize)
+}
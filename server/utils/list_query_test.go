@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"oneclickvirt/model/common"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testListSpec = ListSpec{
+	Columns: map[string]string{
+		"status":    "status",
+		"createdAt": "created_at",
+		"name":      "name",
+	},
+	DefaultSort: "-createdAt",
+}
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.Session(&gorm.Session{DryRun: true})
+}
+
+// buildSQL 返回查询生成的SQL和参数
+func buildSQL(query *gorm.DB) (string, []interface{}) {
+	stmt := query.Table("items").Find(&[]map[string]interface{}{}).Statement
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestApplyListFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  []string
+		wantSQL string
+		vars    []interface{}
+		wantErr bool
+	}{
+		{name: "默认eq", filter: []string{"status:running"}, wantSQL: "SELECT * FROM `items` WHERE status = ?", vars: []interface{}{"running"}},
+		{name: "in", filter: []string{"status:in:running,stopped"}, wantSQL: "SELECT * FROM `items` WHERE status IN (?,?)", vars: []interface{}{"running", "stopped"}},
+		{name: "like", filter: []string{"name:like:web"}, wantSQL: "SELECT * FROM `items` WHERE name LIKE ?", vars: []interface{}{"%web%"}},
+		{name: "null", filter: []string{"createdAt:null:false"}, wantSQL: "SELECT * FROM `items` WHERE created_at IS NOT NULL"},
+		{name: "值中含冒号", filter: []string{"createdAt:gte:2024-03-01 08:00:00"}, wantSQL: "SELECT * FROM `items` WHERE created_at >= ?", vars: []interface{}{"2024-03-01 08:00:00"}},
+		{name: "多个条件", filter: []string{"status:ne:deleted", "", "name:web"}, wantSQL: "SELECT * FROM `items` WHERE status <> ? AND name = ?", vars: []interface{}{"deleted", "web"}},
+		{name: "未允许的字段", filter: []string{"password:secret"}, wantErr: true},
+		{name: "以列名代替字段名", filter: []string{"created_at:gt:1"}, wantErr: true},
+		{name: "注入字段", filter: []string{"status = 1 OR 1=1 --:x"}, wantErr: true},
+		{name: "未知操作符", filter: []string{"status:regex:.*"}, wantErr: true},
+		{name: "格式错误", filter: []string{"status"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ApplyListFilters(dryRunDB(t), common.PageInfo{Filter: tt.filter}, testListSpec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidListQuery) {
+					t.Fatalf("应拒绝 %v，得到 %v", tt.filter, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sql, vars := buildSQL(query)
+			if sql != tt.wantSQL {
+				t.Errorf("SQL = %s, want %s", sql, tt.wantSQL)
+			}
+			if len(vars) != len(tt.vars) || (len(vars) > 0 && !reflect.DeepEqual(vars, tt.vars)) {
+				t.Errorf("参数 = %v, want %v", vars, tt.vars)
+			}
+		})
+	}
+}
+
+func TestApplyListSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		wantSQL string
+		wantErr bool
+	}{
+		{name: "默认排序", sort: "", wantSQL: "SELECT * FROM `items` ORDER BY created_at DESC"},
+		{name: "多字段", sort: "status, -createdAt", wantSQL: "SELECT * FROM `items` ORDER BY status ASC,created_at DESC"},
+		{name: "显式升序", sort: "+name", wantSQL: "SELECT * FROM `items` ORDER BY name ASC"},
+		{name: "未允许的字段", sort: "-password", wantErr: true},
+		{name: "注入排序", sort: "name; DROP TABLE users", wantErr: true},
+		{name: "部分字段不允许", sort: "name,secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ApplyListSort(dryRunDB(t), common.PageInfo{Sort: tt.sort}, testListSpec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidListQuery) {
+					t.Fatalf("应拒绝 %q，得到 %v", tt.sort, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql, _ := buildSQL(query); sql != tt.wantSQL {
+				t.Errorf("SQL = %s, want %s", sql, tt.wantSQL)
+			}
+		})
+	}
+}
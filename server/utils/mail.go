@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"oneclickvirt/global"
+)
+
+// ErrMailNotConfigured 未配置SMTP服务器时发送邮件返回的错误
+var ErrMailNotConfigured = errors.New("邮件服务未配置")
+
+// MailConfigured 是否已配置SMTP服务器，通知类调用方可据此提前跳过收件人查询和正文拼装
+func MailConfigured() bool {
+	return global.APP_CONFIG.Auth.EmailSMTPHost != ""
+}
+
+// SendMail 通过配置的SMTP服务发送HTML邮件，未配置SMTP服务器时返回 ErrMailNotConfigured
+func SendMail(to, subject, body string) error {
+	if !MailConfigured() {
+		return ErrMailNotConfigured
+	}
+	config := global.APP_CONFIG.Auth
+	auth := smtp.PlainAuth("", config.EmailUsername, config.EmailPassword, config.EmailSMTPHost)
+	msg := fmt.Sprintf("To: %s\r\nSubject: %s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s", to, subject, body)
+	return smtp.SendMail(
+		fmt.Sprintf("%s:%d", config.EmailSMTPHost, config.EmailSMTPPort),
+		auth,
+		config.EmailUsername,
+		[]string{to},
+		[]byte(msg),
+	)
+}
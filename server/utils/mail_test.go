@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"oneclickvirt/global"
+)
+
+func TestSendMailNotConfigured(t *testing.T) {
+	oldConfig := global.APP_CONFIG
+	t.Cleanup(func() { global.APP_CONFIG = oldConfig })
+	global.APP_CONFIG.Auth.EmailSMTPHost = ""
+
+	if MailConfigured() {
+		t.Fatal("未配置SMTP服务器时 MailConfigured 应返回false")
+	}
+	if err := SendMail("user@example.com", "主题", "正文"); !errors.Is(err, ErrMailNotConfigured) {
+		t.Fatalf("未配置SMTP服务器时应返回 ErrMailNotConfigured, got %v", err)
+	}
+}
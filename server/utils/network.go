@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -91,6 +92,45 @@ func ValidatePortRange(startPort, portCount int) error {
 	return nil
 }
 
+// interfaceNamePattern 网卡名称格式，Linux网卡名最长15个字符
+var interfaceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]{1,15}$`)
+
+// IsValidInterfaceName 检查网卡名称是否合法，可安全拼接到shell命令中
+func IsValidInterfaceName(name string) bool {
+	return interfaceNamePattern.MatchString(name)
+}
+
+// DNATAddCommand 生成添加端口映射DNAT规则的命令，iface为空时不限定入口网卡
+// rule为PREROUTING链中-p之后的匹配与动作部分，如 "-p tcp --dport 20000 -j DNAT --to-destination 10.0.0.2:22"
+func DNATAddCommand(iface, rule string) string {
+	if iface == "" {
+		return "iptables -t nat -A PREROUTING " + rule
+	}
+	return fmt.Sprintf("iptables -t nat -A PREROUTING -i %s %s", iface, rule)
+}
+
+// DNATDeleteCommand 生成删除端口映射DNAT规则的命令
+// 依次尝试各入口网卡（空字符串表示不限定网卡），以便删除修改公网网卡配置之前创建的规则
+func DNATDeleteCommand(rule string, ifaces ...string) string {
+	if len(ifaces) == 0 {
+		ifaces = []string{""}
+	}
+	seen := make(map[string]bool, len(ifaces))
+	var cmds []string
+	for _, iface := range ifaces {
+		if seen[iface] {
+			continue
+		}
+		seen[iface] = true
+		if iface == "" {
+			cmds = append(cmds, "iptables -t nat -D PREROUTING "+rule)
+		} else {
+			cmds = append(cmds, fmt.Sprintf("iptables -t nat -D PREROUTING -i %s %s", iface, rule))
+		}
+	}
+	return strings.Join(cmds, " 2>/dev/null || ")
+}
+
 // CheckPortAvailability 检查指定主机的端口是否可用（未被占用）
 // 使用TCP连接测试，如果能连接成功说明端口被占用（不可用）
 // 返回true表示端口可用（未被占用），false表示端口不可用（已被占用）
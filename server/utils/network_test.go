@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestIsValidInterfaceName(t *testing.T) {
+	for _, name := range []string{"eth0", "vmbr0", "enp3s0f1", "bond0.100", "veth1@if2"} {
+		if !IsValidInterfaceName(name) {
+			t.Errorf("IsValidInterfaceName(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "eth0; reboot", "a b", "averyveryverylongname", "$(id)"} {
+		if IsValidInterfaceName(name) {
+			t.Errorf("IsValidInterfaceName(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestDNATCommands(t *testing.T) {
+	rule := "-p tcp --dport 20000 -j DNAT --to-destination 10.0.0.2:22"
+	if got, want := DNATAddCommand("", rule), "iptables -t nat -A PREROUTING "+rule; got != want {
+		t.Errorf("DNATAddCommand without iface = %q, want %q", got, want)
+	}
+	if got, want := DNATAddCommand("eth1", rule), "iptables -t nat -A PREROUTING -i eth1 "+rule; got != want {
+		t.Errorf("DNATAddCommand with iface = %q, want %q", got, want)
+	}
+
+	want := "iptables -t nat -D PREROUTING -i eth1 " + rule + " 2>/dev/null || iptables -t nat -D PREROUTING " + rule
+	if got := DNATDeleteCommand(rule, "eth1", "", "eth1"); got != want {
+		t.Errorf("DNATDeleteCommand = %q, want %q", got, want)
+	}
+	if got, want := DNATDeleteCommand(rule), "iptables -t nat -D PREROUTING "+rule; got != want {
+		t.Errorf("DNATDeleteCommand without iface = %q, want %q", got, want)
+	}
+}
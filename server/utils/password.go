@@ -160,12 +160,32 @@ func hasRepeatingPattern(password string, maxRepeat int) bool {
 	return false
 }
 
+// generatedPasswordRules 生成密码自检的规则，不满足时重新生成，避免随机出现连续或重复字符
+var generatedPasswordRules = PasswordStrengthConfig{
+	MinLength:        8,
+	RequireUpperCase: true,
+	RequireLowerCase: true,
+	RequireDigit:     true,
+	ForbidCommon:     true,
+}
+
 // GenerateStrongPassword 生成符合策略的强密码（仅包含数字和大小写英文字母）
 func GenerateStrongPassword(length int) string {
 	if length < 8 {
 		length = 8
 	}
 
+	var password string
+	for i := 0; i < maxGeneratePasswordTries; i++ {
+		password = generateStrongPasswordOnce(length)
+		if ValidatePasswordStrength(password, generatedPasswordRules) == nil {
+			break
+		}
+	}
+	return password
+}
+
+func generateStrongPasswordOnce(length int) string {
 	const (
 		uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 		lowercase = "abcdefghijklmnopqrstuvwxyz"
@@ -194,15 +214,27 @@ func GenerateStrongPassword(length int) string {
 	return string(password)
 }
 
-// GenerateInstancePassword 为容器/虚拟机生成随机密码（小写英文开头，后面随机小写英文和数字混合，长度不低于8位）
+// GenerateInstancePassword 为容器/虚拟机生成随机密码（小写英文开头，后面随机小写英文和数字混合）
+// 长度由密码策略的 instance-password-length 决定，不低于8位，出现连续或重复字符时重新生成
 func GenerateInstancePassword() string {
+	length := InstancePasswordLength()
+	var password string
+	for i := 0; i < maxGeneratePasswordTries; i++ {
+		password = generateInstancePasswordOnce(length)
+		if !hasRepeatingPattern(password, 4) {
+			break
+		}
+	}
+	return password
+}
+
+func generateInstancePasswordOnce(length int) string {
 	const (
 		lowercase = "abcdefghijklmnopqrstuvwxyz"
 		digits    = "0123456789"
 	)
 
 	all := lowercase + digits
-	length := 12 // 默认12位长度
 	password := make([]byte, length)
 
 	// 确保首字符是小写英文字母
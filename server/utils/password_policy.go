@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"oneclickvirt/global"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBreachAPI         = "https://api.pwnedpasswords.com/range/"
+	defaultGeneratedLength   = 12
+	minGeneratedLength       = 8
+	minPolicyPasswordLength  = 6
+	breachCheckTimeout       = 5 * time.Second
+	maxGeneratePasswordTries = 50
+)
+
+// CurrentPasswordPolicy 返回当前生效的用户密码策略，未启用自定义策略时使用 DefaultPasswordPolicy
+func CurrentPasswordPolicy() PasswordStrengthConfig {
+	cfg := global.APP_CONFIG.PasswordPolicy
+	if !cfg.Enabled {
+		return DefaultPasswordPolicy
+	}
+	minLength := cfg.MinLength
+	if minLength == 0 {
+		minLength = DefaultPasswordPolicy.MinLength
+	}
+	if minLength < minPolicyPasswordLength {
+		minLength = minPolicyPasswordLength
+	}
+	return PasswordStrengthConfig{
+		MinLength:        minLength,
+		RequireUpperCase: cfg.RequireUpper,
+		RequireLowerCase: cfg.RequireLower,
+		RequireDigit:     cfg.RequireDigit,
+		RequireSpecial:   cfg.RequireSpecial,
+		ForbidCommon:     true,
+		ForbidPersonal:   true,
+	}
+}
+
+// ValidateUserPassword 按当前密码策略校验用户设置的密码，启用泄露检查时还会查询泄露库
+// 泄露库不可用时只记录日志，不阻止用户设置密码
+func ValidateUserPassword(password string, username ...string) error {
+	if err := ValidatePasswordStrength(password, CurrentPasswordPolicy(), username...); err != nil {
+		return err
+	}
+	cfg := global.APP_CONFIG.PasswordPolicy
+	if !cfg.Enabled || !cfg.BreachCheck {
+		return nil
+	}
+	breached, err := IsPasswordBreached(password, cfg.BreachAPI)
+	if err != nil {
+		global.APP_LOG.Warn("密码泄露库查询失败，跳过检查", zap.Error(err))
+		return nil
+	}
+	if breached {
+		return fmt.Errorf("该密码已出现在公开泄露的密码库中，请更换其他密码")
+	}
+	return nil
+}
+
+// IsPasswordBreached 通过k-anonymity接口检查密码是否出现在泄露库中，只发送SHA-1的前5位
+func IsPasswordBreached(password, apiURL string) (bool, error) {
+	if apiURL == "" {
+		apiURL = defaultBreachAPI
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	ctx, cancel := context.WithTimeout(context.Background(), breachCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// 请求填充响应，避免通过响应大小推断查询的前缀
+	req.Header.Set("Add-Padding", "true")
+	resp, err := GetDefaultHTTPClient().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("泄露库返回状态码 %d", resp.StatusCode)
+	}
+	return matchBreachedSuffix(bufio.NewScanner(resp.Body), suffix)
+}
+
+// matchBreachedSuffix 在接口返回的 "后缀:次数" 列表中查找哈希后缀，次数为0的是填充条目
+func matchBreachedSuffix(scanner *bufio.Scanner, suffix string) (bool, error) {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hashSuffix, count, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(hashSuffix, suffix) {
+			continue
+		}
+		return strings.TrimSpace(count) != "0", nil
+	}
+	return false, scanner.Err()
+}
+
+// GeneratedPasswordPolicy 系统生成的用户密码需要满足的策略，生成的密码只含字母和数字，因此不要求特殊字符
+func GeneratedPasswordPolicy() PasswordStrengthConfig {
+	policy := CurrentPasswordPolicy()
+	policy.RequireUpperCase = true
+	policy.RequireLowerCase = true
+	policy.RequireDigit = true
+	policy.RequireSpecial = false
+	policy.MinLength = minGeneratedLength
+	return policy
+}
+
+// GeneratedPasswordLength 系统为用户生成的密码长度，不低于8位且不低于策略的最小长度
+func GeneratedPasswordLength() int {
+	length := global.APP_CONFIG.PasswordPolicy.GeneratedLength
+	if length == 0 {
+		length = defaultGeneratedLength
+	}
+	if minLength := CurrentPasswordPolicy().MinLength; length < minLength {
+		length = minLength
+	}
+	if length < minGeneratedLength {
+		length = minGeneratedLength
+	}
+	return length
+}
+
+// InstancePasswordLength 系统为实例生成的密码长度，不低于8位
+func InstancePasswordLength() int {
+	length := global.APP_CONFIG.PasswordPolicy.InstancePasswordLength
+	if length == 0 {
+		length = defaultGeneratedLength
+	}
+	if length < minGeneratedLength {
+		length = minGeneratedLength
+	}
+	return length
+}
+
+// GenerateUserPassword 按配置的长度生成用户密码
+func GenerateUserPassword() string {
+	return GenerateStrongPassword(GeneratedPasswordLength())
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestMatchBreachedSuffix(t *testing.T) {
+	body := "0018A45C4D1DEF81644B54AB7F969B88D65:3\r\n00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n011053FD0102E94D6AE2F8B83D76FAF94F6:12\r\n"
+	cases := []struct {
+		suffix string
+		want   bool
+	}{
+		{"0018A45C4D1DEF81644B54AB7F969B88D65", true},
+		{"011053fd0102e94d6ae2f8b83d76faf94f6", true},
+		{"00D4F6E8FA6EECAD2A3AA415EEC418D38EC", false}, // 填充条目
+		{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", false},
+	}
+	for _, tc := range cases {
+		got, err := matchBreachedSuffix(bufio.NewScanner(strings.NewReader(body)), tc.suffix)
+		if err != nil || got != tc.want {
+			t.Errorf("matchBreachedSuffix(%s) = %v, %v, want %v", tc.suffix, got, err, tc.want)
+		}
+	}
+}
+
+func TestGeneratedPasswordsPassRules(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		if p := GenerateStrongPassword(12); len(p) != 12 || ValidatePasswordStrength(p, generatedPasswordRules) != nil {
+			t.Fatalf("GenerateStrongPassword produced %q", p)
+		}
+		if p := GenerateInstancePassword(); len(p) != 12 || hasRepeatingPattern(p, 4) {
+			t.Fatalf("GenerateInstancePassword produced %q", p)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import "strings"
+
+// ShellQuote 将参数转义为可以安全拼接到shell命令中的单个参数
+// 始终使用单引号包裹，参数中的单引号替换为 '\”，引号内的 $、`、\、空格等都不会被shell解析
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShellJoin 转义每个参数后用空格连接，用于拼接命令的参数列表
+func ShellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
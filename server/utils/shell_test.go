@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// shellEcho 通过真实的shell展开参数，返回shell看到的参数列表
+func shellEcho(t *testing.T, quoted string) []string {
+	t.Helper()
+	out, err := exec.Command("sh", "-c", `for a in `+quoted+`; do printf '%s\0' "$a"; done`).Output()
+	if err != nil {
+		t.Fatalf("sh failed for %s: %v", quoted, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+}
+
+func FuzzShellQuote(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"web-01",
+		"it's",
+		`a"b`,
+		"$(touch /tmp/pwned)",
+		"`id`",
+		"x; rm -rf /",
+		"line1\nline2",
+		"'$HOME'\\",
+		"*",
+		"https://example.com/a b?x=1&y='2'",
+	} {
+		f.Add(seed)
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		f.Skip("sh not available")
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		// shell参数不能包含NUL，非UTF-8输入与转义逻辑无关
+		if s == "" || strings.ContainsRune(s, 0) || !utf8.ValidString(s) {
+			return
+		}
+		got := shellEcho(t, ShellQuote(s))
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("ShellQuote(%q) expanded to %q", s, got)
+		}
+	})
+}
+
+func TestShellJoin(t *testing.T) {
+	args := []string{"vm'1", "limits.memory", "$(reboot) 512MB"}
+	got := shellEcho(t, ShellJoin(args...))
+	if strings.Join(got, "|") != strings.Join(args, "|") || len(got) != len(args) {
+		t.Fatalf("ShellJoin expanded to %q", got)
+	}
+}
+
+func TestGenerateInstanceName(t *testing.T) {
+	name := GenerateInstanceName("My Node_1'; reboot")
+	if !strings.HasPrefix(name, "my-node-1-reboot-") {
+		t.Fatalf("GenerateInstanceName = %q", name)
+	}
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// System.DbType 取值，与 gorm Dialector 名称一致
+const (
+	DBTypePostgres = "postgres"
+	DBTypeSQLite   = "sqlite"
+)
+
+// SQLiteTimeLayout SQLite中时间的存储格式（驱动参数 _time_format=sqlite），带时区偏移，
+// 驱动写入前统一转换为UTC，可按字符串比较，SQLite日期函数也能正确解析
+const SQLiteTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// 原生SQL的方言差异集中在这里处理，业务代码中的原生SQL只能使用两种数据库都支持的语法，
+// 其余部分（引号、UPSERT、时间函数、类型转换等）通过以下函数生成
+
+// IsPostgres 判断连接是否为PostgreSQL
+func IsPostgres(db *gorm.DB) bool {
+	return db != nil && db.Dialector != nil && db.Dialector.Name() == DBTypePostgres
+}
+
+// IsSQLite 判断连接是否为SQLite
+func IsSQLite(db *gorm.DB) bool {
+	return db != nil && db.Dialector != nil && db.Dialector.Name() == DBTypeSQLite
+}
+
+// QuoteIdent 按方言为列名/表名加引号（MySQL反引号，PostgreSQL/SQLite双引号），用于 key 等保留字
+func QuoteIdent(db *gorm.DB, name string) string {
+	if IsPostgres(db) || IsSQLite(db) {
+		return `"` + name + `"`
+	}
+	return "`" + name + "`"
+}
+
+// SQLUpsert 生成UPSERT冲突子句，拼接在 INSERT 语句末尾
+// conflictColumns 为冲突的唯一索引列（仅PostgreSQL需要），assignments 为 "列 = 表达式"，
+// 表达式中引用待插入值使用 SQLInserted，引用已有值使用 "表名.列名"
+func SQLUpsert(db *gorm.DB, conflictColumns []string, assignments ...string) string {
+	if IsPostgres(db) || IsSQLite(db) {
+		return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s",
+			strings.Join(conflictColumns, ", "), strings.Join(assignments, ", "))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+// SQLInserted 引用UPSERT中待插入行的列值：MySQL VALUES(col)，PostgreSQL/SQLite EXCLUDED.col
+func SQLInserted(db *gorm.DB, column string) string {
+	if IsPostgres(db) || IsSQLite(db) {
+		return "EXCLUDED." + column
+	}
+	return "VALUES(" + column + ")"
+}
+
+// SQLInsertIgnore 生成忽略唯一键冲突的插入语句，body 为 "表名 (列...) VALUES ..." 部分
+func SQLInsertIgnore(db *gorm.DB, body string) string {
+	if IsPostgres(db) || IsSQLite(db) {
+		return "INSERT INTO " + body + " ON CONFLICT DO NOTHING"
+	}
+	return "INSERT IGNORE INTO " + body
+}
+
+// SQLTruncTime 将时间列截断到天(day)或小时(hour)
+func SQLTruncTime(db *gorm.DB, column, unit string) string {
+	if IsPostgres(db) {
+		return fmt.Sprintf("date_trunc('%s', %s)", unit, column)
+	}
+	if IsSQLite(db) {
+		// SQLite日期函数会把带时区的时间转换为UTC，这里直接截取字符串并保留原时区偏移
+		if unit == "hour" {
+			return fmt.Sprintf("(substr(%[1]s, 1, 13) || ':00:00' || substr(%[1]s, -6))", column)
+		}
+		return fmt.Sprintf("(substr(%[1]s, 1, 10) || ' 00:00:00' || substr(%[1]s, -6))", column)
+	}
+	if unit == "hour" {
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00:00')", column)
+	}
+	return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d 00:00:00')", column)
+}
+
+// SQLMinutesSince 计算时间列距当前时间的分钟数
+func SQLMinutesSince(db *gorm.DB, column string) string {
+	if IsPostgres(db) {
+		return fmt.Sprintf("FLOOR(EXTRACT(EPOCH FROM (NOW() - %s)) / 60)", column)
+	}
+	if IsSQLite(db) {
+		return fmt.Sprintf("CAST((julianday('now') - julianday(%s)) * 1440 AS INTEGER)", column)
+	}
+	return fmt.Sprintf("TIMESTAMPDIFF(MINUTE, %s, NOW())", column)
+}
+
+// SQLNow 当前时间
+func SQLNow(db *gorm.DB) string {
+	if IsSQLite(db) {
+		return sqliteUTCTime("")
+	}
+	return "NOW()"
+}
+
+// SQLNowPlusSeconds 当前时间加上参数指定的秒数，占用一个 ? 参数
+func SQLNowPlusSeconds(db *gorm.DB) string {
+	if IsPostgres(db) {
+		return "NOW() + CAST(? AS INTEGER) * INTERVAL '1 second'"
+	}
+	if IsSQLite(db) {
+		return sqliteUTCTime(", CAST(? AS INTEGER) || ' seconds'")
+	}
+	return "NOW() + INTERVAL ? SECOND"
+}
+
+// sqliteUTCTime 生成与 SQLiteTimeLayout 一致的UTC当前时间，modifiers 为附加的时间修饰参数
+func sqliteUTCTime(modifiers string) string {
+	return fmt.Sprintf("(strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'%s) || '+00:00')", modifiers)
+}
+
+// SQLDate 取时间列的日期部分（SQLite为当天零点）
+func SQLDate(db *gorm.DB, column string) string {
+	if IsSQLite(db) {
+		return SQLTruncTime(db, column, "day")
+	}
+	return "DATE(" + column + ")"
+}
+
+// SQLGreatest 取两个表达式中的较大值
+func SQLGreatest(db *gorm.DB, a, b string) string {
+	if IsSQLite(db) {
+		return "MAX(" + a + ", " + b + ")"
+	}
+	return "GREATEST(" + a + ", " + b + ")"
+}
+
+// SQLCastInt 将表达式转换为整数
+func SQLCastInt(db *gorm.DB, expr string) string {
+	if IsPostgres(db) {
+		return "CAST(" + expr + " AS BIGINT)"
+	}
+	if IsSQLite(db) {
+		return "CAST(" + expr + " AS INTEGER)"
+	}
+	return "CAST(" + expr + " AS SIGNED)"
+}
+
+// SQLAppendText 在文本列末尾追加参数内容（列为NULL时视为空串），占用一个 ? 参数
+func SQLAppendText(db *gorm.DB, column string) string {
+	if IsPostgres(db) || IsSQLite(db) {
+		return "COALESCE(" + column + ", '') || CAST(? AS TEXT)"
+	}
+	return "CONCAT(COALESCE(" + column + ", ''), ?)"
+}
+
+// SQLTimeArg 时间参数占位符。PostgreSQL 无法推断 INSERT ... SELECT 选择列表中参数的类型，需要显式转换
+func SQLTimeArg(db *gorm.DB) string {
+	if IsPostgres(db) {
+		return "CAST(? AS TIMESTAMPTZ)"
+	}
+	return "?"
+}
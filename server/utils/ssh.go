@@ -25,6 +25,8 @@ type SSHConfig struct {
 	PrivateKey     string // SSH私钥内容，优先于密码使用
 	ConnectTimeout time.Duration
 	ExecuteTimeout time.Duration
+	ShellInit      string        // 每条命令执行前的shell初始化语句，如定义命令包装函数，为空时不添加
+	Guard          *CommandGuard // 命令白名单检查器，为nil时不检查
 }
 
 type SSHClient struct {
@@ -308,6 +310,10 @@ func (c *SSHClient) Reconnect() error {
 }
 
 func (c *SSHClient) Execute(command string) (string, error) {
+	if err := c.config.Guard.Check(command); err != nil {
+		return "", err
+	}
+
 	// 检查连接健康状态，如果不健康则尝试重连
 	if !c.IsHealthy() {
 		global.APP_LOG.Warn("SSH连接不健康，尝试重连",
@@ -339,6 +345,14 @@ func (c *SSHClient) Execute(command string) (string, error) {
 	return output, err
 }
 
+// shellInit 返回追加在环境初始化之后的shell语句
+func (c *SSHClient) shellInit() string {
+	if c.config.ShellInit == "" {
+		return ""
+	}
+	return c.config.ShellInit + "; "
+}
+
 // executeCommand 执行SSH命令的内部方法
 func (c *SSHClient) executeCommand(command string) (string, error) {
 	session, err := c.client.NewSession()
@@ -359,7 +373,7 @@ func (c *SSHClient) executeCommand(command string) (string, error) {
 
 	// 设置环境变量来确保PATH正确加载，避免使用bash -l -c的转义问题
 	// 这种方式更安全，不需要处理复杂的命令转义
-	envCommand := fmt.Sprintf("source /etc/profile 2>/dev/null || true; source ~/.bashrc 2>/dev/null || true; source ~/.bash_profile 2>/dev/null || true; export PATH=$PATH:/usr/local/bin:/snap/bin:/usr/sbin:/sbin; %s", command)
+	envCommand := fmt.Sprintf("source /etc/profile 2>/dev/null || true; source ~/.bashrc 2>/dev/null || true; source ~/.bash_profile 2>/dev/null || true; export PATH=$PATH:/usr/local/bin:/snap/bin:/usr/sbin:/sbin; %s%s", c.shellInit(), command)
 
 	// 创建一个通道来处理命令执行的超时
 	done := make(chan struct{})
@@ -505,6 +519,10 @@ func TestSSHConnectionLatency(config SSHConfig, testCount int) (minLatency, maxL
 
 // ExecuteWithLogging 执行命令并记录详细的调试信息，用于排查复杂命令的执行问题
 func (c *SSHClient) ExecuteWithLogging(command string, logPrefix string) (string, error) {
+	if err := c.config.Guard.Check(command); err != nil {
+		return "", err
+	}
+
 	// 检查连接健康状态，如果不健康则尝试重连
 	if !c.IsHealthy() {
 		global.APP_LOG.Warn("SSH连接不健康，尝试重连",
@@ -557,7 +575,7 @@ func (c *SSHClient) executeCommandWithLogging(command string, logPrefix string)
 	}
 
 	// 设置环境变量来确保PATH正确加载
-	envCommand := fmt.Sprintf("source /etc/profile 2>/dev/null || true; source ~/.bashrc 2>/dev/null || true; source ~/.bash_profile 2>/dev/null || true; export PATH=$PATH:/usr/local/bin:/snap/bin:/usr/sbin:/sbin; %s", command)
+	envCommand := fmt.Sprintf("source /etc/profile 2>/dev/null || true; source ~/.bashrc 2>/dev/null || true; source ~/.bash_profile 2>/dev/null || true; export PATH=$PATH:/usr/local/bin:/snap/bin:/usr/sbin:/sbin; %s%s", c.shellInit(), command)
 
 	// 记录执行前的信息
 	if global.APP_LOG != nil {
@@ -222,6 +222,35 @@ func (p *SSHConnectionPool) Remove(providerID uint) {
 	}
 }
 
+// Retire 从连接池摘除指定Provider的连接，延迟grace后再关闭
+// 用于凭据轮换：后续请求使用新配置建立连接，已持有旧连接的操作可在宽限期内完成
+func (p *SSHConnectionPool) Retire(providerID uint, grace time.Duration) {
+	p.mu.Lock()
+	client, hasClient := p.conns[providerID]
+	delete(p.conns, providerID)
+	delete(p.configs, providerID)
+	delete(p.lastUsed, providerID)
+	p.mu.Unlock()
+
+	if !hasClient || client == nil {
+		return
+	}
+
+	time.AfterFunc(grace, func() {
+		client.Close()
+		if p.logger != nil {
+			p.logger.Info("已关闭轮换前的SSH连接",
+				zap.Uint("providerID", providerID))
+		}
+	})
+
+	if p.logger != nil {
+		p.logger.Info("SSH连接已摘除，将在宽限期后关闭",
+			zap.Uint("providerID", providerID),
+			zap.Duration("grace", grace))
+	}
+}
+
 // CloseAll 关闭所有连接
 func (p *SSHConnectionPool) CloseAll() {
 	// 先取消context，停止后台清理goroutine
@@ -10,14 +10,7 @@ import (
 
 // UpdateTaskProgress 更新任务进度（全局统一函数）
 func UpdateTaskProgress(taskID uint, progress int, message string) {
-	updates := map[string]interface{}{
-		"progress": progress,
-	}
-	if message != "" {
-		updates["status_message"] = message
-	}
-
-	if err := global.APP_DB.Model(&adminModel.Task{}).Where("id = ?", taskID).Updates(updates).Error; err != nil {
+	if err := SaveTaskProgress(taskID, progress, message); err != nil {
 		global.APP_LOG.Error("更新任务进度失败",
 			zap.Uint("taskId", taskID),
 			zap.Int("progress", progress),
@@ -31,6 +24,21 @@ func UpdateTaskProgress(taskID uint, progress int, message string) {
 	}
 }
 
+// SaveTaskProgress 写入任务进度并返回错误，供需要自行处理失败的调用方使用
+func SaveTaskProgress(taskID uint, progress int, message string) error {
+	updates := map[string]interface{}{
+		"progress": progress,
+	}
+	if message != "" {
+		updates["status_message"] = message
+	}
+	if err := global.APP_DB.Model(&adminModel.Task{}).Where("id = ?", taskID).Updates(updates).Error; err != nil {
+		return err
+	}
+	PublishTaskEvent(TaskProgressEvent{TaskID: taskID, Progress: progress, Message: message})
+	return nil
+}
+
 // MarkTaskCompleted 标记任务最终完成（全局统一函数）
 func MarkTaskCompleted(taskID uint, message string) {
 	updates := map[string]interface{}{
@@ -58,6 +66,7 @@ func MarkTaskCompleted(taskID uint, message string) {
 		global.APP_LOG.Info("任务标记为完成",
 			zap.Uint("taskId", taskID),
 			zap.String("message", message))
+		PublishTaskEvent(TaskProgressEvent{TaskID: taskID, Status: "completed", Progress: 100, Message: message})
 
 		// 释放并发控制锁
 		if global.APP_TASK_LOCK_RELEASER != nil {
@@ -74,6 +83,8 @@ func MarkTaskFailed(taskID uint, errorMessage string) {
 		"error_message": errorMessage,
 	}).Error; err != nil {
 		global.APP_LOG.Error("标记任务失败时出错", zap.Uint("taskId", taskID), zap.Error(err))
+	} else {
+		PublishTaskEvent(TaskProgressEvent{TaskID: taskID, Status: "failed", Message: errorMessage})
 	}
 
 	// 释放并发控制锁
@@ -94,6 +105,11 @@ func GetDefaultTaskTimeout(taskType string) int {
 		"create-port-mapping": 600,  // 10分钟
 		"delete-port-mapping": 300,  // 5分钟
 		"reset-password":      600,  // 10分钟
+		"export-data":         1800, // 30分钟
+		"migrate-port-ip":     1800, // 30分钟
+		"migrate-port-method": 3600, // 60分钟
+		"network-probe":       900,  // 15分钟
+		"detect-port-service": 600,  // 10分钟
 	}
 
 	if timeout, exists := timeouts[taskType]; exists {
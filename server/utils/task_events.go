@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// TaskProgressEvent 任务进度事件，随进度写入推送给订阅者
+type TaskProgressEvent struct {
+	TaskID   uint      `json:"taskId"`
+	Status   string    `json:"status,omitempty"`  // 任务状态，仅状态变化时携带
+	Progress int       `json:"progress"`          // 任务进度（0-100）
+	Message  string    `json:"message,omitempty"` // 阶段描述或错误信息
+	Time     time.Time `json:"time"`
+}
+
+// taskEventBufferSize 每个订阅者的事件缓冲，订阅者处理不及时时丢弃新事件，由订阅方定期回源数据库补齐
+const taskEventBufferSize = 16
+
+var taskEventHub = struct {
+	mu   sync.Mutex
+	subs map[uint]map[chan TaskProgressEvent]struct{}
+}{subs: make(map[uint]map[chan TaskProgressEvent]struct{})}
+
+// SubscribeTaskEvents 订阅任务进度事件，返回的取消函数必须调用以释放订阅
+func SubscribeTaskEvents(taskID uint) (<-chan TaskProgressEvent, func()) {
+	ch := make(chan TaskProgressEvent, taskEventBufferSize)
+	taskEventHub.mu.Lock()
+	if taskEventHub.subs[taskID] == nil {
+		taskEventHub.subs[taskID] = make(map[chan TaskProgressEvent]struct{})
+	}
+	taskEventHub.subs[taskID][ch] = struct{}{}
+	taskEventHub.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			taskEventHub.mu.Lock()
+			delete(taskEventHub.subs[taskID], ch)
+			if len(taskEventHub.subs[taskID]) == 0 {
+				delete(taskEventHub.subs, taskID)
+			}
+			taskEventHub.mu.Unlock()
+		})
+	}
+}
+
+// PublishTaskEvent 向任务的所有订阅者推送事件，不会阻塞任务执行
+func PublishTaskEvent(event TaskProgressEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	taskEventHub.mu.Lock()
+	defer taskEventHub.mu.Unlock()
+	for ch := range taskEventHub.subs[event.TaskID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskEventSubscription(t *testing.T) {
+	events, cancel := SubscribeTaskEvents(42)
+	other, cancelOther := SubscribeTaskEvents(43)
+	defer cancelOther()
+
+	PublishTaskEvent(TaskProgressEvent{TaskID: 42, Progress: 30, Message: "下载镜像"})
+	select {
+	case ev := <-events:
+		if ev.Progress != 30 || ev.Message != "下载镜像" || ev.Time.IsZero() {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+	select {
+	case ev := <-other:
+		t.Fatalf("event leaked to another task: %+v", ev)
+	default:
+	}
+
+	// 缓冲已满时发布不能阻塞
+	for i := 0; i < taskEventBufferSize*2; i++ {
+		PublishTaskEvent(TaskProgressEvent{TaskID: 42, Progress: i})
+	}
+
+	cancel()
+	cancel()
+	taskEventHub.mu.Lock()
+	_, exists := taskEventHub.subs[42]
+	taskEventHub.mu.Unlock()
+	if exists {
+		t.Fatal("subscription not released")
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"oneclickvirt/global"
+)
+
+// 时间统一按UTC存储和比较，只在计算重置边界、定时计划和展示时换算到用户或Provider的时区
+
+// serverLocation 进程启动时的本地时区（TZ环境变量或 /etc/localtime）
+var serverLocation = time.Local
+
+// 时区名称只允许IANA时区数据库中使用的字符，写入实例时不需要额外转义
+var timezonePattern = regexp.MustCompile(`^[A-Za-z0-9_+\-]+(/[A-Za-z0-9_+\-]+)*$`)
+
+// locationCache 已加载的时区，time.LoadLocation 每次都会读取时区数据库
+var locationCache sync.Map
+
+// UseUTC 记录进程原本的本地时区后将 time.Local 设为UTC，需在启动任何goroutine之前调用
+func UseUTC() {
+	if time.Local != time.UTC {
+		serverLocation = time.Local
+	}
+	time.Local = time.UTC
+}
+
+// ServerLocation 返回进程启动时的本地时区，旧版本按该时区写入数据库
+func ServerLocation() *time.Location {
+	return serverLocation
+}
+
+// DefaultLocation 返回默认时区：system.timezone，未配置或无效时为服务器本地时区
+func DefaultLocation() *time.Location {
+	if name := global.APP_CONFIG.System.Timezone; name != "" {
+		if loc, err := cachedLocation(name); err == nil {
+			return loc
+		}
+	}
+	return serverLocation
+}
+
+// LoadLocation 加载时区，为空或无效时返回默认时区
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return DefaultLocation()
+	}
+	loc, err := cachedLocation(name)
+	if err != nil {
+		return DefaultLocation()
+	}
+	return loc
+}
+
+// cachedLocation 加载并缓存时区
+func cachedLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// NormalizeTimezone 校验IANA时区名称，空字符串表示不设置
+func NormalizeTimezone(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil
+	}
+	if len(name) > 64 || name == "Local" || !timezonePattern.MatchString(name) {
+		return "", errors.New("时区格式无效，应为 Asia/Shanghai 这样的时区名称")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", fmt.Errorf("未知的时区: %s", name)
+	}
+	return name, nil
+}
+
+// MonthStart 返回 t 在 loc 时区所在月份的1号零点
+func MonthStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// NextMonthStart 返回 t 在 loc 时区的下个月1号零点，即月度流量的重置时间
+func NextMonthStart(t time.Time, loc *time.Location) time.Time {
+	return MonthStart(t, loc).AddDate(0, 1, 0)
+}
+
+// YearMonth 返回 t 在 loc 时区的年份和月份，用于按月统计
+func YearMonth(t time.Time, loc *time.Location) (int, int) {
+	t = t.In(loc)
+	return t.Year(), int(t.Month())
+}
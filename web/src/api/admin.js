@@ -37,6 +37,21 @@ export const getTaskOverallStats = () => {
   })
 }
 
+export const getTaskAnalytics = (params) => {
+  return request({
+    url: '/v1/admin/tasks/analytics',
+    method: 'get',
+    params
+  })
+}
+
+export const getStuckTasks = () => {
+  return request({
+    url: '/v1/admin/tasks/stuck',
+    method: 'get'
+  })
+}
+
 export const cancelUserTaskByAdmin = (taskId) => {
   return request({
     url: `/v1/admin/tasks/${taskId}/cancel`,
@@ -213,6 +228,16 @@ export const testSSHConnection = (data) => {
   })
 }
 
+// 在Provider宿主机上测试镜像源下载
+export const testProviderImageMirrors = (id, data) => {
+  return request({
+    url: `/v1/admin/providers/${id}/image-mirrors/test`,
+    method: 'post',
+    data,
+    timeout: 300000 // 每个地址最多60秒，多个镜像依次测试
+  })
+}
+
 export const updateProviderStatus = (id, status) => {
   return request({
     url: `/v1/admin/providers/${id}/status`,
@@ -330,6 +355,30 @@ export const batchUpdateAnnouncementStatus = (ids, status) => {
   })
 }
 
+export const getRegistrationApplications = (params) => {
+  return request({
+    url: '/v1/admin/registration-applications',
+    method: 'get',
+    params
+  })
+}
+
+export const approveRegistrationApplications = (ids, note = '') => {
+  return request({
+    url: '/v1/admin/registration-applications/approve',
+    method: 'post',
+    data: { ids, note }
+  })
+}
+
+export const rejectRegistrationApplications = (ids, note = '') => {
+  return request({
+    url: '/v1/admin/registration-applications/reject',
+    method: 'post',
+    data: { ids, note }
+  })
+}
+
 export const getInviteCodes = (params) => {
   return request({
     url: '/v1/admin/invite-codes',
@@ -501,6 +550,38 @@ export const getProviderStatus = (id) => {
   })
 }
 
+export const getHostEvents = (params) => {
+  return request({
+    url: '/v1/admin/host-events',
+    method: 'get',
+    params
+  })
+}
+
+export const getProviderHostEvents = (id, params) => {
+  return request({
+    url: `/v1/admin/providers/${id}/host-events`,
+    method: 'get',
+    params
+  })
+}
+
+export const startProviderNetworkProbe = (id, data = {}) => {
+  return request({
+    url: `/v1/admin/providers/${id}/network-probe`,
+    method: 'post',
+    data
+  })
+}
+
+export const getProviderNetworkProbes = (id, params) => {
+  return request({
+    url: `/v1/admin/providers/${id}/network-probes`,
+    method: 'get',
+    params
+  })
+}
+
 // 配置任务管理API
 export const autoConfigureProvider = (data) => {
   // 使用较长的超时时间（150秒），因为自动配置可能需要一些时间
@@ -50,3 +50,11 @@ export const getAvailableSystemImages = (params) => {
     params
   })
 }
+
+// 通过分享令牌获取实例公开信息
+export const getSharedInstance = (token) => {
+  return request({
+    url: `/v1/public/share/${token}`,
+    method: 'get'
+  })
+}
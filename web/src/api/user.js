@@ -189,6 +189,76 @@ export function getUserLimits() {
   })
 }
 
+// 实例分组
+export function getInstanceGroups() {
+  return request({
+    url: '/v1/user/instance-groups',
+    method: 'get'
+  })
+}
+
+export function createInstanceGroup(data) {
+  return request({
+    url: '/v1/user/instance-groups',
+    method: 'post',
+    data
+  })
+}
+
+export function updateInstanceGroup(id, data) {
+  return request({
+    url: `/v1/user/instance-groups/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+export function deleteInstanceGroup(id) {
+  return request({
+    url: `/v1/user/instance-groups/${id}`,
+    method: 'delete'
+  })
+}
+
+export function instanceGroupAction(id, action) {
+  return request({
+    url: `/v1/user/instance-groups/${id}/action`,
+    method: 'post',
+    data: { action }
+  })
+}
+
+export function assignInstanceGroup(groupId, instanceIds) {
+  return request({
+    url: '/v1/user/instances/group',
+    method: 'put',
+    data: { groupId, instanceIds }
+  })
+}
+
+// API令牌
+export function getUserAPITokens() {
+  return request({
+    url: '/v1/user/api-tokens',
+    method: 'get'
+  })
+}
+
+export function createUserAPIToken(data) {
+  return request({
+    url: '/v1/user/api-tokens',
+    method: 'post',
+    data
+  })
+}
+
+export function deleteUserAPIToken(id) {
+  return request({
+    url: `/v1/user/api-tokens/${id}`,
+    method: 'delete'
+  })
+}
+
 // 实例详情
 export function getUserInstanceDetail(id) {
   return request({
@@ -224,6 +294,25 @@ export function createInstance(data) {
   })
 }
 
+// 批量创建实例
+export function batchCreateInstances(data) {
+  return request({
+    url: '/v1/user/instances/batch',
+    method: 'post',
+    data,
+    timeout: 30000
+  })
+}
+
+// 估算创建实例的等待时间
+export function getCreateInstanceEstimate(params) {
+  return request({
+    url: '/v1/user/instances/create-estimate',
+    method: 'get',
+    params
+  })
+}
+
 // 获取系统镜像
 export function getSystemImages() {
   return request({
@@ -287,6 +376,27 @@ export function cancelUserTask(taskId) {
   })
 }
 
+/**
+ * 订阅任务进度（SSE）
+ * EventSource无法设置请求头，通过token查询参数认证
+ * @param {number} taskId - 任务ID
+ * @param {Function} onEvent - 事件回调，参数为 (事件类型 snapshot/progress/done, 事件数据)
+ * @returns {EventSource} 调用 close() 取消订阅
+ */
+export function subscribeUserTaskEvents(taskId, onEvent) {
+  const token = sessionStorage.getItem('token') || ''
+  const source = new EventSource(`/api/v1/user/tasks/${taskId}/events?token=${encodeURIComponent(token)}`)
+  for (const type of ['snapshot', 'progress', 'done']) {
+    source.addEventListener(type, (e) => {
+      onEvent(type, JSON.parse(e.data))
+      if (type === 'done') {
+        source.close()
+      }
+    })
+  }
+  return source
+}
+
 // 流量统计相关API
 export function getUserTrafficOverview() {
   return request({
@@ -358,3 +468,26 @@ export function getInstanceTrafficHistory(instanceId, params) {
     params
   })
 }
+
+// 实例分享链接相关API
+export function getInstanceShare(instanceId) {
+  return request({
+    url: `/v1/user/instances/${instanceId}/share`,
+    method: 'get'
+  })
+}
+
+export function saveInstanceShare(instanceId, data) {
+  return request({
+    url: `/v1/user/instances/${instanceId}/share`,
+    method: 'put',
+    data
+  })
+}
+
+export function deleteInstanceShare(instanceId) {
+  return request({
+    url: `/v1/user/instances/${instanceId}/share`,
+    method: 'delete'
+  })
+}
@@ -31,6 +31,8 @@ export default {
   lxd: "LXD",
   incus: "Incus",
   docker: "Docker",
+  lxc: "LXC",
+  kubernetes: "Kubernetes",
   sshPort: "SSH",
   container: "Container",
   vm: "VM",
@@ -148,6 +150,9 @@ export default {
   defaultPortCountTip: "Default number of ports allocated per instance",
   portRangeStartTip: "Port mapping available range start value",
   portRangeEndTip: "Port mapping available range end value",
+  guestSshPort: "Guest SSH Port",
+  randomGuestSshPort: "Random SSH Port",
+  guestSshPortTip: "Port sshd listens on inside new instances; the SSH port mapping targets it. When random is enabled, each instance gets a port outside the port mapping range. Proxmox VMs always use 22",
   defaultInboundBandwidthTip: "Default inbound bandwidth limit (Mbps), default value when creating instance",
   defaultOutboundBandwidthTip: "Default outbound bandwidth limit (Mbps), default value when creating instance",
   maxInboundBandwidthTip: "Maximum inbound bandwidth limit (Mbps), instances cannot exceed this value",
@@ -225,7 +230,7 @@ export default {
   supportVM: "Support VM",
   containerTech: "Support Docker, LXC and other container technologies",
   vmTech: "Support KVM, Xen and other virtualization technologies",
-  dockerOnlyContainer: "Docker only supports containers; Incus/LXD/ProxmoxVE supports containers and VMs",
+  dockerOnlyContainer: "Docker/LXC/Kubernetes only supports containers; Incus/LXD/ProxmoxVE supports containers and VMs",
   selectVirtualizationType: "Please select at least one virtualization type",
   instanceLimits: "Instance Limits",
   maxContainers: "Max Containers",
@@ -5,7 +5,7 @@ export default {
   initSystem: 'Initialize System',
   infoTitle: 'Initialization Instructions',
   infoDescription: 'System initialization requires the following information:',
-  infoDatabaseDesc: 'Configure MySQL or MariaDB database connection information',
+  infoDatabaseDesc: 'Configure MySQL, MariaDB or PostgreSQL database connection information, or use SQLite for a single-node install',
   infoAdminDesc: 'Create an administrator account with highest system privileges',
   infoUserDesc: 'Create a regular user account with basic functionality access',
   database: {
@@ -13,11 +13,15 @@ export default {
     type: 'Database Type',
     mysqlRecommended: 'MySQL (Recommended for AMD64 architecture)',
     mariadbRecommended: 'MariaDB (Recommended for ARM64 architecture)',
+    postgres: 'PostgreSQL',
+    sqlite: 'SQLite (single node, no database server)',
     architecture: 'Architecture',
     autoSelectHint: 'The system will automatically select the appropriate database type based on architecture, or you can choose manually',
     host: 'Database Host',
     port: 'Database Port',
     dbName: 'Database Name',
+    sqlitePath: 'Database File Path',
+    sqliteHint: 'The directory is created if missing; SQLite does not support multi-node deployments',
     username: 'Username',
     password: 'Password',
     passwordPlaceholder: 'Database password',
@@ -31,6 +31,8 @@ export default {
   lxd: "LXD",
   incus: "Incus",
   docker: "Docker",
+  lxc: "LXC",
+  kubernetes: "Kubernetes",
   sshPort: "SSH端口",
   container: "容器",
   vm: "虚拟机",
@@ -160,6 +162,9 @@ export default {
   defaultPortCountTip: "每个实例默认分配的端口数量",
   portRangeStartTip: "端口映射可用范围的起始值",
   portRangeEndTip: "端口映射可用范围的结束值",
+  guestSshPort: "实例内SSH端口",
+  randomGuestSshPort: "随机SSH端口",
+  guestSshPortTip: "新建实例内sshd监听的端口，SSH端口映射指向该端口；开启随机后为每个实例在端口映射范围之外随机选择，Proxmox虚拟机固定为22",
   defaultInboundBandwidthTip: "默认入站带宽限制（Mbps），实例创建时的默认值",
   defaultOutboundBandwidthTip: "默认出站带宽限制（Mbps），实例创建时的默认值",
   maxInboundBandwidthTip: "最大入站带宽限制（Mbps），实例不能超过此值",
@@ -237,7 +242,7 @@ export default {
   supportVM: "支持虚拟机",
   containerTech: "支持Docker、LXC等容器技术",
   vmTech: "支持KVM、Xen等虚拟化技术",
-  dockerOnlyContainer: "Docker只支持容器；Incus/LXD/ProxmoxVE支持容器和虚拟机",
+  dockerOnlyContainer: "Docker/LXC/Kubernetes只支持容器；Incus/LXD/ProxmoxVE支持容器和虚拟机",
   selectVirtualizationType: "至少选择一种支持的虚拟化类型",
   instanceLimits: "实例数限制",
   maxContainers: "最大容器数",
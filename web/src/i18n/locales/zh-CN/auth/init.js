@@ -5,7 +5,7 @@ export default {
   initSystem: '初始化系统',
   infoTitle: '初始化说明',
   infoDescription: '系统初始化需要配置以下信息：',
-  infoDatabaseDesc: '设置MySQL或MariaDB数据库连接信息',
+  infoDatabaseDesc: '设置MySQL、MariaDB、PostgreSQL数据库连接信息，单机部署也可使用SQLite',
   infoAdminDesc: '创建拥有系统最高权限的管理员账户',
   infoUserDesc: '创建具有基础功能使用权限的普通用户账户',
   database: {
@@ -13,11 +13,15 @@ export default {
     type: '数据库类型',
     mysqlRecommended: 'MySQL（推荐AMD64架构使用）',
     mariadbRecommended: 'MariaDB（推荐ARM64架构使用）',
+    postgres: 'PostgreSQL',
+    sqlite: 'SQLite（单机部署，无需数据库服务）',
     architecture: '架构',
     autoSelectHint: '系统会根据架构自动选择合适的数据库类型，您也可以手动选择',
     host: '数据库地址',
     port: '数据库端口',
     dbName: '数据库名称',
+    sqlitePath: '数据库文件路径',
+    sqliteHint: '目录不存在时自动创建；SQLite不支持多节点部署',
     username: '用户名',
     password: '密码',
     passwordPlaceholder: '数据库密码',
@@ -143,6 +143,8 @@ export function useProviderFormHelpers() {
     defaultPortCount: 10,
     portRangeStart: 10000,
     portRangeEnd: 65535,
+    guestSshPort: 22,
+    randomGuestSshPort: false,
     networkType: 'nat_ipv4',
     defaultInboundBandwidth: 300,
     defaultOutboundBandwidth: 300,